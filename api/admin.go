@@ -0,0 +1,44 @@
+package api
+
+import (
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/models"
+	"docserver/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Administration ---
+
+// isAdmin reports whether the profile's email is one of the configured administrator emails.
+func isAdmin(profile models.Profile, cfg *config.Config) bool {
+	email := strings.ToLower(profile.Email)
+	for _, adminEmail := range cfg.AdminEmails {
+		if adminEmail == email {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireAdminMiddleware rejects requests from users who are not administrators (see -admin-emails)
+// with 403 Forbidden. Must run after utils.AuthMiddleware.
+func RequireAdminMiddleware(database *db.Database, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userID")
+		if !exists {
+			utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+			return
+		}
+		profile, found := database.GetProfileByID(userID.(string))
+		if !found || !isAdmin(profile, cfg) {
+			utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgAdminOnly)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"docserver/config"
+	"docserver/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsAdmin(t *testing.T) {
+	cfg := &config.Config{AdminEmails: []string{"admin@example.com"}}
+	assert.True(t, isAdmin(models.Profile{Email: "Admin@Example.com"}, cfg))
+	assert.False(t, isAdmin(models.Profile{Email: "user@example.com"}, cfg))
+	assert.False(t, isAdmin(models.Profile{Email: "admin@example.com"}, &config.Config{}))
+}
+
+func TestRequireAdminMiddleware(t *testing.T) {
+	router, _, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	cfg.AdminEmails = []string{"boss@example.com"}
+
+	_, _, adminToken := createTestUserAndLogin(t, router, "boss@example.com", "password123", "The", "Boss")
+	_, _, userToken := createTestUserAndLogin(t, router, "worker@example.com", "password123", "A", "Worker")
+
+	rr := performRequest(router, http.MethodGet, "/admin/scripts", nil, adminToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = performRequest(router, http.MethodGet, "/admin/scripts", nil, userToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Contains(t, rr.Body.String(), "administrators")
+	rr = performRequest(router, http.MethodGet, "/admin/scripts", nil, "")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
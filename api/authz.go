@@ -0,0 +1,35 @@
+package api
+
+import (
+	"docserver/authz"
+	"docserver/config"
+	"docserver/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Authorization ---
+
+// authzFacts answers what the authz package needs to know from the database, or a view of it.
+type authzFacts struct {
+	documentReader
+	cfg *config.Config
+}
+
+// IsAdmin reports whether the profile is an administrator (see -admin-emails).
+func (f authzFacts) IsAdmin(profileID string) bool {
+	profile, found := f.GetProfileByID(profileID)
+	return found && isAdmin(profile, f.cfg)
+}
+
+// can reports whether the user making the request may perform action on doc (see authz.Can),
+// within the documents their token is restricted to, if any.
+func can(c *gin.Context, database documentReader, cfg *config.Config, action authz.Action, doc models.Document) bool {
+	facts := authzFacts{documentReader: database, cfg: cfg}
+	userID := c.GetString("userID")
+	subject := authz.Subject{ID: userID, Admin: facts.IsAdmin(userID)}
+	if documents, restricted := c.Get("tokenDocuments"); restricted {
+		subject.Documents = documents.([]string)
+	}
+	return authz.Can(subject, action, authz.Document(doc, facts))
+}
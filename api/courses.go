@@ -0,0 +1,308 @@
+package api
+
+import (
+	"context"
+	"docserver/apperr"
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/models"
+	"docserver/utils"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Course Databases ---
+
+// courseHeader selects the course database a request is for, as an alternative to the
+// /courses/{id}/ path prefix.
+const courseHeader = "X-Course"
+
+// courseContextKey holds the ID of the course a request was passed on to in its context.
+type courseContextKey struct{}
+
+// requestCourse returns the ID of the course the request is for, or "" if it is for the main database.
+func requestCourse(c *gin.Context) string {
+	id, _ := c.Request.Context().Value(courseContextKey{}).(string)
+	return id
+}
+
+// courseIDPattern is what course IDs look like. They name the course's database file, and
+// starting with a letter or digit keeps them from naming hidden files.
+var courseIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// courseRouter passes requests for a course on to the course's own database and routes,
+// opening them on first use.
+type courseRouter struct {
+	database *db.Database // The main database, holding the list of courses
+	cfg      *config.Config
+	mu       sync.Mutex
+	open     map[string]*courseServer // Keyed by Course ID
+}
+
+// courseServer is an open course database with the routes serving it.
+type courseServer struct {
+	database    *db.Database
+	handler     *gin.Engine
+	stopWorkers func()
+}
+
+// newCourseRouter creates the router of the courses listed in database.
+func newCourseRouter(database *db.Database, cfg *config.Config) *courseRouter {
+	return &courseRouter{database: database, cfg: cfg, open: make(map[string]*courseServer)}
+}
+
+// courseConfig derives the configuration of a course from the server's: the course has its own
+// database file (or data directory) in the courses directory, its own JWT audience, and the
+// settings it overrides. Requests to courses are not journaled (see -record-file).
+func courseConfig(cfg *config.Config, course models.Course) *config.Config {
+	courseCfg := *cfg
+	courseCfg.DbFilePath = filepath.Join(cfg.CoursesDir, course.ID+".json")
+	if cfg.DataDir != "" {
+		courseCfg.DataDir = filepath.Join(cfg.CoursesDir, course.ID)
+	}
+	courseCfg.CoursesDir = ""
+	courseCfg.RecordFile, courseCfg.ReplayFile = "", ""
+	courseCfg.JwtAudience = course.Audience
+
+	overrides := course.Overrides
+	if overrides.AdminEmails != nil {
+		courseCfg.AdminEmails = overrides.AdminEmails
+	}
+	if overrides.InviteOnly != nil {
+		courseCfg.InviteOnly = *overrides.InviteOnly
+	}
+	if overrides.EnablePublicAccess != nil {
+		courseCfg.EnablePublicAccess = *overrides.EnablePublicAccess
+	}
+	if overrides.ReadOnly != nil {
+		courseCfg.ReadOnly = cfg.ReadOnly || *overrides.ReadOnly // A read-only server stays read-only
+	}
+	return &courseCfg
+}
+
+// HeaderMiddleware serves requests carrying an X-Course header from that course's database,
+// under the same path the request has, e.g. /v1/documents.
+func (r *courseRouter) HeaderMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if id := c.GetHeader(courseHeader); id != "" {
+			r.serve(c, id, c.Request.URL.Path)
+			return
+		}
+		c.Next()
+	}
+}
+
+// isCourseRequest reports whether the request was served from a course database.
+func isCourseRequest(c *gin.Context) bool {
+	return c.GetHeader(courseHeader) != "" || c.FullPath() == "/courses/:course/*path"
+}
+
+// ServePath serves /courses/{course}/{path} from the course's database as /{path}.
+func (r *courseRouter) ServePath(c *gin.Context) {
+	r.serve(c, c.Param("course"), c.Param("path"))
+}
+
+// serve passes the request on to the routes of the course with the given ID, as a request for path.
+func (r *courseRouter) serve(c *gin.Context, id, path string) {
+	server, err := r.server(id)
+	if errors.Is(err, apperr.ErrNotFound) {
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgCourseNotFound, id)
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to open the database of course %s: %v", id, err)
+		utils.GinLocalizedError(c, http.StatusServiceUnavailable, i18n.MsgCourseUnavailable, id)
+		return
+	}
+	c.Abort()
+	request := c.Request.Clone(context.WithValue(c.Request.Context(), courseContextKey{}, id))
+	request.URL.Path, request.URL.RawPath = path, ""
+	server.handler.ServeHTTP(c.Writer, request)
+}
+
+// server returns the open course with the given ID, opening its database and starting its
+// workers the first time. Courses that are not listed are apperr.ErrNotFound errors.
+func (r *courseRouter) server(id string) (*courseServer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	course, found := r.database.GetCourse(id)
+	if !found {
+		return nil, apperr.NotFound("course '%s' not found", id)
+	}
+	if server, open := r.open[id]; open {
+		return server, nil
+	}
+
+	if err := os.MkdirAll(r.cfg.CoursesDir, 0755); err != nil {
+		return nil, err
+	}
+	courseCfg := courseConfig(r.cfg, course)
+	database, err := db.NewDatabase(courseCfg)
+	if err != nil {
+		return nil, err
+	}
+	handler := gin.New()
+	handler.Use(gin.Recovery()) // Requests are logged by the server's router
+	if err := configureClientIP(handler, courseCfg); err != nil {
+		_ = database.Close()
+		return nil, err
+	}
+	RegisterRoutes(handler, database, courseCfg)
+
+	server := &courseServer{database: database, handler: handler, stopWorkers: database.StartWorkers(time.Minute)}
+	r.open[id] = server
+	log.Printf("INFO: Opened the database of course %s", id)
+	return server, nil
+}
+
+// drop removes the course with the given ID: its database is closed, saving pending changes,
+// it is taken off the list and its files are moved aside (see db.ArchiveStorage), which are returned.
+func (r *courseRouter) drop(id string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	course, found := r.database.GetCourse(id)
+	if !found {
+		return nil, apperr.NotFound("course '%s' not found", id)
+	}
+	if server, open := r.open[id]; open {
+		server.stopWorkers()
+		if err := server.database.Close(); err != nil {
+			log.Printf("WARN: Failed to save the database of course %s before dropping it: %v", id, err)
+		}
+		delete(r.open, id)
+	}
+	if err := r.database.DeleteCourse(id); err != nil {
+		return nil, err
+	}
+	return db.ArchiveStorage(courseConfig(r.cfg, course), r.cfg.Now())
+}
+
+// CourseRequest defines the body for creating a course.
+type CourseRequest struct {
+	ID        string                 `json:"id" binding:"required"`                // 1-63 lowercase letters, digits and dashes, e.g. "web-dev-fall"
+	Name      string                 `json:"name,omitempty" binding:"max=200"`     // For people
+	Audience  string                 `json:"audience,omitempty" binding:"max=200"` // JWT audience of the course's tokens; defaults to "course:<id>"
+	Overrides models.CourseOverrides `json:"overrides"`                            // Settings that differ from the server's
+}
+
+// toCourse validates the request and converts it to a course.
+func (req CourseRequest) toCourse(cfg *config.Config) (models.Course, error) {
+	course := models.Course{
+		ID:        strings.TrimSpace(req.ID),
+		Name:      strings.TrimSpace(req.Name),
+		Audience:  strings.TrimSpace(req.Audience),
+		Overrides: req.Overrides,
+	}
+	if !courseIDPattern.MatchString(course.ID) {
+		return models.Course{}, fmt.Errorf("id must be 1-63 lowercase letters, digits and dashes, starting with a letter or digit")
+	}
+	if course.Audience == "" {
+		course.Audience = "course:" + course.ID
+	}
+	if course.Audience == cfg.JwtAudience {
+		return models.Course{}, fmt.Errorf("audience must differ from the server's (%s)", cfg.JwtAudience)
+	}
+	if req.Overrides.AdminEmails != nil {
+		course.Overrides.AdminEmails = make([]string, 0, len(req.Overrides.AdminEmails))
+		for _, email := range req.Overrides.AdminEmails {
+			if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+				course.Overrides.AdminEmails = append(course.Overrides.AdminEmails, email)
+			}
+		}
+	}
+	return course, nil
+}
+
+// CreateCourseHandler creates a course database.
+// @Summary      Create a Course (Admin)
+// @Description  Creates a course: an independent database with its own profiles, documents and settings, stored as `<id>.json` in the courses directory (`-courses-dir`). Its API is served under `/courses/{id}/`, e.g. `/courses/web-dev/v1/documents`, or at the usual paths to requests with an `X-Course: {id}` header. The database is created on the first request.
+// @Description
+// @Description  Tokens of a course carry its `audience` (`course:<id>` by default) and are refused by the server and other courses, so students sign up in each course they take. `overrides` can set `admin_emails` (e.g. the course's instructors), `invite_only`, `enable_public_access` and `read_only` for the course; anything not set follows the server. Administrators only.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        course body CourseRequest true "The course ID, name, audience and setting overrides."
+// @Success      201  {object}  utils.Envelope{data=models.Course} "Course created."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The body is invalid, the ID is malformed, or the audience is the server's."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Failure      409  {object}  utils.ErrorEnvelope "Conflict: A course with that ID or audience exists."
+// @Router       /admin/courses [post]
+func CreateCourseHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+
+	var req CourseRequest
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidRequestBody) {
+		return
+	}
+	course, err := req.toCourse(cfg)
+	if err != nil {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgCourseSettings, err)
+		return
+	}
+	course.CreatedBy = userID.(string)
+
+	created, err := database.CreateCourse(course)
+	if err != nil {
+		utils.GinLocalizedError(c, apperr.HTTPStatus(err), i18n.MsgCourseExists, err)
+		return
+	}
+	utils.RespondData(c, http.StatusCreated, created)
+}
+
+// ListCoursesHandler lists all courses.
+// @Summary      List Courses (Admin)
+// @Description  Lists all course databases, ordered by ID, with their audience and setting overrides. Administrators only.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  utils.Envelope{data=[]models.Course} "All courses."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Router       /admin/courses [get]
+func ListCoursesHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	utils.RespondData(c, http.StatusOK, database.ListCourses())
+}
+
+// DropCourseHandler removes a course.
+// @Summary      Drop a Course (Admin)
+// @Description  Removes a course: its API stops answering and its database file (or data directory) is renamed to `<name>.dropped-<time>` in the courses directory, so nothing is lost by accident and a new course with the same ID starts empty. Administrators only.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Param        course path      string  true  "The course ID."
+// @Success      204  "Course dropped."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No course exists with that ID."
+// @Failure      500  {object}  utils.ErrorEnvelope "Internal Server Error: The course's files could not be moved aside."
+// @Router       /admin/courses/{course} [delete]
+func DropCourseHandler(c *gin.Context, database *db.Database, cfg *config.Config, courses *courseRouter) {
+	id := c.Param("course")
+	if _, err := courses.drop(id); err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgCourseNotFound, id)
+			return
+		}
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgCourseDropFailed, id, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
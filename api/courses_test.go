@@ -0,0 +1,144 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"docserver/config"
+	"docserver/db"
+	"docserver/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCoursesServer returns a router hosting course databases in a temporary courses directory,
+// with admin@example.com as the administrator.
+func newCoursesServer(t *testing.T) (*gin.Engine, *config.Config) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	cfg := &config.Config{
+		DbFilePath:    filepath.Join(dir, "db.json"),
+		CoursesDir:    filepath.Join(dir, "courses"),
+		SaveInterval:  10 * time.Millisecond,
+		JwtSecret:     testJWTSecret,
+		JwtAudience:   "docserver",
+		TokenLifetime: time.Hour,
+		BcryptCost:    4,
+		AdminEmails:   []string{"admin@example.com"},
+	}
+	database, err := db.NewDatabase(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = database.Close() })
+	router := gin.New()
+	RegisterRoutes(router, database, cfg)
+	return router, cfg
+}
+
+// signupAndLoginAt signs up and logs in a user through the auth routes under prefix and returns the token.
+func signupAndLoginAt(t *testing.T, router *gin.Engine, prefix, email string) string {
+	signup := gin.H{"email": email, "password": "password123", "first_name": "Course", "last_name": "User"}
+	rr := performRequest(router, http.MethodPost, prefix+"/v1/auth/signup", marshalJSONBody(t, signup), "")
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	rr = performRequest(router, http.MethodPost, prefix+"/v1/auth/login", marshalJSONBody(t, gin.H{"email": email, "password": "password123"}), "")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var resp struct {
+		Data struct {
+			Token string `json:"token"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.NotEmpty(t, resp.Data.Token)
+	return resp.Data.Token
+}
+
+func TestCourses(t *testing.T) {
+	router, cfg := newCoursesServer(t)
+	adminToken := signupAndLoginAt(t, router, "", "admin@example.com")
+
+	rr := performRequest(router, http.MethodPost, "/v1/admin/courses", marshalJSONBody(t, gin.H{"id": "web", "name": "Web Development"}), adminToken)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var created struct {
+		Data models.Course `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	assert.Equal(t, "course:web", created.Data.Audience)
+
+	t.Run("Rejects invalid and duplicate courses", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/v1/admin/courses", marshalJSONBody(t, gin.H{"id": "../web"}), adminToken)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		rr = performRequest(router, http.MethodPost, "/v1/admin/courses", marshalJSONBody(t, gin.H{"id": "root", "audience": "docserver"}), adminToken)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, "the server's audience")
+		rr = performRequest(router, http.MethodPost, "/v1/admin/courses", marshalJSONBody(t, gin.H{"id": "web"}), adminToken)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		rr = performRequest(router, http.MethodPost, "/v1/admin/courses", marshalJSONBody(t, gin.H{"id": "other"}), signupAndLoginAt(t, router, "", "student@example.com"))
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("Serves each course from its own database", func(t *testing.T) {
+		token := signupAndLoginAt(t, router, "/courses/web", "web-student@example.com")
+		rr := performRequest(router, http.MethodPost, "/courses/web/v1/documents", marshalJSONBody(t, gin.H{"content": gin.H{"title": "course doc"}}), token)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+
+		rr = performRequest(router, http.MethodGet, "/courses/web/v1/documents", nil, token)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), "course doc")
+
+		rr = performRequest(router, http.MethodGet, "/v1/documents", nil, token)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code, "course tokens are refused by the server")
+		rr = performRequest(router, http.MethodGet, "/courses/web/v1/documents", nil, adminToken)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code, "server tokens are refused by the course")
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/documents", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("X-Course", "web")
+		header := httptest.NewRecorder()
+		router.ServeHTTP(header, req)
+		assert.Equal(t, http.StatusOK, header.Code)
+		assert.Contains(t, header.Body.String(), "course doc", "selected by the header")
+	})
+
+	t.Run("Unknown courses are not found", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/courses/nope/v1/status", nil, "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.NoFileExists(t, filepath.Join(cfg.CoursesDir, "nope.json"))
+	})
+
+	t.Run("Lists and drops courses", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/v1/admin/courses", nil, adminToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"id":"web"`)
+
+		rr = performRequest(router, http.MethodDelete, "/v1/admin/courses/web", nil, adminToken)
+		require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+		rr = performRequest(router, http.MethodGet, "/courses/web/v1/status", nil, "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.NoFileExists(t, filepath.Join(cfg.CoursesDir, "web.json"))
+		entries, err := os.ReadDir(cfg.CoursesDir)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Contains(t, entries[0].Name(), "web.json.dropped-", "the course's database file was saved and moved aside")
+
+		rr = performRequest(router, http.MethodDelete, "/v1/admin/courses/web", nil, adminToken)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestCourseConfig(t *testing.T) {
+	yes := true
+	cfg := &config.Config{DbFilePath: "/data/db.json", CoursesDir: "/data/courses", RecordFile: "/data/journal", JwtAudience: "docserver", AdminEmails: []string{"admin@example.com"}}
+	courseCfg := courseConfig(cfg, models.Course{ID: "web", Audience: "course:web", Overrides: models.CourseOverrides{AdminEmails: []string{"teacher@example.com"}, InviteOnly: &yes}})
+	assert.Equal(t, "/data/courses/web.json", courseCfg.DbFilePath)
+	assert.Empty(t, courseCfg.CoursesDir)
+	assert.Empty(t, courseCfg.RecordFile)
+	assert.Equal(t, "course:web", courseCfg.JwtAudience)
+	assert.Equal(t, []string{"teacher@example.com"}, courseCfg.AdminEmails)
+	assert.True(t, courseCfg.InviteOnly)
+	assert.Equal(t, "docserver", cfg.JwtAudience, "the server's configuration is left alone")
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/utils"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Durability Acknowledgement ---
+
+// durableHeader tells clients of mutating requests whether the database was on disk when the
+// response was sent: durablePersisted, or durablePending while the debounced save is still to come.
+// With -ephemeral it is always durableEphemeral.
+const (
+	durableHeader    = "X-Durable"
+	durablePending   = "pending"
+	durablePersisted = "persisted"
+	durableEphemeral = "ephemeral" // Never saved (see -ephemeral)
+)
+
+// DurabilityMiddleware sets the X-Durable header on the responses to POST, PUT, PATCH and DELETE
+// requests. With '?durability=flush' the database is saved and synced to disk before the response
+// is sent, so it can only say "persisted" unless saving fails. Other durability values are
+// refused with 400 Bad Request.
+func DurabilityMiddleware(database *db.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isMutatingMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+		flush := false
+		switch durability := c.Query("durability"); durability {
+		case "":
+		case "flush":
+			flush = true
+		default:
+			utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgInvalidDurability, durability)
+			return
+		}
+
+		writer := &durableWriter{ResponseWriter: c.Writer, database: database, flush: flush}
+		c.Writer = writer
+		c.Next()
+		writer.acknowledge() // Responses without a body, e.g. 204 No Content, are written after this
+	}
+}
+
+// durableWriter sets the X-Durable header just before the response headers are sent, that is
+// once the handler has made its change.
+type durableWriter struct {
+	gin.ResponseWriter
+	database     *db.Database
+	flush        bool // Save before answering (?durability=flush)
+	acknowledged bool
+}
+
+// acknowledge saves the database if asked to and sets the X-Durable header, unless the headers
+// were already sent.
+func (w *durableWriter) acknowledge() {
+	if w.acknowledged || w.Written() {
+		return
+	}
+	w.acknowledged = true
+	if w.flush {
+		if err := w.database.PersistNow(); err != nil {
+			log.Printf("ERROR: Failed to save the database for a durability=flush request: %v", err)
+		}
+	}
+	state := durablePersisted
+	if w.database.Ephemeral() {
+		state = durableEphemeral
+	} else if w.database.Unsaved() {
+		state = durablePending
+	}
+	w.Header().Set(durableHeader, state)
+}
+
+// WriteHeaderNow sends the headers, acknowledging first.
+func (w *durableWriter) WriteHeaderNow() {
+	w.acknowledge()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Write sends the headers if needed, acknowledging first, and writes data.
+func (w *durableWriter) Write(data []byte) (int, error) {
+	w.acknowledge()
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString sends the headers if needed, acknowledging first, and writes s.
+func (w *durableWriter) WriteString(s string) (int, error) {
+	w.acknowledge()
+	return w.ResponseWriter.WriteString(s)
+}
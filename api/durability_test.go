@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"docserver/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDurabilityHeader(t *testing.T) {
+	router, _, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	cfg.SaveInterval = time.Hour // Only flushes save within the test
+
+	_, _, token := createTestUserAndLogin(t, router, "durable@example.com", "password123", "Du", "Rable")
+
+	t.Run("Pending until the debounced save", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/v1/documents", marshalJSONBody(t, gin.H{"content": gin.H{"n": 1}}), token)
+		require.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, "pending", rr.Header().Get("X-Durable"))
+	})
+
+	t.Run("Flush saves before answering", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/v1/documents?durability=flush", marshalJSONBody(t, gin.H{"content": gin.H{"n": 2}}), token)
+		require.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, "persisted", rr.Header().Get("X-Durable"))
+		var body struct{ Data models.Document }
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+
+		data, err := os.ReadFile(cfg.DbFilePath)
+		require.NoError(t, err)
+		var saved models.Database
+		require.NoError(t, json.Unmarshal(data, &saved))
+		assert.Contains(t, saved.Documents, body.Data.ID)
+		assert.Len(t, saved.Documents, 2, "earlier changes are saved too")
+
+		// Responses without a body are acknowledged too
+		rr = performRequest(router, http.MethodDelete, "/documents/"+body.Data.ID+"?durability=flush", nil, token)
+		require.Equal(t, http.StatusNoContent, rr.Code)
+		assert.Equal(t, "persisted", rr.Header().Get("X-Durable"))
+	})
+
+	t.Run("Reads are not acknowledged", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/v1/documents", nil, token)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Empty(t, rr.Header().Get("X-Durable"))
+	})
+
+	t.Run("Unknown durability", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/v1/documents?durability=later", marshalJSONBody(t, gin.H{"content": gin.H{"n": 3}}), token)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "invalid_durability")
+	})
+
+	t.Run("Ephemeral", func(t *testing.T) {
+		cfg.Ephemeral = true
+		defer func() { cfg.Ephemeral = false }()
+		rr := performRequest(router, http.MethodPost, "/v1/documents?durability=flush", marshalJSONBody(t, gin.H{"content": gin.H{"n": 4}}), token)
+		require.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, "ephemeral", rr.Header().Get("X-Durable"))
+	})
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"docserver/authz"
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/models"
+	"docserver/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Document Activity ---
+
+// Pagination defaults for the activity feed, matching the document list.
+const (
+	defaultActivityLimit = 20
+	maxActivityLimit     = 100
+)
+
+// GetDocumentActivityHandler lists the activity feed of a document.
+// @Summary      Get a Document's Activity
+// @Description  Lists what has happened to a document, newest first. Each entry has a `type`, a `timestamp` and the `actor_id` of the user who made the change:
+// @Description  *   `created`: The document was created.
+// @Description  *   `updated`: The content was replaced. `changed_paths` lists the content paths that were added, removed or changed (`@this` if the whole content changed). Users a part of the content is shared with only see the changed paths within that part.
+// @Description  *   `shared` / `unshared`: The document was shared with, or unshared from, the users in `profile_ids`.
+// @Description  *   `published` / `unpublished`: The document was made public, or private again.
+// @Description  *   `frozen` / `unfrozen`: The document was made read-only, or writable again.
+// @Description
+// @Description  Only the owner and the users the document is shared with can see its activity; being able to read a public document is not enough.
+// @Description  The most recent 500 entries are kept per document.
+// @Tags         Documents
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id     path      string  true   "The unique identifier of the document."
+// @Param        page   query     int     false  "Page number for pagination (starts at 1)." minimum(1) default(1)
+// @Param        limit  query     int     false  "Number of entries per page." minimum(1) maximum(100) default(20)
+// @Success      200  {object}  utils.Envelope{data=[]models.DocumentEvent,meta=utils.PageMeta,links=utils.PageLinks} "A page of the document's activity, newest first."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: 'page' or 'limit' is not a positive integer."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are neither the owner of the document nor is it shared with you."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No document exists with the specified ID."
+// @Router       /documents/{id}/activity [get]
+func GetDocumentActivityHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	_, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+	docID := c.Param("id")
+
+	page, errPage := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, errLimit := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultActivityLimit)))
+	if errPage != nil || errLimit != nil || page < 1 || limit < 1 {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgInvalidPagination)
+		return
+	}
+	if limit > maxActivityLimit {
+		limit = maxActivityLimit
+	}
+
+	var found, allowed bool
+	var events []models.DocumentEvent
+	_ = database.View(func(v *db.ReadView) error {
+		var doc models.Document
+		if doc, found = v.GetDocumentByID(docID); !found {
+			return nil
+		}
+		if allowed = can(c, v, cfg, authz.ViewActivity, doc); allowed {
+			events = v.GetDocumentEvents(docID)
+			// Sharers limited to part of the content only learn of changes to that part
+			if scope, limited := documentScope(v, doc, c.GetString("userID")); limited {
+				for i := range events {
+					events[i].ChangedPaths = db.ScopedChangedPaths(events[i].ChangedPaths, scope)
+				}
+			}
+		}
+		return nil
+	})
+	if !found {
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgDocumentNotFound, docID)
+		return
+	}
+	if !allowed {
+		utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgDocumentAccessDenied)
+		return
+	}
+
+	total := len(events)
+	start := (page - 1) * limit
+	if start > total {
+		start = total
+	}
+	end := start + limit
+	if end > total {
+		end = total
+	}
+
+	utils.RespondList(c, events[start:end], total, page, limit)
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"docserver/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentActivity(t *testing.T) {
+	router, _, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	ownerID, _, ownerToken := createTestUserAndLogin(t, router, "activity.owner@example.com", "password123", "Act", "Owner")
+	readerID, _, readerToken := createTestUserAndLogin(t, router, "activity.reader@example.com", "password123", "Act", "Reader")
+	_, _, strangerToken := createTestUserAndLogin(t, router, "activity.stranger@example.com", "password123", "Act", "Stranger")
+
+	// Create, edit, share and publish through the API
+	rr := performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, gin.H{
+		"content": gin.H{"title": "Lab report", "sections": []string{"intro"}},
+	}), ownerToken)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var doc models.Document
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+
+	rr = performRequest(router, http.MethodPut, "/documents/"+doc.ID, marshalJSONBody(t, gin.H{
+		"content": gin.H{"title": "Lab report", "sections": []string{"intro", "method"}},
+		"public":  true,
+	}), ownerToken)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	rr = performRequest(router, http.MethodPut, "/documents/"+doc.ID+"/shares/"+readerID, nil, ownerToken)
+	require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+
+	type activityPage struct {
+		Data  []models.DocumentEvent `json:"data"`
+		Total int                    `json:"total"`
+	}
+	getActivity := func(query, token string) (int, activityPage, []models.DocumentEvent) {
+		rr := performRequest(router, http.MethodGet, "/documents/"+doc.ID+"/activity"+query, nil, token)
+		var page activityPage
+		if rr.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &page))
+		}
+		return rr.Code, page, page.Data
+	}
+
+	t.Run("Owner sees the feed newest first", func(t *testing.T) {
+		code, page, events := getActivity("", ownerToken)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 4, page.Total)
+		require.Len(t, events, 4)
+		assert.Equal(t, []string{models.EventShared, models.EventPublished, models.EventUpdated, models.EventCreated},
+			[]string{events[0].Type, events[1].Type, events[2].Type, events[3].Type})
+		assert.Equal(t, []string{readerID}, events[0].ProfileIDs)
+		assert.Equal(t, []string{"sections.1"}, events[2].ChangedPaths)
+		for _, event := range events {
+			assert.Equal(t, ownerID, event.ActorID)
+		}
+	})
+
+	t.Run("Sharers see the feed", func(t *testing.T) {
+		code, _, events := getActivity("", readerToken)
+		require.Equal(t, http.StatusOK, code)
+		assert.Len(t, events, 4)
+	})
+
+	t.Run("Pagination", func(t *testing.T) {
+		code, page, events := getActivity("?page=2&limit=3", ownerToken)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 4, page.Total)
+		require.Len(t, events, 1)
+		assert.Equal(t, models.EventCreated, events[0].Type)
+
+		code, _, _ = getActivity("?page=0", ownerToken)
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("Public readers and strangers are refused", func(t *testing.T) {
+		code, _, _ := getActivity("", strangerToken)
+		assert.Equal(t, http.StatusForbidden, code, "reading a public document does not reveal its activity")
+	})
+
+	t.Run("Unshared users lose access", func(t *testing.T) {
+		rr := performRequest(router, http.MethodDelete, "/documents/"+doc.ID+"/shares/"+readerID, nil, ownerToken)
+		require.Equal(t, http.StatusNoContent, rr.Code)
+		code, _, _ := getActivity("", readerToken)
+		assert.Equal(t, http.StatusForbidden, code)
+
+		_, _, events := getActivity("", ownerToken)
+		require.NotEmpty(t, events)
+		assert.Equal(t, models.EventUnshared, events[0].Type)
+	})
+
+	t.Run("Missing document", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/documents/missing/activity", nil, ownerToken)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Enveloped", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/v1/documents/"+doc.ID+"/activity?limit=2", nil, ownerToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"total_pages":3`)
+	})
+}
+
+func TestDocumentActivity_ShareScope(t *testing.T) {
+	router, _, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	_, _, ownerToken := createTestUserAndLogin(t, router, "activity.scope.owner@example.com", "password123", "Act", "Owner")
+	readerID, _, readerToken := createTestUserAndLogin(t, router, "activity.scope.reader@example.com", "password123", "Act", "Reader")
+
+	rr := performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, gin.H{
+		"content": gin.H{"public": gin.H{"summary": "Draft"}, "grades": gin.H{"ada": 12}},
+	}), ownerToken)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var doc models.Document
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+	rr = performRequest(router, http.MethodPut, "/documents/"+doc.ID+"/shares/"+readerID, nil, ownerToken)
+	require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	rr = performRequest(router, http.MethodPut, "/documents/"+doc.ID+"/shares/"+readerID+"/scope", marshalJSONBody(t, gin.H{"path": "public"}), ownerToken)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	for _, content := range []gin.H{
+		{"public": gin.H{"summary": "Final"}, "grades": gin.H{"ada": 15, "bob": 9}},
+		{"public": gin.H{"summary": "Final"}, "grades": gin.H{"ada": 16, "bob": 9}},
+		{"public": "Withdrawn", "grades": gin.H{}},
+	} {
+		rr = performRequest(router, http.MethodPut, "/documents/"+doc.ID, marshalJSONBody(t, gin.H{"content": content}), ownerToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	changedPaths := func(token string) [][]string {
+		rr := performRequest(router, http.MethodGet, "/documents/"+doc.ID+"/activity", nil, token)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var page struct {
+			Data []models.DocumentEvent `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &page))
+		var paths [][]string
+		for _, event := range page.Data {
+			if event.Type == models.EventUpdated {
+				paths = append(paths, event.ChangedPaths)
+			}
+		}
+		return paths
+	}
+
+	assert.Equal(t, [][]string{{"grades.ada", "grades.bob", "public"}, {"grades.ada"}, {"grades.ada", "grades.bob", "public.summary"}}, changedPaths(ownerToken))
+	assert.Equal(t, [][]string{{"public"}, nil, {"public.summary"}}, changedPaths(readerToken), "paths outside the scope are left out")
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"docserver/sdk"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- API Documentation ---
+
+// Paths DocsHandler serves under /docs.
+const (
+	swaggerSpecPath       = "/swagger.json"
+	postmanCollectionPath = "/postman.json"
+)
+
+// DocsHandler serves the Swagger UI (ui) under /docs/*any. At /docs/swagger.json it serves the
+// Swagger document of routes, the routes of the server, generated when the server starts (see
+// GenerateSwagger, which keeps the info of swaggerJSON), and at /docs/postman.json a Postman
+// collection of that document. Requests of the collection use its baseUrl variable, set to this
+// server's current API version, and its token variable, which the login request sets (see
+// sdk.GeneratePostman). The documents cannot have routes of their own, as the UI's catch-all
+// route would conflict with them.
+func DocsHandler(swaggerJSON []byte, routes gin.RoutesInfo, ui gin.HandlerFunc) (gin.HandlerFunc, error) {
+	swagger, err := GenerateSwagger(routes, swaggerJSON)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := sdk.ParseSpec(swagger)
+	if err != nil {
+		return nil, err
+	}
+	collection := sdk.GeneratePostman(spec)
+	return func(c *gin.Context) {
+		switch c.Param("any") {
+		case swaggerSpecPath:
+			c.Data(http.StatusOK, "application/json; charset=utf-8", swagger)
+		case postmanCollectionPath:
+			c.Data(http.StatusOK, "application/json; charset=utf-8", sdk.WithBaseURL(collection, clientBaseURL(c)))
+		default:
+			ui(c)
+		}
+	}, nil
+}
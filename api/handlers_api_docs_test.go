@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocsHandler(t *testing.T) {
+	served, _, _, cleanup := setupTestServer(t)
+	defer cleanup()
+	swaggerJSON, err := os.ReadFile("../docs/swagger.json")
+	require.NoError(t, err)
+	handler, err := DocsHandler(swaggerJSON, served.Routes(), func(c *gin.Context) {
+		c.String(http.StatusOK, "ui "+c.Param("any"))
+	})
+	require.NoError(t, err)
+	router := gin.New()
+	router.GET("/docs/*any", handler)
+
+	t.Run("Swagger document", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/docs/swagger.json", nil, "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "application/json; charset=utf-8", rr.Header().Get("Content-Type"))
+
+		var spec struct {
+			Info     struct{ Title string }
+			BasePath string
+			Paths    map[string]map[string]json.RawMessage
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &spec))
+		assert.Equal(t, "DocServer API", spec.Info.Title, "the info of the embedded document is kept")
+		assert.Equal(t, "/v1", spec.BasePath)
+		assert.Contains(t, spec.Paths["/documents/{id}"], "get")
+		assert.Contains(t, spec.Paths["/query/validate"], "post", "routes missing from the embedded document are listed")
+	})
+
+	t.Run("Postman collection", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/docs/postman.json", nil)
+		req.Host = "docs.example.com:8080"
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "application/json; charset=utf-8", rr.Header().Get("Content-Type"))
+
+		var collection struct {
+			Auth     struct{ Type string }
+			Variable []struct{ Key, Value string }
+			Item     []struct{ Name string }
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &collection))
+		assert.Equal(t, "bearer", collection.Auth.Type)
+		require.NotEmpty(t, collection.Variable)
+		assert.Equal(t, "baseUrl", collection.Variable[0].Key)
+		assert.Equal(t, "http://docs.example.com:8080/v1", collection.Variable[0].Value)
+		require.NotEmpty(t, collection.Item)
+		assert.Equal(t, "Search by Content (content_query examples)", collection.Item[len(collection.Item)-1].Name)
+	})
+
+	t.Run("Swagger UI", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/docs/index.html", nil, "")
+		assert.Equal(t, "ui /index.html", rr.Body.String())
+	})
+
+	t.Run("Invalid spec", func(t *testing.T) {
+		_, err := DocsHandler([]byte("{"), nil, nil)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"archive/zip"
+	"docserver/apperr"
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/models"
+	"docserver/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Document Archive ---
+
+// archivePageSize is how many documents the archive writes between flushes.
+const archivePageSize = 100
+
+// archiveTruncatedFile is added to archives that hit the size ceiling, explaining what is missing.
+const archiveTruncatedFile = "TRUNCATED.txt"
+
+// ArchiveDocumentsHandler streams the documents matching a query as a zip file.
+// @Summary      Download Documents as a Zip Archive
+// @Description  Streams a zip file with one JSON file per document you can access that matches the query, e.g. for grading offline. Each file holds the document as `GET /documents/{id}` returns it and is named after its ID, followed by its `title` when the content has one (`<id>_<title>.json`).
+// @Description
+// @Description  Accepts the `scope`, `content_query`, `missing`, `sort_by` and `order` parameters of `GET /documents`; all matching documents are included, without pagination.
+// @Description  The archive holds the documents matching when the request arrives, even if documents are created or deleted while it downloads. It holds at most `-archive-max-bytes` of document data: once the next document would exceed it, the archive ends with a `TRUNCATED.txt` file saying how many documents were left out.
+// @Tags         Documents
+// @Produce      application/zip
+// @Security     BearerAuth
+// @Param        scope         query     string   false "Filter by ownership: 'owned', 'shared', 'all' or 'public'." Enums(owned, shared, all, public) default(all)
+// @Param        content_query query     []string false "Filter by document content, as for GET /documents." collectionFormat(multi)
+// @Param        missing       query     string   false "How conditions on paths a document lacks are treated, as for GET /documents." Enums(skip, false, error) default(skip)
+// @Param        sort_by       query     string   false "Order of the files in the archive." Enums(creation_date, last_modified_date) default(creation_date)
+// @Param        order         query     string   false "Sorting direction." Enums(asc, desc) default(desc)
+// @Success      200  {file}    file "The zip archive."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: 'scope' or 'content_query' is invalid."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: 'scope=any' is not available here."
+// @Failure      500  {object}  utils.ErrorEnvelope "Internal Server Error: Something went wrong on the server while retrieving documents."
+// @Router       /documents/archive [get]
+func ArchiveDocumentsHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+
+	params := db.QueryDocumentsParams{
+		AuthUserID:   userID.(string),
+		Scope:        c.DefaultQuery("scope", "all"),
+		ContentQuery: c.QueryArray("content_query"),
+		Missing:      c.Query("missing"),
+		SortBy:       c.DefaultQuery("sort_by", "creation_date"),
+		Order:        c.DefaultQuery("order", "desc"),
+		Limit:        db.MaxLimit,
+	}
+
+	// Read every matching document from one view of the database before anything is written, so
+	// documents created or deleted while the archive streams cannot shift the pages (leaving
+	// some out or adding others twice), and query errors still get an error response
+	docs := make([]models.Document, 0)
+	err := database.View(func(v *db.ReadView) error {
+		for params.Page = 1; ; params.Page++ {
+			page, total, err := v.QueryDocumentsContext(c.Request.Context(), params)
+			if err != nil {
+				return err
+			}
+			docs = append(docs, page...)
+			if len(page) == 0 || len(docs) >= total {
+				return nil
+			}
+		}
+	})
+	if err != nil {
+		if errors.Is(err, apperr.ErrValidation) || errors.Is(err, apperr.ErrForbidden) { // Bad query, or scope 'any'
+			utils.GinErrorFromErr(c, apperr.HTTPStatus(err), err)
+		} else {
+			utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgDocumentQueryFailed, err)
+		}
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", `attachment; filename="documents.zip"`)
+	c.Status(http.StatusOK)
+	archive := zip.NewWriter(c.Writer)
+	defer func() {
+		if err := archive.Close(); err != nil {
+			log.Printf("ERROR: Failed to finish document archive for user %s: %v", params.AuthUserID, err)
+		}
+	}()
+
+	var written int64 // Uncompressed document data in the archive so far
+	for added, doc := range docs {
+		data, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			log.Printf("ERROR: Failed to encode document %s for archive: %v", doc.ID, err)
+			return
+		}
+		if written+int64(len(data)) > cfg.ArchiveMaxBytes {
+			writeArchiveNote(archive, fmt.Sprintf("This archive reached the size limit of %d bytes. It contains %d of the %d matching documents.\n", cfg.ArchiveMaxBytes, added, len(docs)))
+			return
+		}
+		file, err := archive.CreateHeader(&zip.FileHeader{Name: archiveFileName(doc), Method: zip.Deflate, Modified: doc.LastModifiedDate})
+		if err == nil {
+			_, err = file.Write(data)
+		}
+		if err != nil {
+			log.Printf("WARN: Document archive for user %s aborted: %v", params.AuthUserID, err)
+			return // Most likely the client went away
+		}
+		written += int64(len(data))
+		if (added+1)%archivePageSize == 0 {
+			c.Writer.Flush()
+		}
+	}
+}
+
+// archiveTitleChars matches the runs of characters replaced in title parts of archive file names.
+var archiveTitleChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// archiveFileName names a document's file in an archive: its ID, followed by the title from its
+// content (reduced to letters, digits, '.', '_' and '-') if it has one.
+func archiveFileName(doc models.Document) string {
+	content, isObject := doc.Content.(map[string]any)
+	if !isObject {
+		return doc.ID + ".json"
+	}
+	title, _ := content["title"].(string)
+	title = strings.Trim(archiveTitleChars.ReplaceAllString(title, "-"), "-.")
+	if len(title) > 64 {
+		title = title[:64]
+	}
+	if title == "" {
+		return doc.ID + ".json"
+	}
+	return doc.ID + "_" + title + ".json"
+}
+
+// writeArchiveNote adds the TRUNCATED.txt note to an archive.
+func writeArchiveNote(archive *zip.Writer, note string) {
+	file, err := archive.Create(archiveTruncatedFile)
+	if err == nil {
+		_, err = file.Write([]byte(note))
+	}
+	if err != nil {
+		log.Printf("WARN: Failed to add %s to document archive: %v", archiveTruncatedFile, err)
+	}
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"docserver/config"
+	"docserver/db"
+	"docserver/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveDocuments(t *testing.T) {
+	router, database, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	cfg.ArchiveMaxBytes = 1 << 20
+
+	userID, _, token := createTestUserAndLogin(t, router, "archive@example.com", "password123", "Archive", "User")
+	_, _, otherToken := createTestUserAndLogin(t, router, "archive.other@example.com", "password123", "Other", "User")
+	ids := map[string]bool{}
+	for _, content := range []map[string]any{
+		{"title": "Lab 1: Optics", "grade": 8},
+		{"title": "Lab 2", "grade": 5},
+		{"grade": 9},
+	} {
+		rr := performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, map[string]any{"content": content}), token)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var doc models.Document
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+		ids[doc.ID] = true
+	}
+	rr := performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, map[string]any{"content": map[string]any{"grade": 7}}), otherToken)
+	require.Equal(t, http.StatusCreated, rr.Code)
+
+	download := func(query string) map[string][]byte {
+		rr := performRequest(router, http.MethodGet, "/documents/archive"+query, nil, token)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Equal(t, "application/zip", rr.Header().Get("Content-Type"))
+		archive, err := zip.NewReader(bytes.NewReader(rr.Body.Bytes()), int64(rr.Body.Len()))
+		require.NoError(t, err)
+		files := map[string][]byte{}
+		for _, file := range archive.File {
+			reader, err := file.Open()
+			require.NoError(t, err)
+			data, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			files[file.Name] = data
+		}
+		return files
+	}
+
+	t.Run("All accessible documents", func(t *testing.T) {
+		files := download("")
+		require.Len(t, files, 3, "other users' documents are not included")
+		found := map[string]bool{}
+		for name, data := range files {
+			var doc models.Document
+			require.NoError(t, json.Unmarshal(data, &doc), name)
+			assert.True(t, strings.HasPrefix(name, doc.ID), name)
+			found[doc.ID] = true
+		}
+		for id := range ids {
+			assert.True(t, found[id], id)
+		}
+	})
+
+	t.Run("Titles name the files", func(t *testing.T) {
+		files := download("?content_query=" + url.QueryEscape(`title equals "Lab 1: Optics"`))
+		require.Len(t, files, 1)
+		for name := range files {
+			assert.Regexp(t, `^[0-9a-f]+_Lab-1-Optics\.json$`, name)
+		}
+	})
+
+	t.Run("Size ceiling truncates", func(t *testing.T) {
+		cfg.ArchiveMaxBytes = 300
+		defer func() { cfg.ArchiveMaxBytes = 1 << 20 }()
+		files := download("?content_query=" + url.QueryEscape("grade greaterThan 0"))
+		require.Contains(t, files, archiveTruncatedFile)
+		assert.Less(t, len(files), 4)
+		assert.Contains(t, string(files[archiveTruncatedFile]), "of the 3 matching documents")
+	})
+
+	t.Run("Consistent while documents change", func(t *testing.T) {
+		// More documents than are written between flushes, all created at the same instant
+		cfg.Clock = config.NewFixedClock(time.Now())
+		defer func() { cfg.Clock = nil }()
+		for i := 0; i < archivePageSize+20; i++ {
+			_, err := database.CreateDocument(models.Document{OwnerID: userID, Content: map[string]any{"batch": i}})
+			require.NoError(t, err)
+		}
+
+		// Deleting documents once the first files are written does not shift the rest
+		req := httptest.NewRequest(http.MethodGet, "/documents/archive?content_query="+url.QueryEscape("batch greaterthanorequals 0"), nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := &flushHookRecorder{ResponseRecorder: httptest.NewRecorder(), onFlush: func() {
+			docs, _, err := database.QueryDocuments(db.QueryDocumentsParams{AuthUserID: userID, ContentQuery: []string{"batch lessthan 10"}, Limit: db.MaxLimit})
+			require.NoError(t, err)
+			require.Len(t, docs, 10)
+			for _, doc := range docs {
+				require.NoError(t, database.DeleteDocument(doc.ID))
+			}
+		}}
+		router.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		require.Nil(t, rr.onFlush, "the archive was flushed while streaming")
+
+		archive, err := zip.NewReader(bytes.NewReader(rr.Body.Bytes()), int64(rr.Body.Len()))
+		require.NoError(t, err)
+		names := map[string]bool{}
+		for _, file := range archive.File {
+			assert.False(t, names[file.Name], "%s is in the archive twice", file.Name)
+			names[file.Name] = true
+		}
+		assert.Len(t, names, archivePageSize+20)
+	})
+
+	t.Run("Invalid query", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/documents/archive?scope=everything", nil, token)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+// flushHookRecorder is a ResponseRecorder that calls onFlush on the first flush of the response.
+type flushHookRecorder struct {
+	*httptest.ResponseRecorder
+	onFlush func()
+}
+
+func (r *flushHookRecorder) Flush() {
+	if r.onFlush != nil {
+		r.onFlush()
+		r.onFlush = nil
+	}
+	r.ResponseRecorder.Flush()
+}
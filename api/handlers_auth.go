@@ -1,11 +1,15 @@
 package api
 
 import (
+	"docserver/apperr"
 	"docserver/config"
 	"docserver/db"
+	"docserver/i18n"
 	"docserver/models"
 	"docserver/utils"
+	"errors"
 	"fmt" // Added
+	"log"
 	"net/http"
 	"strings" // Added
 	"time"
@@ -20,6 +24,9 @@ type SignupRequest struct {
 	FirstName string `json:"first_name" binding:"required"`
 	LastName  string `json:"last_name" binding:"required"`
 	Extra     any    `json:"extra,omitempty"`
+	AcceptTos bool   `json:"accept_tos,omitempty"` // Accept the current terms of service while signing up
+	InviteCode string `json:"invite_code,omitempty"` // Required when the server is invite-only
+	Challenge string `json:"challenge,omitempty"` // Solved bot-protection challenge, when the server requires one (see GET /auth/challenge)
 }
 
 // SignupResponse defines the data returned after successful signup (omits hash).
@@ -31,6 +38,7 @@ type SignupResponse struct {
 	CreationDate   time.Time `json:"creation_date"`
 	LastModifiedDate time.Time `json:"last_modified_date"`
 	Extra          any       `json:"extra,omitempty"`
+	Tos            *TosStatus `json:"tos,omitempty"` // Only present when the server has terms of service
 }
 
 // SignupHandler handles user registration.
@@ -40,34 +48,49 @@ type SignupResponse struct {
 // @Description  You need to provide your desired `email`, a secure `password` (minimum 8 characters), your `first_name`, and `last_name`.
 // @Description  The server will securely hash the password before storing it (meaning the original password is never saved directly).
 // @Description  If the email address is already registered, the request will fail.
+// @Description  Logging in from a new IP address or browser is recorded as a new device and, unless it is your first login, emailed to you (see `GET /profiles/me/devices`).
+// @Description  If the server has terms of service, the response includes a `tos` object telling whether you accepted the current version; send `"accept_tos": true` to accept it while signing up.
+// @Description  If the server is invite-only, send the `invite_code` an administrator gave you; each code allows a limited number of signups and may expire. Administrators can sign up without one.
+// @Description  If the server has bot protection, send the solved challenge from `GET /auth/challenge` as `challenge`.
+// @Description  Documents that were shared with your email before you signed up (see `POST /documents/{id}/shares/email`) are shared with your new account.
 // @Tags         Authentication
 // @Accept       json
 // @Produce      json
 // @Param        signup body SignupRequest true "User registration details. All fields except 'extra' are required."
-// @Success      201  {object}  models.Profile  "Account Created Successfully. The response body contains the details of the newly created profile (excluding the password hash)."
-// @Failure      400  {object}  utils.APIError "Bad Request: The data you sent is invalid (e.g., missing required fields, invalid email format, password too short) OR the email address is already in use by another account."
-// @Failure      500  {object}  utils.APIError "Internal Server Error: Something went wrong on the server while creating the account (e.g., password hashing failed, database connection issue)."
+// @Success      201  {object}  utils.Envelope{data=SignupResponse}  "Account Created Successfully. The response body contains the details of the newly created profile (excluding the password hash)."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The data you sent is invalid (e.g., missing required fields, invalid email format, password too short, 'extra' breaking the policy set by administrators) OR the email address is already in use by another account."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: The server is invite-only and the invitation code is missing, unknown, expired or used up, OR the bot-protection challenge is missing or not solved."
+// @Failure      500  {object}  utils.ErrorEnvelope "Internal Server Error: Something went wrong on the server while creating the account (e.g., password hashing failed, database connection issue)."
+// @Failure      503  {object}  utils.ErrorEnvelope "Service Unavailable: The bot-protection provider could not be reached to check the challenge."
 // @Router       /auth/signup [post]
 func SignupHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
 	var req SignupRequest
 
 	// Bind JSON request body to the SignupRequest struct
-	// Gin's binding also performs validation based on tags.
-	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.GinBadRequest(c, fmt.Sprintf("Invalid request body: %v", err))
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidRequestBody) {
+		return
+	}
+	if !requireChallenge(c, cfg, req.Challenge) {
+		return
+	}
+
+	// On invite-only servers everyone but administrators needs an invitation code
+	inviteRequired := cfg.InviteOnly && !isAdmin(models.Profile{Email: req.Email}, cfg)
+	if inviteRequired && strings.TrimSpace(req.InviteCode) == "" {
+		utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgInviteRequired)
 		return
 	}
 
 	// Hash the password
-	hashedPassword, err := utils.HashPassword(req.Password, cfg.BcryptCost)
+	hashedPassword, err := utils.HashPasswordWithConfig(req.Password, cfg)
 	if err != nil {
 		// HashPassword already logs the error
-		utils.GinInternalServerError(c, "Failed to process password.")
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgPasswordProcessFailed)
 		return
 	}
 
 	// Create profile model
-	now := time.Now().UTC()
+	now := cfg.Now().UTC()
 	profile := models.Profile{
 		// ID will be generated by db.CreateProfile
 		FirstName:      req.FirstName,
@@ -78,20 +101,34 @@ func SignupHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
 		LastModifiedDate: now,
 		Extra:          req.Extra,
 	}
+	if req.AcceptTos && cfg.TosVersion != "" {
+		profile.TosAcceptance = &models.TosAcceptance{Version: cfg.TosVersion, AcceptedAt: now}
+	}
 
-	// Attempt to create profile in the database
-	createdProfile, err := database.CreateProfile(profile)
+	// Attempt to create profile in the database, using up the invitation if one is required
+	var createdProfile models.Profile
+	if inviteRequired {
+		createdProfile, err = database.CreateProfileWithInvite(profile, req.InviteCode)
+	} else {
+		createdProfile, err = database.CreateProfile(profile)
+	}
 	if err != nil {
-		// Check if it's a duplicate email error (or other specific errors)
-		// Assuming CreateProfile returns an error containing "already exists" for duplicates
-		if strings.Contains(err.Error(), "already exists") { // Make check less brittle
-			utils.GinBadRequest(c, err.Error()) // Use 400 Bad Request as expected by test
-		} else {
-			utils.GinInternalServerError(c, fmt.Sprintf("Failed to create profile: %v", err))
+		switch {
+		case errors.Is(err, apperr.ErrForbidden): // Unknown, expired or used-up invitation
+			utils.GinErrorFromErr(c, http.StatusForbidden, err)
+		case errors.Is(err, apperr.ErrConflict): // Email already in use; 400 rather than 409 for existing clients
+			utils.GinErrorFromErr(c, http.StatusBadRequest, err)
+		case errors.Is(err, apperr.ErrValidation): // 'extra' breaks the extra policy
+			utils.GinErrorFromErr(c, http.StatusBadRequest, err)
+		default:
+			utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgProfileCreateFailed, err)
 		}
 		return
 	}
 
+	// Documents shared with the email before it was registered are now shared with the profile
+	database.ActivatePendingShares(createdProfile)
+
 	// Important: Do NOT return the password hash in the response.
 	// The Profile struct already has `json:"-"` on PasswordHash.
 
@@ -104,10 +141,11 @@ func SignupHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
 		CreationDate:   createdProfile.CreationDate,
 		LastModifiedDate: createdProfile.LastModifiedDate,
 		Extra:          createdProfile.Extra,
+		Tos:            tosStatusFor(createdProfile, cfg),
 	}
 
 	// Return the response object
-	c.JSON(http.StatusCreated, response)
+	utils.RespondData(c, http.StatusCreated, response)
 }
 
 // --- Login Handler (Placeholder) ---
@@ -120,7 +158,8 @@ type LoginRequest struct {
 
 // LoginResponse defines the JSON response for a successful login.
 type LoginResponse struct {
-	Token string `json:"token"`
+	Token string     `json:"token"`
+	Tos   *TosStatus `json:"tos,omitempty"` // Only present when the server has terms of service
 }
 
 // LoginHandler handles user authentication and JWT generation.
@@ -130,45 +169,72 @@ type LoginResponse struct {
 // @Description  If the credentials are correct, the server generates a JSON Web Token (JWT). This token acts like a temporary key or session ID.
 // @Description  You need to include this JWT in the `Authorization` header (as a Bearer token) for subsequent requests to protected endpoints (like accessing your profile or documents).
 // @Description  Example Header: `Authorization: Bearer <your_token_here>`
+// @Description  If the server has terms of service, the response includes a `tos` object telling whether you accepted the current version (see `POST /profiles/me/accept-tos`).
 // @Tags         Authentication
 // @Accept       json
 // @Produce      json
 // @Param        login body LoginRequest true "Your email and password."
-// @Success      200  {object}  LoginResponse "Login Successful. The response body contains the JWT access token."
-// @Failure      400  {object}  utils.APIError "Bad Request: The data you sent is invalid (e.g., missing email or password, incorrect JSON format)."
-// @Failure      401  {object}  utils.APIError "Unauthorized: The email or password you provided is incorrect. Please check your credentials."
-// @Failure      500  {object}  utils.APIError "Internal Server Error: Something went wrong on the server during login (e.g., database issue, error generating the JWT)."
+// @Success      200  {object}  utils.Envelope{data=LoginResponse} "Login Successful. The response body contains the JWT access token."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The data you sent is invalid (e.g., missing email or password, incorrect JSON format)."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: The email or password you provided is incorrect. Please check your credentials."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: Your account is deactivated. An administrator can reactivate it until the date given in the message."
+// @Failure      500  {object}  utils.ErrorEnvelope "Internal Server Error: Something went wrong on the server during login (e.g., database issue, error generating the JWT)."
 // @Router       /auth/login [post]
 func LoginHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
 	var req LoginRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.GinBadRequest(c, fmt.Sprintf("Invalid request body: %v", err))
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidRequestBody) {
 		return
 	}
 
 	// Find profile by email
 	profile, found := database.GetProfileByEmail(req.Email)
 	if !found {
-		utils.GinUnauthorized(c, "Invalid email or password")
+		utils.GinLocalizedError(c, http.StatusUnauthorized, i18n.MsgInvalidCredentials)
 		return
 	}
 
 	// Check password hash
 	if !utils.CheckPasswordHash(req.Password, profile.PasswordHash) {
-		utils.GinUnauthorized(c, "Invalid email or password")
+		utils.GinLocalizedError(c, http.StatusUnauthorized, i18n.MsgInvalidCredentials)
+		return
+	}
+
+	// Deactivated accounts cannot log in until reactivated
+	if profile.Deactivation != nil {
+		utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgAccountDeactivated, profile.Deactivation.PurgeAt.Format(time.RFC3339))
+		return
+	}
+
+	// Hashes made with an older algorithm or cost are replaced while the password is at hand
+	if utils.PasswordNeedsRehash(profile.PasswordHash, cfg) {
+		if newHash, err := utils.HashPasswordWithConfig(req.Password, cfg); err == nil {
+			database.RehashProfilePassword(profile.ID, profile.PasswordHash, newHash)
+		}
+	}
+
+	// Remember the device, and tell the user when a known account is used from a new one
+	device, isNew, known, err := database.RecordLogin(profile.ID, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgTokenGenerateFailed)
 		return
 	}
+	if isNew && known {
+		utils.SendEmail(profile.Email, "New sign-in to your account", fmt.Sprintf(
+			"Your account was signed in to from a new device on %s:\nIP address: %s\nBrowser or app: %s\n"+
+				"If this was not you, revoke the device (DELETE /profiles/me/devices/%s) and change your password.",
+			device.FirstSeen.Format(time.RFC1123), device.IPAddress, device.UserAgent, device.ID))
+	}
 
 	// Generate JWT
-	tokenString, err := utils.GenerateJWT(&profile, cfg)
+	tokenString, err := utils.GenerateJWTForDevice(&profile, device.ID, cfg)
 	if err != nil {
 		// GenerateJWT logs the error
-		utils.GinInternalServerError(c, "Failed to generate authentication token.")
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgTokenGenerateFailed)
 		return
 	}
 
 	// Return token
-	c.JSON(http.StatusOK, LoginResponse{Token: tokenString})
+	utils.RespondData(c, http.StatusOK, LoginResponse{Token: tokenString, Tos: tosStatusFor(profile, cfg)})
 }
 
 // --- Logout Handler (Placeholder) ---
@@ -182,7 +248,7 @@ func LoginHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
 // @Tags         Authentication
 // @Security     BearerAuth
 // @Success      204  "Logout Signaled. No content is returned. Remember to discard the JWT on the client."
-// @Failure      401  {object}  utils.APIError "Unauthorized: Although logout is client-side, this endpoint might still require a valid token to be called as per API design consistency."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Although logout is client-side, this endpoint might still require a valid token to be called as per API design consistency."
 // @Router       /auth/logout [post]
 func LogoutHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
 	// No server-side action needed as JWTs are stateless.
@@ -195,41 +261,55 @@ func LogoutHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
 
 // ForgotPasswordRequest defines the body for the forgot password request.
 type ForgotPasswordRequest struct {
-	Email string `json:"email" binding:"required,email"`
+	Email     string `json:"email" binding:"required,email"`
+	Challenge string `json:"challenge,omitempty"` // Solved bot-protection challenge, when the server requires one (see GET /auth/challenge)
 }
 
-// ForgotPasswordHandler generates and stores an OTP.
+// ForgotPasswordHandler generates, stores and sends an OTP.
 // @Summary      Request Password Reset Code (OTP)
-// @Description  Initiates the password reset process by requesting a One-Time Password (OTP) to be sent (conceptually) to the user's registered email address.
+// @Description  Initiates the password reset process by sending a One-Time Password (OTP) to the account.
 // @Description
 // @Description  Provide the `email` address associated with the account you want to reset the password for.
 // @Description  **Security Note:** To prevent attackers from figuring out which emails are registered ("email enumeration"), this endpoint will *always* return a `202 Accepted` response, regardless of whether the email exists in the system or not.
-// @Description  If the email *does* exist, the server generates an OTP, stores it temporarily, and (in a real system) would send it via email. The OTP is needed for the `/auth/reset-password` step.
+// @Description  If the email *does* exist, the server generates an OTP and sends it to that email address, or, when the server delivers OTPs by SMS, texts it to the profile's phone number (profiles without one still get an email). The OTP's length, characters and lifetime are set by the server operator. The OTP is needed for the `/auth/reset-password` step; requesting a new one replaces the previous one.
+// @Description  If the server has bot protection, send the solved challenge from `GET /auth/challenge` as `challenge`; it is checked before the email address is looked up.
 // @Tags         Authentication
 // @Accept       json
 // @Produce      json
 // @Param        forgotPassword body ForgotPasswordRequest true "The email address for the account needing a password reset."
-// @Success      202  "Request Accepted. If the email address is registered, an OTP has been generated (and would typically be emailed). Check your email for the code."
-// @Failure      400  {object}  utils.APIError "Bad Request: The request body is invalid (e.g., missing email or invalid format)."
-// @Failure      500  {object}  utils.APIError "Internal Server Error: Something went wrong on the server while processing the request (e.g., OTP generation failed)."
+// @Success      202  "Request Accepted. If the email address is registered, an OTP has been sent. Check your email (or phone) for the code."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The request body is invalid (e.g., missing email or invalid format)."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: The bot-protection challenge is missing or not solved."
+// @Failure      500  {object}  utils.ErrorEnvelope "Internal Server Error: Something went wrong on the server while processing the request (e.g., OTP generation failed)."
+// @Failure      503  {object}  utils.ErrorEnvelope "Service Unavailable: The bot-protection provider could not be reached to check the challenge."
 // @Router       /auth/forgot-password [post]
 func ForgotPasswordHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
 	var req ForgotPasswordRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.GinBadRequest(c, fmt.Sprintf("Invalid request body: %v", err))
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidRequestBody) {
+		return
+	}
+	if !requireChallenge(c, cfg, req.Challenge) {
 		return
 	}
 
 	// Check if email exists
-	_, found := database.GetProfileByEmail(req.Email)
-	if found {
-		// Generate and store OTP (GenerateAndStoreOTP logs it)
-		_, err := utils.GenerateAndStoreOTP(req.Email, database) // Pass database instance
+	profile, found := database.GetProfileByEmail(req.Email)
+	if found && profile.Service == nil { // Service accounts have no password to reset
+		// Generate and store OTP
+		otp, expiry, err := utils.GenerateAndStoreOTP(req.Email, cfg, database) // Pass database instance
 		if err != nil {
-			// Should not happen with in-memory store unless rand fails
-			utils.GinInternalServerError(c, fmt.Sprintf("Failed to generate OTP: %v", err))
+			// Should not happen unless rand fails
+			utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgOTPGenerateFailed, err)
 			return
 		}
+		gateway, err := utils.NewSMSGateway(cfg)
+		if err != nil {
+			log.Printf("ERROR: %v; sending the OTP by email instead", err)
+		}
+		// A delivery failure is logged but not reported, so the response still reveals nothing
+		if err := utils.DeliverOTP(c.Request.Context(), cfg, gateway, profile, otp, expiry); err != nil {
+			log.Printf("ERROR: Failed to deliver password reset OTP for %s: %v", req.Email, err)
+		}
 	} else {
 		// Log that the email wasn't found, but don't tell the user
 		fmt.Printf("INFO: Forgot password request for non-existent email: %s\n", req.Email)
@@ -256,40 +336,50 @@ type ResetPasswordRequest struct {
 // @Description  *   The desired `new_password` (must meet minimum length requirements, e.g., 8 characters).
 // @Description
 // @Description  The server will first verify if the provided OTP is correct and hasn't expired for the given email. If valid, it will hash the `new_password` and update the user's account.
+// @Description  Tokens issued for the account before the reset stop working; log in again with the new password.
+// @Description  Each OTP allows only a limited number of wrong guesses (5 by default); after that it is invalidated and you need to request a new one.
+// @Description  After every wrong guess further attempts for the email are refused for a while, starting at one second by default and doubling with each consecutive failure (requesting a new OTP does not reset this). Such attempts get `429 Too Many Requests` with a `Retry-After` header giving the seconds to wait.
 // @Tags         Authentication
 // @Accept       json
 // @Produce      json
 // @Param        resetPassword body ResetPasswordRequest true "Email, OTP, and the new password."
 // @Success      204  "Password Reset Successful. Your new password is now active. You can log in using it. No content is returned in the response body."
-// @Failure      400  {object}  utils.APIError "Bad Request: The request body is invalid (e.g., missing fields, new password too short)."
-// @Failure      401  {object}  utils.APIError "Unauthorized: The provided OTP is incorrect, expired, or does not match the email address."
-// @Failure      404  {object}  utils.APIError "Not Found: The profile associated with the email address could not be found (e.g., it might have been deleted after the OTP was requested)."
-// @Failure      500  {object}  utils.APIError "Internal Server Error: Something went wrong on the server (e.g., hashing the new password failed, database update failed)."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The request body is invalid (e.g., missing fields, new password too short)."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: The provided OTP is incorrect, expired, used up by too many wrong guesses, or does not match the email address."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: The profile associated with the email address could not be found (e.g., it might have been deleted after the OTP was requested)."
+// @Failure      429  {object}  utils.ErrorEnvelope "Too Many Requests: Recent wrong guesses; wait the number of seconds in the `Retry-After` header."
+// @Failure      500  {object}  utils.ErrorEnvelope "Internal Server Error: Something went wrong on the server (e.g., hashing the new password failed, database update failed)."
 // @Router       /auth/reset-password [post]
 func ResetPasswordHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
 	var req ResetPasswordRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.GinBadRequest(c, fmt.Sprintf("Invalid request body: %v", err))
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidRequestBody) {
 		return
 	}
 
 	// Verify OTP
-	validOTP, err := utils.VerifyOTP(req.Email, req.OTP, database) // Pass database instance
+	validOTP, err := utils.VerifyOTP(req.Email, req.OTP, cfg, database) // Pass database instance
 	if err != nil {
+		var msgErr *i18n.Error
+		if errors.As(err, &msgErr) && msgErr.ID == i18n.MsgOTPLocked && len(msgErr.Args) > 0 {
+			// Too many recent failures; tell the client how long to back off
+			c.Header("Retry-After", fmt.Sprint(msgErr.Args[0]))
+			utils.GinErrorFromErr(c, http.StatusTooManyRequests, err)
+			return
+		}
 		// VerifyOTP returns specific errors for expired/invalid/not found
-		utils.GinUnauthorized(c, err.Error()) // Use 401 for OTP issues
+		utils.GinErrorFromErr(c, http.StatusUnauthorized, err) // Use 401 for OTP issues
 		return
 	}
 	if !validOTP {
 		// Should be caught by err != nil, but defensive check
-		utils.GinUnauthorized(c, "Invalid OTP.")
+		utils.GinLocalizedError(c, http.StatusUnauthorized, i18n.MsgInvalidOTP)
 		return
 	}
 
 	// Hash the new password
-	newHashedPassword, err := utils.HashPassword(req.NewPassword, cfg.BcryptCost)
+	newHashedPassword, err := utils.HashPasswordWithConfig(req.NewPassword, cfg)
 	if err != nil {
-		utils.GinInternalServerError(c, "Failed to process new password.")
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgPasswordProcessFailed)
 		return
 	}
 
@@ -297,10 +387,10 @@ func ResetPasswordHandler(c *gin.Context, database *db.Database, cfg *config.Con
 	err = database.UpdateProfilePassword(req.Email, newHashedPassword)
 	if err != nil {
 		// This could happen if the profile was deleted between OTP generation and reset
-		if strings.Contains(strings.ToLower(err.Error()), "not found") {
-			utils.GinNotFound(c, err.Error())
+		if errors.Is(err, apperr.ErrNotFound) {
+			utils.GinErrorFromErr(c, http.StatusNotFound, err)
 		} else {
-			utils.GinInternalServerError(c, fmt.Sprintf("Failed to update password: %v", err))
+			utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgPasswordUpdateFailed, err)
 		}
 		return
 	}
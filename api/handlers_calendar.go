@@ -0,0 +1,156 @@
+package api
+
+import (
+	"docserver/apperr"
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/utils"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Calendar Feed ---
+
+// CalendarFeedResponse is a newly issued calendar feed URL.
+type CalendarFeedResponse struct {
+	URL   string `json:"url"`   // Path of the feed on this server, e.g. /v1/calendar.ics?token={token}
+	Token string `json:"token"` // The token in the URL
+}
+
+// CreateCalendarFeedHandler issues the authenticated user a calendar feed URL.
+// @Summary      Create a Calendar Feed URL
+// @Description  Issues a URL serving your upcoming deadlines as an iCalendar feed, to subscribe to from a calendar app. Documents you own, or that are shared with you, whose content has a top-level `due_date` appear as events: an RFC 3339 time, or a date (`YYYY-MM-DD`) for an all-day deadline. The feed is generated each time it is fetched, so it follows changes to the documents.
+// @Description
+// @Description  The URL does not expire. Creating a new one replaces the previous URL, which stops working; so does changing your password or deleting the feed. `url` is a path on this server (including `/courses/{id}` for course databases).
+// @Tags         Calendar
+// @Produce      json
+// @Security     BearerAuth
+// @Success      201  {object}  utils.Envelope{data=CalendarFeedResponse} "The feed URL."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Router       /calendar/feed [post]
+func CreateCalendarFeedHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+	userIDStr := userID.(string)
+
+	feedID, err := database.RenewCalendarFeed(userIDStr)
+	if err != nil {
+		utils.GinErrorFromErr(c, apperr.HTTPStatus(err), err)
+		return
+	}
+	token, err := utils.GenerateCalendarFeedToken(userIDStr, feedID, cfg)
+	if err != nil {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgCalendarFeedFailed, err)
+		return
+	}
+
+	// The feed lives under the same API version (and course) as this request
+	prefix := strings.TrimSuffix(c.FullPath(), "/calendar/feed")
+	if course := requestCourse(c); course != "" {
+		prefix = "/courses/" + course + prefix
+	}
+	utils.RespondData(c, http.StatusCreated, CalendarFeedResponse{
+		URL:   prefix + "/calendar.ics?token=" + url.QueryEscape(token),
+		Token: token,
+	})
+}
+
+// DeleteCalendarFeedHandler turns the authenticated user's calendar feed off.
+// @Summary      Delete the Calendar Feed
+// @Description  Revokes your calendar feed URL, so calendar apps subscribed to it stop getting your deadlines. Create a new one to turn the feed back on.
+// @Tags         Calendar
+// @Security     BearerAuth
+// @Success      204  "Feed revoked."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Router       /calendar/feed [delete]
+func DeleteCalendarFeedHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+	if err := database.RevokeCalendarFeed(userID.(string)); err != nil {
+		utils.GinErrorFromErr(c, apperr.HTTPStatus(err), err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetCalendarFeedHandler serves the upcoming deadlines of the user a calendar feed token was
+// issued to, as iCalendar.
+// @Summary      Get the Calendar Feed
+// @Description  Returns the upcoming deadlines of the documents you own or that are shared with you, soonest first, as an iCalendar (RFC 5545) feed. It is authenticated by the `token` of a URL from `POST /calendar/feed` rather than an `Authorization` header, since calendar apps cannot send one. Deadlines that have passed are left out; all-day ones stay until the end of their day (UTC).
+// @Tags         Calendar
+// @Produce      text/calendar
+// @Param        token  query     string  true  "The feed token."
+// @Success      200    {string}  string  "The iCalendar feed."
+// @Failure      401    {object}  utils.ErrorEnvelope "Unauthorized: The token is missing, invalid, revoked or replaced."
+// @Router       /calendar.ics [get]
+func GetCalendarFeedHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+
+	deadlines, err := database.UpcomingDeadlines(userID.(string))
+	if err != nil {
+		utils.GinErrorFromErr(c, apperr.HTTPStatus(err), err)
+		return
+	}
+	events := make([]utils.ICalEvent, len(deadlines))
+	for i, deadline := range deadlines {
+		events[i] = utils.ICalEvent{
+			UID:         deadline.Document.ID + "@docserver",
+			Summary:     "Due: " + documentTitle(deadline.Document.Content, deadline.Document.ID),
+			Description: fmt.Sprintf("Document %s", deadline.Document.ID),
+			Start:       deadline.Due,
+			AllDay:      deadline.AllDay,
+			Stamp:       deadline.Document.LastModifiedDate,
+		}
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(utils.RenderICalendar("docserver deadlines", events)))
+}
+
+// documentTitle returns the trimmed top-level "title" of a document's content, or "document {id}"
+// when it has none.
+func documentTitle(content any, docID string) string {
+	if object, isObject := content.(map[string]any); isObject {
+		if title, isString := object["title"].(string); isString && strings.TrimSpace(title) != "" {
+			return strings.TrimSpace(title)
+		}
+	}
+	return "document " + docID
+}
+
+// CalendarFeedMiddleware authenticates a request by the calendar feed token in its "token" query
+// parameter instead of an Authorization header. The token must carry the user's current feed ID;
+// the request then acts as that user.
+func CalendarFeedMiddleware(database *db.Database, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := utils.ValidateCalendarFeedToken(c.Query("token"), cfg)
+		if err != nil {
+			utils.GinLocalizedError(c, http.StatusUnauthorized, i18n.MsgCalendarFeedInvalid, err)
+			return
+		}
+		profile, found := database.GetProfileByID(claims.Subject)
+		if !found || profile.CalendarFeedID != claims.ID {
+			utils.GinLocalizedError(c, http.StatusUnauthorized, i18n.MsgCalendarFeedRevoked)
+			return
+		}
+
+		c.Set("userID", claims.Subject)
+		c.Set("tokenIssuedAt", claims.IssuedAt.Time) // Password changes revoke feeds too (see RequireActiveMiddleware)
+		c.Next()
+	}
+}
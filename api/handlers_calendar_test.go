@@ -0,0 +1,116 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"docserver/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendarFeed(t *testing.T) {
+	router, database, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	clock := config.NewFixedClock(time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC))
+	cfg.Clock = clock
+
+	_, ownerEmail, ownerToken := createTestUserAndLogin(t, router, "calendar.owner@example.com", "password123", "Cal", "Owner")
+	_, _, otherToken := createTestUserAndLogin(t, router, "calendar.other@example.com", "password123", "Cal", "Other")
+
+	create := func(token string, content gin.H) string {
+		rr := performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, gin.H{"content": content}), token)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var doc DocumentResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+		return doc.ID
+	}
+	essay := create(ownerToken, gin.H{"title": "Essay, part 1", "due_date": "2026-03-20T09:00:00Z"})
+	create(ownerToken, gin.H{"title": "Old", "due_date": "2026-03-01"})
+	create(ownerToken, gin.H{"title": "No deadline"})
+	lab := create(otherToken, gin.H{"due_date": "2026-03-15"})
+	create(otherToken, gin.H{"title": "Private", "due_date": "2026-03-15"})
+	ownerProfile, found := database.GetProfileByEmail(ownerEmail)
+	require.True(t, found)
+	require.NoError(t, database.SetShareRecord(lab, []string{ownerProfile.ID}))
+
+	issue := func(t *testing.T, path string) CalendarFeedResponse {
+		t.Helper()
+		rr := performRequest(router, http.MethodPost, path, nil, ownerToken)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var feed CalendarFeedResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &feed))
+		return feed
+	}
+
+	var feed CalendarFeedResponse
+	t.Run("Feed of upcoming deadlines", func(t *testing.T) {
+		feed = issue(t, "/calendar/feed")
+		assert.Equal(t, "/calendar.ics?token="+feed.Token, feed.URL)
+
+		rr := performRequest(router, http.MethodGet, feed.URL, nil, "")
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Equal(t, "text/calendar; charset=utf-8", rr.Header().Get("Content-Type"))
+		body := rr.Body.String()
+		assert.Equal(t, 2, strings.Count(body, "BEGIN:VEVENT"))
+		assert.Contains(t, body, "UID:"+essay+"@docserver\r\n")
+		assert.Contains(t, body, "SUMMARY:Due: Essay\\, part 1\r\n")
+		assert.Contains(t, body, "DTSTART:20260320T090000Z\r\n")
+		assert.Contains(t, body, "SUMMARY:Due: document "+lab+"\r\n", "documents shared with the user, by ID when untitled")
+		assert.Contains(t, body, "DTSTART;VALUE=DATE:20260315\r\n")
+		assert.Less(t, strings.Index(body, lab), strings.Index(body, essay), "soonest first")
+		assert.NotContains(t, body, "Old")
+		assert.NotContains(t, body, "Private")
+	})
+
+	t.Run("Versioned URL", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/v1/calendar/feed", nil, ownerToken)
+		require.Equal(t, http.StatusCreated, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"url":"/v1/calendar.ics?token=`)
+	})
+
+	t.Run("A new URL replaces the previous one", func(t *testing.T) {
+		previous := issue(t, "/calendar/feed")
+		latest := issue(t, "/calendar/feed")
+		rr := performRequest(router, http.MethodGet, previous.URL, nil, "")
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		rr = performRequest(router, http.MethodGet, latest.URL, nil, "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		feed = latest
+	})
+
+	t.Run("Invalid tokens", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/calendar.ics", nil, "")
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		rr = performRequest(router, http.MethodGet, "/calendar.ics?token="+ownerToken, nil, "")
+		assert.Equal(t, http.StatusUnauthorized, rr.Code, "access tokens are not feed tokens")
+	})
+
+	t.Run("Password changes revoke the feed", func(t *testing.T) {
+		clock.Advance(time.Second)
+		require.NoError(t, database.UpdateProfilePassword(ownerEmail, ownerProfile.PasswordHash))
+		rr := performRequest(router, http.MethodGet, feed.URL, nil, "")
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("Deleting the feed", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/auth/login", marshalJSONBody(t, gin.H{"email": ownerEmail, "password": "password123"}), "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		var login struct {
+			Token string `json:"token"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &login))
+		ownerToken = login.Token
+
+		latest := issue(t, "/calendar/feed")
+		rr = performRequest(router, http.MethodDelete, "/calendar/feed", nil, ownerToken)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		rr = performRequest(router, http.MethodGet, latest.URL, nil, "")
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
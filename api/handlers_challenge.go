@@ -0,0 +1,80 @@
+package api
+
+import (
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/utils"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Bot Protection ---
+
+// ChallengeResponse tells clients which challenge the public auth endpoints require.
+type ChallengeResponse struct {
+	Provider   string     `json:"provider"`             // "hcaptcha", "turnstile", "pow", or empty when no challenge is required
+	SiteKey    string     `json:"site_key,omitempty"`   // For hcaptcha and turnstile: the key to render the widget with
+	Challenge  string     `json:"challenge,omitempty"`  // For pow: the challenge to solve
+	Difficulty int        `json:"difficulty,omitempty"` // For pow: the leading zero bits the hash must have
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"` // For pow: when the challenge stops being accepted
+}
+
+// GetChallengeHandler describes the challenge required to sign up or request a password reset.
+// @Summary      Get a Bot-Protection Challenge
+// @Description  Tells you which challenge `POST /auth/signup` and `POST /auth/forgot-password` require, if any. Send the solved challenge as `challenge` in their body.
+// @Description
+// @Description  - `hcaptcha` or `turnstile`: render the provider's widget with `site_key` and send the token it produces.
+// @Description  - `pow` (proof of work): find a nonce such that the SHA-256 of `<challenge>:<nonce>` starts with `difficulty` zero bits and send `<challenge>:<nonce>`. Each challenge works once and expires at `expires_at`; call this endpoint again for a new one.
+// @Description  - empty: no challenge is required.
+// @Tags         Authentication
+// @Produce      json
+// @Success      200  {object}  utils.Envelope{data=ChallengeResponse} "The required challenge."
+// @Failure      500  {object}  utils.ErrorEnvelope "Internal Server Error: A proof-of-work challenge could not be created."
+// @Router       /auth/challenge [get]
+func GetChallengeHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	resp := ChallengeResponse{Provider: cfg.ChallengeProvider}
+	switch verifier := utils.NewChallengeVerifier(cfg).(type) {
+	case *utils.PoWVerifier:
+		challenge, expiresAt, err := verifier.IssueChallenge()
+		if err != nil {
+			utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgChallengeNotIssued, err)
+			return
+		}
+		resp.Challenge = challenge
+		resp.Difficulty = verifier.Difficulty
+		resp.ExpiresAt = &expiresAt
+	case *utils.SiteVerifyVerifier:
+		resp.SiteKey = cfg.ChallengeSiteKey
+	}
+	utils.RespondData(c, http.StatusOK, resp)
+}
+
+// requireChallenge checks the challenge response sent with a public auth request, if the server
+// requires one. It writes the error response and returns false when the request must not proceed.
+func requireChallenge(c *gin.Context, cfg *config.Config, response string) bool {
+	verifier := utils.NewChallengeVerifier(cfg)
+	if verifier == nil {
+		return true
+	}
+	if strings.TrimSpace(response) == "" {
+		utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgChallengeRequired)
+		return false
+	}
+	if err := verifier.Verify(c.Request.Context(), response, c.ClientIP()); err != nil {
+		if errors.Is(err, utils.ErrChallengeFailed) {
+			log.Printf("INFO: Rejected challenge from %s on %s: %v", c.ClientIP(), c.FullPath(), err)
+			utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgChallengeFailed)
+		} else {
+			log.Printf("ERROR: Could not verify challenge with %s: %v", cfg.ChallengeProvider, err)
+			utils.GinLocalizedError(c, http.StatusServiceUnavailable, i18n.MsgChallengeUnavailable)
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"docserver/config"
+	"docserver/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChallengeEndpoints(t *testing.T) {
+	router, _, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	getChallenge := func() ChallengeResponse {
+		rr := performRequest(router, http.MethodGet, "/auth/challenge", nil, "")
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var resp ChallengeResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		return resp
+	}
+	signup := func(email, challenge string) int {
+		return performRequest(router, http.MethodPost, "/auth/signup", marshalJSONBody(t, gin.H{
+			"email": email, "password": "password123", "first_name": "Bot", "last_name": "Check", "challenge": challenge,
+		}), "").Code
+	}
+	forgotPassword := func(email, challenge string) int {
+		return performRequest(router, http.MethodPost, "/auth/forgot-password", marshalJSONBody(t, gin.H{
+			"email": email, "challenge": challenge,
+		}), "").Code
+	}
+
+	t.Run("No challenge by default", func(t *testing.T) {
+		assert.Empty(t, getChallenge().Provider)
+		assert.Equal(t, http.StatusCreated, signup("challenge.open@example.com", ""))
+		assert.Equal(t, http.StatusAccepted, forgotPassword("challenge.open@example.com", ""))
+	})
+
+	cfg.ChallengeProvider = config.ChallengeProviderPoW
+	cfg.ChallengePoWDifficulty = 8
+
+	t.Run("Proof of work", func(t *testing.T) {
+		challenge := getChallenge()
+		assert.Equal(t, config.ChallengeProviderPoW, challenge.Provider)
+		assert.Equal(t, 8, challenge.Difficulty)
+		require.NotNil(t, challenge.ExpiresAt)
+		response := utils.SolvePoWChallenge(challenge.Challenge, challenge.Difficulty)
+
+		assert.Equal(t, http.StatusForbidden, signup("challenge.pow@example.com", ""))
+		assert.Equal(t, http.StatusForbidden, signup("challenge.pow@example.com", challenge.Challenge+":unsolved"))
+		assert.Equal(t, http.StatusCreated, signup("challenge.pow@example.com", response))
+		assert.Equal(t, http.StatusForbidden, signup("challenge.pow2@example.com", response), "challenges are used once")
+
+		assert.Equal(t, http.StatusForbidden, forgotPassword("challenge.pow@example.com", ""))
+		next := getChallenge()
+		assert.Equal(t, http.StatusAccepted, forgotPassword("challenge.pow@example.com", utils.SolvePoWChallenge(next.Challenge, next.Difficulty)))
+	})
+
+	t.Run("Hosted provider exposes the site key", func(t *testing.T) {
+		cfg.ChallengeProvider = config.ChallengeProviderTurnstile
+		cfg.ChallengeSiteKey = "site-key"
+		cfg.ChallengeSecret = "secret"
+		challenge := getChallenge()
+		assert.Equal(t, "site-key", challenge.SiteKey)
+		assert.Empty(t, challenge.Challenge)
+
+		rr := performRequest(router, http.MethodPost, "/v1/auth/signup", marshalJSONBody(t, gin.H{
+			"email": "challenge.hosted@example.com", "password": "password123", "first_name": "Bot", "last_name": "Check",
+		}), "")
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"message_id":"challenge_required"`)
+	})
+}
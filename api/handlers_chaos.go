@@ -0,0 +1,239 @@
+package api
+
+import (
+	"context"
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/utils"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Chaos Injection (Debug Mode) ---
+
+// maxChaosLatency caps the latency and jitter chaos mode may add to a request.
+const maxChaosLatency = time.Minute
+
+// ChaosSettings describes the failures chaos mode injects.
+type ChaosSettings struct {
+	Enabled       bool     `json:"enabled"`
+	Routes        []string `json:"routes"`     // Paths affected, with everything below them, e.g. "/documents"; empty = all
+	LatencyMillis int      `json:"latency_ms"` // Delay added to every affected request
+	JitterMillis  int      `json:"jitter_ms"`  // Up to this much more delay, at random
+	ErrorRate     float64  `json:"error_rate"` // Share of affected requests answered with 500 Internal Server Error (0-1)
+	DropRate      float64  `json:"drop_rate"`  // Share of affected requests whose connection is closed without an answer (0-1)
+}
+
+// chaosState holds the chaos settings. They are kept in memory only, so a restart switches chaos
+// mode off. It is shared by all mounted versions.
+type chaosState struct {
+	mu       sync.RWMutex
+	settings ChaosSettings
+}
+
+// newChaosState returns chaos mode switched off.
+func newChaosState() *chaosState {
+	return &chaosState{settings: ChaosSettings{Routes: []string{}}}
+}
+
+// get returns the current settings.
+func (s *chaosState) get() ChaosSettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.settings
+}
+
+// set replaces the settings.
+func (s *chaosState) set(settings ChaosSettings) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settings = settings
+}
+
+// ChaosMiddleware injects the latency, errors and dropped connections configured with
+// PUT /admin/chaos into requests to the affected routes. It only acts while the server runs in
+// debug mode, and never on /admin routes, so chaos mode can always be switched off.
+// prefix is the version prefix the routes are mounted under ("/v1", or "" for legacy paths).
+func ChaosMiddleware(chaos *chaosState, cfg *config.Config, prefix string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		settings := chaos.get()
+		path := strings.TrimPrefix(c.Request.URL.Path, prefix)
+		if cfg.GinMode != config.GinModeDebug || !settings.Enabled || matchesRoute(path, "/admin") || !chaosCovers(settings, path) {
+			c.Next()
+			return
+		}
+
+		delay := time.Duration(settings.LatencyMillis) * time.Millisecond
+		if settings.JitterMillis > 0 {
+			delay += time.Duration(rand.Float64() * float64(time.Duration(settings.JitterMillis)*time.Millisecond))
+		}
+		if delay > 0 && !sleepContext(c.Request.Context(), delay) {
+			c.Abort() // The client gave up
+			return
+		}
+
+		switch roll := rand.Float64(); {
+		case roll < settings.DropRate:
+			dropConnection(c)
+		case roll < settings.DropRate+settings.ErrorRate:
+			utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgChaosInjected)
+		default:
+			c.Next()
+		}
+	}
+}
+
+// chaosCovers reports whether chaos mode applies to path: it covers every path unless it is
+// limited to some routes.
+func chaosCovers(settings ChaosSettings, path string) bool {
+	if len(settings.Routes) == 0 {
+		return true
+	}
+	for _, route := range settings.Routes {
+		if matchesRoute(path, route) {
+			return true
+		}
+	}
+	return false
+}
+
+// sleepContext waits for d, returning false if ctx ends first.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// dropConnection closes the client's connection without answering. Where the connection cannot be
+// taken over (e.g. HTTP/2), it answers 502 Bad Gateway with an empty body instead, as a proxy
+// would for a dropped upstream connection.
+func dropConnection(c *gin.Context) {
+	c.Abort()
+	// gin's writer claims to support hijacking whatever it wraps, so ask the wrapped one
+	if unwrapper, ok := c.Writer.(interface{ Unwrap() http.ResponseWriter }); ok {
+		if _, ok := unwrapper.Unwrap().(http.Hijacker); ok {
+			if conn, _, err := c.Writer.Hijack(); err == nil {
+				log.Printf("INFO: Chaos mode dropped the connection of %s %s", c.Request.Method, c.Request.URL.Path)
+				_ = conn.Close()
+				return
+			}
+		}
+	}
+	c.Status(http.StatusBadGateway)
+}
+
+// requireChaosMode sends 404 Not Found and returns false unless the server runs in debug mode.
+func requireChaosMode(c *gin.Context, cfg *config.Config) bool {
+	if cfg.GinMode != config.GinModeDebug {
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgChaosDisabled)
+		return false
+	}
+	return true
+}
+
+// GetChaosHandler returns the chaos settings.
+// @Summary      Get Chaos Settings (Admin, Debug Mode)
+// @Description  Returns the failures chaos mode currently injects; see `PUT /admin/chaos`. Only available while the server runs in debug mode. Administrators only.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  utils.Envelope{data=ChaosSettings} "The chaos settings."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: The server is not running in debug mode."
+// @Router       /admin/chaos [get]
+func GetChaosHandler(c *gin.Context, database *db.Database, cfg *config.Config, chaos *chaosState) {
+	if !requireChaosMode(c, cfg) {
+		return
+	}
+	utils.RespondData(c, http.StatusOK, chaos.get())
+}
+
+// SetChaosHandler switches chaos mode on with new settings.
+// @Summary      Switch Chaos Mode On (Admin, Debug Mode)
+// @Description  Makes the server misbehave on purpose, so you can practice writing clients that cope with a slow or unreliable backend: every request to the affected `routes` (all routes if none are given; `/admin` never) is delayed by `latency_ms` plus up to `jitter_ms` at random, then a share of them (`error_rate`, 0-1) is answered with `500 Internal Server Error` and another (`drop_rate`, 0-1) has its connection closed without any answer. Latency and jitter are limited to a minute each.
+// @Description  Chaos mode is kept in memory only, so it is off after a restart; switch it off sooner with `DELETE /admin/chaos`. Only available while the server runs in debug mode. Administrators only.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        settings body ChaosSettings true "The failures to inject; 'enabled' is ignored."
+// @Success      200  {object}  utils.Envelope{data=ChaosSettings} "The new chaos settings."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The body is invalid, a rate or delay is out of range, or a route is not a path."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: The server is not running in debug mode."
+// @Router       /admin/chaos [put]
+func SetChaosHandler(c *gin.Context, database *db.Database, cfg *config.Config, chaos *chaosState) {
+	if !requireChaosMode(c, cfg) {
+		return
+	}
+
+	var req ChaosSettings
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidRequestBody) {
+		return
+	}
+	maxMillis := int(maxChaosLatency / time.Millisecond)
+	switch {
+	case req.LatencyMillis < 0 || req.LatencyMillis > maxMillis || req.JitterMillis < 0 || req.JitterMillis > maxMillis:
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgChaosSettings, fmt.Errorf("latency_ms and jitter_ms must be between 0 and %d", maxMillis))
+		return
+	case req.ErrorRate < 0 || req.DropRate < 0 || req.ErrorRate+req.DropRate > 1:
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgChaosSettings, fmt.Errorf("error_rate and drop_rate must be at least 0 and add up to at most 1"))
+		return
+	}
+
+	settings := ChaosSettings{
+		Enabled:       true,
+		Routes:        make([]string, 0, len(req.Routes)),
+		LatencyMillis: req.LatencyMillis,
+		JitterMillis:  req.JitterMillis,
+		ErrorRate:     req.ErrorRate,
+		DropRate:      req.DropRate,
+	}
+	for _, route := range req.Routes {
+		normalized, ok := normalizeRoute(route)
+		if !ok {
+			utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgChaosRoute, route)
+			return
+		}
+		settings.Routes = append(settings.Routes, normalized)
+	}
+	chaos.set(settings)
+	userID, _ := c.Get("userID")
+	log.Printf("AUDIT: Profile ID %v switched chaos mode on: %+v", userID, settings)
+	utils.RespondData(c, http.StatusOK, settings)
+}
+
+// DeleteChaosHandler switches chaos mode off.
+// @Summary      Switch Chaos Mode Off (Admin, Debug Mode)
+// @Description  Stops injecting failures. Only available while the server runs in debug mode. Administrators only.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Success      204  "Chaos mode is off."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: The server is not running in debug mode."
+// @Router       /admin/chaos [delete]
+func DeleteChaosHandler(c *gin.Context, database *db.Database, cfg *config.Config, chaos *chaosState) {
+	if !requireChaosMode(c, cfg) {
+		return
+	}
+	chaos.set(ChaosSettings{Routes: []string{}})
+	userID, _ := c.Get("userID")
+	log.Printf("AUDIT: Profile ID %v switched chaos mode off", userID)
+	c.Status(http.StatusNoContent)
+}
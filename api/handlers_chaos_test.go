@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"docserver/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChaosMode(t *testing.T) {
+	router, _, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	cfg.GinMode = config.GinModeDebug
+	cfg.AdminEmails = []string{"chaos.admin@example.com"}
+
+	_, _, adminToken := createTestUserAndLogin(t, router, "chaos.admin@example.com", "password123", "Chaos", "Admin")
+	_, _, userToken := createTestUserAndLogin(t, router, "chaos.user@example.com", "password123", "Chaos", "User")
+	setChaos := func(settings gin.H) int {
+		return performRequest(router, http.MethodPut, "/admin/chaos", marshalJSONBody(t, settings), adminToken).Code
+	}
+	defer performRequest(router, http.MethodDelete, "/admin/chaos", nil, adminToken)
+
+	t.Run("Settings are validated", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, performRequest(router, http.MethodPut, "/admin/chaos", marshalJSONBody(t, gin.H{}), userToken).Code)
+		assert.Equal(t, http.StatusBadRequest, setChaos(gin.H{"error_rate": 0.7, "drop_rate": 0.5}))
+		assert.Equal(t, http.StatusBadRequest, setChaos(gin.H{"latency_ms": -1}))
+		assert.Equal(t, http.StatusBadRequest, setChaos(gin.H{"routes": []string{"documents"}}))
+	})
+
+	t.Run("Errors and latency on selected routes", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, setChaos(gin.H{"routes": []string{"/v1/documents"}, "error_rate": 1, "latency_ms": 20}))
+
+		started := time.Now()
+		rr := performRequest(router, http.MethodGet, "/documents", nil, userToken)
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.GreaterOrEqual(t, time.Since(started), 20*time.Millisecond)
+		assert.Equal(t, http.StatusOK, performRequest(router, http.MethodGet, "/profiles/me", nil, userToken).Code, "other routes are unaffected")
+
+		rr = performRequest(router, http.MethodGet, "/admin/chaos", nil, adminToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var settings ChaosSettings
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &settings))
+		assert.True(t, settings.Enabled)
+		assert.Equal(t, []string{"/documents"}, settings.Routes)
+	})
+
+	t.Run("Dropped connections", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, setChaos(gin.H{"drop_rate": 1}))
+		server := httptest.NewServer(router)
+		defer server.Close()
+
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/profiles/me", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+userToken)
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		assert.Error(t, err, "the connection is closed without an answer")
+
+		assert.Equal(t, http.StatusBadGateway, performRequest(router, http.MethodGet, "/profiles/me", nil, userToken).Code, "without a connection to take over")
+	})
+
+	t.Run("Switching off", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, performRequest(router, http.MethodDelete, "/admin/chaos", nil, adminToken).Code)
+		assert.Equal(t, http.StatusOK, performRequest(router, http.MethodGet, "/profiles/me", nil, userToken).Code)
+	})
+
+	t.Run("Debug mode only", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, setChaos(gin.H{"error_rate": 1}))
+		cfg.GinMode = config.GinModeRelease
+		defer func() { cfg.GinMode = config.GinModeDebug }()
+		assert.Equal(t, http.StatusOK, performRequest(router, http.MethodGet, "/profiles/me", nil, userToken).Code)
+		assert.Equal(t, http.StatusNotFound, performRequest(router, http.MethodGet, "/admin/chaos", nil, adminToken).Code)
+	})
+}
@@ -0,0 +1,267 @@
+package api
+
+import (
+	"crypto/sha256"
+	"docserver/apperr"
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/models"
+	"docserver/utils"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Data Erasure ---
+
+// RequestErasureHandler schedules the erasure of all data belonging to the authenticated user.
+// @Summary      Request Erasure of Your Data
+// @Description  Schedules the permanent erasure of your account and everything tied to it: your profile, the documents you own, their share lists, your access to documents others shared with you, your favorites, your scheduled exports (and your documents in other users' export snapshots), and any pending password reset code.
+// @Description  When backups are enabled, the backup file is rewritten after the erasure so it no longer contains your data.
+// @Description
+// @Description  The erasure runs once the server's grace period (see `DOCSERVER_ERASURE_GRACE_PERIOD`) has elapsed. Until then you can cancel it with `DELETE /profiles/me/erase`.
+// @Description  A confirmation email is sent with the scheduled date. If the grace period is zero, the erasure runs immediately and the response contains the erasure report.
+// @Description  Requesting erasure again while one is pending returns the existing request unchanged.
+// @Tags         Profiles
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  utils.Envelope{data=models.ErasureRequest} "An erasure was already pending (returned unchanged), or the erasure was carried out immediately and the report is included."
+// @Success      202  {object}  utils.Envelope{data=models.ErasureRequest} "Erasure scheduled. 'scheduled_for' tells you when it will run."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: Your profile could not be found."
+// @Failure      500  {object}  utils.ErrorEnvelope "Internal Server Error: The erasure could not be scheduled or carried out."
+// @Router       /profiles/me/erase [post]
+func RequestErasureHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+	userIDStr := userID.(string)
+
+	request, created, err := database.ScheduleErasure(userIDStr, cfg.ErasureGracePeriod)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgProfileNotFound)
+		} else {
+			utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgErasureFailed, err)
+		}
+		return
+	}
+	if !created {
+		utils.RespondData(c, http.StatusOK, request)
+		return
+	}
+
+	email := request.Email
+	if cfg.ErasureGracePeriod == 0 {
+		if _, err := database.EraseProfile(userIDStr); err != nil {
+			utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgErasureFailed, err)
+			return
+		}
+		request, _ = database.GetErasureRequest(userIDStr)
+		utils.SendEmail(email, "Your data has been erased",
+			"All data associated with your account has been permanently erased.")
+		utils.RespondData(c, http.StatusOK, request)
+		return
+	}
+
+	utils.SendEmail(email, "Your data erasure request",
+		fmt.Sprintf("We received a request to erase all data associated with your account.\n"+
+			"The erasure will be carried out after %s.\n"+
+			"If you did not make this request, sign in and cancel it with DELETE /profiles/me/erase before then.",
+			request.ScheduledFor.Format(time.RFC1123)))
+	utils.RespondData(c, http.StatusAccepted, request)
+}
+
+// GetErasureStatusHandler returns the erasure request of the authenticated user.
+// @Summary      Check Your Erasure Request
+// @Description  Returns your pending erasure request, including when it is scheduled to run.
+// @Tags         Profiles
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  utils.Envelope{data=models.ErasureRequest} "Your erasure request."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No erasure has been requested."
+// @Router       /profiles/me/erase [get]
+func GetErasureStatusHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+
+	request, found := database.GetErasureRequest(userID.(string))
+	if !found {
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgErasureNotFound)
+		return
+	}
+	utils.RespondData(c, http.StatusOK, request)
+}
+
+// CancelErasureHandler cancels the pending erasure request of the authenticated user.
+// @Summary      Cancel Your Erasure Request
+// @Description  Cancels a pending erasure during its grace period. Your data is kept and nothing is erased.
+// @Tags         Profiles
+// @Security     BearerAuth
+// @Success      204  "Erasure cancelled."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: There is no pending erasure request to cancel."
+// @Router       /profiles/me/erase [delete]
+func CancelErasureHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+	userIDStr := userID.(string)
+
+	if err := database.CancelErasure(userIDStr); err != nil {
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgErasureNotPending)
+		return
+	}
+	log.Printf("INFO: Profile ID %s cancelled its erasure request", userIDStr)
+	c.Status(http.StatusNoContent)
+}
+
+// --- Data Export ---
+
+// exportFormat and exportFormatVersion identify the layout of ExportResponse for machine readers.
+const (
+	exportFormat        = "docserver-export"
+	exportFormatVersion = 1
+)
+
+// ExportSection describes one section of an export in the manifest.
+type ExportSection struct {
+	Name   string `json:"name"`   // Key of the section in the export body
+	Count  int    `json:"count"`  // Number of records in the section
+	SHA256 string `json:"sha256"` // Hex SHA-256 of the section's JSON encoding, for integrity checks
+}
+
+// ExportManifest describes the contents of a data export.
+type ExportManifest struct {
+	Format      string          `json:"format"`
+	Version     int             `json:"version"`
+	GeneratedAt time.Time       `json:"generated_at"` // UTC
+	ProfileID   string          `json:"profile_id"`
+	Sections    []ExportSection `json:"sections"`
+}
+
+// ExportShare lists who one of the user's documents is shared with.
+type ExportShare struct {
+	DocumentID string   `json:"document_id"`
+	SharedWith []string `json:"shared_with"`
+}
+
+// ExportResponse is a complete copy of the data held about a user.
+type ExportResponse struct {
+	Manifest     ExportManifest         `json:"manifest"`
+	Profile      ProfileResponse        `json:"profile"`
+	Documents    []models.Document      `json:"documents"`      // Documents owned by the user
+	Shares       []ExportShare          `json:"shares"`         // Share lists of the user's documents
+	SharedWithMe []string               `json:"shared_with_me"` // IDs of other users' documents shared with the user
+	Favorites    []string               `json:"favorites"`      // IDs of documents the user bookmarked
+	Erasure      *models.ErasureRequest `json:"erasure,omitempty"`
+}
+
+// newExportSection builds the manifest entry for a section.
+func newExportSection(name string, count int, value any) (ExportSection, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ExportSection{}, fmt.Errorf("failed to encode section '%s': %w", name, err)
+	}
+	sum := sha256.Sum256(data)
+	return ExportSection{Name: name, Count: count, SHA256: hex.EncodeToString(sum[:])}, nil
+}
+
+// ExportDataHandler returns a copy of all data held about the authenticated user.
+// @Summary      Export Your Data
+// @Description  Returns everything the server stores about you: your profile (including privacy settings), the documents you own, who each of them is shared with, the IDs of documents others shared with you, your favorites, and any erasure request.
+// @Description
+// @Description  The `manifest` describes the export for automated processing: the format name and version, when it was generated, and for each section its record count and the SHA-256 of the section's JSON encoding.
+// @Tags         Profiles
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  utils.Envelope{data=ExportResponse} "Your data export."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: Your profile could not be found."
+// @Failure      500  {object}  utils.ErrorEnvelope "Internal Server Error: The export could not be produced."
+// @Router       /profiles/me/export [get]
+func ExportDataHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+	userIDStr := userID.(string)
+
+	profile, found := database.GetProfileByID(userIDStr)
+	if !found {
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgProfileNotFound)
+		return
+	}
+
+	documents := database.GetDocumentsByOwner(userIDStr)
+	sort.Slice(documents, func(i, j int) bool { return documents[i].ID < documents[j].ID })
+
+	shares := []ExportShare{}
+	for _, doc := range documents {
+		if record, ok := database.GetShareRecordByDocumentID(doc.ID); ok && len(record.SharedWith) > 0 {
+			shares = append(shares, ExportShare{DocumentID: doc.ID, SharedWith: record.SharedWith})
+		}
+	}
+
+	export := ExportResponse{
+		Profile:      profileResponseFor(profile, userIDStr, nil),
+		Documents:    documents,
+		Shares:       shares,
+		SharedWithMe: database.GetDocumentIDsSharedWith(userIDStr),
+		Favorites:    database.GetFavoriteIDs(userIDStr),
+	}
+	if request, ok := database.GetErasureRequest(userIDStr); ok {
+		export.Erasure = &request
+	}
+
+	type sectionSource struct {
+		name  string
+		count int
+		value any
+	}
+	sections := []sectionSource{
+		{"profile", 1, export.Profile},
+		{"documents", len(export.Documents), export.Documents},
+		{"shares", len(export.Shares), export.Shares},
+		{"shared_with_me", len(export.SharedWithMe), export.SharedWithMe},
+		{"favorites", len(export.Favorites), export.Favorites},
+	}
+	if export.Erasure != nil {
+		sections = append(sections, sectionSource{"erasure", 1, export.Erasure})
+	}
+
+	export.Manifest = ExportManifest{
+		Format:      exportFormat,
+		Version:     exportFormatVersion,
+		GeneratedAt: cfg.Now().UTC(),
+		ProfileID:   userIDStr,
+		Sections:    make([]ExportSection, 0, len(sections)),
+	}
+	for _, s := range sections {
+		section, err := newExportSection(s.name, s.count, s.value)
+		if err != nil {
+			utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgExportFailed, err)
+			return
+		}
+		export.Manifest.Sections = append(export.Manifest.Sections, section)
+	}
+
+	utils.RespondData(c, http.StatusOK, export)
+}
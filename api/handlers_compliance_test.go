@@ -0,0 +1,136 @@
+package api
+
+import (
+	"crypto/sha256"
+	"docserver/models"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErasureEndpoints(t *testing.T) {
+	router, database, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	cfg.ErasureGracePeriod = time.Hour
+
+	userID, _, token := createTestUserAndLogin(t, router, "erase.me@example.com", "password123", "Erase", "Me")
+
+	t.Run("No request yet", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/profiles/me/erase", nil, token)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		rr = performRequest(router, http.MethodDelete, "/profiles/me/erase", nil, token)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	var scheduled models.ErasureRequest
+	t.Run("Schedule", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/profiles/me/erase", nil, token)
+		require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &scheduled))
+		assert.Equal(t, userID, scheduled.ProfileID)
+		assert.Equal(t, models.ErasureStatusPending, scheduled.Status)
+		assert.Equal(t, time.Hour, scheduled.ScheduledFor.Sub(scheduled.RequestedAt))
+	})
+
+	t.Run("Schedule again returns pending request", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/profiles/me/erase", nil, token)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var again models.ErasureRequest
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &again))
+		assert.True(t, scheduled.ScheduledFor.Equal(again.ScheduledFor))
+	})
+
+	t.Run("Status", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/v1/profiles/me/erase", nil, token)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp struct {
+			Data models.ErasureRequest `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, models.ErasureStatusPending, resp.Data.Status)
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		rr := performRequest(router, http.MethodDelete, "/profiles/me/erase", nil, token)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+
+		_, found := database.GetProfileByID(userID)
+		assert.True(t, found, "cancelling keeps the profile")
+	})
+
+	t.Run("Immediate erasure without grace period", func(t *testing.T) {
+		cfg.ErasureGracePeriod = 0
+		_, err := database.CreateDocument(models.Document{OwnerID: userID, Content: "bye"})
+		require.NoError(t, err)
+
+		rr := performRequest(router, http.MethodPost, "/profiles/me/erase", nil, token)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var request models.ErasureRequest
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &request))
+		assert.Equal(t, models.ErasureStatusCompleted, request.Status)
+		require.NotNil(t, request.Report)
+		assert.Equal(t, 1, request.Report.DocumentsDeleted)
+		assert.Empty(t, request.Email)
+
+		_, found := database.GetProfileByID(userID)
+		assert.False(t, found)
+	})
+}
+
+func TestExportEndpoint(t *testing.T) {
+	router, database, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	userID, _, token := createTestUserAndLogin(t, router, "export.me@example.com", "password123", "Export", "Me")
+	otherID, _, _ := createTestUserAndLogin(t, router, "export.other@example.com", "password123", "Other", "User")
+
+	myDoc, err := database.CreateDocument(models.Document{OwnerID: userID, Content: map[string]interface{}{"title": "mine"}})
+	require.NoError(t, err)
+	require.NoError(t, database.SetShareRecord(myDoc.ID, []string{otherID}))
+	theirDoc, err := database.CreateDocument(models.Document{OwnerID: otherID, Content: "theirs"})
+	require.NoError(t, err)
+	require.NoError(t, database.SetShareRecord(theirDoc.ID, []string{userID}))
+
+	rr := performRequest(router, http.MethodGet, "/v1/profiles/me/export", nil, token)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var resp struct {
+		Data struct {
+			Manifest     ExportManifest  `json:"manifest"`
+			Profile      json.RawMessage `json:"profile"`
+			Documents    json.RawMessage `json:"documents"`
+			Shares       []ExportShare   `json:"shares"`
+			SharedWithMe []string        `json:"shared_with_me"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	export := resp.Data
+
+	assert.Equal(t, "docserver-export", export.Manifest.Format)
+	assert.Equal(t, 1, export.Manifest.Version)
+	assert.Equal(t, userID, export.Manifest.ProfileID)
+	assert.Contains(t, string(export.Profile), "export.me@example.com")
+	assert.NotContains(t, string(export.Profile), "password")
+	assert.Equal(t, []ExportShare{{DocumentID: myDoc.ID, SharedWith: []string{otherID}}}, export.Shares)
+	assert.Equal(t, []string{theirDoc.ID}, export.SharedWithMe)
+
+	sections := map[string]ExportSection{}
+	for _, s := range export.Manifest.Sections {
+		sections[s.Name] = s
+	}
+	require.Contains(t, sections, "documents")
+	assert.Equal(t, 1, sections["documents"].Count)
+	assert.Equal(t, 1, sections["shares"].Count)
+	assert.Equal(t, 1, sections["shared_with_me"].Count)
+	assert.NotContains(t, sections, "erasure", "no erasure requested")
+
+	// The checksum matches the section as served
+	sum := sha256.Sum256(export.Documents)
+	assert.Equal(t, hex.EncodeToString(sum[:]), sections["documents"].SHA256)
+}
@@ -0,0 +1,354 @@
+package api
+
+import (
+	"docserver/apperr"
+	"docserver/authz"
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/models"
+	"docserver/utils"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Content Path Updates ---
+
+// ContentPathResponse reports the value at a content path after it was changed.
+type ContentPathResponse struct {
+	DocumentID string `json:"document_id"`
+	Path       string `json:"path"`     // The path changed; an appending index is replaced by the new item's
+	Value      any    `json:"value"`    // Value at the path now; null once removed
+	Previous   any    `json:"previous"` // Value at the path before; null if there was none
+	Version    int    `json:"version"`  // The document's new version, to pass as ?version= with the next change
+}
+
+// contentPathTarget reads the document, content path and precondition (?version=, ?revision= and
+// If-Content-Matches) of a content path request and checks the user may change that path, writing
+// the error response if not. Besides the owner, sharers with write access to part of the content
+// may change paths within that part.
+func contentPathTarget(c *gin.Context, database *db.Database, cfg *config.Config) (models.Document, []string, db.Precondition, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return models.Document{}, nil, db.Precondition{}, false
+	}
+	docID := c.Param("id")
+
+	path, err := db.ParseContentPath(c.Param("path"))
+	if err != nil {
+		utils.GinErrorFromErr(c, http.StatusBadRequest, err)
+		return models.Document{}, nil, db.Precondition{}, false
+	}
+	version, ok := parseVersionQuery(c, "version", 0)
+	if !ok {
+		return models.Document{}, nil, db.Precondition{}, false
+	}
+	revision := 0
+	if value, present := c.GetQuery("revision"); present {
+		if revision, err = strconv.Atoi(value); err != nil || revision < 0 {
+			utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgInvalidRevision)
+			return models.Document{}, nil, db.Precondition{}, false
+		}
+	}
+	matches, ok := contentCondition(c)
+	if !ok {
+		return models.Document{}, nil, db.Precondition{}, false
+	}
+
+	doc, found := database.GetDocumentByID(docID)
+	if !found {
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgDocumentNotFound, docID)
+		return models.Document{}, nil, db.Precondition{}, false
+	}
+	allowed := can(c, database, cfg, authz.UpdateDocument, doc)
+	if !allowed && can(c, database, cfg, authz.UpdateScopedContent, doc) {
+		scope, _ := documentScope(database, doc, userID.(string))
+		scopePath := strings.Split(scope.Path, ".")
+		allowed = len(path) >= len(scopePath) && slices.Equal(path[:len(scopePath)], scopePath)
+	}
+	if !allowed {
+		utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgDocumentUpdateDenied)
+		return models.Document{}, nil, db.Precondition{}, false
+	}
+	return doc, path, db.Precondition{Version: version, Revision: revision, Matches: matches}, true
+}
+
+// respondContentPathChange writes the response for a content path change, or its error.
+func respondContentPathChange(c *gin.Context, docID string, change db.ContentPathChange, err error) {
+	if err != nil {
+		if respondScriptError(c, err) || respondFrozen(c, err) {
+			return
+		}
+		if status := apperr.HTTPStatus(err); status != http.StatusInternalServerError {
+			utils.GinErrorFromErr(c, status, err)
+		} else {
+			utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgDocumentUpdateFailed, err)
+		}
+		return
+	}
+	utils.RespondData(c, http.StatusOK, ContentPathResponse{
+		DocumentID: docID,
+		Path:       db.FormatContentPath(change.Path),
+		Value:      change.Value,
+		Previous:   change.Previous,
+		Version:    change.Document.Version,
+	})
+}
+
+// SetContentPathHandler sets one value inside a document's content.
+// @Summary      Set a Value in a Document
+// @Description  Sets the value at a content path, given in dot notation (e.g. `chapters.2.title`, where numeric parts index arrays), to the JSON value in the request body, keeping the rest of the content. Missing values on the way are created, as arrays for numeric parts and objects otherwise; an array index equal to the array's length, or `-1`, appends to it.
+// @Description
+// @Description  Each change is stored as a new version. Pass the `version` you last saw to make sure nobody changed the document since: if it has moved on, the change is refused with `409 Conflict`. The response gives the new version for the next change.
+// @Description  The owner can change any path; sharers with write access to part of the content can change paths within that part.
+// @Tags         Documents
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string  true   "The unique identifier of the document."
+// @Param        path     path      string  true   "Content path in gjson syntax, as in content_query." example(chapters.2.title)
+// @Param        version  query     int     false  "Only change the document if it is still at this version." minimum(1)
+// @Param        revision query     int     false  "With version: the revision of that version, if it was coalesced." minimum(0)
+// @Param        If-Content-Matches  header  string  false  "Only change the document if its content matches this content query (see `If-Content-Matches` on `PUT /documents/{id}`)."
+// @Param        value    body      object  true   "The JSON value to store at the path."
+// @Success      200  {object}  utils.Envelope{data=ContentPathResponse} "The new value and the document's new version."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The path or body is invalid, or the path goes through a value that is neither an object nor an array."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You cannot change this document, or this part of it."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No document exists with the specified ID."
+// @Failure      409  {object}  utils.ErrorEnvelope "Conflict: The document is no longer at the given version."
+// @Failure      412  {object}  utils.ErrorEnvelope "Precondition Failed: The document does not match the If-Content-Matches condition."
+// @Failure      423  {object}  utils.ErrorEnvelope "Locked: The document is frozen."
+// @Router       /documents/{id}/content/{path} [put]
+func SetContentPathHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	doc, path, pre, ok := contentPathTarget(c, database, cfg)
+	if !ok {
+		return
+	}
+	var value any
+	if !utils.BindJSON(c, cfg, &value, i18n.MsgInvalidDocumentBody) {
+		return
+	}
+
+	change, err := database.SetDocumentContentPath(doc.ID, c.GetString("userID"), path, value, pre)
+	respondContentPathChange(c, doc.ID, change, err)
+}
+
+// DeleteContentPathHandler removes one value from a document's content.
+// @Summary      Remove a Value from a Document
+// @Description  Removes the value at a content path (see `PUT /documents/{id}/content/{path}`), keeping the rest of the content. Removing an array item moves the later items down.
+// @Description  Like setting a value, this creates a new version and can be made conditional on the current `version`.
+// @Tags         Documents
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string  true   "The unique identifier of the document."
+// @Param        path     path      string  true   "Content path in gjson syntax, as in content_query." example(chapters.2)
+// @Param        version  query     int     false  "Only change the document if it is still at this version." minimum(1)
+// @Param        revision query     int     false  "With version: the revision of that version, if it was coalesced." minimum(0)
+// @Param        If-Content-Matches  header  string  false  "Only change the document if its content matches this content query (see `If-Content-Matches` on `PUT /documents/{id}`)."
+// @Success      200  {object}  utils.Envelope{data=ContentPathResponse} "The removed value and the document's new version."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The path is invalid."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You cannot change this document, or this part of it."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No document exists with the specified ID, or it has no value at the path."
+// @Failure      409  {object}  utils.ErrorEnvelope "Conflict: The document is no longer at the given version."
+// @Failure      412  {object}  utils.ErrorEnvelope "Precondition Failed: The document does not match the If-Content-Matches condition."
+// @Failure      423  {object}  utils.ErrorEnvelope "Locked: The document is frozen."
+// @Router       /documents/{id}/content/{path} [delete]
+func DeleteContentPathHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	doc, path, pre, ok := contentPathTarget(c, database, cfg)
+	if !ok {
+		return
+	}
+
+	change, err := database.DeleteDocumentContentPath(doc.ID, c.GetString("userID"), path, pre)
+	respondContentPathChange(c, doc.ID, change, err)
+}
+
+// contentCondition parses the If-Content-Matches headers of a conditional update, each holding one
+// part of a content query as in GET /documents (a condition, or "and"/"or" between two), writing a
+// 400 response and returning false if they are not a valid query. It returns nil without headers.
+func contentCondition(c *gin.Context) (*db.ParsedQuery, bool) {
+	parts := c.Request.Header.Values("If-Content-Matches")
+	if len(parts) == 0 {
+		return nil, true
+	}
+	query, err := db.ParseContentQuery(parts)
+	if err != nil {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgContentConditionInvalid, err)
+		return nil, false
+	}
+	return query, true
+}
+
+// parseArrayIndexQuery reads the required 'index' query parameter of an array operation, writing
+// a 400 response and returning false if it is missing or below -1.
+func parseArrayIndexQuery(c *gin.Context) (int, bool) {
+	value := c.Query("index")
+	index, err := strconv.Atoi(value)
+	if err != nil || index < -1 {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgArrayIndexInvalid, value)
+		return 0, false
+	}
+	return index, true
+}
+
+// AppendContentArrayHandler adds an item to the end of an array inside a document's content.
+// @Summary      Append to an Array in a Document
+// @Description  Appends the JSON value in the request body to the array at a content path (see `PUT /documents/{id}/content/{path}`), for lists such as todo items or roster entries, without sending the whole document. If there is no value at the path yet, a new array is started. The response's `value` is the updated array.
+// @Description  Like other content path changes, this creates a new version and can be made conditional on the current `version`.
+// @Tags         Documents
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string  true   "The unique identifier of the document."
+// @Param        path     path      string  true   "Path of the array in gjson syntax." example(todos)
+// @Param        version  query     int     false  "Only change the document if it is still at this version." minimum(1)
+// @Param        revision query     int     false  "With version: the revision of that version, if it was coalesced." minimum(0)
+// @Param        If-Content-Matches  header  string  false  "Only change the document if its content matches this content query (see `If-Content-Matches` on `PUT /documents/{id}`)."
+// @Param        item     body      object  true   "The JSON value to append."
+// @Success      200  {object}  utils.Envelope{data=ContentPathResponse} "The updated array and the document's new version."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The path or body is invalid, or the value at the path is not an array."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You cannot change this document, or this part of it."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No document exists with the specified ID."
+// @Failure      409  {object}  utils.ErrorEnvelope "Conflict: The document is no longer at the given version."
+// @Failure      412  {object}  utils.ErrorEnvelope "Precondition Failed: The document does not match the If-Content-Matches condition."
+// @Failure      423  {object}  utils.ErrorEnvelope "Locked: The document is frozen."
+// @Router       /documents/{id}/content/{path}/append [post]
+func AppendContentArrayHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	insertIntoContentArray(c, database, cfg, -1)
+}
+
+// InsertContentArrayHandler inserts an item into an array inside a document's content.
+// @Summary      Insert into an Array in a Document
+// @Description  Inserts the JSON value in the request body into the array at a content path, before the item at `index` (0 for the front, the array's length or -1 for the end), moving the later items up. If there is no value at the path yet, a new array is started. The response's `value` is the updated array.
+// @Tags         Documents
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string  true   "The unique identifier of the document."
+// @Param        path     path      string  true   "Path of the array in gjson syntax." example(todos)
+// @Param        index    query     int     true   "Position the item gets; -1 appends." minimum(-1)
+// @Param        version  query     int     false  "Only change the document if it is still at this version." minimum(1)
+// @Param        revision query     int     false  "With version: the revision of that version, if it was coalesced." minimum(0)
+// @Param        If-Content-Matches  header  string  false  "Only change the document if its content matches this content query (see `If-Content-Matches` on `PUT /documents/{id}`)."
+// @Param        item     body      object  true   "The JSON value to insert."
+// @Success      200  {object}  utils.Envelope{data=ContentPathResponse} "The updated array and the document's new version."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The path, index or body is invalid, the index is out of range, or the value at the path is not an array."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You cannot change this document, or this part of it."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No document exists with the specified ID."
+// @Failure      409  {object}  utils.ErrorEnvelope "Conflict: The document is no longer at the given version."
+// @Failure      412  {object}  utils.ErrorEnvelope "Precondition Failed: The document does not match the If-Content-Matches condition."
+// @Failure      423  {object}  utils.ErrorEnvelope "Locked: The document is frozen."
+// @Router       /documents/{id}/content/{path}/insert [post]
+func InsertContentArrayHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	index, ok := parseArrayIndexQuery(c)
+	if !ok {
+		return
+	}
+	insertIntoContentArray(c, database, cfg, index)
+}
+
+// insertIntoContentArray inserts the item in the request body at index (-1 appends).
+func insertIntoContentArray(c *gin.Context, database *db.Database, cfg *config.Config, index int) {
+	doc, path, pre, ok := contentPathTarget(c, database, cfg)
+	if !ok {
+		return
+	}
+	var item any
+	if !utils.BindJSON(c, cfg, &item, i18n.MsgInvalidDocumentBody) {
+		return
+	}
+
+	change, err := database.InsertIntoDocumentArray(doc.ID, c.GetString("userID"), path, index, item, pre)
+	respondContentPathChange(c, doc.ID, change, err)
+}
+
+// RemoveContentArrayHandler removes an item from an array inside a document's content.
+// @Summary      Remove from an Array in a Document
+// @Description  Removes the item at `index` (-1 for the last one) from the array at a content path, moving the later items down. The response's `value` is the updated array and `previous` the array before.
+// @Tags         Documents
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string  true   "The unique identifier of the document."
+// @Param        path     path      string  true   "Path of the array in gjson syntax." example(todos)
+// @Param        index    query     int     true   "Position of the item to remove; -1 removes the last one." minimum(-1)
+// @Param        version  query     int     false  "Only change the document if it is still at this version." minimum(1)
+// @Param        revision query     int     false  "With version: the revision of that version, if it was coalesced." minimum(0)
+// @Param        If-Content-Matches  header  string  false  "Only change the document if its content matches this content query (see `If-Content-Matches` on `PUT /documents/{id}`)."
+// @Success      200  {object}  utils.Envelope{data=ContentPathResponse} "The updated array and the document's new version."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The path or index is invalid, the index is out of range, or the value at the path is not an array."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You cannot change this document, or this part of it."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No document exists with the specified ID, or it has no value at the path."
+// @Failure      409  {object}  utils.ErrorEnvelope "Conflict: The document is no longer at the given version."
+// @Failure      412  {object}  utils.ErrorEnvelope "Precondition Failed: The document does not match the If-Content-Matches condition."
+// @Failure      423  {object}  utils.ErrorEnvelope "Locked: The document is frozen."
+// @Router       /documents/{id}/content/{path}/remove [post]
+func RemoveContentArrayHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	index, ok := parseArrayIndexQuery(c)
+	if !ok {
+		return
+	}
+	doc, path, pre, ok := contentPathTarget(c, database, cfg)
+	if !ok {
+		return
+	}
+
+	change, err := database.RemoveFromDocumentArray(doc.ID, c.GetString("userID"), path, index, pre)
+	respondContentPathChange(c, doc.ID, change, err)
+}
+
+// IncrementRequest defines the optional body for incrementing a number in a document.
+type IncrementRequest struct {
+	Delta *float64 `json:"delta"` // Amount to add, which may be negative or fractional; 1 if not given
+}
+
+// IncrementContentNumberHandler adds to a number inside a document's content.
+// @Summary      Increment a Number in a Document
+// @Description  Adds `delta` (1 unless given; negative to decrement) to the number at a content path (see `PUT /documents/{id}/content/{path}`) and returns the new number as `value`. A missing value counts as 0.
+// @Description  The number is read and updated in one step, so counters such as votes or attempts stay right when many clients increment them at once, which a `GET` followed by a `PUT` cannot guarantee.
+// @Description  Like other content path changes, this creates a new version and can be made conditional on the current `version`.
+// @Tags         Documents
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id         path      string            true   "The unique identifier of the document."
+// @Param        path       path      string            true   "Path of the number in gjson syntax." example(stats.views)
+// @Param        version    query     int               false  "Only change the document if it is still at this version." minimum(1)
+// @Param        revision   query     int               false  "With version: the revision of that version, if it was coalesced." minimum(0)
+// @Param        If-Content-Matches  header  string  false  "Only change the document if its content matches this content query (see `If-Content-Matches` on `PUT /documents/{id}`)."
+// @Param        increment  body      IncrementRequest  false  "The amount to add."
+// @Success      200  {object}  utils.Envelope{data=ContentPathResponse} "The new number and the document's new version."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The path or body is invalid, the value at the path is not a number, or the result would be too large."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You cannot change this document, or this part of it."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No document exists with the specified ID."
+// @Failure      409  {object}  utils.ErrorEnvelope "Conflict: The document is no longer at the given version."
+// @Failure      412  {object}  utils.ErrorEnvelope "Precondition Failed: The document does not match the If-Content-Matches condition."
+// @Failure      423  {object}  utils.ErrorEnvelope "Locked: The document is frozen."
+// @Router       /documents/{id}/content/{path}/increment [post]
+func IncrementContentNumberHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	doc, path, pre, ok := contentPathTarget(c, database, cfg)
+	if !ok {
+		return
+	}
+	var req IncrementRequest
+	if c.Request.ContentLength != 0 && !utils.BindJSON(c, cfg, &req, i18n.MsgIncrementInvalid) {
+		return
+	}
+	delta := 1.0
+	if req.Delta != nil {
+		delta = *req.Delta
+	}
+
+	change, err := database.IncrementDocumentNumber(doc.ID, c.GetString("userID"), path, delta, pre)
+	respondContentPathChange(c, doc.ID, change, err)
+}
@@ -0,0 +1,274 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"docserver/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentPaths(t *testing.T) {
+	router, database, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	ownerID, _, ownerToken := createTestUserAndLogin(t, router, "paths.owner@example.com", "password123", "Paths", "Owner")
+	editorID, _, editorToken := createTestUserAndLogin(t, router, "paths.editor@example.com", "password123", "Paths", "Editor")
+	readerID, _, readerToken := createTestUserAndLogin(t, router, "paths.reader@example.com", "password123", "Paths", "Reader")
+
+	doc, err := database.CreateDocument(models.Document{OwnerID: ownerID, Content: map[string]any{
+		"title": "Roster", "students": []any{"Ann", "Bo"}, "notes": map[string]any{"public": "hi"},
+	}})
+	require.NoError(t, err)
+	require.NoError(t, database.SetShareRecord(doc.ID, []string{editorID, readerID}))
+	_, err = database.SetShareScope(doc.ID, editorID, &models.ShareScope{Path: "notes", Write: true})
+	require.NoError(t, err)
+
+	change := func(t *testing.T, method, path string, body any, token string) (int, ContentPathResponse) {
+		t.Helper()
+		var reqBody io.Reader
+		if body != nil {
+			reqBody = marshalJSONBody(t, body)
+		}
+		rr := performRequest(router, method, "/documents/"+doc.ID+"/content/"+path, reqBody, token)
+		var resp ContentPathResponse
+		if rr.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		}
+		return rr.Code, resp
+	}
+
+	t.Run("Set a value", func(t *testing.T) {
+		code, resp := change(t, http.MethodPut, "title?version=1", "Class roster", ownerToken)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, ContentPathResponse{DocumentID: doc.ID, Path: "title", Value: "Class roster", Previous: "Roster", Version: 2}, resp)
+
+		code, resp = change(t, http.MethodPut, "students.-1", gin.H{"name": "Cy"}, ownerToken)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, map[string]any{"name": "Cy"}, resp.Value)
+		assert.Equal(t, "students.2", resp.Path, "the index the item was appended at")
+		assert.Equal(t, 3, resp.Version)
+
+		stored, _ := database.GetDocumentByID(doc.ID)
+		assert.Equal(t, map[string]any{
+			"title": "Class roster", "students": []any{"Ann", "Bo", map[string]any{"name": "Cy"}}, "notes": map[string]any{"public": "hi"},
+		}, stored.Content)
+	})
+
+	t.Run("Stale version", func(t *testing.T) {
+		code, _ := change(t, http.MethodPut, "title?version=1", "Lost update", ownerToken)
+		assert.Equal(t, http.StatusConflict, code)
+		code, _ = change(t, http.MethodDelete, "title?version=1", nil, ownerToken)
+		assert.Equal(t, http.StatusConflict, code)
+	})
+
+	t.Run("Remove a value", func(t *testing.T) {
+		code, resp := change(t, http.MethodDelete, "students.0?version=3", nil, ownerToken)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "Ann", resp.Previous)
+		assert.Nil(t, resp.Value)
+		assert.Equal(t, 4, resp.Version)
+
+		code, _ = change(t, http.MethodDelete, "missing", nil, ownerToken)
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+
+	t.Run("Invalid requests", func(t *testing.T) {
+		code, _ := change(t, http.MethodPut, "a..b", 1, ownerToken)
+		assert.Equal(t, http.StatusBadRequest, code)
+		code, _ = change(t, http.MethodPut, "title.x", 1, ownerToken)
+		assert.Equal(t, http.StatusBadRequest, code, "a string has no keys")
+		code, _ = change(t, http.MethodPut, "title", nil, ownerToken)
+		assert.Equal(t, http.StatusBadRequest, code, "a value is required")
+		code, _ = change(t, http.MethodPut, "title?version=x", 1, ownerToken)
+		assert.Equal(t, http.StatusBadRequest, code)
+		rr := performRequest(router, http.MethodPut, "/documents/doc_missing/content/title", strings.NewReader(`1`), ownerToken)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Sharers", func(t *testing.T) {
+		code, _ := change(t, http.MethodPut, "title", "Mine", readerToken)
+		assert.Equal(t, http.StatusForbidden, code)
+		code, _ = change(t, http.MethodPut, "title", "Mine", editorToken)
+		assert.Equal(t, http.StatusForbidden, code, "outside the editor's scope")
+		code, resp := change(t, http.MethodPut, "notes.public", "hello", editorToken)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "hello", resp.Value)
+		events := database.GetDocumentEvents(doc.ID)
+		assert.Equal(t, editorID, events[0].ActorID)
+	})
+}
+
+func TestContentArrays(t *testing.T) {
+	router, database, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	ownerID, _, ownerToken := createTestUserAndLogin(t, router, "arrays.owner@example.com", "password123", "Arrays", "Owner")
+	readerID, _, readerToken := createTestUserAndLogin(t, router, "arrays.reader@example.com", "password123", "Arrays", "Reader")
+	doc, err := database.CreateDocument(models.Document{OwnerID: ownerID, Content: map[string]any{"todos": []any{"write"}, "title": "Todo"}})
+	require.NoError(t, err)
+	require.NoError(t, database.SetShareRecord(doc.ID, []string{readerID}))
+
+	op := func(t *testing.T, action string, body any, token string) (int, ContentPathResponse) {
+		t.Helper()
+		var reqBody io.Reader
+		if body != nil {
+			reqBody = marshalJSONBody(t, body)
+		}
+		rr := performRequest(router, http.MethodPost, "/documents/"+doc.ID+"/content/"+action, reqBody, token)
+		var resp ContentPathResponse
+		if rr.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		}
+		return rr.Code, resp
+	}
+
+	code, resp := op(t, "todos/append", "review", ownerToken)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []any{"write", "review"}, resp.Value)
+	assert.Equal(t, 2, resp.Version)
+
+	code, resp = op(t, "todos/insert?index=0&version=2", gin.H{"task": "plan"}, ownerToken)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []any{map[string]any{"task": "plan"}, "write", "review"}, resp.Value)
+
+	code, resp = op(t, "todos/remove?index=1", nil, ownerToken)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []any{map[string]any{"task": "plan"}, "review"}, resp.Value)
+	assert.Equal(t, 4, resp.Version)
+
+	code, resp = op(t, "done/append", 1, ownerToken)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []any{1.0}, resp.Value, "a missing array is started")
+
+	t.Run("Invalid requests", func(t *testing.T) {
+		code, _ := op(t, "todos/insert", "x", ownerToken)
+		assert.Equal(t, http.StatusBadRequest, code, "index is required")
+		code, _ = op(t, "todos/remove?index=-2", nil, ownerToken)
+		assert.Equal(t, http.StatusBadRequest, code)
+		code, _ = op(t, "todos/remove?index=9", nil, ownerToken)
+		assert.Equal(t, http.StatusBadRequest, code, "out of range")
+		code, _ = op(t, "title/append", "x", ownerToken)
+		assert.Equal(t, http.StatusBadRequest, code, "not an array")
+		code, _ = op(t, "todos/append", nil, ownerToken)
+		assert.Equal(t, http.StatusBadRequest, code, "an item is required")
+		code, _ = op(t, "missing/remove?index=0", nil, ownerToken)
+		assert.Equal(t, http.StatusNotFound, code)
+		code, _ = op(t, "todos/append?version=1", "x", ownerToken)
+		assert.Equal(t, http.StatusConflict, code)
+		code, _ = op(t, "todos/append", "x", readerToken)
+		assert.Equal(t, http.StatusForbidden, code)
+	})
+}
+
+func TestIncrementContentNumber(t *testing.T) {
+	router, database, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	ownerID, _, ownerToken := createTestUserAndLogin(t, router, "increment.owner@example.com", "password123", "Increment", "Owner")
+	doc, err := database.CreateDocument(models.Document{OwnerID: ownerID, Content: map[string]any{"votes": 10.0, "title": "Poll"}})
+	require.NoError(t, err)
+
+	increment := func(t *testing.T, path string, body any) (int, ContentPathResponse) {
+		t.Helper()
+		var reqBody io.Reader
+		if body != nil {
+			reqBody = marshalJSONBody(t, body)
+		}
+		rr := performRequest(router, http.MethodPost, "/documents/"+doc.ID+"/content/"+path, reqBody, ownerToken)
+		var resp ContentPathResponse
+		if rr.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		}
+		return rr.Code, resp
+	}
+
+	code, resp := increment(t, "votes/increment", nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 11.0, resp.Value, "the delta defaults to 1")
+	assert.Equal(t, 10.0, resp.Previous)
+
+	code, resp = increment(t, "votes/increment?version=2", gin.H{"delta": -2.5})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 8.5, resp.Value)
+	assert.Equal(t, 3, resp.Version)
+
+	code, resp = increment(t, "stats.views/increment", gin.H{"delta": 5})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 5.0, resp.Value)
+
+	code, _ = increment(t, "title/increment", nil)
+	assert.Equal(t, http.StatusBadRequest, code, "not a number")
+	code, _ = increment(t, "votes/increment", gin.H{"delta": "1"})
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = increment(t, "votes/increment?version=1", nil)
+	assert.Equal(t, http.StatusConflict, code)
+}
+
+func TestConditionalUpdates(t *testing.T) {
+	router, database, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	ownerID, _, token := createTestUserAndLogin(t, router, "conditions.owner@example.com", "password123", "Conditions", "Owner")
+	doc, err := database.CreateDocument(models.Document{OwnerID: ownerID, Content: map[string]any{"status": "draft", "seats": 1.0}})
+	require.NoError(t, err)
+
+	conditional := func(method, path string, body any, conditions ...string) *httptest.ResponseRecorder {
+		var reqBody io.Reader
+		if body != nil {
+			reqBody = marshalJSONBody(t, body)
+		}
+		req, err := http.NewRequest(method, path, reqBody)
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		for _, condition := range conditions {
+			req.Header.Add("If-Content-Matches", condition)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Update applies when the content matches", func(t *testing.T) {
+		rr := conditional(http.MethodPut, "/documents/"+doc.ID, gin.H{"content": gin.H{"status": "submitted", "seats": 1}},
+			`status equals "draft"`, "and", "seats greaterThan 0")
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	})
+
+	t.Run("Update fails when it no longer matches", func(t *testing.T) {
+		rr := conditional(http.MethodPut, "/documents/"+doc.ID, gin.H{"content": gin.H{"status": "lost"}}, `status equals "draft"`)
+		require.Equal(t, http.StatusPreconditionFailed, rr.Code, rr.Body.String())
+		stored, _ := database.GetDocumentByID(doc.ID)
+		assert.Equal(t, "submitted", stored.Content.(map[string]any)["status"])
+		assert.Equal(t, 2, stored.Version)
+	})
+
+	t.Run("Invalid condition", func(t *testing.T) {
+		rr := conditional(http.MethodPut, "/documents/"+doc.ID, gin.H{"content": gin.H{}}, "status resembles draft")
+		assert.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+	})
+
+	t.Run("Upsert of a missing document fails the condition", func(t *testing.T) {
+		rr := conditional(http.MethodPut, "/documents/missing-doc?upsert=true", gin.H{"content": gin.H{}}, `status equals "draft"`)
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code, rr.Body.String())
+		_, found := database.GetDocumentByID("missing-doc")
+		assert.False(t, found)
+	})
+
+	t.Run("Content path operations take conditions", func(t *testing.T) {
+		rr := conditional(http.MethodPost, "/documents/"+doc.ID+"/content/seats/increment", gin.H{"delta": -1}, "seats greaterThan 0")
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		rr = conditional(http.MethodPost, "/documents/"+doc.ID+"/content/seats/increment", gin.H{"delta": -1}, "seats greaterThan 0")
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code, rr.Body.String())
+		stored, _ := database.GetDocumentByID(doc.ID)
+		assert.Equal(t, 0.0, stored.Content.(map[string]any)["seats"])
+	})
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Teacher Dashboard ---
+
+// GetDashboardHandler returns an overview of the server for instructors.
+// @Summary      Get the Dashboard (Admin)
+// @Description  Combines in one response what an instructor-facing UI would otherwise gather from many calls:
+// @Description  *   `users`: For every user, how many documents they own (and of those, how many are public or shared), the size of their content, their API requests and their latest change to any document. Most documents first.
+// @Description  *   `recent_activity`: The latest `limit` activity entries across all documents, newest first, each with its `document_id` (see `GET /documents/{id}/activity`).
+// @Description  *   `storage`: How many profiles, documents and content versions the server holds, and the size of their content.
+// @Description  *   `queries`: How many document lists and searches ran since the server started (and of those, how many filtered on content), the slow queries logged, and the API requests and errors in the API usage reports.
+// @Description
+// @Description  Sizes are of the JSON-encoded content, in bytes. Everything is read at once, so the numbers agree with each other. Administrators only.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        limit  query     int  false  "Number of recent activity entries." minimum(1) maximum(100) default(20)
+// @Success      200  {object}  utils.Envelope{data=db.Dashboard} "The dashboard."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: 'limit' is not a positive integer."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Router       /admin/dashboard [get]
+func GetDashboardHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultActivityLimit)))
+	if err != nil || limit < 1 {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgInvalidPagination)
+		return
+	}
+	if limit > maxActivityLimit {
+		limit = maxActivityLimit
+	}
+
+	utils.RespondData(c, http.StatusOK, database.GetDashboard(limit))
+}
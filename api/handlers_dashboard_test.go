@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"docserver/db"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDashboard(t *testing.T) {
+	router, _, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	cfg.AdminEmails = []string{"dash.admin@example.com"}
+
+	_, _, adminToken := createTestUserAndLogin(t, router, "dash.admin@example.com", "password123", "Dash", "Admin")
+	studentID, _, studentToken := createTestUserAndLogin(t, router, "dash.student@example.com", "password123", "Dash", "Student")
+	for i := 0; i < 2; i++ {
+		rr := performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, map[string]any{"content": map[string]any{"n": i}, "public": i == 0}), studentToken)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	rr := performRequest(router, http.MethodGet, "/documents?content_query="+url.QueryEscape("n equals 1"), nil, studentToken)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	rr = performRequest(router, http.MethodGet, "/documents", nil, studentToken)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	rr = performRequest(router, http.MethodGet, "/admin/dashboard", nil, studentToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = performRequest(router, http.MethodGet, "/admin/dashboard?limit=0", nil, adminToken)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = performRequest(router, http.MethodGet, "/admin/dashboard?limit=1", nil, adminToken)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var dashboard db.Dashboard
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &dashboard))
+
+	require.Len(t, dashboard.Users, 2)
+	student := dashboard.Users[0]
+	assert.Equal(t, studentID, student.ProfileID, "most documents first")
+	assert.Equal(t, 2, student.Documents)
+	assert.Equal(t, 1, student.PublicDocuments)
+	assert.Positive(t, student.ContentBytes)
+	assert.NotNil(t, student.LastActivityAt)
+	assert.Equal(t, 0, dashboard.Users[1].Documents)
+
+	require.Len(t, dashboard.RecentActivity, 1)
+	assert.Equal(t, "created", dashboard.RecentActivity[0].Type)
+	assert.Equal(t, studentID, dashboard.RecentActivity[0].ActorID)
+
+	assert.Equal(t, 2, dashboard.Storage.Profiles)
+	assert.Equal(t, 2, dashboard.Storage.Documents)
+	assert.Equal(t, student.ContentBytes, dashboard.Storage.ContentBytes)
+
+	assert.Equal(t, int64(2), dashboard.Queries.DocumentQueries)
+	assert.Equal(t, int64(1), dashboard.Queries.ContentQueries)
+	assert.Positive(t, dashboard.Queries.APIRequests)
+}
@@ -0,0 +1,206 @@
+package api
+
+import (
+	"docserver/apperr"
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/models"
+	"docserver/utils"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Account Deactivation ---
+
+// RequireActiveMiddleware rejects requests from users whose account is deactivated with
+// 403 Forbidden, so tokens issued before the deactivation stop working. Tokens issued before the
+// user's last password change, or to a device the user revoked, are rejected with 401 Unauthorized.
+// Must run after utils.AuthMiddleware.
+func RequireActiveMiddleware(database *db.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userID")
+		if !exists {
+			utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+			return
+		}
+		profile, found := database.GetProfileByID(userID.(string))
+		if found && profile.Deactivation != nil {
+			utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgAccountDeactivated, profile.Deactivation.PurgeAt.Format(time.RFC3339))
+			return
+		}
+		if found && profile.TokensNotBefore != nil {
+			if issuedAt, ok := c.Get("tokenIssuedAt"); !ok || issuedAt.(time.Time).Before(*profile.TokensNotBefore) {
+				utils.GinLocalizedError(c, http.StatusUnauthorized, i18n.MsgTokenRevoked)
+				return
+			}
+		}
+		if deviceID, ok := c.Get("deviceID"); ok && found && !database.DeviceTrusted(profile.ID, deviceID.(string)) {
+			utils.GinLocalizedError(c, http.StatusUnauthorized, i18n.MsgDeviceRevoked)
+			return
+		}
+		c.Next()
+	}
+}
+
+// DeactivateRequest defines the optional JSON body for deactivating your account.
+type DeactivateRequest struct {
+	ReactivateAt *time.Time `json:"reactivate_at,omitempty"` // Reactivate automatically at this time (RFC 3339); must be before the purge date
+}
+
+// DeactivatedProfile describes a deactivated account to administrators.
+type DeactivatedProfile struct {
+	ID           string               `json:"id"`
+	FirstName    string               `json:"first_name"`
+	LastName     string               `json:"last_name"`
+	Email        string               `json:"email"`
+	Deactivation *models.Deactivation `json:"deactivation,omitempty"` // Empty once reactivated
+}
+
+// deactivatedProfileFor builds the administrator view of a profile.
+func deactivatedProfileFor(profile models.Profile) DeactivatedProfile {
+	return DeactivatedProfile{
+		ID:           profile.ID,
+		FirstName:    profile.FirstName,
+		LastName:     profile.LastName,
+		Email:        profile.Email,
+		Deactivation: profile.Deactivation,
+	}
+}
+
+// DeactivateHandler deactivates the authenticated user's account.
+// @Summary      Deactivate Your Account
+// @Description  Deactivates your account instead of deleting it. While deactivated you cannot log in (existing tokens stop working too) and your documents are hidden from the users you shared them with and from public listings. Nothing is deleted yet.
+// @Description
+// @Description  An administrator can reactivate the account at any time. You can also send `reactivate_at` to have it reactivated automatically, e.g. after a semester break.
+// @Description  If it is not reactivated within the server's grace period (`DOCSERVER_DEACTIVATION_GRACE_PERIOD`), the account and all its data are erased, as with `POST /profiles/me/erase`. The response tells you when (`purge_at`).
+// @Tags         Profiles
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        deactivation body DeactivateRequest false "When to reactivate automatically, if at all."
+// @Success      200  {object}  utils.Envelope{data=models.Deactivation} "Account deactivated."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The body is invalid, or 'reactivate_at' is not between now and the purge date."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: Your profile could not be found."
+// @Router       /profiles/me/deactivate [post]
+func DeactivateHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+	userIDStr := userID.(string)
+
+	var req DeactivateRequest // The body is optional
+	if !utils.BindOptionalJSON(c, cfg, &req, i18n.MsgInvalidRequestBody) {
+		return
+	}
+
+	profile, err := database.DeactivateProfile(userIDStr, req.ReactivateAt, cfg.DeactivationGracePeriod)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgProfileNotFound)
+		} else {
+			utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgDeactivationInvalid, err)
+		}
+		return
+	}
+
+	body := fmt.Sprintf("Your account has been deactivated. You cannot log in and your documents are hidden from other users.\n"+
+		"Unless it is reactivated, the account and all its data will be erased after %s.",
+		profile.Deactivation.PurgeAt.Format(time.RFC1123))
+	if profile.Deactivation.ReactivateAt != nil {
+		body += fmt.Sprintf("\nIt will be reactivated automatically on %s.", profile.Deactivation.ReactivateAt.Format(time.RFC1123))
+	}
+	utils.SendEmail(profile.Email, "Your account has been deactivated", body)
+	utils.RespondData(c, http.StatusOK, profile.Deactivation)
+}
+
+// ListDeactivatedProfilesHandler lists deactivated accounts.
+// @Summary      List Deactivated Accounts (Admin)
+// @Description  Lists deactivated accounts, soonest purge first. Administrators only.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  utils.Envelope{data=[]DeactivatedProfile} "Deactivated accounts."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Router       /admin/profiles/deactivated [get]
+func ListDeactivatedProfilesHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	profiles := database.ListDeactivatedProfiles()
+	items := make([]DeactivatedProfile, len(profiles))
+	for i, profile := range profiles {
+		items[i] = deactivatedProfileFor(profile)
+	}
+	utils.RespondData(c, http.StatusOK, items)
+}
+
+// ReactivateProfileHandler reactivates a deactivated account.
+// @Summary      Reactivate an Account (Admin)
+// @Description  Lifts the deactivation of an account: the user can log in again and their documents are visible to others as before. Administrators only.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "The unique identifier of the profile."
+// @Success      200  {object}  utils.Envelope{data=DeactivatedProfile} "Account reactivated."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No profile exists with the specified ID."
+// @Failure      409  {object}  utils.ErrorEnvelope "Conflict: The account is not deactivated."
+// @Router       /admin/profiles/{id}/reactivate [post]
+func ReactivateProfileHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	profileID := c.Param("id")
+	profile, err := database.ReactivateProfile(profileID)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgProfileNotFound)
+		} else {
+			utils.GinLocalizedError(c, http.StatusConflict, i18n.MsgProfileNotDeactivated, profileID)
+		}
+		return
+	}
+	utils.SendEmail(profile.Email, "Your account has been reactivated",
+		"Your account has been reactivated. You can log in again and your documents are visible to others as before.")
+	utils.RespondData(c, http.StatusOK, deactivatedProfileFor(profile))
+}
+
+// PurgeProfileHandler erases a deactivated account right away.
+// @Summary      Purge a Deactivated Account (Admin)
+// @Description  Erases a deactivated account and all its data now instead of at the end of its grace period, exactly as `POST /profiles/me/erase` would. Only deactivated accounts can be purged. Administrators only.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "The unique identifier of the profile."
+// @Success      200  {object}  utils.Envelope{data=models.ErasureReport} "Account erased."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No profile exists with the specified ID."
+// @Failure      409  {object}  utils.ErrorEnvelope "Conflict: The account is not deactivated."
+// @Failure      500  {object}  utils.ErrorEnvelope "Internal Server Error: The erasure failed."
+// @Router       /admin/profiles/{id}/purge [post]
+func PurgeProfileHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	profileID := c.Param("id")
+	profile, found := database.GetProfileByID(profileID)
+	if !found {
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgProfileNotFound)
+		return
+	}
+	if profile.Deactivation == nil {
+		utils.GinLocalizedError(c, http.StatusConflict, i18n.MsgProfileNotDeactivated, profileID)
+		return
+	}
+
+	report, err := database.EraseProfile(profileID)
+	if err != nil {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgPurgeFailed, err)
+		return
+	}
+	log.Printf("INFO: Deactivated Profile ID %s purged by an administrator", profileID)
+	utils.RespondData(c, http.StatusOK, report)
+}
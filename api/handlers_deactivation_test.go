@@ -0,0 +1,116 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"docserver/db"
+	"docserver/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeactivationEndpoints(t *testing.T) {
+	router, database, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	cfg.AdminEmails = []string{"deactivation.admin@example.com"}
+	cfg.DeactivationGracePeriod = 24 * time.Hour
+
+	_, _, adminToken := createTestUserAndLogin(t, router, "deactivation.admin@example.com", "password123", "Dea", "Admin")
+	ownerID, _, ownerToken := createTestUserAndLogin(t, router, "deactivation.owner@example.com", "password123", "Dea", "Owner")
+	readerID, _, readerToken := createTestUserAndLogin(t, router, "deactivation.reader@example.com", "password123", "Dea", "Reader")
+
+	rr := performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, gin.H{"content": gin.H{"title": "Thesis"}}), ownerToken)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var doc models.Document
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+	rr = performRequest(router, http.MethodPut, "/documents/"+doc.ID+"/shares/"+readerID, nil, ownerToken)
+	require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+
+	login := func(email string) int {
+		return performRequest(router, http.MethodPost, "/auth/login", marshalJSONBody(t, gin.H{"email": email, "password": "password123"}), "").Code
+	}
+
+	t.Run("Reactivation time outside the grace period is refused", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/profiles/me/deactivate", marshalJSONBody(t, gin.H{
+			"reactivate_at": time.Now().Add(48 * time.Hour).Format(time.RFC3339),
+		}), ownerToken)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.False(t, database.IsProfileDeactivated(ownerID))
+	})
+
+	t.Run("Deactivate", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/profiles/me/deactivate", nil, ownerToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var deactivation models.Deactivation
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &deactivation))
+		assert.Equal(t, 24*time.Hour, deactivation.PurgeAt.Sub(deactivation.DeactivatedAt))
+		assert.Nil(t, deactivation.ReactivateAt)
+	})
+
+	t.Run("Login and existing tokens stop working", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, login("deactivation.owner@example.com"))
+		rr := performRequest(router, http.MethodGet, "/profiles/me", nil, ownerToken)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		rr = performRequest(router, http.MethodGet, "/v1/documents", nil, ownerToken)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"message_id":"account_deactivated"`)
+	})
+
+	t.Run("Documents are hidden from sharers", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/documents/"+doc.ID, nil, readerToken)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		_, total, err := database.QueryDocuments(db.QueryDocumentsParams{AuthUserID: readerID, Scope: "shared", Page: 1, Limit: 10})
+		require.NoError(t, err)
+		assert.Zero(t, total)
+	})
+
+	t.Run("Admin lists deactivated accounts", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/admin/profiles/deactivated", nil, readerToken)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+
+		rr = performRequest(router, http.MethodGet, "/admin/profiles/deactivated", nil, adminToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var profiles []DeactivatedProfile
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &profiles))
+		require.Len(t, profiles, 1)
+		assert.Equal(t, ownerID, profiles[0].ID)
+		assert.NotNil(t, profiles[0].Deactivation)
+	})
+
+	t.Run("Admin reactivates", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/admin/profiles/"+ownerID+"/reactivate", nil, adminToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Equal(t, http.StatusOK, login("deactivation.owner@example.com"))
+		rr = performRequest(router, http.MethodGet, "/documents/"+doc.ID, nil, readerToken)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		rr = performRequest(router, http.MethodPost, "/admin/profiles/"+ownerID+"/reactivate", nil, adminToken)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		rr = performRequest(router, http.MethodPost, "/admin/profiles/missing/reactivate", nil, adminToken)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Admin purges", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/admin/profiles/"+ownerID+"/purge", nil, adminToken)
+		assert.Equal(t, http.StatusConflict, rr.Code, "only deactivated accounts can be purged")
+
+		rr = performRequest(router, http.MethodPost, "/profiles/me/deactivate", marshalJSONBody(t, gin.H{
+			"reactivate_at": time.Now().Add(time.Hour).Format(time.RFC3339),
+		}), ownerToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		rr = performRequest(router, http.MethodPost, "/admin/profiles/"+ownerID+"/purge", nil, adminToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var report models.ErasureReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		assert.Equal(t, 1, report.DocumentsDeleted)
+		_, found := database.GetProfileByID(ownerID)
+		assert.False(t, found)
+		assert.Equal(t, http.StatusUnauthorized, login("deactivation.owner@example.com"))
+	})
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"docserver/apperr"
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/models"
+	"docserver/utils"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Known Devices ---
+
+// DeviceResponse describes a device the user logged in from.
+type DeviceResponse struct {
+	models.Device
+	Current bool `json:"current"` // The request was made with a token issued to this device
+}
+
+// ListDevicesHandler lists the devices the authenticated user logged in from.
+// @Summary      List Your Devices
+// @Description  Lists the devices (IP address and browser or app) you logged in from, most recently used first. The first login from a device is emailed to you, unless it was your first login ever.
+// @Description  `current` marks the device your token was issued to. Revoked devices are kept for reference with `revoked_at` set; logging in from them again counts as a new device.
+// @Tags         Profiles
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  utils.Envelope{data=[]DeviceResponse} "Your devices."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Router       /profiles/me/devices [get]
+func ListDevicesHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+	currentID, _ := c.Get("deviceID")
+
+	devices := database.GetDevices(userID.(string))
+	response := make([]DeviceResponse, len(devices))
+	for i, device := range devices {
+		response[i] = DeviceResponse{Device: device, Current: device.ID == currentID}
+	}
+	utils.RespondData(c, http.StatusOK, response)
+}
+
+// RevokeDeviceHandler stops trusting one of the authenticated user's devices.
+// @Summary      Revoke a Device
+// @Description  Stops trusting a device you logged in from: tokens issued to it stop working right away (`401 Unauthorized`), including your own if it is the current device. Logging in from it again works, and counts as a new device.
+// @Tags         Profiles
+// @Security     BearerAuth
+// @Param        device_id  path  string  true  "The unique identifier of the device." example(dev_abc123xyz)
+// @Success      204  "Device revoked."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: You have no device with the specified ID."
+// @Router       /profiles/me/devices/{device_id} [delete]
+func RevokeDeviceHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+
+	deviceID := c.Param("device_id")
+	if _, err := database.RevokeDevice(userID.(string), deviceID); err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgDeviceNotFound, deviceID)
+			return
+		}
+		utils.GinErrorFromErr(c, apperr.HTTPStatus(err), err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDevices(t *testing.T) {
+	router, _, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	_, _, token := createTestUserAndLogin(t, router, "devices.me@example.com", "password123", "Devices", "Me")
+
+	// New sign-in notifications only reach the user's address, i.e. the server log.
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	loginFrom := func(t *testing.T, userAgent string) string {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, "/auth/login", marshalJSONBody(t, gin.H{"email": "devices.me@example.com", "password": "password123"}))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", userAgent)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var resp LoginResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		return resp.Token
+	}
+	listDevices := func(t *testing.T, token string) []DeviceResponse {
+		t.Helper()
+		rr := performRequest(router, http.MethodGet, "/profiles/me/devices", nil, token)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var devices []DeviceResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &devices))
+		return devices
+	}
+
+	t.Run("Known devices do not notify", func(t *testing.T) {
+		logs.Reset()
+		performRequest(router, http.MethodPost, "/auth/login", marshalJSONBody(t, gin.H{"email": "devices.me@example.com", "password": "password123"}), "")
+		assert.NotContains(t, logs.String(), "New sign-in")
+
+		devices := listDevices(t, token)
+		require.Len(t, devices, 1)
+		assert.True(t, devices[0].Current)
+		assert.Equal(t, 2, devices[0].Logins)
+	})
+
+	phoneToken := loginFrom(t, "Phone/1.0")
+
+	t.Run("New devices notify", func(t *testing.T) {
+		assert.Contains(t, logs.String(), "EMAIL to devices.me@example.com: New sign-in to your account")
+		assert.Contains(t, logs.String(), "Phone/1.0")
+
+		devices := listDevices(t, phoneToken)
+		require.Len(t, devices, 2)
+		assert.Equal(t, "Phone/1.0", devices[0].UserAgent, "most recently used first")
+		assert.True(t, devices[0].Current)
+		assert.False(t, devices[1].Current)
+	})
+
+	t.Run("Revoking stops the device's tokens", func(t *testing.T) {
+		phone := listDevices(t, token)[0]
+
+		rr := performRequest(router, http.MethodDelete, "/profiles/me/devices/"+phone.ID, nil, token)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		rr = performRequest(router, http.MethodGet, "/profiles/me", nil, phoneToken)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		rr = performRequest(router, http.MethodGet, "/profiles/me", nil, token)
+		assert.Equal(t, http.StatusOK, rr.Code, "other devices keep working")
+
+		devices := listDevices(t, token)
+		require.Len(t, devices, 2)
+		assert.NotNil(t, devices[0].RevokedAt)
+
+		logs.Reset()
+		newPhoneToken := loginFrom(t, "Phone/1.0")
+		assert.Contains(t, logs.String(), "New sign-in", "a revoked device is new again")
+		rr = performRequest(router, http.MethodGet, "/profiles/me", nil, newPhoneToken)
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("Unknown devices", func(t *testing.T) {
+		rr := performRequest(router, http.MethodDelete, "/profiles/me/devices/dev_missing", nil, token)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		rr = performRequest(router, http.MethodDelete, "/profiles/me/devices/doc_missing", nil, token)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, "IDs of other kinds are rejected")
+
+		_, _, otherToken := createTestUserAndLogin(t, router, "devices.other@example.com", "password123", "Devices", "Other")
+		mine := listDevices(t, token)[0]
+		rr = performRequest(router, http.MethodDelete, "/profiles/me/devices/"+mine.ID, nil, otherToken)
+		assert.Equal(t, http.StatusNotFound, rr.Code, "only your own devices")
+	})
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"docserver/authz"
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/models"
+	"docserver/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Document Diff ---
+
+// DocumentDiffResponse is a structured diff between two versions of a document's content.
+type DocumentDiffResponse struct {
+	DocumentID  string `json:"document_id"`
+	FromVersion int    `json:"from_version"`
+	ToVersion   int    `json:"to_version,omitempty"` // Omitted when comparing against supplied content
+	utils.JSONDiff
+}
+
+// DiffDocumentRequest defines the body for comparing a document against content that has not been saved.
+type DiffDocumentRequest struct {
+	Content any `json:"content" binding:"required"`
+}
+
+// currentVersion returns the document's current version; documents created before
+// version history existed are at version 1.
+func currentVersion(doc models.Document) int {
+	if doc.Version < 1 {
+		return 1
+	}
+	return doc.Version
+}
+
+// parseVersionQuery reads a version number query parameter, using def when it is absent.
+// It writes a 400 response and returns false if the value is not a positive integer.
+func parseVersionQuery(c *gin.Context, name string, def int) (int, bool) {
+	value, present := c.GetQuery(name)
+	if !present {
+		return def, true
+	}
+	version, err := strconv.Atoi(value)
+	if err != nil || version < 1 {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgInvalidVersion, name)
+		return 0, false
+	}
+	return version, true
+}
+
+// readableDocumentForDiff loads a document and checks the user may read it, writing the error response if not.
+func readableDocumentForDiff(c *gin.Context, database *db.Database, cfg *config.Config) (models.Document, bool) {
+	_, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return models.Document{}, false
+	}
+	docID := c.Param("id")
+
+	doc, found := database.GetDocumentByID(docID)
+	if !found {
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgDocumentNotFound, docID)
+		return models.Document{}, false
+	}
+	if !can(c, database, cfg, authz.ReadDocument, doc) {
+		utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgDocumentAccessDenied)
+		return models.Document{}, false
+	}
+	return doc, true
+}
+
+// documentVersionContent returns the content of one version as the user sees it, writing a 404
+// response if it is not kept. Sharers limited to part of the content only get that part.
+func documentVersionContent(c *gin.Context, database *db.Database, doc models.Document, version int) (any, bool) {
+	v, err := database.GetDocumentVersion(doc.ID, version)
+	if err != nil {
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgDocumentVersionNotFound, version, doc.ID)
+		return nil, false
+	}
+	if scope, limited := documentScope(database, doc, c.GetString("userID")); limited {
+		return db.ScopedContent(v.Content, scope), true
+	}
+	return v.Content, true
+}
+
+// GetDocumentDiffHandler compares two versions of a document.
+// @Summary      Compare Two Versions of a Document
+// @Description  Returns the differences between two versions of a document's content, computed on the server. Useful for reviewing what changed between submissions.
+// @Description
+// @Description  Every document starts at version 1 and each content update creates the next version (see `version` on the document). The most recent 50 versions are kept.
+// @Description  `to` defaults to the current version and `from` to the version before `to`.
+// @Description
+// @Description  The diff lists `added`, `removed` and `changed` entries, each with the content `path` (dot notation, e.g. `chapters.2.title`; `@this` when the whole content differs) and the `from`/`to` values.
+// @Description  Objects are compared key by key and arrays index by index; a newly added or removed object or array is reported once at its own path.
+// @Tags         Documents
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    path      string  true   "The unique identifier of the document."
+// @Param        from  query     int     false  "Version to compare from. Defaults to the version before 'to'." minimum(1)
+// @Param        to    query     int     false  "Version to compare to. Defaults to the current version." minimum(1)
+// @Success      200  {object}  utils.Envelope{data=DocumentDiffResponse} "The differences between the two versions."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: 'from' or 'to' is not a positive integer."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You do not have permission to access this document."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: The document, or one of the requested versions, does not exist."
+// @Router       /documents/{id}/diff [get]
+func GetDocumentDiffHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	doc, ok := readableDocumentForDiff(c, database, cfg)
+	if !ok {
+		return
+	}
+
+	to, ok := parseVersionQuery(c, "to", currentVersion(doc))
+	if !ok {
+		return
+	}
+	defaultFrom := to - 1
+	if defaultFrom < 1 {
+		defaultFrom = 1
+	}
+	from, ok := parseVersionQuery(c, "from", defaultFrom)
+	if !ok {
+		return
+	}
+
+	fromContent, ok := documentVersionContent(c, database, doc, from)
+	if !ok {
+		return
+	}
+	toContent, ok := documentVersionContent(c, database, doc, to)
+	if !ok {
+		return
+	}
+
+	utils.RespondData(c, http.StatusOK, DocumentDiffResponse{
+		DocumentID:  doc.ID,
+		FromVersion: from,
+		ToVersion:   to,
+		JSONDiff:    utils.DiffJSON(fromContent, toContent),
+	})
+}
+
+// DiffDocumentContentHandler compares a version of a document with supplied content.
+// @Summary      Compare a Document with New Content
+// @Description  Returns the differences between a version of a document (the current one unless `from` is given) and the `content` in the request body, without saving anything.
+// @Description  Handy for previewing what an update would change. The diff has the same format as `GET /documents/{id}/diff`.
+// @Tags         Documents
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string               true   "The unique identifier of the document."
+// @Param        from     query     int                  false  "Version to compare from. Defaults to the current version." minimum(1)
+// @Param        content  body      DiffDocumentRequest  true   "The content to compare against."
+// @Success      200  {object}  utils.Envelope{data=DocumentDiffResponse} "The differences between the version and the supplied content."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: 'from' is not a positive integer, or the body has no 'content'."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You do not have permission to access this document."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: The document, or the requested version, does not exist."
+// @Router       /documents/{id}/diff [post]
+func DiffDocumentContentHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	doc, ok := readableDocumentForDiff(c, database, cfg)
+	if !ok {
+		return
+	}
+
+	var req DiffDocumentRequest
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidDocumentBody) {
+		return
+	}
+
+	from, ok := parseVersionQuery(c, "from", currentVersion(doc))
+	if !ok {
+		return
+	}
+	fromContent, ok := documentVersionContent(c, database, doc, from)
+	if !ok {
+		return
+	}
+
+	utils.RespondData(c, http.StatusOK, DocumentDiffResponse{
+		DocumentID:  doc.ID,
+		FromVersion: from,
+		JSONDiff:    utils.DiffJSON(fromContent, req.Content),
+	})
+}
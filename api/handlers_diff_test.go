@@ -0,0 +1,105 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"docserver/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentDiff(t *testing.T) {
+	router, database, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	ownerID, _, ownerToken := createTestUserAndLogin(t, router, "diff.owner@example.com", "password123", "Diff", "Owner")
+	readerID, _, readerToken := createTestUserAndLogin(t, router, "diff.reader@example.com", "password123", "Diff", "Reader")
+	_, _, strangerToken := createTestUserAndLogin(t, router, "diff.stranger@example.com", "password123", "Diff", "Stranger")
+
+	doc, err := database.CreateDocument(models.Document{OwnerID: ownerID, Content: map[string]any{"title": "Essay", "score": 70.0}})
+	require.NoError(t, err)
+	_, err = database.UpdateDocument(doc.ID, map[string]any{"title": "Essay", "score": 85.0, "feedback": "Good"})
+	require.NoError(t, err)
+	_, err = database.UpdateDocument(doc.ID, map[string]any{"title": "Final essay", "score": 85.0})
+	require.NoError(t, err)
+	require.NoError(t, database.SetShareRecord(doc.ID, []string{readerID}))
+
+	getDiff := func(query, token string) (int, DocumentDiffResponse) {
+		rr := performRequest(router, http.MethodGet, "/documents/"+doc.ID+"/diff"+query, nil, token)
+		var resp DocumentDiffResponse
+		if rr.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		}
+		return rr.Code, resp
+	}
+
+	t.Run("Defaults to the latest change", func(t *testing.T) {
+		code, resp := getDiff("", ownerToken)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 2, resp.FromVersion)
+		assert.Equal(t, 3, resp.ToVersion)
+		assert.Empty(t, resp.Added)
+		require.Len(t, resp.Removed, 1)
+		assert.Equal(t, "feedback", resp.Removed[0].Path)
+		require.Len(t, resp.Changed, 1)
+		assert.Equal(t, "title", resp.Changed[0].Path)
+		assert.Equal(t, "Final essay", resp.Changed[0].To)
+	})
+
+	t.Run("Explicit versions", func(t *testing.T) {
+		code, resp := getDiff("?from=1&to=2", readerToken)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []string{"feedback", "score"}, resp.Paths())
+		assert.Equal(t, 70.0, resp.Changed[0].From)
+		assert.Equal(t, 85.0, resp.Changed[0].To)
+	})
+
+	t.Run("Invalid and unknown versions", func(t *testing.T) {
+		code, _ := getDiff("?from=abc", ownerToken)
+		assert.Equal(t, http.StatusBadRequest, code)
+		code, _ = getDiff("?to=0", ownerToken)
+		assert.Equal(t, http.StatusBadRequest, code)
+		code, _ = getDiff("?from=1&to=9", ownerToken)
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+
+	t.Run("Access", func(t *testing.T) {
+		code, _ := getDiff("", strangerToken)
+		assert.Equal(t, http.StatusForbidden, code)
+		rr := performRequest(router, http.MethodGet, "/documents/missing/diff", nil, ownerToken)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Compare with supplied content", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/documents/"+doc.ID+"/diff", marshalJSONBody(t, gin.H{
+			"content": gin.H{"title": "Final essay", "score": 85, "grade": "B"},
+		}), ownerToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var resp DocumentDiffResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, 3, resp.FromVersion)
+		assert.Zero(t, resp.ToVersion)
+		assert.Equal(t, []string{"grade"}, resp.Paths())
+
+		current, _ := database.GetDocumentByID(doc.ID)
+		assert.Equal(t, 3, current.Version, "nothing is saved")
+
+		rr = performRequest(router, http.MethodPost, "/documents/"+doc.ID+"/diff?from=1", marshalJSONBody(t, gin.H{"content": "text"}), ownerToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, []string{"@this"}, resp.Paths())
+
+		rr = performRequest(router, http.MethodPost, "/documents/"+doc.ID+"/diff", marshalJSONBody(t, gin.H{}), ownerToken)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Enveloped", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/v1/documents/"+doc.ID+"/diff?from=1", nil, ownerToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"data":{"document_id"`)
+	})
+}
@@ -1,14 +1,19 @@
 package api
 
 import (
+	"docserver/apperr"
+	"docserver/authz"
 	"docserver/config"
 	"docserver/db"
+	"docserver/i18n"
 	"docserver/models"
 	"docserver/utils"
-	"fmt"
+	"errors"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,7 +22,14 @@ import (
 
 // CreateDocumentRequest defines the expected body for creating a document.
 type CreateDocumentRequest struct {
-	Content any `json:"content" binding:"required"` // Content can be any valid JSON
+	Content any    `json:"content" binding:"required"` // Content can be any valid JSON
+	ID      string `json:"id,omitempty"`                // Optional client-supplied ID (letters, digits, '_' or '-', max 64)
+	Key     string `json:"key,omitempty"`               // Optional key from which a deterministic ID is derived
+	Public  bool   `json:"public,omitempty"`            // Make the document readable by anyone (see GET /public/documents)
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // When the document expires (RFC 3339, in the future); omitted, it never does
+	Links   []string `json:"links,omitempty"`           // IDs of documents it links to; you must be able to read them
+	// Format of the content: 'json' (default), 'markdown', 'text' or 'csv'. Non-JSON content must be a string.
+	ContentType string `json:"content_type,omitempty"`
 }
 
 // CreateDocumentHandler handles the creation of a new document.
@@ -38,56 +50,201 @@ type CreateDocumentRequest struct {
 // @Description    }
 // @Description  }
 // @Description  ```
+// @Description
+// @Description  **Choosing the ID:** By default the server generates the ID. You may instead supply either:
+// @Description  *   `id`: Your own ID (1-64 letters, digits, `_` or `-`). It must not already be in use, otherwise `409 Conflict` is returned, and must not have the form of an ID derived from a `key` (32 lowercase hex digits of a version 5 UUID).
+// @Description  *   `key`: Any string. The server derives a deterministic ID from your account and this key, so repeating the request with the same `key` returns the existing document (`200 OK`) instead of creating a duplicate. If that ID is taken by a document of another user, `409 Conflict` is returned.
+// @Description
+// @Description  **Content type:** Set `content_type` to `markdown`, `text` or `csv` to store raw text instead of JSON; `content` must then be a string (and valid CSV for `csv`). Omitted, it is `json`.
+// @Description
+// @Description  Set `"public": true` to make the document readable by anyone; when the server enables public access, guests can read it without an account via `GET /public/documents`.
+// @Description
+// @Description  **Expiry:** Set `expires_at` to a future time to have the document disappear then, e.g. for a temporary scratch document (see `PUT /documents/{id}/expiry`). Documents that expire are returned with `ttl_seconds`, the seconds they have left.
+// @Description
+// @Description  **Links:** `links` lists the IDs of documents this one links to (see `GET /documents/{id}/backlinks` and `GET /graph`). You must be able to read each of them.
 // @Tags         Documents
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
 // @Param        document body CreateDocumentRequest true "The JSON content you want to store in the new document."
-// @Success      201  {object}  models.Document "Document Created Successfully. The response body contains the details of the newly created document, including its unique ID."
-// @Failure      400  {object}  utils.APIError "Bad Request: The request body is invalid. It must be valid JSON and contain the required 'content' field."
-// @Failure      401  {object}  utils.APIError "Unauthorized: Your access token is missing, invalid, or expired. You need to be logged in to create documents."
-// @Failure      500  {object}  utils.APIError "Internal Server Error: Something went wrong on the server while creating the document (e.g., database error)."
+// @Success      200  {object}  utils.Envelope{data=DocumentResponse} "Document Already Exists: A document with the ID derived from 'key' already exists and is returned unchanged."
+// @Success      201  {object}  utils.Envelope{data=DocumentResponse} "Document Created Successfully. The response body contains the details of the newly created document, including its unique ID."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The request body is invalid. It must be valid JSON and contain the required 'content' field. 'id' must be well-formed, not of the form of a key-derived ID, and cannot be combined with 'key'. 'content_type' must be known and match the content. 'expires_at' must be in the future."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired. You need to be logged in to create documents."
+// @Failure      409  {object}  utils.ErrorEnvelope "Conflict: The supplied 'id', or the ID derived from 'key', is already in use by another document."
+// @Failure      422  {object}  utils.ErrorEnvelope "Unprocessable Entity: A document script rejected the content."
+// @Failure      500  {object}  utils.ErrorEnvelope "Internal Server Error: Something went wrong on the server while creating the document (e.g., database error)."
 // @Router       /documents [post]
 func CreateDocumentHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		utils.GinInternalServerError(c, "User ID not found in context.")
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
 		return
 	}
 	userIDStr := userID.(string)
 
 	var req CreateDocumentRequest
-	// Use BindJSON here as ShouldBindJSON might consume the body needed for later gjson validation if we add it
-	if err := c.BindJSON(&req); err != nil {
-		// Check if content is just plain text (not valid JSON) - this might be allowed?
-		// Plan says "Can be any JSON structure or simple text".
-		// Let's assume binding requires valid JSON for now, but allow flexibility later if needed.
-		utils.GinBadRequest(c, fmt.Sprintf("Invalid request body: %v. 'content' must be provided.", err))
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidDocumentBody) {
+		return
+	}
+
+	// Resolve the document ID: client-supplied, derived from a key, or generated by the db
+	docID := ""
+	if req.ID != "" && req.Key != "" {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgIDAndKeyExclusive)
+		return
+	}
+	if req.ID != "" {
+		if !utils.IsValidCustomID(req.ID) {
+			utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgInvalidCustomID)
+			return
+		}
+		if err := utils.ValidateID(req.ID, utils.IDKindDocument); err != nil { // e.g. "usr_..."
+			utils.GinErrorFromErr(c, http.StatusBadRequest, err)
+			return
+		}
+		if utils.IsKeyDerivedID(req.ID) {
+			utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgKeyDerivedID)
+			return
+		}
+		docID = req.ID
+	}
+	if req.Key != "" {
+		docID = utils.DeterministicDocumentID(userIDStr, req.Key)
+		// Same key again: the create is idempotent, return the existing document. One of another
+		// owner can only have been created before IDs of this form were reserved for keys.
+		if existingDoc, found := database.GetDocumentByID(docID); found {
+			if existingDoc.OwnerID != userIDStr {
+				utils.GinLocalizedError(c, http.StatusConflict, i18n.MsgDocumentAlreadyExists, docID)
+				return
+			}
+			utils.RespondData(c, http.StatusOK, DocumentResponse{Document: existingDoc, DocumentExpiry: documentExpiry(existingDoc, cfg.Now())})
+			return
+		}
+	}
+
+	if !checkDocumentLinks(c, database, cfg, docID, req.Links) {
 		return
 	}
 
 	// Create the document model
 	doc := models.Document{
-		OwnerID: userIDStr,
-		Content: req.Content,
-		// ID and timestamps are set by db.CreateDocument
+		ID:          docID, // Empty means db.CreateDocument generates one
+		OwnerID:     userIDStr,
+		Content:     req.Content,
+		Public:      req.Public,
+		ContentType: req.ContentType,
+		ExpiresAt:   req.ExpiresAt,
+		Links:       req.Links,
+		// Timestamps are set by db.CreateDocument
 	}
 
 	// Save to database
 	createdDoc, err := database.CreateDocument(doc)
 	if err != nil {
-		utils.GinInternalServerError(c, fmt.Sprintf("Failed to create document: %v", err))
+		if respondScriptError(c, err) {
+			return
+		}
+		if errors.Is(err, apperr.ErrConflict) {
+			utils.GinErrorFromErr(c, http.StatusConflict, err)
+		} else if errors.Is(err, apperr.ErrValidation) {
+			utils.GinErrorFromErr(c, http.StatusBadRequest, err)
+		} else {
+			utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgDocumentCreateFailed, err)
+		}
 		return
 	}
 
-	c.JSON(http.StatusCreated, createdDoc)
+	utils.RespondData(c, http.StatusCreated, DocumentResponse{Document: createdDoc, DocumentExpiry: documentExpiry(createdDoc, cfg.Now())})
 }
 
 // --- Get Documents (List with Querying) ---
 
-// GetDocumentsResponse defines the structure for the paginated document list results.
+// DocumentListItem is a document as returned by the list endpoints, with flags specific to the viewer.
+type DocumentListItem struct {
+	models.Document
+	DocumentIncludes
+	DocumentExpiry
+	Favorite bool `json:"favorite"` // Whether the viewer has bookmarked the document
+}
+
+// DocumentResponse is a single document with the related records requested through 'include'.
+type DocumentResponse struct {
+	models.Document
+	DocumentIncludes
+	DocumentExpiry
+}
+
+// DocumentIncludes holds the related records embedded in document responses on request
+// ('?include=shares,owner'), saving a follow-up call per document.
+type DocumentIncludes struct {
+	SharedWith *[]ProfileSummary `json:"shared_with,omitempty"` // include=shares: who a document the viewer owns is shared with
+	Owner      *ProfileSummary   `json:"owner,omitempty"`       // include=owner: who owns a document the viewer does not own
+}
+
+// documentIncludeOptions lists the values 'include' accepts.
+var documentIncludeOptions = []string{"shares", "owner"}
+
+// parseDocumentIncludes reads the comma-separated 'include' parameter. It writes a 400 response
+// and returns false if it names anything but "shares" and "owner".
+func parseDocumentIncludes(c *gin.Context) (map[string]bool, bool) {
+	includes := map[string]bool{}
+	for _, value := range strings.Split(c.Query("include"), ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if !slices.Contains(documentIncludeOptions, value) {
+			utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgInvalidInclude, value)
+			return nil, false
+		}
+		includes[value] = true
+	}
+	return includes, true
+}
+
+// documentReader is what access checks and includes read: the database, or a view of it (see db.View).
+type documentReader interface {
+	GetProfileByID(id string) (models.Profile, bool)
+	GetShareRecordByDocumentID(docID string) (models.ShareRecord, bool)
+	IsProfileDeactivated(profileID string) bool
+	IsAssignedReviewer(docID, profileID string) bool
+}
+
+// documentIncludes looks up the related records of doc named in includes, as seen by viewerID:
+// the profiles a document is shared with only go to its owner, and the owner of a document is
+// only added for other viewers. Profiles that no longer exist are listed by ID alone.
+func documentIncludes(database documentReader, doc models.Document, viewerID string, includes map[string]bool) DocumentIncludes {
+	summary := func(id string) ProfileSummary {
+		profile, found := database.GetProfileByID(id)
+		if !found {
+			return ProfileSummary{ID: id}
+		}
+		return ProfileSummary{ID: profile.ID, FirstName: profile.FirstName, LastName: profile.LastName}
+	}
+
+	var result DocumentIncludes
+	if doc.OwnerID == viewerID {
+		if includes["shares"] {
+			sharedWith := []ProfileSummary{}
+			if record, found := database.GetShareRecordByDocumentID(doc.ID); found {
+				for _, id := range record.SharedWith {
+					sharedWith = append(sharedWith, summary(id))
+				}
+			}
+			result.SharedWith = &sharedWith
+		}
+	} else if includes["owner"] {
+		owner := summary(doc.OwnerID)
+		result.Owner = &owner
+	}
+	return result
+}
+
+// GetDocumentsResponse is the paginated document list shape served on legacy unversioned paths.
+// Versioned paths wrap the list in utils.Envelope with meta and links instead.
 type GetDocumentsResponse struct {
-	Data  []models.Document `json:"data"`
+	Data  []DocumentListItem `json:"data"`
 	Total int               `json:"total"`
 	Page  int               `json:"page"`
 	Limit int               `json:"limit"`
@@ -102,7 +259,15 @@ type GetDocumentsResponse struct {
 // @Description      *   `owned`: Only documents you created.
 // @Description      *   `shared`: Only documents shared with you by others.
 // @Description      *   `all` (default): Both owned and shared documents.
+// @Description      *   `public`: Documents any owner has marked `public`.
+// @Description      *   `any`: Every document, whoever owns it (administrators only; others get `403 Forbidden`).
+// @Description  *   `owner_id`: Only list documents owned by this profile, e.g. `?scope=any&owner_id=...` for an administrator searching one student's documents.
+// @Description  *   `favorites`: Set to `true` to only list documents you bookmarked with `POST /documents/{id}/favorite`. Every listed document carries a `favorite` flag.
+// @Description  *   `workflow_state`: Only list documents in this submission workflow state: `draft`, `submitted`, `approved` or `rejected` (see `POST /documents/{id}/workflow`). Documents that were never submitted count as drafts. Example: `?scope=shared&workflow_state=submitted` lists the submissions waiting for your review.
+// @Description  *   `expired`: Documents past their `expires_at` are left out (`exclude`, the default) until they are purged; `include` lists them with the others and `only` lists nothing else. Listed documents that expire carry `ttl_seconds`, which is `0` for expired ones.
 // @Description  *   `content_query`: Filter documents based on their JSON content using a specific query language (details likely in separate documentation or examples). This allows searching within the document data itself. Example: `?content_query=metadata.status eq "published"`
+// @Description  *   `missing`: What to do with documents lacking a path the `content_query` uses: `skip` them (default), treat the condition as `false` (so conditions joined with `or` can still match), or fail the request with `error`. Conditions negated with `not` match such documents in every mode.
+// @Description  *   `include`: Embed related records in each document, saving a call per document: `shares` adds `shared_with` (the profiles a document you own is shared with) and `owner` adds `owner` (the profile that owns a document you do not own). Combine them as `include=shares,owner`.
 // @Description  *   `sort_by`: Choose the field to sort results by: `creation_date` (default) or `last_modified_date`.
 // @Description  *   `order`: Set the sort direction: `asc` (ascending) or `desc` (descending, default).
 // @Description  *   `page`: For pagination, specify the page number (starts at 1, default is 1).
@@ -112,27 +277,52 @@ type GetDocumentsResponse struct {
 // @Tags         Documents
 // @Produce      json
 // @Security     BearerAuth
-// @Param        scope         query     string  false  "Filter by ownership: 'owned', 'shared', or 'all'." Enums(owned, shared, all) default(all) example(owned)
+// @Param        scope         query     string  false  "Filter by ownership: 'owned', 'shared', 'all', 'public' or 'any' (administrators only)." Enums(owned, shared, all, public, any) default(all) example(owned)
+// @Param        owner_id      query     string  false  "Only list documents owned by this profile."
+// @Param        favorites     query     bool    false  "Only list documents you marked as favorite." default(false)
+// @Param        workflow_state query    string  false  "Only list documents in this workflow state." Enums(draft, submitted, approved, rejected)
+// @Param        expired       query     string  false  "Whether to list documents that have expired." Enums(exclude, include, only) default(exclude)
 // @Param        content_query query     []string false "Advanced filter based on document content (specific syntax applies)." collectionFormat(multi) example(user.name eq "John Doe")
+// @Param        missing       query     string  false  "How conditions on paths a document lacks are treated." Enums(skip, false, error) default(skip)
+// @Param        include       query     string  false  "Comma-separated related records to embed: 'shares' (on documents you own) and/or 'owner' (on documents you do not own)." example(shares,owner)
 // @Param        sort_by       query     string  false  "Field to sort results by." Enums(creation_date, last_modified_date) default(creation_date) example(last_modified_date)
 // @Param        order         query     string  false  "Sorting direction." Enums(asc, desc) default(desc) example(asc)
 // @Param        page          query     int     false  "Page number for pagination (starts at 1)." minimum(1) default(1) example(2)
 // @Param        limit         query     int     false  "Number of documents per page." minimum(1) maximum(100) default(20) example(50)
-// @Success      200  {object}  GetDocumentsResponse "A list of documents matching the criteria, along with pagination details (total count, current page, limit)."
-// @Failure      400  {object}  utils.APIError "Bad Request: One or more query parameters are invalid (e.g., invalid 'scope', incorrect 'content_query' syntax, non-integer 'page'/'limit')."
-// @Failure      401  {object}  utils.APIError "Unauthorized: Your access token is missing, invalid, or expired."
-// @Failure      500  {object}  utils.APIError "Internal Server Error: Something went wrong on the server while retrieving documents."
+// @Success      200  {object}  utils.Envelope{data=[]DocumentListItem,meta=utils.PageMeta,links=utils.PageLinks} "A list of documents matching the criteria, along with pagination details (total count, current page, limit)."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: One or more query parameters are invalid (e.g., invalid 'scope', incorrect 'content_query' syntax, non-integer 'page'/'limit')."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: 'scope=any' was requested by someone who is not an administrator."
+// @Failure      500  {object}  utils.ErrorEnvelope "Internal Server Error: Something went wrong on the server while retrieving documents."
 // @Router       /documents [get]
 func GetDocumentsHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		utils.GinInternalServerError(c, "User ID not found in context.")
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
 		return
 	}
 	userIDStr := userID.(string)
 
-	// Parse query parameters
-	scope := c.DefaultQuery("scope", "all") // owned, shared, all
+	params, ok := parseDocumentQueryParams(c)
+	if !ok {
+		return // Error response already sent
+	}
+	params.AuthUserID = userIDStr
+	params.Scope = c.DefaultQuery("scope", "all") // owned, shared, all, public, any (admins only)
+	params.OwnerID = c.Query("owner_id")
+	params.FavoritesOnly = c.Query("favorites") == "true"
+	params.WorkflowState = c.Query("workflow_state")
+	params.Expired = c.Query("expired")
+	if profile, found := database.GetProfileByID(userIDStr); found {
+		params.IsAdmin = isAdmin(profile, cfg)
+	}
+
+	respondDocumentQuery(c, database, cfg, params)
+}
+
+// parseDocumentQueryParams reads the content query, sorting and pagination parameters shared by the
+// document list endpoints. It writes a 400 response and returns false if they are invalid.
+func parseDocumentQueryParams(c *gin.Context) (db.QueryDocumentsParams, bool) {
 	contentQuery := c.QueryArray("content_query") // Expects ?content_query=path op val&content_query=logic&...
 	sortBy := c.DefaultQuery("sort_by", "creation_date") // creation_date, last_modified_date
 	order := c.DefaultQuery("order", "desc") // asc, desc
@@ -143,44 +333,64 @@ func GetDocumentsHandler(c *gin.Context, database *db.Database, cfg *config.Conf
 	limit, errLimit := strconv.Atoi(limitQuery)
 
 	if errPage != nil || errLimit != nil || page < 1 {
-		utils.GinBadRequest(c, "Invalid 'page' or 'limit' query parameter. Must be positive integers.")
-		return
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgInvalidPagination)
+		return db.QueryDocumentsParams{}, false
 	}
 
-	// Prepare params for database query
-	params := db.QueryDocumentsParams{
-		AuthUserID:   userIDStr,
-		Scope:        scope,
+	return db.QueryDocumentsParams{
 		ContentQuery: contentQuery,
+		Missing:      c.Query("missing"), // skip (default), false, error
 		SortBy:       sortBy,
 		Order:        order,
 		Page:         page,
 		Limit:        limit, // Max limit enforced by db.QueryDocuments/paginateDocuments
+	}, true
+}
+
+// respondDocumentQuery runs a document query and writes the paginated list or the error response.
+func respondDocumentQuery(c *gin.Context, database *db.Database, cfg *config.Config, params db.QueryDocumentsParams) {
+	includes, ok := parseDocumentIncludes(c)
+	if !ok {
+		return // Error response already sent
 	}
 
-	// Execute query
-	docs, totalMatching, err := database.QueryDocuments(params)
+	// The documents, favorites and includes all come from one view of the database
+	now := cfg.Now()
+	var items []DocumentListItem
+	var totalMatching int
+	err := database.View(func(v *db.ReadView) error {
+		docs, total, err := v.QueryDocumentsContext(c.Request.Context(), params)
+		if err != nil {
+			return err
+		}
+		totalMatching = total
+
+		// Flag the viewer's favorites (guests have none)
+		favorites := map[string]bool{}
+		if params.AuthUserID != "" {
+			favorites = v.GetFavorites(params.AuthUserID)
+		}
+		items = make([]DocumentListItem, len(docs))
+		for i, doc := range docs {
+			items[i] = DocumentListItem{Document: doc, DocumentExpiry: documentExpiry(doc, now), Favorite: favorites[doc.ID]}
+			if len(includes) > 0 {
+				items[i].DocumentIncludes = documentIncludes(v, doc, params.AuthUserID, includes)
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		// Check for specific query-related errors (e.g., bad syntax, invalid scope)
-		if strings.Contains(err.Error(), "invalid content_query") ||
-		   strings.Contains(err.Error(), "invalid scope value") ||
-		   strings.Contains(err.Error(), "invalid sort_by value") ||
-		   strings.Contains(err.Error(), "invalid order value") ||
-		   strings.Contains(err.Error(), "error evaluating content query") {
-			utils.GinBadRequest(c, err.Error())
+		// Check for specific query-related errors (e.g., bad syntax, invalid scope, scope 'any' without admin rights)
+		if errors.Is(err, apperr.ErrValidation) || errors.Is(err, apperr.ErrForbidden) {
+			utils.GinErrorFromErr(c, apperr.HTTPStatus(err), err)
 		} else {
-			utils.GinInternalServerError(c, fmt.Sprintf("Failed to query documents: %v", err))
+			utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgDocumentQueryFailed, err)
 		}
 		return
 	}
 
-	// Return paginated list and total count using the defined struct
-	c.JSON(http.StatusOK, GetDocumentsResponse{
-		Data:  docs,
-		Total: totalMatching,
-		Page:  page,
-		Limit: params.Limit, // Return the potentially capped limit
-	})
+	// Return paginated list with pagination metadata
+	utils.RespondList(c, items, totalMatching, params.Page, params.Limit) // Return the potentially capped limit
 }
 
 // --- Get Document by ID ---
@@ -193,70 +403,103 @@ func GetDocumentsHandler(c *gin.Context, database *db.Database, cfg *config.Conf
 // @Description  1. You are the owner of the document.
 // @Description  OR
 // @Description  2. The document has been explicitly shared with you by its owner.
+// @Description  OR
+// @Description  3. The owner has marked the document `public`.
 // @Description
+// @Description  If the owner shared only part of the content with you (see `PUT /documents/{id}/shares/{profile_id}/scope`), `content` holds just that part.
 // @Description  Provide the document's `id` as part of the URL path. You also need your access token for authentication.
 // @Tags         Documents
 // @Produce      json
 // @Security     BearerAuth
 // @Param        id   path      string  true  "The unique identifier of the document you want to retrieve." example(doc_abc123xyz)
-// @Success      200  {object}  models.Document "Successfully retrieved the document. The response body contains the document's details (ID, owner, content, timestamps)."
-// @Failure      400  {object}  utils.APIError "Bad Request: The document ID provided in the URL path is missing or invalid."
-// @Failure      401  {object}  utils.APIError "Unauthorized: Your access token is missing, invalid, or expired."
-// @Failure      403  {object}  utils.APIError "Forbidden: You do not have permission to view this document. You are neither the owner nor has it been shared with you."
-// @Failure      404  {object}  utils.APIError "Not Found: No document exists with the specified ID."
-// @Failure      500  {object}  utils.APIError "Internal Server Error: Something went wrong on the server while retrieving the document."
+// @Param        render query   string  false "Set to 'html' to get a Markdown document rendered as an HTML page." Enums(html)
+// @Param        include query  string  false "Comma-separated related records to embed: 'shares' adds 'shared_with' if you own the document, 'owner' adds 'owner' if you do not." example(shares,owner)
+// @Success      200  {object}  utils.Envelope{data=DocumentResponse} "Successfully retrieved the document. The response body contains the document's details (ID, owner, content, timestamps)."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The document ID provided in the URL path is missing or invalid, 'include' names an unknown record, or 'render' is invalid or used on a document that is not Markdown."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You do not have permission to view this document. You are neither the owner nor has it been shared with you."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No document exists with the specified ID."
+// @Failure      500  {object}  utils.ErrorEnvelope "Internal Server Error: Something went wrong on the server while retrieving the document."
 // @Router       /documents/{id} [get]
 func GetDocumentByIDHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		utils.GinInternalServerError(c, "User ID not found in context.")
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
 		return
 	}
 	userIDStr := userID.(string)
 	docID := c.Param("id") // Get ID from path
 
 	if docID == "" {
-		utils.GinBadRequest(c, "Document ID is required in the path.")
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgDocumentIDRequired)
 		return
 	}
 
-	// Retrieve document from database
-	doc, found := database.GetDocumentByID(docID)
-	if !found {
-		utils.GinNotFound(c, fmt.Sprintf("Document with ID '%s' not found.", docID))
-		return
+	includes, ok := parseDocumentIncludes(c)
+	if !ok {
+		return // Error response already sent
 	}
 
-	// Authorization Check: Is user the owner OR is it shared with them?
-	isOwner := doc.OwnerID == userIDStr
-	isShared := false
-	if !isOwner {
-		shareRecord, shareFound := database.GetShareRecordByDocumentID(docID)
-		if shareFound {
-			for _, sharedID := range shareRecord.SharedWith {
-				if sharedID == userIDStr {
-					isShared = true
-					break
-				}
+	// Retrieve the document, check access and look up includes in one view of the database
+	var doc models.Document
+	var found, allowed bool
+	var related DocumentIncludes
+	_ = database.View(func(v *db.ReadView) error {
+		if doc, found = v.GetDocumentByID(docID); !found {
+			return nil
+		}
+		// Authorization Check: Is user the owner OR is it shared with them (or public)?
+		if allowed = can(c, v, cfg, authz.ReadDocument, doc); allowed {
+			related = documentIncludes(v, doc, userIDStr, includes)
+			if scope, limited := documentScope(v, doc, userIDStr); limited {
+				doc.Content = db.ScopedContent(doc.Content, scope)
 			}
 		}
+		return nil
+	})
+	if !found {
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgDocumentNotFound, docID)
+		return
+	}
+	if !allowed {
+		utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgDocumentAccessDenied)
+		return
 	}
 
-	if !isOwner && !isShared {
-		utils.GinForbidden(c, "You do not have permission to access this document.")
+	// '?render=html' serves Markdown documents as HTML instead of the JSON document
+	if render := c.Query("render"); render != "" {
+		if render != "html" {
+			utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgInvalidRender, render)
+			return
+		}
+		if doc.ContentType != models.ContentTypeMarkdown {
+			contentType := doc.ContentType
+			if contentType == "" {
+				contentType = models.ContentTypeJSON
+			}
+			utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgRenderUnsupported, contentType)
+			return
+		}
+		source, _ := doc.Content.(string)
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(utils.RenderMarkdown(source)))
 		return
 	}
 
-	// Return the document
-	c.JSON(http.StatusOK, doc)
+	// Return the document with any requested related records
+	utils.RespondData(c, http.StatusOK, DocumentResponse{Document: doc, DocumentIncludes: related, DocumentExpiry: documentExpiry(doc, cfg.Now())})
 }
 
 // --- Update Document ---
 
 // UpdateDocumentRequest defines the body for updating a document.
-// Only content can be updated via this endpoint.
+// Content is replaced; Public is only changed when provided.
 type UpdateDocumentRequest struct {
-	Content any `json:"content" binding:"required"`
+	Content any   `json:"content" binding:"required"`
+	Public  *bool `json:"public,omitempty"` // Make the document readable by anyone, or private again
+	// Changes the content type ('json', 'markdown', 'text' or 'csv'); kept as it is when omitted
+	ContentType *string `json:"content_type,omitempty"`
+	// Replaces the IDs of the documents it links to ('[]' removes them); kept as they are when omitted
+	Links *[]string `json:"links,omitempty"`
 }
 
 // UpdateDocumentHandler handles updating a document's content.
@@ -265,7 +508,7 @@ type UpdateDocumentRequest struct {
 // @Description
 // @Description  **Important:** This operation overwrites the previous content completely. If you only want to modify parts of the content, you should first retrieve the document, make changes to the content in your application, and then use this endpoint to save the full, modified content.
 // @Description
-// @Description  Only the user who originally created (owns) the document is allowed to update it.
+// @Description  Only the user who originally created (owns) the document is allowed to update it, except that users it is shared with may replace the part of the content they were given write access to (see `PUT /documents/{id}/shares/{profile_id}/scope`): the value at their path in `content` replaces the one in the document and the rest is kept. They cannot change `public` or `content_type`.
 // @Description  Provide the document's `id` in the URL path and the new JSON `content` in the request body. Authentication via access token is required.
 // @Description
 // @Description  Example Request Body:
@@ -274,64 +517,189 @@ type UpdateDocumentRequest struct {
 // @Description    "content": { "message": "Updated content here!" }
 // @Description  }
 // @Description  ```
+// @Description
+// @Description  Include `"public": true` or `"public": false` to change whether the document is publicly readable; if omitted, it stays as it is.
+// @Description  Likewise, `content_type` changes the document's format (`json`, `markdown`, `text` or `csv`); the new content must fit the resulting type.
+// @Description  `links` replaces the IDs of the documents it links to, each of which you must be able to read (`[]` removes them); if omitted, they stay as they are.
+// @Description
+// @Description  **Upsert:** Normally a missing document yields `404 Not Found`. To create it instead (owned by you, under the `id` from the path):
+// @Description  *   Add `?upsert=true` (or `?create=true`): the document is created if missing (`201 Created`) or updated if it exists and you own it (`200 OK`).
+// @Description  *   Send the header `If-None-Match: *`: the document is created only if it does not exist yet; if it already exists the request fails with `412 Precondition Failed` and nothing is changed.
+// @Description
+// @Description  **Compare-and-set:** Send one or more `If-Content-Matches` headers holding a content query, in the syntax of `content_query` on `GET /documents` (one header per condition or `and`/`or`), to apply the update only if the document's current content matches it, e.g. `If-Content-Matches: status equals "draft"`. The check and the update happen in one step; if the content does not match, the request fails with `412 Precondition Failed` and nothing is changed. Sharers with write access to part of a document are checked against the whole document.
+// @Description
+// @Description  **Autosave:** When the server runs with `-coalesce-window`, an update made within that window of the document's last update replaces that version rather than adding one. Changes are saved to disk shortly after; add `?sync=true` to have the response wait until the database file has been saved and synced.
 // @Tags         Documents
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
 // @Param        id       path      string                true  "The unique identifier of the document to update." example(doc_abc123xyz)
+// @Param        sync     query     bool                  false "Respond only once the change has been saved and synced to disk." default(false)
+// @Param        upsert   query     bool                  false "Create the document with this ID if it does not exist." default(false)
+// @Param        create   query     bool                  false "Alias for 'upsert'." default(false)
+// @Param        If-None-Match header string              false "Set to '*' to create the document only if it does not already exist."
+// @Param        If-Content-Matches header string         false "Update only if the current content matches this content query part, e.g. 'status eq \"draft\"'; repeat for more parts."
 // @Param        document body      UpdateDocumentRequest true  "The new JSON content to replace the existing document content."
-// @Success      200      {object}  models.Document       "Document Updated Successfully. The response body contains the complete document with the updated content and modification timestamp."
-// @Failure      400      {object}  utils.APIError   "Bad Request: The document ID in the path is missing/invalid, or the request body is invalid (must contain 'content' field with valid JSON)."
-// @Failure      401      {object}  utils.APIError   "Unauthorized: Your access token is missing, invalid, or expired."
-// @Failure      403      {object}  utils.APIError   "Forbidden: You are not the owner of this document, so you cannot update it."
-// @Failure      404      {object}  utils.APIError   "Not Found: No document exists with the specified ID."
-// @Failure      500      {object}  utils.APIError   "Internal Server Error: Something went wrong on the server while updating the document."
+// @Success      200      {object}  utils.Envelope{data=models.Document}       "Document Updated Successfully. The response body contains the complete document with the updated content and modification timestamp."
+// @Success      201      {object}  utils.Envelope{data=models.Document}       "Document Created: The document did not exist and an upsert was requested ('?upsert=true' or 'If-None-Match: *')."
+// @Failure      400      {object}  utils.ErrorEnvelope   "Bad Request: The document ID in the path is missing/invalid, or the request body is invalid (must contain 'content' field with valid JSON, fitting the content type)."
+// @Failure      401      {object}  utils.ErrorEnvelope   "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403      {object}  utils.ErrorEnvelope   "Forbidden: You are not the owner of this document, nor were you given write access to part of it, so you cannot update it."
+// @Failure      404      {object}  utils.ErrorEnvelope   "Not Found: No document exists with the specified ID (and no upsert was requested)."
+// @Failure      412      {object}  utils.ErrorEnvelope   "Precondition Failed: 'If-None-Match: *' was sent but the document already exists, or its content does not match 'If-Content-Matches'."
+// @Failure      422      {object}  utils.ErrorEnvelope   "Unprocessable Entity: A document script rejected the new content."
+// @Failure      423      {object}  utils.ErrorEnvelope   "Locked: The document is frozen (see POST /documents/{id}/freeze)."
+// @Failure      500      {object}  utils.ErrorEnvelope   "Internal Server Error: Something went wrong on the server while updating the document, or, with '?sync=true', while saving it to disk."
 // @Router       /documents/{id} [put]
 func UpdateDocumentHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		utils.GinInternalServerError(c, "User ID not found in context.")
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
 		return
 	}
 	userIDStr := userID.(string)
 	docID := c.Param("id")
 
 	if docID == "" {
-		utils.GinBadRequest(c, "Document ID is required in the path.")
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgDocumentIDRequired)
 		return
 	}
 
 	// Bind request body
 	var req UpdateDocumentRequest
-	if err := c.BindJSON(&req); err != nil {
-		utils.GinBadRequest(c, fmt.Sprintf("Invalid request body: %v. 'content' must be provided.", err))
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidDocumentBody) {
+		return
+	}
+
+	// Upsert: '?upsert=true' (or its alias '?create=true') creates a missing document,
+	// 'If-None-Match: *' creates it only if it does not exist yet
+	createOnly := strings.TrimSpace(c.GetHeader("If-None-Match")) == "*"
+	upsert := c.Query("upsert") == "true" || c.Query("create") == "true"
+	// 'If-Content-Matches' makes the update conditional on the current content
+	matches, ok := contentCondition(c)
+	if !ok {
+		return
+	}
+	if req.Links != nil && !checkDocumentLinks(c, database, cfg, docID, *req.Links) {
 		return
 	}
 
 	// Authorization Check: Only owner can update
 	existingDoc, found := database.GetDocumentByID(docID)
 	if !found {
-		utils.GinNotFound(c, fmt.Sprintf("Document with ID '%s' not found.", docID))
+		if matches != nil && (upsert || createOnly) {
+			utils.GinLocalizedError(c, http.StatusPreconditionFailed, i18n.MsgContentConditionFailed, docID)
+			return
+		}
+		if upsert || createOnly {
+			doc := models.Document{ID: docID, OwnerID: userIDStr, Content: req.Content, Public: req.Public != nil && *req.Public}
+			if req.ContentType != nil {
+				doc.ContentType = *req.ContentType
+			}
+			if req.Links != nil {
+				doc.Links = *req.Links
+			}
+			createDocumentWithID(c, database, doc)
+			return
+		}
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgDocumentNotFound, docID)
+		return
+	}
+	if createOnly {
+		utils.GinLocalizedError(c, http.StatusPreconditionFailed, i18n.MsgDocumentExistsCondition, docID)
 		return
 	}
-	if existingDoc.OwnerID != userIDStr {
-		utils.GinForbidden(c, "You do not have permission to update this document.")
+	// Besides the owner, sharers with write access to part of the content may replace that part
+	scopedUpdate := !can(c, database, cfg, authz.UpdateDocument, existingDoc)
+	if scopedUpdate && (req.Public != nil || req.ContentType != nil || req.Links != nil || !can(c, database, cfg, authz.UpdateScopedContent, existingDoc)) {
+		utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgDocumentUpdateDenied)
 		return
 	}
 
 	// Perform update in database
-	updatedDoc, err := database.UpdateDocument(docID, req.Content)
+	var updatedDoc models.Document
+	var err error
+	pre := db.Precondition{Matches: matches}
+	if scopedUpdate {
+		updatedDoc, err = database.UpdateScopedContentIf(docID, userIDStr, req.Content, pre)
+	} else {
+		updatedDoc, err = database.UpdateDocumentIf(docID, req.Content, req.ContentType, pre)
+	}
 	if err != nil {
+		if respondScriptError(c, err) || respondFrozen(c, err) {
+			return
+		}
+		if errors.Is(err, apperr.ErrPrecondition) {
+			utils.GinErrorFromErr(c, http.StatusPreconditionFailed, err)
+			return
+		}
 		// Should only be "not found" if deleted between check and update, but handle anyway
-		if strings.Contains(strings.ToLower(err.Error()), "not found") {
-			utils.GinNotFound(c, err.Error())
+		if errors.Is(err, apperr.ErrNotFound) {
+			utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgDocumentNotFound, docID)
+		} else if errors.Is(err, apperr.ErrForbidden) { // Not the owner, nor a sharer with write access
+			utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgDocumentUpdateDenied)
+		} else if errors.Is(err, apperr.ErrValidation) {
+			utils.GinErrorFromErr(c, http.StatusBadRequest, err)
+		} else {
+			utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgDocumentUpdateFailed, err)
+		}
+		return
+	}
+	if req.Public != nil {
+		updatedDoc, err = database.SetDocumentPublic(docID, *req.Public)
+		if err != nil {
+			utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgDocumentUpdateFailed, err)
+			return
+		}
+	}
+	if req.Links != nil {
+		updatedDoc, err = database.SetDocumentLinks(docID, *req.Links)
+		if err != nil {
+			utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgDocumentUpdateFailed, err)
+			return
+		}
+	}
+
+	// '?sync=true' waits for the change to be on disk rather than for the debounced save
+	if c.Query("sync") == "true" {
+		if err := database.PersistNow(); err != nil {
+			utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgDocumentSyncFailed, err)
+			return
+		}
+	}
+
+	utils.RespondData(c, http.StatusOK, updatedDoc)
+}
+
+// createDocumentWithID creates a document under a caller-chosen ID and writes the
+// 201 response. Used by PUT upserts when the target document does not exist yet.
+func createDocumentWithID(c *gin.Context, database *db.Database, doc models.Document) {
+	if !utils.IsValidCustomID(doc.ID) {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgInvalidDocumentID)
+		return
+	}
+	if utils.IsKeyDerivedID(doc.ID) {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgKeyDerivedID)
+		return
+	}
+
+	createdDoc, err := database.CreateDocument(doc)
+	if err != nil {
+		if respondScriptError(c, err) {
+			return
+		}
+		// Another request created it between our lookup and insert
+		if errors.Is(err, apperr.ErrConflict) {
+			utils.GinErrorFromErr(c, http.StatusConflict, err)
+		} else if errors.Is(err, apperr.ErrValidation) {
+			utils.GinErrorFromErr(c, http.StatusBadRequest, err)
 		} else {
-			utils.GinInternalServerError(c, fmt.Sprintf("Failed to update document: %v", err))
+			utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgDocumentCreateFailed, err)
 		}
 		return
 	}
 
-	c.JSON(http.StatusOK, updatedDoc)
+	utils.RespondData(c, http.StatusCreated, createdDoc)
 }
 
 // --- Delete Document ---
@@ -349,23 +717,23 @@ func UpdateDocumentHandler(c *gin.Context, database *db.Database, cfg *config.Co
 // @Security     BearerAuth
 // @Param        id   path      string  true  "The unique identifier of the document to delete." example(doc_abc123xyz)
 // @Success      204  "Document Deleted Successfully. No content is returned in the response body because the resource no longer exists."
-// @Failure      400  {object}  utils.APIError "Bad Request: The document ID provided in the URL path is missing or invalid."
-// @Failure      401  {object}  utils.APIError "Unauthorized: Your access token is missing, invalid, or expired."
-// @Failure      403  {object}  utils.APIError "Forbidden: You are not the owner of this document, so you cannot delete it."
-// @Failure      404  {object}  utils.APIError "Not Found: No document exists with the specified ID. (Note: The API might return 204 even if not found, treating deletion of a non-existent item as success)."
-// @Failure      500  {object}  utils.APIError "Internal Server Error: Something went wrong on the server while deleting the document."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The document ID provided in the URL path is missing or invalid."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not the owner of this document, so you cannot delete it."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No document exists with the specified ID. (Note: The API might return 204 even if not found, treating deletion of a non-existent item as success)."
+// @Failure      422  {object}  utils.ErrorEnvelope "Unprocessable Entity: A document script rejected the deletion."
+// @Failure      500  {object}  utils.ErrorEnvelope "Internal Server Error: Something went wrong on the server while deleting the document."
 // @Router       /documents/{id} [delete]
 func DeleteDocumentHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
-	userID, exists := c.Get("userID")
+	_, exists := c.Get("userID")
 	if !exists {
-		utils.GinInternalServerError(c, "User ID not found in context.")
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
 		return
 	}
-	userIDStr := userID.(string)
 	docID := c.Param("id")
 
 	if docID == "" {
-		utils.GinBadRequest(c, "Document ID is required in the path.")
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgDocumentIDRequired)
 		return
 	}
 
@@ -377,20 +745,23 @@ func DeleteDocumentHandler(c *gin.Context, database *db.Database, cfg *config.Co
 		c.Status(http.StatusNoContent)
 		return
 	}
-	if existingDoc.OwnerID != userIDStr {
-		utils.GinForbidden(c, "You do not have permission to delete this document.")
+	if !can(c, database, cfg, authz.DeleteDocument, existingDoc) {
+		utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgDocumentDeleteDenied)
 		return
 	}
 
 	// Perform delete in database (handles associated share record deletion)
 	err := database.DeleteDocument(docID)
 	if err != nil {
+		if respondScriptError(c, err) {
+			return
+		}
 		// Should only be "not found" if deleted between check and delete.
-		if strings.Contains(strings.ToLower(err.Error()), "not found") {
+		if errors.Is(err, apperr.ErrNotFound) {
 			// Already handled above by returning 204 if initially not found.
 			// If it's not found *here*, something odd happened, but 204 is still okay.
 		} else {
-			utils.GinInternalServerError(c, fmt.Sprintf("Failed to delete document: %v", err))
+			utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgDocumentDeleteFailed, err)
 			return // Return 500 if delete fails unexpectedly
 		}
 	}
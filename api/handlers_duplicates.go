@@ -0,0 +1,74 @@
+package api
+
+import (
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/utils"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Near-Duplicate Detection ---
+
+// DuplicateClusterResponse is a group of near-identical documents.
+type DuplicateClusterResponse struct {
+	Documents  []GraphNode        `json:"documents"`  // Oldest first
+	Similarity float64            `json:"similarity"` // Highest similarity of a pair in the cluster, from 0 to 1
+	Pairs      []db.DuplicatePair `json:"pairs"`      // The pairs at or above the threshold, most similar first
+}
+
+// FindDuplicatesHandler reports clusters of near-identical documents among the user's documents.
+// @Summary      Find Near-Duplicate Documents
+// @Description  Compares the text of your documents and groups those that are nearly identical, e.g. to spot copied assignment submissions. The strings, numbers and booleans of each document (not its keys) are split into words, and runs of 3 words are hashed ("shingles"). Two documents' similarity is the share of shingles they have in common (Jaccard similarity), from 0 to 1; documents at or above `threshold` are paired, and pairs sharing a document form a cluster.
+// @Description
+// @Description  Shared documents are compared on the part shared with you. Documents without any words are skipped. Clusters come most similar first.
+// @Tags         Documents
+// @Produce      json
+// @Security     BearerAuth
+// @Param        threshold  query     number  false  "Smallest similarity reported, between 0.5 and 1." default(0.8)
+// @Param        scope      query     string  false  "Documents to compare: 'owned', 'shared' with you, or 'all' of them." Enums(owned, shared, all) default(all)
+// @Success      200  {object}  utils.Envelope{data=[]DuplicateClusterResponse} "The clusters of near-identical documents."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: 'threshold' or 'scope' is invalid."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Router       /documents/duplicates [get]
+func FindDuplicatesHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+
+	threshold := db.DefaultDuplicateThreshold
+	if raw := strings.TrimSpace(c.Query("threshold")); raw != "" {
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil || parsed < db.MinDuplicateThreshold || parsed > 1 {
+			utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgDuplicateThresholdInvalid, raw, db.MinDuplicateThreshold)
+			return
+		}
+		threshold = parsed
+	}
+	scope := strings.ToLower(c.DefaultQuery("scope", "all"))
+	if scope != "owned" && scope != "shared" && scope != "all" {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgDuplicateScopeInvalid, scope)
+		return
+	}
+
+	clusters, err := database.FindDuplicates(userID.(string), scope, threshold)
+	if err != nil {
+		utils.GinErrorFromErr(c, http.StatusInternalServerError, err)
+		return
+	}
+	response := make([]DuplicateClusterResponse, len(clusters))
+	for i, cluster := range clusters {
+		documents := make([]GraphNode, len(cluster.Documents))
+		for j, doc := range cluster.Documents {
+			documents[j] = graphNodeFor(doc)
+		}
+		response[i] = DuplicateClusterResponse{Documents: documents, Similarity: cluster.Similarity, Pairs: cluster.Pairs}
+	}
+	utils.RespondData(c, http.StatusOK, response)
+}
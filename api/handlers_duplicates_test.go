@@ -0,0 +1,59 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindDuplicatesHandler(t *testing.T) {
+	router, _, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	_, _, token := createTestUserAndLogin(t, router, "dupes@example.com", "password123", "Dee", "Dupes")
+	body := "Photosynthesis turns light, water and carbon dioxide into glucose and oxygen inside the chloroplasts of plant cells."
+	var ids []string
+	for _, content := range []gin.H{
+		{"title": "Biology homework", "answer": body},
+		{"title": "Biology homework", "answer": body},
+		{"title": "Shopping", "items": []string{"milk", "eggs", "bread", "coffee"}},
+	} {
+		rr := performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, gin.H{"content": content}), token)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var doc DocumentResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+		ids = append(ids, doc.ID)
+	}
+
+	t.Run("Clusters", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/documents/duplicates", nil, token)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var clusters []DuplicateClusterResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &clusters))
+		require.Len(t, clusters, 1)
+		assert.ElementsMatch(t, []string{ids[0], ids[1]}, []string{clusters[0].Documents[0].ID, clusters[0].Documents[1].ID})
+		assert.Equal(t, "Biology homework", clusters[0].Documents[0].Title)
+		assert.Equal(t, 1.0, clusters[0].Similarity)
+		require.Len(t, clusters[0].Pairs, 1)
+
+		rr = performRequest(router, http.MethodGet, "/documents/duplicates?scope=shared", nil, token)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "[]", rr.Body.String())
+	})
+
+	t.Run("Invalid parameters", func(t *testing.T) {
+		for _, query := range []string{"threshold=abc", "threshold=0.1", "threshold=2", "scope=public"} {
+			rr := performRequest(router, http.MethodGet, "/documents/duplicates?"+query, nil, token)
+			assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+		}
+	})
+
+	t.Run("Requires authentication", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/documents/duplicates", nil, "")
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"docserver/apperr"
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Email Change ---
+
+// emailChangeLifetime is how long the token sent to the new address stays valid.
+const emailChangeLifetime = 24 * time.Hour
+
+// EmailChangeRequest defines the body for requesting an email change.
+type EmailChangeRequest struct {
+	NewEmail string `json:"new_email" binding:"required,email"`
+	Password string `json:"password" binding:"required"` // Current password, to confirm it is really you
+}
+
+// EmailChangeResponse describes a pending email change.
+type EmailChangeResponse struct {
+	NewEmail  string    `json:"new_email"`
+	ExpiresAt time.Time `json:"expires_at"` // UTC; confirm before this time
+}
+
+// ConfirmEmailChangeRequest defines the body for confirming an email change.
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token" binding:"required"` // Token from the confirmation email
+}
+
+// respondEmailChangeError writes the response for a failed email change request or confirmation.
+func respondEmailChangeError(c *gin.Context, err error) {
+	status := apperr.HTTPStatus(err)
+	var msgErr *i18n.Error
+	switch {
+	case errors.As(err, &msgErr) && status != http.StatusInternalServerError:
+		utils.GinErrorFromErr(c, status, err) // Taken address, no pending change, same address, expired or wrong token
+	case status == http.StatusNotFound:
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgProfileNotFound)
+	default:
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgEmailChangeFailed, err)
+	}
+}
+
+// RequestEmailChangeHandler starts changing the authenticated user's email address.
+// @Summary      Request an Email Change
+// @Description  Starts changing the email address you log in with. Send the `new_email` and your current `password`.
+// @Description
+// @Description  A confirmation token is emailed to the new address; your email only changes once you send that token to `POST /profiles/me/email-change/confirm`, within 24 hours. Requesting another change replaces the pending one. Your current address is told about the request.
+// @Tags         Profiles
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        emailChange body EmailChangeRequest true "The new email address and your current password."
+// @Success      202  {object}  utils.Envelope{data=EmailChangeResponse} "Confirmation sent to the new address."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The body is invalid or the new address is your current one."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired, or the password is incorrect."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: Your profile could not be found."
+// @Failure      409  {object}  utils.ErrorEnvelope "Conflict: Another account already uses the new address."
+// @Router       /profiles/me/email-change [post]
+func RequestEmailChangeHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+	userIDStr := userID.(string)
+
+	var req EmailChangeRequest
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidRequestBody) {
+		return
+	}
+	newEmail := strings.TrimSpace(req.NewEmail)
+
+	profile, found := database.GetProfileByID(userIDStr)
+	if !found {
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgProfileNotFound)
+		return
+	}
+	if !utils.CheckPasswordHash(req.Password, profile.PasswordHash) {
+		utils.GinLocalizedError(c, http.StatusUnauthorized, i18n.MsgPasswordIncorrect)
+		return
+	}
+
+	token, err := utils.GenerateToken()
+	if err != nil {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgEmailChangeFailed, err)
+		return
+	}
+	change, err := database.RequestEmailChange(userIDStr, newEmail, utils.HashToken(token), emailChangeLifetime)
+	if err != nil {
+		respondEmailChangeError(c, err)
+		return
+	}
+
+	utils.SendEmail(change.NewEmail, "Confirm your new email address", fmt.Sprintf(
+		"Someone asked to use this address for their docserver account.\n"+
+			"To confirm, send this token to POST /profiles/me/email-change/confirm before %s:\n%s\n"+
+			"If this was not you, ignore this email.",
+		change.ExpiresAt.Format(time.RFC1123), token))
+	utils.SendEmail(profile.Email, "Email change requested", fmt.Sprintf(
+		"A change of your account's email address to %s was requested. It takes effect once confirmed from the new address.\n"+
+			"If this was not you, change your password.", change.NewEmail))
+
+	utils.RespondData(c, http.StatusAccepted, EmailChangeResponse{NewEmail: change.NewEmail, ExpiresAt: change.ExpiresAt})
+}
+
+// ConfirmEmailChangeHandler applies the authenticated user's pending email change.
+// @Summary      Confirm an Email Change
+// @Description  Applies your pending email change using the `token` emailed to the new address by `POST /profiles/me/email-change`. From then on you log in with the new address; your previous address is notified.
+// @Tags         Profiles
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        confirmation body ConfirmEmailChangeRequest true "The token from the confirmation email."
+// @Success      200  {object}  utils.Envelope{data=ProfileResponse} "Email changed. Your updated profile is returned."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The body is invalid, or the token is wrong or has expired."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: There is no pending email change."
+// @Failure      409  {object}  utils.ErrorEnvelope "Conflict: Another account started using the new address in the meantime."
+// @Router       /profiles/me/email-change/confirm [post]
+func ConfirmEmailChangeHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+	userIDStr := userID.(string)
+
+	var req ConfirmEmailChangeRequest
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidRequestBody) {
+		return
+	}
+
+	profile, oldEmail, err := database.ConfirmEmailChange(userIDStr, utils.HashToken(strings.TrimSpace(req.Token)))
+	if err != nil {
+		respondEmailChangeError(c, err)
+		return
+	}
+
+	utils.SendEmail(oldEmail, "Your email address was changed", fmt.Sprintf(
+		"Your account's email address was changed to %s. If this was not you, contact an administrator.", profile.Email))
+	utils.RespondData(c, http.StatusOK, profileResponseFor(profile, userIDStr, nil))
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailChangeEndpoints(t *testing.T) {
+	router, _, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	_, _, token := createTestUserAndLogin(t, router, "change.me@example.com", "password123", "Change", "Me")
+	createTestUserAndLogin(t, router, "change.taken@example.com", "password123", "Change", "Taken")
+
+	login := func(email string) int {
+		return performRequest(router, http.MethodPost, "/auth/login", marshalJSONBody(t, gin.H{"email": email, "password": "password123"}), "").Code
+	}
+
+	// The confirmation token only reaches the new address, i.e. the server log.
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	tokenPattern := regexp.MustCompile(`(?m)^.*  ([0-9a-f]{64})$`)
+
+	t.Run("Request is validated", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/profiles/me/email-change", marshalJSONBody(t, gin.H{
+			"new_email": "change.new@example.com", "password": "wrong-password",
+		}), token)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+		rr = performRequest(router, http.MethodPost, "/profiles/me/email-change", marshalJSONBody(t, gin.H{
+			"new_email": "change.taken@example.com", "password": "password123",
+		}), token)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+
+		rr = performRequest(router, http.MethodPost, "/profiles/me/email-change", marshalJSONBody(t, gin.H{
+			"new_email": "change.me@example.com", "password": "password123",
+		}), token)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = performRequest(router, http.MethodPost, "/profiles/me/email-change/confirm", marshalJSONBody(t, gin.H{"token": "abc"}), token)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	var confirmation string
+	t.Run("Request", func(t *testing.T) {
+		logs.Reset()
+		rr := performRequest(router, http.MethodPost, "/profiles/me/email-change", marshalJSONBody(t, gin.H{
+			"new_email": "change.new@example.com", "password": "password123",
+		}), token)
+		require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+		var response EmailChangeResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, "change.new@example.com", response.NewEmail)
+
+		assert.Contains(t, logs.String(), "EMAIL to change.new@example.com")
+		assert.Contains(t, logs.String(), "EMAIL to change.me@example.com")
+		match := tokenPattern.FindStringSubmatch(logs.String())
+		require.NotNil(t, match, "confirmation token not found in:\n%s", logs.String())
+		confirmation = match[1]
+
+		assert.Equal(t, http.StatusOK, login("change.me@example.com"), "nothing changes before confirmation")
+	})
+
+	t.Run("Wrong token", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/v1/profiles/me/email-change/confirm", marshalJSONBody(t, gin.H{"token": "abc"}), token)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"message_id":"email_change_mismatch"`)
+	})
+
+	t.Run("Confirm", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/profiles/me/email-change/confirm", marshalJSONBody(t, gin.H{"token": confirmation}), token)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var profile ProfileResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &profile))
+		assert.Equal(t, "change.new@example.com", profile.Email)
+
+		assert.Equal(t, http.StatusUnauthorized, login("change.me@example.com"))
+		assert.Equal(t, http.StatusOK, login("change.new@example.com"))
+
+		rr = performRequest(router, http.MethodPost, "/profiles/me/email-change/confirm", marshalJSONBody(t, gin.H{"token": confirmation}), token)
+		assert.Equal(t, http.StatusNotFound, rr.Code, "tokens are single-use")
+	})
+}
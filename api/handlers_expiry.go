@@ -0,0 +1,105 @@
+package api
+
+import (
+	"docserver/apperr"
+	"docserver/authz"
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/models"
+	"docserver/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Document Expiry ---
+
+// DocumentExpiry surfaces how long a document that expires has left.
+type DocumentExpiry struct {
+	TTLSeconds *int64 `json:"ttl_seconds,omitempty"` // Seconds until expires_at, 0 once it has passed; only on documents that expire
+}
+
+// documentExpiry returns the expiry details of doc as of now.
+func documentExpiry(doc models.Document, now time.Time) DocumentExpiry {
+	ttl, expires := db.DocumentTTL(doc, now)
+	if !expires {
+		return DocumentExpiry{}
+	}
+	seconds := int64(ttl / time.Second)
+	return DocumentExpiry{TTLSeconds: &seconds}
+}
+
+// DocumentExpiryRequest defines the body for setting when a document expires.
+type DocumentExpiryRequest struct {
+	ExpiresAt *time.Time `json:"expires_at" binding:"required"` // RFC 3339, in the future
+}
+
+// SetDocumentExpiryHandler sets when a document expires.
+// @Summary      Set When a Document Expires
+// @Description  Makes a document expire at `expires_at` (RFC 3339, in the future), replacing any earlier time. From then on the document is gone for everyone: every request for it gets `404 Not Found` and it is left out of document lists, unless they ask for it with `?expired=include` or `?expired=only`. After the server's grace period (`-expiry-grace-period`, a week by default) it is deleted for good, with its versions, activity and shares.
+// @Description
+// @Description  The document's owner and administrators can set the time. The response includes `ttl_seconds`, the seconds the document has left. Use `DELETE /documents/{id}/expiry` to keep it for good again; an expired document cannot be brought back.
+// @Tags         Documents
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id      path      string                 true  "The unique identifier of the document." example(doc_abc123xyz)
+// @Param        expiry  body      DocumentExpiryRequest  true  "When the document expires."
+// @Success      200  {object}  utils.Envelope{data=DocumentResponse} "The document with its new expiry time."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: 'expires_at' is missing, malformed or not in the future."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are neither the owner of this document nor an administrator."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No document exists with the specified ID, or it has expired."
+// @Router       /documents/{id}/expiry [put]
+func SetDocumentExpiryHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	var req DocumentExpiryRequest
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidRequestBody) {
+		return
+	}
+	setDocumentExpiry(c, database, cfg, req.ExpiresAt)
+}
+
+// ClearDocumentExpiryHandler makes a document never expire.
+// @Summary      Keep a Document for Good
+// @Description  Removes the expiry time of a document (see `PUT /documents/{id}/expiry`), so it is kept until it is deleted. The document's owner and administrators can do this until the document expires. Removing the expiry time of a document that has none changes nothing.
+// @Tags         Documents
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "The unique identifier of the document." example(doc_abc123xyz)
+// @Success      200  {object}  utils.Envelope{data=DocumentResponse} "The document, which no longer expires."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are neither the owner of this document nor an administrator."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No document exists with the specified ID, or it has expired."
+// @Router       /documents/{id}/expiry [delete]
+func ClearDocumentExpiryHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	setDocumentExpiry(c, database, cfg, nil)
+}
+
+// setDocumentExpiry sets or clears the expiry time of the document in the path for its owner or an administrator.
+func setDocumentExpiry(c *gin.Context, database *db.Database, cfg *config.Config, expiresAt *time.Time) {
+	_, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+	docID := c.Param("id")
+
+	doc, found := database.GetDocumentByID(docID)
+	if !found {
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgDocumentNotFound, docID)
+		return
+	}
+	if !can(c, database, cfg, authz.SetDocumentExpiry, doc) {
+		utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgDocumentExpiryDenied)
+		return
+	}
+
+	updatedDoc, err := database.SetDocumentExpiry(docID, expiresAt)
+	if err != nil {
+		utils.GinErrorFromErr(c, apperr.HTTPStatus(err), err)
+		return
+	}
+	utils.RespondData(c, http.StatusOK, DocumentResponse{Document: updatedDoc, DocumentExpiry: documentExpiry(updatedDoc, cfg.Now())})
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"docserver/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentExpiry(t *testing.T) {
+	router, _, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	clock := config.NewFixedClock(time.Date(2025, time.June, 1, 9, 0, 0, 0, time.UTC))
+	cfg.Clock = clock
+	cfg.TokenLifetime = 24 * time.Hour // Outlives the expiring documents
+
+	_, _, ownerToken := createTestUserAndLogin(t, router, "expiry.owner@example.com", "password123", "Expiry", "Owner")
+	_, _, otherToken := createTestUserAndLogin(t, router, "expiry.other@example.com", "password123", "Expiry", "Other")
+
+	expiresAt := clock.Now().Add(time.Hour)
+	rr := performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, map[string]any{"content": map[string]any{"title": "scratch"}, "expires_at": expiresAt}), ownerToken)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var doc DocumentResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+	require.NotNil(t, doc.TTLSeconds)
+	assert.Equal(t, int64(3600), *doc.TTLSeconds)
+	expiryPath := "/documents/" + doc.ID + "/expiry"
+
+	rr = performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, map[string]any{"content": "x", "expires_at": clock.Now().Add(-time.Hour)}), ownerToken)
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "expires_at in the past")
+
+	t.Run("Only the owner or an administrator", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPut, expiryPath, marshalJSONBody(t, map[string]any{"expires_at": expiresAt}), otherToken)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		rr = performRequest(router, http.MethodPut, "/documents/doc_missing/expiry", marshalJSONBody(t, map[string]any{"expires_at": expiresAt}), ownerToken)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Owner moves the expiry time", func(t *testing.T) {
+		later := clock.Now().Add(2 * time.Hour)
+		rr := performRequest(router, http.MethodPut, expiryPath, marshalJSONBody(t, map[string]any{"expires_at": later}), ownerToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Contains(t, rr.Body.String(), `"ttl_seconds":7200`)
+
+		rr = performRequest(router, http.MethodPut, expiryPath, marshalJSONBody(t, map[string]any{"expires_at": clock.Now()}), ownerToken)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		rr = performRequest(router, http.MethodPut, expiryPath, marshalJSONBody(t, map[string]any{}), ownerToken)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = performRequest(router, http.MethodGet, "/documents/"+doc.ID, nil, ownerToken)
+		assert.Contains(t, rr.Body.String(), `"ttl_seconds":7200`)
+	})
+
+	t.Run("Expired documents are gone", func(t *testing.T) {
+		clock.Advance(2 * time.Hour)
+		rr := performRequest(router, http.MethodGet, "/documents/"+doc.ID, nil, ownerToken)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		rr = performRequest(router, http.MethodDelete, expiryPath, nil, ownerToken)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		rr = performRequest(router, http.MethodGet, "/v1/documents", nil, ownerToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.NotContains(t, rr.Body.String(), doc.ID)
+		rr = performRequest(router, http.MethodGet, "/v1/documents?expired=only", nil, ownerToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), doc.ID)
+		assert.Contains(t, rr.Body.String(), `"ttl_seconds":0`)
+		rr = performRequest(router, http.MethodGet, "/v1/documents?expired=never", nil, ownerToken)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Clearing the expiry time keeps a document", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, map[string]any{"content": "kept", "expires_at": clock.Now().Add(time.Minute)}), ownerToken)
+		require.Equal(t, http.StatusCreated, rr.Code)
+		var kept DocumentResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &kept))
+
+		rr = performRequest(router, http.MethodDelete, "/documents/"+kept.ID+"/expiry", nil, ownerToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.NotContains(t, rr.Body.String(), "expires_at")
+		assert.NotContains(t, rr.Body.String(), "ttl_seconds")
+		clock.Advance(time.Hour)
+		rr = performRequest(router, http.MethodGet, "/documents/"+kept.ID, nil, ownerToken)
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"docserver/apperr"
+	"docserver/authz"
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/utils"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Favorites ---
+
+// FavoriteResponse reports whether a document is among the user's favorites.
+type FavoriteResponse struct {
+	DocumentID string `json:"document_id"`
+	Favorite   bool   `json:"favorite"`
+}
+
+// AddFavoriteHandler bookmarks a document for the authenticated user.
+// @Summary      Mark a Document as Favorite
+// @Description  Bookmarks a document you use often. You can mark any document you can read: your own, ones shared with you, and public ones.
+// @Description  List your favorites with `GET /documents?favorites=true`. Marking a document that is already a favorite has no effect.
+// @Tags         Documents
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "The unique identifier of the document."
+// @Success      200  {object}  utils.Envelope{data=FavoriteResponse} "The document is now one of your favorites."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You do not have permission to access this document."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No document exists with the specified ID."
+// @Failure      500  {object}  utils.ErrorEnvelope "Internal Server Error: The favorite could not be saved."
+// @Router       /documents/{id}/favorite [post]
+func AddFavoriteHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+	userIDStr := userID.(string)
+	docID := c.Param("id")
+
+	doc, found := database.GetDocumentByID(docID)
+	if !found {
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgDocumentNotFound, docID)
+		return
+	}
+	if !can(c, database, cfg, authz.ReadDocument, doc) {
+		utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgDocumentAccessDenied)
+		return
+	}
+
+	if _, err := database.AddFavorite(userIDStr, docID); err != nil {
+		// Only "not found" if deleted between the lookup and the update
+		if errors.Is(err, apperr.ErrNotFound) {
+			utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgDocumentNotFound, docID)
+		} else {
+			utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgFavoriteFailed, err)
+		}
+		return
+	}
+
+	utils.RespondData(c, http.StatusOK, FavoriteResponse{DocumentID: docID, Favorite: true})
+}
+
+// RemoveFavoriteHandler removes a document from the authenticated user's favorites.
+// @Summary      Remove a Document from Favorites
+// @Description  Removes the bookmark on a document. Removing a document that is not a favorite has no effect.
+// @Tags         Documents
+// @Security     BearerAuth
+// @Param        id   path      string  true  "The unique identifier of the document."
+// @Success      204  "The document is no longer one of your favorites."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Router       /documents/{id}/favorite [delete]
+func RemoveFavoriteHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+
+	database.RemoveFavorite(userID.(string), c.Param("id"))
+	c.Status(http.StatusNoContent)
+}
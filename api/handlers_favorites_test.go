@@ -0,0 +1,95 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"docserver/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFavorites(t *testing.T) {
+	router, database, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	ownerID, _, ownerToken := createTestUserAndLogin(t, router, "fav.owner@example.com", "password123", "Fav", "Owner")
+	readerID, _, readerToken := createTestUserAndLogin(t, router, "fav.reader@example.com", "password123", "Fav", "Reader")
+
+	mine, err := database.CreateDocument(models.Document{OwnerID: readerID, Content: "mine"})
+	require.NoError(t, err)
+	shared, err := database.CreateDocument(models.Document{OwnerID: ownerID, Content: "shared"})
+	require.NoError(t, err)
+	require.NoError(t, database.SetShareRecord(shared.ID, []string{readerID}))
+	private, err := database.CreateDocument(models.Document{OwnerID: ownerID, Content: "private"})
+	require.NoError(t, err)
+
+	listFavorites := func(query string) []DocumentListItem {
+		rr := performRequest(router, http.MethodGet, "/documents"+query, nil, readerToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var resp GetDocumentsResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		return resp.Data
+	}
+
+	t.Run("Favorite a shared document", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/documents/"+shared.ID+"/favorite", nil, readerToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var resp FavoriteResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, FavoriteResponse{DocumentID: shared.ID, Favorite: true}, resp)
+	})
+
+	t.Run("Cannot favorite inaccessible or missing documents", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/documents/"+private.ID+"/favorite", nil, readerToken)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		rr = performRequest(router, http.MethodPost, "/documents/missing/favorite", nil, readerToken)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("List flags favorites", func(t *testing.T) {
+		docs := listFavorites("")
+		require.Len(t, docs, 2)
+		flags := map[string]bool{}
+		for _, d := range docs {
+			flags[d.ID] = d.Favorite
+		}
+		assert.Equal(t, map[string]bool{mine.ID: false, shared.ID: true}, flags)
+	})
+
+	t.Run("List only favorites", func(t *testing.T) {
+		docs := listFavorites("?favorites=true")
+		require.Len(t, docs, 1)
+		assert.Equal(t, shared.ID, docs[0].ID)
+		assert.True(t, docs[0].Favorite)
+
+		assert.Empty(t, listFavorites("?favorites=true&scope=owned"))
+	})
+
+	t.Run("Favorites are per user", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/documents?favorites=true", nil, ownerToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp GetDocumentsResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Empty(t, resp.Data)
+	})
+
+	t.Run("Remove favorite", func(t *testing.T) {
+		rr := performRequest(router, http.MethodDelete, "/documents/"+shared.ID+"/favorite", nil, readerToken)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		rr = performRequest(router, http.MethodDelete, "/documents/"+shared.ID+"/favorite", nil, readerToken)
+		assert.Equal(t, http.StatusNoContent, rr.Code, "removing again is harmless")
+		assert.Empty(t, listFavorites("?favorites=true"))
+	})
+
+	t.Run("Envelope list carries the flag", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/v1/documents/"+mine.ID+"/favorite", marshalJSONBody(t, gin.H{}), readerToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+		rr = performRequest(router, http.MethodGet, "/v1/documents?favorites=true", nil, readerToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"favorite":true`)
+	})
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/models"
+	"docserver/utils"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Fetch and Store ---
+// Only registered when the server has fetch domains configured (see config.FetchAllowedDomains).
+
+// FetchDocumentRequest defines the body for creating a document from a URL.
+type FetchDocumentRequest struct {
+	URL    string `json:"url" binding:"required"` // http(s) URL of a JSON resource on an allowed domain
+	Public bool   `json:"public,omitempty"`       // Make the new document readable by anyone
+}
+
+// FetchDocumentHandler downloads JSON from an allow-listed URL and stores it as a new document.
+// @Summary      Create a Document from a URL
+// @Description  Downloads JSON from a URL on the server side and stores it as a new document you own. Handy for importing data from web APIs that cannot be called from the browser because of CORS.
+// @Description
+// @Description  Only available when the server has allowed domains configured (`DOCSERVER_FETCH_ALLOWED_DOMAINS`); the URL's host must be one of them or a subdomain of one, and redirects are only followed to allowed hosts.
+// @Description  The response must have a JSON content type (`application/json` or `*+json`), fit within the size limit (`DOCSERVER_FETCH_MAX_BYTES`) and arrive within the time limit (`DOCSERVER_FETCH_TIMEOUT`).
+// @Tags         Documents
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        fetch body FetchDocumentRequest true "The URL to import."
+// @Success      201  {object}  utils.Envelope{data=models.Document} "The fetched JSON, stored as a new document."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The body is invalid or the URL is not an absolute http(s) URL."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: The URL's host is not an allowed domain."
+// @Failure      422  {object}  utils.ErrorEnvelope "Unprocessable Entity: A document script rejected the content."
+// @Failure      502  {object}  utils.ErrorEnvelope "Bad Gateway: The remote server failed, or returned something other than JSON within the size limit."
+// @Failure      504  {object}  utils.ErrorEnvelope "Gateway Timeout: The remote server did not answer in time."
+// @Router       /documents/fetch [post]
+func FetchDocumentHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+
+	var req FetchDocumentRequest
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidRequestBody) {
+		return
+	}
+	target, err := utils.ParseFetchURL(req.URL)
+	if err != nil {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgFetchURLInvalid, req.URL, err)
+		return
+	}
+	if !utils.HostAllowed(target.Hostname(), cfg.FetchAllowedDomains) {
+		utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgFetchDomainNotAllowed, target.Hostname(), strings.Join(cfg.FetchAllowedDomains, ", "))
+		return
+	}
+
+	content, err := utils.FetchJSON(c.Request.Context(), target, utils.FetchOptions{
+		AllowedDomains: cfg.FetchAllowedDomains,
+		MaxBytes:       cfg.FetchMaxBytes,
+		Timeout:        cfg.FetchTimeout,
+	})
+	if err != nil {
+		if errors.Is(err, utils.ErrFetchTimeout) {
+			utils.GinLocalizedError(c, http.StatusGatewayTimeout, i18n.MsgFetchTimeout, target.String(), cfg.FetchTimeout)
+		} else {
+			utils.GinLocalizedError(c, http.StatusBadGateway, i18n.MsgFetchFailed, target.String(), err)
+		}
+		return
+	}
+
+	createdDoc, err := database.CreateDocument(models.Document{OwnerID: userID.(string), Content: content, Public: req.Public})
+	if err != nil {
+		if !respondScriptError(c, err) {
+			utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgDocumentCreateFailed, err)
+		}
+		return
+	}
+	log.Printf("INFO: Document ID %s fetched from %s", createdDoc.ID, target.Redacted())
+	utils.RespondData(c, http.StatusCreated, createdDoc)
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"docserver/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchDocument(t *testing.T) {
+	router, database, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/data.json":
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			_, _ = w.Write([]byte(`{"course":"CS101","students":[1,2,3]}`))
+		case "/page.html":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(`<html></html>`))
+		case "/big.json":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`"` + strings.Repeat("x", 200) + `"`))
+		case "/slow.json":
+			time.Sleep(300 * time.Millisecond)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer remote.Close()
+
+	ownerID, _, token := createTestUserAndLogin(t, router, "fetcher@example.com", "password123", "Fetch", "Er")
+
+	t.Run("Disabled by default", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/documents/fetch", marshalJSONBody(t, gin.H{"url": remote.URL + "/data.json"}), token)
+		assert.NotEqual(t, http.StatusCreated, rr.Code)
+		assert.Empty(t, database.GetDocumentsByOwner(ownerID))
+	})
+
+	// Routes are registered at startup, so build a fresh router once fetching is configured
+	cfg.FetchAllowedDomains = []string{"127.0.0.1"}
+	cfg.FetchMaxBytes = 100
+	cfg.FetchTimeout = 100 * time.Millisecond
+	fetchRouter := gin.New()
+	RegisterRoutes(fetchRouter, database, cfg)
+
+	fetch := func(body gin.H) *httptest.ResponseRecorder {
+		return performRequest(fetchRouter, http.MethodPost, "/v1/documents/fetch", marshalJSONBody(t, body), token)
+	}
+
+	t.Run("Stores fetched JSON", func(t *testing.T) {
+		rr := fetch(gin.H{"url": remote.URL + "/data.json", "public": true})
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var resp struct {
+			Data models.Document `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, ownerID, resp.Data.OwnerID)
+		assert.True(t, resp.Data.Public)
+		assert.Equal(t, map[string]any{"course": "CS101", "students": []any{1.0, 2.0, 3.0}}, resp.Data.Content)
+
+		stored, found := database.GetDocumentByID(resp.Data.ID)
+		require.True(t, found)
+		assert.Equal(t, resp.Data.Content, stored.Content)
+	})
+
+	t.Run("Requires authentication", func(t *testing.T) {
+		rr := performRequest(fetchRouter, http.MethodPost, "/documents/fetch", marshalJSONBody(t, gin.H{"url": remote.URL + "/data.json"}), "")
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("Invalid URL", func(t *testing.T) {
+		for _, target := range []string{"", "not a url", "ftp://127.0.0.1/data.json", "/data.json"} {
+			rr := fetch(gin.H{"url": target})
+			assert.Equal(t, http.StatusBadRequest, rr.Code, target)
+		}
+	})
+
+	t.Run("Domain not allowed", func(t *testing.T) {
+		rr := fetch(gin.H{"url": "http://example.com/data.json"})
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"message_id":"fetch_domain_not_allowed"`)
+	})
+
+	t.Run("Remote failures", func(t *testing.T) {
+		for _, path := range []string{"/missing.json", "/page.html", "/big.json"} {
+			rr := fetch(gin.H{"url": remote.URL + path})
+			assert.Equal(t, http.StatusBadGateway, rr.Code, path)
+			assert.Contains(t, rr.Body.String(), `"message_id":"fetch_failed"`, path)
+		}
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		rr := fetch(gin.H{"url": remote.URL + "/slow.json"})
+		assert.Equal(t, http.StatusGatewayTimeout, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"message_id":"fetch_timeout"`)
+	})
+
+	assert.Len(t, database.GetDocumentsByOwner(ownerID), 1, "failed fetches store nothing")
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"docserver/apperr"
+	"docserver/authz"
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/utils"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Document Freezing ---
+
+// respondFrozen writes 423 Locked if err says the document is frozen, and reports whether it did.
+func respondFrozen(c *gin.Context, err error) bool {
+	if !errors.Is(err, apperr.ErrLocked) {
+		return false
+	}
+	utils.GinErrorFromErr(c, http.StatusLocked, err)
+	return true
+}
+
+// FreezeDocumentHandler makes a document read-only.
+// @Summary      Freeze a Document
+// @Description  Makes a document read-only, for example when an assignment's deadline has passed and submissions must not change any more. While a document is frozen, replacing its content (`PUT /documents/{id}`), making it public or private, and changing who it is shared with all fail with `423 Locked`, and search-and-replace (`POST /documents/replace`) leaves it alone. It can still be read, and deleted by its owner.
+// @Description
+// @Description  The document's owner and administrators can freeze it. The response shows when it was frozen (`frozen_at`) and by whom (`frozen_by`). Freezing a frozen document changes nothing. Use `DELETE /documents/{id}/freeze` to unfreeze it.
+// @Tags         Documents
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "The unique identifier of the document to freeze." example(doc_abc123xyz)
+// @Success      200  {object}  utils.Envelope{data=models.Document} "The frozen document."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are neither the owner of this document nor an administrator."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No document exists with the specified ID."
+// @Router       /documents/{id}/freeze [post]
+func FreezeDocumentHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	setDocumentFrozen(c, database, cfg, true)
+}
+
+// UnfreezeDocumentHandler makes a frozen document writable again.
+// @Summary      Unfreeze a Document
+// @Description  Makes a frozen document writable again (see `POST /documents/{id}/freeze`). The document's owner and administrators can unfreeze it, except that a document an administrator froze can only be unfrozen by an administrator, so students cannot reopen their own locked submissions. Unfreezing a document that is not frozen changes nothing.
+// @Tags         Documents
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "The unique identifier of the document to unfreeze." example(doc_abc123xyz)
+// @Success      200  {object}  utils.Envelope{data=models.Document} "The unfrozen document."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are neither the owner of this document nor an administrator, or an administrator froze it."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No document exists with the specified ID."
+// @Router       /documents/{id}/freeze [delete]
+func UnfreezeDocumentHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	setDocumentFrozen(c, database, cfg, false)
+}
+
+// setDocumentFrozen freezes or unfreezes the document in the path for its owner or an administrator.
+func setDocumentFrozen(c *gin.Context, database *db.Database, cfg *config.Config, frozen bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+	userIDStr := userID.(string)
+	docID := c.Param("id")
+
+	doc, found := database.GetDocumentByID(docID)
+	if !found {
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgDocumentNotFound, docID)
+		return
+	}
+	if !can(c, database, cfg, authz.FreezeDocument, doc) {
+		utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgDocumentFreezeDenied)
+		return
+	}
+	// A freeze by an administrator binds the owner
+	if !frozen && !can(c, database, cfg, authz.UnfreezeDocument, doc) {
+		utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgDocumentUnfreezeDenied)
+		return
+	}
+
+	updatedDoc, err := database.SetDocumentFrozen(docID, frozen, userIDStr)
+	if err != nil {
+		utils.GinErrorFromErr(c, apperr.HTTPStatus(err), err)
+		return
+	}
+	utils.RespondData(c, http.StatusOK, updatedDoc)
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"docserver/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFreezeDocument(t *testing.T) {
+	router, _, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	cfg.AdminEmails = []string{"freeze.teacher@example.com"}
+
+	teacherID, _, teacherToken := createTestUserAndLogin(t, router, "freeze.teacher@example.com", "password123", "Freeze", "Teacher")
+	studentID, _, studentToken := createTestUserAndLogin(t, router, "freeze.student@example.com", "password123", "Freeze", "Student")
+	friendID, _, friendToken := createTestUserAndLogin(t, router, "freeze.friend@example.com", "password123", "Freeze", "Friend")
+
+	rr := performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, map[string]any{"content": map[string]any{"answer": 42}}), studentToken)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var doc models.Document
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+	freezePath := "/documents/" + doc.ID + "/freeze"
+	update := func() int {
+		return performRequest(router, http.MethodPut, "/documents/"+doc.ID, marshalJSONBody(t, map[string]any{"content": map[string]any{"answer": 43}}), studentToken).Code
+	}
+
+	t.Run("Only the owner or an administrator", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, freezePath, nil, friendToken)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		rr = performRequest(router, http.MethodPost, "/documents/doc_missing/freeze", nil, studentToken)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Owner freezes and unfreezes", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, freezePath, nil, studentToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var frozen models.Document
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &frozen))
+		assert.True(t, frozen.Frozen)
+		assert.Equal(t, studentID, frozen.FrozenBy)
+		assert.Equal(t, http.StatusLocked, update())
+
+		rr = performRequest(router, http.MethodDelete, freezePath, nil, studentToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Equal(t, http.StatusOK, update())
+	})
+
+	t.Run("Administrator freeze locks the owner out", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, freezePath, nil, teacherToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var frozen models.Document
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &frozen))
+		assert.Equal(t, teacherID, frozen.FrozenBy)
+
+		assert.Equal(t, http.StatusLocked, update())
+		rr = performRequest(router, http.MethodPut, "/documents/"+doc.ID+"?upsert=true", marshalJSONBody(t, map[string]any{"content": 1}), studentToken)
+		assert.Equal(t, http.StatusLocked, rr.Code, "upserts of existing documents are updates")
+		rr = performRequest(router, http.MethodPut, "/documents/"+doc.ID+"/shares", marshalJSONBody(t, map[string]any{"shared_with": []string{friendID}}), studentToken)
+		assert.Equal(t, http.StatusLocked, rr.Code, rr.Body.String())
+		rr = performRequest(router, http.MethodPut, "/documents/"+doc.ID+"/shares/"+friendID, nil, studentToken)
+		assert.Equal(t, http.StatusLocked, rr.Code)
+		rr = performRequest(router, http.MethodDelete, "/documents/"+doc.ID+"/shares/"+friendID, nil, studentToken)
+		assert.Equal(t, http.StatusLocked, rr.Code)
+
+		rr = performRequest(router, http.MethodGet, "/documents/"+doc.ID, nil, studentToken)
+		assert.Equal(t, http.StatusOK, rr.Code, "frozen documents can be read")
+
+		rr = performRequest(router, http.MethodDelete, freezePath, nil, studentToken)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		rr = performRequest(router, http.MethodDelete, freezePath, nil, teacherToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Equal(t, http.StatusOK, update())
+	})
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"crypto/subtle"
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Token Introspection ---
+// Only registered when the server has introspection clients configured (see config.IntrospectClients).
+
+// IntrospectRequest defines the form (or JSON) body of a token introspection request.
+type IntrospectRequest struct {
+	Token         string `form:"token" json:"token" binding:"required"`  // The access token to check
+	TokenTypeHint string `form:"token_type_hint" json:"token_type_hint"` // Accepted and ignored; only access tokens exist
+}
+
+// IntrospectResponse describes a token as in RFC 7662. Inactive tokens only carry `active: false`.
+type IntrospectResponse struct {
+	Active    bool     `json:"active"`
+	Scope     string   `json:"scope,omitempty"`      // Space-separated scopes the token is limited to; empty if unrestricted
+	Subject   string   `json:"sub,omitempty"`        // Profile ID of the token's user
+	Username  string   `json:"username,omitempty"`   // Email the token was issued to
+	TokenType string   `json:"token_type,omitempty"` // Always "Bearer"
+	ExpiresAt int64    `json:"exp,omitempty"`        // Unix time
+	IssuedAt  int64    `json:"iat,omitempty"`        // Unix time
+	NotBefore int64    `json:"nbf,omitempty"`        // Unix time
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  []string `json:"aud,omitempty"`
+	Documents []string `json:"documents,omitempty"` // The only documents the token reaches; any its user may if empty
+	DeviceID  string   `json:"device_id,omitempty"` // Device the token was issued to at login
+	Admin     bool     `json:"admin,omitempty"`     // The user may use the /admin endpoints
+}
+
+// IntrospectHandler tells a companion service whether an access token is active and whose it is.
+// @Summary      Introspect an Access Token
+// @Description  Lets other services that accept this server's tokens check one (RFC 7662): the response tells whether the `token` is `active` and, if so, its subject (`sub`, the profile ID), `username` (email), expiry and other claims.
+// @Description  A token is active if it is validly signed and not expired, and its user still exists, is not deactivated, has not changed their password since it was issued and has not revoked the device it was issued to. Inactive or malformed tokens get `{"active": false}` and nothing else.
+// @Description
+// @Description  Tokens limited to some scopes (see `POST /admin/service-accounts`) list them in `scope`, space-separated.
+// @Description  Callers authenticate with HTTP Basic authentication, using a client ID and secret from `DOCSERVER_INTROSPECT_CLIENTS`; the endpoint only exists when some are configured. The body is form-encoded as in the RFC (`token=...`), or JSON. The response is not wrapped in `data`.
+// @Tags         Authentication
+// @Accept       x-www-form-urlencoded
+// @Accept       json
+// @Produce      json
+// @Param        token            formData  string  true   "The access token to check."
+// @Param        token_type_hint  formData  string  false  "Ignored; all tokens are access tokens."
+// @Success      200  {object}  IntrospectResponse "Whether the token is active, and its claims if it is."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The token is missing."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: The client ID or secret is missing or wrong."
+// @Router       /auth/introspect [post]
+func IntrospectHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	if !introspectClientValid(c, cfg) {
+		c.Header("WWW-Authenticate", `Basic realm="introspect"`)
+		utils.GinLocalizedError(c, http.StatusUnauthorized, i18n.MsgIntrospectClientInvalid)
+		return
+	}
+
+	var req IntrospectRequest
+	if err := c.ShouldBind(&req); err != nil {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgInvalidRequestBody, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, introspectToken(req.Token, database, cfg))
+}
+
+// introspectClientValid reports whether the request carries the Basic credentials of a configured
+// introspection client.
+func introspectClientValid(c *gin.Context, cfg *config.Config) bool {
+	id, secret, ok := c.Request.BasicAuth()
+	if !ok {
+		return false
+	}
+	expected, known := cfg.IntrospectClients[id]
+	if !known {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(secret), []byte(expected)) == 1
+}
+
+// introspectToken describes a token, applying the same checks as utils.AuthMiddleware and
+// RequireActiveMiddleware.
+func introspectToken(token string, database *db.Database, cfg *config.Config) IntrospectResponse {
+	inactive := IntrospectResponse{Active: false}
+	claims, err := utils.ValidateJWT(token, cfg)
+	if err != nil {
+		return inactive
+	}
+	profile, found := database.GetProfileByID(claims.UserID)
+	if !found || profile.Deactivation != nil {
+		return inactive
+	}
+	if profile.TokensNotBefore != nil && (claims.IssuedAt == nil || claims.IssuedAt.Time.Before(*profile.TokensNotBefore)) {
+		return inactive
+	}
+	if claims.DeviceID != "" && !database.DeviceTrusted(profile.ID, claims.DeviceID) {
+		return inactive
+	}
+	scopes := claims.Scopes
+	if len(scopes) > 0 && profile.Service != nil {
+		if scopes = utils.ScopesWithin(scopes, profile.Service.Scopes); len(scopes) == 0 {
+			return inactive
+		}
+	}
+
+	response := IntrospectResponse{
+		Active:    true,
+		Scope:     strings.Join(scopes, " "),
+		Subject:   profile.ID,
+		Username:  profile.Email,
+		TokenType: "Bearer",
+		Issuer:    claims.Issuer,
+		Audience:  claims.Audience,
+		Documents: claims.Documents,
+		DeviceID:  claims.DeviceID,
+		Admin:     isAdmin(profile, cfg),
+	}
+	if claims.ExpiresAt != nil {
+		response.ExpiresAt = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		response.IssuedAt = claims.IssuedAt.Unix()
+	}
+	if claims.NotBefore != nil {
+		response.NotBefore = claims.NotBefore.Unix()
+	}
+	return response
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntrospect(t *testing.T) {
+	router, database, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	userID, _, token := createTestUserAndLogin(t, router, "introspect.me@example.com", "password123", "Intro", "Spect")
+
+	t.Run("Disabled by default", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/auth/introspect", nil, "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	// Routes are registered at startup, so build a fresh router once clients are configured
+	cfg.IntrospectClients = map[string]string{"grader": "s3cret"}
+	introspectRouter := gin.New()
+	RegisterRoutes(introspectRouter, database, cfg)
+
+	introspect := func(t *testing.T, clientID, secret, token string) *httptest.ResponseRecorder {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, "/v1/auth/introspect", strings.NewReader(url.Values{"token": {token}}.Encode()))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if clientID != "" {
+			req.SetBasicAuth(clientID, secret)
+		}
+		rr := httptest.NewRecorder()
+		introspectRouter.ServeHTTP(rr, req)
+		return rr
+	}
+	describe := func(t *testing.T, token string) IntrospectResponse {
+		t.Helper()
+		rr := introspect(t, "grader", "s3cret", token)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var resp IntrospectResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		return resp
+	}
+
+	t.Run("Clients must authenticate", func(t *testing.T) {
+		rr := introspect(t, "", "", token)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		assert.Contains(t, rr.Header().Get("WWW-Authenticate"), "Basic")
+		rr = introspect(t, "grader", "wrong", token)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		rr = introspect(t, "unknown", "s3cret", token)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		rr = introspect(t, "grader", "s3cret", "")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Active tokens", func(t *testing.T) {
+		rr := introspect(t, "grader", "s3cret", token)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+		assert.NotContains(t, rr.Body.String(), `"data"`, "the response is not enveloped")
+
+		resp := describe(t, token)
+		assert.True(t, resp.Active)
+		assert.Equal(t, userID, resp.Subject)
+		assert.Equal(t, "introspect.me@example.com", resp.Username)
+		assert.Equal(t, "Bearer", resp.TokenType)
+		assert.NotEmpty(t, resp.DeviceID)
+		assert.Positive(t, resp.ExpiresAt)
+		assert.False(t, resp.Admin)
+
+		rr = performRequest(introspectRouter, http.MethodPost, "/v1/auth/introspect", marshalJSONBody(t, gin.H{"token": token}), "")
+		assert.Equal(t, http.StatusUnauthorized, rr.Code, "JSON bodies need credentials too")
+	})
+
+	t.Run("Inactive tokens", func(t *testing.T) {
+		resp := describe(t, "not-a-token")
+		assert.Equal(t, IntrospectResponse{Active: false}, resp)
+		rr := introspect(t, "grader", "s3cret", "not-a-token")
+		assert.JSONEq(t, `{"active":false}`, rr.Body.String())
+
+		_, err := database.RevokeDevice(userID, describe(t, token).DeviceID)
+		require.NoError(t, err)
+		assert.False(t, describe(t, token).Active, "tokens of revoked devices are inactive")
+	})
+}
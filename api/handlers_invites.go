@@ -0,0 +1,122 @@
+package api
+
+import (
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/models"
+	"docserver/utils"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Invitations (Admin) ---
+
+// maxInviteUses caps how many signups a single invitation code may allow.
+const maxInviteUses = 10000
+
+// InviteRequest defines the body for creating an invitation code.
+type InviteRequest struct {
+	MaxUses   *int       `json:"max_uses,omitempty"`   // Signups the code allows (1-10000); defaults to 1
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // RFC 3339; omit for a code that never expires
+	Note      string     `json:"note,omitempty"`       // For administrators, e.g. the class the code is for
+}
+
+// toInvite validates the request and converts it to an invitation, as of now.
+func (req InviteRequest) toInvite(now time.Time) (models.Invite, error) {
+	invite := models.Invite{MaxUses: 1, Note: strings.TrimSpace(req.Note)}
+	if req.MaxUses != nil {
+		if *req.MaxUses < 1 || *req.MaxUses > maxInviteUses {
+			return models.Invite{}, fmt.Errorf("max_uses must be between 1 and %d", maxInviteUses)
+		}
+		invite.MaxUses = *req.MaxUses
+	}
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(now) {
+			return models.Invite{}, fmt.Errorf("expires_at must be in the future")
+		}
+		expiresAt := req.ExpiresAt.UTC()
+		invite.ExpiresAt = &expiresAt
+	}
+	return invite, nil
+}
+
+// CreateInviteHandler creates an invitation code.
+// @Summary      Create an Invitation Code (Admin)
+// @Description  Creates a code that lets people sign up while the server is invite-only (`DOCSERVER_INVITE_ONLY`): they send it as `invite_code` to `POST /auth/signup`. Administrators only.
+// @Description
+// @Description  A code allows `max_uses` signups (1 by default) and, if `expires_at` is set, stops working at that time. Codes are not case-sensitive. Hand one multi-use code to a whole class, or create one per student.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        invite body InviteRequest false "Usage limit, expiry and note. All optional."
+// @Success      201  {object}  utils.Envelope{data=models.Invite} "Invitation created. Share its `code`."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The body is invalid, `max_uses` is out of range, or `expires_at` is not in the future."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Failure      500  {object}  utils.ErrorEnvelope "Internal Server Error: The invitation could not be created."
+// @Router       /admin/invites [post]
+func CreateInviteHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+
+	var req InviteRequest
+	if !utils.BindOptionalJSON(c, cfg, &req, i18n.MsgInvalidRequestBody) {
+		return
+	}
+	invite, err := req.toInvite(cfg.Now())
+	if err != nil {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgInviteSettings, err)
+		return
+	}
+	invite.CreatedBy = userID.(string)
+
+	created, err := database.CreateInvite(invite)
+	if err != nil {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgInviteCreateFailed, err)
+		return
+	}
+	utils.RespondData(c, http.StatusCreated, created)
+}
+
+// ListInvitesHandler lists all invitation codes.
+// @Summary      List Invitation Codes (Admin)
+// @Description  Lists all invitation codes, newest first, with how often each has been used. Expired and used-up codes are listed until deleted. Administrators only.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  utils.Envelope{data=[]models.Invite} "All invitation codes."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Router       /admin/invites [get]
+func ListInvitesHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	utils.RespondData(c, http.StatusOK, database.ListInvites())
+}
+
+// DeleteInviteHandler revokes an invitation code.
+// @Summary      Delete an Invitation Code (Admin)
+// @Description  Revokes an invitation code so it can no longer be used to sign up. Accounts already created with it are not affected. Administrators only.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Param        code path      string  true  "The invitation code."
+// @Success      204  "Invitation deleted."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No invitation exists with that code."
+// @Router       /admin/invites/{code} [delete]
+func DeleteInviteHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	code := c.Param("code")
+	if err := database.DeleteInvite(code); err != nil {
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgInviteNotFound, code)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
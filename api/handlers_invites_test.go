@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"docserver/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInviteEndpoints(t *testing.T) {
+	router, _, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	cfg.AdminEmails = []string{"invite.admin@example.com"}
+
+	_, _, adminToken := createTestUserAndLogin(t, router, "invite.admin@example.com", "password123", "Inv", "Admin")
+	_, _, userToken := createTestUserAndLogin(t, router, "invite.user@example.com", "password123", "Inv", "User")
+	cfg.InviteOnly = true
+
+	signup := func(email, code string) int {
+		return performRequest(router, http.MethodPost, "/auth/signup", marshalJSONBody(t, gin.H{
+			"email": email, "password": "password123", "first_name": "Inv", "last_name": "Student", "invite_code": code,
+		}), "").Code
+	}
+
+	t.Run("Only administrators manage invitations", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/admin/invites", nil, userToken)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		rr = performRequest(router, http.MethodGet, "/admin/invites", nil, userToken)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("Settings are validated", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/admin/invites", marshalJSONBody(t, gin.H{"max_uses": 0}), adminToken)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		rr = performRequest(router, http.MethodPost, "/admin/invites", marshalJSONBody(t, gin.H{
+			"expires_at": time.Now().Add(-time.Hour).Format(time.RFC3339),
+		}), adminToken)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Signup requires a valid code", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/admin/invites", marshalJSONBody(t, gin.H{"max_uses": 2, "note": "CS 101"}), adminToken)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var invite models.Invite
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &invite))
+		assert.Equal(t, 2, invite.MaxUses)
+
+		assert.Equal(t, http.StatusForbidden, signup("invite.s1@example.com", ""))
+		assert.Equal(t, http.StatusForbidden, signup("invite.s1@example.com", "WRONGCODE"))
+		assert.Equal(t, http.StatusCreated, signup("invite.s1@example.com", strings.ToLower(invite.Code)))
+		assert.Equal(t, http.StatusBadRequest, signup("invite.s1@example.com", invite.Code), "duplicate email does not use the code")
+		assert.Equal(t, http.StatusCreated, signup("invite.s2@example.com", invite.Code))
+
+		rr = performRequest(router, http.MethodPost, "/v1/auth/signup", marshalJSONBody(t, gin.H{
+			"email": "invite.s3@example.com", "password": "password123", "first_name": "Inv", "last_name": "Student", "invite_code": invite.Code,
+		}), "")
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"message_id":"invite_used_up"`)
+	})
+
+	t.Run("Administrators sign up without a code", func(t *testing.T) {
+		cfg.AdminEmails = append(cfg.AdminEmails, "invite.admin2@example.com")
+		assert.Equal(t, http.StatusCreated, signup("Invite.Admin2@example.com", ""))
+	})
+
+	t.Run("List and delete", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/admin/invites", nil, adminToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var invites []models.Invite
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &invites))
+		require.Len(t, invites, 1)
+		assert.Equal(t, 2, invites[0].Uses)
+
+		rr = performRequest(router, http.MethodDelete, "/admin/invites/"+invites[0].Code, nil, adminToken)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		rr = performRequest(router, http.MethodDelete, "/admin/invites/"+invites[0].Code, nil, adminToken)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Open signup ignores codes", func(t *testing.T) {
+		cfg.InviteOnly = false
+		assert.Equal(t, http.StatusCreated, signup("invite.open@example.com", ""))
+	})
+}
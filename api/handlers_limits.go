@@ -0,0 +1,60 @@
+package api
+
+import (
+	"docserver/config"
+	"docserver/db"
+	"docserver/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Limits ---
+
+// RateLimitInfo describes the caller's allowance on one rate limited route.
+type RateLimitInfo struct {
+	Route         string `json:"route"`          // Method and path, e.g. "GET /status"
+	Limit         int    `json:"limit"`          // Requests allowed per window
+	WindowSeconds int    `json:"window_seconds"` // Length of a window
+	Remaining     int    `json:"remaining"`      // Requests left in the current window
+	ResetSeconds  int    `json:"reset_seconds"`  // Seconds until the current window ends
+}
+
+// LimitsResponse describes the limits that apply to the caller.
+type LimitsResponse struct {
+	RateLimits      []RateLimitInfo `json:"rate_limits"`       // Rate limited routes; routes not listed are unlimited
+	MaxBodyBytes    int64           `json:"max_body_bytes"`    // Largest JSON request body accepted (0 = no limit)
+	FetchMaxBytes   int64           `json:"fetch_max_bytes"`   // Largest response POST /documents/fetch accepts
+	ArchiveMaxBytes int64           `json:"archive_max_bytes"` // Most document data GET /documents/archive puts in one zip
+	MaxPageSize     int             `json:"max_page_size"`     // Largest 'limit' honoured by document listings
+}
+
+// GetLimitsHandler describes the rate and size limits that apply to the caller.
+// @Summary      Get Your Limits
+// @Description  Describes the limits the server applies to you, so a client can pace itself instead of discovering them through `429 Too Many Requests` and `413 Request Entity Too Large` responses.
+// @Description  `rate_limits` lists each rate limited route with its allowance per window and what is left of your current window (`remaining`, `reset_seconds`); routes not listed are not rate limited. Rate limits count requests per client IP address.
+// @Description  Responses of rate limited routes also carry the same figures in `X-RateLimit-Limit`, `X-RateLimit-Remaining` and `X-RateLimit-Reset` (seconds) headers. Asking for your limits does not count against them. No login is needed.
+// @Tags         Status
+// @Produce      json
+// @Success      200  {object}  utils.Envelope{data=LimitsResponse} "The limits that apply to you."
+// @Router       /limits [get]
+func GetLimitsHandler(c *gin.Context, database *db.Database, cfg *config.Config, limits rateLimits) {
+	response := LimitsResponse{
+		RateLimits:      []RateLimitInfo{},
+		MaxBodyBytes:    cfg.MaxBodyBytes,
+		FetchMaxBytes:   cfg.FetchMaxBytes,
+		ArchiveMaxBytes: cfg.ArchiveMaxBytes,
+		MaxPageSize:     db.MaxLimit,
+	}
+	if limits.status != nil {
+		state := limits.status.Peek(c.ClientIP())
+		response.RateLimits = append(response.RateLimits, RateLimitInfo{
+			Route:         "GET /status",
+			Limit:         state.Limit,
+			WindowSeconds: utils.ResetSeconds(limits.status.Window()),
+			Remaining:     state.Remaining,
+			ResetSeconds:  utils.ResetSeconds(state.Reset),
+		})
+	}
+	utils.RespondData(c, http.StatusOK, response)
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetLimits(t *testing.T) {
+	router, database, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	cfg.MaxBodyBytes = 2048
+
+	getLimits := func(router *gin.Engine) LimitsResponse {
+		rr := performRequest(router, http.MethodGet, "/v1/limits", nil, "")
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var body struct {
+			Data LimitsResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		return body.Data
+	}
+
+	t.Run("Size limits", func(t *testing.T) {
+		limits := getLimits(router)
+		assert.Equal(t, int64(2048), limits.MaxBodyBytes)
+		assert.Equal(t, 100, limits.MaxPageSize)
+		assert.Empty(t, limits.RateLimits, "the test server limits no routes")
+	})
+
+	t.Run("Rate limits", func(t *testing.T) {
+		limitedCfg := *cfg
+		limitedCfg.StatusRateLimit = 3
+		limitedRouter := gin.New()
+		RegisterRoutes(limitedRouter, database, &limitedCfg)
+
+		rr := performRequest(limitedRouter, http.MethodGet, "/status", nil, "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "3", rr.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, "2", rr.Header().Get("X-RateLimit-Remaining"))
+		assert.Equal(t, "60", rr.Header().Get("X-RateLimit-Reset"))
+
+		limits := getLimits(limitedRouter)
+		require.Len(t, limits.RateLimits, 1)
+		status := limits.RateLimits[0]
+		assert.Equal(t, "GET /status", status.Route)
+		assert.Equal(t, 3, status.Limit)
+		assert.Equal(t, 60, status.WindowSeconds)
+		assert.Equal(t, 2, status.Remaining, "asking for limits does not use them up")
+		assert.InDelta(t, 60, status.ResetSeconds, 1)
+	})
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"docserver/authz"
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/models"
+	"docserver/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Document Links ---
+
+// GraphNode is a document in the link graph.
+type GraphNode struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`    // The content's top-level "title", or "document {id}"
+	OwnerID string `json:"owner_id"` // Profile ID of the owner
+}
+
+// GraphEdge is a link from one document to another.
+type GraphEdge struct {
+	From string `json:"from"` // ID of the linking document
+	To   string `json:"to"`   // ID of the linked document
+}
+
+// LinkGraph is the link graph of the documents a user can access.
+type LinkGraph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// graphNodeFor describes doc as a graph node.
+func graphNodeFor(doc models.Document) GraphNode {
+	return GraphNode{ID: doc.ID, Title: documentTitle(doc.Content, doc.ID), OwnerID: doc.OwnerID}
+}
+
+// checkDocumentLinks checks the links of document docID ("" for a new document without a chosen
+// ID) and that the user making the request may read every document they point to, so documents
+// only link to what their author can see. It writes a 400 response and returns false otherwise;
+// missing and unreadable documents are refused alike.
+func checkDocumentLinks(c *gin.Context, database *db.Database, cfg *config.Config, docID string, links []string) bool {
+	links, err := db.NormalizeLinks(docID, links)
+	if err != nil {
+		utils.GinErrorFromErr(c, http.StatusBadRequest, err)
+		return false
+	}
+	denied := ""
+	_ = database.View(func(v *db.ReadView) error {
+		for _, link := range links {
+			target, found := v.GetDocumentByID(link)
+			if !found || !can(c, v, cfg, authz.ReadDocument, target) {
+				denied = link
+				return nil
+			}
+		}
+		return nil
+	})
+	if denied != "" {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgDocumentLinkInaccessible, denied)
+		return false
+	}
+	return true
+}
+
+// GetBacklinksHandler lists the documents linking to a document.
+// @Summary      Get a Document's Backlinks
+// @Description  Lists the documents whose `links` include this document, oldest first, as graph nodes. Only documents you can read are listed, so backlinks from other users' private documents stay hidden.
+// @Tags         Documents
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "The unique identifier of the document."
+// @Success      200  {object}  utils.Envelope{data=[]GraphNode} "The documents linking to it."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You cannot read this document."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No document exists with the specified ID."
+// @Router       /documents/{id}/backlinks [get]
+func GetBacklinksHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	docID := c.Param("id")
+
+	var found, allowed bool
+	backlinks := make([]GraphNode, 0)
+	_ = database.View(func(v *db.ReadView) error {
+		var doc models.Document
+		if doc, found = v.GetDocumentByID(docID); !found {
+			return nil
+		}
+		if allowed = can(c, v, cfg, authz.ReadDocument, doc); !allowed {
+			return nil
+		}
+		for _, source := range v.GetBacklinks(docID) {
+			if can(c, v, cfg, authz.ReadDocument, source) {
+				backlinks = append(backlinks, graphNodeFor(source))
+			}
+		}
+		return nil
+	})
+	if !found {
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgDocumentNotFound, docID)
+		return
+	}
+	if !allowed {
+		utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgDocumentAccessDenied)
+		return
+	}
+	utils.RespondData(c, http.StatusOK, backlinks)
+}
+
+// GetLinkGraphHandler returns the link graph of the documents the user can access.
+// @Summary      Get the Link Graph
+// @Description  Returns the documents you own or that are shared with you as `nodes`, oldest first, and the links between them as `edges`, e.g. to draw them with a graph library. Links to documents outside that set are left out.
+// @Tags         Documents
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  utils.Envelope{data=LinkGraph} "The link graph."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Router       /graph [get]
+func GetLinkGraphHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+
+	documents, err := database.AccessibleDocuments(userID.(string))
+	if err != nil {
+		utils.GinErrorFromErr(c, http.StatusInternalServerError, err)
+		return
+	}
+	graph := LinkGraph{Nodes: make([]GraphNode, len(documents)), Edges: make([]GraphEdge, 0)}
+	accessible := make(map[string]bool, len(documents))
+	for i, doc := range documents {
+		graph.Nodes[i] = graphNodeFor(doc)
+		accessible[doc.ID] = true
+	}
+	for _, doc := range documents {
+		for _, link := range doc.Links {
+			if accessible[link] {
+				graph.Edges = append(graph.Edges, GraphEdge{From: doc.ID, To: link})
+			}
+		}
+	}
+	utils.RespondData(c, http.StatusOK, graph)
+}
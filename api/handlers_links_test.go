@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentLinkEndpoints(t *testing.T) {
+	router, database, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	_, _, aliceToken := createTestUserAndLogin(t, router, "links.alice@example.com", "password123", "Alice", "Links")
+	bobID, _, bobToken := createTestUserAndLogin(t, router, "links.bob@example.com", "password123", "Bob", "Links")
+
+	create := func(t *testing.T, token string, body gin.H) DocumentResponse {
+		t.Helper()
+		rr := performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, body), token)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var doc DocumentResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+		return doc
+	}
+	notes := create(t, aliceToken, gin.H{"content": gin.H{"title": "Notes"}})
+	bobsPrivate := create(t, bobToken, gin.H{"content": gin.H{"title": "Private"}})
+	bobsShared := create(t, bobToken, gin.H{"content": gin.H{"title": "Shared"}, "links": []string{bobsPrivate.ID}})
+	require.NoError(t, database.SetShareRecord(bobsShared.ID, []string{notes.OwnerID}))
+
+	var essay DocumentResponse
+	t.Run("Links are set on creation", func(t *testing.T) {
+		essay = create(t, aliceToken, gin.H{"content": gin.H{"title": "Essay"}, "links": []string{notes.ID, bobsShared.ID, notes.ID}})
+		assert.Equal(t, []string{notes.ID, bobsShared.ID}, essay.Links)
+	})
+
+	t.Run("Targets must be readable", func(t *testing.T) {
+		for name, links := range map[string][]string{
+			"not shared": {bobsPrivate.ID},
+			"missing":    {"doc_missing"},
+			"blank":      {""},
+		} {
+			rr := performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, gin.H{"content": gin.H{}, "links": links}), aliceToken)
+			assert.Equal(t, http.StatusBadRequest, rr.Code, name)
+		}
+		rr := performRequest(router, http.MethodPut, "/documents/"+notes.ID, marshalJSONBody(t, gin.H{"content": gin.H{"title": "Notes"}, "links": []string{notes.ID}}), aliceToken)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, "self links")
+		doc, _ := database.GetDocumentByID(notes.ID)
+		assert.Empty(t, doc.Links, "nothing was changed")
+	})
+
+	t.Run("Updates replace or keep the links", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPut, "/documents/"+essay.ID, marshalJSONBody(t, gin.H{"content": gin.H{"title": "Essay v2"}}), aliceToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Contains(t, rr.Body.String(), bobsShared.ID, "kept when omitted")
+
+		rr = performRequest(router, http.MethodPut, "/documents/"+notes.ID, marshalJSONBody(t, gin.H{"content": gin.H{"title": "Notes"}, "links": []string{bobsShared.ID}}), aliceToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		doc, _ := database.GetDocumentByID(notes.ID)
+		assert.Equal(t, []string{bobsShared.ID}, doc.Links)
+	})
+
+	t.Run("Backlinks", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/documents/"+notes.ID+"/backlinks", nil, aliceToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var backlinks []GraphNode
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &backlinks))
+		assert.Equal(t, []GraphNode{{ID: essay.ID, Title: "Essay v2", OwnerID: essay.OwnerID}}, backlinks)
+
+		rr = performRequest(router, http.MethodGet, "/documents/"+bobsShared.ID+"/backlinks", nil, bobToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "[]", rr.Body.String(), "Alice's documents are not Bob's to see")
+
+		rr = performRequest(router, http.MethodGet, "/documents/"+notes.ID+"/backlinks", nil, bobToken)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		rr = performRequest(router, http.MethodGet, "/documents/doc_missing/backlinks", nil, bobToken)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Graph", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/graph", nil, aliceToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var graph LinkGraph
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &graph))
+		assert.ElementsMatch(t, []GraphNode{
+			{ID: notes.ID, Title: "Notes", OwnerID: notes.OwnerID},
+			{ID: bobsShared.ID, Title: "Shared", OwnerID: bobID},
+			{ID: essay.ID, Title: "Essay v2", OwnerID: essay.OwnerID},
+		}, graph.Nodes)
+		assert.ElementsMatch(t, []GraphEdge{
+			{From: notes.ID, To: bobsShared.ID},
+			{From: essay.ID, To: notes.ID},
+			{From: essay.ID, To: bobsShared.ID},
+		}, graph.Edges, "the link to Bob's private document is left out")
+
+		rr = performRequest(router, http.MethodGet, "/v1/graph", nil, bobToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"from":"`+bobsShared.ID+`","to":"`+bobsPrivate.ID+`"`)
+	})
+}
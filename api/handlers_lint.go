@@ -0,0 +1,135 @@
+package api
+
+import (
+	"docserver/apperr"
+	"docserver/authz"
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/models"
+	"docserver/utils"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Document Linting ---
+
+// LintDocumentRequest defines the optional body for linting a document.
+type LintDocumentRequest struct {
+	Rules     utils.LintRules `json:"rules"`                             // Limits and checks; zero values use the defaults
+	Normalize []string        `json:"normalize,omitempty"`               // Normalizations to preview or apply: "trim_strings", "nan_strings", "numbers"
+	Apply     bool            `json:"apply,omitempty"`                   // Store the normalized content as a new version
+	Version   int             `json:"version,omitempty" binding:"min=0"` // With apply, only apply if the document is still at this version
+}
+
+// LintDocumentResponse lists the problems found in a document and previews, or reports, its normalization.
+type LintDocumentResponse struct {
+	DocumentID string            `json:"document_id"`
+	Version    int               `json:"version"` // Version that was linted, or the new version if the normalization was applied
+	Issues     []utils.LintIssue `json:"issues"`
+	Normalized any               `json:"normalized,omitempty"` // Content after the requested normalizations
+	Changes    *utils.JSONDiff   `json:"changes,omitempty"`    // What the normalizations change
+	Applied    bool              `json:"applied"`              // Whether the normalized content was stored
+}
+
+// LintDocumentHandler checks a document's content against lint rules and optionally normalizes it.
+// @Summary      Lint and Normalize a Document
+// @Description  Checks a JSON document's content and reports each problem with its content `path`, the `rule` it breaks and a message. The rules, all configurable in `rules`:
+// @Description  - `max_depth`: objects and arrays nested deeper than `max_depth` levels (20 by default).
+// @Description  - `max_key_length`: object keys longer than `max_key_length` characters (64 by default).
+// @Description  - `nan_string`: strings such as `"NaN"` or `"-Infinity"` standing in for numbers JSON cannot hold, unless `allow_nan_strings` is set.
+// @Description  - `date_format`: dates written differently from most others in the document (e.g. `03/05/2024` among `2024-05-01` dates), unless `allow_mixed_dates` is set.
+// @Description
+// @Description  `normalize` lists normalizations to preview: `trim_strings` trims the white space around strings, `nan_strings` replaces NaN-like strings with `null`, and `numbers` turns strings holding plain numbers (`"42"`, `"1.50"`, but not `"02134"`) into numbers and `-0` into `0`. Object keys need no sorting: documents are always stored and returned with sorted keys and canonical numbers.
+// @Description  The response shows the `normalized` content and the `changes` it makes, in the format of `GET /documents/{id}/diff`, without saving anything.
+// @Description  With `apply` set, the normalized content is stored as a new version, which needs permission to update the document. Pass the `version` the preview was made at to apply only if the document has not changed since (`409 Conflict` otherwise).
+// @Description
+// @Description  Sharers limited to part of a document lint that part. The body is optional; without one the document is linted with the default rules.
+// @Tags         Documents
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    path      string               true   "The unique identifier of the document."
+// @Param        lint  body      LintDocumentRequest  false  "Rules, normalizations and whether to apply them."
+// @Success      200  {object}  utils.Envelope{data=LintDocumentResponse} "The problems found and, if requested, the normalization."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The body is malformed, names an unknown normalization, or the document is not JSON."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You cannot read this document, or cannot update it to apply the normalization."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No document exists with the specified ID."
+// @Failure      409  {object}  utils.ErrorEnvelope "Conflict: The document changed since the given version, or while it was normalized."
+// @Failure      423  {object}  utils.ErrorEnvelope "Locked: The document is frozen, so the normalization cannot be applied."
+// @Router       /documents/{id}/lint [post]
+func LintDocumentHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	doc, ok := readableDocumentForDiff(c, database, cfg)
+	if !ok {
+		return
+	}
+
+	var req LintDocumentRequest
+	if c.Request.ContentLength != 0 && !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidRequestBody) {
+		return
+	}
+	if err := utils.CheckNormalizations(req.Normalize); err != nil {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgLintInvalid, err)
+		return
+	}
+	if doc.ContentType != "" && doc.ContentType != models.ContentTypeJSON {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgLintUnsupported, doc.ID, doc.ContentType)
+		return
+	}
+
+	content := doc.Content
+	scope, scoped := documentScope(database, doc, c.GetString("userID"))
+	if scoped {
+		content = db.ScopedContent(content, scope)
+	}
+	resp := LintDocumentResponse{
+		DocumentID: doc.ID,
+		Version:    currentVersion(doc),
+		Issues:     utils.LintJSON(content, req.Rules),
+	}
+	if len(req.Normalize) > 0 {
+		resp.Normalized = utils.LintNormalize(content, req.Normalize)
+		changes := utils.DiffJSON(content, resp.Normalized)
+		resp.Changes = &changes
+	}
+	if !req.Apply {
+		utils.RespondData(c, http.StatusOK, resp)
+		return
+	}
+
+	// Applying replaces the whole content, so it is for those who may update the whole document
+	if scoped || !can(c, database, cfg, authz.UpdateDocument, doc) {
+		utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgDocumentUpdateDenied)
+		return
+	}
+	if req.Version != 0 && req.Version != currentVersion(doc) {
+		utils.GinLocalizedError(c, http.StatusConflict, i18n.MsgDocumentVersionMismatch, doc.ID, currentVersion(doc), req.Version)
+		return
+	}
+	if resp.Changes == nil || resp.Changes.Empty() {
+		resp.Applied = true // Nothing to change, so no new version
+		utils.RespondData(c, http.StatusOK, resp)
+		return
+	}
+
+	updatedDoc, err := database.UpdateDocumentIfUnchanged(doc, resp.Normalized)
+	if err != nil {
+		if respondScriptError(c, err) || respondFrozen(c, err) {
+			return
+		}
+		if errors.Is(err, apperr.ErrNotFound) {
+			utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgDocumentNotFound, doc.ID)
+		} else if status := apperr.HTTPStatus(err); status != http.StatusInternalServerError {
+			utils.GinErrorFromErr(c, status, err)
+		} else {
+			utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgDocumentUpdateFailed, err)
+		}
+		return
+	}
+	resp.Version = currentVersion(updatedDoc)
+	resp.Applied = true
+	utils.RespondData(c, http.StatusOK, resp)
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"docserver/models"
+	"docserver/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintDocument(t *testing.T) {
+	router, database, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	ownerID, _, ownerToken := createTestUserAndLogin(t, router, "lint.owner@example.com", "password123", "Lint", "Owner")
+	readerID, _, readerToken := createTestUserAndLogin(t, router, "lint.reader@example.com", "password123", "Lint", "Reader")
+	_, _, strangerToken := createTestUserAndLogin(t, router, "lint.stranger@example.com", "password123", "Lint", "Stranger")
+
+	doc, err := database.CreateDocument(models.Document{OwnerID: ownerID, Content: map[string]any{
+		"score": " 42 ", "grade": "NaN", "due": "2024-05-01", "submitted": "2024-05-02", "graded": "03/05/2024",
+	}})
+	require.NoError(t, err)
+	require.NoError(t, database.SetShareRecord(doc.ID, []string{readerID}))
+
+	lint := func(t *testing.T, body any, token string) (int, LintDocumentResponse) {
+		t.Helper()
+		var reqBody io.Reader
+		if body != nil {
+			reqBody = marshalJSONBody(t, body)
+		}
+		rr := performRequest(router, http.MethodPost, "/documents/"+doc.ID+"/lint", reqBody, token)
+		var resp LintDocumentResponse
+		if rr.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		}
+		return rr.Code, resp
+	}
+
+	t.Run("Default rules without a body", func(t *testing.T) {
+		code, resp := lint(t, nil, readerToken)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, doc.ID, resp.DocumentID)
+		assert.Equal(t, 1, resp.Version)
+		require.Len(t, resp.Issues, 2)
+		assert.Equal(t, utils.LintIssue{Path: "grade", Rule: utils.LintRuleNaNString, Message: "'NaN' is not a number JSON can hold; use null or a number"}, resp.Issues[0])
+		assert.Equal(t, "graded", resp.Issues[1].Path)
+		assert.Equal(t, utils.LintRuleDateFormat, resp.Issues[1].Rule)
+		assert.Nil(t, resp.Changes)
+		assert.False(t, resp.Applied)
+	})
+
+	t.Run("Rules can be relaxed", func(t *testing.T) {
+		code, resp := lint(t, gin.H{"rules": gin.H{"allow_nan_strings": true, "allow_mixed_dates": true}}, ownerToken)
+		require.Equal(t, http.StatusOK, code)
+		assert.Empty(t, resp.Issues)
+		code, _ = lint(t, gin.H{"rules": gin.H{"max_depth": -1}}, ownerToken)
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("Normalization preview", func(t *testing.T) {
+		code, resp := lint(t, gin.H{"normalize": []string{"trim_strings", "numbers", "nan_strings"}}, readerToken)
+		require.Equal(t, http.StatusOK, code)
+		require.NotNil(t, resp.Changes)
+		assert.Equal(t, []string{"grade", "score"}, resp.Changes.Paths())
+		assert.Equal(t, 42.0, resp.Normalized.(map[string]any)["score"])
+		assert.False(t, resp.Applied)
+
+		stored, _ := database.GetDocumentByID(doc.ID)
+		assert.Equal(t, " 42 ", stored.Content.(map[string]any)["score"], "a preview changes nothing")
+
+		code, _ = lint(t, gin.H{"normalize": []string{"sort_keys"}}, ownerToken)
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("Access", func(t *testing.T) {
+		code, _ := lint(t, nil, strangerToken)
+		assert.Equal(t, http.StatusForbidden, code)
+		code, _ = lint(t, gin.H{"normalize": []string{"numbers"}, "apply": true}, readerToken)
+		assert.Equal(t, http.StatusForbidden, code, "applying needs update permission")
+		rr := performRequest(router, http.MethodPost, "/documents/doc_missing/lint", nil, ownerToken)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Applying", func(t *testing.T) {
+		code, _ := lint(t, gin.H{"normalize": []string{"trim_strings"}, "apply": true, "version": 7}, ownerToken)
+		assert.Equal(t, http.StatusConflict, code, "the document is not at version 7")
+
+		code, resp := lint(t, gin.H{"normalize": []string{"trim_strings", "numbers"}, "apply": true, "version": 1}, ownerToken)
+		require.Equal(t, http.StatusOK, code)
+		assert.True(t, resp.Applied)
+		assert.Equal(t, 2, resp.Version)
+		stored, _ := database.GetDocumentByID(doc.ID)
+		assert.Equal(t, 42.0, stored.Content.(map[string]any)["score"])
+		assert.Equal(t, "NaN", stored.Content.(map[string]any)["grade"])
+
+		code, resp = lint(t, gin.H{"normalize": []string{"numbers"}, "apply": true}, ownerToken)
+		require.Equal(t, http.StatusOK, code)
+		assert.True(t, resp.Changes.Empty())
+		assert.Equal(t, 2, resp.Version, "nothing to change, no new version")
+	})
+
+	t.Run("Only JSON documents", func(t *testing.T) {
+		notes, err := database.CreateDocument(models.Document{OwnerID: ownerID, Content: "# Notes", ContentType: models.ContentTypeMarkdown})
+		require.NoError(t, err)
+		rr := performRequest(router, http.MethodPost, "/documents/"+notes.ID+"/lint", nil, ownerToken)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"docserver/bench"
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/utils"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Load Generation (Admin) ---
+
+// defaultLoadDocuments is how many documents a load generation creates when not told.
+const defaultLoadDocuments = 1000
+
+// GenerateLoadResponse reports what a load generation created.
+type GenerateLoadResponse struct {
+	Users          int      `json:"users"`
+	Documents      int      `json:"documents"`
+	SharedWithYou  int      `json:"shared_with_you"` // Of those, how many were shared with the caller
+	UserIDs        []string `json:"user_ids"`        // Profile IDs of the generated users
+	DurationMillis float64  `json:"duration_ms"`
+}
+
+// GenerateLoadHandler fills the database with generated users and documents for performance labs.
+// @Summary      Generate Load (Admin)
+// @Description  Creates `users` users and `docs` course documents they own directly in the database, far faster than creating them one request at a time, so a class can measure the server against a large dataset. The documents look like assignments (course, status, score, tags, author and text), are spread evenly over the users, and every tenth is shared with you, so both `scope=owned` lists of the users and your `scope=shared` or `scope=any` searches have data. The same `seed` gives the same content. The generated users have no password, so they cannot log in.
+// @Description
+// @Description  Only available when the server runs with `-allow-load-generation`, and limited to `-load-generation-max-docs` documents (100,000 by default) and `-load-generation-max-users` users (1,000 by default) per request. Administrators only.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        docs   query  int  false  "Documents to create (default 1000)." minimum(0)
+// @Param        users  query  int  false  "Users to create (default one per 20 documents, up to the limit)." minimum(1)
+// @Param        seed   query  int  false  "Seed of the generated content (default 1)."
+// @Success      201  {object}  utils.Envelope{data=GenerateLoadResponse} "What was created."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: A parameter is not a number or is over the server's limit."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator, or load generation is disabled."
+// @Failure      500  {object}  utils.ErrorEnvelope "Internal Server Error: Generation stopped part way; what was created is kept."
+// @Router       /admin/generate-load [post]
+func GenerateLoadHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	if !cfg.LoadGeneration {
+		utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgLoadGenerationDisabled)
+		return
+	}
+	docs, ok := loadGenerationParam(c, "docs", defaultLoadDocuments, 0)
+	if !ok {
+		return
+	}
+	if docs > cfg.LoadGenerationMaxDocs {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgLoadGenerationTooLarge, "docs", docs, cfg.LoadGenerationMaxDocs)
+		return
+	}
+	users, ok := loadGenerationParam(c, "users", min(cfg.LoadGenerationMaxUsers, max(1, (docs+19)/20)), 1)
+	if !ok {
+		return
+	}
+	seed, ok := loadGenerationParam(c, "seed", 1, 0)
+	if !ok {
+		return
+	}
+	if users > cfg.LoadGenerationMaxUsers {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgLoadGenerationTooLarge, "users", users, cfg.LoadGenerationMaxUsers)
+		return
+	}
+
+	started := time.Now()
+	generated, err := bench.Generate(database, bench.GenerateOptions{
+		Documents:  docs,
+		Students:   users,
+		Seed:       int64(seed),
+		Instructor: c.GetString("userID"),
+		Batch:      strconv.FormatInt(started.UnixNano(), 36), // Emails of earlier generations are taken
+	})
+	if err != nil {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgLoadGenerationFailed, len(generated.Documents), err)
+		return
+	}
+	took := time.Since(started)
+	log.Printf("AUDIT: Profile ID %s generated %d users and %d documents in %s", c.GetString("userID"), len(generated.Students), len(generated.Documents), took)
+
+	utils.RespondData(c, http.StatusCreated, GenerateLoadResponse{
+		Users:          len(generated.Students),
+		Documents:      len(generated.Documents),
+		SharedWithYou:  generated.Shared,
+		UserIDs:        generated.Students,
+		DurationMillis: float64(took) / float64(time.Millisecond),
+	})
+}
+
+// loadGenerationParam reads an optional whole-number query parameter of at least minimum, writing
+// a 400 response and returning false if it is not one.
+func loadGenerationParam(c *gin.Context, name string, fallback, minimum int) (int, bool) {
+	value, given := c.GetQuery(name)
+	if !given {
+		return fallback, true
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < minimum {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgLoadGenerationInvalid, name, value, minimum)
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"docserver/db"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateLoad(t *testing.T) {
+	router, database, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	cfg.AdminEmails = []string{"load.admin@example.com"}
+	cfg.LoadGenerationMaxDocs = 500
+	cfg.LoadGenerationMaxUsers = 10
+
+	adminID, _, adminToken := createTestUserAndLogin(t, router, "load.admin@example.com", "password123", "Load", "Admin")
+	_, _, userToken := createTestUserAndLogin(t, router, "load.user@example.com", "password123", "Load", "User")
+
+	t.Run("Disabled by default", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/admin/generate-load?docs=10", nil, adminToken)
+		assert.Equal(t, http.StatusForbidden, rr.Code, rr.Body.String())
+	})
+
+	cfg.LoadGeneration = true
+
+	t.Run("Administrators only", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/admin/generate-load?docs=10", nil, userToken)
+		assert.Equal(t, http.StatusForbidden, rr.Code, rr.Body.String())
+	})
+
+	t.Run("Generates users and documents", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/admin/generate-load?docs=45&users=3&seed=7", nil, adminToken)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var resp GenerateLoadResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, 3, resp.Users)
+		assert.Equal(t, 45, resp.Documents)
+		assert.Equal(t, 5, resp.SharedWithYou)
+		require.Len(t, resp.UserIDs, 3)
+		assert.Len(t, database.GetDocumentsByOwner(resp.UserIDs[0]), 15)
+
+		_, total, err := database.QueryDocuments(db.QueryDocumentsParams{AuthUserID: adminID, Scope: "shared"})
+		require.NoError(t, err)
+		assert.Equal(t, 5, total)
+
+		// Generating again creates new users rather than failing on their emails
+		rr = performRequest(router, http.MethodPost, "/admin/generate-load?docs=20", nil, adminToken)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, 1, resp.Users)
+	})
+
+	t.Run("Limits", func(t *testing.T) {
+		for _, query := range []string{"docs=501", "docs=10&users=11", "docs=many", "users=0"} {
+			rr := performRequest(router, http.MethodPost, "/admin/generate-load?"+query, nil, adminToken)
+			assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+		}
+	})
+}
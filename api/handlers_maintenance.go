@@ -0,0 +1,154 @@
+package api
+
+import (
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/models"
+	"docserver/utils"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Maintenance Mode ---
+
+// defaultMaintenanceRetryAfter is the Retry-After (in seconds) sent when the administrator set none.
+const defaultMaintenanceRetryAfter = 300
+
+// maintenanceExemptPaths are writes allowed during maintenance, so administrators can still log in
+// and switch it off, companion services can still check tokens and the docs can still check
+// queries. Paths under /admin are always allowed.
+var maintenanceExemptPaths = []string{"/auth/login", "/auth/logout", "/auth/introspect", "/query/validate"}
+
+// MaintenanceMiddleware refuses write requests (anything but GET, HEAD and OPTIONS) with
+// 503 Service Unavailable and a Retry-After header while maintenance mode covers their path.
+// prefix is the version prefix the routes are mounted under ("/v1", or "" for legacy paths).
+func MaintenanceMiddleware(database *db.Database, prefix string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		maintenance := database.GetMaintenance()
+		path := strings.TrimPrefix(c.Request.URL.Path, prefix)
+		if !maintenance.Enabled || maintenanceExempt(path) || !maintenanceCovers(maintenance, path) {
+			c.Next()
+			return
+		}
+
+		retryAfter := maintenance.RetryAfterSeconds
+		if retryAfter <= 0 {
+			retryAfter = defaultMaintenanceRetryAfter
+		}
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		if maintenance.Message != "" {
+			utils.GinLocalizedError(c, http.StatusServiceUnavailable, i18n.MsgMaintenanceNote, maintenance.Message)
+		} else {
+			utils.GinLocalizedError(c, http.StatusServiceUnavailable, i18n.MsgMaintenance)
+		}
+	}
+}
+
+// maintenanceExempt reports whether writes to path are allowed even during maintenance.
+func maintenanceExempt(path string) bool {
+	if matchesRoute(path, "/admin") {
+		return true
+	}
+	for _, exempt := range maintenanceExemptPaths {
+		if path == exempt {
+			return true
+		}
+	}
+	return false
+}
+
+// maintenanceCovers reports whether maintenance applies to path: it covers every path unless
+// it is limited to some routes.
+func maintenanceCovers(maintenance models.Maintenance, path string) bool {
+	if len(maintenance.Routes) == 0 {
+		return true
+	}
+	for _, route := range maintenance.Routes {
+		if matchesRoute(path, route) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeRoute turns a route given by an administrator into the form matchesRoute expects:
+// without the version prefix or a trailing slash. It reports false if route is not a path.
+func normalizeRoute(route string) (string, bool) {
+	normalized := strings.TrimSuffix(strings.TrimSpace(route), "/")
+	if trimmed, versioned := strings.CutPrefix(normalized, "/"+CurrentAPIVersion); versioned && (trimmed == "" || strings.HasPrefix(trimmed, "/")) {
+		normalized = trimmed
+	}
+	if !strings.HasPrefix(normalized, "/") || strings.ContainsAny(normalized, " ?#") {
+		return "", false
+	}
+	return normalized, true
+}
+
+// matchesRoute reports whether path is route or lies below it ("/documents" matches "/documents/abc").
+func matchesRoute(path, route string) bool {
+	return path == route || strings.HasPrefix(path, route+"/")
+}
+
+// MaintenanceRequest defines the body for switching maintenance mode.
+type MaintenanceRequest struct {
+	Enabled           *bool    `json:"enabled" binding:"required"`
+	Routes            []string `json:"routes,omitempty"`                              // Limit maintenance to these paths and everything below them, e.g. "/documents"; empty = all
+	Message           string   `json:"message,omitempty" binding:"max=500"`           // Shown to clients whose writes are refused
+	RetryAfterSeconds int      `json:"retry_after_seconds,omitempty" binding:"min=0"` // Retry-After sent with refusals (default 300)
+}
+
+// SetMaintenanceHandler switches maintenance mode on or off.
+// @Summary      Switch Maintenance Mode (Admin)
+// @Description  While maintenance mode is enabled, write requests (`POST`, `PUT`, `PATCH`, `DELETE`) are refused with `503 Service Unavailable` and a `Retry-After` header, while reads keep working. Use it during backups or migrations.
+// @Description  Send `routes` (e.g. `["/documents"]`) to limit it to those paths and everything below them, with or without the version prefix; without routes it covers every route. Logging in and out and the `/admin` endpoints are never blocked.
+// @Description  The setting is saved with the database, so it survives restarts, and `GET /status` reports it. Administrators only.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        maintenance body MaintenanceRequest true "Whether maintenance is on, and for which routes."
+// @Success      200  {object}  utils.Envelope{data=models.Maintenance} "The new maintenance mode."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The body is invalid, or a route is not a path."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Router       /admin/maintenance [post]
+func SetMaintenanceHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+
+	var req MaintenanceRequest
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidRequestBody) {
+		return
+	}
+
+	routes := make([]string, 0, len(req.Routes))
+	for _, route := range req.Routes {
+		normalized, ok := normalizeRoute(route)
+		if !ok {
+			utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgMaintenanceRoute, route)
+			return
+		}
+		routes = append(routes, normalized)
+	}
+
+	maintenance := database.SetMaintenance(models.Maintenance{
+		Enabled:           *req.Enabled,
+		Routes:            routes,
+		Message:           strings.TrimSpace(req.Message),
+		RetryAfterSeconds: req.RetryAfterSeconds,
+		ChangedBy:         userID.(string),
+	})
+	utils.RespondData(c, http.StatusOK, maintenance)
+}
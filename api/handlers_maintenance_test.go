@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"docserver/i18n"
+	"docserver/models"
+	"docserver/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenanceMode(t *testing.T) {
+	router, _, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	cfg.AdminEmails = []string{"maint.admin@example.com"}
+
+	_, _, adminToken := createTestUserAndLogin(t, router, "maint.admin@example.com", "password123", "Maint", "Admin")
+	_, _, userToken := createTestUserAndLogin(t, router, "maint.user@example.com", "password123", "Maint", "User")
+	rr := performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, gin.H{"content": "before"}), userToken)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var doc models.Document
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+
+	setMaintenance := func(body gin.H) *models.Maintenance {
+		rr := performRequest(router, http.MethodPost, "/admin/maintenance", marshalJSONBody(t, body), adminToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var maintenance models.Maintenance
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &maintenance))
+		return &maintenance
+	}
+
+	t.Run("Admins only", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/admin/maintenance", marshalJSONBody(t, gin.H{"enabled": true}), userToken)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("Global maintenance refuses writes only", func(t *testing.T) {
+		maintenance := setMaintenance(gin.H{"enabled": true, "retry_after_seconds": 60})
+		assert.True(t, maintenance.Enabled)
+
+		rr := performRequest(router, http.MethodPut, "/v1/documents/"+doc.ID, marshalJSONBody(t, gin.H{"content": "during"}), userToken)
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		assert.Equal(t, "60", rr.Header().Get("Retry-After"))
+		var resp utils.ErrorEnvelope
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, i18n.MsgMaintenance, resp.Error.MessageID)
+
+		rr = performRequest(router, http.MethodGet, "/documents/"+doc.ID, nil, userToken)
+		assert.Equal(t, http.StatusOK, rr.Code, "reads keep working")
+		rr = performRequest(router, http.MethodPost, "/auth/login", marshalJSONBody(t, gin.H{"email": "maint.user@example.com", "password": "password123"}), "")
+		assert.Equal(t, http.StatusOK, rr.Code, "logging in keeps working")
+
+		rr = performRequest(router, http.MethodGet, "/status", nil, "")
+		var status StatusResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &status))
+		assert.True(t, status.Maintenance.Enabled)
+	})
+
+	t.Run("Limited to routes", func(t *testing.T) {
+		setMaintenance(gin.H{"enabled": true, "routes": []string{"/v1/profiles/"}, "message": "Moving profiles"})
+
+		rr := performRequest(router, http.MethodPut, "/profiles/me", marshalJSONBody(t, gin.H{"first_name": "M", "last_name": "U"}), userToken)
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		assert.Equal(t, "300", rr.Header().Get("Retry-After"), "default Retry-After")
+		assert.Contains(t, rr.Body.String(), "Moving profiles")
+
+		rr = performRequest(router, http.MethodPut, "/documents/"+doc.ID, marshalJSONBody(t, gin.H{"content": "after"}), userToken)
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	})
+
+	t.Run("Invalid route", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/admin/maintenance", marshalJSONBody(t, gin.H{"enabled": true, "routes": []string{"documents"}}), adminToken)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Disabled again", func(t *testing.T) {
+		setMaintenance(gin.H{"enabled": false})
+		rr := performRequest(router, http.MethodPut, "/profiles/me", marshalJSONBody(t, gin.H{"first_name": "M", "last_name": "U"}), userToken)
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	})
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/models"
+	"docserver/utils"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Mock Data ---
+
+const (
+	defaultMockCount = 20  // Mock documents generated when 'n' is not given
+	maxMockCount     = 100 // Most mock documents per request
+)
+
+// GetMockDocumentsHandler generates fake documents without storing them.
+// @Summary      Generate Mock Documents (Debug Mode)
+// @Description  Returns `n` made-up documents whose content follows `shape`, in the same form as `GET /documents`, so you can build a frontend against realistic data before it creates any documents. Nothing is stored, and every request returns new documents unless you pass the same `seed`.
+// @Description
+// @Description  `shape` is a JSON value describing the content: a string names the kind of value to generate (`string`, `word`, `sentence`, `paragraph`, `name`, `first_name`, `last_name`, `email`, `url`, `id`, `int`, `number`, `bool`, `date` or `enum`), an object generates an object with a value for each of its fields, and an array with one element generates one to five values of that element's shape. Numbers, booleans and `null` are copied as they are.
+// @Description  `int` and `number` take an optional range such as `int:1..10`, and `enum` a list of choices such as `enum:draft|final`. Example: `{"title":"sentence","grade":"int:0..100","tags":["word"]}`. Without a shape you get lab reports.
+// @Description
+// @Description  Only available while the server runs in debug mode (`-gin-mode debug`, the default); otherwise it answers `404 Not Found`. No login required.
+// @Tags         Mock
+// @Produce      json
+// @Param        n      query     int     false "Number of documents to generate (1-100)." default(20)
+// @Param        shape  query     string  false "JSON shape of the generated content."
+// @Param        seed   query     int     false "Seed for the generator; the same seed and shape give the same documents."
+// @Success      200  {object}  utils.Envelope{data=[]models.Document,meta=utils.PageMeta} "The generated documents."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: 'n', 'shape' or 'seed' is invalid."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: The server is not running in debug mode."
+// @Router       /mock/documents [get]
+func GetMockDocumentsHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	if cfg.GinMode != config.GinModeDebug {
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgMockDisabled)
+		return
+	}
+
+	count, err := strconv.Atoi(c.DefaultQuery("n", strconv.Itoa(defaultMockCount)))
+	if err != nil || count < 1 || count > maxMockCount {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgMockCount, maxMockCount)
+		return
+	}
+
+	var shape any
+	if shapeQuery := c.Query("shape"); shapeQuery == "" {
+		shape = utils.MockDocumentShape
+	} else {
+		if err := json.Unmarshal([]byte(shapeQuery), &shape); err != nil {
+			utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgMockShape, err)
+			return
+		}
+	}
+	if err := utils.ValidateMockShape(shape); err != nil {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgMockShape, err)
+		return
+	}
+
+	seed := time.Now().UnixNano()
+	if seedQuery := c.Query("seed"); seedQuery != "" {
+		if seed, err = strconv.ParseInt(seedQuery, 10, 64); err != nil {
+			utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgMockSeed)
+			return
+		}
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	docs := make([]models.Document, count)
+	for i := range docs {
+		content, _ := utils.GenerateMockContent(shape, rng) // The shape was validated above
+		id, _ := utils.GenerateMockContent("id", rng)
+		ownerID, _ := utils.GenerateMockContent("id", rng)
+		created := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(rng.Int63n(int64(365 * 24 * time.Hour)))).Truncate(time.Second)
+		docs[i] = models.Document{
+			ID:               id.(string),
+			OwnerID:          ownerID.(string),
+			Content:          content,
+			Version:          1 + rng.Intn(5),
+			CreationDate:     created,
+			LastModifiedDate: created.Add(time.Duration(rng.Int63n(int64(30 * 24 * time.Hour)))).Truncate(time.Second),
+		}
+	}
+	utils.RespondList(c, docs, count, 1, count)
+}
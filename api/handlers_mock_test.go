@@ -0,0 +1,73 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"docserver/config"
+	"docserver/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockDocuments(t *testing.T) {
+	router, database, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	cfg.GinMode = config.GinModeDebug
+
+	list := func(query string) []models.Document {
+		rr := performRequest(router, http.MethodGet, "/v1/mock/documents"+query, nil, "")
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var body struct {
+			Data []models.Document `json:"data"`
+			Meta struct {
+				Total int `json:"total"`
+			} `json:"meta"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		assert.Equal(t, len(body.Data), body.Meta.Total)
+		return body.Data
+	}
+
+	t.Run("Default shape", func(t *testing.T) {
+		docs := list("")
+		require.Len(t, docs, defaultMockCount)
+		for _, doc := range docs {
+			assert.Len(t, doc.ID, 32)
+			content := doc.Content.(map[string]any)
+			assert.Contains(t, content, "title")
+			assert.Contains(t, content, "grade")
+		}
+		assert.Empty(t, database.GetAllDocuments(), "mock documents are not stored")
+	})
+
+	t.Run("Custom shape and seed", func(t *testing.T) {
+		query := "?n=3&seed=5&shape=" + url.QueryEscape(`{"name":"name","score":"int:1..3","tags":["enum:a|b"]}`)
+		docs := list(query)
+		require.Len(t, docs, 3)
+		for _, doc := range docs {
+			content := doc.Content.(map[string]any)
+			assert.Len(t, content, 3)
+			assert.GreaterOrEqual(t, content["score"], float64(1))
+			assert.LessOrEqual(t, content["score"], float64(3))
+		}
+		assert.Equal(t, docs, list(query), "the same seed gives the same documents")
+	})
+
+	t.Run("Invalid parameters", func(t *testing.T) {
+		for _, query := range []string{"?n=0", "?n=101", "?n=many", "?seed=x", "?shape=" + url.QueryEscape(`{"a":"colour"}`), "?shape=" + url.QueryEscape(`{"a":`)} {
+			rr := performRequest(router, http.MethodGet, "/mock/documents"+query, nil, "")
+			assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+		}
+	})
+
+	t.Run("Debug mode only", func(t *testing.T) {
+		cfg.GinMode = config.GinModeRelease
+		defer func() { cfg.GinMode = config.GinModeDebug }()
+		rr := performRequest(router, http.MethodGet, "/mock/documents", nil, "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
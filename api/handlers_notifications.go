@@ -0,0 +1,279 @@
+package api
+
+import (
+	"docserver/apperr"
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/models"
+	"docserver/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Notification Channels ---
+
+// NotificationChannelRequest defines the body for creating or replacing a notification channel.
+type NotificationChannelRequest struct {
+	Name       string   `json:"name" binding:"required"`
+	Kind       string   `json:"kind" binding:"required" example:"slack"`                                                  // "slack" or "discord"
+	WebhookURL string   `json:"webhook_url" binding:"required" example:"https://hooks.slack.com/services/T000/B000/XXXX"` // The channel's incoming webhook
+	Events     []string `json:"events,omitempty"`                                                                         // Events to send (default all): "document.shared", "submission.received"
+	AllUsers   bool     `json:"all_users,omitempty"`                                                                      // Administrators only: events of every user
+	MaxPerHour int      `json:"max_per_hour,omitempty"`                                                                   // Messages per hour at most (default and ceiling: the server's limit)
+	Enabled    *bool    `json:"enabled,omitempty"`                                                                        // Defaults to true
+}
+
+// toChannel validates the request and converts it to a notification channel. isAdmin tells
+// whether the user may receive the events of every user.
+func (req NotificationChannelRequest) toChannel(cfg *config.Config, isAdmin bool) (models.NotificationChannel, error) {
+	kind := strings.ToLower(strings.TrimSpace(req.Kind))
+	if kind != models.NotificationChannelSlack && kind != models.NotificationChannelDiscord {
+		return models.NotificationChannel{}, fmt.Errorf("kind must be '%s' or '%s'", models.NotificationChannelSlack, models.NotificationChannelDiscord)
+	}
+
+	if len(cfg.WebhookAllowedDomains) == 0 {
+		return models.NotificationChannel{}, fmt.Errorf("webhook targets are not enabled on this server")
+	}
+	parsed, err := utils.ParseFetchURL(req.WebhookURL)
+	if err != nil {
+		return models.NotificationChannel{}, fmt.Errorf("webhook_url: %v", err)
+	}
+	if !utils.HostAllowed(parsed.Hostname(), cfg.WebhookAllowedDomains) {
+		return models.NotificationChannel{}, fmt.Errorf("webhook_url host '%s' is not allowed. Allowed domains: %s",
+			parsed.Hostname(), strings.Join(cfg.WebhookAllowedDomains, ", "))
+	}
+
+	events := make([]string, 0, len(req.Events))
+	for _, event := range req.Events {
+		event = strings.ToLower(strings.TrimSpace(event))
+		if !db.IsNotificationEvent(event) {
+			return models.NotificationChannel{}, fmt.Errorf("unknown event '%s'; events are %s", event, strings.Join(db.NotificationEvents, ", "))
+		}
+		if !slices.Contains(events, event) {
+			events = append(events, event)
+		}
+	}
+	if len(events) == 0 {
+		events = slices.Clone(db.NotificationEvents)
+	}
+
+	if req.AllUsers && !isAdmin {
+		return models.NotificationChannel{}, fmt.Errorf("only administrators may receive the events of all users")
+	}
+	maxPerHour := req.MaxPerHour
+	switch {
+	case maxPerHour == 0:
+		maxPerHour = cfg.NotificationMaxPerHour
+	case maxPerHour < 0 || maxPerHour > cfg.NotificationMaxPerHour:
+		return models.NotificationChannel{}, fmt.Errorf("max_per_hour must be between 1 and %d", cfg.NotificationMaxPerHour)
+	}
+
+	return models.NotificationChannel{
+		Name:       strings.TrimSpace(req.Name),
+		Kind:       kind,
+		WebhookURL: parsed.String(),
+		Events:     events,
+		AllUsers:   req.AllUsers,
+		MaxPerHour: maxPerHour,
+		Enabled:    req.Enabled == nil || *req.Enabled,
+	}, nil
+}
+
+// ownedNotificationChannel loads the channel in the :id path parameter and checks that the
+// authenticated user owns it. Channels of other users are reported as not found. On failure the
+// response is already sent.
+func ownedNotificationChannel(c *gin.Context, database *db.Database) (models.NotificationChannel, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return models.NotificationChannel{}, false
+	}
+	channelID := c.Param("id")
+	channel, found := database.GetNotificationChannel(channelID)
+	if !found || channel.OwnerID != userID.(string) {
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgNotificationChannelNotFound, channelID)
+		return models.NotificationChannel{}, false
+	}
+	return channel, true
+}
+
+// ListNotificationChannelsHandler lists the authenticated user's notification channels.
+// @Summary      List Your Notification Channels
+// @Description  Lists the Slack and Discord webhooks you have events posted to, oldest first.
+// @Tags         Notifications
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  utils.Envelope{data=[]models.NotificationChannel} "Your notification channels."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Router       /notification-channels [get]
+func ListNotificationChannelsHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+	utils.RespondData(c, http.StatusOK, database.ListNotificationChannels(userID.(string)))
+}
+
+// CreateNotificationChannelHandler adds a notification channel.
+// @Summary      Add a Notification Channel
+// @Description  Has events that concern you posted to a Slack or Discord channel through its incoming webhook (`webhook_url`, which must be on one of the server's allowed domains, `DOCSERVER_WEBHOOK_ALLOWED_DOMAINS`, e.g. `hooks.slack.com` or `discord.com`). The events are:
+// @Description  *   **`document.shared`**: someone shared a document with you.
+// @Description  *   **`submission.received`**: a document shared with you was submitted for review (to you, if the owner named a reviewer).
+// @Description
+// @Description  `events` picks which are posted (all by default). At most `max_per_hour` messages are posted an hour (by default, and at most, the server's limit, `DOCSERVER_NOTIFICATION_MAX_PER_HOUR`); events beyond it are dropped. Administrators can set `all_users` to have the events of every user posted, e.g. to a staff channel.
+// @Tags         Notifications
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        channel body NotificationChannelRequest true "The channel."
+// @Success      201  {object}  utils.Envelope{data=models.NotificationChannel} "Channel added."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: A field is missing or invalid, or the webhook URL is not allowed."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      500  {object}  utils.ErrorEnvelope "Internal Server Error: The channel could not be saved."
+// @Router       /notification-channels [post]
+func CreateNotificationChannelHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+
+	var req NotificationChannelRequest
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidRequestBody) {
+		return
+	}
+	channel, err := req.toChannel(cfg, authzFacts{documentReader: database, cfg: cfg}.IsAdmin(c.GetString("userID")))
+	if err != nil {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgNotificationChannelInvalid, err)
+		return
+	}
+	channel.OwnerID = userID.(string)
+
+	created, err := database.CreateNotificationChannel(channel)
+	if err != nil {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgNotificationChannelSaveFailed, err)
+		return
+	}
+	utils.RespondData(c, http.StatusCreated, created)
+}
+
+// GetNotificationChannelHandler returns one of the authenticated user's notification channels.
+// @Summary      Get a Notification Channel
+// @Description  Returns one of your notification channels.
+// @Tags         Notifications
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "The unique identifier of the channel."
+// @Success      200  {object}  utils.Envelope{data=models.NotificationChannel} "The channel."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: You have no notification channel with the specified ID."
+// @Router       /notification-channels/{id} [get]
+func GetNotificationChannelHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	channel, ok := ownedNotificationChannel(c, database)
+	if !ok {
+		return // Error response already sent
+	}
+	utils.RespondData(c, http.StatusOK, channel)
+}
+
+// UpdateNotificationChannelHandler replaces one of the authenticated user's notification channels.
+// @Summary      Replace a Notification Channel
+// @Description  Replaces a channel's settings. Send `"enabled": false` to pause a channel without deleting it.
+// @Tags         Notifications
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string                      true  "The unique identifier of the channel."
+// @Param        channel  body      NotificationChannelRequest  true  "The new settings."
+// @Success      200  {object}  utils.Envelope{data=models.NotificationChannel} "Channel updated."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: A field is missing or invalid, or the webhook URL is not allowed."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: You have no notification channel with the specified ID."
+// @Failure      500  {object}  utils.ErrorEnvelope "Internal Server Error: The channel could not be saved."
+// @Router       /notification-channels/{id} [put]
+func UpdateNotificationChannelHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	existing, ok := ownedNotificationChannel(c, database)
+	if !ok {
+		return // Error response already sent
+	}
+
+	var req NotificationChannelRequest
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidRequestBody) {
+		return
+	}
+	channel, err := req.toChannel(cfg, authzFacts{documentReader: database, cfg: cfg}.IsAdmin(c.GetString("userID")))
+	if err != nil {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgNotificationChannelInvalid, err)
+		return
+	}
+
+	updated, err := database.UpdateNotificationChannel(existing.ID, channel)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgNotificationChannelNotFound, existing.ID)
+		} else {
+			utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgNotificationChannelSaveFailed, err)
+		}
+		return
+	}
+	utils.RespondData(c, http.StatusOK, updated)
+}
+
+// DeleteNotificationChannelHandler removes one of the authenticated user's notification channels.
+// @Summary      Delete a Notification Channel
+// @Description  Stops posting events to a channel and forgets it.
+// @Tags         Notifications
+// @Security     BearerAuth
+// @Param        id   path      string  true  "The unique identifier of the channel."
+// @Success      204  "Channel deleted."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: You have no notification channel with the specified ID."
+// @Router       /notification-channels/{id} [delete]
+func DeleteNotificationChannelHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	channel, ok := ownedNotificationChannel(c, database)
+	if !ok {
+		return // Error response already sent
+	}
+	if err := database.DeleteNotificationChannel(channel.ID); err != nil {
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgNotificationChannelNotFound, channel.ID)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// TestNotificationChannelHandler posts a test message to one of the authenticated user's channels.
+// @Summary      Send a Test Message
+// @Description  Posts a test message to the channel right away, even if it is disabled, so you can check its webhook works. The message counts against the channel's `max_per_hour`.
+// @Tags         Notifications
+// @Security     BearerAuth
+// @Param        id   path      string  true  "The unique identifier of the channel."
+// @Success      204  "The message was delivered."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: You have no notification channel with the specified ID."
+// @Failure      429  {object}  utils.ErrorEnvelope "Too Many Requests: The channel was sent its `max_per_hour` messages this hour."
+// @Failure      502  {object}  utils.ErrorEnvelope "Bad Gateway: The webhook could not be reached or refused the message."
+// @Router       /notification-channels/{id}/test [post]
+func TestNotificationChannelHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	channel, ok := ownedNotificationChannel(c, database)
+	if !ok {
+		return // Error response already sent
+	}
+	err := database.SendTestNotification(channel.ID)
+	switch {
+	case err == nil:
+		c.Status(http.StatusNoContent)
+	case errors.Is(err, db.ErrNotificationRateLimited):
+		utils.GinLocalizedError(c, http.StatusTooManyRequests, i18n.MsgNotificationChannelRateLimited)
+	case errors.Is(err, apperr.ErrNotFound):
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgNotificationChannelNotFound, channel.ID)
+	default:
+		utils.GinLocalizedError(c, http.StatusBadGateway, i18n.MsgNotificationDeliveryFailed, err)
+	}
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"docserver/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationChannelEndpoints(t *testing.T) {
+	router, _, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	cfg.AdminEmails = []string{"channels.admin@example.com"}
+	cfg.NotificationMaxPerHour = 30
+
+	posted := make(chan string, 10)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		posted <- string(body)
+	}))
+	defer hook.Close()
+
+	_, _, ownerToken := createTestUserAndLogin(t, router, "channels.owner@example.com", "password123", "Chan", "Owner")
+	_, _, adminToken := createTestUserAndLogin(t, router, "channels.admin@example.com", "password123", "Chan", "Admin")
+
+	t.Run("Webhooks must be enabled", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/notification-channels", marshalJSONBody(t, gin.H{
+			"name": "class", "kind": "slack", "webhook_url": hook.URL,
+		}), ownerToken)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "not enabled")
+	})
+
+	cfg.WebhookAllowedDomains = []string{"127.0.0.1"}
+	defer func() { cfg.WebhookAllowedDomains = nil }()
+
+	var channel models.NotificationChannel
+	t.Run("Create with defaults", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/notification-channels", marshalJSONBody(t, gin.H{
+			"name": "class", "kind": "Slack", "webhook_url": hook.URL + "/in",
+		}), ownerToken)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &channel))
+		assert.Equal(t, models.NotificationChannelSlack, channel.Kind)
+		assert.Equal(t, []string{models.NotificationDocumentShared, models.NotificationSubmissionReceived}, channel.Events)
+		assert.Equal(t, cfg.NotificationMaxPerHour, channel.MaxPerHour)
+		assert.True(t, channel.Enabled)
+		assert.False(t, channel.AllUsers)
+	})
+
+	t.Run("Invalid channels are refused", func(t *testing.T) {
+		for name, body := range map[string]gin.H{
+			"kind":      {"name": "x", "kind": "teams", "webhook_url": hook.URL},
+			"url":       {"name": "x", "kind": "slack", "webhook_url": "ftp://127.0.0.1/in"},
+			"host":      {"name": "x", "kind": "slack", "webhook_url": "https://hooks.slack.com/in"},
+			"event":     {"name": "x", "kind": "slack", "webhook_url": hook.URL, "events": []string{"document.deleted"}},
+			"rate":      {"name": "x", "kind": "slack", "webhook_url": hook.URL, "max_per_hour": cfg.NotificationMaxPerHour + 1},
+			"all users": {"name": "x", "kind": "slack", "webhook_url": hook.URL, "all_users": true},
+		} {
+			rr := performRequest(router, http.MethodPost, "/notification-channels", marshalJSONBody(t, body), ownerToken)
+			assert.Equal(t, http.StatusBadRequest, rr.Code, name)
+		}
+	})
+
+	t.Run("Administrators can receive every user's events", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/notification-channels", marshalJSONBody(t, gin.H{
+			"name": "staff", "kind": "discord", "webhook_url": hook.URL + "/staff", "all_users": true,
+			"events": []string{"submission.received"}, "max_per_hour": 5,
+		}), adminToken)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var staff models.NotificationChannel
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &staff))
+		assert.True(t, staff.AllUsers)
+		assert.Equal(t, []string{models.NotificationSubmissionReceived}, staff.Events)
+		assert.Equal(t, 5, staff.MaxPerHour)
+	})
+
+	t.Run("List, update and other users", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/notification-channels", nil, ownerToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var channels []models.NotificationChannel
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &channels))
+		assert.Len(t, channels, 1)
+
+		rr = performRequest(router, http.MethodPut, "/notification-channels/"+channel.ID, marshalJSONBody(t, gin.H{
+			"name": "class", "kind": "slack", "webhook_url": hook.URL + "/in", "events": []string{"document.shared"}, "enabled": false,
+		}), ownerToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &channel))
+		assert.False(t, channel.Enabled)
+		assert.Equal(t, []string{models.NotificationDocumentShared}, channel.Events)
+
+		for _, method := range []string{http.MethodGet, http.MethodDelete} {
+			rr = performRequest(router, method, "/notification-channels/"+channel.ID, nil, adminToken)
+			assert.Equal(t, http.StatusNotFound, rr.Code, method)
+		}
+		rr = performRequest(router, http.MethodGet, "/notification-channels/sch_0123456789", nil, ownerToken)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, "not a channel ID")
+	})
+
+	t.Run("Test message", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/notification-channels/"+channel.ID+"/test", nil, ownerToken)
+		require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+		assert.Contains(t, <-posted, `"text":"Test message`)
+
+		hook.Close()
+		rr = performRequest(router, http.MethodPost, "/notification-channels/"+channel.ID+"/test", nil, ownerToken)
+		assert.Equal(t, http.StatusBadGateway, rr.Code)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		rr := performRequest(router, http.MethodDelete, "/notification-channels/"+channel.ID, nil, ownerToken)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		rr = performRequest(router, http.MethodGet, "/notification-channels/"+channel.ID, nil, ownerToken)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"docserver/config"
+	"docserver/db"
+	"docserver/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Orphaned References ---
+
+// ListOrphansHandler reports entries that refer to profiles, documents or schedules that no longer exist.
+// @Summary      Report Orphaned References (Admin)
+// @Description  Lists share records, sharers, favorites, document activity and versions, schedule run histories, pending email changes and reviews that refer to a profile, document or schedule that no longer exists, with counts per collection. Nothing is changed; use `POST /admin/orphans/clean` to remove them. Administrators only.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  utils.Envelope{data=db.OrphanReport} "The orphaned entries."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Router       /admin/orphans [get]
+func ListOrphansHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	utils.RespondData(c, http.StatusOK, database.FindOrphans())
+}
+
+// CleanOrphansHandler removes the entries ListOrphansHandler reports.
+// @Summary      Remove Orphaned References (Admin)
+// @Description  Removes the entries `GET /admin/orphans` reports and returns them. Sharers and favorites pointing at missing records are dropped from their lists; the other entries are deleted. Administrators only.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  utils.Envelope{data=db.OrphanReport} "The removed entries."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Router       /admin/orphans/clean [post]
+func CleanOrphansHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	utils.RespondData(c, http.StatusOK, database.CleanOrphans())
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"docserver/db"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrphanEndpoints(t *testing.T) {
+	router, database, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	cfg.AdminEmails = []string{"orphan.admin@example.com"}
+
+	_, _, adminToken := createTestUserAndLogin(t, router, "orphan.admin@example.com", "password123", "Orphan", "Admin")
+	userID, _, userToken := createTestUserAndLogin(t, router, "orphan.user@example.com", "password123", "Orphan", "User")
+	rr := performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, map[string]any{"content": "x"}), userToken)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var created struct{ ID string }
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	_, err := database.AddFavorite(userID, created.ID)
+	require.NoError(t, err)
+
+	// Drop the document behind the favorites' back
+	database.Database.Mu.Lock()
+	delete(database.Database.Documents, created.ID)
+	database.Database.Mu.Unlock()
+
+	rr = performRequest(router, http.MethodGet, "/admin/orphans", nil, userToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	rr = performRequest(router, http.MethodGet, "/admin/orphans", nil, adminToken)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var report db.OrphanReport
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+	assert.Contains(t, report.Orphans, db.Orphan{Collection: db.OrphanFavorites, Key: userID, MissingType: "document", MissingID: created.ID})
+	assert.NotEmpty(t, database.GetFavoriteIDs(userID), "the report changes nothing")
+
+	rr = performRequest(router, http.MethodPost, "/admin/orphans/clean", nil, adminToken)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Empty(t, database.GetFavoriteIDs(userID))
+	assert.Empty(t, database.FindOrphans().Orphans)
+}
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"docserver/config"
+	"docserver/db"
+	"docserver/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Password Hash Migration ---
+
+// GetPasswordHashReportHandler reports how many accounts still have password hashes made with older parameters.
+// @Summary      Report Password Hash Migration (Admin)
+// @Description  When the password hashing algorithm or its cost changes (see -password-hash, -bcrypt-cost, -argon2-* and -password-hash-target), stored hashes are replaced with ones made with the new parameters as their owners log in. This reports how far that has got: how many accounts have a hash made with the configured parameters (`current`), how many will be rehashed at their next login (`outdated`), how many hashes were replaced since the server started (`rehashed`), and the accounts per set of parameters (`groups`). Administrators only.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  utils.Envelope{data=db.PasswordHashReport} "The password hash report."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Router       /admin/password-hashes [get]
+func GetPasswordHashReportHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	utils.RespondData(c, http.StatusOK, database.PasswordHashReport())
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"docserver/db"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordHashReport(t *testing.T) {
+	router, _, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	cfg.AdminEmails = []string{"hash.admin@example.com"}
+
+	_, _, adminToken := createTestUserAndLogin(t, router, "hash.admin@example.com", "password123", "Hash", "Admin")
+	_, _, userToken := createTestUserAndLogin(t, router, "hash.user@example.com", "password123", "Hash", "User")
+	report := func() db.PasswordHashReport {
+		rr := performRequest(router, http.MethodGet, "/admin/password-hashes", nil, adminToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var report db.PasswordHashReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		return report
+	}
+
+	rr := performRequest(router, http.MethodGet, "/admin/password-hashes", nil, userToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	got := report()
+	assert.Equal(t, "bcrypt cost=4", got.Configured)
+	assert.Equal(t, 2, got.Accounts)
+	assert.Equal(t, 2, got.Current)
+	assert.Zero(t, got.Outdated)
+	assert.Equal(t, []db.PasswordHashGroup{{Parameters: "bcrypt cost=4", Accounts: 2, Current: true}}, got.Groups)
+
+	// Raising the cost leaves both accounts outdated until they log in
+	cfg.BcryptCost = 5
+	got = report()
+	assert.Equal(t, 2, got.Outdated)
+	assert.Zero(t, got.Current)
+
+	rr = performRequest(router, http.MethodPost, "/auth/login", marshalJSONBody(t, gin.H{"email": "hash.user@example.com", "password": "password123"}), "")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	got = report()
+	assert.Equal(t, 1, got.Current)
+	assert.Equal(t, 1, got.Outdated)
+	assert.Equal(t, int64(1), got.Rehashed)
+	assert.Equal(t, []db.PasswordHashGroup{
+		{Parameters: "bcrypt cost=4", Accounts: 1, Current: false},
+		{Parameters: "bcrypt cost=5", Accounts: 1, Current: true},
+	}, got.Groups)
+}
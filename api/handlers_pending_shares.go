@@ -0,0 +1,108 @@
+package api
+
+import (
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Share by Email ---
+
+// Outcomes of sharing a document by email
+const (
+	ShareByEmailShared  = "shared"  // The email belongs to a user, who now has access
+	ShareByEmailPending = "pending" // Nobody has the email yet; they get access when they sign up
+)
+
+// ShareByEmailRequest defines the body for sharing a document by email.
+type ShareByEmailRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ShareByEmailResponse tells how a document was shared with an email.
+type ShareByEmailResponse struct {
+	Email     string `json:"email"`
+	Status    string `json:"status"`               // "shared" or "pending"
+	ProfileID string `json:"profile_id,omitempty"` // The user the document is now shared with; only when "shared"
+}
+
+// ShareByEmailHandler shares a document with an email address, registered or not.
+// @Summary      Share a Document by Email
+// @Description  Shares a document with the user who has the given `email`. If someone already uses the email, the document is shared with them right away (`200 OK`, status `shared`), as with `PUT /documents/{id}/shares/{profile_id}`.
+// @Description
+// @Description  Otherwise the share is kept as pending (`202 Accepted`, status `pending`) and the document is shared with whoever signs up with that email. Pending shares are listed in `pending_emails` by `GET /documents/{id}/shares`; withdraw one with `DELETE /documents/{id}/shares/email/{email}`. Emails are compared case-insensitively, and sharing twice with the same email changes nothing.
+// @Description
+// @Description  Only the document owner can share it, and not with their own email.
+// @Tags         Sharing
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id     path      string               true  "The unique identifier of the document you want to share." example(doc_abc123xyz)
+// @Param        share  body      ShareByEmailRequest  true  "The email to share the document with."
+// @Success      200  {object}  utils.Envelope{data=ShareByEmailResponse} "Shared with the user who has the email."
+// @Success      202  {object}  utils.Envelope{data=ShareByEmailResponse} "Nobody has the email yet; shared once someone signs up with it."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The email is missing or invalid, or it is your own."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not the owner of this document, so you cannot share it."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No document exists with the specified ID."
+// @Failure      423  {object}  utils.ErrorEnvelope "Locked: The document is frozen."
+// @Router       /documents/{id}/shares/email [post]
+func ShareByEmailHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	docID := c.Param("id")
+	ownerID, ok := checkDocumentOwner(c, database, cfg, docID)
+	if !ok {
+		return // Error response already sent by helper
+	}
+
+	var req ShareByEmailRequest
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidRequestBody) {
+		return
+	}
+	if profile, found := database.GetProfileByEmail(req.Email); found && profile.ID == ownerID {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgShareWithOwner)
+		return
+	}
+
+	profileID, err := database.ShareWithEmail(docID, req.Email, ownerID)
+	if err != nil {
+		if respondFrozen(c, err) {
+			return
+		}
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgSharerAddFailed, err)
+		return
+	}
+
+	if profileID != "" {
+		utils.RespondData(c, http.StatusOK, ShareByEmailResponse{Email: req.Email, Status: ShareByEmailShared, ProfileID: profileID})
+		return
+	}
+	utils.RespondData(c, http.StatusAccepted, ShareByEmailResponse{Email: req.Email, Status: ShareByEmailPending})
+}
+
+// CancelPendingShareHandler withdraws the pending share of a document with an email.
+// @Summary      Withdraw a Pending Share
+// @Description  Withdraws the pending share of a document with an email nobody has signed up with yet (see `POST /documents/{id}/shares/email`). Once someone has signed up with the email, the document is shared with them and `DELETE /documents/{id}/shares/{profile_id}` stops sharing it. Withdrawing a share that is not pending changes nothing.
+// @Description
+// @Description  Only the document owner can withdraw pending shares.
+// @Tags         Sharing
+// @Security     BearerAuth
+// @Param        id     path  string  true  "The unique identifier of the document." example(doc_abc123xyz)
+// @Param        email  path  string  true  "The email of the pending share." example(jane.doe@example.com)
+// @Success      204  "The share is no longer pending. No content is returned."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not the owner of this document, so you cannot modify its shares."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No document exists with the specified ID."
+// @Router       /documents/{id}/shares/email/{email} [delete]
+func CancelPendingShareHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	docID := c.Param("id")
+	if _, ok := checkDocumentOwner(c, database, cfg, docID); !ok {
+		return // Error response already sent by helper
+	}
+
+	database.CancelPendingShare(docID, c.Param("email"))
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShareByEmail(t *testing.T) {
+	router, _, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	_, _, ownerToken := createTestUserAndLogin(t, router, "pending.owner@example.com", "password123", "Pending", "Owner")
+	readerID, _, readerToken := createTestUserAndLogin(t, router, "pending.reader@example.com", "password123", "Pending", "Reader")
+
+	rr := performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, map[string]any{"content": "for later"}), ownerToken)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	var doc DocumentResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+	sharePath := "/documents/" + doc.ID + "/shares/email"
+
+	t.Run("Registered emails are shared with right away", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, sharePath, marshalJSONBody(t, map[string]any{"email": "Pending.Reader@example.com"}), ownerToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var resp ShareByEmailResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, ShareByEmailShared, resp.Status)
+		assert.Equal(t, readerID, resp.ProfileID)
+
+		rr = performRequest(router, http.MethodGet, "/documents/"+doc.ID, nil, readerToken)
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("Rejected requests", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, sharePath, marshalJSONBody(t, map[string]any{"email": "pending.owner@example.com"}), ownerToken)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, "own email")
+		rr = performRequest(router, http.MethodPost, sharePath, marshalJSONBody(t, map[string]any{"email": "not-an-email"}), ownerToken)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		rr = performRequest(router, http.MethodPost, sharePath, marshalJSONBody(t, map[string]any{"email": "newcomer@example.com"}), readerToken)
+		assert.Equal(t, http.StatusForbidden, rr.Code, "only the owner")
+	})
+
+	t.Run("Unregistered emails get access on signup", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, sharePath, marshalJSONBody(t, map[string]any{"email": "newcomer@example.com"}), ownerToken)
+		require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+		assert.Contains(t, rr.Body.String(), `"status":"pending"`)
+		rr = performRequest(router, http.MethodPost, sharePath, marshalJSONBody(t, map[string]any{"email": "withdrawn@example.com"}), ownerToken)
+		require.Equal(t, http.StatusAccepted, rr.Code)
+
+		rr = performRequest(router, http.MethodGet, "/documents/"+doc.ID+"/shares", nil, ownerToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var shares GetSharersResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &shares))
+		assert.Equal(t, []string{readerID}, shares.SharedWith)
+		assert.Equal(t, []string{"newcomer@example.com", "withdrawn@example.com"}, shares.PendingEmails)
+
+		rr = performRequest(router, http.MethodDelete, sharePath+"/withdrawn@example.com", nil, ownerToken)
+		require.Equal(t, http.StatusNoContent, rr.Code)
+
+		newcomerID, _, newcomerToken := createTestUserAndLogin(t, router, "newcomer@example.com", "password123", "New", "Comer")
+		rr = performRequest(router, http.MethodGet, "/documents/"+doc.ID, nil, newcomerToken)
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		_, _, withdrawnToken := createTestUserAndLogin(t, router, "withdrawn@example.com", "password123", "With", "Drawn")
+		rr = performRequest(router, http.MethodGet, "/documents/"+doc.ID, nil, withdrawnToken)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+
+		rr = performRequest(router, http.MethodGet, "/documents/"+doc.ID+"/shares", nil, ownerToken)
+		shares = GetSharersResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &shares))
+		assert.ElementsMatch(t, []string{readerID, newcomerID}, shares.SharedWith)
+		assert.Empty(t, shares.PendingEmails)
+	})
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/models"
+	"docserver/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Profile Extra Policy ---
+
+// ExtraPolicyRequest defines the body for setting the policy for profiles' `extra` data.
+type ExtraPolicyRequest struct {
+	Schema          any      `json:"schema,omitempty"`           // JSON Schema `extra` must match; omit to allow any JSON
+	MaxBytes        int      `json:"max_bytes,omitempty"`        // Largest `extra`, JSON-encoded (0 = 16 KiB)
+	SensitiveKeys   []string `json:"sensitive_keys,omitempty"`   // Object key patterns, e.g. "*token*"
+	RejectSensitive bool     `json:"reject_sensitive,omitempty"` // Refuse sensitive keys instead of redacting them in search results
+}
+
+// GetExtraPolicyHandler returns the policy for profiles' extra data.
+// @Summary      Get the Profile Extra Policy (Admin)
+// @Description  Returns what profiles may keep in `extra` (see `PUT /admin/profile-extra-policy`). Until a policy is set, `extra` may be any JSON up to 16 KiB, and the values of keys matching `*token*`, `*password*` or `*secret*` are redacted in search results. Administrators only.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  utils.Envelope{data=models.ExtraPolicy} "The policy in effect."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Router       /admin/profile-extra-policy [get]
+func GetExtraPolicyHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	utils.RespondData(c, http.StatusOK, database.GetExtraPolicy())
+}
+
+// SetExtraPolicyHandler sets the policy for profiles' extra data.
+// @Summary      Set the Profile Extra Policy (Admin)
+// @Description  Limits what profiles may keep in `extra`, replacing the policy in effect:
+// @Description  *   `schema`: A JSON Schema `extra` must match. The keywords `type`, `enum`, `const`, `properties`, `required`, `additionalProperties`, `maxProperties`, `items`, `minItems`, `maxItems`, `minLength`, `maxLength`, `pattern`, `minimum` and `maximum` are supported; schemas using others are rejected.
+// @Description  *   `max_bytes`: The largest `extra` allowed, JSON-encoded. Defaults to 16 KiB.
+// @Description  *   `sensitive_keys`: Object key patterns, at any depth, where `*` matches anything and case is ignored, e.g. `*token*`. Their values are replaced with `"[redacted]"` when other users see the profile in search results, or with `reject_sensitive`, signups and profile updates with such keys are refused.
+// @Description
+// @Description  The policy applies when `extra` is set at signup or changed with `PUT /profiles/me` (`400 Bad Request` if it breaks it); what profiles already hold is not checked again. It is saved with the database. Administrators only.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        policy  body      ExtraPolicyRequest  true  "The new policy."
+// @Success      200  {object}  utils.Envelope{data=models.ExtraPolicy} "The policy now in effect."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The schema uses unsupported keywords or is invalid, a key pattern is invalid, or 'max_bytes' is negative."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Router       /admin/profile-extra-policy [put]
+func SetExtraPolicyHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+
+	var req ExtraPolicyRequest
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidRequestBody) {
+		return
+	}
+
+	policy, err := database.SetExtraPolicy(&models.ExtraPolicy{
+		Schema:          req.Schema,
+		MaxBytes:        req.MaxBytes,
+		SensitiveKeys:   req.SensitiveKeys,
+		RejectSensitive: req.RejectSensitive,
+		ChangedBy:       userID.(string),
+	})
+	if err != nil {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgExtraPolicyInvalid, err)
+		return
+	}
+	utils.RespondData(c, http.StatusOK, policy)
+}
+
+// ResetExtraPolicyHandler goes back to the default policy for profiles' extra data.
+// @Summary      Reset the Profile Extra Policy (Admin)
+// @Description  Removes the policy set with `PUT /admin/profile-extra-policy`: `extra` may again be any JSON up to 16 KiB, and keys matching `*token*`, `*password*` or `*secret*` are redacted in search results. Administrators only.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  utils.Envelope{data=models.ExtraPolicy} "The default policy, now in effect."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Router       /admin/profile-extra-policy [delete]
+func ResetExtraPolicyHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	policy, _ := database.SetExtraPolicy(nil)
+	utils.RespondData(c, http.StatusOK, policy)
+}
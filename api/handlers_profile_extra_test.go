@@ -0,0 +1,67 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtraPolicy(t *testing.T) {
+	router, _, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	cfg.AdminEmails = []string{"extra.admin@example.com"}
+
+	_, _, adminToken := createTestUserAndLogin(t, router, "extra.admin@example.com", "password123", "Extra", "Admin")
+	_, _, userToken := createTestUserAndLogin(t, router, "extra.user@example.com", "password123", "Extra", "User")
+	update := func(extra any) int {
+		body := map[string]any{"first_name": "Extra", "last_name": "User", "extra": extra}
+		return performRequest(router, http.MethodPut, "/profiles/me", marshalJSONBody(t, body), userToken).Code
+	}
+
+	t.Run("Sensitive keys are redacted in search results by default", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, update(map[string]any{"course": "CS101", "api_token": "s3cret"}))
+		rr := performRequest(router, http.MethodGet, "/profiles?email=extra.user", nil, adminToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), "CS101")
+		assert.Contains(t, rr.Body.String(), `"api_token":"[redacted]"`)
+		assert.NotContains(t, rr.Body.String(), "s3cret")
+
+		rr = performRequest(router, http.MethodGet, "/profiles/me", nil, userToken)
+		assert.Contains(t, rr.Body.String(), "s3cret", "owners see their own")
+	})
+
+	t.Run("Only administrators set the policy", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPut, "/admin/profile-extra-policy", marshalJSONBody(t, map[string]any{"max_bytes": 10}), userToken)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		rr = performRequest(router, http.MethodPut, "/admin/profile-extra-policy", marshalJSONBody(t, map[string]any{"schema": map[string]any{"type": "list"}}), adminToken)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	policy := map[string]any{
+		"schema":           map[string]any{"type": "object", "required": []string{"course"}},
+		"sensitive_keys":   []string{"*token*"},
+		"reject_sensitive": true,
+	}
+	rr := performRequest(router, http.MethodPut, "/admin/profile-extra-policy", marshalJSONBody(t, policy), adminToken)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	t.Run("Extra breaking the policy is refused", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, update(map[string]any{"course": "CS101", "api_token": "s3cret2"}))
+		assert.Equal(t, http.StatusBadRequest, update(map[string]any{"year": 1}))
+		assert.Equal(t, http.StatusOK, update(map[string]any{"course": "CS102"}))
+
+		signup := map[string]any{"email": "extra.new@example.com", "password": "password123", "first_name": "New", "last_name": "User", "extra": map[string]any{}}
+		rr := performRequest(router, http.MethodPost, "/auth/signup", marshalJSONBody(t, signup), "")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "course")
+	})
+
+	t.Run("Resetting", func(t *testing.T) {
+		rr := performRequest(router, http.MethodDelete, "/admin/profile-extra-policy", nil, adminToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), "*password*")
+		assert.Equal(t, http.StatusOK, update(map[string]any{"year": 1}))
+	})
+}
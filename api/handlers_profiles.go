@@ -1,12 +1,16 @@
 package api
 
 import (
+	"docserver/apperr"
 	"docserver/config"
 	"docserver/db"
+	"docserver/i18n"
 	"docserver/models"
 	"docserver/utils"
-	"fmt" // Added
+	"errors"
+	"fmt"
 	"net/http"
+	"regexp"
 	"sort" // Added for sorting profiles
 	"strconv"
 	"strings"
@@ -18,14 +22,80 @@ import (
 // --- Get Current Profile ---
 
 // ProfileResponse defines the data returned for profile endpoints (omits hash).
+// Email and Extra are omitted when the profile's privacy settings hide them from the viewer;
+// Privacy, TosAcceptance and Phone are only included when viewers look at their own profile.
 type ProfileResponse struct {
 	ID             string    `json:"id"`
 	FirstName      string    `json:"first_name"`
 	LastName       string    `json:"last_name"`
-	Email          string    `json:"email"`
+	Email          string    `json:"email,omitempty"`
 	CreationDate   time.Time `json:"creation_date"`
 	LastModifiedDate time.Time `json:"last_modified_date"`
 	Extra          any       `json:"extra,omitempty"`
+	Privacy        *models.ProfilePrivacy `json:"privacy,omitempty"`
+	TosAcceptance  *models.TosAcceptance  `json:"tos_acceptance,omitempty"`
+	Phone          string                 `json:"phone,omitempty"`
+}
+
+// fieldVisibleTo reports whether a profile field with the given visibility may be shown to viewerID.
+// contacts is the owner's set of sharing contacts (see db.GetSharingContacts).
+func fieldVisibleTo(visibility models.FieldVisibility, ownerID, viewerID string, contacts map[string]bool) bool {
+	if ownerID == viewerID {
+		return true
+	}
+	switch visibility {
+	case models.VisibilityPrivate:
+		return false
+	case models.VisibilitySharers:
+		return contacts[ownerID]
+	default: // Empty or public
+		return true
+	}
+}
+
+// profileResponseFor builds the view of profile seen by viewerID, hiding fields the viewer may not see.
+// contacts must be the viewer's sharing contacts; it may be nil when the viewer is the owner.
+func profileResponseFor(profile models.Profile, viewerID string, contacts map[string]bool) ProfileResponse {
+	response := ProfileResponse{
+		ID:               profile.ID,
+		FirstName:        profile.FirstName,
+		LastName:         profile.LastName,
+		CreationDate:     profile.CreationDate,
+		LastModifiedDate: profile.LastModifiedDate,
+	}
+	if fieldVisibleTo(profile.Privacy.Email, profile.ID, viewerID, contacts) {
+		response.Email = profile.Email
+	}
+	if fieldVisibleTo(profile.Privacy.Extra, profile.ID, viewerID, contacts) {
+		response.Extra = profile.Extra
+	}
+	if profile.ID == viewerID {
+		privacy := profile.Privacy
+		response.Privacy = &privacy
+		response.TosAcceptance = profile.TosAcceptance
+		response.Phone = profile.Phone
+	}
+	return response
+}
+
+// validateProfilePrivacy checks that every visibility value is known.
+// It returns the offending field name and value, or empty strings if all are valid.
+func validateProfilePrivacy(privacy models.ProfilePrivacy) (field string, value models.FieldVisibility) {
+	fields := []struct {
+		name  string
+		value models.FieldVisibility
+	}{
+		{"email", privacy.Email},
+		{"extra", privacy.Extra},
+	}
+	for _, f := range fields {
+		switch f.value {
+		case "", models.VisibilityPublic, models.VisibilitySharers, models.VisibilityPrivate:
+		default:
+			return f.name, f.value
+		}
+	}
+	return "", ""
 }
 
 // GetProfileMeHandler retrieves the profile of the currently authenticated user.
@@ -37,21 +107,21 @@ type ProfileResponse struct {
 // @Tags         Profiles
 // @Produce      json
 // @Security     BearerAuth
-// @Success      200  {object}  models.Profile  "Your profile details were successfully retrieved. The response body contains your profile information (excluding sensitive data like the password hash)."
-// @Failure      401  {object}  utils.APIError "Unauthorized: Your access token is missing, invalid, or expired. You might need to log in again."
-// @Failure      404  {object}  utils.APIError "Not Found: The server couldn't find a profile associated with your access token. This is unusual if your token is valid."
-// @Failure      500  {object}  utils.APIError "Internal Server Error: Something went wrong on the server side (e.g., a database connection issue or a problem reading your user ID from the token context)."
+// @Success      200  {object}  utils.Envelope{data=ProfileResponse}  "Your profile details were successfully retrieved. The response body contains your profile information (excluding sensitive data like the password hash)."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired. You might need to log in again."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: The server couldn't find a profile associated with your access token. This is unusual if your token is valid."
+// @Failure      500  {object}  utils.ErrorEnvelope "Internal Server Error: Something went wrong on the server side (e.g., a database connection issue or a problem reading your user ID from the token context)."
 // @Router       /profiles/me [get]
 func GetProfileMeHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
 	// Get userID from context (set by AuthMiddleware)
 	userID, exists := c.Get("userID")
 	if !exists {
-		utils.GinInternalServerError(c, "User ID not found in context. Middleware issue?")
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
 		return
 	}
 	userIDStr, ok := userID.(string)
 	if !ok || userIDStr == "" {
-		utils.GinInternalServerError(c, "Invalid User ID format in context.")
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDInvalid)
 		return
 	}
 
@@ -59,76 +129,100 @@ func GetProfileMeHandler(c *gin.Context, database *db.Database, cfg *config.Conf
 	profile, found := database.GetProfileByID(userIDStr)
 	if !found {
 		// This shouldn't happen if the JWT is valid and the user wasn't deleted mid-session
-		utils.GinError(c, http.StatusNotFound, "Authenticated user profile not found.")
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgProfileNotFound)
 		return
 	}
 
 	// Create response object excluding the hash
-	response := ProfileResponse{
-		ID:             profile.ID,
-		FirstName:      profile.FirstName,
-		LastName:       profile.LastName,
-		Email:          profile.Email,
-		CreationDate:   profile.CreationDate,
-		LastModifiedDate: profile.LastModifiedDate,
-		Extra:          profile.Extra,
-	}
+	response := profileResponseFor(profile, userIDStr, nil)
 
 	// Return the response object
-	c.JSON(http.StatusOK, response)
+	utils.RespondData(c, http.StatusOK, response)
 }
 
 // --- Update Profile ---
 
 // UpdateProfileRequest defines the fields allowed for updating a profile.
-// Note: Email and Password cannot be changed here. Password reset has its own flow.
+// Note: Email and Password cannot be changed here. Both have their own flows (email change, password reset).
 type UpdateProfileRequest struct {
 	FirstName string `json:"first_name" binding:"required"`
 	LastName  string `json:"last_name" binding:"required"`
 	Extra     any    `json:"extra,omitempty"`
+	Privacy   *models.ProfilePrivacy `json:"privacy,omitempty"` // Optional; existing settings are kept when omitted
+	Phone     *string                `json:"phone,omitempty"`   // Optional E.164 number, e.g. +14155550123; kept when omitted, removed when empty
 }
 
+// phonePattern matches E.164 phone numbers.
+var phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
 // UpdateProfileMeHandler updates the profile of the currently authenticated user.
 // @Summary      Update Your Own Profile
 // @Description  Allows the currently logged-in user to update their own profile information.
 // @Description
 // @Description  You can change your `first_name`, `last_name`, and any custom `extra` data associated with your profile.
-// @Description  **Important:** You *cannot* change your email address or password using this endpoint. Password changes typically have a separate, more secure process (like a password reset flow).
+// @Description  You can also set `privacy` to control who sees your `email` and `extra` fields: `public` (any logged-in user, the default), `sharers` (only users you share documents with or who share with you) or `private` (only you). Omit `privacy` to keep your current settings.
+// @Description  Set `phone` to an international (E.164) number such as `+14155550123` to receive password reset codes by SMS when the server sends them that way; send an empty string to remove it, or omit it to keep the current one. Only you can see your phone number.
+// @Description  **Important:** You *cannot* change your email address or password using this endpoint. Email changes go through `POST /profiles/me/email-change`, which confirms the new address first; password changes use the password reset flow.
 // @Description  You need to provide your current access token for authentication. The request body should contain the fields you want to update in JSON format.
 // @Tags         Profiles
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
 // @Param        profile body UpdateProfileRequest true "The profile fields you want to update. 'first_name' and 'last_name' are required."
-// @Success      200  {object}  models.Profile  "Your profile was successfully updated. The response body contains the complete, updated profile."
-// @Failure      400  {object}  utils.APIError "Bad Request: The data you sent in the request body is invalid. This could be due to missing required fields ('first_name', 'last_name') or incorrect JSON formatting."
-// @Failure      401  {object}  utils.APIError "Unauthorized: Your access token is missing, invalid, or expired. You need to be logged in to update your profile."
-// @Failure      404  {object}  utils.APIError "Not Found: The server couldn't find your profile based on your access token."
-// @Failure      500  {object}  utils.APIError "Internal Server Error: Something went wrong on the server while trying to update your profile (e.g., a database error)."
+// @Success      200  {object}  utils.Envelope{data=ProfileResponse}  "Your profile was successfully updated. The response body contains the complete, updated profile."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The data you sent in the request body is invalid. This could be due to missing required fields ('first_name', 'last_name'), an invalid 'phone' number, 'extra' breaking the policy set by administrators (see GET /admin/profile-extra-policy), or incorrect JSON formatting."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired. You need to be logged in to update your profile."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: The server couldn't find your profile based on your access token."
+// @Failure      500  {object}  utils.ErrorEnvelope "Internal Server Error: Something went wrong on the server while trying to update your profile (e.g., a database error)."
 // @Router       /profiles/me [put]
 func UpdateProfileMeHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
 	// Get userID from context
 	userID, exists := c.Get("userID")
 	if !exists {
-		utils.GinInternalServerError(c, "User ID not found in context.")
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
 		return
 	}
 	userIDStr := userID.(string) // Assume valid from middleware
 
 	// Bind JSON request body
 	var req UpdateProfileRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.GinBadRequest(c, fmt.Sprintf("Invalid request body: %v", err))
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidRequestBody) {
 		return
 	}
 
+	// Validate privacy settings, if provided
+	if req.Privacy != nil {
+		if field, value := validateProfilePrivacy(*req.Privacy); field != "" {
+			utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgInvalidVisibility, value, field)
+			return
+		}
+	}
+
+	// Validate the phone number, if provided
+	if req.Phone != nil {
+		*req.Phone = strings.TrimSpace(*req.Phone)
+		if *req.Phone != "" && !phonePattern.MatchString(*req.Phone) {
+			utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgInvalidPhone)
+			return
+		}
+	}
+
 	// Get the existing profile to preserve fields not being updated
 	existingProfile, found := database.GetProfileByID(userIDStr)
 	if !found {
-		utils.GinError(c, http.StatusNotFound, "Authenticated user profile not found.")
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgProfileNotFound)
 		return
 	}
 
+	privacy := existingProfile.Privacy
+	if req.Privacy != nil {
+		privacy = *req.Privacy
+	}
+	phone := existingProfile.Phone
+	if req.Phone != nil {
+		phone = *req.Phone
+	}
+
 	// Create the updated profile model, preserving non-updatable fields
 	updatedProfileData := models.Profile{
 		ID:           existingProfile.ID,
@@ -139,28 +233,27 @@ func UpdateProfileMeHandler(c *gin.Context, database *db.Database, cfg *config.C
 		CreationDate: existingProfile.CreationDate, // Preserve original creation date
 		// LastModifiedDate will be set by db.UpdateProfile
 		Extra: req.Extra, // Update from request
+		Privacy: privacy,
+		TosAcceptance: existingProfile.TosAcceptance,
+		Phone: phone,
 	}
 
 	// Perform the update in the database
 	updatedProfile, err := database.UpdateProfile(userIDStr, updatedProfileData)
 	if err != nil {
+		if errors.Is(err, apperr.ErrValidation) { // 'extra' breaks the extra policy
+			utils.GinErrorFromErr(c, http.StatusBadRequest, err)
+			return
+		}
 		// UpdateProfile handles "not found" internally, but check just in case
-		utils.GinInternalServerError(c, fmt.Sprintf("Failed to update profile: %v", err))
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgProfileUpdateFailed, err)
 		return
 	}
 
 	// Create response object excluding the hash
-	response := ProfileResponse{
-		ID:             updatedProfile.ID,
-		FirstName:      updatedProfile.FirstName,
-		LastName:       updatedProfile.LastName,
-		Email:          updatedProfile.Email,
-		CreationDate:   updatedProfile.CreationDate,
-		LastModifiedDate: updatedProfile.LastModifiedDate,
-		Extra:          updatedProfile.Extra,
-	}
+	response := profileResponseFor(updatedProfile, userIDStr, nil)
 	// Return the updated profile response
-	c.JSON(http.StatusOK, response)
+	utils.RespondData(c, http.StatusOK, response)
 }
 
 // --- Delete Profile ---
@@ -175,15 +268,15 @@ func UpdateProfileMeHandler(c *gin.Context, database *db.Database, cfg *config.C
 // @Tags         Profiles
 // @Security     BearerAuth
 // @Success      204  "Account Successfully Deleted. No content is returned in the response body because the resource (your profile) no longer exists."
-// @Failure      401  {object}  utils.APIError "Unauthorized: Your access token is missing, invalid, or expired. You need to be logged in to delete your account."
-// @Failure      404  {object}  utils.APIError "Not Found: The server couldn't find your profile based on your access token."
-// @Failure      500  {object}  utils.APIError "Internal Server Error: Something went wrong on the server while trying to delete your account (e.g., a database error)."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired. You need to be logged in to delete your account."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: The server couldn't find your profile based on your access token."
+// @Failure      500  {object}  utils.ErrorEnvelope "Internal Server Error: Something went wrong on the server while trying to delete your account (e.g., a database error)."
 // @Router       /profiles/me [delete]
 func DeleteProfileMeHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
 	// Get userID from context
 	userID, exists := c.Get("userID")
 	if !exists {
-		utils.GinInternalServerError(c, "User ID not found in context.")
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
 		return
 	}
 	userIDStr := userID.(string) // Assume valid from middleware
@@ -197,10 +290,10 @@ func DeleteProfileMeHandler(c *gin.Context, database *db.Database, cfg *config.C
 	// For now, just delete the profile record itself.
 	err := database.DeleteProfile(userIDStr)
 	if err != nil {
-		if strings.Contains(strings.ToLower(err.Error()), "not found") {
-			utils.GinNotFound(c, "Authenticated user profile not found.")
+		if errors.Is(err, apperr.ErrNotFound) {
+			utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgProfileNotFound)
 		} else {
-			utils.GinInternalServerError(c, fmt.Sprintf("Failed to delete profile: %v", err))
+			utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgProfileDeleteFailed, err)
 		}
 		return
 	}
@@ -211,7 +304,8 @@ func DeleteProfileMeHandler(c *gin.Context, database *db.Database, cfg *config.C
 
 // --- Search Profiles ---
 
-// SearchProfilesResponse defines the structure for the paginated profile search results.
+// SearchProfilesResponse is the paginated profile search shape served on legacy unversioned paths.
+// Versioned paths wrap the list in utils.Envelope with meta and links instead.
 type SearchProfilesResponse struct {
 	Data  []ProfileResponse `json:"data"`
 	Total int              `json:"total"`
@@ -219,6 +313,106 @@ type SearchProfilesResponse struct {
 	Limit int              `json:"limit"`
 }
 
+// maxProfileIDs caps how many profiles can be looked up by ID in one request.
+const maxProfileIDs = 100
+
+// profileSearch holds the parsed filters and ordering of a profile search.
+type profileSearch struct {
+	Email, FirstName, LastName string
+	IDs                        []string  // Only these profiles; nil for all
+	CreatedAfter               time.Time // Zero for no bound
+	CreatedBefore              time.Time // Zero for no bound
+	SortBy                     string    // "email", "name" or "creation_date"
+	Descending                 bool
+}
+
+// parseProfileSearch reads the filter and sort query parameters of a profile search.
+func parseProfileSearch(c *gin.Context) (profileSearch, error) {
+	search := profileSearch{
+		Email:     c.Query("email"),
+		FirstName: c.Query("first_name"),
+		LastName:  c.Query("last_name"),
+		SortBy:    strings.ToLower(c.DefaultQuery("sort_by", "email")),
+	}
+	switch search.SortBy {
+	case "email", "name", "creation_date":
+	default:
+		return profileSearch{}, fmt.Errorf("sort_by must be 'email', 'name' or 'creation_date', not '%s'", search.SortBy)
+	}
+	switch order := strings.ToLower(c.DefaultQuery("order", "asc")); order {
+	case "asc":
+	case "desc":
+		search.Descending = true
+	default:
+		return profileSearch{}, fmt.Errorf("order must be 'asc' or 'desc', not '%s'", order)
+	}
+
+	for name, bound := range map[string]*time.Time{"created_after": &search.CreatedAfter, "created_before": &search.CreatedBefore} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return profileSearch{}, fmt.Errorf("%s must be an RFC 3339 timestamp, e.g. 2024-01-31T00:00:00Z", name)
+		}
+		*bound = parsed
+	}
+
+	if idsQuery, present := c.GetQuery("ids"); present {
+		search.IDs = []string{}
+		seen := map[string]bool{}
+		for _, id := range strings.Split(idsQuery, ",") {
+			if id = strings.TrimSpace(id); id != "" && !seen[id] {
+				seen[id] = true
+				search.IDs = append(search.IDs, id)
+			}
+		}
+		if len(search.IDs) > maxProfileIDs {
+			return profileSearch{}, fmt.Errorf("at most %d ids can be looked up at once", maxProfileIDs)
+		}
+	}
+	return search, nil
+}
+
+// matches reports whether a profile, as seen by the viewer, passes the search filters.
+// Email is matched on the viewer's view so hidden values can't be probed.
+func (s profileSearch) matches(profile models.Profile, view ProfileResponse) bool {
+	containsFold := func(value, query string) bool {
+		return query == "" || strings.Contains(strings.ToLower(value), strings.ToLower(query))
+	}
+	if !containsFold(view.Email, s.Email) || !containsFold(profile.FirstName, s.FirstName) || !containsFold(profile.LastName, s.LastName) {
+		return false
+	}
+	if !s.CreatedAfter.IsZero() && !profile.CreationDate.After(s.CreatedAfter) {
+		return false
+	}
+	if !s.CreatedBefore.IsZero() && !profile.CreationDate.Before(s.CreatedBefore) {
+		return false
+	}
+	return true
+}
+
+// less orders two profile views by the search's sort field, then by ID so pagination is stable.
+func (s profileSearch) less(a, b ProfileResponse) bool {
+	var cmp int
+	switch s.SortBy {
+	case "name":
+		cmp = strings.Compare(strings.ToLower(a.LastName+"\x00"+a.FirstName), strings.ToLower(b.LastName+"\x00"+b.FirstName))
+	case "creation_date":
+		cmp = a.CreationDate.Compare(b.CreationDate)
+	default:
+		cmp = strings.Compare(strings.ToLower(a.Email), strings.ToLower(b.Email))
+	}
+	if cmp == 0 {
+		cmp = strings.Compare(a.ID, b.ID)
+	}
+	if s.Descending {
+		return cmp > 0
+	}
+	return cmp < 0
+}
+
 // SearchProfilesHandler searches for profiles based on query parameters.
 // @Summary      Search User Profiles
 // @Description  Allows authenticated users to search for other user profiles within the system.
@@ -227,31 +421,46 @@ type SearchProfilesResponse struct {
 // @Description  *   `email`: Find profiles where the email address contains the provided text (case-insensitive). Example: `?email=test.com`
 // @Description  *   `first_name`: Find profiles where the first name contains the provided text (case-insensitive). Example: `?first_name=jo`
 // @Description  *   `last_name`: Find profiles where the last name contains the provided text (case-insensitive). Example: `?last_name=smi`
+// @Description  *   `created_after` / `created_before`: Only profiles created after / before an RFC 3339 timestamp. Example: `?created_after=2024-09-01T00:00:00Z`
+// @Description  *   `ids`: Only the profiles with these IDs, comma-separated (at most 100), e.g. to show the names in a document's `shared_with` list with one request. Unknown IDs are skipped.
 // @Description  You can combine multiple filters. The search returns profiles that match *all* provided filters.
+// @Description  Fields hidden by a profile's privacy settings are left out of the results and are not matched by the `email` filter.
+// @Description  In other users' `extra`, the values of keys the administrators consider sensitive (by default, keys containing `token`, `password` or `secret`) are replaced with `"[redacted]"`.
+// @Description
+// @Description  Results are sorted by `sort_by`: `email` (default), `name` (last name, then first name) or `creation_date`, in `order` `asc` (default) or `desc`.
 // @Description
 // @Description  Results are paginated to handle potentially large numbers of users:
 // @Description  *   `page`: Specifies which page of results to retrieve (starts at 1). Default is 1. Example: `?page=2`
 // @Description  *   `limit`: Specifies how many profiles to return per page. Default is 20, maximum is 100. Example: `?limit=50`
 // @Description
-// @Description  Example combining filters and pagination: `/profiles?first_name=a&page=1&limit=10` (Find profiles with 'a' in the first name, show the first 10 results).
+// @Description  Example combining filters and pagination: `/profiles?first_name=a&sort_by=name&page=1&limit=10` (Find profiles with 'a' in the first name, show the first 10 results by name).
 // @Tags         Profiles
 // @Produce      json
 // @Security     BearerAuth
-// @Param        email       query     string  false  "Filter profiles where email contains this text (case-insensitive)." example(user@example.com)
-// @Param        first_name  query     string  false  "Filter profiles where first name contains this text (case-insensitive)." example(John)
-// @Param        last_name   query     string  false  "Filter profiles where last name contains this text (case-insensitive)." example(Doe)
-// @Param        page        query     int     false  "Page number for results (starts at 1)." minimum(1) default(1) example(1)
-// @Param        limit       query     int     false  "Number of profiles per page." minimum(1) maximum(100) default(20) example(20)
-// @Success      200  {object}  SearchProfilesResponse "A list of profiles matching the search criteria, along with pagination details (total count, current page, limit)."
-// @Failure      400  {object}  utils.APIError "Bad Request: Invalid query parameters. 'page' and 'limit' must be positive integers. 'limit' cannot exceed 100."
-// @Failure      401  {object}  utils.APIError "Unauthorized: Your access token is missing, invalid, or expired. You need to be logged in to search profiles."
-// @Failure      500  {object}  utils.APIError "Internal Server Error: Something went wrong on the server while searching for profiles."
+// @Param        email          query     string  false  "Filter profiles where email contains this text (case-insensitive)." example(user@example.com)
+// @Param        first_name     query     string  false  "Filter profiles where first name contains this text (case-insensitive)." example(John)
+// @Param        last_name      query     string  false  "Filter profiles where last name contains this text (case-insensitive)." example(Doe)
+// @Param        created_after  query     string  false  "Only profiles created after this time (RFC 3339)." example(2024-09-01T00:00:00Z)
+// @Param        created_before query     string  false  "Only profiles created before this time (RFC 3339)." example(2025-01-01T00:00:00Z)
+// @Param        ids            query     string  false  "Only profiles with these IDs, comma-separated (at most 100)." example(a1b2c3,d4e5f6)
+// @Param        sort_by        query     string  false  "Field to sort results by." Enums(email, name, creation_date) default(email)
+// @Param        order          query     string  false  "Sorting direction." Enums(asc, desc) default(asc)
+// @Param        page           query     int     false  "Page number for results (starts at 1)." minimum(1) default(1) example(1)
+// @Param        limit          query     int     false  "Number of profiles per page." minimum(1) maximum(100) default(20) example(20)
+// @Success      200  {object}  utils.Envelope{data=[]ProfileResponse,meta=utils.PageMeta,links=utils.PageLinks} "A list of profiles matching the search criteria, along with pagination details (total count, current page, limit)."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: Invalid query parameters. 'page' and 'limit' must be positive integers and 'limit' cannot exceed 100; 'sort_by', 'order', the dates or 'ids' are invalid."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired. You need to be logged in to search profiles."
+// @Failure      500  {object}  utils.ErrorEnvelope "Internal Server Error: Something went wrong on the server while searching for profiles."
 // @Router       /profiles [get]
 func SearchProfilesHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+	viewerID := userID.(string)
+
 	// Get query parameters
-	emailQuery := c.Query("email")
-	firstNameQuery := c.Query("first_name")
-	lastNameQuery := c.Query("last_name")
 	pageQuery := c.DefaultQuery("page", "1")
 	limitQuery := c.DefaultQuery("limit", "20") // Use same default as document query
 
@@ -259,73 +468,57 @@ func SearchProfilesHandler(c *gin.Context, database *db.Database, cfg *config.Co
 	limit, errLimit := strconv.Atoi(limitQuery)
 
 	if errPage != nil || errLimit != nil || page < 1 {
-		utils.GinBadRequest(c, "Invalid 'page' or 'limit' query parameter. Must be positive integers.")
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgInvalidPagination)
 		return
 	}
 	// Enforce max limit
 	if limit > 100 {
 		limit = 100
 	}
+	search, err := parseProfileSearch(c)
+	if err != nil {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgProfileSearchInvalid, err)
+		return
+	}
 
-	// Get all profiles (inefficient for large datasets, but simple for now)
-	allProfiles := database.GetAllProfiles()
+	// Look up the requested IDs, or scan all profiles (inefficient for large datasets, but simple for now)
+	var candidates []models.Profile
+	if search.IDs != nil {
+		for _, id := range search.IDs {
+			if profile, found := database.GetProfileByID(id); found {
+				candidates = append(candidates, profile)
+			}
+		}
+	} else {
+		candidates = database.GetAllProfiles()
+	}
+	// Profiles connected to the viewer through sharing may see "sharers" fields
+	contacts := database.GetSharingContacts(viewerID)
 
-	// Filter based on query params (case-insensitive contains)
 	filteredProfiles := make([]ProfileResponse, 0)
-	for _, profile := range allProfiles {
-		match := true
-		if emailQuery != "" && !strings.Contains(strings.ToLower(profile.Email), strings.ToLower(emailQuery)) {
-			match = false
-		}
-		if firstNameQuery != "" && !strings.Contains(strings.ToLower(profile.FirstName), strings.ToLower(firstNameQuery)) {
-			match = false
+	for _, profile := range candidates {
+		// Only match on fields the viewer is allowed to see, so hidden values can't be probed
+		responseProfile := profileResponseFor(profile, viewerID, contacts)
+		if profile.ID != viewerID {
+			responseProfile.Extra = database.RedactExtra(responseProfile.Extra) // Sensitive keys are not shown to others
 		}
-		if lastNameQuery != "" && !strings.Contains(strings.ToLower(profile.LastName), strings.ToLower(lastNameQuery)) {
-			match = false
-		}
-
-		if match {
-			// Create response object excluding the hash
-			responseProfile := ProfileResponse{
-				ID:             profile.ID,
-				FirstName:      profile.FirstName,
-				LastName:       profile.LastName,
-				Email:          profile.Email,
-				CreationDate:   profile.CreationDate,
-				LastModifiedDate: profile.LastModifiedDate,
-				Extra:          profile.Extra,
-			}
+		if search.matches(profile, responseProfile) {
 			filteredProfiles = append(filteredProfiles, responseProfile)
 		}
 	}
 
 	totalMatching := len(filteredProfiles)
-
-	// Sort results for stable pagination (e.g., by Email then ID)
 	sort.SliceStable(filteredProfiles, func(i, j int) bool {
-		p1 := filteredProfiles[i]
-		p2 := filteredProfiles[j]
-		email1Lower := strings.ToLower(p1.Email)
-		email2Lower := strings.ToLower(p2.Email)
-		if email1Lower != email2Lower {
-			return email1Lower < email2Lower // Primary sort: Email (case-insensitive)
-		}
-		return p1.ID < p2.ID // Secondary sort: ID (guaranteed unique)
+		return search.less(filteredProfiles[i], filteredProfiles[j])
 	})
 
-
 	// Paginate the results (using a similar helper as for documents, maybe move to utils?)
 	startIndex := (page - 1) * limit
 	endIndex := startIndex + limit
 
 	if startIndex >= totalMatching {
 		// Page out of bounds, return empty list
-		c.JSON(http.StatusOK, gin.H{
-			"data":  []models.Profile{},
-			"total": totalMatching,
-			"page":  page,
-			"limit": limit,
-		})
+		utils.RespondList(c, []ProfileResponse{}, totalMatching, page, limit)
 		return
 	}
 
@@ -335,11 +528,66 @@ func SearchProfilesHandler(c *gin.Context, database *db.Database, cfg *config.Co
 
 	paginatedProfiles := filteredProfiles[startIndex:endIndex]
 
-	// Return paginated list and total count using the defined struct
-	c.JSON(http.StatusOK, SearchProfilesResponse{
-		Data:  paginatedProfiles,
-		Total: totalMatching,
-		Page:  page,
-		Limit: limit,
-	})
+	// Return paginated list with pagination metadata
+	utils.RespondList(c, paginatedProfiles, totalMatching, page, limit)
+}
+
+// --- Resolve Profiles ---
+
+// ResolveProfilesRequest lists the profile IDs to resolve.
+type ResolveProfilesRequest struct {
+	IDs []string `json:"ids" binding:"required"`
+}
+
+// ProfileSummary is the public part of a profile: what is needed to show who someone is.
+type ProfileSummary struct {
+	ID        string `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+// ResolveProfilesResponse holds the resolved profiles and the IDs that matched no profile.
+type ResolveProfilesResponse struct {
+	Profiles []ProfileSummary `json:"profiles"`
+	Missing  []string         `json:"missing"`
+}
+
+// ResolveProfilesHandler returns public summaries of many profiles at once.
+// @Summary      Resolve Profile IDs
+// @Description  Looks up to 100 profile IDs in one request and returns each profile's `id`, `first_name` and `last_name`, e.g. to show the names behind a document's `shared_with` list.
+// @Description  Profiles are returned in the order their IDs were sent, without duplicates. IDs that match no profile are listed in `missing` instead.
+// @Tags         Profiles
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body ResolveProfilesRequest true "The profile IDs to resolve (1 to 100)."
+// @Success      200  {object}  utils.Envelope{data=ResolveProfilesResponse} "The profiles found and the IDs that were not."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The body is not valid JSON, 'ids' is missing, or it holds no IDs or more than 100."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Router       /profiles/resolve [post]
+func ResolveProfilesHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	var req ResolveProfilesRequest
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidRequestBody) {
+		return
+	}
+	if len(req.IDs) == 0 || len(req.IDs) > maxProfileIDs {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgTooManyProfileIDs, maxProfileIDs)
+		return
+	}
+
+	response := ResolveProfilesResponse{Profiles: []ProfileSummary{}, Missing: []string{}}
+	seen := make(map[string]bool, len(req.IDs))
+	for _, id := range req.IDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		profile, found := database.GetProfileByID(id)
+		if !found {
+			response.Missing = append(response.Missing, id)
+			continue
+		}
+		response.Profiles = append(response.Profiles, ProfileSummary{ID: profile.ID, FirstName: profile.FirstName, LastName: profile.LastName})
+	}
+	utils.RespondData(c, http.StatusOK, response)
 }
@@ -0,0 +1,272 @@
+package api
+
+import (
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/models"
+	"docserver/utils"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Roster Provisioning (Admin) ---
+
+// maxProvisionAccounts caps the rows of one roster, as each new account hashes a password.
+const maxProvisionAccounts = 1000
+
+// How provisioned users learn their temporary password
+const (
+	ProvisionDeliveryResponse = "response" // Listed in the provisioning report, for the administrator to hand out
+	ProvisionDeliveryEmail    = "email"    // Sent to each user in an invitation email
+)
+
+// Outcomes of a roster row
+const (
+	ProvisionStatusCreated   = "created"   // A new account was created
+	ProvisionStatusExisting  = "existing"  // The email already had an account, which was kept (and moved into the group)
+	ProvisionStatusDuplicate = "duplicate" // An earlier row had the same email
+	ProvisionStatusInvalid   = "invalid"   // The row was rejected; see its error
+)
+
+// ProvisionAccount is one row of a roster.
+type ProvisionAccount struct {
+	Email     string `json:"email" example:"ada@example.com"`
+	FirstName string `json:"first_name" example:"Ada"`
+	LastName  string `json:"last_name" example:"Lovelace"`
+	Group     string `json:"group,omitempty"` // Overrides the roster's group for this account
+}
+
+// ProvisionRequest is a JSON roster.
+type ProvisionRequest struct {
+	Group    string             `json:"group,omitempty" example:"CS101-fall"` // Class or workspace to put the accounts in
+	Delivery string             `json:"delivery,omitempty" example:"email"`   // 'response' (default) or 'email'
+	Accounts []ProvisionAccount `json:"accounts" binding:"required"`
+}
+
+// ProvisionResult is the outcome of one roster row.
+type ProvisionResult struct {
+	Row               int    `json:"row"` // 1-based, not counting a CSV header
+	Email             string `json:"email"`
+	Status            string `json:"status"` // One of the ProvisionStatus* values
+	ProfileID         string `json:"profile_id,omitempty"`
+	Group             string `json:"group,omitempty"`
+	TemporaryPassword string `json:"temporary_password,omitempty"` // Only for created accounts with 'response' delivery
+	Error             string `json:"error,omitempty"`              // Why the row was rejected
+}
+
+// ProvisionReport summarizes a roster import.
+type ProvisionReport struct {
+	Created    int               `json:"created"`
+	Existing   int               `json:"existing"`
+	Duplicates int               `json:"duplicates"`
+	Invalid    int               `json:"invalid"`
+	Delivery   string            `json:"delivery"`
+	Accounts   []ProvisionResult `json:"accounts"`
+}
+
+// ProvisionAccountsHandler creates accounts in bulk from a roster.
+// @Summary      Provision Accounts from a Roster (Admin)
+// @Description  Creates an account for every person on a class roster, so students can log in without signing up. Send the roster as JSON (`{"group": "...", "delivery": "...", "accounts": [{"email", "first_name", "last_name"}]}`) or as CSV (`Content-Type: text/csv`) whose first line names the columns `email`, `first_name`, `last_name` and, optionally, `group`; with CSV, pass `group` and `delivery` as query parameters. At most 1000 accounts per request. Administrators only.
+// @Description
+// @Description  Each new account gets a random temporary password, which the user should change after logging in. With `delivery` `response` (the default) the passwords are listed in the report for you to hand out; with `email` each user is sent theirs in an invitation email and the report leaves them out.
+// @Description  Accounts are put in `group` (a row's own `group` wins). Importing a roster again is safe: emails that already have an account are reported as `existing` and keep their password, only moving into the group; repeated emails within the roster are reported as `duplicate`, and invalid rows as `invalid` with the reason, without stopping the import.
+// @Tags         Admin
+// @Accept       json
+// @Accept       text/csv
+// @Produce      json
+// @Security     BearerAuth
+// @Param        roster    body      ProvisionRequest  true   "The roster, as JSON or CSV."
+// @Param        group     query     string            false  "With a CSV roster: the group to put the accounts in."
+// @Param        delivery  query     string            false  "With a CSV roster: 'response' or 'email'."
+// @Success      200  {object}  utils.Envelope{data=ProvisionReport} "The outcome of every row."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The roster cannot be read, is empty or too long, or the delivery is unknown."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Failure      413  {object}  utils.ErrorEnvelope "Request Entity Too Large: The roster is larger than the request body limit."
+// @Router       /admin/provision [post]
+func ProvisionAccountsHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	var req ProvisionRequest
+	if strings.HasPrefix(c.ContentType(), "text/csv") {
+		accounts, ok := readCSVRoster(c, cfg)
+		if !ok {
+			return // Error response already sent
+		}
+		req = ProvisionRequest{Group: c.Query("group"), Delivery: c.Query("delivery"), Accounts: accounts}
+	} else if !utils.BindJSON(c, cfg, &req, i18n.MsgProvisionInvalidBody) {
+		return
+	}
+
+	delivery := strings.ToLower(strings.TrimSpace(req.Delivery))
+	if delivery == "" {
+		delivery = ProvisionDeliveryResponse
+	}
+	if delivery != ProvisionDeliveryResponse && delivery != ProvisionDeliveryEmail {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgProvisionInvalidDelivery, req.Delivery)
+		return
+	}
+	if len(req.Accounts) == 0 {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgProvisionEmpty)
+		return
+	}
+	if len(req.Accounts) > maxProvisionAccounts {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgProvisionTooMany, maxProvisionAccounts)
+		return
+	}
+
+	lang := utils.RequestLanguage(c)
+	report := ProvisionReport{Delivery: delivery, Accounts: make([]ProvisionResult, 0, len(req.Accounts))}
+	firstRow := make(map[string]int) // Lowercased email -> first row using it
+	for i, account := range req.Accounts {
+		result := provisionAccount(database, cfg, i+1, account, strings.TrimSpace(req.Group), delivery, firstRow, lang)
+		switch result.Status {
+		case ProvisionStatusCreated:
+			report.Created++
+		case ProvisionStatusExisting:
+			report.Existing++
+		case ProvisionStatusDuplicate:
+			report.Duplicates++
+		default:
+			report.Invalid++
+		}
+		report.Accounts = append(report.Accounts, result)
+	}
+	utils.RespondData(c, http.StatusOK, report)
+}
+
+// provisionAccount validates and provisions one roster row. firstRow remembers the emails of
+// earlier rows; row errors are rendered in lang.
+func provisionAccount(database *db.Database, cfg *config.Config, row int, account ProvisionAccount, group, delivery string, firstRow map[string]int, lang string) ProvisionResult {
+	email := strings.TrimSpace(account.Email)
+	result := ProvisionResult{Row: row, Email: email, Status: ProvisionStatusInvalid}
+	result.Group = group
+	if own := strings.TrimSpace(account.Group); own != "" {
+		result.Group = own
+	}
+	if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+		result.Error = i18n.Translate(lang, i18n.MsgProvisionInvalidEmail, email)
+		return result
+	}
+	firstName, lastName := strings.TrimSpace(account.FirstName), strings.TrimSpace(account.LastName)
+	if firstName == "" || lastName == "" {
+		result.Error = i18n.Translate(lang, i18n.MsgProvisionMissingName)
+		return result
+	}
+	key := strings.ToLower(email)
+	if earlier, seen := firstRow[key]; seen {
+		result.Status = ProvisionStatusDuplicate
+		result.Error = i18n.Translate(lang, i18n.MsgProvisionDuplicate, earlier)
+		return result
+	}
+	firstRow[key] = row
+
+	// Only hash a password for emails without an account; ProvisionProfile settles any race
+	var password string
+	now := cfg.Now().UTC()
+	profile := models.Profile{FirstName: firstName, LastName: lastName, Email: email, Group: result.Group, CreationDate: now, LastModifiedDate: now}
+	if _, exists := database.GetProfileByEmail(email); !exists {
+		var err error
+		if password, err = utils.GenerateTemporaryPassword(); err == nil {
+			profile.PasswordHash, err = utils.HashPasswordWithConfig(password, cfg)
+		}
+		if err != nil {
+			result.Error = i18n.Translate(lang, i18n.MsgProvisionFailed, err)
+			return result
+		}
+	}
+
+	stored, created, err := database.ProvisionProfile(profile)
+	if err != nil {
+		result.Error = i18n.Translate(lang, i18n.MsgProvisionFailed, err)
+		return result
+	}
+	result.ProfileID = stored.ID
+	result.Group = stored.Group
+	if !created {
+		result.Status = ProvisionStatusExisting
+		return result
+	}
+	result.Status = ProvisionStatusCreated
+	if delivery == ProvisionDeliveryEmail {
+		sendProvisionEmail(stored, password)
+	} else {
+		result.TemporaryPassword = password
+	}
+	return result
+}
+
+// sendProvisionEmail invites a provisioned user, giving them their temporary password.
+func sendProvisionEmail(profile models.Profile, password string) {
+	body := fmt.Sprintf("Hello %s,\n\nAn account has been created for you.\n\nEmail: %s\nTemporary password: %s\n", profile.FirstName, profile.Email, password)
+	if profile.Group != "" {
+		body += fmt.Sprintf("Group: %s\n", profile.Group)
+	}
+	body += "\nPlease log in and change your password.\n"
+	utils.SendEmail(profile.Email, "Your account is ready", body)
+}
+
+// readCSVRoster reads a CSV roster from the request body. The header line names the columns;
+// 'email', 'first_name' and 'last_name' are required and 'group' is optional, in any order.
+// If the roster cannot be read it sends the error response and returns false.
+func readCSVRoster(c *gin.Context, cfg *config.Config) ([]ProvisionAccount, bool) {
+	body := c.Request.Body
+	if cfg.MaxBodyBytes > 0 {
+		body = http.MaxBytesReader(c.Writer, body, cfg.MaxBodyBytes)
+	}
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1 // Short rows leave the missing columns empty
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			utils.GinLocalizedError(c, http.StatusRequestEntityTooLarge, i18n.MsgRequestBodyTooLarge, maxBytesErr.Limit)
+		} else {
+			utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgProvisionInvalidCSV, err)
+		}
+		return nil, false
+	}
+	if len(records) == 0 {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgProvisionInvalidCSV, io.EOF)
+		return nil, false
+	}
+
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i // Spreadsheets may start the file with a byte order mark
+	}
+	for _, required := range []string{"email", "first_name", "last_name"} {
+		if _, found := columns[required]; !found {
+			utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgProvisionInvalidCSV, fmt.Errorf("missing column %q", required))
+			return nil, false
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, found := columns[name]; found && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	accounts := make([]ProvisionAccount, 0, len(records)-1)
+	for _, record := range records[1:] {
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue // Blank line
+		}
+		accounts = append(accounts, ProvisionAccount{
+			Email:     field(record, "email"),
+			FirstName: field(record, "first_name"),
+			LastName:  field(record, "last_name"),
+			Group:     field(record, "group"),
+		})
+	}
+	return accounts, true
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvisionAccounts(t *testing.T) {
+	router, database, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	cfg.AdminEmails = []string{"provision.admin@example.com"}
+
+	_, _, adminToken := createTestUserAndLogin(t, router, "provision.admin@example.com", "password123", "Prov", "Admin")
+	_, _, userToken := createTestUserAndLogin(t, router, "provision.user@example.com", "password123", "Prov", "User")
+
+	provision := func(token string, body gin.H) (int, ProvisionReport) {
+		rr := performRequest(router, http.MethodPost, "/admin/provision", marshalJSONBody(t, body), token)
+		var report ProvisionReport
+		if rr.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		}
+		return rr.Code, report
+	}
+	login := func(email, password string) int {
+		return performRequest(router, http.MethodPost, "/auth/login", marshalJSONBody(t, gin.H{"email": email, "password": password}), "").Code
+	}
+
+	t.Run("Administrators only", func(t *testing.T) {
+		code, _ := provision(userToken, gin.H{"accounts": []gin.H{{"email": "x@example.com", "first_name": "X", "last_name": "Y"}}})
+		assert.Equal(t, http.StatusForbidden, code)
+	})
+
+	t.Run("Invalid rosters", func(t *testing.T) {
+		code, _ := provision(adminToken, gin.H{"accounts": []gin.H{}})
+		assert.Equal(t, http.StatusBadRequest, code)
+		code, _ = provision(adminToken, gin.H{"delivery": "pigeon", "accounts": []gin.H{{"email": "x@example.com"}}})
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	roster := gin.H{"group": "cs101", "accounts": []gin.H{
+		{"email": "ada@example.com", "first_name": "Ada", "last_name": "Lovelace"},
+		{"email": "grace@example.com", "first_name": "Grace", "last_name": "Hopper", "group": "cs102"},
+		{"email": "ADA@example.com", "first_name": "Ada", "last_name": "Again"},
+		{"email": "not-an-email", "first_name": "No", "last_name": "Email"},
+		{"email": "provision.user@example.com", "first_name": "Prov", "last_name": "User"},
+	}}
+
+	t.Run("Creates accounts with temporary passwords", func(t *testing.T) {
+		code, report := provision(adminToken, roster)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 2, report.Created)
+		assert.Equal(t, 1, report.Existing)
+		assert.Equal(t, 1, report.Duplicates)
+		assert.Equal(t, 1, report.Invalid)
+		require.Len(t, report.Accounts, 5)
+
+		ada := report.Accounts[0]
+		assert.Equal(t, ProvisionStatusCreated, ada.Status)
+		assert.Equal(t, "cs101", ada.Group)
+		require.NotEmpty(t, ada.TemporaryPassword)
+		assert.Equal(t, http.StatusOK, login("ada@example.com", ada.TemporaryPassword))
+		assert.Equal(t, "cs102", report.Accounts[1].Group, "a row's group wins")
+		assert.Equal(t, ProvisionStatusDuplicate, report.Accounts[2].Status)
+		assert.NotEmpty(t, report.Accounts[3].Error)
+
+		existing := report.Accounts[4]
+		assert.Equal(t, ProvisionStatusExisting, existing.Status)
+		assert.Empty(t, existing.TemporaryPassword)
+		assert.Equal(t, http.StatusOK, login("provision.user@example.com", "password123"), "existing accounts keep their password")
+		profile, _ := database.GetProfileByEmail("provision.user@example.com")
+		assert.Equal(t, "cs101", profile.Group)
+	})
+
+	t.Run("Importing again changes nothing", func(t *testing.T) {
+		code, report := provision(adminToken, roster)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 0, report.Created)
+		assert.Equal(t, 3, report.Existing)
+	})
+
+	t.Run("CSV roster with email delivery", func(t *testing.T) {
+		csvRoster := "Email,Last_Name,First_Name\nalan@example.com,Turing,Alan\n\nkatherine@example.com,Johnson\n"
+		req, err := http.NewRequest(http.MethodPost, "/admin/provision?group=math&delivery=email", strings.NewReader(csvRoster))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "text/csv")
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		var report ProvisionReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		assert.Equal(t, ProvisionDeliveryEmail, report.Delivery)
+		require.Len(t, report.Accounts, 2)
+		assert.Equal(t, ProvisionStatusCreated, report.Accounts[0].Status)
+		assert.Equal(t, "math", report.Accounts[0].Group)
+		assert.Empty(t, report.Accounts[0].TemporaryPassword, "emailed, not listed")
+		assert.Equal(t, ProvisionStatusInvalid, report.Accounts[1].Status, "first name missing")
+
+		req, _ = http.NewRequest(http.MethodPost, "/admin/provision", strings.NewReader("mail,name\na@example.com,A\n"))
+		req.Header.Set("Content-Type", "text/csv")
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
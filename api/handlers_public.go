@@ -0,0 +1,66 @@
+package api
+
+import (
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Public (Guest) Access ---
+// These routes are only registered when public access is enabled (see config.EnablePublicAccess)
+// and need no authentication. They are read-only and only ever return documents marked public.
+
+// GetPublicDocumentsHandler lists documents that their owners have marked public.
+// @Summary      List Public Documents (No Login Needed)
+// @Description  Lists documents whose owners have marked them `public`, such as reference material published by an instructor. No account or access token is needed.
+// @Description
+// @Description  Only available when the server runs with public access enabled (`DOCSERVER_ENABLE_PUBLIC_ACCESS`); otherwise this path does not exist.
+// @Description  Supports the same `content_query`, `missing`, `include`, `sort_by`, `order`, `page` and `limit` parameters as `GET /documents`.
+// @Tags         Public
+// @Produce      json
+// @Param        content_query query     []string false "Advanced filter based on document content (specific syntax applies)." collectionFormat(multi) example(course equals "CS101")
+// @Param        missing       query     string  false  "How conditions on paths a document lacks are treated, as for GET /documents." Enums(skip, false, error) default(skip)
+// @Param        include       query     string  false  "Set to 'owner' to embed the profile that owns each document." example(owner)
+// @Param        sort_by       query     string  false  "Field to sort results by." Enums(creation_date, last_modified_date) default(creation_date)
+// @Param        order         query     string  false  "Sorting direction." Enums(asc, desc) default(desc)
+// @Param        page          query     int     false  "Page number for pagination (starts at 1)." minimum(1) default(1)
+// @Param        limit         query     int     false  "Number of documents per page." minimum(1) maximum(100) default(20)
+// @Success      200  {object}  utils.Envelope{data=[]DocumentListItem,meta=utils.PageMeta,links=utils.PageLinks} "A page of public documents."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: One or more query parameters are invalid."
+// @Failure      500  {object}  utils.ErrorEnvelope "Internal Server Error: Something went wrong on the server while retrieving documents."
+// @Router       /public/documents [get]
+func GetPublicDocumentsHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	params, ok := parseDocumentQueryParams(c)
+	if !ok {
+		return // Error response already sent
+	}
+	params.Scope = "public"
+
+	respondDocumentQuery(c, database, cfg, params)
+}
+
+// GetPublicDocumentByIDHandler returns a single public document.
+// @Summary      Get a Public Document (No Login Needed)
+// @Description  Returns a document that its owner has marked `public`. No account or access token is needed.
+// @Description  Documents that are not public are reported as not found.
+// @Tags         Public
+// @Produce      json
+// @Param        id   path      string  true  "The unique identifier of the document."
+// @Success      200  {object}  utils.Envelope{data=models.Document} "The public document."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No public document exists with the specified ID."
+// @Router       /public/documents/{id} [get]
+func GetPublicDocumentByIDHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	docID := c.Param("id")
+
+	doc, found := database.GetDocumentByID(docID)
+	if !found || !doc.Public || database.IsProfileDeactivated(doc.OwnerID) {
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgDocumentNotFound, docID)
+		return
+	}
+
+	utils.RespondData(c, http.StatusOK, doc)
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"docserver/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublicDocuments(t *testing.T) {
+	router, database, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	_, _, ownerToken := createTestUserAndLogin(t, router, "instructor@example.com", "password123", "Inst", "Ructor")
+	_, _, otherToken := createTestUserAndLogin(t, router, "student@example.com", "password123", "Stu", "Dent")
+
+	createDoc := func(body gin.H) models.Document {
+		rr := performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, body), ownerToken)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var doc models.Document
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+		return doc
+	}
+	syllabus := createDoc(gin.H{"content": gin.H{"course": "CS101", "title": "Syllabus"}, "public": true})
+	notes := createDoc(gin.H{"content": gin.H{"course": "CS102", "title": "Notes"}, "public": true})
+	private := createDoc(gin.H{"content": gin.H{"course": "CS101", "title": "Grades"}})
+	assert.True(t, syllabus.Public)
+	assert.False(t, private.Public)
+
+	t.Run("Disabled by default", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/public/documents", nil, "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Authenticated users can read public documents", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/documents/"+syllabus.ID, nil, otherToken)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		rr = performRequest(router, http.MethodGet, "/documents/"+private.ID, nil, otherToken)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("Update toggles public", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPut, "/documents/"+notes.ID, marshalJSONBody(t, gin.H{"content": gin.H{"course": "CS102", "title": "Notes v2"}, "public": false}), ownerToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var doc models.Document
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+		assert.False(t, doc.Public)
+
+		rr = performRequest(router, http.MethodPut, "/documents/"+notes.ID, marshalJSONBody(t, gin.H{"content": gin.H{"course": "CS102", "title": "Notes v3"}}), ownerToken)
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+		assert.False(t, doc.Public, "omitting 'public' keeps the current value")
+	})
+
+	// Enable guest access; routes are registered at startup, so build a fresh router
+	cfg.EnablePublicAccess = true
+	guestRouter := gin.New()
+	RegisterRoutes(guestRouter, database, cfg)
+
+	t.Run("Guest list", func(t *testing.T) {
+		rr := performRequest(guestRouter, http.MethodGet, "/v1/public/documents", nil, "")
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var resp struct {
+			Data []models.Document `json:"data"`
+			Meta struct {
+				Total int `json:"total"`
+			} `json:"meta"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.Data, 1)
+		assert.Equal(t, syllabus.ID, resp.Data[0].ID)
+		assert.Equal(t, 1, resp.Meta.Total)
+	})
+
+	t.Run("Guest content query", func(t *testing.T) {
+		rr := performRequest(guestRouter, http.MethodGet, "/public/documents?content_query="+url.QueryEscape(`course equals "CS999"`), nil, "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp GetDocumentsResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Empty(t, resp.Data)
+
+		rr = performRequest(guestRouter, http.MethodGet, "/public/documents?content_query=bad", nil, "")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Guest get by ID", func(t *testing.T) {
+		rr := performRequest(guestRouter, http.MethodGet, "/public/documents/"+syllabus.ID, nil, "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		rr = performRequest(guestRouter, http.MethodGet, "/public/documents/"+private.ID, nil, "")
+		assert.Equal(t, http.StatusNotFound, rr.Code, "private documents are not revealed")
+	})
+
+	t.Run("Guests cannot write", func(t *testing.T) {
+		rr := performRequest(guestRouter, http.MethodPost, "/public/documents", marshalJSONBody(t, gin.H{"content": "x"}), "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
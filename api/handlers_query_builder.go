@@ -0,0 +1,67 @@
+package api
+
+import (
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Query Builder ---
+
+// QueryOperatorsResponse describes the content_query syntax, for building queries.
+type QueryOperatorsResponse struct {
+	Operators         []db.QueryOperator `json:"operators"`
+	Logical           []string           `json:"logical"`            // Join conditions; applied from left to right, without precedence
+	Negation          string             `json:"negation"`           // Prefix negating a condition
+	InsensitiveSuffix string             `json:"insensitive_suffix"` // Appended to an operator to compare strings ignoring case
+	LengthSuffix      string             `json:"length_suffix"`      // Appended to a path to compare the number of elements or keys there
+}
+
+// ValidateQueryRequest holds a candidate content_query.
+type ValidateQueryRequest struct {
+	ContentQuery []string `json:"content_query"` // The parts, as repeated in ?content_query=
+}
+
+// GetQueryOperatorsHandler describes the operators of content_query.
+// @Summary      List the Query Operators
+// @Description  Describes the syntax of the `content_query` parameter of `GET /documents` for query builders: each operator with the types of `value` it takes (`value_types`) and of content it applies to (`target_types`; conditions on content of another type fail), whether it has a case-insensitive variant (`insensitive`) and applies to plain text documents (`plain_text`), with an example.
+// @Description  A query alternates conditions (`path operator value`, or `operator value` for the content itself) and the `logical` operators. No login is needed.
+// @Tags         Documents
+// @Produce      json
+// @Success      200  {object}  utils.Envelope{data=QueryOperatorsResponse} "The operators and syntax of content_query."
+// @Router       /query/operators [get]
+func GetQueryOperatorsHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	utils.RespondData(c, http.StatusOK, QueryOperatorsResponse{
+		Operators:         db.QueryOperators,
+		Logical:           []string{string(db.LogicAnd), string(db.LogicOr)},
+		Negation:          "not",
+		InsensitiveSuffix: "-insensitive",
+		LengthSuffix:      ".#",
+	})
+}
+
+// ValidateQueryHandler checks a candidate content_query.
+// @Summary      Validate a Content Query
+// @Description  Checks the parts of a `content_query` without running it, and reports every problem as a diagnostic with the `index` of its part (`-1` for the whole query), a `severity`, a stable `code` and a localized `message`:
+// @Description  *   `error`: `GET /documents` would reject the query (e.g. an unknown operator or a missing value), or the value does not suit the operator (e.g. `priority greaterthan "high"`).
+// @Description  *   `warning`: the query works, perhaps not as meant (e.g. a string value left unquoted, or `and` and `or` mixed, which apply from left to right).
+// @Description
+// @Description  Where a fix is likely, such as `gt` for `greaterthan` or a misspelled operator, the diagnostic has a `suggestion` replacing the part. A `valid` query comes back as it is understood in `query`. No login is needed.
+// @Tags         Documents
+// @Accept       json
+// @Produce      json
+// @Param        query  body      ValidateQueryRequest  true  "The parts of the content_query."
+// @Success      200  {object}  utils.Envelope{data=db.QueryValidation} "Whether the query is valid, and its diagnostics."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The body is malformed."
+// @Router       /query/validate [post]
+func ValidateQueryHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	var req ValidateQueryRequest
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidRequestBody) {
+		return
+	}
+	utils.RespondData(c, http.StatusOK, db.ValidateContentQuery(req.ContentQuery, utils.RequestLanguage(c)))
+}
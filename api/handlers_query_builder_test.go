@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"docserver/db"
+	"docserver/i18n"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetQueryOperators(t *testing.T) {
+	router, _, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	rr := performRequest(router, http.MethodGet, "/query/operators", nil, "")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var resp QueryOperatorsResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, db.QueryOperators, resp.Operators)
+	assert.Equal(t, []string{"and", "or"}, resp.Logical)
+	assert.Equal(t, "not", resp.Negation)
+	assert.Equal(t, ".#", resp.LengthSuffix)
+}
+
+func TestValidateQuery(t *testing.T) {
+	router, _, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	validate := func(body string, acceptLanguage string) (*httptest.ResponseRecorder, db.QueryValidation) {
+		req := httptest.NewRequest(http.MethodPost, "/query/validate", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Language", acceptLanguage)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		var result db.QueryValidation
+		if rr.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+		}
+		return rr, result
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		rr, result := validate(`{"content_query": ["status equals \"active\"", "and", "priority greaterthan 3"]}`, "")
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.True(t, result.Valid)
+		assert.Equal(t, `status equals "active" and priority greaterthan 3`, result.Query)
+		assert.Empty(t, result.Diagnostics)
+	})
+
+	t.Run("Diagnostics", func(t *testing.T) {
+		rr, result := validate(`{"content_query": ["priority gt \"high\""]}`, "fr")
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.False(t, result.Valid)
+		require.Len(t, result.Diagnostics, 1)
+		assert.Equal(t, i18n.MsgQueryInvalidOperator, result.Diagnostics[0].Code)
+		assert.Equal(t, `priority greaterthan "high"`, result.Diagnostics[0].Suggestion)
+		assert.Equal(t, "fr", rr.Header().Get("Content-Language"))
+	})
+
+	t.Run("Malformed body", func(t *testing.T) {
+		rr, _ := validate(`{"content_query": "status equals 1"}`, "")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Allowed in read-only mode", func(t *testing.T) {
+		cfg.ReadOnly = true
+		defer func() { cfg.ReadOnly = false }()
+		rr, result := validate(`{"content_query": ["equals 1"]}`, "")
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.True(t, result.Valid)
+	})
+}
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"docserver/config"
+	"docserver/db"
+	"docserver/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Query Cache ---
+
+// GetQueryCacheStatsHandler reports the size and hit rate of the document query cache.
+// @Summary      Get Query Cache Statistics (Admin)
+// @Description  Reports how many document query results the query cache holds and how many queries it answered (hits) or had to run (misses) since the server started. A result is reused when the same user sends the same list or search parameters again before any data changes; every change makes all cached results stale. The size is set with -query-cache-size (0 turns the cache off). Administrators only.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  utils.Envelope{data=db.QueryCacheStats} "The query cache statistics."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Router       /admin/query-cache [get]
+func GetQueryCacheStatsHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	utils.RespondData(c, http.StatusOK, database.QueryCacheStats())
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"docserver/db"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetQueryCacheStats(t *testing.T) {
+	router, _, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	cfg.AdminEmails = []string{"cache.admin@example.com"}
+	cfg.QueryCacheSize = 10
+
+	_, _, adminToken := createTestUserAndLogin(t, router, "cache.admin@example.com", "password123", "Cache", "Admin")
+	_, _, userToken := createTestUserAndLogin(t, router, "cache.user@example.com", "password123", "Cache", "User")
+	for range 2 {
+		rr := performRequest(router, http.MethodGet, "/documents?scope=owned", nil, userToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	rr := performRequest(router, http.MethodGet, "/admin/query-cache", nil, userToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	rr = performRequest(router, http.MethodGet, "/admin/query-cache", nil, adminToken)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var stats db.QueryCacheStats
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &stats))
+	assert.Equal(t, db.QueryCacheStats{Capacity: 10, Size: 1, Hits: 1, Misses: 1}, stats)
+}
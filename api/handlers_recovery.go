@@ -0,0 +1,33 @@
+package api
+
+import (
+	"docserver/config"
+	"docserver/db"
+	"docserver/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Database Recovery ---
+
+// RecoveryResponse tells whether the database had to be recovered from damaged files on startup.
+type RecoveryResponse struct {
+	Recovered bool                `json:"recovered"`         // False if the database loaded as is
+	Reports   []db.RecoveryReport `json:"reports,omitempty"` // How each damaged file was recovered
+}
+
+// GetRecoveryHandler reports whether and how the database was recovered on startup.
+// @Summary      Report Database Recovery (Admin)
+// @Description  When the database file cannot be loaded on startup, because it does not match its checksum (see -checksum) or, with -auto-recover, because it cannot be parsed, the server starts from the `.bak` file or from the records it can still read, and moves the damaged file aside. This reports whether that happened since the server started and, for each damaged file (`file`; with -data-dir the files of the directory are recovered one by one), why (`reason`), from what (`source`: `backup` or `salvage`), where the damaged file went (`quarantined_as`) and, when salvaging, how many records of each collection were kept (`recovered`). Administrators only.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  utils.Envelope{data=RecoveryResponse} "Whether the database was recovered."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Router       /admin/recovery [get]
+func GetRecoveryHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	reports := database.Recoveries()
+	utils.RespondData(c, http.StatusOK, RecoveryResponse{Recovered: len(reports) > 0, Reports: reports})
+}
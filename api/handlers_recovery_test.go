@@ -0,0 +1,58 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"docserver/db"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRecovery(t *testing.T) {
+	router, database, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	cfg.AdminEmails = []string{"recovery.admin@example.com", "recovery.again@example.com"}
+
+	_, _, adminToken := createTestUserAndLogin(t, router, "recovery.admin@example.com", "password123", "Re", "Covery")
+	_, _, userToken := createTestUserAndLogin(t, router, "recovery.user@example.com", "password123", "Us", "Er")
+	recovery := func(router *gin.Engine, token string) RecoveryResponse {
+		rr := performRequest(router, http.MethodGet, "/v1/admin/recovery", nil, token)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var body struct{ Data RecoveryResponse }
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		return body.Data
+	}
+
+	rr := performRequest(router, http.MethodGet, "/v1/admin/recovery", nil, userToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Equal(t, RecoveryResponse{}, recovery(router, adminToken))
+
+	// Restart on a truncated file
+	require.NoError(t, database.PersistNow())
+	data, err := os.ReadFile(cfg.DbFilePath)
+	require.NoError(t, err)
+	cut := strings.Index(string(data), "recovery.user@example.com")
+	require.NoError(t, os.WriteFile(cfg.DbFilePath, data[:cut], 0644))
+	cfg.AutoRecover = true
+	restarted, err := db.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer restarted.Close()
+	restartedRouter := gin.New()
+	RegisterRoutes(restartedRouter, restarted, cfg)
+
+	_, _, againToken := createTestUserAndLogin(t, restartedRouter, "recovery.again@example.com", "password123", "Ag", "Ain")
+
+	got := recovery(restartedRouter, againToken)
+	assert.True(t, got.Recovered)
+	require.Len(t, got.Reports, 1)
+	assert.Equal(t, cfg.DbFilePath, got.Reports[0].File)
+	assert.Equal(t, db.RecoverySourceSalvage, got.Reports[0].Source)
+	assert.Contains(t, got.Reports[0].Recovered, "profiles")
+	assert.Less(t, got.Reports[0].Recovered["profiles"], 2, "the file was cut inside a profile")
+}
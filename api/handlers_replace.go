@@ -0,0 +1,121 @@
+package api
+
+import (
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Search and Replace ---
+
+// ReplaceDocumentsRequest defines the body for a search-and-replace across your documents.
+// Send either `old` (exact value match) or `regex` (pattern within a string value), plus `new`.
+type ReplaceDocumentsRequest struct {
+	ContentQuery []string        `json:"content_query,omitempty"`                // Same syntax as GET /documents; empty matches all your documents
+	Path         string          `json:"path" binding:"required" example:"room"` // Dot-separated path to the value; numeric parts index arrays
+	Old          json.RawMessage `json:"old,omitempty" swaggertype:"object"`     // Value to replace (any JSON, including null)
+	Regex        string          `json:"regex,omitempty" example:"^B(\\d+)$"`    // RE2 pattern replaced within string values
+	New          json.RawMessage `json:"new" swaggertype:"object"`               // Replacement; with regex, a string that may use $1 etc.
+	DryRun       bool            `json:"dry_run,omitempty"`                      // Report what would change without saving
+}
+
+// toReplaceSpec validates the request and converts it to a replacement for the owner's documents.
+func (req ReplaceDocumentsRequest) toReplaceSpec(ownerID string) (db.ReplaceSpec, error) {
+	spec := db.ReplaceSpec{OwnerID: ownerID, ContentQuery: req.ContentQuery, DryRun: req.DryRun}
+
+	spec.Path = strings.TrimSpace(req.Path)
+	for _, part := range strings.Split(spec.Path, ".") {
+		if part == "" {
+			return db.ReplaceSpec{}, fmt.Errorf("path '%s' has an empty part", req.Path)
+		}
+	}
+	if len(req.New) == 0 {
+		return db.ReplaceSpec{}, fmt.Errorf("new is required")
+	}
+	if err := json.Unmarshal(req.New, &spec.New); err != nil {
+		return db.ReplaceSpec{}, fmt.Errorf("new: %v", err)
+	}
+
+	switch {
+	case len(req.Old) > 0 && req.Regex != "":
+		return db.ReplaceSpec{}, fmt.Errorf("send either old or regex, not both")
+	case len(req.Old) > 0:
+		if err := json.Unmarshal(req.Old, &spec.Old); err != nil {
+			return db.ReplaceSpec{}, fmt.Errorf("old: %v", err)
+		}
+		spec.HasOld = true
+	case req.Regex != "":
+		if _, isString := spec.New.(string); !isString {
+			return db.ReplaceSpec{}, fmt.Errorf("new must be a string when regex is used")
+		}
+		pattern, err := regexp.Compile(req.Regex)
+		if err != nil {
+			return db.ReplaceSpec{}, fmt.Errorf("regex: %v", err)
+		}
+		spec.Regex = pattern
+	default:
+		return db.ReplaceSpec{}, fmt.Errorf("either old or regex is required")
+	}
+	return spec, nil
+}
+
+// ReplaceDocumentsHandler replaces a value across the authenticated user's matching documents.
+// @Summary      Search and Replace Across Your Documents
+// @Description  Replaces the value at `path` in every document you own that matches `content_query` (same syntax as `GET /documents`; omit it to consider all your documents). Documents shared with you are never changed.
+// @Description
+// @Description  Choose what is replaced with one of:
+// @Description  *   **`old`**: the value at `path` is replaced by `new` when it equals `old` exactly (any JSON value, e.g. `"B12"`, `3` or `null`).
+// @Description  *   **`regex`**: when the value at `path` is a string, every match of the pattern is replaced by `new`, which may refer to groups as `$1`.
+// @Description
+// @Description  Frozen documents (see `POST /documents/{id}/freeze`) are skipped and counted in `frozen`.
+// @Description  The replacement is atomic: if any document cannot be updated (for example a document script rejects it), no document is changed. Each changed document gets a new version and an activity entry, as with `PUT /documents/{id}`.
+// @Description  Send `"dry_run": true` to see the report without saving anything.
+// @Tags         Documents
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        replace body ReplaceDocumentsRequest true "What to replace."
+// @Success      200  {object}  utils.Envelope{data=db.ReplaceReport} "The documents that were (or, for a dry run, would be) changed."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The body is invalid, the content_query or regex does not parse, or neither (or both) of old and regex were given."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      422  {object}  utils.ErrorEnvelope "Unprocessable Entity: A document script rejected one of the changes; nothing was changed."
+// @Failure      500  {object}  utils.ErrorEnvelope "Internal Server Error: The replacement could not be applied; nothing was changed."
+// @Router       /documents/replace [post]
+func ReplaceDocumentsHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+
+	var req ReplaceDocumentsRequest
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidRequestBody) {
+		return
+	}
+	spec, err := req.toReplaceSpec(userID.(string))
+	if err != nil {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgReplaceInvalid, err)
+		return
+	}
+
+	report, err := database.ReplaceInDocuments(spec)
+	if err != nil {
+		var msgErr *i18n.Error
+		if errors.As(err, &msgErr) && msgErr.ID == i18n.MsgQueryInvalid {
+			utils.GinErrorFromErr(c, http.StatusBadRequest, err)
+		} else if !respondScriptError(c, err) {
+			utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgReplaceFailed, err)
+		}
+		return
+	}
+	utils.RespondData(c, http.StatusOK, report)
+}
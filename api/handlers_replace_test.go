@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"docserver/db"
+	"docserver/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplaceDocuments(t *testing.T) {
+	router, database, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	_, _, ownerToken := createTestUserAndLogin(t, router, "replace.owner@example.com", "password123", "Re", "Place")
+	otherID, _, otherToken := createTestUserAndLogin(t, router, "replace.other@example.com", "password123", "Ot", "Her")
+
+	createDoc := func(token string, content gin.H) models.Document {
+		rr := performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, gin.H{"content": content}), token)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var doc models.Document
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+		return doc
+	}
+	lecture := createDoc(ownerToken, gin.H{"course": "CS101", "room": "B12", "teacher": nil})
+	lab := createDoc(ownerToken, gin.H{"course": "CS101", "room": "B14", "teacher": nil})
+	shared := createDoc(ownerToken, gin.H{"course": "CS102", "room": "B12"})
+	rr := performRequest(router, http.MethodPut, "/documents/"+shared.ID+"/shares/"+otherID, nil, ownerToken)
+	require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+
+	replace := func(token string, body gin.H) (*db.ReplaceReport, int, string) {
+		rr := performRequest(router, http.MethodPost, "/documents/replace", marshalJSONBody(t, body), token)
+		if rr.Code != http.StatusOK {
+			return nil, rr.Code, rr.Body.String()
+		}
+		var report db.ReplaceReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		return &report, rr.Code, rr.Body.String()
+	}
+
+	t.Run("Dry run", func(t *testing.T) {
+		report, code, body := replace(ownerToken, gin.H{"path": "room", "regex": `^B(\d+)$`, "new": "C$1", "dry_run": true})
+		require.Equal(t, http.StatusOK, code, body)
+		assert.True(t, report.DryRun)
+		assert.Equal(t, 3, report.Matched)
+		assert.Equal(t, 3, report.Changed)
+		doc, _ := database.GetDocumentByID(lecture.ID)
+		assert.Equal(t, "B12", doc.Content.(map[string]any)["room"])
+	})
+
+	t.Run("Filtered regex replacement", func(t *testing.T) {
+		report, code, body := replace(ownerToken, gin.H{"content_query": []string{`course equals "CS101"`}, "path": "room", "regex": `^B(\d+)$`, "new": "C$1"})
+		require.Equal(t, http.StatusOK, code, body)
+		require.Equal(t, 2, report.Changed)
+		assert.Equal(t, db.ReplaceChange{DocumentID: lab.ID, From: "B14", To: "C14", Version: 2}, report.Changes[1])
+		doc, _ := database.GetDocumentByID(shared.ID)
+		assert.Equal(t, "B12", doc.Content.(map[string]any)["room"], "outside the content query")
+	})
+
+	t.Run("Null can be replaced", func(t *testing.T) {
+		report, code, body := replace(ownerToken, gin.H{"path": "teacher", "old": nil, "new": gin.H{"name": "Ann"}})
+		require.Equal(t, http.StatusOK, code, body)
+		assert.Equal(t, 2, report.Changed)
+	})
+
+	t.Run("Only owned documents are changed", func(t *testing.T) {
+		report, code, body := replace(otherToken, gin.H{"path": "room", "old": "B12", "new": "hacked"})
+		require.Equal(t, http.StatusOK, code, body)
+		assert.Equal(t, 0, report.Matched)
+		doc, _ := database.GetDocumentByID(shared.ID)
+		assert.Equal(t, "B12", doc.Content.(map[string]any)["room"])
+	})
+
+	t.Run("Invalid requests", func(t *testing.T) {
+		for name, body := range map[string]gin.H{
+			"no path":          {"old": "a", "new": "b"},
+			"empty path part":  {"path": "a..b", "old": "a", "new": "b"},
+			"no new":           {"path": "room", "old": "a"},
+			"neither":          {"path": "room", "new": "b"},
+			"both":             {"path": "room", "old": "a", "regex": "a", "new": "b"},
+			"bad regex":        {"path": "room", "regex": "(", "new": "b"},
+			"regex non-string": {"path": "room", "regex": "a", "new": 1},
+			"bad query":        {"path": "room", "old": "a", "new": "b", "content_query": []string{"room sortof 1"}},
+		} {
+			_, code, _ := replace(ownerToken, body)
+			assert.Equal(t, http.StatusBadRequest, code, name)
+		}
+	})
+}
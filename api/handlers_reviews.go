@@ -0,0 +1,268 @@
+package api
+
+import (
+	"docserver/apperr"
+	"docserver/authz"
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/models"
+	"docserver/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Peer Reviews ---
+
+// AssignReviewRequest names the profile to review a document.
+type AssignReviewRequest struct {
+	ReviewerID string `json:"reviewer_id" binding:"required" example:"usr_abc123xyz"`
+}
+
+// SubmitReviewRequest is a reviewer's feedback.
+type SubmitReviewRequest struct {
+	Rating   int            `json:"rating" binding:"required" example:"4"`                     // Overall rating, 1-5
+	Criteria map[string]int `json:"criteria,omitempty"`                                        // Optional ratings (1-5) per criterion, e.g. {"clarity": 5}
+	Comment  string         `json:"comment,omitempty" example:"Clear, but cite your sources."` // Free-form feedback
+}
+
+// DocumentReviewsResponse lists the reviews of a document.
+type DocumentReviewsResponse struct {
+	Reviews []models.Review   `json:"reviews"`
+	Summary *db.ReviewSummary `json:"summary,omitempty"` // Only for the owner and administrators
+}
+
+// AssignReviewHandler assigns a reviewer to a document.
+// @Summary      Assign a Reviewer
+// @Description  Asks another user to review one of your documents. The reviewer can then read the document, even if it is not shared with them, and submit their feedback with `PUT /reviews/{id}`. Each profile can be assigned to a document once. Only the owner or an administrator can assign reviewers.
+// @Tags         Reviews
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string               true  "The unique identifier of the document." example(doc_abc123xyz)
+// @Param        request  body      AssignReviewRequest  true  "The profile to review the document."
+// @Success      201  {object}  utils.Envelope{data=models.Review} "The review assignment."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: 'reviewer_id' is missing, or names the owner."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not the owner of the document or an administrator."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No document or reviewer profile exists with the specified ID."
+// @Failure      409  {object}  utils.ErrorEnvelope "Conflict: The profile is already assigned to review the document."
+// @Router       /documents/{id}/reviews [post]
+func AssignReviewHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	doc, userID, manager, ok := reviewedDocument(c, database, cfg)
+	if !ok {
+		return // Error response already sent
+	}
+	if !manager {
+		utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgReviewAssignDenied)
+		return
+	}
+
+	var req AssignReviewRequest
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgReviewInvalidBody) {
+		return
+	}
+	review, err := database.AssignReview(doc.ID, strings.TrimSpace(req.ReviewerID), userID)
+	if err != nil {
+		utils.GinErrorFromErr(c, apperr.HTTPStatus(err), err)
+		return
+	}
+	utils.RespondData(c, http.StatusCreated, review)
+}
+
+// ListDocumentReviewsHandler lists the reviews of a document.
+// @Summary      List a Document's Reviews
+// @Description  Lists the reviews of a document in the order they were assigned. The owner and administrators see every review and a `summary`: how many are pending and submitted, and the average overall and per-criterion ratings of the submitted ones. A reviewer sees only their own review and no summary.
+// @Tags         Reviews
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "The unique identifier of the document." example(doc_abc123xyz)
+// @Success      200  {object}  utils.Envelope{data=DocumentReviewsResponse} "The reviews."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not the owner, a reviewer of the document or an administrator."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No document exists with the specified ID."
+// @Router       /documents/{id}/reviews [get]
+func ListDocumentReviewsHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	doc, userID, manager, ok := reviewedDocument(c, database, cfg)
+	if !ok {
+		return // Error response already sent
+	}
+
+	reviews := database.ListDocumentReviews(doc.ID)
+	if manager {
+		summary := db.SummarizeReviews(reviews)
+		utils.RespondData(c, http.StatusOK, DocumentReviewsResponse{Reviews: reviews, Summary: &summary})
+		return
+	}
+	own := make([]models.Review, 0, 1)
+	for _, review := range reviews {
+		if review.ReviewerID == userID {
+			own = append(own, review)
+		}
+	}
+	if len(own) == 0 {
+		utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgReviewAccessDenied)
+		return
+	}
+	utils.RespondData(c, http.StatusOK, DocumentReviewsResponse{Reviews: own})
+}
+
+// ListMyReviewsHandler lists the reviews assigned to the authenticated user.
+// @Summary      List Your Reviews
+// @Description  Lists the reviews you were assigned, in the order they were assigned. Pass `status=pending` to see only those still waiting for your feedback.
+// @Tags         Reviews
+// @Produce      json
+// @Security     BearerAuth
+// @Param        status  query     string  false  "Only reviews with this status: 'pending' or 'submitted'."
+// @Success      200  {object}  utils.Envelope{data=[]models.Review} "Your reviews."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The status is unknown."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Router       /reviews [get]
+func ListMyReviewsHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+	status := c.Query("status")
+	if status != "" && status != models.ReviewStatusPending && status != models.ReviewStatusSubmitted {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgReviewInvalidStatus, status)
+		return
+	}
+	utils.RespondData(c, http.StatusOK, database.ListReviewsAssignedTo(userID.(string), status))
+}
+
+// GetReviewHandler returns a review.
+// @Summary      Get a Review
+// @Description  Returns a review. Visible to its reviewer, the owner of the reviewed document and administrators.
+// @Tags         Reviews
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "The unique identifier of the review." example(rev_abc123xyz)
+// @Success      200  {object}  utils.Envelope{data=models.Review} "The review."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not the reviewer, the owner of the document or an administrator."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No review exists with the specified ID."
+// @Router       /reviews/{id} [get]
+func GetReviewHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	review, _, ok := visibleReview(c, database, cfg)
+	if !ok {
+		return // Error response already sent
+	}
+	utils.RespondData(c, http.StatusOK, review)
+}
+
+// SubmitReviewHandler records the reviewer's feedback.
+// @Summary      Submit Review Feedback
+// @Description  Records your feedback in a review you were assigned: an overall `rating` from 1 to 5, optional `criteria` rated from 1 to 5 each (at most 20, such as `{"clarity": 5, "accuracy": 3}`) and a `comment`. The review becomes `submitted`. Submitting again replaces your earlier feedback.
+// @Tags         Reviews
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string               true  "The unique identifier of the review." example(rev_abc123xyz)
+// @Param        request  body      SubmitReviewRequest  true  "Your feedback."
+// @Success      200  {object}  utils.Envelope{data=models.Review} "The submitted review."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The rating is missing, or a rating or criterion is invalid."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not the reviewer."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No review exists with the specified ID."
+// @Router       /reviews/{id} [put]
+func SubmitReviewHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	review, _, ok := visibleReview(c, database, cfg)
+	if !ok {
+		return // Error response already sent
+	}
+
+	var req SubmitReviewRequest
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgReviewFeedbackInvalidBody) {
+		return
+	}
+	userID, _ := c.Get("userID")
+	submitted, err := database.SubmitReview(review.ID, userID.(string), db.ReviewFeedback{
+		Rating:   req.Rating,
+		Criteria: req.Criteria,
+		Comment:  strings.TrimSpace(req.Comment),
+	})
+	if err != nil {
+		utils.GinErrorFromErr(c, apperr.HTTPStatus(err), err)
+		return
+	}
+	utils.RespondData(c, http.StatusOK, submitted)
+}
+
+// DeleteReviewHandler removes a review assignment.
+// @Summary      Remove a Reviewer
+// @Description  Removes a review assignment along with any feedback given. The reviewer can no longer read the document unless it is otherwise shared with them. Only the owner of the document or an administrator can remove reviewers.
+// @Tags         Reviews
+// @Security     BearerAuth
+// @Param        id   path      string  true  "The unique identifier of the review." example(rev_abc123xyz)
+// @Success      204  "Review removed."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not the owner of the document or an administrator."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No review exists with the specified ID."
+// @Router       /reviews/{id} [delete]
+func DeleteReviewHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	review, manager, ok := visibleReview(c, database, cfg)
+	if !ok {
+		return // Error response already sent
+	}
+	if !manager {
+		utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgReviewAssignDenied)
+		return
+	}
+	if err := database.DeleteReview(review.ID); err != nil {
+		utils.GinErrorFromErr(c, apperr.HTTPStatus(err), err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// reviewedDocument loads the document in the path and tells who is asking and whether they manage
+// its reviews (see authz.ManageDocumentReview), sending 404 if the document does not exist.
+func reviewedDocument(c *gin.Context, database *db.Database, cfg *config.Config) (models.Document, string, bool, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return models.Document{}, "", false, false
+	}
+	userIDStr := userID.(string)
+	docID := c.Param("id")
+
+	doc, found := database.GetDocumentByID(docID)
+	if !found {
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgDocumentNotFound, docID)
+		return models.Document{}, "", false, false
+	}
+	return doc, userIDStr, can(c, database, cfg, authz.ManageDocumentReview, doc), true
+}
+
+// visibleReview loads the review in the path if the caller is its reviewer, the owner of the
+// reviewed document or an administrator, and tells whether they are one of the latter two
+// (who manage the document's reviews). Otherwise it sends 403 or 404.
+func visibleReview(c *gin.Context, database *db.Database, cfg *config.Config) (models.Review, bool, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return models.Review{}, false, false
+	}
+	userIDStr := userID.(string)
+	reviewID := c.Param("id")
+
+	review, found := database.GetReview(reviewID)
+	if !found {
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgReviewNotFound, reviewID)
+		return models.Review{}, false, false
+	}
+	profile, _ := database.GetProfileByID(userIDStr)
+	manager := isAdmin(profile, cfg)
+	if doc, found := database.GetDocumentByID(review.DocumentID); found {
+		manager = can(c, database, cfg, authz.ManageDocumentReview, doc)
+	}
+	if !manager && review.ReviewerID != userIDStr {
+		utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgReviewAccessDenied)
+		return models.Review{}, false, false
+	}
+	return review, manager, true
+}
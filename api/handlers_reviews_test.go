@@ -0,0 +1,101 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"docserver/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentReviews(t *testing.T) {
+	router, _, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	_, _, ownerToken := createTestUserAndLogin(t, router, "review.owner@example.com", "password123", "Review", "Owner")
+	reviewerID, _, reviewerToken := createTestUserAndLogin(t, router, "review.reviewer@example.com", "password123", "Review", "Reviewer")
+	_, _, strangerToken := createTestUserAndLogin(t, router, "review.stranger@example.com", "password123", "Review", "Stranger")
+	cfg.AdminEmails = []string{"review.admin@example.com"}
+	_, _, adminToken := createTestUserAndLogin(t, router, "review.admin@example.com", "password123", "Review", "Admin")
+
+	rr := performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, map[string]any{"content": map[string]any{"essay": "..."}}), ownerToken)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var doc models.Document
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+
+	rr = performRequest(router, http.MethodGet, "/documents/"+doc.ID, nil, reviewerToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code, "not yet a reviewer")
+
+	// Assigning
+	rr = performRequest(router, http.MethodPost, "/documents/"+doc.ID+"/reviews", marshalJSONBody(t, map[string]any{"reviewer_id": reviewerID}), strangerToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = performRequest(router, http.MethodPost, "/documents/"+doc.ID+"/reviews", marshalJSONBody(t, map[string]any{}), ownerToken)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	rr = performRequest(router, http.MethodPost, "/documents/"+doc.ID+"/reviews", marshalJSONBody(t, map[string]any{"reviewer_id": reviewerID}), ownerToken)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var review models.Review
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &review))
+	assert.Equal(t, models.ReviewStatusPending, review.Status)
+	rr = performRequest(router, http.MethodPost, "/documents/"+doc.ID+"/reviews", marshalJSONBody(t, map[string]any{"reviewer_id": reviewerID}), adminToken)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	rr = performRequest(router, http.MethodGet, "/documents/"+doc.ID, nil, reviewerToken)
+	assert.Equal(t, http.StatusOK, rr.Code, "reviewers can read the document")
+
+	// Reviewer's view
+	rr = performRequest(router, http.MethodGet, "/reviews?status=pending", nil, reviewerToken)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var mine []models.Review
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &mine))
+	require.Len(t, mine, 1)
+	assert.Equal(t, review.ID, mine[0].ID)
+	rr = performRequest(router, http.MethodGet, "/reviews?status=late", nil, reviewerToken)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	rr = performRequest(router, http.MethodGet, "/reviews/"+review.ID, nil, strangerToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	// Feedback
+	rr = performRequest(router, http.MethodPut, "/reviews/"+review.ID, marshalJSONBody(t, map[string]any{"rating": 4}), ownerToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code, "only the reviewer gives feedback")
+	rr = performRequest(router, http.MethodPut, "/reviews/"+review.ID, marshalJSONBody(t, map[string]any{"rating": 4, "criteria": map[string]int{"clarity": 9}}), reviewerToken)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	rr = performRequest(router, http.MethodPut, "/reviews/"+review.ID, marshalJSONBody(t, map[string]any{"rating": 4, "criteria": map[string]int{"clarity": 5}, "comment": "Nice"}), reviewerToken)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &review))
+	assert.Equal(t, models.ReviewStatusSubmitted, review.Status)
+	assert.Equal(t, "Nice", review.Comment)
+
+	// Owner's aggregated view, and the reviewer's own
+	var listing DocumentReviewsResponse
+	rr = performRequest(router, http.MethodGet, "/documents/"+doc.ID+"/reviews", nil, ownerToken)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &listing))
+	require.Len(t, listing.Reviews, 1)
+	require.NotNil(t, listing.Summary)
+	assert.Equal(t, 1, listing.Summary.Submitted)
+	require.NotNil(t, listing.Summary.AverageRating)
+	assert.InDelta(t, 4.0, *listing.Summary.AverageRating, 0.001)
+	assert.InDelta(t, 5.0, listing.Summary.Criteria["clarity"], 0.001)
+
+	listing = DocumentReviewsResponse{}
+	rr = performRequest(router, http.MethodGet, "/documents/"+doc.ID+"/reviews", nil, reviewerToken)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &listing))
+	assert.Len(t, listing.Reviews, 1)
+	assert.Nil(t, listing.Summary)
+	rr = performRequest(router, http.MethodGet, "/documents/"+doc.ID+"/reviews", nil, strangerToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	// Removal
+	rr = performRequest(router, http.MethodDelete, "/reviews/"+review.ID, nil, reviewerToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = performRequest(router, http.MethodDelete, "/reviews/"+review.ID, nil, ownerToken)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	rr = performRequest(router, http.MethodGet, "/documents/"+doc.ID, nil, reviewerToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = performRequest(router, http.MethodGet, "/reviews/doc_notareview", nil, ownerToken)
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "document IDs are not review IDs")
+}
@@ -0,0 +1,330 @@
+package api
+
+import (
+	"docserver/apperr"
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/models"
+	"docserver/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Scheduled Exports ---
+
+// minScheduleInterval is the shortest allowed time between runs; the worker checks once a minute.
+const minScheduleInterval = time.Minute
+
+// ScheduleRequest defines the body for creating or replacing a scheduled export.
+type ScheduleRequest struct {
+	Name         string   `json:"name" binding:"required"`
+	ContentQuery []string `json:"content_query,omitempty"`                   // Same syntax as GET /documents
+	Scope        string   `json:"scope,omitempty"`                           // "owned", "shared" or "all" (default)
+	Interval     string   `json:"interval" binding:"required" example:"24h"` // Go duration, at least 1m
+	Target       string   `json:"target,omitempty"`                          // "download" (default) or "webhook"
+	WebhookURL   string   `json:"webhook_url,omitempty"`                     // Required for webhook targets
+	Enabled      *bool    `json:"enabled,omitempty"`                         // Defaults to true
+}
+
+// toSchedule validates the request and converts it to a schedule.
+func (req ScheduleRequest) toSchedule(cfg *config.Config) (models.Schedule, error) {
+	if _, err := db.ParseContentQuery(req.ContentQuery); err != nil {
+		return models.Schedule{}, fmt.Errorf("content_query: %v", err)
+	}
+
+	scope := strings.ToLower(strings.TrimSpace(req.Scope))
+	switch scope {
+	case "":
+		scope = "all"
+	case "owned", "shared", "all":
+	default:
+		return models.Schedule{}, fmt.Errorf("scope must be one of 'owned', 'shared' or 'all'")
+	}
+
+	interval, err := time.ParseDuration(strings.TrimSpace(req.Interval))
+	if err != nil {
+		return models.Schedule{}, fmt.Errorf("interval must be a duration such as '24h': %v", err)
+	}
+	if interval < minScheduleInterval {
+		return models.Schedule{}, fmt.Errorf("interval must be at least %s", minScheduleInterval)
+	}
+
+	target := strings.ToLower(strings.TrimSpace(req.Target))
+	webhookURL := ""
+	switch target {
+	case "", models.ScheduleTargetDownload:
+		target = models.ScheduleTargetDownload
+	case models.ScheduleTargetWebhook:
+		if len(cfg.WebhookAllowedDomains) == 0 {
+			return models.Schedule{}, fmt.Errorf("webhook targets are not enabled on this server")
+		}
+		parsed, err := utils.ParseFetchURL(req.WebhookURL)
+		if err != nil {
+			return models.Schedule{}, fmt.Errorf("webhook_url: %v", err)
+		}
+		if !utils.HostAllowed(parsed.Hostname(), cfg.WebhookAllowedDomains) {
+			return models.Schedule{}, fmt.Errorf("webhook_url host '%s' is not allowed. Allowed domains: %s",
+				parsed.Hostname(), strings.Join(cfg.WebhookAllowedDomains, ", "))
+		}
+		webhookURL = parsed.String()
+	default:
+		return models.Schedule{}, fmt.Errorf("target must be '%s' or '%s'", models.ScheduleTargetDownload, models.ScheduleTargetWebhook)
+	}
+
+	return models.Schedule{
+		Name:         strings.TrimSpace(req.Name),
+		ContentQuery: req.ContentQuery,
+		Scope:        scope,
+		Interval:     interval.String(),
+		Target:       target,
+		WebhookURL:   webhookURL,
+		Enabled:      req.Enabled == nil || *req.Enabled,
+	}, nil
+}
+
+// ownedSchedule loads the schedule in the :id path parameter and checks that the authenticated user
+// owns it. Schedules of other users are reported as not found. On failure the response is already sent.
+func ownedSchedule(c *gin.Context, database *db.Database) (models.Schedule, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return models.Schedule{}, false
+	}
+	scheduleID := c.Param("id")
+	schedule, found := database.GetSchedule(scheduleID)
+	if !found || schedule.OwnerID != userID.(string) {
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgScheduleNotFound, scheduleID)
+		return models.Schedule{}, false
+	}
+	return schedule, true
+}
+
+// ListSchedulesHandler lists the authenticated user's scheduled exports.
+// @Summary      List Your Scheduled Exports
+// @Description  Lists your scheduled exports, oldest first.
+// @Tags         Schedules
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  utils.Envelope{data=[]models.Schedule} "Your schedules."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Router       /schedules [get]
+func ListSchedulesHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+	utils.RespondData(c, http.StatusOK, database.ListSchedules(userID.(string)))
+}
+
+// CreateScheduleHandler adds a scheduled export.
+// @Summary      Schedule a Recurring Export
+// @Description  Schedules a recurring export of the documents you can access that match a `content_query` (same syntax as `GET /documents`; omit it to export everything in `scope`).
+// @Description  The first run happens one `interval` from now (e.g. `24h` for nightly), then every `interval` after that. Runs are carried out by a background worker that checks once a minute.
+// @Description
+// @Description  Each run takes a snapshot of the matching documents, oldest first, and delivers it to the `target`:
+// @Description  *   **`download`** (default): the snapshot is kept and can be fetched from `GET /schedules/{id}/runs/{run_id}/download`.
+// @Description  *   **`webhook`**: the snapshot is POSTed as JSON to `webhook_url`, which must be on one of the server's allowed domains (`DOCSERVER_WEBHOOK_ALLOWED_DOMAINS`).
+// @Description
+// @Description  Every run is recorded in the schedule's run history (`GET /schedules/{id}/runs`); the latest 20 runs are kept.
+// @Tags         Schedules
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        schedule body ScheduleRequest true "The schedule."
+// @Success      201  {object}  utils.Envelope{data=models.Schedule} "Schedule created. 'next_run' tells you when it first runs."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: A field is missing or invalid, or the webhook URL is not allowed."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      500  {object}  utils.ErrorEnvelope "Internal Server Error: The schedule could not be saved."
+// @Router       /schedules [post]
+func CreateScheduleHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+
+	var req ScheduleRequest
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidRequestBody) {
+		return
+	}
+	schedule, err := req.toSchedule(cfg)
+	if err != nil {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgScheduleInvalid, err)
+		return
+	}
+	schedule.OwnerID = userID.(string)
+
+	created, err := database.CreateSchedule(schedule)
+	if err != nil {
+		if errors.Is(err, apperr.ErrValidation) {
+			utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgScheduleInvalid, err)
+		} else {
+			utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgScheduleSaveFailed, err)
+		}
+		return
+	}
+	utils.RespondData(c, http.StatusCreated, created)
+}
+
+// GetScheduleHandler returns one of the authenticated user's scheduled exports.
+// @Summary      Get a Scheduled Export
+// @Description  Returns one of your schedules, including when it last ran and when it runs next.
+// @Tags         Schedules
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "The unique identifier of the schedule."
+// @Success      200  {object}  utils.Envelope{data=models.Schedule} "The schedule."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: You have no schedule with the specified ID."
+// @Router       /schedules/{id} [get]
+func GetScheduleHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	schedule, ok := ownedSchedule(c, database)
+	if !ok {
+		return // Error response already sent
+	}
+	utils.RespondData(c, http.StatusOK, schedule)
+}
+
+// UpdateScheduleHandler replaces one of the authenticated user's scheduled exports.
+// @Summary      Replace a Scheduled Export
+// @Description  Replaces a schedule's settings. The next run is rescheduled to one `interval` from now. Send `"enabled": false` to pause a schedule without deleting it; its run history is kept.
+// @Tags         Schedules
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string           true  "The unique identifier of the schedule."
+// @Param        schedule body      ScheduleRequest  true  "The new settings."
+// @Success      200  {object}  utils.Envelope{data=models.Schedule} "Schedule updated."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: A field is missing or invalid, or the webhook URL is not allowed."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: You have no schedule with the specified ID."
+// @Failure      500  {object}  utils.ErrorEnvelope "Internal Server Error: The schedule could not be saved."
+// @Router       /schedules/{id} [put]
+func UpdateScheduleHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	existing, ok := ownedSchedule(c, database)
+	if !ok {
+		return // Error response already sent
+	}
+
+	var req ScheduleRequest
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidRequestBody) {
+		return
+	}
+	schedule, err := req.toSchedule(cfg)
+	if err != nil {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgScheduleInvalid, err)
+		return
+	}
+
+	updated, err := database.UpdateSchedule(existing.ID, schedule)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgScheduleNotFound, existing.ID)
+		} else if errors.Is(err, apperr.ErrValidation) {
+			utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgScheduleInvalid, err)
+		} else {
+			utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgScheduleSaveFailed, err)
+		}
+		return
+	}
+	utils.RespondData(c, http.StatusOK, updated)
+}
+
+// DeleteScheduleHandler removes one of the authenticated user's scheduled exports.
+// @Summary      Delete a Scheduled Export
+// @Description  Removes a schedule along with its run history and any snapshots kept for download.
+// @Tags         Schedules
+// @Security     BearerAuth
+// @Param        id   path      string  true  "The unique identifier of the schedule."
+// @Success      204  "Schedule deleted."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: You have no schedule with the specified ID."
+// @Router       /schedules/{id} [delete]
+func DeleteScheduleHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	schedule, ok := ownedSchedule(c, database)
+	if !ok {
+		return // Error response already sent
+	}
+	if err := database.DeleteSchedule(schedule.ID); err != nil {
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgScheduleNotFound, schedule.ID)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RunScheduleHandler runs one of the authenticated user's scheduled exports right away.
+// @Summary      Run a Scheduled Export Now
+// @Description  Runs a schedule immediately, whether or not it is enabled, and records the run in its history. The regular timetable is not affected.
+// @Description  A run that fails (e.g. the webhook could not be reached) is still recorded and returned, with `status` set to `failed` and the reason in `error`.
+// @Tags         Schedules
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "The unique identifier of the schedule."
+// @Success      201  {object}  utils.Envelope{data=models.ScheduleRun} "The recorded run."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: You have no schedule with the specified ID."
+// @Router       /schedules/{id}/run [post]
+func RunScheduleHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	schedule, ok := ownedSchedule(c, database)
+	if !ok {
+		return // Error response already sent
+	}
+	run, err := database.RunSchedule(c.Request.Context(), schedule.ID, models.ScheduleTriggerManual)
+	if err != nil {
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgScheduleNotFound, schedule.ID)
+		return
+	}
+	utils.RespondData(c, http.StatusCreated, run)
+}
+
+// GetScheduleRunsHandler lists the run history of one of the authenticated user's scheduled exports.
+// @Summary      List the Runs of a Scheduled Export
+// @Description  Returns the latest runs of a schedule, newest first: when each started and finished, what triggered it, how many documents it exported, and whether it succeeded.
+// @Tags         Schedules
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "The unique identifier of the schedule."
+// @Success      200  {object}  utils.Envelope{data=[]models.ScheduleRun} "The run history."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: You have no schedule with the specified ID."
+// @Router       /schedules/{id}/runs [get]
+func GetScheduleRunsHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	schedule, ok := ownedSchedule(c, database)
+	if !ok {
+		return // Error response already sent
+	}
+	utils.RespondData(c, http.StatusOK, database.GetScheduleRuns(schedule.ID))
+}
+
+// DownloadScheduleRunHandler returns the snapshot kept by a run of a download schedule.
+// @Summary      Download a Scheduled Export
+// @Description  Returns the documents exported by a successful run of a `download` schedule, as they were when the run took place.
+// @Tags         Schedules
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id      path      string  true  "The unique identifier of the schedule."
+// @Param        run_id  path      string  true  "The unique identifier of the run."
+// @Success      200  {object}  utils.Envelope{data=db.ScheduleExport} "The exported documents."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: You have no schedule with the specified ID, or the run kept no snapshot."
+// @Router       /schedules/{id}/runs/{run_id}/download [get]
+func DownloadScheduleRunHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	schedule, ok := ownedSchedule(c, database)
+	if !ok {
+		return // Error response already sent
+	}
+	runID := c.Param("run_id")
+	export, err := database.GetScheduleExport(schedule.ID, runID)
+	if err != nil {
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgScheduleRunNotFound, runID)
+		return
+	}
+	utils.RespondData(c, http.StatusOK, export)
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"docserver/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduleEndpoints(t *testing.T) {
+	router, _, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	_, _, ownerToken := createTestUserAndLogin(t, router, "schedules.owner@example.com", "password123", "Sched", "Owner")
+	_, _, otherToken := createTestUserAndLogin(t, router, "schedules.other@example.com", "password123", "Sched", "Other")
+
+	for _, course := range []string{"CS101", "CS101", "CS102"} {
+		rr := performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, gin.H{"content": gin.H{"course": course}}), ownerToken)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	}
+
+	var schedule models.Schedule
+	t.Run("Create", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/schedules", marshalJSONBody(t, gin.H{
+			"name":          "nightly CS101",
+			"content_query": []string{`course equals "CS101"`},
+			"interval":      "24h",
+		}), ownerToken)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &schedule))
+		assert.Equal(t, "all", schedule.Scope)
+		assert.Equal(t, models.ScheduleTargetDownload, schedule.Target)
+		assert.True(t, schedule.Enabled)
+		assert.Equal(t, "24h0m0s", schedule.Interval)
+	})
+
+	t.Run("Invalid schedules are refused", func(t *testing.T) {
+		for name, body := range map[string]gin.H{
+			"query":    {"name": "x", "interval": "1h", "content_query": []string{"course sortof 1"}},
+			"scope":    {"name": "x", "interval": "1h", "scope": "public"},
+			"interval": {"name": "x", "interval": "10s"},
+			"target":   {"name": "x", "interval": "1h", "target": "email"},
+			"webhook":  {"name": "x", "interval": "1h", "target": "webhook", "webhook_url": "https://hooks.example.com/in"},
+		} {
+			rr := performRequest(router, http.MethodPost, "/schedules", marshalJSONBody(t, body), ownerToken)
+			assert.Equal(t, http.StatusBadRequest, rr.Code, name)
+		}
+
+		cfg.WebhookAllowedDomains = []string{"hooks.example.com"}
+		defer func() { cfg.WebhookAllowedDomains = nil }()
+		rr := performRequest(router, http.MethodPost, "/schedules", marshalJSONBody(t, gin.H{
+			"name": "x", "interval": "1h", "target": "webhook", "webhook_url": "https://evil.example.net/in",
+		}), ownerToken)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "is not allowed")
+	})
+
+	t.Run("List, get and other users", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/schedules", nil, ownerToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var schedules []models.Schedule
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &schedules))
+		require.Len(t, schedules, 1)
+
+		rr = performRequest(router, http.MethodGet, "/schedules/"+schedule.ID, nil, ownerToken)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		rr = performRequest(router, http.MethodGet, "/schedules", nil, otherToken)
+		assert.JSONEq(t, `[]`, rr.Body.String())
+		for _, path := range []string{"/schedules/" + schedule.ID, "/schedules/" + schedule.ID + "/runs"} {
+			rr = performRequest(router, http.MethodGet, path, nil, otherToken)
+			assert.Equal(t, http.StatusNotFound, rr.Code, path)
+		}
+		rr = performRequest(router, http.MethodPost, "/schedules/"+schedule.ID+"/run", nil, otherToken)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Run now and download", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/v1/schedules/"+schedule.ID+"/run", nil, ownerToken)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var runResp struct {
+			Data models.ScheduleRun `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &runResp))
+		run := runResp.Data
+		assert.Equal(t, models.ScheduleRunSucceeded, run.Status)
+		assert.Equal(t, models.ScheduleTriggerManual, run.Trigger)
+		assert.Equal(t, 2, run.DocumentCount)
+
+		rr = performRequest(router, http.MethodGet, "/schedules/"+schedule.ID+"/runs", nil, ownerToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var runs []models.ScheduleRun
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &runs))
+		require.Len(t, runs, 1)
+		assert.Nil(t, runs[0].Documents)
+
+		rr = performRequest(router, http.MethodGet, "/schedules/"+schedule.ID+"/runs/"+run.ID+"/download", nil, ownerToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var export struct {
+			RunID     string            `json:"run_id"`
+			Documents []models.Document `json:"documents"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &export))
+		assert.Equal(t, run.ID, export.RunID)
+		assert.Len(t, export.Documents, 2)
+
+		rr = performRequest(router, http.MethodGet, "/schedules/"+schedule.ID+"/runs/missing/download", nil, ownerToken)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Update and delete", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPut, "/schedules/"+schedule.ID, marshalJSONBody(t, gin.H{
+			"name": "paused", "interval": "1h", "scope": "owned", "enabled": false,
+		}), ownerToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var updated models.Schedule
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &updated))
+		assert.False(t, updated.Enabled)
+		assert.Empty(t, updated.ContentQuery)
+
+		rr = performRequest(router, http.MethodDelete, "/schedules/"+schedule.ID, nil, otherToken)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		rr = performRequest(router, http.MethodDelete, "/schedules/"+schedule.ID, nil, ownerToken)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		rr = performRequest(router, http.MethodGet, "/schedules/"+schedule.ID, nil, ownerToken)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
@@ -0,0 +1,207 @@
+package api
+
+import (
+	"docserver/apperr"
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/models"
+	"docserver/scripting"
+	"docserver/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Document Scripts (Admin) ---
+
+// ScriptRequest defines the body for creating or replacing a script.
+type ScriptRequest struct {
+	Name    string `json:"name" binding:"required"`
+	Event   string `json:"event" binding:"required"`  // "create", "update" or "delete"
+	Source  string `json:"source" binding:"required"` // Lua source code
+	Enabled *bool  `json:"enabled,omitempty"`         // Defaults to true
+}
+
+// toScript validates the request (event and Lua syntax) and converts it to a script.
+func (req ScriptRequest) toScript() (models.Script, error) {
+	switch req.Event {
+	case models.ScriptEventCreate, models.ScriptEventUpdate, models.ScriptEventDelete:
+	default:
+		return models.Script{}, fmt.Errorf("event must be one of '%s', '%s' or '%s'",
+			models.ScriptEventCreate, models.ScriptEventUpdate, models.ScriptEventDelete)
+	}
+	if err := scripting.Compile(req.Name, req.Source, scripting.Limits{}); err != nil {
+		return models.Script{}, err
+	}
+	return models.Script{
+		Name:    strings.TrimSpace(req.Name),
+		Event:   req.Event,
+		Source:  req.Source,
+		Enabled: req.Enabled == nil || *req.Enabled,
+	}, nil
+}
+
+// respondScriptError writes the response for a document operation stopped by a script and reports
+// whether err was such an error: 422 when a script rejected the change, 500 when a script failed.
+func respondScriptError(c *gin.Context, err error) bool {
+	var msgErr *i18n.Error
+	if !errors.As(err, &msgErr) {
+		return false
+	}
+	switch msgErr.ID {
+	case i18n.MsgScriptRejected:
+		utils.GinErrorFromErr(c, http.StatusUnprocessableEntity, err)
+	case i18n.MsgScriptFailed:
+		utils.GinErrorFromErr(c, http.StatusInternalServerError, err)
+	default:
+		return false
+	}
+	return true
+}
+
+// ListScriptsHandler lists all document scripts.
+// @Summary      List Document Scripts (Admin)
+// @Description  Lists the Lua scripts run on document events, in the order they run (oldest first). Administrators only.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  utils.Envelope{data=[]models.Script} "All scripts."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Router       /admin/scripts [get]
+func ListScriptsHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	utils.RespondData(c, http.StatusOK, database.ListScripts())
+}
+
+// CreateScriptHandler adds a document script.
+// @Summary      Add a Document Script (Admin)
+// @Description  Registers a Lua script that runs whenever a document is created, updated or deleted (`event`). Administrators only.
+// @Description
+// @Description  A script sees `event`, `doc.id`, `doc.owner_id`, `doc.content` and `previous` (the stored content on update and delete). It can:
+// @Description  *   **Validate / reject:** call `reject("reason")` to refuse the operation; the client gets `422 Unprocessable Entity` with the reason.
+// @Description  *   **Mutate:** change `doc.content` (create and update only); the changed content is what gets stored.
+// @Description
+// @Description  Scripts run in a sandbox (no file, OS or module access) with a time limit (`DOCSERVER_SCRIPT_TIMEOUT`) and caps on call depth and memory-heavy operations. A script that errors or exceeds its limits fails the operation with `500`.
+// @Description  Enabled scripts for an event run in order, oldest first, after any content transformations. The source is checked for syntax errors when saved.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        script body ScriptRequest true "The script."
+// @Success      201  {object}  utils.Envelope{data=models.Script} "Script added."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: A field is missing, the event is unknown, or the Lua source does not compile."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Failure      500  {object}  utils.ErrorEnvelope "Internal Server Error: The script could not be saved."
+// @Router       /admin/scripts [post]
+func CreateScriptHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+
+	var req ScriptRequest
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidRequestBody) {
+		return
+	}
+	script, err := req.toScript()
+	if err != nil {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgScriptInvalid, err)
+		return
+	}
+	script.CreatedBy = userID.(string)
+
+	created, err := database.CreateScript(script)
+	if err != nil {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgScriptSaveFailed, err)
+		return
+	}
+	utils.RespondData(c, http.StatusCreated, created)
+}
+
+// GetScriptHandler returns a document script.
+// @Summary      Get a Document Script (Admin)
+// @Description  Returns a script, including its source. Administrators only.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "The unique identifier of the script."
+// @Success      200  {object}  utils.Envelope{data=models.Script} "The script."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No script exists with the specified ID."
+// @Router       /admin/scripts/{id} [get]
+func GetScriptHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	scriptID := c.Param("id")
+	script, found := database.GetScript(scriptID)
+	if !found {
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgScriptNotFound, scriptID)
+		return
+	}
+	utils.RespondData(c, http.StatusOK, script)
+}
+
+// UpdateScriptHandler replaces a document script.
+// @Summary      Replace a Document Script (Admin)
+// @Description  Replaces a script's name, event, source and enabled flag. Send `"enabled": false` to switch a script off without deleting it. Administrators only.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id     path      string         true  "The unique identifier of the script."
+// @Param        script body      ScriptRequest  true  "The new script."
+// @Success      200  {object}  utils.Envelope{data=models.Script} "Script updated."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: A field is missing, the event is unknown, or the Lua source does not compile."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No script exists with the specified ID."
+// @Router       /admin/scripts/{id} [put]
+func UpdateScriptHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	scriptID := c.Param("id")
+
+	var req ScriptRequest
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidRequestBody) {
+		return
+	}
+	script, err := req.toScript()
+	if err != nil {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgScriptInvalid, err)
+		return
+	}
+
+	updated, err := database.UpdateScript(scriptID, script)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgScriptNotFound, scriptID)
+		} else {
+			utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgScriptSaveFailed, err)
+		}
+		return
+	}
+	utils.RespondData(c, http.StatusOK, updated)
+}
+
+// DeleteScriptHandler removes a document script.
+// @Summary      Delete a Document Script (Admin)
+// @Description  Removes a script; it stops running immediately. Administrators only.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Param        id   path      string  true  "The unique identifier of the script."
+// @Success      204  "Script deleted."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No script exists with the specified ID."
+// @Router       /admin/scripts/{id} [delete]
+func DeleteScriptHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	scriptID := c.Param("id")
+	if err := database.DeleteScript(scriptID); err != nil {
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgScriptNotFound, scriptID)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"docserver/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScriptEndpoints(t *testing.T) {
+	router, _, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	cfg.AdminEmails = []string{"scripts.admin@example.com"}
+
+	_, _, adminToken := createTestUserAndLogin(t, router, "scripts.admin@example.com", "password123", "Script", "Admin")
+	_, _, userToken := createTestUserAndLogin(t, router, "scripts.user@example.com", "password123", "Script", "User")
+
+	var script models.Script
+	t.Run("Create", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/admin/scripts", marshalJSONBody(t, gin.H{
+			"name":   "require-course",
+			"event":  "create",
+			"source": `if doc.content.course == nil then reject("course is required") end`,
+		}), adminToken)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &script))
+		assert.True(t, script.Enabled, "enabled by default")
+		assert.NotEmpty(t, script.CreatedBy)
+	})
+
+	t.Run("Invalid scripts are refused", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/admin/scripts", marshalJSONBody(t, gin.H{
+			"name": "bad", "event": "create", "source": "if then",
+		}), adminToken)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		rr = performRequest(router, http.MethodPost, "/admin/scripts", marshalJSONBody(t, gin.H{
+			"name": "bad", "event": "publish", "source": "-- ok",
+		}), adminToken)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "event must be one of")
+	})
+
+	t.Run("Rejection reaches the client as 422", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, gin.H{"content": gin.H{"title": "x"}}), userToken)
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		assert.Contains(t, rr.Body.String(), "course is required")
+
+		rr = performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, gin.H{"content": gin.H{"course": "CS101"}}), userToken)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		rr = performRequest(router, http.MethodPost, "/v1/documents", marshalJSONBody(t, gin.H{"content": "text"}), userToken)
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"message_id":"script_rejected"`)
+	})
+
+	t.Run("Get, list, disable and delete", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/admin/scripts/"+script.ID, nil, adminToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), "require-course")
+
+		rr = performRequest(router, http.MethodPut, "/admin/scripts/"+script.ID, marshalJSONBody(t, gin.H{
+			"name": "require-course", "event": "create", "source": script.Source, "enabled": false,
+		}), adminToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		rr = performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, gin.H{"content": "text"}), userToken)
+		assert.Equal(t, http.StatusCreated, rr.Code, "disabled scripts do not run")
+
+		rr = performRequest(router, http.MethodGet, "/admin/scripts", nil, adminToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var scripts []models.Script
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &scripts))
+		assert.Len(t, scripts, 1)
+
+		rr = performRequest(router, http.MethodDelete, "/admin/scripts/"+script.ID, nil, adminToken)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		rr = performRequest(router, http.MethodGet, "/admin/scripts/"+script.ID, nil, adminToken)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		rr = performRequest(router, http.MethodPut, "/admin/scripts/"+script.ID, marshalJSONBody(t, gin.H{
+			"name": "x", "event": "create", "source": "-- ok",
+		}), adminToken)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Non-admins cannot manage scripts", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/admin/scripts", marshalJSONBody(t, gin.H{
+			"name": "sneaky", "event": "create", "source": "-- ok",
+		}), userToken)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+}
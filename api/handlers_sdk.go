@@ -0,0 +1,65 @@
+package api
+
+import (
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/sdk"
+	"docserver/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Client SDKs ---
+
+// GetSDKHandler serves a client library for the API.
+// @Summary      Download a Client Library
+// @Description  Returns a ready-to-use client library for the API, generated from this documentation when the server was built. No login is needed.
+// @Description  It has a typed method per operation (e.g. `get_documents_by_id` in Python, `getDocumentsById` in TypeScript) and calls this server by default, under the current API version (and course, when downloaded from `/courses/{id}`).
+// @Description  *   `python`: `docserver_client.py`, for Python 3.8 or later with no packages to install.
+// @Description  *   `typescript`: `docserver_client.ts`, using `fetch` (browsers, Node.js 18 or later).
+// @Description
+// @Description  Methods return the `data` of the response (lists return the whole page, with `meta` and `links`) and raise or reject with a `DocServerError` carrying the error's `status`, `code` and `message`.
+// @Tags         Status
+// @Produce      plain
+// @Param        language  path      string  true  "The language of the client." Enums(python, typescript)
+// @Success      200  {string}  string  "The source code of the client."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: There is no client in that language."
+// @Router       /sdk/{language} [get]
+func GetSDKHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	name := c.Param("language")
+	language, found := sdk.LanguageByName(name)
+	if !found {
+		names := make([]string, len(sdk.Languages))
+		for i, language := range sdk.Languages {
+			names[i] = language.Name
+		}
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgSDKLanguageUnknown, name, strings.Join(names, ", "))
+		return
+	}
+
+	source, err := language.Source(clientBaseURL(c))
+	if err != nil {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgSDKFailed, err)
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="`+language.File+`"`)
+	c.Data(http.StatusOK, language.ContentType, source)
+}
+
+// clientBaseURL returns the URL clients and collections downloaded with this request call: this
+// server, under the current API version (the legacy paths answer without the envelope the clients
+// expect) and the request's course.
+func clientBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil || strings.EqualFold(c.GetHeader("X-Forwarded-Proto"), "https") {
+		scheme = "https"
+	}
+	prefix := ""
+	if course := requestCourse(c); course != "" {
+		prefix = "/courses/" + course
+	}
+	return scheme + "://" + c.Request.Host + prefix + "/" + CurrentAPIVersion
+}
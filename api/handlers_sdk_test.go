@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSDKHandler(t *testing.T) {
+	router, _, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	download := func(path string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Host = "docs.example.com:8080"
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Python", func(t *testing.T) {
+		rr := download("/sdk/python", nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Equal(t, "text/x-python; charset=utf-8", rr.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="docserver_client.py"`, rr.Header().Get("Content-Disposition"))
+		assert.Contains(t, rr.Body.String(), `BASE_URL = "http://docs.example.com:8080/v1"`, "calls the versioned API even from the legacy path")
+		assert.Contains(t, rr.Body.String(), `def get_documents_by_id(self, id: str, *, render: Optional[Literal["html"]] = None, include: Optional[str] = None) -> DocumentResponse:`)
+	})
+
+	t.Run("TypeScript behind a TLS proxy", func(t *testing.T) {
+		rr := download("/v1/sdk/typescript", http.Header{"X-Forwarded-Proto": {"https"}})
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Contains(t, rr.Body.String(), `export const BASE_URL = "https://docs.example.com:8080/v1";`)
+		assert.Contains(t, rr.Body.String(), `getDocumentsById(id: string, query: { render?: "html"; include?: string } = {}): Promise<DocumentResponse> {`)
+	})
+
+	t.Run("Unknown language", func(t *testing.T) {
+		rr := download("/v1/sdk/cobol", nil)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Contains(t, rr.Body.String(), "python, typescript")
+	})
+}
@@ -0,0 +1,246 @@
+package api
+
+import (
+	"docserver/apperr"
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/models"
+	"docserver/utils"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Service Accounts ---
+
+// defaultServiceTokenTTL is how long service account tokens last when the request sets no ttl.
+const defaultServiceTokenTTL = 30 * 24 * time.Hour
+
+// maxServiceTokenTTL is the longest a service account token may last.
+const maxServiceTokenTTL = 365 * 24 * time.Hour
+
+// ServiceAccountRequest defines the body for creating a service account.
+type ServiceAccountRequest struct {
+	Name   string   `json:"name" binding:"required"`   // e.g. "Grading Bot"; also gives the account's email, grading-bot@service.invalid
+	Scopes []string `json:"scopes" binding:"required"` // Scopes its tokens may carry, e.g. ["documents:read"]
+}
+
+// ServiceAccountScopesRequest defines the body for changing a service account's scopes.
+type ServiceAccountScopesRequest struct {
+	Scopes []string `json:"scopes" binding:"required"`
+}
+
+// ServiceTokenRequest defines the optional body for issuing a service account token.
+type ServiceTokenRequest struct {
+	Scopes []string `json:"scopes,omitempty"` // Some of the account's scopes; all of them if omitted
+	TTL    string   `json:"ttl,omitempty"`    // Go duration, e.g. "720h"; 30 days if omitted, at most a year
+}
+
+// ServiceAccountResponse describes a service account.
+type ServiceAccountResponse struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Email        string    `json:"email"`
+	Scopes       []string  `json:"scopes"`
+	CreatedBy    string    `json:"created_by"`
+	CreationDate time.Time `json:"creation_date"`
+}
+
+// ServiceTokenResponse holds a token issued to a service account.
+type ServiceTokenResponse struct {
+	Token     string    `json:"token"`
+	Scopes    []string  `json:"scopes"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// serviceAccountResponseFor describes a service account profile.
+func serviceAccountResponseFor(profile models.Profile) ServiceAccountResponse {
+	return ServiceAccountResponse{
+		ID:           profile.ID,
+		Name:         profile.FirstName,
+		Email:        profile.Email,
+		Scopes:       profile.Service.Scopes,
+		CreatedBy:    profile.Service.CreatedBy,
+		CreationDate: profile.CreationDate,
+	}
+}
+
+// respondServiceAccountError sends the response for an error of the service account methods.
+func respondServiceAccountError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, apperr.ErrNotFound):
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgServiceAccountNotFound, c.Param("id"))
+	case errors.Is(err, apperr.ErrValidation):
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgServiceAccountInvalid, err)
+	default:
+		utils.GinErrorFromErr(c, apperr.HTTPStatus(err), err)
+	}
+}
+
+// CreateServiceAccountHandler creates a service account.
+// @Summary      Create a Service Account (Admin)
+// @Description  Creates a non-human account for a bot or integration, e.g. one students build against the class server. It cannot log in; instead, issue it tokens with `POST /admin/service-accounts/{id}/tokens`. Each token is limited to some of the account's `scopes`:
+// @Description  *   `documents:read`: Read documents (`GET /documents...`, diffs).
+// @Description  *   `documents:write`: Also create, change and delete documents.
+// @Description  *   `profiles:read`: Read its profile and search profiles.
+// @Description  *   `profiles:write`: Also change its profile.
+// @Description
+// @Description  Other endpoints refuse scoped tokens with `403 Forbidden`. The account acts as its own user: it owns the documents it creates and reads those shared with it. Its email is derived from the `name` (`grading-bot@service.invalid` for "Grading Bot"). Administrators only.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        account  body      ServiceAccountRequest  true  "The account's name and scopes."
+// @Success      201  {object}  utils.Envelope{data=ServiceAccountResponse} "The new service account."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The name is empty or a scope is unknown."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Failure      409  {object}  utils.ErrorEnvelope "Conflict: A service account with the same email exists."
+// @Router       /admin/service-accounts [post]
+func CreateServiceAccountHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+
+	var req ServiceAccountRequest
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidRequestBody) {
+		return
+	}
+	profile, err := database.CreateServiceAccount(req.Name, req.Scopes, userID.(string))
+	if err != nil {
+		if errors.Is(err, apperr.ErrConflict) {
+			utils.GinLocalizedError(c, http.StatusConflict, i18n.MsgEmailAlreadyExists, db.ServiceAccountEmail(req.Name))
+			return
+		}
+		respondServiceAccountError(c, err)
+		return
+	}
+	utils.RespondData(c, http.StatusCreated, serviceAccountResponseFor(profile))
+}
+
+// ListServiceAccountsHandler lists the service accounts.
+// @Summary      List Service Accounts (Admin)
+// @Description  Lists the service accounts, oldest first. Administrators only.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  utils.Envelope{data=[]ServiceAccountResponse} "The service accounts."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Router       /admin/service-accounts [get]
+func ListServiceAccountsHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	accounts := database.GetServiceAccounts()
+	response := make([]ServiceAccountResponse, len(accounts))
+	for i, profile := range accounts {
+		response[i] = serviceAccountResponseFor(profile)
+	}
+	utils.RespondData(c, http.StatusOK, response)
+}
+
+// SetServiceAccountScopesHandler changes the scopes of a service account.
+// @Summary      Change a Service Account's Scopes (Admin)
+// @Description  Replaces the scopes a service account's tokens may carry. Tokens already issued lose the scopes it no longer has right away. Administrators only.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id      path      string                       true  "The service account's ID." example(usr_abc123xyz)
+// @Param        scopes  body      ServiceAccountScopesRequest  true  "The new scopes."
+// @Success      200  {object}  utils.Envelope{data=ServiceAccountResponse} "The updated service account."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: A scope is unknown, or none is given."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No service account exists with the specified ID."
+// @Router       /admin/service-accounts/{id} [put]
+func SetServiceAccountScopesHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	var req ServiceAccountScopesRequest
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidRequestBody) {
+		return
+	}
+	profile, err := database.SetServiceAccountScopes(c.Param("id"), req.Scopes)
+	if err != nil {
+		respondServiceAccountError(c, err)
+		return
+	}
+	utils.RespondData(c, http.StatusOK, serviceAccountResponseFor(profile))
+}
+
+// DeleteServiceAccountHandler deletes a service account.
+// @Summary      Delete a Service Account (Admin)
+// @Description  Deletes a service account. Its tokens stop working right away; the documents it owns are kept. Administrators only.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Param        id   path      string  true  "The service account's ID." example(usr_abc123xyz)
+// @Success      204  "Service account deleted."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No service account exists with the specified ID."
+// @Router       /admin/service-accounts/{id} [delete]
+func DeleteServiceAccountHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	if err := database.DeleteServiceAccount(c.Param("id")); err != nil {
+		respondServiceAccountError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// IssueServiceTokenHandler issues a token to a service account.
+// @Summary      Issue a Service Account Token (Admin)
+// @Description  Issues a token the service account authenticates with (`Authorization: Bearer <token>`). It carries the requested `scopes`, which must be among the account's (all of them by default), and lasts `ttl` (30 days by default, at most a year).
+// @Description  Tokens are not stored: keep the one returned. They stop working when they expire, when the account is deleted, and for the scopes the account loses. Administrators only.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id     path      string               true   "The service account's ID." example(usr_abc123xyz)
+// @Param        token  body      ServiceTokenRequest  false  "The token's scopes and lifetime."
+// @Success      201  {object}  utils.Envelope{data=ServiceTokenResponse} "The token."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: A scope is not one of the account's, or the ttl is invalid."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No service account exists with the specified ID."
+// @Router       /admin/service-accounts/{id}/tokens [post]
+func IssueServiceTokenHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	var req ServiceTokenRequest
+	if c.Request.ContentLength != 0 && !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidRequestBody) {
+		return
+	}
+	profile, err := database.GetServiceAccount(c.Param("id"))
+	if err != nil {
+		respondServiceAccountError(c, err)
+		return
+	}
+
+	scopes := profile.Service.Scopes
+	if len(req.Scopes) > 0 {
+		if scopes, err = utils.NormalizeScopes(req.Scopes); err != nil {
+			utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgServiceAccountInvalid, err)
+			return
+		}
+		if within := utils.ScopesWithin(scopes, profile.Service.Scopes); len(within) != len(scopes) {
+			utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgServiceTokenScopes, strings.Join(profile.Service.Scopes, ", "))
+			return
+		}
+	}
+	ttl := defaultServiceTokenTTL
+	if req.TTL != "" {
+		ttl, err = time.ParseDuration(req.TTL)
+		if err != nil || ttl <= 0 || ttl > maxServiceTokenTTL {
+			utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgServiceTokenTTL, req.TTL, maxServiceTokenTTL)
+			return
+		}
+	}
+
+	token, err := utils.GenerateScopedJWT(&profile, scopes, ttl, cfg)
+	if err != nil {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgTokenGenerateFailed)
+		return
+	}
+	utils.RespondData(c, http.StatusCreated, ServiceTokenResponse{Token: token, Scopes: scopes, ExpiresAt: cfg.Now().Add(ttl).UTC().Truncate(time.Second)})
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceAccounts(t *testing.T) {
+	router, _, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	cfg.AdminEmails = []string{"service.admin@example.com"}
+
+	_, _, adminToken := createTestUserAndLogin(t, router, "service.admin@example.com", "password123", "Service", "Admin")
+	_, _, studentToken := createTestUserAndLogin(t, router, "service.student@example.com", "password123", "Service", "Student")
+
+	rr := performRequest(router, http.MethodPost, "/admin/service-accounts", marshalJSONBody(t, gin.H{"name": "Grading Bot", "scopes": []string{"documents:read"}}), studentToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = performRequest(router, http.MethodPost, "/admin/service-accounts", marshalJSONBody(t, gin.H{"name": "Grading Bot", "scopes": []string{"grades:read"}}), adminToken)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = performRequest(router, http.MethodPost, "/admin/service-accounts", marshalJSONBody(t, gin.H{"name": "Grading Bot", "scopes": []string{"documents:write", "profiles:read"}}), adminToken)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var bot ServiceAccountResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &bot))
+	assert.Equal(t, "grading-bot@service.invalid", bot.Email)
+	assert.Equal(t, []string{"documents:write", "profiles:read"}, bot.Scopes)
+
+	rr = performRequest(router, http.MethodPost, "/admin/service-accounts", marshalJSONBody(t, gin.H{"name": "grading bot", "scopes": []string{"documents:read"}}), adminToken)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	rr = performRequest(router, http.MethodGet, "/admin/service-accounts", nil, adminToken)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var accounts []ServiceAccountResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &accounts))
+	require.Len(t, accounts, 1)
+
+	issue := func(t *testing.T, body any) *ServiceTokenResponse {
+		t.Helper()
+		rr := performRequest(router, http.MethodPost, "/admin/service-accounts/"+bot.ID+"/tokens", marshalJSONBody(t, body), adminToken)
+		if rr.Code != http.StatusCreated {
+			return nil
+		}
+		var token ServiceTokenResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &token))
+		return &token
+	}
+
+	t.Run("Issuing tokens", func(t *testing.T) {
+		assert.Nil(t, issue(t, gin.H{"scopes": []string{"profiles:write"}}), "only the account's scopes")
+		assert.Nil(t, issue(t, gin.H{"ttl": "9000h"}))
+		assert.Nil(t, issue(t, gin.H{"ttl": "soon"}))
+
+		rr := performRequest(router, http.MethodPost, "/admin/service-accounts/"+bot.ID+"/tokens", nil, adminToken)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var token ServiceTokenResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &token))
+		assert.Equal(t, bot.Scopes, token.Scopes, "all of the account's scopes by default")
+
+		rr = performRequest(router, http.MethodPost, "/admin/service-accounts/usr_missing/tokens", nil, adminToken)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Scopes are enforced", func(t *testing.T) {
+		readToken := issue(t, gin.H{"scopes": []string{"documents:read"}})
+		require.NotNil(t, readToken)
+		writeToken := issue(t, gin.H{"scopes": []string{"documents:write"}})
+		require.NotNil(t, writeToken)
+
+		rr := performRequest(router, http.MethodGet, "/documents", nil, readToken.Token)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		rr = performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, gin.H{"content": gin.H{"a": 1}}), readToken.Token)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		rr = performRequest(router, http.MethodGet, "/profiles/me", nil, readToken.Token)
+		assert.Equal(t, http.StatusForbidden, rr.Code, "profiles:read was not requested")
+
+		rr = performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, gin.H{"content": gin.H{"a": 1}}), writeToken.Token)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var doc DocumentResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+		assert.Equal(t, bot.ID, doc.OwnerID, "the account acts as its own user")
+		rr = performRequest(router, http.MethodGet, "/documents/"+doc.ID, nil, writeToken.Token)
+		assert.Equal(t, http.StatusOK, rr.Code, "write implies read")
+
+		rr = performRequest(router, http.MethodGet, "/reviews", nil, writeToken.Token)
+		assert.Equal(t, http.StatusForbidden, rr.Code, "routes without a scope refuse scoped tokens")
+		rr = performRequest(router, http.MethodPost, "/auth/login", marshalJSONBody(t, gin.H{"email": bot.Email, "password": ""}), "")
+		assert.NotEqual(t, http.StatusOK, rr.Code, "service accounts cannot log in")
+	})
+
+	t.Run("Narrowing and deleting", func(t *testing.T) {
+		token := issue(t, nil)
+		require.NotNil(t, token)
+
+		rr := performRequest(router, http.MethodPut, "/admin/service-accounts/"+bot.ID, marshalJSONBody(t, gin.H{"scopes": []string{"profiles:read"}}), adminToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		rr = performRequest(router, http.MethodGet, "/documents", nil, token.Token)
+		assert.Equal(t, http.StatusForbidden, rr.Code, "tokens lose the scopes the account lost")
+		rr = performRequest(router, http.MethodGet, "/profiles/me", nil, token.Token)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		rr = performRequest(router, http.MethodDelete, "/admin/service-accounts/"+bot.ID, nil, adminToken)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		rr = performRequest(router, http.MethodGet, "/profiles/me", nil, token.Token)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		rr = performRequest(router, http.MethodDelete, "/admin/service-accounts/"+bot.ID, nil, adminToken)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"docserver/apperr"
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/models"
+	"docserver/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Share Scopes ---
+
+// documentScope returns the scope limiting what viewerID sees of doc, and false if they may see all of it.
+func documentScope(database documentReader, doc models.Document, viewerID string) (models.ShareScope, bool) {
+	record, _ := database.GetShareRecordByDocumentID(doc.ID)
+	return db.ShareScopeOf(doc, record, viewerID)
+}
+
+// ShareScopeRequest defines the body for limiting a share to part of a document.
+type ShareScopeRequest struct {
+	Path  string `json:"path" binding:"required"` // Dot-separated object keys within the content, e.g. "public"
+	Write bool   `json:"write"`                   // Let the sharer replace that part
+}
+
+// ShareScopeResponse is the part of a document a profile can now access.
+type ShareScopeResponse struct {
+	ProfileID string `json:"profile_id"`
+	models.ShareScope
+}
+
+// SetShareScopeHandler limits the share of a document with one user to part of its content.
+// @Summary      Share Only Part of a Document
+// @Description  Limits what a user the document is shared with can see to the part of its content at `path`, given as dot-separated object keys (e.g. `public` for `content.public`, or `chapters.intro`). They then get the document with only that part of the content, nested under the same keys (an empty object if there is nothing there), in `GET /documents/{id}`, document lists and diffs, and content queries only match that part.
+// @Description
+// @Description  With `"write": true` they may also change that part with `PUT /documents/{id}`: the value at `path` in the `content` they send replaces the one in the document (or removes it if they send none), and the rest of the document stays as it is. They cannot change `public` or `content_type`.
+// @Description
+// @Description  The document must already be shared with the user. Setting a scope again replaces it; `DELETE /documents/{id}/shares/{profile_id}/scope` gives them read access to the whole document again. Public documents can be read in full by everyone regardless. Only the document owner can set scopes.
+// @Tags         Sharing
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id          path      string             true  "The unique identifier of the document." example(doc_abc123xyz)
+// @Param        profile_id  path      string             true  "The unique identifier of the user the document is shared with." example(user_123)
+// @Param        scope       body      ShareScopeRequest  true  "The part of the content they can access."
+// @Success      200  {object}  utils.Envelope{data=ShareScopeResponse} "The share is now limited to that part."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: 'path' is missing or is not dot-separated object keys."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not the owner of this document, so you cannot modify its shares."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No document exists with the specified ID, or it is not shared with the user."
+// @Failure      423  {object}  utils.ErrorEnvelope "Locked: The document is frozen."
+// @Router       /documents/{id}/shares/{profile_id}/scope [put]
+func SetShareScopeHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	docID := c.Param("id")
+	if _, ok := checkDocumentOwner(c, database, cfg, docID); !ok {
+		return // Error response already sent by helper
+	}
+
+	var req ShareScopeRequest
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidRequestBody) {
+		return
+	}
+
+	profileID := c.Param("profile_id")
+	record, err := database.SetShareScope(docID, profileID, &models.ShareScope{Path: req.Path, Write: req.Write})
+	if err != nil {
+		if respondFrozen(c, err) {
+			return
+		}
+		utils.GinErrorFromErr(c, apperr.HTTPStatus(err), err)
+		return
+	}
+	utils.RespondData(c, http.StatusOK, ShareScopeResponse{ProfileID: profileID, ShareScope: record.Scopes[profileID]})
+}
+
+// ClearShareScopeHandler lets a user read the whole document again.
+// @Summary      Share the Whole Document Again
+// @Description  Removes the limit set with `PUT /documents/{id}/shares/{profile_id}/scope`, so the user can read all of the document's content again (but not change it). Removing a limit that is not set changes nothing. Only the document owner can do this.
+// @Tags         Sharing
+// @Security     BearerAuth
+// @Param        id          path  string  true  "The unique identifier of the document." example(doc_abc123xyz)
+// @Param        profile_id  path  string  true  "The unique identifier of the user the document is shared with." example(user_123)
+// @Success      204  "The user can read the whole document. No content is returned."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not the owner of this document, so you cannot modify its shares."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No document exists with the specified ID, or it is not shared with the user."
+// @Failure      423  {object}  utils.ErrorEnvelope "Locked: The document is frozen."
+// @Router       /documents/{id}/shares/{profile_id}/scope [delete]
+func ClearShareScopeHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	docID := c.Param("id")
+	if _, ok := checkDocumentOwner(c, database, cfg, docID); !ok {
+		return // Error response already sent by helper
+	}
+
+	if _, err := database.SetShareScope(docID, c.Param("profile_id"), nil); err != nil {
+		if respondFrozen(c, err) {
+			return
+		}
+		utils.GinErrorFromErr(c, apperr.HTTPStatus(err), err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
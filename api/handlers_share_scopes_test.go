@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShareScopes(t *testing.T) {
+	router, _, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	_, _, ownerToken := createTestUserAndLogin(t, router, "scope.owner@example.com", "password123", "Scope", "Owner")
+	readerID, _, readerToken := createTestUserAndLogin(t, router, "scope.reader@example.com", "password123", "Scope", "Reader")
+	writerID, _, writerToken := createTestUserAndLogin(t, router, "scope.writer@example.com", "password123", "Scope", "Writer")
+
+	content := map[string]any{"public": map[string]any{"title": "Lab 1"}, "private": map[string]any{"grade": 17}}
+	rr := performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, map[string]any{"content": content}), ownerToken)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	var doc DocumentResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+	docPath := "/documents/" + doc.ID
+	rr = performRequest(router, http.MethodPut, docPath+"/shares", marshalJSONBody(t, map[string]any{"shared_with": []string{readerID, writerID}}), ownerToken)
+	require.Equal(t, http.StatusNoContent, rr.Code)
+
+	t.Run("Only the owner sets scopes of existing shares", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPut, docPath+"/shares/"+readerID+"/scope", marshalJSONBody(t, map[string]any{"path": "public"}), readerToken)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		rr = performRequest(router, http.MethodPut, docPath+"/shares/someone_else/scope", marshalJSONBody(t, map[string]any{"path": "public"}), ownerToken)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		rr = performRequest(router, http.MethodPut, docPath+"/shares/"+readerID+"/scope", marshalJSONBody(t, map[string]any{"path": "public."}), ownerToken)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = performRequest(router, http.MethodPut, docPath+"/shares/"+readerID+"/scope", marshalJSONBody(t, map[string]any{"path": "public"}), ownerToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		rr = performRequest(router, http.MethodPut, docPath+"/shares/"+writerID+"/scope", marshalJSONBody(t, map[string]any{"path": "public", "write": true}), ownerToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"write":true`)
+
+		rr = performRequest(router, http.MethodGet, docPath+"/shares", nil, ownerToken)
+		var shares GetSharersResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &shares))
+		assert.Equal(t, "public", shares.Scopes[readerID].Path)
+		assert.True(t, shares.Scopes[writerID].Write)
+	})
+
+	t.Run("Scoped sharers read only their part", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, docPath, nil, readerToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), "Lab 1")
+		assert.NotContains(t, rr.Body.String(), "grade")
+
+		rr = performRequest(router, http.MethodGet, "/documents?scope=shared", nil, readerToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), doc.ID)
+		assert.NotContains(t, rr.Body.String(), "grade")
+
+		rr = performRequest(router, http.MethodGet, docPath, nil, ownerToken)
+		assert.Contains(t, rr.Body.String(), "grade")
+	})
+
+	t.Run("Writers update only their part", func(t *testing.T) {
+		body := map[string]any{"content": map[string]any{"public": map[string]any{"title": "Lab 1 (revised)"}, "private": map[string]any{"grade": 20}}}
+		rr := performRequest(router, http.MethodPut, docPath, marshalJSONBody(t, body), readerToken)
+		assert.Equal(t, http.StatusForbidden, rr.Code, "read-only scope")
+		rr = performRequest(router, http.MethodPut, docPath, marshalJSONBody(t, map[string]any{"content": body["content"], "public": true}), writerToken)
+		assert.Equal(t, http.StatusForbidden, rr.Code, "only the owner publishes")
+
+		rr = performRequest(router, http.MethodPut, docPath, marshalJSONBody(t, body), writerToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Contains(t, rr.Body.String(), "Lab 1 (revised)")
+		assert.NotContains(t, rr.Body.String(), "grade")
+
+		rr = performRequest(router, http.MethodGet, docPath, nil, ownerToken)
+		assert.Contains(t, rr.Body.String(), "Lab 1 (revised)")
+		assert.Contains(t, rr.Body.String(), `"grade":17`, "the rest is kept")
+
+		rr = performRequest(router, http.MethodGet, docPath+"/diff", nil, readerToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Contains(t, rr.Body.String(), "public.title")
+		assert.NotContains(t, rr.Body.String(), "grade")
+	})
+
+	t.Run("Clearing the scope shares the whole document", func(t *testing.T) {
+		rr := performRequest(router, http.MethodDelete, docPath+"/shares/"+readerID+"/scope", nil, ownerToken)
+		require.Equal(t, http.StatusNoContent, rr.Code)
+		rr = performRequest(router, http.MethodGet, docPath, nil, readerToken)
+		assert.Contains(t, rr.Body.String(), "grade")
+	})
+}
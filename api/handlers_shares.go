@@ -1,11 +1,12 @@
 package api
 
 import (
+	"docserver/authz"
 	"docserver/config"
 	"docserver/db"
-	// "docserver/models" // Removed unused import
+	"docserver/i18n"
+	"docserver/models"
 	"docserver/utils"
-	"fmt"
 	"net/http"
 	"strings"
 
@@ -13,22 +14,22 @@ import (
 )
 
 // Helper function to check document ownership for share operations
-func checkDocumentOwner(c *gin.Context, database *db.Database, docID string) (string, bool) {
+func checkDocumentOwner(c *gin.Context, database *db.Database, cfg *config.Config, docID string) (string, bool) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		utils.GinInternalServerError(c, "User ID not found in context.")
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
 		return "", false
 	}
 	userIDStr := userID.(string)
 
 	doc, found := database.GetDocumentByID(docID)
 	if !found {
-		utils.GinNotFound(c, fmt.Sprintf("Document with ID '%s' not found.", docID))
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgDocumentNotFound, docID)
 		return "", false
 	}
 
-	if doc.OwnerID != userIDStr {
-		utils.GinForbidden(c, "Only the document owner can manage shares.")
+	if !can(c, database, cfg, authz.ShareDocument, doc) {
+		utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgShareOwnerOnly)
 		return "", false
 	}
 
@@ -39,7 +40,9 @@ func checkDocumentOwner(c *gin.Context, database *db.Database, docID string) (st
 
 // GetSharersResponse defines the structure for the response.
 type GetSharersResponse struct {
-	SharedWith []string `json:"shared_with"` // List of Profile IDs (dashless)
+	SharedWith    []string                     `json:"shared_with"`              // List of Profile IDs (dashless)
+	PendingEmails []string                     `json:"pending_emails,omitempty"` // Emails shared with before anyone signed up with them
+	Scopes        map[string]models.ShareScope `json:"scopes,omitempty"`         // Profiles limited to part of the content, by profile ID
 }
 
 // GetSharersHandler retrieves the list of profile IDs a document is shared with.
@@ -49,38 +52,42 @@ type GetSharersResponse struct {
 // @Description  Only the user who originally created (owns) the document can use this endpoint to see who they've shared it with.
 // @Description  Provide the document's `id` in the URL path. Authentication via access token is required.
 // @Description  If the document hasn't been shared with anyone, it returns an empty list.
+// @Description  Emails the document was shared with before anyone signed up with them are listed in `pending_emails`.
+// @Description  Users limited to part of the content (see `PUT /documents/{id}/shares/{profile_id}/scope`) are listed in `scopes`.
 // @Tags         Sharing
 // @Produce      json
 // @Security     BearerAuth
 // @Param        id   path      string  true  "The unique identifier of the document whose share list you want to view." example(doc_abc123xyz)
-// @Success      200  {object}  GetSharersResponse "Successfully retrieved the list of profile IDs the document is shared with. The 'shared_with' array contains the IDs."
-// @Failure      400  {object}  utils.APIError "Bad Request: The document ID provided in the URL path is missing or invalid."
-// @Failure      401  {object}  utils.APIError "Unauthorized: Your access token is missing, invalid, or expired."
-// @Failure      403  {object}  utils.APIError "Forbidden: You are not the owner of this document, so you cannot view its share list."
-// @Failure      404  {object}  utils.APIError "Not Found: No document exists with the specified ID."
-// @Failure      500  {object}  utils.APIError "Internal Server Error: Something went wrong on the server while retrieving the share list."
+// @Success      200  {object}  utils.Envelope{data=GetSharersResponse} "Successfully retrieved the list of profile IDs the document is shared with. The 'shared_with' array contains the IDs."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The document ID provided in the URL path is missing or invalid."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not the owner of this document, so you cannot view its share list."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No document exists with the specified ID."
+// @Failure      500  {object}  utils.ErrorEnvelope "Internal Server Error: Something went wrong on the server while retrieving the share list."
 // @Router       /documents/{id}/shares [get]
 func GetSharersHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
 	docID := c.Param("id")
 	if docID == "" {
-		utils.GinBadRequest(c, "Document ID is required in the path.")
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgDocumentIDRequired)
 		return
 	}
 
 	// Check ownership
-	if _, ok := checkDocumentOwner(c, database, docID); !ok {
+	if _, ok := checkDocumentOwner(c, database, cfg, docID); !ok {
 		return // Error response already sent by helper
 	}
 
+	pendingEmails := database.PendingShareEmails(docID)
+
 	// Get the share record
 	shareRecord, found := database.GetShareRecordByDocumentID(docID)
 	if !found {
 		// No shares exist, return empty list
-		c.JSON(http.StatusOK, GetSharersResponse{SharedWith: []string{}})
+		utils.RespondData(c, http.StatusOK, GetSharersResponse{SharedWith: []string{}, PendingEmails: pendingEmails})
 		return
 	}
 
-	c.JSON(http.StatusOK, GetSharersResponse{SharedWith: shareRecord.SharedWith})
+	utils.RespondData(c, http.StatusOK, GetSharersResponse{SharedWith: shareRecord.SharedWith, PendingEmails: pendingEmails, Scopes: shareRecord.Scopes})
 }
 
 // --- Set/Update Sharers ---
@@ -116,32 +123,31 @@ type SetSharersRequest struct {
 // @Param        id           path      string            true  "The unique identifier of the document whose share list you want to set/replace." example(doc_abc123xyz)
 // @Param        shareRequest body      SetSharersRequest true  "A JSON object containing the 'shared_with' key, whose value is an array of profile IDs."
 // @Success      204          "Share List Updated Successfully. No content is returned in the response body."
-// @Failure      400          {object}  utils.APIError "Bad Request: The request body is invalid (e.g., missing 'shared_with' array, invalid JSON) OR you tried to include the owner's ID in the 'shared_with' list."
-// @Failure      401          {object}  utils.APIError "Unauthorized: Your access token is missing, invalid, or expired."
-// @Failure      403          {object}  utils.APIError "Forbidden: You are not the owner of this document, so you cannot modify its share list."
-// @Failure      404          {object}  utils.APIError "Not Found: No document exists with the specified ID."
-// @Failure      500          {object}  utils.APIError "Internal Server Error: Something went wrong on the server while updating the share list."
+// @Failure      400          {object}  utils.ErrorEnvelope "Bad Request: The request body is invalid (e.g., missing 'shared_with' array, invalid JSON) OR you tried to include the owner's ID in the 'shared_with' list."
+// @Failure      401          {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403          {object}  utils.ErrorEnvelope "Forbidden: You are not the owner of this document, so you cannot modify its share list."
+// @Failure      404          {object}  utils.ErrorEnvelope "Not Found: No document exists with the specified ID."
+// @Failure      423          {object}  utils.ErrorEnvelope "Locked: The document is frozen."
+// @Failure      500          {object}  utils.ErrorEnvelope "Internal Server Error: Something went wrong on the server while updating the share list."
 // @Router       /documents/{id}/shares [put]
 func SetSharersHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
 	docID := c.Param("id")
 	// If docID is empty, the route itself wasn't matched correctly by Gin
 	// (e.g., /documents//shares), so NotFound is more appropriate than BadRequest.
 	if docID == "" {
-		utils.GinNotFound(c, "Document ID is required in the path.")
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgDocumentIDRequired)
 		return
 	}
 
 	// Check ownership
-	ownerID, ok := checkDocumentOwner(c, database, docID)
+	ownerID, ok := checkDocumentOwner(c, database, cfg, docID)
 	if !ok {
 		return // Error response already sent by helper
 	}
 
 	// Bind request body
 	var req SetSharersRequest
-	// Use BindJSON, ShouldBindJSON might consume body if we add validation later
-	if err := c.BindJSON(&req); err != nil {
-		utils.GinBadRequest(c, fmt.Sprintf("Invalid request body: %v. 'shared_with' array is required.", err))
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidSharesBody) {
 		return
 	}
 
@@ -153,7 +159,7 @@ func SetSharersHandler(c *gin.Context, database *db.Database, cfg *config.Config
 			continue // Skip empty IDs
 		}
 		if profileID == ownerID {
-			utils.GinBadRequest(c, "Cannot share document with the owner.")
+			utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgShareWithOwner)
 			return
 		}
 		// Check if profile exists (optional, can be slow)
@@ -169,8 +175,11 @@ func SetSharersHandler(c *gin.Context, database *db.Database, cfg *config.Config
 	// Update the share record in the database
 	err := database.SetShareRecord(docID, validSharers) // Pass validated list
 	if err != nil {
+		if respondFrozen(c, err) {
+			return
+		}
 		// SetShareRecord currently doesn't return errors unless DB save fails unexpectedly
-		utils.GinInternalServerError(c, fmt.Sprintf("Failed to update shares: %v", err))
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgSharesUpdateFailed, err)
 		return
 	}
 
@@ -193,11 +202,12 @@ func SetSharersHandler(c *gin.Context, database *db.Database, cfg *config.Config
 // @Param        id         path      string  true  "The unique identifier of the document you want to share." example(doc_abc123xyz)
 // @Param        profile_id path      string  true  "The unique identifier of the user profile you want to grant access to." example(user_123)
 // @Success      204        "User Added to Share List Successfully (or was already shared with). No content is returned."
-// @Failure      400        {object}  utils.APIError "Bad Request: You tried to share the document with its owner (yourself)."
-// @Failure      401        {object}  utils.APIError "Unauthorized: Your access token is missing, invalid, or expired."
-// @Failure      403        {object}  utils.APIError "Forbidden: You are not the owner of this document, so you cannot share it."
-// @Failure      404        {object}  utils.APIError "Not Found: The specified Document ID or Profile ID does not exist, or the IDs were missing from the URL path."
-// @Failure      500        {object}  utils.APIError "Internal Server Error: Something went wrong on the server while adding the user to the share list."
+// @Failure      400        {object}  utils.ErrorEnvelope "Bad Request: You tried to share the document with its owner (yourself)."
+// @Failure      401        {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403        {object}  utils.ErrorEnvelope "Forbidden: You are not the owner of this document, so you cannot share it."
+// @Failure      404        {object}  utils.ErrorEnvelope "Not Found: The specified Document ID or Profile ID does not exist, or the IDs were missing from the URL path."
+// @Failure      423        {object}  utils.ErrorEnvelope "Locked: The document is frozen."
+// @Failure      500        {object}  utils.ErrorEnvelope "Internal Server Error: Something went wrong on the server while adding the user to the share list."
 // @Router       /documents/{id}/shares/{profile_id} [put]
 func AddSharerHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
 	docID := c.Param("id")
@@ -205,19 +215,19 @@ func AddSharerHandler(c *gin.Context, database *db.Database, cfg *config.Config)
 
 	// If either ID is empty, the route is effectively not found.
 	if docID == "" || profileID == "" {
-		utils.GinNotFound(c, "Document ID and Profile ID are required in the path.")
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgShareIDsRequired)
 		return
 	}
 
 	// Check ownership
-	ownerID, ok := checkDocumentOwner(c, database, docID)
+	ownerID, ok := checkDocumentOwner(c, database, cfg, docID)
 	if !ok {
 		return // Error response already sent by helper
 	}
 
 	// Prevent sharing with self
 	if profileID == ownerID {
-		utils.GinBadRequest(c, "Cannot share document with the owner.")
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgShareWithOwner)
 		return
 	}
 
@@ -231,7 +241,10 @@ func AddSharerHandler(c *gin.Context, database *db.Database, cfg *config.Config)
 	// Add sharer in the database
 	err := database.AddSharerToDocument(docID, profileID)
 	if err != nil {
-		utils.GinInternalServerError(c, fmt.Sprintf("Failed to add sharer: %v", err))
+		if respondFrozen(c, err) {
+			return
+		}
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgSharerAddFailed, err)
 		return
 	}
 
@@ -254,10 +267,11 @@ func AddSharerHandler(c *gin.Context, database *db.Database, cfg *config.Config)
 // @Param        id         path      string  true  "The unique identifier of the document you want to modify shares for." example(doc_abc123xyz)
 // @Param        profile_id path      string  true  "The unique identifier of the user profile whose access you want to revoke." example(user_123)
 // @Success      204        "User Removed from Share List Successfully (or was not shared with). No content is returned."
-// @Failure      401        {object}  utils.APIError "Unauthorized: Your access token is missing, invalid, or expired."
-// @Failure      403        {object}  utils.APIError "Forbidden: You are not the owner of this document, so you cannot modify its shares."
-// @Failure      404        {object}  utils.APIError "Not Found: The specified Document ID or Profile ID does not exist, or the IDs were missing from the URL path."
-// @Failure      500        {object}  utils.APIError "Internal Server Error: Something went wrong on the server while removing the user from the share list."
+// @Failure      401        {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403        {object}  utils.ErrorEnvelope "Forbidden: You are not the owner of this document, so you cannot modify its shares."
+// @Failure      404        {object}  utils.ErrorEnvelope "Not Found: The specified Document ID or Profile ID does not exist, or the IDs were missing from the URL path."
+// @Failure      423        {object}  utils.ErrorEnvelope "Locked: The document is frozen."
+// @Failure      500        {object}  utils.ErrorEnvelope "Internal Server Error: Something went wrong on the server while removing the user from the share list."
 // @Router       /documents/{id}/shares/{profile_id} [delete]
 func RemoveSharerHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
 	docID := c.Param("id")
@@ -265,19 +279,22 @@ func RemoveSharerHandler(c *gin.Context, database *db.Database, cfg *config.Conf
 
 	// If either ID is empty, the route is effectively not found.
 	if docID == "" || profileID == "" {
-		utils.GinNotFound(c, "Document ID and Profile ID are required in the path.")
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgShareIDsRequired)
 		return
 	}
 
 	// Check ownership
-	if _, ok := checkDocumentOwner(c, database, docID); !ok {
+	if _, ok := checkDocumentOwner(c, database, cfg, docID); !ok {
 		return // Error response already sent by helper
 	}
 
 	// Remove sharer in the database
 	err := database.RemoveSharerFromDocument(docID, profileID)
 	if err != nil {
-		utils.GinInternalServerError(c, fmt.Sprintf("Failed to remove sharer: %v", err))
+		if respondFrozen(c, err) {
+			return
+		}
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgSharerRemoveFailed, err)
 		return
 	}
 
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"docserver/apperr"
+	"docserver/authz"
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/utils"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Signed URLs ---
+
+const defaultSignedURLTTL = 10 * time.Minute // Lifetime of signed URLs when no ttl is given
+const maxSignedURLTTL = 24 * time.Hour       // Longest lifetime a signed URL may have
+
+// SignedURLResponse is a newly created signed URL.
+type SignedURLResponse struct {
+	URL       string    `json:"url"`   // Path of the signed URL on this server, e.g. /v1/signed/{token}
+	Token     string    `json:"token"` // The token in the URL
+	Op        string    `json:"op"`    // "read" or "write"
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CreateSignedURLHandler creates a one-time URL for reading or writing a document.
+// @Summary      Create a Signed URL
+// @Description  Creates a short-lived URL that performs one operation on the document without an `Authorization` header, e.g. to hand to a browser, a script or another service. It is signed with the server's key and acts on your behalf:
+// @Description
+// @Description  - `op=read`: `GET` the URL to get the document, as with `GET /documents/{id}`. You must be able to read the document.
+// @Description  - `op=write`: `PUT` the URL with the same body as `PUT /documents/{id}` to update the document. You must be able to update it, or the part of it shared with you with write access.
+// @Description
+// @Description  Each signed URL works once: the first request uses it up, even if it fails afterwards, and later ones get `410 Gone`. It stops working once `ttl` has passed, if you change your password, or if your account is deactivated. `url` is a path on this server (including `/courses/{id}` for course databases).
+// @Tags         Documents
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true   "The unique identifier of the document." example(doc_abc123xyz)
+// @Param        op   query     string  true   "What the URL allows." Enums(read, write)
+// @Param        ttl  query     string  false  "How long the URL stays valid, as a duration up to 24h. Defaults to 10m." example(10m)
+// @Success      201  {object}  utils.Envelope{data=SignedURLResponse} "The signed URL."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: 'op' or 'ttl' is missing or invalid."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You cannot perform the operation on this document."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No document exists with the specified ID."
+// @Router       /documents/{id}/signed-url [post]
+func CreateSignedURLHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+	userIDStr := userID.(string)
+
+	op := c.Query("op")
+	if op != utils.SignedURLRead && op != utils.SignedURLWrite {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgSignedURLInvalidOp, op)
+		return
+	}
+	ttl := defaultSignedURLTTL
+	if raw := strings.TrimSpace(c.Query("ttl")); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed < time.Second || parsed > maxSignedURLTTL {
+			utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgSignedURLInvalidTTL, raw, maxSignedURLTTL)
+			return
+		}
+		ttl = parsed
+	}
+
+	docID := c.Param("id")
+	doc, found := database.GetDocumentByID(docID)
+	if !found {
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgDocumentNotFound, docID)
+		return
+	}
+	allowed := can(c, database, cfg, authz.ReadDocument, doc)
+	if op == utils.SignedURLWrite {
+		allowed = can(c, database, cfg, authz.UpdateDocument, doc) || can(c, database, cfg, authz.UpdateScopedContent, doc)
+	}
+	if !allowed {
+		utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgSignedURLDenied)
+		return
+	}
+
+	token, expiresAt, err := utils.GenerateSignedURLToken(docID, op, userIDStr, ttl, cfg)
+	if err != nil {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgSignedURLCreateFailed, err)
+		return
+	}
+
+	// The signed URL lives under the same API version (and course) as this request
+	prefix := strings.TrimSuffix(c.FullPath(), "/documents/:id/signed-url")
+	if course := requestCourse(c); course != "" {
+		prefix = "/courses/" + course + prefix
+	}
+	utils.RespondData(c, http.StatusCreated, SignedURLResponse{
+		URL:       prefix + "/signed/" + token,
+		Token:     token,
+		Op:        op,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// SignedURLMiddleware authenticates a request by the signed URL token in its :token parameter
+// instead of an Authorization header. The token must allow op and not have been used; the request
+// then acts as the user who created it on the document it was created for, which it sets as the
+// :id parameter. Handlers still check that the user may perform the operation.
+func SignedURLMiddleware(database *db.Database, cfg *config.Config, op string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := utils.ValidateSignedURLToken(c.Param("token"), cfg)
+		if err != nil {
+			utils.GinLocalizedError(c, http.StatusUnauthorized, i18n.MsgSignedURLInvalid, err)
+			return
+		}
+		if claims.Op != op {
+			utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgSignedURLWrongOp, claims.Op)
+			return
+		}
+		if err := database.UseSignedURL(claims.ID, claims.ExpiresAt.Time); err != nil {
+			if apperr.HTTPStatus(err) == http.StatusConflict {
+				utils.GinLocalizedError(c, http.StatusGone, i18n.MsgSignedURLUsed)
+				return
+			}
+			utils.GinErrorFromErr(c, apperr.HTTPStatus(err), err)
+			return
+		}
+
+		c.Set("userID", claims.Subject)
+		c.Set("tokenIssuedAt", claims.IssuedAt.Time) // Password changes revoke signed URLs too (see RequireActiveMiddleware)
+		c.Params = append(c.Params, gin.Param{Key: "id", Value: claims.DocumentID})
+		c.Next()
+	}
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"docserver/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignedURLs(t *testing.T) {
+	router, _, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	clock := config.NewFixedClock(time.Date(2025, time.June, 1, 9, 0, 0, 0, time.UTC))
+	cfg.Clock = clock
+
+	_, _, ownerToken := createTestUserAndLogin(t, router, "signed.owner@example.com", "password123", "Signed", "Owner")
+	_, _, otherToken := createTestUserAndLogin(t, router, "signed.other@example.com", "password123", "Signed", "Other")
+
+	rr := performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, map[string]any{"content": map[string]any{"title": "Draft"}}), ownerToken)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	var doc DocumentResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+	signPath := "/documents/" + doc.ID + "/signed-url"
+
+	sign := func(t *testing.T, query string) SignedURLResponse {
+		t.Helper()
+		rr := performRequest(router, http.MethodPost, signPath+query, nil, ownerToken)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var signed SignedURLResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &signed))
+		return signed
+	}
+
+	t.Run("Creating", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, signPath+"?op=delete", nil, ownerToken)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		rr = performRequest(router, http.MethodPost, signPath+"?op=read&ttl=48h", nil, ownerToken)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		rr = performRequest(router, http.MethodPost, signPath+"?op=read", nil, otherToken)
+		assert.Equal(t, http.StatusForbidden, rr.Code, "only those who may read the document")
+		rr = performRequest(router, http.MethodPost, "/documents/doc_missing/signed-url?op=read", nil, ownerToken)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		signed := sign(t, "?op=read&ttl=5m")
+		assert.Equal(t, "/signed/"+signed.Token, signed.URL)
+		assert.Equal(t, clock.Now().Add(5*time.Minute), signed.ExpiresAt)
+
+		rr = performRequest(router, http.MethodPost, "/v1"+signPath+"?op=read", nil, ownerToken)
+		require.Equal(t, http.StatusCreated, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"url":"/v1/signed/`)
+	})
+
+	t.Run("Reading works once without a header", func(t *testing.T) {
+		signed := sign(t, "?op=read")
+		rr := performRequest(router, http.MethodGet, signed.URL, nil, "")
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Contains(t, rr.Body.String(), "Draft")
+
+		rr = performRequest(router, http.MethodGet, signed.URL, nil, "")
+		assert.Equal(t, http.StatusGone, rr.Code)
+	})
+
+	t.Run("Writing replaces the content", func(t *testing.T) {
+		signed := sign(t, "?op=write")
+		rr := performRequest(router, http.MethodGet, signed.URL, nil, "")
+		assert.Equal(t, http.StatusForbidden, rr.Code, "a write URL does not read")
+
+		signed = sign(t, "?op=write")
+		rr = performRequest(router, http.MethodPut, signed.URL, marshalJSONBody(t, map[string]any{"content": map[string]any{"title": "Final"}}), "")
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		rr = performRequest(router, http.MethodGet, "/documents/"+doc.ID, nil, ownerToken)
+		assert.Contains(t, rr.Body.String(), "Final")
+	})
+
+	t.Run("Invalid tokens", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/signed/"+ownerToken, nil, "")
+		assert.Equal(t, http.StatusUnauthorized, rr.Code, "access tokens are not signed URLs")
+
+		signed := sign(t, "?op=read&ttl=1m")
+		clock.Advance(2 * time.Minute)
+		rr = performRequest(router, http.MethodGet, signed.URL, nil, "")
+		assert.Equal(t, http.StatusUnauthorized, rr.Code, "expired")
+	})
+}
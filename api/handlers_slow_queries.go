@@ -0,0 +1,26 @@
+package api
+
+import (
+	"docserver/config"
+	"docserver/db"
+	"docserver/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Slow Query Log ---
+
+// ListSlowQueriesHandler lists the latest content queries that exceeded the slow query threshold.
+// @Summary      List Slow Queries (Admin)
+// @Description  Lists the latest content queries (up to 100, newest first) whose evaluation took longer than the slow query threshold (see -slow-query-threshold), with the parsed query, the documents scanned and the caller. The list is kept in memory and starts empty when the server restarts. Administrators only.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  utils.Envelope{data=[]db.SlowQuery} "The latest slow queries."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Router       /admin/slow-queries [get]
+func ListSlowQueriesHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	utils.RespondData(c, http.StatusOK, database.GetSlowQueries())
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"docserver/db"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListSlowQueries(t *testing.T) {
+	router, _, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	cfg.AdminEmails = []string{"slow.admin@example.com"}
+	cfg.SlowQueryThreshold = time.Nanosecond
+
+	adminID, _, adminToken := createTestUserAndLogin(t, router, "slow.admin@example.com", "password123", "Slow", "Admin")
+	_, _, userToken := createTestUserAndLogin(t, router, "slow.user@example.com", "password123", "Slow", "User")
+	rr := performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, map[string]any{"content": map[string]any{"n": 2}}), adminToken)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+
+	rr = performRequest(router, http.MethodGet, "/documents?content_query="+url.QueryEscape("n greaterthan 1"), nil, adminToken)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	rr = performRequest(router, http.MethodGet, "/admin/slow-queries", nil, userToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	rr = performRequest(router, http.MethodGet, "/admin/slow-queries", nil, adminToken)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var slow []db.SlowQuery
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &slow))
+	require.Len(t, slow, 1)
+	assert.Equal(t, adminID, slow[0].CallerID)
+	assert.Equal(t, "n greaterthan 1", slow[0].ParsedQuery)
+	assert.Equal(t, 1, slow[0].DocumentsScanned)
+	assert.Equal(t, 1, slow[0].DocumentsMatched)
+}
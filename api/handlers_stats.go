@@ -0,0 +1,100 @@
+package api
+
+import (
+	"docserver/authz"
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/models"
+	"docserver/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Profile Statistics ---
+
+// GetProfileStatsHandler returns statistics about the authenticated user's documents.
+// @Summary      Get Your Statistics
+// @Description  Returns counts of the documents you own (in total, public, and shared with someone), the documents others share with you and your favorites,
+// @Description  the size of your documents' content in bytes (JSON-encoded), and when you last created or changed a document.
+// @Description  `last_activity_at` is your most recent change to any document, including ones shared with you. Timestamps are left out until there is activity.
+// @Tags         Profiles
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  utils.Envelope{data=models.ProfileStats} "Your statistics."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Router       /profiles/me/stats [get]
+func GetProfileStatsHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+
+	utils.RespondData(c, http.StatusOK, database.GetProfileStats(userID.(string)))
+}
+
+// --- Document Statistics ---
+
+// GetDocumentStatsHandler returns statistics about a document's content.
+// @Summary      Get a Document's Statistics
+// @Description  Describes the shape of a document's content, e.g. to sanity-check a data model: its size in bytes (JSON-encoded), its `depth` (0 for a plain value, 1 for a flat object or array, and one more per level of nesting),
+// @Description  the number of keys of its objects at any depth (`key_count`), and the length of each top-level array (`array_lengths`, by key, or `@this` if the content itself is an array).
+// @Description  `modifications` lists when the latest versions were saved, newest first; the document's creation counts as its first version, and the last 50 versions are kept.
+// @Description  Users a document is shared with on a scope get the statistics of the part they can read.
+// @Tags         Documents
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    path      string  true   "The unique identifier of the document."
+// @Param        last  query     int     false  "Number of modification timestamps." minimum(1) maximum(50) default(10)
+// @Success      200  {object}  utils.Envelope{data=models.DocumentStats} "The document's statistics."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: 'last' is not an integer between 1 and 50."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You do not have permission to read this document."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No document exists with the specified ID."
+// @Router       /documents/{id}/stats [get]
+func GetDocumentStatsHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+	docID := c.Param("id")
+
+	last := db.DefaultStatsModifications
+	if raw := c.Query("last"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > db.MaxStatsModifications {
+			utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgDocumentStatsLastInvalid, raw, db.MaxStatsModifications)
+			return
+		}
+		last = parsed
+	}
+
+	var found, allowed bool
+	var stats models.DocumentStats
+	_ = database.View(func(v *db.ReadView) error {
+		var doc models.Document
+		if doc, found = v.GetDocumentByID(docID); !found {
+			return nil
+		}
+		if allowed = can(c, v, cfg, authz.ReadDocument, doc); allowed {
+			if scope, limited := documentScope(v, doc, userID.(string)); limited {
+				doc.Content = db.ScopedContent(doc.Content, scope)
+			}
+			stats = v.DocumentStats(doc, last)
+		}
+		return nil
+	})
+	if !found {
+		utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgDocumentNotFound, docID)
+		return
+	}
+	if !allowed {
+		utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgDocumentAccessDenied)
+		return
+	}
+	utils.RespondData(c, http.StatusOK, stats)
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"docserver/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetProfileStats(t *testing.T) {
+	router, database, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	ownerID, _, ownerToken := createTestUserAndLogin(t, router, "stats.owner@example.com", "password123", "Stats", "Owner")
+	readerID, _, readerToken := createTestUserAndLogin(t, router, "stats.reader@example.com", "password123", "Stats", "Reader")
+
+	doc, err := database.CreateDocument(models.Document{OwnerID: ownerID, Content: "hello"})
+	require.NoError(t, err)
+	require.NoError(t, database.SetShareRecord(doc.ID, []string{readerID}))
+
+	getStats := func(token string) (models.ProfileStats, map[string]interface{}) {
+		rr := performRequest(router, http.MethodGet, "/profiles/me/stats", nil, token)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var stats models.ProfileStats
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &stats))
+		var raw map[string]interface{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &raw))
+		return stats, raw
+	}
+
+	t.Run("Owner", func(t *testing.T) {
+		stats, _ := getStats(ownerToken)
+		assert.Equal(t, 1, stats.OwnedDocuments)
+		assert.Equal(t, 1, stats.SharedDocuments)
+		assert.Equal(t, int64(len(`"hello"`)), stats.ContentBytes)
+		assert.NotNil(t, stats.LastModifiedAt)
+	})
+
+	t.Run("Reader", func(t *testing.T) {
+		stats, raw := getStats(readerToken)
+		assert.Equal(t, 0, stats.OwnedDocuments)
+		assert.Equal(t, 1, stats.SharedWithMe)
+		assert.NotContains(t, raw, "last_created_at", "no activity yet")
+	})
+
+	t.Run("Requires authentication", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/profiles/me/stats", nil, "")
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
+
+func TestGetDocumentStats(t *testing.T) {
+	router, database, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	ownerID, _, ownerToken := createTestUserAndLogin(t, router, "docstats.owner@example.com", "password123", "Stats", "Owner")
+	readerID, _, readerToken := createTestUserAndLogin(t, router, "docstats.reader@example.com", "password123", "Stats", "Reader")
+	_, _, strangerToken := createTestUserAndLogin(t, router, "docstats.stranger@example.com", "password123", "Stats", "Stranger")
+
+	doc, err := database.CreateDocument(models.Document{OwnerID: ownerID, Content: map[string]any{
+		"public":  map[string]any{"items": []any{"a", "b"}},
+		"private": map[string]any{"grades": []any{1.0, 2.0, 3.0}, "notes": "secret"},
+	}})
+	require.NoError(t, err)
+	_, err = database.UpdateDocument(doc.ID, doc.Content)
+	require.NoError(t, err)
+	require.NoError(t, database.SetShareRecord(doc.ID, []string{readerID}))
+	_, err = database.SetShareScope(doc.ID, readerID, &models.ShareScope{Path: "public"})
+	require.NoError(t, err)
+
+	getStats := func(t *testing.T, query, token string) models.DocumentStats {
+		t.Helper()
+		rr := performRequest(router, http.MethodGet, "/documents/"+doc.ID+"/stats"+query, nil, token)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var stats models.DocumentStats
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &stats))
+		return stats
+	}
+
+	t.Run("Owner", func(t *testing.T) {
+		stats := getStats(t, "", ownerToken)
+		assert.Equal(t, 3, stats.Depth)
+		assert.Equal(t, 5, stats.KeyCount)
+		assert.Empty(t, stats.ArrayLengths, "no top-level arrays")
+		assert.Equal(t, 2, stats.Version)
+		assert.Len(t, stats.Modifications, 2)
+
+		assert.Len(t, getStats(t, "?last=1", ownerToken).Modifications, 1)
+	})
+
+	t.Run("Scoped sharer sees the stats of their part", func(t *testing.T) {
+		stats := getStats(t, "", readerToken)
+		assert.Equal(t, 2, stats.KeyCount, "public and its items")
+		assert.Equal(t, 3, stats.Depth)
+		assert.Less(t, stats.ContentBytes, getStats(t, "", ownerToken).ContentBytes)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		for _, last := range []string{"0", "51", "abc"} {
+			rr := performRequest(router, http.MethodGet, "/documents/"+doc.ID+"/stats?last="+last, nil, ownerToken)
+			assert.Equal(t, http.StatusBadRequest, rr.Code, last)
+		}
+		rr := performRequest(router, http.MethodGet, "/documents/"+doc.ID+"/stats", nil, strangerToken)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		rr = performRequest(router, http.MethodGet, "/documents/doc_missing/stats", nil, ownerToken)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"docserver/config"
+	"docserver/db"
+	"docserver/models"
+	"docserver/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Server Status ---
+
+// StatusResponse describes the running server.
+type StatusResponse struct {
+	Version       string               `json:"version"`        // Release version, "dev" for local builds
+	Commit        string               `json:"commit"`         // Commit the server was built from
+	BuildDate     string               `json:"build_date"`     // When the server was built (RFC 3339), or "unknown"
+	APIVersion    string               `json:"api_version"`    // Current API version prefix, e.g. "v1"
+	UptimeSeconds int64                `json:"uptime_seconds"` // Seconds since the server started
+	Persistence   db.PersistenceStatus `json:"persistence"`    // Whether data is being saved successfully
+	Maintenance   models.Maintenance   `json:"maintenance"`    // Whether writes are currently refused (see POST /admin/maintenance)
+	ReadOnly      bool                 `json:"read_only"`      // Whether the server refuses every change (see -read-only)
+	Ephemeral     bool                 `json:"ephemeral"`      // Whether the data is kept in memory only and lost on shutdown (see -ephemeral)
+}
+
+// GetStatusHandler reports which release is running and whether it is saving data.
+// @Summary      Get Server Status
+// @Description  Reports the release `version`, `commit` and `build_date` the server was built from, its uptime, whether saving the database to disk works (`persistence.healthy`), whether maintenance mode refuses writes (`maintenance.enabled`, with the affected `routes`), whether the server was started with -read-only (`read_only`), refusing every change, and whether it runs with -ephemeral (`ephemeral`), keeping all data in memory only.
+// @Description  No login is needed, so clients can check which server they are talking to. Requests are rate limited per client (30 per minute by default); over the limit the server answers `429 Too Many Requests` with a `Retry-After` header. `X-RateLimit-Limit`, `X-RateLimit-Remaining` and `X-RateLimit-Reset` headers report the allowance on every response (see `GET /limits`).
+// @Tags         Status
+// @Produce      json
+// @Success      200  {object}  utils.Envelope{data=StatusResponse} "The server status."
+// @Failure      429  {object}  utils.ErrorEnvelope "Too Many Requests: Wait the number of seconds in the `Retry-After` header."
+// @Router       /status [get]
+func GetStatusHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	utils.RespondData(c, http.StatusOK, StatusResponse{
+		Version:       utils.Version,
+		Commit:        utils.BuildCommit(),
+		BuildDate:     utils.BuildTime(),
+		APIVersion:    CurrentAPIVersion,
+		UptimeSeconds: int64(utils.Uptime().Seconds()),
+		Persistence:   database.PersistenceStatus(),
+		Maintenance:   database.GetMaintenance(),
+		ReadOnly:      database.ReadOnly(),
+		Ephemeral:     database.Ephemeral(),
+	})
+}
+
+// VersionResponse identifies the server release and the API versions it serves.
+type VersionResponse struct {
+	Version              string   `json:"version"`                // Release version, "dev" for local builds
+	Commit               string   `json:"commit"`                 // Commit the server was built from
+	BuildDate            string   `json:"build_date"`             // When the server was built (RFC 3339), or "unknown"
+	APIVersion           string   `json:"api_version"`            // Current API version prefix, e.g. "v1"
+	SupportedAPIVersions []string `json:"supported_api_versions"` // Every API version prefix served
+}
+
+// GetVersionHandler reports which release is running.
+// @Summary      Get Server Version
+// @Description  Reports the release `version`, `commit` and `build_date` of the server and the API versions it serves. No login is needed.
+// @Description  Every response also carries the release version in an `X-DocServer-Version` header. Clients that need a newer server can send `X-DocServer-Min-Version: v1.2.0` with any request;
+// @Description  an older server answers `412 Precondition Failed` instead of handling the request. Development builds (version `dev`) accept any minimum.
+// @Tags         Status
+// @Produce      json
+// @Success      200  {object}  utils.Envelope{data=VersionResponse} "The server version."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: X-DocServer-Min-Version is not a release version such as v1.2.3."
+// @Failure      412  {object}  utils.ErrorEnvelope "Precondition Failed: The server is older than X-DocServer-Min-Version."
+// @Router       /version [get]
+func GetVersionHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	utils.RespondData(c, http.StatusOK, VersionResponse{
+		Version:              utils.Version,
+		Commit:               utils.BuildCommit(),
+		BuildDate:            utils.BuildTime(),
+		APIVersion:           CurrentAPIVersion,
+		SupportedAPIVersions: SupportedAPIVersions,
+	})
+}
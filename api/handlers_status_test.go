@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"docserver/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetStatus(t *testing.T) {
+	router, database, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	t.Run("No login needed", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/status", nil, "")
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		var status StatusResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &status))
+		assert.Equal(t, utils.Version, status.Version)
+		assert.NotEmpty(t, status.Commit)
+		assert.Equal(t, CurrentAPIVersion, status.APIVersion)
+		assert.GreaterOrEqual(t, status.UptimeSeconds, int64(0))
+		assert.True(t, status.Persistence.Healthy)
+		assert.False(t, status.ReadOnly)
+		assert.False(t, status.Ephemeral)
+	})
+
+	t.Run("Reports ephemeral mode", func(t *testing.T) {
+		cfg.Ephemeral = true
+		defer func() { cfg.Ephemeral = false }()
+		rr := performRequest(router, http.MethodGet, "/status", nil, "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		var status StatusResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &status))
+		assert.True(t, status.Ephemeral)
+	})
+
+	t.Run("Rate limited across versions", func(t *testing.T) {
+		limitedCfg := *cfg
+		limitedCfg.StatusRateLimit = 2
+		limitedRouter := gin.New()
+		RegisterRoutes(limitedRouter, database, &limitedCfg)
+
+		assert.Equal(t, http.StatusOK, performRequest(limitedRouter, http.MethodGet, "/status", nil, "").Code)
+		assert.Equal(t, http.StatusOK, performRequest(limitedRouter, http.MethodGet, "/v1/status", nil, "").Code)
+		rr := performRequest(limitedRouter, http.MethodGet, "/status", nil, "")
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.NotEmpty(t, rr.Header().Get("Retry-After"))
+	})
+}
+
+func TestGetVersion(t *testing.T) {
+	router, _, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	rr := performRequest(router, http.MethodGet, "/v1/version", nil, "")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, utils.Version, rr.Header().Get("X-DocServer-Version"))
+
+	var resp struct {
+		Data VersionResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, utils.Version, resp.Data.Version)
+	assert.NotEmpty(t, resp.Data.Commit)
+	assert.NotEmpty(t, resp.Data.BuildDate)
+	assert.Equal(t, CurrentAPIVersion, resp.Data.APIVersion)
+	assert.Equal(t, SupportedAPIVersions, resp.Data.SupportedAPIVersions)
+}
@@ -4,14 +4,19 @@ import (
 	"bytes"
 	"docserver/config"
 	"docserver/db"
+	"docserver/i18n"
+	"docserver/models"
 	"docserver/utils"
 	"encoding/json"
 	"fmt" // Added
 	"io"
+	"log"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings" // Added for case-insensitive comparison
 	"testing"
 	"time"
@@ -52,48 +57,7 @@ func setupTestServer(t *testing.T) (*gin.Engine, *db.Database, *config.Config, f
 	router := gin.Default() // Use Default to include logger/recovery middleware like main
 	router.RedirectTrailingSlash = false // Disable automatic redirect for trailing slashes
 
-	// Public routes
-	authGroup := router.Group("/auth")
-	{
-		authGroup.POST("/signup", func(c *gin.Context) { SignupHandler(c, database, cfg) })
-		authGroup.POST("/login", func(c *gin.Context) { LoginHandler(c, database, cfg) })
-		authGroup.POST("/forgot-password", func(c *gin.Context) { ForgotPasswordHandler(c, database, cfg) })
-		authGroup.POST("/reset-password", func(c *gin.Context) { ResetPasswordHandler(c, database, cfg) })
-	}
-
-	// Protected routes
-	authMiddleware := utils.AuthMiddleware(cfg)
-
-	profileGroup := router.Group("/profiles")
-	profileGroup.Use(authMiddleware)
-	{
-		profileGroup.GET("/me", func(c *gin.Context) { GetProfileMeHandler(c, database, cfg) })
-		profileGroup.PUT("/me", func(c *gin.Context) { UpdateProfileMeHandler(c, database, cfg) })
-		profileGroup.DELETE("/me", func(c *gin.Context) { DeleteProfileMeHandler(c, database, cfg) })
-		profileGroup.GET("", func(c *gin.Context) { SearchProfilesHandler(c, database, cfg) })
-	}
-
-	docGroup := router.Group("/documents")
-	docGroup.Use(authMiddleware)
-	{
-		docGroup.POST("", func(c *gin.Context) { CreateDocumentHandler(c, database, cfg) })
-		docGroup.GET("", func(c *gin.Context) { GetDocumentsHandler(c, database, cfg) })
-		docGroup.GET("/:id", func(c *gin.Context) { GetDocumentByIDHandler(c, database, cfg) })
-		docGroup.PUT("/:id", func(c *gin.Context) { UpdateDocumentHandler(c, database, cfg) })
-		docGroup.DELETE("/:id", func(c *gin.Context) { DeleteDocumentHandler(c, database, cfg) })
-
-		shareGroup := docGroup.Group("/:id/shares")
-		{
-			shareGroup.GET("", func(c *gin.Context) { GetSharersHandler(c, database, cfg) })
-			shareGroup.PUT("", func(c *gin.Context) { SetSharersHandler(c, database, cfg) })
-			shareGroup.PUT("/:profile_id", func(c *gin.Context) { AddSharerHandler(c, database, cfg) })
-			shareGroup.DELETE("/:profile_id", func(c *gin.Context) { RemoveSharerHandler(c, database, cfg) })
-		}
-	}
-	
-	// Logout route
-	router.POST("/auth/logout", authMiddleware, func(c *gin.Context) { LogoutHandler(c, database, cfg) })
-
+	RegisterRoutes(router, database, cfg)
 
 	// Cleanup function to close the database and remove the temporary directory
 	cleanup := func() {
@@ -171,6 +135,24 @@ func createTestUserAndLogin(t *testing.T, router *gin.Engine, email, password, f
 	return userID, userEmail, token
 }
 
+// deliveredOTPPattern finds a password reset OTP in a logged email or text message.
+var deliveredOTPPattern = regexp.MustCompile(`(?:set a new password:\n\S+ \S+ {3}|reset code is )(\S+?)(?:\n|\. )`)
+
+// requestOTP requests a password reset OTP for email and returns it as it was delivered. Only a
+// hash of the OTP is stored, so it is read from the logged email or text message.
+func requestOTP(t *testing.T, router *gin.Engine, email string) string {
+	var logs bytes.Buffer
+	previous := log.Writer()
+	log.SetOutput(io.MultiWriter(previous, &logs))
+	defer log.SetOutput(previous)
+
+	rr := performRequest(router, http.MethodPost, "/auth/forgot-password", marshalJSONBody(t, gin.H{"email": email}), "")
+	require.Equal(t, http.StatusAccepted, rr.Code)
+	match := deliveredOTPPattern.FindStringSubmatch(logs.String())
+	require.NotNil(t, match, "no OTP was delivered to %s", email)
+	return match[1]
+}
+
 // --- Authentication Endpoint Tests ---
 
 func TestAuthEndpoints(t *testing.T) {
@@ -249,7 +231,7 @@ func TestAuthEndpoints(t *testing.T) {
 		var errorResponse map[string]interface{} // More general type for binding errors
 		err := json.Unmarshal(rr.Body.Bytes(), &errorResponse)
 		require.NoError(t, err)
-		assert.Contains(t, rr.Body.String(), "Password", "Error message should mention missing Password field") // Simple string check
+		assert.Contains(t, rr.Body.String(), "'password' is required", "Error message should name the missing field by its JSON name")
 	})
 
 
@@ -339,6 +321,59 @@ func TestAuthEndpoints(t *testing.T) {
 	})
 
 }
+
+func TestLoginRehashesPassword(t *testing.T) {
+	router, database, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	_, email, token := createTestUserAndLogin(t, router, "rehash@example.com", "password123", "Re", "Hash")
+	login := func() {
+		rr := performRequest(router, "POST", "/auth/login", marshalJSONBody(t, gin.H{"email": email, "password": "password123"}), "")
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	}
+	hash := func() string {
+		profile, found := database.GetProfileByEmail(email)
+		require.True(t, found)
+		return profile.PasswordHash
+	}
+
+	bcryptHash := hash()
+	login()
+	assert.Equal(t, bcryptHash, hash(), "an up to date hash is kept")
+
+	cfg.BcryptCost = 5
+	login()
+	assert.True(t, strings.HasPrefix(hash(), "$2a$05$"), "rehashed with the new cost")
+
+	cfg.PasswordHash = config.PasswordHashArgon2id
+	cfg.Argon2Time, cfg.Argon2MemoryKiB, cfg.Argon2Threads = 1, 64, 1
+	login()
+	assert.True(t, strings.HasPrefix(hash(), "$argon2id$"), "rehashed with the new algorithm")
+	assert.True(t, utils.CheckPasswordHash("password123", hash()))
+
+	rr := performRequest(router, "GET", "/profiles/me", nil, token)
+	assert.Equal(t, http.StatusOK, rr.Code, "tokens issued before the rehash stay valid")
+}
+
+func TestFrozenClock(t *testing.T) {
+	router, database, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	frozen := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	clock := config.NewFixedClock(frozen)
+	cfg.Clock = clock
+
+	userID, _, token := createTestUserAndLogin(t, router, "frozen@example.com", "password123", "Fro", "Zen")
+	profile, found := database.GetProfileByID(userID)
+	require.True(t, found)
+	assert.Equal(t, frozen, profile.CreationDate)
+
+	rr := performRequest(router, "GET", "/profiles/me", nil, token)
+	assert.Equal(t, http.StatusOK, rr.Code, "the token is valid on the frozen clock, though expired on the wall clock")
+
+	clock.Advance(cfg.TokenLifetime + time.Minute)
+	rr = performRequest(router, "GET", "/profiles/me", nil, token)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code, "the token expires as the clock moves on")
+}
 // --- Profile Endpoint Tests ---
 
 func TestProfileEndpoints(t *testing.T) {
@@ -1150,17 +1185,15 @@ func TestPasswordResetEndpoints(t *testing.T) {
 
 	// --- Forgot Password ---
 	t.Run("Forgot Password Success", func(t *testing.T) {
-		forgotPayload := gin.H{"email": userEmail}
-		rr := performRequest(router, "POST", "/auth/forgot-password", marshalJSONBody(t, forgotPayload), "")
-
-		assert.Equal(t, http.StatusAccepted, rr.Code, "Forgot password should return 202 Accepted")
+		otp := requestOTP(t, router, userEmail)
+		assert.Len(t, otp, 6, "OTP length should match expected") // Use literal 6 as otpLength is not exported
 
-		// Verify OTP was stored in the database (need to retrieve it)
-		otp, expiry, found := database.RetrieveOTP(userEmail) // Assuming RetrieveOTP is accessible
+		// Verify the OTP was stored in the database, hashed
+		otpHash, expiry, found := database.RetrieveOTP(userEmail)
 		assert.True(t, found, "OTP should be stored in the database")
-		assert.NotEmpty(t, otp, "Stored OTP should not be empty")
+		assert.NotContains(t, otpHash, otp, "Only a hash of the OTP should be stored")
+		assert.True(t, utils.CheckPasswordHash(otp, otpHash), "The stored hash should match the delivered OTP")
 		assert.True(t, expiry.After(time.Now()), "OTP expiry should be in the future")
-		assert.Len(t, otp, 6, "OTP length should match expected") // Use literal 6 as otpLength is not exported
 
 		generatedOTP = otp // Store for the reset test
 	})
@@ -1203,13 +1236,12 @@ func TestPasswordResetEndpoints(t *testing.T) {
 
 	t.Run("Reset Password Expired OTP", func(t *testing.T) {
 		// Generate a new OTP first
-		forgotPayload := gin.H{"email": userEmail}
-		performRequest(router, "POST", "/auth/forgot-password", marshalJSONBody(t, forgotPayload), "")
-		otp, _, found := database.RetrieveOTP(userEmail)
+		otp := requestOTP(t, router, userEmail)
+		otpHash, _, found := database.RetrieveOTP(userEmail)
 		require.True(t, found)
 
 		// Manually expire the OTP in the database
-		database.StoreOTP(userEmail, otp, time.Now().Add(-1*time.Minute)) // Set expiry to 1 minute ago
+		database.StoreOTP(userEmail, otpHash, time.Now().Add(-1*time.Minute)) // Set expiry to 1 minute ago
 
 		resetPayload := gin.H{
 			"email":         userEmail,
@@ -1233,11 +1265,7 @@ func TestPasswordResetEndpoints(t *testing.T) {
 
 	t.Run("Reset Password Success", func(t *testing.T) {
 		// Generate a fresh OTP
-		forgotPayload := gin.H{"email": userEmail}
-		performRequest(router, "POST", "/auth/forgot-password", marshalJSONBody(t, forgotPayload), "")
-		otp, _, found := database.RetrieveOTP(userEmail)
-		require.True(t, found)
-		require.NotEmpty(t, otp)
+		otp := requestOTP(t, router, userEmail)
 
 		newPassword := "SuccessfullyResetPassword"
 		resetPayload := gin.H{
@@ -1264,6 +1292,31 @@ func TestPasswordResetEndpoints(t *testing.T) {
 		assert.Equal(t, http.StatusUnauthorized, loginRROld.Code, "Login with OLD password should fail after successful reset")
 	})
 
+	t.Run("Reset Password Revokes Earlier Tokens", func(t *testing.T) {
+		loginRR := performRequest(router, "POST", "/auth/login", marshalJSONBody(t, gin.H{"email": userEmail, "password": "SuccessfullyResetPassword"}), "")
+		require.Equal(t, http.StatusOK, loginRR.Code)
+		var login map[string]string
+		require.NoError(t, json.Unmarshal(loginRR.Body.Bytes(), &login))
+		oldToken := login["token"]
+		require.NotEmpty(t, oldToken)
+		assert.Equal(t, http.StatusOK, performRequest(router, "GET", "/profiles/me", nil, oldToken).Code)
+
+		// Token issue times have second precision: change the password in a later second
+		time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+		otp := requestOTP(t, router, userEmail)
+		rr := performRequest(router, "POST", "/auth/reset-password", marshalJSONBody(t, gin.H{"email": userEmail, "otp": otp, "new_password": "AnotherNewPassword"}), "")
+		require.Equal(t, http.StatusNoContent, rr.Code)
+
+		rr = performRequest(router, "GET", "/profiles/me", nil, oldToken)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		assert.Contains(t, rr.Body.String(), "before the password was changed")
+
+		loginRR = performRequest(router, "POST", "/auth/login", marshalJSONBody(t, gin.H{"email": userEmail, "password": "AnotherNewPassword"}), "")
+		require.Equal(t, http.StatusOK, loginRR.Code)
+		require.NoError(t, json.Unmarshal(loginRR.Body.Bytes(), &login))
+		assert.Equal(t, http.StatusOK, performRequest(router, "GET", "/profiles/me", nil, login["token"]).Code, "tokens issued after the change work")
+	})
+
 	t.Run("Reset Password OTP Not Found", func(t *testing.T) {
 		// Assumes OTP was deleted by the successful reset test above
 		resetPayload := gin.H{
@@ -1295,11 +1348,7 @@ func TestPasswordResetEndpoints(t *testing.T) {
 		require.NotEmpty(t, tempUserID)
 
 		// Generate OTP for the temporary user
-		forgotPayload := gin.H{"email": tempUserEmail}
-		performRequest(router, "POST", "/auth/forgot-password", marshalJSONBody(t, forgotPayload), "")
-		otp, _, found := database.RetrieveOTP(tempUserEmail)
-		require.True(t, found, "OTP should be generated for temp user")
-		require.NotEmpty(t, otp)
+		otp := requestOTP(t, router, tempUserEmail)
 
 		// Manually delete the user from the database AFTER generating OTP
 		err := database.DeleteProfile(tempUserID)
@@ -1317,4 +1366,793 @@ func TestPasswordResetEndpoints(t *testing.T) {
 		assert.Equal(t, http.StatusNotFound, rr.Code, "Reset password for deleted user should return 404 Not Found")
 	})
 
-}
\ No newline at end of file
+}
+
+func TestPasswordResetConfiguredOTP(t *testing.T) {
+	router, _, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	cfg.OTPLength = 8
+	cfg.OTPCharset = "ABCDEFGH"
+	cfg.OTPMaxAttempts = 2
+	cfg.OTPDelivery = config.OTPDeliverySMS
+
+	_, email, token := createTestUserAndLogin(t, router, "otp.sms@example.com", "password123", "Otp", "Sms")
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	t.Run("Phone number is validated and private", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPut, "/profiles/me", marshalJSONBody(t, gin.H{"first_name": "Otp", "last_name": "Sms", "phone": "555-0123"}), token)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = performRequest(router, http.MethodPut, "/profiles/me", marshalJSONBody(t, gin.H{"first_name": "Otp", "last_name": "Sms", "phone": "+14155550123"}), token)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Contains(t, rr.Body.String(), `"phone":"+14155550123"`)
+
+		rr = performRequest(router, http.MethodPut, "/profiles/me", marshalJSONBody(t, gin.H{"first_name": "Otp", "last_name": "Renamed"}), token)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Contains(t, rr.Body.String(), `"phone":"+14155550123"`, "omitting phone keeps it")
+
+		_, _, otherToken := createTestUserAndLogin(t, router, "otp.viewer@example.com", "password123", "Otp", "Viewer")
+		rr = performRequest(router, http.MethodGet, "/profiles?email=otp.sms", nil, otherToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Contains(t, rr.Body.String(), email)
+		assert.NotContains(t, rr.Body.String(), "phone")
+	})
+
+	var otp string
+	t.Run("OTP is texted in the configured format", func(t *testing.T) {
+		logs.Reset()
+		otp = requestOTP(t, router, email)
+		assert.Len(t, otp, 8)
+		assert.Empty(t, strings.Trim(otp, "ABCDEFGH"))
+		assert.Contains(t, logs.String(), "SMS to +14155550123")
+		assert.Contains(t, logs.String(), otp)
+		assert.NotContains(t, logs.String(), "EMAIL to")
+	})
+
+	t.Run("Wrong guesses use up the OTP", func(t *testing.T) {
+		require.NotEmpty(t, otp)
+		reset := func(code string) *httptest.ResponseRecorder {
+			return performRequest(router, http.MethodPost, "/v1/auth/reset-password", marshalJSONBody(t, gin.H{
+				"email": email, "otp": code, "new_password": "newPassword1",
+			}), "")
+		}
+
+		rr := reset("HHHHHHHH")
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"message_id":"otp_mismatch"`)
+		rr = reset("HHHHHHHH")
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"message_id":"otp_attempts_exceeded"`)
+		rr = reset(otp)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code, "the right code no longer works")
+	})
+}
+
+func TestPasswordResetBackoff(t *testing.T) {
+	router, _, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	cfg.OTPBackoff = time.Minute
+	cfg.OTPBackoffMax = time.Hour
+
+	_, email, _ := createTestUserAndLogin(t, router, "otp.backoff@example.com", "password123", "Otp", "Backoff")
+	reset := func(code string) *httptest.ResponseRecorder {
+		return performRequest(router, http.MethodPost, "/v1/auth/reset-password", marshalJSONBody(t, gin.H{
+			"email": email, "otp": code, "new_password": "newPassword1",
+		}), "")
+	}
+
+	otp := requestOTP(t, router, email)
+
+	rr := reset("wrong")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	rr = reset(otp)
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code, "even the right code waits out the backoff")
+	assert.Contains(t, rr.Body.String(), `"message_id":"otp_locked"`)
+	assert.Contains(t, []string{"59", "60"}, rr.Header().Get("Retry-After"))
+
+	// Requesting a new OTP does not lift the lockout
+	otp = requestOTP(t, router, email)
+	rr = reset(otp)
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+}
+
+func TestCustomDocumentIDs(t *testing.T) {
+	router, database, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	user1ID, _, token1 := createTestUserAndLogin(t, router, "custom.id1@example.com", "password123", "Custom", "One")
+	user2ID, _, token2 := createTestUserAndLogin(t, router, "custom.id2@example.com", "password123", "Custom", "Two")
+
+	t.Run("Create With Custom ID", func(t *testing.T) {
+		payload := gin.H{"id": "syllabus_2024", "content": gin.H{"title": "Syllabus"}}
+		rr := performRequest(router, "POST", "/documents", marshalJSONBody(t, payload), token1)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+
+		var docResp map[string]interface{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &docResp))
+		assert.Equal(t, "syllabus_2024", docResp["id"])
+	})
+
+	t.Run("Create With Duplicate Custom ID", func(t *testing.T) {
+		payload := gin.H{"id": "syllabus_2024", "content": "duplicate"}
+		rr := performRequest(router, "POST", "/documents", marshalJSONBody(t, payload), token2)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("Create With Invalid Custom ID", func(t *testing.T) {
+		payload := gin.H{"id": "not a valid id!", "content": "x"}
+		rr := performRequest(router, "POST", "/documents", marshalJSONBody(t, payload), token1)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Create With Both ID And Key", func(t *testing.T) {
+		payload := gin.H{"id": "both", "key": "both", "content": "x"}
+		rr := performRequest(router, "POST", "/documents", marshalJSONBody(t, payload), token1)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Create With Key Is Idempotent", func(t *testing.T) {
+		payload := gin.H{"key": "homework-1", "content": gin.H{"answer": 42}}
+		rr := performRequest(router, "POST", "/documents", marshalJSONBody(t, payload), token1)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var first map[string]interface{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &first))
+
+		payload["content"] = gin.H{"answer": 43}
+		rr = performRequest(router, "POST", "/documents", marshalJSONBody(t, payload), token1)
+		require.Equal(t, http.StatusOK, rr.Code, "Repeating a keyed create should return the existing document")
+		var second map[string]interface{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &second))
+		assert.Equal(t, first["id"], second["id"])
+		assert.Equal(t, float64(42), second["content"].(map[string]interface{})["answer"], "Existing content should be unchanged")
+
+		// The same key used by another user yields a different document
+		rr = performRequest(router, "POST", "/documents", marshalJSONBody(t, payload), token2)
+		require.Equal(t, http.StatusCreated, rr.Code)
+		var third map[string]interface{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &third))
+		assert.NotEqual(t, first["id"], third["id"])
+	})
+
+	t.Run("Key-Derived IDs Are Reserved", func(t *testing.T) {
+		derived := utils.DeterministicDocumentID(user2ID, "homework-2")
+		payload := gin.H{"id": derived, "content": "squatting"}
+		rr := performRequest(router, "POST", "/documents", marshalJSONBody(t, payload), token1)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"message_id":"key_derived_id"`)
+		rr = performRequest(router, "PUT", "/documents/"+derived+"?create=true", marshalJSONBody(t, gin.H{"content": "squatting"}), token1)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		// A document of another owner already at the derived ID is not handed out
+		_, err := database.CreateDocument(models.Document{ID: derived, OwnerID: user1ID, Content: "squatting"})
+		require.NoError(t, err)
+		rr = performRequest(router, "POST", "/documents", marshalJSONBody(t, gin.H{"key": "homework-2", "content": "mine"}), token2)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.NotContains(t, rr.Body.String(), "squatting")
+	})
+
+	t.Run("PUT With Create Flag Creates Then Updates", func(t *testing.T) {
+		payload := gin.H{"content": gin.H{"v": 1}}
+		rr := performRequest(router, "PUT", "/documents/upsert-me?create=true", marshalJSONBody(t, payload), token2)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+
+		doc, found := database.GetDocumentByID("upsert-me")
+		require.True(t, found)
+		assert.Equal(t, user2ID, doc.OwnerID, "Caller should own the created document")
+
+		payload = gin.H{"content": gin.H{"v": 2}}
+		rr = performRequest(router, "PUT", "/documents/upsert-me?create=true", marshalJSONBody(t, payload), token2)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		// Another user cannot take over an existing ID via create=true
+		rr = performRequest(router, "PUT", "/documents/upsert-me?create=true", marshalJSONBody(t, payload), token1)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("PUT Without Create Flag Still 404s", func(t *testing.T) {
+		payload := gin.H{"content": "x"}
+		rr := performRequest(router, "PUT", "/documents/does-not-exist", marshalJSONBody(t, payload), token1)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestUpsertDocument(t *testing.T) {
+	router, database, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	userID, _, token := createTestUserAndLogin(t, router, "upsert1@example.com", "password123", "Upsert", "One")
+	_, _, otherToken := createTestUserAndLogin(t, router, "upsert2@example.com", "password123", "Upsert", "Two")
+
+	putWithHeaders := func(path string, body interface{}, token string, headers map[string]string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodPut, path, marshalJSONBody(t, body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Upsert Flag Creates Missing Document", func(t *testing.T) {
+		rr := performRequest(router, "PUT", "/documents/notes-1?upsert=true", marshalJSONBody(t, gin.H{"content": "v1"}), token)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		doc, found := database.GetDocumentByID("notes-1")
+		require.True(t, found)
+		assert.Equal(t, userID, doc.OwnerID)
+		assert.Equal(t, "v1", doc.Content)
+	})
+
+	t.Run("Upsert Flag Updates Existing Document", func(t *testing.T) {
+		rr := performRequest(router, "PUT", "/documents/notes-1?upsert=true", marshalJSONBody(t, gin.H{"content": "v2"}), token)
+		require.Equal(t, http.StatusOK, rr.Code)
+		doc, _ := database.GetDocumentByID("notes-1")
+		assert.Equal(t, "v2", doc.Content)
+	})
+
+	t.Run("Upsert Flag Does Not Bypass Ownership", func(t *testing.T) {
+		rr := performRequest(router, "PUT", "/documents/notes-1?upsert=true", marshalJSONBody(t, gin.H{"content": "hijack"}), otherToken)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("If-None-Match Creates Missing Document", func(t *testing.T) {
+		rr := putWithHeaders("/documents/notes-2", gin.H{"content": "fresh"}, token, map[string]string{"If-None-Match": "*"})
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		_, found := database.GetDocumentByID("notes-2")
+		assert.True(t, found)
+	})
+
+	t.Run("If-None-Match Fails When Document Exists", func(t *testing.T) {
+		rr := putWithHeaders("/documents/notes-2", gin.H{"content": "overwrite"}, token, map[string]string{"If-None-Match": "*"})
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+		doc, _ := database.GetDocumentByID("notes-2")
+		assert.Equal(t, "fresh", doc.Content, "Content must not change when the precondition fails")
+	})
+
+	t.Run("Invalid ID Is Rejected On Upsert", func(t *testing.T) {
+		rr := performRequest(router, "PUT", "/documents/bad%20id?upsert=true", marshalJSONBody(t, gin.H{"content": "x"}), token)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestUpdateDocumentSync(t *testing.T) {
+	router, database, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	cfg.SaveInterval = time.Hour // Only '?sync=true' saves within the test
+	cfg.CoalesceWindow = time.Hour
+
+	_, _, token := createTestUserAndLogin(t, router, "sync@example.com", "password123", "Sy", "Nc")
+	rr := performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, gin.H{"content": gin.H{"text": ""}}), token)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	var doc models.Document
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+
+	for _, text := range []string{"a", "ab", "abc"} {
+		rr = performRequest(router, http.MethodPut, "/documents/"+doc.ID, marshalJSONBody(t, gin.H{"content": gin.H{"text": text}}), token)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	}
+	rr = performRequest(router, http.MethodPut, "/documents/"+doc.ID+"?sync=true", marshalJSONBody(t, gin.H{"content": gin.H{"text": "abcd"}}), token)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, "persisted", rr.Header().Get("X-Durable"))
+	var updated models.Document
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &updated))
+	assert.Equal(t, 2, updated.Version, "the autosaves were folded into one version")
+
+	data, err := os.ReadFile(cfg.DbFilePath)
+	require.NoError(t, err, "the database file is written before the response")
+	var saved models.Database
+	require.NoError(t, json.Unmarshal(data, &saved))
+	assert.Equal(t, map[string]any{"text": "abcd"}, saved.Documents[doc.ID].Content)
+	current, _ := database.GetDocumentByID(doc.ID)
+	assert.Equal(t, current.Content, saved.Documents[doc.ID].Content)
+}
+
+func TestDocumentContentTypes(t *testing.T) {
+	router, database, _, cleanup := setupTestServer(t)
+	defer cleanup()
+	_, _, token := createTestUserAndLogin(t, router, "types@example.com", "password123", "Content", "Types")
+
+	var docID string
+	t.Run("Create Markdown Document", func(t *testing.T) {
+		body := gin.H{"content": "# Notes\n\n<b>bold</b> *claim*", "content_type": "Markdown"}
+		rr := performRequest(router, "POST", "/documents", marshalJSONBody(t, body), token)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var doc models.Document
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+		assert.Equal(t, models.ContentTypeMarkdown, doc.ContentType)
+		docID = doc.ID
+	})
+
+	t.Run("Render As HTML", func(t *testing.T) {
+		rr := performRequest(router, "GET", "/documents/"+docID+"?render=html", nil, token)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Equal(t, "text/html; charset=utf-8", rr.Header().Get("Content-Type"))
+		assert.Equal(t, "<h1>Notes</h1>\n<p>&lt;b&gt;bold&lt;/b&gt; <em>claim</em></p>\n", rr.Body.String())
+
+		rr = performRequest(router, "GET", "/documents/"+docID+"?render=pdf", nil, token)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Only Markdown Renders", func(t *testing.T) {
+		rr := performRequest(router, "POST", "/documents", marshalJSONBody(t, gin.H{"content": map[string]int{"a": 1}}), token)
+		require.Equal(t, http.StatusCreated, rr.Code)
+		var doc models.Document
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+		assert.Empty(t, doc.ContentType, "JSON is the default and is not stored")
+
+		rr = performRequest(router, "GET", "/documents/"+doc.ID+"?render=html", nil, token)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "this document is json")
+	})
+
+	t.Run("Invalid Declarations Are Rejected", func(t *testing.T) {
+		rr := performRequest(router, "POST", "/documents", marshalJSONBody(t, gin.H{"content": "x", "content_type": "yaml"}), token)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "Invalid content_type 'yaml'")
+
+		rr = performRequest(router, "POST", "/documents", marshalJSONBody(t, gin.H{"content": gin.H{"a": 1}, "content_type": "text"}), token)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = performRequest(router, "POST", "/documents", marshalJSONBody(t, gin.H{"content": "a,b\n\"1,2", "content_type": "csv"}), token)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "not valid CSV")
+	})
+
+	t.Run("Update Keeps Or Changes The Type", func(t *testing.T) {
+		rr := performRequest(router, "PUT", "/documents/"+docID, marshalJSONBody(t, gin.H{"content": gin.H{"a": 1}}), token)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, "still a markdown document")
+
+		rr = performRequest(router, "PUT", "/documents/"+docID, marshalJSONBody(t, gin.H{"content": "a,b\n1,2", "content_type": "csv"}), token)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		doc, _ := database.GetDocumentByID(docID)
+		assert.Equal(t, models.ContentTypeCSV, doc.ContentType)
+
+		rr = performRequest(router, "PUT", "/documents/"+docID, marshalJSONBody(t, gin.H{"content": gin.H{"a": 1}, "content_type": "json"}), token)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		doc, _ = database.GetDocumentByID(docID)
+		assert.Empty(t, doc.ContentType)
+	})
+}
+
+// --- Localization Tests ---
+
+func TestDocumentQueryMissingPaths(t *testing.T) {
+	router, _, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	_, _, token := createTestUserAndLogin(t, router, "missing.paths@example.com", "password123", "Missing", "Paths")
+	for _, content := range []map[string]any{{"tags": []string{"urgent"}, "done": false}, {"done": true}} {
+		rr := performRequest(router, "POST", "/documents", marshalJSONBody(t, gin.H{"content": content}), token)
+		require.Equal(t, http.StatusCreated, rr.Code)
+	}
+	query := "/documents?content_query=" + url.QueryEscape(`tags contains "urgent"`) + "&content_query=or&content_query=" + url.QueryEscape("done equals true")
+
+	for missing, want := range map[string]int{"": 1, "skip": 1, "false": 2} {
+		rr := performRequest(router, "GET", query+"&missing="+missing, nil, token)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var resp utils.PaginatedResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, want, resp.Total, "missing=%s", missing)
+	}
+
+	rr := performRequest(router, "GET", query+"&missing=error", nil, token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "has no path 'tags'")
+
+	rr = performRequest(router, "GET", query+"&missing=maybe", nil, token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestDocumentIncludes(t *testing.T) {
+	router, _, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	ownerID, _, ownerToken := createTestUserAndLogin(t, router, "include.owner@example.com", "password123", "Ada", "Owner")
+	readerID, _, readerToken := createTestUserAndLogin(t, router, "include.reader@example.com", "password123", "Alan", "Reader")
+
+	var docIDs []string
+	for _, content := range []string{"shared", "private"} {
+		rr := performRequest(router, "POST", "/documents", marshalJSONBody(t, gin.H{"content": content}), ownerToken)
+		require.Equal(t, http.StatusCreated, rr.Code)
+		var doc models.Document
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+		docIDs = append(docIDs, doc.ID)
+	}
+	rr := performRequest(router, "PUT", "/documents/"+docIDs[0]+"/shares", marshalJSONBody(t, gin.H{"shared_with": []string{readerID}}), ownerToken)
+	require.Equal(t, http.StatusNoContent, rr.Code)
+
+	list := func(token, query string) map[string]DocumentListItem {
+		rr := performRequest(router, "GET", "/documents"+query, nil, token)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var resp GetDocumentsResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		items := map[string]DocumentListItem{}
+		for _, item := range resp.Data {
+			items[item.ID] = item
+		}
+		return items
+	}
+
+	t.Run("Not included by default", func(t *testing.T) {
+		for _, item := range list(ownerToken, "") {
+			assert.Nil(t, item.SharedWith)
+			assert.Nil(t, item.Owner)
+		}
+		rr := performRequest(router, "GET", "/documents/"+docIDs[0], nil, ownerToken)
+		assert.NotContains(t, rr.Body.String(), "shared_with")
+	})
+
+	t.Run("Shares on owned documents", func(t *testing.T) {
+		items := list(ownerToken, "?include=shares,owner")
+		require.Len(t, items, 2)
+		require.NotNil(t, items[docIDs[0]].SharedWith)
+		assert.Equal(t, []ProfileSummary{{ID: readerID, FirstName: "Alan", LastName: "Reader"}}, *items[docIDs[0]].SharedWith)
+		require.NotNil(t, items[docIDs[1]].SharedWith)
+		assert.Empty(t, *items[docIDs[1]].SharedWith, "unshared documents list no one")
+		assert.Nil(t, items[docIDs[0]].Owner, "the viewer owns the document")
+	})
+
+	t.Run("Owner on shared documents", func(t *testing.T) {
+		items := list(readerToken, "?include=owner,shares")
+		require.Len(t, items, 1)
+		assert.Equal(t, &ProfileSummary{ID: ownerID, FirstName: "Ada", LastName: "Owner"}, items[docIDs[0]].Owner)
+		assert.Nil(t, items[docIDs[0]].SharedWith, "only the owner sees who a document is shared with")
+	})
+
+	t.Run("Document detail", func(t *testing.T) {
+		rr := performRequest(router, "GET", "/documents/"+docIDs[0]+"?include=owner", nil, readerToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var doc DocumentResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+		assert.Equal(t, docIDs[0], doc.ID)
+		assert.Equal(t, &ProfileSummary{ID: ownerID, FirstName: "Ada", LastName: "Owner"}, doc.Owner)
+	})
+
+	t.Run("Unknown include", func(t *testing.T) {
+		rr := performRequest(router, "GET", "/documents?include=owner,editors", nil, ownerToken)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "editors")
+		rr = performRequest(router, "GET", "/documents/"+docIDs[0]+"?include=everything", nil, ownerToken)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestAdminDocumentQueries(t *testing.T) {
+	router, _, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	cfg.AdminEmails = []string{"instructor@example.com"}
+
+	_, _, adminToken := createTestUserAndLogin(t, router, "instructor@example.com", "password123", "Ins", "Tructor")
+	student1ID, _, student1Token := createTestUserAndLogin(t, router, "student1@example.com", "password123", "Stu", "One")
+	_, _, student2Token := createTestUserAndLogin(t, router, "student2@example.com", "password123", "Stu", "Two")
+	for _, token := range []string{student1Token, student2Token} {
+		rr := performRequest(router, "POST", "/documents", marshalJSONBody(t, gin.H{"content": gin.H{"course": "CS101"}}), token)
+		require.Equal(t, http.StatusCreated, rr.Code)
+	}
+	courseQuery := "&content_query=" + url.QueryEscape(`course equals "CS101"`)
+
+	list := func(token, query string) []DocumentListItem {
+		rr := performRequest(router, "GET", "/documents"+query, nil, token)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var resp GetDocumentsResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		return resp.Data
+	}
+
+	assert.Len(t, list(adminToken, "?scope=any"+courseQuery), 2, "admins search every student's documents")
+	assert.Empty(t, list(adminToken, "?scope=all"+courseQuery), "other scopes still only cover the admin's own documents")
+	docs := list(adminToken, "?scope=any&owner_id="+student1ID+courseQuery)
+	require.Len(t, docs, 1)
+	assert.Equal(t, student1ID, docs[0].OwnerID)
+	assert.Empty(t, list(student2Token, "?owner_id="+student1ID), "the owner filter does not widen access")
+
+	rr := performRequest(router, "GET", "/documents?scope=any", nil, student1Token)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Contains(t, rr.Body.String(), "administrators")
+}
+
+func TestLocalizedErrors(t *testing.T) {
+	router, _, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	_, _, token := createTestUserAndLogin(t, router, "i18n@example.com", "password123", "Lang", "User")
+
+	getWithLanguage := func(path, acceptLanguage string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if acceptLanguage != "" {
+			req.Header.Set("Accept-Language", acceptLanguage)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Defaults to English with message ID", func(t *testing.T) {
+		rr := getWithLanguage("/documents/missing", "")
+		require.Equal(t, http.StatusNotFound, rr.Code)
+		var resp utils.APIError
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, "Document with ID 'missing' not found.", resp.Error)
+		assert.Equal(t, "document_not_found", resp.MessageID)
+		assert.Equal(t, "en", rr.Header().Get("Content-Language"))
+	})
+
+	t.Run("Spanish handler message", func(t *testing.T) {
+		rr := getWithLanguage("/documents/missing", "es-ES,es;q=0.9")
+		var resp utils.APIError
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, "No se encontró el documento con ID 'missing'.", resp.Error)
+		assert.Equal(t, "document_not_found", resp.MessageID)
+		assert.Equal(t, "es", rr.Header().Get("Content-Language"))
+	})
+
+	t.Run("French query parser message", func(t *testing.T) {
+		rr := getWithLanguage("/documents?content_query=name%20badop%20x", "fr")
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		var resp utils.APIError
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, "query_invalid", resp.MessageID)
+		assert.Equal(t, "content_query invalide : condition invalide à l'index 0 ('name badop x') : opérateur invalide 'badop'", resp.Error)
+	})
+
+	t.Run("Enveloped errors carry message ID", func(t *testing.T) {
+		rr := getWithLanguage("/v1/documents/missing", "fr")
+		var env utils.ErrorEnvelope
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &env))
+		assert.Equal(t, "document_not_found", env.Error.MessageID)
+		assert.Equal(t, "Document avec l'ID 'missing' introuvable.", env.Error.Message)
+	})
+}
+
+func TestSearchProfilesSortAndFilter(t *testing.T) {
+	router, database, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	zedID, _, token := createTestUserAndLogin(t, router, "a.zed@example.com", "password123", "Amy", "Zed")
+	bobID, _, _ := createTestUserAndLogin(t, router, "b.adams@example.com", "password123", "Bob", "Adams")
+	carlID, _, _ := createTestUserAndLogin(t, router, "c.adams@example.com", "password123", "Carl", "Adams")
+
+	// Spread the creation dates so the date sort and filters are deterministic
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, id := range []string{carlID, zedID, bobID} {
+		profile, found := database.GetProfileByID(id)
+		require.True(t, found)
+		profile.CreationDate = base.AddDate(0, i, 0)
+		database.Database.Profiles[id] = profile
+	}
+
+	searchIDs := func(query string) []interface{} {
+		rr := performRequest(router, http.MethodGet, "/profiles?"+query, nil, token)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var resp SearchProfilesResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		ids := []interface{}{}
+		for _, p := range resp.Data {
+			ids = append(ids, p.ID)
+		}
+		return ids
+	}
+
+	t.Run("Default sort is by email", func(t *testing.T) {
+		assert.Equal(t, []interface{}{zedID, bobID, carlID}, searchIDs(""))
+	})
+
+	t.Run("Sort by name", func(t *testing.T) {
+		assert.Equal(t, []interface{}{bobID, carlID, zedID}, searchIDs("sort_by=name"))
+		assert.Equal(t, []interface{}{zedID, carlID, bobID}, searchIDs("sort_by=name&order=desc"))
+	})
+
+	t.Run("Sort by creation date", func(t *testing.T) {
+		assert.Equal(t, []interface{}{carlID, zedID, bobID}, searchIDs("sort_by=creation_date"))
+	})
+
+	t.Run("Creation date filters", func(t *testing.T) {
+		assert.Equal(t, []interface{}{zedID, bobID}, searchIDs("created_after=2024-01-15T00:00:00Z"))
+		assert.Equal(t, []interface{}{zedID}, searchIDs("created_after=2024-01-15T00:00:00Z&created_before=2024-03-01T00:00:00Z"))
+	})
+
+	t.Run("Lookup by IDs", func(t *testing.T) {
+		assert.Equal(t, []interface{}{bobID, carlID}, searchIDs("ids="+carlID+",missing,"+bobID+","+carlID))
+		assert.Equal(t, []interface{}{carlID}, searchIDs("ids="+carlID+","+bobID+"&first_name=carl"))
+		assert.Empty(t, searchIDs("ids="))
+	})
+
+	t.Run("Invalid parameters", func(t *testing.T) {
+		manyIDs := make([]string, maxProfileIDs+1)
+		for i := range manyIDs {
+			manyIDs[i] = fmt.Sprintf("id%d", i)
+		}
+		tooMany := strings.Join(manyIDs, ",")
+		for _, query := range []string{"sort_by=age", "order=up", "created_after=yesterday", "created_before=2024-01-01", "ids=" + tooMany} {
+			rr := performRequest(router, http.MethodGet, "/profiles?"+query, nil, token)
+			assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+			assert.Contains(t, rr.Body.String(), "Invalid profile search", query)
+		}
+	})
+}
+
+func TestResolveProfiles(t *testing.T) {
+	router, _, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	aliceID, _, token := createTestUserAndLogin(t, router, "resolve.alice@example.com", "password123", "Alice", "Able")
+	bobID, _, _ := createTestUserAndLogin(t, router, "resolve.bob@example.com", "password123", "Bob", "Baker")
+
+	t.Run("Success", func(t *testing.T) {
+		body := marshalJSONBody(t, gin.H{"ids": []string{bobID, "missing", aliceID, bobID}})
+		rr := performRequest(router, http.MethodPost, "/profiles/resolve", body, token)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		var resp ResolveProfilesResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, []ProfileSummary{
+			{ID: bobID, FirstName: "Bob", LastName: "Baker"},
+			{ID: aliceID, FirstName: "Alice", LastName: "Able"},
+		}, resp.Profiles)
+		assert.Equal(t, []string{"missing"}, resp.Missing)
+		assert.NotContains(t, rr.Body.String(), "resolve.bob@example.com", "summaries leave out the email")
+	})
+
+	t.Run("Invalid requests", func(t *testing.T) {
+		tooMany := make([]string, maxProfileIDs+1)
+		for i := range tooMany {
+			tooMany[i] = fmt.Sprintf("id%d", i)
+		}
+		for name, body := range map[string]interface{}{
+			"no ids":   gin.H{},
+			"empty":    gin.H{"ids": []string{}},
+			"too many": gin.H{"ids": tooMany},
+		} {
+			rr := performRequest(router, http.MethodPost, "/profiles/resolve", marshalJSONBody(t, body), token)
+			assert.Equal(t, http.StatusBadRequest, rr.Code, name)
+		}
+	})
+
+	t.Run("Requires authentication", func(t *testing.T) {
+		body := marshalJSONBody(t, gin.H{"ids": []string{aliceID}})
+		rr := performRequest(router, http.MethodPost, "/profiles/resolve", body, "")
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
+
+// --- Request Body Tests ---
+
+func TestRequestBodyDecoding(t *testing.T) {
+	router, _, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	_, _, token := createTestUserAndLogin(t, router, "body.user@example.com", "password123", "Body", "User")
+
+	t.Run("Too large", func(t *testing.T) {
+		cfg.MaxBodyBytes = 100
+		defer func() { cfg.MaxBodyBytes = 0 }()
+		rr := performRequest(router, http.MethodPost, "/v1/documents", marshalJSONBody(t, gin.H{"content": strings.Repeat("x", 200)}), token)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+		var resp utils.ErrorEnvelope
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, i18n.MsgRequestBodyTooLarge, resp.Error.MessageID)
+	})
+
+	t.Run("Type errors name the field", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPut, "/profiles/me", marshalJSONBody(t, gin.H{"first_name": 1, "last_name": "User"}), token)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "'first_name' must be a JSON string, not number")
+	})
+
+	t.Run("Unknown fields", func(t *testing.T) {
+		body := gin.H{"first_name": "Body", "last_name": "User", "nickname": "bod"}
+		rr := performRequest(router, http.MethodPut, "/profiles/me", marshalJSONBody(t, body), token)
+		assert.Equal(t, http.StatusOK, rr.Code, "ignored unless strict")
+
+		cfg.StrictJSON = true
+		defer func() { cfg.StrictJSON = false }()
+		rr = performRequest(router, http.MethodPut, "/profiles/me", marshalJSONBody(t, body), token)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "unknown field 'nickname'")
+	})
+}
+
+// --- Profile Privacy Tests ---
+
+func TestProfilePrivacy(t *testing.T) {
+	router, _, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	ownerID, _, ownerToken := createTestUserAndLogin(t, router, "private.owner@example.com", "password123", "Priv", "Owner")
+	friendID, _, friendToken := createTestUserAndLogin(t, router, "friend@example.com", "password123", "Friend", "User")
+	_, _, strangerToken := createTestUserAndLogin(t, router, "stranger@example.com", "password123", "Stranger", "User")
+
+	// findProfile searches by first name and returns the owner's entry as seen by the token holder
+	findProfile := func(token, query string) map[string]interface{} {
+		rr := performRequest(router, http.MethodGet, "/profiles?"+query, nil, token)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp struct {
+			Data []map[string]interface{} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		for _, p := range resp.Data {
+			if p["id"] == ownerID {
+				return p
+			}
+		}
+		return nil
+	}
+
+	t.Run("Default is public", func(t *testing.T) {
+		p := findProfile(strangerToken, "first_name=Priv")
+		require.NotNil(t, p)
+		assert.Equal(t, "private.owner@example.com", p["email"])
+		assert.NotContains(t, p, "privacy", "privacy settings are only shown to the owner")
+	})
+
+	t.Run("Invalid visibility rejected", func(t *testing.T) {
+		body := marshalJSONBody(t, map[string]interface{}{
+			"first_name": "Priv", "last_name": "Owner",
+			"privacy": map[string]string{"email": "friends"},
+		})
+		rr := performRequest(router, http.MethodPut, "/profiles/me", body, ownerToken)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	// Owner hides email from everyone except sharing contacts, and extra from everyone
+	body := marshalJSONBody(t, map[string]interface{}{
+		"first_name": "Priv", "last_name": "Owner",
+		"extra":   map[string]string{"phone": "555-0100"},
+		"privacy": map[string]string{"email": "sharers", "extra": "private"},
+	})
+	rr := performRequest(router, http.MethodPut, "/profiles/me", body, ownerToken)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	// Share a document with the friend to make them a sharing contact
+	docBody := marshalJSONBody(t, map[string]interface{}{"content": "hello"})
+	rr = performRequest(router, http.MethodPost, "/documents", docBody, ownerToken)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+	rr = performRequest(router, http.MethodPut, fmt.Sprintf("/documents/%s/shares/%s", doc["id"], friendID), nil, ownerToken)
+	require.Equal(t, http.StatusNoContent, rr.Code)
+
+	t.Run("Owner sees everything including settings", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/profiles/me", nil, ownerToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var me map[string]interface{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &me))
+		assert.Equal(t, "private.owner@example.com", me["email"])
+		assert.NotNil(t, me["extra"])
+		assert.Equal(t, map[string]interface{}{"email": "sharers", "extra": "private"}, me["privacy"])
+	})
+
+	t.Run("Stranger cannot see hidden fields", func(t *testing.T) {
+		p := findProfile(strangerToken, "first_name=Priv")
+		require.NotNil(t, p)
+		assert.NotContains(t, p, "email")
+		assert.NotContains(t, p, "extra")
+	})
+
+	t.Run("Stranger cannot find profile by hidden email", func(t *testing.T) {
+		assert.Nil(t, findProfile(strangerToken, "email=private.owner"))
+	})
+
+	t.Run("Sharing contact sees sharers fields only", func(t *testing.T) {
+		p := findProfile(friendToken, "email=private.owner")
+		require.NotNil(t, p)
+		assert.Equal(t, "private.owner@example.com", p["email"])
+		assert.NotContains(t, p, "extra")
+	})
+
+	t.Run("Omitting privacy keeps existing settings", func(t *testing.T) {
+		body := marshalJSONBody(t, map[string]interface{}{"first_name": "Priv", "last_name": "Renamed"})
+		rr := performRequest(router, http.MethodPut, "/profiles/me", body, ownerToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var me map[string]interface{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &me))
+		assert.Equal(t, map[string]interface{}{"email": "sharers", "extra": "private"}, me["privacy"])
+	})
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"docserver/authz"
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/utils"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Restricted Tokens ---
+
+// maxRestrictedTokenTTL is the longest a restricted token may last.
+const maxRestrictedTokenTTL = 30 * 24 * time.Hour
+
+// maxRestrictedTokenDocuments is the most documents a restricted token may be limited to.
+const maxRestrictedTokenDocuments = 100
+
+// RestrictedTokenRequest defines the body for minting a restricted token.
+type RestrictedTokenRequest struct {
+	Scopes    []string `json:"scopes" binding:"required"` // e.g. ["documents:read"]
+	Documents []string `json:"documents,omitempty"`       // IDs of the only documents the token reaches; any you can reach if omitted
+	TTL       string   `json:"ttl,omitempty"`             // Go duration, e.g. "2h"; the login token lifetime if omitted, at most 30 days
+}
+
+// RestrictedTokenResponse holds a restricted token.
+type RestrictedTokenResponse struct {
+	Token     string    `json:"token"`
+	Scopes    []string  `json:"scopes"`
+	Documents []string  `json:"documents,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CreateRestrictedTokenHandler mints a token that can do less than the caller's own.
+// @Summary      Create a Restricted Token
+// @Description  Mints a token for handing to a script or another person, which can only do part of what you can:
+// @Description  *   `scopes`: What it may do: `documents:read`, `documents:write`, `profiles:read` and `profiles:write`, where write includes read. Other endpoints refuse it with `403 Forbidden`.
+// @Description  *   `documents`: If given, the only documents it reaches, which you must be able to read. It can then only use the endpoints of those documents (`/documents/{id}...`), not list, search or create documents.
+// @Description  *   `ttl`: How long it lasts, by default as long as a login token and at most 30 days.
+// @Description
+// @Description  The token acts as you, within those limits, and stops working with yours: when your password changes, or when you revoke the device you logged in from. Restricted tokens cannot mint other tokens.
+// @Tags         Authentication
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        token  body      RestrictedTokenRequest  true  "The token's scopes, documents and lifetime."
+// @Success      201  {object}  utils.Envelope{data=RestrictedTokenResponse} "The token."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: A scope is unknown, a document ID is malformed, there are too many documents, or the ttl is invalid."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You cannot read one of the documents, or your token is itself restricted."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: One of the documents does not exist."
+// @Router       /auth/tokens [post]
+func CreateRestrictedTokenHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+	profile, found := database.GetProfileByID(userID.(string))
+	if !found {
+		utils.GinLocalizedError(c, http.StatusUnauthorized, i18n.MsgTokenAccountMissing)
+		return
+	}
+
+	var req RestrictedTokenRequest
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgInvalidRequestBody) {
+		return
+	}
+	scopes, err := utils.NormalizeScopes(req.Scopes)
+	if err != nil {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgRestrictedTokenInvalid, err)
+		return
+	}
+	documents := slices.Clone(req.Documents)
+	slices.Sort(documents)
+	documents = slices.Compact(documents)
+	if len(documents) > maxRestrictedTokenDocuments {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgRestrictedTokenDocuments, maxRestrictedTokenDocuments)
+		return
+	}
+	for _, docID := range documents {
+		if err := utils.ValidateID(docID, utils.IDKindDocument); err != nil {
+			utils.GinErrorFromErr(c, http.StatusBadRequest, err)
+			return
+		}
+		doc, found := database.GetDocumentByID(docID)
+		if !found {
+			utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgDocumentNotFound, docID)
+			return
+		}
+		if !can(c, database, cfg, authz.ReadDocument, doc) {
+			utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgTokenDocumentDenied, docID)
+			return
+		}
+	}
+	ttl := cfg.TokenLifetime
+	if req.TTL != "" {
+		ttl, err = time.ParseDuration(req.TTL)
+		if err != nil || ttl <= 0 || ttl > maxRestrictedTokenTTL {
+			utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgServiceTokenTTL, req.TTL, maxRestrictedTokenTTL)
+			return
+		}
+	}
+
+	token, err := utils.GenerateRestrictedJWT(&profile, c.GetString("deviceID"), scopes, documents, ttl, cfg)
+	if err != nil {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgTokenGenerateFailed)
+		return
+	}
+	utils.RespondData(c, http.StatusCreated, RestrictedTokenResponse{
+		Token:     token,
+		Scopes:    scopes,
+		Documents: documents,
+		ExpiresAt: cfg.Now().Add(ttl).UTC().Truncate(time.Second),
+	})
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestrictedTokens(t *testing.T) {
+	router, _, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	_, _, ownerToken := createTestUserAndLogin(t, router, "tokens.owner@example.com", "password123", "Tokens", "Owner")
+	_, _, otherToken := createTestUserAndLogin(t, router, "tokens.other@example.com", "password123", "Tokens", "Other")
+
+	createDoc := func(t *testing.T, token string, content gin.H) string {
+		t.Helper()
+		rr := performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, gin.H{"content": content}), token)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var doc DocumentResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+		return doc.ID
+	}
+	allowedID := createDoc(t, ownerToken, gin.H{"n": 1})
+	hiddenID := createDoc(t, ownerToken, gin.H{"n": 2})
+	strangerID := createDoc(t, otherToken, gin.H{"n": 3})
+
+	mint := func(t *testing.T, token string, body gin.H) (int, RestrictedTokenResponse) {
+		t.Helper()
+		rr := performRequest(router, http.MethodPost, "/auth/tokens", marshalJSONBody(t, body), token)
+		var resp RestrictedTokenResponse
+		if rr.Code == http.StatusCreated {
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		}
+		return rr.Code, resp
+	}
+
+	t.Run("Minting is validated", func(t *testing.T) {
+		code, _ := mint(t, ownerToken, gin.H{"scopes": []string{}})
+		assert.Equal(t, http.StatusBadRequest, code)
+		code, _ = mint(t, ownerToken, gin.H{"scopes": []string{"documents:read"}, "ttl": "800h"})
+		assert.Equal(t, http.StatusBadRequest, code)
+		code, _ = mint(t, ownerToken, gin.H{"scopes": []string{"documents:read"}, "documents": []string{"doc_missing"}})
+		assert.Equal(t, http.StatusNotFound, code)
+		code, _ = mint(t, ownerToken, gin.H{"scopes": []string{"documents:read"}, "documents": []string{strangerID}})
+		assert.Equal(t, http.StatusForbidden, code, "only documents you can read")
+		code, _ = mint(t, "", gin.H{"scopes": []string{"documents:read"}})
+		assert.Equal(t, http.StatusUnauthorized, code)
+	})
+
+	code, restricted := mint(t, ownerToken, gin.H{"scopes": []string{"documents:read"}, "documents": []string{allowedID, allowedID}})
+	require.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, []string{allowedID}, restricted.Documents)
+
+	t.Run("Only the listed documents", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/documents/"+allowedID, nil, restricted.Token)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		rr = performRequest(router, http.MethodGet, "/documents/"+hiddenID, nil, restricted.Token)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		rr = performRequest(router, http.MethodGet, "/documents", nil, restricted.Token)
+		assert.Equal(t, http.StatusForbidden, rr.Code, "no listing")
+		rr = performRequest(router, http.MethodPut, "/documents/"+allowedID, marshalJSONBody(t, gin.H{"content": gin.H{"n": 9}}), restricted.Token)
+		assert.Equal(t, http.StatusForbidden, rr.Code, "documents:read does not write")
+	})
+
+	t.Run("Restricted tokens cannot do more", func(t *testing.T) {
+		code, _ := mint(t, restricted.Token, gin.H{"scopes": []string{"documents:write"}})
+		assert.Equal(t, http.StatusForbidden, code)
+		rr := performRequest(router, http.MethodGet, "/profiles/me/export", nil, restricted.Token)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("Scopes without documents", func(t *testing.T) {
+		code, writer := mint(t, ownerToken, gin.H{"scopes": []string{"documents:write", "profiles:read"}, "ttl": "10m"})
+		require.Equal(t, http.StatusCreated, code)
+		rr := performRequest(router, http.MethodGet, "/documents", nil, writer.Token)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		rr = performRequest(router, http.MethodPut, "/documents/"+hiddenID, marshalJSONBody(t, gin.H{"content": gin.H{"n": 9}}), writer.Token)
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		rr = performRequest(router, http.MethodGet, "/profiles/me", nil, writer.Token)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		rr = performRequest(router, http.MethodPut, "/profiles/me", marshalJSONBody(t, gin.H{"first_name": "X", "last_name": "Y"}), writer.Token)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+}
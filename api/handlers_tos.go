@@ -0,0 +1,140 @@
+package api
+
+import (
+	"docserver/apperr"
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/models"
+	"docserver/utils"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Terms of Service ---
+
+// TosStatus reports whether a user has accepted the current terms of service.
+// It is only included in responses when the server has a terms of service version configured.
+type TosStatus struct {
+	Version         string     `json:"version"`                    // Current terms of service version
+	URL             string     `json:"url,omitempty"`              // Where the current terms can be read
+	Accepted        bool       `json:"accepted"`                   // Whether the user accepted the current version
+	AcceptedVersion string     `json:"accepted_version,omitempty"` // Latest version the user accepted, if any
+	AcceptedAt      *time.Time `json:"accepted_at,omitempty"`      // When the user accepted AcceptedVersion
+}
+
+// hasAcceptedTos reports whether the profile accepted the currently configured terms of service.
+// Always true when no terms of service are configured.
+func hasAcceptedTos(profile models.Profile, cfg *config.Config) bool {
+	if cfg.TosVersion == "" {
+		return true
+	}
+	return profile.TosAcceptance != nil && profile.TosAcceptance.Version == cfg.TosVersion
+}
+
+// tosStatusFor builds the terms of service status of a profile, or nil if no terms are configured.
+func tosStatusFor(profile models.Profile, cfg *config.Config) *TosStatus {
+	if cfg.TosVersion == "" {
+		return nil
+	}
+	status := &TosStatus{
+		Version:  cfg.TosVersion,
+		URL:      cfg.TosURL,
+		Accepted: hasAcceptedTos(profile, cfg),
+	}
+	if profile.TosAcceptance != nil {
+		acceptedAt := profile.TosAcceptance.AcceptedAt
+		status.AcceptedVersion = profile.TosAcceptance.Version
+		status.AcceptedAt = &acceptedAt
+	}
+	return status
+}
+
+// RequireTosMiddleware rejects requests from users who have not accepted the current terms of service
+// with 403 Forbidden. It does nothing unless the server is configured with both a terms of service
+// version and -require-tos. Must run after utils.AuthMiddleware.
+func RequireTosMiddleware(database *db.Database, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !cfg.RequireTos || cfg.TosVersion == "" {
+			c.Next()
+			return
+		}
+
+		userID, exists := c.Get("userID")
+		if !exists {
+			utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+			return
+		}
+		profile, found := database.GetProfileByID(userID.(string))
+		if !found {
+			utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgProfileNotFound)
+			return
+		}
+		if !hasAcceptedTos(profile, cfg) {
+			utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgTosNotAccepted, cfg.TosVersion)
+			return
+		}
+		c.Next()
+	}
+}
+
+// AcceptTosRequest defines the optional JSON body for accepting the terms of service.
+type AcceptTosRequest struct {
+	Version string `json:"version,omitempty"` // Version being accepted; defaults to the current version
+}
+
+// AcceptTosHandler records that the authenticated user accepted the current terms of service.
+// @Summary      Accept the Terms of Service
+// @Description  Records that you accept the server's current terms of service, along with the time of acceptance.
+// @Description
+// @Description  You may send the `version` you are accepting; it must match the current version, so you never accept terms you have not seen. If omitted, the current version is accepted.
+// @Description  When the server requires acceptance, document and profile search endpoints return `403 Forbidden` until you have accepted the current version.
+// @Tags         Profiles
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        tos body AcceptTosRequest false "The terms of service version being accepted."
+// @Success      200  {object}  utils.Envelope{data=TosStatus} "Acceptance recorded."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The request body is invalid, or the server has no terms of service."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: Your profile could not be found."
+// @Failure      409  {object}  utils.ErrorEnvelope "Conflict: The version you sent is not the current terms of service version."
+// @Failure      500  {object}  utils.ErrorEnvelope "Internal Server Error: The acceptance could not be recorded."
+// @Router       /profiles/me/accept-tos [post]
+func AcceptTosHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+	userIDStr := userID.(string)
+
+	var req AcceptTosRequest // The body is optional
+	if !utils.BindOptionalJSON(c, cfg, &req, i18n.MsgInvalidRequestBody) {
+		return
+	}
+
+	if cfg.TosVersion == "" {
+		utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgTosNotConfigured)
+		return
+	}
+	if req.Version != "" && req.Version != cfg.TosVersion {
+		utils.GinLocalizedError(c, http.StatusConflict, i18n.MsgTosVersionMismatch, req.Version, cfg.TosVersion)
+		return
+	}
+
+	profile, err := database.AcceptTos(userIDStr, cfg.TosVersion)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			utils.GinLocalizedError(c, http.StatusNotFound, i18n.MsgProfileNotFound)
+		} else {
+			utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgTosAcceptFailed, err)
+		}
+		return
+	}
+
+	utils.RespondData(c, http.StatusOK, tosStatusFor(profile, cfg))
+}
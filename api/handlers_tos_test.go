@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTermsOfService(t *testing.T) {
+	router, _, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	_, _, token := createTestUserAndLogin(t, router, "tos.user@example.com", "password123", "Tos", "User")
+
+	t.Run("Not configured", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/profiles/me/accept-tos", nil, token)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = performRequest(router, http.MethodPost, "/auth/login", marshalJSONBody(t, gin.H{"email": "tos.user@example.com", "password": "password123"}), "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.NotContains(t, rr.Body.String(), `"tos"`, "no tos status without configured terms")
+	})
+
+	cfg.TosVersion = "2024-01"
+	cfg.TosURL = "https://example.com/tos"
+	cfg.RequireTos = true
+
+	t.Run("Login reports pending acceptance", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/auth/login", marshalJSONBody(t, gin.H{"email": "tos.user@example.com", "password": "password123"}), "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp LoginResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.NotNil(t, resp.Tos)
+		assert.Equal(t, "2024-01", resp.Tos.Version)
+		assert.Equal(t, "https://example.com/tos", resp.Tos.URL)
+		assert.False(t, resp.Tos.Accepted)
+	})
+
+	t.Run("Blocked until accepted", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/documents", nil, token)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		rr = performRequest(router, http.MethodGet, "/profiles", nil, token)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+
+		rr = performRequest(router, http.MethodGet, "/profiles/me", nil, token)
+		assert.Equal(t, http.StatusOK, rr.Code, "own profile stays available")
+	})
+
+	t.Run("Wrong version rejected", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/profiles/me/accept-tos", marshalJSONBody(t, gin.H{"version": "2023-01"}), token)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("Accept", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/profiles/me/accept-tos", marshalJSONBody(t, gin.H{"version": "2024-01"}), token)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var status TosStatus
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &status))
+		assert.True(t, status.Accepted)
+		assert.Equal(t, "2024-01", status.AcceptedVersion)
+		require.NotNil(t, status.AcceptedAt)
+
+		rr = performRequest(router, http.MethodGet, "/documents", nil, token)
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("New version requires acceptance again", func(t *testing.T) {
+		cfg.TosVersion = "2025-01"
+		rr := performRequest(router, http.MethodGet, "/documents", nil, token)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+
+		rr = performRequest(router, http.MethodPost, "/profiles/me/accept-tos", nil, token)
+		require.Equal(t, http.StatusOK, rr.Code, "body is optional")
+		rr = performRequest(router, http.MethodGet, "/documents", nil, token)
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("Accept at signup", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/auth/signup", marshalJSONBody(t, gin.H{
+			"email": "tos.signup@example.com", "password": "password123",
+			"first_name": "Tos", "last_name": "Signup", "accept_tos": true,
+		}), "")
+		require.Equal(t, http.StatusCreated, rr.Code)
+		var resp SignupResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.NotNil(t, resp.Tos)
+		assert.True(t, resp.Tos.Accepted)
+		assert.Equal(t, "2025-01", resp.Tos.AcceptedVersion)
+	})
+}
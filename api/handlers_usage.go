@@ -0,0 +1,88 @@
+package api
+
+import (
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- API Usage ---
+
+// APIUsageMiddleware counts each request of a logged-in user, with its request and response body
+// sizes, against the route it matched (e.g. "GET /documents/:id"). prefix is the version prefix
+// the routes are mounted under ("/v1", or "" for legacy paths), so both count as the same route.
+// Requests without a login or a matching route are not counted.
+func APIUsageMiddleware(database *db.Database, cfg *config.Config, prefix string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		userID, exists := c.Get("userID")
+		if !exists || c.FullPath() == "" {
+			return
+		}
+		route := c.Request.Method + " " + strings.TrimPrefix(c.FullPath(), prefix)
+		database.RecordAPIUsage(userID.(string), route, c.Writer.Status(), c.Request.ContentLength, int64(c.Writer.Size()), cfg.Now())
+	}
+}
+
+// ProfileAPIUsage is a profile's API usage with who the profile is.
+type ProfileAPIUsage struct {
+	db.APIUsageReport
+	Email     string `json:"email"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Group     string `json:"group,omitempty"`
+}
+
+// GetMyAPIUsageHandler reports the authenticated user's API usage.
+// @Summary      Get Your API Usage
+// @Description  Reports how many requests you have made since your account was created, how many failed (4xx or 5xx), and how many bytes you sent and received, in total and for each route (such as `GET /documents/:id`), most used first. Requests made under `/v1` and the legacy paths count together. Requests made in the last minute may take that long to be saved, but are already included.
+// @Tags         Profiles
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  utils.Envelope{data=db.APIUsageReport} "Your API usage."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Router       /profiles/me/usage/api [get]
+func GetMyAPIUsageHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+	utils.RespondData(c, http.StatusOK, database.GetAPIUsage(userID.(string)))
+}
+
+// ListAPIUsageHandler reports the API usage of every profile.
+// @Summary      List API Usage by Profile (Admin)
+// @Description  Reports the API usage of every profile, busiest first, in the form of `GET /profiles/me/usage/api` and with each profile's email, name and group. Profiles that never made a request are listed last with zero counts and no `last_request_at`, so you can see both who is hammering the server and who has not started yet. Pass `group` to see only the accounts of one class (see `POST /admin/provision`). Administrators only.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        group  query     string  false  "Only profiles in this group."
+// @Success      200  {object}  utils.Envelope{data=[]ProfileAPIUsage} "API usage by profile."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: You are not an administrator."
+// @Router       /admin/usage/api [get]
+func ListAPIUsageHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	group := c.Query("group")
+	usage := make([]ProfileAPIUsage, 0)
+	for _, report := range database.ListAPIUsage() {
+		profile, found := database.GetProfileByID(report.ProfileID)
+		if !found || (group != "" && profile.Group != group) {
+			continue
+		}
+		usage = append(usage, ProfileAPIUsage{
+			APIUsageReport: report,
+			Email:          profile.Email,
+			FirstName:      profile.FirstName,
+			LastName:       profile.LastName,
+			Group:          profile.Group,
+		})
+	}
+	utils.RespondData(c, http.StatusOK, usage)
+}
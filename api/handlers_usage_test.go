@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"docserver/db"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIUsage(t *testing.T) {
+	router, database, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	cfg.AdminEmails = []string{"usage.admin@example.com"}
+
+	_, _, adminToken := createTestUserAndLogin(t, router, "usage.admin@example.com", "password123", "Usage", "Admin")
+	studentID, _, studentToken := createTestUserAndLogin(t, router, "usage.student@example.com", "password123", "Usage", "Student")
+	idleID, _, _ := createTestUserAndLogin(t, router, "usage.idle@example.com", "password123", "Usage", "Idle")
+
+	rr := performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, map[string]any{"content": map[string]any{"a": 1}}), studentToken)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	performRequest(router, http.MethodGet, "/v1/documents", nil, studentToken)
+	performRequest(router, http.MethodGet, "/documents", nil, studentToken)
+	performRequest(router, http.MethodGet, "/documents/doc_missing", nil, studentToken)
+
+	t.Run("Own usage", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/profiles/me/usage/api", nil, studentToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var report db.APIUsageReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		assert.Equal(t, studentID, report.ProfileID)
+		assert.Equal(t, int64(4), report.Requests, "the usage request itself is counted once it is answered")
+		assert.Equal(t, int64(1), report.Errors)
+		assert.Positive(t, report.RequestBytes)
+		require.NotEmpty(t, report.Routes)
+		assert.Equal(t, "GET /documents", report.Routes[0].Route, "versioned and legacy paths count together")
+		assert.Equal(t, int64(2), report.Routes[0].Requests)
+	})
+
+	t.Run("Admin roll-up", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/admin/usage/api", nil, studentToken)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+
+		database.FlushAPIUsage()
+		rr = performRequest(router, http.MethodGet, "/admin/usage/api", nil, adminToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var usage []ProfileAPIUsage
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &usage))
+		require.Len(t, usage, 3)
+		assert.Equal(t, studentID, usage[0].ProfileID)
+		assert.Equal(t, "usage.student@example.com", usage[0].Email)
+		idle := usage[1:] // The administrator's own request is only counted once answered
+		assert.Contains(t, []string{idle[0].ProfileID, idle[1].ProfileID}, idleID)
+		assert.Zero(t, idle[0].Requests)
+		assert.Zero(t, idle[1].Requests)
+
+		rr = performRequest(router, http.MethodGet, "/admin/usage/api?group=cs101", nil, adminToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &usage))
+		assert.Empty(t, usage)
+	})
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"docserver/apperr"
+	"docserver/config"
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Submission Workflow ---
+
+// WorkflowTransitionRequest asks to move a document to another workflow state.
+type WorkflowTransitionRequest struct {
+	State      string `json:"state" binding:"required" example:"submitted"`          // draft, submitted, approved or rejected
+	ReviewerID string `json:"reviewer_id,omitempty"`                                 // When submitting: the only profile that may approve or reject
+	Comment    string `json:"comment,omitempty" example:"Please cite your sources."` // Recorded with the transition
+}
+
+// TransitionWorkflowHandler moves a document through the submission workflow.
+// @Summary      Submit, Approve or Reject a Document
+// @Description  Moves a document through the optional submission workflow, whose current state and history are shown in the document's `workflow` field. Documents start as `draft`:
+// @Description  *   The owner submits a draft (`"state": "submitted"`), optionally naming a `reviewer_id`, who must be a profile the document is shared with. The owner can withdraw a submission back to `draft`.
+// @Description  *   The reviewer approves (`approved`) or rejects (`rejected`) a submitted document, optionally with a `comment`. If the owner named a reviewer, only they can decide; otherwise anyone the document is shared with can. Administrators can always decide.
+// @Description  *   The owner can resubmit a rejected document, or move it back to `draft` to rework it. Approved documents stay approved.
+// @Description
+// @Description  Every transition is recorded with who made it, when, the content version it applied to and the comment. List documents by state with `GET /documents?workflow_state=submitted`.
+// @Tags         Documents
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string                    true  "The unique identifier of the document." example(doc_abc123xyz)
+// @Param        request  body      WorkflowTransitionRequest true  "The state to move to."
+// @Success      200  {object}  utils.Envelope{data=models.Document} "The document in its new state."
+// @Failure      400  {object}  utils.ErrorEnvelope "Bad Request: The state is unknown, or the reviewer is not a profile the document is shared with."
+// @Failure      401  {object}  utils.ErrorEnvelope "Unauthorized: Your access token is missing, invalid, or expired."
+// @Failure      403  {object}  utils.ErrorEnvelope "Forbidden: Only the owner can submit, withdraw or reopen the document, and only its reviewer can approve or reject it."
+// @Failure      404  {object}  utils.ErrorEnvelope "Not Found: No document exists with the specified ID."
+// @Failure      409  {object}  utils.ErrorEnvelope "Conflict: The document cannot move from its current state to the requested one."
+// @Router       /documents/{id}/workflow [post]
+func TransitionWorkflowHandler(c *gin.Context, database *db.Database, cfg *config.Config) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+		return
+	}
+	userIDStr := userID.(string)
+
+	var req WorkflowTransitionRequest
+	if !utils.BindJSON(c, cfg, &req, i18n.MsgWorkflowInvalidBody) {
+		return
+	}
+	change := db.WorkflowChange{
+		DocumentID: c.Param("id"),
+		State:      strings.ToLower(strings.TrimSpace(req.State)),
+		ActorID:    userIDStr,
+		ReviewerID: strings.TrimSpace(req.ReviewerID),
+		Comment:    strings.TrimSpace(req.Comment),
+	}
+	if profile, found := database.GetProfileByID(userIDStr); found {
+		change.ActorIsAdmin = isAdmin(profile, cfg)
+	}
+
+	doc, err := database.TransitionDocumentWorkflow(change)
+	if err != nil {
+		utils.GinErrorFromErr(c, apperr.HTTPStatus(err), err)
+		return
+	}
+	utils.RespondData(c, http.StatusOK, doc)
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"docserver/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentWorkflow(t *testing.T) {
+	router, _, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	studentID, _, studentToken := createTestUserAndLogin(t, router, "workflow.student@example.com", "password123", "Workflow", "Student")
+	teacherID, _, teacherToken := createTestUserAndLogin(t, router, "workflow.teacher@example.com", "password123", "Workflow", "Teacher")
+
+	rr := performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, map[string]any{"content": map[string]any{"essay": "..."}}), studentToken)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var doc models.Document
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+	rr = performRequest(router, http.MethodPut, "/documents/"+doc.ID+"/shares/"+teacherID, nil, studentToken)
+	require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+
+	transition := func(token string, body map[string]any) (int, models.Document) {
+		rr := performRequest(router, http.MethodPost, "/documents/"+doc.ID+"/workflow", marshalJSONBody(t, body), token)
+		var doc models.Document
+		if rr.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+		}
+		return rr.Code, doc
+	}
+	listed := func(token, query string) []string {
+		rr := performRequest(router, http.MethodGet, "/documents?"+query, nil, token)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var body struct {
+			Data []models.Document `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		var ids []string
+		for _, doc := range body.Data {
+			ids = append(ids, doc.ID)
+		}
+		return ids
+	}
+
+	code, _ := transition(studentToken, map[string]any{"state": "approved"})
+	assert.Equal(t, http.StatusConflict, code)
+	code, _ = transition(studentToken, map[string]any{"state": "graded"})
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = transition(teacherToken, map[string]any{"state": "submitted"})
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code, submitted := transition(studentToken, map[string]any{"state": "submitted", "reviewer_id": teacherID})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, models.WorkflowStateSubmitted, submitted.Workflow.State)
+	assert.Equal(t, []string{doc.ID}, listed(teacherToken, "scope=shared&workflow_state=submitted"))
+	assert.Empty(t, listed(studentToken, "workflow_state=draft"))
+
+	code, _ = transition(studentToken, map[string]any{"state": "approved"})
+	assert.Equal(t, http.StatusForbidden, code, "owners cannot approve their own work")
+	code, approved := transition(teacherToken, map[string]any{"state": "Approved", "comment": "Well done"})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, models.WorkflowStateApproved, approved.Workflow.State)
+	require.Len(t, approved.Workflow.Transitions, 2)
+	assert.Equal(t, studentID, approved.Workflow.Transitions[0].ActorID)
+	assert.Equal(t, teacherID, approved.Workflow.Transitions[1].ActorID)
+	assert.Equal(t, "Well done", approved.Workflow.Transitions[1].Comment)
+	assert.Equal(t, []string{doc.ID}, listed(studentToken, "workflow_state=approved"))
+
+	rr = performRequest(router, http.MethodGet, "/documents?workflow_state=graded", nil, studentToken)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -0,0 +1,314 @@
+package api
+
+import (
+	"bytes"
+	"docserver/config"
+	"docserver/db"
+	"docserver/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Request Journal (Record and Replay) ---
+
+// JournalEntry is one request recorded with -record-file, one JSON object per line of the journal.
+type JournalEntry struct {
+	Seq    int         `json:"seq"`              // Position in the journal, from 1
+	Time   time.Time   `json:"time"`             // When the request arrived, on the server's clock
+	Method string      `json:"method"`           // e.g. "POST"
+	URI    string      `json:"uri"`              // Path and query as sent, e.g. "/v1/documents?x=1"
+	Header http.Header `json:"header,omitempty"` // Request headers, including Authorization
+	Body   []byte      `json:"body,omitempty"`   // Request body (base64 in the journal)
+	Status int         `json:"status"`           // Status the server answered with
+	IDs    []string    `json:"ids,omitempty"`    // IDs generated while serving it, in order
+}
+
+// isMutatingMethod reports whether requests with method may change the database and so are recorded.
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// recordingIDs is the database's ID source while recording: it generates IDs as the source it
+// replaces would and notes them while a request is being recorded.
+type recordingIDs struct {
+	next db.IDSource // nil = random IDs (see utils.NewID)
+
+	mu        sync.Mutex
+	recording bool
+	ids       []string
+}
+
+// NewID generates an ID and notes it if a request is being recorded.
+func (r *recordingIDs) NewID(strategy string, kind utils.IDKind, now time.Time) string {
+	var id string
+	if r.next != nil {
+		id = r.next.NewID(strategy, kind, now)
+	} else {
+		id = utils.NewID(strategy, kind)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.recording {
+		r.ids = append(r.ids, id)
+	}
+	return id
+}
+
+// start begins noting IDs.
+func (r *recordingIDs) start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.recording, r.ids = true, nil
+}
+
+// stop stops noting IDs and returns those noted since start.
+func (r *recordingIDs) stop() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := r.ids
+	r.recording, r.ids = false, nil
+	return ids
+}
+
+// Recorder appends every mutating request to a journal (see -record-file). Mutating requests are
+// served one at a time while recording, so the IDs each one generates can be told apart.
+type Recorder struct {
+	cfg *config.Config
+	ids *recordingIDs
+
+	mu      sync.Mutex // Held while a mutating request is served and written
+	file    *os.File
+	encoder *json.Encoder
+	seq     int
+}
+
+// NewRecorder opens the journal at path, creating it if needed (readable by the owner only, as it
+// holds passwords and tokens), and makes database note the IDs it generates. Entries are appended
+// after any already in the journal.
+func NewRecorder(path string, database *db.Database, cfg *config.Config) (*Recorder, error) {
+	entries, err := countJournalEntries(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal '%s': %w", path, err)
+	}
+	ids := &recordingIDs{next: database.IDSource()}
+	database.SetIDSource(ids)
+	log.Printf("INFO: Recording mutating requests to %s (%d entries so far)", path, entries)
+	return &Recorder{cfg: cfg, ids: ids, file: file, encoder: json.NewEncoder(file), seq: entries}, nil
+}
+
+// countJournalEntries returns how many entries the journal at path holds (0 if it does not exist).
+func countJournalEntries(path string) (int, error) {
+	count := 0
+	err := readJournal(path, func(JournalEntry) error {
+		count++
+		return nil
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	return count, err
+}
+
+// Middleware records each POST, PUT, PATCH and DELETE request that matches a route, with the
+// time it arrived, the status it got and the IDs it generated. Other requests pass untouched.
+func (r *Recorder) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isMutatingMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			if body, err = io.ReadAll(c.Request.Body); err != nil {
+				log.Printf("WARN: Failed to read request body for the journal: %v", err)
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		at := r.cfg.Now().UTC()
+		r.ids.start()
+		c.Next()
+		ids := r.ids.stop()
+		if c.FullPath() == "" {
+			return // No route: nothing can have changed
+		}
+		if isCourseRequest(c) {
+			return // Course databases are not journaled
+		}
+
+		header := c.Request.Header.Clone()
+		header.Del("Content-Length")
+		entry := JournalEntry{
+			Seq:    r.seq + 1,
+			Time:   at,
+			Method: c.Request.Method,
+			URI:    c.Request.URL.RequestURI(),
+			Header: header,
+			Body:   body,
+			Status: c.Writer.Status(),
+			IDs:    ids,
+		}
+		if err := r.encoder.Encode(entry); err != nil {
+			log.Printf("ERROR: Failed to write request %d to the journal: %v", entry.Seq, err)
+			return
+		}
+		r.seq++
+	}
+}
+
+// Close closes the journal.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// readJournal calls fn with each entry of the journal at path, in order, stopping at the first error.
+func readJournal(path string, fn func(JournalEntry) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	decoder := json.NewDecoder(file)
+	for line := 1; ; line++ {
+		var entry JournalEntry
+		if err := decoder.Decode(&entry); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("journal '%s', entry %d: %w", path, line, err)
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+}
+
+// replayIDs is the database's ID source while replaying: it hands out the IDs a request generated
+// when it was recorded, so later requests find what it created under the same IDs.
+type replayIDs struct {
+	mu    sync.Mutex
+	queue []string
+}
+
+// NewID returns the next recorded ID, or a random one once they are used up or if the next does
+// not suit kind (the replay took another course than the recording).
+func (r *replayIDs) NewID(strategy string, kind utils.IDKind, now time.Time) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.queue) > 0 {
+		id := r.queue[0]
+		r.queue = r.queue[1:]
+		if utils.ValidateID(id, kind) == nil {
+			return id
+		}
+	}
+	return utils.NewID(strategy, kind)
+}
+
+// load replaces the IDs to hand out.
+func (r *replayIDs) load(ids []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queue = ids
+}
+
+// ReplayMismatch is a replayed request that got another status than when it was recorded.
+type ReplayMismatch struct {
+	Seq      int    `json:"seq"`
+	Method   string `json:"method"`
+	URI      string `json:"uri"`
+	Recorded int    `json:"recorded_status"`
+	Replayed int    `json:"replayed_status"`
+}
+
+// ReplayReport summarizes a replay.
+type ReplayReport struct {
+	Journal    string           `json:"journal"`
+	Replayed   int              `json:"replayed"` // Requests sent again
+	Skipped    int              `json:"skipped"`  // Requests made after the replay-until instant
+	Until      time.Time        `json:"until,omitempty"`
+	Mismatches []ReplayMismatch `json:"mismatches"`
+}
+
+// String describes the report for the console.
+func (r ReplayReport) String() string {
+	out := fmt.Sprintf("Replayed %d request(s) from '%s'", r.Replayed, r.Journal)
+	if r.Skipped > 0 {
+		out += fmt.Sprintf(", skipping %d made after %s", r.Skipped, r.Until.Format(time.RFC3339))
+	}
+	if len(r.Mismatches) == 0 {
+		return out + ". Every request got the status it was recorded with."
+	}
+	out += fmt.Sprintf(". %d request(s) got another status than when recorded:\n", len(r.Mismatches))
+	for _, m := range r.Mismatches {
+		out += fmt.Sprintf("  - #%d %s %s: recorded %d, replayed %d\n", m.Seq, m.Method, m.URI, m.Recorded, m.Replayed)
+	}
+	return out
+}
+
+// ReplayJournal sends the requests of the journal at path to handler again, in order, to rebuild
+// the state they left in database. Each request is served with the clock frozen at the time it was
+// recorded and is given the IDs it generated then, so tokens stay valid and later requests find
+// what earlier ones created. Requests made after until are skipped unless until is zero. cfg's
+// clock and database's ID source are restored afterwards.
+func ReplayJournal(handler http.Handler, database *db.Database, cfg *config.Config, path string, until time.Time) (ReplayReport, error) {
+	report := ReplayReport{Journal: path, Until: until, Mismatches: []ReplayMismatch{}}
+
+	clock := config.NewFixedClock(cfg.Now())
+	previousClock := cfg.Clock
+	cfg.Clock = clock
+	defer func() { cfg.Clock = previousClock }()
+	ids := &replayIDs{}
+	previousIDs := database.IDSource()
+	database.SetIDSource(ids)
+	defer database.SetIDSource(previousIDs)
+
+	err := readJournal(path, func(entry JournalEntry) error {
+		if !until.IsZero() && entry.Time.After(until) {
+			report.Skipped++
+			return nil
+		}
+		clock.Set(entry.Time)
+		ids.load(entry.IDs)
+		request, err := http.NewRequest(entry.Method, entry.URI, bytes.NewReader(entry.Body))
+		if err != nil {
+			return fmt.Errorf("journal '%s', request %d: %w", path, entry.Seq, err)
+		}
+		request.Header = entry.Header.Clone()
+		if request.Header == nil {
+			request.Header = http.Header{}
+		}
+		request.RemoteAddr = "127.0.0.1:0"
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+
+		report.Replayed++
+		if response.Code != entry.Status {
+			report.Mismatches = append(report.Mismatches, ReplayMismatch{Seq: entry.Seq, Method: entry.Method, URI: entry.URI, Recorded: entry.Status, Replayed: response.Code})
+		}
+		return nil
+	})
+	return report, err
+}
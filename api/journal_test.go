@@ -0,0 +1,165 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"docserver/config"
+	"docserver/db"
+	"docserver/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newJournalServer returns a router and database using a database file in dir, recording to
+// journal unless it is empty.
+func newJournalServer(t *testing.T, dir, journal string) (*gin.Engine, *db.Database, *config.Config) {
+	cfg := &config.Config{
+		DbFilePath:    filepath.Join(dir, "db.json"),
+		SaveInterval:  10 * time.Millisecond,
+		JwtSecret:     testJWTSecret,
+		TokenLifetime: time.Hour,
+		BcryptCost:    4,
+		IDStrategy:    config.IDStrategyPrefixed,
+	}
+	database, err := db.NewDatabase(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = database.Close() })
+	router := gin.New()
+	if journal != "" {
+		recorder, err := NewRecorder(journal, database, cfg)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = recorder.Close() })
+		router.Use(recorder.Middleware())
+	}
+	RegisterRoutes(router, database, cfg)
+	return router, database, cfg
+}
+
+func readJournalEntries(t *testing.T, path string) []JournalEntry {
+	var entries []JournalEntry
+	require.NoError(t, readJournal(path, func(entry JournalEntry) error {
+		entries = append(entries, entry)
+		return nil
+	}))
+	return entries
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	journal := filepath.Join(t.TempDir(), "journal.jsonl")
+	router, database, _ := newJournalServer(t, t.TempDir(), journal)
+
+	userID, _, token := createTestUserAndLogin(t, router, "record@example.com", "password123", "Re", "Cord")
+	create := func(path string, content any) models.Document {
+		rr := performRequest(router, http.MethodPost, path, marshalJSONBody(t, gin.H{"content": content}), token)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var doc models.Document
+		if path == "/documents" {
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+		} else {
+			var body struct{ Data models.Document }
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			doc = body.Data
+		}
+		return doc
+	}
+	first := create("/documents", map[string]any{"step": 1})
+	time.Sleep(20 * time.Millisecond) // So the two documents can be told apart by time
+	second := create("/v1/documents", map[string]any{"step": 2})
+	rr := performRequest(router, http.MethodPut, "/documents/"+first.ID, marshalJSONBody(t, gin.H{"content": map[string]any{"step": 3}}), token)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	rr = performRequest(router, http.MethodDelete, "/documents/"+second.ID, nil, token)
+	require.Equal(t, http.StatusNoContent, rr.Code)
+	performRequest(router, http.MethodGet, "/documents/"+first.ID, nil, token)       // Reads are not recorded
+	performRequest(router, http.MethodPost, "/no-such-route", nil, token)            // Nor requests without a route
+	rr = performRequest(router, http.MethodGet, "/documents/"+second.ID, nil, token) // Gone
+	require.Equal(t, http.StatusNotFound, rr.Code)
+
+	entries := readJournalEntries(t, journal)
+	require.Len(t, entries, 6, "signup, login, two creations, an update and a deletion")
+	for i, entry := range entries {
+		assert.Equal(t, i+1, entry.Seq)
+	}
+	assert.Equal(t, "/auth/signup", entries[0].URI)
+	assert.Equal(t, []string{userID}, entries[0].IDs)
+	assert.Equal(t, []string{first.ID}, entries[2].IDs)
+	assert.Equal(t, "/v1/documents", entries[3].URI)
+	assert.Equal(t, http.StatusNoContent, entries[5].Status)
+	assert.Equal(t, "Bearer "+token, entries[5].Header.Get("Authorization"))
+	info, err := os.Stat(journal)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "the journal holds passwords and tokens")
+
+	t.Run("Replay rebuilds the database", func(t *testing.T) {
+		replayRouter, replayed, replayCfg := newJournalServer(t, t.TempDir(), "")
+		report, err := ReplayJournal(replayRouter, replayed, replayCfg, journal, time.Time{})
+		require.NoError(t, err)
+		assert.Equal(t, 6, report.Replayed)
+		assert.Empty(t, report.Mismatches, report.String())
+		assert.Nil(t, replayCfg.Clock, "the clock is restored")
+
+		profile, found := replayed.GetProfileByID(userID)
+		require.True(t, found, "the profile has its recorded ID")
+		original, _ := database.GetProfileByID(userID)
+		assert.Equal(t, original.Email, profile.Email)
+		doc, found := replayed.GetDocumentByID(first.ID)
+		require.True(t, found)
+		assert.Equal(t, map[string]any{"step": float64(3)}, doc.Content)
+		assert.WithinDuration(t, first.CreationDate, doc.CreationDate, 10*time.Millisecond, "stamped with the recorded time")
+		_, found = replayed.GetDocumentByID(second.ID)
+		assert.False(t, found)
+	})
+
+	t.Run("Replay until an instant", func(t *testing.T) {
+		replayRouter, replayed, replayCfg := newJournalServer(t, t.TempDir(), "")
+		report, err := ReplayJournal(replayRouter, replayed, replayCfg, journal, entries[3].Time.Add(-time.Millisecond))
+		require.NoError(t, err)
+		assert.Equal(t, 3, report.Replayed)
+		assert.Equal(t, 3, report.Skipped)
+		assert.Contains(t, report.String(), "skipping 3")
+
+		doc, found := replayed.GetDocumentByID(first.ID)
+		require.True(t, found)
+		assert.Equal(t, map[string]any{"step": float64(1)}, doc.Content, "as it was before the update")
+		_, found = replayed.GetDocumentByID(second.ID)
+		assert.False(t, found, "not created yet")
+	})
+
+	t.Run("Mismatches are reported", func(t *testing.T) {
+		replayRouter, replayed, replayCfg := newJournalServer(t, t.TempDir(), "")
+		_, err := ReplayJournal(replayRouter, replayed, replayCfg, journal, time.Time{})
+		require.NoError(t, err)
+		report, err := ReplayJournal(replayRouter, replayed, replayCfg, journal, time.Time{})
+		require.NoError(t, err)
+		require.NotEmpty(t, report.Mismatches, "replaying twice signs up the same email twice")
+		assert.Equal(t, 1, report.Mismatches[0].Seq)
+		assert.Equal(t, http.StatusCreated, report.Mismatches[0].Recorded)
+		assert.Contains(t, report.String(), "#1 POST /auth/signup")
+	})
+
+	t.Run("Recording appends to an existing journal", func(t *testing.T) {
+		appendRouter, _, _ := newJournalServer(t, t.TempDir(), journal)
+		createTestUserAndLogin(t, appendRouter, "again@example.com", "password123", "Ag", "Ain")
+		entries := readJournalEntries(t, journal)
+		require.Len(t, entries, 8)
+		assert.Equal(t, 8, entries[7].Seq)
+	})
+
+	t.Run("Missing or malformed journal", func(t *testing.T) {
+		replayRouter, replayed, replayCfg := newJournalServer(t, t.TempDir(), "")
+		_, err := ReplayJournal(replayRouter, replayed, replayCfg, filepath.Join(t.TempDir(), "missing.jsonl"), time.Time{})
+		assert.ErrorIs(t, err, os.ErrNotExist)
+
+		malformed := filepath.Join(t.TempDir(), "malformed.jsonl")
+		require.NoError(t, os.WriteFile(malformed, []byte("{\"seq\":1}\nnot json\n"), 0600))
+		_, err = NewRecorder(malformed, replayed, replayCfg)
+		assert.ErrorContains(t, err, "entry 2")
+	})
+}
@@ -0,0 +1,358 @@
+package api
+
+import (
+	"docserver/config"
+	"docserver/utils"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- OpenAPI Document ---
+
+// RouteDoc describes a route for the Swagger 2.0 (OpenAPI 2) document the server generates from
+// its route table at startup (see GenerateSwagger). Routes are described with describe where they
+// are registered, so the document lists exactly the routes served. Path parameters are taken from
+// the route itself.
+type RouteDoc struct {
+	Summary      string
+	Tags         []string
+	Public       bool         // Needs no access token
+	Query        []QueryParam // Query parameters
+	Body         any          // A value of the request body's type (json.RawMessage for any JSON); nil if there is none
+	BodyOptional bool         // The body may be left out
+	Status       int          // Status of a successful response; 0 means 200 OK
+	Response     any          // A value of the type of the response's data; nil if there is none
+	List         bool         // The response is a page of Response values, with meta and links
+	Produces     string       // Content type of a response that is not JSON, e.g. text/calendar
+	Bare         bool         // The response is not wrapped in the envelope (see utils.Envelope)
+}
+
+// QueryParam is a query parameter of a route.
+type QueryParam struct {
+	Name        string
+	Type        string // string, integer, number, boolean or array (of strings, repeated in the query)
+	Required    bool
+	Description string
+	Enum        []string
+}
+
+// routeDocs holds the RouteDoc of each route by its key (see routeKey). Routes are registered once
+// per version prefix, and again for each course database, always with the same RouteDoc.
+var routeDocs = struct {
+	sync.RWMutex
+	byRoute map[string]RouteDoc
+}{byRoute: make(map[string]RouteDoc)}
+
+// describe records the RouteDoc of the route registered on group with method and relativePath.
+func describe(group *gin.RouterGroup, method, relativePath string, doc RouteDoc) {
+	routeDocs.Lock()
+	defer routeDocs.Unlock()
+	routeDocs.byRoute[routeKey(method, strings.TrimSuffix(group.BasePath(), "/")+relativePath)] = doc
+}
+
+// routeKey identifies a route by its method and path without the version prefix, e.g.
+// "GET /documents/:id" for GET /v1/documents/:id.
+func routeKey(method, fullPath string) string {
+	return method + " " + strings.TrimPrefix(fullPath, "/"+CurrentAPIVersion)
+}
+
+// routeDoc returns the RouteDoc of a route, and whether it was described.
+func routeDoc(method, fullPath string) (RouteDoc, bool) {
+	routeDocs.RLock()
+	defer routeDocs.RUnlock()
+	doc, found := routeDocs.byRoute[routeKey(method, fullPath)]
+	return doc, found
+}
+
+type swaggerDocument struct {
+	Swagger             string                                  `json:"swagger"`
+	Info                json.RawMessage                         `json:"info"`
+	BasePath            string                                  `json:"basePath"`
+	Paths               map[string]map[string]*swaggerOperation `json:"paths"`
+	Definitions         map[string]*swaggerSchema               `json:"definitions"`
+	SecurityDefinitions map[string]swaggerSecurityScheme        `json:"securityDefinitions"`
+}
+
+type swaggerSecurityScheme struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	In          string `json:"in"`
+	Description string `json:"description"`
+}
+
+type swaggerOperation struct {
+	Summary     string                     `json:"summary,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	Tags        []string                   `json:"tags,omitempty"`
+	Consumes    []string                   `json:"consumes,omitempty"`
+	Produces    []string                   `json:"produces,omitempty"`
+	Security    []map[string][]string      `json:"security,omitempty"`
+	Parameters  []swaggerParameter         `json:"parameters,omitempty"`
+	Responses   map[string]swaggerResponse `json:"responses"`
+}
+
+type swaggerParameter struct {
+	Name             string         `json:"name"`
+	In               string         `json:"in"` // path, query or body
+	Description      string         `json:"description,omitempty"`
+	Required         bool           `json:"required"`
+	Type             string         `json:"type,omitempty"`
+	Items            *swaggerSchema `json:"items,omitempty"`
+	CollectionFormat string         `json:"collectionFormat,omitempty"`
+	Enum             []string       `json:"enum,omitempty"`
+	Schema           *swaggerSchema `json:"schema,omitempty"` // Body parameters
+}
+
+type swaggerResponse struct {
+	Description string         `json:"description"`
+	Schema      *swaggerSchema `json:"schema,omitempty"`
+}
+
+type swaggerSchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Properties           map[string]*swaggerSchema `json:"properties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	Items                *swaggerSchema            `json:"items,omitempty"`
+	AdditionalProperties *swaggerSchema            `json:"additionalProperties,omitempty"`
+	AllOf                []*swaggerSchema          `json:"allOf,omitempty"`
+	Enum                 []string                  `json:"enum,omitempty"`
+}
+
+// bearerAuth is the security scheme of the routes needing an access token.
+const bearerAuth = "BearerAuth"
+
+// GenerateSwagger returns the Swagger 2.0 document of the routes served under the current API
+// version, from their RouteDocs. The info of base, the document swag writes from the annotations
+// of main.go, is kept, as are the descriptions of the operations it has. Routes that were not
+// described are listed without a summary or types.
+func GenerateSwagger(routes gin.RoutesInfo, base []byte) ([]byte, error) {
+	var baseDoc struct {
+		Info  json.RawMessage `json:"info"`
+		Paths map[string]map[string]struct {
+			Description string `json:"description"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(base, &baseDoc); err != nil {
+		return nil, fmt.Errorf("invalid swagger spec: %w", err)
+	}
+	doc := swaggerDocument{
+		Swagger:  "2.0",
+		Info:     baseDoc.Info,
+		BasePath: "/" + CurrentAPIVersion,
+		Paths:    make(map[string]map[string]*swaggerOperation),
+		SecurityDefinitions: map[string]swaggerSecurityScheme{bearerAuth: {
+			Type: "apiKey", Name: "Authorization", In: "header", Description: `Type "Bearer" followed by a space and the access token.`,
+		}},
+	}
+	schemas := schemaBuilder{definitions: make(map[string]*swaggerSchema)}
+	for _, route := range routes {
+		routePath, versioned := strings.CutPrefix(route.Path, "/"+CurrentAPIVersion+"/")
+		if !versioned {
+			continue
+		}
+		described, _ := routeDoc(route.Method, route.Path)
+		var params []swaggerParameter
+		segments := strings.Split(routePath, "/")
+		for i, segment := range segments {
+			if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+				segments[i] = "{" + segment[1:] + "}"
+				params = append(params, swaggerParameter{Name: segment[1:], In: "path", Required: true, Type: "string"})
+			}
+		}
+		swaggerPath := "/" + strings.Join(segments, "/")
+		if doc.Paths[swaggerPath] == nil {
+			doc.Paths[swaggerPath] = make(map[string]*swaggerOperation)
+		}
+		method := strings.ToLower(route.Method)
+		op := schemas.operation(described, params)
+		op.Description = baseDoc.Paths[swaggerPath][method].Description
+		doc.Paths[swaggerPath][method] = op
+	}
+	doc.Definitions = schemas.definitions
+
+	data, err := json.MarshalIndent(doc, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// ClientSwagger returns the Swagger document the client libraries of package sdk are generated
+// from: that of every route the server can register, the optional ones (token introspection,
+// public access, fetching URLs) included as if they were configured. base is as for GenerateSwagger.
+func ClientSwagger(base []byte) ([]byte, error) {
+	cfg := &config.Config{
+		GinMode:             gin.Mode(),
+		IntrospectClients:   map[string]string{"client": ""},
+		EnablePublicAccess:  true,
+		FetchAllowedDomains: []string{"example.com"},
+	}
+	router := gin.New()
+	RegisterRoutes(router, nil, cfg) // Handlers are only registered, never called
+	return GenerateSwagger(router.Routes(), base)
+}
+
+// operation returns the operation of a route, given the parameters of its path.
+func (b *schemaBuilder) operation(doc RouteDoc, params []swaggerParameter) *swaggerOperation {
+	op := &swaggerOperation{Summary: doc.Summary, Tags: doc.Tags, Produces: []string{"application/json"}, Responses: make(map[string]swaggerResponse)}
+	if !doc.Public {
+		op.Security = []map[string][]string{{bearerAuth: {}}}
+	}
+	for _, query := range doc.Query {
+		param := swaggerParameter{Name: query.Name, In: "query", Description: query.Description, Required: query.Required, Type: query.Type, Enum: query.Enum}
+		if query.Type == "array" {
+			param.Items, param.CollectionFormat, param.Enum = &swaggerSchema{Type: "string", Enum: query.Enum}, "multi", nil
+		}
+		params = append(params, param)
+	}
+	if doc.Body != nil {
+		op.Consumes = []string{"application/json"}
+		params = append(params, swaggerParameter{Name: "body", In: "body", Required: !doc.BodyOptional, Schema: b.schemaOf(reflect.TypeOf(doc.Body))})
+	}
+	op.Parameters = params
+
+	status := doc.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	response := swaggerResponse{Description: http.StatusText(status)}
+	switch {
+	case doc.Produces != "":
+		op.Produces = []string{doc.Produces}
+		response.Schema = &swaggerSchema{Type: "string"}
+	case doc.Response != nil:
+		data := b.schemaOf(reflect.TypeOf(doc.Response))
+		if doc.List {
+			data = &swaggerSchema{Type: "array", Items: data}
+		}
+		response.Schema = data
+		if !doc.Bare {
+			envelope := &swaggerSchema{Type: "object", Properties: map[string]*swaggerSchema{"data": data}}
+			if doc.List {
+				envelope.Properties["meta"] = b.schemaOf(reflect.TypeOf(utils.PageMeta{}))
+				envelope.Properties["links"] = b.schemaOf(reflect.TypeOf(utils.PageLinks{}))
+			}
+			response.Schema = &swaggerSchema{AllOf: []*swaggerSchema{b.schemaOf(reflect.TypeOf(utils.Envelope{})), envelope}}
+		}
+	}
+	op.Responses[strconv.Itoa(status)] = response
+	op.Responses["default"] = swaggerResponse{Description: "Error", Schema: b.schemaOf(reflect.TypeOf(utils.ErrorEnvelope{}))}
+	return op
+}
+
+// schemaBuilder builds the schemas of Go types, defining named structs once, by package and
+// name (e.g. models.Document), as swag does.
+type schemaBuilder struct {
+	definitions map[string]*swaggerSchema
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// schemaOf returns the schema of values of type t, as encoding/json writes them.
+func (b *schemaBuilder) schemaOf(t reflect.Type) *swaggerSchema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &swaggerSchema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		return &swaggerSchema{} // Any JSON value
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		return &swaggerSchema{Type: "string"}
+	case t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType):
+		return &swaggerSchema{} // Written its own way
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &swaggerSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &swaggerSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &swaggerSchema{Type: "number"}
+	case reflect.String:
+		return &swaggerSchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &swaggerSchema{Type: "string", Format: "byte"}
+		}
+		return &swaggerSchema{Type: "array", Items: b.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &swaggerSchema{Type: "object", AdditionalProperties: b.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		name := path.Base(t.PkgPath()) + "." + t.Name()
+		if _, defined := b.definitions[name]; !defined {
+			definition := &swaggerSchema{}
+			b.definitions[name] = definition // Before its fields, which may refer to it
+			*definition = *b.structSchema(t)
+		}
+		return &swaggerSchema{Ref: "#/definitions/" + name}
+	}
+	return &swaggerSchema{} // Interfaces hold any JSON value
+}
+
+// structSchema returns the schema of a struct's JSON object. Fields the request binding requires
+// are required.
+func (b *schemaBuilder) structSchema(t reflect.Type) *swaggerSchema {
+	schema := &swaggerSchema{Type: "object", Properties: make(map[string]*swaggerSchema)}
+	b.addFields(schema, t)
+	return schema
+}
+
+// addFields adds the fields of struct type t to schema, including those of embedded structs.
+func (b *schemaBuilder) addFields(schema *swaggerSchema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				b.addFields(schema, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if strings.Contains(","+options+",", ",string,") {
+			schema.Properties[name] = &swaggerSchema{Type: "string"}
+		} else {
+			schema.Properties[name] = b.schemaOf(field.Type)
+		}
+		if strings.Contains(","+field.Tag.Get("binding")+",", ",required,") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"docserver/models"
+	"docserver/sdk"
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoutesAreDescribed(t *testing.T) {
+	_, database, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	// Register the optional routes too
+	cfg.IntrospectClients = map[string]string{"client": "secret"}
+	cfg.FetchAllowedDomains = []string{"example.com"}
+	cfg.EnablePublicAccess = true
+	router := gin.New()
+	RegisterRoutes(router, database, cfg)
+
+	for _, route := range router.Routes() {
+		if !strings.HasPrefix(route.Path, "/"+CurrentAPIVersion+"/") {
+			continue
+		}
+		doc, found := routeDoc(route.Method, route.Path)
+		if assert.True(t, found, "%s %s is not described", route.Method, route.Path) {
+			assert.NotEmpty(t, doc.Summary, "%s %s", route.Method, route.Path)
+			assert.NotEmpty(t, doc.Tags, "%s %s", route.Method, route.Path)
+		}
+	}
+}
+
+func TestGenerateSwagger(t *testing.T) {
+	router, _, _, cleanup := setupTestServer(t)
+	defer cleanup()
+	base, err := os.ReadFile("../docs/swagger.json")
+	require.NoError(t, err)
+	swagger, err := GenerateSwagger(router.Routes(), base)
+	require.NoError(t, err)
+
+	var doc struct {
+		BasePath string
+		Paths    map[string]map[string]struct {
+			Summary     string
+			Description string
+			Security    []map[string][]string
+			Parameters  []struct {
+				Name, In, Type string
+				Required       bool
+			}
+			Responses map[string]json.RawMessage
+		}
+		Definitions         map[string]json.RawMessage
+		SecurityDefinitions map[string]struct{ Type, Name, In string }
+	}
+	require.NoError(t, json.Unmarshal(swagger, &doc))
+	assert.Equal(t, "/v1", doc.BasePath)
+	assert.Equal(t, "apiKey", doc.SecurityDefinitions["BearerAuth"].Type)
+	assert.Equal(t, "Authorization", doc.SecurityDefinitions["BearerAuth"].Name)
+	assert.NotContains(t, doc.Paths, "/v1/documents", "paths are relative to basePath")
+
+	get := doc.Paths["/documents/{id}"]["get"]
+	assert.NotEmpty(t, get.Summary)
+	assert.NotEmpty(t, get.Description, "descriptions are kept from the base document")
+	assert.Equal(t, []map[string][]string{{"BearerAuth": {}}}, get.Security)
+	require.NotEmpty(t, get.Parameters)
+	assert.Equal(t, "id", get.Parameters[0].Name)
+	assert.Equal(t, "path", get.Parameters[0].In)
+	assert.True(t, get.Parameters[0].Required)
+	assert.JSONEq(t, `{"description": "OK", "schema": {"allOf": [
+		{"$ref": "#/definitions/utils.Envelope"},
+		{"type": "object", "properties": {"data": {"$ref": "#/definitions/api.DocumentResponse"}}}
+	]}}`, string(get.Responses["200"]))
+	assert.Contains(t, get.Responses, "default")
+	assert.Contains(t, doc.Definitions, "api.DocumentResponse")
+	assert.Contains(t, doc.Definitions, "utils.ErrorEnvelope")
+
+	list := doc.Paths["/documents"]["get"]
+	var listResponse struct {
+		Schema struct {
+			AllOf []struct {
+				Properties map[string]struct{ Type string }
+			}
+		}
+	}
+	require.NoError(t, json.Unmarshal(list.Responses["200"], &listResponse))
+	require.Len(t, listResponse.Schema.AllOf, 2)
+	assert.Equal(t, "array", listResponse.Schema.AllOf[1].Properties["data"].Type)
+	assert.Contains(t, listResponse.Schema.AllOf[1].Properties, "meta")
+	assert.Contains(t, listResponse.Schema.AllOf[1].Properties, "links")
+
+	assert.Empty(t, doc.Paths["/query/operators"]["get"].Security, "public routes need no token")
+
+	// The document can be read as the SDK and Postman generators read it
+	spec, err := sdk.ParseSpec(swagger)
+	require.NoError(t, err)
+	assert.NotEmpty(t, spec.Paths)
+
+	_, err = GenerateSwagger(nil, []byte("{"))
+	assert.Error(t, err)
+}
+
+func TestGeneratedClientsAreCurrent(t *testing.T) {
+	base, err := os.ReadFile("../docs/swagger.json")
+	require.NoError(t, err)
+	swagger, err := ClientSwagger(base)
+	require.NoError(t, err)
+	spec, err := sdk.ParseSpec(swagger)
+	require.NoError(t, err)
+	for _, language := range sdk.Languages {
+		embedded, err := language.Source(sdk.BaseURLPlaceholder)
+		require.NoError(t, err)
+		assert.Equal(t, string(language.Generate(spec)), string(embedded), "%s is out of date: run go generate ./sdk", language.File)
+		assert.NotContains(t, string(embedded), "password_hash:", language.File)
+	}
+}
+
+func TestSchemaOf(t *testing.T) {
+	type Embedded struct {
+		Note string `json:"note,omitempty"`
+	}
+	type Example struct {
+		Embedded
+		Name     string            `json:"name" binding:"required"`
+		Count    int64             `json:"count,string"`
+		When     *time.Time        `json:"when"`
+		Labels   map[string]string `json:"labels"`
+		Raw      json.RawMessage   `json:"raw"`
+		Data     []byte            `json:"data"`
+		Document models.Document   `json:"document"`
+		Secret   string            `json:"-"`
+		hidden   string
+		Untagged bool
+	}
+
+	schemas := schemaBuilder{definitions: make(map[string]*swaggerSchema)}
+	schema := schemas.schemaOf(reflect.TypeOf([]*Example{}))
+	assert.Equal(t, &swaggerSchema{Type: "array", Items: &swaggerSchema{Ref: "#/definitions/api.Example"}}, schema)
+
+	definition := schemas.definitions["api.Example"]
+	require.NotNil(t, definition)
+	assert.Equal(t, map[string]*swaggerSchema{
+		"note":     {Type: "string"},
+		"name":     {Type: "string"},
+		"count":    {Type: "string"},
+		"when":     {Type: "string", Format: "date-time"},
+		"labels":   {Type: "object", AdditionalProperties: &swaggerSchema{Type: "string"}},
+		"raw":      {},
+		"data":     {Type: "string", Format: "byte"},
+		"document": {Ref: "#/definitions/models.Document"},
+		"Untagged": {Type: "boolean"},
+	}, definition.Properties)
+	assert.Equal(t, []string{"name"}, definition.Required)
+	assert.Contains(t, schemas.definitions, "models.Document")
+}
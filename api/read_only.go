@@ -0,0 +1,41 @@
+package api
+
+import (
+	"docserver/config"
+	"docserver/i18n"
+	"docserver/utils"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Read-Only Mode ---
+
+// readOnlyExemptPaths are POST requests that change nothing, allowed with -read-only so visitors
+// can still log in and read.
+var readOnlyExemptPaths = []string{"/auth/login", "/auth/logout", "/auth/introspect", "/auth/tokens", "/profiles/resolve", "/query/validate"}
+
+// ReadOnlyMiddleware refuses POST, PUT, PATCH and DELETE requests with 403 Forbidden while the
+// server runs with -read-only, except those that only read (see readOnlyExempt). prefix is the
+// version prefix the routes are mounted under ("/v1", or "" for legacy paths).
+func ReadOnlyMiddleware(cfg *config.Config, prefix string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !cfg.ReadOnly || !isMutatingMethod(c.Request.Method) || readOnlyExempt(strings.TrimPrefix(c.Request.URL.Path, prefix)) {
+			c.Next()
+			return
+		}
+		utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgReadOnly)
+	}
+}
+
+// readOnlyExempt reports whether a request to path is allowed with -read-only although it is
+// not a GET: logging in and out, introspecting and minting tokens, resolving profiles, validating
+// queries and diffing document versions.
+func readOnlyExempt(path string) bool {
+	if slices.Contains(readOnlyExemptPaths, path) {
+		return true
+	}
+	return strings.HasPrefix(path, "/documents/") && strings.HasSuffix(path, "/diff")
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"docserver/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOnlyMode(t *testing.T) {
+	router, _, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	_, _, token := createTestUserAndLogin(t, router, "readonly@example.com", "password123", "Read", "Only")
+	rr := performRequest(router, http.MethodPost, "/v1/documents", marshalJSONBody(t, gin.H{"content": gin.H{"n": 1}}), token)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	var created struct{ Data models.Document }
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	cfg.ReadOnly = true
+
+	t.Run("Refuses changes", func(t *testing.T) {
+		rr := performRequest(router, http.MethodPost, "/v1/documents", marshalJSONBody(t, gin.H{"content": gin.H{"n": 2}}), token)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.Contains(t, rr.Body.String(), "read_only")
+		rr = performRequest(router, http.MethodDelete, "/documents/"+created.Data.ID, nil, token)
+		assert.Equal(t, http.StatusForbidden, rr.Code, "legacy paths too")
+	})
+
+	t.Run("Serves reads", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/v1/documents/"+created.Data.ID, nil, token)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		rr = performRequest(router, http.MethodPost, "/v1/auth/login", marshalJSONBody(t, gin.H{"email": "readonly@example.com", "password": "password123"}), "")
+		assert.Equal(t, http.StatusOK, rr.Code, "logging in changes nothing")
+	})
+
+	t.Run("Reported in the status", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/v1/status", nil, "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		var body struct{ Data StatusResponse }
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		assert.True(t, body.Data.ReadOnly)
+	})
+}
+
+func TestReadOnlyExempt(t *testing.T) {
+	assert.True(t, readOnlyExempt("/auth/login"))
+	assert.True(t, readOnlyExempt("/documents/abc/diff"))
+	assert.True(t, readOnlyExempt("/query/validate"))
+	assert.False(t, readOnlyExempt("/auth/signup"))
+	assert.False(t, readOnlyExempt("/documents/abc"))
+}
@@ -0,0 +1,978 @@
+package api
+
+import (
+	"docserver/config"
+	"docserver/db"
+	"docserver/models"
+	"docserver/utils"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NewRouter creates the Gin engine with the logger and recovery middleware, in the configured
+// Gin mode. The client IP (c.ClientIP) is taken from cfg.ClientIPHeaders only for requests
+// coming from one of cfg.TrustedProxies; for everyone else it is the connecting IP, so clients
+// cannot pick their own IP to dodge rate limits. No routes are registered yet.
+func NewRouter(cfg *config.Config) (*gin.Engine, error) {
+	gin.SetMode(cfg.GinMode)
+	router := gin.New()
+	router.Use(gin.Logger(), gin.Recovery())
+	if err := configureClientIP(router, cfg); err != nil {
+		return nil, err
+	}
+	return router, nil
+}
+
+// configureClientIP sets which proxies the router takes the client IP from (see NewRouter).
+func configureClientIP(router *gin.Engine, cfg *config.Config) error {
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil { // Empty trusts no proxy
+		return fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+	router.ForwardedByClientIP = len(cfg.ClientIPHeaders) > 0
+	router.RemoteIPHeaders = cfg.ClientIPHeaders
+	return nil
+}
+
+// RegisterRoutes attaches every API route to the router.
+// Routes are served under the current version prefix (e.g. /v1/documents) and,
+// for backwards compatibility, under the legacy unversioned paths (e.g. /documents).
+// Versioned paths answer with the standard response envelope (see utils.Envelope);
+// legacy paths keep their original response shapes and advertise their deprecation via headers.
+// With -courses-dir, the routes of each course database are served under /courses/{id}/ and to
+// requests carrying an X-Course header.
+func RegisterRoutes(router *gin.Engine, database *db.Database, cfg *config.Config) {
+	limits := newRateLimits(cfg)
+	chaos := newChaosState()
+
+	// --- Course Databases ---
+	var courses *courseRouter
+	if cfg.CoursesDir != "" {
+		courses = newCourseRouter(database, cfg)
+		router.Use(courses.HeaderMiddleware())
+		router.Any("/courses/:course/*path", courses.ServePath)
+	}
+
+	// --- Versioned Routes ---
+	v1Group := router.Group("/" + CurrentAPIVersion)
+	v1Group.Use(utils.EnvelopeMiddleware(), ServerVersionMiddleware(), APIVersionMiddleware(CurrentAPIVersion), ReadOnlyMiddleware(cfg, "/"+CurrentAPIVersion), MaintenanceMiddleware(database, "/"+CurrentAPIVersion), DurabilityMiddleware(database), APIUsageMiddleware(database, cfg, "/"+CurrentAPIVersion), ChaosMiddleware(chaos, cfg, "/"+CurrentAPIVersion))
+	registerAPIRoutes(v1Group, database, cfg, limits, chaos, courses)
+
+	// --- Legacy (Unversioned) Aliases ---
+	legacyGroup := router.Group("")
+	legacyGroup.Use(DeprecationMiddleware(cfg), ServerVersionMiddleware(), APIVersionMiddleware(CurrentAPIVersion), ReadOnlyMiddleware(cfg, ""), MaintenanceMiddleware(database, ""), DurabilityMiddleware(database), APIUsageMiddleware(database, cfg, ""), ChaosMiddleware(chaos, cfg, ""))
+	registerAPIRoutes(legacyGroup, database, cfg, limits, chaos, courses)
+}
+
+// rateLimits holds the rate limiters of limited routes. It is shared by all mounted
+// versions, so a client gets the same allowance whichever path it uses.
+type rateLimits struct {
+	status *utils.RateLimiter // GET /status; nil when unlimited
+}
+
+// newRateLimits builds the rate limiters configured in cfg.
+func newRateLimits(cfg *config.Config) rateLimits {
+	var limits rateLimits
+	if cfg.StatusRateLimit > 0 {
+		limits.status = utils.NewRateLimiter(cfg.StatusRateLimit, time.Minute)
+	}
+	return limits
+}
+
+// rateLimited returns the middleware enforcing limiter, or one letting every request through if it is nil.
+func rateLimited(limiter *utils.RateLimiter) gin.HandlerFunc {
+	if limiter == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return limiter.Middleware()
+}
+
+// Route parameters holding IDs, by the kind of entity they name. utils.ValidateIDParams rejects
+// malformed ones with 400 Bad Request before the handlers look them up.
+var (
+	documentIDParams            = map[string]utils.IDKind{"id": utils.IDKindDocument, "profile_id": utils.IDKindProfile}
+	scheduleIDParams            = map[string]utils.IDKind{"id": utils.IDKindSchedule, "run_id": utils.IDKindScheduleRun}
+	scriptIDParams              = map[string]utils.IDKind{"id": utils.IDKindScript}
+	profileIDParams             = map[string]utils.IDKind{"id": utils.IDKindProfile}
+	reviewIDParams              = map[string]utils.IDKind{"id": utils.IDKindReview}
+	deviceIDParams              = map[string]utils.IDKind{"device_id": utils.IDKindDevice}
+	notificationChannelIDParams = map[string]utils.IDKind{"id": utils.IDKindChannel}
+)
+
+// registerAPIRoutes registers all API endpoints on the given group.
+// It is called once per mounted version prefix. courses is nil without -courses-dir.
+func registerAPIRoutes(rg *gin.RouterGroup, database *db.Database, cfg *config.Config, limits rateLimits, chaos *chaosState, courses *courseRouter) {
+	// --- Public Status (No Auth Required, Rate Limited) ---
+	// GET /status
+	describe(rg, http.MethodGet, "/status", RouteDoc{Summary: "Get Server Status", Tags: []string{"Status"}, Public: true, Response: StatusResponse{}})
+	rg.GET("/status", rateLimited(limits.status), func(c *gin.Context) {
+		GetStatusHandler(c, database, cfg)
+	})
+	// GET /limits
+	describe(rg, http.MethodGet, "/limits", RouteDoc{Summary: "Get Your Limits", Tags: []string{"Status"}, Public: true, Response: LimitsResponse{}})
+	rg.GET("/limits", func(c *gin.Context) {
+		GetLimitsHandler(c, database, cfg, limits)
+	})
+	// GET /version
+	describe(rg, http.MethodGet, "/version", RouteDoc{Summary: "Get Server Version", Tags: []string{"Status"}, Public: true, Response: VersionResponse{}})
+	rg.GET("/version", func(c *gin.Context) {
+		GetVersionHandler(c, database, cfg)
+	})
+
+	// --- Client Libraries (No Auth Required) ---
+	// GET /sdk/{language}
+	describe(rg, http.MethodGet, "/sdk/:language", RouteDoc{Summary: "Download a Client Library", Tags: []string{"Status"}, Public: true, Produces: "text/plain"})
+	rg.GET("/sdk/:language", func(c *gin.Context) {
+		GetSDKHandler(c, database, cfg)
+	})
+
+	// --- Query Builder (No Auth Required) ---
+	// GET /query/operators
+	describe(rg, http.MethodGet, "/query/operators", RouteDoc{Summary: "List the Query Operators", Tags: []string{"Documents"}, Public: true, Response: QueryOperatorsResponse{}})
+	rg.GET("/query/operators", func(c *gin.Context) {
+		GetQueryOperatorsHandler(c, database, cfg)
+	})
+	// POST /query/validate
+	describe(rg, http.MethodPost, "/query/validate", RouteDoc{Summary: "Validate a Content Query", Tags: []string{"Documents"}, Public: true, Body: ValidateQueryRequest{}, Response: db.QueryValidation{}})
+	rg.POST("/query/validate", func(c *gin.Context) {
+		ValidateQueryHandler(c, database, cfg)
+	})
+
+	// --- Mock Data (No Auth Required, Debug Mode Only) ---
+	// GET /mock/documents
+	describe(rg, http.MethodGet, "/mock/documents", RouteDoc{Summary: "Generate Mock Documents (Debug Mode)", Tags: []string{"Mock"}, Public: true, Response: models.Document{}, List: true, Query: []QueryParam{
+		{Name: "n", Type: "integer", Description: "Number of documents to generate (1-100)."},
+		{Name: "shape", Type: "string", Description: "JSON shape of the generated content."},
+		{Name: "seed", Type: "integer", Description: "Seed for the generator; the same seed and shape give the same documents."},
+	}})
+	rg.GET("/mock/documents", func(c *gin.Context) {
+		GetMockDocumentsHandler(c, database, cfg)
+	})
+
+	// --- Public Routes (No Auth Required) ---
+	authGroup := rg.Group("/auth")
+	{
+		// POST /auth/signup
+		describe(authGroup, http.MethodPost, "/signup", RouteDoc{Summary: "Register a New User Account", Tags: []string{"Authentication"}, Public: true, Body: SignupRequest{}, Status: http.StatusCreated, Response: SignupResponse{}})
+		authGroup.POST("/signup", func(c *gin.Context) {
+			SignupHandler(c, database, cfg)
+		})
+		// POST /auth/login
+		describe(authGroup, http.MethodPost, "/login", RouteDoc{Summary: "Log In to Your Account", Tags: []string{"Authentication"}, Public: true, Body: LoginRequest{}, Response: LoginResponse{}})
+		authGroup.POST("/login", func(c *gin.Context) {
+			LoginHandler(c, database, cfg)
+		})
+		// POST /auth/forgot-password
+		describe(authGroup, http.MethodPost, "/forgot-password", RouteDoc{Summary: "Request Password Reset Code (OTP)", Tags: []string{"Authentication"}, Public: true, Body: ForgotPasswordRequest{}, Status: http.StatusAccepted})
+		authGroup.POST("/forgot-password", func(c *gin.Context) {
+			ForgotPasswordHandler(c, database, cfg)
+		})
+		// POST /auth/reset-password
+		describe(authGroup, http.MethodPost, "/reset-password", RouteDoc{Summary: "Set New Password Using Reset Code (OTP)", Tags: []string{"Authentication"}, Public: true, Body: ResetPasswordRequest{}, Status: http.StatusNoContent})
+		authGroup.POST("/reset-password", func(c *gin.Context) {
+			ResetPasswordHandler(c, database, cfg)
+		})
+		// GET /auth/challenge
+		describe(authGroup, http.MethodGet, "/challenge", RouteDoc{Summary: "Get a Bot-Protection Challenge", Tags: []string{"Authentication"}, Public: true, Response: ChallengeResponse{}})
+		authGroup.GET("/challenge", func(c *gin.Context) {
+			GetChallengeHandler(c, database, cfg)
+		})
+		// POST /auth/introspect (only when introspection clients are configured)
+		if len(cfg.IntrospectClients) > 0 {
+			describe(authGroup, http.MethodPost, "/introspect", RouteDoc{Summary: "Introspect an Access Token", Tags: []string{"Authentication"}, Public: true, Response: IntrospectResponse{}, Bare: true})
+			authGroup.POST("/introspect", func(c *gin.Context) {
+				IntrospectHandler(c, database, cfg)
+			})
+		}
+	}
+
+	// --- Public Guest Routes (No Auth, Read-Only, Opt-In) ---
+	if cfg.EnablePublicAccess {
+		publicGroup := rg.Group("/public")
+		{
+			// GET /public/documents
+			describe(publicGroup, http.MethodGet, "/documents", RouteDoc{Summary: "List Public Documents (No Login Needed)", Tags: []string{"Public"}, Public: true, Response: DocumentListItem{}, List: true, Query: []QueryParam{
+				{Name: "content_query", Type: "array", Description: "Advanced filter based on document content (specific syntax applies)."},
+				{Name: "missing", Type: "string", Description: "How conditions on paths a document lacks are treated, as for GET /documents.", Enum: []string{"skip", "false", "error"}},
+				{Name: "include", Type: "string", Description: "Set to 'owner' to embed the profile that owns each document."},
+				{Name: "sort_by", Type: "string", Description: "Field to sort results by.", Enum: []string{"creation_date", "last_modified_date"}},
+				{Name: "order", Type: "string", Description: "Sorting direction.", Enum: []string{"asc", "desc"}},
+				{Name: "page", Type: "integer", Description: "Page number for pagination (starts at 1)."},
+				{Name: "limit", Type: "integer", Description: "Number of documents per page."},
+			}})
+			publicGroup.GET("/documents", func(c *gin.Context) {
+				GetPublicDocumentsHandler(c, database, cfg)
+			})
+			// GET /public/documents/{id}
+			describe(publicGroup, http.MethodGet, "/documents/:id", RouteDoc{Summary: "Get a Public Document (No Login Needed)", Tags: []string{"Public"}, Public: true, Response: models.Document{}})
+			publicGroup.GET("/documents/:id", utils.ValidateIDParams(documentIDParams), func(c *gin.Context) {
+				GetPublicDocumentByIDHandler(c, database, cfg)
+			})
+		}
+	}
+
+	// --- Protected Routes (Auth Required) ---
+	// Apply AuthMiddleware
+	authMiddleware := utils.AuthMiddleware(cfg)
+	// Blocks use of shared data until the current terms of service are accepted (when required).
+	// Account management under /profiles/me stays available so users can accept, export or erase.
+	tosMiddleware := RequireTosMiddleware(database, cfg)
+	// Rejects tokens of deactivated accounts, which can no longer log in either.
+	activeMiddleware := RequireActiveMiddleware(database)
+	// Limits tokens carrying scopes (service accounts, restricted tokens) to the routes of one resource, or to none.
+	scopeMiddleware := func(resource string) gin.HandlerFunc {
+		return RequireScopeMiddleware(database, resource, rg.BasePath())
+	}
+
+	// Profile Routes
+	profileGroup := rg.Group("/profiles")
+	profileGroup.Use(authMiddleware, activeMiddleware, scopeMiddleware("profiles"))
+	// Managing the account itself (exporting, erasing, deactivating, email and devices) needs an unrestricted token.
+	accountOnly := scopeMiddleware("")
+	{
+		// GET /profiles/me
+		describe(profileGroup, http.MethodGet, "/me", RouteDoc{Summary: "Get Your Own Profile", Tags: []string{"Profiles"}, Response: ProfileResponse{}})
+		profileGroup.GET("/me", func(c *gin.Context) {
+			GetProfileMeHandler(c, database, cfg)
+		})
+		// PUT /profiles/me
+		describe(profileGroup, http.MethodPut, "/me", RouteDoc{Summary: "Update Your Own Profile", Tags: []string{"Profiles"}, Body: UpdateProfileRequest{}, Response: ProfileResponse{}})
+		profileGroup.PUT("/me", func(c *gin.Context) {
+			UpdateProfileMeHandler(c, database, cfg)
+		})
+		// DELETE /profiles/me
+		describe(profileGroup, http.MethodDelete, "/me", RouteDoc{Summary: "Delete Your Own Profile", Tags: []string{"Profiles"}, Status: http.StatusNoContent})
+		profileGroup.DELETE("/me", accountOnly, func(c *gin.Context) {
+			DeleteProfileMeHandler(c, database, cfg)
+		})
+		// POST /profiles/me/erase
+		describe(profileGroup, http.MethodPost, "/me/erase", RouteDoc{Summary: "Request Erasure of Your Data", Tags: []string{"Profiles"}, Response: models.ErasureRequest{}})
+		profileGroup.POST("/me/erase", accountOnly, func(c *gin.Context) {
+			RequestErasureHandler(c, database, cfg)
+		})
+		// GET /profiles/me/erase
+		describe(profileGroup, http.MethodGet, "/me/erase", RouteDoc{Summary: "Check Your Erasure Request", Tags: []string{"Profiles"}, Response: models.ErasureRequest{}})
+		profileGroup.GET("/me/erase", accountOnly, func(c *gin.Context) {
+			GetErasureStatusHandler(c, database, cfg)
+		})
+		// DELETE /profiles/me/erase
+		describe(profileGroup, http.MethodDelete, "/me/erase", RouteDoc{Summary: "Cancel Your Erasure Request", Tags: []string{"Profiles"}, Status: http.StatusNoContent})
+		profileGroup.DELETE("/me/erase", accountOnly, func(c *gin.Context) {
+			CancelErasureHandler(c, database, cfg)
+		})
+		// GET /profiles/me/export
+		describe(profileGroup, http.MethodGet, "/me/export", RouteDoc{Summary: "Export Your Data", Tags: []string{"Profiles"}, Response: ExportResponse{}})
+		profileGroup.GET("/me/export", accountOnly, func(c *gin.Context) {
+			ExportDataHandler(c, database, cfg)
+		})
+		// GET /profiles/me/stats
+		describe(profileGroup, http.MethodGet, "/me/stats", RouteDoc{Summary: "Get Your Statistics", Tags: []string{"Profiles"}, Response: models.ProfileStats{}})
+		profileGroup.GET("/me/stats", func(c *gin.Context) {
+			GetProfileStatsHandler(c, database, cfg)
+		})
+		// GET /profiles/me/usage/api
+		describe(profileGroup, http.MethodGet, "/me/usage/api", RouteDoc{Summary: "Get Your API Usage", Tags: []string{"Profiles"}, Response: db.APIUsageReport{}})
+		profileGroup.GET("/me/usage/api", func(c *gin.Context) {
+			GetMyAPIUsageHandler(c, database, cfg)
+		})
+		// POST /profiles/me/accept-tos
+		describe(profileGroup, http.MethodPost, "/me/accept-tos", RouteDoc{Summary: "Accept the Terms of Service", Tags: []string{"Profiles"}, Body: AcceptTosRequest{}, BodyOptional: true, Response: TosStatus{}})
+		profileGroup.POST("/me/accept-tos", func(c *gin.Context) {
+			AcceptTosHandler(c, database, cfg)
+		})
+		// POST /profiles/me/email-change
+		describe(profileGroup, http.MethodPost, "/me/email-change", RouteDoc{Summary: "Request an Email Change", Tags: []string{"Profiles"}, Body: EmailChangeRequest{}, Status: http.StatusAccepted, Response: EmailChangeResponse{}})
+		profileGroup.POST("/me/email-change", accountOnly, func(c *gin.Context) {
+			RequestEmailChangeHandler(c, database, cfg)
+		})
+		// POST /profiles/me/email-change/confirm
+		describe(profileGroup, http.MethodPost, "/me/email-change/confirm", RouteDoc{Summary: "Confirm an Email Change", Tags: []string{"Profiles"}, Body: ConfirmEmailChangeRequest{}, Response: ProfileResponse{}})
+		profileGroup.POST("/me/email-change/confirm", accountOnly, func(c *gin.Context) {
+			ConfirmEmailChangeHandler(c, database, cfg)
+		})
+		// POST /profiles/me/deactivate
+		describe(profileGroup, http.MethodPost, "/me/deactivate", RouteDoc{Summary: "Deactivate Your Account", Tags: []string{"Profiles"}, Body: DeactivateRequest{}, BodyOptional: true, Response: models.Deactivation{}})
+		profileGroup.POST("/me/deactivate", accountOnly, func(c *gin.Context) {
+			DeactivateHandler(c, database, cfg)
+		})
+		// GET /profiles/me/devices
+		describe(profileGroup, http.MethodGet, "/me/devices", RouteDoc{Summary: "List Your Devices", Tags: []string{"Profiles"}, Response: []DeviceResponse{}})
+		profileGroup.GET("/me/devices", accountOnly, func(c *gin.Context) {
+			ListDevicesHandler(c, database, cfg)
+		})
+		// DELETE /profiles/me/devices/:device_id
+		describe(profileGroup, http.MethodDelete, "/me/devices/:device_id", RouteDoc{Summary: "Revoke a Device", Tags: []string{"Profiles"}, Status: http.StatusNoContent})
+		profileGroup.DELETE("/me/devices/:device_id", accountOnly, utils.ValidateIDParams(deviceIDParams), func(c *gin.Context) {
+			RevokeDeviceHandler(c, database, cfg)
+		})
+		// POST /profiles/resolve
+		describe(profileGroup, http.MethodPost, "/resolve", RouteDoc{Summary: "Resolve Profile IDs", Tags: []string{"Profiles"}, Body: ResolveProfilesRequest{}, Response: ResolveProfilesResponse{}})
+		profileGroup.POST("/resolve", tosMiddleware, func(c *gin.Context) {
+			ResolveProfilesHandler(c, database, cfg)
+		})
+		// GET /profiles (Search)
+		describe(profileGroup, http.MethodGet, "", RouteDoc{Summary: "Search User Profiles", Tags: []string{"Profiles"}, Response: ProfileResponse{}, List: true, Query: []QueryParam{
+			{Name: "email", Type: "string", Description: "Filter profiles where email contains this text (case-insensitive)."},
+			{Name: "first_name", Type: "string", Description: "Filter profiles where first name contains this text (case-insensitive)."},
+			{Name: "last_name", Type: "string", Description: "Filter profiles where last name contains this text (case-insensitive)."},
+			{Name: "created_after", Type: "string", Description: "Only profiles created after this time (RFC 3339)."},
+			{Name: "created_before", Type: "string", Description: "Only profiles created before this time (RFC 3339)."},
+			{Name: "ids", Type: "string", Description: "Only profiles with these IDs, comma-separated (at most 100)."},
+			{Name: "sort_by", Type: "string", Description: "Field to sort results by.", Enum: []string{"email", "name", "creation_date"}},
+			{Name: "order", Type: "string", Description: "Sorting direction.", Enum: []string{"asc", "desc"}},
+			{Name: "page", Type: "integer", Description: "Page number for results (starts at 1)."},
+			{Name: "limit", Type: "integer", Description: "Number of profiles per page."},
+		}})
+		profileGroup.GET("", tosMiddleware, func(c *gin.Context) { // Note: Empty path for group root
+			SearchProfilesHandler(c, database, cfg)
+		})
+	}
+
+	// Document Routes
+	docGroup := rg.Group("/documents")
+	docGroup.Use(authMiddleware, activeMiddleware, scopeMiddleware("documents"), tosMiddleware, utils.ValidateIDParams(documentIDParams))
+	{
+		// POST /documents
+		describe(docGroup, http.MethodPost, "", RouteDoc{Summary: "Create a New Document", Tags: []string{"Documents"}, Body: CreateDocumentRequest{}, Response: DocumentResponse{}})
+		docGroup.POST("", func(c *gin.Context) {
+			CreateDocumentHandler(c, database, cfg)
+		})
+		// GET /documents (List/Query)
+		describe(docGroup, http.MethodGet, "", RouteDoc{Summary: "List and Search Your Documents", Tags: []string{"Documents"}, Response: DocumentListItem{}, List: true, Query: []QueryParam{
+			{Name: "scope", Type: "string", Description: "Filter by ownership: 'owned', 'shared', 'all', 'public' or 'any' (administrators only).", Enum: []string{"owned", "shared", "all", "public", "any"}},
+			{Name: "owner_id", Type: "string", Description: "Only list documents owned by this profile."},
+			{Name: "favorites", Type: "boolean", Description: "Only list documents you marked as favorite."},
+			{Name: "workflow_state", Type: "string", Description: "Only list documents in this workflow state.", Enum: []string{"draft", "submitted", "approved", "rejected"}},
+			{Name: "expired", Type: "string", Description: "Whether to list documents that have expired.", Enum: []string{"exclude", "include", "only"}},
+			{Name: "content_query", Type: "array", Description: "Advanced filter based on document content (specific syntax applies)."},
+			{Name: "missing", Type: "string", Description: "How conditions on paths a document lacks are treated.", Enum: []string{"skip", "false", "error"}},
+			{Name: "include", Type: "string", Description: "Comma-separated related records to embed: 'shares' (on documents you own) and/or 'owner' (on documents you do not own)."},
+			{Name: "sort_by", Type: "string", Description: "Field to sort results by.", Enum: []string{"creation_date", "last_modified_date"}},
+			{Name: "order", Type: "string", Description: "Sorting direction.", Enum: []string{"asc", "desc"}},
+			{Name: "page", Type: "integer", Description: "Page number for pagination (starts at 1)."},
+			{Name: "limit", Type: "integer", Description: "Number of documents per page."},
+		}})
+		docGroup.GET("", func(c *gin.Context) {
+			GetDocumentsHandler(c, database, cfg)
+		})
+		// GET /documents/archive
+		describe(docGroup, http.MethodGet, "/archive", RouteDoc{Summary: "Download Documents as a Zip Archive", Tags: []string{"Documents"}, Produces: "application/zip", Query: []QueryParam{
+			{Name: "scope", Type: "string", Description: "Filter by ownership: 'owned', 'shared', 'all' or 'public'.", Enum: []string{"owned", "shared", "all", "public"}},
+			{Name: "content_query", Type: "array", Description: "Filter by document content, as for GET /documents."},
+			{Name: "missing", Type: "string", Description: "How conditions on paths a document lacks are treated, as for GET /documents.", Enum: []string{"skip", "false", "error"}},
+			{Name: "sort_by", Type: "string", Description: "Order of the files in the archive.", Enum: []string{"creation_date", "last_modified_date"}},
+			{Name: "order", Type: "string", Description: "Sorting direction.", Enum: []string{"asc", "desc"}},
+		}})
+		docGroup.GET("/archive", func(c *gin.Context) {
+			ArchiveDocumentsHandler(c, database, cfg)
+		})
+		// GET /documents/duplicates
+		describe(docGroup, http.MethodGet, "/duplicates", RouteDoc{Summary: "Find Near-Duplicate Documents", Tags: []string{"Documents"}, Response: []DuplicateClusterResponse{}, Query: []QueryParam{
+			{Name: "threshold", Type: "number", Description: "Smallest similarity reported, between 0.5 and 1."},
+			{Name: "scope", Type: "string", Description: "Documents to compare: 'owned', 'shared' with you, or 'all' of them.", Enum: []string{"owned", "shared", "all"}},
+		}})
+		docGroup.GET("/duplicates", func(c *gin.Context) {
+			FindDuplicatesHandler(c, database, cfg)
+		})
+		// POST /documents/replace
+		describe(docGroup, http.MethodPost, "/replace", RouteDoc{Summary: "Search and Replace Across Your Documents", Tags: []string{"Documents"}, Body: ReplaceDocumentsRequest{}, Response: db.ReplaceReport{}})
+		docGroup.POST("/replace", func(c *gin.Context) {
+			ReplaceDocumentsHandler(c, database, cfg)
+		})
+		// POST /documents/fetch (only when fetch domains are configured)
+		if len(cfg.FetchAllowedDomains) > 0 {
+			describe(docGroup, http.MethodPost, "/fetch", RouteDoc{Summary: "Create a Document from a URL", Tags: []string{"Documents"}, Body: FetchDocumentRequest{}, Status: http.StatusCreated, Response: models.Document{}})
+			docGroup.POST("/fetch", func(c *gin.Context) {
+				FetchDocumentHandler(c, database, cfg)
+			})
+		}
+		// GET /documents/{id}
+		describe(docGroup, http.MethodGet, "/:id", RouteDoc{Summary: "Get a Specific Document by ID", Tags: []string{"Documents"}, Response: DocumentResponse{}, Query: []QueryParam{
+			{Name: "render", Type: "string", Description: "Set to 'html' to get a Markdown document rendered as an HTML page.", Enum: []string{"html"}},
+			{Name: "include", Type: "string", Description: "Comma-separated related records to embed: 'shares' adds 'shared_with' if you own the document, 'owner' adds 'owner' if you do not."},
+		}})
+		docGroup.GET("/:id", func(c *gin.Context) {
+			GetDocumentByIDHandler(c, database, cfg)
+		})
+		// PUT /documents/{id}
+		describe(docGroup, http.MethodPut, "/:id", RouteDoc{Summary: "Update a Document's Content", Tags: []string{"Documents"}, Body: UpdateDocumentRequest{}, Response: models.Document{}, Query: []QueryParam{
+			{Name: "sync", Type: "boolean", Description: "Respond only once the change has been saved and synced to disk."},
+			{Name: "upsert", Type: "boolean", Description: "Create the document with this ID if it does not exist."},
+			{Name: "create", Type: "boolean", Description: "Alias for 'upsert'."},
+		}})
+		docGroup.PUT("/:id", func(c *gin.Context) {
+			UpdateDocumentHandler(c, database, cfg)
+		})
+		// DELETE /documents/{id}
+		describe(docGroup, http.MethodDelete, "/:id", RouteDoc{Summary: "Delete a Document", Tags: []string{"Documents"}, Status: http.StatusNoContent})
+		docGroup.DELETE("/:id", func(c *gin.Context) {
+			DeleteDocumentHandler(c, database, cfg)
+		})
+		// PUT /documents/{id}/content/{path}
+		describe(docGroup, http.MethodPut, "/:id/content/:path", RouteDoc{Summary: "Set a Value in a Document", Tags: []string{"Documents"}, Body: json.RawMessage{}, Response: ContentPathResponse{}, Query: []QueryParam{
+			{Name: "version", Type: "integer", Description: "Only change the document if it is still at this version."},
+			{Name: "revision", Type: "integer", Description: "With version: the revision of that version, if it was coalesced (0 by default)."},
+		}})
+		docGroup.PUT("/:id/content/:path", func(c *gin.Context) {
+			SetContentPathHandler(c, database, cfg)
+		})
+		// DELETE /documents/{id}/content/{path}
+		describe(docGroup, http.MethodDelete, "/:id/content/:path", RouteDoc{Summary: "Remove a Value from a Document", Tags: []string{"Documents"}, Response: ContentPathResponse{}, Query: []QueryParam{
+			{Name: "version", Type: "integer", Description: "Only change the document if it is still at this version."},
+			{Name: "revision", Type: "integer", Description: "With version: the revision of that version, if it was coalesced (0 by default)."},
+		}})
+		docGroup.DELETE("/:id/content/:path", func(c *gin.Context) {
+			DeleteContentPathHandler(c, database, cfg)
+		})
+		// POST /documents/{id}/content/{path}/append
+		describe(docGroup, http.MethodPost, "/:id/content/:path/append", RouteDoc{Summary: "Append to an Array in a Document", Tags: []string{"Documents"}, Body: json.RawMessage{}, Response: ContentPathResponse{}, Query: []QueryParam{
+			{Name: "version", Type: "integer", Description: "Only change the document if it is still at this version."},
+			{Name: "revision", Type: "integer", Description: "With version: the revision of that version, if it was coalesced (0 by default)."},
+		}})
+		docGroup.POST("/:id/content/:path/append", func(c *gin.Context) {
+			AppendContentArrayHandler(c, database, cfg)
+		})
+		// POST /documents/{id}/content/{path}/insert
+		describe(docGroup, http.MethodPost, "/:id/content/:path/insert", RouteDoc{Summary: "Insert into an Array in a Document", Tags: []string{"Documents"}, Body: json.RawMessage{}, Response: ContentPathResponse{}, Query: []QueryParam{
+			{Name: "index", Type: "integer", Required: true, Description: "Position the item gets; -1 appends."},
+			{Name: "version", Type: "integer", Description: "Only change the document if it is still at this version."},
+			{Name: "revision", Type: "integer", Description: "With version: the revision of that version, if it was coalesced (0 by default)."},
+		}})
+		docGroup.POST("/:id/content/:path/insert", func(c *gin.Context) {
+			InsertContentArrayHandler(c, database, cfg)
+		})
+		// POST /documents/{id}/content/{path}/remove
+		describe(docGroup, http.MethodPost, "/:id/content/:path/remove", RouteDoc{Summary: "Remove from an Array in a Document", Tags: []string{"Documents"}, Response: ContentPathResponse{}, Query: []QueryParam{
+			{Name: "index", Type: "integer", Required: true, Description: "Position of the item to remove; -1 removes the last one."},
+			{Name: "version", Type: "integer", Description: "Only change the document if it is still at this version."},
+			{Name: "revision", Type: "integer", Description: "With version: the revision of that version, if it was coalesced (0 by default)."},
+		}})
+		docGroup.POST("/:id/content/:path/remove", func(c *gin.Context) {
+			RemoveContentArrayHandler(c, database, cfg)
+		})
+		// POST /documents/{id}/content/{path}/increment
+		describe(docGroup, http.MethodPost, "/:id/content/:path/increment", RouteDoc{Summary: "Increment a Number in a Document", Tags: []string{"Documents"}, Body: IncrementRequest{}, BodyOptional: true, Response: ContentPathResponse{}, Query: []QueryParam{
+			{Name: "version", Type: "integer", Description: "Only change the document if it is still at this version."},
+			{Name: "revision", Type: "integer", Description: "With version: the revision of that version, if it was coalesced (0 by default)."},
+		}})
+		docGroup.POST("/:id/content/:path/increment", func(c *gin.Context) {
+			IncrementContentNumberHandler(c, database, cfg)
+		})
+
+		// GET /documents/{id}/activity
+		describe(docGroup, http.MethodGet, "/:id/activity", RouteDoc{Summary: "Get a Document's Activity", Tags: []string{"Documents"}, Response: models.DocumentEvent{}, List: true, Query: []QueryParam{
+			{Name: "page", Type: "integer", Description: "Page number for pagination (starts at 1)."},
+			{Name: "limit", Type: "integer", Description: "Number of entries per page."},
+		}})
+		docGroup.GET("/:id/activity", func(c *gin.Context) {
+			GetDocumentActivityHandler(c, database, cfg)
+		})
+		// GET /documents/{id}/backlinks
+		describe(docGroup, http.MethodGet, "/:id/backlinks", RouteDoc{Summary: "Get a Document's Backlinks", Tags: []string{"Documents"}, Response: []GraphNode{}})
+		docGroup.GET("/:id/backlinks", func(c *gin.Context) {
+			GetBacklinksHandler(c, database, cfg)
+		})
+		// GET /documents/{id}/stats
+		describe(docGroup, http.MethodGet, "/:id/stats", RouteDoc{Summary: "Get a Document's Statistics", Tags: []string{"Documents"}, Response: models.DocumentStats{}, Query: []QueryParam{
+			{Name: "last", Type: "integer", Description: "Number of modification timestamps."},
+		}})
+		docGroup.GET("/:id/stats", func(c *gin.Context) {
+			GetDocumentStatsHandler(c, database, cfg)
+		})
+
+		// GET /documents/{id}/diff
+		describe(docGroup, http.MethodGet, "/:id/diff", RouteDoc{Summary: "Compare Two Versions of a Document", Tags: []string{"Documents"}, Response: DocumentDiffResponse{}, Query: []QueryParam{
+			{Name: "from", Type: "integer", Description: "Version to compare from. Defaults to the version before 'to'."},
+			{Name: "to", Type: "integer", Description: "Version to compare to. Defaults to the current version."},
+		}})
+		docGroup.GET("/:id/diff", func(c *gin.Context) {
+			GetDocumentDiffHandler(c, database, cfg)
+		})
+		// POST /documents/{id}/diff
+		describe(docGroup, http.MethodPost, "/:id/diff", RouteDoc{Summary: "Compare a Document with New Content", Tags: []string{"Documents"}, Body: DiffDocumentRequest{}, Response: DocumentDiffResponse{}, Query: []QueryParam{
+			{Name: "from", Type: "integer", Description: "Version to compare from. Defaults to the current version."},
+		}})
+		docGroup.POST("/:id/diff", func(c *gin.Context) {
+			DiffDocumentContentHandler(c, database, cfg)
+		})
+		// POST /documents/{id}/lint
+		describe(docGroup, http.MethodPost, "/:id/lint", RouteDoc{Summary: "Lint and Normalize a Document", Tags: []string{"Documents"}, Body: LintDocumentRequest{}, BodyOptional: true, Response: LintDocumentResponse{}})
+		docGroup.POST("/:id/lint", func(c *gin.Context) {
+			LintDocumentHandler(c, database, cfg)
+		})
+
+		// POST /documents/{id}/workflow
+		describe(docGroup, http.MethodPost, "/:id/workflow", RouteDoc{Summary: "Submit, Approve or Reject a Document", Tags: []string{"Documents"}, Body: WorkflowTransitionRequest{}, Response: models.Document{}})
+		docGroup.POST("/:id/workflow", func(c *gin.Context) {
+			TransitionWorkflowHandler(c, database, cfg)
+		})
+		// POST /documents/{id}/reviews
+		describe(docGroup, http.MethodPost, "/:id/reviews", RouteDoc{Summary: "Assign a Reviewer", Tags: []string{"Reviews"}, Body: AssignReviewRequest{}, Status: http.StatusCreated, Response: models.Review{}})
+		docGroup.POST("/:id/reviews", func(c *gin.Context) {
+			AssignReviewHandler(c, database, cfg)
+		})
+		// GET /documents/{id}/reviews
+		describe(docGroup, http.MethodGet, "/:id/reviews", RouteDoc{Summary: "List a Document's Reviews", Tags: []string{"Reviews"}, Response: DocumentReviewsResponse{}})
+		docGroup.GET("/:id/reviews", func(c *gin.Context) {
+			ListDocumentReviewsHandler(c, database, cfg)
+		})
+		// POST /documents/{id}/freeze
+		describe(docGroup, http.MethodPost, "/:id/freeze", RouteDoc{Summary: "Freeze a Document", Tags: []string{"Documents"}, Response: models.Document{}})
+		docGroup.POST("/:id/freeze", func(c *gin.Context) {
+			FreezeDocumentHandler(c, database, cfg)
+		})
+		// DELETE /documents/{id}/freeze
+		describe(docGroup, http.MethodDelete, "/:id/freeze", RouteDoc{Summary: "Unfreeze a Document", Tags: []string{"Documents"}, Response: models.Document{}})
+		docGroup.DELETE("/:id/freeze", func(c *gin.Context) {
+			UnfreezeDocumentHandler(c, database, cfg)
+		})
+		// PUT /documents/{id}/expiry
+		describe(docGroup, http.MethodPut, "/:id/expiry", RouteDoc{Summary: "Set When a Document Expires", Tags: []string{"Documents"}, Body: DocumentExpiryRequest{}, Response: DocumentResponse{}})
+		docGroup.PUT("/:id/expiry", func(c *gin.Context) {
+			SetDocumentExpiryHandler(c, database, cfg)
+		})
+		// DELETE /documents/{id}/expiry
+		describe(docGroup, http.MethodDelete, "/:id/expiry", RouteDoc{Summary: "Keep a Document for Good", Tags: []string{"Documents"}, Response: DocumentResponse{}})
+		docGroup.DELETE("/:id/expiry", func(c *gin.Context) {
+			ClearDocumentExpiryHandler(c, database, cfg)
+		})
+
+		// POST /documents/{id}/signed-url
+		describe(docGroup, http.MethodPost, "/:id/signed-url", RouteDoc{Summary: "Create a Signed URL", Tags: []string{"Documents"}, Status: http.StatusCreated, Response: SignedURLResponse{}, Query: []QueryParam{
+			{Name: "op", Type: "string", Required: true, Description: "What the URL allows.", Enum: []string{"read", "write"}},
+			{Name: "ttl", Type: "string", Description: "How long the URL stays valid, as a duration up to 24h. Defaults to 10m."},
+		}})
+		docGroup.POST("/:id/signed-url", func(c *gin.Context) {
+			CreateSignedURLHandler(c, database, cfg)
+		})
+
+		// POST /documents/{id}/favorite
+		describe(docGroup, http.MethodPost, "/:id/favorite", RouteDoc{Summary: "Mark a Document as Favorite", Tags: []string{"Documents"}, Response: FavoriteResponse{}})
+		docGroup.POST("/:id/favorite", func(c *gin.Context) {
+			AddFavoriteHandler(c, database, cfg)
+		})
+		// DELETE /documents/{id}/favorite
+		describe(docGroup, http.MethodDelete, "/:id/favorite", RouteDoc{Summary: "Remove a Document from Favorites", Tags: []string{"Documents"}, Status: http.StatusNoContent})
+		docGroup.DELETE("/:id/favorite", func(c *gin.Context) {
+			RemoveFavoriteHandler(c, database, cfg)
+		})
+
+		// Sharing Sub-routes (nested under /documents/{id})
+		shareGroup := docGroup.Group("/:id/shares")
+		{
+			// GET /documents/{id}/shares
+			describe(shareGroup, http.MethodGet, "", RouteDoc{Summary: "See Who a Document is Shared With", Tags: []string{"Sharing"}, Response: GetSharersResponse{}})
+			shareGroup.GET("", func(c *gin.Context) {
+				GetSharersHandler(c, database, cfg)
+			})
+			// PUT /documents/{id}/shares
+			describe(shareGroup, http.MethodPut, "", RouteDoc{Summary: "Set/Replace Who a Document is Shared With", Tags: []string{"Sharing"}, Body: SetSharersRequest{}, Status: http.StatusNoContent})
+			shareGroup.PUT("", func(c *gin.Context) {
+				SetSharersHandler(c, database, cfg)
+			})
+			// POST /documents/{id}/shares/email
+			describe(shareGroup, http.MethodPost, "/email", RouteDoc{Summary: "Share a Document by Email", Tags: []string{"Sharing"}, Body: ShareByEmailRequest{}, Response: ShareByEmailResponse{}})
+			shareGroup.POST("/email", func(c *gin.Context) {
+				ShareByEmailHandler(c, database, cfg)
+			})
+			// DELETE /documents/{id}/shares/email/{email}
+			describe(shareGroup, http.MethodDelete, "/email/:email", RouteDoc{Summary: "Withdraw a Pending Share", Tags: []string{"Sharing"}, Status: http.StatusNoContent})
+			shareGroup.DELETE("/email/:email", func(c *gin.Context) {
+				CancelPendingShareHandler(c, database, cfg)
+			})
+			// PUT /documents/{id}/shares/{profile_id}
+			describe(shareGroup, http.MethodPut, "/:profile_id", RouteDoc{Summary: "Share a Document with One User", Tags: []string{"Sharing"}, Status: http.StatusNoContent})
+			shareGroup.PUT("/:profile_id", func(c *gin.Context) {
+				AddSharerHandler(c, database, cfg)
+			})
+			// DELETE /documents/{id}/shares/{profile_id}
+			describe(shareGroup, http.MethodDelete, "/:profile_id", RouteDoc{Summary: "Stop Sharing a Document with One User", Tags: []string{"Sharing"}, Status: http.StatusNoContent})
+			shareGroup.DELETE("/:profile_id", func(c *gin.Context) {
+				RemoveSharerHandler(c, database, cfg)
+			})
+			// PUT /documents/{id}/shares/{profile_id}/scope
+			describe(shareGroup, http.MethodPut, "/:profile_id/scope", RouteDoc{Summary: "Share Only Part of a Document", Tags: []string{"Sharing"}, Body: ShareScopeRequest{}, Response: ShareScopeResponse{}})
+			shareGroup.PUT("/:profile_id/scope", func(c *gin.Context) {
+				SetShareScopeHandler(c, database, cfg)
+			})
+			// DELETE /documents/{id}/shares/{profile_id}/scope
+			describe(shareGroup, http.MethodDelete, "/:profile_id/scope", RouteDoc{Summary: "Share the Whole Document Again", Tags: []string{"Sharing"}, Status: http.StatusNoContent})
+			shareGroup.DELETE("/:profile_id/scope", func(c *gin.Context) {
+				ClearShareScopeHandler(c, database, cfg)
+			})
+		}
+	}
+
+	// Signed URL Routes (authenticated by the token in the URL instead of a header)
+	signedGroup := rg.Group("/signed")
+	{
+		// GET /signed/{token}
+		describe(signedGroup, http.MethodGet, "/:token", RouteDoc{Summary: "Read a Document Through a Signed URL", Tags: []string{"Documents"}, Public: true, Response: DocumentResponse{}, Query: []QueryParam{
+			{Name: "render", Type: "string", Description: "Set to 'html' to get a Markdown document rendered as an HTML page.", Enum: []string{"html"}},
+		}})
+		signedGroup.GET("/:token", SignedURLMiddleware(database, cfg, utils.SignedURLRead), activeMiddleware, tosMiddleware, func(c *gin.Context) {
+			GetDocumentByIDHandler(c, database, cfg)
+		})
+		// PUT /signed/{token}
+		describe(signedGroup, http.MethodPut, "/:token", RouteDoc{Summary: "Update a Document Through a Signed URL", Tags: []string{"Documents"}, Public: true, Body: UpdateDocumentRequest{}, Response: models.Document{}, Query: []QueryParam{
+			{Name: "sync", Type: "boolean", Description: "Respond only once the change has been saved and synced to disk."},
+		}})
+		signedGroup.PUT("/:token", SignedURLMiddleware(database, cfg, utils.SignedURLWrite), activeMiddleware, tosMiddleware, func(c *gin.Context) {
+			UpdateDocumentHandler(c, database, cfg)
+		})
+	}
+
+	// GET /graph
+	describe(rg, http.MethodGet, "/graph", RouteDoc{Summary: "Get the Link Graph", Tags: []string{"Documents"}, Response: LinkGraph{}})
+	rg.GET("/graph", authMiddleware, activeMiddleware, scopeMiddleware("documents"), tosMiddleware, func(c *gin.Context) {
+		GetLinkGraphHandler(c, database, cfg)
+	})
+
+	// Calendar Feed Routes
+	calendarGroup := rg.Group("/calendar")
+	calendarGroup.Use(authMiddleware, activeMiddleware, scopeMiddleware(""), tosMiddleware)
+	{
+		// POST /calendar/feed
+		describe(calendarGroup, http.MethodPost, "/feed", RouteDoc{Summary: "Create a Calendar Feed URL", Tags: []string{"Calendar"}, Status: http.StatusCreated, Response: CalendarFeedResponse{}})
+		calendarGroup.POST("/feed", func(c *gin.Context) {
+			CreateCalendarFeedHandler(c, database, cfg)
+		})
+		// DELETE /calendar/feed
+		describe(calendarGroup, http.MethodDelete, "/feed", RouteDoc{Summary: "Delete the Calendar Feed", Tags: []string{"Calendar"}, Status: http.StatusNoContent})
+		calendarGroup.DELETE("/feed", func(c *gin.Context) {
+			DeleteCalendarFeedHandler(c, database, cfg)
+		})
+	}
+	// GET /calendar.ics (authenticated by the feed token in the URL instead of a header)
+	describe(rg, http.MethodGet, "/calendar.ics", RouteDoc{Summary: "Get the Calendar Feed", Tags: []string{"Calendar"}, Public: true, Produces: "text/calendar", Query: []QueryParam{
+		{Name: "token", Type: "string", Required: true, Description: "The feed token."},
+	}})
+	rg.GET("/calendar.ics", CalendarFeedMiddleware(database, cfg), activeMiddleware, tosMiddleware, func(c *gin.Context) {
+		GetCalendarFeedHandler(c, database, cfg)
+	})
+
+	// Scheduled Export Routes
+	scheduleGroup := rg.Group("/schedules")
+	scheduleGroup.Use(authMiddleware, activeMiddleware, scopeMiddleware(""), tosMiddleware, utils.ValidateIDParams(scheduleIDParams))
+	{
+		// GET /schedules
+		describe(scheduleGroup, http.MethodGet, "", RouteDoc{Summary: "List Your Scheduled Exports", Tags: []string{"Schedules"}, Response: []models.Schedule{}})
+		scheduleGroup.GET("", func(c *gin.Context) {
+			ListSchedulesHandler(c, database, cfg)
+		})
+		// POST /schedules
+		describe(scheduleGroup, http.MethodPost, "", RouteDoc{Summary: "Schedule a Recurring Export", Tags: []string{"Schedules"}, Body: ScheduleRequest{}, Status: http.StatusCreated, Response: models.Schedule{}})
+		scheduleGroup.POST("", func(c *gin.Context) {
+			CreateScheduleHandler(c, database, cfg)
+		})
+		// GET /schedules/{id}
+		describe(scheduleGroup, http.MethodGet, "/:id", RouteDoc{Summary: "Get a Scheduled Export", Tags: []string{"Schedules"}, Response: models.Schedule{}})
+		scheduleGroup.GET("/:id", func(c *gin.Context) {
+			GetScheduleHandler(c, database, cfg)
+		})
+		// PUT /schedules/{id}
+		describe(scheduleGroup, http.MethodPut, "/:id", RouteDoc{Summary: "Replace a Scheduled Export", Tags: []string{"Schedules"}, Body: ScheduleRequest{}, Response: models.Schedule{}})
+		scheduleGroup.PUT("/:id", func(c *gin.Context) {
+			UpdateScheduleHandler(c, database, cfg)
+		})
+		// DELETE /schedules/{id}
+		describe(scheduleGroup, http.MethodDelete, "/:id", RouteDoc{Summary: "Delete a Scheduled Export", Tags: []string{"Schedules"}, Status: http.StatusNoContent})
+		scheduleGroup.DELETE("/:id", func(c *gin.Context) {
+			DeleteScheduleHandler(c, database, cfg)
+		})
+		// POST /schedules/{id}/run
+		describe(scheduleGroup, http.MethodPost, "/:id/run", RouteDoc{Summary: "Run a Scheduled Export Now", Tags: []string{"Schedules"}, Status: http.StatusCreated, Response: models.ScheduleRun{}})
+		scheduleGroup.POST("/:id/run", func(c *gin.Context) {
+			RunScheduleHandler(c, database, cfg)
+		})
+		// GET /schedules/{id}/runs
+		describe(scheduleGroup, http.MethodGet, "/:id/runs", RouteDoc{Summary: "List the Runs of a Scheduled Export", Tags: []string{"Schedules"}, Response: []models.ScheduleRun{}})
+		scheduleGroup.GET("/:id/runs", func(c *gin.Context) {
+			GetScheduleRunsHandler(c, database, cfg)
+		})
+		// GET /schedules/{id}/runs/{run_id}/download
+		describe(scheduleGroup, http.MethodGet, "/:id/runs/:run_id/download", RouteDoc{Summary: "Download a Scheduled Export", Tags: []string{"Schedules"}, Response: db.ScheduleExport{}})
+		scheduleGroup.GET("/:id/runs/:run_id/download", func(c *gin.Context) {
+			DownloadScheduleRunHandler(c, database, cfg)
+		})
+	}
+
+	// Notification Channel Routes
+	notificationGroup := rg.Group("/notification-channels")
+	notificationGroup.Use(authMiddleware, activeMiddleware, scopeMiddleware(""), tosMiddleware, utils.ValidateIDParams(notificationChannelIDParams))
+	{
+		// GET /notification-channels
+		describe(notificationGroup, http.MethodGet, "", RouteDoc{Summary: "List Your Notification Channels", Tags: []string{"Notifications"}, Response: []models.NotificationChannel{}})
+		notificationGroup.GET("", func(c *gin.Context) {
+			ListNotificationChannelsHandler(c, database, cfg)
+		})
+		// POST /notification-channels
+		describe(notificationGroup, http.MethodPost, "", RouteDoc{Summary: "Add a Notification Channel", Tags: []string{"Notifications"}, Body: NotificationChannelRequest{}, Status: http.StatusCreated, Response: models.NotificationChannel{}})
+		notificationGroup.POST("", func(c *gin.Context) {
+			CreateNotificationChannelHandler(c, database, cfg)
+		})
+		// GET /notification-channels/{id}
+		describe(notificationGroup, http.MethodGet, "/:id", RouteDoc{Summary: "Get a Notification Channel", Tags: []string{"Notifications"}, Response: models.NotificationChannel{}})
+		notificationGroup.GET("/:id", func(c *gin.Context) {
+			GetNotificationChannelHandler(c, database, cfg)
+		})
+		// PUT /notification-channels/{id}
+		describe(notificationGroup, http.MethodPut, "/:id", RouteDoc{Summary: "Replace a Notification Channel", Tags: []string{"Notifications"}, Body: NotificationChannelRequest{}, Response: models.NotificationChannel{}})
+		notificationGroup.PUT("/:id", func(c *gin.Context) {
+			UpdateNotificationChannelHandler(c, database, cfg)
+		})
+		// DELETE /notification-channels/{id}
+		describe(notificationGroup, http.MethodDelete, "/:id", RouteDoc{Summary: "Delete a Notification Channel", Tags: []string{"Notifications"}, Status: http.StatusNoContent})
+		notificationGroup.DELETE("/:id", func(c *gin.Context) {
+			DeleteNotificationChannelHandler(c, database, cfg)
+		})
+		// POST /notification-channels/{id}/test
+		describe(notificationGroup, http.MethodPost, "/:id/test", RouteDoc{Summary: "Send a Test Message", Tags: []string{"Notifications"}, Status: http.StatusNoContent})
+		notificationGroup.POST("/:id/test", func(c *gin.Context) {
+			TestNotificationChannelHandler(c, database, cfg)
+		})
+	}
+
+	// Review Routes
+	reviewGroup := rg.Group("/reviews")
+	reviewGroup.Use(authMiddleware, activeMiddleware, scopeMiddleware(""), tosMiddleware, utils.ValidateIDParams(reviewIDParams))
+	{
+		// GET /reviews
+		describe(reviewGroup, http.MethodGet, "", RouteDoc{Summary: "List Your Reviews", Tags: []string{"Reviews"}, Response: []models.Review{}, Query: []QueryParam{
+			{Name: "status", Type: "string", Description: "Only reviews with this status: 'pending' or 'submitted'."},
+		}})
+		reviewGroup.GET("", func(c *gin.Context) {
+			ListMyReviewsHandler(c, database, cfg)
+		})
+		// GET /reviews/{id}
+		describe(reviewGroup, http.MethodGet, "/:id", RouteDoc{Summary: "Get a Review", Tags: []string{"Reviews"}, Response: models.Review{}})
+		reviewGroup.GET("/:id", func(c *gin.Context) {
+			GetReviewHandler(c, database, cfg)
+		})
+		// PUT /reviews/{id}
+		describe(reviewGroup, http.MethodPut, "/:id", RouteDoc{Summary: "Submit Review Feedback", Tags: []string{"Reviews"}, Body: SubmitReviewRequest{}, Response: models.Review{}})
+		reviewGroup.PUT("/:id", func(c *gin.Context) {
+			SubmitReviewHandler(c, database, cfg)
+		})
+		// DELETE /reviews/{id}
+		describe(reviewGroup, http.MethodDelete, "/:id", RouteDoc{Summary: "Remove a Reviewer", Tags: []string{"Reviews"}, Status: http.StatusNoContent})
+		reviewGroup.DELETE("/:id", func(c *gin.Context) {
+			DeleteReviewHandler(c, database, cfg)
+		})
+	}
+
+	// Admin Routes
+	adminGroup := rg.Group("/admin")
+	adminGroup.Use(authMiddleware, activeMiddleware, scopeMiddleware(""), RequireAdminMiddleware(database, cfg))
+	{
+		// GET /admin/scripts
+		describe(adminGroup, http.MethodGet, "/scripts", RouteDoc{Summary: "List Document Scripts (Admin)", Tags: []string{"Admin"}, Response: []models.Script{}})
+		adminGroup.GET("/scripts", func(c *gin.Context) {
+			ListScriptsHandler(c, database, cfg)
+		})
+		// POST /admin/scripts
+		describe(adminGroup, http.MethodPost, "/scripts", RouteDoc{Summary: "Add a Document Script (Admin)", Tags: []string{"Admin"}, Body: ScriptRequest{}, Status: http.StatusCreated, Response: models.Script{}})
+		adminGroup.POST("/scripts", func(c *gin.Context) {
+			CreateScriptHandler(c, database, cfg)
+		})
+		// GET /admin/scripts/{id}
+		describe(adminGroup, http.MethodGet, "/scripts/:id", RouteDoc{Summary: "Get a Document Script (Admin)", Tags: []string{"Admin"}, Response: models.Script{}})
+		adminGroup.GET("/scripts/:id", utils.ValidateIDParams(scriptIDParams), func(c *gin.Context) {
+			GetScriptHandler(c, database, cfg)
+		})
+		// PUT /admin/scripts/{id}
+		describe(adminGroup, http.MethodPut, "/scripts/:id", RouteDoc{Summary: "Replace a Document Script (Admin)", Tags: []string{"Admin"}, Body: ScriptRequest{}, Response: models.Script{}})
+		adminGroup.PUT("/scripts/:id", utils.ValidateIDParams(scriptIDParams), func(c *gin.Context) {
+			UpdateScriptHandler(c, database, cfg)
+		})
+		// DELETE /admin/scripts/{id}
+		describe(adminGroup, http.MethodDelete, "/scripts/:id", RouteDoc{Summary: "Delete a Document Script (Admin)", Tags: []string{"Admin"}, Status: http.StatusNoContent})
+		adminGroup.DELETE("/scripts/:id", utils.ValidateIDParams(scriptIDParams), func(c *gin.Context) {
+			DeleteScriptHandler(c, database, cfg)
+		})
+		// GET /admin/profiles/deactivated
+		describe(adminGroup, http.MethodGet, "/profiles/deactivated", RouteDoc{Summary: "List Deactivated Accounts (Admin)", Tags: []string{"Admin"}, Response: []DeactivatedProfile{}})
+		adminGroup.GET("/profiles/deactivated", func(c *gin.Context) {
+			ListDeactivatedProfilesHandler(c, database, cfg)
+		})
+		// POST /admin/profiles/{id}/reactivate
+		describe(adminGroup, http.MethodPost, "/profiles/:id/reactivate", RouteDoc{Summary: "Reactivate an Account (Admin)", Tags: []string{"Admin"}, Response: DeactivatedProfile{}})
+		adminGroup.POST("/profiles/:id/reactivate", utils.ValidateIDParams(profileIDParams), func(c *gin.Context) {
+			ReactivateProfileHandler(c, database, cfg)
+		})
+		// POST /admin/profiles/{id}/purge
+		describe(adminGroup, http.MethodPost, "/profiles/:id/purge", RouteDoc{Summary: "Purge a Deactivated Account (Admin)", Tags: []string{"Admin"}, Response: models.ErasureReport{}})
+		adminGroup.POST("/profiles/:id/purge", utils.ValidateIDParams(profileIDParams), func(c *gin.Context) {
+			PurgeProfileHandler(c, database, cfg)
+		})
+		// POST /admin/generate-load
+		describe(adminGroup, http.MethodPost, "/generate-load", RouteDoc{Summary: "Generate Load (Admin)", Tags: []string{"Admin"}, Status: http.StatusCreated, Response: GenerateLoadResponse{}, Query: []QueryParam{
+			{Name: "docs", Type: "integer", Description: "Documents to create (default 1000)."},
+			{Name: "users", Type: "integer", Description: "Users to create (default one per 20 documents, up to the limit)."},
+			{Name: "seed", Type: "integer", Description: "Seed of the generated content (default 1)."},
+		}})
+		adminGroup.POST("/generate-load", func(c *gin.Context) {
+			GenerateLoadHandler(c, database, cfg)
+		})
+		// POST /admin/provision
+		describe(adminGroup, http.MethodPost, "/provision", RouteDoc{Summary: "Provision Accounts from a Roster (Admin)", Tags: []string{"Admin"}, Body: ProvisionRequest{}, Response: ProvisionReport{}, Query: []QueryParam{
+			{Name: "group", Type: "string", Description: "With a CSV roster: the group to put the accounts in."},
+			{Name: "delivery", Type: "string", Description: "With a CSV roster: 'response' or 'email'."},
+		}})
+		adminGroup.POST("/provision", func(c *gin.Context) {
+			ProvisionAccountsHandler(c, database, cfg)
+		})
+		// GET /admin/invites
+		describe(adminGroup, http.MethodGet, "/invites", RouteDoc{Summary: "List Invitation Codes (Admin)", Tags: []string{"Admin"}, Response: []models.Invite{}})
+		adminGroup.GET("/invites", func(c *gin.Context) {
+			ListInvitesHandler(c, database, cfg)
+		})
+		// POST /admin/invites
+		describe(adminGroup, http.MethodPost, "/invites", RouteDoc{Summary: "Create an Invitation Code (Admin)", Tags: []string{"Admin"}, Body: InviteRequest{}, BodyOptional: true, Status: http.StatusCreated, Response: models.Invite{}})
+		adminGroup.POST("/invites", func(c *gin.Context) {
+			CreateInviteHandler(c, database, cfg)
+		})
+		// DELETE /admin/invites/{code}
+		describe(adminGroup, http.MethodDelete, "/invites/:code", RouteDoc{Summary: "Delete an Invitation Code (Admin)", Tags: []string{"Admin"}, Status: http.StatusNoContent})
+		adminGroup.DELETE("/invites/:code", func(c *gin.Context) {
+			DeleteInviteHandler(c, database, cfg)
+		})
+		// GET /admin/usage/api
+		describe(adminGroup, http.MethodGet, "/usage/api", RouteDoc{Summary: "List API Usage by Profile (Admin)", Tags: []string{"Admin"}, Response: []ProfileAPIUsage{}, Query: []QueryParam{
+			{Name: "group", Type: "string", Description: "Only profiles in this group."},
+		}})
+		adminGroup.GET("/usage/api", func(c *gin.Context) {
+			ListAPIUsageHandler(c, database, cfg)
+		})
+		// GET /admin/dashboard
+		describe(adminGroup, http.MethodGet, "/dashboard", RouteDoc{Summary: "Get the Dashboard (Admin)", Tags: []string{"Admin"}, Response: db.Dashboard{}, Query: []QueryParam{
+			{Name: "limit", Type: "integer", Description: "Number of recent activity entries."},
+		}})
+		adminGroup.GET("/dashboard", func(c *gin.Context) {
+			GetDashboardHandler(c, database, cfg)
+		})
+		// GET /admin/slow-queries
+		describe(adminGroup, http.MethodGet, "/slow-queries", RouteDoc{Summary: "List Slow Queries (Admin)", Tags: []string{"Admin"}, Response: []db.SlowQuery{}})
+		adminGroup.GET("/slow-queries", func(c *gin.Context) {
+			ListSlowQueriesHandler(c, database, cfg)
+		})
+		// GET /admin/query-cache
+		describe(adminGroup, http.MethodGet, "/query-cache", RouteDoc{Summary: "Get Query Cache Statistics (Admin)", Tags: []string{"Admin"}, Response: db.QueryCacheStats{}})
+		adminGroup.GET("/query-cache", func(c *gin.Context) {
+			GetQueryCacheStatsHandler(c, database, cfg)
+		})
+		// GET /admin/password-hashes
+		describe(adminGroup, http.MethodGet, "/password-hashes", RouteDoc{Summary: "Report Password Hash Migration (Admin)", Tags: []string{"Admin"}, Response: db.PasswordHashReport{}})
+		adminGroup.GET("/password-hashes", func(c *gin.Context) {
+			GetPasswordHashReportHandler(c, database, cfg)
+		})
+		// GET /admin/chaos
+		describe(adminGroup, http.MethodGet, "/chaos", RouteDoc{Summary: "Get Chaos Settings (Admin, Debug Mode)", Tags: []string{"Admin"}, Response: ChaosSettings{}})
+		adminGroup.GET("/chaos", func(c *gin.Context) {
+			GetChaosHandler(c, database, cfg, chaos)
+		})
+		// PUT /admin/chaos
+		describe(adminGroup, http.MethodPut, "/chaos", RouteDoc{Summary: "Switch Chaos Mode On (Admin, Debug Mode)", Tags: []string{"Admin"}, Body: ChaosSettings{}, Response: ChaosSettings{}})
+		adminGroup.PUT("/chaos", func(c *gin.Context) {
+			SetChaosHandler(c, database, cfg, chaos)
+		})
+		// DELETE /admin/chaos
+		describe(adminGroup, http.MethodDelete, "/chaos", RouteDoc{Summary: "Switch Chaos Mode Off (Admin, Debug Mode)", Tags: []string{"Admin"}, Status: http.StatusNoContent})
+		adminGroup.DELETE("/chaos", func(c *gin.Context) {
+			DeleteChaosHandler(c, database, cfg, chaos)
+		})
+		// GET /admin/profile-extra-policy
+		describe(adminGroup, http.MethodGet, "/profile-extra-policy", RouteDoc{Summary: "Get the Profile Extra Policy (Admin)", Tags: []string{"Admin"}, Response: models.ExtraPolicy{}})
+		adminGroup.GET("/profile-extra-policy", func(c *gin.Context) {
+			GetExtraPolicyHandler(c, database, cfg)
+		})
+		// PUT /admin/profile-extra-policy
+		describe(adminGroup, http.MethodPut, "/profile-extra-policy", RouteDoc{Summary: "Set the Profile Extra Policy (Admin)", Tags: []string{"Admin"}, Body: ExtraPolicyRequest{}, Response: models.ExtraPolicy{}})
+		adminGroup.PUT("/profile-extra-policy", func(c *gin.Context) {
+			SetExtraPolicyHandler(c, database, cfg)
+		})
+		// DELETE /admin/profile-extra-policy
+		describe(adminGroup, http.MethodDelete, "/profile-extra-policy", RouteDoc{Summary: "Reset the Profile Extra Policy (Admin)", Tags: []string{"Admin"}, Response: models.ExtraPolicy{}})
+		adminGroup.DELETE("/profile-extra-policy", func(c *gin.Context) {
+			ResetExtraPolicyHandler(c, database, cfg)
+		})
+		// POST /admin/maintenance
+		describe(adminGroup, http.MethodPost, "/maintenance", RouteDoc{Summary: "Switch Maintenance Mode (Admin)", Tags: []string{"Admin"}, Body: MaintenanceRequest{}, Response: models.Maintenance{}})
+		adminGroup.POST("/maintenance", func(c *gin.Context) {
+			SetMaintenanceHandler(c, database, cfg)
+		})
+		// GET /admin/orphans
+		describe(adminGroup, http.MethodGet, "/orphans", RouteDoc{Summary: "Report Orphaned References (Admin)", Tags: []string{"Admin"}, Response: db.OrphanReport{}})
+		adminGroup.GET("/orphans", func(c *gin.Context) {
+			ListOrphansHandler(c, database, cfg)
+		})
+		// POST /admin/orphans/clean
+		describe(adminGroup, http.MethodPost, "/orphans/clean", RouteDoc{Summary: "Remove Orphaned References (Admin)", Tags: []string{"Admin"}, Response: db.OrphanReport{}})
+		adminGroup.POST("/orphans/clean", func(c *gin.Context) {
+			CleanOrphansHandler(c, database, cfg)
+		})
+		// GET /admin/recovery
+		describe(adminGroup, http.MethodGet, "/recovery", RouteDoc{Summary: "Report Database Recovery (Admin)", Tags: []string{"Admin"}, Response: RecoveryResponse{}})
+		adminGroup.GET("/recovery", func(c *gin.Context) {
+			GetRecoveryHandler(c, database, cfg)
+		})
+		// GET /admin/service-accounts
+		describe(adminGroup, http.MethodGet, "/service-accounts", RouteDoc{Summary: "List Service Accounts (Admin)", Tags: []string{"Admin"}, Response: []ServiceAccountResponse{}})
+		adminGroup.GET("/service-accounts", func(c *gin.Context) {
+			ListServiceAccountsHandler(c, database, cfg)
+		})
+		// POST /admin/service-accounts
+		describe(adminGroup, http.MethodPost, "/service-accounts", RouteDoc{Summary: "Create a Service Account (Admin)", Tags: []string{"Admin"}, Body: ServiceAccountRequest{}, Status: http.StatusCreated, Response: ServiceAccountResponse{}})
+		adminGroup.POST("/service-accounts", func(c *gin.Context) {
+			CreateServiceAccountHandler(c, database, cfg)
+		})
+		// PUT /admin/service-accounts/{id}
+		describe(adminGroup, http.MethodPut, "/service-accounts/:id", RouteDoc{Summary: "Change a Service Account's Scopes (Admin)", Tags: []string{"Admin"}, Body: ServiceAccountScopesRequest{}, Response: ServiceAccountResponse{}})
+		adminGroup.PUT("/service-accounts/:id", utils.ValidateIDParams(profileIDParams), func(c *gin.Context) {
+			SetServiceAccountScopesHandler(c, database, cfg)
+		})
+		// DELETE /admin/service-accounts/{id}
+		describe(adminGroup, http.MethodDelete, "/service-accounts/:id", RouteDoc{Summary: "Delete a Service Account (Admin)", Tags: []string{"Admin"}, Status: http.StatusNoContent})
+		adminGroup.DELETE("/service-accounts/:id", utils.ValidateIDParams(profileIDParams), func(c *gin.Context) {
+			DeleteServiceAccountHandler(c, database, cfg)
+		})
+		// POST /admin/service-accounts/{id}/tokens
+		describe(adminGroup, http.MethodPost, "/service-accounts/:id/tokens", RouteDoc{Summary: "Issue a Service Account Token (Admin)", Tags: []string{"Admin"}, Body: ServiceTokenRequest{}, BodyOptional: true, Status: http.StatusCreated, Response: ServiceTokenResponse{}})
+		adminGroup.POST("/service-accounts/:id/tokens", utils.ValidateIDParams(profileIDParams), func(c *gin.Context) {
+			IssueServiceTokenHandler(c, database, cfg)
+		})
+		if courses != nil {
+			// GET /admin/courses
+			describe(adminGroup, http.MethodGet, "/courses", RouteDoc{Summary: "List Courses (Admin)", Tags: []string{"Admin"}, Response: []models.Course{}})
+			adminGroup.GET("/courses", func(c *gin.Context) {
+				ListCoursesHandler(c, database, cfg)
+			})
+			// POST /admin/courses
+			describe(adminGroup, http.MethodPost, "/courses", RouteDoc{Summary: "Create a Course (Admin)", Tags: []string{"Admin"}, Body: CourseRequest{}, Status: http.StatusCreated, Response: models.Course{}})
+			adminGroup.POST("/courses", func(c *gin.Context) {
+				CreateCourseHandler(c, database, cfg)
+			})
+			// DELETE /admin/courses/{course}
+			describe(adminGroup, http.MethodDelete, "/courses/:course", RouteDoc{Summary: "Drop a Course (Admin)", Tags: []string{"Admin"}, Status: http.StatusNoContent})
+			adminGroup.DELETE("/courses/:course", func(c *gin.Context) {
+				DropCourseHandler(c, database, cfg, courses)
+			})
+		}
+	}
+
+	// Logout route (needs auth middleware)
+	// POST /auth/logout
+	// It's under /auth conceptually, but needs the middleware
+	describe(rg, http.MethodPost, "/auth/logout", RouteDoc{Summary: "Log Out (Client-Side Action)", Tags: []string{"Authentication"}, Status: http.StatusNoContent})
+	rg.POST("/auth/logout", authMiddleware, func(c *gin.Context) {
+		LogoutHandler(c, database, cfg)
+	})
+	// POST /auth/tokens
+	describe(rg, http.MethodPost, "/auth/tokens", RouteDoc{Summary: "Create a Restricted Token", Tags: []string{"Authentication"}, Body: RestrictedTokenRequest{}, Status: http.StatusCreated, Response: RestrictedTokenResponse{}})
+	rg.POST("/auth/tokens", authMiddleware, activeMiddleware, scopeMiddleware(""), func(c *gin.Context) {
+		CreateRestrictedTokenHandler(c, database, cfg)
+	})
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"docserver/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRouter_ClientIP(t *testing.T) {
+	clientIP := func(router *gin.Engine, remoteAddr string, headers map[string]string) string {
+		req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+		req.RemoteAddr = remoteAddr
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr.Body.String()
+	}
+	newRouter := func(cfg *config.Config) *gin.Engine {
+		cfg.GinMode = config.GinModeTest
+		router, err := NewRouter(cfg)
+		require.NoError(t, err)
+		router.GET("/ip", func(c *gin.Context) { c.String(http.StatusOK, c.ClientIP()) })
+		return router
+	}
+	forwarded := map[string]string{"X-Forwarded-For": "203.0.113.7, 10.0.0.2"}
+
+	t.Run("No trusted proxies", func(t *testing.T) {
+		router := newRouter(&config.Config{ClientIPHeaders: []string{"X-Forwarded-For"}})
+		assert.Equal(t, "10.0.0.1", clientIP(router, "10.0.0.1:5000", forwarded), "forwarded headers are ignored")
+	})
+
+	t.Run("Trusted proxy", func(t *testing.T) {
+		router := newRouter(&config.Config{TrustedProxies: []string{"10.0.0.0/8"}, ClientIPHeaders: []string{"X-Forwarded-For"}})
+		assert.Equal(t, "203.0.113.7", clientIP(router, "10.0.0.1:5000", forwarded))
+		assert.Equal(t, "198.51.100.9", clientIP(router, "198.51.100.9:5000", forwarded), "only trusted proxies may set the client IP")
+		assert.Equal(t, "10.0.0.1", clientIP(router, "10.0.0.1:5000", map[string]string{"X-Real-Ip": "203.0.113.8"}), "unlisted headers are ignored")
+	})
+
+	t.Run("No client IP headers", func(t *testing.T) {
+		router := newRouter(&config.Config{TrustedProxies: []string{"10.0.0.1"}})
+		assert.Equal(t, "10.0.0.1", clientIP(router, "10.0.0.1:5000", forwarded))
+	})
+
+	t.Run("Invalid proxy", func(t *testing.T) {
+		_, err := NewRouter(&config.Config{GinMode: config.GinModeTest, TrustedProxies: []string{"not-an-ip"}})
+		assert.Error(t, err)
+	})
+}
+
+func TestRouteIDValidation(t *testing.T) {
+	router, _, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+	cfg.IDStrategy = config.IDStrategyPrefixed
+
+	_, _, token := createTestUserAndLogin(t, router, "ids.routes@example.com", "password123", "Id", "Routes")
+	rr := performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, gin.H{"content": gin.H{"a": 1}}), token)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var doc struct {
+		ID string `json:"id"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+	require.True(t, strings.HasPrefix(doc.ID, "doc_"), doc.ID)
+
+	assert.Equal(t, http.StatusOK, performRequest(router, http.MethodGet, "/documents/"+doc.ID, nil, token).Code)
+	assert.Equal(t, http.StatusNotFound, performRequest(router, http.MethodGet, "/documents/0123456789abcdef", nil, token).Code, "unprefixed IDs are still looked up")
+
+	for path, message := range map[string]string{
+		"/documents/usr_0123": "prefix of another kind",
+		"/documents/bad%20id": "not a valid ID",
+		"/schedules/doc_0123": "prefix of another kind",
+	} {
+		rr := performRequest(router, http.MethodGet, path, nil, token)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, path)
+		assert.Contains(t, rr.Body.String(), message, path)
+	}
+	rr = performRequest(router, http.MethodPut, "/documents/"+doc.ID+"/shares/doc_012", nil, token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "profile IDs are checked too")
+
+	rr = performRequest(router, http.MethodGet, "/documents/usr_0123", nil, "")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code, "authentication comes first")
+
+	rr = performRequest(router, http.MethodPost, "/documents", marshalJSONBody(t, gin.H{"id": "usr_mine", "content": "x"}), token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "custom IDs cannot claim another kind's prefix")
+}
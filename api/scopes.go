@@ -0,0 +1,65 @@
+package api
+
+import (
+	"docserver/db"
+	"docserver/i18n"
+	"docserver/utils"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Token Scopes ---
+
+// RequireScopeMiddleware limits tokens carrying scopes (see utils.Claims.Scopes) to the routes of
+// resource: requests that only read need "<resource>:read" and others "<resource>:write". With an
+// empty resource, scoped tokens are refused altogether. Tokens restricted to some documents only
+// reach the routes of those documents (with an "id" parameter) among the "documents" routes. The scopes of a service account's token
+// are narrowed to those the account still has, and the token stops working once the account is
+// deleted. Tokens without scopes pass. prefix is the path the routes are mounted under ("/v1",
+// or "" for legacy paths). Must run after utils.AuthMiddleware.
+func RequireScopeMiddleware(database *db.Database, resource, prefix string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, scoped := c.Get("tokenScopes")
+		if !scoped {
+			c.Next()
+			return
+		}
+		scopes := value.([]string)
+
+		userID, exists := c.Get("userID")
+		if !exists {
+			utils.GinLocalizedError(c, http.StatusInternalServerError, i18n.MsgUserIDMissing)
+			return
+		}
+		profile, found := database.GetProfileByID(userID.(string))
+		if !found {
+			utils.GinLocalizedError(c, http.StatusUnauthorized, i18n.MsgTokenAccountMissing)
+			return
+		}
+		if profile.Service != nil {
+			scopes = utils.ScopesWithin(scopes, profile.Service.Scopes)
+		}
+
+		needed := ""
+		if resource != "" {
+			needed = resource + ":write"
+			if !isMutatingMethod(c.Request.Method) || readOnlyExempt(strings.TrimPrefix(c.Request.URL.Path, prefix)) {
+				needed = resource + ":read"
+			}
+		}
+		if needed == "" || !utils.HasScope(scopes, needed) {
+			utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgScopeDenied, strings.Join(scopes, " "), c.Request.Method, c.Request.URL.Path)
+			return
+		}
+		if documents, restricted := c.Get("tokenDocuments"); restricted && resource == "documents" {
+			if docID := c.Param("id"); docID == "" || !slices.Contains(documents.([]string), docID) {
+				utils.GinLocalizedError(c, http.StatusForbidden, i18n.MsgTokenDocumentsOnly, c.Request.Method, c.Request.URL.Path)
+				return
+			}
+		}
+		c.Next()
+	}
+}
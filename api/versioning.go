@@ -0,0 +1,109 @@
+package api
+
+import (
+	"docserver/config"
+	"docserver/i18n"
+	"docserver/utils"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CurrentAPIVersion is the newest API version served by this build.
+// Routes are mounted under "/" + CurrentAPIVersion (e.g. /v1/documents).
+const CurrentAPIVersion = "v1"
+
+// SupportedAPIVersions lists every API version this build can serve.
+var SupportedAPIVersions = []string{"v1"}
+
+const (
+	// acceptVersionHeader lets clients state which API version they expect.
+	acceptVersionHeader = "Accept-Version"
+	// apiVersionHeader reports the API version that served the response.
+	apiVersionHeader = "API-Version"
+	// serverVersionHeader reports the release version of the server.
+	serverVersionHeader = "X-DocServer-Version"
+	// minServerVersionHeader lets clients state the oldest server release they work with.
+	minServerVersionHeader = "X-DocServer-Min-Version"
+)
+
+// normalizeAPIVersion turns "1", "V1" or "v1" into the canonical "v1" form.
+func normalizeAPIVersion(version string) string {
+	version = strings.ToLower(strings.TrimSpace(version))
+	if version != "" && !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	return version
+}
+
+// isSupportedAPIVersion reports whether the (normalized) version is served by this build.
+func isSupportedAPIVersion(version string) bool {
+	for _, v := range SupportedAPIVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// APIVersionMiddleware performs version negotiation for routes served as the given version.
+// If the client sends an Accept-Version header that does not match, the request is
+// rejected with 406 Not Acceptable so the client never silently talks to the wrong API.
+// Every response carries an API-Version header naming the version that served it.
+func APIVersionMiddleware(version string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header(apiVersionHeader, version)
+
+		requested := normalizeAPIVersion(c.GetHeader(acceptVersionHeader))
+		if requested != "" && requested != version {
+			if isSupportedAPIVersion(requested) {
+				utils.GinLocalizedError(c, http.StatusNotAcceptable, i18n.MsgAPIVersionMismatch, requested, version, requested)
+			} else {
+				utils.GinLocalizedError(c, http.StatusNotAcceptable, i18n.MsgAPIVersionUnsupported, requested, strings.Join(SupportedAPIVersions, ", "))
+			}
+			return
+		}
+
+		c.Set("apiVersion", version)
+		c.Next()
+	}
+}
+
+// ServerVersionMiddleware adds an X-DocServer-Version header with the release version to every
+// response. If the client sends an X-DocServer-Min-Version header naming a newer release, the
+// request is rejected with 412 Precondition Failed, so clients learn the server needs upgrading
+// instead of failing on missing features. Development builds satisfy any minimum.
+func ServerVersionMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header(serverVersionHeader, utils.Version)
+
+		if minVersion := strings.TrimSpace(c.GetHeader(minServerVersionHeader)); minVersion != "" {
+			ok, err := utils.VersionAtLeast(minVersion)
+			if err != nil {
+				utils.GinLocalizedError(c, http.StatusBadRequest, i18n.MsgInvalidMinVersion, minVersion)
+				return
+			}
+			if !ok {
+				utils.GinLocalizedError(c, http.StatusPreconditionFailed, i18n.MsgServerVersionTooOld, utils.Version, minVersion)
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// DeprecationMiddleware marks responses from legacy unversioned paths as deprecated.
+// It sets the Deprecation header, a Sunset header when a sunset date is configured,
+// and a Link header pointing at the equivalent versioned path.
+func DeprecationMiddleware(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		if !cfg.LegacySunset.IsZero() {
+			c.Header("Sunset", cfg.LegacySunset.UTC().Format(http.TimeFormat))
+		}
+		c.Header("Link", fmt.Sprintf("</%s%s>; rel=\"successor-version\"", CurrentAPIVersion, c.Request.URL.Path))
+		c.Next()
+	}
+}
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"docserver/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- API Versioning Tests ---
+
+func TestAPIVersioning(t *testing.T) {
+	router, _, cfg, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	_, _, token := createTestUserAndLogin(t, router, "version@example.com", "password123", "Version", "User")
+
+	t.Run("Versioned path serves requests without deprecation headers", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/v1/profiles/me", nil, token)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, CurrentAPIVersion, rr.Header().Get("API-Version"))
+		assert.Empty(t, rr.Header().Get("Deprecation"))
+		assert.Empty(t, rr.Header().Get("Sunset"))
+	})
+
+	t.Run("Versioned auth routes work", func(t *testing.T) {
+		body := marshalJSONBody(t, map[string]string{"email": "version@example.com", "password": "password123"})
+		rr := performRequest(router, http.MethodPost, "/v1/auth/login", body, "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("Legacy path is deprecated and links to successor", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/profiles/me", nil, token)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "true", rr.Header().Get("Deprecation"))
+		assert.Equal(t, `</v1/profiles/me>; rel="successor-version"`, rr.Header().Get("Link"))
+		assert.Empty(t, rr.Header().Get("Sunset"), "no sunset header without a configured date")
+	})
+
+	t.Run("Legacy path advertises configured sunset date", func(t *testing.T) {
+		cfg.LegacySunset = time.Date(2030, 1, 31, 0, 0, 0, 0, time.UTC)
+		defer func() { cfg.LegacySunset = time.Time{} }()
+
+		rr := performRequest(router, http.MethodGet, "/profiles/me", nil, token)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "Thu, 31 Jan 2030 00:00:00 GMT", rr.Header().Get("Sunset"))
+	})
+
+	t.Run("Accept-Version negotiation", func(t *testing.T) {
+		cases := []struct {
+			name           string
+			acceptVersion  string
+			expectedStatus int
+		}{
+			{"No header", "", http.StatusOK},
+			{"Matching version", "v1", http.StatusOK},
+			{"Matching bare number", "1", http.StatusOK},
+			{"Unsupported version", "v2", http.StatusNotAcceptable},
+		}
+
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				req, err := http.NewRequest(http.MethodGet, "/v1/profiles/me", nil)
+				require.NoError(t, err)
+				req.Header.Set("Authorization", "Bearer "+token)
+				if tc.acceptVersion != "" {
+					req.Header.Set("Accept-Version", tc.acceptVersion)
+				}
+				rr := httptest.NewRecorder()
+				router.ServeHTTP(rr, req)
+				assert.Equal(t, tc.expectedStatus, rr.Code)
+			})
+		}
+	})
+
+	t.Run("Minimum server version", func(t *testing.T) {
+		original := utils.Version
+		t.Cleanup(func() { utils.Version = original })
+		utils.Version = "v1.4.0"
+
+		cases := []struct {
+			name           string
+			minVersion     string
+			expectedStatus int
+		}{
+			{"No header", "", http.StatusOK},
+			{"Older minimum", "v1.3", http.StatusOK},
+			{"Same version", "1.4.0", http.StatusOK},
+			{"Newer minimum", "v1.5.0", http.StatusPreconditionFailed},
+			{"Invalid minimum", "latest", http.StatusBadRequest},
+		}
+
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				for _, path := range []string{"/v1/profiles/me", "/profiles/me"} {
+					req, err := http.NewRequest(http.MethodGet, path, nil)
+					require.NoError(t, err)
+					req.Header.Set("Authorization", "Bearer "+token)
+					if tc.minVersion != "" {
+						req.Header.Set("X-DocServer-Min-Version", tc.minVersion)
+					}
+					rr := httptest.NewRecorder()
+					router.ServeHTTP(rr, req)
+					assert.Equal(t, tc.expectedStatus, rr.Code, path)
+					assert.Equal(t, "v1.4.0", rr.Header().Get("X-DocServer-Version"), path)
+				}
+			})
+		}
+	})
+}
+
+func TestVersionedResponseEnvelope(t *testing.T) {
+	router, _, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	_, _, token := createTestUserAndLogin(t, router, "envelope@example.com", "password123", "Envelope", "User")
+	for i := 0; i < 3; i++ {
+		body := marshalJSONBody(t, map[string]interface{}{"content": map[string]int{"n": i}})
+		rr := performRequest(router, http.MethodPost, "/v1/documents", body, token)
+		require.Equal(t, http.StatusCreated, rr.Code)
+
+		var created map[string]json.RawMessage
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+		assert.Contains(t, created, "data", "single resources should be wrapped in data")
+	}
+
+	t.Run("List includes meta and links", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/v1/documents?limit=2", nil, token)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var env struct {
+			Data []map[string]interface{} `json:"data"`
+			Meta struct {
+				Total      int `json:"total"`
+				Page       int `json:"page"`
+				Limit      int `json:"limit"`
+				TotalPages int `json:"total_pages"`
+			} `json:"meta"`
+			Links struct {
+				Next string `json:"next"`
+				Prev string `json:"prev"`
+			} `json:"links"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &env))
+		assert.Len(t, env.Data, 2)
+		assert.Equal(t, 3, env.Meta.Total)
+		assert.Equal(t, 2, env.Meta.TotalPages)
+		assert.Equal(t, "/v1/documents?limit=2&page=2", env.Links.Next)
+		assert.Empty(t, env.Links.Prev)
+	})
+
+	t.Run("Errors use typed error object", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/v1/documents/doesnotexist", nil, token)
+		require.Equal(t, http.StatusNotFound, rr.Code)
+
+		var env struct {
+			Error struct {
+				Status  int    `json:"status"`
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &env))
+		assert.Equal(t, http.StatusNotFound, env.Error.Status)
+		assert.Equal(t, "not_found", env.Error.Code)
+		assert.NotEmpty(t, env.Error.Message)
+	})
+
+	t.Run("Legacy paths keep original shapes", func(t *testing.T) {
+		rr := performRequest(router, http.MethodGet, "/documents?limit=2", nil, token)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var legacy GetDocumentsResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &legacy))
+		assert.Equal(t, 3, legacy.Total)
+
+		rr = performRequest(router, http.MethodGet, "/documents/doesnotexist", nil, token)
+		assert.JSONEq(t, `{"error": "Document with ID 'doesnotexist' not found.", "message_id": "document_not_found"}`, rr.Body.String())
+	})
+}
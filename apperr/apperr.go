@@ -0,0 +1,92 @@
+// Package apperr defines the kinds of application errors shared by the db and api
+// packages. The db layer marks its errors with a kind (not found, conflict, ...) and
+// the api layer picks the HTTP status with errors.Is or HTTPStatus, instead of
+// matching on error text.
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Error kinds. Test for them with errors.Is.
+var (
+	ErrNotFound     = errors.New("not found")           // The requested resource does not exist
+	ErrConflict     = errors.New("conflict")            // The change clashes with existing data, e.g. a taken email or ID
+	ErrForbidden    = errors.New("forbidden")           // The caller may not perform the operation
+	ErrValidation   = errors.New("validation failed")   // The input is malformed or out of range
+	ErrLocked       = errors.New("locked")              // The resource is locked against changes, e.g. a frozen document
+	ErrPrecondition = errors.New("precondition failed") // A condition the caller set for the change does not hold
+)
+
+// kindError marks an error with a kind while keeping its message and wrapped causes.
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string { return e.err.Error() }
+
+// Unwrap exposes both the original error and the kind, so errors.Is matches the kind
+// and errors.As still finds wrapped values such as *i18n.Error.
+func (e *kindError) Unwrap() []error { return []error{e.err, e.kind} }
+
+// Wrap marks err with kind. It returns nil if err is nil.
+func Wrap(kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &kindError{kind: kind, err: err}
+}
+
+// NotFound formats an error (like fmt.Errorf) of kind ErrNotFound.
+func NotFound(format string, args ...any) error {
+	return Wrap(ErrNotFound, fmt.Errorf(format, args...))
+}
+
+// Conflict formats an error (like fmt.Errorf) of kind ErrConflict.
+func Conflict(format string, args ...any) error {
+	return Wrap(ErrConflict, fmt.Errorf(format, args...))
+}
+
+// Forbidden formats an error (like fmt.Errorf) of kind ErrForbidden.
+func Forbidden(format string, args ...any) error {
+	return Wrap(ErrForbidden, fmt.Errorf(format, args...))
+}
+
+// Validation formats an error (like fmt.Errorf) of kind ErrValidation.
+func Validation(format string, args ...any) error {
+	return Wrap(ErrValidation, fmt.Errorf(format, args...))
+}
+
+// Locked formats an error (like fmt.Errorf) of kind ErrLocked.
+func Locked(format string, args ...any) error {
+	return Wrap(ErrLocked, fmt.Errorf(format, args...))
+}
+
+// PreconditionFailed formats an error (like fmt.Errorf) of kind ErrPrecondition.
+func PreconditionFailed(format string, args ...any) error {
+	return Wrap(ErrPrecondition, fmt.Errorf(format, args...))
+}
+
+// HTTPStatus returns the HTTP status code for err's kind, or 500 Internal Server Error
+// if err has none.
+func HTTPStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrLocked):
+		return http.StatusLocked
+	case errors.Is(err, ErrPrecondition):
+		return http.StatusPreconditionFailed
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,44 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"docserver/i18n"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKinds(t *testing.T) {
+	err := NotFound("document with ID '%s' not found", "doc1")
+	assert.Equal(t, "document with ID 'doc1' not found", err.Error(), "the message is unchanged")
+	assert.True(t, errors.Is(err, ErrNotFound))
+	assert.False(t, errors.Is(err, ErrConflict))
+
+	wrapped := fmt.Errorf("loading: %w", Validation("bad interval: %w", io.ErrUnexpectedEOF))
+	assert.True(t, errors.Is(wrapped, ErrValidation), "kinds survive further wrapping")
+	assert.True(t, errors.Is(wrapped, io.ErrUnexpectedEOF), "causes stay reachable")
+
+	localized := Wrap(ErrConflict, i18n.NewError(i18n.MsgEmailAlreadyExists, "a@example.com"))
+	var msgErr *i18n.Error
+	require.True(t, errors.As(localized, &msgErr))
+	assert.Equal(t, i18n.MsgEmailAlreadyExists, msgErr.ID)
+	assert.True(t, errors.Is(localized, ErrConflict))
+
+	assert.Nil(t, Wrap(ErrForbidden, nil))
+}
+
+func TestHTTPStatus(t *testing.T) {
+	assert.Equal(t, http.StatusNotFound, HTTPStatus(NotFound("x")))
+	assert.Equal(t, http.StatusConflict, HTTPStatus(Conflict("x")))
+	assert.Equal(t, http.StatusForbidden, HTTPStatus(Forbidden("x")))
+	assert.Equal(t, http.StatusBadRequest, HTTPStatus(Validation("x")))
+	assert.Equal(t, http.StatusLocked, HTTPStatus(Locked("x")))
+	assert.Equal(t, http.StatusPreconditionFailed, HTTPStatus(PreconditionFailed("x")))
+	assert.Equal(t, http.StatusInternalServerError, HTTPStatus(errors.New("disk full")))
+	assert.Equal(t, http.StatusInternalServerError, HTTPStatus(nil))
+}
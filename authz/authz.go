@@ -0,0 +1,112 @@
+// Package authz decides who may do what with a document. Handlers describe the caller as a
+// Subject and the document as a Resource and ask Can, instead of each comparing owner IDs and
+// share lists on its own, so roles or new permissions change the rules here and nowhere else.
+//
+// Can only answers whether an action is allowed. Whether it can happen right now is up to the db
+// package: a frozen document, for one, is refused there with an apperr.ErrLocked error.
+package authz
+
+import (
+	"docserver/models"
+	"slices"
+)
+
+// Action is something a subject may want to do with a document.
+type Action string
+
+// Actions on documents
+const (
+	ReadDocument         Action = "document:read"          // Read it: owner, sharers and assigned reviewers, anyone if it is public
+	ViewActivity         Action = "document:activity"      // See its activity feed: owner and sharers; being public is not enough
+	UpdateDocument       Action = "document:update"        // Replace all of its content, make it public or change its content type: owner
+	UpdateScopedContent  Action = "document:update-scoped" // Replace the part of its content shared with write access: sharers with such a scope
+	DeleteDocument       Action = "document:delete"        // Delete it: owner
+	ShareDocument        Action = "document:share"         // See and change who it is shared with: owner
+	SetDocumentExpiry    Action = "document:expiry"        // Set or clear when it expires: owner and administrators
+	FreezeDocument       Action = "document:freeze"        // Freeze it: owner and administrators
+	UnfreezeDocument     Action = "document:unfreeze"      // Unfreeze it: as FreezeDocument, but only administrators undo a freeze by an administrator
+	ManageDocumentReview Action = "document:reviews"       // Assign reviewers and see every review: owner and administrators
+)
+
+// Subject is the user asking.
+type Subject struct {
+	ID    string
+	Admin bool // Listed in -admin-emails
+	// Documents restricts the subject to these documents, e.g. when it acts through a token
+	// minted for a script (see POST /auth/tokens); nil allows any.
+	Documents []string
+}
+
+// Facts looks up what decisions depend on besides the document itself, e.g. the database or a
+// read view of it.
+type Facts interface {
+	GetShareRecordByDocumentID(docID string) (models.ShareRecord, bool)
+	IsProfileDeactivated(profileID string) bool
+	IsAssignedReviewer(docID, profileID string) bool
+	IsAdmin(profileID string) bool
+}
+
+// Resource is a document, with the facts to decide on it. Facts are only looked up when an
+// action depends on them.
+type Resource struct {
+	Document models.Document
+	facts    Facts
+}
+
+// Document returns doc as a resource whose facts come from facts.
+func Document(doc models.Document, facts Facts) Resource {
+	return Resource{Document: doc, facts: facts}
+}
+
+// Can reports whether user may perform action on resource. Unknown actions, and every action on
+// documents outside the subject's Documents, are denied.
+func Can(user Subject, action Action, resource Resource) bool {
+	doc := resource.Document
+	if user.Documents != nil && !slices.Contains(user.Documents, doc.ID) {
+		return false
+	}
+	owner := user.ID != "" && doc.OwnerID == user.ID
+
+	switch action {
+	case ReadDocument:
+		if owner {
+			return true
+		}
+		if resource.facts.IsProfileDeactivated(doc.OwnerID) {
+			return false // Documents of deactivated accounts are hidden from other users
+		}
+		return doc.Public || resource.isSharer(user.ID) || resource.facts.IsAssignedReviewer(doc.ID, user.ID)
+	case ViewActivity:
+		return owner || (!resource.facts.IsProfileDeactivated(doc.OwnerID) && resource.isSharer(user.ID))
+	case UpdateDocument, DeleteDocument, ShareDocument:
+		return owner
+	case UpdateScopedContent:
+		if owner || resource.facts.IsProfileDeactivated(doc.OwnerID) || !resource.isSharer(user.ID) {
+			return false
+		}
+		record, _ := resource.facts.GetShareRecordByDocumentID(doc.ID)
+		return record.Scopes[user.ID].Write
+	case SetDocumentExpiry, FreezeDocument, ManageDocumentReview:
+		return owner || user.Admin
+	case UnfreezeDocument:
+		if user.Admin {
+			return true
+		}
+		if !owner {
+			return false
+		}
+		// A freeze by an administrator binds the owner
+		return !doc.Frozen || doc.FrozenBy == user.ID || !resource.facts.IsAdmin(doc.FrozenBy)
+	default:
+		return false
+	}
+}
+
+// isSharer reports whether the document is shared with profileID.
+func (r Resource) isSharer(profileID string) bool {
+	if profileID == "" {
+		return false
+	}
+	record, found := r.facts.GetShareRecordByDocumentID(r.Document.ID)
+	return found && slices.Contains(record.SharedWith, profileID)
+}
@@ -0,0 +1,100 @@
+package authz
+
+import (
+	"testing"
+
+	"docserver/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeFacts answers from fixed data.
+type fakeFacts struct {
+	shares      models.ShareRecord
+	deactivated map[string]bool
+	reviewers   map[string]bool
+	admins      map[string]bool
+}
+
+func (f fakeFacts) GetShareRecordByDocumentID(docID string) (models.ShareRecord, bool) {
+	return f.shares, len(f.shares.SharedWith) > 0
+}
+func (f fakeFacts) IsProfileDeactivated(profileID string) bool      { return f.deactivated[profileID] }
+func (f fakeFacts) IsAssignedReviewer(docID, profileID string) bool { return f.reviewers[profileID] }
+func (f fakeFacts) IsAdmin(profileID string) bool                   { return f.admins[profileID] }
+
+func TestCan(t *testing.T) {
+	facts := fakeFacts{
+		shares: models.ShareRecord{
+			SharedWith: []string{"reader", "writer"},
+			Scopes:     map[string]models.ShareScope{"writer": {Path: "answers", Write: true}},
+		},
+		deactivated: map[string]bool{},
+		reviewers:   map[string]bool{"reviewer": true},
+		admins:      map[string]bool{"admin": true},
+	}
+	doc := models.Document{ID: "doc", OwnerID: "owner"}
+	owner, reader, writer := Subject{ID: "owner"}, Subject{ID: "reader"}, Subject{ID: "writer"}
+	reviewer, admin, stranger := Subject{ID: "reviewer"}, Subject{ID: "admin", Admin: true}, Subject{ID: "stranger"}
+
+	tests := []struct {
+		action  Action
+		allowed []Subject
+		denied  []Subject
+	}{
+		{ReadDocument, []Subject{owner, reader, writer, reviewer}, []Subject{admin, stranger, {}}},
+		{ViewActivity, []Subject{owner, reader, writer}, []Subject{reviewer, admin, stranger}},
+		{UpdateDocument, []Subject{owner}, []Subject{reader, writer, admin}},
+		{UpdateScopedContent, []Subject{writer}, []Subject{owner, reader, stranger}},
+		{DeleteDocument, []Subject{owner}, []Subject{reader, admin}},
+		{ShareDocument, []Subject{owner}, []Subject{reader, admin}},
+		{SetDocumentExpiry, []Subject{owner, admin}, []Subject{reader, stranger}},
+		{FreezeDocument, []Subject{owner, admin}, []Subject{writer, stranger}},
+		{UnfreezeDocument, []Subject{owner, admin}, []Subject{writer, stranger}},
+		{ManageDocumentReview, []Subject{owner, admin}, []Subject{reviewer, stranger}},
+		{Action("document:unknown"), nil, []Subject{owner, admin}},
+	}
+	for _, tt := range tests {
+		for _, user := range tt.allowed {
+			assert.True(t, Can(user, tt.action, Document(doc, facts)), "%s should be allowed to %s", user.ID, tt.action)
+		}
+		for _, user := range tt.denied {
+			assert.False(t, Can(user, tt.action, Document(doc, facts)), "%s should not be allowed to %s", user.ID, tt.action)
+		}
+	}
+
+	t.Run("Public documents can be read by anyone", func(t *testing.T) {
+		public := doc
+		public.Public = true
+		assert.True(t, Can(stranger, ReadDocument, Document(public, facts)))
+		assert.False(t, Can(stranger, ViewActivity, Document(public, facts)))
+	})
+
+	t.Run("Deactivated owners hide their documents", func(t *testing.T) {
+		facts := facts
+		facts.deactivated = map[string]bool{"owner": true}
+		assert.True(t, Can(owner, ReadDocument, Document(doc, facts)))
+		assert.False(t, Can(reader, ReadDocument, Document(doc, facts)))
+		assert.False(t, Can(writer, UpdateScopedContent, Document(doc, facts)))
+	})
+
+	t.Run("Subjects restricted to some documents", func(t *testing.T) {
+		restricted := owner
+		restricted.Documents = []string{"other"}
+		assert.False(t, Can(restricted, ReadDocument, Document(doc, facts)))
+		restricted.Documents = []string{"other", "doc"}
+		assert.True(t, Can(restricted, UpdateDocument, Document(doc, facts)))
+		public := doc
+		public.ID, public.Public = "public", true
+		assert.False(t, Can(restricted, ReadDocument, Document(public, facts)), "not even public documents")
+	})
+
+	t.Run("A freeze by an administrator binds the owner", func(t *testing.T) {
+		frozen := doc
+		frozen.Frozen, frozen.FrozenBy = true, "admin"
+		assert.False(t, Can(owner, UnfreezeDocument, Document(frozen, facts)))
+		assert.True(t, Can(admin, UnfreezeDocument, Document(frozen, facts)))
+		frozen.FrozenBy = "owner"
+		assert.True(t, Can(owner, UnfreezeDocument, Document(frozen, facts)))
+	})
+}
@@ -0,0 +1,127 @@
+package bench
+
+import (
+	"docserver/config"
+	"docserver/db"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// DefaultSizes are the dataset sizes, in documents, Run measures unless told otherwise.
+var DefaultSizes = []int{10_000, 100_000}
+
+// Case is one measured operation.
+type Case struct {
+	Name string
+	Run  func(b *testing.B, d *Dataset)
+}
+
+// contentQuery is what an instructor searching the class's work asks: the good scores of one course.
+var contentQuery = []string{`course equals "CS101"`, "and", "score greaterThanOrEquals 90"}
+
+// Cases lists the measured operations, in the order Run reports them.
+var Cases = []Case{
+	{"QueryDocuments/owned", func(b *testing.B, d *Dataset) {
+		// A student's first page of their own documents
+		params := db.QueryDocumentsParams{AuthUserID: d.Students[0], Scope: "owned"}
+		for i := 0; i < b.N; i++ {
+			if _, _, err := d.Database.QueryDocuments(params); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}},
+	{"QueryDocuments/content", func(b *testing.B, d *Dataset) {
+		// An instructor searching every document
+		params := db.QueryDocumentsParams{AuthUserID: d.Instructor, IsAdmin: true, Scope: "any", ContentQuery: contentQuery}
+		for i := 0; i < b.N; i++ {
+			if _, _, err := d.Database.QueryDocuments(params); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}},
+	{"EvaluateContentQuery", func(b *testing.B, d *Dataset) {
+		query, err := db.ParseContentQuery(contentQuery)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := d.Database.EvaluateContentQuery(d.Documents[i%len(d.Documents)], query); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}},
+	{"Persist", func(b *testing.B, d *Dataset) {
+		for i := 0; i < b.N; i++ {
+			if err := d.Database.PersistNow(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}},
+	{"Load", func(b *testing.B, d *Dataset) {
+		if err := d.Database.PersistNow(); err != nil {
+			b.Fatal(err)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if err := d.Database.Load(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}},
+}
+
+// Run generates a dataset of each size in a temporary directory, measures every case on it and
+// writes the results to out in the Go benchmark format, one line per case and size, e.g.
+//
+//	BenchmarkQueryDocuments/content/docs=10000  	     120	   9876543 ns/op	 123456 B/op	  789 allocs/op
+//
+// so reports of two versions can be compared with benchstat. Settings of cfg that shape saving
+// apply (see NewDataset). The server log is silenced while it runs.
+func Run(cfg config.Config, sizes []int, out io.Writer) error {
+	if len(sizes) == 0 {
+		sizes = DefaultSizes
+	}
+	logOutput := log.Writer()
+	log.SetOutput(io.Discard) // Every generated document would be logged
+	defer log.SetOutput(logOutput)
+
+	fmt.Fprintf(out, "goos: %s\ngoarch: %s\npkg: docserver/bench\ncpus: %d\n", runtime.GOOS, runtime.GOARCH, runtime.NumCPU())
+	for _, size := range sizes {
+		dir, err := os.MkdirTemp("", "docserver-bench-")
+		if err != nil {
+			return err
+		}
+		started := time.Now()
+		dataset, err := NewDataset(cfg, dir, size, 1)
+		if err != nil {
+			_ = os.RemoveAll(dir)
+			return fmt.Errorf("failed to generate a dataset of %d documents: %w", size, err)
+		}
+		fmt.Fprintf(out, "# %d documents of %d students generated in %s\n", size, len(dataset.Students), time.Since(started).Round(time.Millisecond))
+
+		for _, c := range Cases {
+			result := testing.Benchmark(func(b *testing.B) {
+				b.ReportAllocs()
+				c.Run(b, dataset)
+			})
+			if result.N == 0 {
+				_ = os.RemoveAll(dir)
+				return fmt.Errorf("%s failed on %d documents", c.Name, size) // b.Fatal leaves no result
+			}
+			fmt.Fprintf(out, "Benchmark%s/docs=%d\t%s\t%s\n", c.Name, size, result.String(), result.MemString())
+		}
+		if err := dataset.Database.Close(); err != nil {
+			return err
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
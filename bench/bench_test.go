@@ -0,0 +1,70 @@
+package bench
+
+import (
+	"bytes"
+	"docserver/config"
+	"docserver/db"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sizes are the dataset sizes the benchmarks run on, e.g. go test -bench . ./bench -sizes=10000,500000
+var sizes = flag.String("sizes", "10000", "Comma-separated dataset sizes, in documents, to benchmark")
+
+// BenchmarkCases runs every case on a dataset of each size.
+func BenchmarkCases(b *testing.B) {
+	logOutput := log.Writer()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(logOutput)
+
+	for _, field := range strings.Split(*sizes, ",") {
+		size, err := strconv.Atoi(strings.TrimSpace(field))
+		require.NoError(b, err)
+		dataset, err := NewDataset(config.Config{}, b.TempDir(), size, 1)
+		require.NoError(b, err)
+		for _, c := range Cases {
+			b.Run(fmt.Sprintf("%s/docs=%d", c.Name, size), func(b *testing.B) {
+				b.ReportAllocs()
+				c.Run(b, dataset)
+			})
+		}
+		require.NoError(b, dataset.Database.Close())
+	}
+}
+
+func TestNewDataset(t *testing.T) {
+	dataset, err := NewDataset(config.Config{}, t.TempDir(), 45, 7)
+	require.NoError(t, err)
+	assert.Len(t, dataset.Documents, 45)
+	assert.Len(t, dataset.Students, 3)
+
+	again, err := NewDataset(config.Config{}, t.TempDir(), 45, 7)
+	require.NoError(t, err)
+	assert.Equal(t, dataset.Documents[44].Content, again.Documents[44].Content, "the same seed gives the same content")
+
+	docs, total, err := dataset.Database.QueryDocuments(db.QueryDocumentsParams{AuthUserID: dataset.Instructor, Scope: "shared"})
+	require.NoError(t, err)
+	assert.Equal(t, 5, total, "every tenth document is shared with the instructor")
+	assert.Len(t, docs, 5)
+}
+
+func TestRun(t *testing.T) {
+	require.NoError(t, flag.Set("test.benchtime", "1x"))
+	t.Cleanup(func() { _ = flag.Set("test.benchtime", "1s") })
+
+	var out bytes.Buffer
+	require.NoError(t, Run(config.Config{Checksum: true}, []int{30}, &out))
+	report := out.String()
+	assert.Contains(t, report, "pkg: docserver/bench\n")
+	for _, c := range Cases {
+		assert.Regexp(t, `(?m)^Benchmark`+c.Name+`/docs=30\t\s*1\t.* ns/op\t.* B/op\t.* allocs/op$`, report)
+	}
+}
@@ -0,0 +1,165 @@
+// Package bench measures the database operations a large class leans on hardest: document lists
+// and content searches, saving and loading the database file, and evaluating content queries.
+//
+// The measurements run on generated datasets shaped like a course's (see NewDataset), so their
+// results can be compared between versions of the server, e.g. before and after a change to
+// indexing or persistence. Run them with `go test -bench . ./bench` or `docserver bench`
+// (see Run), which print results in the Go benchmark format that benchstat compares.
+package bench
+
+import (
+	"docserver/config"
+	"docserver/db"
+	"docserver/models"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"time"
+)
+
+// Dataset shape: a class of students, each writing documentsPerStudent documents (unless told
+// how many students there are) for one of the courses, with every sharedEvery-th document shared
+// with the instructor.
+const (
+	documentsPerStudent = 20
+	sharedEvery         = 10
+)
+
+var (
+	courses   = []string{"CS101", "CS102", "MATH201", "PHYS110", "HIST150"}
+	statuses  = []string{"draft", "submitted", "graded"}
+	tags      = []string{"essay", "lab", "quiz", "project", "late", "revised", "group"}
+	firstName = []string{"Ann", "Bo", "Cy", "Dee", "Eli", "Fay", "Gus", "Hal", "Ivy", "Jo"}
+)
+
+// Dataset is a database filled with generated course documents, stored in its own directory.
+type Dataset struct {
+	Config     *config.Config
+	Database   *db.Database
+	Documents  []models.Document // In the order they were created
+	Students   []string          // Profile IDs of the students, who own the documents
+	Instructor string            // Profile ID of the administrator the documents are shared with
+}
+
+// NewDataset generates a dataset of the given number of documents in dir, the same for the same
+// seed. Settings of base that shape saving, such as -checksum or -data-dir, are kept, so the
+// measurements reflect them; the database file itself, the save interval and the query cache are
+// the dataset's own. Nothing is saved until PersistNow is called.
+func NewDataset(base config.Config, dir string, documents int, seed int64) (*Dataset, error) {
+	cfg := base
+	cfg.DbFilePath = filepath.Join(dir, "bench_db.json")
+	if cfg.DataDir != "" {
+		cfg.DataDir = filepath.Join(dir, "data")
+	}
+	cfg.SaveInterval = 24 * time.Hour // Saves are measured, not triggered by the generation
+	cfg.Ephemeral = false
+	cfg.ReadOnly = false
+	cfg.QueryCacheSize = 0 // Every query is run
+	cfg.TransformsFile = ""
+
+	database, err := db.NewDatabase(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	instructor, err := database.CreateProfile(models.Profile{FirstName: "Pat", LastName: "Instructor", Email: "instructor@bench.example"})
+	if err != nil {
+		return nil, err
+	}
+	generated, err := Generate(database, GenerateOptions{Documents: documents, Seed: seed, Instructor: instructor.ID})
+	if err != nil {
+		return nil, err
+	}
+	return &Dataset{Config: &cfg, Database: database, Documents: generated.Documents, Students: generated.Students, Instructor: instructor.ID}, nil
+}
+
+// GenerateOptions says what Generate creates.
+type GenerateOptions struct {
+	Documents  int    // Documents to create
+	Students   int    // Profiles owning them, taking turns (0 = one per 20 documents)
+	Seed       int64  // The same seed gives the same content
+	Instructor string // Profile every tenth document is shared with ("" = none is shared)
+	Batch      string // Part of the students' emails, so generating again does not reuse them (e.g. "lab3")
+}
+
+// Generated lists what Generate created.
+type Generated struct {
+	Students  []string          // Profile IDs
+	Documents []models.Document // In the order they were created
+	Shared    int               // Documents shared with the instructor
+}
+
+// Generate creates students (profiles without a password) and course documents they own
+// directly in database, without going through the API. Documents are created in batches (see
+// db.CreateDocuments), so a large dataset takes seconds rather than minutes.
+func Generate(database *db.Database, opts GenerateOptions) (Generated, error) {
+	students := opts.Students
+	if students <= 0 {
+		students = max(1, (opts.Documents+documentsPerStudent-1)/documentsPerStudent)
+	}
+	batch := opts.Batch
+	if batch != "" {
+		batch = "." + batch
+	}
+
+	var generated Generated
+	for i := 0; i < students; i++ {
+		student, err := database.CreateProfile(models.Profile{
+			FirstName: firstName[i%len(firstName)],
+			LastName:  fmt.Sprintf("Student%d", i),
+			Email:     fmt.Sprintf("student%d%s@bench.example", i, batch),
+		})
+		if err != nil {
+			return generated, err
+		}
+		generated.Students = append(generated.Students, student.ID)
+	}
+
+	random := rand.New(rand.NewSource(opts.Seed))
+	docs := make([]models.Document, opts.Documents)
+	for i := range docs {
+		docs[i] = models.Document{OwnerID: generated.Students[i%students], Content: documentContent(random, i)}
+	}
+	var err error
+	if generated.Documents, err = database.CreateDocuments(docs); err != nil {
+		return generated, err
+	}
+	if opts.Instructor == "" {
+		return generated, nil
+	}
+	for i := 0; i < len(generated.Documents); i += sharedEvery {
+		if err := database.SetShareRecord(generated.Documents[i].ID, []string{opts.Instructor}); err != nil {
+			return generated, err
+		}
+		generated.Shared++
+	}
+	return generated, nil
+}
+
+// documentContent returns the content of the i-th generated document: an assignment with its
+// course, status, score, tags, author and a few paragraphs of text.
+func documentContent(random *rand.Rand, i int) map[string]any {
+	docTags := []any{}
+	for _, tag := range tags {
+		if random.Intn(4) == 0 {
+			docTags = append(docTags, tag)
+		}
+	}
+	paragraphs := make([]any, 1+random.Intn(4))
+	for p := range paragraphs {
+		paragraphs[p] = fmt.Sprintf("Paragraph %d of assignment %d: %s", p+1, i, loremIpsum[:40+random.Intn(len(loremIpsum)-40)])
+	}
+	submitted := time.Date(2025, time.September, 1, 9, 0, 0, 0, time.UTC).Add(time.Duration(random.Intn(120*24)) * time.Hour)
+	return map[string]any{
+		"course":     courses[random.Intn(len(courses))],
+		"assignment": float64(1 + i%documentsPerStudent),
+		"title":      fmt.Sprintf("Assignment %d", 1+i%documentsPerStudent),
+		"status":     statuses[random.Intn(len(statuses))],
+		"score":      float64(random.Intn(101)),
+		"tags":       docTags,
+		"author":     map[string]any{"name": firstName[random.Intn(len(firstName))], "year": float64(1 + random.Intn(4))},
+		"submitted":  submitted.Format(time.RFC3339),
+		"body":       paragraphs,
+	}
+}
+
+const loremIpsum = "Lorem ipsum dolor sit amet, consectetur adipiscing elit, sed do eiusmod tempor incididunt ut labore et dolore magna aliqua. Ut enim ad minim veniam, quis nostrud exercitation ullamco laboris nisi ut aliquip ex ea commodo consequat."
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFixedClock(t *testing.T) {
+	frozen := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFixedClock(frozen)
+	assert.Equal(t, frozen, clock.Now())
+	assert.Equal(t, frozen, clock.Now(), "the clock does not move by itself")
+
+	clock.Advance(time.Hour)
+	assert.Equal(t, frozen.Add(time.Hour), clock.Now())
+	clock.Set(frozen)
+	assert.Equal(t, frozen, clock.Now())
+
+	cfg := &Config{Clock: clock}
+	assert.Equal(t, frozen, cfg.Now())
+}
+
+func TestConfig_NowWithoutClock(t *testing.T) {
+	var nilConfig *Config
+	assert.WithinDuration(t, time.Now(), nilConfig.Now(), time.Second)
+	assert.WithinDuration(t, time.Now(), (&Config{}).Now(), time.Second)
+	assert.WithinDuration(t, time.Now(), (&Config{Clock: SystemClock{}}).Now(), time.Second)
+}
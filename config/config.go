@@ -9,6 +9,10 @@ import (
 	"crypto/rand" // Needed for JWT generation
 	"encoding/hex"  // Needed for JWT generation
 	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
 	"strings"
 )
 
@@ -17,30 +21,221 @@ type Config struct {
 	// Server settings
 	ListenAddress string
 	ListenPort    string
+	GinMode         string   // One of the GinMode* constants
+	TrustedProxies  []string // IPs and CIDR ranges of proxies whose client IP headers are believed (empty = none)
+	ClientIPHeaders []string // Headers a trusted proxy reports the client IP in, checked in order (empty = always use the connecting IP)
 
 	// Database settings
 	DbFilePath    string
+	DataDir       string // Directory the database is stored in, one file per group of collections (empty = the single -db-file)
+	CoursesDir    string // Directory of the course databases managed under /admin/courses (empty = no courses)
 	SaveInterval  time.Duration
+	CoalesceWindow time.Duration // Updates of a document this soon after its last update are folded into that version (0 = off)
 	EnableBackup  bool
+	DedupeContent bool // Store identical document content once in the database file
+	Fsync         bool // Sync the database file and its directory to disk on every save
+	Checksum      bool // End the database file with a checksum of its content, checked on load
+	AutoRecover   bool // Load the backup, or whatever can be salvaged, when the database file cannot be parsed
+	MigrateDryRun bool // Report pending schema migrations and exit without starting the server
+	ReadOnly      bool // Refuse every change: mutating requests get 403 and the database is never saved
+	Ephemeral     bool // Keep the database in memory only: nothing is loaded from or saved to disk
+	RecordFile    string    // Journal every mutating request is appended to (empty = not recorded)
+	ReplayFile    string    // Journal to replay into a new database file, exiting afterwards (empty = serve as usual)
+	ReplayUntil   time.Time // Replay only requests made up to this instant (zero = all)
+	Bench         bool      // Measure the database on generated datasets, print a report and exit (see package bench)
+	BenchSizes    []int     // Dataset sizes, in documents, Bench measures
+	TransformsFile string // JSON file of content transformation rules run on document create/update (empty = none)
+	ScriptTimeout  time.Duration // Time limit for each document script run
+	ExpiryGracePeriod time.Duration // How long an expired document is kept before it is purged
+	IDStrategy     string        // How new IDs are generated: one of the IDStrategy* constants
+
+	// API settings
+	MaxBodyBytes int64 // Largest JSON request body accepted (0 = no limit)
+	StrictJSON   bool  // Reject JSON request bodies with fields the endpoint does not know
+	EnablePublicAccess bool // Serve documents marked public to unauthenticated guests under /public
+	LegacySunset time.Time // Date after which unversioned API paths may be removed (zero = not announced)
+	FetchAllowedDomains []string      // Domains POST /documents/fetch may download from (empty = endpoint disabled)
+	FetchMaxBytes       int64         // Largest response POST /documents/fetch accepts
+	ArchiveMaxBytes     int64         // Most document data GET /documents/archive puts in one zip
+	FetchTimeout        time.Duration // Time limit for each fetch
+	WebhookAllowedDomains []string    // Domains scheduled exports and notification channels may be delivered to (empty = webhook targets disabled)
+	NotificationMaxPerHour int        // Most messages a notification channel may be sent per hour (its default and ceiling)
+
+	// Compliance settings
+	TosVersion         string        // Current terms of service version users must accept (empty = no terms)
+	TosURL             string        // Where the current terms of service can be read (optional)
+	RequireTos         bool          // Block API use until the current terms have been accepted
+	ErasureGracePeriod time.Duration // Delay between an erasure request and the actual erasure, during which it can be cancelled
+	DeactivationGracePeriod time.Duration // How long a deactivated account is kept before it is purged
+
+	// Administration settings
+	AdminEmails []string // Emails of profiles allowed to use the /admin endpoints (lowercased)
+	InviteOnly  bool     // Signup requires an invitation code created by an administrator
 
 	// Authentication settings
-	JwtSecret     string // The actual secret key
-	JwtSecretFile string // Path to the file containing the secret
-	TokenLifetime time.Duration
-	BcryptCost    int
+	JwtSecret          string // The actual secret key
+	JwtSecretFile      string // Path to the file containing the secret
+	TokenLifetime      time.Duration
+	JwtLeeway          time.Duration // Clock skew tolerated when checking a token's exp, nbf and iat
+	JwtIssuer          string        // Issuer tokens are signed with and must carry
+	JwtAudience        string        // Audience tokens are signed with and must carry; empty = not checked
+	IntrospectClients  map[string]string // Client IDs and secrets of services allowed to call POST /auth/introspect (empty = endpoint disabled)
+	BcryptCost         int           // bcrypt cost of new password hashes
+	PasswordHash       string        // Algorithm of new password hashes: one of the PasswordHash* constants
+	Argon2Time         uint32        // argon2id passes over memory
+	Argon2MemoryKiB    uint32        // argon2id memory in KiB
+	Argon2Threads      uint8         // argon2id parallelism
+	PasswordHashTarget time.Duration // Tune BcryptCost or Argon2Time at startup so hashing takes about this long (0 = off)
+
+	// Password reset OTP settings
+	OTPLength      int           // Characters per OTP
+	OTPCharset     string        // Characters OTPs are drawn from
+	OTPLifetime    time.Duration // How long an OTP stays valid
+	OTPMaxAttempts int           // Wrong guesses allowed before an OTP is invalidated
+	OTPBackoff     time.Duration // Wait after a failed verification, doubled for each consecutive failure (0 = none)
+	OTPBackoffMax  time.Duration // Longest wait between verifications
+	OTPDelivery    string        // OTPDeliveryEmail or OTPDeliverySMS
+	SMSGatewayURL  string        // Where text messages are POSTed as JSON (empty = written to the server log)
+
+	// Bot protection for signup and password reset requests
+	ChallengeProvider      string // One of the ChallengeProvider* constants (empty = no challenge)
+	ChallengeSiteKey       string // Public site key handed to the hCaptcha/Turnstile widget
+	ChallengeSecret        string // Secret key for verifying hCaptcha/Turnstile tokens
+	ChallengePoWDifficulty int    // Leading zero bits a proof-of-work solution needs
+
+	StatusRateLimit int // Requests per minute each client may make to GET /status (0 = unlimited)
+
+	// Tracing
+	OTLPEndpoint    string // OpenTelemetry collector spans are exported to, e.g. http://localhost:4318 (empty = tracing off)
+	OTLPHeaders     string // Headers sent to the collector, "key1=value1,key2=value2"
+	OTelServiceName string // Service name spans are reported under
+
+	SlowQueryThreshold time.Duration // Content queries taking longer are logged and listed by GET /admin/slow-queries (0 = off)
+	QueryCacheSize     int           // Document query results kept for reuse until the data changes (0 = no cache)
+
+	// Load generation, for performance labs
+	LoadGeneration         bool // Let administrators generate data with POST /admin/generate-load
+	LoadGenerationMaxDocs  int  // Most documents one generation may create
+	LoadGenerationMaxUsers int  // Most users one generation may create
+
+	// Deterministic fixtures mode, for reproducible test runs
+	Fixtures     bool      // Freeze the clock at FixturesTime and generate IDs from FixturesSeed
+	FixturesTime time.Time // Instant the clock is frozen at in fixtures mode
+	FixturesSeed int64     // Seed of the ID generator in fixtures mode
+	Clock        Clock     // Where the time is read from (nil = the wall clock, see Now)
 }
 
+// Challenge providers
+const (
+	ChallengeProviderHCaptcha  = "hcaptcha"  // Verify hCaptcha tokens
+	ChallengeProviderTurnstile = "turnstile" // Verify Cloudflare Turnstile tokens
+	ChallengeProviderPoW       = "pow"       // Built-in proof-of-work, no third party involved
+)
+
+// Gin modes
+const (
+	GinModeDebug   = "debug"   // Verbose route and warning output
+	GinModeRelease = "release" // Quiet, for deployments
+	GinModeTest    = "test"
+)
+
+// Password hashing algorithms
+const (
+	PasswordHashBcrypt   = "bcrypt"
+	PasswordHashArgon2id = "argon2id"
+)
+
+// ID strategies
+const (
+	IDStrategyUUID     = "uuid"     // Random UUIDv4 without dashes
+	IDStrategyUUIDv7   = "uuidv7"   // Time-ordered UUIDv7 without dashes, so IDs sort by creation time
+	IDStrategyNanoID   = "nanoid"   // 21 random URL-safe characters
+	IDStrategyPrefixed = "prefixed" // Entity prefix and a UUIDv7, e.g. doc_0190b5c4...
+)
+
+// OTP delivery channels
+const (
+	OTPDeliveryEmail = "email" // Send OTPs to the profile's email address
+	OTPDeliverySMS   = "sms"   // Text OTPs to the profile's phone number, falling back to email when it has none
+)
+
 const (
 	defaultAddress       = "0.0.0.0"
 	defaultPort          = "8080"
+	defaultGinMode       = GinModeDebug
+	defaultTrustedProxies  = "" // Trust no proxy: the client IP is the connecting IP
+	defaultClientIPHeaders = "X-Forwarded-For,X-Real-IP"
 	defaultDbFile        = "./docs.json" // Relative to working dir
+	defaultDataDir       = "" // Everything in the single -db-file
+	defaultCoursesDir    = "" // No course databases
+	defaultCoursesJwtAudience = "docserver" // Audience of the main database's tokens when hosting courses
 	defaultSaveInterval  = 3 * time.Second
+	defaultCoalesceWindow = 0 // Every update is its own version
 	defaultEnableBackup  = true
+	defaultDedupeContent = false
+	defaultFsync         = true
+	defaultChecksum      = true
+	defaultAutoRecover   = false
+	defaultMigrateDryRun = false
+	defaultReadOnly      = false
+	defaultEphemeral     = false
+	defaultRecordFile    = "" // Requests are not recorded
+	defaultReplayFile    = ""
+	defaultBenchSizes    = "10000,100000"
+	defaultTransformsFile = "" // No content transformations
+	defaultScriptTimeout = 100 * time.Millisecond
+	defaultIDStrategy    = IDStrategyPrefixed
+	defaultAdminEmails   = "" // No administrators
+	defaultInviteOnly    = false
+	defaultLegacySunset  = "" // No sunset date announced for unversioned paths
+	defaultEnablePublicAccess = false
+	defaultMaxBodyBytes  = 1 << 20 // 1 MiB
+	defaultStrictJSON    = false
+	defaultFetchAllowedDomains = "" // Fetching disabled
+	defaultFetchMaxBytes = 1 << 20 // 1 MiB
+	defaultArchiveMaxBytes = 64 << 20 // 64 MiB
+	defaultFetchTimeout  = 10 * time.Second
+	defaultWebhookAllowedDomains = "" // Webhook delivery disabled
+	defaultNotificationMaxPerHour = 30
+	defaultErasureGracePeriod = 7 * 24 * time.Hour
+	defaultDeactivationGracePeriod = 30 * 24 * time.Hour
+	defaultExpiryGracePeriod = 7 * 24 * time.Hour
+	defaultTosVersion    = "" // No terms of service
+	defaultTosURL        = ""
+	defaultRequireTos    = false
 	defaultJwtSecretFile = "" // No default file
 	defaultJwtSecretEnv  = "" // No default env secret
 	defaultJwtKeyFile    = "./docs.key" // Default file if we generate a key
 	defaultTokenLifetime = 1 * time.Hour
+	defaultJwtLeeway     = 30 * time.Second
+	defaultJwtIssuer     = "docserver"
+	defaultJwtAudience   = "" // Not checked
+	defaultIntrospectClients = "" // Introspection disabled
 	defaultBcryptCost    = 12
+	defaultPasswordHash  = PasswordHashBcrypt
+	defaultArgon2Time      = 2
+	defaultArgon2MemoryKiB = 19 * 1024 // 19 MiB, with 2 passes as recommended by OWASP
+	defaultArgon2Threads   = 1
+	defaultPasswordHashTarget = 0 // No calibration
+	defaultOTPLength      = 6
+	defaultOTPCharset     = "0123456789"
+	defaultOTPLifetime    = 5 * time.Minute
+	defaultOTPMaxAttempts = 5
+	defaultOTPBackoff     = 1 * time.Second
+	defaultOTPBackoffMax  = 15 * time.Minute
+	defaultOTPDelivery    = OTPDeliveryEmail
+	defaultSMSGatewayURL  = "" // Text messages are logged
+	defaultChallengeProvider      = "" // No challenge
+	defaultChallengePoWDifficulty = 20
+	defaultStatusRateLimit        = 30 // Per client per minute
+	defaultOTelServiceName        = "docserver"
+	defaultSlowQueryThreshold     = 500 * time.Millisecond
+	defaultQueryCacheSize         = 256
+	defaultLoadGenerationMaxDocs  = 100_000
+	defaultLoadGenerationMaxUsers = 1_000
+	defaultFixtures               = false
+	defaultFixturesTime           = "2025-01-01T00:00:00Z"
+	defaultFixturesSeed           = 1
 )
 
 // LoadConfig loads configuration from defaults, environment variables, and command-line flags.
@@ -53,17 +248,94 @@ func LoadConfig() (*Config, error) {
 	flag.StringVar(&cfg.ListenAddress, "address", getEnv("DOCSERVER_LISTEN_ADDRESS", defaultAddress), "Server listen address (Env: DOCSERVER_LISTEN_ADDRESS)")
 	// Define flag with the ultimate default. We'll check env var after parsing.
 	flag.StringVar(&cfg.ListenPort, "port", defaultPort, "Server listen port (Env: DOCSERVER_LISTEN_PORT)")
+	flag.StringVar(&cfg.GinMode, "gin-mode", getEnv("DOCSERVER_GIN_MODE", getEnv("GIN_MODE", defaultGinMode)), "Gin mode: debug, release or test (Env: DOCSERVER_GIN_MODE or GIN_MODE)")
+	trustedProxiesStr := flag.String("trusted-proxies", getEnv("DOCSERVER_TRUSTED_PROXIES", defaultTrustedProxies), "Comma-separated IPs and CIDR ranges of reverse proxies trusted to report the client IP; empty trusts none (Env: DOCSERVER_TRUSTED_PROXIES)")
+	clientIPHeadersStr := flag.String("client-ip-headers", getEnv("DOCSERVER_CLIENT_IP_HEADERS", defaultClientIPHeaders), "Comma-separated headers trusted proxies report the client IP in, checked in order (Env: DOCSERVER_CLIENT_IP_HEADERS)")
 	flag.StringVar(&cfg.DbFilePath, "db-file", getEnv("DOCSERVER_DB_FILE_PATH", defaultDbFile), "Path to the JSON database file (Env: DOCSERVER_DB_FILE_PATH)")
+	flag.StringVar(&cfg.DataDir, "data-dir", getEnv("DOCSERVER_DATA_DIR", defaultDataDir), "Store the database in this directory as profiles.json, documents.json, shares.json and server.json instead of in -db-file (Env: DOCSERVER_DATA_DIR)")
+	flag.StringVar(&cfg.CoursesDir, "courses-dir", getEnv("DOCSERVER_COURSES_DIR", defaultCoursesDir), "Host course databases, created under /admin/courses and served under /courses/{id}/, in this directory (Env: DOCSERVER_COURSES_DIR)")
 	saveIntervalStr := flag.String("save-interval", getEnv("DOCSERVER_SAVE_INTERVAL", defaultSaveInterval.String()), "Debounce interval for saving DB (e.g., 5s, 100ms) (Env: DOCSERVER_SAVE_INTERVAL)")
+	coalesceWindowStr := flag.String("coalesce-window", getEnv("DOCSERVER_COALESCE_WINDOW", time.Duration(defaultCoalesceWindow).String()), "Fold updates of a document made this soon after its last update into that version, e.g. 2s for autosaving editors; 0 disables (Env: DOCSERVER_COALESCE_WINDOW)")
 	flag.BoolVar(&cfg.EnableBackup, "enable-backup", getEnvBool("DOCSERVER_ENABLE_BACKUP", defaultEnableBackup), "Enable database backup (.bak file) before saving (Env: DOCSERVER_ENABLE_BACKUP)")
+	flag.BoolVar(&cfg.Fsync, "fsync", getEnvBool("DOCSERVER_FSYNC", defaultFsync), "Sync the database file and its directory to disk on every save, so a power failure cannot lose saved data (Env: DOCSERVER_FSYNC)")
+	flag.BoolVar(&cfg.Checksum, "checksum", getEnvBool("DOCSERVER_CHECKSUM", defaultChecksum), "End the database file with a SHA-256 checksum line, checked on load, falling back to the .bak file if it does not match (Env: DOCSERVER_CHECKSUM)")
+	flag.BoolVar(&cfg.AutoRecover, "auto-recover", getEnvBool("DOCSERVER_AUTO_RECOVER", defaultAutoRecover), "If the database file cannot be parsed, start from the .bak file or, failing that, from the records that can be salvaged, setting the damaged file aside (Env: DOCSERVER_AUTO_RECOVER)")
+	flag.BoolVar(&cfg.DedupeContent, "dedupe-content", getEnvBool("DOCSERVER_DEDUPE_CONTENT", defaultDedupeContent), "Store content shared by several documents once in the database file, keyed by its hash (Env: DOCSERVER_DEDUPE_CONTENT)")
+	flag.BoolVar(&cfg.ReadOnly, "read-only", getEnvBool("DOCSERVER_READ_ONLY", defaultReadOnly), "Serve reads only: refuse POST, PUT, PATCH and DELETE requests with 403 (except logging in and out) and never save the database, e.g. to publish a frozen dataset (Env: DOCSERVER_READ_ONLY)")
+	flag.BoolVar(&cfg.Ephemeral, "ephemeral", getEnvBool("DOCSERVER_EPHEMERAL", defaultEphemeral), "Keep the database in memory only: start empty, never write the database file and lose all data when the server stops, e.g. for CI or short demos (Env: DOCSERVER_EPHEMERAL)")
+	flag.BoolVar(&cfg.MigrateDryRun, "migrate-dry-run", getEnvBool("DOCSERVER_MIGRATE_DRY_RUN", defaultMigrateDryRun), "Report required database schema migrations and exit without modifying the file (Env: DOCSERVER_MIGRATE_DRY_RUN)")
+	flag.StringVar(&cfg.RecordFile, "record-file", getEnv("DOCSERVER_RECORD_FILE", defaultRecordFile), "Append every mutating request to this journal, so -replay-file can rebuild the database from it (Env: DOCSERVER_RECORD_FILE)")
+	flag.StringVar(&cfg.ReplayFile, "replay-file", getEnv("DOCSERVER_REPLAY_FILE", defaultReplayFile), "Replay the requests of a journal made with -record-file into a new db-file and exit (Env: DOCSERVER_REPLAY_FILE)")
+	flag.BoolVar(&cfg.Bench, "bench", getEnvBool("DOCSERVER_BENCH", false), "Benchmark document queries, saves and loads on generated datasets, print the results and exit; same as the 'bench' command (Env: DOCSERVER_BENCH)")
+	benchSizesStr := flag.String("bench-sizes", getEnv("DOCSERVER_BENCH_SIZES", defaultBenchSizes), "Comma-separated dataset sizes, in documents, the benchmarks run on (Env: DOCSERVER_BENCH_SIZES)")
+	replayUntilStr := flag.String("replay-until", getEnv("DOCSERVER_REPLAY_UNTIL", ""), "RFC 3339 instant: replay only the requests made up to it (Env: DOCSERVER_REPLAY_UNTIL)")
+	flag.StringVar(&cfg.TransformsFile, "transforms-file", getEnv("DOCSERVER_TRANSFORMS_FILE", defaultTransformsFile), "Path to a JSON file of content transformation rules applied on document create/update (Env: DOCSERVER_TRANSFORMS_FILE)")
+	flag.StringVar(&cfg.IDStrategy, "id-strategy", getEnv("DOCSERVER_ID_STRATEGY", defaultIDStrategy), "How new IDs are generated: uuid, uuidv7, nanoid or prefixed (Env: DOCSERVER_ID_STRATEGY)")
+	scriptTimeoutStr := flag.String("script-timeout", getEnv("DOCSERVER_SCRIPT_TIMEOUT", defaultScriptTimeout.String()), "Time limit for each document script run (e.g., 100ms, 1s) (Env: DOCSERVER_SCRIPT_TIMEOUT)")
+	adminEmailsStr := flag.String("admin-emails", getEnv("DOCSERVER_ADMIN_EMAILS", defaultAdminEmails), "Comma-separated emails of accounts allowed to use the /admin endpoints (Env: DOCSERVER_ADMIN_EMAILS)")
+	flag.BoolVar(&cfg.InviteOnly, "invite-only", getEnvBool("DOCSERVER_INVITE_ONLY", defaultInviteOnly), "Require an invitation code from POST /admin/invites to sign up; administrators can always sign up (Env: DOCSERVER_INVITE_ONLY)")
+	flag.Int64Var(&cfg.MaxBodyBytes, "max-body-bytes", getEnvInt64("DOCSERVER_MAX_BODY_BYTES", defaultMaxBodyBytes), "Largest JSON request body in bytes; larger requests are rejected with 413, 0 disables the limit (Env: DOCSERVER_MAX_BODY_BYTES)")
+	flag.BoolVar(&cfg.StrictJSON, "strict-json", getEnvBool("DOCSERVER_STRICT_JSON", defaultStrictJSON), "Reject JSON request bodies containing fields the endpoint does not know (Env: DOCSERVER_STRICT_JSON)")
+	flag.BoolVar(&cfg.EnablePublicAccess, "enable-public-access", getEnvBool("DOCSERVER_ENABLE_PUBLIC_ACCESS", defaultEnablePublicAccess), "Allow unauthenticated read-only access to documents marked public via /public/documents (Env: DOCSERVER_ENABLE_PUBLIC_ACCESS)")
+	fetchDomainsStr := flag.String("fetch-allowed-domains", getEnv("DOCSERVER_FETCH_ALLOWED_DOMAINS", defaultFetchAllowedDomains), "Comma-separated domains POST /documents/fetch may download JSON from; empty disables the endpoint (Env: DOCSERVER_FETCH_ALLOWED_DOMAINS)")
+	flag.Int64Var(&cfg.ArchiveMaxBytes, "archive-max-bytes", getEnvInt64("DOCSERVER_ARCHIVE_MAX_BYTES", defaultArchiveMaxBytes), "Most document data in bytes GET /documents/archive puts in one zip; larger archives are truncated (Env: DOCSERVER_ARCHIVE_MAX_BYTES)")
+	flag.Int64Var(&cfg.FetchMaxBytes, "fetch-max-bytes", getEnvInt64("DOCSERVER_FETCH_MAX_BYTES", defaultFetchMaxBytes), "Largest response in bytes POST /documents/fetch accepts (Env: DOCSERVER_FETCH_MAX_BYTES)")
+	webhookDomainsStr := flag.String("webhook-allowed-domains", getEnv("DOCSERVER_WEBHOOK_ALLOWED_DOMAINS", defaultWebhookAllowedDomains), "Comma-separated domains scheduled exports and notification channels may be POSTed to, e.g. hooks.slack.com,discord.com; empty disables webhook targets (Env: DOCSERVER_WEBHOOK_ALLOWED_DOMAINS)")
+	flag.IntVar(&cfg.NotificationMaxPerHour, "notification-max-per-hour", int(getEnvInt64("DOCSERVER_NOTIFICATION_MAX_PER_HOUR", defaultNotificationMaxPerHour)), "Most messages a Slack or Discord notification channel is sent per hour, the default and ceiling of its max_per_hour (Env: DOCSERVER_NOTIFICATION_MAX_PER_HOUR)")
+	fetchTimeoutStr := flag.String("fetch-timeout", getEnv("DOCSERVER_FETCH_TIMEOUT", defaultFetchTimeout.String()), "Time limit for each fetch by POST /documents/fetch (e.g., 10s) (Env: DOCSERVER_FETCH_TIMEOUT)")
+	legacySunsetStr := flag.String("legacy-sunset", getEnv("DOCSERVER_LEGACY_SUNSET", defaultLegacySunset), "Sunset date (YYYY-MM-DD) advertised on deprecated unversioned API paths (Env: DOCSERVER_LEGACY_SUNSET)")
+	deactivationGraceStr := flag.String("deactivation-grace-period", getEnv("DOCSERVER_DEACTIVATION_GRACE_PERIOD", defaultDeactivationGracePeriod.String()), "How long a deactivated account is kept before it is purged (e.g., 720h) (Env: DOCSERVER_DEACTIVATION_GRACE_PERIOD)")
+	expiryGraceStr := flag.String("expiry-grace-period", getEnv("DOCSERVER_EXPIRY_GRACE_PERIOD", defaultExpiryGracePeriod.String()), "How long an expired document is kept before it is purged (e.g., 168h, 0s) (Env: DOCSERVER_EXPIRY_GRACE_PERIOD)")
+	erasureGraceStr := flag.String("erasure-grace-period", getEnv("DOCSERVER_ERASURE_GRACE_PERIOD", defaultErasureGracePeriod.String()), "Delay before a requested profile erasure is carried out (e.g., 168h, 0s) (Env: DOCSERVER_ERASURE_GRACE_PERIOD)")
+	flag.StringVar(&cfg.TosVersion, "tos-version", getEnv("DOCSERVER_TOS_VERSION", defaultTosVersion), "Current terms of service version users are asked to accept, e.g. 2024-01 (Env: DOCSERVER_TOS_VERSION)")
+	flag.StringVar(&cfg.TosURL, "tos-url", getEnv("DOCSERVER_TOS_URL", defaultTosURL), "URL of the current terms of service, included in signup and login responses (Env: DOCSERVER_TOS_URL)")
+	flag.BoolVar(&cfg.RequireTos, "require-tos", getEnvBool("DOCSERVER_REQUIRE_TOS", defaultRequireTos), "Reject document and search requests until the current terms of service are accepted (Env: DOCSERVER_REQUIRE_TOS)")
+	otpLength := flag.Int("otp-length", int(getEnvInt64("DOCSERVER_OTP_LENGTH", defaultOTPLength)), "Characters per password reset OTP (Env: DOCSERVER_OTP_LENGTH)")
+	flag.StringVar(&cfg.OTPCharset, "otp-charset", getEnv("DOCSERVER_OTP_CHARSET", defaultOTPCharset), "Characters password reset OTPs are drawn from (Env: DOCSERVER_OTP_CHARSET)")
+	otpLifetimeStr := flag.String("otp-ttl", getEnv("DOCSERVER_OTP_TTL", defaultOTPLifetime.String()), "How long a password reset OTP stays valid (e.g., 5m) (Env: DOCSERVER_OTP_TTL)")
+	otpMaxAttempts := flag.Int("otp-max-attempts", int(getEnvInt64("DOCSERVER_OTP_MAX_ATTEMPTS", defaultOTPMaxAttempts)), "Wrong guesses allowed before a password reset OTP is invalidated (Env: DOCSERVER_OTP_MAX_ATTEMPTS)")
+	otpBackoffStr := flag.String("otp-backoff", getEnv("DOCSERVER_OTP_BACKOFF", defaultOTPBackoff.String()), "Wait after a failed OTP verification, doubled for each consecutive failure; 0 disables (Env: DOCSERVER_OTP_BACKOFF)")
+	otpBackoffMaxStr := flag.String("otp-backoff-max", getEnv("DOCSERVER_OTP_BACKOFF_MAX", defaultOTPBackoffMax.String()), "Longest wait between OTP verifications (Env: DOCSERVER_OTP_BACKOFF_MAX)")
+	flag.StringVar(&cfg.OTPDelivery, "otp-delivery", getEnv("DOCSERVER_OTP_DELIVERY", defaultOTPDelivery), "How password reset OTPs are sent: email, or sms to the profile's phone number (Env: DOCSERVER_OTP_DELIVERY)")
+	flag.StringVar(&cfg.ChallengeProvider, "challenge-provider", getEnv("DOCSERVER_CHALLENGE_PROVIDER", defaultChallengeProvider), "Challenge required on signup and forgot-password: hcaptcha, turnstile, pow, or empty for none (Env: DOCSERVER_CHALLENGE_PROVIDER)")
+	flag.StringVar(&cfg.ChallengeSiteKey, "challenge-site-key", getEnv("DOCSERVER_CHALLENGE_SITE_KEY", ""), "hCaptcha/Turnstile site key given to clients by GET /auth/challenge (Env: DOCSERVER_CHALLENGE_SITE_KEY)")
+	flag.StringVar(&cfg.ChallengeSecret, "challenge-secret", getEnv("DOCSERVER_CHALLENGE_SECRET", ""), "hCaptcha/Turnstile secret key used to verify tokens (Env: DOCSERVER_CHALLENGE_SECRET)")
+	challengeDifficulty := flag.Int("challenge-pow-difficulty", int(getEnvInt64("DOCSERVER_CHALLENGE_POW_DIFFICULTY", defaultChallengePoWDifficulty)), "Leading zero bits a proof-of-work challenge solution needs (Env: DOCSERVER_CHALLENGE_POW_DIFFICULTY)")
+	flag.IntVar(&cfg.StatusRateLimit, "status-rate-limit", int(getEnvInt64("DOCSERVER_STATUS_RATE_LIMIT", defaultStatusRateLimit)), "Requests per minute each client may make to GET /status; 0 disables the limit (Env: DOCSERVER_STATUS_RATE_LIMIT)")
+	flag.StringVar(&cfg.OTLPEndpoint, "otlp-endpoint", getEnv("DOCSERVER_OTLP_ENDPOINT", getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "")), "OpenTelemetry collector (OTLP/HTTP) to export trace spans to, e.g. http://localhost:4318; empty disables tracing (Env: DOCSERVER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_ENDPOINT)")
+	flag.StringVar(&cfg.OTLPHeaders, "otlp-headers", getEnv("DOCSERVER_OTLP_HEADERS", getEnv("OTEL_EXPORTER_OTLP_HEADERS", "")), "Headers sent to the OTLP collector, as key1=value1,key2=value2 (Env: DOCSERVER_OTLP_HEADERS or OTEL_EXPORTER_OTLP_HEADERS)")
+	flag.StringVar(&cfg.OTelServiceName, "otel-service-name", getEnv("OTEL_SERVICE_NAME", defaultOTelServiceName), "Service name trace spans are reported under (Env: OTEL_SERVICE_NAME)")
+	slowQueryThresholdStr := flag.String("slow-query-threshold", getEnv("DOCSERVER_SLOW_QUERY_THRESHOLD", defaultSlowQueryThreshold.String()), "Content queries taking longer than this are logged and listed by GET /admin/slow-queries; 0 disables (Env: DOCSERVER_SLOW_QUERY_THRESHOLD)")
+	flag.IntVar(&cfg.QueryCacheSize, "query-cache-size", int(getEnvInt64("DOCSERVER_QUERY_CACHE_SIZE", defaultQueryCacheSize)), "Document query results cached until the data changes, least recently used dropped first; 0 disables the cache (Env: DOCSERVER_QUERY_CACHE_SIZE)")
+	flag.BoolVar(&cfg.LoadGeneration, "allow-load-generation", getEnvBool("DOCSERVER_ALLOW_LOAD_GENERATION", false), "Let administrators fill the database with generated users and documents via POST /admin/generate-load; for development and performance labs only (Env: DOCSERVER_ALLOW_LOAD_GENERATION)")
+	flag.IntVar(&cfg.LoadGenerationMaxDocs, "load-generation-max-docs", int(getEnvInt64("DOCSERVER_LOAD_GENERATION_MAX_DOCS", defaultLoadGenerationMaxDocs)), "Most documents one POST /admin/generate-load may create (Env: DOCSERVER_LOAD_GENERATION_MAX_DOCS)")
+	flag.IntVar(&cfg.LoadGenerationMaxUsers, "load-generation-max-users", int(getEnvInt64("DOCSERVER_LOAD_GENERATION_MAX_USERS", defaultLoadGenerationMaxUsers)), "Most users one POST /admin/generate-load may create (Env: DOCSERVER_LOAD_GENERATION_MAX_USERS)")
+	flag.StringVar(&cfg.SMSGatewayURL, "sms-gateway-url", getEnv("DOCSERVER_SMS_GATEWAY_URL", defaultSMSGatewayURL), "URL text messages are POSTed to as JSON; empty writes them to the server log (Env: DOCSERVER_SMS_GATEWAY_URL)")
 	flag.StringVar(&cfg.JwtSecretFile, "jwt-secret-file", getEnv("DOCSERVER_JWT_SECRET_FILE", defaultJwtSecretFile), "Path to file containing JWT secret key (overrides DOCSERVER_JWT_SECRET env var) (Env: DOCSERVER_JWT_SECRET_FILE)")
+	flag.StringVar(&cfg.PasswordHash, "password-hash", getEnv("DOCSERVER_PASSWORD_HASH", defaultPasswordHash), "Algorithm of new password hashes: bcrypt or argon2id (Env: DOCSERVER_PASSWORD_HASH)")
+	flag.IntVar(&cfg.BcryptCost, "bcrypt-cost", int(getEnvInt64("DOCSERVER_BCRYPT_COST", defaultBcryptCost)), "bcrypt cost of new password hashes, 4-31 (Env: DOCSERVER_BCRYPT_COST)")
+	argon2Time := flag.Int64("argon2-time", getEnvInt64("DOCSERVER_ARGON2_TIME", defaultArgon2Time), "argon2id passes over memory (Env: DOCSERVER_ARGON2_TIME)")
+	argon2Memory := flag.Int64("argon2-memory", getEnvInt64("DOCSERVER_ARGON2_MEMORY", defaultArgon2MemoryKiB), "argon2id memory in KiB (Env: DOCSERVER_ARGON2_MEMORY)")
+	argon2Threads := flag.Int64("argon2-threads", getEnvInt64("DOCSERVER_ARGON2_THREADS", defaultArgon2Threads), "argon2id parallelism, 1-255 (Env: DOCSERVER_ARGON2_THREADS)")
+	passwordHashTargetStr := flag.String("password-hash-target", getEnv("DOCSERVER_PASSWORD_HASH_TARGET", time.Duration(defaultPasswordHashTarget).String()), "At startup, raise or lower the bcrypt cost or argon2id passes so hashing a password takes about this long; 0 disables (Env: DOCSERVER_PASSWORD_HASH_TARGET)")
+	jwtLeewayStr := flag.String("jwt-leeway", getEnv("DOCSERVER_JWT_LEEWAY", defaultJwtLeeway.String()), "Clock skew tolerated when checking when a token expires, becomes valid and was issued (Env: DOCSERVER_JWT_LEEWAY)")
+	flag.StringVar(&cfg.JwtIssuer, "jwt-issuer", getEnv("DOCSERVER_JWT_ISSUER", defaultJwtIssuer), "Issuer (iss) tokens are signed with and must carry (Env: DOCSERVER_JWT_ISSUER)")
+	flag.BoolVar(&cfg.Fixtures, "fixtures", getEnvBool("DOCSERVER_FIXTURES", defaultFixtures), "Deterministic fixtures mode: freeze the clock at fixtures-time and derive IDs from fixtures-seed, for reproducible test runs (Env: DOCSERVER_FIXTURES)")
+	fixturesTimeStr := flag.String("fixtures-time", getEnv("DOCSERVER_FIXTURES_TIME", defaultFixturesTime), "RFC 3339 instant the clock is frozen at in fixtures mode (Env: DOCSERVER_FIXTURES_TIME)")
+	flag.Int64Var(&cfg.FixturesSeed, "fixtures-seed", getEnvInt64("DOCSERVER_FIXTURES_SEED", defaultFixturesSeed), "Seed of the ID generator in fixtures mode (Env: DOCSERVER_FIXTURES_SEED)")
+	introspectClientsStr := flag.String("introspect-clients", getEnv("DOCSERVER_INTROSPECT_CLIENTS", defaultIntrospectClients), "Comma-separated id:secret credentials of services allowed to call POST /auth/introspect; empty disables the endpoint (Env: DOCSERVER_INTROSPECT_CLIENTS)")
+	flag.StringVar(&cfg.JwtAudience, "jwt-audience", getEnv("DOCSERVER_JWT_AUDIENCE", defaultJwtAudience), "Audience (aud) tokens are signed with and must carry; empty leaves it out (Env: DOCSERVER_JWT_AUDIENCE)")
 
 	// Non-configurable defaults (as per plan)
 	cfg.TokenLifetime = defaultTokenLifetime
-	cfg.BcryptCost = defaultBcryptCost
 
 	// Parse flags to override defaults and env vars
 	flag.Parse()
+	// "docserver bench" is -bench; flags may also follow the command
+	if flag.Arg(0) == "bench" {
+		cfg.Bench = true
+		_ = flag.CommandLine.Parse(flag.Args()[1:]) // Exits on invalid flags, as flag.Parse does
+	}
 
 	// --- Post-Flag Parsing Adjustments ---
 	// Explicitly check environment variables to allow them to override defaults
@@ -105,6 +377,10 @@ func LoadConfig() (*Config, error) {
 	}
 
 
+	if cfg.RequireTos && cfg.TosVersion == "" {
+		log.Printf("WARN: require-tos is set but no tos-version is configured. Terms of service will not be enforced.")
+	}
+
 	// Parse duration after flags are parsed
 	var err error
 	cfg.SaveInterval, err = time.ParseDuration(*saveIntervalStr)
@@ -112,6 +388,286 @@ func LoadConfig() (*Config, error) {
 		log.Printf("WARN: Invalid save-interval duration '%s'. Using default %s. Error: %v", *saveIntervalStr, defaultSaveInterval, err)
 		cfg.SaveInterval = defaultSaveInterval
 	}
+	cfg.CoalesceWindow, err = time.ParseDuration(*coalesceWindowStr)
+	if err != nil || cfg.CoalesceWindow < 0 {
+		log.Printf("WARN: Invalid coalesce-window duration '%s'. Using default %s. Error: %v", *coalesceWindowStr, time.Duration(defaultCoalesceWindow), err)
+		cfg.CoalesceWindow = defaultCoalesceWindow
+	}
+
+	cfg.ErasureGracePeriod, err = time.ParseDuration(*erasureGraceStr)
+	if err != nil || cfg.ErasureGracePeriod < 0 {
+		log.Printf("WARN: Invalid erasure-grace-period duration '%s'. Using default %s. Error: %v", *erasureGraceStr, defaultErasureGracePeriod, err)
+		cfg.ErasureGracePeriod = defaultErasureGracePeriod
+	}
+	cfg.DeactivationGracePeriod, err = time.ParseDuration(*deactivationGraceStr)
+	if err != nil || cfg.DeactivationGracePeriod <= 0 {
+		log.Printf("WARN: Invalid deactivation-grace-period duration '%s'. Using default %s. Error: %v", *deactivationGraceStr, defaultDeactivationGracePeriod, err)
+		cfg.DeactivationGracePeriod = defaultDeactivationGracePeriod
+	}
+
+	cfg.ExpiryGracePeriod, err = time.ParseDuration(*expiryGraceStr)
+	if err != nil || cfg.ExpiryGracePeriod < 0 {
+		log.Printf("WARN: Invalid expiry-grace-period duration '%s'. Using default %s. Error: %v", *expiryGraceStr, defaultExpiryGracePeriod, err)
+		cfg.ExpiryGracePeriod = defaultExpiryGracePeriod
+	}
+
+	cfg.ScriptTimeout, err = time.ParseDuration(*scriptTimeoutStr)
+	if err != nil || cfg.ScriptTimeout <= 0 {
+		log.Printf("WARN: Invalid script-timeout duration '%s'. Using default %s. Error: %v", *scriptTimeoutStr, defaultScriptTimeout, err)
+		cfg.ScriptTimeout = defaultScriptTimeout
+	}
+	cfg.IDStrategy = strings.ToLower(strings.TrimSpace(cfg.IDStrategy))
+	switch cfg.IDStrategy {
+	case IDStrategyUUID, IDStrategyUUIDv7, IDStrategyNanoID, IDStrategyPrefixed:
+	default:
+		log.Printf("WARN: Invalid id-strategy '%s' (expected %s, %s, %s or %s). Using default %s.", cfg.IDStrategy, IDStrategyUUID, IDStrategyUUIDv7, IDStrategyNanoID, IDStrategyPrefixed, defaultIDStrategy)
+		cfg.IDStrategy = defaultIDStrategy
+	}
+
+	cfg.FetchTimeout, err = time.ParseDuration(*fetchTimeoutStr)
+	if err != nil || cfg.FetchTimeout <= 0 {
+		log.Printf("WARN: Invalid fetch-timeout duration '%s'. Using default %s. Error: %v", *fetchTimeoutStr, defaultFetchTimeout, err)
+		cfg.FetchTimeout = defaultFetchTimeout
+	}
+	if cfg.FetchMaxBytes <= 0 {
+		log.Printf("WARN: Invalid fetch-max-bytes %d. Using default %d.", cfg.FetchMaxBytes, int64(defaultFetchMaxBytes))
+		cfg.FetchMaxBytes = defaultFetchMaxBytes
+	}
+	cfg.JwtLeeway, err = time.ParseDuration(*jwtLeewayStr)
+	if err != nil || cfg.JwtLeeway < 0 {
+		log.Printf("WARN: Invalid jwt-leeway duration '%s'. Using default %s. Error: %v", *jwtLeewayStr, defaultJwtLeeway, err)
+		cfg.JwtLeeway = defaultJwtLeeway
+	}
+	cfg.PasswordHash = strings.ToLower(strings.TrimSpace(cfg.PasswordHash))
+	if cfg.PasswordHash != PasswordHashBcrypt && cfg.PasswordHash != PasswordHashArgon2id {
+		log.Printf("WARN: Invalid password-hash '%s' (expected %s or %s). Using default %s.", cfg.PasswordHash, PasswordHashBcrypt, PasswordHashArgon2id, defaultPasswordHash)
+		cfg.PasswordHash = defaultPasswordHash
+	}
+	if cfg.BcryptCost < 4 || cfg.BcryptCost > 31 {
+		log.Printf("WARN: Invalid bcrypt-cost %d (must be 4-31). Using default %d.", cfg.BcryptCost, defaultBcryptCost)
+		cfg.BcryptCost = defaultBcryptCost
+	}
+	if *argon2Time < 1 || *argon2Time > math.MaxUint32 {
+		log.Printf("WARN: Invalid argon2-time %d (must be >= 1). Using default %d.", *argon2Time, defaultArgon2Time)
+		*argon2Time = defaultArgon2Time
+	}
+	cfg.Argon2Time = uint32(*argon2Time)
+	if *argon2Threads < 1 || *argon2Threads > math.MaxUint8 {
+		log.Printf("WARN: Invalid argon2-threads %d (must be 1-255). Using default %d.", *argon2Threads, defaultArgon2Threads)
+		*argon2Threads = defaultArgon2Threads
+	}
+	cfg.Argon2Threads = uint8(*argon2Threads)
+	if *argon2Memory < 8*int64(cfg.Argon2Threads) || *argon2Memory > math.MaxUint32 {
+		log.Printf("WARN: Invalid argon2-memory %d KiB (must be at least 8 per thread). Using default %d.", *argon2Memory, defaultArgon2MemoryKiB)
+		*argon2Memory = defaultArgon2MemoryKiB
+	}
+	cfg.Argon2MemoryKiB = uint32(*argon2Memory)
+	cfg.PasswordHashTarget, err = time.ParseDuration(*passwordHashTargetStr)
+	if err != nil || cfg.PasswordHashTarget < 0 {
+		log.Printf("WARN: Invalid password-hash-target duration '%s'. Using default %s. Error: %v", *passwordHashTargetStr, time.Duration(defaultPasswordHashTarget), err)
+		cfg.PasswordHashTarget = defaultPasswordHashTarget
+	}
+	cfg.JwtIssuer = strings.TrimSpace(cfg.JwtIssuer)
+	if cfg.JwtIssuer == "" {
+		log.Printf("WARN: Empty jwt-issuer. Using default %q.", defaultJwtIssuer)
+		cfg.JwtIssuer = defaultJwtIssuer
+	}
+	cfg.JwtAudience = strings.TrimSpace(cfg.JwtAudience)
+	for _, credential := range strings.Split(*introspectClientsStr, ",") {
+		if credential = strings.TrimSpace(credential); credential == "" {
+			continue
+		}
+		id, secret, found := strings.Cut(credential, ":")
+		if id, secret = strings.TrimSpace(id), strings.TrimSpace(secret); !found || id == "" || secret == "" {
+			log.Printf("WARN: Invalid introspect-clients entry '%s' (expected id:secret). Skipping it.", id)
+			continue
+		}
+		if cfg.IntrospectClients == nil {
+			cfg.IntrospectClients = make(map[string]string)
+		}
+		cfg.IntrospectClients[id] = secret
+	}
+	if cfg.ArchiveMaxBytes <= 0 {
+		log.Printf("WARN: Invalid archive-max-bytes %d. Using default %d.", cfg.ArchiveMaxBytes, int64(defaultArchiveMaxBytes))
+		cfg.ArchiveMaxBytes = defaultArchiveMaxBytes
+	}
+	if cfg.MaxBodyBytes < 0 {
+		log.Printf("WARN: Invalid max-body-bytes %d. Using default %d.", cfg.MaxBodyBytes, int64(defaultMaxBodyBytes))
+		cfg.MaxBodyBytes = defaultMaxBodyBytes
+	}
+	for _, domain := range strings.Split(*fetchDomainsStr, ",") {
+		if domain = strings.ToLower(strings.TrimSpace(domain)); domain != "" {
+			cfg.FetchAllowedDomains = append(cfg.FetchAllowedDomains, domain)
+		}
+	}
+	for _, domain := range strings.Split(*webhookDomainsStr, ",") {
+		if domain = strings.ToLower(strings.TrimSpace(domain)); domain != "" {
+			cfg.WebhookAllowedDomains = append(cfg.WebhookAllowedDomains, domain)
+		}
+	}
+	if cfg.NotificationMaxPerHour < 1 {
+		log.Printf("WARN: Invalid notification-max-per-hour %d (must be >= 1). Using default %d.", cfg.NotificationMaxPerHour, defaultNotificationMaxPerHour)
+		cfg.NotificationMaxPerHour = defaultNotificationMaxPerHour
+	}
+
+	// Password reset OTPs
+	cfg.OTPLength = *otpLength
+	if cfg.OTPLength < 4 || cfg.OTPLength > 64 {
+		log.Printf("WARN: Invalid otp-length %d (must be 4-64). Using default %d.", cfg.OTPLength, defaultOTPLength)
+		cfg.OTPLength = defaultOTPLength
+	}
+	if len([]rune(cfg.OTPCharset)) < 2 {
+		log.Printf("WARN: Invalid otp-charset '%s' (needs at least 2 characters). Using default %s.", cfg.OTPCharset, defaultOTPCharset)
+		cfg.OTPCharset = defaultOTPCharset
+	}
+	cfg.OTPLifetime, err = time.ParseDuration(*otpLifetimeStr)
+	if err != nil || cfg.OTPLifetime <= 0 {
+		log.Printf("WARN: Invalid otp-ttl duration '%s'. Using default %s. Error: %v", *otpLifetimeStr, defaultOTPLifetime, err)
+		cfg.OTPLifetime = defaultOTPLifetime
+	}
+	cfg.OTPMaxAttempts = *otpMaxAttempts
+	if cfg.OTPMaxAttempts < 1 {
+		log.Printf("WARN: Invalid otp-max-attempts %d. Using default %d.", cfg.OTPMaxAttempts, defaultOTPMaxAttempts)
+		cfg.OTPMaxAttempts = defaultOTPMaxAttempts
+	}
+	cfg.OTPBackoff, err = time.ParseDuration(*otpBackoffStr)
+	if err != nil || cfg.OTPBackoff < 0 {
+		log.Printf("WARN: Invalid otp-backoff duration '%s'. Using default %s. Error: %v", *otpBackoffStr, defaultOTPBackoff, err)
+		cfg.OTPBackoff = defaultOTPBackoff
+	}
+	cfg.SlowQueryThreshold, err = time.ParseDuration(*slowQueryThresholdStr)
+	if err != nil || cfg.SlowQueryThreshold < 0 {
+		log.Printf("WARN: Invalid slow-query-threshold duration '%s'. Using default %s. Error: %v", *slowQueryThresholdStr, defaultSlowQueryThreshold, err)
+		cfg.SlowQueryThreshold = defaultSlowQueryThreshold
+	}
+	cfg.OTPBackoffMax, err = time.ParseDuration(*otpBackoffMaxStr)
+	if err != nil || cfg.OTPBackoffMax < cfg.OTPBackoff {
+		log.Printf("WARN: Invalid otp-backoff-max duration '%s' (must be at least otp-backoff). Using %s. Error: %v", *otpBackoffMaxStr, max(defaultOTPBackoffMax, cfg.OTPBackoff), err)
+		cfg.OTPBackoffMax = max(defaultOTPBackoffMax, cfg.OTPBackoff)
+	}
+	cfg.OTPDelivery = strings.ToLower(strings.TrimSpace(cfg.OTPDelivery))
+	if cfg.OTPDelivery != OTPDeliveryEmail && cfg.OTPDelivery != OTPDeliverySMS {
+		log.Printf("WARN: Invalid otp-delivery '%s' (expected %s or %s). Using default %s.", cfg.OTPDelivery, OTPDeliveryEmail, OTPDeliverySMS, defaultOTPDelivery)
+		cfg.OTPDelivery = defaultOTPDelivery
+	}
+
+	// Bot protection challenge
+	cfg.ChallengeProvider = strings.ToLower(strings.TrimSpace(cfg.ChallengeProvider))
+	switch cfg.ChallengeProvider {
+	case "", ChallengeProviderPoW:
+	case ChallengeProviderHCaptcha, ChallengeProviderTurnstile:
+		if cfg.ChallengeSecret == "" {
+			log.Printf("WARN: challenge-provider '%s' needs a challenge-secret. No challenge will be required.", cfg.ChallengeProvider)
+			cfg.ChallengeProvider = ""
+		}
+	default:
+		log.Printf("WARN: Invalid challenge-provider '%s' (expected %s, %s or %s). No challenge will be required.", cfg.ChallengeProvider, ChallengeProviderHCaptcha, ChallengeProviderTurnstile, ChallengeProviderPoW)
+		cfg.ChallengeProvider = ""
+	}
+	cfg.ChallengePoWDifficulty = *challengeDifficulty
+	if cfg.ChallengePoWDifficulty < 1 || cfg.ChallengePoWDifficulty > 32 {
+		log.Printf("WARN: Invalid challenge-pow-difficulty %d (must be 1-32). Using default %d.", cfg.ChallengePoWDifficulty, defaultChallengePoWDifficulty)
+		cfg.ChallengePoWDifficulty = defaultChallengePoWDifficulty
+	}
+
+	if cfg.StatusRateLimit < 0 {
+		log.Printf("WARN: Invalid status-rate-limit %d (must be >= 0). Using default %d.", cfg.StatusRateLimit, defaultStatusRateLimit)
+		cfg.StatusRateLimit = defaultStatusRateLimit
+	}
+
+	if cfg.LoadGenerationMaxDocs < 1 {
+		log.Printf("WARN: Invalid load-generation-max-docs %d (must be >= 1). Using default %d.", cfg.LoadGenerationMaxDocs, defaultLoadGenerationMaxDocs)
+		cfg.LoadGenerationMaxDocs = defaultLoadGenerationMaxDocs
+	}
+	if cfg.LoadGenerationMaxUsers < 1 {
+		log.Printf("WARN: Invalid load-generation-max-users %d (must be >= 1). Using default %d.", cfg.LoadGenerationMaxUsers, defaultLoadGenerationMaxUsers)
+		cfg.LoadGenerationMaxUsers = defaultLoadGenerationMaxUsers
+	}
+
+	if cfg.QueryCacheSize < 0 {
+		log.Printf("WARN: Invalid query-cache-size %d (must be >= 0). Using default %d.", cfg.QueryCacheSize, defaultQueryCacheSize)
+		cfg.QueryCacheSize = defaultQueryCacheSize
+	}
+
+	if cfg.OTLPEndpoint != "" && !strings.HasPrefix(cfg.OTLPEndpoint, "http://") && !strings.HasPrefix(cfg.OTLPEndpoint, "https://") {
+		log.Printf("WARN: Invalid otlp-endpoint '%s' (must be an http:// or https:// URL). Tracing is disabled.", cfg.OTLPEndpoint)
+		cfg.OTLPEndpoint = ""
+	}
+	if strings.TrimSpace(cfg.OTelServiceName) == "" {
+		cfg.OTelServiceName = defaultOTelServiceName
+	}
+
+	// Gin mode and client IP resolution
+	cfg.GinMode = strings.ToLower(strings.TrimSpace(cfg.GinMode))
+	if cfg.GinMode != GinModeDebug && cfg.GinMode != GinModeRelease && cfg.GinMode != GinModeTest {
+		log.Printf("WARN: Invalid gin-mode '%s' (expected %s, %s or %s). Using default %s.", cfg.GinMode, GinModeDebug, GinModeRelease, GinModeTest, defaultGinMode)
+		cfg.GinMode = defaultGinMode
+	}
+	for _, proxy := range strings.Split(*trustedProxiesStr, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy == "" {
+			continue
+		}
+		_, _, cidrErr := net.ParseCIDR(proxy)
+		if cidrErr != nil && net.ParseIP(proxy) == nil {
+			log.Printf("WARN: Invalid trusted-proxies entry '%s' (expected an IP or CIDR range). Ignoring it.", proxy)
+			continue
+		}
+		cfg.TrustedProxies = append(cfg.TrustedProxies, proxy)
+	}
+	for _, header := range strings.Split(*clientIPHeadersStr, ",") {
+		if header = strings.TrimSpace(header); header != "" {
+			cfg.ClientIPHeaders = append(cfg.ClientIPHeaders, http.CanonicalHeaderKey(header))
+		}
+	}
+
+	// Admin emails are compared case-insensitively
+	for _, email := range strings.Split(*adminEmailsStr, ",") {
+		if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+			cfg.AdminEmails = append(cfg.AdminEmails, email)
+		}
+	}
+	if cfg.InviteOnly && len(cfg.AdminEmails) == 0 {
+		log.Printf("WARN: invite-only is set but no admin-emails are configured. Nobody can create invitations, so nobody can sign up.")
+	}
+
+	// Parse legacy sunset date (optional)
+	if *legacySunsetStr != "" {
+		cfg.LegacySunset, err = time.Parse("2006-01-02", *legacySunsetStr)
+		if err != nil {
+			log.Printf("WARN: Invalid legacy-sunset date '%s' (expected YYYY-MM-DD). No Sunset header will be sent. Error: %v", *legacySunsetStr, err)
+			cfg.LegacySunset = time.Time{}
+		}
+	}
+
+	// Parse replay cut-off (optional). Replaying too much would build the wrong state, so this is an error.
+	if *replayUntilStr != "" {
+		cfg.ReplayUntil, err = time.Parse(time.RFC3339, *replayUntilStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid replay-until '%s' (expected RFC 3339, e.g. 2025-01-01T12:00:00Z): %w", *replayUntilStr, err)
+		}
+	}
+
+	// Parse benchmark dataset sizes. A benchmark of the wrong sizes is wasted time, so this is an error.
+	for _, field := range strings.Split(*benchSizesStr, ",") {
+		size, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || size < 1 {
+			return nil, fmt.Errorf("invalid bench-sizes '%s' (expected document counts, e.g. %s)", *benchSizesStr, defaultBenchSizes)
+		}
+		cfg.BenchSizes = append(cfg.BenchSizes, size)
+	}
+
+	// Fixtures mode freezes the clock; otherwise the wall clock is used
+	cfg.FixturesTime, err = time.Parse(time.RFC3339, *fixturesTimeStr)
+	if err != nil {
+		log.Printf("WARN: Invalid fixtures-time '%s' (expected RFC 3339, e.g. %s). Using default. Error: %v", *fixturesTimeStr, defaultFixturesTime, err)
+		cfg.FixturesTime, _ = time.Parse(time.RFC3339, defaultFixturesTime)
+	}
+	cfg.FixturesTime = cfg.FixturesTime.UTC()
+	if cfg.Fixtures {
+		cfg.Clock = NewFixedClock(cfg.FixturesTime)
+	} else {
+		cfg.Clock = SystemClock{}
+	}
 
 	// --- JWT Secret Handling ---
 	// Priority: File (CLI/Env) > Env Var > Default Key File > Generate
@@ -170,9 +726,11 @@ func LoadConfig() (*Config, error) {
 		}
 		cfg.JwtSecret = newSecret
 
-		// Attempt to save the generated key to the default file
-		err = os.WriteFile(defaultJwtKeyFile, []byte(newSecret), 0600) // Read/write for owner only
-		if err != nil {
+		// Attempt to save the generated key to the default file, unless nothing may be written
+		if cfg.Ephemeral {
+			log.Printf("INFO: Ephemeral mode: the generated JWT secret is used for this session only.")
+			secretSource = "Generated (ephemeral, not saved)"
+		} else if err = os.WriteFile(defaultJwtKeyFile, []byte(newSecret), 0600); err != nil { // Read/write for owner only
 			// Log a warning but continue - the server can still run with the generated key in memory
 			log.Printf("WARN: Failed to save generated JWT secret to '%s': %v. The server will use the generated key for this session only.", defaultJwtKeyFile, err)
 		} else {
@@ -204,6 +762,29 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("database path '%s' points to a directory, not a file", cfg.DbFilePath)
 	}
 	// We don't return os.IsNotExist(err) as an error here, because the DB might be created on first run.
+
+	if cfg.CoursesDir != "" {
+		absCoursesDir, err := filepath.Abs(cfg.CoursesDir)
+		if err != nil {
+			return nil, fmt.Errorf("could not determine absolute path for courses-dir '%s': %w", cfg.CoursesDir, err)
+		}
+		cfg.CoursesDir = absCoursesDir
+		if cfg.JwtAudience == "" {
+			// Course tokens carry the course as audience; checking one here keeps them out of the main database
+			log.Printf("INFO: Hosting courses: tokens of the main database get the audience %q.", defaultCoursesJwtAudience)
+			cfg.JwtAudience = defaultCoursesJwtAudience
+		}
+	}
+	if cfg.DataDir != "" {
+		absDataDir, err := filepath.Abs(cfg.DataDir)
+		if err != nil {
+			return nil, fmt.Errorf("could not determine absolute path for data-dir '%s': %w", cfg.DataDir, err)
+		}
+		cfg.DataDir = absDataDir
+		if fileInfo, err := os.Stat(cfg.DataDir); err == nil && !fileInfo.IsDir() {
+			return nil, fmt.Errorf("data directory '%s' is a file, not a directory", cfg.DataDir)
+		}
+	}
 	// Further validation (permissions, etc.) will happen in db.NewDatabase.
 
 	// (Moved path resolution and validation earlier, before logging)
@@ -222,6 +803,18 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+// getEnvInt64 retrieves an integer environment variable or returns a default value.
+func getEnvInt64(key string, fallback int64) int64 {
+	if value, exists := os.LookupEnv(key); exists {
+		parsed, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err == nil {
+			return parsed
+		}
+		log.Printf("WARN: Invalid integer value for environment variable %s: '%s'. Using default: %d", key, value, fallback)
+	}
+	return fallback
+}
+
 // getEnvBool retrieves a boolean environment variable or returns a default value.
 // Recognizes "true", "1", "yes" (case-insensitive) as true.
 func getEnvBool(key string, fallback bool) bool {
@@ -243,12 +836,126 @@ func logConfiguration(cfg *Config, secretSource string) {
 	log.Println("--- Configuration ---")
 	log.Printf("Server Address: %s", cfg.ListenAddress)
 	log.Printf("Server Port: %s", cfg.ListenPort)
-	log.Printf("Database File: %s", cfg.DbFilePath)
+	log.Printf("Gin Mode: %s", cfg.GinMode)
+	if len(cfg.TrustedProxies) > 0 {
+		log.Printf("Trusted Proxies: %s (client IP from %s)", strings.Join(cfg.TrustedProxies, ", "), strings.Join(cfg.ClientIPHeaders, ", "))
+	} else {
+		log.Printf("Trusted Proxies: none")
+	}
+	if cfg.CoursesDir != "" {
+		log.Printf("Courses Directory: %s", cfg.CoursesDir)
+	}
+	if cfg.DataDir != "" {
+		log.Printf("Database Directory: %s", cfg.DataDir)
+	} else {
+		log.Printf("Database File: %s", cfg.DbFilePath)
+	}
 	log.Printf("Database Save Interval: %s", cfg.SaveInterval)
+	if cfg.CoalesceWindow > 0 {
+		log.Printf("Update Coalescing Window: %s", cfg.CoalesceWindow)
+	}
 	log.Printf("Database Backup Enabled: %t", cfg.EnableBackup)
+	log.Printf("Content Deduplication Enabled: %t", cfg.DedupeContent)
+	log.Printf("Database Fsync Enabled: %t", cfg.Fsync)
+	log.Printf("Database Checksum Enabled: %t", cfg.Checksum)
+	log.Printf("Database Auto Recovery Enabled: %t", cfg.AutoRecover)
+	if cfg.Ephemeral {
+		log.Printf("Ephemeral Mode: %t (nothing is loaded from or saved to disk; all data is lost on shutdown)", cfg.Ephemeral)
+	}
+	if cfg.ReadOnly {
+		log.Printf("Read-Only Mode: %t (changes are refused and the database is never saved)", cfg.ReadOnly)
+	}
+	if cfg.MigrateDryRun {
+		log.Printf("Migration Dry Run: %t", cfg.MigrateDryRun)
+	}
+	if cfg.TransformsFile != "" {
+		log.Printf("Content Transforms File: %s", cfg.TransformsFile)
+	}
+	if cfg.RecordFile != "" {
+		log.Printf("Recording Requests To: %s", cfg.RecordFile)
+	}
+	log.Printf("Script Timeout: %s", cfg.ScriptTimeout)
+	log.Printf("ID Strategy: %s", cfg.IDStrategy)
+	log.Printf("Administrators: %d", len(cfg.AdminEmails))
+	log.Printf("Invite-Only Signup: %t", cfg.InviteOnly)
+	if len(cfg.IntrospectClients) > 0 {
+		log.Printf("Token Introspection Clients: %d", len(cfg.IntrospectClients))
+	}
+	log.Printf("Public Guest Access Enabled: %t", cfg.EnablePublicAccess)
+	log.Printf("Max Archive Size: %d bytes", cfg.ArchiveMaxBytes)
+	if cfg.MaxBodyBytes > 0 {
+		log.Printf("Max Request Body: %d bytes (strict JSON: %t)", cfg.MaxBodyBytes, cfg.StrictJSON)
+	} else {
+		log.Printf("Max Request Body: unlimited (strict JSON: %t)", cfg.StrictJSON)
+	}
+	if len(cfg.FetchAllowedDomains) > 0 {
+		log.Printf("Fetch Allowed Domains: %s (max %d bytes, timeout %s)", strings.Join(cfg.FetchAllowedDomains, ", "), cfg.FetchMaxBytes, cfg.FetchTimeout)
+	}
+	if len(cfg.WebhookAllowedDomains) > 0 {
+		log.Printf("Webhook Allowed Domains: %s (notification channels: at most %d messages per hour)", strings.Join(cfg.WebhookAllowedDomains, ", "), cfg.NotificationMaxPerHour)
+	}
+	if !cfg.LegacySunset.IsZero() {
+		log.Printf("Legacy API Sunset: %s", cfg.LegacySunset.Format("2006-01-02"))
+	}
+	log.Printf("Erasure Grace Period: %s", cfg.ErasureGracePeriod)
+	log.Printf("Deactivation Grace Period: %s", cfg.DeactivationGracePeriod)
+	log.Printf("Expiry Grace Period: %s", cfg.ExpiryGracePeriod)
+	if cfg.TosVersion != "" {
+		log.Printf("Terms of Service Version: %s (required: %t)", cfg.TosVersion, cfg.RequireTos)
+	}
 	log.Printf("JWT Secret Source: %s", determineJwtSecretSource(cfg, secretSource)) // Pass hint
 	log.Printf("JWT Token Lifetime: %s", cfg.TokenLifetime)
-	log.Printf("Bcrypt Cost: %d", cfg.BcryptCost)
+	log.Printf("JWT Issuer: %s (leeway %s)", cfg.JwtIssuer, cfg.JwtLeeway)
+	if cfg.JwtAudience != "" {
+		log.Printf("JWT Audience: %s", cfg.JwtAudience)
+	}
+	if cfg.PasswordHash == PasswordHashArgon2id {
+		log.Printf("Password Hashing: argon2id (%d passes, %d KiB, %d threads)", cfg.Argon2Time, cfg.Argon2MemoryKiB, cfg.Argon2Threads)
+	} else {
+		log.Printf("Password Hashing: bcrypt (cost %d)", cfg.BcryptCost)
+	}
+	if cfg.PasswordHashTarget > 0 {
+		log.Printf("Password Hash Target: %s (calibrated at startup)", cfg.PasswordHashTarget)
+	}
+	log.Printf("OTP: %d characters, valid %s, %d attempts, delivered by %s", cfg.OTPLength, cfg.OTPLifetime, cfg.OTPMaxAttempts, cfg.OTPDelivery)
+	log.Printf("OTP Backoff: %s, up to %s", cfg.OTPBackoff, cfg.OTPBackoffMax)
+	if cfg.SMSGatewayURL != "" {
+		log.Printf("SMS Gateway: %s", cfg.SMSGatewayURL)
+	}
+	switch cfg.ChallengeProvider {
+	case "":
+		log.Printf("Signup Challenge: none")
+	case ChallengeProviderPoW:
+		log.Printf("Signup Challenge: %s (%d bits)", cfg.ChallengeProvider, cfg.ChallengePoWDifficulty)
+	default:
+		log.Printf("Signup Challenge: %s", cfg.ChallengeProvider)
+	}
+	if cfg.StatusRateLimit > 0 {
+		log.Printf("Status Rate Limit: %d requests per minute", cfg.StatusRateLimit)
+	} else {
+		log.Printf("Status Rate Limit: none")
+	}
+	if cfg.SlowQueryThreshold > 0 {
+		log.Printf("Slow Query Threshold: %s", cfg.SlowQueryThreshold)
+	} else {
+		log.Printf("Slow Query Log: off")
+	}
+	if cfg.LoadGeneration {
+		log.Printf("WARN: Load generation is on: administrators may generate up to %d documents and %d users at a time", cfg.LoadGenerationMaxDocs, cfg.LoadGenerationMaxUsers)
+	}
+	if cfg.QueryCacheSize > 0 {
+		log.Printf("Query Cache: %d results", cfg.QueryCacheSize)
+	} else {
+		log.Printf("Query Cache: off")
+	}
+	if cfg.OTLPEndpoint != "" {
+		log.Printf("Tracing: exporting spans as '%s' to %s", cfg.OTelServiceName, cfg.OTLPEndpoint)
+	} else {
+		log.Printf("Tracing: off")
+	}
+	if cfg.Fixtures {
+		log.Printf("WARN: Fixtures mode: clock frozen at %s, IDs seeded with %d. Not for production use.", cfg.FixturesTime.Format(time.RFC3339), cfg.FixturesSeed)
+	}
 	log.Println("---------------------")
 }
 
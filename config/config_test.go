@@ -512,4 +512,1500 @@ func TestHandleConfigError(t *testing.T) {
 	assert.NotPanics(t, func() {
 		handleConfigError("testField", "badValue", assert.AnError, "defaultValue")
 	}, "handleConfigError should not panic")
-}
\ No newline at end of file
+}
+// --- Migration Dry Run Option ---
+
+func TestLoadConfig_MigrateDryRun(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-migrate-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+
+	t.Run("Default is disabled", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		os.Unsetenv("DOCSERVER_MIGRATE_DRY_RUN")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.False(t, cfg.MigrateDryRun)
+	})
+
+	t.Run("Enabled via flag", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--migrate-dry-run")
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.True(t, cfg.MigrateDryRun)
+	})
+
+	t.Run("Enabled via env", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		t.Setenv("DOCSERVER_MIGRATE_DRY_RUN", "true")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.True(t, cfg.MigrateDryRun)
+	})
+}
+
+// --- Legacy Sunset Option ---
+
+func TestLoadConfig_LegacySunset(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-sunset-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+
+	t.Run("Default is unset", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		os.Unsetenv("DOCSERVER_LEGACY_SUNSET")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.True(t, cfg.LegacySunset.IsZero())
+	})
+
+	t.Run("Set via flag", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--legacy-sunset", "2030-01-31")
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2030, 1, 31, 0, 0, 0, 0, time.UTC), cfg.LegacySunset)
+	})
+
+	t.Run("Invalid date is ignored", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		t.Setenv("DOCSERVER_LEGACY_SUNSET", "next-year")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.True(t, cfg.LegacySunset.IsZero())
+	})
+}
+
+func TestLoadConfig_ErasureGracePeriod(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-erasure-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+
+	t.Run("Default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		os.Unsetenv("DOCSERVER_ERASURE_GRACE_PERIOD")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, defaultErasureGracePeriod, cfg.ErasureGracePeriod)
+	})
+
+	t.Run("Set via env", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		t.Setenv("DOCSERVER_ERASURE_GRACE_PERIOD", "0s")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, time.Duration(0), cfg.ErasureGracePeriod)
+	})
+
+	t.Run("Flag overrides env", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--erasure-grace-period", "48h")
+		defer cleanup()
+		t.Setenv("DOCSERVER_ERASURE_GRACE_PERIOD", "1h")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, 48*time.Hour, cfg.ErasureGracePeriod)
+	})
+
+	t.Run("Negative falls back to default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--erasure-grace-period", "-1h")
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, defaultErasureGracePeriod, cfg.ErasureGracePeriod)
+	})
+}
+
+func TestLoadConfig_TermsOfService(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-tos-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+
+	t.Run("Default has no terms", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		os.Unsetenv("DOCSERVER_TOS_VERSION")
+		os.Unsetenv("DOCSERVER_REQUIRE_TOS")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Empty(t, cfg.TosVersion)
+		assert.False(t, cfg.RequireTos)
+	})
+
+	t.Run("Set via env", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		t.Setenv("DOCSERVER_TOS_VERSION", "2024-01")
+		t.Setenv("DOCSERVER_TOS_URL", "https://example.com/tos")
+		t.Setenv("DOCSERVER_REQUIRE_TOS", "true")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, "2024-01", cfg.TosVersion)
+		assert.Equal(t, "https://example.com/tos", cfg.TosURL)
+		assert.True(t, cfg.RequireTos)
+	})
+
+	t.Run("Flag overrides env", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--tos-version", "2025-06")
+		defer cleanup()
+		t.Setenv("DOCSERVER_TOS_VERSION", "2024-01")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, "2025-06", cfg.TosVersion)
+	})
+}
+
+func TestLoadConfig_EnablePublicAccess(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-public-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+
+	t.Run("Disabled by default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		os.Unsetenv("DOCSERVER_ENABLE_PUBLIC_ACCESS")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.False(t, cfg.EnablePublicAccess)
+	})
+
+	t.Run("Enabled via env", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		t.Setenv("DOCSERVER_ENABLE_PUBLIC_ACCESS", "true")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.True(t, cfg.EnablePublicAccess)
+	})
+
+	t.Run("Flag overrides env", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--enable-public-access=false")
+		defer cleanup()
+		t.Setenv("DOCSERVER_ENABLE_PUBLIC_ACCESS", "true")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.False(t, cfg.EnablePublicAccess)
+	})
+}
+
+func TestLoadConfig_TransformsFile(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-transforms-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+
+	t.Run("None by default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		os.Unsetenv("DOCSERVER_TRANSFORMS_FILE")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Empty(t, cfg.TransformsFile)
+	})
+
+	t.Run("From env", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		t.Setenv("DOCSERVER_TRANSFORMS_FILE", "/etc/docserver/transforms.json")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, "/etc/docserver/transforms.json", cfg.TransformsFile)
+	})
+
+	t.Run("Flag overrides env", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--transforms-file=./rules.json")
+		defer cleanup()
+		t.Setenv("DOCSERVER_TRANSFORMS_FILE", "/etc/docserver/transforms.json")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, "./rules.json", cfg.TransformsFile)
+	})
+}
+
+func TestLoadConfig_ScriptTimeout(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-script-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+
+	t.Run("Default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		os.Unsetenv("DOCSERVER_SCRIPT_TIMEOUT")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, defaultScriptTimeout, cfg.ScriptTimeout)
+	})
+
+	t.Run("From env", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		t.Setenv("DOCSERVER_SCRIPT_TIMEOUT", "250ms")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, 250*time.Millisecond, cfg.ScriptTimeout)
+	})
+
+	t.Run("Invalid or non-positive falls back to default", func(t *testing.T) {
+		for _, value := range []string{"fast", "0s", "-1s"} {
+			cleanup := resetFlagsAndArgs("--script-timeout=" + value)
+			cfg, err := LoadConfig()
+			cleanup()
+			require.NoError(t, err)
+			assert.Equal(t, defaultScriptTimeout, cfg.ScriptTimeout, value)
+		}
+	})
+}
+
+func TestLoadConfig_AdminEmails(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-admin-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+
+	t.Run("None by default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		os.Unsetenv("DOCSERVER_ADMIN_EMAILS")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Empty(t, cfg.AdminEmails)
+	})
+
+	t.Run("Comma-separated, trimmed and lowercased", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		t.Setenv("DOCSERVER_ADMIN_EMAILS", " Teacher@School.edu, ,ta@school.edu ")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"teacher@school.edu", "ta@school.edu"}, cfg.AdminEmails)
+	})
+
+	t.Run("Flag overrides env", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--admin-emails=root@example.com")
+		defer cleanup()
+		t.Setenv("DOCSERVER_ADMIN_EMAILS", "teacher@school.edu")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"root@example.com"}, cfg.AdminEmails)
+	})
+}
+
+func TestLoadConfig_Fetch(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-fetch-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+
+	t.Run("Disabled by default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		os.Unsetenv("DOCSERVER_FETCH_ALLOWED_DOMAINS")
+		os.Unsetenv("DOCSERVER_FETCH_MAX_BYTES")
+		os.Unsetenv("DOCSERVER_FETCH_TIMEOUT")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Empty(t, cfg.FetchAllowedDomains)
+		assert.Equal(t, int64(defaultFetchMaxBytes), cfg.FetchMaxBytes)
+		assert.Equal(t, defaultFetchTimeout, cfg.FetchTimeout)
+	})
+
+	t.Run("From env", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		t.Setenv("DOCSERVER_FETCH_ALLOWED_DOMAINS", " API.Example.com, ,data.gov ")
+		t.Setenv("DOCSERVER_FETCH_MAX_BYTES", "2048")
+		t.Setenv("DOCSERVER_FETCH_TIMEOUT", "3s")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"api.example.com", "data.gov"}, cfg.FetchAllowedDomains)
+		assert.Equal(t, int64(2048), cfg.FetchMaxBytes)
+		assert.Equal(t, 3*time.Second, cfg.FetchTimeout)
+	})
+
+	t.Run("Invalid limits fall back to defaults", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--fetch-max-bytes=0", "--fetch-timeout=soon")
+		defer cleanup()
+		t.Setenv("DOCSERVER_FETCH_MAX_BYTES", "lots")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, int64(defaultFetchMaxBytes), cfg.FetchMaxBytes)
+		assert.Equal(t, defaultFetchTimeout, cfg.FetchTimeout)
+	})
+}
+
+func TestLoadConfig_WebhookAllowedDomains(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-webhook-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+
+	t.Run("None by default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		os.Unsetenv("DOCSERVER_WEBHOOK_ALLOWED_DOMAINS")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Empty(t, cfg.WebhookAllowedDomains)
+	})
+
+	t.Run("From flag", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--webhook-allowed-domains=Hooks.Example.com,,ci.dev ")
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"hooks.example.com", "ci.dev"}, cfg.WebhookAllowedDomains)
+	})
+}
+
+func TestLoadConfig_NotificationMaxPerHour(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-notification-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+	os.Unsetenv("DOCSERVER_NOTIFICATION_MAX_PER_HOUR")
+
+	t.Run("Default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, defaultNotificationMaxPerHour, cfg.NotificationMaxPerHour)
+	})
+
+	t.Run("Set via env", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		t.Setenv("DOCSERVER_NOTIFICATION_MAX_PER_HOUR", "5")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, 5, cfg.NotificationMaxPerHour)
+	})
+
+	t.Run("Zero falls back to default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--notification-max-per-hour=0")
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, defaultNotificationMaxPerHour, cfg.NotificationMaxPerHour)
+	})
+}
+
+func TestLoadConfig_DeactivationGracePeriod(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-deactivation-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+
+	t.Run("Default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		os.Unsetenv("DOCSERVER_DEACTIVATION_GRACE_PERIOD")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, defaultDeactivationGracePeriod, cfg.DeactivationGracePeriod)
+	})
+
+	t.Run("Set via env", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		t.Setenv("DOCSERVER_DEACTIVATION_GRACE_PERIOD", "72h")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, 72*time.Hour, cfg.DeactivationGracePeriod)
+	})
+
+	t.Run("Invalid or non-positive falls back to default", func(t *testing.T) {
+		for _, value := range []string{"forever", "0s", "-1h"} {
+			cleanup := resetFlagsAndArgs("--deactivation-grace-period=" + value)
+			cfg, err := LoadConfig()
+			cleanup()
+			require.NoError(t, err)
+			assert.Equal(t, defaultDeactivationGracePeriod, cfg.DeactivationGracePeriod, value)
+		}
+	})
+}
+
+func TestLoadConfig_OTP(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-otp-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+	for _, key := range []string{"DOCSERVER_OTP_LENGTH", "DOCSERVER_OTP_CHARSET", "DOCSERVER_OTP_TTL", "DOCSERVER_OTP_MAX_ATTEMPTS", "DOCSERVER_OTP_DELIVERY", "DOCSERVER_SMS_GATEWAY_URL"} {
+		os.Unsetenv(key)
+	}
+
+	t.Run("Defaults", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, defaultOTPLength, cfg.OTPLength)
+		assert.Equal(t, defaultOTPCharset, cfg.OTPCharset)
+		assert.Equal(t, defaultOTPLifetime, cfg.OTPLifetime)
+		assert.Equal(t, defaultOTPMaxAttempts, cfg.OTPMaxAttempts)
+		assert.Equal(t, OTPDeliveryEmail, cfg.OTPDelivery)
+		assert.Empty(t, cfg.SMSGatewayURL)
+	})
+
+	t.Run("Set via env", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		t.Setenv("DOCSERVER_OTP_LENGTH", "8")
+		t.Setenv("DOCSERVER_OTP_CHARSET", "ABCDEFGHJKLMNPQRSTUVWXYZ23456789")
+		t.Setenv("DOCSERVER_OTP_TTL", "15m")
+		t.Setenv("DOCSERVER_OTP_MAX_ATTEMPTS", "3")
+		t.Setenv("DOCSERVER_OTP_DELIVERY", "SMS")
+		t.Setenv("DOCSERVER_SMS_GATEWAY_URL", "https://sms.example.com/send")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, 8, cfg.OTPLength)
+		assert.Equal(t, "ABCDEFGHJKLMNPQRSTUVWXYZ23456789", cfg.OTPCharset)
+		assert.Equal(t, 15*time.Minute, cfg.OTPLifetime)
+		assert.Equal(t, 3, cfg.OTPMaxAttempts)
+		assert.Equal(t, OTPDeliverySMS, cfg.OTPDelivery)
+		assert.Equal(t, "https://sms.example.com/send", cfg.SMSGatewayURL)
+	})
+
+	t.Run("Invalid values fall back to defaults", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--otp-length=2", "--otp-charset=x", "--otp-ttl=0s", "--otp-max-attempts=0", "--otp-delivery=pigeon")
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, defaultOTPLength, cfg.OTPLength)
+		assert.Equal(t, defaultOTPCharset, cfg.OTPCharset)
+		assert.Equal(t, defaultOTPLifetime, cfg.OTPLifetime)
+		assert.Equal(t, defaultOTPMaxAttempts, cfg.OTPMaxAttempts)
+		assert.Equal(t, OTPDeliveryEmail, cfg.OTPDelivery)
+	})
+}
+
+func TestLoadConfig_OTPBackoff(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-otp-backoff-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+	os.Unsetenv("DOCSERVER_OTP_BACKOFF")
+	os.Unsetenv("DOCSERVER_OTP_BACKOFF_MAX")
+
+	t.Run("Defaults", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, defaultOTPBackoff, cfg.OTPBackoff)
+		assert.Equal(t, defaultOTPBackoffMax, cfg.OTPBackoffMax)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--otp-backoff=0s")
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Zero(t, cfg.OTPBackoff)
+	})
+
+	t.Run("Invalid values fall back to defaults", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--otp-backoff=-1s", "--otp-backoff-max=later")
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, defaultOTPBackoff, cfg.OTPBackoff)
+		assert.Equal(t, defaultOTPBackoffMax, cfg.OTPBackoffMax)
+	})
+
+	t.Run("Maximum below the base is raised", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--otp-backoff=1h", "--otp-backoff-max=1m")
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, time.Hour, cfg.OTPBackoffMax)
+	})
+}
+
+func TestLoadConfig_InviteOnly(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-invite-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+	os.Unsetenv("DOCSERVER_INVITE_ONLY")
+
+	t.Run("Open by default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.False(t, cfg.InviteOnly)
+	})
+
+	t.Run("Set via env", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		t.Setenv("DOCSERVER_INVITE_ONLY", "true")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.True(t, cfg.InviteOnly)
+	})
+
+	t.Run("Set via flag", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--invite-only")
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.True(t, cfg.InviteOnly)
+	})
+}
+
+func TestLoadConfig_Challenge(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-challenge-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+	for _, key := range []string{"DOCSERVER_CHALLENGE_PROVIDER", "DOCSERVER_CHALLENGE_SITE_KEY", "DOCSERVER_CHALLENGE_SECRET", "DOCSERVER_CHALLENGE_POW_DIFFICULTY"} {
+		os.Unsetenv(key)
+	}
+
+	t.Run("None by default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Empty(t, cfg.ChallengeProvider)
+		assert.Equal(t, defaultChallengePoWDifficulty, cfg.ChallengePoWDifficulty)
+	})
+
+	t.Run("Set via env", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		t.Setenv("DOCSERVER_CHALLENGE_PROVIDER", "Turnstile")
+		t.Setenv("DOCSERVER_CHALLENGE_SITE_KEY", "site-key")
+		t.Setenv("DOCSERVER_CHALLENGE_SECRET", "secret-key")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, ChallengeProviderTurnstile, cfg.ChallengeProvider)
+		assert.Equal(t, "site-key", cfg.ChallengeSiteKey)
+		assert.Equal(t, "secret-key", cfg.ChallengeSecret)
+	})
+
+	t.Run("Proof of work", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--challenge-provider=pow", "--challenge-pow-difficulty=16")
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, ChallengeProviderPoW, cfg.ChallengeProvider)
+		assert.Equal(t, 16, cfg.ChallengePoWDifficulty)
+	})
+
+	t.Run("Provider without a secret is disabled", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--challenge-provider=hcaptcha", "--challenge-pow-difficulty=64")
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Empty(t, cfg.ChallengeProvider)
+		assert.Equal(t, defaultChallengePoWDifficulty, cfg.ChallengePoWDifficulty)
+	})
+
+	t.Run("Unknown provider is disabled", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--challenge-provider=recaptcha")
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Empty(t, cfg.ChallengeProvider)
+	})
+}
+
+func TestLoadConfig_StatusRateLimit(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-status-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+	os.Unsetenv("DOCSERVER_STATUS_RATE_LIMIT")
+
+	t.Run("Default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, defaultStatusRateLimit, cfg.StatusRateLimit)
+	})
+
+	t.Run("Disabled via env", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		t.Setenv("DOCSERVER_STATUS_RATE_LIMIT", "0")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, 0, cfg.StatusRateLimit)
+	})
+
+	t.Run("Negative falls back to default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--status-rate-limit=-5")
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, defaultStatusRateLimit, cfg.StatusRateLimit)
+	})
+}
+
+func TestLoadConfig_QueryCacheSize(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-query-cache-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+	os.Unsetenv("DOCSERVER_QUERY_CACHE_SIZE")
+
+	t.Run("Default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, defaultQueryCacheSize, cfg.QueryCacheSize)
+	})
+
+	t.Run("Disabled via env", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		t.Setenv("DOCSERVER_QUERY_CACHE_SIZE", "0")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Zero(t, cfg.QueryCacheSize)
+	})
+
+	t.Run("Negative falls back to default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--query-cache-size=-1")
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, defaultQueryCacheSize, cfg.QueryCacheSize)
+	})
+}
+
+func TestLoadConfig_LoadGeneration(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-load-generation-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+	for _, key := range []string{"DOCSERVER_ALLOW_LOAD_GENERATION", "DOCSERVER_LOAD_GENERATION_MAX_DOCS", "DOCSERVER_LOAD_GENERATION_MAX_USERS"} {
+		os.Unsetenv(key)
+	}
+
+	t.Run("Off by default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.False(t, cfg.LoadGeneration)
+		assert.Equal(t, defaultLoadGenerationMaxDocs, cfg.LoadGenerationMaxDocs)
+		assert.Equal(t, defaultLoadGenerationMaxUsers, cfg.LoadGenerationMaxUsers)
+	})
+
+	t.Run("Enabled with limits", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("-allow-load-generation", "-load-generation-max-docs=5000")
+		defer cleanup()
+		t.Setenv("DOCSERVER_LOAD_GENERATION_MAX_USERS", "50")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.True(t, cfg.LoadGeneration)
+		assert.Equal(t, 5000, cfg.LoadGenerationMaxDocs)
+		assert.Equal(t, 50, cfg.LoadGenerationMaxUsers)
+	})
+
+	t.Run("Zero limits fall back to the defaults", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("-load-generation-max-docs=0", "-load-generation-max-users=0")
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, defaultLoadGenerationMaxDocs, cfg.LoadGenerationMaxDocs)
+		assert.Equal(t, defaultLoadGenerationMaxUsers, cfg.LoadGenerationMaxUsers)
+	})
+}
+
+func TestLoadConfig_Bench(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-bench-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+	os.Unsetenv("DOCSERVER_BENCH")
+	os.Unsetenv("DOCSERVER_BENCH_SIZES")
+
+	t.Run("Default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.False(t, cfg.Bench)
+		assert.Equal(t, []int{10000, 100000}, cfg.BenchSizes)
+	})
+
+	t.Run("Bench command with flags after it", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("-checksum=false", "bench", "-bench-sizes=500, 2000")
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.True(t, cfg.Bench)
+		assert.False(t, cfg.Checksum)
+		assert.Equal(t, []int{500, 2000}, cfg.BenchSizes)
+	})
+
+	t.Run("Invalid sizes", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("-bench-sizes=10k")
+		defer cleanup()
+
+		_, err := LoadConfig()
+		assert.Error(t, err)
+	})
+}
+
+func TestLoadConfig_Tracing(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-tracing-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+	for _, key := range []string{"DOCSERVER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_ENDPOINT", "DOCSERVER_OTLP_HEADERS", "OTEL_EXPORTER_OTLP_HEADERS", "OTEL_SERVICE_NAME"} {
+		os.Unsetenv(key)
+	}
+
+	t.Run("Off by default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Empty(t, cfg.OTLPEndpoint)
+		assert.Equal(t, defaultOTelServiceName, cfg.OTelServiceName)
+	})
+
+	t.Run("Standard OpenTelemetry env vars", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318")
+		t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "api-key=secret")
+		t.Setenv("OTEL_SERVICE_NAME", "docserver-lab")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, "http://collector:4318", cfg.OTLPEndpoint)
+		assert.Equal(t, "api-key=secret", cfg.OTLPHeaders)
+		assert.Equal(t, "docserver-lab", cfg.OTelServiceName)
+	})
+
+	t.Run("Flag wins and invalid endpoints disable tracing", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--otlp-endpoint=collector:4318")
+		defer cleanup()
+		t.Setenv("DOCSERVER_OTLP_ENDPOINT", "http://ignored:4318")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Empty(t, cfg.OTLPEndpoint)
+	})
+}
+
+func TestLoadConfig_SlowQueryThreshold(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-slow-query-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+	os.Unsetenv("DOCSERVER_SLOW_QUERY_THRESHOLD")
+
+	t.Run("Default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, defaultSlowQueryThreshold, cfg.SlowQueryThreshold)
+	})
+
+	t.Run("Set via env", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		t.Setenv("DOCSERVER_SLOW_QUERY_THRESHOLD", "0")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Zero(t, cfg.SlowQueryThreshold)
+	})
+
+	t.Run("Invalid falls back to default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--slow-query-threshold=-1s")
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, defaultSlowQueryThreshold, cfg.SlowQueryThreshold)
+	})
+}
+
+func TestLoadConfig_ProxySettings(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-proxy-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+	os.Unsetenv("DOCSERVER_GIN_MODE")
+	os.Unsetenv("GIN_MODE")
+	os.Unsetenv("DOCSERVER_TRUSTED_PROXIES")
+	os.Unsetenv("DOCSERVER_CLIENT_IP_HEADERS")
+
+	t.Run("Defaults", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, GinModeDebug, cfg.GinMode)
+		assert.Empty(t, cfg.TrustedProxies)
+		assert.Equal(t, []string{"X-Forwarded-For", "X-Real-Ip"}, cfg.ClientIPHeaders)
+	})
+
+	t.Run("Set via env", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		t.Setenv("GIN_MODE", "Release")
+		t.Setenv("DOCSERVER_TRUSTED_PROXIES", "10.0.0.0/8, 192.168.1.5 ,,::1")
+		t.Setenv("DOCSERVER_CLIENT_IP_HEADERS", "cf-connecting-ip")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, GinModeRelease, cfg.GinMode)
+		assert.Equal(t, []string{"10.0.0.0/8", "192.168.1.5", "::1"}, cfg.TrustedProxies)
+		assert.Equal(t, []string{"Cf-Connecting-Ip"}, cfg.ClientIPHeaders)
+	})
+
+	t.Run("Invalid values are dropped", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--gin-mode=loud", "--trusted-proxies=10.0.0.0/33,proxy.local,172.16.0.1", "--client-ip-headers=")
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, defaultGinMode, cfg.GinMode)
+		assert.Equal(t, []string{"172.16.0.1"}, cfg.TrustedProxies)
+		assert.Empty(t, cfg.ClientIPHeaders)
+	})
+}
+
+func TestLoadConfig_RequestBody(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-body-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+	os.Unsetenv("DOCSERVER_MAX_BODY_BYTES")
+	os.Unsetenv("DOCSERVER_STRICT_JSON")
+
+	t.Run("Defaults", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, int64(defaultMaxBodyBytes), cfg.MaxBodyBytes)
+		assert.False(t, cfg.StrictJSON)
+	})
+
+	t.Run("Set via env", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		t.Setenv("DOCSERVER_MAX_BODY_BYTES", "0")
+		t.Setenv("DOCSERVER_STRICT_JSON", "true")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Zero(t, cfg.MaxBodyBytes)
+		assert.True(t, cfg.StrictJSON)
+	})
+
+	t.Run("Negative limit falls back to default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--max-body-bytes=-5")
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, int64(defaultMaxBodyBytes), cfg.MaxBodyBytes)
+	})
+}
+
+func TestLoadConfig_DedupeContent(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-dedupe-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+	os.Unsetenv("DOCSERVER_DEDUPE_CONTENT")
+
+	t.Run("Off by default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.False(t, cfg.DedupeContent)
+	})
+
+	t.Run("Set via flag", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--dedupe-content")
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.True(t, cfg.DedupeContent)
+	})
+}
+
+func TestLoadConfig_ArchiveMaxBytes(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-archive-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+	os.Unsetenv("DOCSERVER_ARCHIVE_MAX_BYTES")
+
+	t.Run("Default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, int64(defaultArchiveMaxBytes), cfg.ArchiveMaxBytes)
+	})
+
+	t.Run("Set via env", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		t.Setenv("DOCSERVER_ARCHIVE_MAX_BYTES", "1024")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, int64(1024), cfg.ArchiveMaxBytes)
+	})
+
+	t.Run("Zero falls back to default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--archive-max-bytes=0")
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, int64(defaultArchiveMaxBytes), cfg.ArchiveMaxBytes)
+	})
+}
+
+func TestLoadConfig_JWTValidation(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-jwt-validation-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+	os.Unsetenv("DOCSERVER_JWT_LEEWAY")
+	os.Unsetenv("DOCSERVER_JWT_ISSUER")
+	os.Unsetenv("DOCSERVER_JWT_AUDIENCE")
+	os.Unsetenv("DOCSERVER_INTROSPECT_CLIENTS")
+
+	t.Run("Defaults", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, defaultJwtLeeway, cfg.JwtLeeway)
+		assert.Equal(t, defaultJwtIssuer, cfg.JwtIssuer)
+		assert.Empty(t, cfg.JwtAudience)
+		assert.Empty(t, cfg.IntrospectClients)
+	})
+
+	t.Run("Introspection clients", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--introspect-clients=grader:s3cret, notes : other:secret ,broken,:nameless")
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"grader": "s3cret", "notes": "other:secret"}, cfg.IntrospectClients)
+	})
+
+	t.Run("Set via env", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		t.Setenv("DOCSERVER_JWT_LEEWAY", "2m")
+		t.Setenv("DOCSERVER_JWT_ISSUER", "https://docs.example.com")
+		t.Setenv("DOCSERVER_JWT_AUDIENCE", "docs-web")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, 2*time.Minute, cfg.JwtLeeway)
+		assert.Equal(t, "https://docs.example.com", cfg.JwtIssuer)
+		assert.Equal(t, "docs-web", cfg.JwtAudience)
+	})
+
+	t.Run("Invalid values fall back to defaults", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--jwt-leeway=-5s", "--jwt-issuer= ")
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, defaultJwtLeeway, cfg.JwtLeeway)
+		assert.Equal(t, defaultJwtIssuer, cfg.JwtIssuer)
+	})
+}
+
+func TestLoadConfig_IDStrategy(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-id-strategy-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+	os.Unsetenv("DOCSERVER_ID_STRATEGY")
+
+	t.Run("Default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, IDStrategyPrefixed, cfg.IDStrategy)
+	})
+
+	t.Run("Set via env", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		t.Setenv("DOCSERVER_ID_STRATEGY", "UUIDv7")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, IDStrategyUUIDv7, cfg.IDStrategy)
+	})
+
+	t.Run("Invalid value falls back to default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--id-strategy=snowflake")
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, IDStrategyPrefixed, cfg.IDStrategy)
+	})
+}
+
+func TestLoadConfig_PasswordHashing(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-password-hash-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+	for _, env := range []string{"DOCSERVER_PASSWORD_HASH", "DOCSERVER_BCRYPT_COST", "DOCSERVER_ARGON2_TIME", "DOCSERVER_ARGON2_MEMORY", "DOCSERVER_ARGON2_THREADS", "DOCSERVER_PASSWORD_HASH_TARGET"} {
+		os.Unsetenv(env)
+	}
+
+	t.Run("Defaults", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, PasswordHashBcrypt, cfg.PasswordHash)
+		assert.Equal(t, defaultBcryptCost, cfg.BcryptCost)
+		assert.Equal(t, uint32(defaultArgon2Time), cfg.Argon2Time)
+		assert.Equal(t, uint32(defaultArgon2MemoryKiB), cfg.Argon2MemoryKiB)
+		assert.Equal(t, uint8(defaultArgon2Threads), cfg.Argon2Threads)
+		assert.Zero(t, cfg.PasswordHashTarget)
+	})
+
+	t.Run("Set via env and flags", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--argon2-time=3", "--password-hash-target=250ms")
+		defer cleanup()
+		t.Setenv("DOCSERVER_PASSWORD_HASH", "Argon2id")
+		t.Setenv("DOCSERVER_BCRYPT_COST", "10")
+		t.Setenv("DOCSERVER_ARGON2_MEMORY", "65536")
+		t.Setenv("DOCSERVER_ARGON2_THREADS", "4")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, PasswordHashArgon2id, cfg.PasswordHash)
+		assert.Equal(t, 10, cfg.BcryptCost)
+		assert.Equal(t, uint32(3), cfg.Argon2Time)
+		assert.Equal(t, uint32(65536), cfg.Argon2MemoryKiB)
+		assert.Equal(t, uint8(4), cfg.Argon2Threads)
+		assert.Equal(t, 250*time.Millisecond, cfg.PasswordHashTarget)
+	})
+
+	t.Run("Invalid values fall back to defaults", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--password-hash=md5", "--bcrypt-cost=3", "--argon2-time=0", "--argon2-memory=4", "--argon2-threads=300", "--password-hash-target=-1s")
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, PasswordHashBcrypt, cfg.PasswordHash)
+		assert.Equal(t, defaultBcryptCost, cfg.BcryptCost)
+		assert.Equal(t, uint32(defaultArgon2Time), cfg.Argon2Time)
+		assert.Equal(t, uint32(defaultArgon2MemoryKiB), cfg.Argon2MemoryKiB)
+		assert.Equal(t, uint8(defaultArgon2Threads), cfg.Argon2Threads)
+		assert.Zero(t, cfg.PasswordHashTarget)
+	})
+}
+
+func TestLoadConfig_Fixtures(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-fixtures-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+	for _, env := range []string{"DOCSERVER_FIXTURES", "DOCSERVER_FIXTURES_TIME", "DOCSERVER_FIXTURES_SEED"} {
+		os.Unsetenv(env)
+	}
+	defaultTime := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Off by default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.False(t, cfg.Fixtures)
+		assert.Equal(t, SystemClock{}, cfg.Clock)
+		assert.Equal(t, defaultTime, cfg.FixturesTime)
+		assert.Equal(t, int64(defaultFixturesSeed), cfg.FixturesSeed)
+	})
+
+	t.Run("Set via env and flags", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--fixtures", "--fixtures-seed=42")
+		defer cleanup()
+		t.Setenv("DOCSERVER_FIXTURES_TIME", "2024-06-01T12:00:00+02:00")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.True(t, cfg.Fixtures)
+		assert.Equal(t, int64(42), cfg.FixturesSeed)
+		frozen := time.Date(2024, time.June, 1, 10, 0, 0, 0, time.UTC)
+		assert.Equal(t, frozen, cfg.FixturesTime)
+		assert.Equal(t, frozen, cfg.Now())
+		time.Sleep(2 * time.Millisecond)
+		assert.Equal(t, frozen, cfg.Now(), "the clock is frozen")
+	})
+
+	t.Run("Invalid time falls back to default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--fixtures", "--fixtures-time=yesterday")
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, defaultTime, cfg.Now())
+	})
+}
+
+func TestLoadConfig_RecordReplay(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-record-replay-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+	for _, env := range []string{"DOCSERVER_RECORD_FILE", "DOCSERVER_REPLAY_FILE", "DOCSERVER_REPLAY_UNTIL"} {
+		os.Unsetenv(env)
+	}
+
+	t.Run("Off by default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Empty(t, cfg.RecordFile)
+		assert.Empty(t, cfg.ReplayFile)
+		assert.True(t, cfg.ReplayUntil.IsZero())
+	})
+
+	t.Run("Set via env and flags", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--replay-file=journal.jsonl", "--replay-until=2025-03-01T09:30:00Z")
+		defer cleanup()
+		t.Setenv("DOCSERVER_RECORD_FILE", "requests.jsonl")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, "requests.jsonl", cfg.RecordFile)
+		assert.Equal(t, "journal.jsonl", cfg.ReplayFile)
+		assert.Equal(t, time.Date(2025, time.March, 1, 9, 30, 0, 0, time.UTC), cfg.ReplayUntil)
+	})
+
+	t.Run("Invalid replay-until is an error", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--replay-until=tuesday")
+		defer cleanup()
+
+		_, err := LoadConfig()
+		assert.ErrorContains(t, err, "invalid replay-until")
+	})
+}
+
+func TestLoadConfig_CoalesceWindow(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-coalesce-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+	os.Unsetenv("DOCSERVER_COALESCE_WINDOW")
+
+	t.Run("Off by default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Zero(t, cfg.CoalesceWindow)
+	})
+
+	t.Run("Set via env", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		t.Setenv("DOCSERVER_COALESCE_WINDOW", "2s")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, 2*time.Second, cfg.CoalesceWindow)
+	})
+
+	t.Run("Invalid falls back to off", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--coalesce-window=-1s")
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Zero(t, cfg.CoalesceWindow)
+	})
+}
+
+func TestLoadConfig_FsyncAndChecksum(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-fsync-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+	os.Unsetenv("DOCSERVER_FSYNC")
+	os.Unsetenv("DOCSERVER_CHECKSUM")
+	os.Unsetenv("DOCSERVER_AUTO_RECOVER")
+
+	t.Run("On by default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.True(t, cfg.Fsync)
+		assert.True(t, cfg.Checksum)
+		assert.False(t, cfg.AutoRecover)
+	})
+
+	t.Run("Auto recovery via env", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		t.Setenv("DOCSERVER_AUTO_RECOVER", "true")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.True(t, cfg.AutoRecover)
+	})
+
+	t.Run("Switched off via env and flags", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--checksum=false")
+		defer cleanup()
+		t.Setenv("DOCSERVER_FSYNC", "false")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.False(t, cfg.Fsync)
+		assert.False(t, cfg.Checksum)
+	})
+}
+
+func TestLoadConfig_DataDir(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-data-dir-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+	os.Unsetenv("DOCSERVER_DATA_DIR")
+
+	t.Run("Off by default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Empty(t, cfg.DataDir)
+	})
+
+	t.Run("Made absolute", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		t.Setenv("DOCSERVER_DATA_DIR", "relative-data")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, absPath("relative-data"), cfg.DataDir)
+	})
+
+	t.Run("Rejects a file", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "docs.json")
+		require.NoError(t, os.WriteFile(file, []byte("{}"), 0644))
+		cleanup := resetFlagsAndArgs("--data-dir", file)
+		defer cleanup()
+
+		_, err := LoadConfig()
+		assert.ErrorContains(t, err, "not a directory")
+	})
+}
+
+func TestLoadConfig_ReadOnly(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-read-only-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+	os.Unsetenv("DOCSERVER_READ_ONLY")
+
+	t.Run("Off by default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.False(t, cfg.ReadOnly)
+	})
+
+	t.Run("Via env", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		t.Setenv("DOCSERVER_READ_ONLY", "true")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.True(t, cfg.ReadOnly)
+	})
+
+	t.Run("Via flag", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--read-only")
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.True(t, cfg.ReadOnly)
+	})
+}
+
+func TestLoadConfig_Ephemeral(t *testing.T) {
+	os.Unsetenv("DOCSERVER_EPHEMERAL")
+	os.Unsetenv("DOCSERVER_JWT_SECRET")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+
+	t.Run("Off by default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		t.Setenv("DOCSERVER_JWT_SECRET", "test-ephemeral-secret")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.False(t, cfg.Ephemeral)
+	})
+
+	t.Run("Does not save a generated JWT secret", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--ephemeral")
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.True(t, cfg.Ephemeral)
+		assert.NotEmpty(t, cfg.JwtSecret)
+		assert.NoFileExists(t, defaultJwtKeyFile)
+	})
+}
+
+func TestLoadConfig_CoursesDir(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-courses-dir-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+	os.Unsetenv("DOCSERVER_COURSES_DIR")
+	os.Unsetenv("DOCSERVER_JWT_AUDIENCE")
+
+	t.Run("Off by default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Empty(t, cfg.CoursesDir)
+		assert.Empty(t, cfg.JwtAudience)
+	})
+
+	t.Run("Gives the main database's tokens an audience", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--courses-dir", "relative-courses")
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, absPath("relative-courses"), cfg.CoursesDir)
+		assert.Equal(t, "docserver", cfg.JwtAudience)
+	})
+
+	t.Run("Keeps a configured audience", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs("--courses-dir", "relative-courses", "--jwt-audience", "main")
+		defer cleanup()
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, "main", cfg.JwtAudience)
+	})
+}
+
+func TestLoadConfig_ExpiryGracePeriod(t *testing.T) {
+	t.Setenv("DOCSERVER_JWT_SECRET", "test-expiry-secret")
+	_ = os.Remove(defaultJwtKeyFile)
+	t.Cleanup(func() { _ = os.Remove(defaultJwtKeyFile) })
+
+	t.Run("Default", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		os.Unsetenv("DOCSERVER_EXPIRY_GRACE_PERIOD")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, defaultExpiryGracePeriod, cfg.ExpiryGracePeriod)
+	})
+
+	t.Run("Zero purges right away", func(t *testing.T) {
+		cleanup := resetFlagsAndArgs()
+		defer cleanup()
+		t.Setenv("DOCSERVER_EXPIRY_GRACE_PERIOD", "0s")
+
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Zero(t, cfg.ExpiryGracePeriod)
+	})
+
+	t.Run("Invalid or negative falls back to default", func(t *testing.T) {
+		for _, value := range []string{"forever", "-1h"} {
+			cleanup := resetFlagsAndArgs("--expiry-grace-period=" + value)
+			cfg, err := LoadConfig()
+			cleanup()
+			require.NoError(t, err)
+			assert.Equal(t, defaultExpiryGracePeriod, cfg.ExpiryGracePeriod, value)
+		}
+	})
+}
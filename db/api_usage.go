@@ -0,0 +1,191 @@
+package db
+
+import (
+	"docserver/models"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// --- API Usage ---
+
+// apiUsageBuffer collects API usage between flushes. It has its own lock so counting a request
+// does not need the data lock or trigger a save; FlushAPIUsage moves the counts into the data.
+type apiUsageBuffer struct {
+	mu      sync.Mutex
+	pending map[string]map[string]models.APIUsageCounter // Keyed by Profile ID, then route
+}
+
+// APIRouteUsage is a profile's usage of one route.
+type APIRouteUsage struct {
+	Route string `json:"route"` // Method and path pattern, e.g. "GET /documents/:id"
+	models.APIUsageCounter
+}
+
+// APIUsageReport totals a profile's API usage, with a breakdown by route.
+type APIUsageReport struct {
+	ProfileID     string          `json:"profile_id"`
+	Requests      int64           `json:"requests"`
+	Errors        int64           `json:"errors"`
+	RequestBytes  int64           `json:"request_bytes"`
+	ResponseBytes int64           `json:"response_bytes"`
+	LastRequestAt *time.Time      `json:"last_request_at,omitempty"` // UTC; nil if the profile never made a request
+	Routes        []APIRouteUsage `json:"routes"`                    // Most requested first
+}
+
+// RecordAPIUsage counts a request a profile made to route. It is kept in memory until the next
+// FlushAPIUsage, but is already included in usage reports.
+func (db *Database) RecordAPIUsage(profileID, route string, status int, requestBytes, responseBytes int64, at time.Time) {
+	db.apiUsage.mu.Lock()
+	defer db.apiUsage.mu.Unlock()
+
+	if db.apiUsage.pending == nil {
+		db.apiUsage.pending = make(map[string]map[string]models.APIUsageCounter)
+	}
+	routes := db.apiUsage.pending[profileID]
+	if routes == nil {
+		routes = make(map[string]models.APIUsageCounter)
+		db.apiUsage.pending[profileID] = routes
+	}
+	counter := routes[route]
+	counter.Requests++
+	if status >= 400 {
+		counter.Errors++
+	}
+	counter.RequestBytes += max(requestBytes, 0)
+	counter.ResponseBytes += max(responseBytes, 0)
+	counter.LastRequestAt = at.UTC()
+	routes[route] = counter
+}
+
+// takePendingAPIUsage returns the usage recorded since the last flush and starts a new buffer.
+func (db *Database) takePendingAPIUsage() map[string]map[string]models.APIUsageCounter {
+	db.apiUsage.mu.Lock()
+	defer db.apiUsage.mu.Unlock()
+
+	pending := db.apiUsage.pending
+	db.apiUsage.pending = nil
+	return pending
+}
+
+// addAPIUsage adds counts to usage.
+func addAPIUsage(usage, counts map[string]map[string]models.APIUsageCounter) {
+	for profileID, routes := range counts {
+		stored := usage[profileID]
+		if stored == nil {
+			stored = make(map[string]models.APIUsageCounter, len(routes))
+			usage[profileID] = stored
+		}
+		for route, counter := range routes {
+			total := stored[route]
+			total.Requests += counter.Requests
+			total.Errors += counter.Errors
+			total.RequestBytes += counter.RequestBytes
+			total.ResponseBytes += counter.ResponseBytes
+			if counter.LastRequestAt.After(total.LastRequestAt) {
+				total.LastRequestAt = counter.LastRequestAt
+			}
+			stored[route] = total
+		}
+	}
+}
+
+// mergePendingAPIUsage moves the buffered usage into the data and returns the number of profiles
+// it concerned. Usage of profiles that no longer exist is dropped. Must be called with the write lock held.
+func (db *Database) mergePendingAPIUsage() int {
+	pending := db.takePendingAPIUsage()
+	for profileID := range pending {
+		if _, found := db.Database.Profiles[profileID]; !found {
+			delete(pending, profileID)
+		}
+	}
+	addAPIUsage(db.Database.APIUsage, pending)
+	return len(pending)
+}
+
+// FlushAPIUsage moves the usage recorded since the last flush into the data and saves it.
+func (db *Database) FlushAPIUsage() {
+	db.Database.Mu.Lock()
+	flushed := db.mergePendingAPIUsage()
+	db.Database.Mu.Unlock()
+
+	if flushed > 0 {
+		log.Printf("DEBUG: Flushed API usage of %d profiles", flushed)
+		db.requestSave()
+	}
+}
+
+// StartAPIUsageWorker flushes the recorded API usage every interval until the returned function
+// is called. Usage still buffered at shutdown is saved by Close.
+func (db *Database) StartAPIUsageWorker(interval time.Duration) (stop func()) {
+	return startWorker(interval, db.now, func(time.Time) { db.FlushAPIUsage() })
+}
+
+// currentAPIUsage returns the saved usage of the profiles with the buffered usage added.
+// Must be called with the lock held.
+func (db *Database) currentAPIUsage(profileIDs ...string) map[string]map[string]models.APIUsageCounter {
+	usage := make(map[string]map[string]models.APIUsageCounter, len(profileIDs))
+	db.apiUsage.mu.Lock()
+	defer db.apiUsage.mu.Unlock()
+	for _, profileID := range profileIDs {
+		addAPIUsage(usage, map[string]map[string]models.APIUsageCounter{profileID: db.Database.APIUsage[profileID]})
+		addAPIUsage(usage, map[string]map[string]models.APIUsageCounter{profileID: db.apiUsage.pending[profileID]})
+	}
+	return usage
+}
+
+// GetAPIUsage reports a profile's API usage, including requests not yet flushed.
+func (db *Database) GetAPIUsage(profileID string) APIUsageReport {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+
+	return apiUsageReport(profileID, db.currentAPIUsage(profileID)[profileID])
+}
+
+// ListAPIUsage reports the API usage of every profile, including those that never made a
+// request, busiest first.
+func (db *Database) ListAPIUsage() []APIUsageReport {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+
+	profileIDs := make([]string, 0, len(db.Database.Profiles))
+	for profileID := range db.Database.Profiles {
+		profileIDs = append(profileIDs, profileID)
+	}
+	usage := db.currentAPIUsage(profileIDs...)
+	reports := make([]APIUsageReport, 0, len(profileIDs))
+	for _, profileID := range profileIDs {
+		reports = append(reports, apiUsageReport(profileID, usage[profileID]))
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Requests != reports[j].Requests {
+			return reports[i].Requests > reports[j].Requests
+		}
+		return reports[i].ProfileID < reports[j].ProfileID
+	})
+	return reports
+}
+
+// apiUsageReport totals the usage of a profile by route.
+func apiUsageReport(profileID string, routes map[string]models.APIUsageCounter) APIUsageReport {
+	report := APIUsageReport{ProfileID: profileID, Routes: make([]APIRouteUsage, 0, len(routes))}
+	for route, counter := range routes {
+		report.Requests += counter.Requests
+		report.Errors += counter.Errors
+		report.RequestBytes += counter.RequestBytes
+		report.ResponseBytes += counter.ResponseBytes
+		if report.LastRequestAt == nil || counter.LastRequestAt.After(*report.LastRequestAt) {
+			last := counter.LastRequestAt
+			report.LastRequestAt = &last
+		}
+		report.Routes = append(report.Routes, APIRouteUsage{Route: route, APIUsageCounter: counter})
+	}
+	sort.Slice(report.Routes, func(i, j int) bool {
+		if report.Routes[i].Requests != report.Routes[j].Requests {
+			return report.Routes[i].Requests > report.Routes[j].Requests
+		}
+		return report.Routes[i].Route < report.Routes[j].Route
+	})
+	return report
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"docserver/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabase_APIUsage(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	busy, err := db.CreateProfile(models.Profile{Email: "busy@example.com"})
+	require.NoError(t, err)
+	idle, err := db.CreateProfile(models.Profile{Email: "idle@example.com"})
+	require.NoError(t, err)
+
+	at := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+	db.RecordAPIUsage(busy.ID, "GET /documents", 200, 0, 500, at)
+	db.RecordAPIUsage(busy.ID, "POST /documents", 201, 100, 200, at.Add(time.Minute))
+
+	t.Run("Unflushed usage is reported", func(t *testing.T) {
+		report := db.GetAPIUsage(busy.ID)
+		assert.Equal(t, int64(2), report.Requests)
+		assert.Equal(t, int64(100), report.RequestBytes)
+		assert.Equal(t, int64(700), report.ResponseBytes)
+		require.NotNil(t, report.LastRequestAt)
+		assert.Equal(t, at.Add(time.Minute), *report.LastRequestAt)
+		assert.Empty(t, db.Database.APIUsage, "nothing saved before the flush")
+	})
+
+	t.Run("Flushing adds to the saved counts", func(t *testing.T) {
+		db.FlushAPIUsage()
+		require.Contains(t, db.Database.APIUsage, busy.ID)
+		db.RecordAPIUsage(busy.ID, "GET /documents", 404, 0, 50, at.Add(2*time.Minute))
+		db.RecordAPIUsage("deleted-profile", "GET /documents", 200, 0, 50, at)
+		db.FlushAPIUsage()
+		assert.NotContains(t, db.Database.APIUsage, "deleted-profile")
+
+		report := db.GetAPIUsage(busy.ID)
+		assert.Equal(t, int64(3), report.Requests)
+		assert.Equal(t, int64(1), report.Errors)
+		require.Len(t, report.Routes, 2)
+		assert.Equal(t, "GET /documents", report.Routes[0].Route, "most requested first")
+		assert.Equal(t, int64(2), report.Routes[0].Requests)
+	})
+
+	t.Run("Every profile is listed", func(t *testing.T) {
+		reports := db.ListAPIUsage()
+		require.Len(t, reports, 2)
+		assert.Equal(t, busy.ID, reports[0].ProfileID)
+		assert.Equal(t, idle.ID, reports[1].ProfileID)
+		assert.Zero(t, reports[1].Requests)
+		assert.Nil(t, reports[1].LastRequestAt)
+	})
+
+	t.Run("Close saves buffered usage", func(t *testing.T) {
+		db.RecordAPIUsage(idle.ID, "GET /profiles/me", 200, 0, 10, at)
+		require.NoError(t, db.Close())
+		assert.Contains(t, db.Database.APIUsage, idle.ID)
+	})
+}
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/models"
+	"docserver/utils"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// --- Calendar Feed ---
+
+// DueDateField is the top-level content field holding a document's deadline.
+const DueDateField = "due_date"
+
+// Deadline is a document's upcoming deadline.
+type Deadline struct {
+	Document models.Document
+	Due      time.Time // In UTC; midnight of the day for all-day deadlines
+	AllDay   bool      // Whether the deadline is a date (YYYY-MM-DD) rather than a time
+}
+
+// ParseDueDate reads a deadline: an RFC 3339 time, or a date (YYYY-MM-DD) for an all-day
+// deadline. ok is false for any other value.
+func ParseDueDate(value any) (due time.Time, allDay bool, ok bool) {
+	text, isString := value.(string)
+	if !isString {
+		return time.Time{}, false, false
+	}
+	text = strings.TrimSpace(text)
+	if parsed, err := time.Parse(time.RFC3339, text); err == nil {
+		return parsed.UTC(), false, true
+	}
+	if parsed, err := time.Parse(time.DateOnly, text); err == nil {
+		return parsed, true, true
+	}
+	return time.Time{}, false, false
+}
+
+// UpcomingDeadlines returns the deadlines, soonest first, of the documents a profile owns or
+// that are shared with it whose content has a due_date that has not passed. All-day deadlines
+// count until the end of their day (in UTC). Documents with another due_date value are skipped.
+func (db *Database) UpcomingDeadlines(profileID string) ([]Deadline, error) {
+	if _, found := db.GetProfileByID(profileID); !found {
+		return nil, apperr.NotFound("profile with ID '%s' not found", profileID)
+	}
+	now := db.now().UTC()
+	today := now.Truncate(24 * time.Hour)
+
+	documents, err := db.AccessibleDocuments(profileID)
+	if err != nil {
+		return nil, err
+	}
+	deadlines := make([]Deadline, 0)
+	for _, doc := range documents {
+		content, isObject := doc.Content.(map[string]any)
+		if !isObject {
+			continue
+		}
+		due, allDay, ok := ParseDueDate(content[DueDateField])
+		if !ok || (allDay && due.Before(today)) || (!allDay && due.Before(now)) {
+			continue
+		}
+		deadlines = append(deadlines, Deadline{Document: doc, Due: due, AllDay: allDay})
+	}
+
+	sort.SliceStable(deadlines, func(i, j int) bool { return deadlines[i].Due.Before(deadlines[j].Due) })
+	return deadlines, nil
+}
+
+// RenewCalendarFeed gives a profile a new calendar feed ID and returns it. Feed tokens carrying
+// the previous ID stop working.
+func (db *Database) RenewCalendarFeed(profileID string) (string, error) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	profile, found := db.Database.Profiles[profileID]
+	if !found {
+		return "", apperr.NotFound("profile with ID '%s' not found", profileID)
+	}
+	profile.CalendarFeedID = utils.GenerateDashlessUUID()
+	db.putProfile(profile)
+	log.Printf("INFO: Issued a new calendar feed for Profile ID %s", profileID)
+
+	db.requestSave()
+	return profile.CalendarFeedID, nil
+}
+
+// RevokeCalendarFeed turns a profile's calendar feed off, so no feed token works until a new
+// one is issued.
+func (db *Database) RevokeCalendarFeed(profileID string) error {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	profile, found := db.Database.Profiles[profileID]
+	if !found {
+		return apperr.NotFound("profile with ID '%s' not found", profileID)
+	}
+	profile.CalendarFeedID = ""
+	db.putProfile(profile)
+	log.Printf("INFO: Revoked the calendar feed of Profile ID %s", profileID)
+
+	db.requestSave()
+	return nil
+}
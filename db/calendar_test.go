@@ -0,0 +1,95 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/config"
+	"docserver/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDueDate(t *testing.T) {
+	due, allDay, ok := ParseDueDate("2026-03-10T17:00:00+01:00")
+	require.True(t, ok)
+	assert.False(t, allDay)
+	assert.Equal(t, time.Date(2026, 3, 10, 16, 0, 0, 0, time.UTC), due)
+
+	due, allDay, ok = ParseDueDate(" 2026-03-12 ")
+	require.True(t, ok)
+	assert.True(t, allDay)
+	assert.Equal(t, time.Date(2026, 3, 12, 0, 0, 0, 0, time.UTC), due)
+
+	for _, value := range []any{nil, 20260312.0, "next friday", "2026-13-01", ""} {
+		_, _, ok := ParseDueDate(value)
+		assert.False(t, ok, "%v", value)
+	}
+}
+
+func TestUpcomingDeadlines(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	db.config.Clock = config.NewFixedClock(time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC))
+
+	owner, err := db.CreateProfile(models.Profile{FirstName: "Dana", Email: "dana@example.com"})
+	require.NoError(t, err)
+	other, err := db.CreateProfile(models.Profile{FirstName: "Omar", Email: "omar@example.com"})
+	require.NoError(t, err)
+
+	create := func(ownerID string, content any) models.Document {
+		doc, err := db.CreateDocument(models.Document{OwnerID: ownerID, Content: content})
+		require.NoError(t, err)
+		return doc
+	}
+	later := create(owner.ID, map[string]any{"title": "Essay", "due_date": "2026-03-20T09:00:00Z"})
+	today := create(owner.ID, map[string]any{"title": "Quiz", "due_date": "2026-03-10"})
+	create(owner.ID, map[string]any{"title": "Passed", "due_date": "2026-03-10T11:59:00Z"})
+	create(owner.ID, map[string]any{"title": "Yesterday", "due_date": "2026-03-09"})
+	create(owner.ID, map[string]any{"title": "Vague", "due_date": "soon"})
+	create(owner.ID, []any{"not", "an", "object"})
+	shared := create(other.ID, map[string]any{"title": "Group project", "due_date": "2026-03-15T00:00:00Z"})
+	create(other.ID, map[string]any{"title": "Not shared", "due_date": "2026-03-15T00:00:00Z"})
+	require.NoError(t, db.SetShareRecord(shared.ID, []string{owner.ID}))
+
+	deadlines, err := db.UpcomingDeadlines(owner.ID)
+	require.NoError(t, err)
+	require.Len(t, deadlines, 3)
+	assert.Equal(t, today.ID, deadlines[0].Document.ID, "all-day deadlines last until the end of the day")
+	assert.True(t, deadlines[0].AllDay)
+	assert.Equal(t, shared.ID, deadlines[1].Document.ID, "shared documents are included")
+	assert.Equal(t, later.ID, deadlines[2].Document.ID)
+	assert.Equal(t, time.Date(2026, 3, 20, 9, 0, 0, 0, time.UTC), deadlines[2].Due)
+
+	_, err = db.UpcomingDeadlines("missing")
+	assert.ErrorIs(t, err, apperr.ErrNotFound)
+}
+
+func TestCalendarFeedID(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	profile, err := db.CreateProfile(models.Profile{FirstName: "Dana", Email: "dana@example.com"})
+	require.NoError(t, err)
+
+	first, err := db.RenewCalendarFeed(profile.ID)
+	require.NoError(t, err)
+	assert.NotEmpty(t, first)
+	second, err := db.RenewCalendarFeed(profile.ID)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+
+	profile.FirstName = "Dani"
+	_, err = db.UpdateProfile(profile.ID, profile)
+	require.NoError(t, err)
+	stored, _ := db.GetProfileByID(profile.ID)
+	assert.Equal(t, second, stored.CalendarFeedID, "profile updates keep the feed")
+
+	require.NoError(t, db.RevokeCalendarFeed(profile.ID))
+	stored, _ = db.GetProfileByID(profile.ID)
+	assert.Empty(t, stored.CalendarFeedID)
+
+	_, err = db.RenewCalendarFeed("missing")
+	assert.ErrorIs(t, err, apperr.ErrNotFound)
+	assert.ErrorIs(t, db.RevokeCalendarFeed("missing"), apperr.ErrNotFound)
+}
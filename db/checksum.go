@@ -0,0 +1,42 @@
+package db
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// --- Database File Checksum ---
+
+// checksumFooterPrefix starts the last line of a database file saved with -checksum, followed by
+// the hex SHA-256 of everything before that line.
+const checksumFooterPrefix = "#sha256:"
+
+// ErrChecksumMismatch is returned when a database file's content does not match its checksum,
+// e.g. because it was only partly written or was edited by hand.
+var ErrChecksumMismatch = errors.New("database file does not match its checksum")
+
+// appendChecksum ends data with a checksum footer line.
+func appendChecksum(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	return fmt.Appendf(data, "\n%s%s\n", checksumFooterPrefix, hex.EncodeToString(sum[:]))
+}
+
+// verifyChecksum checks the checksum footer of a database file and returns the content without
+// it. Files without a footer, saved before checksums or with -checksum off, are returned as they are.
+func verifyChecksum(data []byte) ([]byte, error) {
+	trimmed := bytes.TrimRight(data, "\n")
+	newline := bytes.LastIndexByte(trimmed, '\n')
+	footer := trimmed[newline+1:]
+	if !bytes.HasPrefix(footer, []byte(checksumFooterPrefix)) {
+		return data, nil
+	}
+	content := trimmed[:max(newline, 0)]
+	sum := sha256.Sum256(content)
+	if string(footer[len(checksumFooterPrefix):]) != hex.EncodeToString(sum[:]) {
+		return nil, ErrChecksumMismatch
+	}
+	return content, nil
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"docserver/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyChecksum(t *testing.T) {
+	content := []byte(`{"profiles":{}}`)
+	saved := appendChecksum(content)
+	assert.True(t, strings.HasPrefix(string(saved), string(content)+"\n#sha256:"))
+
+	got, err := verifyChecksum(saved)
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+
+	got, err = verifyChecksum(content)
+	require.NoError(t, err, "files without a checksum are accepted")
+	assert.Equal(t, content, got)
+
+	tampered := []byte(strings.Replace(string(saved), "{}", `{"x":1}`, 1))
+	_, err = verifyChecksum(tampered)
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+}
+
+func TestLoad_Checksum(t *testing.T) {
+	dir := t.TempDir()
+	cfg := createTestConfig(t, dir)
+	cfg.Checksum, cfg.Fsync = true, true
+
+	// save creates a document and saves twice, so the backup holds the first save
+	save := func(content string) models.Document {
+		db, err := NewDatabase(cfg)
+		require.NoError(t, err)
+		doc, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: content})
+		require.NoError(t, err)
+		require.NoError(t, db.PersistNow())
+		require.NoError(t, db.Close())
+		return doc
+	}
+	first := save("first")
+	second := save("second")
+	data := readTestDBFile(t, cfg)
+	require.Contains(t, data, "\n#sha256:")
+
+	t.Run("A matching file loads", func(t *testing.T) {
+		db, err := NewDatabase(cfg)
+		require.NoError(t, err)
+		defer db.Close()
+		_, found := db.GetDocumentByID(second.ID)
+		assert.True(t, found)
+		report, err := PlanMigrations(cfg.DbFilePath)
+		require.NoError(t, err, "the checksum line is no JSON error")
+		assert.Empty(t, report.Pending)
+	})
+
+	t.Run("A damaged file falls back to the backup", func(t *testing.T) {
+		writeTestDBFile(t, cfg, strings.Replace(data, "second", "SECOND", 1))
+		db, err := NewDatabase(cfg)
+		require.NoError(t, err)
+		_, found := db.GetDocumentByID(first.ID)
+		assert.True(t, found)
+		_, found = db.GetDocumentByID(second.ID)
+		assert.False(t, found, "only in the damaged file")
+		assert.True(t, db.Unsaved(), "the recovered state is to be saved")
+		require.NoError(t, db.Close())
+
+		quarantined, err := filepath.Glob(cfg.DbFilePath + ".corrupt-*")
+		require.NoError(t, err)
+		require.Len(t, quarantined, 1)
+		corrupt, err := os.ReadFile(quarantined[0])
+		require.NoError(t, err)
+		assert.Contains(t, string(corrupt), "SECOND")
+		_, err = verifyChecksum([]byte(readTestDBFile(t, cfg)))
+		assert.NoError(t, err, "the database file is whole again")
+	})
+
+	t.Run("Refuses to start without a usable backup", func(t *testing.T) {
+		writeTestDBFile(t, cfg, strings.Replace(data, "second", "SECOND", 1))
+		require.NoError(t, os.Remove(cfg.DbFilePath+".bak"))
+		_, err := NewDatabase(cfg)
+		assert.ErrorIs(t, err, ErrChecksumMismatch)
+		_, err = PlanMigrations(cfg.DbFilePath)
+		assert.ErrorIs(t, err, ErrChecksumMismatch)
+	})
+}
@@ -0,0 +1,11 @@
+package db
+
+import "time"
+
+// --- Clock ---
+
+// now returns the time on the configured clock: the wall clock, or the frozen clock of fixtures
+// mode (see -fixtures). Timestamps stored in the database are taken from it.
+func (db *Database) now() time.Time {
+	return db.config.Now()
+}
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"docserver/config"
+	"docserver/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabase_Fixtures(t *testing.T) {
+	frozen := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	// run creates a fresh fixtures-mode database and returns what the same requests store in it
+	run := func(seed int64) (models.Profile, models.Document) {
+		cfg := createTestConfig(t, t.TempDir())
+		cfg.IDStrategy = config.IDStrategyPrefixed
+		cfg.Fixtures, cfg.FixturesSeed, cfg.Clock = true, seed, config.NewFixedClock(frozen)
+		db, err := NewDatabase(cfg)
+		require.NoError(t, err)
+		defer db.Close()
+
+		profile, err := db.CreateProfile(models.Profile{Email: "fixtures@example.com", FirstName: "F", LastName: "X"})
+		require.NoError(t, err)
+		doc, err := db.CreateDocument(models.Document{OwnerID: profile.ID, Content: map[string]any{"a": 1}})
+		require.NoError(t, err)
+		return profile, doc
+	}
+
+	profile, doc := run(1)
+	assert.Regexp(t, `^usr_[0-9a-f]{12}7[0-9a-f]{19}$`, profile.ID)
+	assert.Equal(t, frozen, profile.CreationDate)
+	assert.Equal(t, frozen, doc.CreationDate)
+	assert.Equal(t, frozen, doc.LastModifiedDate)
+
+	againProfile, againDoc := run(1)
+	assert.Equal(t, profile.ID, againProfile.ID, "the same seed gives the same IDs")
+	assert.Equal(t, doc.ID, againDoc.ID)
+	assert.Less(t, profile.ID[4:], doc.ID[4:], "IDs generated at the same instant keep their order")
+
+	otherProfile, _ := run(2)
+	assert.NotEqual(t, profile.ID, otherProfile.ID, "another seed gives other IDs")
+
+	t.Run("Wall clock without fixtures mode", func(t *testing.T) {
+		db, cleanup := setupTestDB(t)
+		defer cleanup()
+		assert.WithinDuration(t, time.Now(), db.now(), time.Second)
+	})
+}
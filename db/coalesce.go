@@ -0,0 +1,60 @@
+package db
+
+import (
+	"docserver/models"
+	"docserver/utils"
+	"log"
+)
+
+// --- Update Coalescing ---
+
+// coalesceUpdate folds an update into the document's latest version instead of adding a new one
+// when the latest change to the document was an update made less than -coalesce-window ago, as
+// with an editor autosaving every few seconds. The version's content, timestamp and activity
+// event are replaced, so its changed paths cover everything changed since the version before.
+// It reports whether the update was folded in; if not, nothing was changed.
+// Must be called with the write lock held.
+func (db *Database) coalesceUpdate(existingDoc models.Document, newContent any) (models.Document, bool) {
+	window := db.config.CoalesceWindow
+	if window <= 0 {
+		return models.Document{}, false
+	}
+	now := db.now().UTC()
+	if now.Sub(existingDoc.LastModifiedDate) >= window {
+		return models.Document{}, false
+	}
+	id := existingDoc.ID
+	versions := db.Database.DocumentVersions[id]
+	events := db.Database.DocumentEvents[id]
+	if len(versions) < 2 || versions[len(versions)-1].Version != existingDoc.Version || len(events) == 0 {
+		return models.Document{}, false
+	}
+	latest := events[len(events)-1]
+	if latest.Type != models.EventUpdated || latest.Version != existingDoc.Version {
+		return models.Document{}, false // Something else happened since, e.g. it was shared
+	}
+
+	existingDoc.Content = newContent
+	existingDoc.Revision++ // So that version preconditions see the change (see checkPrecondition)
+	existingDoc.LastModifiedDate = now
+	db.Database.Documents[id] = existingDoc
+	versions[len(versions)-1].Content = newContent
+	versions[len(versions)-1].Timestamp = now
+	latest.ChangedPaths = utils.DiffJSON(versions[len(versions)-2].Content, newContent).Paths()
+	latest.Timestamp = now
+	events[len(events)-1] = latest
+	log.Printf("DEBUG: Coalesced update of Document ID %s into version %d", id, existingDoc.Version)
+	return existingDoc, true
+}
+
+// requestCoalescedSave schedules a save after a coalesced update. Unlike requestSave, it leaves a
+// save that is already scheduled alone rather than postponing it, so a steady stream of updates
+// cannot keep the database from being saved.
+func (db *Database) requestCoalescedSave() {
+	db.saveMutex.Lock()
+	scheduled := db.savePending && db.saveTimer != nil
+	db.saveMutex.Unlock()
+	if !scheduled {
+		db.requestSave()
+	}
+}
@@ -0,0 +1,140 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/config"
+	"docserver/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateDocument_Coalescing(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	clock := config.NewFixedClock(time.Date(2025, time.March, 1, 9, 0, 0, 0, time.UTC))
+	db.config.Clock = clock
+	db.config.CoalesceWindow = 2 * time.Second
+
+	doc, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: map[string]any{"title": "Draft"}})
+	require.NoError(t, err)
+	clock.Advance(5 * time.Second)
+	updated, err := db.UpdateDocument(doc.ID, map[string]any{"title": "Draft", "body": "H"})
+	require.NoError(t, err)
+	require.Equal(t, 2, updated.Version, "the first update after creation is a new version")
+
+	// Autosaves one second apart are folded into version 2
+	for _, body := range []string{"He", "Hel", "Hello"} {
+		clock.Advance(time.Second)
+		updated, err = db.UpdateDocument(doc.ID, map[string]any{"title": "Final", "body": body})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, updated.Version)
+	assert.Equal(t, 3, updated.Revision, "each coalesced update is a revision")
+	assert.Equal(t, clock.Now(), updated.LastModifiedDate)
+	version, err := db.GetDocumentVersion(doc.ID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"title": "Final", "body": "Hello"}, version.Content)
+	assert.Equal(t, clock.Now(), version.Timestamp)
+	events := db.GetDocumentEvents(doc.ID)
+	require.Len(t, events, 2, "created and one update")
+	assert.Equal(t, models.EventUpdated, events[0].Type)
+	assert.Equal(t, []string{"body", "title"}, events[0].ChangedPaths, "everything changed since version 1")
+
+	t.Run("After the window a new version starts", func(t *testing.T) {
+		clock.Advance(2 * time.Second)
+		updated, err := db.UpdateDocument(doc.ID, map[string]any{"title": "Final", "body": "Hello!"})
+		require.NoError(t, err)
+		assert.Equal(t, 3, updated.Version)
+		assert.Zero(t, updated.Revision)
+		assert.Equal(t, []string{"body"}, db.GetDocumentEvents(doc.ID)[0].ChangedPaths)
+	})
+
+	t.Run("Other changes end the version", func(t *testing.T) {
+		_, err := db.SetDocumentFrozen(doc.ID, true, "owner")
+		require.NoError(t, err)
+		_, err = db.SetDocumentFrozen(doc.ID, false, "owner")
+		require.NoError(t, err)
+		clock.Advance(time.Second)
+		updated, err := db.UpdateDocument(doc.ID, map[string]any{"title": "Final", "body": "Bye"})
+		require.NoError(t, err)
+		assert.Equal(t, 4, updated.Version)
+	})
+
+	t.Run("Changing the content type is a new version", func(t *testing.T) {
+		clock.Advance(time.Second)
+		updated, err := db.UpdateDocumentAs(doc.ID, "# Final", models.ContentTypeMarkdown)
+		require.NoError(t, err)
+		assert.Equal(t, 5, updated.Version)
+	})
+
+	t.Run("Off without a window", func(t *testing.T) {
+		db.config.CoalesceWindow = 0
+		clock.Advance(time.Millisecond)
+		updated, err := db.UpdateDocument(doc.ID, "# Final!")
+		require.NoError(t, err)
+		assert.Equal(t, 6, updated.Version)
+	})
+}
+
+func TestUpdateDocumentIfUnchanged(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	clock := config.NewFixedClock(time.Date(2025, time.March, 1, 9, 0, 0, 0, time.UTC))
+	db.config.Clock = clock
+	db.config.CoalesceWindow = 2 * time.Second
+
+	doc, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: map[string]any{"n": 1.0}})
+	require.NoError(t, err)
+	clock.Advance(5 * time.Second)
+	seen, err := db.UpdateDocument(doc.ID, map[string]any{"n": 2.0})
+	require.NoError(t, err)
+
+	// A coalesced update keeps the version but still counts as a change
+	clock.Advance(time.Second)
+	_, err = db.UpdateDocument(doc.ID, map[string]any{"n": 3.0})
+	require.NoError(t, err)
+	_, err = db.UpdateDocumentIfUnchanged(seen, map[string]any{"n": 4.0})
+	assert.ErrorIs(t, err, apperr.ErrConflict)
+	current, _ := db.GetDocumentByID(doc.ID)
+	assert.Equal(t, map[string]any{"n": 3.0}, current.Content)
+
+	updated, err := db.UpdateDocumentIfUnchanged(current, map[string]any{"n": 4.0})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"n": 4.0}, updated.Content)
+
+	_, err = db.UpdateDocumentIfUnchanged(models.Document{ID: "doc_missing"}, nil)
+	assert.ErrorIs(t, err, apperr.ErrNotFound)
+}
+
+func TestSetDocumentContentPath_AfterCoalescedUpdate(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	clock := config.NewFixedClock(time.Date(2025, time.March, 1, 9, 0, 0, 0, time.UTC))
+	db.config.Clock = clock
+	db.config.CoalesceWindow = 2 * time.Second
+
+	doc, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: map[string]any{"title": "Draft", "body": ""}})
+	require.NoError(t, err)
+	clock.Advance(5 * time.Second)
+	seen, err := db.UpdateDocument(doc.ID, map[string]any{"title": "Draft", "body": "H"})
+	require.NoError(t, err)
+
+	// Another editor's autosave is folded into the version the client saw
+	clock.Advance(time.Second)
+	coalesced, err := db.UpdateDocument(doc.ID, map[string]any{"title": "Essay", "body": "Hello"})
+	require.NoError(t, err)
+	require.Equal(t, seen.Version, coalesced.Version)
+
+	_, err = db.SetDocumentContentPath(doc.ID, "owner", []string{"title"}, "Draft 2", Precondition{Version: seen.Version, Revision: seen.Revision})
+	assert.ErrorIs(t, err, apperr.ErrConflict, "the version's content changed since it was seen")
+	current, _ := db.GetDocumentByID(doc.ID)
+	assert.Equal(t, map[string]any{"title": "Essay", "body": "Hello"}, current.Content)
+
+	change, err := db.SetDocumentContentPath(doc.ID, "owner", []string{"title"}, "Essay 2", Precondition{Version: current.Version, Revision: current.Revision})
+	require.NoError(t, err)
+	assert.Equal(t, seen.Version+1, change.Document.Version)
+	assert.Zero(t, change.Document.Revision)
+}
@@ -0,0 +1,327 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/i18n"
+	"docserver/models"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/sjson"
+)
+
+// --- Content Path Updates ---
+
+// ContentPathChange is the result of changing one value inside a document's content.
+type ContentPathChange struct {
+	Document models.Document // The updated document
+	Path     []string        // The path changed, with an appending index replaced by the new item's
+	Previous any             // Value at the path before, nil if there was none
+	Value    any             // Value at the path after, nil if it was removed
+}
+
+// contentPathSpecialChars are the characters gjson gives a meaning in paths besides '.' and '\':
+// wildcards, array queries and lengths, modifiers, pipes, multipaths and literals. Content paths
+// name one value to change, so they must be escaped to be part of a key.
+const contentPathSpecialChars = "*?#@|[]{}!"
+
+// ParseContentPath splits a content path into its parts. Paths use the gjson syntax content_query
+// does: object keys and array indexes separated by dots (e.g. "chapters.2.title"), with '\'
+// escaping a dot or special character that is part of a key ("versions.1\.0"). As in sjson, the
+// index -1 appends. It returns an ErrValidation error for empty paths and parts, and for paths using
+// gjson features that name more than one value, such as "chapters.#.title".
+func ParseContentPath(path string) ([]string, error) {
+	invalid := apperr.Wrap(apperr.ErrValidation, i18n.NewError(i18n.MsgContentPathInvalid, path))
+	var parts []string
+	var part strings.Builder
+	for i := 0; i < len(path); i++ {
+		switch ch := path[i]; {
+		case ch == '\\':
+			i++
+			if i == len(path) {
+				return nil, invalid
+			}
+			part.WriteByte(path[i])
+		case ch == '.':
+			if part.Len() == 0 {
+				return nil, invalid
+			}
+			parts = append(parts, part.String())
+			part.Reset()
+		case strings.IndexByte(contentPathSpecialChars, ch) >= 0:
+			return nil, invalid
+		default:
+			part.WriteByte(ch)
+		}
+	}
+	if part.Len() == 0 {
+		return nil, invalid
+	}
+	return append(parts, part.String()), nil
+}
+
+// FormatContentPath joins the parts of a content path back into the path ParseContentPath reads,
+// escaping dots and special characters in keys, and a leading ':', which makes sjson treat a
+// numeric key as an object key.
+func FormatContentPath(parts []string) string {
+	var path strings.Builder
+	for i, part := range parts {
+		if i > 0 {
+			path.WriteByte('.')
+		}
+		for j := 0; j < len(part); j++ {
+			if ch := part[j]; ch == '.' || ch == '\\' || strings.IndexByte(contentPathSpecialChars, ch) >= 0 || (j == 0 && ch == ':') {
+				path.WriteByte('\\')
+			}
+			path.WriteByte(part[j])
+		}
+	}
+	return path.String()
+}
+
+// SetDocumentContentPath sets the value at a path of a document's content, creating missing
+// values on the way as setContentPathValue does; an array index equal to the array's length, or -1, appends. The rest of the
+// content is kept. The document must satisfy pre, or the error checkPrecondition gives is
+// returned. actorID is recorded as the author; permissions are checked at handler level.
+func (db *Database) SetDocumentContentPath(docID, actorID string, path []string, value any, pre Precondition) (ContentPathChange, error) {
+	return db.changeDocumentContentPath(docID, actorID, path, pre, func(any, bool) (any, bool, error) {
+		return cloneJSON(value), true, nil
+	})
+}
+
+// DeleteDocumentContentPath removes the value at a path of a document's content; removing an
+// array item moves the later ones down. It returns an ErrNotFound error if there is no value
+// there, and fails on pre as SetDocumentContentPath does.
+func (db *Database) DeleteDocumentContentPath(docID, actorID string, path []string, pre Precondition) (ContentPathChange, error) {
+	return db.changeDocumentContentPath(docID, actorID, path, pre, func(_ any, found bool) (any, bool, error) {
+		if !found {
+			return nil, false, apperr.Wrap(apperr.ErrNotFound, i18n.NewError(i18n.MsgContentPathNotFound, FormatContentPath(path), docID))
+		}
+		return nil, false, nil
+	})
+}
+
+// InsertIntoDocumentArray inserts value into the array at a path of a document's content, before
+// the item at index; index -1 appends. A missing array is created, so appending to a path without
+// a value (or inserting at 0) starts a new array. It returns an ErrValidation error if the value
+// at the path is not an array or index is out of range, and an ErrConflict error as
+// SetDocumentContentPath does.
+func (db *Database) InsertIntoDocumentArray(docID, actorID string, path []string, index int, value any, pre Precondition) (ContentPathChange, error) {
+	return db.changeDocumentContentPath(docID, actorID, path, pre, func(current any, found bool) (any, bool, error) {
+		items, err := contentArray(docID, path, current, found)
+		if err != nil {
+			return nil, false, err
+		}
+		if index == -1 {
+			index = len(items)
+		}
+		if index < 0 || index > len(items) {
+			return nil, false, apperr.Wrap(apperr.ErrValidation, i18n.NewError(i18n.MsgArrayIndexOutOfRange, index, FormatContentPath(path), len(items)))
+		}
+		return slices.Insert(items, index, cloneJSON(value)), true, nil
+	})
+}
+
+// RemoveFromDocumentArray removes the item at index from the array at a path of a document's
+// content, moving the later ones down; index -1 removes the last item. It returns an ErrNotFound error if there is no value at
+// the path, and otherwise fails like InsertIntoDocumentArray.
+func (db *Database) RemoveFromDocumentArray(docID, actorID string, path []string, index int, pre Precondition) (ContentPathChange, error) {
+	return db.changeDocumentContentPath(docID, actorID, path, pre, func(current any, found bool) (any, bool, error) {
+		if !found {
+			return nil, false, apperr.Wrap(apperr.ErrNotFound, i18n.NewError(i18n.MsgContentPathNotFound, FormatContentPath(path), docID))
+		}
+		items, err := contentArray(docID, path, current, found)
+		if err != nil {
+			return nil, false, err
+		}
+		if index == -1 {
+			index = len(items) - 1
+		}
+		if index < 0 || index >= len(items) {
+			return nil, false, apperr.Wrap(apperr.ErrValidation, i18n.NewError(i18n.MsgArrayIndexOutOfRange, index, FormatContentPath(path), len(items)))
+		}
+		return slices.Delete(items, index, index+1), true, nil
+	})
+}
+
+// IncrementDocumentNumber adds delta to the number at a path of a document's content, counting a
+// missing value as 0. As the write lock is held from reading the number to storing the sum,
+// concurrent increments never get lost. It returns an ErrValidation error if the value at the
+// path is not a number or the sum is too large, and fails on pre as SetDocumentContentPath does.
+func (db *Database) IncrementDocumentNumber(docID, actorID string, path []string, delta float64, pre Precondition) (ContentPathChange, error) {
+	return db.changeDocumentContentPath(docID, actorID, path, pre, func(current any, found bool) (any, bool, error) {
+		number := 0.0
+		if found {
+			var isNumber bool
+			if number, isNumber = current.(float64); !isNumber {
+				return nil, false, apperr.Wrap(apperr.ErrValidation, i18n.NewError(i18n.MsgContentPathNotNumber, FormatContentPath(path), docID))
+			}
+		}
+		sum := number + delta
+		if math.IsInf(sum, 0) || math.IsNaN(sum) {
+			return nil, false, apperr.Wrap(apperr.ErrValidation, i18n.NewError(i18n.MsgIncrementOverflow, FormatContentPath(path)))
+		}
+		return sum, true, nil
+	})
+}
+
+// contentArray returns the array found at a path, or an empty one if there is no value there.
+func contentArray(docID string, path []string, current any, found bool) ([]any, error) {
+	if !found {
+		return []any{}, nil
+	}
+	items, isArray := current.([]any)
+	if !isArray {
+		return nil, apperr.Wrap(apperr.ErrValidation, i18n.NewError(i18n.MsgContentPathNotArray, FormatContentPath(path), docID))
+	}
+	return slices.Clone(items), nil
+}
+
+// changeDocumentContentPath replaces the value at a path of a document's content with the one
+// change returns for the current value, or with keep false, removes it. The update runs the
+// content transformations and update scripts and is stored as a new version, never coalesced,
+// so the version tells every path update apart.
+func (db *Database) changeDocumentContentPath(docID, actorID string, path []string, pre Precondition, change func(current any, found bool) (any, bool, error)) (ContentPathChange, error) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	existingDoc, found := db.Database.Documents[docID]
+	if !found || documentExpired(existingDoc, db.now()) {
+		return ContentPathChange{}, apperr.NotFound("document with ID '%s' not found", docID)
+	}
+	if err := db.checkNotFrozen(docID); err != nil {
+		return ContentPathChange{}, err
+	}
+	if err := db.checkPrecondition(existingDoc, pre); err != nil {
+		return ContentPathChange{}, err
+	}
+
+	content := cloneJSON(existingDoc.Content)
+	path = resolveAppendIndexes(content, path)
+	previous, exists := jsonPathValue(content, path)
+	value, keep, err := change(previous, exists)
+	if err != nil {
+		return ContentPathChange{}, err
+	}
+	if keep {
+		content, err = setContentPathValue(content, path, value)
+	} else {
+		content, err = removeContentPathValue(content, path)
+	}
+	if err != nil {
+		return ContentPathChange{}, apperr.Wrap(apperr.ErrValidation, i18n.NewError(i18n.MsgContentPathUnusable, FormatContentPath(path), err))
+	}
+	if content, err = db.prepareDocumentUpdate(existingDoc, content); err != nil {
+		return ContentPathChange{}, err
+	}
+	updatedDoc := db.storeDocumentUpdate(existingDoc, content, actorID)
+	log.Printf("INFO: Profile ID %s changed '%s' in Document ID %s", actorID, FormatContentPath(path), docID)
+
+	db.requestSave()
+	result := ContentPathChange{Document: updatedDoc, Path: path, Previous: previous}
+	if keep {
+		result.Value, _ = jsonPathValue(updatedDoc.Content, path) // As stored, after transformations and scripts
+	}
+	return result, nil
+}
+
+// currentDocumentVersion returns a document's version; documents created before version history
+// existed are at version 1.
+func currentDocumentVersion(doc models.Document) int {
+	if doc.Version < 1 {
+		return 1
+	}
+	return doc.Version
+}
+
+// resolveAppendIndexes returns path with every -1 that indexes an array in content replaced by the
+// array's length, the index an appended item gets.
+func resolveAppendIndexes(content any, path []string) []string {
+	resolved := slices.Clone(path)
+	current := content
+	for i, part := range resolved {
+		if items, isArray := current.([]any); isArray && part == "-1" {
+			resolved[i] = strconv.Itoa(len(items))
+			return resolved // What follows is created
+		}
+		value, found := jsonPathValue(current, []string{part})
+		if !found {
+			return resolved
+		}
+		current = value
+	}
+	return resolved
+}
+
+// checkSettablePath returns an error if setting a value at a path would go through a value that
+// is neither an object nor an array, or skip array items; sjson would replace the value on the way
+// or pad the array with nulls.
+func checkSettablePath(content any, parts []string) error {
+	current := content
+	for _, part := range parts {
+		switch node := current.(type) {
+		case nil:
+			return nil // Created by sjson
+		case map[string]any:
+			value, found := node[part]
+			if !found {
+				return nil
+			}
+			current = value
+		case []any:
+			index, err := strconv.Atoi(part)
+			if err != nil || index < -1 || index > len(node) {
+				return fmt.Errorf("array index '%s' out of range", part)
+			}
+			if index == -1 || index == len(node) {
+				return nil
+			}
+			current = node[index]
+		default:
+			return fmt.Errorf("path part '%s' is not inside an object or array", part)
+		}
+	}
+	return nil
+}
+
+// setContentPathValue sets the value at a path with sjson and returns the content. Missing values
+// on the way are created, as arrays for numeric parts and objects otherwise; an array index equal
+// to the length, or -1, appends.
+func setContentPathValue(content any, parts []string, value any) (any, error) {
+	if err := checkSettablePath(content, parts); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = sjson.SetBytes(data, FormatContentPath(parts), value); err != nil {
+		return nil, err
+	}
+	var updated any
+	err = json.Unmarshal(data, &updated)
+	return updated, err
+}
+
+// removeContentPathValue removes the value at a path, which must exist, with sjson and returns the
+// content.
+func removeContentPathValue(content any, parts []string) (any, error) {
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("the whole content cannot be removed")
+	}
+	data, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = sjson.DeleteBytes(data, FormatContentPath(parts)); err != nil {
+		return nil, err
+	}
+	var updated any
+	err = json.Unmarshal(data, &updated)
+	return updated, err
+}
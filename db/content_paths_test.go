@@ -0,0 +1,254 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/models"
+	"encoding/json"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestParseContentPath(t *testing.T) {
+	parts, err := ParseContentPath("chapters.2.title")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"chapters", "2", "title"}, parts)
+
+	parts, err = ParseContentPath(`versions.1\.0.notes\#`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"versions", "1.0", "notes#"}, parts)
+	assert.Equal(t, `versions.1\.0.notes\#`, FormatContentPath(parts))
+
+	for _, path := range []string{"", "a..b", ".a", "a.", `a\`, "chapters.#", "chapters.#.title", "chapters.#(title==A)", "a*", "a.b?", "a|b", "@reverse", "[a,b]", "{a}", "!true"} {
+		_, err := ParseContentPath(path)
+		assert.ErrorIs(t, err, apperr.ErrValidation, path)
+	}
+}
+
+// Content paths name the same values as the gjson paths of content_query.
+func TestParseContentPath_MatchesGJSON(t *testing.T) {
+	content := map[string]any{
+		"chapters":   []any{map[string]any{"title": "One"}, map[string]any{"title": "Two"}},
+		"versions":   map[string]any{"1.0": map[string]any{"notes*": "first"}},
+		`back\slash`: true,
+	}
+	contentJSON, err := json.Marshal(content)
+	require.NoError(t, err)
+
+	for _, path := range []string{"chapters.1.title", "chapters.0", `versions.1\.0.notes\*`, `back\\slash`} {
+		parts, err := ParseContentPath(path)
+		require.NoError(t, err, path)
+		value, found := jsonPathValue(content, parts)
+		require.True(t, found, path)
+		result := gjson.Get(string(contentJSON), path)
+		require.True(t, result.Exists(), path)
+		assert.Equal(t, result.Value(), value, path)
+		assert.Equal(t, path, FormatContentPath(parts))
+	}
+}
+
+func TestSetDocumentContentPath(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	doc, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: map[string]any{"title": "Essay", "tags": []any{"a"}}})
+	require.NoError(t, err)
+
+	change, err := db.SetDocumentContentPath(doc.ID, "owner", []string{"meta", "author", "name"}, "Kim", Precondition{})
+	require.NoError(t, err)
+	assert.Equal(t, "Kim", change.Value)
+	assert.Nil(t, change.Previous)
+	assert.Equal(t, 2, change.Document.Version)
+	assert.Equal(t, map[string]any{"title": "Essay", "tags": []any{"a"}, "meta": map[string]any{"author": map[string]any{"name": "Kim"}}}, change.Document.Content)
+
+	change, err = db.SetDocumentContentPath(doc.ID, "owner", []string{"tags", "-1"}, "b", Precondition{Version: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tags", "1"}, change.Path)
+	assert.Equal(t, "b", change.Value)
+	_, err = db.SetDocumentContentPath(doc.ID, "owner", []string{"tags", "2"}, "c", Precondition{Version: 3})
+	require.NoError(t, err)
+	change, err = db.SetDocumentContentPath(doc.ID, "owner", []string{"tags", "0"}, "A", Precondition{Version: 4})
+	require.NoError(t, err)
+	assert.Equal(t, "a", change.Previous)
+	assert.Equal(t, []any{"A", "b", "c"}, change.Document.Content.(map[string]any)["tags"])
+
+	t.Run("Stale versions are refused", func(t *testing.T) {
+		_, err := db.SetDocumentContentPath(doc.ID, "owner", []string{"title"}, "Other", Precondition{Version: 4})
+		assert.ErrorIs(t, err, apperr.ErrConflict)
+		current, _ := db.GetDocumentByID(doc.ID)
+		assert.Equal(t, "Essay", current.Content.(map[string]any)["title"])
+	})
+
+	t.Run("Unusable paths", func(t *testing.T) {
+		_, err := db.SetDocumentContentPath(doc.ID, "owner", []string{"title", "x"}, 1, Precondition{})
+		assert.ErrorIs(t, err, apperr.ErrValidation, "a string holds no keys")
+		_, err = db.SetDocumentContentPath(doc.ID, "owner", []string{"tags", "9"}, 1, Precondition{})
+		assert.ErrorIs(t, err, apperr.ErrValidation)
+		_, err = db.SetDocumentContentPath("doc_missing", "owner", []string{"a"}, 1, Precondition{})
+		assert.ErrorIs(t, err, apperr.ErrNotFound)
+	})
+
+	t.Run("Frozen documents", func(t *testing.T) {
+		frozen, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: map[string]any{}})
+		require.NoError(t, err)
+		_, err = db.SetDocumentFrozen(frozen.ID, true, "owner")
+		require.NoError(t, err)
+		_, err = db.SetDocumentContentPath(frozen.ID, "owner", []string{"a"}, 1, Precondition{})
+		assert.ErrorIs(t, err, apperr.ErrLocked)
+	})
+}
+
+// Values set and removed with sjson can be read back at the same paths with gjson.
+func TestSetDocumentContentPath_MatchesGJSON(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	doc, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: map[string]any{"scores": map[string]any{"0": 1}, "list": []any{"a"}}})
+	require.NoError(t, err)
+
+	for _, path := range []string{`versions.1\.0`, `notes\*`, `back\\slash`, `\:label`, `\:0`, "scores.0", "list.0", "list.-1", "grid.0.0"} {
+		parts, err := ParseContentPath(path)
+		require.NoError(t, err, path)
+		change, err := db.SetDocumentContentPath(doc.ID, "owner", parts, path, Precondition{})
+		require.NoError(t, err, path)
+		contentJSON, err := json.Marshal(change.Document.Content)
+		require.NoError(t, err)
+		assert.Equal(t, path, gjson.GetBytes(contentJSON, FormatContentPath(change.Path)).Value(), path)
+	}
+
+	current, _ := db.GetDocumentByID(doc.ID)
+	content := current.Content.(map[string]any)
+	assert.Equal(t, map[string]any{"0": "scores.0"}, content["scores"], "numeric keys of objects stay keys")
+	assert.Equal(t, []any{"list.0", "list.-1"}, content["list"])
+	assert.Equal(t, []any{[]any{"grid.0.0"}}, content["grid"], "numeric parts create arrays")
+	assert.Equal(t, `\:0`, content[":0"])
+
+	for _, path := range []string{`versions.1\.0`, `\:label`, "scores.0"} {
+		parts, err := ParseContentPath(path)
+		require.NoError(t, err, path)
+		change, err := db.DeleteDocumentContentPath(doc.ID, "owner", parts, Precondition{})
+		require.NoError(t, err, path)
+		contentJSON, err := json.Marshal(change.Document.Content)
+		require.NoError(t, err)
+		assert.False(t, gjson.GetBytes(contentJSON, path).Exists(), path)
+	}
+	change, err := db.DeleteDocumentContentPath(doc.ID, "owner", []string{"list", "0"}, Precondition{})
+	require.NoError(t, err)
+	assert.Equal(t, []any{"list.-1"}, change.Document.Content.(map[string]any)["list"])
+}
+
+func TestDeleteDocumentContentPath(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	doc, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: map[string]any{"title": "Essay", "tags": []any{"a", "b", "c"}}})
+	require.NoError(t, err)
+
+	change, err := db.DeleteDocumentContentPath(doc.ID, "owner", []string{"tags", "1"}, Precondition{Version: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "b", change.Previous)
+	assert.Nil(t, change.Value)
+	change, err = db.DeleteDocumentContentPath(doc.ID, "owner", []string{"title"}, Precondition{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"tags": []any{"a", "c"}}, change.Document.Content)
+	assert.Equal(t, 3, change.Document.Version)
+
+	_, err = db.DeleteDocumentContentPath(doc.ID, "owner", []string{"title"}, Precondition{})
+	assert.ErrorIs(t, err, apperr.ErrNotFound)
+	_, err = db.DeleteDocumentContentPath(doc.ID, "owner", []string{"tags", "0"}, Precondition{Version: 1})
+	assert.ErrorIs(t, err, apperr.ErrConflict)
+
+	events := db.GetDocumentEvents(doc.ID)
+	assert.Equal(t, "owner", events[0].ActorID)
+}
+
+func TestDocumentArrays(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	doc, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: map[string]any{"todos": []any{"a", "c"}, "title": "List"}})
+	require.NoError(t, err)
+	todos := []string{"todos"}
+
+	change, err := db.InsertIntoDocumentArray(doc.ID, "owner", todos, 1, "b", Precondition{Version: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []any{"a", "b", "c"}, change.Value)
+	assert.Equal(t, []any{"a", "c"}, change.Previous)
+	change, err = db.InsertIntoDocumentArray(doc.ID, "owner", todos, -1, map[string]any{"d": true}, Precondition{})
+	require.NoError(t, err)
+	assert.Equal(t, []any{"a", "b", "c", map[string]any{"d": true}}, change.Value)
+
+	change, err = db.RemoveFromDocumentArray(doc.ID, "owner", todos, 0, Precondition{Version: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []any{"b", "c", map[string]any{"d": true}}, change.Value)
+	change, err = db.RemoveFromDocumentArray(doc.ID, "owner", todos, -1, Precondition{})
+	require.NoError(t, err)
+	assert.Equal(t, []any{"b", "c"}, change.Value)
+	assert.Equal(t, 5, change.Document.Version)
+
+	t.Run("Missing arrays", func(t *testing.T) {
+		change, err := db.InsertIntoDocumentArray(doc.ID, "owner", []string{"done"}, -1, "x", Precondition{})
+		require.NoError(t, err)
+		assert.Equal(t, []any{"x"}, change.Value, "a new array is started")
+		_, err = db.InsertIntoDocumentArray(doc.ID, "owner", []string{"later"}, 1, "x", Precondition{})
+		assert.ErrorIs(t, err, apperr.ErrValidation)
+		_, err = db.RemoveFromDocumentArray(doc.ID, "owner", []string{"later"}, 0, Precondition{})
+		assert.ErrorIs(t, err, apperr.ErrNotFound)
+	})
+
+	t.Run("Invalid targets", func(t *testing.T) {
+		_, err := db.InsertIntoDocumentArray(doc.ID, "owner", []string{"title"}, -1, "x", Precondition{})
+		assert.ErrorIs(t, err, apperr.ErrValidation, "not an array")
+		_, err = db.InsertIntoDocumentArray(doc.ID, "owner", todos, 3, "x", Precondition{})
+		assert.ErrorIs(t, err, apperr.ErrValidation)
+		_, err = db.RemoveFromDocumentArray(doc.ID, "owner", todos, 2, Precondition{})
+		assert.ErrorIs(t, err, apperr.ErrValidation)
+		_, err = db.RemoveFromDocumentArray(doc.ID, "owner", todos, 0, Precondition{Version: 1})
+		assert.ErrorIs(t, err, apperr.ErrConflict)
+	})
+
+	current, _ := db.GetDocumentByID(doc.ID)
+	assert.Equal(t, []any{"b", "c"}, current.Content.(map[string]any)["todos"])
+}
+
+func TestIncrementDocumentNumber(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	doc, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: map[string]any{"votes": 2.0, "title": "Poll"}})
+	require.NoError(t, err)
+
+	change, err := db.IncrementDocumentNumber(doc.ID, "owner", []string{"votes"}, 1.5, Precondition{Version: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 3.5, change.Value)
+	assert.Equal(t, 2.0, change.Previous)
+	change, err = db.IncrementDocumentNumber(doc.ID, "owner", []string{"stats", "views"}, -1, Precondition{})
+	require.NoError(t, err)
+	assert.Equal(t, -1.0, change.Value, "a missing value counts as 0")
+
+	_, err = db.IncrementDocumentNumber(doc.ID, "owner", []string{"title"}, 1, Precondition{})
+	assert.ErrorIs(t, err, apperr.ErrValidation)
+	_, err = db.IncrementDocumentNumber(doc.ID, "owner", []string{"votes"}, math.MaxFloat64, Precondition{})
+	require.NoError(t, err)
+	_, err = db.IncrementDocumentNumber(doc.ID, "owner", []string{"votes"}, math.MaxFloat64, Precondition{})
+	assert.ErrorIs(t, err, apperr.ErrValidation, "too large")
+
+	t.Run("Concurrent increments", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := db.IncrementDocumentNumber(doc.ID, "owner", []string{"counter"}, 1, Precondition{})
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+		current, _ := db.GetDocumentByID(doc.ID)
+		assert.Equal(t, 50.0, current.Content.(map[string]any)["counter"])
+	})
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/i18n"
+	"docserver/models"
+	"encoding/csv"
+	"strings"
+)
+
+// --- Content Types ---
+
+// NormalizeContentType checks a declared content type and returns it the way it is stored:
+// lowercased, with JSON (the default) stored as "". Unknown types are an apperr.ErrValidation error.
+func NormalizeContentType(contentType string) (string, error) {
+	switch normalized := strings.ToLower(strings.TrimSpace(contentType)); normalized {
+	case "", models.ContentTypeJSON:
+		return "", nil
+	case models.ContentTypeMarkdown, models.ContentTypeText, models.ContentTypeCSV:
+		return normalized, nil
+	default:
+		return "", apperr.Wrap(apperr.ErrValidation, i18n.NewError(i18n.MsgInvalidContentType, contentType))
+	}
+}
+
+// IsTextContent reports whether documents of the (normalized) content type hold raw text
+// rather than JSON, so content queries treat them as plain text.
+func IsTextContent(contentType string) bool {
+	return contentType != ""
+}
+
+// checkContent returns an apperr.ErrValidation error if content does not fit the (normalized)
+// content type: text types need a string, and CSV must parse.
+func checkContent(contentType string, content any) error {
+	if !IsTextContent(contentType) {
+		return nil
+	}
+	text, isString := content.(string)
+	if !isString {
+		return apperr.Wrap(apperr.ErrValidation, i18n.NewError(i18n.MsgContentNotText, contentType))
+	}
+	if contentType == models.ContentTypeCSV {
+		reader := csv.NewReader(strings.NewReader(text))
+		reader.FieldsPerRecord = 0 // All records as long as the first
+		if _, err := reader.ReadAll(); err != nil {
+			return apperr.Wrap(apperr.ErrValidation, i18n.NewError(i18n.MsgContentInvalidCSV, err))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/models"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeContentType(t *testing.T) {
+	for input, expected := range map[string]string{"": "", "json": "", "JSON": "", " Markdown ": "markdown", "text": "text", "csv": "csv"} {
+		normalized, err := NormalizeContentType(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, normalized, input)
+	}
+	_, err := NormalizeContentType("yaml")
+	assert.True(t, errors.Is(err, apperr.ErrValidation), err)
+}
+
+func TestCheckContent(t *testing.T) {
+	assert.NoError(t, checkContent("", map[string]any{"a": 1}), "JSON accepts anything")
+	assert.NoError(t, checkContent(models.ContentTypeMarkdown, "# Title"))
+	assert.NoError(t, checkContent(models.ContentTypeCSV, "a,b\n1,2\n"))
+
+	err := checkContent(models.ContentTypeText, 42.0)
+	assert.True(t, errors.Is(err, apperr.ErrValidation), err)
+	err = checkContent(models.ContentTypeCSV, "a,b\n1,2,3\n")
+	assert.True(t, errors.Is(err, apperr.ErrValidation), "rows must be as long as the header")
+}
+
+func TestDatabase_DocumentContentTypes(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	notes, err := db.CreateDocument(models.Document{OwnerID: "u1", ContentType: "Markdown", Content: "# Lab notes\nThe *result* holds."})
+	require.NoError(t, err)
+	assert.Equal(t, models.ContentTypeMarkdown, notes.ContentType)
+	_, err = db.CreateDocument(models.Document{OwnerID: "u1", Content: map[string]any{"title": "Lab notes"}})
+	require.NoError(t, err)
+
+	t.Run("Declared type is checked", func(t *testing.T) {
+		_, err := db.CreateDocument(models.Document{OwnerID: "u1", ContentType: "text", Content: []any{"x"}})
+		assert.True(t, errors.Is(err, apperr.ErrValidation), err)
+		_, err = db.UpdateDocument(notes.ID, map[string]any{"a": 1})
+		assert.True(t, errors.Is(err, apperr.ErrValidation), "updates keep the type")
+		_, err = db.UpdateDocumentAs(notes.ID, "x", "xml")
+		assert.True(t, errors.Is(err, apperr.ErrValidation), err)
+	})
+
+	t.Run("Content queries see plain text", func(t *testing.T) {
+		docs, _, err := db.QueryDocuments(QueryDocumentsParams{AuthUserID: "u1", Scope: "owned", ContentQuery: []string{`contains-insensitive "RESULT"`}})
+		require.NoError(t, err)
+		require.Len(t, docs, 1)
+		assert.Equal(t, notes.ID, docs[0].ID)
+
+		docs, _, err = db.QueryDocuments(QueryDocumentsParams{AuthUserID: "u1", Scope: "owned", ContentQuery: []string{`title equals "Lab notes"`}})
+		require.NoError(t, err)
+		require.Len(t, docs, 1, "paths never match text documents")
+		assert.NotEqual(t, notes.ID, docs[0].ID)
+	})
+
+	t.Run("Type can be changed", func(t *testing.T) {
+		updated, err := db.UpdateDocumentAs(notes.ID, map[string]any{"done": true}, "json")
+		require.NoError(t, err)
+		assert.Empty(t, updated.ContentType)
+	})
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/config"
+	"docserver/models"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+)
+
+// --- Course Databases ---
+
+// CreateCourse registers a course database. The course ID and audience must not be taken by
+// another course; both are validated at handler level otherwise.
+func (db *Database) CreateCourse(course models.Course) (models.Course, error) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	if _, taken := db.Database.Courses[course.ID]; taken {
+		return models.Course{}, apperr.Conflict("course '%s' already exists", course.ID)
+	}
+	for _, other := range db.Database.Courses {
+		if other.Audience == course.Audience {
+			return models.Course{}, apperr.Conflict("audience '%s' is used by course '%s'", course.Audience, other.ID)
+		}
+	}
+	course.CreationDate = db.now().UTC()
+	db.Database.Courses[course.ID] = course
+	log.Printf("AUDIT: Profile ID %s created course %s", course.CreatedBy, course.ID)
+
+	db.requestSave()
+	return course, nil
+}
+
+// GetCourse returns the course with the given ID.
+func (db *Database) GetCourse(id string) (models.Course, bool) {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+	course, found := db.Database.Courses[id]
+	return course, found
+}
+
+// ListCourses returns all courses, ordered by ID.
+func (db *Database) ListCourses() []models.Course {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+
+	courses := make([]models.Course, 0, len(db.Database.Courses))
+	for _, course := range db.Database.Courses {
+		courses = append(courses, course)
+	}
+	sort.Slice(courses, func(i, j int) bool { return courses[i].ID < courses[j].ID })
+	return courses
+}
+
+// DeleteCourse removes a course from the list. Its database files are left alone (see ArchiveStorage).
+func (db *Database) DeleteCourse(id string) error {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	if _, found := db.Database.Courses[id]; !found {
+		return apperr.NotFound("course '%s' not found", id)
+	}
+	delete(db.Database.Courses, id)
+	log.Printf("AUDIT: Course %s dropped", id)
+
+	db.requestSave()
+	return nil
+}
+
+// ArchiveStorage moves the files of the database configured by cfg aside, renaming the database
+// file and its backup, or the data directory, to <name>.dropped-<time>, so a database created
+// under the same name starts empty. It returns the new names; files that do not exist are skipped.
+func ArchiveStorage(cfg *config.Config, at time.Time) ([]string, error) {
+	if cfg.Ephemeral {
+		return nil, nil // Nothing was written
+	}
+	paths := []string{cfg.DbFilePath, cfg.DbFilePath + ".bak"}
+	if cfg.DataDir != "" {
+		paths = []string{cfg.DataDir}
+	}
+	var archived []string
+	for _, path := range paths {
+		if !fileExists(path) {
+			continue
+		}
+		target := fmt.Sprintf("%s.dropped-%s", path, at.UTC().Format("20060102T150405Z"))
+		if err := os.Rename(path, target); err != nil {
+			return archived, err
+		}
+		archived = append(archived, target)
+	}
+	return archived, nil
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/models"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCourses(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	web, err := db.CreateCourse(models.Course{ID: "web", Name: "Web Development", Audience: "course:web", CreatedBy: "admin"})
+	require.NoError(t, err)
+	assert.False(t, web.CreationDate.IsZero())
+	_, err = db.CreateCourse(models.Course{ID: "algo", Audience: "course:algo"})
+	require.NoError(t, err)
+
+	_, err = db.CreateCourse(models.Course{ID: "web", Audience: "course:other"})
+	assert.ErrorIs(t, err, apperr.ErrConflict, "the ID is taken")
+	_, err = db.CreateCourse(models.Course{ID: "other", Audience: "course:web"})
+	assert.ErrorIs(t, err, apperr.ErrConflict, "the audience is taken")
+
+	course, found := db.GetCourse("web")
+	require.True(t, found)
+	assert.Equal(t, "Web Development", course.Name)
+	courses := db.ListCourses()
+	require.Len(t, courses, 2)
+	assert.Equal(t, "algo", courses[0].ID, "ordered by ID")
+
+	require.NoError(t, db.DeleteCourse("web"))
+	_, found = db.GetCourse("web")
+	assert.False(t, found)
+	assert.ErrorIs(t, db.DeleteCourse("web"), apperr.ErrNotFound)
+}
+
+func TestArchiveStorage(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Moves the database file and its backup aside", func(t *testing.T) {
+		cfg := createTestConfig(t, t.TempDir())
+		require.NoError(t, os.WriteFile(cfg.DbFilePath, []byte("{}"), 0644))
+		require.NoError(t, os.WriteFile(cfg.DbFilePath+".bak", []byte("{}"), 0644))
+
+		archived, err := ArchiveStorage(cfg, at)
+		require.NoError(t, err)
+		assert.Equal(t, []string{cfg.DbFilePath + ".dropped-20240301T120000Z", cfg.DbFilePath + ".bak.dropped-20240301T120000Z"}, archived)
+		assert.NoFileExists(t, cfg.DbFilePath)
+		assert.FileExists(t, archived[0])
+	})
+
+	t.Run("Moves the data directory aside", func(t *testing.T) {
+		cfg := createTestConfig(t, t.TempDir())
+		cfg.DataDir = filepath.Join(filepath.Dir(cfg.DbFilePath), "data")
+		require.NoError(t, os.MkdirAll(cfg.DataDir, 0755))
+
+		archived, err := ArchiveStorage(cfg, at)
+		require.NoError(t, err)
+		assert.Equal(t, []string{cfg.DataDir + ".dropped-20240301T120000Z"}, archived)
+		assert.NoDirExists(t, cfg.DataDir)
+	})
+
+	t.Run("Skips a database that was never saved", func(t *testing.T) {
+		cfg := createTestConfig(t, t.TempDir())
+		archived, err := ArchiveStorage(cfg, at)
+		require.NoError(t, err)
+		assert.Empty(t, archived)
+	})
+}
@@ -0,0 +1,163 @@
+package db
+
+import (
+	"docserver/models"
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// --- Teacher Dashboard ---
+
+// DashboardUser summarizes one profile's documents and API use.
+type DashboardUser struct {
+	ProfileID       string     `json:"profile_id"`
+	FirstName       string     `json:"first_name"`
+	LastName        string     `json:"last_name"`
+	Email           string     `json:"email"`
+	Documents       int        `json:"documents"`                  // Documents they own
+	PublicDocuments int        `json:"public_documents"`           // Of those, how many are public
+	SharedDocuments int        `json:"shared_documents"`           // Of those, how many are shared with someone
+	ContentBytes    int64      `json:"content_bytes"`              // Size of their documents' current content (JSON-encoded)
+	Requests        int64      `json:"requests"`                   // API requests they made
+	LastActivityAt  *time.Time `json:"last_activity_at,omitempty"` // UTC; their latest change to any document
+}
+
+// DashboardActivity is a document event, with the document it happened to.
+type DashboardActivity struct {
+	DocumentID string `json:"document_id"`
+	models.DocumentEvent
+}
+
+// DashboardStorage tells how much data the server holds.
+type DashboardStorage struct {
+	Profiles     int   `json:"profiles"`
+	Documents    int   `json:"documents"`
+	Versions     int   `json:"versions"`      // Content versions kept for diffs, current ones included
+	ContentBytes int64 `json:"content_bytes"` // Current content of all documents (JSON-encoded)
+	VersionBytes int64 `json:"version_bytes"` // Content of the kept versions (JSON-encoded)
+}
+
+// DashboardQueries tells how much the server is asked.
+type DashboardQueries struct {
+	DocumentQueries int64 `json:"document_queries"` // Document lists and searches since the server started
+	ContentQueries  int64 `json:"content_queries"`  // Of those, how many filtered on content
+	SlowQueries     int   `json:"slow_queries"`     // Content queries currently in the slow query log
+	CacheHits       int64 `json:"cache_hits"`       // Document queries answered from the query cache
+	CacheMisses     int64 `json:"cache_misses"`     // Document queries the cache had no current result for
+	APIRequests     int64 `json:"api_requests"`     // Authenticated API requests, as in the API usage reports
+	APIErrors       int64 `json:"api_errors"`       // Of those, how many failed
+}
+
+// Dashboard combines what an instructor wants to see at a glance.
+type Dashboard struct {
+	GeneratedAt    time.Time           `json:"generated_at"`    // UTC
+	Users          []DashboardUser     `json:"users"`           // Most documents first
+	RecentActivity []DashboardActivity `json:"recent_activity"` // Newest first
+	Storage        DashboardStorage    `json:"storage"`
+	Queries        DashboardQueries    `json:"queries"`
+}
+
+// GetDashboard builds the dashboard from one read of the database, with the activityLimit most
+// recent document events. Each collection is looked at once, so it costs about as much as one
+// document list rather than one call per user.
+func (db *Database) GetDashboard(activityLimit int) Dashboard {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+
+	dashboard := Dashboard{
+		GeneratedAt:    db.now().UTC(),
+		Users:          make([]DashboardUser, 0, len(db.Database.Profiles)),
+		RecentActivity: []DashboardActivity{},
+		Storage: DashboardStorage{
+			Profiles:  len(db.Database.Profiles),
+			Documents: len(db.Database.Documents),
+		},
+		Queries: DashboardQueries{
+			DocumentQueries: db.documentQueries.Load(),
+			ContentQueries:  db.contentQueries.Load(),
+			SlowQueries:     len(db.GetSlowQueries()),
+			CacheHits:       db.queryCache.hits.Load(),
+			CacheMisses:     db.queryCache.misses.Load(),
+		},
+	}
+
+	profileIDs := make([]string, 0, len(db.Database.Profiles))
+	for profileID := range db.Database.Profiles {
+		profileIDs = append(profileIDs, profileID)
+	}
+	usage := db.currentAPIUsage(profileIDs...)
+	users := make(map[string]*DashboardUser, len(profileIDs))
+	for _, profileID := range profileIDs {
+		profile := db.Database.Profiles[profileID]
+		report := apiUsageReport(profileID, usage[profileID])
+		dashboard.Queries.APIRequests += report.Requests
+		dashboard.Queries.APIErrors += report.Errors
+		users[profileID] = &DashboardUser{
+			ProfileID: profileID,
+			FirstName: profile.FirstName,
+			LastName:  profile.LastName,
+			Email:     profile.Email,
+			Requests:  report.Requests,
+		}
+	}
+
+	for _, doc := range db.Database.Documents {
+		var size int64
+		if encoded, err := json.Marshal(doc.Content); err == nil {
+			size = int64(len(encoded))
+		}
+		dashboard.Storage.ContentBytes += size
+		user, found := users[doc.OwnerID]
+		if !found {
+			continue // Orphaned documents still take up space
+		}
+		user.Documents++
+		user.ContentBytes += size
+		if doc.Public {
+			user.PublicDocuments++
+		}
+		if record, found := db.Database.ShareRecords[doc.ID]; found && len(record.SharedWith) > 0 {
+			user.SharedDocuments++
+		}
+	}
+
+	for _, versions := range db.Database.DocumentVersions {
+		dashboard.Storage.Versions += len(versions)
+		for _, version := range versions {
+			if encoded, err := json.Marshal(version.Content); err == nil {
+				dashboard.Storage.VersionBytes += int64(len(encoded))
+			}
+		}
+	}
+
+	for docID, events := range db.Database.DocumentEvents {
+		for _, event := range events {
+			if user, found := users[event.ActorID]; found {
+				user.LastActivityAt = latest(user.LastActivityAt, event.Timestamp)
+			}
+			dashboard.RecentActivity = append(dashboard.RecentActivity, DashboardActivity{DocumentID: docID, DocumentEvent: event})
+		}
+	}
+	sort.SliceStable(dashboard.RecentActivity, func(i, j int) bool {
+		a, b := dashboard.RecentActivity[i], dashboard.RecentActivity[j]
+		if !a.Timestamp.Equal(b.Timestamp) {
+			return a.Timestamp.After(b.Timestamp)
+		}
+		return a.DocumentID < b.DocumentID
+	})
+	if activityLimit >= 0 && len(dashboard.RecentActivity) > activityLimit {
+		dashboard.RecentActivity = dashboard.RecentActivity[:activityLimit]
+	}
+
+	for _, user := range users {
+		dashboard.Users = append(dashboard.Users, *user)
+	}
+	sort.Slice(dashboard.Users, func(i, j int) bool {
+		if dashboard.Users[i].Documents != dashboard.Users[j].Documents {
+			return dashboard.Users[i].Documents > dashboard.Users[j].Documents
+		}
+		return dashboard.Users[i].ProfileID < dashboard.Users[j].ProfileID
+	})
+	return dashboard
+}
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"docserver/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDashboard(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	teacher, err := db.CreateProfile(models.Profile{Email: "teacher@example.com"})
+	require.NoError(t, err)
+	student, err := db.CreateProfile(models.Profile{Email: "student@example.com"})
+	require.NoError(t, err)
+	doc, err := db.CreateDocument(models.Document{OwnerID: student.ID, Content: map[string]any{"answer": 42}})
+	require.NoError(t, err)
+	_, err = db.CreateDocument(models.Document{OwnerID: student.ID, Content: "draft", Public: true})
+	require.NoError(t, err)
+	_, err = db.CreateDocument(models.Document{OwnerID: "gone", Content: "orphan"})
+	require.NoError(t, err)
+	require.NoError(t, db.SetShareRecord(doc.ID, []string{teacher.ID}))
+	db.RecordAPIUsage(student.ID, "GET /documents", 200, 0, 10, time.Now())
+	db.RecordAPIUsage(student.ID, "GET /documents", 400, 0, 10, time.Now())
+	_, _, err = db.QueryDocuments(QueryDocumentsParams{AuthUserID: student.ID, ContentQuery: []string{"answer equals 42"}})
+	require.NoError(t, err)
+
+	dashboard := db.GetDashboard(2)
+	require.Len(t, dashboard.Users, 2)
+	assert.Equal(t, student.ID, dashboard.Users[0].ProfileID)
+	assert.Equal(t, 2, dashboard.Users[0].Documents)
+	assert.Equal(t, 1, dashboard.Users[0].PublicDocuments)
+	assert.Equal(t, 1, dashboard.Users[0].SharedDocuments)
+	assert.Equal(t, int64(len(`{"answer":42}`)+len(`"draft"`)), dashboard.Users[0].ContentBytes)
+	assert.Equal(t, int64(2), dashboard.Users[0].Requests)
+	assert.Equal(t, teacher.ID, dashboard.Users[1].ProfileID)
+
+	assert.Len(t, dashboard.RecentActivity, 2, "limited")
+	assert.False(t, dashboard.RecentActivity[0].Timestamp.Before(dashboard.RecentActivity[1].Timestamp), "newest first")
+
+	assert.Equal(t, 3, dashboard.Storage.Documents)
+	assert.Equal(t, dashboard.Users[0].ContentBytes+int64(len(`"orphan"`)), dashboard.Storage.ContentBytes, "orphans take up space too")
+	assert.Equal(t, int64(1), dashboard.Queries.DocumentQueries)
+	assert.Equal(t, int64(1), dashboard.Queries.ContentQueries)
+	assert.Equal(t, int64(2), dashboard.Queries.APIRequests)
+	assert.Equal(t, int64(1), dashboard.Queries.APIErrors)
+}
@@ -1,16 +1,24 @@
 package db
 
 import (
+	"context"
+	"crypto/sha256"
+	"docserver/apperr"
 	"docserver/config"
+	"docserver/i18n"
 	"docserver/models" // Corrected import path
-	"docserver/utils"  // Added for GenerateDashlessUUID
-	"encoding/json"
-	"fmt"              // Added for errors
+	"docserver/tracing"
+	"docserver/transform"
+	"docserver/utils"
 	"log"
 	"os"
-	"strings"          // Added for EqualFold
+	"path/filepath"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Database holds all application data and manages concurrent access
@@ -22,14 +30,24 @@ type Database struct {
 	saveTimer       *time.Timer   // Timer for debounced saving
 	savePending     bool          // Flag to indicate if a save is queued
 	saveMutex       sync.Mutex    // Mutex specifically for the save timer logic
-	otpStore        map[string]otpRecord // Temporary store for password reset OTPs
-	otpMutex        sync.Mutex    // Mutex for OTP store access
-}
-
-// otpRecord stores the OTP and its expiry time
-type otpRecord struct {
-	otp    string
-	expiry time.Time
+	persistMutex    sync.Mutex    // Held while the database file is written, so saves do not overlap
+	changes         atomic.Uint64 // Counts changes made (see requestSave)
+	savedChanges    atomic.Uint64 // Value of changes when the latest successful save read the state (see Unsaved)
+	transforms      *transform.Pipeline // Content transformations run on create/update (nil = none)
+	staged          bool          // A transaction's working copy (see WithTransaction): never written to disk
+	profileIndexes  *indexSet[models.Profile] // Unique indexes on Profiles, e.g. by email (see index.go)
+	persistHealth   persistHealth             // Outcome of the latest saves (see health.go)
+	slowQueries     slowQueryLog              // Content queries over the slow query threshold (see slow_queries.go)
+	passwordRehashes atomic.Int64            // Password hashes replaced at login since startup (see password_hashes.go)
+	documentQueries atomic.Int64             // Document queries run since startup (see dashboard.go)
+	contentQueries  atomic.Int64             // Of those, queries filtering on content
+	queryGeneration atomic.Uint64            // Bumped on every change, making cached query results stale (see query_cache.go)
+	queryCache      queryCache               // Latest query results, with -query-cache-size
+	notifications   notifier                 // Posts to notification channels (see notifications.go)
+	apiUsage        apiUsageBuffer            // API usage recorded since the last flush (see api_usage.go)
+	ids             IDSource                  // Generates IDs, e.g. in fixtures mode (nil = random IDs, see ids.go)
+	recovery        []RecoveryReport          // How damaged database files were recovered on load (see recovery.go)
+	layoutSums      map[string][sha256.Size]byte // Checksums of the data directory files as last written, guarded by persistMutex (see layout.go)
 }
 
 // NewDatabase creates and initializes a new Database instance.
@@ -37,21 +55,56 @@ type otpRecord struct {
 func NewDatabase(cfg *config.Config) (*Database, error) {
 	db := &Database{
 		Database: models.Database{ // Initialize the embedded struct
+			SchemaVersion: CurrentSchemaVersion,
 			Profiles:     make(map[string]models.Profile),
 			Documents:    make(map[string]models.Document),
 			ShareRecords: make(map[string]models.ShareRecord),
+			ErasureRequests: make(map[string]models.ErasureRequest),
+			Favorites:    make(map[string][]string),
+			DocumentEvents: make(map[string][]models.DocumentEvent),
+			DocumentVersions: make(map[string][]models.DocumentVersion),
+			Scripts:      make(map[string]models.Script),
+			Schedules:    make(map[string]models.Schedule),
+			ScheduleRuns: make(map[string][]models.ScheduleRun),
+			NotificationChannels: make(map[string]models.NotificationChannel),
+			EmailChanges: make(map[string]models.EmailChange),
+			OTPs:         make(map[string]models.OTPRecord),
+			OTPLockouts:  make(map[string]models.OTPLockout),
+			Invites:      make(map[string]models.Invite),
+			Reviews:      make(map[string]models.Review),
+			APIUsage:     make(map[string]map[string]models.APIUsageCounter),
+			Courses:      make(map[string]models.Course),
+			PendingShares: make(map[string][]models.PendingShare),
+			SignedURLUses: make(map[string]time.Time),
+			Devices:      make(map[string][]models.Device),
 			// mu is initialized automatically (zero value is usable)
 		},
 		config:   cfg,
-		otpStore: make(map[string]otpRecord),
-		// saveTimer, savePending, saveMutex, otpMutex are initialized automatically
+		// saveTimer, savePending, saveMutex are initialized automatically
 	}
 
 	// Assign config values needed by the embedded struct (if they were separate)
 	// Since we are embedding, we might access cfg directly or store copies if needed
 	// For now, we'll keep the config reference and access cfg.DbFilePath etc. directly in methods.
 
-	log.Printf("INFO: Initializing database with file: %s", cfg.DbFilePath)
+	if cfg.Fixtures {
+		db.ids = utils.NewSeededIDs(cfg.FixturesSeed)
+	}
+
+	if cfg.TransformsFile != "" {
+		pipeline, err := transform.LoadRules(cfg.TransformsFile)
+		if err != nil {
+			return nil, err
+		}
+		db.transforms = pipeline
+		log.Printf("INFO: Loaded %d content transformation rule(s) from %s", len(pipeline.Rules()), cfg.TransformsFile)
+	}
+
+	if cfg.Ephemeral {
+		log.Printf("WARN: ******** EPHEMERAL MODE: the database lives in memory only. Nothing is loaded from or saved to disk, and all data is lost when the server stops. ********")
+	} else {
+		log.Printf("INFO: Initializing database with file: %s", db.storageLocation())
+	}
 	err := db.Load()
 	if err != nil {
 		// Load handles logging specific errors (file not found vs. parse error)
@@ -68,26 +121,38 @@ func NewDatabase(cfg *config.Config) (*Database, error) {
 	return db, nil // Return db outside the error check
 } // Close the NewDatabase function
 		
-		// Load reads the database state from the JSON file specified in the configuration.
+		// Load reads the database state from the JSON file specified in the configuration, or from the
+		// files of the data directory (see layout.go). With -ephemeral nothing is read.
 		// If the file doesn't exist, it initializes an empty database state and logs a message.
-		// If the file exists but cannot be parsed, it logs a critical error and returns it.
+		// If the file exists but cannot be parsed, it is recovered from if possible (see recoverState),
+		// otherwise it logs a critical error and returns it.
 		func (db *Database) Load() error {
 			// Access embedded fields explicitly
 			db.Database.Mu.Lock() // Acquire write lock for loading (modifies the maps)
 			defer db.Database.Mu.Unlock()
+			defer db.rebuildIndexes() // Whatever was loaded, index it (runs before the unlock)
+			if db.config.Ephemeral {
+				return nil // Starts empty (see -ephemeral)
+			}
 		
-			fileData, err := os.ReadFile(db.config.DbFilePath)
+			fileData, single, err := db.readStorage()
 			if err != nil {
 		if os.IsNotExist(err) {
-			log.Printf("INFO: Database file '%s' not found. Initializing empty database.", db.config.DbFilePath)
+			log.Printf("INFO: Database file '%s' not found. Initializing empty database.", db.storageLocation())
 			// Ensure maps are initialized (already done in NewDatabase, but good practice here too)
 			db.Database.Profiles = make(map[string]models.Profile)
 			db.Database.Documents = make(map[string]models.Document)
 			db.Database.ShareRecords = make(map[string]models.ShareRecord)
 			return nil // Not an error if the file doesn't exist
 		}
+		if !single {
+			// A data directory file that could not be read or recovered: starting empty would overwrite it
+			log.Printf("CRITICAL: Failed to load data directory '%s': %v. Server startup might be affected.", db.storageLocation(), err)
+			db.ensureCollections()
+			return err
+		}
 		// Other file read errors
-		log.Printf("ERROR: Failed to read database file '%s': %v. Attempting to proceed with empty state.", db.config.DbFilePath, err)
+		log.Printf("ERROR: Failed to read database file '%s': %v. Attempting to proceed with empty state.", db.storageLocation(), err)
 		// Initialize empty maps just in case, although Load is usually called on an already initialized struct
 		db.Database.Profiles = make(map[string]models.Profile)
 		db.Database.Documents = make(map[string]models.Document)
@@ -97,27 +162,42 @@ func NewDatabase(cfg *config.Config) (*Database, error) {
 		return nil
 	}
 
-	// File exists, attempt to unmarshal
-	// We unmarshal directly into the embedded models.Database part
-	err = json.Unmarshal(fileData, &db.Database)
+	// File exists: check it, bring it up to the current schema and unmarshal it
+	state, report, err := db.decodeState(fileData)
+	if err != nil && single {
+		state, err = db.recoverState(fileData, err) // The files of a data directory were recovered one by one as they were read
+	}
 	if err != nil {
-		log.Printf("CRITICAL: Failed to parse JSON data from database file '%s': %v. Server startup might be affected.", db.config.DbFilePath, err)
-		// Do NOT wipe the in-memory state here if unmarshalling fails,
-		// but ensure maps are initialized before returning the critical error.
-		if db.Database.Profiles == nil {
-			db.Database.Profiles = make(map[string]models.Profile)
-		}
-		if db.Database.Documents == nil {
-			db.Database.Documents = make(map[string]models.Document)
-		}
-		if db.Database.ShareRecords == nil {
-			db.Database.ShareRecords = make(map[string]models.ShareRecord)
-		}
+		log.Printf("CRITICAL: Failed to parse or migrate database file '%s': %v. Server startup might be affected.", db.storageLocation(), err)
+		// Do NOT wipe the in-memory state here, but ensure maps are initialized before returning the critical error.
+		db.ensureCollections()
 		// Return the error so the caller (NewDatabase) knows it's critical.
 		return err
 	}
+	copyCollections(&db.Database, state, false)
 
-	// Ensure maps are not nil after unmarshalling (if the JSON file had null values for them)
+	db.Database.SchemaVersion = CurrentSchemaVersion
+	if len(report.Pending) > 0 {
+		log.Printf("INFO: Migrated database from schema version %d to %d. Scheduling save.", report.FromVersion, report.ToVersion)
+		db.requestSave()
+	}
+
+	log.Printf("INFO: Successfully loaded database from %s. Profiles: %d, Documents: %d, ShareRecords: %d",
+		db.storageLocation(), len(db.Database.Profiles), len(db.Database.Documents), len(db.Database.ShareRecords))
+
+	return nil
+}
+
+// SetTransforms replaces the content transformations run on document create/update (nil disables them).
+func (db *Database) SetTransforms(pipeline *transform.Pipeline) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+	db.transforms = pipeline
+}
+
+// ensureCollections initializes any nil collection maps.
+// Must be called with the write lock held.
+func (db *Database) ensureCollections() {
 	if db.Database.Profiles == nil {
 		db.Database.Profiles = make(map[string]models.Profile)
 	}
@@ -127,34 +207,131 @@ func NewDatabase(cfg *config.Config) (*Database, error) {
 	if db.Database.ShareRecords == nil {
 		db.Database.ShareRecords = make(map[string]models.ShareRecord)
 	}
-
-	log.Printf("INFO: Successfully loaded database from %s. Profiles: %d, Documents: %d, ShareRecords: %d",
-		db.config.DbFilePath, len(db.Database.Profiles), len(db.Database.Documents), len(db.Database.ShareRecords))
-
-	return nil
+	if db.Database.ErasureRequests == nil {
+		db.Database.ErasureRequests = make(map[string]models.ErasureRequest)
+	}
+	if db.Database.Favorites == nil {
+		db.Database.Favorites = make(map[string][]string)
+	}
+	if db.Database.DocumentEvents == nil {
+		db.Database.DocumentEvents = make(map[string][]models.DocumentEvent)
+	}
+	if db.Database.DocumentVersions == nil {
+		db.Database.DocumentVersions = make(map[string][]models.DocumentVersion)
+	}
+	if db.Database.Scripts == nil {
+		db.Database.Scripts = make(map[string]models.Script)
+	}
+	if db.Database.Schedules == nil {
+		db.Database.Schedules = make(map[string]models.Schedule)
+	}
+	if db.Database.ScheduleRuns == nil {
+		db.Database.ScheduleRuns = make(map[string][]models.ScheduleRun)
+	}
+	if db.Database.NotificationChannels == nil {
+		db.Database.NotificationChannels = make(map[string]models.NotificationChannel)
+	}
+	if db.Database.EmailChanges == nil {
+		db.Database.EmailChanges = make(map[string]models.EmailChange)
+	}
+	if db.Database.OTPs == nil {
+		db.Database.OTPs = make(map[string]models.OTPRecord)
+	}
+	if db.Database.OTPLockouts == nil {
+		db.Database.OTPLockouts = make(map[string]models.OTPLockout)
+	}
+	if db.Database.Invites == nil {
+		db.Database.Invites = make(map[string]models.Invite)
+	}
+	if db.Database.Reviews == nil {
+		db.Database.Reviews = make(map[string]models.Review)
+	}
+	if db.Database.APIUsage == nil {
+		db.Database.APIUsage = make(map[string]map[string]models.APIUsageCounter)
+	}
+	if db.Database.Courses == nil {
+		db.Database.Courses = make(map[string]models.Course)
+	}
+	if db.Database.PendingShares == nil {
+		db.Database.PendingShares = make(map[string][]models.PendingShare)
+	}
+	if db.Database.SignedURLUses == nil {
+		db.Database.SignedURLUses = make(map[string]time.Time)
+	}
+	if db.Database.Devices == nil {
+		db.Database.Devices = make(map[string][]models.Device)
+	}
 }
 
 // --- Placeholder for Save/Persist logic ---
-// persist saves the current database state to the JSON file.
-// This is the actual file writing logic, called by the debounced mechanism.
+// persist saves the current database state to the JSON file and records the outcome.
+// Called by the debounced mechanism.
 func (db *Database) persist() error {
+	if db.staged {
+		return nil // Saved by the transaction's commit
+	}
+	if db.config.Ephemeral {
+		return nil // Nothing is written (see -ephemeral)
+	}
+	if db.config.ReadOnly {
+		return ErrReadOnly
+	}
+	_, span := tracing.Start(context.Background(), "db.persist")
+	defer span.End()
+	span.SetAttributes(attribute.String("db.file", db.storageLocation()))
+	db.persistMutex.Lock()
+	err := db.writeFile()
+	db.persistMutex.Unlock()
+	tracing.RecordError(span, err)
+	db.persistHealth.record(err)
+	return err
+}
+
+// writeFile is the actual file writing logic of persist.
+func (db *Database) writeFile() error {
 	// Access embedded fields explicitly
 	db.Database.Mu.RLock() // Use Read Lock for marshalling the current state
 	defer db.Database.Mu.RUnlock()
 
 	log.Printf("DEBUG: Persist triggered. Marshalling database state...")
-	jsonData, err := json.MarshalIndent(&db.Database, "", "  ") // Use embedded struct
+	covered := db.changes.Load() // Every change noted so far is in the state marshalled below
+	jsonData, err := db.marshalState() // The embedded struct, with content deduplicated if enabled
 	if err != nil {
 		log.Printf("ERROR: Failed to marshal database state to JSON: %v", err)
 		return err // Don't proceed if marshalling fails
 	}
 
+	if db.config.DataDir != "" {
+		err = db.writeLayout(jsonData)
+	} else {
+		if db.config.Checksum {
+			jsonData = appendChecksum(jsonData)
+		}
+		err = db.writeAtomic(db.config.DbFilePath, jsonData)
+	}
+	if err != nil {
+		return err
+	}
+	db.savedChanges.Store(covered)
+
+	log.Printf("INFO: Successfully saved database state to %s", db.storageLocation())
+	return nil
+}
+
+// writeAtomic replaces the file at path with jsonData, keeping the previous file as <path>.bak
+// if backups are enabled.
+func (db *Database) writeAtomic(path string, jsonData []byte) error {
 	// --- Atomic Write ---
-	tempFilePath := db.config.DbFilePath + ".tmp"
-	backupFilePath := db.config.DbFilePath + ".bak"
+	var err error
+	tempFilePath := path + ".tmp"
+	backupFilePath := path + ".bak"
 
-	// Write to temporary file first
-	err = os.WriteFile(tempFilePath, jsonData, 0644) // Sensible default permissions
+	// Write to temporary file first, synced (with -fsync) so the rename below never exposes a partial file
+	if db.config.Fsync {
+		err = writeFileSynced(tempFilePath, jsonData, 0644) // Sensible default permissions
+	} else {
+		err = os.WriteFile(tempFilePath, jsonData, 0644)
+	}
 	if err != nil {
 		log.Printf("ERROR: Failed to write to temporary database file '%s': %v", tempFilePath, err)
 		return err
@@ -163,33 +340,37 @@ func (db *Database) persist() error {
 	// Handle backup if enabled
 	if db.config.EnableBackup {
 		// Check if original file exists before trying to back it up
-		if _, err := os.Stat(db.config.DbFilePath); err == nil {
+		if _, err := os.Stat(path); err == nil {
 			// Original file exists, attempt rename to .bak
-			err = os.Rename(db.config.DbFilePath, backupFilePath)
+			err = os.Rename(path, backupFilePath)
 			if err != nil {
 				// If rename fails (e.g., .bak exists and OS doesn't overwrite), log warning but continue
-				log.Printf("WARN: Failed to rename '%s' to '%s' for backup: %v. Proceeding with save.", db.config.DbFilePath, backupFilePath, err)
+				log.Printf("WARN: Failed to rename '%s' to '%s' for backup: %v. Proceeding with save.", path, backupFilePath, err)
 				// Optionally, attempt to remove existing .bak first: os.Remove(backupFilePath)
 			} else {
 				log.Printf("DEBUG: Created backup file: %s", backupFilePath)
 			}
 		} else if !os.IsNotExist(err) {
             // Some other error occurred checking the original file status
-            log.Printf("WARN: Error checking status of original DB file '%s' before backup: %v", db.config.DbFilePath, err)
+            log.Printf("WARN: Error checking status of original DB file '%s' before backup: %v", path, err)
         }
 	}
 
 	// Atomically rename temporary file to the final destination
-	err = os.Rename(tempFilePath, db.config.DbFilePath)
+	err = os.Rename(tempFilePath, path)
 	if err != nil {
-		log.Printf("ERROR: Failed to atomically rename temporary file '%s' to '%s': %v", tempFilePath, db.config.DbFilePath, err)
+		log.Printf("ERROR: Failed to atomically rename temporary file '%s' to '%s': %v", tempFilePath, path, err)
 		// Attempt to clean up the temp file
 		_ = os.Remove(tempFilePath)
 		// Consider trying to restore the backup if the rename failed? More complex.
 		return err
 	}
+	if db.config.Fsync {
+		if err := syncDir(filepath.Dir(path)); err != nil {
+			log.Printf("WARN: Failed to sync directory of '%s': %v", path, err)
+		}
+	}
 
-	log.Printf("INFO: Successfully saved database state to %s", db.config.DbFilePath)
 	return nil
 }
 
@@ -197,9 +378,20 @@ func (db *Database) persist() error {
 // --- Placeholder for Debounced Save logic ---
 // requestSave is called after every write operation to trigger a debounced save.
 func (db *Database) requestSave() {
+    db.queryGeneration.Add(1) // Whatever changed, cached query results may no longer hold
     db.saveMutex.Lock() // Lock the save timer logic
     defer db.saveMutex.Unlock()
 
+    // A transaction's working copy only notes that it changed; the commit saves
+    if db.staged {
+        db.savePending = true
+        return
+    }
+    if db.persistenceDisabled() {
+        return // Never saved (see -read-only and -ephemeral)
+    }
+    db.changes.Add(1)
+
     // Instant save if interval is zero or negative
     if db.config.SaveInterval <= 0 {
         log.Printf("DEBUG: Save interval <= 0, triggering immediate persist.")
@@ -243,50 +435,128 @@ func (db *Database) requestSave() {
 }
 
 // --- OTP Store Methods ---
+// OTPs are persisted with the rest of the data so a restart does not invalidate password resets in flight.
+
+// otpLockoutRetention is how long failed OTP verifications are remembered after the last one.
+const otpLockoutRetention = 24 * time.Hour
 
-// StoreOTP saves an OTP for a given email with an expiry time.
-// It uses otpMutex for thread-safe access to the otpStore map.
-func (db *Database) StoreOTP(email string, otp string, expiry time.Time) {
-	db.otpMutex.Lock()
-	defer db.otpMutex.Unlock()
-	db.otpStore[email] = otpRecord{otp: otp, expiry: expiry}
+// StoreOTP saves the hash of an OTP (see utils.HashOTP) for a given email with an expiry time,
+// replacing any earlier one. Expired OTPs of other emails, and lockouts not touched for
+// otpLockoutRetention, are dropped at the same time.
+func (db *Database) StoreOTP(email string, otpHash string, expiry time.Time) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	now := db.now()
+	for storedEmail, record := range db.Database.OTPs {
+		if now.After(record.ExpiresAt) {
+			delete(db.Database.OTPs, storedEmail)
+		}
+	}
+	for storedEmail, lockout := range db.Database.OTPLockouts {
+		if now.After(lockout.LockedUntil) && now.Sub(lockout.LastFailedAt) > otpLockoutRetention {
+			delete(db.Database.OTPLockouts, storedEmail)
+		}
+	}
+	db.Database.OTPs[email] = models.OTPRecord{OTPHash: otpHash, ExpiresAt: expiry}
 	log.Printf("DEBUG: Stored OTP for %s", email)
+
+	db.requestSave()
 }
 
-// RetrieveOTP fetches the stored OTP and expiry time for a given email.
-// It returns the otp, expiry time, and a boolean indicating if found.
-// It uses otpMutex for thread-safe access.
+// RetrieveOTP fetches the stored OTP hash and expiry time for a given email.
+// It returns the hash, expiry time, and a boolean indicating if found.
+// Expiry is checked by the caller (see utils.VerifyOTP).
 func (db *Database) RetrieveOTP(email string) (string, time.Time, bool) {
-	db.otpMutex.Lock() // Lock for reading and potential modification (if cleaning up expired)
-	defer db.otpMutex.Unlock()
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
 
-	record, found := db.otpStore[email]
+	record, found := db.Database.OTPs[email]
 	if !found {
 		return "", time.Time{}, false
 	}
+	return record.OTPHash, record.ExpiresAt, true
+}
 
-	// Optional: Check for expiry here and delete if expired?
-	// The VerifyOTP function in utils/auth.go already does this check,
-	// but doing it here too could help keep the store clean proactively.
-	// For now, we'll just return what we found.
-	// if time.Now().After(record.expiry) {
-	//     delete(db.otpStore, email)
-	//     log.Printf("DEBUG: Cleaned up expired OTP for %s during retrieval", email)
-	//     return "", time.Time{}, false
-	// }
+// RecordFailedOTPAttempt counts a wrong guess at an email's OTP. It returns the number of wrong
+// guesses at the current OTP (0 if the email has no OTP) and the time until which further
+// verifications are refused: backoffBase doubled for every consecutive failure, at most backoffMax.
+// A zero backoffBase disables the backoff.
+func (db *Database) RecordFailedOTPAttempt(email string, backoffBase, backoffMax time.Duration) (int, time.Time) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	record, found := db.Database.OTPs[email]
+	if !found {
+		return 0, time.Time{}
+	}
+	record.Attempts++
+	db.Database.OTPs[email] = record
+
+	now := db.now().UTC()
+	lockout := db.Database.OTPLockouts[email]
+	lockout.Failures++
+	lockout.LastFailedAt = now
+	lockout.LockedUntil = time.Time{}
+	if backoffBase > 0 {
+		lockout.LockedUntil = now.Add(otpBackoff(lockout.Failures, backoffBase, backoffMax))
+	}
+	db.Database.OTPLockouts[email] = lockout
+
+	db.requestSave()
+	return record.Attempts, lockout.LockedUntil
+}
+
+// otpBackoff returns base doubled for each failure after the first, capped at limit (if limit > 0).
+func otpBackoff(failures int, base, limit time.Duration) time.Duration {
+	backoff := base
+	for i := 1; i < failures; i++ {
+		if limit > 0 && backoff >= limit {
+			break
+		}
+		backoff *= 2
+	}
+	if limit > 0 && backoff > limit {
+		backoff = limit
+	}
+	return backoff
+}
+
+// OTPLockedUntil returns the time until which OTP verifications for an email are refused
+// (zero if they are not).
+func (db *Database) OTPLockedUntil(email string) time.Time {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+
+	return db.Database.OTPLockouts[email].LockedUntil
+}
+
+// ClearOTPLockout forgets an email's failed OTP verifications, e.g. after a successful one.
+func (db *Database) ClearOTPLockout(email string) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	if _, found := db.Database.OTPLockouts[email]; !found {
+		return
+	}
+	delete(db.Database.OTPLockouts, email)
 
-	return record.otp, record.expiry, true
+	db.requestSave()
 }
 
 // DeleteOTP removes the OTP record for a given email.
-// It uses otpMutex for thread-safe access.
 func (db *Database) DeleteOTP(email string) {
-	db.otpMutex.Lock()
-	defer db.otpMutex.Unlock()
-	delete(db.otpStore, email)
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	if _, found := db.Database.OTPs[email]; !found {
+		return
+	}
+	delete(db.Database.OTPs, email)
 	log.Printf("DEBUG: Deleted OTP for %s", email)
-}
 
+	db.requestSave()
+}
 
 // --- CRUD Methods: Profiles ---
 
@@ -297,26 +567,34 @@ func (db *Database) CreateProfile(profile models.Profile) (models.Profile, error
 	db.Database.Mu.Lock() // Full lock for checking uniqueness and writing
 	defer db.Database.Mu.Unlock()
 
-	// Check if email already exists (case-insensitive check recommended)
-	for _, existingProfile := range db.Database.Profiles {
-		if strings.EqualFold(existingProfile.Email, profile.Email) {
-			return models.Profile{}, fmt.Errorf("email '%s' already exists", profile.Email)
-		}
+	return db.createProfile(profile)
+}
+
+// createProfile does the work of CreateProfile. Must be called with the lock held.
+func (db *Database) createProfile(profile models.Profile) (models.Profile, error) {
+	// Check if email already exists (case-insensitive)
+	if _, taken := db.profileIDByEmail(profile.Email); taken {
+		return models.Profile{}, apperr.Wrap(apperr.ErrConflict, i18n.NewError(i18n.MsgEmailAlreadyExists, profile.Email))
+	}
+	if err := db.checkProfileExtra(profile.Extra); err != nil {
+		return models.Profile{}, err
 	}
 
 	// Assign ID, timestamps if not already set (should be done by handler ideally)
 	if profile.ID == "" {
-		// Assuming utils.GenerateDashlessUUID exists and is imported
-		// Need to add "docserver/utils" to imports
-		profile.ID = utils.GenerateDashlessUUID()
+		id, err := db.newID(utils.IDKindProfile, func(id string) bool { _, taken := db.Database.Profiles[id]; return taken })
+		if err != nil {
+			return models.Profile{}, err
+		}
+		profile.ID = id
 	}
-	now := time.Now().UTC()
+	now := db.now().UTC()
 	if profile.CreationDate.IsZero() {
 		profile.CreationDate = now
 	}
 	profile.LastModifiedDate = now // Always update last modified on create/update
 
-	db.Database.Profiles[profile.ID] = profile
+	db.putProfile(profile)
 	log.Printf("INFO: Created Profile ID: %s, Email: %s", profile.ID, profile.Email)
 
 	// Trigger save
@@ -330,9 +608,7 @@ func (db *Database) CreateProfile(profile models.Profile) (models.Profile, error
 func (db *Database) GetProfileByID(id string) (models.Profile, bool) {
 	db.Database.Mu.RLock() // Read lock is sufficient
 	defer db.Database.Mu.RUnlock()
-
-	profile, found := db.Database.Profiles[id]
-	return profile, found
+	return (&ReadView{db: db}).GetProfileByID(id)
 }
 
 // GetProfileByEmail retrieves a profile by its email address (case-insensitive).
@@ -341,12 +617,11 @@ func (db *Database) GetProfileByEmail(email string) (models.Profile, bool) {
 	db.Database.Mu.RLock() // Read lock
 	defer db.Database.Mu.RUnlock()
 
-	for _, profile := range db.Database.Profiles {
-		if strings.EqualFold(profile.Email, email) {
-			return profile, true
-		}
+	id, found := db.profileIDByEmail(email)
+	if !found {
+		return models.Profile{}, false
 	}
-	return models.Profile{}, false
+	return db.Database.Profiles[id], true
 }
 
 // UpdateProfile updates an existing profile.
@@ -359,24 +634,25 @@ func (db *Database) UpdateProfile(id string, updatedProfile models.Profile) (mod
 
 	existingProfile, found := db.Database.Profiles[id]
 	if !found {
-		return models.Profile{}, fmt.Errorf("profile with ID '%s' not found", id)
+		return models.Profile{}, apperr.NotFound("profile with ID '%s' not found", id)
 	}
 
 	// Preserve original creation date and ID
 	updatedProfile.ID = existingProfile.ID
 	updatedProfile.CreationDate = existingProfile.CreationDate
-	updatedProfile.LastModifiedDate = time.Now().UTC() // Update modification timestamp
+	updatedProfile.LastModifiedDate = db.now().UTC() // Update modification timestamp
+	updatedProfile.TokensNotBefore = existingProfile.TokensNotBefore // Only moved by password changes
+	updatedProfile.Service = existingProfile.Service // Only changed by SetServiceAccountScopes
+	updatedProfile.CalendarFeedID = existingProfile.CalendarFeedID // Only changed by RenewCalendarFeed and RevokeCalendarFeed
 	// Ensure email isn't changed to one that already exists (unless it's the same profile)
-	if !strings.EqualFold(existingProfile.Email, updatedProfile.Email) {
-		for _, p := range db.Database.Profiles {
-			if p.ID != id && strings.EqualFold(p.Email, updatedProfile.Email) {
-				return models.Profile{}, fmt.Errorf("cannot update profile, email '%s' already exists for another user", updatedProfile.Email)
-			}
-		}
+	if db.emailTakenByOther(id, updatedProfile.Email) {
+		return models.Profile{}, apperr.Conflict("cannot update profile, email '%s' already exists for another user", updatedProfile.Email)
+	}
+	if err := db.checkProfileExtraChange(existingProfile, updatedProfile); err != nil {
+		return models.Profile{}, err
 	}
 
-
-	db.Database.Profiles[id] = updatedProfile
+	db.putProfile(updatedProfile)
 	log.Printf("INFO: Updated Profile ID: %s", id)
 
 	// Trigger save
@@ -394,10 +670,10 @@ func (db *Database) DeleteProfile(id string) error {
 
 	_, found := db.Database.Profiles[id]
 	if !found {
-		return fmt.Errorf("profile with ID '%s' not found", id)
+		return apperr.NotFound("profile with ID '%s' not found", id)
 	}
 
-	delete(db.Database.Profiles, id)
+	db.removeProfile(id)
 	log.Printf("INFO: Deleted Profile ID: %s", id)
 
 	// TODO: Implement cascading delete for documents owned by this profile
@@ -423,36 +699,31 @@ func (db *Database) GetAllProfiles() []models.Profile {
 }
 
 // UpdateProfilePassword finds a profile by email and updates only its password hash.
+// Tokens issued before the change stop working: the profile's TokensNotBefore is moved to now
+// (to the second, the precision of a token's issue time).
 // Returns error if the email is not found.
 func (db *Database) UpdateProfilePassword(email string, newPasswordHash string) error {
  db.Database.Mu.Lock() // Full lock for read-modify-write
  defer db.Database.Mu.Unlock()
 
- var targetProfileID string
- found := false
-
  // Find the profile ID by email (case-insensitive)
- for id, profile := range db.Database.Profiles {
-  if strings.EqualFold(profile.Email, email) {
-   targetProfileID = id
-   found = true
-   break
-  }
- }
-
+ targetProfileID, found := db.profileIDByEmail(email)
  if !found {
-  return fmt.Errorf("profile with email '%s' not found", email)
+  return apperr.Wrap(apperr.ErrNotFound, i18n.NewError(i18n.MsgProfileEmailNotFound, email))
  }
 
  // Get the actual profile struct (must exist if found by email)
  profileToUpdate := db.Database.Profiles[targetProfileID]
 
- // Update hash and modification time
+ // Update hash and modification time, and revoke earlier tokens
+ now := db.now().UTC()
+ notBefore := now.Truncate(time.Second)
  profileToUpdate.PasswordHash = newPasswordHash
- profileToUpdate.LastModifiedDate = time.Now().UTC()
+ profileToUpdate.LastModifiedDate = now
+ profileToUpdate.TokensNotBefore = &notBefore
 
  // Save back to map
- db.Database.Profiles[targetProfileID] = profileToUpdate
+ db.putProfile(profileToUpdate)
  log.Printf("INFO: Updated password hash for Profile ID: %s (Email: %s)", targetProfileID, email)
 
  // Trigger save
@@ -461,37 +732,156 @@ func (db *Database) UpdateProfilePassword(email string, newPasswordHash string)
  return nil
 }
 
+// RehashProfilePassword replaces a profile's password hash with one of the same password made with
+// the current hashing parameters. Unlike UpdateProfilePassword it keeps issued tokens valid. Nothing
+// changes if the stored hash is no longer oldHash (the password changed meanwhile); the returned
+// bool reports whether the hash was replaced.
+func (db *Database) RehashProfilePassword(id, oldHash, newHash string) bool {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	profile, found := db.Database.Profiles[id]
+	if !found || profile.PasswordHash != oldHash {
+		return false
+	}
+	profile.PasswordHash = newHash
+	db.putProfile(profile)
+	log.Printf("INFO: Rehashed password of Profile ID %s with the current parameters", id)
+	db.passwordRehashes.Add(1)
+
+	db.requestSave()
+	return true
+}
+
+// AcceptTos records that a profile accepted the given terms of service version now.
+// Returns the updated profile, or an error if the profile does not exist.
+func (db *Database) AcceptTos(profileID, version string) (models.Profile, error) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	profile, found := db.Database.Profiles[profileID]
+	if !found {
+		return models.Profile{}, apperr.NotFound("profile with ID '%s' not found", profileID)
+	}
+
+	profile.TosAcceptance = &models.TosAcceptance{Version: version, AcceptedAt: db.now().UTC()}
+	db.putProfile(profile)
+	log.Printf("INFO: Profile ID %s accepted terms of service version %s", profileID, version)
 
+	db.requestSave()
+	return profile, nil
+}
 
 // --- CRUD Methods: Documents ---
 
 // CreateDocument adds a new document to the database.
+// If doc.ID is empty a new ID is generated; otherwise the supplied ID is used
+// and an "already exists" error is returned if it is taken.
 func (db *Database) CreateDocument(doc models.Document) (models.Document, error) {
 	db.Database.Mu.Lock()
 	defer db.Database.Mu.Unlock()
 
+	doc, err := db.createDocument(doc)
+	if err != nil {
+		return models.Document{}, err
+	}
+	log.Printf("INFO: Created Document ID: %s, OwnerID: %s", doc.ID, doc.OwnerID)
+
+	// Trigger save
+	db.requestSave()
+
+	return doc, nil
+}
+
+// createDocumentsBatch is how many documents CreateDocuments creates per hold of the lock.
+const createDocumentsBatch = 1000
+
+// CreateDocuments adds many documents, each as CreateDocument would, logging a summary instead of
+// every document. The lock is taken for batches of them, so requests are served in between. It
+// stops at the first document that cannot be created and returns the ones created before it.
+func (db *Database) CreateDocuments(docs []models.Document) ([]models.Document, error) {
+	created := make([]models.Document, 0, len(docs))
+	for start := 0; start < len(docs); start += createDocumentsBatch {
+		err := func() error {
+			db.Database.Mu.Lock()
+			defer db.Database.Mu.Unlock()
+			defer db.requestSave()
+			for _, doc := range docs[start:min(start+createDocumentsBatch, len(docs))] {
+				doc, err := db.createDocument(doc)
+				if err != nil {
+					return err
+				}
+				created = append(created, doc)
+			}
+			return nil
+		}()
+		if err != nil {
+			log.Printf("INFO: Created %d of %d documents", len(created), len(docs))
+			return created, err
+		}
+	}
+	log.Printf("INFO: Created %d documents", len(created))
+	return created, nil
+}
+
+// createDocument does the work of CreateDocument. Must be called with the lock held.
+func (db *Database) createDocument(doc models.Document) (models.Document, error) {
 	if doc.OwnerID == "" {
 		// This should ideally be validated at the handler level
-		return models.Document{}, fmt.Errorf("document must have an OwnerID")
+		return models.Document{}, apperr.Validation("document must have an OwnerID")
 	}
 	// Check if owner profile exists? Optional, depends on desired strictness.
 	// _, ownerExists := db.Database.Profiles[doc.OwnerID]
 	// if !ownerExists {
-	// 	 return models.Document{}, fmt.Errorf("owner profile with ID '%s' not found", doc.OwnerID)
+	// 	 return models.Document{}, apperr.NotFound("owner profile with ID '%s' not found", doc.OwnerID)
 	// }
 
-	// Assign ID and timestamps
-	doc.ID = utils.GenerateDashlessUUID()
-	now := time.Now().UTC()
+	// Assign ID (unless the caller supplied one) and timestamps
+	if doc.ID == "" {
+		id, err := db.newID(utils.IDKindDocument, func(id string) bool { _, taken := db.Database.Documents[id]; return taken })
+		if err != nil {
+			return models.Document{}, err
+		}
+		doc.ID = id
+	} else if _, exists := db.Database.Documents[doc.ID]; exists {
+		return models.Document{}, apperr.Wrap(apperr.ErrConflict, i18n.NewError(i18n.MsgDocumentAlreadyExists, doc.ID))
+	}
+	contentType, err := NormalizeContentType(doc.ContentType)
+	if err != nil {
+		return models.Document{}, err
+	}
+	doc.ContentType = contentType
+	if doc.Links, err = NormalizeLinks(doc.ID, doc.Links); err != nil {
+		return models.Document{}, err
+	}
+	content, err := db.transforms.Apply(doc.Content)
+	if err != nil {
+		return models.Document{}, err
+	}
+	doc.Content = content
+	if doc.Content, err = db.runDocumentScripts(models.ScriptEventCreate, doc, nil); err != nil {
+		return models.Document{}, err
+	}
+	doc.Content = utils.NormalizeTextJSON(doc.Content) // Stored in NFC, so searches match however the text was typed
+	if err := checkContent(doc.ContentType, doc.Content); err != nil {
+		return models.Document{}, err
+	}
+
+	now := db.now().UTC()
+	if err := checkExpiresAt(doc.ExpiresAt, now); err != nil {
+		return models.Document{}, err
+	}
+	if doc.ExpiresAt != nil {
+		at := doc.ExpiresAt.UTC()
+		doc.ExpiresAt = &at
+	}
 	doc.CreationDate = now
 	doc.LastModifiedDate = now
+	doc.Version = 1
 
 	db.Database.Documents[doc.ID] = doc
-	log.Printf("INFO: Created Document ID: %s, OwnerID: %s", doc.ID, doc.OwnerID)
-
-	// Trigger save
-	db.requestSave()
-
+	db.recordDocumentVersion(doc)
+	db.recordDocumentEvent(doc.ID, models.DocumentEvent{Type: models.EventCreated, ActorID: doc.OwnerID, Version: doc.Version})
 	return doc, nil
 }
 
@@ -499,9 +889,7 @@ func (db *Database) CreateDocument(doc models.Document) (models.Document, error)
 func (db *Database) GetDocumentByID(id string) (models.Document, bool) {
 	db.Database.Mu.RLock()
 	defer db.Database.Mu.RUnlock()
-
-	doc, found := db.Database.Documents[id]
-	return doc, found
+	return (&ReadView{db: db}).GetDocumentByID(id)
 }
 
 // GetDocumentsByOwner retrieves all documents owned by a specific profile ID.
@@ -535,24 +923,168 @@ func (db *Database) GetAllDocuments() []models.Document {
 // UpdateDocument updates an existing document's content.
 // Only the owner can update the document (checked at handler level).
 func (db *Database) UpdateDocument(id string, newContent any) (models.Document, error) {
+	return db.UpdateDocumentIf(id, newContent, nil, Precondition{})
+}
+
+// UpdateDocumentIfUnchanged replaces a document's content like UpdateDocument, but only if it is
+// still as seen: at the same version and not modified since (coalesced updates keep the version).
+// Otherwise it returns an apperr.ErrConflict error and changes nothing.
+func (db *Database) UpdateDocumentIfUnchanged(seen models.Document, newContent any) (models.Document, error) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	existingDoc, found := db.Database.Documents[seen.ID]
+	if !found {
+		return models.Document{}, apperr.NotFound("document with ID '%s' not found", seen.ID)
+	}
+	if existingDoc.Version != seen.Version || !existingDoc.LastModifiedDate.Equal(seen.LastModifiedDate) {
+		return models.Document{}, apperr.Wrap(apperr.ErrConflict, i18n.NewError(i18n.MsgDocumentChanged, seen.ID))
+	}
+	if err := db.checkNotFrozen(seen.ID); err != nil {
+		return models.Document{}, err
+	}
+	return db.updateDocument(existingDoc, newContent, existingDoc.ContentType)
+}
+
+// UpdateDocumentAs replaces a document's content and changes its content type (see models.ContentTypeJSON and friends).
+func (db *Database) UpdateDocumentAs(id string, newContent any, contentType string) (models.Document, error) {
+	return db.UpdateDocumentIf(id, newContent, &contentType, Precondition{})
+}
+
+// UpdateDocumentIf replaces a document's content, and with a contentType, its content type, if the
+// document satisfies pre; otherwise it returns the error checkPrecondition gives.
+func (db *Database) UpdateDocumentIf(id string, newContent any, contentType *string, pre Precondition) (models.Document, error) {
 	db.Database.Mu.Lock()
 	defer db.Database.Mu.Unlock()
 
 	existingDoc, found := db.Database.Documents[id]
 	if !found {
-		return models.Document{}, fmt.Errorf("document with ID '%s' not found", id)
+		return models.Document{}, apperr.NotFound("document with ID '%s' not found", id)
+	}
+	if err := db.checkNotFrozen(id); err != nil {
+		return models.Document{}, err
+	}
+	newType := existingDoc.ContentType
+	if contentType != nil {
+		var err error
+		if newType, err = NormalizeContentType(*contentType); err != nil {
+			return models.Document{}, err
+		}
+	}
+	if err := db.checkPrecondition(existingDoc, pre); err != nil {
+		return models.Document{}, err
+	}
+	return db.updateDocument(existingDoc, newContent, newType)
+}
+
+// updateDocument stores new content, of the given normalized content type, as the next version
+// of a document. Must be called with the write lock held.
+func (db *Database) updateDocument(existingDoc models.Document, newContent any, contentType string) (models.Document, error) {
+	target := existingDoc
+	target.ContentType = contentType
+	newContent, err := db.prepareDocumentUpdate(target, newContent)
+	if err != nil {
+		return models.Document{}, err
+	}
+	if contentType == existingDoc.ContentType {
+		if updatedDoc, ok := db.coalesceUpdate(existingDoc, newContent); ok {
+			db.requestCoalescedSave()
+			return updatedDoc, nil
+		}
+	}
+	updatedDoc := db.storeDocumentUpdate(existingDoc, newContent, existingDoc.OwnerID)
+	if updatedDoc.ContentType != contentType {
+		updatedDoc.ContentType = contentType
+		db.Database.Documents[updatedDoc.ID] = updatedDoc
 	}
 
-	// Update content and timestamp
+	// Trigger save
+	db.requestSave()
+
+	return updatedDoc, nil
+}
+
+// prepareDocumentUpdate runs the content transformations and update scripts on the new content
+// of an existing document, normalizes its text to NFC (see utils.NormalizeText), checks it fits
+// the document's content type and returns the content to store. Must be called with the write
+// lock held.
+func (db *Database) prepareDocumentUpdate(existingDoc models.Document, newContent any) (any, error) {
+	newContent, err := db.transforms.Apply(newContent)
+	if err != nil {
+		return nil, err
+	}
+	scriptDoc := existingDoc
+	scriptDoc.Content = newContent
+	if newContent, err = db.runDocumentScripts(models.ScriptEventUpdate, scriptDoc, existingDoc.Content); err != nil {
+		return nil, err
+	}
+	newContent = utils.NormalizeTextJSON(newContent)
+	if err := checkContent(existingDoc.ContentType, newContent); err != nil {
+		return nil, err
+	}
+	return newContent, nil
+}
+
+// storeDocumentUpdate saves prepared content as the next version of a document and records the
+// update by actorID in its history and activity feed. Must be called with the write lock held.
+func (db *Database) storeDocumentUpdate(existingDoc models.Document, newContent any, actorID string) models.Document {
+	id := existingDoc.ID
+	changedPaths := utils.DiffJSON(existingDoc.Content, newContent).Paths()
+
+	// Documents created before version history existed start their history with the current content
+	if len(db.Database.DocumentVersions[id]) == 0 {
+		if existingDoc.Version == 0 {
+			existingDoc.Version = 1
+		}
+		db.recordDocumentVersion(existingDoc)
+	}
+
+	// Update content, version and timestamp
 	existingDoc.Content = newContent
-	existingDoc.LastModifiedDate = time.Now().UTC()
+	existingDoc.Version++
+	existingDoc.Revision = 0
+	existingDoc.LastModifiedDate = db.now().UTC()
 
 	db.Database.Documents[id] = existingDoc
+	db.recordDocumentVersion(existingDoc)
+	db.recordDocumentEvent(id, models.DocumentEvent{
+		Type:         models.EventUpdated,
+		ActorID:      actorID,
+		Version:      existingDoc.Version,
+		ChangedPaths: changedPaths,
+	})
 	log.Printf("INFO: Updated Document ID: %s", id)
+	return existingDoc
+}
 
-	// Trigger save
-	db.requestSave()
+// SetDocumentPublic marks a document as public (readable by anyone) or not.
+// Only the owner can change this (checked at handler level).
+func (db *Database) SetDocumentPublic(id string, public bool) (models.Document, error) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
 
+	existingDoc, found := db.Database.Documents[id]
+	if !found {
+		return models.Document{}, apperr.NotFound("document with ID '%s' not found", id)
+	}
+	if err := db.checkNotFrozen(id); err != nil {
+		return models.Document{}, err
+	}
+	if existingDoc.Public == public {
+		return existingDoc, nil
+	}
+
+	existingDoc.Public = public
+	existingDoc.LastModifiedDate = db.now().UTC()
+	db.Database.Documents[id] = existingDoc
+	eventType := models.EventUnpublished
+	if public {
+		eventType = models.EventPublished
+	}
+	db.recordDocumentEvent(id, models.DocumentEvent{Type: eventType, ActorID: existingDoc.OwnerID})
+	log.Printf("INFO: Set Document ID %s public: %t", id, public)
+
+	db.requestSave()
 	return existingDoc, nil
 }
 
@@ -563,11 +1095,24 @@ func (db *Database) DeleteDocument(id string) error {
 	db.Database.Mu.Lock()
 	defer db.Database.Mu.Unlock()
 
-	_, found := db.Database.Documents[id]
+	doc, found := db.Database.Documents[id]
 	if !found {
-		return fmt.Errorf("document with ID '%s' not found", id)
+		return apperr.NotFound("document with ID '%s' not found", id)
+	}
+	if _, err := db.runDocumentScripts(models.ScriptEventDelete, doc, doc.Content); err != nil {
+		return err
 	}
+	db.removeDocument(id)
 
+	// Trigger save
+	db.requestSave()
+
+	return nil
+}
+
+// removeDocument deletes a document with its share record, favorites, activity, versions and reviews.
+// Must be called with the write lock held.
+func (db *Database) removeDocument(id string) {
 	// Delete the document
 	delete(db.Database.Documents, id)
 	log.Printf("INFO: Deleted Document ID: %s", id)
@@ -578,11 +1123,11 @@ func (db *Database) DeleteDocument(id string) error {
 		delete(db.Database.ShareRecords, id)
 		log.Printf("INFO: Deleted associated ShareRecord for Document ID: %s", id)
 	}
-
-	// Trigger save
-	db.requestSave()
-
-	return nil
+	db.removeDocumentFromFavorites(id)
+	delete(db.Database.DocumentEvents, id)
+	delete(db.Database.DocumentVersions, id)
+	db.deleteReviewsOf(id)
+	db.deletePendingSharesOf(id)
 }
 
 
@@ -593,13 +1138,7 @@ func (db *Database) DeleteDocument(id string) error {
 func (db *Database) GetShareRecordByDocumentID(docID string) (models.ShareRecord, bool) {
 	db.Database.Mu.RLock()
 	defer db.Database.Mu.RUnlock()
-
-	record, found := db.Database.ShareRecords[docID]
-	// Ensure the DocumentID field is set (it's the key, but good practice)
-	if found {
-		record.DocumentID = docID
-	}
-	return record, found
+	return (&ReadView{db: db}).GetShareRecordByDocumentID(docID)
 }
 
 // SetShareRecord creates or replaces the entire share record for a document.
@@ -608,6 +1147,9 @@ func (db *Database) GetShareRecordByDocumentID(docID string) (models.ShareRecord
 func (db *Database) SetShareRecord(docID string, sharedWith []string) error {
 	db.Database.Mu.Lock()
 	defer db.Database.Mu.Unlock()
+	if err := db.checkNotFrozen(docID); err != nil {
+		return err
+	}
 
 	// Optional: Check if document exists?
 	// _, docFound := db.Database.Documents[docID]
@@ -633,12 +1175,14 @@ func (db *Database) SetShareRecord(docID string, sharedWith []string) error {
 		}
 	}
 
+	db.recordShareChanges(docID, db.Database.ShareRecords[docID].SharedWith, uniqueSharedWith)
 
 	if len(uniqueSharedWith) > 0 {
-		record := models.ShareRecord{
+		record := pruneShareScopes(models.ShareRecord{
 			DocumentID: docID, // Although not stored in JSON, useful internally
 			SharedWith: uniqueSharedWith,
-		}
+			Scopes:     db.Database.ShareRecords[docID].Scopes, // Kept for the profiles still shared with
+		})
 		db.Database.ShareRecords[docID] = record
 		log.Printf("INFO: Set/Updated ShareRecord for Document ID: %s, SharedWith: %d profiles", docID, len(uniqueSharedWith))
 	} else {
@@ -659,10 +1203,24 @@ func (db *Database) SetShareRecord(docID string, sharedWith []string) error {
 func (db *Database) AddSharerToDocument(docID, profileID string) error {
 	db.Database.Mu.Lock()
 	defer db.Database.Mu.Unlock()
+	if err := db.checkNotFrozen(docID); err != nil {
+		return err
+	}
 
 	// Optional: Check if document exists?
 	// Optional: Check if profile exists?
 
+	if db.addSharer(docID, profileID) {
+		// Trigger save
+		db.requestSave()
+	}
+	return nil
+}
+
+// addSharer adds profileID to the share list of a document, whether or not it is frozen, and
+// records the change. It reports whether the profile was not on the list yet.
+// Must be called with the write lock held.
+func (db *Database) addSharer(docID, profileID string) bool {
 	record, found := db.Database.ShareRecords[docID]
 	if !found {
 		// No existing record, create a new one
@@ -683,17 +1241,14 @@ func (db *Database) AddSharerToDocument(docID, profileID string) error {
 			record.SharedWith = append(record.SharedWith, profileID)
 		} else {
 			// Already shared, no change needed
-			return nil // Or return a specific indicator? For now, just return nil.
+			return false
 		}
 	}
 
 	db.Database.ShareRecords[docID] = record
+	db.recordShareChanges(docID, nil, []string{profileID})
 	log.Printf("INFO: Added Sharer '%s' to Document ID: %s", profileID, docID)
-
-	// Trigger save
-	db.requestSave()
-
-	return nil
+	return true
 }
 
 // RemoveSharerFromDocument removes a single profile ID from a document's share list.
@@ -702,6 +1257,9 @@ func (db *Database) AddSharerToDocument(docID, profileID string) error {
 func (db *Database) RemoveSharerFromDocument(docID, profileID string) error {
 	db.Database.Mu.Lock()
 	defer db.Database.Mu.Unlock()
+	if err := db.checkNotFrozen(docID); err != nil {
+		return err
+	}
 
 	record, found := db.Database.ShareRecords[docID]
 	if !found {
@@ -721,10 +1279,11 @@ func (db *Database) RemoveSharerFromDocument(docID, profileID string) error {
 	if foundIndex != -1 {
 		// Remove element by slicing
 		record.SharedWith = append(record.SharedWith[:foundIndex], record.SharedWith[foundIndex+1:]...)
+		db.recordShareChanges(docID, []string{profileID}, nil)
 
 		if len(record.SharedWith) > 0 {
 			// Update the record
-			db.Database.ShareRecords[docID] = record
+			db.Database.ShareRecords[docID] = pruneShareScopes(record)
 			log.Printf("INFO: Removed Sharer '%s' from Document ID: %s", profileID, docID)
 		} else {
 			// List is now empty, remove the whole record
@@ -743,10 +1302,65 @@ return nil
 }
 
 
+// GetSharingContacts returns the IDs of profiles connected to profileID through sharing:
+// profiles that profileID has shared a document with, and owners of documents shared with profileID.
+// Used to enforce the "sharers" profile field visibility.
+func (db *Database) GetSharingContacts(profileID string) map[string]bool {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+
+	contacts := make(map[string]bool)
+	for docID, record := range db.Database.ShareRecords {
+		doc, found := db.Database.Documents[docID]
+		if !found {
+			continue
+		}
+		if doc.OwnerID == profileID {
+			for _, sharedID := range record.SharedWith {
+				contacts[sharedID] = true
+			}
+			continue
+		}
+		for _, sharedID := range record.SharedWith {
+			if sharedID == profileID {
+				contacts[doc.OwnerID] = true
+				break
+			}
+		}
+	}
+	delete(contacts, profileID)
+	return contacts
+}
+
+// GetDocumentIDsSharedWith returns the IDs of documents owned by others that are shared with profileID, sorted.
+func (db *Database) GetDocumentIDsSharedWith(profileID string) []string {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+
+	docIDs := []string{}
+	for docID, record := range db.Database.ShareRecords {
+		for _, sharedID := range record.SharedWith {
+			if sharedID == profileID {
+				docIDs = append(docIDs, docID)
+				break
+			}
+		}
+	}
+	sort.Strings(docIDs)
+	return docIDs
+}
+
 // Close ensures any pending save operation is completed before shutdown.
 func (db *Database) Close() error {
 	var needsFinalPersist bool
 
+	db.waitForNotifications()
+
+	// Keep the API usage recorded since the last flush
+	db.Database.Mu.Lock()
+	usageFlushed := db.mergePendingAPIUsage() > 0
+	db.Database.Mu.Unlock()
+
 	db.saveMutex.Lock()
 	log.Printf("DEBUG: Closing database instance. Checking for pending save...")
 
@@ -758,11 +1372,15 @@ func (db *Database) Close() error {
 	}
 
 	// Check if a save was pending *after* stopping the timer
-	if db.savePending {
+	if db.savePending || usageFlushed {
 		needsFinalPersist = true
 		db.savePending = false // Reset flag under lock
 	}
 	db.saveMutex.Unlock() // Release lock before potentially calling persist
+	if needsFinalPersist && db.persistenceDisabled() {
+		log.Printf("INFO: Read-only or ephemeral mode: not saving the database on close.")
+		needsFinalPersist = false
+	}
 
 	// Perform persist outside the lock if needed
 	if needsFinalPersist {
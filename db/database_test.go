@@ -1,6 +1,7 @@
 package db
 
 import (
+	"docserver/apperr"
 	"docserver/config"
 	"docserver/models"
 	"encoding/json"
@@ -91,8 +92,7 @@ func TestDatabase_Load_FileNotFound(t *testing.T) {
 			Documents:    nil,
 			ShareRecords: nil,
 		},
-		config:   cfg,
-		otpStore: make(map[string]otpRecord),
+		config: cfg,
 	}
 
 	err := db.Load()
@@ -132,8 +132,7 @@ func TestDatabase_Load_ValidFile(t *testing.T) {
 
 	db := &Database{ // Create manually
 		Database: models.Database{},
-		config:   cfg,
-		otpStore: make(map[string]otpRecord),
+		config: cfg,
 	}
 
 	err := db.Load()
@@ -166,8 +165,7 @@ func TestDatabase_Load_InvalidJSON(t *testing.T) {
 
 	db := &Database{ // Create manually
 		Database: models.Database{},
-		config:   cfg,
-		otpStore: make(map[string]otpRecord),
+		config: cfg,
 	}
 
 	err := db.Load()
@@ -329,8 +327,8 @@ func TestDatabase_OTPStoreMethods(t *testing.T) {
 
 	email1 := "otp1@example.com"
 	email2 := "otp2@example.com"
-	otp1 := "111111"
-	otp2 := "222222"
+	otp1 := "otp-hash-1"
+	otp2 := "otp-hash-2"
 	expiry1 := time.Now().Add(5 * time.Minute)
 	expiry2 := time.Now().Add(-5 * time.Minute) // Expired
 
@@ -338,11 +336,11 @@ func TestDatabase_OTPStoreMethods(t *testing.T) {
 	db.StoreOTP(email1, otp1, expiry1)
 	db.StoreOTP(email2, otp2, expiry2)
 
-	assert.Len(t, db.otpStore, 2, "Should have 2 OTPs stored")
-	storedRecord1, found1 := db.otpStore[email1]
+	assert.Len(t, db.Database.OTPs, 2, "Should have 2 OTPs stored")
+	storedRecord1, found1 := db.Database.OTPs[email1]
 	require.True(t, found1, "OTP for email1 should be found in internal map")
-	assert.Equal(t, otp1, storedRecord1.otp, "Stored OTP for email1 mismatch")
-	assert.Equal(t, expiry1, storedRecord1.expiry, "Stored expiry for email1 mismatch")
+	assert.Equal(t, otp1, storedRecord1.OTPHash, "Stored OTP for email1 mismatch")
+	assert.Equal(t, expiry1, storedRecord1.ExpiresAt, "Stored expiry for email1 mismatch")
 
 	// 2. Retrieve Valid OTP
 	retrievedOtp1, retrievedExpiry1, foundRetrieve1 := db.RetrieveOTP(email1)
@@ -360,15 +358,88 @@ func TestDatabase_OTPStoreMethods(t *testing.T) {
 	_, _, foundRetrieve3 := db.RetrieveOTP("nonexistent@example.com")
 	assert.False(t, foundRetrieve3, "RetrieveOTP should not find non-existent email")
 
-	// 5. Delete OTP
+	// 5. Count wrong guesses
+	attempts, _ := db.RecordFailedOTPAttempt(email1, 0, 0)
+	assert.Equal(t, 1, attempts)
+	attempts, _ = db.RecordFailedOTPAttempt(email1, 0, 0)
+	assert.Equal(t, 2, attempts)
+	attempts, _ = db.RecordFailedOTPAttempt("nonexistent@example.com", 0, 0)
+	assert.Equal(t, 0, attempts, "No OTP, nothing to count")
+
+	// 6. Delete OTP
 	db.DeleteOTP(email1)
-	assert.Len(t, db.otpStore, 1, "Should have 1 OTP left after deleting email1")
-	_, foundAfterDelete := db.otpStore[email1]
+	assert.Len(t, db.Database.OTPs, 1, "Should have 1 OTP left after deleting email1")
+	_, foundAfterDelete := db.Database.OTPs[email1]
 	assert.False(t, foundAfterDelete, "OTP for email1 should not be found after deletion")
 
-	// 6. Delete Non-existent OTP (should not panic)
+	// 7. Delete Non-existent OTP (should not panic)
 	db.DeleteOTP("nonexistent@example.com")
-	assert.Len(t, db.otpStore, 1, "Deleting non-existent OTP should not change store size")
+	assert.Len(t, db.Database.OTPs, 1, "Deleting non-existent OTP should not change store size")
+
+	// 8. Storing a new OTP drops expired ones
+	db.StoreOTP(email1, otp1, expiry1)
+	_, expiredKept := db.Database.OTPs[email2]
+	assert.False(t, expiredKept, "Expired OTP should be dropped when another is stored")
+}
+
+func TestDatabase_OTPsSurviveRestart(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	expiry := time.Now().Add(5 * time.Minute).UTC()
+	db.StoreOTP("restart@example.com", "otp-hash", expiry)
+	db.RecordFailedOTPAttempt("restart@example.com", time.Minute, time.Hour)
+	require.NoError(t, db.persist())
+
+	reloaded, err := NewDatabase(db.config)
+	require.NoError(t, err)
+	record, found := reloaded.Database.OTPs["restart@example.com"]
+	require.True(t, found, "OTP should be loaded from the database file")
+	assert.Equal(t, "otp-hash", record.OTPHash)
+	assert.True(t, expiry.Equal(record.ExpiresAt))
+	assert.Equal(t, 1, record.Attempts)
+	assert.False(t, reloaded.OTPLockedUntil("restart@example.com").IsZero(), "Lockout should be loaded too")
+}
+
+func TestDatabase_OTPLockout(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	email := "lockout@example.com"
+
+	t.Run("Backoff doubles up to the maximum", func(t *testing.T) {
+		db.StoreOTP(email, "123456", time.Now().Add(time.Hour))
+		var waits []time.Duration
+		for i := 0; i < 5; i++ {
+			before := time.Now()
+			_, lockedUntil := db.RecordFailedOTPAttempt(email, time.Second, 5*time.Second)
+			waits = append(waits, lockedUntil.Sub(before).Round(time.Second))
+		}
+		assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}, waits)
+		assert.True(t, db.OTPLockedUntil(email).After(time.Now()))
+	})
+
+	t.Run("A new OTP keeps the lockout", func(t *testing.T) {
+		db.StoreOTP(email, "654321", time.Now().Add(time.Hour))
+		attempts, lockedUntil := db.RecordFailedOTPAttempt(email, time.Second, 5*time.Second)
+		assert.Equal(t, 1, attempts, "attempts are counted per OTP")
+		assert.InDelta(t, 5, time.Until(lockedUntil).Seconds(), 1, "backoff is counted per email")
+	})
+
+	t.Run("Clear", func(t *testing.T) {
+		db.ClearOTPLockout(email)
+		assert.True(t, db.OTPLockedUntil(email).IsZero())
+		_, lockedUntil := db.RecordFailedOTPAttempt(email, 0, 0)
+		assert.True(t, lockedUntil.IsZero(), "no backoff configured")
+	})
+
+	t.Run("Stale lockouts are pruned", func(t *testing.T) {
+		db.Database.OTPLockouts["stale@example.com"] = models.OTPLockout{Failures: 3, LastFailedAt: time.Now().Add(-48 * time.Hour)}
+		db.StoreOTP(email, "111111", time.Now().Add(time.Hour))
+		_, kept := db.Database.OTPLockouts["stale@example.com"]
+		assert.False(t, kept)
+		_, kept = db.Database.OTPLockouts[email]
+		assert.True(t, kept)
+	})
 }
 
 
@@ -416,6 +487,7 @@ func TestDatabase_CreateProfile(t *testing.T) {
 	_, err = db.CreateProfile(profileDataExistingEmail)
 	assert.Error(t, err, "CreateProfile should return error for existing email")
 	assert.Contains(t, err.Error(), "email 'CREATE@example.com' already exists", "Error message should indicate email exists")
+	assert.ErrorIs(t, err, apperr.ErrConflict)
 
 	// Ensure only one profile was actually created
 	assert.Len(t, db.Database.Profiles, 1, "Should only have 1 profile after duplicate email attempt")
@@ -450,6 +522,7 @@ func TestDatabase_GetProfileByEmail(t *testing.T) {
 	db.Database.Profiles[profile1.ID] = profile1
 	db.Database.Profiles[profile2.ID] = profile2 // Add second one to test case-insensitivity finds *one*
 	db.Database.Profiles[profile3.ID] = profile3
+	db.rebuildIndexes() // Direct map writes bypass the unique indexes
 
 
 	// 1. Get existing profile by email (exact case)
@@ -488,6 +561,7 @@ func TestDatabase_UpdateProfile(t *testing.T) {
 	}
 	db.Database.Profiles[profile1.ID] = profile1
 	db.Database.Profiles[profile2.ID] = profile2
+	db.rebuildIndexes() // Direct map writes bypass the unique indexes
 
 
 	// 1. Update existing profile (successful)
@@ -614,6 +688,7 @@ func TestDatabase_UpdateProfilePassword(t *testing.T) {
 		CreationDate: initialTime, LastModifiedDate: initialTime,
 	}
 	db.Database.Profiles[profile.ID] = profile
+	db.rebuildIndexes() // Direct map writes bypass the unique indexes
 
 	newHash := "newbcryptpasswordhash"
 
@@ -626,6 +701,14 @@ func TestDatabase_UpdateProfilePassword(t *testing.T) {
 	assert.Equal(t, newHash, updatedProfile.PasswordHash, "PasswordHash should be updated")
 	assert.True(t, updatedProfile.LastModifiedDate.After(initialTime), "LastModifiedDate should be updated")
 	assert.Equal(t, profile.CreationDate, updatedProfile.CreationDate, "CreationDate should not change") // Ensure other fields didn't change
+	require.NotNil(t, updatedProfile.TokensNotBefore, "Earlier tokens should be revoked")
+	assert.Equal(t, updatedProfile.LastModifiedDate.Truncate(time.Second), *updatedProfile.TokensNotBefore)
+
+	// Other profile updates keep the cutoff
+	updatedProfile.TokensNotBefore = nil
+	_, err = db.UpdateProfile(profile.ID, updatedProfile)
+	require.NoError(t, err)
+	assert.NotNil(t, db.Database.Profiles[profile.ID].TokensNotBefore, "UpdateProfile should preserve TokensNotBefore")
 
 	// Verify save was requested (by checking if LastModifiedDate changed, hash isn't saved)
 	time.Sleep(db.config.SaveInterval * 2)
@@ -643,6 +726,24 @@ func TestDatabase_UpdateProfilePassword(t *testing.T) {
 	assert.Equal(t, newHash, db.Database.Profiles[profile.ID].PasswordHash, "PasswordHash should remain unchanged after failed update")
 }
 
+func TestDatabase_RehashProfilePassword(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	profile := models.Profile{ID: "rehash1", Email: "rehash@example.com", PasswordHash: "oldhash"}
+	db.Database.Profiles[profile.ID] = profile
+	db.rebuildIndexes()
+
+	assert.False(t, db.RehashProfilePassword(profile.ID, "otherhash", "newhash"), "the password changed meanwhile")
+	assert.Equal(t, "oldhash", db.Database.Profiles[profile.ID].PasswordHash)
+	assert.False(t, db.RehashProfilePassword("missing", "oldhash", "newhash"))
+
+	assert.True(t, db.RehashProfilePassword(profile.ID, "oldhash", "newhash"))
+	updated := db.Database.Profiles[profile.ID]
+	assert.Equal(t, "newhash", updated.PasswordHash)
+	assert.Nil(t, updated.TokensNotBefore, "issued tokens stay valid")
+}
+
 
 // --- Document CRUD Tests ---
 
@@ -695,6 +796,30 @@ func TestDatabase_CreateDocument(t *testing.T) {
 	// For now, we comment this part out as it would currently pass (assigning an empty OwnerID).
 }
 
+func TestDatabase_CreateDocuments(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	docs := make([]models.Document, createDocumentsBatch+5)
+	for i := range docs {
+		docs[i] = models.Document{OwnerID: "owner", Content: map[string]any{"n": float64(i)}}
+	}
+	created, err := db.CreateDocuments(docs)
+	require.NoError(t, err)
+	require.Len(t, created, len(docs))
+	stored, found := db.GetDocumentByID(created[createDocumentsBatch].ID)
+	require.True(t, found)
+	assert.Equal(t, map[string]any{"n": float64(createDocumentsBatch)}, stored.Content)
+	assert.Equal(t, 1, stored.Version)
+	assert.Len(t, db.GetDocumentEvents(stored.ID), 1)
+
+	// It stops at the first document that cannot be created
+	created, err = db.CreateDocuments([]models.Document{{OwnerID: "owner", Content: "ok"}, {Content: "no owner"}, {OwnerID: "owner"}})
+	assert.ErrorIs(t, err, apperr.ErrValidation)
+	assert.Len(t, created, 1)
+	assert.Len(t, db.GetDocumentsByOwner("owner"), len(docs)+1)
+}
+
 func TestDatabase_GetDocumentByID(t *testing.T) {
 	db, cleanup := setupTestDB(t)
 	defer cleanup()
@@ -1050,4 +1175,135 @@ func TestDatabase_RemoveSharerFromDocument(t *testing.T) {
 	err = db.RemoveSharerFromDocument("nonexistentdoc", user1)
 	require.NoError(t, err, "RemoveSharer failed for non-existent document ID")
 	assert.Empty(t, db.Database.ShareRecords, "ShareRecords map should remain empty")
-}
\ No newline at end of file
+}
+func TestDatabase_GetSharingContacts(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ownedDoc, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: "a"})
+	require.NoError(t, err)
+	otherDoc, err := db.CreateDocument(models.Document{OwnerID: "other", Content: "b"})
+	require.NoError(t, err)
+
+	require.NoError(t, db.SetShareRecord(ownedDoc.ID, []string{"reader1", "reader2"}))
+	require.NoError(t, db.SetShareRecord(otherDoc.ID, []string{"owner"}))
+
+	ownerContacts := db.GetSharingContacts("owner")
+	assert.Equal(t, map[string]bool{"reader1": true, "reader2": true, "other": true}, ownerContacts,
+		"contacts should include people shared with and owners who shared with us")
+
+	readerContacts := db.GetSharingContacts("reader1")
+	assert.Equal(t, map[string]bool{"owner": true}, readerContacts, "co-recipients are not contacts")
+
+	assert.Empty(t, db.GetSharingContacts("stranger"))
+}
+
+func TestDatabase_GetDocumentIDsSharedWith(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	require.NoError(t, db.SetShareRecord("doc-b", []string{"reader", "other"}))
+	require.NoError(t, db.SetShareRecord("doc-a", []string{"reader"}))
+	require.NoError(t, db.SetShareRecord("doc-c", []string{"other"}))
+
+	assert.Equal(t, []string{"doc-a", "doc-b"}, db.GetDocumentIDsSharedWith("reader"))
+	assert.Empty(t, db.GetDocumentIDsSharedWith("stranger"))
+}
+
+func TestDatabase_AcceptTos(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	profile, err := db.CreateProfile(models.Profile{Email: "tos@example.com"})
+	require.NoError(t, err)
+
+	updated, err := db.AcceptTos(profile.ID, "2024-01")
+	require.NoError(t, err)
+	require.NotNil(t, updated.TosAcceptance)
+	assert.Equal(t, "2024-01", updated.TosAcceptance.Version)
+	assert.WithinDuration(t, time.Now(), updated.TosAcceptance.AcceptedAt, time.Minute)
+
+	stored, _ := db.GetProfileByID(profile.ID)
+	assert.Equal(t, updated.TosAcceptance, stored.TosAcceptance)
+
+	_, err = db.AcceptTos("missing", "2024-01")
+	assert.Error(t, err)
+}
+
+func TestDatabase_SetDocumentPublic(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	doc, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: "notes"})
+	require.NoError(t, err)
+	assert.False(t, doc.Public)
+
+	updated, err := db.SetDocumentPublic(doc.ID, true)
+	require.NoError(t, err)
+	assert.True(t, updated.Public)
+	assert.Equal(t, "notes", updated.Content, "content is untouched")
+
+	stored, _ := db.GetDocumentByID(doc.ID)
+	assert.True(t, stored.Public)
+
+	_, err = db.SetDocumentPublic("missing", true)
+	assert.Error(t, err)
+}
+
+func TestDatabase_ContentTransforms(t *testing.T) {
+	tempDir := createTempDir(t)
+	defer os.RemoveAll(tempDir)
+	cfg := createTestConfig(t, tempDir)
+	cfg.TransformsFile = filepath.Join(tempDir, "transforms.json")
+	require.NoError(t, os.WriteFile(cfg.TransformsFile, []byte(`[
+		{"type": "essay", "transformer": "word_count", "field": "body"},
+		{"transformer": "normalize_date", "field": "due"}
+	]`), 0600))
+
+	db, err := NewDatabase(cfg)
+	require.NoError(t, err)
+
+	doc, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: map[string]any{"type": "essay", "body": "a b c", "due": "March 5, 2024"}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"type": "essay", "body": "a b c", "word_count": 3.0, "due": "2024-03-05"}, doc.Content)
+
+	updated, err := db.UpdateDocument(doc.ID, map[string]any{"type": "essay", "body": "a b", "due": "2024-03-05"})
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, updated.Content.(map[string]any)["word_count"], "computed fields are refreshed on update")
+	events := db.GetDocumentEvents(doc.ID)
+	assert.Equal(t, []string{"body", "word_count"}, events[0].ChangedPaths)
+
+	db.SetTransforms(nil)
+	updated, err = db.UpdateDocument(doc.ID, map[string]any{"body": "x"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"body": "x"}, updated.Content)
+
+	// An invalid rules file prevents startup
+	require.NoError(t, os.WriteFile(cfg.TransformsFile, []byte(`[{"transformer": "nope", "field": "x"}]`), 0600))
+	_, err = NewDatabase(cfg)
+	assert.ErrorContains(t, err, "unknown transformer")
+}
+
+func TestDatabase_ErrorKinds(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, err := db.UpdateProfile("missing", models.Profile{})
+	assert.ErrorIs(t, err, apperr.ErrNotFound)
+	assert.ErrorIs(t, db.DeleteDocument("missing"), apperr.ErrNotFound)
+	assert.ErrorIs(t, db.UpdateProfilePassword("missing@example.com", "hash"), apperr.ErrNotFound)
+
+	owner, err := db.CreateProfile(models.Profile{Email: "kinds@example.com"})
+	require.NoError(t, err)
+	doc, err := db.CreateDocument(models.Document{OwnerID: owner.ID, Content: "x"})
+	require.NoError(t, err)
+	_, err = db.CreateDocument(models.Document{ID: doc.ID, OwnerID: owner.ID, Content: "y"})
+	assert.ErrorIs(t, err, apperr.ErrConflict)
+	_, err = db.CreateDocument(models.Document{Content: "no owner"})
+	assert.ErrorIs(t, err, apperr.ErrValidation)
+
+	_, _, err = db.QueryDocuments(QueryDocumentsParams{AuthUserID: owner.ID, Scope: "everything"})
+	assert.ErrorIs(t, err, apperr.ErrValidation)
+	_, _, err = db.QueryDocuments(QueryDocumentsParams{AuthUserID: owner.ID, ContentQuery: []string{"name"}})
+	assert.ErrorIs(t, err, apperr.ErrValidation)
+}
@@ -0,0 +1,146 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/models"
+	"log"
+	"sort"
+	"time"
+)
+
+// --- Account Deactivation ---
+
+// DeactivateProfile deactivates an account: the user can no longer log in and their documents
+// are hidden from other users until the account is reactivated. Unless reactivated first, the
+// account is erased (see EraseProfile) once gracePeriod has elapsed.
+// reactivateAt, if not nil, schedules an automatic reactivation and must fall within the grace period.
+// Deactivating an account that is already deactivated returns it unchanged.
+func (db *Database) DeactivateProfile(profileID string, reactivateAt *time.Time, gracePeriod time.Duration) (models.Profile, error) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	profile, found := db.Database.Profiles[profileID]
+	if !found {
+		return models.Profile{}, apperr.NotFound("profile with ID '%s' not found", profileID)
+	}
+	if profile.Deactivation != nil {
+		return profile, nil
+	}
+
+	now := db.now().UTC()
+	deactivation := &models.Deactivation{DeactivatedAt: now, PurgeAt: now.Add(gracePeriod)}
+	if reactivateAt != nil {
+		at := reactivateAt.UTC()
+		if !at.After(now) || !at.Before(deactivation.PurgeAt) {
+			return models.Profile{}, apperr.Validation("reactivation time must be between now and %s", deactivation.PurgeAt.Format(time.RFC3339))
+		}
+		deactivation.ReactivateAt = &at
+	}
+	profile.Deactivation = deactivation
+	profile.LastModifiedDate = now
+
+	db.putProfile(profile)
+	log.Printf("INFO: Deactivated Profile ID %s; purge scheduled for %s", profileID, deactivation.PurgeAt.Format(time.RFC3339))
+
+	db.requestSave()
+	return profile, nil
+}
+
+// ReactivateProfile lifts the deactivation of an account.
+func (db *Database) ReactivateProfile(profileID string) (models.Profile, error) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	profile, found := db.Database.Profiles[profileID]
+	if !found {
+		return models.Profile{}, apperr.NotFound("profile with ID '%s' not found", profileID)
+	}
+	if profile.Deactivation == nil {
+		return models.Profile{}, apperr.Conflict("profile with ID '%s' is not deactivated", profileID)
+	}
+	profile.Deactivation = nil
+	profile.LastModifiedDate = db.now().UTC()
+
+	db.putProfile(profile)
+	log.Printf("INFO: Reactivated Profile ID %s", profileID)
+
+	db.requestSave()
+	return profile, nil
+}
+
+// IsProfileDeactivated reports whether the profile exists and is deactivated.
+func (db *Database) IsProfileDeactivated(profileID string) bool {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+	return (&ReadView{db: db}).IsProfileDeactivated(profileID)
+}
+
+// ListDeactivatedProfiles returns the deactivated profiles, soonest purge first.
+func (db *Database) ListDeactivatedProfiles() []models.Profile {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+
+	profiles := make([]models.Profile, 0)
+	for _, profile := range db.Database.Profiles {
+		if profile.Deactivation != nil {
+			profiles = append(profiles, profile)
+		}
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		if !profiles[i].Deactivation.PurgeAt.Equal(profiles[j].Deactivation.PurgeAt) {
+			return profiles[i].Deactivation.PurgeAt.Before(profiles[j].Deactivation.PurgeAt)
+		}
+		return profiles[i].ID < profiles[j].ID
+	})
+	return profiles
+}
+
+// ProcessDeactivations reactivates the accounts whose reactivation time has come and erases
+// those whose grace period has elapsed by now. It returns how many of each it handled.
+func (db *Database) ProcessDeactivations(now time.Time) (reactivated, purged int) {
+	db.Database.Mu.RLock()
+	var toReactivate, toPurge []string
+	for id, profile := range db.Database.Profiles {
+		switch {
+		case profile.Deactivation == nil:
+		case profile.Deactivation.ReactivateAt != nil && !now.Before(*profile.Deactivation.ReactivateAt):
+			toReactivate = append(toReactivate, id)
+		case !now.Before(profile.Deactivation.PurgeAt):
+			toPurge = append(toPurge, id)
+		}
+	}
+	db.Database.Mu.RUnlock()
+
+	for _, id := range toReactivate {
+		if _, err := db.ReactivateProfile(id); err != nil {
+			log.Printf("ERROR: Scheduled reactivation of Profile ID %s failed: %v", id, err)
+			continue
+		}
+		reactivated++
+	}
+	for _, id := range toPurge {
+		if _, err := db.EraseProfile(id); err != nil {
+			log.Printf("ERROR: Purge of deactivated Profile ID %s failed: %v", id, err)
+			continue
+		}
+		purged++
+	}
+	return reactivated, purged
+}
+
+// StartDeactivationWorker periodically runs ProcessDeactivations in the background.
+// Call the returned function to stop the worker.
+func (db *Database) StartDeactivationWorker(interval time.Duration) (stop func()) {
+	return startWorker(interval, db.now, func(now time.Time) { db.ProcessDeactivations(now) })
+}
+
+// deactivatedProfileIDs returns the IDs of all deactivated profiles. Must be called with the lock held.
+func (db *Database) deactivatedProfileIDs() map[string]bool {
+	ids := make(map[string]bool)
+	for id, profile := range db.Database.Profiles {
+		if profile.Deactivation != nil {
+			ids[id] = true
+		}
+	}
+	return ids
+}
@@ -0,0 +1,123 @@
+package db
+
+import (
+	"docserver/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabase_DeactivateAndReactivateProfile(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	owner, err := db.CreateProfile(models.Profile{Email: "owner@example.com"})
+	require.NoError(t, err)
+	reader, err := db.CreateProfile(models.Profile{Email: "reader@example.com"})
+	require.NoError(t, err)
+	doc, err := db.CreateDocument(models.Document{OwnerID: owner.ID, Content: map[string]any{"title": "notes"}, Public: true})
+	require.NoError(t, err)
+	require.NoError(t, db.AddSharerToDocument(doc.ID, reader.ID))
+
+	visibleTo := func(userID, scope string) int {
+		_, total, err := db.QueryDocuments(QueryDocumentsParams{AuthUserID: userID, Scope: scope, Page: 1, Limit: 10})
+		require.NoError(t, err)
+		return total
+	}
+	require.Equal(t, 1, visibleTo(reader.ID, "shared"))
+	require.Equal(t, 1, visibleTo("", "public"))
+
+	t.Run("Unknown profile", func(t *testing.T) {
+		_, err := db.DeactivateProfile("missing", nil, time.Hour)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not found")
+	})
+
+	t.Run("Reactivation time must fall within the grace period", func(t *testing.T) {
+		past := time.Now().Add(-time.Minute)
+		_, err := db.DeactivateProfile(owner.ID, &past, time.Hour)
+		assert.Error(t, err)
+		late := time.Now().Add(2 * time.Hour)
+		_, err = db.DeactivateProfile(owner.ID, &late, time.Hour)
+		assert.Error(t, err)
+		assert.False(t, db.IsProfileDeactivated(owner.ID))
+	})
+
+	t.Run("Deactivate hides documents from other users", func(t *testing.T) {
+		profile, err := db.DeactivateProfile(owner.ID, nil, time.Hour)
+		require.NoError(t, err)
+		require.NotNil(t, profile.Deactivation)
+		assert.Equal(t, time.Hour, profile.Deactivation.PurgeAt.Sub(profile.Deactivation.DeactivatedAt))
+		assert.True(t, db.IsProfileDeactivated(owner.ID))
+
+		assert.Equal(t, 0, visibleTo(reader.ID, "shared"))
+		assert.Equal(t, 0, visibleTo("", "public"))
+		assert.Equal(t, 1, visibleTo(owner.ID, "owned"), "data is preserved")
+	})
+
+	t.Run("Deactivating again changes nothing", func(t *testing.T) {
+		before, _ := db.GetProfileByID(owner.ID)
+		profile, err := db.DeactivateProfile(owner.ID, nil, 24*time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, before.Deactivation, profile.Deactivation)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		profiles := db.ListDeactivatedProfiles()
+		require.Len(t, profiles, 1)
+		assert.Equal(t, owner.ID, profiles[0].ID)
+	})
+
+	t.Run("Reactivate", func(t *testing.T) {
+		profile, err := db.ReactivateProfile(owner.ID)
+		require.NoError(t, err)
+		assert.Nil(t, profile.Deactivation)
+		assert.Equal(t, 1, visibleTo(reader.ID, "shared"))
+		assert.Empty(t, db.ListDeactivatedProfiles())
+
+		_, err = db.ReactivateProfile(owner.ID)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not deactivated")
+	})
+}
+
+func TestDatabase_ProcessDeactivations(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	returning, err := db.CreateProfile(models.Profile{Email: "returning@example.com"})
+	require.NoError(t, err)
+	leaving, err := db.CreateProfile(models.Profile{Email: "leaving@example.com"})
+	require.NoError(t, err)
+	_, err = db.CreateDocument(models.Document{OwnerID: leaving.ID, Content: "draft"})
+	require.NoError(t, err)
+
+	reactivateAt := time.Now().Add(time.Hour)
+	_, err = db.DeactivateProfile(returning.ID, &reactivateAt, 48*time.Hour)
+	require.NoError(t, err)
+	_, err = db.DeactivateProfile(leaving.ID, nil, 24*time.Hour)
+	require.NoError(t, err)
+
+	reactivated, purged := db.ProcessDeactivations(time.Now())
+	assert.Zero(t, reactivated)
+	assert.Zero(t, purged)
+
+	reactivated, purged = db.ProcessDeactivations(time.Now().Add(2 * time.Hour))
+	assert.Equal(t, 1, reactivated)
+	assert.Zero(t, purged)
+	assert.False(t, db.IsProfileDeactivated(returning.ID))
+	assert.True(t, db.IsProfileDeactivated(leaving.ID))
+
+	reactivated, purged = db.ProcessDeactivations(time.Now().Add(25 * time.Hour))
+	assert.Zero(t, reactivated)
+	assert.Equal(t, 1, purged)
+	_, found := db.GetProfileByID(leaving.ID)
+	assert.False(t, found, "purged profile is erased")
+	_, total, err := db.QueryDocuments(QueryDocumentsParams{AuthUserID: leaving.ID, Scope: "owned", Page: 1, Limit: 10})
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	_, found = db.GetProfileByID(returning.ID)
+	assert.True(t, found)
+}
@@ -0,0 +1,114 @@
+package db
+
+import (
+	"crypto/sha256"
+	"docserver/models"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// --- Content Deduplication ---
+
+// contentHashPrefix marks content references; the rest is the hex SHA-256 of the content's JSON.
+const contentHashPrefix = "sha256:"
+
+// persistedDatabase is the layout of the database file. It is models.Database, except that
+// documents may refer to an entry of Contents instead of holding their content (see -dedupe-content).
+// Its Documents field shadows the embedded one when marshalling.
+type persistedDatabase struct {
+	*models.Database
+	Documents map[string]persistedDocument `json:"documents"`
+	Contents  map[string]json.RawMessage   `json:"contents,omitempty"` // Keyed by content hash; content shared by several documents
+}
+
+// persistedDocument is a document as stored in the file. When ContentRef is set, Content is
+// empty and the content is the Contents entry ContentRef names.
+type persistedDocument struct {
+	models.Document
+	ContentRef string `json:"content_ref,omitempty"`
+}
+
+// contentHash returns the reference under which content with the given JSON encoding is stored.
+func contentHash(encoded []byte) string {
+	sum := sha256.Sum256(encoded)
+	return contentHashPrefix + hex.EncodeToString(sum[:])
+}
+
+// marshalState encodes the database for the file. With -dedupe-content, content that several
+// documents have in common is written once to "contents" and the documents refer to it by hash;
+// content only one document has, or that is shorter than a reference, stays inline.
+// Must be called with the lock held.
+func (db *Database) marshalState() ([]byte, error) {
+	if !db.config.DedupeContent {
+		return json.MarshalIndent(&db.Database, "", "  ")
+	}
+
+	encoded := make(map[string]json.RawMessage, len(db.Database.Documents))
+	users := make(map[string]int) // Content hash -> number of documents with that content
+	for id, doc := range db.Database.Documents {
+		content, err := json.Marshal(doc.Content)
+		if err != nil {
+			return nil, fmt.Errorf("document '%s': %w", id, err)
+		}
+		encoded[id] = content
+		users[contentHash(content)]++
+	}
+
+	state := persistedDatabase{
+		Database:  &db.Database,
+		Documents: make(map[string]persistedDocument, len(db.Database.Documents)),
+		Contents:  make(map[string]json.RawMessage),
+	}
+	for id, doc := range db.Database.Documents {
+		content := encoded[id]
+		hash := contentHash(content)
+		if users[hash] < 2 || len(content) <= len(hash) {
+			doc.Content = content
+			state.Documents[id] = persistedDocument{Document: doc}
+			continue
+		}
+		state.Contents[hash] = content
+		doc.Content = nil
+		state.Documents[id] = persistedDocument{Document: doc, ContentRef: hash}
+	}
+	return json.MarshalIndent(&state, "", "  ")
+}
+
+// unmarshalState decodes a database file into db.Database, resolving content references.
+// Files with references are read whether or not -dedupe-content is set. Documents sharing a
+// reference share the decoded content, which is safe because content is never changed in
+// place: updates replace a document's content, so a modified document gets its own copy.
+// Must be called with the write lock held.
+func (db *Database) unmarshalState(data []byte) error {
+	state := persistedDatabase{Database: &db.Database}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+
+	shared := make(map[string]any, len(state.Contents))
+	for hash, raw := range state.Contents {
+		var content any
+		if err := json.Unmarshal(raw, &content); err != nil {
+			return fmt.Errorf("content '%s': %w", hash, err)
+		}
+		shared[hash] = content
+	}
+
+	documents := make(map[string]models.Document, len(state.Documents))
+	for id, stored := range state.Documents {
+		doc := stored.Document
+		if stored.ContentRef != "" {
+			content, found := shared[stored.ContentRef]
+			if !found {
+				return fmt.Errorf("document '%s' refers to missing content '%s'", id, stored.ContentRef)
+			}
+			doc.Content = content
+		}
+		documents[id] = doc
+	}
+	if state.Documents != nil {
+		db.Database.Documents = documents
+	}
+	return nil
+}
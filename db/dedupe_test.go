@@ -0,0 +1,84 @@
+package db
+
+import (
+	"docserver/models"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabase_DedupeContent(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	db.config.DedupeContent = true
+
+	template := map[string]any{"title": "Lab report", "sections": []any{"Hypothesis", "Materials", "Method", "Results", "Discussion", "References"}}
+	var copies []models.Document
+	for _, owner := range []string{"u1", "u2", "u3"} {
+		doc, err := db.CreateDocument(models.Document{OwnerID: owner, Content: template})
+		require.NoError(t, err)
+		copies = append(copies, doc)
+	}
+	unique, err := db.CreateDocument(models.Document{OwnerID: "u1", Content: map[string]any{"title": "Mine"}})
+	require.NoError(t, err)
+	short, err := db.CreateDocument(models.Document{OwnerID: "u2", Content: "hi"})
+	require.NoError(t, err)
+	_, err = db.CreateDocument(models.Document{OwnerID: "u3", Content: "hi"})
+	require.NoError(t, err)
+
+	readFile := func() persistedDatabase {
+		require.NoError(t, db.persist())
+		data, err := os.ReadFile(db.config.DbFilePath)
+		require.NoError(t, err)
+		state := persistedDatabase{Database: &models.Database{}}
+		require.NoError(t, json.Unmarshal(data, &state))
+		return state
+	}
+
+	t.Run("Shared content is stored once", func(t *testing.T) {
+		state := readFile()
+		require.Len(t, state.Contents, 1)
+		ref := state.Documents[copies[0].ID].ContentRef
+		assert.Contains(t, state.Contents, ref)
+		for _, doc := range copies {
+			assert.Equal(t, ref, state.Documents[doc.ID].ContentRef)
+			assert.Nil(t, state.Documents[doc.ID].Content)
+		}
+		assert.Empty(t, state.Documents[unique.ID].ContentRef, "content of one document stays inline")
+		assert.Empty(t, state.Documents[short.ID].ContentRef, "content shorter than a reference stays inline")
+	})
+
+	t.Run("References are resolved on load", func(t *testing.T) {
+		reloaded, err := NewDatabase(db.config)
+		require.NoError(t, err)
+		for _, doc := range copies {
+			loaded, found := reloaded.GetDocumentByID(doc.ID)
+			require.True(t, found)
+			assert.Equal(t, "Lab report", loaded.Content.(map[string]any)["title"])
+		}
+
+		// Copy on write: changing one copy leaves the others alone
+		_, err = reloaded.UpdateDocument(copies[0].ID, map[string]any{"title": "Changed"})
+		require.NoError(t, err)
+		other, _ := reloaded.GetDocumentByID(copies[1].ID)
+		assert.Equal(t, "Lab report", other.Content.(map[string]any)["title"])
+	})
+
+	t.Run("Disabled writes content inline", func(t *testing.T) {
+		db.config.DedupeContent = false
+		state := readFile()
+		assert.Empty(t, state.Contents)
+		assert.Empty(t, state.Documents[copies[0].ID].ContentRef)
+		assert.NotNil(t, state.Documents[copies[0].ID].Content)
+	})
+
+	t.Run("Missing content is an error", func(t *testing.T) {
+		broken := `{"schema_version": 1, "documents": {"d1": {"id": "d1", "content": null, "content_ref": "sha256:00"}}}`
+		require.NoError(t, os.WriteFile(db.config.DbFilePath, []byte(broken), 0644))
+		_, err := NewDatabase(db.config)
+		assert.ErrorContains(t, err, "missing content")
+	})
+}
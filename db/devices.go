@@ -0,0 +1,107 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/models"
+	"docserver/utils"
+	"log"
+	"slices"
+	"sort"
+)
+
+// --- Known Devices ---
+
+// maxDevices caps the devices remembered per profile; the ones seen longest ago are dropped first.
+const maxDevices = 50
+
+// maxUserAgentLength caps the user agent stored for a device.
+const maxUserAgentLength = 512
+
+// RecordLogin notes a login of a profile from an IP address and user agent, and returns the
+// device it came from. isNew is true when the profile never logged in from that combination
+// before (or stopped trusting it), and known is true when it had logged in from elsewhere, so a
+// new device is worth telling the user about.
+func (db *Database) RecordLogin(profileID, ipAddress, userAgent string) (device models.Device, isNew, known bool, err error) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	now := db.now().UTC()
+	devices := slices.Clone(db.Database.Devices[profileID])
+	known = len(devices) > 0
+	index := slices.IndexFunc(devices, func(d models.Device) bool {
+		return d.RevokedAt == nil && d.IPAddress == ipAddress && d.UserAgent == userAgent
+	})
+	if index >= 0 {
+		devices[index].LastSeen = now
+		devices[index].Logins++
+		device = devices[index]
+	} else {
+		id, err := db.newID(utils.IDKindDevice, func(id string) bool {
+			return slices.ContainsFunc(devices, func(d models.Device) bool { return d.ID == id })
+		})
+		if err != nil {
+			return models.Device{}, false, false, err
+		}
+		device = models.Device{ID: id, IPAddress: ipAddress, UserAgent: userAgent, FirstSeen: now, LastSeen: now, Logins: 1}
+		devices = append(devices, device)
+		isNew = true
+		log.Printf("INFO: New device %s for Profile ID %s (%s)", id, profileID, ipAddress)
+	}
+	if len(devices) > maxDevices {
+		sort.SliceStable(devices, func(i, j int) bool { return devices[i].LastSeen.Before(devices[j].LastSeen) })
+		devices = devices[len(devices)-maxDevices:]
+		sort.SliceStable(devices, func(i, j int) bool { return devices[i].FirstSeen.Before(devices[j].FirstSeen) })
+	}
+	db.Database.Devices[profileID] = devices
+
+	db.requestSave()
+	return device, isNew, known, nil
+}
+
+// GetDevices returns the devices a profile logged in from, most recently seen first.
+func (db *Database) GetDevices(profileID string) []models.Device {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+
+	devices := slices.Clone(db.Database.Devices[profileID])
+	if devices == nil {
+		devices = []models.Device{}
+	}
+	sort.SliceStable(devices, func(i, j int) bool { return devices[i].LastSeen.After(devices[j].LastSeen) })
+	return devices
+}
+
+// RevokeDevice stops trusting a device of a profile: tokens issued to it are no longer accepted,
+// and the next login from it counts as a new device. Revoking it again changes nothing.
+func (db *Database) RevokeDevice(profileID, deviceID string) (models.Device, error) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	devices := slices.Clone(db.Database.Devices[profileID])
+	index := slices.IndexFunc(devices, func(d models.Device) bool { return d.ID == deviceID })
+	if index < 0 {
+		return models.Device{}, apperr.NotFound("device '%s' not found", deviceID)
+	}
+	if devices[index].RevokedAt == nil {
+		now := db.now().UTC()
+		devices[index].RevokedAt = &now
+		db.Database.Devices[profileID] = devices
+		log.Printf("INFO: Device %s of Profile ID %s revoked", deviceID, profileID)
+		db.requestSave()
+	}
+	return devices[index], nil
+}
+
+// DeviceTrusted reports whether tokens issued to a profile's device may be used: the device is
+// known and was not revoked.
+func (db *Database) DeviceTrusted(profileID, deviceID string) bool {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+
+	return slices.ContainsFunc(db.Database.Devices[profileID], func(d models.Device) bool {
+		return d.ID == deviceID && d.RevokedAt == nil
+	})
+}
@@ -0,0 +1,73 @@
+package db
+
+import (
+	"docserver/apperr"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordLogin(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	first, isNew, known, err := db.RecordLogin("usr_1", "192.0.2.1", "Firefox")
+	require.NoError(t, err)
+	assert.True(t, isNew)
+	assert.False(t, known, "the first login has nothing to compare with")
+	assert.Equal(t, 1, first.Logins)
+
+	again, isNew, known, err := db.RecordLogin("usr_1", "192.0.2.1", "Firefox")
+	require.NoError(t, err)
+	assert.False(t, isNew)
+	assert.True(t, known)
+	assert.Equal(t, first.ID, again.ID)
+	assert.Equal(t, 2, again.Logins)
+
+	other, isNew, known, err := db.RecordLogin("usr_1", "192.0.2.1", "Safari")
+	require.NoError(t, err)
+	assert.True(t, isNew, "a new user agent is a new device")
+	assert.True(t, known)
+	assert.NotEqual(t, first.ID, other.ID)
+
+	_, isNew, known, err = db.RecordLogin("usr_2", "192.0.2.1", "Firefox")
+	require.NoError(t, err)
+	assert.True(t, isNew, "devices are per profile")
+	assert.False(t, known)
+
+	t.Run("Devices are capped", func(t *testing.T) {
+		for i := 0; i < maxDevices+5; i++ {
+			_, _, _, err := db.RecordLogin("usr_3", fmt.Sprintf("198.51.100.%d", i), "Firefox")
+			require.NoError(t, err)
+		}
+		devices := db.GetDevices("usr_3")
+		assert.Len(t, devices, maxDevices)
+		assert.Equal(t, fmt.Sprintf("198.51.100.%d", maxDevices+4), devices[0].IPAddress)
+	})
+}
+
+func TestRevokeDevice(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	device, _, _, err := db.RecordLogin("usr_1", "192.0.2.1", "Firefox")
+	require.NoError(t, err)
+	assert.True(t, db.DeviceTrusted("usr_1", device.ID))
+	assert.False(t, db.DeviceTrusted("usr_2", device.ID), "devices belong to one profile")
+
+	_, err = db.RevokeDevice("usr_2", device.ID)
+	assert.ErrorIs(t, err, apperr.ErrNotFound)
+
+	revoked, err := db.RevokeDevice("usr_1", device.ID)
+	require.NoError(t, err)
+	require.NotNil(t, revoked.RevokedAt)
+	assert.False(t, db.DeviceTrusted("usr_1", device.ID))
+
+	again, isNew, _, err := db.RecordLogin("usr_1", "192.0.2.1", "Firefox")
+	require.NoError(t, err)
+	assert.True(t, isNew, "a revoked device is new again")
+	assert.NotEqual(t, device.ID, again.ID)
+	assert.Len(t, db.GetDevices("usr_1"), 2)
+}
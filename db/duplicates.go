@@ -0,0 +1,172 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/models"
+	"docserver/utils"
+	"encoding/binary"
+	"hash/fnv"
+	"sort"
+)
+
+// --- Near-Duplicate Detection ---
+
+// Similarity thresholds of FindDuplicates.
+const (
+	DefaultDuplicateThreshold = 0.8
+	MinDuplicateThreshold     = 0.5 // Below it, the MinHash bands miss too many pairs
+)
+
+// duplicateBands splits MinHash signatures into bands of duplicateBandRows values: documents
+// agreeing on a whole band are compared. With 32 bands of 2, pairs at least 50% similar are
+// compared with a probability above 99.9%.
+const (
+	duplicateBands    = 32
+	duplicateBandRows = utils.MinHashSize / duplicateBands
+)
+
+// DuplicatePair is two documents and how similar their text is.
+type DuplicatePair struct {
+	A          string  `json:"a"`          // Document ID
+	B          string  `json:"b"`          // Document ID
+	Similarity float64 `json:"similarity"` // Jaccard similarity of their word shingles, from 0 to 1
+}
+
+// DuplicateCluster is a group of near-identical documents: each is similar enough to at least
+// one other document of the cluster.
+type DuplicateCluster struct {
+	Documents  []models.Document `json:"documents"`  // Oldest first, as the profile sees them
+	Similarity float64           `json:"similarity"` // Highest similarity of a pair in the cluster
+	Pairs      []DuplicatePair   `json:"pairs"`      // The pairs at or above the threshold, most similar first
+}
+
+// FindDuplicates groups the documents in scope for a profile (as with GET /documents) whose text
+// is at least threshold similar. Each document's strings, numbers and booleans are split into
+// words and hashed in runs of utils.ShingleSize words; MinHash signatures of those shingles
+// pick the pairs worth comparing, whose exact Jaccard similarity is then computed. Documents
+// without any words are skipped. Clusters come most similar first.
+func (db *Database) FindDuplicates(profileID, scope string, threshold float64) ([]DuplicateCluster, error) {
+	if threshold < MinDuplicateThreshold || threshold > 1 {
+		return nil, apperr.Validation("threshold must be between %v and 1", MinDuplicateThreshold)
+	}
+	var documents []models.Document
+	err := db.View(func(v *ReadView) error {
+		var err error
+		documents, err = v.documentsInScope(profileID, scope)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Shingle each document and bucket it by the bands of its signature
+	shingles := make([]map[uint64]bool, 0, len(documents))
+	compared := make([]models.Document, 0, len(documents))
+	buckets := make(map[uint64][]int)
+	for _, doc := range documents {
+		set := utils.Shingles(utils.ContentWords(doc.Content))
+		if len(set) == 0 {
+			continue
+		}
+		index := len(compared)
+		shingles = append(shingles, set)
+		compared = append(compared, doc)
+		signature := utils.MinHash(set)
+		for band := 0; band < duplicateBands; band++ {
+			key := bandKey(band, signature[band*duplicateBandRows:(band+1)*duplicateBandRows])
+			buckets[key] = append(buckets[key], index)
+		}
+	}
+
+	// Compare the candidates and join the similar ones
+	parent := make([]int, len(compared))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	checked := make(map[[2]int]bool)
+	var pairs []DuplicatePair
+	for _, bucket := range buckets {
+		for x := 0; x < len(bucket); x++ {
+			for y := x + 1; y < len(bucket); y++ {
+				i, j := bucket[x], bucket[y]
+				if checked[[2]int{i, j}] {
+					continue
+				}
+				checked[[2]int{i, j}] = true
+				similarity := utils.Jaccard(shingles[i], shingles[j])
+				if similarity < threshold {
+					continue
+				}
+				pairs = append(pairs, DuplicatePair{A: compared[i].ID, B: compared[j].ID, Similarity: roundSimilarity(similarity)})
+				parent[find(i)] = find(j)
+			}
+		}
+	}
+
+	// Gather the clusters
+	position := make(map[string]int, len(compared)) // Documents come oldest first
+	for i, doc := range compared {
+		position[doc.ID] = i
+	}
+	byRoot := make(map[int]*DuplicateCluster)
+	for _, pair := range pairs {
+		root := find(position[pair.A])
+		cluster, found := byRoot[root]
+		if !found {
+			cluster = &DuplicateCluster{}
+			byRoot[root] = cluster
+		}
+		cluster.Pairs = append(cluster.Pairs, pair)
+		cluster.Similarity = max(cluster.Similarity, pair.Similarity)
+	}
+	clusters := make([]DuplicateCluster, 0, len(byRoot))
+	for root, cluster := range byRoot {
+		for i, doc := range compared {
+			if find(i) == root {
+				cluster.Documents = append(cluster.Documents, doc)
+			}
+		}
+		sort.Slice(cluster.Pairs, func(i, j int) bool {
+			if cluster.Pairs[i].Similarity != cluster.Pairs[j].Similarity {
+				return cluster.Pairs[i].Similarity > cluster.Pairs[j].Similarity
+			}
+			if cluster.Pairs[i].A != cluster.Pairs[j].A {
+				return position[cluster.Pairs[i].A] < position[cluster.Pairs[j].A]
+			}
+			return position[cluster.Pairs[i].B] < position[cluster.Pairs[j].B]
+		})
+		clusters = append(clusters, *cluster)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].Similarity != clusters[j].Similarity {
+			return clusters[i].Similarity > clusters[j].Similarity
+		}
+		return position[clusters[i].Documents[0].ID] < position[clusters[j].Documents[0].ID]
+	})
+	return clusters, nil
+}
+
+// bandKey hashes one band of a MinHash signature, together with its number.
+func bandKey(band int, values []uint64) uint64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(band))
+	h.Write(buf[:])
+	for _, value := range values {
+		binary.LittleEndian.PutUint64(buf[:], value)
+		h.Write(buf[:])
+	}
+	return h.Sum64()
+}
+
+// roundSimilarity rounds a similarity to three decimals for display.
+func roundSimilarity(similarity float64) float64 {
+	return float64(int(similarity*1000+0.5)) / 1000
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/config"
+	"docserver/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const duplicateEssay = "The industrial revolution began in Britain in the late eighteenth century and spread to Europe and North America, transforming manufacturing, transport and the daily lives of workers in growing cities."
+
+func TestFindDuplicates(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	clock := config.NewFixedClock(time.Date(2025, time.June, 1, 9, 0, 0, 0, time.UTC))
+	db.config.Clock = clock
+
+	create := func(ownerID string, content any) models.Document {
+		t.Helper()
+		doc, err := db.CreateDocument(models.Document{OwnerID: ownerID, Content: content})
+		require.NoError(t, err)
+		clock.Advance(time.Minute)
+		return doc
+	}
+	original := create("teacher", map[string]any{"title": "Essay", "body": duplicateEssay})
+	copied := create("teacher", map[string]any{"text": duplicateEssay, "title": "Essay"})
+	edited := create("teacher", map[string]any{"title": "Essay", "body": duplicateEssay + " Steam power was central."})
+	create("teacher", map[string]any{"title": "Lab report", "body": "We measured the boiling point of water at different altitudes using a digital thermometer."})
+	create("teacher", map[string]any{"title": ""})
+	create("teacher", map[string]any{})
+	shared := create("student", map[string]any{"body": duplicateEssay, "title": "Essay"})
+
+	t.Run("Clusters", func(t *testing.T) {
+		clusters, err := db.FindDuplicates("teacher", "owned", DefaultDuplicateThreshold)
+		require.NoError(t, err)
+		require.Len(t, clusters, 1, "documents without words are not duplicates of each other")
+		cluster := clusters[0]
+		require.Len(t, cluster.Documents, 3)
+		assert.Equal(t, []string{original.ID, copied.ID, edited.ID}, []string{cluster.Documents[0].ID, cluster.Documents[1].ID, cluster.Documents[2].ID}, "oldest first")
+		assert.Equal(t, 1.0, cluster.Similarity, "keys are not compared")
+		require.Len(t, cluster.Pairs, 3)
+		assert.Equal(t, DuplicatePair{A: original.ID, B: copied.ID, Similarity: 1}, cluster.Pairs[0])
+		assert.Less(t, cluster.Pairs[1].Similarity, 1.0)
+		assert.GreaterOrEqual(t, cluster.Pairs[2].Similarity, DefaultDuplicateThreshold)
+	})
+
+	t.Run("Threshold", func(t *testing.T) {
+		clusters, err := db.FindDuplicates("teacher", "owned", 1)
+		require.NoError(t, err)
+		require.Len(t, clusters, 1)
+		assert.Len(t, clusters[0].Documents, 2, "only the exact copy")
+
+		for _, threshold := range []float64{0.2, 1.5} {
+			_, err = db.FindDuplicates("teacher", "owned", threshold)
+			assert.ErrorIs(t, err, apperr.ErrValidation)
+		}
+	})
+
+	t.Run("Scope", func(t *testing.T) {
+		require.NoError(t, db.SetShareRecord(shared.ID, []string{"teacher"}))
+		clusters, err := db.FindDuplicates("teacher", "all", 1)
+		require.NoError(t, err)
+		require.Len(t, clusters, 1)
+		assert.Len(t, clusters[0].Documents, 3, "the shared copy joins the cluster")
+
+		clusters, err = db.FindDuplicates("teacher", "shared", DefaultDuplicateThreshold)
+		require.NoError(t, err)
+		assert.Empty(t, clusters, "a single document has no duplicates")
+
+		clusters, err = db.FindDuplicates("student", "all", DefaultDuplicateThreshold)
+		require.NoError(t, err)
+		assert.Empty(t, clusters)
+	})
+}
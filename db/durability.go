@@ -0,0 +1,57 @@
+package db
+
+import (
+	"os"
+)
+
+// --- Durable Saves ---
+
+// PersistNow saves the database right away and returns once the file has been written (and, with
+// -fsync, synced to disk), rather than leaving the change to the debounced save. A save already scheduled is cancelled, as
+// this one covers it.
+func (db *Database) PersistNow() error {
+	if db.staged {
+		return nil // Saved by the transaction's commit
+	}
+	db.saveMutex.Lock()
+	if db.saveTimer != nil {
+		db.saveTimer.Stop()
+		db.saveTimer = nil
+	}
+	db.savePending = false
+	db.saveMutex.Unlock()
+	return db.persist()
+}
+
+// Unsaved reports whether changes were made since the database was last saved, so a crash now
+// would lose them.
+func (db *Database) Unsaved() bool {
+	return db.changes.Load() != db.savedChanges.Load()
+}
+
+// writeFileSynced is os.WriteFile, also syncing the file to disk before closing it.
+func writeFileSynced(path string, data []byte, perm os.FileMode) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// syncDir syncs a directory to disk, so a file renamed into it stays there after a crash.
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"docserver/models"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPersistNow(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	db.config.SaveInterval = time.Hour // The debounced save would never come in time
+
+	doc, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: map[string]any{"saved": true}})
+	require.NoError(t, err)
+	require.NoError(t, db.PersistNow())
+
+	data, err := os.ReadFile(db.config.DbFilePath)
+	require.NoError(t, err)
+	var saved models.Database
+	require.NoError(t, json.Unmarshal(data, &saved))
+	assert.Contains(t, saved.Documents, doc.ID)
+	_, err = os.Stat(db.config.DbFilePath + ".tmp")
+	assert.True(t, os.IsNotExist(err), "the temporary file is renamed into place")
+
+	db.saveMutex.Lock()
+	assert.False(t, db.savePending, "the scheduled save is cancelled")
+	assert.Nil(t, db.saveTimer)
+	db.saveMutex.Unlock()
+}
+
+func TestUnsaved(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	db.config.SaveInterval = time.Hour
+
+	assert.False(t, db.Unsaved(), "nothing changed since loading")
+	_, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: "draft"})
+	require.NoError(t, err)
+	assert.True(t, db.Unsaved())
+	require.NoError(t, db.PersistNow())
+	assert.False(t, db.Unsaved())
+}
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"crypto/subtle"
+	"docserver/apperr"
+	"docserver/i18n"
+	"docserver/models"
+	"log"
+	"strings"
+	"time"
+)
+
+// --- Email Change ---
+
+// RequestEmailChange records a pending change of a profile's email address, replacing any earlier one.
+// tokenHash is the hash of the confirmation token sent to the new address (see utils.HashToken).
+// The new address must differ from the current one and must not belong to another profile.
+func (db *Database) RequestEmailChange(profileID, newEmail, tokenHash string, lifetime time.Duration) (models.EmailChange, error) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	profile, found := db.Database.Profiles[profileID]
+	if !found {
+		return models.EmailChange{}, apperr.NotFound("profile with ID '%s' not found", profileID)
+	}
+	if strings.EqualFold(profile.Email, newEmail) {
+		return models.EmailChange{}, apperr.Wrap(apperr.ErrValidation, i18n.NewError(i18n.MsgEmailChangeSame))
+	}
+	if db.emailTakenByOther(profileID, newEmail) {
+		return models.EmailChange{}, apperr.Wrap(apperr.ErrConflict, i18n.NewError(i18n.MsgEmailAlreadyExists, newEmail))
+	}
+
+	now := db.now().UTC()
+	change := models.EmailChange{
+		NewEmail:    newEmail,
+		TokenHash:   tokenHash,
+		RequestedAt: now,
+		ExpiresAt:   now.Add(lifetime),
+	}
+	db.Database.EmailChanges[profileID] = change
+	log.Printf("AUDIT: Profile ID %s requested an email change from %s to %s", profileID, profile.Email, newEmail)
+
+	db.requestSave()
+	return change, nil
+}
+
+// ConfirmEmailChange applies a profile's pending email change if tokenHash matches it.
+// It returns the updated profile and the previous email address. Uniqueness is checked again,
+// since the new address may have been taken since the change was requested.
+func (db *Database) ConfirmEmailChange(profileID, tokenHash string) (models.Profile, string, error) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	profile, found := db.Database.Profiles[profileID]
+	if !found {
+		return models.Profile{}, "", apperr.NotFound("profile with ID '%s' not found", profileID)
+	}
+	change, pending := db.Database.EmailChanges[profileID]
+	if !pending {
+		return models.Profile{}, "", apperr.Wrap(apperr.ErrNotFound, i18n.NewError(i18n.MsgEmailChangeNotFound))
+	}
+	now := db.now().UTC()
+	if now.After(change.ExpiresAt) {
+		delete(db.Database.EmailChanges, profileID)
+		db.requestSave()
+		log.Printf("AUDIT: Expired email change of Profile ID %s to %s discarded", profileID, change.NewEmail)
+		return models.Profile{}, "", apperr.Wrap(apperr.ErrValidation, i18n.NewError(i18n.MsgEmailChangeExpired))
+	}
+	if subtle.ConstantTimeCompare([]byte(change.TokenHash), []byte(tokenHash)) != 1 {
+		log.Printf("AUDIT: Invalid email change token for Profile ID %s", profileID)
+		return models.Profile{}, "", apperr.Wrap(apperr.ErrValidation, i18n.NewError(i18n.MsgEmailChangeMismatch))
+	}
+	if db.emailTakenByOther(profileID, change.NewEmail) {
+		delete(db.Database.EmailChanges, profileID)
+		db.requestSave()
+		return models.Profile{}, "", apperr.Wrap(apperr.ErrConflict, i18n.NewError(i18n.MsgEmailAlreadyExists, change.NewEmail))
+	}
+
+	oldEmail := profile.Email
+	profile.Email = change.NewEmail
+	profile.LastModifiedDate = now
+	db.putProfile(profile)
+	delete(db.Database.EmailChanges, profileID)
+	log.Printf("AUDIT: Profile ID %s changed email from %s to %s", profileID, oldEmail, profile.Email)
+
+	db.requestSave()
+	return profile, oldEmail, nil
+}
+
+// emailTakenByOther reports whether another profile uses the email (case-insensitive).
+// Must be called with the lock held.
+func (db *Database) emailTakenByOther(profileID, email string) bool {
+	return db.profileIndexes.get(profileEmailIndex).takenByOther(profileID, email)
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"docserver/i18n"
+	"docserver/models"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabase_EmailChange(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	profile, err := db.CreateProfile(models.Profile{Email: "old@example.com"})
+	require.NoError(t, err)
+	_, err = db.CreateProfile(models.Profile{Email: "taken@example.com"})
+	require.NoError(t, err)
+
+	messageID := func(err error) string {
+		var msgErr *i18n.Error
+		require.True(t, errors.As(err, &msgErr), "expected a localized error, got %v", err)
+		return msgErr.ID
+	}
+
+	t.Run("Request is validated", func(t *testing.T) {
+		_, err := db.RequestEmailChange("missing", "new@example.com", "hash", time.Hour)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not found")
+
+		_, err = db.RequestEmailChange(profile.ID, "OLD@example.com", "hash", time.Hour)
+		assert.Equal(t, i18n.MsgEmailChangeSame, messageID(err))
+
+		_, err = db.RequestEmailChange(profile.ID, "Taken@example.com", "hash", time.Hour)
+		assert.Equal(t, i18n.MsgEmailAlreadyExists, messageID(err))
+	})
+
+	t.Run("Nothing to confirm", func(t *testing.T) {
+		_, _, err := db.ConfirmEmailChange(profile.ID, "hash")
+		assert.Equal(t, i18n.MsgEmailChangeNotFound, messageID(err))
+	})
+
+	t.Run("Wrong token keeps the request pending", func(t *testing.T) {
+		change, err := db.RequestEmailChange(profile.ID, "new@example.com", "right", time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, time.Hour, change.ExpiresAt.Sub(change.RequestedAt))
+
+		_, _, err = db.ConfirmEmailChange(profile.ID, "wrong")
+		assert.Equal(t, i18n.MsgEmailChangeMismatch, messageID(err))
+		_, pending := db.Database.EmailChanges[profile.ID]
+		assert.True(t, pending)
+	})
+
+	t.Run("Confirm", func(t *testing.T) {
+		updated, oldEmail, err := db.ConfirmEmailChange(profile.ID, "right")
+		require.NoError(t, err)
+		assert.Equal(t, "old@example.com", oldEmail)
+		assert.Equal(t, "new@example.com", updated.Email)
+		found, ok := db.GetProfileByEmail("new@example.com")
+		require.True(t, ok)
+		assert.Equal(t, profile.ID, found.ID)
+		_, pending := db.Database.EmailChanges[profile.ID]
+		assert.False(t, pending)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		_, err := db.RequestEmailChange(profile.ID, "later@example.com", "token", -time.Minute)
+		require.NoError(t, err)
+		_, _, err = db.ConfirmEmailChange(profile.ID, "token")
+		assert.Equal(t, i18n.MsgEmailChangeExpired, messageID(err))
+		_, pending := db.Database.EmailChanges[profile.ID]
+		assert.False(t, pending, "expired requests are discarded")
+	})
+
+	t.Run("Address taken in the meantime", func(t *testing.T) {
+		_, err := db.RequestEmailChange(profile.ID, "contested@example.com", "token", time.Hour)
+		require.NoError(t, err)
+		_, err = db.CreateProfile(models.Profile{Email: "contested@example.com"})
+		require.NoError(t, err)
+
+		_, _, err = db.ConfirmEmailChange(profile.ID, "token")
+		assert.Equal(t, i18n.MsgEmailAlreadyExists, messageID(err))
+		current, _ := db.GetProfileByID(profile.ID)
+		assert.Equal(t, "new@example.com", current.Email)
+	})
+
+	t.Run("Erasure discards a pending change", func(t *testing.T) {
+		_, err := db.RequestEmailChange(profile.ID, "final@example.com", "token", time.Hour)
+		require.NoError(t, err)
+		report, err := db.EraseProfile(profile.ID)
+		require.NoError(t, err)
+		assert.True(t, report.EmailChangeCleared)
+		assert.Empty(t, db.Database.EmailChanges)
+	})
+}
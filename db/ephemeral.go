@@ -0,0 +1,14 @@
+package db
+
+// --- Ephemeral Mode ---
+
+// Ephemeral reports whether the server runs with -ephemeral, keeping the database in memory only:
+// it started empty and nothing is ever written to disk.
+func (db *Database) Ephemeral() bool {
+	return db.config.Ephemeral
+}
+
+// persistenceDisabled reports whether saves are skipped, because of -ephemeral or -read-only.
+func (db *Database) persistenceDisabled() bool {
+	return db.config.Ephemeral || db.config.ReadOnly
+}
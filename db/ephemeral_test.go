@@ -0,0 +1,32 @@
+package db
+
+import (
+	"docserver/models"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEphemeral(t *testing.T) {
+	cfg := createTestConfig(t, t.TempDir())
+	writeTestDBFile(t, cfg, `{"schema_version": 1, "profiles": {"p1": {"id": "p1", "email": "on-disk@example.com"}}}`)
+	before := readTestDBFile(t, cfg)
+	cfg.Ephemeral = true
+
+	db, err := NewDatabase(cfg)
+	require.NoError(t, err)
+	assert.True(t, db.Ephemeral())
+	assert.Empty(t, db.GetAllProfiles(), "the database file is not loaded")
+
+	_, err = db.CreateProfile(models.Profile{Email: "in-memory@example.com", FirstName: "I", LastName: "M"})
+	require.NoError(t, err)
+	assert.Len(t, db.GetAllProfiles(), 1)
+	assert.NoError(t, db.PersistNow())
+	assert.NoError(t, db.Close())
+
+	assert.Equal(t, before, readTestDBFile(t, cfg), "the database file is never written")
+	_, err = os.Stat(cfg.DbFilePath + ".bak")
+	assert.True(t, os.IsNotExist(err))
+}
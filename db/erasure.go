@@ -0,0 +1,236 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/models"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// --- Data Erasure ---
+
+// ScheduleErasure records a request to erase all data of a profile once the grace period elapses.
+// If a pending request already exists it is returned unchanged and created is false.
+func (db *Database) ScheduleErasure(profileID string, gracePeriod time.Duration) (request models.ErasureRequest, created bool, err error) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	profile, found := db.Database.Profiles[profileID]
+	if !found {
+		return models.ErasureRequest{}, false, apperr.NotFound("profile with ID '%s' not found", profileID)
+	}
+
+	if existing, ok := db.Database.ErasureRequests[profileID]; ok && existing.Status == models.ErasureStatusPending {
+		return existing, false, nil
+	}
+
+	now := db.now().UTC()
+	request = models.ErasureRequest{
+		ProfileID:    profileID,
+		Email:        profile.Email,
+		Status:       models.ErasureStatusPending,
+		RequestedAt:  now,
+		ScheduledFor: now.Add(gracePeriod),
+	}
+	db.Database.ErasureRequests[profileID] = request
+	log.Printf("INFO: Scheduled erasure of Profile ID %s for %s", profileID, request.ScheduledFor.Format(time.RFC3339))
+
+	db.requestSave()
+	return request, true, nil
+}
+
+// GetErasureRequest returns the erasure request recorded for a profile, if any.
+func (db *Database) GetErasureRequest(profileID string) (models.ErasureRequest, bool) {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+	request, found := db.Database.ErasureRequests[profileID]
+	return request, found
+}
+
+// CancelErasure withdraws a pending erasure request.
+// Returns an error if there is no pending request for the profile.
+func (db *Database) CancelErasure(profileID string) error {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	request, found := db.Database.ErasureRequests[profileID]
+	if !found || request.Status != models.ErasureStatusPending {
+		return apperr.NotFound("pending erasure request for profile '%s' not found", profileID)
+	}
+	delete(db.Database.ErasureRequests, profileID)
+	log.Printf("INFO: Cancelled erasure of Profile ID %s", profileID)
+
+	db.requestSave()
+	return nil
+}
+
+// EraseProfile permanently removes a profile and everything tied to it: the profile itself,
+// the documents it owns and their share lists, its entries in other users' share lists,
+// its favorites, its mentions in other documents' activity feeds, its scheduled exports (and its
+// documents in other users' export snapshots) and any pending password reset OTP (with its failed attempts).
+// The database is saved immediately and, when backups are enabled, the backup file is overwritten
+// so it no longer contains the erased data.
+// The erasure request (if any) is kept as completed, without personal data, holding the report.
+func (db *Database) EraseProfile(profileID string) (models.ErasureReport, error) {
+	db.Database.Mu.Lock()
+
+	profile, found := db.Database.Profiles[profileID]
+	request, hasRequest := db.Database.ErasureRequests[profileID]
+	if !found && !hasRequest {
+		db.Database.Mu.Unlock()
+		return models.ErasureReport{}, apperr.NotFound("profile with ID '%s' not found", profileID)
+	}
+	// The profile may already have been deleted during the grace period; still erase the rest.
+	email := profile.Email
+	if !found {
+		email = request.Email
+	}
+
+	report := models.ErasureReport{ProfileDeleted: found}
+	db.removeProfile(profileID)
+
+	for docID, doc := range db.Database.Documents {
+		if doc.OwnerID != profileID {
+			continue
+		}
+		delete(db.Database.Documents, docID)
+		db.removeDocumentFromFavorites(docID)
+		delete(db.Database.DocumentEvents, docID)
+		delete(db.Database.DocumentVersions, docID)
+		report.ReviewsDeleted += db.deleteReviewsOf(docID)
+		report.DocumentsDeleted++
+		if _, hasShares := db.Database.ShareRecords[docID]; hasShares {
+			delete(db.Database.ShareRecords, docID)
+			report.ShareRecordsDeleted++
+		}
+	}
+
+	for docID, record := range db.Database.ShareRecords {
+		remaining := make([]string, 0, len(record.SharedWith))
+		for _, sharedID := range record.SharedWith {
+			if sharedID == profileID {
+				report.SharesRevoked++
+				continue
+			}
+			remaining = append(remaining, sharedID)
+		}
+		if len(remaining) == len(record.SharedWith) {
+			continue
+		}
+		if len(remaining) == 0 {
+			delete(db.Database.ShareRecords, docID)
+		} else {
+			record.SharedWith = remaining
+			db.Database.ShareRecords[docID] = pruneShareScopes(record)
+		}
+	}
+
+	if _, hasFavorites := db.Database.Favorites[profileID]; hasFavorites {
+		delete(db.Database.Favorites, profileID)
+		report.FavoritesCleared = true
+	}
+	report.EventsScrubbed = db.scrubProfileFromEvents(profileID)
+	report.SchedulesDeleted, report.SnapshotsScrubbed = db.deleteSchedulesOf(profileID)
+	report.ChannelsDeleted = db.deleteNotificationChannelsOf(profileID)
+	report.ReviewsDeleted += db.deleteReviewsBy(profileID)
+	if _, hasOTP := db.Database.OTPs[email]; hasOTP {
+		delete(db.Database.OTPs, email)
+		report.OTPCleared = true
+	}
+	delete(db.Database.OTPLockouts, email)
+	if _, hasUsage := db.Database.APIUsage[profileID]; hasUsage {
+		delete(db.Database.APIUsage, profileID)
+		report.APIUsageCleared = true
+	}
+	if _, hasDevices := db.Database.Devices[profileID]; hasDevices {
+		delete(db.Database.Devices, profileID)
+		report.DevicesCleared = true
+	}
+	if _, hasEmailChange := db.Database.EmailChanges[profileID]; hasEmailChange {
+		delete(db.Database.EmailChanges, profileID)
+		report.EmailChangeCleared = true
+	}
+
+	completedAt := db.now().UTC()
+	if !hasRequest {
+		request = models.ErasureRequest{ProfileID: profileID, RequestedAt: completedAt, ScheduledFor: completedAt}
+	}
+	request.Email = "" // Drop the last piece of personal data
+	request.Status = models.ErasureStatusCompleted
+	request.CompletedAt = &completedAt
+	request.Report = &report
+	db.Database.ErasureRequests[profileID] = request
+
+	db.Database.Mu.Unlock()
+
+	// Save right away rather than waiting for the debounce, then scrub the backup
+	if err := db.persist(); err != nil {
+		return report, fmt.Errorf("failed to save database after erasure: %w", err)
+	}
+	report.Backup = db.scrubBackup()
+
+	// Record the final report (the backup result is only known now)
+	db.Database.Mu.Lock()
+	request.Report = &report
+	db.Database.ErasureRequests[profileID] = request
+	db.Database.Mu.Unlock()
+	db.requestSave()
+
+	log.Printf("INFO: Erased Profile ID %s: %d documents, %d share records, %d shares revoked, backup %s",
+		profileID, report.DocumentsDeleted, report.ShareRecordsDeleted, report.SharesRevoked, report.Backup)
+	return report, nil
+}
+
+// scrubBackup overwrites the backup files with the current database files so that data
+// removed from the database does not survive in the backups.
+func (db *Database) scrubBackup() string {
+	if !db.config.EnableBackup || db.config.Ephemeral {
+		return "not_enabled"
+	}
+	if db.staged {
+		return "deferred" // Inside a transaction the file does not hold the erasure yet
+	}
+	for _, path := range db.storagePaths() {
+		backupFilePath := path + ".bak"
+		data, err := os.ReadFile(path)
+		if err == nil {
+			err = os.WriteFile(backupFilePath, data, 0644)
+		}
+		if err != nil {
+			log.Printf("ERROR: Failed to scrub backup file '%s': %v", backupFilePath, err)
+			return "failed"
+		}
+	}
+	return "scrubbed"
+}
+
+// ProcessDueErasures carries out every pending erasure whose grace period has elapsed by now.
+// It returns the number of profiles erased.
+func (db *Database) ProcessDueErasures(now time.Time) int {
+	db.Database.Mu.RLock()
+	var due []string
+	for profileID, request := range db.Database.ErasureRequests {
+		if request.Status == models.ErasureStatusPending && !now.Before(request.ScheduledFor) {
+			due = append(due, profileID)
+		}
+	}
+	db.Database.Mu.RUnlock()
+
+	erased := 0
+	for _, profileID := range due {
+		if _, err := db.EraseProfile(profileID); err != nil {
+			log.Printf("ERROR: Scheduled erasure of Profile ID %s failed: %v", profileID, err)
+			continue
+		}
+		erased++
+	}
+	return erased
+}
+
+// StartErasureWorker periodically runs ProcessDueErasures in the background.
+// Call the returned function to stop the worker.
+func (db *Database) StartErasureWorker(interval time.Duration) (stop func()) {
+	return startWorker(interval, db.now, func(now time.Time) { db.ProcessDueErasures(now) })
+}
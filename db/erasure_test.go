@@ -0,0 +1,184 @@
+package db
+
+import (
+	"docserver/models"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabase_ScheduleAndCancelErasure(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	profile, err := db.CreateProfile(models.Profile{Email: "erase@example.com"})
+	require.NoError(t, err)
+
+	t.Run("Unknown profile", func(t *testing.T) {
+		_, _, err := db.ScheduleErasure("missing", time.Hour)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not found")
+	})
+
+	t.Run("Schedule", func(t *testing.T) {
+		request, created, err := db.ScheduleErasure(profile.ID, time.Hour)
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.Equal(t, models.ErasureStatusPending, request.Status)
+		assert.Equal(t, "erase@example.com", request.Email)
+		assert.Equal(t, time.Hour, request.ScheduledFor.Sub(request.RequestedAt))
+
+		stored, found := db.GetErasureRequest(profile.ID)
+		require.True(t, found)
+		assert.Equal(t, request, stored)
+	})
+
+	t.Run("Scheduling again returns the pending request", func(t *testing.T) {
+		first, _ := db.GetErasureRequest(profile.ID)
+		request, created, err := db.ScheduleErasure(profile.ID, 24*time.Hour)
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, first, request)
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		require.NoError(t, db.CancelErasure(profile.ID))
+		_, found := db.GetErasureRequest(profile.ID)
+		assert.False(t, found)
+
+		err := db.CancelErasure(profile.ID)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not found")
+	})
+}
+
+func TestDatabase_EraseProfile(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	target, err := db.CreateProfile(models.Profile{Email: "target@example.com"})
+	require.NoError(t, err)
+	other, err := db.CreateProfile(models.Profile{Email: "other@example.com"})
+	require.NoError(t, err)
+
+	ownedShared, err := db.CreateDocument(models.Document{OwnerID: target.ID, Content: "shared"})
+	require.NoError(t, err)
+	_, err = db.CreateDocument(models.Document{OwnerID: target.ID, Content: "private"})
+	require.NoError(t, err)
+	otherDoc, err := db.CreateDocument(models.Document{OwnerID: other.ID, Content: "theirs"})
+	require.NoError(t, err)
+	otherSoloDoc, err := db.CreateDocument(models.Document{OwnerID: other.ID, Content: "theirs too"})
+	require.NoError(t, err)
+
+	require.NoError(t, db.SetShareRecord(ownedShared.ID, []string{other.ID}))
+	require.NoError(t, db.SetShareRecord(otherDoc.ID, []string{target.ID, "someone"}))
+	require.NoError(t, db.SetShareRecord(otherSoloDoc.ID, []string{target.ID}))
+	db.StoreOTP(target.Email, "123456", time.Now().Add(time.Minute))
+	db.RecordFailedOTPAttempt(target.Email, time.Minute, time.Hour)
+
+	// Make sure a backup holding the target's data exists before erasing
+	require.NoError(t, db.persist())
+	require.NoError(t, db.persist())
+	backup, err := os.ReadFile(db.config.DbFilePath + ".bak")
+	require.NoError(t, err)
+	require.Contains(t, string(backup), "target@example.com")
+
+	_, _, err = db.ScheduleErasure(target.ID, time.Hour)
+	require.NoError(t, err)
+
+	report, err := db.EraseProfile(target.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ErasureReport{
+		ProfileDeleted:      true,
+		DocumentsDeleted:    2,
+		ShareRecordsDeleted: 1,
+		SharesRevoked:       2,
+		OTPCleared:          true,
+		EventsScrubbed:      2,
+		Backup:              "scrubbed",
+	}, report)
+
+	_, found := db.GetProfileByID(target.ID)
+	assert.False(t, found)
+	assert.Empty(t, db.GetDocumentsByOwner(target.ID))
+	assert.Len(t, db.GetDocumentsByOwner(other.ID), 2, "other users' documents are kept")
+	_, found = db.GetShareRecordByDocumentID(ownedShared.ID)
+	assert.False(t, found)
+	record, found := db.GetShareRecordByDocumentID(otherDoc.ID)
+	require.True(t, found)
+	assert.Equal(t, []string{"someone"}, record.SharedWith)
+	_, found = db.GetShareRecordByDocumentID(otherSoloDoc.ID)
+	assert.False(t, found, "share records left empty are removed")
+	assert.Empty(t, db.GetDocumentEvents(ownedShared.ID), "activity of erased documents is removed")
+	for _, event := range db.GetDocumentEvents(otherDoc.ID) {
+		assert.NotContains(t, event.ProfileIDs, target.ID)
+	}
+	for _, event := range db.GetDocumentEvents(otherSoloDoc.ID) {
+		assert.NotEqual(t, models.EventShared, event.Type, "share entries naming only the erased profile are removed")
+	}
+	_, _, found = db.RetrieveOTP(target.Email)
+	assert.False(t, found)
+	assert.True(t, db.OTPLockedUntil(target.Email).IsZero())
+
+	request, found := db.GetErasureRequest(target.ID)
+	require.True(t, found)
+	assert.Equal(t, models.ErasureStatusCompleted, request.Status)
+	assert.Empty(t, request.Email)
+	require.NotNil(t, request.CompletedAt)
+	require.NotNil(t, request.Report)
+	assert.Equal(t, report, *request.Report)
+
+	backup, err = os.ReadFile(db.config.DbFilePath + ".bak")
+	require.NoError(t, err)
+	assert.NotContains(t, string(backup), "target@example.com")
+	assert.NotContains(t, readTestDBFile(t, db.config), "target@example.com")
+
+	_, err = db.EraseProfile("missing")
+	assert.Error(t, err)
+}
+
+func TestDatabase_EraseProfile_BackupDisabled(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	db.config.EnableBackup = false
+
+	profile, err := db.CreateProfile(models.Profile{Email: "nobackup@example.com"})
+	require.NoError(t, err)
+
+	report, err := db.EraseProfile(profile.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "not_enabled", report.Backup)
+
+	request, found := db.GetErasureRequest(profile.ID)
+	require.True(t, found, "a completed request is recorded even without a prior schedule")
+	assert.Equal(t, models.ErasureStatusCompleted, request.Status)
+}
+
+func TestDatabase_ProcessDueErasures(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	due, err := db.CreateProfile(models.Profile{Email: "due@example.com"})
+	require.NoError(t, err)
+	later, err := db.CreateProfile(models.Profile{Email: "later@example.com"})
+	require.NoError(t, err)
+
+	_, _, err = db.ScheduleErasure(due.ID, time.Minute)
+	require.NoError(t, err)
+	_, _, err = db.ScheduleErasure(later.ID, time.Hour)
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, db.ProcessDueErasures(time.Now().UTC()))
+	assert.Equal(t, 1, db.ProcessDueErasures(time.Now().UTC().Add(2*time.Minute)))
+
+	_, found := db.GetProfileByID(due.ID)
+	assert.False(t, found)
+	_, found = db.GetProfileByID(later.ID)
+	assert.True(t, found)
+
+	// Completed requests are not processed again
+	assert.Equal(t, 0, db.ProcessDueErasures(time.Now().UTC().Add(2*time.Minute)))
+}
@@ -0,0 +1,120 @@
+package db
+
+import (
+	"docserver/models"
+)
+
+// --- Document Activity ---
+
+// maxDocumentEvents caps the activity kept per document; the oldest entries are dropped first.
+const maxDocumentEvents = 500
+
+// recordDocumentEvent appends an event to a document's activity feed, stamping it with the current time.
+// Must be called with the write lock held; the caller triggers the save.
+func (db *Database) recordDocumentEvent(docID string, event models.DocumentEvent) {
+	event.Timestamp = db.now().UTC()
+	events := append(db.Database.DocumentEvents[docID], event)
+	if len(events) > maxDocumentEvents {
+		events = append([]models.DocumentEvent{}, events[len(events)-maxDocumentEvents:]...)
+	}
+	db.Database.DocumentEvents[docID] = events
+}
+
+// recordShareChanges records "shared" and "unshared" events for the difference between two share lists,
+// and notifies the profiles a document is newly shared with (see notify).
+// Events are attributed to the document's owner, who is the only one allowed to change sharing.
+// Nothing is recorded for share records of documents that do not exist.
+// Must be called with the write lock held.
+func (db *Database) recordShareChanges(docID string, before, after []string) {
+	doc, found := db.Database.Documents[docID]
+	if !found {
+		return
+	}
+
+	inBefore := make(map[string]bool, len(before))
+	for _, id := range before {
+		inBefore[id] = true
+	}
+	inAfter := make(map[string]bool, len(after))
+	for _, id := range after {
+		inAfter[id] = true
+	}
+
+	var added, removed []string
+	for _, id := range after {
+		if !inBefore[id] {
+			added = append(added, id)
+		}
+	}
+	for _, id := range before {
+		if !inAfter[id] {
+			removed = append(removed, id)
+		}
+	}
+
+	if len(added) > 0 {
+		db.recordDocumentEvent(docID, models.DocumentEvent{Type: models.EventShared, ActorID: doc.OwnerID, ProfileIDs: added})
+		db.notify(notification{event: models.NotificationDocumentShared, docID: docID, actorID: doc.OwnerID, recipients: added})
+	}
+	if len(removed) > 0 {
+		db.recordDocumentEvent(docID, models.DocumentEvent{Type: models.EventUnshared, ActorID: doc.OwnerID, ProfileIDs: removed})
+	}
+}
+
+// GetDocumentEvents returns a document's activity feed, newest first.
+// Access to the document is checked at handler level.
+func (db *Database) GetDocumentEvents(docID string) []models.DocumentEvent {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+	return (&ReadView{db: db}).GetDocumentEvents(docID)
+}
+
+// scrubProfileFromEvents removes a profile from the activity feeds of documents it does not own:
+// it is dropped from "shared"/"unshared" entries (entries left with no profiles are removed)
+// and anonymized where it was the actor. Returns the number of entries changed or removed.
+// Must be called with the write lock held.
+func (db *Database) scrubProfileFromEvents(profileID string) int {
+	scrubbed := 0
+	for docID, events := range db.Database.DocumentEvents {
+		kept := events[:0:0]
+		changed := false
+		for _, event := range events {
+			touched := false
+			if event.ActorID == profileID {
+				event.ActorID = ""
+				touched = true
+			}
+			if len(event.ProfileIDs) > 0 {
+				remaining := make([]string, 0, len(event.ProfileIDs))
+				for _, id := range event.ProfileIDs {
+					if id != profileID {
+						remaining = append(remaining, id)
+					}
+				}
+				if len(remaining) != len(event.ProfileIDs) {
+					touched = true
+					if len(remaining) == 0 {
+						scrubbed++
+						changed = true
+						continue
+					}
+					event.ProfileIDs = remaining
+				}
+			}
+			if touched {
+				scrubbed++
+				changed = true
+			}
+			kept = append(kept, event)
+		}
+		if !changed {
+			continue
+		}
+		if len(kept) == 0 {
+			delete(db.Database.DocumentEvents, docID)
+		} else {
+			db.Database.DocumentEvents[docID] = kept
+		}
+	}
+	return scrubbed
+}
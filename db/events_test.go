@@ -0,0 +1,84 @@
+package db
+
+import (
+	"docserver/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func eventTypes(events []models.DocumentEvent) []string {
+	types := make([]string, len(events))
+	for i, event := range events {
+		types[i] = event.Type
+	}
+	return types
+}
+
+func TestDatabase_DocumentEvents(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	doc, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: map[string]any{"title": "Draft", "tags": []any{"a"}}})
+	require.NoError(t, err)
+
+	_, err = db.UpdateDocument(doc.ID, map[string]any{"title": "Final", "tags": []any{"a"}, "grade": 90.0})
+	require.NoError(t, err)
+	require.NoError(t, db.SetShareRecord(doc.ID, []string{"alice", "bob"}))
+	require.NoError(t, db.AddSharerToDocument(doc.ID, "carol"))
+	require.NoError(t, db.AddSharerToDocument(doc.ID, "carol")) // No change, no event
+	require.NoError(t, db.SetShareRecord(doc.ID, []string{"bob", "carol"}))
+	require.NoError(t, db.RemoveSharerFromDocument(doc.ID, "bob"))
+	_, err = db.SetDocumentPublic(doc.ID, true)
+	require.NoError(t, err)
+	_, err = db.SetDocumentPublic(doc.ID, false)
+	require.NoError(t, err)
+
+	events := db.GetDocumentEvents(doc.ID)
+	assert.Equal(t, []string{
+		models.EventUnpublished, models.EventPublished, models.EventUnshared, models.EventUnshared,
+		models.EventShared, models.EventShared, models.EventUpdated, models.EventCreated,
+	}, eventTypes(events), "newest first")
+
+	for i, event := range events {
+		assert.Equal(t, "owner", event.ActorID)
+		assert.False(t, event.Timestamp.IsZero())
+		if i > 0 {
+			assert.False(t, event.Timestamp.After(events[i-1].Timestamp))
+		}
+	}
+	assert.Equal(t, []string{"grade", "title"}, events[6].ChangedPaths)
+	assert.Equal(t, []string{"alice", "bob"}, events[5].ProfileIDs)
+	assert.Equal(t, []string{"carol"}, events[4].ProfileIDs)
+	assert.Equal(t, []string{"alice"}, events[3].ProfileIDs)
+	assert.Equal(t, []string{"bob"}, events[2].ProfileIDs)
+
+	// Share lists of unknown documents have no activity
+	require.NoError(t, db.SetShareRecord("missing", []string{"alice"}))
+	assert.Empty(t, db.GetDocumentEvents("missing"))
+
+	// Persisted with the database
+	require.NoError(t, db.persist())
+	assert.Contains(t, readTestDBFile(t, db.config), `"document_events"`)
+
+	// Deleting the document drops its activity
+	require.NoError(t, db.DeleteDocument(doc.ID))
+	assert.Empty(t, db.GetDocumentEvents(doc.ID))
+}
+
+func TestDatabase_DocumentEvents_Capped(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	doc, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: 0})
+	require.NoError(t, err)
+	for i := 1; i <= maxDocumentEvents; i++ {
+		_, err := db.UpdateDocument(doc.ID, i)
+		require.NoError(t, err)
+	}
+
+	events := db.GetDocumentEvents(doc.ID)
+	require.Len(t, events, maxDocumentEvents)
+	assert.Equal(t, models.EventUpdated, events[len(events)-1].Type, "the oldest entry (created) was dropped")
+}
@@ -0,0 +1,100 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/models"
+	"log"
+	"time"
+)
+
+// --- Document Expiry ---
+
+// How document lists treat expired documents (see QueryDocumentsParams.Expired)
+const (
+	ExpiredExclude = "exclude" // Leave them out (the default)
+	ExpiredInclude = "include" // List them with the others
+	ExpiredOnly    = "only"    // List only them
+)
+
+// documentExpired reports whether doc has expired by now.
+func documentExpired(doc models.Document, now time.Time) bool {
+	return doc.ExpiresAt != nil && !now.Before(*doc.ExpiresAt)
+}
+
+// DocumentTTL returns how long doc has left until it expires as of now, 0 once it has expired,
+// and false if it does not expire.
+func DocumentTTL(doc models.Document, now time.Time) (time.Duration, bool) {
+	if doc.ExpiresAt == nil {
+		return 0, false
+	}
+	return max(doc.ExpiresAt.Sub(now), 0), true
+}
+
+// checkExpiresAt returns an ErrValidation error unless expiresAt is nil or after now.
+func checkExpiresAt(expiresAt *time.Time, now time.Time) error {
+	if expiresAt != nil && !expiresAt.After(now) {
+		return apperr.Validation("expires_at must be in the future")
+	}
+	return nil
+}
+
+// SetDocumentExpiry sets when a document expires, or with a nil expiresAt, makes it never expire.
+// From then on the document is not found by GetDocumentByID and is left out of document lists
+// unless they ask for expired documents; it is purged -expiry-grace-period later (see
+// PurgeExpiredDocuments). Expired documents are not found, so they cannot be given a new time.
+// Permissions are checked at handler level.
+func (db *Database) SetDocumentExpiry(id string, expiresAt *time.Time) (models.Document, error) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	now := db.now().UTC()
+	doc, found := db.Database.Documents[id]
+	if !found || documentExpired(doc, now) {
+		return models.Document{}, apperr.NotFound("document with ID '%s' not found", id)
+	}
+	if err := checkExpiresAt(expiresAt, now); err != nil {
+		return models.Document{}, err
+	}
+
+	doc.ExpiresAt = nil
+	if expiresAt != nil {
+		at := expiresAt.UTC()
+		doc.ExpiresAt = &at
+	}
+	doc.LastModifiedDate = now
+	db.Database.Documents[id] = doc
+	if doc.ExpiresAt != nil {
+		log.Printf("INFO: Document ID %s expires at %s", id, doc.ExpiresAt.Format(time.RFC3339))
+	} else {
+		log.Printf("INFO: Document ID %s no longer expires", id)
+	}
+
+	db.requestSave()
+	return doc, nil
+}
+
+// PurgeExpiredDocuments deletes the documents that expired at least gracePeriod before now,
+// with everything belonging to them, bypassing document scripts. It returns how many it deleted.
+func (db *Database) PurgeExpiredDocuments(now time.Time, gracePeriod time.Duration) int {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	purged := 0
+	for id, doc := range db.Database.Documents {
+		if documentExpired(doc, now.Add(-gracePeriod)) {
+			db.removeDocument(id)
+			purged++
+		}
+	}
+	if purged > 0 {
+		log.Printf("INFO: Purged %d expired document(s)", purged)
+		db.requestSave()
+	}
+	return purged
+}
+
+// StartExpiryWorker periodically runs PurgeExpiredDocuments in the background with the
+// configured -expiry-grace-period. Call the returned function to stop the worker.
+func (db *Database) StartExpiryWorker(interval time.Duration) (stop func()) {
+	return startWorker(interval, db.now, func(now time.Time) { db.PurgeExpiredDocuments(now, db.config.ExpiryGracePeriod) })
+}
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/config"
+	"docserver/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentExpiry(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	clock := config.NewFixedClock(time.Date(2025, time.June, 1, 9, 0, 0, 0, time.UTC))
+	db.config.Clock = clock
+	inAnHour := clock.Now().Add(time.Hour)
+
+	past := clock.Now().Add(-time.Minute)
+	_, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: "x", ExpiresAt: &past})
+	assert.ErrorIs(t, err, apperr.ErrValidation, "expires_at must be in the future")
+
+	doc, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: map[string]any{"title": "scratch"}, ExpiresAt: &inAnHour})
+	require.NoError(t, err)
+	kept, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: map[string]any{"title": "kept"}})
+	require.NoError(t, err)
+	ttl, expires := DocumentTTL(doc, clock.Now())
+	assert.True(t, expires)
+	assert.Equal(t, time.Hour, ttl)
+	_, expires = DocumentTTL(kept, clock.Now())
+	assert.False(t, expires)
+
+	t.Run("Expired documents are gone until purged", func(t *testing.T) {
+		clock.Advance(time.Hour)
+		_, found := db.GetDocumentByID(doc.ID)
+		assert.False(t, found)
+		_, err := db.SetDocumentExpiry(doc.ID, nil)
+		assert.ErrorIs(t, err, apperr.ErrNotFound, "cannot be brought back")
+
+		list := func(expired string) []string {
+			docs, _, err := db.QueryDocuments(QueryDocumentsParams{AuthUserID: "owner", Scope: "owned", Expired: expired, Page: 1, Limit: 10, SortBy: "creation_date"})
+			require.NoError(t, err)
+			ids := []string{}
+			for _, d := range docs {
+				ids = append(ids, d.ID)
+			}
+			return ids
+		}
+		assert.Equal(t, []string{kept.ID}, list(""))
+		assert.ElementsMatch(t, []string{doc.ID, kept.ID}, list(ExpiredInclude))
+		assert.Equal(t, []string{doc.ID}, list(ExpiredOnly))
+		_, _, err = db.QueryDocuments(QueryDocumentsParams{AuthUserID: "owner", Expired: "sometimes", Page: 1, Limit: 10})
+		assert.ErrorIs(t, err, apperr.ErrValidation)
+
+		assert.Zero(t, db.PurgeExpiredDocuments(clock.Now(), time.Hour), "still within the grace period")
+		assert.Equal(t, 1, db.PurgeExpiredDocuments(clock.Now().Add(time.Hour), time.Hour))
+		assert.Equal(t, []string{}, list(ExpiredOnly))
+		assert.Empty(t, db.GetDocumentEvents(doc.ID), "purged with its activity")
+	})
+
+	t.Run("Setting and clearing the expiry time", func(t *testing.T) {
+		at := clock.Now().Add(24 * time.Hour)
+		updated, err := db.SetDocumentExpiry(kept.ID, &at)
+		require.NoError(t, err)
+		require.NotNil(t, updated.ExpiresAt)
+		assert.Equal(t, at, *updated.ExpiresAt)
+
+		updated, err = db.SetDocumentExpiry(kept.ID, nil)
+		require.NoError(t, err)
+		assert.Nil(t, updated.ExpiresAt)
+
+		past := clock.Now()
+		_, err = db.SetDocumentExpiry(kept.ID, &past)
+		assert.ErrorIs(t, err, apperr.ErrValidation)
+		_, err = db.SetDocumentExpiry("missing", &at)
+		assert.ErrorIs(t, err, apperr.ErrNotFound)
+	})
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"docserver/apperr"
+	"log"
+)
+
+// --- Favorites ---
+
+// AddFavorite bookmarks a document for a profile.
+// Returns false if it was already a favorite, or an error if the document does not exist.
+// Access to the document is checked at handler level.
+func (db *Database) AddFavorite(profileID, docID string) (bool, error) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	if _, found := db.Database.Documents[docID]; !found {
+		return false, apperr.NotFound("document with ID '%s' not found", docID)
+	}
+	for _, favID := range db.Database.Favorites[profileID] {
+		if favID == docID {
+			return false, nil
+		}
+	}
+
+	db.Database.Favorites[profileID] = append(db.Database.Favorites[profileID], docID)
+	log.Printf("INFO: Profile ID %s added Document ID %s to favorites", profileID, docID)
+
+	db.requestSave()
+	return true, nil
+}
+
+// RemoveFavorite removes a document from a profile's favorites.
+// Returns false if it was not a favorite.
+func (db *Database) RemoveFavorite(profileID, docID string) bool {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	favorites := db.Database.Favorites[profileID]
+	for i, favID := range favorites {
+		if favID != docID {
+			continue
+		}
+		favorites = append(favorites[:i:i], favorites[i+1:]...)
+		if len(favorites) == 0 {
+			delete(db.Database.Favorites, profileID)
+		} else {
+			db.Database.Favorites[profileID] = favorites
+		}
+		log.Printf("INFO: Profile ID %s removed Document ID %s from favorites", profileID, docID)
+
+		db.requestSave()
+		return true
+	}
+	return false
+}
+
+// GetFavorites returns the set of document IDs a profile has bookmarked.
+func (db *Database) GetFavorites(profileID string) map[string]bool {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+	return (&ReadView{db: db}).GetFavorites(profileID)
+}
+
+// GetFavoriteIDs returns the document IDs a profile has bookmarked, in the order they were added.
+func (db *Database) GetFavoriteIDs(profileID string) []string {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+
+	return append([]string{}, db.Database.Favorites[profileID]...)
+}
+
+// removeDocumentFromFavorites drops a deleted document from every profile's favorites.
+// Must be called with the write lock held.
+func (db *Database) removeDocumentFromFavorites(docID string) {
+	for profileID, favorites := range db.Database.Favorites {
+		remaining := favorites[:0:0]
+		for _, favID := range favorites {
+			if favID != docID {
+				remaining = append(remaining, favID)
+			}
+		}
+		if len(remaining) == len(favorites) {
+			continue
+		}
+		if len(remaining) == 0 {
+			delete(db.Database.Favorites, profileID)
+		} else {
+			db.Database.Favorites[profileID] = remaining
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"docserver/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabase_Favorites(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	doc1, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: "one"})
+	require.NoError(t, err)
+	doc2, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: "two"})
+	require.NoError(t, err)
+
+	t.Run("Add", func(t *testing.T) {
+		added, err := db.AddFavorite("reader", doc2.ID)
+		require.NoError(t, err)
+		assert.True(t, added)
+		added, err = db.AddFavorite("reader", doc1.ID)
+		require.NoError(t, err)
+		assert.True(t, added)
+
+		added, err = db.AddFavorite("reader", doc1.ID)
+		require.NoError(t, err)
+		assert.False(t, added, "adding twice is a no-op")
+
+		assert.Equal(t, []string{doc2.ID, doc1.ID}, db.GetFavoriteIDs("reader"), "kept in the order added")
+		assert.Equal(t, map[string]bool{doc1.ID: true, doc2.ID: true}, db.GetFavorites("reader"))
+		assert.Empty(t, db.GetFavorites("someone-else"))
+	})
+
+	t.Run("Unknown document", func(t *testing.T) {
+		_, err := db.AddFavorite("reader", "missing")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not found")
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		assert.True(t, db.RemoveFavorite("reader", doc2.ID))
+		assert.False(t, db.RemoveFavorite("reader", doc2.ID))
+		assert.Equal(t, []string{doc1.ID}, db.GetFavoriteIDs("reader"))
+	})
+
+	t.Run("Deleting a document drops it from favorites", func(t *testing.T) {
+		_, err := db.AddFavorite("other", doc1.ID)
+		require.NoError(t, err)
+
+		require.NoError(t, db.DeleteDocument(doc1.ID))
+		assert.Empty(t, db.GetFavoriteIDs("reader"))
+		assert.Empty(t, db.GetFavoriteIDs("other"))
+		db.Database.Mu.RLock()
+		assert.Empty(t, db.Database.Favorites, "empty lists are removed")
+		db.Database.Mu.RUnlock()
+	})
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/i18n"
+	"docserver/models"
+	"log"
+)
+
+// --- Document Freezing ---
+
+// checkNotFrozen returns an ErrLocked error if the document exists and is frozen.
+// Must be called with the lock held.
+func (db *Database) checkNotFrozen(docID string) error {
+	if doc, found := db.Database.Documents[docID]; found && doc.Frozen {
+		return apperr.Wrap(apperr.ErrLocked, i18n.NewError(i18n.MsgDocumentFrozen, docID))
+	}
+	return nil
+}
+
+// SetDocumentFrozen freezes a document, making its content, public flag and shares read-only,
+// or unfreezes it. While it is frozen, the methods changing those return an ErrLocked error and
+// search-and-replace skips it. actorID is the profile making the change; permissions are checked at handler
+// level. Freezing a frozen document (or unfreezing one that is not) changes nothing, so the
+// original freeze time and actor are kept.
+func (db *Database) SetDocumentFrozen(id string, frozen bool, actorID string) (models.Document, error) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	doc, found := db.Database.Documents[id]
+	if !found {
+		return models.Document{}, apperr.NotFound("document with ID '%s' not found", id)
+	}
+	if doc.Frozen == frozen {
+		return doc, nil
+	}
+
+	eventType := models.EventUnfrozen
+	doc.Frozen = frozen
+	doc.FrozenAt = nil
+	doc.FrozenBy = ""
+	if frozen {
+		eventType = models.EventFrozen
+		now := db.now().UTC()
+		doc.FrozenAt = &now
+		doc.FrozenBy = actorID
+	}
+	db.Database.Documents[id] = doc
+	db.recordDocumentEvent(id, models.DocumentEvent{Type: eventType, ActorID: actorID})
+	log.Printf("INFO: Set Document ID %s frozen: %t (by Profile ID %s)", id, frozen, actorID)
+
+	db.requestSave()
+	return doc, nil
+}
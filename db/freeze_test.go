@@ -0,0 +1,67 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabase_SetDocumentFrozen(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	doc, err := db.CreateDocument(models.Document{OwnerID: "owner1", Content: map[string]any{"answer": "42"}})
+	require.NoError(t, err)
+
+	frozen, err := db.SetDocumentFrozen(doc.ID, true, "teacher1")
+	require.NoError(t, err)
+	assert.True(t, frozen.Frozen)
+	require.NotNil(t, frozen.FrozenAt)
+	assert.Equal(t, "teacher1", frozen.FrozenBy)
+
+	again, err := db.SetDocumentFrozen(doc.ID, true, "owner1")
+	require.NoError(t, err)
+	assert.Equal(t, "teacher1", again.FrozenBy, "freezing again keeps the original freeze")
+
+	t.Run("Changes are locked", func(t *testing.T) {
+		_, err := db.UpdateDocument(doc.ID, map[string]any{"answer": "43"})
+		assert.ErrorIs(t, err, apperr.ErrLocked)
+		_, err = db.UpdateDocumentAs(doc.ID, "43", models.ContentTypeText)
+		assert.ErrorIs(t, err, apperr.ErrLocked)
+		_, err = db.SetDocumentPublic(doc.ID, true)
+		assert.ErrorIs(t, err, apperr.ErrLocked)
+		assert.ErrorIs(t, db.SetShareRecord(doc.ID, []string{"friend"}), apperr.ErrLocked)
+		assert.ErrorIs(t, db.AddSharerToDocument(doc.ID, "friend"), apperr.ErrLocked)
+		assert.ErrorIs(t, db.RemoveSharerFromDocument(doc.ID, "friend"), apperr.ErrLocked)
+
+		report, err := db.ReplaceInDocuments(ReplaceSpec{OwnerID: "owner1", Path: "answer", Old: "42", HasOld: true, New: "43"})
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Matched)
+		assert.Equal(t, 1, report.Frozen)
+		assert.Zero(t, report.Changed)
+
+		stored, _ := db.GetDocumentByID(doc.ID)
+		assert.Equal(t, map[string]any{"answer": "42"}, stored.Content)
+		assert.Equal(t, 1, stored.Version)
+	})
+
+	unfrozen, err := db.SetDocumentFrozen(doc.ID, false, "teacher1")
+	require.NoError(t, err)
+	assert.False(t, unfrozen.Frozen)
+	assert.Nil(t, unfrozen.FrozenAt)
+	assert.Empty(t, unfrozen.FrozenBy)
+	_, err = db.UpdateDocument(doc.ID, map[string]any{"answer": "43"})
+	assert.NoError(t, err)
+
+	var types []string
+	for _, event := range db.GetDocumentEvents(doc.ID) {
+		types = append(types, event.Type)
+	}
+	assert.Equal(t, []string{models.EventUpdated, models.EventUnfrozen, models.EventFrozen, models.EventCreated}, types)
+
+	_, err = db.SetDocumentFrozen("missing", true, "owner1")
+	assert.ErrorIs(t, err, apperr.ErrNotFound)
+}
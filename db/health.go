@@ -0,0 +1,56 @@
+package db
+
+import (
+	"sync"
+	"time"
+)
+
+// --- Persistence Health ---
+
+// PersistenceStatus reports whether the database is being saved successfully.
+type PersistenceStatus struct {
+	Healthy       bool       `json:"healthy"`                   // False if the latest save failed
+	LastSavedAt   *time.Time `json:"last_saved_at,omitempty"`   // UTC; latest successful save, if any since startup
+	LastFailureAt *time.Time `json:"last_failure_at,omitempty"` // UTC; latest failed save, if any since startup
+	SavePending   bool       `json:"save_pending"`              // Changes are waiting for the debounced save
+}
+
+// persistHealth tracks the outcome of saves. It has its own lock because saves run
+// outside both the data lock and the save timer lock.
+type persistHealth struct {
+	mu            sync.Mutex
+	lastSavedAt   time.Time
+	lastFailureAt time.Time
+	lastErr       error
+}
+
+// record notes the outcome of a save.
+func (h *persistHealth) record(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastErr = err
+	if err != nil {
+		h.lastFailureAt = time.Now().UTC()
+	} else {
+		h.lastSavedAt = time.Now().UTC()
+	}
+}
+
+// PersistenceStatus returns the outcome of the latest save. A database that has not needed
+// to save since startup is healthy.
+func (db *Database) PersistenceStatus() PersistenceStatus {
+	db.saveMutex.Lock()
+	status := PersistenceStatus{SavePending: db.savePending}
+	db.saveMutex.Unlock()
+
+	db.persistHealth.mu.Lock()
+	defer db.persistHealth.mu.Unlock()
+	status.Healthy = db.persistHealth.lastErr == nil
+	if t := db.persistHealth.lastSavedAt; !t.IsZero() {
+		status.LastSavedAt = &t
+	}
+	if t := db.persistHealth.lastFailureAt; !t.IsZero() {
+		status.LastFailureAt = &t
+	}
+	return status
+}
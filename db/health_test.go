@@ -0,0 +1,36 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabase_PersistenceStatus(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	status := db.PersistenceStatus()
+	assert.True(t, status.Healthy, "nothing to save yet")
+	assert.Nil(t, status.LastSavedAt)
+	assert.Nil(t, status.LastFailureAt)
+
+	require.NoError(t, db.persist())
+	status = db.PersistenceStatus()
+	assert.True(t, status.Healthy)
+	require.NotNil(t, status.LastSavedAt)
+
+	goodPath := db.config.DbFilePath
+	db.config.DbFilePath = filepath.Join(goodPath, "missing-dir", "db.json")
+	assert.Error(t, db.persist())
+	status = db.PersistenceStatus()
+	assert.False(t, status.Healthy)
+	assert.NotNil(t, status.LastFailureAt)
+	assert.NotNil(t, status.LastSavedAt, "the last success is kept")
+
+	db.config.DbFilePath = goodPath
+	require.NoError(t, db.persist())
+	assert.True(t, db.PersistenceStatus().Healthy, "recovers with the next successful save")
+}
@@ -0,0 +1,56 @@
+package db
+
+import (
+	"docserver/utils"
+	"fmt"
+	"log"
+	"time"
+)
+
+// --- ID Generation ---
+
+// maxIDAttempts is how many IDs newID generates before giving up on finding a free one.
+const maxIDAttempts = 5
+
+// newID generates an ID of the given kind with the configured strategy (see -id-strategy),
+// generating another while taken reports it in use. Must be called with the lock held, as
+// taken usually reads the database.
+func (db *Database) newID(kind utils.IDKind, taken func(id string) bool) (string, error) {
+	for attempt := 0; attempt < maxIDAttempts; attempt++ {
+		id := db.generateID(kind)
+		if !taken(id) {
+			return id, nil
+		}
+		log.Printf("WARN: Generated %s ID %s is already taken; generating another", kind, id)
+	}
+	return "", fmt.Errorf("no free %s ID found in %d attempts", kind, maxIDAttempts)
+}
+
+// IDSource generates IDs in place of utils.NewID, such as the seeded generator of fixtures mode
+// (utils.SeededIDs) or one handing out the IDs recorded in a journal.
+type IDSource interface {
+	NewID(strategy string, kind utils.IDKind, now time.Time) string
+}
+
+// IDSource returns where new IDs come from, or nil when they are random (see utils.NewID).
+func (db *Database) IDSource() IDSource {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+	return db.ids
+}
+
+// SetIDSource makes new IDs come from source; nil makes them random again.
+func (db *Database) SetIDSource(source IDSource) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+	db.ids = source
+}
+
+// generateID generates an ID of the given kind with the configured strategy, from the ID source
+// if one is set (see SetIDSource).
+func (db *Database) generateID(kind utils.IDKind) string {
+	if db.ids != nil {
+		return db.ids.NewID(db.config.IDStrategy, kind, db.now())
+	}
+	return utils.NewID(db.config.IDStrategy, kind)
+}
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"docserver/config"
+	"docserver/models"
+	"docserver/utils"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabase_IDStrategy(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	db.config.IDStrategy = config.IDStrategyPrefixed
+
+	profile, err := db.CreateProfile(models.Profile{Email: "ids@example.com", FirstName: "I", LastName: "D"})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(profile.ID, "usr_"), profile.ID)
+	doc, err := db.CreateDocument(models.Document{OwnerID: profile.ID, Content: map[string]any{"a": 1}})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(doc.ID, "doc_"), doc.ID)
+	script, err := db.CreateScript(models.Script{Name: "noop", Event: models.ScriptEventCreate, Source: "-- noop"})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(script.ID, "scr_"), script.ID)
+
+	t.Run("Taken IDs are regenerated", func(t *testing.T) {
+		var tried []string
+		id, err := db.newID(utils.IDKindDocument, func(id string) bool {
+			tried = append(tried, id)
+			return len(tried) == 1
+		})
+		require.NoError(t, err)
+		require.Len(t, tried, 2)
+		assert.Equal(t, tried[1], id)
+	})
+
+	t.Run("Gives up after repeated collisions", func(t *testing.T) {
+		_, err := db.newID(utils.IDKindDocument, func(string) bool { return true })
+		assert.ErrorContains(t, err, "no free doc ID")
+	})
+}
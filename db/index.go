@@ -0,0 +1,182 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/models"
+	"docserver/utils"
+	"log"
+	"sort"
+	"strings"
+)
+
+// --- Unique Indexes ---
+
+// uniqueIndex maps a field of the records in a collection to the record's ID, so lookups and
+// uniqueness checks do not have to scan the collection. Values are normalized before they are
+// compared (e.g. lowercased), and records whose value is empty are not indexed.
+// Like the collections, indexes must only be used with the lock held.
+type uniqueIndex[T any] struct {
+	name      string
+	field     func(T) string
+	normalize func(string) string
+	ids       map[string]string // Normalized value -> record ID
+	values    map[string]string // Record ID -> normalized value
+}
+
+// key returns the normalized value of v, or "" if it is not indexed.
+func (ix *uniqueIndex[T]) key(v string) string {
+	if ix.normalize != nil {
+		v = ix.normalize(v)
+	}
+	return v
+}
+
+// lookup returns the ID of the record holding value.
+func (ix *uniqueIndex[T]) lookup(value string) (string, bool) {
+	key := ix.key(value)
+	if key == "" {
+		return "", false
+	}
+	id, found := ix.ids[key]
+	return id, found
+}
+
+// takenByOther reports whether a record other than id holds value.
+func (ix *uniqueIndex[T]) takenByOther(id, value string) bool {
+	holder, found := ix.lookup(value)
+	return found && holder != id
+}
+
+// indexSet holds the unique indexes registered for one collection and keeps them in step with it.
+// Register new unique fields here rather than scanning the collection.
+type indexSet[T any] struct {
+	indexes map[string]*uniqueIndex[T]
+}
+
+// newIndexSet returns an empty set of indexes.
+func newIndexSet[T any]() *indexSet[T] {
+	return &indexSet[T]{indexes: make(map[string]*uniqueIndex[T])}
+}
+
+// register adds a unique index on the value field returns, compared after normalize (may be nil).
+// Call rebuild afterwards if the collection already holds records.
+func (s *indexSet[T]) register(name string, field func(T) string, normalize func(string) string) {
+	s.indexes[name] = &uniqueIndex[T]{
+		name:      name,
+		field:     field,
+		normalize: normalize,
+		ids:       make(map[string]string),
+		values:    make(map[string]string),
+	}
+}
+
+// get returns the index registered under name. It panics for unknown names, which are programming errors.
+func (s *indexSet[T]) get(name string) *uniqueIndex[T] {
+	ix, found := s.indexes[name]
+	if !found {
+		panic("db: no index named " + name)
+	}
+	return ix
+}
+
+// check returns an apperr.ErrConflict error if storing record under id would break a unique index.
+func (s *indexSet[T]) check(id string, record T) error {
+	for _, name := range s.names() {
+		ix := s.indexes[name]
+		if value := ix.field(record); ix.takenByOther(id, value) {
+			return apperr.Conflict("%s '%s' already exists", name, value)
+		}
+	}
+	return nil
+}
+
+// put indexes record under id, replacing what was indexed for id before. Call check first.
+func (s *indexSet[T]) put(id string, record T) {
+	s.remove(id)
+	for _, ix := range s.indexes {
+		if key := ix.key(ix.field(record)); key != "" {
+			ix.ids[key] = id
+			ix.values[id] = key
+		}
+	}
+}
+
+// remove drops everything indexed for id.
+func (s *indexSet[T]) remove(id string) {
+	for _, ix := range s.indexes {
+		if key, found := ix.values[id]; found {
+			delete(ix.ids, key)
+			delete(ix.values, id)
+		}
+	}
+}
+
+// rebuild indexes a whole collection, e.g. after loading it. If records clash (which the
+// checks prevent, but a hand-edited file may contain), the record with the lowest ID wins.
+func (s *indexSet[T]) rebuild(records map[string]T) {
+	for _, ix := range s.indexes {
+		ix.ids = make(map[string]string, len(records))
+		ix.values = make(map[string]string, len(records))
+	}
+	ids := make([]string, 0, len(records))
+	for id := range records {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if err := s.check(id, records[id]); err != nil {
+			log.Printf("WARN: Record '%s' not indexed: %v", id, err)
+			continue
+		}
+		s.put(id, records[id])
+	}
+}
+
+// names returns the registered index names in a stable order.
+func (s *indexSet[T]) names() []string {
+	names := make([]string, 0, len(s.indexes))
+	for name := range s.indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Profile index names.
+const profileEmailIndex = "email"
+
+// newProfileIndexes returns the unique indexes on profiles: email addresses, case-insensitively
+// and whatever the Unicode normalization form of an internationalized address.
+func newProfileIndexes() *indexSet[models.Profile] {
+	indexes := newIndexSet[models.Profile]()
+	indexes.register(profileEmailIndex, func(p models.Profile) string { return p.Email }, func(email string) string {
+		return strings.ToLower(utils.NormalizeText(email))
+	})
+	return indexes
+}
+
+// putProfile stores a profile and updates the indexes. Call checkProfile first.
+// Must be called with the write lock held.
+func (db *Database) putProfile(profile models.Profile) {
+	db.Database.Profiles[profile.ID] = profile
+	db.profileIndexes.put(profile.ID, profile)
+}
+
+// removeProfile deletes a profile and its index entries. Must be called with the write lock held.
+func (db *Database) removeProfile(id string) {
+	delete(db.Database.Profiles, id)
+	db.profileIndexes.remove(id)
+}
+
+// profileIDByEmail returns the ID of the profile using email (case-insensitive).
+// Must be called with the lock held.
+func (db *Database) profileIDByEmail(email string) (string, bool) {
+	return db.profileIndexes.get(profileEmailIndex).lookup(email)
+}
+
+// rebuildIndexes re-creates the indexes from the collections, e.g. after loading them.
+// Must be called with the write lock held.
+func (db *Database) rebuildIndexes() {
+	db.profileIndexes = newProfileIndexes()
+	db.profileIndexes.rebuild(db.Database.Profiles)
+}
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/models"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexSet(t *testing.T) {
+	type record struct{ Code string }
+	indexes := newIndexSet[record]()
+	indexes.register("code", func(r record) string { return r.Code }, strings.ToUpper)
+	codes := indexes.get("code")
+
+	indexes.put("a", record{Code: "abc"})
+	id, found := codes.lookup("ABC")
+	assert.True(t, found)
+	assert.Equal(t, "a", id)
+
+	err := indexes.check("b", record{Code: "Abc"})
+	assert.True(t, errors.Is(err, apperr.ErrConflict), err)
+	assert.NoError(t, indexes.check("a", record{Code: "abc"}), "a record does not clash with itself")
+	assert.NoError(t, indexes.check("b", record{}), "empty values are not indexed")
+
+	indexes.put("a", record{Code: "xyz"})
+	_, found = codes.lookup("abc")
+	assert.False(t, found, "the old value is released")
+	indexes.remove("a")
+	_, found = codes.lookup("xyz")
+	assert.False(t, found)
+
+	indexes.rebuild(map[string]record{"2": {Code: "dup"}, "1": {Code: "DUP"}, "3": {Code: "solo"}})
+	id, _ = codes.lookup("dup")
+	assert.Equal(t, "1", id, "the lowest ID wins a clash")
+	assert.Panics(t, func() { indexes.get("missing") })
+}
+
+func TestDatabase_ProfileEmailIndex(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	alice, err := db.CreateProfile(models.Profile{Email: "alice@example.com"})
+	require.NoError(t, err)
+	bob, err := db.CreateProfile(models.Profile{Email: "bob@example.com"})
+	require.NoError(t, err)
+
+	t.Run("Email changes move the entry", func(t *testing.T) {
+		alice.Email = "Alice.New@example.com"
+		_, err := db.UpdateProfile(alice.ID, alice)
+		require.NoError(t, err)
+
+		_, found := db.GetProfileByEmail("alice@example.com")
+		assert.False(t, found)
+		found1, found := db.GetProfileByEmail("alice.new@EXAMPLE.com")
+		require.True(t, found)
+		assert.Equal(t, alice.ID, found1.ID)
+
+		_, err = db.CreateProfile(models.Profile{Email: "alice@example.com"})
+		assert.NoError(t, err, "the old address is free again")
+	})
+
+	t.Run("Internationalized emails match in any normalization form", func(t *testing.T) {
+		jose, err := db.CreateProfile(models.Profile{Email: "jos\u00e9@example.com"})
+		require.NoError(t, err)
+		found, ok := db.GetProfileByEmail("JOSE\u0301@example.com")
+		require.True(t, ok)
+		assert.Equal(t, jose.ID, found.ID)
+		_, err = db.CreateProfile(models.Profile{Email: "jose\u0301@example.com"})
+		assert.ErrorIs(t, err, apperr.ErrConflict)
+	})
+
+	t.Run("Deleted profiles release their email", func(t *testing.T) {
+		require.NoError(t, db.DeleteProfile(bob.ID))
+		_, found := db.GetProfileByEmail("bob@example.com")
+		assert.False(t, found)
+		_, err := db.CreateProfile(models.Profile{Email: "BOB@example.com"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Rolled back transactions leave the index alone", func(t *testing.T) {
+		_ = db.WithTransaction(func(tx *Tx) error {
+			_, err := tx.CreateProfile(models.Profile{Email: "ghost@example.com"})
+			require.NoError(t, err)
+			return errors.New("roll back")
+		})
+		_, err := db.CreateProfile(models.Profile{Email: "ghost@example.com"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Rebuilt on load", func(t *testing.T) {
+		require.NoError(t, db.Close())
+		reloaded, err := NewDatabase(db.config)
+		require.NoError(t, err)
+		profile, found := reloaded.GetProfileByEmail("ALICE.NEW@example.com")
+		require.True(t, found)
+		assert.Equal(t, alice.ID, profile.ID)
+		_, err = reloaded.CreateProfile(models.Profile{Email: "ghost@EXAMPLE.com"})
+		assert.True(t, errors.Is(err, apperr.ErrConflict), err)
+	})
+}
@@ -0,0 +1,114 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/i18n"
+	"docserver/models"
+	"docserver/utils"
+	"log"
+	"sort"
+	"strings"
+)
+
+// --- Invitations ---
+
+// normalizeInviteCode makes codes case-insensitive and tolerant of surrounding spaces.
+func normalizeInviteCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// CreateInvite stores a new invitation under a freshly generated code.
+// MaxUses and ExpiresAt are validated at handler level.
+func (db *Database) CreateInvite(invite models.Invite) (models.Invite, error) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	for {
+		code, err := utils.GenerateInviteCode()
+		if err != nil {
+			return models.Invite{}, err
+		}
+		if _, taken := db.Database.Invites[code]; !taken {
+			invite.Code = code
+			break
+		}
+	}
+	invite.Uses = 0
+	invite.LastUsedAt = nil
+	invite.CreationDate = db.now().UTC()
+
+	db.Database.Invites[invite.Code] = invite
+	log.Printf("AUDIT: Profile ID %s created invitation %s (%d uses)", invite.CreatedBy, invite.Code, invite.MaxUses)
+
+	db.requestSave()
+	return invite, nil
+}
+
+// ListInvites returns all invitations, newest first.
+func (db *Database) ListInvites() []models.Invite {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+
+	invites := make([]models.Invite, 0, len(db.Database.Invites))
+	for _, invite := range db.Database.Invites {
+		invites = append(invites, invite)
+	}
+	sort.Slice(invites, func(i, j int) bool {
+		if !invites[i].CreationDate.Equal(invites[j].CreationDate) {
+			return invites[i].CreationDate.After(invites[j].CreationDate)
+		}
+		return invites[i].Code < invites[j].Code
+	})
+	return invites
+}
+
+// DeleteInvite revokes an invitation; profiles already created with it are unaffected.
+func (db *Database) DeleteInvite(code string) error {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	code = normalizeInviteCode(code)
+	if _, found := db.Database.Invites[code]; !found {
+		return apperr.NotFound("invitation '%s' not found", code)
+	}
+	delete(db.Database.Invites, code)
+	log.Printf("AUDIT: Invitation %s revoked", code)
+
+	db.requestSave()
+	return nil
+}
+
+// CreateProfileWithInvite creates a profile like CreateProfile, using up one use of the invitation code.
+// Both happen under one lock, so a code is never used more than MaxUses times and a failed signup
+// (e.g. a taken email) does not use it up. Invitation problems are apperr.ErrForbidden
+// errors wrapping an *i18n.Error (MsgInviteInvalid, MsgInviteExpired or MsgInviteUsedUp).
+func (db *Database) CreateProfileWithInvite(profile models.Profile, code string) (models.Profile, error) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	code = normalizeInviteCode(code)
+	invite, found := db.Database.Invites[code]
+	if !found {
+		log.Printf("AUDIT: Signup for %s with unknown invitation code", profile.Email)
+		return models.Profile{}, apperr.Wrap(apperr.ErrForbidden, i18n.NewError(i18n.MsgInviteInvalid))
+	}
+	now := db.now().UTC()
+	if invite.ExpiresAt != nil && now.After(*invite.ExpiresAt) {
+		return models.Profile{}, apperr.Wrap(apperr.ErrForbidden, i18n.NewError(i18n.MsgInviteExpired))
+	}
+	if invite.Uses >= invite.MaxUses {
+		return models.Profile{}, apperr.Wrap(apperr.ErrForbidden, i18n.NewError(i18n.MsgInviteUsedUp))
+	}
+
+	created, err := db.createProfile(profile)
+	if err != nil {
+		return models.Profile{}, err
+	}
+	invite.Uses++
+	invite.LastUsedAt = &now
+	db.Database.Invites[code] = invite
+	log.Printf("AUDIT: Profile ID %s signed up with invitation %s (%d of %d uses)", created.ID, code, invite.Uses, invite.MaxUses)
+
+	db.requestSave()
+	return created, nil
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"docserver/i18n"
+	"docserver/models"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabase_Invites(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	messageID := func(err error) string {
+		var msgErr *i18n.Error
+		require.True(t, errors.As(err, &msgErr), "expected a localized error, got %v", err)
+		return msgErr.ID
+	}
+
+	invite, err := db.CreateInvite(models.Invite{MaxUses: 2, Note: "CS 101", CreatedBy: "admin"})
+	require.NoError(t, err)
+	assert.Len(t, invite.Code, 10)
+	assert.Zero(t, invite.Uses)
+
+	t.Run("Unknown code", func(t *testing.T) {
+		_, err := db.CreateProfileWithInvite(models.Profile{Email: "nobody@example.com"}, "NOPE")
+		assert.Equal(t, i18n.MsgInviteInvalid, messageID(err))
+		_, found := db.GetProfileByEmail("nobody@example.com")
+		assert.False(t, found)
+	})
+
+	t.Run("Codes are used up", func(t *testing.T) {
+		// Codes are case-insensitive
+		_, err := db.CreateProfileWithInvite(models.Profile{Email: "first@example.com"}, " "+invite.Code+" ")
+		require.NoError(t, err)
+
+		// A failed signup does not use the code
+		_, err = db.CreateProfileWithInvite(models.Profile{Email: "FIRST@example.com"}, invite.Code)
+		assert.Equal(t, i18n.MsgEmailAlreadyExists, messageID(err))
+
+		_, err = db.CreateProfileWithInvite(models.Profile{Email: "second@example.com"}, invite.Code)
+		require.NoError(t, err)
+		_, err = db.CreateProfileWithInvite(models.Profile{Email: "third@example.com"}, invite.Code)
+		assert.Equal(t, i18n.MsgInviteUsedUp, messageID(err))
+
+		stored := db.ListInvites()[0]
+		assert.Equal(t, 2, stored.Uses)
+		assert.NotNil(t, stored.LastUsedAt)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		past := time.Now().Add(-time.Minute)
+		expired, err := db.CreateInvite(models.Invite{MaxUses: 5, ExpiresAt: &past})
+		require.NoError(t, err)
+		_, err = db.CreateProfileWithInvite(models.Profile{Email: "late@example.com"}, expired.Code)
+		assert.Equal(t, i18n.MsgInviteExpired, messageID(err))
+	})
+
+	t.Run("List and revoke", func(t *testing.T) {
+		invites := db.ListInvites()
+		require.Len(t, invites, 2)
+		assert.Equal(t, "CS 101", invites[1].Note, "newest first")
+
+		require.NoError(t, db.DeleteInvite(invites[1].Code))
+		assert.Len(t, db.ListInvites(), 1)
+		err := db.DeleteInvite(invites[1].Code)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not found")
+	})
+}
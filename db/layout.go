@@ -0,0 +1,220 @@
+package db
+
+import (
+	"crypto/sha256"
+	"docserver/config"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// --- Multi-File Storage Layout ---
+
+// Files of the data directory (see -data-dir), in the order they are written. Each holds some of
+// the top-level values of the database file, so damage to one, e.g. a huge documents.json cut
+// short by a full disk, leaves the others, such as the accounts in profiles.json, loadable.
+const (
+	layoutProfilesFile  = "profiles.json"  // Accounts and what belongs to them
+	layoutDocumentsFile = "documents.json" // Documents and their history
+	layoutSharesFile    = "shares.json"    // Share records
+	layoutServerFile    = "server.json"    // Everything else, including the schema version
+)
+
+var layoutFileNames = []string{layoutProfilesFile, layoutDocumentsFile, layoutSharesFile, layoutServerFile}
+
+// layoutFiles maps the top-level values of the database file to the data directory file they are
+// stored in. Values not listed are stored in server.json.
+var layoutFiles = map[string]string{
+	"profiles":          layoutProfilesFile,
+	"email_changes":     layoutProfilesFile,
+	"otps":              layoutProfilesFile,
+	"otp_lockouts":      layoutProfilesFile,
+	"invites":           layoutProfilesFile,
+	"erasure_requests":  layoutProfilesFile,
+	"devices":           layoutProfilesFile,
+	"documents":         layoutDocumentsFile,
+	"contents":          layoutDocumentsFile, // Next to the documents referring to it (see -dedupe-content)
+	"document_versions": layoutDocumentsFile,
+	"document_events":   layoutDocumentsFile,
+	"favorites":         layoutDocumentsFile,
+	"reviews":           layoutDocumentsFile,
+	"share_records":     layoutSharesFile,
+	"pending_shares":    layoutSharesFile,
+}
+
+// layoutFile returns the data directory file the top-level value key is stored in.
+func layoutFile(key string) string {
+	if name, found := layoutFiles[key]; found {
+		return name
+	}
+	return layoutServerFile
+}
+
+// SchemaFile returns the file holding the schema version of the database: the -db-file or, with
+// -data-dir, server.json once the directory has been written.
+func SchemaFile(cfg *config.Config) string {
+	if cfg.DataDir != "" {
+		path := filepath.Join(cfg.DataDir, layoutServerFile)
+		if fileExists(path) || !fileExists(cfg.DbFilePath) {
+			return path
+		}
+	}
+	return cfg.DbFilePath
+}
+
+// fileExists reports whether there is a file at path.
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// storageLocation returns where the database is stored, for the log.
+func (db *Database) storageLocation() string {
+	if db.config.DataDir != "" {
+		return db.config.DataDir
+	}
+	return db.config.DbFilePath
+}
+
+// storagePaths returns the files the database is stored in.
+func (db *Database) storagePaths() []string {
+	if db.config.DataDir == "" {
+		return []string{db.config.DbFilePath}
+	}
+	paths := make([]string, len(layoutFileNames))
+	for i, name := range layoutFileNames {
+		paths[i] = filepath.Join(db.config.DataDir, name)
+	}
+	return paths
+}
+
+// splitLayout splits marshalled database state into the content of each data directory file.
+func splitLayout(data []byte) (map[string][]byte, error) {
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	groups := make(map[string]map[string]json.RawMessage, len(layoutFileNames))
+	for _, name := range layoutFileNames {
+		groups[name] = make(map[string]json.RawMessage)
+	}
+	for key, value := range values {
+		groups[layoutFile(key)][key] = value
+	}
+	files := make(map[string][]byte, len(groups))
+	for name, group := range groups {
+		content, err := json.MarshalIndent(group, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		files[name] = content
+	}
+	return files, nil
+}
+
+// writeLayout writes marshalled database state to the data directory, skipping the files whose
+// content is the same as when this server last wrote them, so a change to a document leaves
+// profiles.json and its backup alone.
+// Must be called with persistMutex held.
+func (db *Database) writeLayout(data []byte) error {
+	files, err := splitLayout(data)
+	if err != nil {
+		log.Printf("ERROR: Failed to split database state into the files of '%s': %v", db.config.DataDir, err)
+		return err
+	}
+	if err := os.MkdirAll(db.config.DataDir, 0755); err != nil {
+		log.Printf("ERROR: Failed to create data directory '%s': %v", db.config.DataDir, err)
+		return err
+	}
+	if db.layoutSums == nil {
+		db.layoutSums = make(map[string][sha256.Size]byte, len(layoutFileNames))
+	}
+	for _, name := range layoutFileNames {
+		content := files[name]
+		if db.config.Checksum {
+			content = appendChecksum(content)
+		}
+		path := filepath.Join(db.config.DataDir, name)
+		sum := sha256.Sum256(content)
+		if written, found := db.layoutSums[name]; found && written == sum && fileExists(path) {
+			continue
+		}
+		if err := db.writeAtomic(path, content); err != nil {
+			return err
+		}
+		db.layoutSums[name] = sum
+	}
+	return nil
+}
+
+// readStorage reads the database: the -db-file or, with -data-dir, the files of the directory
+// combined into the content of a database file (see readLayout). The first time a data
+// directory is used, an existing -db-file is read instead and a save is scheduled to split it
+// into the directory; the -db-file itself is left alone. It reports whether the data came from
+// the -db-file. A database that was never saved returns an error for which os.IsNotExist is true.
+// Must be called with the write lock held.
+func (db *Database) readStorage() (data []byte, single bool, err error) {
+	if db.config.DataDir == "" {
+		data, err = os.ReadFile(db.config.DbFilePath)
+		return data, true, err
+	}
+	data, err = db.readLayout()
+	if !os.IsNotExist(err) || !fileExists(db.config.DbFilePath) {
+		return data, false, err
+	}
+	log.Printf("INFO: Data directory '%s' is empty. Loading '%s' and moving its data into the directory.", db.config.DataDir, db.config.DbFilePath)
+	if data, err = os.ReadFile(db.config.DbFilePath); err == nil {
+		db.requestSave()
+	}
+	return data, true, err
+}
+
+// readLayout reads the files of the data directory into the content of one database file. A
+// file that cannot be loaded is recovered on its own (see recoverFile), so that damage to
+// documents.json does not keep the profiles from loading. It returns os.ErrNotExist if the
+// directory holds none of the files.
+// Must be called with the write lock held.
+func (db *Database) readLayout() ([]byte, error) {
+	combined := make(map[string]json.RawMessage)
+	found := false
+	for _, name := range layoutFileNames {
+		path := filepath.Join(db.config.DataDir, name)
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		found = true
+
+		var values map[string]json.RawMessage
+		decode := func(data []byte) error {
+			content, err := verifyChecksum(data)
+			if err != nil {
+				return err
+			}
+			values = nil
+			return json.Unmarshal(content, &values)
+		}
+		if err := decode(data); err != nil {
+			if err := db.recoverFile(path, data, err, decode); err != nil {
+				return nil, fmt.Errorf("failed to load '%s': %w", path, err)
+			}
+		}
+		for key, value := range values {
+			if layoutFile(key) == name { // Values belonging to other files, e.g. the schema version a salvage adds, are ignored
+				combined[key] = value
+			}
+		}
+	}
+	if !found {
+		return nil, os.ErrNotExist
+	}
+	if _, found := combined["schema_version"]; !found {
+		combined["schema_version"] = json.RawMessage(fmt.Sprint(CurrentSchemaVersion)) // server.json was lost; only this schema writes data directories
+	}
+	return json.Marshal(combined)
+}
@@ -0,0 +1,135 @@
+package db
+
+import (
+	"docserver/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayout(t *testing.T) {
+	// setup saves a database with a profile and a document shared with a second profile to a data
+	// directory and returns the closed database
+	setup := func(t *testing.T) *Database {
+		cfg := createTestConfig(t, t.TempDir())
+		cfg.DataDir = filepath.Join(filepath.Dir(cfg.DbFilePath), "data")
+		cfg.Checksum = true
+		db, err := NewDatabase(cfg)
+		require.NoError(t, err)
+		owner, err := db.CreateProfile(models.Profile{Email: "layout-owner@example.com", FirstName: "L", LastName: "O"})
+		require.NoError(t, err)
+		reader, err := db.CreateProfile(models.Profile{Email: "layout-reader@example.com", FirstName: "L", LastName: "R"})
+		require.NoError(t, err)
+		doc, err := db.CreateDocument(models.Document{OwnerID: owner.ID, Content: map[string]any{"title": "layout-content"}})
+		require.NoError(t, err)
+		require.NoError(t, db.AddSharerToDocument(doc.ID, reader.ID))
+		require.NoError(t, db.PersistNow())
+		require.NoError(t, db.Close())
+		return db
+	}
+	readLayoutFile := func(t *testing.T, db *Database, name string) string {
+		return string(mustReadFile(t, filepath.Join(db.config.DataDir, name)))
+	}
+
+	t.Run("Splits the collections into files", func(t *testing.T) {
+		db := setup(t)
+		assert.NoFileExists(t, db.config.DbFilePath)
+		profiles := readLayoutFile(t, db, "profiles.json")
+		assert.Contains(t, profiles, "layout-owner@example.com")
+		assert.NotContains(t, profiles, "layout-content")
+		documents := readLayoutFile(t, db, "documents.json")
+		assert.Contains(t, documents, "layout-content")
+		assert.Contains(t, documents, `"document_events"`)
+		assert.NotContains(t, documents, "layout-owner@example.com")
+		assert.Contains(t, readLayoutFile(t, db, "shares.json"), `"share_records"`)
+		assert.Contains(t, readLayoutFile(t, db, "server.json"), `"schema_version"`)
+		assert.Contains(t, profiles, checksumFooterPrefix, "every file has its own checksum")
+
+		reloaded, err := NewDatabase(db.config)
+		require.NoError(t, err)
+		defer reloaded.Close()
+		assert.Len(t, reloaded.GetAllProfiles(), 2)
+		docs := reloaded.GetAllDocuments()
+		require.Len(t, docs, 1)
+		record, found := reloaded.GetShareRecordByDocumentID(docs[0].ID)
+		require.True(t, found)
+		assert.Len(t, record.SharedWith, 1)
+		assert.Nil(t, reloaded.Recoveries())
+	})
+
+	t.Run("Saves only the files that changed", func(t *testing.T) {
+		db := setup(t)
+		reloaded, err := NewDatabase(db.config)
+		require.NoError(t, err)
+		defer reloaded.Close()
+		require.NoError(t, reloaded.PersistNow()) // The first save writes every file
+		for _, name := range layoutFileNames {
+			require.NoError(t, os.Remove(filepath.Join(db.config.DataDir, name+".bak")))
+		}
+
+		doc := reloaded.GetAllDocuments()[0]
+		_, err = reloaded.UpdateDocument(doc.ID, map[string]any{"title": "changed"})
+		require.NoError(t, err)
+		require.NoError(t, reloaded.PersistNow())
+		assert.FileExists(t, filepath.Join(db.config.DataDir, "documents.json.bak"))
+		assert.NoFileExists(t, filepath.Join(db.config.DataDir, "profiles.json.bak"))
+		assert.NoFileExists(t, filepath.Join(db.config.DataDir, "shares.json.bak"))
+		assert.Contains(t, readLayoutFile(t, db, "documents.json"), "changed")
+	})
+
+	t.Run("A damaged file is recovered on its own", func(t *testing.T) {
+		db := setup(t)
+		db.config.AutoRecover = true
+		path := filepath.Join(db.config.DataDir, "documents.json")
+		_ = os.Remove(path + ".bak")
+		documents := readLayoutFile(t, db, "documents.json")
+		require.NoError(t, os.WriteFile(path, []byte(documents[:strings.Index(documents, "layout-content")]), 0644))
+
+		recovered, err := NewDatabase(db.config)
+		require.NoError(t, err)
+		defer recovered.Close()
+		assert.Len(t, recovered.GetAllProfiles(), 2, "profiles.json was not affected")
+		assert.Empty(t, recovered.GetAllDocuments(), "the document was cut off")
+		reports := recovered.Recoveries()
+		require.Len(t, reports, 1)
+		assert.Equal(t, path, reports[0].File)
+		assert.Equal(t, RecoverySourceSalvage, reports[0].Source)
+		assert.FileExists(t, reports[0].QuarantinedAs)
+	})
+
+	t.Run("Refuses to start on a damaged file without a backup", func(t *testing.T) {
+		db := setup(t)
+		path := filepath.Join(db.config.DataDir, "profiles.json")
+		_ = os.Remove(path + ".bak")
+		profiles := readLayoutFile(t, db, "profiles.json")
+		require.NoError(t, os.WriteFile(path, []byte(strings.Replace(profiles, "layout-owner", "tampered", 1)), 0644))
+
+		_, err := NewDatabase(db.config)
+		assert.ErrorIs(t, err, ErrChecksumMismatch)
+	})
+
+	t.Run("Moves an existing database file into the directory", func(t *testing.T) {
+		cfg := createTestConfig(t, t.TempDir())
+		single, err := NewDatabase(cfg)
+		require.NoError(t, err)
+		_, err = single.CreateProfile(models.Profile{Email: "moved@example.com", FirstName: "M", LastName: "D"})
+		require.NoError(t, err)
+		require.NoError(t, single.PersistNow())
+		require.NoError(t, single.Close())
+
+		cfg.DataDir = filepath.Join(filepath.Dir(cfg.DbFilePath), "data")
+		assert.Equal(t, cfg.DbFilePath, SchemaFile(cfg), "the directory was not written yet")
+		moved, err := NewDatabase(cfg)
+		require.NoError(t, err)
+		require.Len(t, moved.GetAllProfiles(), 1)
+		require.NoError(t, moved.PersistNow())
+		require.NoError(t, moved.Close())
+		assert.Contains(t, string(mustReadFile(t, filepath.Join(cfg.DataDir, "profiles.json"))), "moved@example.com")
+		assert.FileExists(t, cfg.DbFilePath, "the database file is left alone")
+		assert.Equal(t, filepath.Join(cfg.DataDir, "server.json"), SchemaFile(cfg))
+	})
+}
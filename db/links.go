@@ -0,0 +1,126 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/models"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// --- Document Links ---
+
+// MaxDocumentLinks is the most links a document may declare.
+const MaxDocumentLinks = 100
+
+// NormalizeLinks trims, de-duplicates and checks the links of document docID ("" for a document
+// yet to be created). Whether the targets exist and may be linked to by the user is checked at
+// handler level.
+func NormalizeLinks(docID string, links []string) ([]string, error) {
+	if len(links) == 0 {
+		return nil, nil
+	}
+	normalized := make([]string, 0, len(links))
+	for _, link := range links {
+		link = strings.TrimSpace(link)
+		switch {
+		case link == "":
+			return nil, apperr.Validation("links must be document IDs")
+		case link == docID:
+			return nil, apperr.Validation("a document cannot link to itself")
+		case !slices.Contains(normalized, link):
+			normalized = append(normalized, link)
+		}
+	}
+	if len(normalized) > MaxDocumentLinks {
+		return nil, apperr.Validation("a document may link to at most %d documents", MaxDocumentLinks)
+	}
+	return normalized, nil
+}
+
+// SetDocumentLinks replaces the documents a document links to. An empty list removes its links.
+func (db *Database) SetDocumentLinks(id string, links []string) (models.Document, error) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	doc, found := db.Database.Documents[id]
+	if !found {
+		return models.Document{}, apperr.NotFound("document with ID '%s' not found", id)
+	}
+	if err := db.checkNotFrozen(id); err != nil {
+		return models.Document{}, err
+	}
+	normalized, err := NormalizeLinks(id, links)
+	if err != nil {
+		return models.Document{}, err
+	}
+	if slices.Equal(doc.Links, normalized) {
+		return doc, nil
+	}
+
+	doc.Links = normalized
+	doc.LastModifiedDate = db.now().UTC()
+	db.Database.Documents[id] = doc
+	log.Printf("INFO: Set the links of Document ID %s to %d documents", id, len(normalized))
+
+	db.requestSave()
+	return doc, nil
+}
+
+// GetBacklinks returns the documents linking to a document, oldest first.
+func (db *Database) GetBacklinks(docID string) []models.Document {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+	return (&ReadView{db: db}).GetBacklinks(docID)
+}
+
+// GetBacklinks returns the documents linking to a document, oldest first. Expired documents are left out.
+func (v *ReadView) GetBacklinks(docID string) []models.Document {
+	now := v.db.now()
+	backlinks := make([]models.Document, 0)
+	for _, doc := range v.db.Database.Documents {
+		if slices.Contains(doc.Links, docID) && !documentExpired(doc, now) {
+			backlinks = append(backlinks, doc)
+		}
+	}
+	sort.Slice(backlinks, func(i, j int) bool {
+		if !backlinks[i].CreationDate.Equal(backlinks[j].CreationDate) {
+			return backlinks[i].CreationDate.Before(backlinks[j].CreationDate)
+		}
+		return backlinks[i].ID < backlinks[j].ID
+	})
+	return backlinks
+}
+
+// AccessibleDocuments returns the documents a profile owns or that are shared with it, oldest
+// first, as GET /documents lists them: shared documents limited to the part shared with it.
+func (db *Database) AccessibleDocuments(profileID string) (documents []models.Document, err error) {
+	err = db.View(func(v *ReadView) error {
+		documents, err = v.AccessibleDocuments(profileID)
+		return err
+	})
+	return documents, err
+}
+
+// AccessibleDocuments returns the documents a profile owns or that are shared with it (see Database.AccessibleDocuments).
+func (v *ReadView) AccessibleDocuments(profileID string) ([]models.Document, error) {
+	return v.documentsInScope(profileID, "all")
+}
+
+// documentsInScope returns every document GET /documents lists for a profile with scope "owned",
+// "shared" or "all", oldest first.
+func (v *ReadView) documentsInScope(profileID, scope string) ([]models.Document, error) {
+	params := QueryDocumentsParams{AuthUserID: profileID, Scope: scope, SortBy: "creation_date", Order: "asc", Limit: MaxLimit}
+	documents := make([]models.Document, 0)
+	for params.Page = 1; ; params.Page++ {
+		page, total, err := v.QueryDocuments(params)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, page...)
+		if len(page) == 0 || len(documents) >= total {
+			return documents, nil
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/config"
+	"docserver/models"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeLinks(t *testing.T) {
+	links, err := NormalizeLinks("doc_a", []string{" doc_b", "doc_c", "doc_b "})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"doc_b", "doc_c"}, links)
+
+	links, err = NormalizeLinks("doc_a", []string{})
+	require.NoError(t, err)
+	assert.Nil(t, links)
+
+	_, err = NormalizeLinks("doc_a", []string{"doc_a"})
+	assert.ErrorIs(t, err, apperr.ErrValidation, "self link")
+	_, err = NormalizeLinks("doc_a", []string{" "})
+	assert.ErrorIs(t, err, apperr.ErrValidation)
+	tooMany := make([]string, MaxDocumentLinks+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("doc_%d", i)
+	}
+	_, err = NormalizeLinks("doc_x", tooMany)
+	assert.ErrorIs(t, err, apperr.ErrValidation)
+}
+
+func TestDocumentLinks(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	clock := config.NewFixedClock(time.Date(2025, time.June, 1, 9, 0, 0, 0, time.UTC))
+	db.config.Clock = clock
+
+	target, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: map[string]any{"title": "Target"}})
+	require.NoError(t, err)
+	first, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: map[string]any{}, Links: []string{target.ID, target.ID}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{target.ID}, first.Links, "duplicates are dropped")
+	clock.Advance(time.Minute)
+	second, err := db.CreateDocument(models.Document{OwnerID: "other", Content: map[string]any{}})
+	require.NoError(t, err)
+
+	t.Run("Set links", func(t *testing.T) {
+		updated, err := db.SetDocumentLinks(second.ID, []string{target.ID, first.ID})
+		require.NoError(t, err)
+		assert.Equal(t, []string{target.ID, first.ID}, updated.Links)
+
+		_, err = db.SetDocumentLinks(second.ID, []string{second.ID})
+		assert.ErrorIs(t, err, apperr.ErrValidation)
+		_, err = db.SetDocumentLinks("missing", nil)
+		assert.ErrorIs(t, err, apperr.ErrNotFound)
+	})
+
+	t.Run("Backlinks", func(t *testing.T) {
+		backlinks := db.GetBacklinks(target.ID)
+		require.Len(t, backlinks, 2)
+		assert.Equal(t, first.ID, backlinks[0].ID, "oldest first")
+		assert.Equal(t, second.ID, backlinks[1].ID)
+		assert.Empty(t, db.GetBacklinks(second.ID))
+
+		expiresAt := clock.Now().Add(time.Hour)
+		_, err := db.SetDocumentExpiry(first.ID, &expiresAt)
+		require.NoError(t, err)
+		clock.Advance(2 * time.Hour)
+		assert.Len(t, db.GetBacklinks(target.ID), 1, "expired documents are left out")
+	})
+
+	t.Run("Removing links", func(t *testing.T) {
+		updated, err := db.SetDocumentLinks(second.ID, []string{})
+		require.NoError(t, err)
+		assert.Empty(t, updated.Links)
+		assert.Len(t, db.GetBacklinks(first.ID), 0)
+	})
+
+	t.Run("Frozen documents keep their links", func(t *testing.T) {
+		_, err := db.SetDocumentFrozen(second.ID, true, "other")
+		require.NoError(t, err)
+		_, err = db.SetDocumentLinks(second.ID, []string{target.ID})
+		assert.ErrorIs(t, err, apperr.ErrLocked)
+	})
+}
+
+func TestAccessibleDocuments(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	owned, err := db.CreateDocument(models.Document{OwnerID: "reader", Content: map[string]any{}})
+	require.NoError(t, err)
+	shared, err := db.CreateDocument(models.Document{OwnerID: "writer", Content: map[string]any{}})
+	require.NoError(t, err)
+	_, err = db.CreateDocument(models.Document{OwnerID: "writer", Content: map[string]any{}, Public: true})
+	require.NoError(t, err)
+	require.NoError(t, db.SetShareRecord(shared.ID, []string{"reader"}))
+
+	documents, err := db.AccessibleDocuments("reader")
+	require.NoError(t, err)
+	require.Len(t, documents, 2, "public documents of others are not included")
+	assert.Equal(t, owned.ID, documents[0].ID)
+	assert.Equal(t, shared.ID, documents[1].ID)
+}
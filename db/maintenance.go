@@ -0,0 +1,46 @@
+package db
+
+import (
+	"docserver/models"
+	"log"
+	"slices"
+	"strings"
+)
+
+// --- Maintenance Mode ---
+
+// GetMaintenance returns the current maintenance mode (disabled if it was never set).
+func (db *Database) GetMaintenance() models.Maintenance {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+
+	if db.Database.Maintenance == nil {
+		return models.Maintenance{}
+	}
+	maintenance := *db.Database.Maintenance
+	maintenance.Routes = slices.Clone(maintenance.Routes)
+	return maintenance
+}
+
+// SetMaintenance switches maintenance mode and saves it, so it survives restarts.
+// Since is set to the current time; routes should already be validated.
+func (db *Database) SetMaintenance(maintenance models.Maintenance) models.Maintenance {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	maintenance.Since = db.now().UTC()
+	maintenance.Routes = slices.Clone(maintenance.Routes)
+	db.Database.Maintenance = &maintenance
+	if maintenance.Enabled {
+		routes := "all routes"
+		if len(maintenance.Routes) > 0 {
+			routes = strings.Join(maintenance.Routes, ", ")
+		}
+		log.Printf("INFO: Maintenance mode enabled by Profile ID %s for %s", maintenance.ChangedBy, routes)
+	} else {
+		log.Printf("INFO: Maintenance mode disabled by Profile ID %s", maintenance.ChangedBy)
+	}
+
+	db.requestSave()
+	return maintenance
+}
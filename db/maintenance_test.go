@@ -0,0 +1,27 @@
+package db
+
+import (
+	"docserver/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabase_Maintenance(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	assert.False(t, db.GetMaintenance().Enabled)
+
+	set := db.SetMaintenance(models.Maintenance{Enabled: true, Routes: []string{"/documents"}, ChangedBy: "admin"})
+	assert.False(t, set.Since.IsZero())
+	assert.Equal(t, set, db.GetMaintenance())
+
+	require.NoError(t, db.persist())
+	reloaded, err := NewDatabase(db.config)
+	require.NoError(t, err)
+	maintenance := reloaded.GetMaintenance()
+	assert.True(t, maintenance.Enabled, "survives restarts")
+	assert.Equal(t, []string{"/documents"}, maintenance.Routes)
+}
@@ -0,0 +1,198 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+)
+
+// --- Schema Migrations ---
+
+// Migration describes a single, ordered change to the persisted database format.
+// Migrations operate on the raw top-level JSON object (before it is unmarshalled
+// into models.Database) so they can rename keys, move data between collections,
+// or fill in fields that older files never had.
+type Migration struct {
+	Version     int                                        // Schema version the file is at *after* this migration runs
+	Description string                                     // Human-readable summary (shown in dry-run reports)
+	Apply       func(raw map[string]json.RawMessage) error // Mutates the raw document in place
+}
+
+// migrations is the ordered list of all known migrations.
+// Append new migrations to the end; never reorder or remove existing entries.
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "Introduce schema_version and ensure all top-level collections exist",
+		Apply: func(raw map[string]json.RawMessage) error {
+			for _, key := range []string{"profiles", "documents", "share_records"} {
+				if value, ok := raw[key]; !ok || string(value) == "null" {
+					raw[key] = json.RawMessage("{}")
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version:     2,
+		Description: "Drop password reset OTPs stored in plaintext (only their hashes are stored now)",
+		Apply: func(raw map[string]json.RawMessage) error {
+			value, ok := raw["otps"]
+			if !ok || string(value) == "null" {
+				return nil
+			}
+			var otps map[string]map[string]json.RawMessage
+			if err := json.Unmarshal(value, &otps); err != nil {
+				return fmt.Errorf("invalid otps: %w", err)
+			}
+			for email, record := range otps {
+				if _, hashed := record["otp_hash"]; !hashed {
+					delete(otps, email) // The user requests a new OTP
+				}
+			}
+			data, err := json.Marshal(otps)
+			if err != nil {
+				return err
+			}
+			raw["otps"] = data
+			return nil
+		},
+	},
+}
+
+// CurrentSchemaVersion is the schema version written by this build of the server.
+var CurrentSchemaVersion = latestMigrationVersion()
+
+// latestMigrationVersion returns the version of the last registered migration.
+func latestMigrationVersion() int {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// MigrationReport summarizes which migrations a database file requires (or received).
+type MigrationReport struct {
+	FilePath    string      // Path of the inspected database file
+	FromVersion int         // schema_version found in the file (0 if absent)
+	ToVersion   int         // schema_version after all pending migrations
+	Pending     []Migration // Migrations that must run, in order
+}
+
+// readSchemaVersion extracts schema_version from the raw document (0 if missing).
+func readSchemaVersion(raw map[string]json.RawMessage) (int, error) {
+	value, ok := raw["schema_version"]
+	if !ok || string(value) == "null" {
+		return 0, nil
+	}
+	var version int
+	if err := json.Unmarshal(value, &version); err != nil {
+		return 0, fmt.Errorf("invalid schema_version: %w", err)
+	}
+	return version, nil
+}
+
+// pendingMigrations returns the migrations that must run to bring a file at
+// fromVersion up to CurrentSchemaVersion.
+func pendingMigrations(fromVersion int) ([]Migration, error) {
+	if fromVersion > CurrentSchemaVersion {
+		return nil, fmt.Errorf("database schema_version %d is newer than supported version %d; upgrade the server", fromVersion, CurrentSchemaVersion)
+	}
+	pending := make([]Migration, 0)
+	for _, m := range migrations {
+		if m.Version > fromVersion {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// migrateData applies all pending migrations to the raw JSON file contents.
+// It returns the migrated JSON along with a report of what was applied.
+// If no migrations are pending the input data is returned unchanged.
+func migrateData(fileData []byte) ([]byte, MigrationReport, error) {
+	report := MigrationReport{ToVersion: CurrentSchemaVersion}
+
+	raw := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(fileData, &raw); err != nil {
+		return nil, report, err
+	}
+
+	fromVersion, err := readSchemaVersion(raw)
+	if err != nil {
+		return nil, report, err
+	}
+	report.FromVersion = fromVersion
+
+	pending, err := pendingMigrations(fromVersion)
+	if err != nil {
+		return nil, report, err
+	}
+	report.Pending = pending
+	if len(pending) == 0 {
+		return fileData, report, nil
+	}
+
+	for _, m := range pending {
+		if err := m.Apply(raw); err != nil {
+			return nil, report, fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Description, err)
+		}
+		raw["schema_version"] = json.RawMessage(fmt.Sprintf("%d", m.Version))
+		log.Printf("INFO: Applied database migration %d: %s", m.Version, m.Description)
+	}
+
+	migrated, err := json.Marshal(raw)
+	if err != nil {
+		return nil, report, fmt.Errorf("failed to re-encode migrated database: %w", err)
+	}
+	return migrated, report, nil
+}
+
+// PlanMigrations inspects the database file at filePath and reports which
+// migrations would run on Load, without modifying the file.
+// A missing file yields an empty report (a new database starts at the current version).
+func PlanMigrations(filePath string) (MigrationReport, error) {
+	report := MigrationReport{FilePath: filePath, FromVersion: CurrentSchemaVersion, ToVersion: CurrentSchemaVersion}
+
+	fileData, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return report, nil
+		}
+		return report, err
+	}
+
+	if fileData, err = verifyChecksum(fileData); err != nil {
+		return report, fmt.Errorf("%w: '%s'", err, filePath)
+	}
+	raw := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(fileData, &raw); err != nil {
+		return report, fmt.Errorf("failed to parse database file '%s': %w", filePath, err)
+	}
+	fromVersion, err := readSchemaVersion(raw)
+	if err != nil {
+		return report, err
+	}
+	report.FromVersion = fromVersion
+
+	pending, err := pendingMigrations(fromVersion)
+	if err != nil {
+		return report, err
+	}
+	report.Pending = pending
+	return report, nil
+}
+
+// String renders the report in a form suitable for printing from the CLI.
+func (r MigrationReport) String() string {
+	if len(r.Pending) == 0 {
+		return fmt.Sprintf("Database '%s' is at schema version %d. No migrations required.", r.FilePath, r.FromVersion)
+	}
+	out := fmt.Sprintf("Database '%s' is at schema version %d and requires %d migration(s) to reach version %d:\n",
+		r.FilePath, r.FromVersion, len(r.Pending), r.ToVersion)
+	for _, m := range r.Pending {
+		out += fmt.Sprintf("  - %d: %s\n", m.Version, m.Description)
+	}
+	return out
+}
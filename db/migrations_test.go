@@ -0,0 +1,116 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Migration Tests ---
+
+func TestMigrateData_LegacyFileWithoutVersion(t *testing.T) {
+	legacy := `{"profiles": {"p1": {"id": "p1", "email": "a@example.com"}}, "documents": null}`
+
+	migrated, report, err := migrateData([]byte(legacy))
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, report.FromVersion)
+	assert.Equal(t, CurrentSchemaVersion, report.ToVersion)
+	assert.Len(t, report.Pending, len(migrations))
+
+	var raw map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(migrated, &raw))
+	assert.JSONEq(t, "{}", string(raw["documents"]), "null collections should be replaced with empty objects")
+	assert.JSONEq(t, "{}", string(raw["share_records"]), "missing collections should be created")
+	assert.Equal(t, fmt.Sprint(CurrentSchemaVersion), string(raw["schema_version"]))
+}
+
+func TestMigrateData_CurrentVersionUnchanged(t *testing.T) {
+	current := `{"schema_version": 2, "profiles": {}, "documents": {}, "share_records": {}}`
+
+	migrated, report, err := migrateData([]byte(current))
+	require.NoError(t, err)
+	assert.Empty(t, report.Pending)
+	assert.Equal(t, current, string(migrated), "data should be returned untouched when no migrations are pending")
+}
+
+func TestMigrateData_PlaintextOTPsDropped(t *testing.T) {
+	version1 := `{"schema_version": 1, "otps": {
+		"plain@example.com": {"otp": "123456", "expires_at": "2030-01-01T00:00:00Z"},
+		"hashed@example.com": {"otp_hash": "$2a$04$hash", "expires_at": "2030-01-01T00:00:00Z"}
+	}}`
+
+	migrated, report, err := migrateData([]byte(version1))
+	require.NoError(t, err)
+	require.Len(t, report.Pending, 1)
+	var raw struct {
+		OTPs map[string]json.RawMessage `json:"otps"`
+	}
+	require.NoError(t, json.Unmarshal(migrated, &raw))
+	assert.Len(t, raw.OTPs, 1)
+	assert.Contains(t, raw.OTPs, "hashed@example.com")
+	assert.NotContains(t, string(migrated), "123456")
+
+	_, _, err = migrateData([]byte(`{"schema_version": 1}`))
+	assert.NoError(t, err, "files without OTPs migrate too")
+}
+
+func TestMigrateData_NewerVersionRejected(t *testing.T) {
+	_, _, err := migrateData([]byte(`{"schema_version": 999}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "newer than supported")
+}
+
+func TestPlanMigrations(t *testing.T) {
+	tempDir := createTempDir(t)
+	defer os.RemoveAll(tempDir)
+
+	t.Run("Missing file requires nothing", func(t *testing.T) {
+		report, err := PlanMigrations(filepath.Join(tempDir, "missing.json"))
+		require.NoError(t, err)
+		assert.Empty(t, report.Pending)
+		assert.Contains(t, report.String(), "No migrations required")
+	})
+
+	t.Run("Legacy file reports pending migrations without modifying it", func(t *testing.T) {
+		path := filepath.Join(tempDir, "legacy.json")
+		legacy := `{"profiles": {}, "documents": {}}`
+		require.NoError(t, os.WriteFile(path, []byte(legacy), 0644))
+
+		report, err := PlanMigrations(path)
+		require.NoError(t, err)
+		assert.Equal(t, 0, report.FromVersion)
+		assert.NotEmpty(t, report.Pending)
+		assert.Contains(t, report.String(), "requires")
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, legacy, string(data), "dry run must not modify the file")
+	})
+}
+
+func TestDatabase_Load_MigratesAndPersists(t *testing.T) {
+	tempDir := createTempDir(t)
+	defer os.RemoveAll(tempDir)
+	cfg := createTestConfig(t, tempDir)
+
+	writeTestDBFile(t, cfg, `{"profiles": {"p1": {"id": "p1", "email": "a@example.com"}}}`)
+
+	db, err := NewDatabase(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, CurrentSchemaVersion, db.Database.SchemaVersion)
+	_, found := db.GetProfileByID("p1")
+	assert.True(t, found, "existing data should survive migration")
+
+	// The migration schedules a save; wait for the debounced persist.
+	time.Sleep(cfg.SaveInterval * 5)
+	var saved map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal([]byte(readTestDBFile(t, cfg)), &saved))
+	assert.Equal(t, fmt.Sprint(CurrentSchemaVersion), string(saved["schema_version"]), "migrated schema version should be persisted")
+}
@@ -0,0 +1,283 @@
+package db
+
+import (
+	"context"
+	"docserver/apperr"
+	"docserver/models"
+	"docserver/utils"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- Notification Channels ---
+
+// notificationTimeout limits each post of a message to a chat webhook.
+const notificationTimeout = 10 * time.Second
+
+// ErrNotificationRateLimited is returned when a channel was already sent its max_per_hour messages.
+var ErrNotificationRateLimited = errors.New("the channel was sent as many messages as it allows this hour")
+
+// NotificationEvents lists the events notification channels can be sent, in the order they are documented.
+var NotificationEvents = []string{models.NotificationDocumentShared, models.NotificationSubmissionReceived}
+
+// IsNotificationEvent reports whether event is one of NotificationEvents.
+func IsNotificationEvent(event string) bool {
+	return slices.Contains(NotificationEvents, event)
+}
+
+// notification is an event as sent to the notification channels concerned by it.
+type notification struct {
+	event      string // One of the models.Notification* events
+	docID      string
+	actorID    string   // Profile that caused it
+	recipients []string // Profiles it concerns; their channels, and those of all users, are sent it
+}
+
+// notificationMessage is a message waiting to be posted to a channel.
+type notificationMessage struct {
+	channel models.NotificationChannel
+	text    string
+}
+
+// notifier posts messages to notification channels in the background, counting them against each
+// channel's max_per_hour. Messages over it are dropped, so a burst of shares cannot flood a chat.
+type notifier struct {
+	mu       sync.Mutex
+	limiters map[int]*utils.RateLimiter // Keyed by hourly limit; each counts the channels with that limit by ID
+	posting  sync.WaitGroup             // Messages being posted
+	staged   []notificationMessage      // In a transaction: messages posted once it commits
+}
+
+// allow counts a message to channel, reporting whether it is within the channel's hourly limit.
+func (n *notifier) allow(channel models.NotificationChannel) bool {
+	n.mu.Lock()
+	limiter, found := n.limiters[channel.MaxPerHour]
+	if !found {
+		if n.limiters == nil {
+			n.limiters = make(map[int]*utils.RateLimiter)
+		}
+		limiter = utils.NewRateLimiter(channel.MaxPerHour, time.Hour)
+		n.limiters[channel.MaxPerHour] = limiter
+	}
+	n.mu.Unlock()
+	allowed, _ := limiter.Allow(channel.ID)
+	return allowed
+}
+
+// CreateNotificationChannel stores a new notification channel. The fields are validated at handler level.
+func (db *Database) CreateNotificationChannel(channel models.NotificationChannel) (models.NotificationChannel, error) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	id, err := db.newID(utils.IDKindChannel, func(id string) bool { _, taken := db.Database.NotificationChannels[id]; return taken })
+	if err != nil {
+		return models.NotificationChannel{}, err
+	}
+	now := db.now().UTC()
+	channel.ID = id
+	channel.CreationDate = now
+	channel.LastModifiedDate = now
+
+	db.Database.NotificationChannels[channel.ID] = channel
+	log.Printf("INFO: Created notification channel ID %s ('%s', %s) for Profile ID %s", channel.ID, channel.Name, channel.Kind, channel.OwnerID)
+
+	db.requestSave()
+	return channel, nil
+}
+
+// GetNotificationChannel retrieves a notification channel by its ID.
+func (db *Database) GetNotificationChannel(id string) (models.NotificationChannel, bool) {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+
+	channel, found := db.Database.NotificationChannels[id]
+	return channel, found
+}
+
+// ListNotificationChannels returns the notification channels owned by a profile, oldest first.
+func (db *Database) ListNotificationChannels(ownerID string) []models.NotificationChannel {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+
+	channels := make([]models.NotificationChannel, 0)
+	for _, channel := range db.Database.NotificationChannels {
+		if channel.OwnerID == ownerID {
+			channels = append(channels, channel)
+		}
+	}
+	sort.Slice(channels, func(i, j int) bool {
+		if !channels[i].CreationDate.Equal(channels[j].CreationDate) {
+			return channels[i].CreationDate.Before(channels[j].CreationDate)
+		}
+		return channels[i].ID < channels[j].ID
+	})
+	return channels
+}
+
+// UpdateNotificationChannel replaces a notification channel's settings.
+func (db *Database) UpdateNotificationChannel(id string, updated models.NotificationChannel) (models.NotificationChannel, error) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	channel, found := db.Database.NotificationChannels[id]
+	if !found {
+		return models.NotificationChannel{}, apperr.NotFound("notification channel with ID '%s' not found", id)
+	}
+	channel.Name = updated.Name
+	channel.Kind = updated.Kind
+	channel.WebhookURL = updated.WebhookURL
+	channel.Events = updated.Events
+	channel.AllUsers = updated.AllUsers
+	channel.MaxPerHour = updated.MaxPerHour
+	channel.Enabled = updated.Enabled
+	channel.LastModifiedDate = db.now().UTC()
+
+	db.Database.NotificationChannels[id] = channel
+	log.Printf("INFO: Updated notification channel ID %s ('%s')", id, channel.Name)
+
+	db.requestSave()
+	return channel, nil
+}
+
+// DeleteNotificationChannel removes a notification channel.
+func (db *Database) DeleteNotificationChannel(id string) error {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	if _, found := db.Database.NotificationChannels[id]; !found {
+		return apperr.NotFound("notification channel with ID '%s' not found", id)
+	}
+	delete(db.Database.NotificationChannels, id)
+	log.Printf("INFO: Deleted notification channel ID %s", id)
+
+	db.requestSave()
+	return nil
+}
+
+// SendTestNotification posts a test message to a channel right away, whether or not it is
+// enabled, and returns why it could not be delivered. The message counts against the channel's
+// hourly limit; once that is reached, ErrNotificationRateLimited is returned.
+func (db *Database) SendTestNotification(id string) error {
+	channel, found := db.GetNotificationChannel(id)
+	if !found {
+		return apperr.NotFound("notification channel with ID '%s' not found", id)
+	}
+	if !db.notifications.allow(channel) {
+		return ErrNotificationRateLimited
+	}
+	return db.postNotification(notificationMessage{channel: channel, text: fmt.Sprintf("Test message for the notification channel '%s' from docserver.", channel.Name)})
+}
+
+// notify sends an event to the enabled channels that filter it in and are concerned by it: those
+// of its recipients and those of all users. Messages are posted in the background, or once the
+// transaction commits. Must be called with the lock held.
+func (db *Database) notify(n notification) {
+	for _, channel := range db.Database.NotificationChannels {
+		if !channel.Enabled || !slices.Contains(channel.Events, n.event) {
+			continue
+		}
+		if !channel.AllUsers && !slices.Contains(n.recipients, channel.OwnerID) {
+			continue
+		}
+		message := notificationMessage{channel: channel, text: db.notificationText(n, channel.OwnerID)}
+		if db.staged {
+			db.notifications.staged = append(db.notifications.staged, message)
+		} else {
+			db.sendNotification(message)
+		}
+	}
+}
+
+// sendNotification posts a message in the background if the channel's hourly limit allows it.
+func (db *Database) sendNotification(message notificationMessage) {
+	if !db.notifications.allow(message.channel) {
+		log.Printf("WARN: Notification channel ID %s reached its limit of %d messages per hour; message dropped", message.channel.ID, message.channel.MaxPerHour)
+		return
+	}
+	db.notifications.posting.Add(1)
+	go func() {
+		defer db.notifications.posting.Done()
+		if err := db.postNotification(message); err != nil {
+			log.Printf("WARN: Failed to post to notification channel ID %s: %v", message.channel.ID, err)
+		}
+	}()
+}
+
+// postNotification posts a message to its channel's webhook, which must be on one of the allowed domains.
+func (db *Database) postNotification(message notificationMessage) error {
+	target, err := utils.ParseFetchURL(message.channel.WebhookURL)
+	if err != nil {
+		return apperr.Validation("invalid webhook URL '%s': %w", message.channel.WebhookURL, err)
+	}
+	// Slack reads "text", Discord "content"
+	payload := map[string]string{"text": message.text}
+	if message.channel.Kind == models.NotificationChannelDiscord {
+		payload = map[string]string{"content": message.text}
+	}
+	return utils.PostJSON(context.Background(), target, payload, utils.FetchOptions{
+		AllowedDomains: db.config.WebhookAllowedDomains,
+		Timeout:        notificationTimeout,
+	})
+}
+
+// waitForNotifications waits until the messages being posted are delivered or have failed.
+func (db *Database) waitForNotifications() {
+	db.notifications.posting.Wait()
+}
+
+// notificationText describes an event to the owner of a channel. Must be called with the lock held.
+func (db *Database) notificationText(n notification, channelOwnerID string) string {
+	document := n.docID
+	if doc, found := db.Database.Documents[n.docID]; found {
+		if content, isObject := doc.Content.(map[string]any); isObject {
+			if title, isString := content["title"].(string); isString && strings.TrimSpace(title) != "" {
+				document = fmt.Sprintf("'%s' (%s)", strings.TrimSpace(title), n.docID)
+			}
+		}
+	}
+	actor := db.profileName(n.actorID)
+
+	switch n.event {
+	case models.NotificationDocumentShared:
+		if slices.Contains(n.recipients, channelOwnerID) {
+			return fmt.Sprintf("%s shared the document %s with you.", actor, document)
+		}
+		names := make([]string, len(n.recipients))
+		for i, id := range n.recipients {
+			names[i] = db.profileName(id)
+		}
+		return fmt.Sprintf("%s shared the document %s with %s.", actor, document, strings.Join(names, ", "))
+	case models.NotificationSubmissionReceived:
+		return fmt.Sprintf("%s submitted the document %s for review.", actor, document)
+	default:
+		return fmt.Sprintf("%s: %s", n.event, document)
+	}
+}
+
+// profileName returns the full name of a profile, or its ID if it has none. Must be called with the lock held.
+func (db *Database) profileName(id string) string {
+	profile := db.Database.Profiles[id]
+	if name := strings.TrimSpace(profile.FirstName + " " + profile.LastName); name != "" {
+		return name
+	}
+	return id
+}
+
+// deleteNotificationChannelsOf removes the notification channels a profile owns and returns how
+// many there were. Must be called with the write lock held.
+func (db *Database) deleteNotificationChannelsOf(profileID string) int {
+	deleted := 0
+	for id, channel := range db.Database.NotificationChannels {
+		if channel.OwnerID == profileID {
+			delete(db.Database.NotificationChannels, id)
+			deleted++
+		}
+	}
+	return deleted
+}
@@ -0,0 +1,177 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/models"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chatHook is a fake Slack/Discord webhook recording the messages posted to it, by path.
+type chatHook struct {
+	*httptest.Server
+	mu       sync.Mutex
+	messages map[string][]map[string]string
+}
+
+func newChatHook(t *testing.T) *chatHook {
+	hook := &chatHook{messages: make(map[string][]map[string]string)}
+	hook.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var message map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&message))
+		hook.mu.Lock()
+		hook.messages[r.URL.Path] = append(hook.messages[r.URL.Path], message)
+		hook.mu.Unlock()
+	}))
+	t.Cleanup(hook.Close)
+	return hook
+}
+
+// received returns the messages posted to path.
+func (h *chatHook) received(path string) []map[string]string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.messages[path]
+}
+
+func TestNotificationChannels(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	hook := newChatHook(t)
+	db.config.WebhookAllowedDomains = []string{"127.0.0.1"}
+
+	owner, err := db.CreateProfile(models.Profile{FirstName: "Olga", LastName: "Owner", Email: "olga@example.com"})
+	require.NoError(t, err)
+	reviewer, err := db.CreateProfile(models.Profile{FirstName: "Rita", LastName: "Reviewer", Email: "rita@example.com"})
+	require.NoError(t, err)
+	staff, err := db.CreateProfile(models.Profile{FirstName: "Sam", LastName: "Staff", Email: "sam@example.com"})
+	require.NoError(t, err)
+	doc, err := db.CreateDocument(models.Document{OwnerID: owner.ID, Content: map[string]any{"title": "Essay 1"}})
+	require.NoError(t, err)
+
+	channel := func(ownerID, path string, kind string, events ...string) models.NotificationChannel {
+		created, err := db.CreateNotificationChannel(models.NotificationChannel{OwnerID: ownerID, Name: path, Kind: kind,
+			WebhookURL: hook.URL + path, Events: events, MaxPerHour: 10, Enabled: true})
+		require.NoError(t, err)
+		return created
+	}
+	slack := channel(reviewer.ID, "/slack", models.NotificationChannelSlack, models.NotificationDocumentShared, models.NotificationSubmissionReceived)
+	discord := channel(reviewer.ID, "/discord", models.NotificationChannelDiscord, models.NotificationSubmissionReceived)
+	everyone := channel(staff.ID, "/staff", models.NotificationChannelSlack, models.NotificationDocumentShared)
+	everyone.AllUsers = true
+	_, err = db.UpdateNotificationChannel(everyone.ID, everyone)
+	require.NoError(t, err)
+
+	t.Run("CRUD", func(t *testing.T) {
+		assert.Equal(t, []models.NotificationChannel{slack, discord}, db.ListNotificationChannels(reviewer.ID))
+		assert.Empty(t, db.ListNotificationChannels(owner.ID))
+		stored, found := db.GetNotificationChannel(everyone.ID)
+		require.True(t, found)
+		assert.True(t, stored.AllUsers)
+		assert.ErrorIs(t, db.DeleteNotificationChannel("missing"), apperr.ErrNotFound)
+		_, err := db.UpdateNotificationChannel("missing", everyone)
+		assert.ErrorIs(t, err, apperr.ErrNotFound)
+	})
+
+	t.Run("Shares notify the profiles shared with and all-users channels", func(t *testing.T) {
+		require.NoError(t, db.SetShareRecord(doc.ID, []string{reviewer.ID}))
+		db.waitForNotifications()
+
+		assert.Equal(t, []map[string]string{{"text": "Olga Owner shared the document 'Essay 1' (" + doc.ID + ") with you."}}, hook.received("/slack"))
+		assert.Empty(t, hook.received("/discord"), "filtered out")
+		assert.Equal(t, []map[string]string{{"text": "Olga Owner shared the document 'Essay 1' (" + doc.ID + ") with Rita Reviewer."}}, hook.received("/staff"))
+
+		require.NoError(t, db.SetShareRecord(doc.ID, []string{reviewer.ID}))
+		db.waitForNotifications()
+		assert.Len(t, hook.received("/slack"), 1, "nothing new was shared")
+	})
+
+	t.Run("Submissions notify the reviewers", func(t *testing.T) {
+		_, err := db.TransitionDocumentWorkflow(WorkflowChange{DocumentID: doc.ID, State: models.WorkflowStateSubmitted, ActorID: owner.ID})
+		require.NoError(t, err)
+		db.waitForNotifications()
+
+		want := "Olga Owner submitted the document 'Essay 1' (" + doc.ID + ") for review."
+		assert.Equal(t, map[string]string{"text": want}, hook.received("/slack")[1])
+		assert.Equal(t, []map[string]string{{"content": want}}, hook.received("/discord"), "Discord messages are 'content'")
+		assert.Len(t, hook.received("/staff"), 1, "filtered out")
+	})
+
+	t.Run("Disabled channels are skipped", func(t *testing.T) {
+		discord.Enabled = false
+		_, err := db.UpdateNotificationChannel(discord.ID, discord)
+		require.NoError(t, err)
+		_, err = db.TransitionDocumentWorkflow(WorkflowChange{DocumentID: doc.ID, State: models.WorkflowStateRejected, ActorID: reviewer.ID})
+		require.NoError(t, err)
+		_, err = db.TransitionDocumentWorkflow(WorkflowChange{DocumentID: doc.ID, State: models.WorkflowStateSubmitted, ActorID: owner.ID})
+		require.NoError(t, err)
+		db.waitForNotifications()
+		assert.Len(t, hook.received("/discord"), 1)
+		assert.Len(t, hook.received("/slack"), 3)
+	})
+
+	t.Run("Rate limit drops messages", func(t *testing.T) {
+		limited := channel(owner.ID, "/limited", models.NotificationChannelSlack, models.NotificationDocumentShared)
+		limited.MaxPerHour = 1
+		_, err := db.UpdateNotificationChannel(limited.ID, limited)
+		require.NoError(t, err)
+		other, err := db.CreateDocument(models.Document{OwnerID: reviewer.ID, Content: map[string]any{}})
+		require.NoError(t, err)
+		third, err := db.CreateDocument(models.Document{OwnerID: staff.ID, Content: map[string]any{}})
+		require.NoError(t, err)
+
+		require.NoError(t, db.SetShareRecord(other.ID, []string{owner.ID}))
+		require.NoError(t, db.SetShareRecord(third.ID, []string{owner.ID}))
+		db.waitForNotifications()
+		assert.Len(t, hook.received("/limited"), 1)
+		assert.ErrorIs(t, db.SendTestNotification(limited.ID), ErrNotificationRateLimited)
+	})
+
+	t.Run("Test messages", func(t *testing.T) {
+		require.NoError(t, db.SendTestNotification(discord.ID), "sent even though the channel is disabled")
+		assert.Contains(t, hook.received("/discord")[1]["content"], "Test message")
+
+		down := channel(owner.ID, "/down", models.NotificationChannelSlack)
+		assert.ErrorContains(t, db.SendTestNotification(down.ID), "404")
+		db.config.WebhookAllowedDomains = []string{"hooks.slack.com"}
+		defer func() { db.config.WebhookAllowedDomains = []string{"127.0.0.1"} }()
+		assert.Error(t, db.SendTestNotification(slack.ID), "the host is no longer allowed")
+		assert.ErrorIs(t, db.SendTestNotification("missing"), apperr.ErrNotFound)
+	})
+
+	t.Run("Rolled back transactions notify no one", func(t *testing.T) {
+		before := len(hook.received("/slack"))
+		fresh, err := db.CreateDocument(models.Document{OwnerID: owner.ID, Content: map[string]any{}})
+		require.NoError(t, err)
+		_ = db.WithTransaction(func(tx *Tx) error {
+			require.NoError(t, tx.SetShareRecord(fresh.ID, []string{reviewer.ID}))
+			return errors.New("roll back")
+		})
+		db.waitForNotifications()
+		assert.Len(t, hook.received("/slack"), before)
+
+		require.NoError(t, db.WithTransaction(func(tx *Tx) error {
+			return tx.SetShareRecord(fresh.ID, []string{reviewer.ID})
+		}))
+		db.waitForNotifications()
+		assert.Len(t, hook.received("/slack"), before+1, "committed transactions do")
+	})
+
+	t.Run("Erasure deletes the profile's channels", func(t *testing.T) {
+		report, err := db.EraseProfile(reviewer.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 2, report.ChannelsDeleted)
+		assert.Empty(t, db.ListNotificationChannels(reviewer.ID))
+	})
+}
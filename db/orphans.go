@@ -0,0 +1,193 @@
+package db
+
+import (
+	"log"
+	"slices"
+	"sort"
+)
+
+// --- Orphaned References ---
+
+// Collections the orphan check looks at.
+const (
+	OrphanShareRecords     = "share_records"     // Share records of deleted documents, or sharers whose profile is gone
+	OrphanFavorites        = "favorites"         // Favorites of deleted profiles, or of deleted documents
+	OrphanDocumentEvents   = "document_events"   // Activity of deleted documents
+	OrphanDocumentVersions = "document_versions" // Version history of deleted documents
+	OrphanScheduleRuns     = "schedule_runs"     // Run history of deleted schedules
+	OrphanEmailChanges     = "email_changes"     // Pending email changes of deleted profiles
+	OrphanReviews          = "reviews"           // Reviews of deleted documents, or assigned to deleted profiles
+	OrphanAPIUsage         = "api_usage"         // API usage counts of deleted profiles
+	OrphanDevices          = "devices"           // Known devices of deleted profiles
+)
+
+// Orphan is an entry that points at a profile, document or schedule that no longer exists.
+type Orphan struct {
+	Collection  string `json:"collection"`   // One of the Orphan* collections
+	Key         string `json:"key"`          // Key of the entry in the collection
+	MissingType string `json:"missing_type"` // "profile", "document" or "schedule"
+	MissingID   string `json:"missing_id"`   // ID of the missing record
+}
+
+// OrphanReport lists the orphaned entries found (or removed) by FindOrphans and CleanOrphans.
+type OrphanReport struct {
+	Orphans []Orphan       `json:"orphans"`
+	Counts  map[string]int `json:"counts"` // Number of orphans per collection
+}
+
+// FindOrphans reports entries referring to profiles, documents or schedules that no longer exist.
+// Deleting those normally cleans up after itself; orphans are left by older versions of the
+// server, hand-edited files, or deletions interrupted by a crash.
+func (db *Database) FindOrphans() OrphanReport {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+	return db.findOrphans()
+}
+
+// CleanOrphans removes the entries FindOrphans reports and returns what was removed.
+func (db *Database) CleanOrphans() OrphanReport {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	report := db.findOrphans()
+	if len(report.Orphans) == 0 {
+		return report
+	}
+	for _, orphan := range report.Orphans {
+		db.removeOrphan(orphan)
+	}
+	log.Printf("INFO: Removed %d orphaned entries: %v", len(report.Orphans), report.Counts)
+	db.requestSave()
+	return report
+}
+
+// findOrphans collects the orphaned entries, sorted by collection and key.
+// Must be called with the lock held.
+func (db *Database) findOrphans() OrphanReport {
+	var orphans []Orphan
+	profileExists := func(id string) bool { _, found := db.Database.Profiles[id]; return found }
+	documentExists := func(id string) bool { _, found := db.Database.Documents[id]; return found }
+
+	for docID, record := range db.Database.ShareRecords {
+		if !documentExists(docID) {
+			orphans = append(orphans, Orphan{OrphanShareRecords, docID, "document", docID})
+			continue
+		}
+		for _, profileID := range record.SharedWith {
+			if !profileExists(profileID) {
+				orphans = append(orphans, Orphan{OrphanShareRecords, docID, "profile", profileID})
+			}
+		}
+	}
+	for profileID, docIDs := range db.Database.Favorites {
+		if !profileExists(profileID) {
+			orphans = append(orphans, Orphan{OrphanFavorites, profileID, "profile", profileID})
+			continue
+		}
+		for _, docID := range docIDs {
+			if !documentExists(docID) {
+				orphans = append(orphans, Orphan{OrphanFavorites, profileID, "document", docID})
+			}
+		}
+	}
+	for docID := range db.Database.DocumentEvents {
+		if !documentExists(docID) {
+			orphans = append(orphans, Orphan{OrphanDocumentEvents, docID, "document", docID})
+		}
+	}
+	for docID := range db.Database.DocumentVersions {
+		if !documentExists(docID) {
+			orphans = append(orphans, Orphan{OrphanDocumentVersions, docID, "document", docID})
+		}
+	}
+	for scheduleID := range db.Database.ScheduleRuns {
+		if _, found := db.Database.Schedules[scheduleID]; !found {
+			orphans = append(orphans, Orphan{OrphanScheduleRuns, scheduleID, "schedule", scheduleID})
+		}
+	}
+	for profileID := range db.Database.EmailChanges {
+		if !profileExists(profileID) {
+			orphans = append(orphans, Orphan{OrphanEmailChanges, profileID, "profile", profileID})
+		}
+	}
+	for profileID := range db.Database.APIUsage {
+		if !profileExists(profileID) {
+			orphans = append(orphans, Orphan{OrphanAPIUsage, profileID, "profile", profileID})
+		}
+	}
+	for profileID := range db.Database.Devices {
+		if !profileExists(profileID) {
+			orphans = append(orphans, Orphan{OrphanDevices, profileID, "profile", profileID})
+		}
+	}
+	for reviewID, review := range db.Database.Reviews {
+		if !documentExists(review.DocumentID) {
+			orphans = append(orphans, Orphan{OrphanReviews, reviewID, "document", review.DocumentID})
+		} else if !profileExists(review.ReviewerID) {
+			orphans = append(orphans, Orphan{OrphanReviews, reviewID, "profile", review.ReviewerID})
+		}
+	}
+
+	sort.Slice(orphans, func(i, j int) bool {
+		a, b := orphans[i], orphans[j]
+		if a.Collection != b.Collection {
+			return a.Collection < b.Collection
+		}
+		if a.Key != b.Key {
+			return a.Key < b.Key
+		}
+		return a.MissingID < b.MissingID
+	})
+	report := OrphanReport{Orphans: make([]Orphan, 0, len(orphans)), Counts: make(map[string]int)}
+	for _, orphan := range orphans {
+		report.Orphans = append(report.Orphans, orphan)
+		report.Counts[orphan.Collection]++
+	}
+	return report
+}
+
+// removeOrphan deletes one orphaned entry. Must be called with the write lock held.
+func (db *Database) removeOrphan(orphan Orphan) {
+	switch orphan.Collection {
+	case OrphanShareRecords:
+		if orphan.MissingType == "document" {
+			delete(db.Database.ShareRecords, orphan.Key)
+			return
+		}
+		record, found := db.Database.ShareRecords[orphan.Key]
+		if !found {
+			return
+		}
+		record.SharedWith = slices.DeleteFunc(slices.Clone(record.SharedWith), func(id string) bool { return id == orphan.MissingID })
+		if len(record.SharedWith) == 0 {
+			delete(db.Database.ShareRecords, orphan.Key)
+		} else {
+			db.Database.ShareRecords[orphan.Key] = pruneShareScopes(record)
+		}
+	case OrphanFavorites:
+		if orphan.MissingType == "profile" {
+			delete(db.Database.Favorites, orphan.Key)
+			return
+		}
+		favorites := slices.DeleteFunc(slices.Clone(db.Database.Favorites[orphan.Key]), func(id string) bool { return id == orphan.MissingID })
+		if len(favorites) == 0 {
+			delete(db.Database.Favorites, orphan.Key)
+		} else {
+			db.Database.Favorites[orphan.Key] = favorites
+		}
+	case OrphanDocumentEvents:
+		delete(db.Database.DocumentEvents, orphan.Key)
+	case OrphanDocumentVersions:
+		delete(db.Database.DocumentVersions, orphan.Key)
+	case OrphanScheduleRuns:
+		delete(db.Database.ScheduleRuns, orphan.Key)
+	case OrphanEmailChanges:
+		delete(db.Database.EmailChanges, orphan.Key)
+	case OrphanReviews:
+		delete(db.Database.Reviews, orphan.Key)
+	case OrphanAPIUsage:
+		delete(db.Database.APIUsage, orphan.Key)
+	case OrphanDevices:
+		delete(db.Database.Devices, orphan.Key)
+	}
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"docserver/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabase_Orphans(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	alice, err := db.CreateProfile(models.Profile{Email: "alice@example.com"})
+	require.NoError(t, err)
+	bob, err := db.CreateProfile(models.Profile{Email: "bob@example.com"})
+	require.NoError(t, err)
+	doc, err := db.CreateDocument(models.Document{OwnerID: alice.ID, Content: "notes"})
+	require.NoError(t, err)
+	require.NoError(t, db.SetShareRecord(doc.ID, []string{bob.ID, "gone-profile"}))
+	_, err = db.AddFavorite(bob.ID, doc.ID)
+	require.NoError(t, err)
+
+	// Leave references behind the way an interrupted deletion would
+	db.Database.Mu.Lock()
+	db.Database.ShareRecords["gone-doc"] = models.ShareRecord{SharedWith: []string{bob.ID}}
+	db.Database.Favorites[bob.ID] = append(db.Database.Favorites[bob.ID], "gone-doc")
+	db.Database.Favorites["gone-profile"] = []string{doc.ID}
+	db.Database.DocumentEvents["gone-doc"] = []models.DocumentEvent{{Type: "created"}}
+	db.Database.DocumentVersions["gone-doc"] = []models.DocumentVersion{{Version: 1}}
+	db.Database.ScheduleRuns["gone-schedule"] = []models.ScheduleRun{{ID: "run"}}
+	db.Database.EmailChanges["gone-profile"] = models.EmailChange{NewEmail: "new@example.com"}
+	db.Database.Mu.Unlock()
+
+	expected := []Orphan{
+		{OrphanDocumentEvents, "gone-doc", "document", "gone-doc"},
+		{OrphanDocumentVersions, "gone-doc", "document", "gone-doc"},
+		{OrphanEmailChanges, "gone-profile", "profile", "gone-profile"},
+		{OrphanFavorites, bob.ID, "document", "gone-doc"},
+		{OrphanFavorites, "gone-profile", "profile", "gone-profile"},
+		{OrphanScheduleRuns, "gone-schedule", "schedule", "gone-schedule"},
+		{OrphanShareRecords, doc.ID, "profile", "gone-profile"},
+		{OrphanShareRecords, "gone-doc", "document", "gone-doc"},
+	}
+	if bob.ID > "gone-profile" {
+		expected[3], expected[4] = expected[4], expected[3]
+	}
+	if doc.ID > "gone-doc" {
+		expected[6], expected[7] = expected[7], expected[6]
+	}
+
+	report := db.FindOrphans()
+	assert.Equal(t, expected, report.Orphans)
+	assert.Equal(t, 2, report.Counts[OrphanShareRecords])
+
+	cleaned := db.CleanOrphans()
+	assert.Equal(t, report, cleaned)
+	assert.Empty(t, db.FindOrphans().Orphans)
+
+	record, found := db.GetShareRecordByDocumentID(doc.ID)
+	require.True(t, found)
+	assert.Equal(t, []string{bob.ID}, record.SharedWith, "live sharers are kept")
+	assert.Equal(t, []string{doc.ID}, db.GetFavoriteIDs(bob.ID), "live favorites are kept")
+	assert.Empty(t, db.CleanOrphans().Orphans)
+}
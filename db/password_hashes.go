@@ -0,0 +1,67 @@
+package db
+
+import (
+	"docserver/utils"
+	"sort"
+)
+
+// --- Password Hash Migration ---
+
+// PasswordHashGroup counts the accounts whose password hash was made with the same parameters.
+type PasswordHashGroup struct {
+	Parameters string `json:"parameters"` // e.g. "bcrypt cost=12" or "argon2id m=19456,t=2,p=1"
+	Accounts   int    `json:"accounts"`
+	Current    bool   `json:"current"` // Made with the configured parameters
+}
+
+// PasswordHashReport tells how far stored password hashes have moved to the configured
+// algorithm and parameters. Outdated hashes are replaced when their owners log in.
+type PasswordHashReport struct {
+	Configured   string              `json:"configured"`   // Parameters new hashes are made with
+	Accounts     int                 `json:"accounts"`     // Profiles with a password hash
+	Current      int                 `json:"current"`      // Hashes made with the configured parameters
+	Outdated     int                 `json:"outdated"`     // Hashes to be replaced at the next login
+	Unrecognized int                 `json:"unrecognized"` // Hashes of no known algorithm; never replaced
+	Rehashed     int64               `json:"rehashed"`     // Hashes replaced at login since the server started
+	Groups       []PasswordHashGroup `json:"groups"`       // Most accounts first
+}
+
+// PasswordHashReport counts the stored password hashes by the parameters they were made with.
+func (db *Database) PasswordHashReport() PasswordHashReport {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+
+	report := PasswordHashReport{
+		Configured: utils.ConfiguredPasswordHashParameters(db.config),
+		Rehashed:   db.passwordRehashes.Load(),
+		Groups:     []PasswordHashGroup{},
+	}
+	groups := map[string]int{}
+	for _, profile := range db.Database.Profiles {
+		if profile.PasswordHash == "" {
+			continue
+		}
+		report.Accounts++
+		parameters := utils.PasswordHashParameters(profile.PasswordHash)
+		switch {
+		case parameters == "":
+			report.Unrecognized++
+			continue
+		case utils.PasswordNeedsRehash(profile.PasswordHash, db.config):
+			report.Outdated++
+		default:
+			report.Current++
+		}
+		groups[parameters]++
+	}
+	for parameters, accounts := range groups {
+		report.Groups = append(report.Groups, PasswordHashGroup{Parameters: parameters, Accounts: accounts, Current: parameters == report.Configured})
+	}
+	sort.Slice(report.Groups, func(i, j int) bool {
+		if report.Groups[i].Accounts != report.Groups[j].Accounts {
+			return report.Groups[i].Accounts > report.Groups[j].Accounts
+		}
+		return report.Groups[i].Parameters < report.Groups[j].Parameters
+	})
+	return report
+}
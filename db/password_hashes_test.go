@@ -0,0 +1,45 @@
+package db
+
+import (
+	"docserver/config"
+	"docserver/models"
+	"docserver/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabase_PasswordHashReport(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	db.config.PasswordHash = config.PasswordHashArgon2id
+	db.config.Argon2Time, db.config.Argon2MemoryKiB, db.config.Argon2Threads = 1, 64, 1
+
+	argonHash, err := utils.HashPasswordWithConfig("pw", db.config)
+	require.NoError(t, err)
+	bcryptHash, err := utils.HashPassword("pw", 4)
+	require.NoError(t, err)
+	for email, hash := range map[string]string{"a@example.com": argonHash, "b@example.com": bcryptHash, "c@example.com": bcryptHash, "d@example.com": "garbage", "e@example.com": ""} {
+		_, err := db.CreateProfile(models.Profile{Email: email, PasswordHash: hash})
+		require.NoError(t, err)
+	}
+
+	report := db.PasswordHashReport()
+	assert.Equal(t, "argon2id m=64,t=1,p=1", report.Configured)
+	assert.Equal(t, 4, report.Accounts, "profiles without a password are left out")
+	assert.Equal(t, 1, report.Current)
+	assert.Equal(t, 2, report.Outdated)
+	assert.Equal(t, 1, report.Unrecognized)
+	assert.Equal(t, []PasswordHashGroup{
+		{Parameters: "bcrypt cost=4", Accounts: 2},
+		{Parameters: "argon2id m=64,t=1,p=1", Accounts: 1, Current: true},
+	}, report.Groups)
+
+	profile, found := db.GetProfileByEmail("b@example.com")
+	require.True(t, found)
+	require.True(t, db.RehashProfilePassword(profile.ID, bcryptHash, argonHash))
+	report = db.PasswordHashReport()
+	assert.Equal(t, 2, report.Current)
+	assert.Equal(t, int64(1), report.Rehashed)
+}
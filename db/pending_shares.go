@@ -0,0 +1,139 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/models"
+	"docserver/utils"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// --- Pending Shares ---
+
+// normalizeEmail makes emails case-insensitive, tolerant of surrounding spaces and in NFC (see
+// utils.NormalizeText), as profile lookups by email are.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(utils.NormalizeText(email)))
+}
+
+// ShareWithEmail shares a document with whoever has the given email. If a profile has it, the
+// document is shared with that profile right away and its ID is returned. Otherwise the share is
+// kept as pending until someone signs up with the email (see ActivatePendingShares) and the
+// returned ID is empty. Sharing again with the same email changes nothing. sharedBy is the
+// document owner; permissions and sharing with the owner are checked at handler level.
+func (db *Database) ShareWithEmail(docID, email, sharedBy string) (string, error) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	if _, found := db.Database.Documents[docID]; !found {
+		return "", apperr.NotFound("document with ID '%s' not found", docID)
+	}
+	if err := db.checkNotFrozen(docID); err != nil {
+		return "", err
+	}
+
+	email = normalizeEmail(email)
+	if profileID, found := db.profileIDByEmail(email); found {
+		if db.addSharer(docID, profileID) {
+			db.requestSave()
+		}
+		return profileID, nil
+	}
+
+	pending := db.Database.PendingShares[email]
+	if slices.ContainsFunc(pending, func(share models.PendingShare) bool { return share.DocumentID == docID }) {
+		return "", nil
+	}
+	db.Database.PendingShares[email] = append(pending, models.PendingShare{DocumentID: docID, SharedBy: sharedBy, CreationDate: db.now().UTC()})
+	log.Printf("INFO: Document ID %s shared with unregistered email %s (pending)", docID, email)
+
+	db.requestSave()
+	return "", nil
+}
+
+// PendingShareEmails returns the emails a document has pending shares with, sorted.
+func (db *Database) PendingShareEmails(docID string) []string {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+
+	emails := make([]string, 0)
+	for email, pending := range db.Database.PendingShares {
+		if slices.ContainsFunc(pending, func(share models.PendingShare) bool { return share.DocumentID == docID }) {
+			emails = append(emails, email)
+		}
+	}
+	sort.Strings(emails)
+	return emails
+}
+
+// CancelPendingShare withdraws the pending share of a document with an email. It reports whether
+// there was one.
+func (db *Database) CancelPendingShare(docID, email string) bool {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	email = normalizeEmail(email)
+	if !db.removePendingShares(email, func(share models.PendingShare) bool { return share.DocumentID == docID }) {
+		return false
+	}
+	log.Printf("INFO: Pending share of Document ID %s with %s withdrawn", docID, email)
+
+	db.requestSave()
+	return true
+}
+
+// ActivatePendingShares turns the pending shares with the email of a newly signed-up profile into
+// regular shares with it. Frozen documents are shared too, as they were shared before they were
+// frozen. It returns the IDs of the documents now shared with the profile.
+func (db *Database) ActivatePendingShares(profile models.Profile) []string {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	email := normalizeEmail(profile.Email)
+	pending, found := db.Database.PendingShares[email]
+	if !found {
+		return nil
+	}
+	delete(db.Database.PendingShares, email)
+
+	activated := make([]string, 0, len(pending))
+	for _, share := range pending {
+		doc, found := db.Database.Documents[share.DocumentID]
+		if !found || doc.OwnerID == profile.ID {
+			continue
+		}
+		db.addSharer(share.DocumentID, profile.ID)
+		activated = append(activated, share.DocumentID)
+	}
+	log.Printf("INFO: Activated %d pending share(s) for Profile ID %s", len(activated), profile.ID)
+
+	db.requestSave()
+	return activated
+}
+
+// deletePendingSharesOf removes the pending shares of a deleted document.
+// Must be called with the write lock held.
+func (db *Database) deletePendingSharesOf(docID string) {
+	for email := range db.Database.PendingShares {
+		db.removePendingShares(email, func(share models.PendingShare) bool { return share.DocumentID == docID })
+	}
+}
+
+// removePendingShares removes the pending shares with email that match, dropping the email once
+// it has none left. It reports whether any were removed.
+// Must be called with the write lock held.
+func (db *Database) removePendingShares(email string, match func(models.PendingShare) bool) bool {
+	pending := db.Database.PendingShares[email]
+	remaining := slices.DeleteFunc(slices.Clone(pending), match)
+	if len(remaining) == len(pending) {
+		return false
+	}
+	if len(remaining) == 0 {
+		delete(db.Database.PendingShares, email)
+	} else {
+		db.Database.PendingShares[email] = remaining
+	}
+	return true
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"docserver/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPendingShares(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	owner, err := db.CreateProfile(models.Profile{Email: "owner@example.com"})
+	require.NoError(t, err)
+	doc, err := db.CreateDocument(models.Document{OwnerID: owner.ID, Content: "shared"})
+	require.NoError(t, err)
+	other, err := db.CreateDocument(models.Document{OwnerID: owner.ID, Content: "deleted"})
+	require.NoError(t, err)
+
+	profileID, err := db.ShareWithEmail(doc.ID, " New.User@Example.com", owner.ID)
+	require.NoError(t, err)
+	assert.Empty(t, profileID, "nobody has the email yet")
+	_, err = db.ShareWithEmail(doc.ID, "new.user@example.com", owner.ID)
+	require.NoError(t, err)
+	_, err = db.ShareWithEmail(other.ID, "new.user@example.com", owner.ID)
+	require.NoError(t, err)
+	_, err = db.ShareWithEmail("missing", "new.user@example.com", owner.ID)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"new.user@example.com"}, db.PendingShareEmails(doc.ID))
+	assert.Len(t, db.Database.PendingShares["new.user@example.com"], 2, "sharing twice changes nothing")
+
+	t.Run("Deleting a document drops its pending shares", func(t *testing.T) {
+		require.NoError(t, db.DeleteDocument(other.ID))
+		assert.Len(t, db.Database.PendingShares["new.user@example.com"], 1)
+	})
+
+	t.Run("Signing up activates them", func(t *testing.T) {
+		user, err := db.CreateProfile(models.Profile{Email: "NEW.user@example.com"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{doc.ID}, db.ActivatePendingShares(user))
+		record, found := db.GetShareRecordByDocumentID(doc.ID)
+		require.True(t, found)
+		assert.Contains(t, record.SharedWith, user.ID)
+		assert.Empty(t, db.PendingShareEmails(doc.ID))
+		assert.Nil(t, db.ActivatePendingShares(user), "only once")
+
+		profileID, err := db.ShareWithEmail(doc.ID, "new.user@example.com", owner.ID)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, profileID, "registered emails are shared with right away")
+	})
+
+	t.Run("Withdrawing a pending share", func(t *testing.T) {
+		_, err := db.ShareWithEmail(doc.ID, "later@example.com", owner.ID)
+		require.NoError(t, err)
+		assert.True(t, db.CancelPendingShare(doc.ID, "LATER@example.com"))
+		assert.False(t, db.CancelPendingShare(doc.ID, "later@example.com"))
+		assert.NotContains(t, db.Database.PendingShares, "later@example.com")
+	})
+}
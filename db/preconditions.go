@@ -0,0 +1,41 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/i18n"
+	"docserver/models"
+)
+
+// --- Conditional Updates ---
+
+// Precondition is what a document must satisfy for a conditional update to apply. It is checked
+// under the write lock, so nothing can change the document between the check and the update.
+type Precondition struct {
+	Version  int          // If above 0, the version the document must be at
+	Revision int          // With Version, the revision of that version it must be at (see models.Document)
+	Matches  *ParsedQuery // If set, a content query (as in GET /documents) the current content must match
+}
+
+// checkPrecondition returns an ErrConflict error if doc is not at the expected version and
+// revision (coalesced updates keep the version but not the revision), and an
+// ErrPrecondition error if its content does not match the query or the query cannot be evaluated
+// against it. Must be called with the lock held.
+func (db *Database) checkPrecondition(doc models.Document, pre Precondition) error {
+	if pre.Version > 0 && pre.Version != currentDocumentVersion(doc) {
+		return apperr.Wrap(apperr.ErrConflict, i18n.NewError(i18n.MsgDocumentVersionMismatch, doc.ID, currentDocumentVersion(doc), pre.Version))
+	}
+	if pre.Version > 0 && pre.Revision != doc.Revision {
+		return apperr.Wrap(apperr.ErrConflict, i18n.NewError(i18n.MsgDocumentRevisionMismatch, pre.Version, doc.ID, doc.Revision, pre.Revision))
+	}
+	if pre.Matches == nil {
+		return nil
+	}
+	match, err := db.EvaluateContentQuery(doc, pre.Matches)
+	if err != nil {
+		return apperr.Wrap(apperr.ErrPrecondition, i18n.NewError(i18n.MsgContentConditionError, doc.ID, err))
+	}
+	if !match {
+		return apperr.Wrap(apperr.ErrPrecondition, i18n.NewError(i18n.MsgContentConditionFailed, doc.ID))
+	}
+	return nil
+}
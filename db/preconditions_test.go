@@ -0,0 +1,54 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateDocumentIf(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	doc, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: map[string]any{"status": "draft", "seats": 2.0}})
+	require.NoError(t, err)
+	isDraft, err := ParseContentQuery([]string{`status equals "draft"`, "and", "seats greaterThan 0"})
+	require.NoError(t, err)
+
+	updated, err := db.UpdateDocumentIf(doc.ID, map[string]any{"status": "submitted", "seats": 2.0}, nil, Precondition{Matches: isDraft})
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated.Version)
+
+	_, err = db.UpdateDocumentIf(doc.ID, map[string]any{"status": "lost"}, nil, Precondition{Matches: isDraft})
+	assert.ErrorIs(t, err, apperr.ErrPrecondition, "no longer a draft")
+	_, err = db.UpdateDocumentIf(doc.ID, map[string]any{"status": "lost"}, nil, Precondition{Version: 1})
+	assert.ErrorIs(t, err, apperr.ErrConflict)
+	current, _ := db.GetDocumentByID(doc.ID)
+	assert.Equal(t, "submitted", current.Content.(map[string]any)["status"])
+
+	text := models.ContentTypeText
+	updated, err = db.UpdateDocumentIf(doc.ID, "notes", &text, Precondition{Version: 2})
+	require.NoError(t, err)
+	assert.Equal(t, models.ContentTypeText, updated.ContentType)
+	_, err = db.UpdateDocumentIf(doc.ID, "more", nil, Precondition{Matches: isDraft})
+	assert.ErrorIs(t, err, apperr.ErrPrecondition, "plain text has no status")
+}
+
+func TestContentPathPrecondition(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	doc, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: map[string]any{"seats": 1.0}})
+	require.NoError(t, err)
+	seatsLeft, err := ParseContentQuery([]string{"seats greaterThan 0"})
+	require.NoError(t, err)
+
+	change, err := db.IncrementDocumentNumber(doc.ID, "owner", []string{"seats"}, -1, Precondition{Matches: seatsLeft})
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, change.Value)
+	_, err = db.IncrementDocumentNumber(doc.ID, "owner", []string{"seats"}, -1, Precondition{Matches: seatsLeft})
+	assert.ErrorIs(t, err, apperr.ErrPrecondition, "the last seat is taken")
+}
@@ -0,0 +1,208 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/i18n"
+	"docserver/models"
+	"docserver/utils"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// --- Profile Extra Policy ---
+
+// defaultMaxExtraBytes is the largest Profile.Extra (JSON-encoded) allowed when the policy sets none.
+const defaultMaxExtraBytes = 16 << 10
+
+// defaultSensitiveExtraKeys are redacted in search results until an administrator sets a policy.
+var defaultSensitiveExtraKeys = []string{"*token*", "*password*", "*secret*"}
+
+// redactedValue replaces the values of sensitive keys in search results.
+const redactedValue = "[redacted]"
+
+// GetExtraPolicy returns the policy for Profile.Extra, or the default one if none was set.
+func (db *Database) GetExtraPolicy() models.ExtraPolicy {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+
+	return db.extraPolicy()
+}
+
+// extraPolicy returns the policy in effect. Must be called with the lock held.
+func (db *Database) extraPolicy() models.ExtraPolicy {
+	if db.Database.ExtraPolicy == nil {
+		return models.ExtraPolicy{SensitiveKeys: slices.Clone(defaultSensitiveExtraKeys)}
+	}
+	policy := *db.Database.ExtraPolicy
+	policy.SensitiveKeys = slices.Clone(policy.SensitiveKeys)
+	return policy
+}
+
+// SetExtraPolicy checks and saves the policy for Profile.Extra, or with nil, goes back to the
+// default one. UpdatedAt is set to the current time. Extra already stored is not checked again.
+func (db *Database) SetExtraPolicy(policy *models.ExtraPolicy) (models.ExtraPolicy, error) {
+	if policy != nil {
+		if err := checkExtraPolicy(*policy); err != nil {
+			return models.ExtraPolicy{}, err
+		}
+	}
+
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	if policy == nil {
+		db.Database.ExtraPolicy = nil
+		log.Printf("INFO: Profile extra policy reset to the default")
+	} else {
+		stored := *policy
+		stored.SensitiveKeys = slices.Clone(policy.SensitiveKeys)
+		stored.Schema = cloneJSON(policy.Schema)
+		stored.UpdatedAt = db.now().UTC()
+		db.Database.ExtraPolicy = &stored
+		log.Printf("INFO: Profile extra policy set by Profile ID %s (schema: %t, max bytes: %d, sensitive keys: %s, reject: %t)",
+			stored.ChangedBy, stored.Schema != nil, stored.MaxBytes, strings.Join(stored.SensitiveKeys, ", "), stored.RejectSensitive)
+	}
+
+	db.requestSave()
+	return db.extraPolicy(), nil
+}
+
+// checkExtraPolicy returns an apperr.ErrValidation error if the policy cannot be applied.
+func checkExtraPolicy(policy models.ExtraPolicy) error {
+	if policy.MaxBytes < 0 {
+		return apperr.Validation("max_bytes must not be negative")
+	}
+	for _, pattern := range policy.SensitiveKeys {
+		if strings.TrimSpace(pattern) == "" {
+			return apperr.Validation("sensitive key patterns must not be empty")
+		}
+		if _, err := path.Match(strings.ToLower(pattern), ""); err != nil {
+			return apperr.Validation("invalid sensitive key pattern '%s': %v", pattern, err)
+		}
+	}
+	if policy.Schema != nil {
+		if err := utils.CheckJSONSchema(policy.Schema); err != nil {
+			return apperr.Validation("invalid schema: %v", err)
+		}
+	}
+	return nil
+}
+
+// checkProfileExtra returns an apperr.ErrValidation error if extra breaks the policy: it is too
+// large, has a sensitive key while those are rejected, or does not match the schema.
+// Must be called with the lock held.
+func (db *Database) checkProfileExtra(extra any) error {
+	if extra == nil {
+		return nil
+	}
+	policy := db.extraPolicy()
+
+	maxBytes := policy.MaxBytes
+	if maxBytes == 0 {
+		maxBytes = defaultMaxExtraBytes
+	}
+	if encoded, err := json.Marshal(extra); err == nil && len(encoded) > maxBytes {
+		return apperr.Wrap(apperr.ErrValidation, i18n.NewError(i18n.MsgProfileExtraTooLarge, len(encoded), maxBytes))
+	}
+	if policy.RejectSensitive {
+		if key, found := findSensitiveKey(extra, policy.SensitiveKeys, ""); found {
+			return apperr.Wrap(apperr.ErrValidation, i18n.NewError(i18n.MsgProfileExtraSensitiveKey, key))
+		}
+	}
+	if policy.Schema != nil {
+		if err := utils.ValidateJSONSchema(policy.Schema, extra); err != nil {
+			return apperr.Wrap(apperr.ErrValidation, i18n.NewError(i18n.MsgProfileExtraSchema, err))
+		}
+	}
+	return nil
+}
+
+// checkProfileExtraChange checks the Extra of an updated profile, unless it did not change:
+// updates of other fields keep working if the policy became stricter since.
+// Must be called with the lock held.
+func (db *Database) checkProfileExtraChange(existing, updated models.Profile) error {
+	if reflect.DeepEqual(existing.Extra, updated.Extra) {
+		return nil
+	}
+	return db.checkProfileExtra(updated.Extra)
+}
+
+// RedactExtra returns a copy of extra in which the values of sensitive keys are replaced, for
+// showing a profile to other users.
+func (db *Database) RedactExtra(extra any) any {
+	if extra == nil {
+		return nil
+	}
+	policy := db.GetExtraPolicy()
+	if len(policy.SensitiveKeys) == 0 {
+		return extra
+	}
+	return redactSensitiveKeys(cloneJSON(extra), policy.SensitiveKeys)
+}
+
+// sensitiveKey reports whether an object key matches one of the patterns.
+func sensitiveKey(key string, patterns []string) bool {
+	key = strings.ToLower(key)
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(strings.ToLower(pattern), key); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// findSensitiveKey returns the path of the first sensitive key in value, in key order.
+func findSensitiveKey(value any, patterns []string, at string) (string, bool) {
+	switch typed := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(typed))
+		for key := range typed {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			keyPath := key
+			if at != "" {
+				keyPath = at + "." + key
+			}
+			if sensitiveKey(key, patterns) {
+				return keyPath, true
+			}
+			if found, ok := findSensitiveKey(typed[key], patterns, keyPath); ok {
+				return found, true
+			}
+		}
+	case []any:
+		for i, item := range typed {
+			if found, ok := findSensitiveKey(item, patterns, fmt.Sprintf("%s[%d]", at, i)); ok {
+				return found, true
+			}
+		}
+	}
+	return "", false
+}
+
+// redactSensitiveKeys replaces the values of sensitive keys in value, which it changes in place.
+func redactSensitiveKeys(value any, patterns []string) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		for key, child := range typed {
+			if sensitiveKey(key, patterns) {
+				typed[key] = redactedValue
+			} else {
+				typed[key] = redactSensitiveKeys(child, patterns)
+			}
+		}
+	case []any:
+		for i, item := range typed {
+			typed[i] = redactSensitiveKeys(item, patterns)
+		}
+	}
+	return value
+}
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtraPolicy(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	policy := db.GetExtraPolicy()
+	assert.Equal(t, defaultSensitiveExtraKeys, policy.SensitiveKeys, "redacted by default")
+	assert.Equal(t, map[string]any{"api_token": redactedValue, "nested": []any{map[string]any{"Password": redactedValue, "x": 1.0}}},
+		db.RedactExtra(map[string]any{"api_token": "abc", "nested": []any{map[string]any{"Password": "hunter2", "x": 1.0}}}))
+
+	_, err := db.CreateProfile(models.Profile{Email: "big@example.com", Extra: string(make([]byte, defaultMaxExtraBytes))})
+	assert.ErrorIs(t, err, apperr.ErrValidation, "too large")
+
+	t.Run("Invalid policies are refused", func(t *testing.T) {
+		_, err := db.SetExtraPolicy(&models.ExtraPolicy{MaxBytes: -1})
+		assert.ErrorIs(t, err, apperr.ErrValidation)
+		_, err = db.SetExtraPolicy(&models.ExtraPolicy{SensitiveKeys: []string{"[token"}})
+		assert.ErrorIs(t, err, apperr.ErrValidation)
+		_, err = db.SetExtraPolicy(&models.ExtraPolicy{Schema: map[string]any{"anyOf": []any{}}})
+		assert.ErrorIs(t, err, apperr.ErrValidation)
+	})
+
+	profile, err := db.CreateProfile(models.Profile{Email: "user@example.com", Extra: map[string]any{"github_token": "abc"}})
+	require.NoError(t, err)
+
+	policy, err = db.SetExtraPolicy(&models.ExtraPolicy{
+		Schema:          map[string]any{"type": "object", "properties": map[string]any{"year": map[string]any{"type": "integer"}}},
+		MaxBytes:        64,
+		SensitiveKeys:   []string{"*token*"},
+		RejectSensitive: true,
+		ChangedBy:       profile.ID,
+	})
+	require.NoError(t, err)
+	assert.False(t, policy.UpdatedAt.IsZero())
+
+	t.Run("Applies to new and changed extra", func(t *testing.T) {
+		_, err := db.CreateProfile(models.Profile{Email: "a@example.com", Extra: map[string]any{"year": "first"}})
+		assert.ErrorIs(t, err, apperr.ErrValidation, "schema")
+		_, err = db.CreateProfile(models.Profile{Email: "b@example.com", Extra: map[string]any{"a": map[string]any{"refresh_TOKEN": "x"}}})
+		assert.ErrorContains(t, err, "a.refresh_TOKEN")
+		_, err = db.CreateProfile(models.Profile{Email: "c@example.com", Extra: map[string]any{"notes": string(make([]byte, 64))}})
+		assert.ErrorIs(t, err, apperr.ErrValidation, "max_bytes")
+		_, err = db.CreateProfile(models.Profile{Email: "d@example.com", Extra: map[string]any{"year": 2}})
+		assert.NoError(t, err)
+
+		// Other changes to profiles holding extra from before are still allowed
+		profile.FirstName = "Renamed"
+		_, err = db.UpdateProfile(profile.ID, profile)
+		require.NoError(t, err)
+		profile.Extra = map[string]any{"github_token": "def"}
+		_, err = db.UpdateProfile(profile.ID, profile)
+		assert.ErrorIs(t, err, apperr.ErrValidation)
+	})
+
+	t.Run("Resetting", func(t *testing.T) {
+		policy, err := db.SetExtraPolicy(nil)
+		require.NoError(t, err)
+		assert.Nil(t, policy.Schema)
+		assert.Nil(t, db.Database.ExtraPolicy)
+	})
+}
@@ -0,0 +1,36 @@
+package db
+
+import (
+	"docserver/models"
+	"log"
+)
+
+// --- Roster Provisioning ---
+
+// ProvisionProfile creates a profile for a roster import. If its email is already in use the
+// existing profile is kept as it is, except that it is moved into profile.Group when one is given,
+// so importing the same roster again changes nothing. It returns the stored profile and whether
+// it was created.
+func (db *Database) ProvisionProfile(profile models.Profile) (models.Profile, bool, error) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	if id, taken := db.profileIDByEmail(profile.Email); taken {
+		existing := db.Database.Profiles[id]
+		if profile.Group != "" && existing.Group != profile.Group {
+			existing.Group = profile.Group
+			existing.LastModifiedDate = db.now().UTC()
+			db.putProfile(existing)
+			log.Printf("AUDIT: Provisioning moved Profile ID %s into group %q", existing.ID, existing.Group)
+			db.requestSave()
+		}
+		return existing, false, nil
+	}
+
+	created, err := db.createProfile(profile)
+	if err != nil {
+		return models.Profile{}, false, err
+	}
+	log.Printf("AUDIT: Provisioned Profile ID %s in group %q", created.ID, created.Group)
+	return created, true, nil
+}
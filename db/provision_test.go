@@ -0,0 +1,34 @@
+package db
+
+import (
+	"docserver/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabase_ProvisionProfile(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	created, isNew, err := db.ProvisionProfile(models.Profile{Email: "ada@example.com", FirstName: "Ada", LastName: "Lovelace", PasswordHash: "hash", Group: "cs101"})
+	require.NoError(t, err)
+	assert.True(t, isNew)
+	assert.Equal(t, "cs101", created.Group)
+
+	again, isNew, err := db.ProvisionProfile(models.Profile{Email: "ADA@example.com", FirstName: "Other", LastName: "Name", PasswordHash: "other"})
+	require.NoError(t, err)
+	assert.False(t, isNew)
+	assert.Equal(t, created.ID, again.ID)
+	assert.Equal(t, "cs101", again.Group, "no group keeps the current one")
+	assert.Equal(t, "hash", again.PasswordHash, "existing accounts keep their password")
+
+	moved, isNew, err := db.ProvisionProfile(models.Profile{Email: "ada@example.com", Group: "cs102"})
+	require.NoError(t, err)
+	assert.False(t, isNew)
+	assert.Equal(t, "cs102", moved.Group)
+	stored, _ := db.GetProfileByID(created.ID)
+	assert.Equal(t, "cs102", stored.Group)
+	assert.Equal(t, "Ada", stored.FirstName)
+}
@@ -1,7 +1,12 @@
 package db
 
 import (
+	"context"
+	"docserver/apperr"
+	"docserver/i18n"
 	"docserver/models"
+	"docserver/tracing"
+	"docserver/utils"
 	"encoding/json" // Added
 	"errors"
 	"fmt"
@@ -10,9 +15,10 @@ import (
 	"strconv" // Re-added for compareJSONValue
 	"regexp" // Added for number literal check
 	"strings"
-	// "time" // Removed unused import
+	"time"
 
 	"github.com/tidwall/gjson"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Simple regex to check if a string looks like a number literal (integer or float)
@@ -27,6 +33,7 @@ type QueryCondition struct {
 	ParsedValue   interface{} // The parsed value (string, float64, bool, nil)
 	ValueType     gjson.Type  // The type determined during parsing
 	IsInsensitive bool        // Flag derived from operator suffix
+	Negated       bool        // Set by a "not" prefix: the condition matches where it otherwise would not
 	Original      string      // Original condition string for error messages
 }
 
@@ -42,6 +49,48 @@ const (
 type ParsedQuery struct {
 	Conditions []QueryCondition
 	Logic      []LogicalOperator // Logic[i] applies between Conditions[i] and Conditions[i+1]
+	Missing    string            // How conditions on paths a document lacks are treated: one of the Missing* modes ("" = MissingSkip)
+}
+
+// Ways of treating conditions on a path a document does not have (the "missing" query parameter).
+// Negated conditions match such documents in every mode.
+const (
+	MissingSkip  = "skip"  // The document is left out, whatever the other conditions say (default)
+	MissingFalse = "false" // The condition is false, so other conditions joined with "or" can still match
+	MissingError = "error" // The whole query fails
+)
+
+// String renders the query as it was understood, in the content_query syntax: one space
+// between parts, string values quoted and "-insensitive" restored on insensitive operators.
+func (q *ParsedQuery) String() string {
+	if q == nil {
+		return ""
+	}
+	var b strings.Builder
+	for i, cond := range q.Conditions {
+		if i > 0 {
+			fmt.Fprintf(&b, " %s ", q.Logic[i-1])
+		}
+		operator := cond.Operator
+		if cond.IsInsensitive {
+			operator += "-insensitive"
+		}
+		if cond.Negated {
+			b.WriteString("not ")
+		}
+		if cond.Path != "" {
+			b.WriteString(cond.Path + " ")
+		}
+		switch v := cond.ParsedValue.(type) {
+		case nil:
+			fmt.Fprintf(&b, "%s null", operator)
+		case string:
+			fmt.Fprintf(&b, "%s \"%s\"", operator, v)
+		default:
+			fmt.Fprintf(&b, "%s %v", operator, v)
+		}
+	}
+	return b.String()
 }
 
 // --- Query Parsing ---
@@ -78,19 +127,19 @@ func ParseContentQuery(queryParts []string) (*ParsedQuery, error) {
 	for i, part := range queryParts {
 		part = strings.TrimSpace(part)
 		if part == "" {
-			return nil, fmt.Errorf("query part at index %d is empty", i)
+			return nil, i18n.NewError(i18n.MsgQueryPartEmpty, i)
 		}
 
 		if isExpectingCondition {
 			condition, err := parseSingleCondition(part)
 			if err != nil {
-				return nil, fmt.Errorf("invalid condition at index %d ('%s'): %w", i, part, err)
+				return nil, i18n.NewError(i18n.MsgQueryInvalidCondition, i, part, err)
 			}
 			parsed.Conditions = append(parsed.Conditions, condition)
 		} else {
 			logic := LogicalOperator(strings.ToLower(part))
 			if logic != LogicAnd && logic != LogicOr {
-				return nil, fmt.Errorf("invalid logical operator at index %d: '%s', expected 'and' or 'or'", i, part)
+				return nil, i18n.NewError(i18n.MsgQueryInvalidLogic, i, part)
 			}
 			parsed.Logic = append(parsed.Logic, logic)
 		}
@@ -100,25 +149,36 @@ func ParseContentQuery(queryParts []string) (*ParsedQuery, error) {
 	// The loop must end after parsing a condition
 	// The loop must end after parsing a condition. If we are still expecting one, it means the query ended with a logical operator.
 	if isExpectingCondition && len(queryParts) > 0 { // Add len check to allow empty query
-		return nil, errors.New("query must end with a condition, not a logical operator")
+		return nil, i18n.NewError(i18n.MsgQueryTrailingLogic)
 	}
 
 	// Number of logic operators must be one less than the number of conditions
 	if len(parsed.Conditions) > 1 && len(parsed.Logic) != len(parsed.Conditions)-1 {
 		// This case should theoretically be caught by the alternating check, but double-check
-		return nil, errors.New("mismatch between number of conditions and logical operators")
+		return nil, i18n.NewError(i18n.MsgQueryLogicMismatch)
 	}
 
 	return parsed, nil
 }
 
 // parseSingleCondition parses a string like "path operator value" into QueryCondition,
-// determining the type of the value.
+// determining the type of the value. A leading "not" negates the condition.
 func parseSingleCondition(conditionStr string) (QueryCondition, error) {
 	parts := strings.Fields(conditionStr) // Simple split by whitespace
 
+	// "not <condition>" always negates, so a field named "not" cannot be queried at the root
+	if len(parts) > 1 && strings.EqualFold(parts[0], "not") {
+		cond, err := parseSingleCondition(strings.TrimSpace(conditionStr)[len(parts[0]):])
+		if err != nil {
+			return QueryCondition{}, err
+		}
+		cond.Negated = !cond.Negated
+		cond.Original = conditionStr
+		return cond, nil
+	}
+
 	if len(parts) < 2 {
-		return QueryCondition{}, fmt.Errorf("condition must have at least an operator and a value")
+		return QueryCondition{}, i18n.NewError(i18n.MsgQueryMissingValue)
 	}
 
 	var path, operator, rawValueStr string
@@ -134,7 +194,7 @@ func parseSingleCondition(conditionStr string) (QueryCondition, error) {
 		// Reconstruct the raw value string, preserving original spacing if quoted
 		valueStartIndex := strings.Index(conditionStr, parts[1])
 		if valueStartIndex == -1 { // Should not happen if parts[1] exists
-			return QueryCondition{}, fmt.Errorf("internal parsing error: could not find value start")
+			return QueryCondition{}, i18n.NewError(i18n.MsgQueryValueStart)
 		}
 		rawValueStr = strings.TrimSpace(conditionStr[valueStartIndex:])
 
@@ -144,21 +204,21 @@ func parseSingleCondition(conditionStr string) (QueryCondition, error) {
 		// Reconstruct the raw value string
 		valueStartIndex := strings.Index(conditionStr, parts[2])
 		if valueStartIndex == -1 { // Should not happen if parts[2] exists
-			return QueryCondition{}, fmt.Errorf("internal parsing error: could not find value start")
+			return QueryCondition{}, i18n.NewError(i18n.MsgQueryValueStart)
 		}
 		rawValueStr = strings.TrimSpace(conditionStr[valueStartIndex:])
 
 		// Validate operator early (before insensitive check)
 		_, isValidOp := validOperators[operator]
 		if !isValidOp && !strings.HasSuffix(operator, "-insensitive") {
-			return QueryCondition{}, fmt.Errorf("invalid operator '%s'", operator)
+			return QueryCondition{}, i18n.NewError(i18n.MsgQueryInvalidOperator, operator)
 		}
 	} else { // len(parts) == 2 and first part is NOT an operator (e.g., "path value")
 		potentialOp := strings.ToLower(parts[1])
 		if _, isValid := validOperators[potentialOp]; isValid {
-			return QueryCondition{}, fmt.Errorf("condition must have at least an operator and a value") // Missing value
+			return QueryCondition{}, i18n.NewError(i18n.MsgQueryMissingValue) // Missing value
 		}
-		return QueryCondition{}, fmt.Errorf("invalid condition format") // Missing operator
+		return QueryCondition{}, i18n.NewError(i18n.MsgQueryInvalidFormat) // Missing operator
 	}
 
 	// Handle insensitive suffix
@@ -166,7 +226,7 @@ func parseSingleCondition(conditionStr string) (QueryCondition, error) {
 		baseOperator := strings.TrimSuffix(operator, "-insensitive")
 		isSupported := stringOnlyOperators[baseOperator] || arrayOrStringOperators[baseOperator] || baseOperator == "equals" || baseOperator == "notequals"
 		if !isSupported {
-			return QueryCondition{}, fmt.Errorf("invalid base operator for insensitive matching '%s'", baseOperator)
+			return QueryCondition{}, i18n.NewError(i18n.MsgQueryInvalidInsensitive, baseOperator)
 		}
 		isInsensitive = true
 		operator = baseOperator // Use the base operator moving forward
@@ -205,8 +265,13 @@ func parseSingleCondition(conditionStr string) (QueryCondition, error) {
 	}
 	// --- End Value Parsing ---
 
+	// Content is stored in NFC, so the path and value are searched for in NFC too
+	if str, isString := parsedValue.(string); isString {
+		parsedValue = utils.NormalizeText(str)
+	}
+
 	return QueryCondition{
-		Path:          path,
+		Path:          utils.NormalizeText(path),
 		Operator:      operator, // Base operator
 		ParsedValue:   parsedValue,
 		ValueType:     valueType,
@@ -225,7 +290,7 @@ func (db *Database) EvaluateContentQuery(doc models.Document, query *ParsedQuery
 	}
 
 	// Evaluate the first condition
-	result, err := db.evaluateSingleCondition(doc, query.Conditions[0])
+	result, err := db.evaluateQueryCondition(doc, query.Conditions[0], query.Missing)
 	if err != nil {
 		// Ensure errors from evaluation (like invalid op on plain text) are returned
 		return false, fmt.Errorf("error evaluating condition '%s': %w", query.Conditions[0].Original, err)
@@ -238,7 +303,7 @@ func (db *Database) EvaluateContentQuery(doc models.Document, query *ParsedQuery
 			return false, fmt.Errorf("internal error: logic operator index %d out of bounds for conditions", i)
 		}
 
-		nextResult, err := db.evaluateSingleCondition(doc, query.Conditions[i+1])
+		nextResult, err := db.evaluateQueryCondition(doc, query.Conditions[i+1], query.Missing)
 		if err != nil {
 			// Ensure errors from evaluation are returned
 			return false, fmt.Errorf("error evaluating condition '%s': %w", query.Conditions[i+1].Original, err)
@@ -255,8 +320,58 @@ func (db *Database) EvaluateContentQuery(doc models.Document, query *ParsedQuery
 	return result, nil
 }
 
+// missingPathError reports that a condition's path does not exist in a document.
+type missingPathError struct {
+	path string
+}
+
+func (e missingPathError) Error() string {
+	return fmt.Sprintf("path '%s' does not exist in document content", e.path)
+}
+
+// evaluateQueryCondition is evaluateSingleCondition, treating a missing path as false in
+// MissingFalse mode.
+func (db *Database) evaluateQueryCondition(doc models.Document, cond QueryCondition, missing string) (bool, error) {
+	match, err := db.evaluateSingleCondition(doc, cond)
+	var missingPath missingPathError
+	if missing == MissingFalse && errors.As(err, &missingPath) {
+		return false, nil
+	}
+	return match, err
+}
+
 // evaluateSingleCondition checks if a document satisfies one specific condition.
+// A negated condition matches where the condition does not, including documents lacking its
+// path; other errors (such as an operator that does not suit the value) are returned as they are.
 func (db *Database) evaluateSingleCondition(doc models.Document, cond QueryCondition) (bool, error) {
+	if cond.Negated {
+		cond.Negated = false
+		match, err := db.evaluateSingleCondition(doc, cond)
+		var missing missingPathError
+		if errors.As(err, &missing) {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return !match, nil
+	}
+
+	// Markdown, text and CSV documents are plain text: there is no JSON to look paths up in
+	if IsTextContent(doc.ContentType) {
+		if cond.Path != "" {
+			return false, nil
+		}
+		text, _ := doc.Content.(string)
+		if !isValidForPlainText(cond.Operator, cond.IsInsensitive) {
+			return false, fmt.Errorf("content is plain text, and operator '%s' is not supported", cond.Original)
+		}
+		if _, isString := cond.ParsedValue.(string); !isString {
+			return false, nil
+		}
+		return comparePlainText(text, cond)
+	}
+
 	// Convert doc.Content to JSON string if it's not already a string
 	// This is needed for gjson to parse it.
 	var contentJSON string
@@ -292,11 +407,17 @@ func (db *Database) evaluateSingleCondition(doc models.Document, cond QueryCondi
 	if cond.Path == "" {
         // If path is empty, operate on the root of the content JSON
         targetValue = gjson.Parse(contentJSON)
+	} else if base, isCount := countedPath(cond.Path); isCount && !isPlainText {
+		// "tags.#" compares the number of elements (or keys) of the value at "tags"
+		var err error
+		if targetValue, err = countAtPath(contentJSON, base); err != nil {
+			return false, err
+		}
 	} else {
 		targetValue = gjson.Get(contentJSON, cond.Path)
 		      // If path doesn't exist, it's an error (to match test Path_non-existent:_error)
 		      if !targetValue.Exists() && !isPlainText { // Don't error if plain text (path is irrelevant)
-		          return false, fmt.Errorf("path '%s' does not exist in document content", cond.Path)
+		          return false, missingPathError{cond.Path}
 		      }
 	}
 
@@ -319,6 +440,40 @@ func (db *Database) evaluateSingleCondition(doc models.Document, cond QueryCondi
 	return compareJSONValue(targetValue, cond)
 }
 
+// countedPath reports whether path asks for a length, i.e. is "#" or ends in ".#", and returns
+// the path of the array or object to count ("" for the content itself).
+func countedPath(path string) (string, bool) {
+	if path == "#" {
+		return "", true
+	}
+	base, isCount := strings.CutSuffix(path, ".#")
+	return base, isCount && base != ""
+}
+
+// countAtPath returns the number of elements of the array, or keys of the object, at path
+// (the content itself when path is empty) as a number to compare against.
+func countAtPath(contentJSON, path string) (gjson.Result, error) {
+	container := gjson.Parse(contentJSON)
+	if path != "" {
+		container = gjson.Get(contentJSON, path)
+	}
+	if !container.Exists() {
+		return gjson.Result{}, missingPathError{path}
+	}
+	if !container.IsArray() && !container.IsObject() {
+		if path == "" {
+			return gjson.Result{}, fmt.Errorf("content is not an array or object, so '#' has no length")
+		}
+		return gjson.Result{}, fmt.Errorf("path '%s' is not an array or object, so '%s.#' has no length", path, path)
+	}
+	count := 0
+	container.ForEach(func(_, _ gjson.Result) bool {
+		count++
+		return true
+	})
+	return gjson.Result{Type: gjson.Number, Raw: strconv.Itoa(count), Num: float64(count)}, nil
+}
+
 // isValidForPlainText checks if an operator is allowed for non-JSON string content.
 func isValidForPlainText(operator string, isInsensitive bool) bool {
     opKey := operator
@@ -347,6 +502,7 @@ func comparePlainText(textContent string, cond QueryCondition) (bool, error) {
 		return false, fmt.Errorf("internal error: expected string value for plain text comparison, got %T", cond.ParsedValue)
 	}
 
+	textContent = utils.NormalizeText(textContent) // Content stored before it was normalized on save
 	op := cond.Operator
 	if cond.IsInsensitive {
 		op += "-insensitive" // Reconstruct full operator for switch
@@ -411,7 +567,7 @@ func compareJSONValue(targetValue gjson.Result, cond QueryCondition) (bool, erro
 				// Element is String. Match ONLY if condValType is String AND strings match.
 				if condValType == gjson.String {
 					condStr := parsedVal.(string) // Assert type
-					elementStr := value.String()
+					elementStr := utils.NormalizeText(value.String())
 					if cond.IsInsensitive {
 						elementMatches = strings.EqualFold(elementStr, condStr)
 					} else {
@@ -483,7 +639,7 @@ func compareJSONValue(targetValue gjson.Result, cond QueryCondition) (bool, erro
 	// Handle different target types
 	switch targetType {
 	case gjson.String:
-		targetStr := targetValue.String()
+		targetStr := utils.NormalizeText(targetValue.String()) // Content stored before it was normalized on save
 		// Check if operator is valid for String
 		switch op {
 		case "equals", "notequals", "contains", "startswith", "endswith":
@@ -620,8 +776,14 @@ func compareJSONValue(targetValue gjson.Result, cond QueryCondition) (bool, erro
 // QueryDocumentsParams holds all parameters for querying documents.
 type QueryDocumentsParams struct {
 	AuthUserID    string   // ID of the authenticated user (for scope filtering)
-	Scope         string   // "owned", "shared", "all" (default)
+	Scope         string   // "owned", "shared", "all" (default), "public" (AuthUserID may be empty), "any" (every document; admins only)
+	IsAdmin       bool     // Whether AuthUserID is an administrator, which scope "any" requires
+	OwnerID       string   // Only documents owned by this profile ("" = any owner)
+	FavoritesOnly bool     // Only documents AuthUserID has bookmarked
+	WorkflowState string   // Only documents in this workflow state ("" = any; documents never submitted are drafts)
+	Expired       string   // How expired documents are treated: one of the Expired* modes ("" = ExpiredExclude)
 	ContentQuery  []string // Raw content query parts
+	Missing       string   // How conditions on missing paths are treated: one of the Missing* modes ("" = MissingSkip)
 	SortBy        string   // "creation_date", "last_modified_date" (default)
 	Order         string   // "asc", "desc" (default)
 	Page          int      // 1-based page number
@@ -630,23 +792,121 @@ type QueryDocumentsParams struct {
 
 // QueryDocuments performs filtering, sorting, and pagination on documents.
 func (db *Database) QueryDocuments(params QueryDocumentsParams) ([]models.Document, int, error) {
+	return db.QueryDocumentsContext(context.Background(), params)
+}
+
+// QueryDocumentsContext is QueryDocuments, traced as a child of the span in ctx. The documents,
+// shares and favorites it looks at are read from one view of the database (see View).
+func (db *Database) QueryDocumentsContext(ctx context.Context, params QueryDocumentsParams) (docs []models.Document, total int, err error) {
+	err = db.View(func(v *ReadView) error {
+		docs, total, err = v.QueryDocumentsContext(ctx, params)
+		return err
+	})
+	return docs, total, err
+}
+
+// QueryDocumentsContext is QueryDocuments, traced as a child of the span in ctx. With
+// -query-cache-size, a query asked again before the data changes is answered from the cache.
+func (v *ReadView) QueryDocumentsContext(ctx context.Context, params QueryDocumentsParams) (docs []models.Document, total int, err error) {
+	db := v.db
+	capacity := db.config.QueryCacheSize
+	if capacity <= 0 {
+		return v.queryDocuments(ctx, params)
+	}
+
+	key := queryCacheKey(params)
+	generation := db.queryGeneration.Load()
+	now := db.now()
+	if docs, total, hit := db.queryCache.get(key, generation, now); hit {
+		db.documentQueries.Add(1)
+		if len(params.ContentQuery) > 0 {
+			db.contentQueries.Add(1)
+		}
+		return docs, total, nil
+	}
+	if docs, total, err = v.queryDocuments(ctx, params); err != nil {
+		return nil, 0, err
+	}
+	db.queryCache.put(capacity, &queryCacheEntry{
+		key:        key,
+		generation: generation,
+		validUntil: nextDocumentExpiry(db.Database.Documents, now),
+		docs:       docs,
+		total:      total,
+	})
+	return docs, total, nil
+}
+
+// queryDocuments runs a document query.
+func (v *ReadView) queryDocuments(ctx context.Context, params QueryDocumentsParams) (docs []models.Document, total int, err error) {
+	db := v.db
+	started := time.Now()
+	ctx, span := tracing.Start(ctx, "db.QueryDocuments")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+	span.SetAttributes(attribute.String("query.scope", params.Scope), attribute.Int("query.parts", len(params.ContentQuery)))
+
 	// 1. Parse Content Query
 	parsedQuery, err := ParseContentQuery(params.ContentQuery)
 	if err != nil {
-		return nil, 0, fmt.Errorf("invalid content_query: %w", err)
+		return nil, 0, apperr.Wrap(apperr.ErrValidation, i18n.NewError(i18n.MsgQueryInvalid, err))
+	}
+	switch params.Missing {
+	case "", MissingSkip, MissingFalse, MissingError:
+	default:
+		return nil, 0, apperr.Wrap(apperr.ErrValidation, i18n.NewError(i18n.MsgQueryInvalidMissing, params.Missing))
+	}
+	if parsedQuery != nil {
+		parsedQuery.Missing = params.Missing
+	}
+	db.documentQueries.Add(1)
+	if parsedQuery != nil {
+		db.contentQueries.Add(1)
+	}
+
+	if params.WorkflowState != "" && !IsWorkflowState(params.WorkflowState) {
+		return nil, 0, apperr.Wrap(apperr.ErrValidation, i18n.NewError(i18n.MsgQueryInvalidWorkflowState, params.WorkflowState))
+	}
+
+	switch params.Expired {
+	case "", ExpiredExclude, ExpiredInclude, ExpiredOnly:
+	default:
+		return nil, 0, apperr.Wrap(apperr.ErrValidation, i18n.NewError(i18n.MsgQueryInvalidExpired, params.Expired))
+	}
+	now := db.now()
+
+	scope := strings.ToLower(params.Scope)
+	if scope == "any" && !params.IsAdmin {
+		return nil, 0, apperr.Wrap(apperr.ErrForbidden, i18n.NewError(i18n.MsgQueryScopeAdminOnly))
 	}
 
 	// 2. Get Initial Set (All documents for now, optimize later if needed)
-	allDocs := db.GetAllDocuments() // Needs RLock internally
+	allDocs := make([]models.Document, 0, len(db.Database.Documents))
+	for _, doc := range db.Database.Documents {
+		allDocs = append(allDocs, doc)
+	}
+	deactivated := db.deactivatedProfileIDs() // Their documents are hidden from everyone else
+	var favorites map[string]bool
+	if params.FavoritesOnly {
+		favorites = v.GetFavorites(params.AuthUserID)
+	}
 
 	// 3. Filter by Scope and Content Query
+	_, evalSpan := tracing.Start(ctx, "db.EvaluateQuery")
+	evaluated := 0 // Documents the content query was run against
 	filteredDocs := make([]models.Document, 0)
 	for _, doc := range allDocs {
+		if params.OwnerID != "" && doc.OwnerID != params.OwnerID {
+			continue
+		}
+
 		// Check scope first
 		isOwned := doc.OwnerID == params.AuthUserID
 		isShared := false
+		var shareRecord models.ShareRecord
 		if !isOwned { // Only check shares if not owned
-			shareRecord, found := db.GetShareRecordByDocumentID(doc.ID) // Needs RLock internally
+			var found bool
+			shareRecord, found = v.GetShareRecordByDocumentID(doc.ID)
 			if found {
 				for _, sharedID := range shareRecord.SharedWith {
 					if sharedID == params.AuthUserID {
@@ -658,24 +918,53 @@ func (db *Database) QueryDocuments(params QueryDocumentsParams) ([]models.Docume
 		}
 
 		scopeMatch := false
-		switch strings.ToLower(params.Scope) {
+		switch scope {
 		case "owned":
 			scopeMatch = isOwned
 		case "shared":
 			scopeMatch = isShared
 		case "all", "": // Default to all
 			scopeMatch = isOwned || isShared
+		case "public":
+			scopeMatch = doc.Public
+		case "any": // Administrators only, checked above
+			scopeMatch = true
 		default:
-			return nil, 0, fmt.Errorf("invalid scope value: '%s', expected 'owned', 'shared', or 'all'", params.Scope)
+			evalSpan.End()
+			return nil, 0, apperr.Wrap(apperr.ErrValidation, i18n.NewError(i18n.MsgQueryInvalidScope, params.Scope))
 		}
 
 		if !scopeMatch {
 			continue // Skip doc if scope doesn't match
 		}
+		if !isOwned && scope != "any" && deactivated[doc.OwnerID] {
+			continue
+		}
+		if isShared && scope != "any" {
+			// Sharers limited to part of the content see, match and sort on that part only
+			if shareScope, limited := ShareScopeOf(doc, shareRecord, params.AuthUserID); limited {
+				doc.Content = ScopedContent(doc.Content, shareScope)
+			}
+		}
+		if params.FavoritesOnly && !favorites[doc.ID] {
+			continue
+		}
+		if params.WorkflowState != "" && documentWorkflowState(doc) != params.WorkflowState {
+			continue
+		}
+		if params.Expired != ExpiredInclude && documentExpired(doc, now) != (params.Expired == ExpiredOnly) {
+			continue // Expired documents are only listed on request
+		}
 
 		// Check content query if applicable
 		if parsedQuery != nil {
+			evaluated++
 			contentMatch, err := db.EvaluateContentQuery(doc, parsedQuery)
+			var missingPath missingPathError
+			if params.Missing == MissingError && errors.As(err, &missingPath) {
+				evalSpan.End()
+				return nil, 0, apperr.Wrap(apperr.ErrValidation, i18n.NewError(i18n.MsgQueryMissingPath, doc.ID, missingPath.path))
+			}
 			if err != nil {
 				// Log error if evaluation fails for a document, but continue processing others.
 				// Do not return an error from QueryDocuments itself unless query parsing failed.
@@ -692,6 +981,24 @@ func (db *Database) QueryDocuments(params QueryDocumentsParams) ([]models.Docume
 	}
 
     totalMatching := len(filteredDocs) // Total count before pagination
+	evalSpan.SetAttributes(
+		attribute.Int("documents.scanned", len(allDocs)),
+		attribute.Int("documents.evaluated", evaluated),
+		attribute.Int("documents.matched", totalMatching),
+	)
+	evalSpan.End()
+	span.SetAttributes(attribute.Int("documents.matched", totalMatching))
+	if parsedQuery != nil {
+		db.recordSlowQuery(SlowQuery{
+			CallerID:           params.AuthUserID,
+			Scope:              params.Scope,
+			ContentQuery:       params.ContentQuery,
+			ParsedQuery:        parsedQuery.String(),
+			DocumentsScanned:   len(allDocs),
+			DocumentsEvaluated: evaluated,
+			DocumentsMatched:   totalMatching,
+		}, time.Since(started))
+	}
 
 	// 4. Sort
     err = sortDocuments(filteredDocs, params.SortBy, params.Order)
@@ -715,11 +1022,18 @@ func sortDocuments(docs []models.Document, sortBy, order string) error {
     lessFunc := func(i, j int) bool {
         docI := docs[i]
         docJ := docs[j]
+        // Documents with the same date are ordered by ID, so pages of one view never overlap
         switch strings.ToLower(sortBy) {
         case "last_modified_date":
-            return docI.LastModifiedDate.Before(docJ.LastModifiedDate)
+            if !docI.LastModifiedDate.Equal(docJ.LastModifiedDate) {
+                return docI.LastModifiedDate.Before(docJ.LastModifiedDate)
+            }
+            return docI.ID < docJ.ID
         case "creation_date", "": // Default to creation_date
-            return docI.CreationDate.Before(docJ.CreationDate)
+            if !docI.CreationDate.Equal(docJ.CreationDate) {
+                return docI.CreationDate.Before(docJ.CreationDate)
+            }
+            return docI.ID < docJ.ID
         default:
             // Return error from the outer function
             return false // Value doesn't matter here
@@ -734,7 +1048,7 @@ func sortDocuments(docs []models.Document, sortBy, order string) error {
             return originalLess(j, i)
         }
     } else if strings.ToLower(order) != "asc" && order != "" {
-         return fmt.Errorf("invalid order value: '%s', expected 'asc' or 'desc'", order)
+         return apperr.Wrap(apperr.ErrValidation, i18n.NewError(i18n.MsgQueryInvalidOrder, order))
     }
 
 
@@ -743,7 +1057,7 @@ func sortDocuments(docs []models.Document, sortBy, order string) error {
         case "last_modified_date", "creation_date", "":
              // Valid cases
         default:
-             return fmt.Errorf("invalid sort_by value: '%s', expected 'creation_date' or 'last_modified_date'", sortBy)
+             return apperr.Wrap(apperr.ErrValidation, i18n.NewError(i18n.MsgQueryInvalidSortBy, sortBy))
     }
 
 
@@ -753,7 +1067,9 @@ func sortDocuments(docs []models.Document, sortBy, order string) error {
 
 // --- Pagination Helper ---
 const defaultLimit = 20
-const maxLimit = 100
+
+// MaxLimit is the largest page of documents a query returns; larger limits are capped.
+const MaxLimit = 100
 
 func paginateDocuments(docs []models.Document, page, limit int) ([]models.Document, error) {
     if page <= 0 {
@@ -762,8 +1078,8 @@ func paginateDocuments(docs []models.Document, page, limit int) ([]models.Docume
     if limit <= 0 {
         limit = defaultLimit
     }
-    if limit > maxLimit {
-        limit = maxLimit
+    if limit > MaxLimit {
+        limit = MaxLimit
     }
 
     startIndex := (page - 1) * limit
@@ -0,0 +1,277 @@
+package db
+
+import (
+	"docserver/i18n"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// --- Query Builder Support ---
+
+// QueryOperator describes an operator of the content_query syntax, for building queries.
+type QueryOperator struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	ValueTypes  []string `json:"value_types"`  // JSON types of the values it takes: string, number, boolean, null
+	TargetTypes []string `json:"target_types"` // JSON types of the content it applies to; other types fail the query
+	Insensitive bool     `json:"insensitive"`  // Has a case-insensitive variant, named with an "-insensitive" suffix
+	PlainText   bool     `json:"plain_text"`   // Applies to plain text documents (Markdown, text, CSV)
+	Example     string   `json:"example"`
+}
+
+// QueryOperators lists the operators of content_query, as evaluated by compareJSONValue and
+// comparePlainText.
+var QueryOperators = []QueryOperator{
+	{Name: "equals", Description: "The value equals the given one. Objects at the root never match.",
+		ValueTypes: []string{"string", "number", "boolean", "null"}, TargetTypes: []string{"string", "number", "boolean", "null"},
+		Insensitive: true, PlainText: true, Example: `status equals "active"`},
+	{Name: "notequals", Description: "The value differs from the given one, including values of another type.",
+		ValueTypes: []string{"string", "number", "boolean", "null"}, TargetTypes: []string{"string", "number", "boolean", "null"},
+		Insensitive: true, PlainText: true, Example: `status notequals "archived"`},
+	{Name: "greaterthan", Description: "The number is greater than the given one.",
+		ValueTypes: []string{"number"}, TargetTypes: []string{"number"}, Example: "priority greaterthan 3"},
+	{Name: "lessthan", Description: "The number is less than the given one.",
+		ValueTypes: []string{"number"}, TargetTypes: []string{"number"}, Example: "priority lessthan 3"},
+	{Name: "greaterthanorequals", Description: "The number is greater than or equal to the given one.",
+		ValueTypes: []string{"number"}, TargetTypes: []string{"number"}, Example: "priority greaterthanorequals 5"},
+	{Name: "lessthanorequals", Description: "The number is less than or equal to the given one.",
+		ValueTypes: []string{"number"}, TargetTypes: []string{"number"}, Example: "priority lessthanorequals 5"},
+	{Name: "contains", Description: "The string contains the given text, or the array has an element equal to the given value.",
+		ValueTypes: []string{"string", "number", "boolean", "null"}, TargetTypes: []string{"string", "array"},
+		Insensitive: true, PlainText: true, Example: `tags contains "urgent"`},
+	{Name: "startswith", Description: "The string starts with the given text.",
+		ValueTypes: []string{"string"}, TargetTypes: []string{"string"}, Insensitive: true, PlainText: true, Example: `name startswith "Al"`},
+	{Name: "endswith", Description: "The string ends with the given text.",
+		ValueTypes: []string{"string"}, TargetTypes: []string{"string"}, Insensitive: true, PlainText: true, Example: `email endswith "@example.com"`},
+}
+
+// queryOperatorAliases maps operators of other query languages to those of content_query, to
+// suggest a fix for them.
+var queryOperatorAliases = map[string]string{
+	"eq": "equals", "=": "equals", "==": "equals", "is": "equals",
+	"ne": "notequals", "neq": "notequals", "!=": "notequals", "<>": "notequals",
+	"gt": "greaterthan", ">": "greaterthan",
+	"lt": "lessthan", "<": "lessthan",
+	"gte": "greaterthanorequals", "ge": "greaterthanorequals", ">=": "greaterthanorequals",
+	"lte": "lessthanorequals", "le": "lessthanorequals", "<=": "lessthanorequals",
+	"has": "contains", "includes": "contains", "like": "contains",
+}
+
+// queryLogicAliases maps logical operators of other languages to "and" and "or".
+var queryLogicAliases = map[string]LogicalOperator{"&&": LogicAnd, "&": LogicAnd, "||": LogicOr, "|": LogicOr}
+
+// Severities of a QueryDiagnostic.
+const (
+	QueryDiagnosticError   = "error"   // The query is rejected, or fails on every document with content of the right type
+	QueryDiagnosticWarning = "warning" // The query works, perhaps not as meant
+)
+
+// QueryDiagnostic is a problem ValidateContentQuery found in a query.
+type QueryDiagnostic struct {
+	Index      int    `json:"index"` // The content_query part it is about; -1 for the whole query
+	Severity   string `json:"severity"`
+	Code       string `json:"code"` // Stable message ID (see i18n)
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion,omitempty"` // A replacement for the part, when one is likely meant
+}
+
+// QueryValidation is the result of ValidateContentQuery.
+type QueryValidation struct {
+	Valid       bool              `json:"valid"`           // Whether the query has no errors
+	Query       string            `json:"query,omitempty"` // The query as it is understood, when valid (see ParsedQuery.String)
+	Diagnostics []QueryDiagnostic `json:"diagnostics"`
+}
+
+// ValidateContentQuery checks the parts of a content_query as ParseContentQuery does, but reports
+// every problem rather than the first, and checks the values against the operators' types.
+// Messages are in lang.
+func ValidateContentQuery(parts []string, lang string) QueryValidation {
+	result := QueryValidation{Diagnostics: []QueryDiagnostic{}}
+	report := func(index int, severity string, err *i18n.Error, suggestion string) {
+		result.Diagnostics = append(result.Diagnostics, QueryDiagnostic{
+			Index: index, Severity: severity, Code: err.ID, Message: err.Localize(lang), Suggestion: suggestion,
+		})
+	}
+
+	if len(parts) == 0 {
+		report(-1, QueryDiagnosticWarning, i18n.NewError(i18n.MsgQueryEmpty), "")
+	}
+	var firstLogic LogicalOperator
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			report(i, QueryDiagnosticError, i18n.NewError(i18n.MsgQueryPartEmpty, i), "")
+			continue
+		}
+
+		if i%2 == 1 {
+			logic := LogicalOperator(strings.ToLower(part))
+			if alias, found := queryLogicAliases[part]; found {
+				report(i, QueryDiagnosticError, i18n.NewError(i18n.MsgQueryInvalidLogic, i, part), string(alias))
+				continue
+			}
+			if logic != LogicAnd && logic != LogicOr {
+				report(i, QueryDiagnosticError, i18n.NewError(i18n.MsgQueryInvalidLogic, i, part), "")
+				continue
+			}
+			if firstLogic == "" {
+				firstLogic = logic
+			} else if logic != firstLogic {
+				report(i, QueryDiagnosticWarning, i18n.NewError(i18n.MsgQueryMixedLogic), "")
+				firstLogic = logic // Report each switch once
+			}
+			continue
+		}
+
+		cond, err := parseSingleCondition(part)
+		if err != nil {
+			var msgErr *i18n.Error
+			if !errors.As(err, &msgErr) {
+				msgErr = i18n.NewError(i18n.MsgQueryInvalid, err)
+			}
+			report(i, QueryDiagnosticError, msgErr, conditionSuggestion(part, msgErr))
+			continue
+		}
+		checkConditionTypes(i, part, cond, report)
+	}
+	if len(parts)%2 == 0 && len(parts) > 0 {
+		report(len(parts)-1, QueryDiagnosticError, i18n.NewError(i18n.MsgQueryTrailingLogic), "")
+	}
+
+	result.Valid = true
+	for _, diagnostic := range result.Diagnostics {
+		if diagnostic.Severity == QueryDiagnosticError {
+			result.Valid = false
+		}
+	}
+	if result.Valid {
+		if query, err := ParseContentQuery(parts); err == nil {
+			result.Query = query.String()
+		}
+	}
+	return result
+}
+
+// checkConditionTypes reports a condition whose value does not suit its operator, as well as
+// string values left unquoted.
+func checkConditionTypes(index int, part string, cond QueryCondition, report func(int, string, *i18n.Error, string)) {
+	operator := queryOperator(cond.Operator)
+	valueType := queryValueType(cond.ValueType)
+	name := cond.Operator
+	allowed := operator.ValueTypes
+	if cond.IsInsensitive {
+		name += "-insensitive"
+		allowed = []string{"string"}
+	}
+
+	if _, isCount := countedPath(cond.Path); isCount && (valueType != "number" || !slices.Contains(operator.TargetTypes, "number")) {
+		report(index, QueryDiagnosticError, i18n.NewError(i18n.MsgQueryLengthValue, cond.Path), "")
+		return
+	}
+	if !slices.Contains(allowed, valueType) {
+		report(index, QueryDiagnosticError, i18n.NewError(i18n.MsgQueryValueType, name, strings.Join(allowed, " or "), queryValueString(cond), valueType), "")
+		return
+	}
+	if valueType == "string" && !strings.HasSuffix(part, `"`) {
+		quoted := ParsedQuery{Conditions: []QueryCondition{cond}}
+		report(index, QueryDiagnosticWarning, i18n.NewError(i18n.MsgQueryUnquotedString, cond.ParsedValue), quoted.String())
+	}
+}
+
+// conditionSuggestion returns a likely fix for a condition ParseContentQuery rejected with err,
+// replacing an operator of another query language (e.g. "gt") or one misspelled, or "".
+func conditionSuggestion(part string, err *i18n.Error) string {
+	if err.ID != i18n.MsgQueryInvalidOperator || len(err.Args) == 0 {
+		return ""
+	}
+	operator, _ := err.Args[0].(string)
+	replacement, found := queryOperatorAliases[operator]
+	if !found {
+		replacement = closestQueryOperator(operator)
+	}
+	if replacement == "" {
+		return ""
+	}
+	// The operator is the first or second field, or after a "not" the third
+	offset := 0
+	for i, field := range strings.Fields(part) {
+		start := offset + strings.Index(part[offset:], field)
+		if strings.EqualFold(field, operator) {
+			return part[:start] + replacement + part[start+len(field):]
+		}
+		if i == 2 {
+			break
+		}
+		offset = start + len(field)
+	}
+	return ""
+}
+
+// closestQueryOperator returns the operator (with its suffix, if any) nearest to a misspelled
+// one, or "" if none is close enough to be what was meant.
+func closestQueryOperator(operator string) string {
+	best, bestDistance := "", 3 // At most two edits
+	for name := range validOperators {
+		if distance := editDistance(operator, name); distance < bestDistance || distance == bestDistance && name < best {
+			best, bestDistance = name, distance
+		}
+	}
+	if bestDistance >= 3 {
+		return ""
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current := make([]int, len(rb)+1)
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(rb)]
+}
+
+// queryOperator returns the description of a base operator.
+func queryOperator(name string) QueryOperator {
+	for _, operator := range QueryOperators {
+		if operator.Name == name {
+			return operator
+		}
+	}
+	return QueryOperator{Name: name}
+}
+
+// queryValueType returns the JSON type name of a parsed condition value.
+func queryValueType(t gjson.Type) string {
+	switch t {
+	case gjson.Number:
+		return "number"
+	case gjson.True, gjson.False:
+		return "boolean"
+	case gjson.Null:
+		return "null"
+	}
+	return "string"
+}
+
+// queryValueString renders a condition's value as it appears in a query.
+func queryValueString(cond QueryCondition) string {
+	rendered := ParsedQuery{Conditions: []QueryCondition{{Operator: "equals", ParsedValue: cond.ParsedValue}}}
+	return strings.TrimPrefix(rendered.String(), "equals ")
+}
@@ -0,0 +1,124 @@
+package db
+
+import (
+	"docserver/i18n"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryOperators(t *testing.T) {
+	// Every operator the parser accepts is described, with its variants
+	described := make(map[string]bool)
+	for _, operator := range QueryOperators {
+		described[operator.Name] = true
+		if operator.Insensitive {
+			described[operator.Name+"-insensitive"] = true
+		}
+		assert.Equal(t, operator.PlainText, isValidForPlainText(operator.Name, false), operator.Name)
+
+		condition, err := parseSingleCondition(operator.Example)
+		require.NoError(t, err, operator.Example)
+		assert.Equal(t, operator.Name, condition.Operator, "the example uses the operator")
+	}
+	for name := range validOperators {
+		assert.True(t, described[name], name)
+	}
+	assert.Len(t, described, len(validOperators))
+}
+
+func TestValidateContentQuery(t *testing.T) {
+	type diagnostic struct {
+		Index      int
+		Severity   string
+		Code       string
+		Suggestion string
+	}
+	validate := func(parts ...string) (QueryValidation, []diagnostic) {
+		result := ValidateContentQuery(parts, "en")
+		var diagnostics []diagnostic
+		for _, d := range result.Diagnostics {
+			assert.NotEmpty(t, d.Message)
+			diagnostics = append(diagnostics, diagnostic{d.Index, d.Severity, d.Code, d.Suggestion})
+		}
+		return result, diagnostics
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		result, diagnostics := validate(`status equals "active"`, "AND", "tags.# greaterthan 1", "and", `not name startswith-insensitive "al"`)
+		assert.True(t, result.Valid)
+		assert.Empty(t, diagnostics)
+		assert.Equal(t, `status equals "active" and tags.# greaterthan 1 and not name startswith-insensitive "al"`, result.Query)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		result, diagnostics := validate()
+		assert.True(t, result.Valid)
+		assert.Equal(t, []diagnostic{{-1, QueryDiagnosticWarning, i18n.MsgQueryEmpty, ""}}, diagnostics)
+	})
+
+	t.Run("Every syntax error is reported", func(t *testing.T) {
+		result, diagnostics := validate(`priority gt 3`, "&&", `status equal "x"`, "xor", "  ", "or")
+		assert.False(t, result.Valid)
+		assert.Empty(t, result.Query)
+		assert.Equal(t, []diagnostic{
+			{0, QueryDiagnosticError, i18n.MsgQueryInvalidOperator, "priority greaterthan 3"},
+			{1, QueryDiagnosticError, i18n.MsgQueryInvalidLogic, "and"},
+			{2, QueryDiagnosticError, i18n.MsgQueryInvalidOperator, `status equals "x"`},
+			{3, QueryDiagnosticError, i18n.MsgQueryInvalidLogic, ""},
+			{4, QueryDiagnosticError, i18n.MsgQueryPartEmpty, ""},
+			{5, QueryDiagnosticError, i18n.MsgQueryTrailingLogic, ""},
+		}, diagnostics)
+	})
+
+	t.Run("Suggestions keep the rest of the condition", func(t *testing.T) {
+		_, diagnostics := validate(`not  title  >=  "a  b"`)
+		assert.Equal(t, []diagnostic{{0, QueryDiagnosticError, i18n.MsgQueryInvalidOperator, `not  title  greaterthanorequals  "a  b"`}}, diagnostics)
+		_, diagnostics = validate(`title frobnicate "a"`)
+		assert.Equal(t, []diagnostic{{0, QueryDiagnosticError, i18n.MsgQueryInvalidOperator, ""}}, diagnostics, "nothing close")
+		_, diagnostics = validate(`status "active"`)
+		assert.Equal(t, []diagnostic{{0, QueryDiagnosticError, i18n.MsgQueryInvalidFormat, ""}}, diagnostics)
+	})
+
+	t.Run("Types", func(t *testing.T) {
+		result, diagnostics := validate(`priority greaterthan "high"`, "or", "name startswith 5", "or", "done equals-insensitive true", "or", "tags contains 3")
+		assert.False(t, result.Valid)
+		assert.Equal(t, []diagnostic{
+			{0, QueryDiagnosticError, i18n.MsgQueryValueType, ""},
+			{2, QueryDiagnosticError, i18n.MsgQueryValueType, ""},
+			{4, QueryDiagnosticError, i18n.MsgQueryValueType, ""},
+		}, diagnostics, "contains takes any value, for arrays")
+		assert.Equal(t, `operator 'greaterthan' needs a number value, but "high" is a string`, result.Diagnostics[0].Message)
+		assert.Equal(t, `operator 'equals-insensitive' needs a string value, but true is a boolean`, result.Diagnostics[2].Message)
+
+		_, diagnostics = validate("tags.# contains 1", "and", `tags.# equals "2"`, "and", "# lessthan 3")
+		assert.Equal(t, []diagnostic{
+			{0, QueryDiagnosticError, i18n.MsgQueryLengthValue, ""},
+			{2, QueryDiagnosticError, i18n.MsgQueryLengthValue, ""},
+		}, diagnostics)
+	})
+
+	t.Run("Warnings", func(t *testing.T) {
+		result, diagnostics := validate("status equals active", "and", "a equals 1", "or", "b equals 2", "or", "c equals 3", "and", "d equals 4")
+		assert.True(t, result.Valid, "warnings leave the query valid")
+		assert.Equal(t, []diagnostic{
+			{0, QueryDiagnosticWarning, i18n.MsgQueryUnquotedString, `status equals "active"`},
+			{3, QueryDiagnosticWarning, i18n.MsgQueryMixedLogic, ""},
+			{7, QueryDiagnosticWarning, i18n.MsgQueryMixedLogic, ""},
+		}, diagnostics)
+	})
+
+	t.Run("Localized", func(t *testing.T) {
+		result := ValidateContentQuery([]string{"priority lessthan true"}, "es")
+		require.Len(t, result.Diagnostics, 1)
+		assert.Equal(t, "el operador 'lessthan' necesita un valor de tipo number, pero true es de tipo boolean", result.Diagnostics[0].Message)
+	})
+}
+
+func TestClosestQueryOperator(t *testing.T) {
+	assert.Equal(t, "greaterthan", closestQueryOperator("greatherthan"))
+	assert.Equal(t, "contains-insensitive", closestQueryOperator("contains-insensitve"))
+	assert.Equal(t, "", closestQueryOperator("xyz"))
+	assert.Equal(t, 3, editDistance("kitten", "sitting"))
+}
@@ -0,0 +1,152 @@
+package db
+
+import (
+	"container/list"
+	"docserver/models"
+	"docserver/utils"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// --- Query Result Cache ---
+
+// QueryCacheStats tells how much the query cache is used.
+type QueryCacheStats struct {
+	Capacity int   `json:"capacity"` // Most results kept (0 = the cache is off)
+	Size     int   `json:"size"`     // Results kept now, stale ones included until they are looked up or dropped
+	Hits     int64 `json:"hits"`     // Queries answered from the cache since the server started
+	Misses   int64 `json:"misses"`   // Queries that had to be run, as nothing or only a stale result was cached
+}
+
+// queryCache keeps the latest document query results (see -query-cache-size), least recently used
+// dropped first. A result is only used while the data is as it was when the query ran: every
+// change bumps the database's query generation (see requestSave), which makes all cached results
+// stale. A change to one document can move it into or out of anyone's scope (sharing it, or
+// deactivating its owner), so no user's results survive it.
+type queryCache struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element // Values are *queryCacheEntry
+	order   list.List                // Most recently used first
+	hits    atomic.Int64
+	misses  atomic.Int64
+}
+
+// queryCacheEntry is one cached query result.
+type queryCacheEntry struct {
+	key        string
+	generation uint64     // Query generation the result was computed at
+	validUntil *time.Time // When the first document in the database expires, changing the result without a write (nil = none does)
+	docs       []models.Document
+	total      int
+}
+
+// get returns the result cached for key if it is still current at generation and now.
+func (c *queryCache) get(key string, generation uint64, now time.Time) ([]models.Document, int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, found := c.entries[key]
+	if !found {
+		c.misses.Add(1)
+		return nil, 0, false
+	}
+	entry := element.Value.(*queryCacheEntry)
+	if entry.generation != generation || (entry.validUntil != nil && !now.Before(*entry.validUntil)) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		c.misses.Add(1)
+		return nil, 0, false
+	}
+	c.order.MoveToFront(element)
+	c.hits.Add(1)
+	return slices.Clone(entry.docs), entry.total, true
+}
+
+// put caches a result, dropping the least recently used ones beyond capacity.
+func (c *queryCache) put(capacity int, entry *queryCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[string]*list.Element)
+	}
+	entry.docs = slices.Clone(entry.docs)
+	if element, found := c.entries[entry.key]; found {
+		current := element.Value.(*queryCacheEntry)
+		if current.generation > entry.generation {
+			return // A newer result was cached while this query ran
+		}
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[entry.key] = c.order.PushFront(entry)
+	for c.order.Len() > capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*queryCacheEntry).key)
+	}
+}
+
+// QueryCacheStats reports the size and hit rate of the query cache.
+func (db *Database) QueryCacheStats() QueryCacheStats {
+	db.queryCache.mu.Lock()
+	size := db.queryCache.order.Len()
+	db.queryCache.mu.Unlock()
+	return QueryCacheStats{
+		Capacity: db.config.QueryCacheSize,
+		Size:     size,
+		Hits:     db.queryCache.hits.Load(),
+		Misses:   db.queryCache.misses.Load(),
+	}
+}
+
+// queryCacheKey returns the cache key of a query: the user it is run for and its parameters,
+// normalized so that queries written differently but answered the same share a key.
+func queryCacheKey(params QueryDocumentsParams) string {
+	params.Scope = strings.ToLower(params.Scope)
+	if params.Scope == "" {
+		params.Scope = "all"
+	}
+	params.SortBy = strings.ToLower(params.SortBy)
+	params.Order = strings.ToLower(params.Order)
+	if params.Expired == "" {
+		params.Expired = ExpiredExclude
+	}
+	if params.Missing == "" {
+		params.Missing = MissingSkip
+	}
+	parts := make([]string, len(params.ContentQuery))
+	for i, part := range params.ContentQuery {
+		parts[i] = utils.NormalizeText(strings.TrimSpace(part))
+		if i%2 == 1 {
+			parts[i] = strings.ToLower(parts[i]) // "and" or "or"
+		}
+	}
+	params.ContentQuery = parts
+	if params.Page <= 0 {
+		params.Page = 1
+	}
+	if params.Limit <= 0 {
+		params.Limit = defaultLimit
+	}
+	params.Limit = min(params.Limit, MaxLimit)
+
+	key, _ := json.Marshal(params) // Strings, numbers and booleans only, so it cannot fail
+	return string(key)
+}
+
+// nextDocumentExpiry returns the earliest expiry after now among the documents, or nil if none expires.
+func nextDocumentExpiry(docs map[string]models.Document, now time.Time) *time.Time {
+	var next *time.Time
+	for _, doc := range docs {
+		if doc.ExpiresAt != nil && now.Before(*doc.ExpiresAt) && (next == nil || doc.ExpiresAt.Before(*next)) {
+			next = doc.ExpiresAt
+		}
+	}
+	return next
+}
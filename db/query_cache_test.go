@@ -0,0 +1,74 @@
+package db
+
+import (
+	"docserver/config"
+	"docserver/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryCache(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	db.config.QueryCacheSize = 2
+
+	doc, err := db.CreateDocument(models.Document{OwnerID: "ann", Content: map[string]any{"course": "CS101"}})
+	require.NoError(t, err)
+	query := QueryDocumentsParams{AuthUserID: "ann", ContentQuery: []string{`course equals "CS101"`}}
+
+	docs, total, err := db.QueryDocuments(query)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	// Written differently, but the same query
+	docs, _, err = db.QueryDocuments(QueryDocumentsParams{AuthUserID: "ann", Scope: "ALL", Page: 1, ContentQuery: []string{` course equals "CS101" `}})
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, QueryCacheStats{Capacity: 2, Size: 1, Hits: 1, Misses: 1}, db.QueryCacheStats())
+
+	t.Run("Other users have their own results", func(t *testing.T) {
+		_, total, err := db.QueryDocuments(QueryDocumentsParams{AuthUserID: "bo", ContentQuery: query.ContentQuery})
+		require.NoError(t, err)
+		assert.Zero(t, total)
+		assert.Equal(t, int64(2), db.QueryCacheStats().Misses)
+	})
+
+	t.Run("Changes make results stale", func(t *testing.T) {
+		require.NoError(t, db.SetShareRecord(doc.ID, []string{"bo"}))
+		_, total, err := db.QueryDocuments(QueryDocumentsParams{AuthUserID: "bo", ContentQuery: query.ContentQuery})
+		require.NoError(t, err)
+		assert.Equal(t, 1, total, "shared with bo since")
+
+		_, err = db.UpdateDocument(doc.ID, map[string]any{"course": "CS102"})
+		require.NoError(t, err)
+		_, total, err = db.QueryDocuments(query)
+		require.NoError(t, err)
+		assert.Zero(t, total)
+	})
+
+	t.Run("Least recently used results are dropped", func(t *testing.T) {
+		for _, owner := range []string{"ann", "bo", "cy"} {
+			_, _, err := db.QueryDocuments(QueryDocumentsParams{AuthUserID: owner})
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 2, db.QueryCacheStats().Size)
+	})
+
+	t.Run("Expiring documents make results stale", func(t *testing.T) {
+		clock := config.NewFixedClock(time.Date(2025, time.June, 1, 9, 0, 0, 0, time.UTC))
+		db.config.Clock = clock
+		expiresAt := clock.Now().Add(time.Hour)
+		_, err := db.SetDocumentExpiry(doc.ID, &expiresAt)
+		require.NoError(t, err)
+
+		_, total, err := db.QueryDocuments(QueryDocumentsParams{AuthUserID: "ann"})
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		clock.Set(expiresAt)
+		_, total, err = db.QueryDocuments(QueryDocumentsParams{AuthUserID: "ann"})
+		require.NoError(t, err)
+		assert.Zero(t, total)
+	})
+}
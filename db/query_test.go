@@ -1,16 +1,23 @@
 package db
 
 import (
+	"context"
+	"docserver/apperr"
 	"docserver/config" // Added
 	"docserver/models" // Added
+	"docserver/tracing"
 	"fmt" // Added
 	"path/filepath" // Added for t.TempDir()
+	"strings"
 	"testing"
 	"time" // Added
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/tidwall/gjson" // Added
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 // --- Parsing Tests ---
@@ -116,6 +123,33 @@ func TestParseSingleCondition(t *testing.T) {
 			expectErr:   true,
 			errContains: "invalid base operator for insensitive matching 'greaterthan'", // Base op is invalid for -insensitive
 		},
+		{
+			name:  "Valid: not prefix",
+			input: `NOT tags contains "archived"`,
+			expected: QueryCondition{
+				Path: "tags", Operator: "contains", ParsedValue: "archived", ValueType: gjson.String, Negated: true, Original: `NOT tags contains "archived"`,
+			},
+		},
+		{
+			name:  "Valid: not prefix (root path)",
+			input: `not equals 5`,
+			expected: QueryCondition{
+				Path: "", Operator: "equals", ParsedValue: float64(5), ValueType: gjson.Number, Negated: true, Original: `not equals 5`,
+			},
+		},
+		{
+			name:  "Valid: double negation",
+			input: `not not age equals 5`,
+			expected: QueryCondition{
+				Path: "age", Operator: "equals", ParsedValue: float64(5), ValueType: gjson.Number, Negated: false, Original: `not not age equals 5`,
+			},
+		},
+		{
+			name:        "Invalid: not prefix without condition",
+			input:       `not age`,
+			expectErr:   true,
+			errContains: "at least an operator and a value",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -279,8 +313,7 @@ func init() {
 			Documents:    make(map[string]models.Document),
 			ShareRecords: make(map[string]models.ShareRecord),
 		},
-		config:   cfg,
-		otpStore: make(map[string]otpRecord),
+		config: cfg,
 	}
 }
 
@@ -374,6 +407,19 @@ func TestEvaluateSingleCondition(t *testing.T) {
 		// --- Object Comparisons ---
 		{name: "Object invalid op: error", docContent: `{"user": {"name": "X"}}`, condition: `user equals {}`, expectErr: true, errContains: "operator 'equals' cannot directly compare JSON objects"},
 
+		// --- Lengths ---
+		{name: "Array length greaterThan: match", docContent: `{"tags": ["a", "b", "c", "d"]}`, condition: `tags.# greaterThan 3`, expectMatch: true},
+		{name: "Array length greaterThan: no match", docContent: `{"tags": ["a", "b", "c"]}`, condition: `tags.# greaterThan 3`, expectMatch: false},
+		{name: "Empty array length equals 0: match", docContent: `{"assignees": []}`, condition: `assignees.# equals 0`, expectMatch: true},
+		{name: "Nested array length: match", docContent: `{"task": {"assignees": ["x", "y"]}}`, condition: `task.assignees.# lessThanOrEquals 2`, expectMatch: true},
+		{name: "Object key count: match", docContent: `{"meta": {"a": 1, "b": 2}}`, condition: `meta.# equals 2`, expectMatch: true},
+		{name: "Empty object key count: match", docContent: `{"meta": {}}`, condition: `meta.# equals 0`, expectMatch: true},
+		{name: "Root object key count: match", docContent: `{"a": 1, "b": 2, "c": 3}`, condition: `# greaterThanOrEquals 3`, expectMatch: true},
+		{name: "Root array length: match", docContent: `[1, 2]`, condition: `# equals 2`, expectMatch: true},
+		{name: "Length string op: error", docContent: `{"tags": ["a"]}`, condition: `tags.# startsWith "1"`, expectErr: true, errContains: "cannot apply string operator"},
+		{name: "Length of scalar: error", docContent: `{"name": "test"}`, condition: `name.# equals 4`, expectErr: true, errContains: "path 'name' is not an array or object"},
+		{name: "Length of non-existent path: error", docContent: `{"name": "test"}`, condition: `tags.# equals 0`, expectErr: true, errContains: "path 'tags' does not exist"},
+
 		// --- Path Issues ---
 		{name: "Path non-existent: error", docContent: `{"name": "test"}`, condition: `address.street equals "Main"`, expectErr: true, errContains: "path 'address.street' does not exist"},
 		{name: "Path non-existent (root): ok", docContent: `{}`, condition: `equals "test"`, expectMatch: false}, // Root exists but is empty object
@@ -441,6 +487,12 @@ func TestEvaluateContentQuery(t *testing.T) {
 		{name: "Empty query: match", doc: doc1, queryParts: []string{}, expectMatch: true},
 		{name: "Nil query: match", doc: doc1, queryParts: nil, expectMatch: true},
 		{name: "Evaluation error: bubbles up", doc: doc1, queryParts: []string{`name equals "test"`, "and", `nonexistent greaterThan 10`}, expectErr: true, errContains: "path 'nonexistent' does not exist"},
+		{name: "NOT: match", doc: doc2, queryParts: []string{`not tags contains "A"`}, expectMatch: true},
+		{name: "NOT: no match", doc: doc1, queryParts: []string{`not tags contains "A"`}, expectMatch: false},
+		{name: "NOT with AND", doc: doc3, queryParts: []string{`name equals "test"`, "and", `not age lessThan 35`}, expectMatch: true},
+		{name: "NOT: missing path matches", doc: doc1, queryParts: []string{`not archived equals true`}, expectMatch: true},
+		{name: "NOT: missing length path matches", doc: doc1, queryParts: []string{`not assignees.# greaterThan 0`}, expectMatch: true},
+		{name: "NOT: type errors still bubble up", doc: doc1, queryParts: []string{`not name greaterThan 10`}, expectErr: true, errContains: "type mismatch"},
 		{name: "Parsing error: bubbles up", doc: doc1, queryParts: []string{`name equals "test"`, "and", `age greater`}, expectErr: true, errContains: "invalid content_query"}, // Error comes from ParseContentQuery
 	}
 
@@ -604,8 +656,8 @@ func TestPaginateDocuments(t *testing.T) {
 		// Expect all docs since defaultLimit (20) > len(docs) (15)
 		{name: "Limit 0 (defaults to defaultLimit)", page: 1, limit: 0, inputDocs: docs, expectedIDs: []string{"doc1", "doc2", "doc3", "doc4", "doc5", "doc6", "doc7", "doc8", "doc9", "doc10", "doc11", "doc12", "doc13", "doc14", "doc15"}},
 		{name: "Limit -1 (defaults to defaultLimit)", page: 1, limit: -1, inputDocs: docs, expectedIDs: []string{"doc1", "doc2", "doc3", "doc4", "doc5", "doc6", "doc7", "doc8", "doc9", "doc10", "doc11", "doc12", "doc13", "doc14", "doc15"}},
-		// Expect all docs since maxLimit (100) > len(docs) (15)
-		{name: "Limit > maxLimit (caps at maxLimit)", page: 1, limit: maxLimit + 10, inputDocs: docs, expectedIDs: []string{"doc1", "doc2", "doc3", "doc4", "doc5", "doc6", "doc7", "doc8", "doc9", "doc10", "doc11", "doc12", "doc13", "doc14", "doc15"}},
+		// Expect all docs since MaxLimit (100) > len(docs) (15)
+		{name: "Limit > MaxLimit (caps at MaxLimit)", page: 1, limit: MaxLimit + 10, inputDocs: docs, expectedIDs: []string{"doc1", "doc2", "doc3", "doc4", "doc5", "doc6", "doc7", "doc8", "doc9", "doc10", "doc11", "doc12", "doc13", "doc14", "doc15"}},
 
 		// Edge Cases: Boundaries
 		{name: "Page out of bounds (high)", page: 4, limit: 5, inputDocs: docs, expectedIDs: []string{}}, // Page 4 with limit 5 is empty
@@ -679,7 +731,7 @@ func TestQueryDocuments(t *testing.T) {
 	// Documents
 	doc1 := models.Document{ID: "doc1", OwnerID: user1ID, Content: `{"name": "alpha", "value": 10}`, CreationDate: time1, LastModifiedDate: time1}
 	doc2 := models.Document{ID: "doc2", OwnerID: user1ID, Content: `{"name": "beta", "value": 20}`, CreationDate: time2, LastModifiedDate: time3} // Modified later
-	doc3 := models.Document{ID: "doc3", OwnerID: user2ID, Content: `{"name": "gamma", "value": 15}`, CreationDate: time3, LastModifiedDate: time3}
+	doc3 := models.Document{ID: "doc3", OwnerID: user2ID, Content: `{"name": "gamma", "value": 15}`, CreationDate: time3, LastModifiedDate: time3, Public: true}
 	doc4 := models.Document{ID: "doc4", OwnerID: user1ID, Content: `Just plain text`, CreationDate: time1a, LastModifiedDate: time2} // Use time1a
 
 	// Add documents directly to the map with fixed IDs
@@ -710,6 +762,12 @@ func TestQueryDocuments(t *testing.T) {
 			expectedIDs:   []string{"doc1", "doc4", "doc2"}, // Default sort: creation_date asc (time1, time1a, time2)
 			expectedTotal: 3,
 		},
+		{
+			name:          "Scope: public for a guest",
+			params:        QueryDocumentsParams{Scope: "public"},
+			expectedIDs:   []string{"doc3"},
+			expectedTotal: 1,
+		},
 		{
 			name:          "Scope: owned by user2",
 			params:        QueryDocumentsParams{AuthUserID: user2ID, Scope: "owned"},
@@ -752,6 +810,30 @@ func TestQueryDocuments(t *testing.T) {
 			expectErr:     true,
 			errContains:   "invalid scope value: 'invalid'",
 		},
+		{
+			name:          "Scope: any for an admin",
+			params:        QueryDocumentsParams{AuthUserID: user2ID, Scope: "any", IsAdmin: true},
+			expectedIDs:   []string{"doc1", "doc4", "doc2", "doc3"},
+			expectedTotal: 4,
+		},
+		{
+			name:        "Scope: any for a non-admin",
+			params:      QueryDocumentsParams{AuthUserID: user2ID, Scope: "any"},
+			expectErr:   true,
+			errContains: "only available to administrators",
+		},
+		{
+			name:          "Owner filter: any scope",
+			params:        QueryDocumentsParams{AuthUserID: user1ID, Scope: "any", IsAdmin: true, OwnerID: user2ID},
+			expectedIDs:   []string{"doc3"},
+			expectedTotal: 1,
+		},
+		{
+			name:          "Owner filter: within the caller's scope",
+			params:        QueryDocumentsParams{AuthUserID: user2ID, Scope: "all", OwnerID: user1ID},
+			expectedIDs:   []string{"doc2"}, // Shared with user2; user1's other documents stay hidden
+			expectedTotal: 1,
+		},
 
 		// --- Content Filtering (with Scope) ---
 		{
@@ -851,8 +933,8 @@ func TestQueryDocuments(t *testing.T) {
 			expectedTotal: 3,
 		},
 		{
-			name:          "Paginate with max limit", // maxLimit is 100
-			params:        QueryDocumentsParams{AuthUserID: user1ID, Scope: "owned", Page: 1, Limit: maxLimit + 1},
+			name:          "Paginate with max limit", // MaxLimit is 100
+			params:        QueryDocumentsParams{AuthUserID: user1ID, Scope: "owned", Page: 1, Limit: MaxLimit + 1},
 			expectedIDs:   []string{"doc1", "doc4", "doc2"}, // Default sort: time1, time1a, time2
 			expectedTotal: 3,
 		},
@@ -890,4 +972,159 @@ func TestQueryDocuments(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+// spanRecorder collects the spans exported while tracing is enabled in a test.
+type spanRecorder struct {
+	*tracetest.InMemoryExporter
+}
+
+// byName returns the last exported span called name.
+func (r spanRecorder) byName(name string) *tracetest.SpanStub {
+	spans := r.GetSpans()
+	for i := len(spans) - 1; i >= 0; i-- {
+		if spans[i].Name == name {
+			return &spans[i]
+		}
+	}
+	return nil
+}
+
+func TestQueryDocuments_MissingPaths(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	tagged, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: map[string]any{"tags": []any{"urgent"}, "done": false}})
+	require.NoError(t, err)
+	untagged, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: map[string]any{"done": true}})
+	require.NoError(t, err)
+
+	query := func(missing string, parts ...string) ([]string, error) {
+		docs, _, err := db.QueryDocuments(QueryDocumentsParams{AuthUserID: "owner", ContentQuery: parts, Missing: missing, Order: "asc"})
+		ids := make([]string, 0, len(docs))
+		for _, doc := range docs {
+			ids = append(ids, doc.ID)
+		}
+		return ids, err
+	}
+	orQuery := []string{`tags contains "urgent"`, "or", "done equals true"}
+
+	t.Run("Skip (default)", func(t *testing.T) {
+		ids, err := query("", orQuery...)
+		require.NoError(t, err)
+		assert.Equal(t, []string{tagged.ID}, ids, "the untagged document is skipped despite 'done equals true'")
+		ids, err = query(MissingSkip, orQuery...)
+		require.NoError(t, err)
+		assert.Equal(t, []string{tagged.ID}, ids)
+	})
+
+	t.Run("False", func(t *testing.T) {
+		ids, err := query(MissingFalse, orQuery...)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{tagged.ID, untagged.ID}, ids)
+		ids, err = query(MissingFalse, `tags contains "urgent"`)
+		require.NoError(t, err)
+		assert.Equal(t, []string{tagged.ID}, ids)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		_, err := query(MissingError, orQuery...)
+		assert.ErrorIs(t, err, apperr.ErrValidation)
+		assert.ErrorContains(t, err, untagged.ID)
+		assert.ErrorContains(t, err, "'tags'")
+
+		ids, err := query(MissingError, `not tags contains "urgent"`)
+		require.NoError(t, err, "negated conditions match missing paths")
+		assert.Equal(t, []string{untagged.ID}, ids)
+	})
+
+	t.Run("Invalid mode", func(t *testing.T) {
+		_, err := query("sometimes", orQuery...)
+		assert.ErrorIs(t, err, apperr.ErrValidation)
+	})
+}
+
+func TestQueryDocuments_UnicodeNormalization(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	const composed, decomposed = "caf\u00e9", "cafe\u0301" // The same word, as NFC and as NFD
+	typedNFD, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: map[string]any{"place": decomposed, "tags": []any{decomposed}}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"place": composed, "tags": []any{composed}}, typedNFD.Content, "content is stored in NFC")
+	typedNFC, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: map[string]any{"place": composed + " du coin", "tags": []any{}}})
+	require.NoError(t, err)
+
+	// A document stored before content was normalized
+	db.Database.Mu.Lock()
+	legacy := db.Database.Documents[typedNFC.ID]
+	legacy.ID = "legacy"
+	legacy.Content = map[string]any{"place": "Le " + decomposed}
+	db.Database.Documents[legacy.ID] = legacy
+	db.Database.Mu.Unlock()
+
+	query := func(parts ...string) []string {
+		docs, _, err := db.QueryDocuments(QueryDocumentsParams{AuthUserID: "owner", ContentQuery: parts, SortBy: "creation_date", Order: "asc"})
+		require.NoError(t, err)
+		ids := make([]string, 0, len(docs))
+		for _, doc := range docs {
+			ids = append(ids, doc.ID)
+		}
+		return ids
+	}
+	for _, word := range []string{composed, decomposed} {
+		assert.ElementsMatch(t, []string{typedNFD.ID, typedNFC.ID, "legacy"}, query(`place contains "`+word+`"`), word)
+		assert.Equal(t, []string{typedNFD.ID}, query(`place equals "`+word+`"`), word)
+		assert.Equal(t, []string{typedNFD.ID}, query(`tags contains "`+word+`"`), word)
+		assert.ElementsMatch(t, []string{typedNFD.ID, typedNFC.ID}, query(`place startsWith-insensitive "`+strings.ToUpper(word)+`"`), word)
+	}
+
+	keyed, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: map[string]any{decomposed: true}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{keyed.ID}, query(decomposed+" equals true"), "keys and paths are normalized too")
+
+	updated, err := db.UpdateDocument(typedNFC.ID, map[string]any{"place": "Cre\u0300me"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"place": "Cr\u00e8me"}, updated.Content, "updates are stored in NFC")
+}
+
+func TestQueryDocuments_Tracing(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	for _, content := range []string{`{"n": 1}`, `{"n": 2}`, `{"n": 3}`} {
+		_, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: gjson.Parse(content).Value()})
+		require.NoError(t, err)
+	}
+	_, err := db.CreateDocument(models.Document{OwnerID: "someone-else", Content: "other"})
+	require.NoError(t, err)
+
+	recorder := spanRecorder{tracetest.NewInMemoryExporter()}
+	stopTracing := tracing.Enable(recorder, "docserver-test")
+	defer stopTracing()
+
+	ctx, request := tracing.Start(context.Background(), "GET /documents")
+	_, total, err := db.QueryDocumentsContext(ctx, QueryDocumentsParams{
+		AuthUserID: "owner", Scope: "owned", ContentQuery: []string{"n greaterthan 1"}, Page: 1, Limit: 10,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	request.End()
+	tracing.Flush()
+
+	query := recorder.byName("db.QueryDocuments")
+	require.NotNil(t, query)
+	assert.Equal(t, request.SpanContext().SpanID(), query.Parent.SpanID())
+	evaluation := recorder.byName("db.EvaluateQuery")
+	require.NotNil(t, evaluation)
+	assert.Equal(t, query.SpanContext.SpanID(), evaluation.Parent.SpanID())
+	assert.Subset(t, evaluation.Attributes, []attribute.KeyValue{
+		attribute.Int("documents.scanned", 4),
+		attribute.Int("documents.evaluated", 3), // Only documents in scope are evaluated
+		attribute.Int("documents.matched", 2),
+	})
+
+	_, _, err = db.QueryDocumentsContext(ctx, QueryDocumentsParams{AuthUserID: "owner", ContentQuery: []string{"n bogus 1"}})
+	require.Error(t, err)
+	tracing.Flush()
+	assert.Equal(t, codes.Error, recorder.byName("db.QueryDocuments").Status.Code, "query errors mark the span as failed")
+}
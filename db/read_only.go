@@ -0,0 +1,17 @@
+package db
+
+import (
+	"docserver/apperr"
+	"errors"
+)
+
+// --- Read-Only Mode ---
+
+// ErrReadOnly is returned by attempts to save the database while the server runs with -read-only.
+// It is of kind apperr.ErrForbidden.
+var ErrReadOnly = apperr.Wrap(apperr.ErrForbidden, errors.New("the database is read-only"))
+
+// ReadOnly reports whether the server runs with -read-only, so the database is never saved.
+func (db *Database) ReadOnly() bool {
+	return db.config.ReadOnly
+}
@@ -0,0 +1,26 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOnly(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	db.config.ReadOnly = true
+
+	_, err := db.CreateProfile(models.Profile{Email: "read-only@example.com", FirstName: "R", LastName: "O"})
+	require.NoError(t, err, "changes in memory are left to the api layer to refuse")
+	assert.False(t, db.Unsaved(), "no save was scheduled")
+	err = db.PersistNow()
+	assert.ErrorIs(t, err, ErrReadOnly)
+	assert.ErrorIs(t, err, apperr.ErrForbidden)
+	assert.NoFileExists(t, db.config.DbFilePath)
+	assert.NoError(t, db.Close())
+	assert.NoFileExists(t, db.config.DbFilePath)
+}
@@ -0,0 +1,250 @@
+package db
+
+import (
+	"bytes"
+	"docserver/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// --- Recovery from a Damaged Database File ---
+
+// Where a recovered state came from
+const (
+	RecoverySourceBackup  = "backup"  // The .bak file
+	RecoverySourceSalvage = "salvage" // The records of the damaged file that could still be read
+)
+
+// RecoveryReport describes how a database file was recovered on startup after it could not be loaded.
+type RecoveryReport struct {
+	RecoveredAt   time.Time      `json:"recovered_at"`             // UTC
+	File          string         `json:"file"`                     // The damaged file
+	Reason        string         `json:"reason"`                   // Why the file could not be loaded
+	Source        string         `json:"source"`                   // "backup" or "salvage"
+	QuarantinedAs string         `json:"quarantined_as,omitempty"` // Where the damaged file was moved, if it could be
+	Recovered     map[string]int `json:"recovered,omitempty"`      // Records salvaged per collection, e.g. {"documents": 12}
+}
+
+// String summarizes the report for the log.
+func (r RecoveryReport) String() string {
+	out := fmt.Sprintf("recovered the database from the %s", r.Source)
+	if r.Source == RecoverySourceSalvage {
+		collections := make([]string, 0, len(r.Recovered))
+		for name, count := range r.Recovered {
+			collections = append(collections, fmt.Sprintf("%s: %d", name, count))
+		}
+		sort.Strings(collections)
+		out = fmt.Sprintf("salvaged records from the damaged database file (%s)", strings.Join(collections, ", "))
+	}
+	if r.QuarantinedAs != "" {
+		out += "; the damaged file was moved to " + r.QuarantinedAs
+	}
+	return out
+}
+
+// Recoveries returns how the database was recovered when it was loaded, one report per damaged
+// file (see -data-dir), or nil if everything loaded as is.
+func (db *Database) Recoveries() []RecoveryReport {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+	if len(db.recovery) == 0 {
+		return nil
+	}
+	return append([]RecoveryReport(nil), db.recovery...)
+}
+
+// decodeState checks the checksum of database file data, brings it up to the current schema and
+// unmarshals it into a new state, leaving the database alone.
+func (db *Database) decodeState(data []byte) (*models.Database, MigrationReport, error) {
+	content, err := verifyChecksum(data)
+	if err != nil {
+		return nil, MigrationReport{}, err
+	}
+	content, report, err := migrateData(content)
+	if err != nil {
+		return nil, report, err
+	}
+	scratch := &Database{config: db.config}
+	if err := scratch.unmarshalState(content); err != nil {
+		return nil, report, err
+	}
+	scratch.ensureCollections() // If the JSON file had null values for them
+	return &scratch.Database, report, nil
+}
+
+// recoverState is called when the database file data could not be loaded because of cause. It
+// returns the state recovered by recoverFile.
+// Must be called with the write lock held.
+func (db *Database) recoverState(data []byte, cause error) (*models.Database, error) {
+	var state *models.Database
+	err := db.recoverFile(db.config.DbFilePath, data, cause, func(data []byte) error {
+		var err error
+		state, _, err = db.decodeState(data)
+		return err
+	})
+	return state, err
+}
+
+// recoverFile is called when the data of the file at path could not be loaded because of cause.
+// A file that does not match its checksum is replaced by its backup file when decode accepts
+// that. With -auto-recover, the backup is tried for any damage and, failing that, the records of
+// the damaged file that can still be read are salvaged and passed to decode. When it recovers,
+// the damaged file is moved aside to a timestamped <file>.corrupt-<time> (unless -read-only)
+// and a save is scheduled; otherwise cause is returned.
+// Must be called with the write lock held.
+func (db *Database) recoverFile(path string, data []byte, cause error, decode func([]byte) error) error {
+	if !db.config.AutoRecover && !errors.Is(cause, ErrChecksumMismatch) {
+		return cause
+	}
+	report := RecoveryReport{RecoveredAt: db.now().UTC(), File: path, Reason: cause.Error(), Source: RecoverySourceBackup}
+
+	backupErr := loadBackup(path, decode)
+	if backupErr != nil {
+		if !db.config.AutoRecover {
+			return fmt.Errorf("%w: '%s' (backup unusable: %v)", cause, path, backupErr)
+		}
+		salvaged, counts := salvageState(stripChecksum(data))
+		if err := decode(salvaged); err != nil {
+			return fmt.Errorf("%w: '%s' (backup unusable: %v; nothing could be salvaged: %v)", cause, path, backupErr, err)
+		}
+		report.Source, report.Recovered = RecoverySourceSalvage, counts
+	}
+
+	quarantined := fmt.Sprintf("%s.corrupt-%s", path, report.RecoveredAt.Format("20060102T150405Z"))
+	if !db.config.ReadOnly { // With -read-only the database files are never changed
+		if err := os.Rename(path, quarantined); err != nil {
+			log.Printf("WARN: Failed to move damaged database file '%s' aside: %v", path, err)
+		} else {
+			report.QuarantinedAs = quarantined
+		}
+	}
+	log.Printf("WARN: Database file '%s' could not be loaded (%v): %s.", path, cause, report)
+	db.recovery = append(db.recovery, report)
+	db.requestSave() // Write the recovered state back to the database file
+	return nil
+}
+
+// loadBackup passes the backup file of the file at path to decode.
+func loadBackup(path string, decode func([]byte) error) error {
+	data, err := os.ReadFile(path + ".bak")
+	if err != nil {
+		return err
+	}
+	return decode(data)
+}
+
+// stripChecksum removes the checksum footer line from database file data, if it has one,
+// without checking it.
+func stripChecksum(data []byte) []byte {
+	trimmed := bytes.TrimRight(data, "\n")
+	newline := bytes.LastIndexByte(trimmed, '\n')
+	if bytes.HasPrefix(trimmed[newline+1:], []byte(checksumFooterPrefix)) {
+		return trimmed[:max(newline, 0)]
+	}
+	return data
+}
+
+// salvageSingletons lists the top-level values of the database file that are single objects
+// rather than collections of records.
+var salvageSingletons = map[string]bool{"maintenance": true}
+
+// salvageState reads as much of damaged database file data as it can: it walks the top-level
+// collections record by record and stops at the first one that cannot be read, e.g. where a
+// truncated file ends. It returns the readable part as a database file and the number of records
+// kept per collection. Documents whose deduplicated content was lost are dropped.
+func salvageState(data []byte) ([]byte, map[string]int) {
+	salvaged := map[string]json.RawMessage{}
+	counts := map[string]int{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return []byte("{}"), counts
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		key, isKey := token.(string)
+		if err != nil || !isKey {
+			break
+		}
+		if token, err = decoder.Token(); err != nil {
+			break
+		}
+		if token != json.Delim('{') {
+			if _, isDelim := token.(json.Delim); isDelim {
+				break // No top-level arrays are written
+			}
+			if value, err := json.Marshal(token); err == nil {
+				salvaged[key] = value // e.g. schema_version
+			}
+			continue
+		}
+
+		records := map[string]json.RawMessage{}
+		complete := salvageRecords(decoder, records)
+		if value, err := json.Marshal(records); err == nil {
+			salvaged[key] = value
+			if !salvageSingletons[key] {
+				counts[key] = len(records)
+			}
+		}
+		if !complete {
+			break
+		}
+	}
+
+	if _, found := salvaged["schema_version"]; !found {
+		salvaged["schema_version"] = json.RawMessage(fmt.Sprint(CurrentSchemaVersion)) // Written by this server
+	}
+	dropDocumentsWithoutContent(salvaged, counts)
+	out, _ := json.Marshal(salvaged) // Raw messages that were read as JSON always encode
+	return out, counts
+}
+
+// salvageRecords reads the entries of the object the decoder is in into records, up to its end.
+// It reports whether the whole object could be read.
+func salvageRecords(decoder *json.Decoder, records map[string]json.RawMessage) bool {
+	for decoder.More() {
+		token, err := decoder.Token()
+		id, isKey := token.(string)
+		if err != nil || !isKey {
+			return false
+		}
+		var record json.RawMessage
+		if err := decoder.Decode(&record); err != nil {
+			return false
+		}
+		records[id] = record
+	}
+	token, err := decoder.Token()
+	return err == nil && token == json.Delim('}')
+}
+
+// dropDocumentsWithoutContent removes salvaged documents referring to deduplicated content
+// (see -dedupe-content) that was not salvaged.
+func dropDocumentsWithoutContent(salvaged map[string]json.RawMessage, counts map[string]int) {
+	var documents, contents map[string]json.RawMessage
+	if json.Unmarshal(salvaged["documents"], &documents) != nil {
+		return
+	}
+	_ = json.Unmarshal(salvaged["contents"], &contents)
+	for id, raw := range documents {
+		var ref struct {
+			ContentRef string `json:"content_ref"`
+		}
+		if json.Unmarshal(raw, &ref) == nil && ref.ContentRef != "" {
+			if _, found := contents[ref.ContentRef]; !found {
+				delete(documents, id)
+			}
+		}
+	}
+	if value, err := json.Marshal(documents); err == nil {
+		salvaged["documents"] = value
+		counts["documents"] = len(documents)
+	}
+}
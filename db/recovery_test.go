@@ -0,0 +1,122 @@
+package db
+
+import (
+	"docserver/config"
+	"docserver/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_AutoRecover(t *testing.T) {
+	// setup saves a database with two profiles and a document, so the backup holds a first save
+	// with one profile, and returns the saved file and the closed database
+	setup := func(t *testing.T) (string, *Database) {
+		cfg := createTestConfig(t, t.TempDir())
+		cfg.IDStrategy, cfg.Fixtures = config.IDStrategyPrefixed, true // IDs sort in creation order
+		cfg.Clock = config.NewFixedClock(time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC))
+		db, err := NewDatabase(cfg)
+		require.NoError(t, err)
+		_, err = db.CreateProfile(models.Profile{Email: "first@example.com", FirstName: "F", LastName: "A"})
+		require.NoError(t, err)
+		require.NoError(t, db.PersistNow())
+		_, err = db.CreateProfile(models.Profile{Email: "second@example.com", FirstName: "S", LastName: "B"})
+		require.NoError(t, err)
+		_, err = db.CreateDocument(models.Document{OwnerID: "owner", Content: "kept"})
+		require.NoError(t, err)
+		require.NoError(t, db.PersistNow())
+		require.NoError(t, db.Close())
+		return readTestDBFile(t, cfg), db
+	}
+	truncated := func(data string) string {
+		return data[:strings.Index(data, `"second@example.com"`)] // Cut inside the second profile
+	}
+
+	t.Run("Refuses to start without it", func(t *testing.T) {
+		data, db := setup(t)
+		writeTestDBFile(t, db.config, truncated(data))
+		_, err := NewDatabase(db.config)
+		assert.Error(t, err)
+	})
+
+	t.Run("Loads the backup", func(t *testing.T) {
+		data, db := setup(t)
+		db.config.AutoRecover = true
+		writeTestDBFile(t, db.config, truncated(data))
+
+		recovered, err := NewDatabase(db.config)
+		require.NoError(t, err)
+		defer recovered.Close()
+		assert.Len(t, recovered.GetAllProfiles(), 1, "as of the first save")
+		reports := recovered.Recoveries()
+		require.Len(t, reports, 1)
+		report := reports[0]
+		assert.Equal(t, db.config.DbFilePath, report.File)
+		assert.Equal(t, RecoverySourceBackup, report.Source)
+		assert.NotEmpty(t, report.Reason)
+		assert.Equal(t, truncated(data), string(mustReadFile(t, report.QuarantinedAs)))
+	})
+
+	t.Run("Salvages the readable records", func(t *testing.T) {
+		data, db := setup(t)
+		db.config.AutoRecover = true
+		require.NoError(t, os.Remove(db.config.DbFilePath+".bak"))
+		writeTestDBFile(t, db.config, truncated(data))
+
+		recovered, err := NewDatabase(db.config)
+		require.NoError(t, err)
+		reports := recovered.Recoveries()
+		require.Len(t, reports, 1)
+		report := reports[0]
+		assert.Equal(t, RecoverySourceSalvage, report.Source)
+		assert.Equal(t, 1, report.Recovered["profiles"], "the second profile was cut off")
+		assert.Contains(t, report.String(), "profiles: 1")
+		profiles := recovered.GetAllProfiles()
+		require.Len(t, profiles, 1)
+		assert.Equal(t, "first@example.com", profiles[0].Email)
+		require.NoError(t, recovered.Close())
+
+		quarantined, err := filepath.Glob(db.config.DbFilePath + ".corrupt-*")
+		require.NoError(t, err)
+		assert.Equal(t, []string{report.QuarantinedAs}, quarantined)
+		reloaded, err := NewDatabase(db.config)
+		require.NoError(t, err, "the salvaged state was saved")
+		defer reloaded.Close()
+		assert.Nil(t, reloaded.Recoveries())
+		assert.Len(t, reloaded.GetAllProfiles(), 1)
+	})
+
+	t.Run("Unreadable files salvage nothing", func(t *testing.T) {
+		_, db := setup(t)
+		db.config.AutoRecover = true
+		require.NoError(t, os.Remove(db.config.DbFilePath+".bak"))
+		writeTestDBFile(t, db.config, "not json at all")
+
+		recovered, err := NewDatabase(db.config)
+		require.NoError(t, err)
+		defer recovered.Close()
+		assert.Empty(t, recovered.GetAllProfiles())
+		require.Len(t, recovered.Recoveries(), 1)
+		assert.Empty(t, recovered.Recoveries()[0].Recovered)
+	})
+}
+
+func TestSalvageState_DedupedContent(t *testing.T) {
+	data := `{"schema_version": 1, "documents": {"a": {"id": "a", "content_ref": "h1"}, "b": {"id": "b", "content": 1}}, "contents": {`
+	salvaged, counts := salvageState([]byte(data))
+	assert.Equal(t, 1, counts["documents"], "'a' lost its content")
+	assert.Equal(t, 0, counts["contents"])
+	assert.Contains(t, string(salvaged), `"schema_version":1`)
+	assert.NotContains(t, string(salvaged), `"a"`)
+}
+
+func mustReadFile(t *testing.T, path string) []byte {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return data
+}
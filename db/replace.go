@@ -0,0 +1,216 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/i18n"
+	"docserver/models"
+	"docserver/utils"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// --- Search and Replace ---
+
+// ReplaceSpec describes a search-and-replace across the documents of one owner.
+// Exactly one of Old (with HasOld set) or Regex selects what is replaced.
+type ReplaceSpec struct {
+	OwnerID      string         // Only documents owned by this profile are changed
+	ContentQuery []string       // Raw content query parts; empty matches every owned document
+	Path         string         // Dot-separated path to the value to replace; numeric parts index arrays
+	Old          any            // Value replaced when the value at Path equals it
+	HasOld       bool           // Whether Old was given (it may be null)
+	Regex        *regexp.Regexp // Pattern replaced within a string value at Path
+	New          any            // Replacement value; for Regex, a string that may use $1 etc.
+	DryRun       bool           // Report the changes without saving them
+}
+
+// ReplaceChange reports the replacement made (or that would be made) in one document.
+type ReplaceChange struct {
+	DocumentID string `json:"document_id"`
+	From       any    `json:"from"`              // Value at the path before
+	To         any    `json:"to"`                // Value at the path after
+	Version    int    `json:"version,omitempty"` // New document version (not set for dry runs)
+}
+
+// ReplaceReport summarizes a search-and-replace.
+type ReplaceReport struct {
+	DryRun  bool            `json:"dry_run"`
+	Matched int             `json:"matched"` // Owned documents matching the content query
+	Changed int             `json:"changed"` // Documents whose value at the path was replaced
+	Frozen  int             `json:"frozen"`  // Matching documents left alone because they are frozen
+	Changes []ReplaceChange `json:"changes"` // One entry per changed document, oldest document first
+}
+
+// ReplaceInDocuments applies a replacement to every matching document the owner has.
+// It is atomic: the new contents of all documents are prepared (including content transformations
+// and update scripts) before any is stored, and if one fails nothing is changed.
+// Documents without a matching value at the path, and frozen documents, are left alone.
+func (db *Database) ReplaceInDocuments(spec ReplaceSpec) (ReplaceReport, error) {
+	parsedQuery, err := ParseContentQuery(spec.ContentQuery)
+	if err != nil {
+		return ReplaceReport{}, apperr.Wrap(apperr.ErrValidation, i18n.NewError(i18n.MsgQueryInvalid, err))
+	}
+	pathParts := strings.Split(utils.NormalizeText(spec.Path), ".") // Keys are stored in NFC
+
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	candidates := make([]models.Document, 0)
+	for _, doc := range db.Database.Documents {
+		if doc.OwnerID == spec.OwnerID {
+			candidates = append(candidates, doc)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if !candidates[i].CreationDate.Equal(candidates[j].CreationDate) {
+			return candidates[i].CreationDate.Before(candidates[j].CreationDate)
+		}
+		return candidates[i].ID < candidates[j].ID
+	})
+
+	report := ReplaceReport{DryRun: spec.DryRun, Changes: []ReplaceChange{}}
+	var prepared []models.Document
+	for _, doc := range candidates {
+		match, err := db.EvaluateContentQuery(doc, parsedQuery)
+		if err != nil {
+			log.Printf("WARN: Error evaluating content query for document ID %s, skipping document: %v", doc.ID, err)
+			continue
+		}
+		if !match {
+			continue
+		}
+		report.Matched++
+		if doc.Frozen {
+			report.Frozen++
+			continue
+		}
+
+		content := cloneJSON(doc.Content)
+		current, found := jsonPathValue(content, pathParts)
+		if !found {
+			continue
+		}
+		replacement, replace := spec.replacementFor(current)
+		if !replace {
+			continue
+		}
+		if content, err = setJSONPathValue(content, pathParts, replacement); err != nil {
+			return ReplaceReport{}, fmt.Errorf("document '%s': %w", doc.ID, err)
+		}
+		if content, err = db.prepareDocumentUpdate(doc, content); err != nil {
+			return ReplaceReport{}, err
+		}
+
+		updated := doc
+		updated.Content = content
+		prepared = append(prepared, updated)
+		report.Changes = append(report.Changes, ReplaceChange{DocumentID: doc.ID, From: current, To: replacement})
+	}
+	report.Changed = len(report.Changes)
+
+	if spec.DryRun || len(prepared) == 0 {
+		return report, nil
+	}
+	for i, doc := range prepared {
+		stored := db.storeDocumentUpdate(db.Database.Documents[doc.ID], doc.Content, doc.OwnerID)
+		report.Changes[i].Version = stored.Version
+	}
+	log.Printf("INFO: Replaced '%s' in %d document(s) of Profile ID %s", spec.Path, len(prepared), spec.OwnerID)
+
+	db.requestSave()
+	return report, nil
+}
+
+// replacementFor returns the value that replaces current, and whether current should be replaced.
+func (spec ReplaceSpec) replacementFor(current any) (any, bool) {
+	if spec.Regex != nil {
+		text, isString := current.(string)
+		if !isString {
+			return nil, false
+		}
+		replaced := spec.Regex.ReplaceAllString(text, fmt.Sprint(spec.New))
+		return replaced, replaced != text
+	}
+	// Old matches text whatever its Unicode normalization form (see utils.NormalizeText)
+	if spec.HasOld && reflect.DeepEqual(utils.NormalizeTextJSON(current), utils.NormalizeTextJSON(cloneJSON(spec.Old))) && !reflect.DeepEqual(current, cloneJSON(spec.New)) {
+		return spec.New, true
+	}
+	return nil, false
+}
+
+// cloneJSON deep-copies a JSON value into its generic decoded form, so it can be changed
+// without touching the stored document.
+func cloneJSON(value any) any {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var clone any
+	if err := json.Unmarshal(data, &clone); err != nil {
+		return value
+	}
+	return clone
+}
+
+// jsonPathValue returns the value at a dot-separated path; numeric parts index arrays.
+func jsonPathValue(content any, parts []string) (any, bool) {
+	current := content
+	for _, part := range parts {
+		switch node := current.(type) {
+		case map[string]any:
+			value, ok := node[part]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []any:
+			index, err := strconv.Atoi(part)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+			current = node[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// setJSONPathValue replaces the existing value at a dot-separated path and returns the content.
+func setJSONPathValue(content any, parts []string, value any) (any, error) {
+	if len(parts) == 0 {
+		return value, nil
+	}
+	switch node := content.(type) {
+	case map[string]any:
+		child, ok := node[parts[0]]
+		if !ok {
+			return nil, fmt.Errorf("path part '%s' not found", parts[0])
+		}
+		updated, err := setJSONPathValue(child, parts[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		node[parts[0]] = updated
+		return node, nil
+	case []any:
+		index, err := strconv.Atoi(parts[0])
+		if err != nil || index < 0 || index >= len(node) {
+			return nil, fmt.Errorf("array index '%s' out of range", parts[0])
+		}
+		updated, err := setJSONPathValue(node[index], parts[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		node[index] = updated
+		return node, nil
+	default:
+		return nil, fmt.Errorf("path part '%s' is not inside an object or array", parts[0])
+	}
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"docserver/i18n"
+	"docserver/models"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabase_ReplaceInDocuments(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	create := func(owner string, content any) models.Document {
+		doc, err := db.CreateDocument(models.Document{OwnerID: owner, Content: content})
+		require.NoError(t, err)
+		return doc
+	}
+	first := create("owner", map[string]any{"course": "CS101", "room": "B12", "staff": []any{map[string]any{"name": "Ann"}}})
+	second := create("owner", map[string]any{"course": "CS101", "room": "B14", "staff": []any{map[string]any{"name": "Bob"}}})
+	other := create("owner", map[string]any{"course": "CS102", "room": "B12"})
+	notMine := create("someone", map[string]any{"course": "CS101", "room": "B12"})
+
+	t.Run("Dry run changes nothing", func(t *testing.T) {
+		report, err := db.ReplaceInDocuments(ReplaceSpec{OwnerID: "owner", ContentQuery: []string{`course equals "CS101"`},
+			Path: "room", Old: "B12", HasOld: true, New: "C3", DryRun: true})
+		require.NoError(t, err)
+		assert.True(t, report.DryRun)
+		assert.Equal(t, 2, report.Matched)
+		require.Equal(t, 1, report.Changed)
+		assert.Equal(t, ReplaceChange{DocumentID: first.ID, From: "B12", To: "C3"}, report.Changes[0])
+
+		stored, _ := db.GetDocumentByID(first.ID)
+		assert.Equal(t, "B12", stored.Content.(map[string]any)["room"])
+		assert.Equal(t, 1, stored.Version)
+	})
+
+	t.Run("Value replacement", func(t *testing.T) {
+		report, err := db.ReplaceInDocuments(ReplaceSpec{OwnerID: "owner", Path: "room", Old: "B12", HasOld: true, New: "C3"})
+		require.NoError(t, err)
+		assert.Equal(t, 3, report.Matched)
+		require.Equal(t, 2, report.Changed)
+		assert.Equal(t, []string{first.ID, other.ID}, []string{report.Changes[0].DocumentID, report.Changes[1].DocumentID})
+		assert.Equal(t, 2, report.Changes[0].Version)
+
+		stored, _ := db.GetDocumentByID(first.ID)
+		assert.Equal(t, "C3", stored.Content.(map[string]any)["room"])
+		untouched, _ := db.GetDocumentByID(notMine.ID)
+		assert.Equal(t, "B12", untouched.Content.(map[string]any)["room"], "other owners' documents are not changed")
+		events := db.GetDocumentEvents(first.ID)
+		assert.Equal(t, []string{"room"}, events[0].ChangedPaths)
+	})
+
+	t.Run("Values match in any normalization form", func(t *testing.T) {
+		cafe := create("barista", map[string]any{"place": "caf\u00e9"})
+		report, err := db.ReplaceInDocuments(ReplaceSpec{OwnerID: "barista", Path: "place", Old: "cafe\u0301", HasOld: true, New: "bar"})
+		require.NoError(t, err)
+		require.Equal(t, 1, report.Changed)
+		assert.Equal(t, cafe.ID, report.Changes[0].DocumentID)
+	})
+
+	t.Run("Regex replacement in arrays", func(t *testing.T) {
+		report, err := db.ReplaceInDocuments(ReplaceSpec{OwnerID: "owner", Path: "staff.0.name",
+			Regex: regexp.MustCompile(`^(\w+)$`), New: "Dr. $1"})
+		require.NoError(t, err)
+		require.Equal(t, 2, report.Changed)
+		assert.Equal(t, "Dr. Bob", report.Changes[1].To)
+		stored, _ := db.GetDocumentByID(second.ID)
+		assert.Equal(t, "Dr. Bob", stored.Content.(map[string]any)["staff"].([]any)[0].(map[string]any)["name"])
+	})
+
+	t.Run("Atomic when a script rejects one document", func(t *testing.T) {
+		db.config.ScriptTimeout = time.Second
+		script, err := db.CreateScript(models.Script{Name: "no-cs102", Event: models.ScriptEventUpdate, Enabled: true,
+			Source: `if doc.content.course == "CS102" and doc.content.room == "X" then reject("room X is closed") end`})
+		require.NoError(t, err)
+		defer db.DeleteScript(script.ID)
+
+		_, err = db.ReplaceInDocuments(ReplaceSpec{OwnerID: "owner", Path: "room", Old: "C3", HasOld: true, New: "X"})
+		var msgErr *i18n.Error
+		require.True(t, errors.As(err, &msgErr), err)
+		assert.Equal(t, i18n.MsgScriptRejected, msgErr.ID)
+
+		stored, _ := db.GetDocumentByID(first.ID)
+		assert.Equal(t, "C3", stored.Content.(map[string]any)["room"], "no document was changed")
+	})
+
+	t.Run("Invalid query", func(t *testing.T) {
+		_, err := db.ReplaceInDocuments(ReplaceSpec{OwnerID: "owner", ContentQuery: []string{"room sortof 1"}, Path: "room", Old: "a", HasOld: true, New: "b"})
+		assert.Error(t, err)
+	})
+}
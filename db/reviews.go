@@ -0,0 +1,246 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/i18n"
+	"docserver/models"
+	"docserver/utils"
+	"log"
+	"maps"
+	"sort"
+)
+
+// --- Reviews ---
+
+// Limits on review feedback
+const (
+	minReviewRating       = 1
+	maxReviewRating       = 5
+	maxReviewCriteria     = 20
+	maxReviewCriterionLen = 64
+)
+
+// ReviewFeedback is what a reviewer submits.
+type ReviewFeedback struct {
+	Rating   int            // Overall rating, 1-5
+	Criteria map[string]int // Optional ratings (1-5) per criterion
+	Comment  string
+}
+
+// ReviewSummary aggregates the reviews of a document.
+type ReviewSummary struct {
+	Assigned      int                `json:"assigned"`
+	Pending       int                `json:"pending"`
+	Submitted     int                `json:"submitted"`
+	AverageRating *float64           `json:"average_rating,omitempty"` // Of the submitted reviews; nil if there are none
+	Criteria      map[string]float64 `json:"criteria"`                 // Average rating per criterion, over the reviews that rated it
+}
+
+// AssignReview assigns a reviewer to a document. The reviewer must exist and may not own the
+// document or already review it. Whether the caller may assign reviewers is checked at handler level.
+func (db *Database) AssignReview(docID, reviewerID, assignedBy string) (models.Review, error) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	doc, found := db.Database.Documents[docID]
+	if !found {
+		return models.Review{}, apperr.NotFound("document with ID '%s' not found", docID)
+	}
+	if _, found := db.Database.Profiles[reviewerID]; !found {
+		return models.Review{}, apperr.Wrap(apperr.ErrNotFound, i18n.NewError(i18n.MsgReviewerNotFound, reviewerID))
+	}
+	if reviewerID == doc.OwnerID {
+		return models.Review{}, apperr.Wrap(apperr.ErrValidation, i18n.NewError(i18n.MsgReviewOwnDocument))
+	}
+	for _, review := range db.Database.Reviews {
+		if review.DocumentID == docID && review.ReviewerID == reviewerID {
+			return models.Review{}, apperr.Wrap(apperr.ErrConflict, i18n.NewError(i18n.MsgReviewAlreadyAssigned, reviewerID))
+		}
+	}
+
+	id, err := db.newID(utils.IDKindReview, func(id string) bool { _, taken := db.Database.Reviews[id]; return taken })
+	if err != nil {
+		return models.Review{}, err
+	}
+	review := models.Review{
+		ID:         id,
+		DocumentID: docID,
+		ReviewerID: reviewerID,
+		AssignedBy: assignedBy,
+		Status:     models.ReviewStatusPending,
+		AssignedAt: db.now().UTC(),
+	}
+	db.Database.Reviews[id] = review
+	log.Printf("INFO: Profile ID %s assigned Profile ID %s to review Document ID %s", assignedBy, reviewerID, docID)
+
+	db.requestSave()
+	return review, nil
+}
+
+// GetReview returns a review by its ID.
+func (db *Database) GetReview(id string) (models.Review, bool) {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+
+	review, found := db.Database.Reviews[id]
+	return review, found
+}
+
+// ListDocumentReviews returns the reviews of a document, in the order they were assigned.
+func (db *Database) ListDocumentReviews(docID string) []models.Review {
+	return db.listReviews(func(review models.Review) bool { return review.DocumentID == docID })
+}
+
+// ListReviewsAssignedTo returns the reviews a profile was assigned, in the order they were
+// assigned, optionally only those with the given status ("" = any).
+func (db *Database) ListReviewsAssignedTo(reviewerID, status string) []models.Review {
+	return db.listReviews(func(review models.Review) bool {
+		return review.ReviewerID == reviewerID && (status == "" || review.Status == status)
+	})
+}
+
+// listReviews returns the reviews matching keep, oldest assignment first.
+func (db *Database) listReviews(keep func(models.Review) bool) []models.Review {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+
+	reviews := make([]models.Review, 0)
+	for _, review := range db.Database.Reviews {
+		if keep(review) {
+			reviews = append(reviews, review)
+		}
+	}
+	sort.Slice(reviews, func(i, j int) bool {
+		if !reviews[i].AssignedAt.Equal(reviews[j].AssignedAt) {
+			return reviews[i].AssignedAt.Before(reviews[j].AssignedAt)
+		}
+		return reviews[i].ID < reviews[j].ID
+	})
+	return reviews
+}
+
+// IsAssignedReviewer reports whether the profile was assigned to review the document.
+func (db *Database) IsAssignedReviewer(docID, profileID string) bool {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+	return (&ReadView{db: db}).IsAssignedReviewer(docID, profileID)
+}
+
+// SubmitReview records the reviewer's feedback, replacing any given before.
+// Only the assigned reviewer may submit it.
+func (db *Database) SubmitReview(id, reviewerID string, feedback ReviewFeedback) (models.Review, error) {
+	if err := validateReviewFeedback(feedback); err != nil {
+		return models.Review{}, err
+	}
+
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	review, found := db.Database.Reviews[id]
+	if !found {
+		return models.Review{}, apperr.Wrap(apperr.ErrNotFound, i18n.NewError(i18n.MsgReviewNotFound, id))
+	}
+	if review.ReviewerID != reviewerID {
+		return models.Review{}, apperr.Wrap(apperr.ErrForbidden, i18n.NewError(i18n.MsgReviewReviewerOnly))
+	}
+
+	now := db.now().UTC()
+	review.Status = models.ReviewStatusSubmitted
+	review.Rating = feedback.Rating
+	review.Criteria = maps.Clone(feedback.Criteria)
+	review.Comment = feedback.Comment
+	review.SubmittedAt = &now
+	db.Database.Reviews[id] = review
+	log.Printf("INFO: Profile ID %s submitted Review ID %s of Document ID %s", reviewerID, id, review.DocumentID)
+
+	db.requestSave()
+	return review, nil
+}
+
+// validateReviewFeedback checks that the ratings are in range and the criteria are few and named.
+func validateReviewFeedback(feedback ReviewFeedback) error {
+	if feedback.Rating < minReviewRating || feedback.Rating > maxReviewRating {
+		return apperr.Wrap(apperr.ErrValidation, i18n.NewError(i18n.MsgReviewInvalidRating, feedback.Rating, "rating"))
+	}
+	if len(feedback.Criteria) > maxReviewCriteria {
+		return apperr.Wrap(apperr.ErrValidation, i18n.NewError(i18n.MsgReviewInvalidCriteria, maxReviewCriteria, maxReviewCriterionLen))
+	}
+	for name, rating := range feedback.Criteria {
+		if name == "" || len(name) > maxReviewCriterionLen {
+			return apperr.Wrap(apperr.ErrValidation, i18n.NewError(i18n.MsgReviewInvalidCriteria, maxReviewCriteria, maxReviewCriterionLen))
+		}
+		if rating < minReviewRating || rating > maxReviewRating {
+			return apperr.Wrap(apperr.ErrValidation, i18n.NewError(i18n.MsgReviewInvalidRating, rating, name))
+		}
+	}
+	return nil
+}
+
+// DeleteReview removes a review assignment and its feedback.
+func (db *Database) DeleteReview(id string) error {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	review, found := db.Database.Reviews[id]
+	if !found {
+		return apperr.Wrap(apperr.ErrNotFound, i18n.NewError(i18n.MsgReviewNotFound, id))
+	}
+	delete(db.Database.Reviews, id)
+	log.Printf("INFO: Deleted Review ID %s of Document ID %s", id, review.DocumentID)
+
+	db.requestSave()
+	return nil
+}
+
+// deleteReviewsOf removes the reviews of a deleted document. Must be called with the write lock held.
+func (db *Database) deleteReviewsOf(docID string) int {
+	deleted := 0
+	for id, review := range db.Database.Reviews {
+		if review.DocumentID == docID {
+			delete(db.Database.Reviews, id)
+			deleted++
+		}
+	}
+	return deleted
+}
+
+// deleteReviewsBy removes the reviews a profile was assigned. Must be called with the write lock held.
+func (db *Database) deleteReviewsBy(reviewerID string) int {
+	deleted := 0
+	for id, review := range db.Database.Reviews {
+		if review.ReviewerID == reviewerID {
+			delete(db.Database.Reviews, id)
+			deleted++
+		}
+	}
+	return deleted
+}
+
+// SummarizeReviews aggregates reviews: how many are pending and submitted, and the average
+// overall and per-criterion ratings of the submitted ones.
+func SummarizeReviews(reviews []models.Review) ReviewSummary {
+	summary := ReviewSummary{Assigned: len(reviews), Criteria: map[string]float64{}}
+	ratingSum := 0
+	criterionSums := map[string]int{}
+	criterionCounts := map[string]int{}
+	for _, review := range reviews {
+		if review.Status != models.ReviewStatusSubmitted {
+			summary.Pending++
+			continue
+		}
+		summary.Submitted++
+		ratingSum += review.Rating
+		for name, rating := range review.Criteria {
+			criterionSums[name] += rating
+			criterionCounts[name]++
+		}
+	}
+	if summary.Submitted > 0 {
+		average := float64(ratingSum) / float64(summary.Submitted)
+		summary.AverageRating = &average
+	}
+	for name, sum := range criterionSums {
+		summary.Criteria[name] = float64(sum) / float64(criterionCounts[name])
+	}
+	return summary
+}
@@ -0,0 +1,127 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabase_Reviews(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	profile := func(email string) string {
+		created, err := db.CreateProfile(models.Profile{Email: email, FirstName: "Review", LastName: "Test"})
+		require.NoError(t, err)
+		return created.ID
+	}
+	ownerID, aliceID, bobID := profile("owner@example.com"), profile("alice@example.com"), profile("bob@example.com")
+	doc, err := db.CreateDocument(models.Document{OwnerID: ownerID, Content: map[string]any{"essay": "..."}})
+	require.NoError(t, err)
+
+	t.Run("Invalid assignments", func(t *testing.T) {
+		_, err := db.AssignReview("missing", aliceID, ownerID)
+		assert.ErrorIs(t, err, apperr.ErrNotFound)
+		_, err = db.AssignReview(doc.ID, "nobody", ownerID)
+		assert.ErrorIs(t, err, apperr.ErrNotFound)
+		_, err = db.AssignReview(doc.ID, ownerID, ownerID)
+		assert.ErrorIs(t, err, apperr.ErrValidation, "owners cannot review their own documents")
+	})
+
+	aliceReview, err := db.AssignReview(doc.ID, aliceID, ownerID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ReviewStatusPending, aliceReview.Status)
+	assert.True(t, db.IsAssignedReviewer(doc.ID, aliceID))
+	_, err = db.AssignReview(doc.ID, aliceID, ownerID)
+	assert.ErrorIs(t, err, apperr.ErrConflict)
+	bobReview, err := db.AssignReview(doc.ID, bobID, ownerID)
+	require.NoError(t, err)
+
+	t.Run("Submit feedback", func(t *testing.T) {
+		_, err := db.SubmitReview(aliceReview.ID, bobID, ReviewFeedback{Rating: 3})
+		assert.ErrorIs(t, err, apperr.ErrForbidden, "only the reviewer gives feedback")
+		_, err = db.SubmitReview(aliceReview.ID, aliceID, ReviewFeedback{Rating: 6})
+		assert.ErrorIs(t, err, apperr.ErrValidation)
+		_, err = db.SubmitReview(aliceReview.ID, aliceID, ReviewFeedback{Rating: 4, Criteria: map[string]int{"clarity": 0}})
+		assert.ErrorIs(t, err, apperr.ErrValidation)
+		_, err = db.SubmitReview(aliceReview.ID, aliceID, ReviewFeedback{Rating: 4, Criteria: map[string]int{"": 3}})
+		assert.ErrorIs(t, err, apperr.ErrValidation)
+
+		criteria := map[string]int{"clarity": 5, "accuracy": 3}
+		submitted, err := db.SubmitReview(aliceReview.ID, aliceID, ReviewFeedback{Rating: 4, Criteria: criteria, Comment: "Good"})
+		require.NoError(t, err)
+		criteria["clarity"] = 1
+		assert.Equal(t, models.ReviewStatusSubmitted, submitted.Status)
+		assert.Equal(t, 5, submitted.Criteria["clarity"], "the criteria are copied")
+		require.NotNil(t, submitted.SubmittedAt)
+
+		assert.Len(t, db.ListReviewsAssignedTo(aliceID, ""), 1)
+		assert.Empty(t, db.ListReviewsAssignedTo(aliceID, models.ReviewStatusPending))
+		assert.Len(t, db.ListReviewsAssignedTo(bobID, models.ReviewStatusPending), 1)
+	})
+
+	t.Run("Summary", func(t *testing.T) {
+		_, err := db.SubmitReview(bobReview.ID, bobID, ReviewFeedback{Rating: 1, Criteria: map[string]int{"clarity": 2}})
+		require.NoError(t, err)
+		reviews := db.ListDocumentReviews(doc.ID)
+		require.Len(t, reviews, 2)
+		assert.Equal(t, aliceReview.ID, reviews[0].ID, "oldest assignment first")
+
+		summary := SummarizeReviews(reviews)
+		assert.Equal(t, 2, summary.Assigned)
+		assert.Equal(t, 2, summary.Submitted)
+		assert.Equal(t, 0, summary.Pending)
+		require.NotNil(t, summary.AverageRating)
+		assert.InDelta(t, 2.5, *summary.AverageRating, 0.001)
+		assert.InDelta(t, 3.5, summary.Criteria["clarity"], 0.001)
+		assert.InDelta(t, 3.0, summary.Criteria["accuracy"], 0.001, "only reviews rating a criterion count")
+
+		assert.Nil(t, SummarizeReviews(nil).AverageRating)
+	})
+
+	t.Run("Removal", func(t *testing.T) {
+		require.NoError(t, db.DeleteReview(bobReview.ID))
+		assert.False(t, db.IsAssignedReviewer(doc.ID, bobID))
+		assert.ErrorIs(t, db.DeleteReview(bobReview.ID), apperr.ErrNotFound)
+
+		require.NoError(t, db.DeleteDocument(doc.ID))
+		_, found := db.GetReview(aliceReview.ID)
+		assert.False(t, found, "reviews go with their document")
+	})
+}
+
+func TestDatabase_ReviewOrphansAndErasure(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	owner, err := db.CreateProfile(models.Profile{Email: "owner@example.com"})
+	require.NoError(t, err)
+	reviewer, err := db.CreateProfile(models.Profile{Email: "reviewer@example.com"})
+	require.NoError(t, err)
+	doc, err := db.CreateDocument(models.Document{OwnerID: owner.ID, Content: "text"})
+	require.NoError(t, err)
+	review, err := db.AssignReview(doc.ID, reviewer.ID, owner.ID)
+	require.NoError(t, err)
+
+	// A reviewer deleted without erasure leaves an orphaned review
+	db.Database.Mu.Lock()
+	db.removeProfile(reviewer.ID)
+	db.Database.Mu.Unlock()
+	report := db.FindOrphans()
+	assert.Equal(t, 1, report.Counts[OrphanReviews])
+	db.CleanOrphans()
+	_, found := db.GetReview(review.ID)
+	assert.False(t, found)
+
+	other, err := db.CreateProfile(models.Profile{Email: "other@example.com"})
+	require.NoError(t, err)
+	_, err = db.AssignReview(doc.ID, other.ID, owner.ID)
+	require.NoError(t, err)
+	erasure, err := db.EraseProfile(owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, erasure.ReviewsDeleted)
+	assert.Empty(t, db.ListReviewsAssignedTo(other.ID, ""))
+}
@@ -0,0 +1,342 @@
+package db
+
+import (
+	"context"
+	"docserver/apperr"
+	"docserver/models"
+	"docserver/utils"
+	"log"
+	"sort"
+	"time"
+)
+
+// --- Scheduled Exports ---
+
+// maxScheduleRuns is how many runs (and download snapshots) are kept per schedule.
+const maxScheduleRuns = 20
+
+// scheduleWebhookTimeout limits each delivery of a snapshot to a webhook target.
+const scheduleWebhookTimeout = 10 * time.Second
+
+// ScheduleExport is the snapshot produced by a schedule run, as downloaded or POSTed to a webhook.
+type ScheduleExport struct {
+	ScheduleID   string            `json:"schedule_id"`
+	ScheduleName string            `json:"schedule_name"`
+	RunID        string            `json:"run_id"`
+	GeneratedAt  time.Time         `json:"generated_at"` // UTC
+	Documents    []models.Document `json:"documents"`    // Oldest first
+}
+
+// CreateSchedule stores a new schedule; its first run is one interval from now.
+// The fields are validated at handler level.
+func (db *Database) CreateSchedule(schedule models.Schedule) (models.Schedule, error) {
+	interval, err := time.ParseDuration(schedule.Interval)
+	if err != nil {
+		return models.Schedule{}, apperr.Validation("invalid interval '%s': %w", schedule.Interval, err)
+	}
+
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	id, err := db.newID(utils.IDKindSchedule, func(id string) bool { _, taken := db.Database.Schedules[id]; return taken })
+	if err != nil {
+		return models.Schedule{}, err
+	}
+	now := db.now().UTC()
+	schedule.ID = id
+	schedule.NextRun = now.Add(interval)
+	schedule.LastRun = nil
+	schedule.CreationDate = now
+	schedule.LastModifiedDate = now
+
+	db.Database.Schedules[schedule.ID] = schedule
+	log.Printf("INFO: Created Schedule ID %s ('%s') for Profile ID %s every %s", schedule.ID, schedule.Name, schedule.OwnerID, schedule.Interval)
+
+	db.requestSave()
+	return schedule, nil
+}
+
+// GetSchedule retrieves a schedule by its ID.
+func (db *Database) GetSchedule(id string) (models.Schedule, bool) {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+
+	schedule, found := db.Database.Schedules[id]
+	return schedule, found
+}
+
+// ListSchedules returns the schedules owned by a profile, oldest first.
+func (db *Database) ListSchedules(ownerID string) []models.Schedule {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+
+	schedules := make([]models.Schedule, 0)
+	for _, schedule := range db.Database.Schedules {
+		if schedule.OwnerID == ownerID {
+			schedules = append(schedules, schedule)
+		}
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		if !schedules[i].CreationDate.Equal(schedules[j].CreationDate) {
+			return schedules[i].CreationDate.Before(schedules[j].CreationDate)
+		}
+		return schedules[i].ID < schedules[j].ID
+	})
+	return schedules
+}
+
+// UpdateSchedule replaces a schedule's settings. The next run is rescheduled to one interval from now.
+func (db *Database) UpdateSchedule(id string, updated models.Schedule) (models.Schedule, error) {
+	interval, err := time.ParseDuration(updated.Interval)
+	if err != nil {
+		return models.Schedule{}, apperr.Validation("invalid interval '%s': %w", updated.Interval, err)
+	}
+
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	schedule, found := db.Database.Schedules[id]
+	if !found {
+		return models.Schedule{}, apperr.NotFound("schedule with ID '%s' not found", id)
+	}
+	now := db.now().UTC()
+	schedule.Name = updated.Name
+	schedule.ContentQuery = updated.ContentQuery
+	schedule.Scope = updated.Scope
+	schedule.Interval = updated.Interval
+	schedule.Target = updated.Target
+	schedule.WebhookURL = updated.WebhookURL
+	schedule.Enabled = updated.Enabled
+	schedule.NextRun = now.Add(interval)
+	schedule.LastModifiedDate = now
+
+	db.Database.Schedules[id] = schedule
+	log.Printf("INFO: Updated Schedule ID %s ('%s')", id, schedule.Name)
+
+	db.requestSave()
+	return schedule, nil
+}
+
+// DeleteSchedule removes a schedule and its run history.
+func (db *Database) DeleteSchedule(id string) error {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	if _, found := db.Database.Schedules[id]; !found {
+		return apperr.NotFound("schedule with ID '%s' not found", id)
+	}
+	delete(db.Database.Schedules, id)
+	delete(db.Database.ScheduleRuns, id)
+	log.Printf("INFO: Deleted Schedule ID %s", id)
+
+	db.requestSave()
+	return nil
+}
+
+// GetScheduleRuns returns a schedule's run history, newest first, without the snapshots.
+func (db *Database) GetScheduleRuns(scheduleID string) []models.ScheduleRun {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+
+	stored := db.Database.ScheduleRuns[scheduleID]
+	runs := make([]models.ScheduleRun, 0, len(stored))
+	for i := len(stored) - 1; i >= 0; i-- {
+		run := stored[i]
+		run.Documents = nil
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+// GetScheduleExport returns the snapshot kept by a successful run of a download schedule.
+func (db *Database) GetScheduleExport(scheduleID, runID string) (ScheduleExport, error) {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+
+	schedule, found := db.Database.Schedules[scheduleID]
+	if !found {
+		return ScheduleExport{}, apperr.NotFound("schedule with ID '%s' not found", scheduleID)
+	}
+	for _, run := range db.Database.ScheduleRuns[scheduleID] {
+		if run.ID != runID {
+			continue
+		}
+		if run.Status != models.ScheduleRunSucceeded || run.Target != models.ScheduleTargetDownload {
+			break
+		}
+		documents := run.Documents
+		if documents == nil {
+			documents = []models.Document{}
+		}
+		return newScheduleExport(schedule, run.ID, run.StartedAt, documents), nil
+	}
+	return ScheduleExport{}, apperr.NotFound("download for run '%s' of schedule '%s' not found", runID, scheduleID)
+}
+
+// RunSchedule exports the documents currently matching a schedule and delivers the snapshot:
+// download targets keep it in the run history, webhook targets POST it to the schedule's URL.
+// The run is recorded whether it succeeded or not; a failed run is not an error.
+func (db *Database) RunSchedule(ctx context.Context, scheduleID, trigger string) (models.ScheduleRun, error) {
+	schedule, found := db.GetSchedule(scheduleID)
+	if !found {
+		return models.ScheduleRun{}, apperr.NotFound("schedule with ID '%s' not found", scheduleID)
+	}
+
+	run := models.ScheduleRun{
+		ID:        db.generateID(utils.IDKindScheduleRun), // Runs are only looked up within their schedule
+		Trigger:   trigger,
+		Target:    schedule.Target,
+		Status:    models.ScheduleRunSucceeded,
+		StartedAt: db.now().UTC(),
+	}
+	documents, err := db.scheduleSnapshot(schedule)
+	if err == nil {
+		run.DocumentCount = len(documents)
+		switch schedule.Target {
+		case models.ScheduleTargetWebhook:
+			err = db.deliverScheduleExport(ctx, newScheduleExport(schedule, run.ID, run.StartedAt, documents), schedule.WebhookURL)
+		default:
+			run.Documents = documents
+		}
+	}
+	if err != nil {
+		run.Status = models.ScheduleRunFailed
+		run.Error = err.Error()
+		run.Documents = nil
+		log.Printf("WARN: Run %s of Schedule ID %s failed: %v", run.ID, scheduleID, err)
+	}
+	run.FinishedAt = db.now().UTC()
+
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	schedule, found = db.Database.Schedules[scheduleID]
+	if !found { // Deleted while running
+		return run, nil
+	}
+	startedAt := run.StartedAt
+	schedule.LastRun = &startedAt
+	if trigger == models.ScheduleTriggerTimer {
+		if interval, err := time.ParseDuration(schedule.Interval); err == nil {
+			schedule.NextRun = run.FinishedAt.Add(interval)
+		}
+	}
+	db.Database.Schedules[scheduleID] = schedule
+
+	runs := append(db.Database.ScheduleRuns[scheduleID], run)
+	if len(runs) > maxScheduleRuns {
+		runs = runs[len(runs)-maxScheduleRuns:]
+	}
+	db.Database.ScheduleRuns[scheduleID] = runs
+
+	db.requestSave()
+	run.Documents = nil
+	return run, nil
+}
+
+// ProcessDueSchedules runs every enabled schedule whose next run is due by now.
+// It returns the number of schedules run.
+func (db *Database) ProcessDueSchedules(now time.Time) int {
+	db.Database.Mu.RLock()
+	var due []string
+	for id, schedule := range db.Database.Schedules {
+		if schedule.Enabled && !now.Before(schedule.NextRun) {
+			due = append(due, id)
+		}
+	}
+	db.Database.Mu.RUnlock()
+
+	ran := 0
+	for _, id := range due {
+		if _, err := db.RunSchedule(context.Background(), id, models.ScheduleTriggerTimer); err != nil {
+			log.Printf("ERROR: Scheduled run of Schedule ID %s failed: %v", id, err)
+			continue
+		}
+		ran++
+	}
+	return ran
+}
+
+// StartScheduleWorker periodically runs ProcessDueSchedules in the background.
+// Call the returned function to stop the worker.
+func (db *Database) StartScheduleWorker(interval time.Duration) (stop func()) {
+	return startWorker(interval, db.now, func(now time.Time) { db.ProcessDueSchedules(now) })
+}
+
+// scheduleSnapshot collects every document the schedule's owner can access that matches its query.
+func (db *Database) scheduleSnapshot(schedule models.Schedule) ([]models.Document, error) {
+	if _, found := db.GetProfileByID(schedule.OwnerID); !found {
+		return nil, apperr.NotFound("owner profile '%s' not found", schedule.OwnerID)
+	}
+	params := QueryDocumentsParams{
+		AuthUserID:   schedule.OwnerID,
+		Scope:        schedule.Scope,
+		ContentQuery: schedule.ContentQuery,
+		SortBy:       "creation_date",
+		Order:        "asc",
+		Limit:        MaxLimit,
+	}
+	documents := make([]models.Document, 0)
+	for params.Page = 1; ; params.Page++ {
+		page, total, err := db.QueryDocuments(params)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, page...)
+		if len(page) == 0 || len(documents) >= total {
+			return documents, nil
+		}
+	}
+}
+
+// deliverScheduleExport POSTs a snapshot to a webhook on one of the allowed domains.
+func (db *Database) deliverScheduleExport(ctx context.Context, export ScheduleExport, webhookURL string) error {
+	target, err := utils.ParseFetchURL(webhookURL)
+	if err != nil {
+		return apperr.Validation("invalid webhook URL '%s': %w", webhookURL, err)
+	}
+	return utils.PostJSON(ctx, target, export, utils.FetchOptions{
+		AllowedDomains: db.config.WebhookAllowedDomains,
+		Timeout:        scheduleWebhookTimeout,
+	})
+}
+
+// newScheduleExport builds the snapshot of a run.
+func newScheduleExport(schedule models.Schedule, runID string, generatedAt time.Time, documents []models.Document) ScheduleExport {
+	return ScheduleExport{
+		ScheduleID:   schedule.ID,
+		ScheduleName: schedule.Name,
+		RunID:        runID,
+		GeneratedAt:  generatedAt,
+		Documents:    documents,
+	}
+}
+
+// deleteSchedulesOf removes the schedules a profile owns and strips the profile's documents from
+// other users' download snapshots. It returns the number of schedules deleted and snapshots changed.
+// Must be called with the write lock held.
+func (db *Database) deleteSchedulesOf(profileID string) (deleted, scrubbed int) {
+	for id, schedule := range db.Database.Schedules {
+		if schedule.OwnerID == profileID {
+			delete(db.Database.Schedules, id)
+			delete(db.Database.ScheduleRuns, id)
+			deleted++
+		}
+	}
+	for _, runs := range db.Database.ScheduleRuns {
+		for i, run := range runs {
+			kept := make([]models.Document, 0, len(run.Documents))
+			for _, doc := range run.Documents {
+				if doc.OwnerID != profileID {
+					kept = append(kept, doc)
+				}
+			}
+			if len(kept) != len(run.Documents) {
+				runs[i].Documents = kept
+				runs[i].DocumentCount = len(kept)
+				scrubbed++
+			}
+		}
+	}
+	return deleted, scrubbed
+}
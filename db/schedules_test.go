@@ -0,0 +1,174 @@
+package db
+
+import (
+	"context"
+	"docserver/models"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabase_SchedulesCRUD(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	before := time.Now().UTC()
+	first, err := db.CreateSchedule(models.Schedule{OwnerID: "owner", Name: "nightly", Scope: "all", Interval: "24h", Target: models.ScheduleTargetDownload, Enabled: true})
+	require.NoError(t, err)
+	assert.NotEmpty(t, first.ID)
+	assert.False(t, first.NextRun.Before(before.Add(24*time.Hour)), "first run is one interval away")
+	_, err = db.CreateSchedule(models.Schedule{OwnerID: "other", Name: "theirs", Interval: "1h"})
+	require.NoError(t, err)
+	_, err = db.CreateSchedule(models.Schedule{OwnerID: "owner", Interval: "soon"})
+	assert.Error(t, err)
+
+	schedules := db.ListSchedules("owner")
+	require.Len(t, schedules, 1)
+	assert.Equal(t, first.ID, schedules[0].ID)
+
+	updated, err := db.UpdateSchedule(first.ID, models.Schedule{Name: "hourly", Scope: "owned", Interval: "1h", Target: models.ScheduleTargetDownload})
+	require.NoError(t, err)
+	assert.Equal(t, "hourly", updated.Name)
+	assert.Equal(t, "owner", updated.OwnerID, "owner is kept")
+	assert.False(t, updated.Enabled)
+	assert.True(t, updated.NextRun.Before(first.NextRun), "rescheduled for the new interval")
+	_, err = db.UpdateSchedule("missing", models.Schedule{Interval: "1h"})
+	assert.ErrorContains(t, err, "not found")
+
+	require.NoError(t, db.DeleteSchedule(first.ID))
+	_, found := db.GetSchedule(first.ID)
+	assert.False(t, found)
+	assert.ErrorContains(t, db.DeleteSchedule(first.ID), "not found")
+}
+
+func TestDatabase_RunSchedule(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	owner, err := db.CreateProfile(models.Profile{Email: "owner@example.com"})
+	require.NoError(t, err)
+	mine, err := db.CreateDocument(models.Document{OwnerID: owner.ID, Content: map[string]any{"course": "CS101"}})
+	require.NoError(t, err)
+	_, err = db.CreateDocument(models.Document{OwnerID: owner.ID, Content: map[string]any{"course": "CS102"}})
+	require.NoError(t, err)
+	shared, err := db.CreateDocument(models.Document{OwnerID: "someone", Content: map[string]any{"course": "CS101"}})
+	require.NoError(t, err)
+	require.NoError(t, db.SetShareRecord(shared.ID, []string{owner.ID}))
+
+	schedule, err := db.CreateSchedule(models.Schedule{OwnerID: owner.ID, Name: "cs101", ContentQuery: []string{`course equals "CS101"`},
+		Scope: "all", Interval: "1h", Target: models.ScheduleTargetDownload, Enabled: true})
+	require.NoError(t, err)
+
+	t.Run("Download snapshot", func(t *testing.T) {
+		run, err := db.RunSchedule(context.Background(), schedule.ID, models.ScheduleTriggerManual)
+		require.NoError(t, err)
+		assert.Equal(t, models.ScheduleRunSucceeded, run.Status)
+		assert.Equal(t, 2, run.DocumentCount)
+		assert.Nil(t, run.Documents, "snapshots are not returned with the run")
+
+		export, err := db.GetScheduleExport(schedule.ID, run.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{mine.ID, shared.ID}, []string{export.Documents[0].ID, export.Documents[1].ID})
+
+		// Later changes do not alter the snapshot
+		_, err = db.UpdateDocument(mine.ID, map[string]any{"course": "CS101", "edited": true})
+		require.NoError(t, err)
+		export, err = db.GetScheduleExport(schedule.ID, run.ID)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"course": "CS101"}, export.Documents[0].Content)
+
+		stored, _ := db.GetSchedule(schedule.ID)
+		require.NotNil(t, stored.LastRun)
+		assert.Equal(t, schedule.NextRun, stored.NextRun, "manual runs keep the timetable")
+
+		_, err = db.GetScheduleExport(schedule.ID, "missing")
+		assert.Error(t, err)
+	})
+
+	t.Run("Webhook delivery", func(t *testing.T) {
+		var received ScheduleExport
+		hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/down" {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		}))
+		defer hook.Close()
+		db.config.WebhookAllowedDomains = []string{"127.0.0.1"}
+
+		webhook, err := db.CreateSchedule(models.Schedule{OwnerID: owner.ID, Name: "push", Scope: "owned", Interval: "1h",
+			Target: models.ScheduleTargetWebhook, WebhookURL: hook.URL + "/in", Enabled: true})
+		require.NoError(t, err)
+		run, err := db.RunSchedule(context.Background(), webhook.ID, models.ScheduleTriggerManual)
+		require.NoError(t, err)
+		assert.Equal(t, models.ScheduleRunSucceeded, run.Status, run.Error)
+		assert.Equal(t, webhook.ID, received.ScheduleID)
+		assert.Equal(t, run.ID, received.RunID)
+		assert.Len(t, received.Documents, 2)
+		_, err = db.GetScheduleExport(webhook.ID, run.ID)
+		assert.Error(t, err, "webhook runs keep no snapshot")
+
+		webhook.WebhookURL = hook.URL + "/down"
+		_, err = db.UpdateSchedule(webhook.ID, webhook)
+		require.NoError(t, err)
+		run, err = db.RunSchedule(context.Background(), webhook.ID, models.ScheduleTriggerManual)
+		require.NoError(t, err)
+		assert.Equal(t, models.ScheduleRunFailed, run.Status)
+		assert.Contains(t, run.Error, "503")
+
+		runs := db.GetScheduleRuns(webhook.ID)
+		require.Len(t, runs, 2)
+		assert.Equal(t, models.ScheduleRunFailed, runs[0].Status, "newest first")
+	})
+
+	t.Run("Worker runs due schedules", func(t *testing.T) {
+		due := time.Now().UTC().Add(2 * time.Hour)
+		ran := db.ProcessDueSchedules(due)
+		assert.Equal(t, 2, ran)
+		stored, _ := db.GetSchedule(schedule.ID)
+		assert.True(t, stored.NextRun.After(time.Now().UTC()), "next run moved forward")
+		runs := db.GetScheduleRuns(schedule.ID)
+		assert.Equal(t, models.ScheduleTriggerTimer, runs[0].Trigger)
+
+		_, err := db.UpdateSchedule(schedule.ID, models.Schedule{Name: "paused", Scope: "all", Interval: "1h", Target: models.ScheduleTargetDownload, Enabled: false})
+		require.NoError(t, err)
+		assert.Equal(t, 1, db.ProcessDueSchedules(due.Add(2*time.Hour)), "disabled schedules are skipped")
+	})
+
+	t.Run("Run history is capped", func(t *testing.T) {
+		for i := 0; i < maxScheduleRuns+5; i++ {
+			_, err := db.RunSchedule(context.Background(), schedule.ID, models.ScheduleTriggerManual)
+			require.NoError(t, err)
+		}
+		assert.Len(t, db.GetScheduleRuns(schedule.ID), maxScheduleRuns)
+	})
+
+	t.Run("Erasure removes schedules and scrubs snapshots", func(t *testing.T) {
+		viewer, err := db.CreateProfile(models.Profile{Email: "viewer@example.com"})
+		require.NoError(t, err)
+		require.NoError(t, db.SetShareRecord(mine.ID, []string{viewer.ID}))
+		viewerSchedule, err := db.CreateSchedule(models.Schedule{OwnerID: viewer.ID, Name: "copy", Scope: "shared", Interval: "1h", Target: models.ScheduleTargetDownload})
+		require.NoError(t, err)
+		run, err := db.RunSchedule(context.Background(), viewerSchedule.ID, models.ScheduleTriggerManual)
+		require.NoError(t, err)
+		require.Equal(t, 1, run.DocumentCount)
+
+		report, err := db.EraseProfile(owner.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 2, report.SchedulesDeleted)
+		assert.Equal(t, 1, report.SnapshotsScrubbed)
+		assert.Empty(t, db.ListSchedules(owner.ID))
+		_, err = db.RunSchedule(context.Background(), schedule.ID, models.ScheduleTriggerManual)
+		assert.ErrorContains(t, err, "not found")
+
+		export, err := db.GetScheduleExport(viewerSchedule.ID, run.ID)
+		require.NoError(t, err)
+		assert.Empty(t, export.Documents)
+	})
+}
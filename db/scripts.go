@@ -0,0 +1,139 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/i18n"
+	"docserver/models"
+	"docserver/scripting"
+	"docserver/utils"
+	"log"
+	"sort"
+)
+
+// --- Document Scripts ---
+
+// CreateScript stores a new script. The event and source are validated at handler level.
+func (db *Database) CreateScript(script models.Script) (models.Script, error) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	id, err := db.newID(utils.IDKindScript, func(id string) bool { _, taken := db.Database.Scripts[id]; return taken })
+	if err != nil {
+		return models.Script{}, err
+	}
+	now := db.now().UTC()
+	script.ID = id
+	script.CreationDate = now
+	script.LastModifiedDate = now
+
+	db.Database.Scripts[script.ID] = script
+	log.Printf("INFO: Created Script ID %s ('%s') on event '%s'", script.ID, script.Name, script.Event)
+
+	db.requestSave()
+	return script, nil
+}
+
+// GetScript retrieves a script by its ID.
+func (db *Database) GetScript(id string) (models.Script, bool) {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+
+	script, found := db.Database.Scripts[id]
+	return script, found
+}
+
+// ListScripts returns all scripts in the order they run: oldest first.
+func (db *Database) ListScripts() []models.Script {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+
+	return db.sortedScripts()
+}
+
+// UpdateScript replaces a script's name, event, source and enabled flag.
+func (db *Database) UpdateScript(id string, updated models.Script) (models.Script, error) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	script, found := db.Database.Scripts[id]
+	if !found {
+		return models.Script{}, apperr.NotFound("script with ID '%s' not found", id)
+	}
+	script.Name = updated.Name
+	script.Event = updated.Event
+	script.Source = updated.Source
+	script.Enabled = updated.Enabled
+	script.LastModifiedDate = db.now().UTC()
+
+	db.Database.Scripts[id] = script
+	log.Printf("INFO: Updated Script ID %s ('%s')", id, script.Name)
+
+	db.requestSave()
+	return script, nil
+}
+
+// DeleteScript removes a script.
+func (db *Database) DeleteScript(id string) error {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	if _, found := db.Database.Scripts[id]; !found {
+		return apperr.NotFound("script with ID '%s' not found", id)
+	}
+	delete(db.Database.Scripts, id)
+	log.Printf("INFO: Deleted Script ID %s", id)
+
+	db.requestSave()
+	return nil
+}
+
+// sortedScripts returns the scripts oldest first. Must be called with the lock held.
+func (db *Database) sortedScripts() []models.Script {
+	scripts := make([]models.Script, 0, len(db.Database.Scripts))
+	for _, script := range db.Database.Scripts {
+		scripts = append(scripts, script)
+	}
+	sort.Slice(scripts, func(i, j int) bool {
+		if !scripts[i].CreationDate.Equal(scripts[j].CreationDate) {
+			return scripts[i].CreationDate.Before(scripts[j].CreationDate)
+		}
+		return scripts[i].ID < scripts[j].ID
+	})
+	return scripts
+}
+
+// runDocumentScripts runs the enabled scripts for an event on a document, in order, each seeing
+// the content left by the previous one, and returns the final content. previous is the stored
+// content (nil on create). A script that rejects the change or fails stops the operation with
+// an *i18n.Error (MsgScriptRejected or MsgScriptFailed).
+// Must be called with the write lock held.
+func (db *Database) runDocumentScripts(event string, doc models.Document, previous any) (any, error) {
+	content := doc.Content
+	limits := scripting.Limits{}
+	if db.config != nil {
+		limits.Timeout = db.config.ScriptTimeout
+	}
+
+	for _, script := range db.sortedScripts() {
+		if !script.Enabled || script.Event != event {
+			continue
+		}
+		result, err := scripting.Run(script.Name, script.Source, scripting.Input{
+			Event:      event,
+			DocumentID: doc.ID,
+			OwnerID:    doc.OwnerID,
+			Content:    content,
+			Previous:   previous,
+		}, limits)
+		if err != nil {
+			log.Printf("ERROR: Script ID %s ('%s') failed on %s of Document ID %s: %v", script.ID, script.Name, event, doc.ID, err)
+			return nil, i18n.NewError(i18n.MsgScriptFailed, script.Name, err)
+		}
+		if result.Rejected {
+			log.Printf("INFO: Script ID %s ('%s') rejected %s of Document ID %s: %s", script.ID, script.Name, event, doc.ID, result.Reason)
+			return nil, i18n.NewError(i18n.MsgScriptRejected, script.Name, result.Reason)
+		}
+		content = result.Content
+	}
+	return content, nil
+}
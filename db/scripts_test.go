@@ -0,0 +1,93 @@
+package db
+
+import (
+	"docserver/i18n"
+	"docserver/models"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabase_ScriptsCRUD(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	first, err := db.CreateScript(models.Script{Name: "first", Event: models.ScriptEventCreate, Source: "-- noop", Enabled: true, CreatedBy: "admin"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, first.ID)
+	assert.False(t, first.CreationDate.IsZero())
+	second, err := db.CreateScript(models.Script{Name: "second", Event: models.ScriptEventUpdate, Source: "-- noop"})
+	require.NoError(t, err)
+
+	scripts := db.ListScripts()
+	require.Len(t, scripts, 2)
+	assert.Equal(t, []string{first.ID, second.ID}, []string{scripts[0].ID, scripts[1].ID}, "oldest first")
+
+	updated, err := db.UpdateScript(first.ID, models.Script{Name: "renamed", Event: models.ScriptEventDelete, Source: "-- changed", Enabled: false})
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", updated.Name)
+	assert.Equal(t, "admin", updated.CreatedBy, "creator is kept")
+	assert.False(t, updated.Enabled)
+
+	_, err = db.UpdateScript("missing", models.Script{})
+	assert.ErrorContains(t, err, "not found")
+
+	require.NoError(t, db.DeleteScript(first.ID))
+	_, found := db.GetScript(first.ID)
+	assert.False(t, found)
+	assert.ErrorContains(t, db.DeleteScript(first.ID), "not found")
+}
+
+func TestDatabase_DocumentScripts(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	db.config.ScriptTimeout = time.Second
+
+	_, err := db.CreateScript(models.Script{Name: "stamp", Event: models.ScriptEventCreate, Enabled: true,
+		Source: `doc.content.owner = doc.owner_id`})
+	require.NoError(t, err)
+	_, err = db.CreateScript(models.Script{Name: "require-title", Event: models.ScriptEventCreate, Enabled: true,
+		Source: `if doc.content.title == nil then reject("a title is required") end`})
+	require.NoError(t, err)
+	_, err = db.CreateScript(models.Script{Name: "disabled", Event: models.ScriptEventCreate, Enabled: false,
+		Source: `reject("never runs")`})
+	require.NoError(t, err)
+	_, err = db.CreateScript(models.Script{Name: "monotonic", Event: models.ScriptEventUpdate, Enabled: true,
+		Source: `if doc.content.score < previous.score then reject("score cannot decrease") end`})
+	require.NoError(t, err)
+	_, err = db.CreateScript(models.Script{Name: "keep-graded", Event: models.ScriptEventDelete, Enabled: true,
+		Source: `if doc.content.graded then reject("graded documents cannot be deleted") end`})
+	require.NoError(t, err)
+
+	doc, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: map[string]any{"title": "T", "score": 1.0}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"title": "T", "score": 1.0, "owner": "owner"}, doc.Content, "scripts run in order")
+
+	_, err = db.CreateDocument(models.Document{OwnerID: "owner", Content: map[string]any{"score": 1.0}})
+	var msgErr *i18n.Error
+	require.True(t, errors.As(err, &msgErr))
+	assert.Equal(t, i18n.MsgScriptRejected, msgErr.ID)
+	assert.Contains(t, err.Error(), "a title is required")
+	assert.Len(t, db.GetAllDocuments(), 1, "rejected documents are not stored")
+
+	_, err = db.UpdateDocument(doc.ID, map[string]any{"title": "T", "score": 0.0})
+	assert.ErrorContains(t, err, "score cannot decrease")
+	current, _ := db.GetDocumentByID(doc.ID)
+	assert.Equal(t, 1, current.Version, "rejected updates change nothing")
+
+	_, err = db.UpdateDocument(doc.ID, map[string]any{"title": "T", "score": 5.0, "graded": true})
+	require.NoError(t, err)
+	assert.ErrorContains(t, db.DeleteDocument(doc.ID), "graded documents cannot be deleted")
+	_, found := db.GetDocumentByID(doc.ID)
+	assert.True(t, found)
+
+	// A failing script stops the operation with MsgScriptFailed
+	_, err = db.CreateScript(models.Script{Name: "broken", Event: models.ScriptEventUpdate, Enabled: true, Source: `local x = nil; x.y = 1`})
+	require.NoError(t, err)
+	_, err = db.UpdateDocument(doc.ID, map[string]any{"title": "T", "score": 6.0})
+	require.True(t, errors.As(err, &msgErr))
+	assert.Equal(t, i18n.MsgScriptFailed, msgErr.ID)
+}
@@ -0,0 +1,132 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/models"
+	"docserver/utils"
+	"log"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// --- Service Accounts ---
+
+// serviceAccountDomain is the email domain of service accounts. It is reserved (RFC 2606), so the
+// addresses never reach anyone and cannot clash with people's.
+const serviceAccountDomain = "service.invalid"
+
+// serviceAccountNameChars matches the runs of characters replaced with "-" in a service account's email.
+var serviceAccountNameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// ServiceAccountEmail returns the email address of the service account named name, e.g.
+// "grading-bot@service.invalid" for "Grading Bot".
+func ServiceAccountEmail(name string) string {
+	return strings.Trim(serviceAccountNameChars.ReplaceAllString(strings.ToLower(name), "-"), "-") + "@" + serviceAccountDomain
+}
+
+// CreateServiceAccount creates a service account named name, whose tokens may carry scopes.
+// It returns an apperr.ErrValidation error if the name or a scope is invalid, and an
+// apperr.ErrConflict one if a service account with the same email exists.
+func (db *Database) CreateServiceAccount(name string, scopes []string, createdBy string) (models.Profile, error) {
+	name = strings.TrimSpace(name)
+	if ServiceAccountEmail(name) == "@"+serviceAccountDomain {
+		return models.Profile{}, apperr.Validation("the name must contain letters or digits")
+	}
+	scopes, err := utils.NormalizeScopes(scopes)
+	if err != nil {
+		return models.Profile{}, apperr.Validation("%v", err)
+	}
+
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	email := ServiceAccountEmail(name)
+	if _, taken := db.profileIDByEmail(email); taken {
+		return models.Profile{}, apperr.Conflict("a service account with the email '%s' already exists", email)
+	}
+	profile, err := db.createProfile(models.Profile{
+		FirstName: name,
+		Email:     email,
+		Service:   &models.ServiceAccount{Scopes: scopes, CreatedBy: createdBy},
+	})
+	if err != nil {
+		return models.Profile{}, err
+	}
+	log.Printf("INFO: Service account %s created by Profile ID %s with scopes %s", profile.ID, createdBy, strings.Join(scopes, ", "))
+	return profile, nil
+}
+
+// GetServiceAccounts returns the service accounts, oldest first.
+func (db *Database) GetServiceAccounts() []models.Profile {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+
+	accounts := []models.Profile{}
+	for _, profile := range db.Database.Profiles {
+		if profile.Service != nil {
+			accounts = append(accounts, profile)
+		}
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		if !accounts[i].CreationDate.Equal(accounts[j].CreationDate) {
+			return accounts[i].CreationDate.Before(accounts[j].CreationDate)
+		}
+		return accounts[i].ID < accounts[j].ID
+	})
+	return accounts
+}
+
+// GetServiceAccount returns the service account with the given ID, or an apperr.ErrNotFound error
+// if there is none (including when the ID is a person's profile).
+func (db *Database) GetServiceAccount(id string) (models.Profile, error) {
+	profile, found := db.GetProfileByID(id)
+	if !found || profile.Service == nil {
+		return models.Profile{}, apperr.NotFound("service account '%s' not found", id)
+	}
+	return profile, nil
+}
+
+// SetServiceAccountScopes replaces the scopes a service account's tokens may carry. Tokens
+// already issued lose the scopes it no longer has.
+func (db *Database) SetServiceAccountScopes(id string, scopes []string) (models.Profile, error) {
+	scopes, err := utils.NormalizeScopes(scopes)
+	if err != nil {
+		return models.Profile{}, apperr.Validation("%v", err)
+	}
+
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	profile, found := db.Database.Profiles[id]
+	if !found || profile.Service == nil {
+		return models.Profile{}, apperr.NotFound("service account '%s' not found", id)
+	}
+	service := *profile.Service
+	service.Scopes = slices.Clone(scopes)
+	profile.Service = &service
+	profile.LastModifiedDate = db.now().UTC()
+	db.putProfile(profile)
+	log.Printf("INFO: Scopes of service account %s set to %s", id, strings.Join(scopes, ", "))
+
+	db.requestSave()
+	return profile, nil
+}
+
+// DeleteServiceAccount deletes a service account; its tokens stop working. The documents it
+// owns are kept, like those of deleted profiles.
+func (db *Database) DeleteServiceAccount(id string) error {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	profile, found := db.Database.Profiles[id]
+	if !found || profile.Service == nil {
+		return apperr.NotFound("service account '%s' not found", id)
+	}
+	db.removeProfile(id)
+	log.Printf("INFO: Deleted service account %s", id)
+
+	db.requestSave()
+	return nil
+}
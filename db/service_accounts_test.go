@@ -0,0 +1,69 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/models"
+	"docserver/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceAccounts(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	assert.Equal(t, "grading-bot@service.invalid", ServiceAccountEmail(" Grading Bot! "))
+
+	bot, err := db.CreateServiceAccount("Grading Bot", []string{"documents:write", "documents:write"}, "usr_admin")
+	require.NoError(t, err)
+	assert.Equal(t, "grading-bot@service.invalid", bot.Email)
+	assert.Empty(t, bot.PasswordHash, "service accounts cannot log in")
+	require.NotNil(t, bot.Service)
+	assert.Equal(t, []string{utils.ScopeDocumentsWrite}, bot.Service.Scopes)
+	assert.Equal(t, "usr_admin", bot.Service.CreatedBy)
+
+	t.Run("Invalid accounts", func(t *testing.T) {
+		_, err := db.CreateServiceAccount("grading bot", []string{"documents:read"}, "usr_admin")
+		assert.ErrorIs(t, err, apperr.ErrConflict)
+		_, err = db.CreateServiceAccount("!!", []string{"documents:read"}, "usr_admin")
+		assert.ErrorIs(t, err, apperr.ErrValidation)
+		_, err = db.CreateServiceAccount("Other Bot", []string{"everything"}, "usr_admin")
+		assert.ErrorIs(t, err, apperr.ErrValidation)
+	})
+
+	t.Run("Listing skips people", func(t *testing.T) {
+		_, err := db.CreateProfile(models.Profile{Email: "person@example.com"})
+		require.NoError(t, err)
+		accounts := db.GetServiceAccounts()
+		require.Len(t, accounts, 1)
+		assert.Equal(t, bot.ID, accounts[0].ID)
+	})
+
+	t.Run("Changing scopes", func(t *testing.T) {
+		updated, err := db.SetServiceAccountScopes(bot.ID, []string{"profiles:read"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{utils.ScopeProfilesRead}, updated.Service.Scopes)
+		_, err = db.SetServiceAccountScopes(bot.ID, nil)
+		assert.ErrorIs(t, err, apperr.ErrValidation)
+
+		profile, err := db.GetServiceAccount(bot.ID)
+		require.NoError(t, err)
+		profile.Service = nil // As built by PUT /profiles/me
+		profile.FirstName = "Renamed Bot"
+		_, err = db.UpdateProfile(bot.ID, profile)
+		require.NoError(t, err)
+		profile, err = db.GetServiceAccount(bot.ID)
+		require.NoError(t, err, "profile updates keep the account a service account")
+		assert.Equal(t, []string{utils.ScopeProfilesRead}, profile.Service.Scopes)
+	})
+
+	t.Run("Deleting", func(t *testing.T) {
+		person, _ := db.GetProfileByEmail("person@example.com")
+		assert.ErrorIs(t, db.DeleteServiceAccount(person.ID), apperr.ErrNotFound, "people are not service accounts")
+		require.NoError(t, db.DeleteServiceAccount(bot.ID))
+		_, err := db.GetServiceAccount(bot.ID)
+		assert.ErrorIs(t, err, apperr.ErrNotFound)
+	})
+}
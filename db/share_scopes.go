@@ -0,0 +1,227 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/models"
+	"docserver/utils"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// --- Share Scopes ---
+
+// parseScopePath splits a share scope path into its object keys.
+func parseScopePath(path string) ([]string, error) {
+	parts := strings.Split(strings.TrimSpace(path), ".")
+	for i, part := range parts {
+		parts[i] = strings.TrimSpace(part)
+		if parts[i] == "" {
+			return nil, apperr.Validation("invalid share path '%s': expected dot-separated object keys", path)
+		}
+	}
+	return parts, nil
+}
+
+// ShareScopeOf returns the scope limiting what viewerID sees of doc, and false if they may see all
+// of it: as its owner, because it is public, or because it is shared with them without a scope.
+func ShareScopeOf(doc models.Document, record models.ShareRecord, viewerID string) (models.ShareScope, bool) {
+	if doc.OwnerID == viewerID || doc.Public {
+		return models.ShareScope{}, false
+	}
+	scope, limited := record.Scopes[viewerID]
+	return scope, limited
+}
+
+// ScopedContent returns the part of content a scoped sharer sees: the subtree at the scope's path,
+// nested under the same keys, or an empty object if the content has nothing there.
+func ScopedContent(content any, scope models.ShareScope) any {
+	parts, err := parseScopePath(scope.Path)
+	if err != nil {
+		return map[string]any{}
+	}
+	value, found := objectPathValue(content, parts)
+	if !found {
+		return map[string]any{}
+	}
+	for i := len(parts) - 1; i >= 0; i-- {
+		value = map[string]any{parts[i]: value}
+	}
+	return value
+}
+
+// ScopedChangedPaths returns the changed paths of an activity entry that a scoped sharer may see:
+// those within the scope's subtree, and those of objects on the way to it (including
+// utils.RootPath), whose change may have changed the subtree. Paths elsewhere are dropped.
+func ScopedChangedPaths(paths []string, scope models.ShareScope) []string {
+	scopeParts, err := parseScopePath(scope.Path)
+	if err != nil {
+		return nil
+	}
+	var visible []string
+	for _, path := range paths {
+		parts := utils.SplitDiffPath(path)
+		n := min(len(parts), len(scopeParts))
+		if slices.Equal(parts[:n], scopeParts[:n]) {
+			visible = append(visible, path)
+		}
+	}
+	return visible
+}
+
+// objectPathValue returns the value at a path of object keys.
+func objectPathValue(content any, parts []string) (any, bool) {
+	current := content
+	for _, part := range parts {
+		node, isObject := current.(map[string]any)
+		if !isObject {
+			return nil, false
+		}
+		value, found := node[part]
+		if !found {
+			return nil, false
+		}
+		current = value
+	}
+	return current, true
+}
+
+// setObjectPathValue sets the value at a path of object keys, creating missing objects on the way,
+// or with found false, removes it. It returns the content.
+func setObjectPathValue(content any, parts []string, value any, found bool) (any, error) {
+	if len(parts) == 0 {
+		return value, nil
+	}
+	node, isObject := content.(map[string]any)
+	if content == nil {
+		node, isObject = map[string]any{}, true
+	}
+	if !isObject {
+		return nil, apperr.Validation("path part '%s' is not inside an object", parts[0])
+	}
+	if _, exists := node[parts[0]]; !exists && !found {
+		return node, nil // Nothing to remove
+	}
+	if len(parts) == 1 && !found {
+		delete(node, parts[0])
+		return node, nil
+	}
+	child, err := setObjectPathValue(node[parts[0]], parts[1:], value, found)
+	if err != nil {
+		return nil, err
+	}
+	node[parts[0]] = child
+	return node, nil
+}
+
+// SetShareScope limits the share of a document with a profile to the subtree of the content at
+// scope.Path, replacing any earlier scope; with a nil scope, the profile can read all of the
+// content again. The document must already be shared with the profile. Permissions are checked
+// at handler level.
+func (db *Database) SetShareScope(docID, profileID string, scope *models.ShareScope) (models.ShareRecord, error) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	if _, found := db.Database.Documents[docID]; !found {
+		return models.ShareRecord{}, apperr.NotFound("document with ID '%s' not found", docID)
+	}
+	if err := db.checkNotFrozen(docID); err != nil {
+		return models.ShareRecord{}, err
+	}
+	record, found := db.Database.ShareRecords[docID]
+	if !found || !slices.Contains(record.SharedWith, profileID) {
+		return models.ShareRecord{}, apperr.NotFound("document '%s' is not shared with profile '%s'", docID, profileID)
+	}
+
+	record.Scopes = maps.Clone(record.Scopes)
+	if scope != nil {
+		parts, err := parseScopePath(scope.Path)
+		if err != nil {
+			return models.ShareRecord{}, err
+		}
+		if record.Scopes == nil {
+			record.Scopes = make(map[string]models.ShareScope)
+		}
+		record.Scopes[profileID] = models.ShareScope{Path: strings.Join(parts, "."), Write: scope.Write}
+		log.Printf("INFO: Share of Document ID %s with '%s' limited to '%s' (write: %t)", docID, profileID, record.Scopes[profileID].Path, scope.Write)
+	} else {
+		delete(record.Scopes, profileID)
+		log.Printf("INFO: Share of Document ID %s with '%s' no longer limited", docID, profileID)
+	}
+	record = pruneShareScopes(record)
+	db.Database.ShareRecords[docID] = record
+
+	db.requestSave()
+	record.DocumentID = docID
+	return record, nil
+}
+
+// UpdateScopedContent replaces the part of a document's content a sharer with write access to it
+// may change: the value at the sharer's scope path in content (shaped like ScopedContent) replaces
+// the one in the document, or removes it if content has none. The rest of the document is kept.
+// It returns the updated document as the sharer sees it, or an ErrForbidden error if the sharer
+// has no scope with write access. The update is recorded as the sharer's and never coalesced.
+func (db *Database) UpdateScopedContent(docID, profileID string, content any) (models.Document, error) {
+	return db.UpdateScopedContentIf(docID, profileID, content, Precondition{})
+}
+
+// UpdateScopedContentIf is UpdateScopedContent for a document that must satisfy pre; the query is
+// evaluated against the whole document, not only the sharer's part.
+func (db *Database) UpdateScopedContentIf(docID, profileID string, content any, pre Precondition) (models.Document, error) {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	existingDoc, found := db.Database.Documents[docID]
+	if !found || documentExpired(existingDoc, db.now()) {
+		return models.Document{}, apperr.NotFound("document with ID '%s' not found", docID)
+	}
+	record := db.Database.ShareRecords[docID]
+	scope, scoped := record.Scopes[profileID]
+	if !scoped || !scope.Write || !slices.Contains(record.SharedWith, profileID) {
+		return models.Document{}, apperr.Forbidden("profile '%s' may not update document '%s'", profileID, docID)
+	}
+	if err := db.checkNotFrozen(docID); err != nil {
+		return models.Document{}, err
+	}
+	if err := db.checkPrecondition(existingDoc, pre); err != nil {
+		return models.Document{}, err
+	}
+
+	parts, err := parseScopePath(scope.Path)
+	if err != nil {
+		return models.Document{}, err
+	}
+	value, found := objectPathValue(content, parts)
+	newContent, err := setObjectPathValue(cloneJSON(existingDoc.Content), parts, cloneJSON(value), found)
+	if err != nil {
+		return models.Document{}, err
+	}
+	if newContent, err = db.prepareDocumentUpdate(existingDoc, newContent); err != nil {
+		return models.Document{}, err
+	}
+	updatedDoc := db.storeDocumentUpdate(existingDoc, newContent, profileID)
+
+	db.requestSave()
+	updatedDoc.Content = ScopedContent(updatedDoc.Content, scope)
+	return updatedDoc, nil
+}
+
+// pruneShareScopes drops the scopes of profiles the document is no longer shared with.
+func pruneShareScopes(record models.ShareRecord) models.ShareRecord {
+	if len(record.Scopes) == 0 {
+		record.Scopes = nil
+		return record
+	}
+	scopes := make(map[string]models.ShareScope, len(record.Scopes))
+	for _, profileID := range record.SharedWith {
+		if scope, found := record.Scopes[profileID]; found {
+			scopes[profileID] = scope
+		}
+	}
+	record.Scopes = scopes
+	if len(scopes) == 0 {
+		record.Scopes = nil
+	}
+	return record
+}
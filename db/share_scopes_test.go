@@ -0,0 +1,86 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShareScopes(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	doc, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: map[string]any{
+		"public":  map[string]any{"title": "Lab 1"},
+		"private": map[string]any{"grade": 17.0},
+	}})
+	require.NoError(t, err)
+	require.NoError(t, db.SetShareRecord(doc.ID, []string{"reader", "writer"}))
+
+	_, err = db.SetShareScope(doc.ID, "stranger", &models.ShareScope{Path: "public"})
+	assert.ErrorIs(t, err, apperr.ErrNotFound, "not shared with them")
+	_, err = db.SetShareScope(doc.ID, "reader", &models.ShareScope{Path: "public..title"})
+	assert.ErrorIs(t, err, apperr.ErrValidation)
+	_, err = db.SetShareScope(doc.ID, "reader", &models.ShareScope{Path: "public"})
+	require.NoError(t, err)
+	record, err := db.SetShareScope(doc.ID, "writer", &models.ShareScope{Path: " public ", Write: true})
+	require.NoError(t, err)
+	assert.Equal(t, models.ShareScope{Path: "public", Write: true}, record.Scopes["writer"])
+
+	t.Run("Scoped sharers see only their part", func(t *testing.T) {
+		scope, limited := ShareScopeOf(doc, record, "reader")
+		require.True(t, limited)
+		assert.Equal(t, map[string]any{"public": map[string]any{"title": "Lab 1"}}, ScopedContent(doc.Content, scope))
+		assert.Equal(t, map[string]any{}, ScopedContent(doc.Content, models.ShareScope{Path: "missing.part"}))
+		_, limited = ShareScopeOf(doc, record, "owner")
+		assert.False(t, limited)
+
+		docs, _, err := db.QueryDocuments(QueryDocumentsParams{AuthUserID: "reader", Scope: "shared", Page: 1, Limit: 10, ContentQuery: []string{`private.grade equals 17`}})
+		require.NoError(t, err)
+		assert.Empty(t, docs, "hidden content does not match")
+		docs, _, err = db.QueryDocuments(QueryDocumentsParams{AuthUserID: "reader", Scope: "shared", Page: 1, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, docs, 1)
+		assert.NotContains(t, docs[0].Content, "private")
+	})
+
+	t.Run("Writers replace only their part", func(t *testing.T) {
+		_, err := db.UpdateScopedContent(doc.ID, "reader", map[string]any{"public": map[string]any{"title": "x"}})
+		assert.ErrorIs(t, err, apperr.ErrForbidden, "read-only scope")
+
+		updated, err := db.UpdateScopedContent(doc.ID, "writer", map[string]any{
+			"public":  map[string]any{"title": "Lab 1 (revised)"},
+			"private": map[string]any{"grade": 20.0},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"public": map[string]any{"title": "Lab 1 (revised)"}}, updated.Content)
+		stored, _ := db.GetDocumentByID(doc.ID)
+		assert.Equal(t, map[string]any{
+			"public":  map[string]any{"title": "Lab 1 (revised)"},
+			"private": map[string]any{"grade": 17.0},
+		}, stored.Content, "the rest is kept")
+		events := db.GetDocumentEvents(doc.ID)
+		assert.Equal(t, "writer", events[0].ActorID)
+
+		_, err = db.UpdateScopedContent(doc.ID, "writer", map[string]any{})
+		require.NoError(t, err)
+		stored, _ = db.GetDocumentByID(doc.ID)
+		assert.Equal(t, map[string]any{"private": map[string]any{"grade": 17.0}}, stored.Content, "sending nothing removes the part")
+	})
+
+	t.Run("Scopes go with the share", func(t *testing.T) {
+		require.NoError(t, db.RemoveSharerFromDocument(doc.ID, "reader"))
+		record, _ := db.GetShareRecordByDocumentID(doc.ID)
+		assert.NotContains(t, record.Scopes, "reader")
+		require.NoError(t, db.SetShareRecord(doc.ID, []string{"writer", "reader"}))
+		record, _ = db.GetShareRecordByDocumentID(doc.ID)
+		assert.Equal(t, map[string]models.ShareScope{"writer": {Path: "public", Write: true}}, record.Scopes)
+
+		record, err := db.SetShareScope(doc.ID, "writer", nil)
+		require.NoError(t, err)
+		assert.Nil(t, record.Scopes)
+	})
+}
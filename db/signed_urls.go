@@ -0,0 +1,32 @@
+package db
+
+import (
+	"docserver/apperr"
+	"log"
+	"time"
+)
+
+// --- Signed URLs ---
+
+// UseSignedURL marks the signed URL token with ID tokenID, valid until expiresAt, as used, or
+// returns an ErrConflict error if it was used before. Used tokens are remembered until they
+// expire, across restarts, so each signed URL works once.
+func (db *Database) UseSignedURL(tokenID string, expiresAt time.Time) error {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	now := db.now()
+	for id, expiry := range db.Database.SignedURLUses {
+		if !expiry.After(now) {
+			delete(db.Database.SignedURLUses, id) // Expired tokens are refused anyway
+		}
+	}
+	if _, used := db.Database.SignedURLUses[tokenID]; used {
+		return apperr.Conflict("signed URL '%s' has already been used", tokenID)
+	}
+	db.Database.SignedURLUses[tokenID] = expiresAt.UTC()
+	log.Printf("INFO: Signed URL '%s' used", tokenID)
+
+	db.requestSave()
+	return nil
+}
@@ -0,0 +1,28 @@
+package db
+
+import (
+	"docserver/apperr"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUseSignedURL(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := db.now()
+	require.NoError(t, db.UseSignedURL("token_1", now.Add(time.Minute)))
+	err := db.UseSignedURL("token_1", now.Add(time.Minute))
+	assert.ErrorIs(t, err, apperr.ErrConflict, "each signed URL works once")
+	require.NoError(t, db.UseSignedURL("token_2", now.Add(time.Minute)))
+
+	t.Run("Expired uses are forgotten", func(t *testing.T) {
+		db.Database.SignedURLUses["token_old"] = now.Add(-time.Minute)
+		require.NoError(t, db.UseSignedURL("token_3", now.Add(time.Minute)))
+		assert.NotContains(t, db.Database.SignedURLUses, "token_old")
+		assert.Len(t, db.Database.SignedURLUses, 3)
+	})
+}
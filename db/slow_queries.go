@@ -0,0 +1,64 @@
+package db
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// --- Slow Query Log ---
+
+// maxSlowQueries caps the slow queries kept in memory; the oldest are dropped first.
+const maxSlowQueries = 100
+
+// SlowQuery describes a content query that took longer than the configured threshold.
+// Slow queries are kept in memory only; they are not saved with the data.
+type SlowQuery struct {
+	Timestamp          time.Time `json:"timestamp"`           // UTC; when the query finished
+	CallerID           string    `json:"caller_id,omitempty"` // Profile ID of the user who ran it (empty for guests)
+	Scope              string    `json:"scope"`
+	ContentQuery       []string  `json:"content_query"`       // As sent by the client
+	ParsedQuery        string    `json:"parsed_query"`        // As the server understood it
+	DocumentsScanned   int       `json:"documents_scanned"`   // Documents looked at
+	DocumentsEvaluated int       `json:"documents_evaluated"` // Documents the content query was run against
+	DocumentsMatched   int       `json:"documents_matched"`
+	DurationMillis     float64   `json:"duration_ms"`
+}
+
+// slowQueryLog holds the latest slow queries. It has its own lock so recording a slow query
+// does not need the data lock.
+type slowQueryLog struct {
+	mu      sync.Mutex
+	queries []SlowQuery // Oldest first
+}
+
+// recordSlowQuery keeps the query if it took longer than the slow query threshold, and logs it.
+func (db *Database) recordSlowQuery(query SlowQuery, took time.Duration) {
+	threshold := db.config.SlowQueryThreshold
+	if threshold <= 0 || took < threshold {
+		return
+	}
+	query.Timestamp = time.Now().UTC()
+	query.DurationMillis = float64(took) / float64(time.Millisecond)
+	log.Printf("WARN: Slow content query (%s, threshold %s) by '%s': %s; scanned %d, evaluated %d, matched %d documents",
+		took, threshold, query.CallerID, query.ParsedQuery, query.DocumentsScanned, query.DocumentsEvaluated, query.DocumentsMatched)
+
+	db.slowQueries.mu.Lock()
+	defer db.slowQueries.mu.Unlock()
+	db.slowQueries.queries = append(db.slowQueries.queries, query)
+	if len(db.slowQueries.queries) > maxSlowQueries {
+		db.slowQueries.queries = append([]SlowQuery{}, db.slowQueries.queries[len(db.slowQueries.queries)-maxSlowQueries:]...)
+	}
+}
+
+// GetSlowQueries returns the recorded slow queries, newest first.
+func (db *Database) GetSlowQueries() []SlowQuery {
+	db.slowQueries.mu.Lock()
+	defer db.slowQueries.mu.Unlock()
+
+	queries := make([]SlowQuery, len(db.slowQueries.queries))
+	for i, query := range db.slowQueries.queries {
+		queries[len(queries)-1-i] = query
+	}
+	return queries
+}
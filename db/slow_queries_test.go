@@ -0,0 +1,78 @@
+package db
+
+import (
+	"docserver/models"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestParsedQuery_String(t *testing.T) {
+	parsed, err := ParseContentQuery([]string{`name Equals-Insensitive  "Ann Lee"`, "OR", "age greaterthan 30", "and", "contains x", "and", "Not deleted equals null"})
+	require.NoError(t, err)
+	rendered := parsed.String()
+	assert.Equal(t, `name equals-insensitive "Ann Lee" or age greaterthan 30 and contains "x" and not deleted equals null`, rendered)
+
+	var nilQuery *ParsedQuery
+	assert.Empty(t, nilQuery.String())
+}
+
+func TestDatabase_SlowQueries(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	for _, content := range []string{`{"n": 1}`, `{"n": 2}`, `{"n": 3}`} {
+		_, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: gjson.Parse(content).Value()})
+		require.NoError(t, err)
+	}
+	query := func(contentQuery ...string) {
+		_, _, err := db.QueryDocuments(QueryDocumentsParams{AuthUserID: "owner", ContentQuery: contentQuery, Page: 1, Limit: 10})
+		require.NoError(t, err)
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		db.config.SlowQueryThreshold = 0
+		query("n greaterthan 1")
+		assert.Empty(t, db.GetSlowQueries())
+	})
+
+	t.Run("Under the threshold", func(t *testing.T) {
+		db.config.SlowQueryThreshold = time.Hour
+		query("n greaterthan 1")
+		assert.Empty(t, db.GetSlowQueries())
+	})
+
+	t.Run("Recorded", func(t *testing.T) {
+		db.config.SlowQueryThreshold = time.Nanosecond
+		query()
+		assert.Empty(t, db.GetSlowQueries(), "queries without a content query are not recorded")
+
+		query("n greaterthan 1")
+		query("n equals 3")
+		slow := db.GetSlowQueries()
+		require.Len(t, slow, 2)
+		latest := slow[0]
+		assert.Equal(t, `n equals 3`, latest.ParsedQuery, "newest first")
+		assert.Equal(t, []string{"n equals 3"}, latest.ContentQuery)
+		assert.Equal(t, "owner", latest.CallerID)
+		assert.Equal(t, 3, latest.DocumentsScanned)
+		assert.Equal(t, 3, latest.DocumentsEvaluated)
+		assert.Equal(t, 1, latest.DocumentsMatched)
+		assert.Greater(t, latest.DurationMillis, 0.0)
+		assert.False(t, latest.Timestamp.IsZero())
+	})
+
+	t.Run("Capped", func(t *testing.T) {
+		for i := 0; i < maxSlowQueries+5; i++ {
+			query(fmt.Sprintf("n equals %d", i))
+		}
+		slow := db.GetSlowQueries()
+		require.Len(t, slow, maxSlowQueries)
+		assert.Equal(t, fmt.Sprintf("n equals %d", maxSlowQueries+4), slow[0].ParsedQuery)
+		assert.Equal(t, "n equals 5", slow[maxSlowQueries-1].ParsedQuery, "the oldest are dropped")
+	})
+}
@@ -0,0 +1,128 @@
+package db
+
+import (
+	"docserver/models"
+	"encoding/json"
+	"time"
+)
+
+// --- Statistics ---
+
+// GetProfileStats counts a profile's documents, shares and favorites and finds their latest
+// activity, in one pass over the data.
+func (db *Database) GetProfileStats(profileID string) models.ProfileStats {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+
+	stats := models.ProfileStats{Favorites: len(db.Database.Favorites[profileID])}
+	for _, doc := range db.Database.Documents {
+		if doc.OwnerID != profileID {
+			continue
+		}
+		stats.OwnedDocuments++
+		if doc.Public {
+			stats.PublicDocuments++
+		}
+		if record, found := db.Database.ShareRecords[doc.ID]; found && len(record.SharedWith) > 0 {
+			stats.SharedDocuments++
+		}
+		if encoded, err := json.Marshal(doc.Content); err == nil {
+			stats.ContentBytes += int64(len(encoded))
+		}
+		stats.LastCreatedAt = latest(stats.LastCreatedAt, doc.CreationDate)
+		stats.LastModifiedAt = latest(stats.LastModifiedAt, doc.LastModifiedDate)
+	}
+
+	for docID, record := range db.Database.ShareRecords {
+		doc, found := db.Database.Documents[docID]
+		if !found || doc.OwnerID == profileID {
+			continue
+		}
+		// Documents of deactivated users are hidden, so they are not counted either
+		if owner, found := db.Database.Profiles[doc.OwnerID]; found && owner.Deactivation != nil {
+			continue
+		}
+		for _, sharedID := range record.SharedWith {
+			if sharedID == profileID {
+				stats.SharedWithMe++
+				break
+			}
+		}
+	}
+
+	for _, events := range db.Database.DocumentEvents {
+		for _, event := range events {
+			if event.ActorID == profileID {
+				stats.LastActivityAt = latest(stats.LastActivityAt, event.Timestamp)
+			}
+		}
+	}
+	return stats
+}
+
+// latest returns the later of current and t, ignoring zero times.
+func latest(current *time.Time, t time.Time) *time.Time {
+	if t.IsZero() || (current != nil && !t.After(*current)) {
+		return current
+	}
+	return &t
+}
+
+// Number of modification timestamps in document statistics.
+const (
+	DefaultStatsModifications = 10
+	MaxStatsModifications     = maxDocumentVersions // Older versions are not kept
+)
+
+// DocumentStats describes a document's content, as given (which may be scoped for the caller),
+// and lists when its last versions (up to last) were saved, newest first.
+func (v *ReadView) DocumentStats(doc models.Document, last int) models.DocumentStats {
+	stats := models.DocumentStats{ArrayLengths: map[string]int{}, Version: doc.Version}
+	if encoded, err := json.Marshal(doc.Content); err == nil {
+		stats.ContentBytes = len(encoded)
+	}
+	stats.Depth, stats.KeyCount = contentShape(doc.Content)
+	switch content := doc.Content.(type) {
+	case map[string]any:
+		for key, value := range content {
+			if array, ok := value.([]any); ok {
+				stats.ArrayLengths[key] = len(array)
+			}
+		}
+	case []any:
+		stats.ArrayLengths["@this"] = len(content)
+	}
+
+	versions := v.db.Database.DocumentVersions[doc.ID]
+	if len(versions) == 0 {
+		// Documents not updated since version history was introduced only have their last change
+		stats.Modifications = []time.Time{doc.LastModifiedDate.UTC()}
+		return stats
+	}
+	for i := len(versions) - 1; i >= 0 && len(stats.Modifications) < last; i-- {
+		stats.Modifications = append(stats.Modifications, versions[i].Timestamp.UTC())
+	}
+	return stats
+}
+
+// contentShape returns the nesting depth of a JSON value and the number of keys of its objects.
+func contentShape(value any) (depth, keys int) {
+	var children []any
+	switch v := value.(type) {
+	case map[string]any:
+		keys = len(v)
+		for _, child := range v {
+			children = append(children, child)
+		}
+	case []any:
+		children = v
+	default:
+		return 0, 0
+	}
+	for _, child := range children {
+		childDepth, childKeys := contentShape(child)
+		depth = max(depth, childDepth)
+		keys += childKeys
+	}
+	return depth + 1, keys
+}
@@ -0,0 +1,120 @@
+package db
+
+import (
+	"docserver/config"
+	"docserver/models"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabase_GetProfileStats(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	owner, err := db.CreateProfile(models.Profile{Email: "stats.owner@example.com"})
+	require.NoError(t, err)
+	reader, err := db.CreateProfile(models.Profile{Email: "stats.reader@example.com"})
+	require.NoError(t, err)
+
+	assert.Equal(t, models.ProfileStats{}, db.GetProfileStats(owner.ID), "nothing yet")
+
+	first, err := db.CreateDocument(models.Document{OwnerID: owner.ID, Content: "hello"})
+	require.NoError(t, err)
+	second, err := db.CreateDocument(models.Document{OwnerID: owner.ID, Content: map[string]any{"a": 1}})
+	require.NoError(t, err)
+	_, err = db.SetDocumentPublic(second.ID, true)
+	require.NoError(t, err)
+	require.NoError(t, db.SetShareRecord(first.ID, []string{reader.ID}))
+	_, err = db.AddFavorite(reader.ID, first.ID)
+	require.NoError(t, err)
+	readerDoc, err := db.CreateDocument(models.Document{OwnerID: reader.ID, Content: "mine"})
+	require.NoError(t, err)
+	require.NoError(t, db.SetShareRecord(readerDoc.ID, []string{owner.ID}))
+
+	stats := db.GetProfileStats(owner.ID)
+	assert.Equal(t, 2, stats.OwnedDocuments)
+	assert.Equal(t, 1, stats.PublicDocuments)
+	assert.Equal(t, 1, stats.SharedDocuments)
+	assert.Equal(t, 1, stats.SharedWithMe)
+	assert.Equal(t, 0, stats.Favorites)
+	assert.Equal(t, int64(len(`"hello"`)+len(`{"a":1}`)), stats.ContentBytes)
+	require.NotNil(t, stats.LastCreatedAt)
+	assert.Equal(t, second.CreationDate, *stats.LastCreatedAt)
+	require.NotNil(t, stats.LastModifiedAt)
+	require.NotNil(t, stats.LastActivityAt)
+	assert.False(t, stats.LastActivityAt.Before(*stats.LastCreatedAt))
+
+	readerStats := db.GetProfileStats(reader.ID)
+	assert.Equal(t, 1, readerStats.OwnedDocuments)
+	assert.Equal(t, 1, readerStats.SharedWithMe)
+	assert.Equal(t, 1, readerStats.Favorites)
+
+	t.Run("Documents of deactivated owners are not counted", func(t *testing.T) {
+		_, err := db.DeactivateProfile(reader.ID, nil, time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, 0, db.GetProfileStats(owner.ID).SharedWithMe)
+	})
+}
+
+func TestDocumentStats(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	created := time.Date(2025, time.June, 1, 9, 0, 0, 0, time.UTC)
+	clock := config.NewFixedClock(created)
+	db.config.Clock = clock
+
+	content := map[string]any{
+		"name":    "Library",
+		"tags":    []any{"a", "b", "c"},
+		"shelves": []any{map[string]any{"id": 1.0, "books": []any{map[string]any{"title": "Dune"}}}},
+		"owner":   map[string]any{"name": "Ada"},
+		"empty":   []any{},
+	}
+	doc, err := db.CreateDocument(models.Document{OwnerID: "teacher", Content: content})
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		clock.Advance(time.Hour)
+		doc, err = db.UpdateDocument(doc.ID, content)
+		require.NoError(t, err)
+	}
+
+	var stats, plain models.DocumentStats
+	_ = db.View(func(v *ReadView) error {
+		stats = v.DocumentStats(doc, 2)
+		plain = v.DocumentStats(models.Document{ID: "doc_plain", Content: "text", LastModifiedDate: created}, 10)
+		return nil
+	})
+	encoded, err := json.Marshal(content)
+	require.NoError(t, err)
+	assert.Equal(t, len(encoded), stats.ContentBytes)
+	assert.Equal(t, 5, stats.Depth, "content > shelves > shelf > books > book")
+	assert.Equal(t, 9, stats.KeyCount)
+	assert.Equal(t, map[string]int{"tags": 3, "shelves": 1, "empty": 0}, stats.ArrayLengths)
+	assert.Equal(t, 4, stats.Version)
+	assert.Equal(t, []time.Time{created.Add(3 * time.Hour), created.Add(2 * time.Hour)}, stats.Modifications, "newest first")
+
+	assert.Equal(t, 0, plain.Depth)
+	assert.Equal(t, 0, plain.KeyCount)
+	assert.Empty(t, plain.ArrayLengths)
+	assert.Equal(t, []time.Time{created}, plain.Modifications, "no history: the last change")
+}
+
+func TestContentShape(t *testing.T) {
+	for _, tc := range []struct {
+		content     any
+		depth, keys int
+	}{
+		{nil, 0, 0},
+		{map[string]any{}, 1, 0},
+		{[]any{1.0, 2.0}, 1, 0},
+		{[]any{map[string]any{"a": 1.0}, map[string]any{"a": 2.0, "b": []any{}}}, 3, 3},
+	} {
+		depth, keys := contentShape(tc.content)
+		assert.Equal(t, tc.depth, depth, "%v", tc.content)
+		assert.Equal(t, tc.keys, keys, "%v", tc.content)
+	}
+}
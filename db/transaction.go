@@ -0,0 +1,124 @@
+package db
+
+import (
+	"docserver/models"
+	"maps"
+	"slices"
+)
+
+// --- Transactions ---
+
+// Tx is the database as seen inside WithTransaction. It offers the full Database API, but
+// works on a private copy of the data that is only committed if the transaction succeeds.
+// Changes are saved with the usual debounce after the commit, so operations that normally
+// save right away (EraseProfile) do not do so inside a transaction, and leave the backup as is.
+type Tx struct {
+	*Database
+}
+
+// WithTransaction runs fn against a copy of the data and, if fn returns nil, replaces the data
+// with the copy in one step. If fn returns an error (or panics) the copy is discarded and the
+// database is left untouched. The write lock is held until fn returns, so transactions see no
+// concurrent changes; fn must use tx, not db, or it will deadlock.
+func (db *Database) WithTransaction(fn func(tx *Tx) error) error {
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	staged := &Database{config: db.config, transforms: db.transforms, ids: db.ids, staged: true}
+	copyCollections(&staged.Database, &db.Database, true)
+	staged.ensureCollections()
+	staged.rebuildIndexes()
+
+	if err := fn(&Tx{Database: staged}); err != nil {
+		return err
+	}
+
+	staged.saveMutex.Lock()
+	changed := staged.savePending
+	staged.saveMutex.Unlock()
+	if !changed {
+		return nil
+	}
+	copyCollections(&db.Database, &staged.Database, false)
+	db.profileIndexes = staged.profileIndexes
+	for _, message := range staged.notifications.staged {
+		db.sendNotification(message)
+	}
+	db.requestSave()
+	return nil
+}
+
+// copyCollections points dst's collections at src's or, when clone is set, at copies of them.
+// Copies are deep enough for the ways the db methods change data: maps and the slices stored in
+// them are copied, the structs inside are not.
+func copyCollections(dst, src *models.Database, clone bool) {
+	dst.SchemaVersion = src.SchemaVersion
+	dst.Maintenance = src.Maintenance // Replaced, never changed in place
+	dst.ExtraPolicy = src.ExtraPolicy // Replaced, never changed in place
+	if !clone {
+		dst.Profiles = src.Profiles
+		dst.Documents = src.Documents
+		dst.ShareRecords = src.ShareRecords
+		dst.ErasureRequests = src.ErasureRequests
+		dst.Favorites = src.Favorites
+		dst.DocumentEvents = src.DocumentEvents
+		dst.DocumentVersions = src.DocumentVersions
+		dst.Scripts = src.Scripts
+		dst.Schedules = src.Schedules
+		dst.ScheduleRuns = src.ScheduleRuns
+		dst.NotificationChannels = src.NotificationChannels
+		dst.EmailChanges = src.EmailChanges
+		dst.OTPs = src.OTPs
+		dst.OTPLockouts = src.OTPLockouts
+		dst.Invites = src.Invites
+		dst.Reviews = src.Reviews
+		dst.APIUsage = src.APIUsage
+		dst.Courses = src.Courses
+		dst.PendingShares = src.PendingShares
+		dst.SignedURLUses = src.SignedURLUses
+		dst.Devices = src.Devices
+		return
+	}
+
+	dst.Profiles = maps.Clone(src.Profiles)
+	dst.Documents = maps.Clone(src.Documents)
+	dst.ShareRecords = make(map[string]models.ShareRecord, len(src.ShareRecords))
+	for docID, record := range src.ShareRecords {
+		record.SharedWith = slices.Clone(record.SharedWith)
+		record.Scopes = maps.Clone(record.Scopes)
+		dst.ShareRecords[docID] = record
+	}
+	dst.ErasureRequests = maps.Clone(src.ErasureRequests)
+	dst.Favorites = cloneSliceMap(src.Favorites)
+	dst.DocumentEvents = cloneSliceMap(src.DocumentEvents)
+	dst.DocumentVersions = cloneSliceMap(src.DocumentVersions)
+	dst.Scripts = maps.Clone(src.Scripts)
+	dst.Schedules = maps.Clone(src.Schedules)
+	dst.ScheduleRuns = cloneSliceMap(src.ScheduleRuns)
+	dst.NotificationChannels = maps.Clone(src.NotificationChannels)
+	dst.EmailChanges = maps.Clone(src.EmailChanges)
+	dst.OTPs = maps.Clone(src.OTPs)
+	dst.OTPLockouts = maps.Clone(src.OTPLockouts)
+	dst.Invites = maps.Clone(src.Invites)
+	dst.Reviews = maps.Clone(src.Reviews)
+	dst.APIUsage = make(map[string]map[string]models.APIUsageCounter, len(src.APIUsage))
+	for profileID, routes := range src.APIUsage {
+		dst.APIUsage[profileID] = maps.Clone(routes)
+	}
+	dst.Courses = maps.Clone(src.Courses) // Replaced, never changed in place
+	dst.PendingShares = cloneSliceMap(src.PendingShares)
+	dst.SignedURLUses = maps.Clone(src.SignedURLUses)
+	dst.Devices = cloneSliceMap(src.Devices)
+}
+
+// cloneSliceMap copies a map of slices, including the slices.
+func cloneSliceMap[K comparable, V any](m map[K][]V) map[K][]V {
+	if m == nil {
+		return nil
+	}
+	clone := make(map[K][]V, len(m))
+	for key, values := range m {
+		clone[key] = slices.Clone(values)
+	}
+	return clone
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"docserver/models"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabase_WithTransaction(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	owner, err := db.CreateProfile(models.Profile{Email: "tx.owner@example.com"})
+	require.NoError(t, err)
+	reader, err := db.CreateProfile(models.Profile{Email: "tx.reader@example.com"})
+	require.NoError(t, err)
+
+	t.Run("Commit", func(t *testing.T) {
+		var docID string
+		err := db.WithTransaction(func(tx *Tx) error {
+			doc, err := tx.CreateDocument(models.Document{OwnerID: owner.ID, Content: "shared"})
+			if err != nil {
+				return err
+			}
+			docID = doc.ID
+			return tx.SetShareRecord(doc.ID, []string{reader.ID})
+		})
+		require.NoError(t, err)
+
+		_, found := db.GetDocumentByID(docID)
+		assert.True(t, found)
+		record, found := db.GetShareRecordByDocumentID(docID)
+		require.True(t, found)
+		assert.Equal(t, []string{reader.ID}, record.SharedWith)
+
+		time.Sleep(db.config.SaveInterval * 3)
+		assert.Contains(t, readTestDBFile(t, db.config), docID, "committed changes are saved")
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		docs := db.GetDocumentsByOwner(owner.ID)
+		require.Len(t, docs, 1)
+		docID := docs[0].ID
+		failure := errors.New("share failed")
+
+		err := db.WithTransaction(func(tx *Tx) error {
+			if _, err := tx.CreateDocument(models.Document{OwnerID: owner.ID, Content: "orphan"}); err != nil {
+				return err
+			}
+			// Changes shared slices in place; must not leak into the committed data
+			if err := tx.RemoveSharerFromDocument(docID, reader.ID); err != nil {
+				return err
+			}
+			_, err := tx.EraseProfile(reader.ID)
+			require.NoError(t, err)
+			return failure
+		})
+		assert.ErrorIs(t, err, failure)
+
+		assert.Len(t, db.GetDocumentsByOwner(owner.ID), 1)
+		record, found := db.GetShareRecordByDocumentID(docID)
+		require.True(t, found)
+		assert.Equal(t, []string{reader.ID}, record.SharedWith)
+		_, found = db.GetProfileByID(reader.ID)
+		assert.True(t, found)
+		assert.NotContains(t, readTestDBFile(t, db.config), "orphan", "staged changes are never written")
+	})
+
+	t.Run("Panic rolls back and releases the lock", func(t *testing.T) {
+		assert.Panics(t, func() {
+			_ = db.WithTransaction(func(tx *Tx) error {
+				_, err := tx.CreateProfile(models.Profile{Email: "tx.panic@example.com"})
+				require.NoError(t, err)
+				panic("boom")
+			})
+		})
+		_, found := db.GetProfileByEmail("tx.panic@example.com")
+		assert.False(t, found)
+	})
+
+	t.Run("Erasure in a transaction", func(t *testing.T) {
+		var report models.ErasureReport
+		err := db.WithTransaction(func(tx *Tx) error {
+			var err error
+			report, err = tx.EraseProfile(reader.ID)
+			return err
+		})
+		require.NoError(t, err)
+		assert.True(t, report.ProfileDeleted)
+		_, found := db.GetProfileByID(reader.ID)
+		assert.False(t, found)
+		assert.Empty(t, db.GetDocumentIDsSharedWith(reader.ID))
+	})
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/models"
+)
+
+// --- Document Versions ---
+
+// maxDocumentVersions caps the content history kept per document; the oldest versions are dropped first.
+const maxDocumentVersions = 50
+
+// recordDocumentVersion appends the document's current content to its history as doc.Version.
+// Must be called with the write lock held; the caller triggers the save.
+func (db *Database) recordDocumentVersion(doc models.Document) {
+	versions := append(db.Database.DocumentVersions[doc.ID], models.DocumentVersion{
+		Version:   doc.Version,
+		Content:   doc.Content,
+		Timestamp: doc.LastModifiedDate,
+	})
+	if len(versions) > maxDocumentVersions {
+		versions = append([]models.DocumentVersion{}, versions[len(versions)-maxDocumentVersions:]...)
+	}
+	db.Database.DocumentVersions[doc.ID] = versions
+}
+
+// GetDocumentVersion returns the content of a document as of the given version.
+// Documents that have not been updated since version history was introduced only have
+// their current content, as version 1. Access to the document is checked at handler level.
+func (db *Database) GetDocumentVersion(docID string, version int) (models.DocumentVersion, error) {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+
+	doc, found := db.Database.Documents[docID]
+	if !found {
+		return models.DocumentVersion{}, apperr.NotFound("document with ID '%s' not found", docID)
+	}
+
+	versions := db.Database.DocumentVersions[docID]
+	if len(versions) == 0 && version == 1 && doc.Version <= 1 {
+		return models.DocumentVersion{Version: 1, Content: doc.Content, Timestamp: doc.LastModifiedDate}, nil
+	}
+	for _, v := range versions {
+		if v.Version == version {
+			return v, nil
+		}
+	}
+	return models.DocumentVersion{}, apperr.NotFound("version %d of document '%s' not found", version, docID)
+}
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"docserver/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabase_DocumentVersions(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	doc, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: "v1"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, doc.Version)
+
+	updated, err := db.UpdateDocument(doc.ID, "v2")
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated.Version)
+	_, err = db.UpdateDocument(doc.ID, "v3")
+	require.NoError(t, err)
+
+	for version, content := range map[int]string{1: "v1", 2: "v2", 3: "v3"} {
+		v, err := db.GetDocumentVersion(doc.ID, version)
+		require.NoError(t, err)
+		assert.Equal(t, version, v.Version)
+		assert.Equal(t, content, v.Content)
+	}
+
+	_, err = db.GetDocumentVersion(doc.ID, 4)
+	assert.Error(t, err)
+	_, err = db.GetDocumentVersion("missing", 1)
+	assert.Error(t, err)
+
+	events := db.GetDocumentEvents(doc.ID)
+	require.NotEmpty(t, events)
+	assert.Equal(t, 3, events[0].Version, "update events carry the version they produced")
+
+	require.NoError(t, db.DeleteDocument(doc.ID))
+	assert.Empty(t, db.Database.DocumentVersions[doc.ID])
+}
+
+func TestDatabase_DocumentVersions_LegacyDocument(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	// A document saved before version history existed has no version and no history
+	db.Database.Documents["legacy"] = models.Document{ID: "legacy", OwnerID: "owner", Content: "original"}
+
+	v, err := db.GetDocumentVersion("legacy", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "original", v.Content, "the current content is version 1")
+
+	updated, err := db.UpdateDocument("legacy", "edited")
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated.Version)
+
+	v, err = db.GetDocumentVersion("legacy", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "original", v.Content, "the pre-update content is kept as version 1")
+}
+
+func TestDatabase_DocumentVersions_Capped(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	doc, err := db.CreateDocument(models.Document{OwnerID: "owner", Content: 0})
+	require.NoError(t, err)
+	for i := 1; i <= maxDocumentVersions; i++ {
+		_, err := db.UpdateDocument(doc.ID, i)
+		require.NoError(t, err)
+	}
+
+	assert.Len(t, db.Database.DocumentVersions[doc.ID], maxDocumentVersions)
+	_, err = db.GetDocumentVersion(doc.ID, 1)
+	assert.Error(t, err, "the oldest version was dropped")
+	_, err = db.GetDocumentVersion(doc.ID, maxDocumentVersions+1)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"docserver/models"
+)
+
+// --- Read Views ---
+
+// ReadView is a read-only view of the database in which nothing changes (see View). Its methods
+// read the same data as the Database methods of the same name, without locking.
+type ReadView struct {
+	db *Database // Read lock held by View
+}
+
+// View runs fn with a read-only view of the database, so the several reads a request makes (a
+// document, its share record, its owner's profile) all see the same state. No change is made
+// until fn returns, as the read lock is held until then. fn must use v, not db: a change waiting
+// for the lock blocks further reads of db, which would deadlock. Keep fn short and write responses
+// after it returns.
+func (db *Database) View(fn func(v *ReadView) error) error {
+	db.Database.Mu.RLock()
+	defer db.Database.Mu.RUnlock()
+	return fn(&ReadView{db: db})
+}
+
+// GetProfileByID retrieves a profile by its ID.
+func (v *ReadView) GetProfileByID(id string) (models.Profile, bool) {
+	profile, found := v.db.Database.Profiles[id]
+	return profile, found
+}
+
+// GetDocumentByID retrieves a document by its ID.
+func (v *ReadView) GetDocumentByID(id string) (models.Document, bool) {
+	doc, found := v.db.Database.Documents[id]
+	if found && documentExpired(doc, v.db.now()) {
+		return models.Document{}, false // Gone until purged (see SetDocumentExpiry)
+	}
+	return doc, found
+}
+
+// GetShareRecordByDocumentID retrieves the share record of a document.
+func (v *ReadView) GetShareRecordByDocumentID(docID string) (models.ShareRecord, bool) {
+	record, found := v.db.Database.ShareRecords[docID]
+	// Ensure the DocumentID field is set (it's the key, but good practice)
+	if found {
+		record.DocumentID = docID
+	}
+	return record, found
+}
+
+// GetFavorites returns the set of document IDs a profile has bookmarked.
+func (v *ReadView) GetFavorites(profileID string) map[string]bool {
+	favorites := make(map[string]bool, len(v.db.Database.Favorites[profileID]))
+	for _, docID := range v.db.Database.Favorites[profileID] {
+		favorites[docID] = true
+	}
+	return favorites
+}
+
+// IsProfileDeactivated reports whether the profile exists and is deactivated.
+func (v *ReadView) IsProfileDeactivated(profileID string) bool {
+	profile, found := v.db.Database.Profiles[profileID]
+	return found && profile.Deactivation != nil
+}
+
+// IsAssignedReviewer reports whether the profile was assigned to review the document.
+func (v *ReadView) IsAssignedReviewer(docID, profileID string) bool {
+	for _, review := range v.db.Database.Reviews {
+		if review.DocumentID == docID && review.ReviewerID == profileID {
+			return true
+		}
+	}
+	return false
+}
+
+// GetDocumentEvents returns a document's activity feed, newest first.
+func (v *ReadView) GetDocumentEvents(docID string) []models.DocumentEvent {
+	stored := v.db.Database.DocumentEvents[docID]
+	events := make([]models.DocumentEvent, len(stored))
+	for i, event := range stored {
+		events[len(stored)-1-i] = event
+	}
+	return events
+}
+
+// QueryDocuments performs filtering, sorting, and pagination on documents (see Database.QueryDocuments).
+func (v *ReadView) QueryDocuments(params QueryDocumentsParams) ([]models.Document, int, error) {
+	return v.QueryDocumentsContext(context.Background(), params)
+}
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"docserver/models"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabase_View(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	owner, err := db.CreateProfile(models.Profile{Email: "view-owner@example.com", FirstName: "V", LastName: "O"})
+	require.NoError(t, err)
+	reader, err := db.CreateProfile(models.Profile{Email: "view-reader@example.com", FirstName: "V", LastName: "R"})
+	require.NoError(t, err)
+	doc, err := db.CreateDocument(models.Document{OwnerID: owner.ID, Content: map[string]any{"v": 1}})
+	require.NoError(t, err)
+	require.NoError(t, db.AddSharerToDocument(doc.ID, reader.ID))
+	_, err = db.AddFavorite(reader.ID, doc.ID)
+	require.NoError(t, err)
+
+	t.Run("Reads match the database", func(t *testing.T) {
+		err := db.View(func(v *ReadView) error {
+			got, found := v.GetDocumentByID(doc.ID)
+			require.True(t, found)
+			assert.Equal(t, doc.ID, got.ID)
+			profile, found := v.GetProfileByID(owner.ID)
+			require.True(t, found)
+			assert.Equal(t, owner.Email, profile.Email)
+			record, found := v.GetShareRecordByDocumentID(doc.ID)
+			require.True(t, found)
+			assert.Equal(t, doc.ID, record.DocumentID)
+			assert.Equal(t, []string{reader.ID}, record.SharedWith)
+			assert.Equal(t, map[string]bool{doc.ID: true}, v.GetFavorites(reader.ID))
+			assert.False(t, v.IsProfileDeactivated(owner.ID))
+			assert.False(t, v.IsAssignedReviewer(doc.ID, reader.ID))
+			assert.Equal(t, db.GetDocumentEvents(doc.ID), v.GetDocumentEvents(doc.ID))
+
+			docs, total, err := v.QueryDocuments(QueryDocumentsParams{AuthUserID: reader.ID, Scope: "shared", Page: 1, Limit: 10})
+			require.NoError(t, err)
+			assert.Equal(t, 1, total)
+			assert.Equal(t, doc.ID, docs[0].ID)
+			return nil
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Returns the error of fn", func(t *testing.T) {
+		failure := errors.New("failure")
+		assert.Equal(t, failure, db.View(func(*ReadView) error { return failure }))
+	})
+
+	t.Run("Changes wait until the view ends", func(t *testing.T) {
+		updated := make(chan struct{})
+		var before, after models.Document
+		err := db.View(func(v *ReadView) error {
+			go func() {
+				_, _ = db.UpdateDocument(doc.ID, map[string]any{"v": 2})
+				close(updated)
+			}()
+			before, _ = v.GetDocumentByID(doc.ID)
+			select {
+			case <-updated:
+				t.Error("the document was updated while the view was open")
+			case <-time.After(50 * time.Millisecond):
+			}
+			after, _ = v.GetDocumentByID(doc.ID)
+			return nil
+		})
+		require.NoError(t, err)
+		<-updated
+		assert.Equal(t, before.Content, after.Content, "both reads see the same state")
+		current, _ := db.GetDocumentByID(doc.ID)
+		assert.Equal(t, map[string]any{"v": 2}, current.Content)
+	})
+}
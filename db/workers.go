@@ -0,0 +1,42 @@
+package db
+
+import "time"
+
+// --- Background Workers ---
+
+// startWorker calls run with the current UTC time on clock every interval until the returned
+// function is called.
+func startWorker(interval time.Duration, clock func() time.Time, run func(now time.Time)) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				run(clock().UTC())
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// StartWorkers starts the background workers every database needs, each running every interval:
+// due profile erasures, scheduled exports, reactivating or purging deactivated accounts, and
+// saving the API usage counted since the last flush, and purging expired documents. Call the returned function to stop them.
+func (db *Database) StartWorkers(interval time.Duration) (stop func()) {
+	stops := []func(){
+		db.StartErasureWorker(interval),
+		db.StartScheduleWorker(interval),
+		db.StartDeactivationWorker(interval),
+		db.StartAPIUsageWorker(interval),
+		db.StartExpiryWorker(interval),
+	}
+	return func() {
+		for _, stop := range stops {
+			stop()
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package db
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartWorker(t *testing.T) {
+	var calls atomic.Int32
+	stop := startWorker(5*time.Millisecond, time.Now, func(now time.Time) {
+		assert.Equal(t, time.UTC, now.Location())
+		calls.Add(1)
+	})
+	assert.Eventually(t, func() bool { return calls.Load() >= 2 }, time.Second, time.Millisecond)
+
+	stop()
+	time.Sleep(20 * time.Millisecond) // Let a tick that was already running finish
+	stopped := calls.Load()
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, stopped, calls.Load(), "no runs after stop")
+}
@@ -0,0 +1,145 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/i18n"
+	"docserver/models"
+	"log"
+)
+
+// --- Submission Workflow ---
+
+// maxWorkflowTransitions caps the transitions kept per document; the oldest are dropped first.
+const maxWorkflowTransitions = 100
+
+// Who may make a workflow transition
+const (
+	workflowRoleOwner    = "owner"    // The document's owner
+	workflowRoleReviewer = "reviewer" // The designated reviewer, a profile the document is shared with, or an administrator
+)
+
+// workflowTransitions maps each state to the states it may move to, and who may make each move.
+// Approved documents stay approved.
+var workflowTransitions = map[string]map[string]string{
+	models.WorkflowStateDraft: {
+		models.WorkflowStateSubmitted: workflowRoleOwner,
+	},
+	models.WorkflowStateSubmitted: {
+		models.WorkflowStateDraft:    workflowRoleOwner, // Withdrawn
+		models.WorkflowStateApproved: workflowRoleReviewer,
+		models.WorkflowStateRejected: workflowRoleReviewer,
+	},
+	models.WorkflowStateRejected: {
+		models.WorkflowStateDraft:     workflowRoleOwner,
+		models.WorkflowStateSubmitted: workflowRoleOwner, // Resubmitted
+	},
+	models.WorkflowStateApproved: {},
+}
+
+// IsWorkflowState reports whether state is one of the models.WorkflowState* constants.
+func IsWorkflowState(state string) bool {
+	_, known := workflowTransitions[state]
+	return known
+}
+
+// documentWorkflowState returns the document's workflow state. Documents that never entered the
+// workflow are drafts.
+func documentWorkflowState(doc models.Document) string {
+	if doc.Workflow == nil {
+		return models.WorkflowStateDraft
+	}
+	return doc.Workflow.State
+}
+
+// WorkflowChange asks to move a document to another workflow state.
+type WorkflowChange struct {
+	DocumentID   string
+	State        string // State to move to: one of the models.WorkflowState* constants
+	ActorID      string // Profile making the change
+	ActorIsAdmin bool   // Administrators may approve and reject any document
+	ReviewerID   string // When submitting: the only profile that may approve or reject ("" keeps the current one)
+	Comment      string // Recorded with the transition
+}
+
+// TransitionDocumentWorkflow moves a document to change.State if the workflow allows it from
+// the document's current state and the actor plays the required role: the owner submits,
+// withdraws and reopens rejected documents; the reviewer approves and rejects. A reviewer must be
+// a profile the document is shared with, and when the owner named one, only that profile (or an
+// administrator) may decide. Every transition is recorded with its time and the content version,
+// and submissions are sent to the notification channels of whoever may review them.
+func (db *Database) TransitionDocumentWorkflow(change WorkflowChange) (models.Document, error) {
+	if !IsWorkflowState(change.State) {
+		return models.Document{}, apperr.Wrap(apperr.ErrValidation, i18n.NewError(i18n.MsgWorkflowInvalidState, change.State))
+	}
+
+	db.Database.Mu.Lock()
+	defer db.Database.Mu.Unlock()
+
+	doc, found := db.Database.Documents[change.DocumentID]
+	if !found {
+		return models.Document{}, apperr.NotFound("document with ID '%s' not found", change.DocumentID)
+	}
+	workflow := models.DocumentWorkflow{State: models.WorkflowStateDraft}
+	if doc.Workflow != nil {
+		workflow = *doc.Workflow
+	}
+	from := workflow.State
+	role, allowed := workflowTransitions[from][change.State]
+	if !allowed {
+		return models.Document{}, apperr.Wrap(apperr.ErrConflict, i18n.NewError(i18n.MsgWorkflowInvalidTransition, from, change.State))
+	}
+
+	switch role {
+	case workflowRoleOwner:
+		if change.ActorID != doc.OwnerID {
+			return models.Document{}, apperr.Wrap(apperr.ErrForbidden, i18n.NewError(i18n.MsgWorkflowOwnerOnly, change.State))
+		}
+	case workflowRoleReviewer:
+		isReviewer := db.isSharedWith(doc.ID, change.ActorID) && (workflow.ReviewerID == "" || workflow.ReviewerID == change.ActorID)
+		if !isReviewer && !change.ActorIsAdmin {
+			return models.Document{}, apperr.Wrap(apperr.ErrForbidden, i18n.NewError(i18n.MsgWorkflowReviewerOnly))
+		}
+	}
+	if change.State == models.WorkflowStateSubmitted && change.ReviewerID != "" {
+		if !db.isSharedWith(doc.ID, change.ReviewerID) {
+			return models.Document{}, apperr.Wrap(apperr.ErrValidation, i18n.NewError(i18n.MsgWorkflowReviewerNotSharer, change.ReviewerID))
+		}
+		workflow.ReviewerID = change.ReviewerID
+	}
+
+	workflow.State = change.State
+	workflow.Transitions = append(append([]models.WorkflowTransition{}, workflow.Transitions...), models.WorkflowTransition{
+		From:      from,
+		To:        change.State,
+		ActorID:   change.ActorID,
+		Timestamp: db.now().UTC(),
+		Version:   doc.Version,
+		Comment:   change.Comment,
+	})
+	if len(workflow.Transitions) > maxWorkflowTransitions {
+		workflow.Transitions = workflow.Transitions[len(workflow.Transitions)-maxWorkflowTransitions:]
+	}
+	doc.Workflow = &workflow
+	db.Database.Documents[doc.ID] = doc
+	log.Printf("INFO: Moved Document ID %s from workflow state '%s' to '%s' (by Profile ID %s)", doc.ID, from, change.State, change.ActorID)
+	if change.State == models.WorkflowStateSubmitted {
+		reviewers := db.Database.ShareRecords[doc.ID].SharedWith
+		if workflow.ReviewerID != "" {
+			reviewers = []string{workflow.ReviewerID}
+		}
+		db.notify(notification{event: models.NotificationSubmissionReceived, docID: doc.ID, actorID: change.ActorID, recipients: reviewers})
+	}
+
+	db.requestSave()
+	return doc, nil
+}
+
+// isSharedWith reports whether a document is shared with the profile. Must be called with the lock held.
+func (db *Database) isSharedWith(docID, profileID string) bool {
+	for _, sharedID := range db.Database.ShareRecords[docID].SharedWith {
+		if sharedID == profileID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"docserver/apperr"
+	"docserver/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabase_TransitionDocumentWorkflow(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	doc, err := db.CreateDocument(models.Document{OwnerID: "student", Content: map[string]any{"essay": "..."}})
+	require.NoError(t, err)
+	require.NoError(t, db.SetShareRecord(doc.ID, []string{"teacher", "classmate"}))
+	move := func(state, actorID string) (models.Document, error) {
+		return db.TransitionDocumentWorkflow(WorkflowChange{DocumentID: doc.ID, State: state, ActorID: actorID})
+	}
+
+	t.Run("Invalid requests", func(t *testing.T) {
+		_, err := move("graded", "student")
+		assert.ErrorIs(t, err, apperr.ErrValidation)
+		_, err = move(models.WorkflowStateApproved, "teacher")
+		assert.ErrorIs(t, err, apperr.ErrConflict, "drafts cannot be approved")
+		_, err = move(models.WorkflowStateSubmitted, "teacher")
+		assert.ErrorIs(t, err, apperr.ErrForbidden, "only the owner submits")
+		_, err = db.TransitionDocumentWorkflow(WorkflowChange{DocumentID: doc.ID, State: models.WorkflowStateSubmitted, ActorID: "student", ReviewerID: "stranger"})
+		assert.ErrorIs(t, err, apperr.ErrValidation, "reviewers must be sharers")
+		_, err = db.TransitionDocumentWorkflow(WorkflowChange{DocumentID: "missing", State: models.WorkflowStateSubmitted, ActorID: "student"})
+		assert.ErrorIs(t, err, apperr.ErrNotFound)
+	})
+
+	t.Run("Submit, reject, resubmit, approve", func(t *testing.T) {
+		submitted, err := db.TransitionDocumentWorkflow(WorkflowChange{DocumentID: doc.ID, State: models.WorkflowStateSubmitted, ActorID: "student", ReviewerID: "teacher"})
+		require.NoError(t, err)
+		require.NotNil(t, submitted.Workflow)
+		assert.Equal(t, models.WorkflowStateSubmitted, submitted.Workflow.State)
+		assert.Equal(t, "teacher", submitted.Workflow.ReviewerID)
+
+		_, err = move(models.WorkflowStateApproved, "classmate")
+		assert.ErrorIs(t, err, apperr.ErrForbidden, "only the designated reviewer decides")
+
+		rejected, err := db.TransitionDocumentWorkflow(WorkflowChange{DocumentID: doc.ID, State: models.WorkflowStateRejected, ActorID: "teacher", Comment: "Too short"})
+		require.NoError(t, err)
+		assert.Equal(t, models.WorkflowStateRejected, rejected.Workflow.State)
+
+		_, err = move(models.WorkflowStateSubmitted, "student")
+		require.NoError(t, err)
+		approved, err := db.TransitionDocumentWorkflow(WorkflowChange{DocumentID: doc.ID, State: models.WorkflowStateApproved, ActorID: "admin", ActorIsAdmin: true})
+		require.NoError(t, err)
+		assert.Equal(t, models.WorkflowStateApproved, approved.Workflow.State)
+
+		_, err = move(models.WorkflowStateDraft, "student")
+		assert.ErrorIs(t, err, apperr.ErrConflict, "approved documents stay approved")
+
+		transitions := approved.Workflow.Transitions
+		require.Len(t, transitions, 4)
+		assert.Equal(t, models.WorkflowTransition{From: "draft", To: "submitted", ActorID: "student", Timestamp: transitions[0].Timestamp, Version: 1}, transitions[0])
+		assert.Equal(t, "Too short", transitions[1].Comment)
+		assert.Equal(t, "admin", transitions[3].ActorID)
+		assert.False(t, transitions[0].Timestamp.IsZero())
+	})
+}
+
+func TestDatabase_QueryDocuments_WorkflowState(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	draft, err := db.CreateDocument(models.Document{OwnerID: "student", Content: "draft"})
+	require.NoError(t, err)
+	submitted, err := db.CreateDocument(models.Document{OwnerID: "student", Content: "submitted"})
+	require.NoError(t, err)
+	_, err = db.TransitionDocumentWorkflow(WorkflowChange{DocumentID: submitted.ID, State: models.WorkflowStateSubmitted, ActorID: "student"})
+	require.NoError(t, err)
+
+	ids := func(state string) []string {
+		docs, _, err := db.QueryDocuments(QueryDocumentsParams{AuthUserID: "student", WorkflowState: state, Page: 1, Limit: 10})
+		require.NoError(t, err)
+		var ids []string
+		for _, doc := range docs {
+			ids = append(ids, doc.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, []string{submitted.ID}, ids(models.WorkflowStateSubmitted))
+	assert.Equal(t, []string{draft.ID}, ids(models.WorkflowStateDraft), "never submitted documents are drafts")
+	assert.Empty(t, ids(models.WorkflowStateApproved))
+	assert.Len(t, ids(""), 2)
+
+	_, _, err = db.QueryDocuments(QueryDocumentsParams{AuthUserID: "student", WorkflowState: "graded", Page: 1, Limit: 10})
+	assert.ErrorIs(t, err, apperr.ErrValidation)
+}
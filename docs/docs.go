@@ -1374,7 +1374,7 @@ const docTemplate = `{
 var SwaggerInfo = &swag.Spec{
 	Version:          "",
 	Host:             "localhost:8080",
-	BasePath:         "/",
+	BasePath:         "/v1",
 	Schemes:          []string{},
 	Title:            "DocServer API",
 	Description:      "## DocServer API\n\n**Purpose:** This is a simple API server designed for **educational purposes only**. It demonstrates basic concepts of user authentication, document storage (as JSON), document sharing, and content-based querying. **It is NOT intended for production use.**\n\n**High-Level Overview:**\nDocServer allows users to:\n*   Register and log in to manage their accounts.\n*   Create, retrieve, update, and delete documents. Document content can be any valid JSON structure.\n*   Share their documents with other registered users.\n*   Search for documents they have access to, including powerful filtering based on the document's JSON content.\n\n**Content Querying (`content_query` parameter):**\nThe `GET /documents` endpoint supports filtering documents based on their content using the `content_query` parameter. This allows you to search for documents where specific fields within the JSON content match certain criteria.\n\n**Query Syntax:**\nEach `content_query` parameter string follows the format: `path operator value`\n\n*   **`path`**: A dot-separated path to navigate the JSON structure (e.g., `user.name`, `details.metadata.version`). Use numeric indices for arrays (e.g., `items.0.id`, `tags.1`).\n*   **`operator`**: The comparison operator. Supported operators include:\n*   `equals`: Equal to (strings, numbers, booleans, null)\n*   `notequals`: Not equal to\n*   `greaterthan`: Greater than (numbers)\n*   `greaterthanorequals`: Greater than or equal to (numbers)\n*   `lessthan`: Less than (numbers)\n*   `lessthanorequals`: Less than or equal to (numbers)\n*   `contains`: String contains substring, or array contains element (case-sensitive by default).\n*   `startswith`: String starts with prefix (case-sensitive by default).\n*   `endswith`: String ends with suffix (case-sensitive by default).\n*   **`value`**: The value to compare against.\n*   Strings MUST be enclosed in double quotes (e.g., `\\\"John Doe\\\"`). Remember to URL-encode the query parameter string. Add `-insensitive` suffix to string operators (e.g., `equals-insensitive`, `contains-insensitive`) for case-insensitive matching.\n*   Numbers (e.g., `123`, `45.6`), booleans (`true`/`false`), and `null` should be used directly.\n\n**Logical Operators (Combining Queries):**\nYou combine multiple conditions by providing `content_query` parameters for conditions interleaved with explicit logical operators (`and` or `or`).\n*   **`and` (Explicit):** To link two conditions with AND, place `content_query=and` between them. The document must match *both* conditions.\n*   **`or` (Explicit):** To link two conditions with OR, place `content_query=or` between them. The document must match *either* condition.\n\n**Examples:**\n\n*Assume document content like:*\n```json\n{\n\"project\": \"Alpha\",\n\"status\": \"active\",\n\"priority\": 5,\n\"assignee\": { \"name\": \"Alice\", \"email\": \"alice@example.com\" },\n\"tags\": [\"urgent\", \"backend\"],\n\"metadata\": { \"version\": 1.2, \"reviewed\": true }\n}\n```\n\n1.  **Simple Equality:** Find documents where `status` is `active`.\n`?content_query=status equals \\\"active\\\"`\n\n2.  **Numeric Comparison:** Find documents where `priority` is greater than or equal to `5`.\n`?content_query=priority greaterthanorequals 5`\n\n3.  **Nested Field:** Find documents assigned to `Alice`.\n`?content_query=assignee.name equals \\\"Alice\\\"`\n\n4.  **Array Element:** Find documents where the first tag is `urgent`.\n`?content_query=tags.0 equals \\\"urgent\\\"`\n\n5.  **Explicit `AND`:** Find documents for project `Alpha` **AND** status `active`.\n`?content_query=project equals \\\"Alpha\\\"&content_query=and&content_query=status equals \\\"active\\\"`\n\n6.  **Explicit `OR`:** Find documents where status is `active` **OR** priority is less than `3`.\n`?content_query=status equals \\\"active\\\"&content_query=or&content_query=priority lessthan 3`\n\n7.  **Combined `AND` and `OR`:** Find documents where (project is `Alpha` **AND** status is `active`) **OR** (priority is `10`). Evaluation is strictly left-to-right.\n`?content_query=project equals \\\"Alpha\\\"&content_query=and&content_query=status equals \\\"active\\\"&content_query=or&content_query=priority equals 10`\n*(Explanation: `project equals \"Alpha\"` AND `status equals \"active\"` is evaluated first, then the result is OR'd with `priority equals 10`.)*\n\n8.  **Nested Field with `AND`:** Find documents where `assignee.name` is `Alice` **AND** `metadata.reviewed` is `true`.\n`?content_query=assignee.name equals \\\"Alice\\\"&content_query=and&content_query=metadata.reviewed equals true`\nType \"Bearer\" followed by a space and JWT token.",
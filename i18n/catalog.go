@@ -0,0 +1,1119 @@
+package i18n
+
+// Message IDs. These are part of the API contract: clients receive them in the
+// "message_id" field of error responses, so existing IDs must never be renamed.
+const (
+	// Request validation
+	MsgInvalidRequestBody  = "invalid_request_body"
+	MsgInvalidDocumentBody = "invalid_document_body"
+	MsgInvalidSharesBody   = "invalid_shares_body"
+	MsgInvalidPagination   = "invalid_pagination"
+	MsgIDAndKeyExclusive   = "id_and_key_exclusive"
+	MsgInvalidCustomID     = "invalid_custom_id"
+	MsgKeyDerivedID        = "key_derived_id"
+	MsgInvalidDocumentID   = "invalid_document_id"
+	MsgDocumentIDRequired  = "document_id_required"
+	MsgShareIDsRequired    = "share_ids_required"
+	MsgInvalidContentType  = "invalid_content_type"
+	MsgContentNotText      = "content_not_text"
+	MsgContentInvalidCSV   = "content_invalid_csv"
+	MsgInvalidRender       = "invalid_render"
+	MsgInvalidInclude      = "invalid_include"
+	MsgMalformedID         = "malformed_id"
+	MsgWrongIDKind         = "wrong_id_kind"
+	MsgRenderUnsupported   = "render_unsupported"
+	MsgInvalidDurability   = "invalid_durability"
+
+	// Request body decoding
+	MsgRequestBodyTooLarge = "request_body_too_large"
+	MsgBodyEmpty           = "body_empty"
+	MsgBodySyntax          = "body_syntax"
+	MsgBodyTruncated       = "body_truncated"
+	MsgBodyTrailingData    = "body_trailing_data"
+	MsgBodyNotObject       = "body_not_object"
+	MsgBodyFieldType       = "body_field_type"
+	MsgBodyUnknownField    = "body_unknown_field"
+	MsgBodyFieldRequired   = "body_field_required"
+	MsgBodyFieldInvalid    = "body_field_invalid"
+
+	// Authentication
+	MsgAuthHeaderRequired    = "auth_header_required"
+	MsgAuthHeaderFormat      = "auth_header_format"
+	MsgInvalidToken          = "invalid_token"
+	MsgInvalidCredentials    = "invalid_credentials"
+	MsgInvalidOTP            = "invalid_otp"
+	MsgOTPNotFound           = "otp_not_found"
+	MsgOTPExpired            = "otp_expired"
+	MsgOTPMismatch           = "otp_mismatch"
+	MsgOTPAttemptsExceeded   = "otp_attempts_exceeded"
+	MsgOTPLocked             = "otp_locked"
+	MsgUserIDMissing         = "user_id_missing"
+	MsgUserIDInvalid         = "user_id_invalid"
+	MsgPasswordProcessFailed = "password_process_failed"
+	MsgTokenGenerateFailed   = "token_generate_failed"
+	MsgOTPGenerateFailed     = "otp_generate_failed"
+	MsgPasswordUpdateFailed  = "password_update_failed"
+
+	// Profiles
+	MsgProfileNotFound       = "profile_not_found"
+	MsgProfileEmailNotFound  = "profile_email_not_found"
+	MsgEmailAlreadyExists    = "email_already_exists"
+	MsgInvalidPhone          = "invalid_phone"
+	MsgProfileSearchInvalid  = "profile_search_invalid"
+	MsgTooManyProfileIDs     = "too_many_profile_ids"
+	MsgRateLimited           = "rate_limited"
+	MsgProfileCreateFailed   = "profile_create_failed"
+	MsgProfileUpdateFailed   = "profile_update_failed"
+	MsgProfileDeleteFailed   = "profile_delete_failed"
+	MsgInvalidVisibility     = "invalid_visibility"
+	MsgErasureNotFound       = "erasure_not_found"
+	MsgErasureNotPending     = "erasure_not_pending"
+	MsgErasureFailed         = "erasure_schedule_failed"
+	MsgAccountDeactivated    = "account_deactivated"
+	MsgTokenRevoked          = "token_revoked"
+	MsgDeactivationInvalid   = "deactivation_invalid"
+	MsgProfileNotDeactivated = "profile_not_deactivated"
+	MsgPurgeFailed           = "purge_failed"
+	MsgExportFailed          = "export_failed"
+	MsgTosNotConfigured      = "tos_not_configured"
+	MsgTosVersionMismatch    = "tos_version_mismatch"
+	MsgTosNotAccepted        = "tos_not_accepted"
+	MsgTosAcceptFailed       = "tos_accept_failed"
+	MsgPasswordIncorrect     = "password_incorrect"
+	MsgEmailChangeSame       = "email_change_same"
+	MsgEmailChangeNotFound   = "email_change_not_found"
+	MsgEmailChangeExpired    = "email_change_expired"
+	MsgEmailChangeMismatch   = "email_change_mismatch"
+	MsgEmailChangeFailed     = "email_change_failed"
+
+	// Documents
+	MsgDocumentNotFound               = "document_not_found"
+	MsgDocumentAlreadyExists          = "document_already_exists"
+	MsgDocumentExistsCondition        = "document_exists_precondition"
+	MsgDocumentAccessDenied           = "document_access_denied"
+	MsgDocumentUpdateDenied           = "document_update_denied"
+	MsgDocumentDeleteDenied           = "document_delete_denied"
+	MsgDocumentCreateFailed           = "document_create_failed"
+	MsgDocumentUpdateFailed           = "document_update_failed"
+	MsgDocumentSyncFailed             = "document_sync_failed"
+	MsgDocumentDeleteFailed           = "document_delete_failed"
+	MsgDocumentQueryFailed            = "document_query_failed"
+	MsgFavoriteFailed                 = "favorite_failed"
+	MsgInvalidVersion                 = "invalid_version"
+	MsgInvalidRevision                = "invalid_revision"
+	MsgDocumentVersionNotFound        = "document_version_not_found"
+	MsgDocumentFrozen                 = "document_frozen"
+	MsgDocumentFreezeDenied           = "document_freeze_denied"
+	MsgDocumentUnfreezeDenied         = "document_unfreeze_denied"
+	MsgDocumentExpiryDenied           = "document_expiry_denied"
+	MsgSignedURLInvalidOp             = "signed_url_invalid_op"
+	MsgSignedURLInvalidTTL            = "signed_url_invalid_ttl"
+	MsgSignedURLDenied                = "signed_url_denied"
+	MsgSignedURLCreateFailed          = "signed_url_create_failed"
+	MsgSignedURLInvalid               = "signed_url_invalid"
+	MsgSignedURLWrongOp               = "signed_url_wrong_op"
+	MsgSignedURLUsed                  = "signed_url_used"
+	MsgProfileExtraTooLarge           = "profile_extra_too_large"
+	MsgProfileExtraSensitiveKey       = "profile_extra_sensitive_key"
+	MsgProfileExtraSchema             = "profile_extra_schema"
+	MsgExtraPolicyInvalid             = "extra_policy_invalid"
+	MsgDeviceRevoked                  = "device_revoked"
+	MsgDeviceNotFound                 = "device_not_found"
+	MsgIntrospectClientInvalid        = "introspect_client_invalid"
+	MsgScopeDenied                    = "scope_denied"
+	MsgTokenAccountMissing            = "token_account_missing"
+	MsgServiceAccountNotFound         = "service_account_not_found"
+	MsgServiceAccountInvalid          = "service_account_invalid"
+	MsgServiceTokenScopes             = "service_token_scopes"
+	MsgServiceTokenTTL                = "service_token_ttl"
+	MsgTokenDocumentsOnly             = "token_documents_only"
+	MsgTokenDocumentDenied            = "token_document_denied"
+	MsgRestrictedTokenInvalid         = "restricted_token_invalid"
+	MsgRestrictedTokenDocuments       = "restricted_token_documents"
+	MsgDocumentChanged                = "document_changed"
+	MsgDocumentVersionMismatch        = "document_version_mismatch"
+	MsgDocumentRevisionMismatch       = "document_revision_mismatch"
+	MsgLintUnsupported                = "lint_unsupported"
+	MsgLintInvalid                    = "lint_invalid"
+	MsgContentPathInvalid             = "content_path_invalid"
+	MsgContentPathNotFound            = "content_path_not_found"
+	MsgContentPathUnusable            = "content_path_unusable"
+	MsgContentPathNotArray            = "content_path_not_array"
+	MsgArrayIndexOutOfRange           = "array_index_out_of_range"
+	MsgArrayIndexInvalid              = "array_index_invalid"
+	MsgContentPathNotNumber           = "content_path_not_number"
+	MsgIncrementOverflow              = "increment_overflow"
+	MsgIncrementInvalid               = "increment_invalid"
+	MsgContentConditionFailed         = "content_condition_failed"
+	MsgContentConditionError          = "content_condition_error"
+	MsgContentConditionInvalid        = "content_condition_invalid"
+	MsgLoadGenerationDisabled         = "load_generation_disabled"
+	MsgLoadGenerationInvalid          = "load_generation_invalid"
+	MsgLoadGenerationTooLarge         = "load_generation_too_large"
+	MsgLoadGenerationFailed           = "load_generation_failed"
+	MsgNotificationChannelNotFound    = "notification_channel_not_found"
+	MsgNotificationChannelInvalid     = "notification_channel_invalid"
+	MsgNotificationChannelSaveFailed  = "notification_channel_save_failed"
+	MsgNotificationChannelRateLimited = "notification_channel_rate_limited"
+	MsgNotificationDeliveryFailed     = "notification_delivery_failed"
+	MsgCalendarFeedInvalid            = "calendar_feed_invalid"
+	MsgCalendarFeedRevoked            = "calendar_feed_revoked"
+	MsgCalendarFeedFailed             = "calendar_feed_failed"
+	MsgDocumentLinkInaccessible       = "document_link_inaccessible"
+	MsgDuplicateThresholdInvalid      = "duplicate_threshold_invalid"
+	MsgDuplicateScopeInvalid          = "duplicate_scope_invalid"
+	MsgDocumentStatsLastInvalid       = "document_stats_last_invalid"
+	MsgSDKLanguageUnknown             = "sdk_language_unknown"
+	MsgSDKFailed                      = "sdk_failed"
+	MsgQueryEmpty                     = "query_empty"
+	MsgQueryValueType                 = "query_value_type"
+	MsgQueryLengthValue               = "query_length_value"
+	MsgQueryUnquotedString            = "query_unquoted_string"
+	MsgQueryMixedLogic                = "query_mixed_logic"
+	MsgWorkflowInvalidBody            = "workflow_invalid_body"
+	MsgWorkflowInvalidState           = "workflow_invalid_state"
+	MsgWorkflowInvalidTransition      = "workflow_invalid_transition"
+	MsgWorkflowOwnerOnly              = "workflow_owner_only"
+	MsgWorkflowReviewerOnly           = "workflow_reviewer_only"
+	MsgWorkflowReviewerNotSharer      = "workflow_reviewer_not_sharer"
+	MsgFetchURLInvalid                = "fetch_url_invalid"
+	MsgFetchDomainNotAllowed          = "fetch_domain_not_allowed"
+	MsgFetchFailed                    = "fetch_failed"
+	MsgFetchTimeout                   = "fetch_timeout"
+	MsgReplaceInvalid                 = "replace_invalid"
+	MsgReplaceFailed                  = "replace_failed"
+
+	// Administration and scripts
+	MsgAdminOnly           = "admin_only"
+	MsgScriptNotFound      = "script_not_found"
+	MsgScriptInvalid       = "script_invalid"
+	MsgScriptSaveFailed    = "script_save_failed"
+	MsgScriptRejected      = "script_rejected"
+	MsgScriptFailed        = "script_failed"
+	MsgScheduleNotFound    = "schedule_not_found"
+	MsgScheduleInvalid     = "schedule_invalid"
+	MsgScheduleSaveFailed  = "schedule_save_failed"
+	MsgScheduleRunNotFound = "schedule_run_not_found"
+
+	// Reviews
+	MsgReviewInvalidBody         = "review_invalid_body"
+	MsgReviewFeedbackInvalidBody = "review_feedback_invalid_body"
+	MsgReviewNotFound            = "review_not_found"
+	MsgReviewerNotFound          = "reviewer_not_found"
+	MsgReviewAssignDenied        = "review_assign_denied"
+	MsgReviewAccessDenied        = "review_access_denied"
+	MsgReviewOwnDocument         = "review_own_document"
+	MsgReviewAlreadyAssigned     = "review_already_assigned"
+	MsgReviewReviewerOnly        = "review_reviewer_only"
+	MsgReviewInvalidRating       = "review_invalid_rating"
+	MsgReviewInvalidCriteria     = "review_invalid_criteria"
+	MsgReviewInvalidStatus       = "review_invalid_status"
+
+	// Maintenance mode
+	MsgMaintenance      = "maintenance"
+	MsgMaintenanceNote  = "maintenance_note"
+	MsgMaintenanceRoute = "maintenance_route_invalid"
+	MsgReadOnly         = "read_only"
+
+	// Mock data
+	MsgMockDisabled = "mock_disabled"
+	MsgMockCount    = "mock_count_invalid"
+	MsgMockShape    = "mock_shape_invalid"
+	MsgMockSeed     = "mock_seed_invalid"
+
+	// Chaos injection
+	MsgChaosDisabled = "chaos_disabled"
+	MsgChaosSettings = "chaos_settings_invalid"
+	MsgChaosRoute    = "chaos_route_invalid"
+	MsgChaosInjected = "chaos_injected_error"
+
+	// Invitations
+	MsgInviteRequired     = "invite_required"
+	MsgInviteInvalid      = "invite_invalid"
+	MsgInviteExpired      = "invite_expired"
+	MsgInviteUsedUp       = "invite_used_up"
+	MsgInviteNotFound     = "invite_not_found"
+	MsgInviteSettings     = "invite_settings_invalid"
+	MsgInviteCreateFailed = "invite_create_failed"
+
+	// Course databases
+	MsgCourseNotFound    = "course_not_found"
+	MsgCourseSettings    = "course_settings_invalid"
+	MsgCourseExists      = "course_exists"
+	MsgCourseUnavailable = "course_unavailable"
+	MsgCourseDropFailed  = "course_drop_failed"
+
+	// Roster provisioning
+	MsgProvisionInvalidBody     = "provision_invalid_body"
+	MsgProvisionInvalidCSV      = "provision_invalid_csv"
+	MsgProvisionEmpty           = "provision_empty"
+	MsgProvisionTooMany         = "provision_too_many"
+	MsgProvisionInvalidDelivery = "provision_invalid_delivery"
+	MsgProvisionInvalidEmail    = "provision_invalid_email"
+	MsgProvisionMissingName     = "provision_missing_name"
+	MsgProvisionDuplicate       = "provision_duplicate"
+	MsgProvisionFailed          = "provision_failed"
+
+	// Bot protection
+	MsgChallengeRequired    = "challenge_required"
+	MsgChallengeFailed      = "challenge_failed"
+	MsgChallengeUnavailable = "challenge_unavailable"
+	MsgChallengeNotIssued   = "challenge_not_issued"
+
+	// Sharing
+	MsgShareOwnerOnly     = "share_owner_only"
+	MsgShareWithOwner     = "share_with_owner"
+	MsgSharesUpdateFailed = "shares_update_failed"
+	MsgSharerAddFailed    = "sharer_add_failed"
+	MsgSharerRemoveFailed = "sharer_remove_failed"
+
+	// API versioning
+	MsgAPIVersionMismatch    = "api_version_mismatch"
+	MsgAPIVersionUnsupported = "api_version_unsupported"
+	MsgServerVersionTooOld   = "server_version_too_old"
+	MsgInvalidMinVersion     = "invalid_min_version"
+
+	// Content query parser
+	MsgQueryInvalid              = "query_invalid"
+	MsgQueryPartEmpty            = "query_part_empty"
+	MsgQueryInvalidCondition     = "query_invalid_condition"
+	MsgQueryInvalidLogic         = "query_invalid_logic"
+	MsgQueryTrailingLogic        = "query_trailing_logic"
+	MsgQueryLogicMismatch        = "query_logic_mismatch"
+	MsgQueryMissingValue         = "query_missing_value"
+	MsgQueryValueStart           = "query_value_start"
+	MsgQueryInvalidOperator      = "query_invalid_operator"
+	MsgQueryInvalidFormat        = "query_invalid_format"
+	MsgQueryInvalidInsensitive   = "query_invalid_insensitive"
+	MsgQueryInvalidScope         = "query_invalid_scope"
+	MsgQueryScopeAdminOnly       = "query_scope_admin_only"
+	MsgQueryInvalidOrder         = "query_invalid_order"
+	MsgQueryInvalidSortBy        = "query_invalid_sort_by"
+	MsgQueryInvalidMissing       = "query_invalid_missing"
+	MsgQueryInvalidWorkflowState = "query_invalid_workflow_state"
+	MsgQueryInvalidExpired       = "query_invalid_expired"
+	MsgQueryMissingPath          = "query_missing_path"
+)
+
+// catalogs maps language -> message ID -> fmt template.
+var catalogs = map[string]map[string]string{
+	"en": {
+		MsgInvalidRequestBody:  "Invalid request body: %v",
+		MsgInvalidDocumentBody: "Invalid request body: %v. 'content' must be provided.",
+		MsgInvalidSharesBody:   "Invalid request body: %v. 'shared_with' array is required.",
+		MsgInvalidPagination:   "Invalid 'page' or 'limit' query parameter. Must be positive integers.",
+		MsgIDAndKeyExclusive:   "Provide either 'id' or 'key', not both.",
+		MsgInvalidCustomID:     "Invalid 'id': must be 1-64 characters of letters, digits, '_' or '-'.",
+		MsgKeyDerivedID:        "Invalid 'id': it has the form of an ID derived from a 'key'; send the 'key' instead.",
+		MsgInvalidDocumentID:   "Invalid document ID: must be 1-64 characters of letters, digits, '_' or '-'.",
+		MsgDocumentIDRequired:  "Document ID is required in the path.",
+		MsgShareIDsRequired:    "Document ID and Profile ID are required in the path.",
+		MsgInvalidContentType:  "Invalid content_type '%s'. Use json, markdown, text or csv.",
+		MsgContentNotText:      "The content of a %s document must be a string.",
+		MsgContentInvalidCSV:   "The content is not valid CSV: %v",
+		MsgInvalidRender:       "Invalid render value '%s'. Use html.",
+		MsgInvalidInclude:      "Invalid include value '%s'. Use shares, owner or both, separated by a comma.",
+		MsgMalformedID:         "'%s' is not a valid ID. IDs are 1-64 letters, digits, '_' or '-'.",
+		MsgWrongIDKind:         "'%s' has the '%s_' prefix of another kind of ID. Expected an ID starting with '%s_'.",
+		MsgRenderUnsupported:   "Only markdown documents can be rendered as HTML; this document is %s.",
+		MsgInvalidDurability:   "Invalid durability value '%s'. Use flush.",
+
+		MsgRequestBodyTooLarge: "Request body is too large. The limit is %d bytes.",
+		MsgBodyEmpty:           "the body is empty",
+		MsgBodySyntax:          "malformed JSON at byte %d",
+		MsgBodyTruncated:       "the JSON ends unexpectedly",
+		MsgBodyTrailingData:    "unexpected data after the JSON value",
+		MsgBodyNotObject:       "the body must be a JSON %s, not %s",
+		MsgBodyFieldType:       "'%s' must be a JSON %s, not %s",
+		MsgBodyUnknownField:    "unknown field '%s'",
+		MsgBodyFieldRequired:   "'%s' is required",
+		MsgBodyFieldInvalid:    "'%s' does not satisfy '%s'",
+
+		MsgAuthHeaderRequired:    "Authorization header required",
+		MsgAuthHeaderFormat:      "Authorization header format must be Bearer {token}",
+		MsgInvalidToken:          "Invalid token: %v",
+		MsgInvalidCredentials:    "Invalid email or password",
+		MsgInvalidOTP:            "Invalid OTP.",
+		MsgOTPNotFound:           "no OTP found for this email or it has expired",
+		MsgOTPExpired:            "OTP has expired",
+		MsgOTPMismatch:           "invalid OTP",
+		MsgOTPAttemptsExceeded:   "too many incorrect OTP attempts; request a new code",
+		MsgOTPLocked:             "too many failed OTP attempts; try again in %d seconds",
+		MsgUserIDMissing:         "User ID not found in context.",
+		MsgUserIDInvalid:         "Invalid User ID format in context.",
+		MsgPasswordProcessFailed: "Failed to process password.",
+		MsgTokenGenerateFailed:   "Failed to generate authentication token.",
+		MsgOTPGenerateFailed:     "Failed to generate OTP: %v",
+		MsgPasswordUpdateFailed:  "Failed to update password: %v",
+
+		MsgProfileNotFound:       "Authenticated user profile not found.",
+		MsgProfileEmailNotFound:  "profile with email '%s' not found",
+		MsgEmailAlreadyExists:    "email '%s' already exists",
+		MsgInvalidPhone:          "Invalid phone number: use international format, e.g. +14155550123.",
+		MsgProfileSearchInvalid:  "Invalid profile search: %v",
+		MsgTooManyProfileIDs:     "Between 1 and %d profile IDs can be resolved at once.",
+		MsgRateLimited:           "Too many requests. Try again in %d seconds.",
+		MsgProfileCreateFailed:   "Failed to create profile: %v",
+		MsgProfileUpdateFailed:   "Failed to update profile: %v",
+		MsgProfileDeleteFailed:   "Failed to delete profile: %v",
+		MsgInvalidVisibility:     "Invalid privacy setting '%s' for '%s': must be 'public', 'sharers' or 'private'.",
+		MsgErasureNotFound:       "No erasure has been requested for your profile.",
+		MsgErasureNotPending:     "There is no pending erasure request to cancel.",
+		MsgErasureFailed:         "Failed to schedule erasure: %v",
+		MsgAccountDeactivated:    "This account is deactivated. An administrator can reactivate it until %s, when it will be erased.",
+		MsgTokenRevoked:          "This token was issued before the password was changed. Please log in again.",
+		MsgDeactivationInvalid:   "Invalid deactivation request: %v",
+		MsgProfileNotDeactivated: "Profile '%s' is not deactivated.",
+		MsgPurgeFailed:           "Failed to purge account: %v",
+		MsgExportFailed:          "Failed to export data: %v",
+		MsgTosNotConfigured:      "This server has no terms of service to accept.",
+		MsgTosVersionMismatch:    "Terms of service version '%s' is not the current version '%s'.",
+		MsgTosNotAccepted:        "You must accept the terms of service (version '%s') with POST /profiles/me/accept-tos before using this endpoint.",
+		MsgTosAcceptFailed:       "Failed to record terms of service acceptance: %v",
+		MsgPasswordIncorrect:     "The current password is incorrect.",
+		MsgEmailChangeSame:       "The new email address is the same as the current one.",
+		MsgEmailChangeNotFound:   "There is no pending email change to confirm.",
+		MsgEmailChangeExpired:    "The email change has expired. Please request it again.",
+		MsgEmailChangeMismatch:   "The confirmation token is invalid.",
+		MsgEmailChangeFailed:     "Failed to change email address: %v",
+
+		MsgDocumentNotFound:               "Document with ID '%s' not found.",
+		MsgDocumentAlreadyExists:          "document with ID '%s' already exists",
+		MsgDocumentExistsCondition:        "Document with ID '%s' already exists (If-None-Match: *).",
+		MsgDocumentAccessDenied:           "You do not have permission to access this document.",
+		MsgDocumentUpdateDenied:           "You do not have permission to update this document.",
+		MsgDocumentDeleteDenied:           "You do not have permission to delete this document.",
+		MsgDocumentCreateFailed:           "Failed to create document: %v",
+		MsgDocumentUpdateFailed:           "Failed to update document: %v",
+		MsgDocumentSyncFailed:             "The document was updated but could not be saved to disk: %v",
+		MsgDocumentDeleteFailed:           "Failed to delete document: %v",
+		MsgDocumentQueryFailed:            "Failed to query documents: %v",
+		MsgFavoriteFailed:                 "Failed to update favorites: %v",
+		MsgInvalidVersion:                 "Invalid '%s' query parameter. Must be a positive integer version number.",
+		MsgInvalidRevision:                "Invalid 'revision' query parameter. Must be a whole number, 0 or more.",
+		MsgDocumentVersionNotFound:        "Version %d of document '%s' not found. Only recent versions are kept.",
+		MsgDocumentFrozen:                 "Document '%s' is frozen and cannot be changed until it is unfrozen.",
+		MsgDocumentFreezeDenied:           "Only the document owner or an administrator can freeze or unfreeze this document.",
+		MsgDocumentUnfreezeDenied:         "This document was frozen by an administrator. Only an administrator can unfreeze it.",
+		MsgDocumentExpiryDenied:           "Only the document owner or an administrator can change when this document expires.",
+		MsgSignedURLInvalidOp:             "Invalid op '%s': use 'read' or 'write'.",
+		MsgSignedURLInvalidTTL:            "Invalid ttl '%s': use a duration between 1s and %s, e.g. 10m.",
+		MsgSignedURLDenied:                "You cannot create a signed URL for this operation on this document.",
+		MsgSignedURLCreateFailed:          "Failed to create the signed URL: %v",
+		MsgSignedURLInvalid:               "Invalid signed URL: %v",
+		MsgSignedURLWrongOp:               "This signed URL only allows '%s'.",
+		MsgSignedURLUsed:                  "This signed URL has already been used.",
+		MsgProfileExtraTooLarge:           "'extra' is too large: %d bytes, at most %d allowed.",
+		MsgProfileExtraSensitiveKey:       "'extra' may not contain the key '%s'.",
+		MsgProfileExtraSchema:             "'extra' does not match the schema: %v",
+		MsgExtraPolicyInvalid:             "Invalid extra policy: %v",
+		MsgDeviceRevoked:                  "This token was issued to a device you revoked. Please log in again.",
+		MsgDeviceNotFound:                 "Device '%s' not found",
+		MsgIntrospectClientInvalid:        "Invalid or missing introspection client credentials",
+		MsgScopeDenied:                    "This token's scopes (%s) do not allow %s %s",
+		MsgTokenAccountMissing:            "The account this token was issued to no longer exists",
+		MsgServiceAccountNotFound:         "Service account '%s' not found",
+		MsgServiceAccountInvalid:          "Invalid service account: %v",
+		MsgServiceTokenScopes:             "Tokens of this service account can only carry its scopes: %s",
+		MsgServiceTokenTTL:                "Invalid ttl '%s': expected a positive duration of at most %s",
+		MsgTokenDocumentsOnly:             "This token is limited to some documents and does not allow %s %s",
+		MsgTokenDocumentDenied:            "You cannot read document '%s'",
+		MsgRestrictedTokenInvalid:         "Invalid restricted token: %v",
+		MsgRestrictedTokenDocuments:       "A token can be limited to at most %d documents",
+		MsgDocumentChanged:                "Document '%s' changed while the request was handled. Read it again and retry.",
+		MsgDocumentVersionMismatch:        "Document '%s' is at version %d, not version %d. Read it again and retry.",
+		MsgDocumentRevisionMismatch:       "Version %d of document '%s' was replaced by a coalesced update (revision %d, not revision %d). Read it again and retry.",
+		MsgLintUnsupported:                "Only JSON documents can be linted; document '%s' holds %s content.",
+		MsgLintInvalid:                    "Invalid lint request: %v",
+		MsgContentPathInvalid:             "Invalid content path '%s'. Use dot-separated object keys and array indexes, e.g. 'chapters.2.title', escaping dots and the characters *?#@|[]{}! in keys with a backslash.",
+		MsgContentPathNotFound:            "There is no value at '%s' in document '%s'.",
+		MsgContentPathUnusable:            "Cannot change '%s': %v",
+		MsgContentPathNotArray:            "The value at '%s' in document '%s' is not an array.",
+		MsgArrayIndexOutOfRange:           "Index %d is out of range for '%s', which has %d items.",
+		MsgArrayIndexInvalid:              "Invalid 'index' query parameter '%s'. Must be a whole number, -1 or more.",
+		MsgContentPathNotNumber:           "The value at '%s' in document '%s' is not a number.",
+		MsgIncrementOverflow:              "Incrementing '%s' would give a number too large to store.",
+		MsgIncrementInvalid:               "Invalid increment: %v. 'delta' must be a finite number.",
+		MsgContentConditionFailed:         "Document '%s' does not match the If-Content-Matches condition, so it was not changed.",
+		MsgContentConditionError:          "The If-Content-Matches condition cannot be evaluated against document '%s': %v",
+		MsgContentConditionInvalid:        "Invalid If-Content-Matches header: %v",
+		MsgLoadGenerationDisabled:         "Load generation is disabled on this server (see -allow-load-generation)",
+		MsgLoadGenerationInvalid:          "Invalid '%s' value '%s': expected a whole number of at least %d",
+		MsgLoadGenerationTooLarge:         "'%s' is %d, but this server generates at most %d at a time",
+		MsgLoadGenerationFailed:           "Load generation failed after creating %d documents: %v",
+		MsgNotificationChannelNotFound:    "Notification channel with ID '%s' not found.",
+		MsgNotificationChannelInvalid:     "Invalid notification channel: %v",
+		MsgNotificationChannelSaveFailed:  "Failed to save notification channel: %v",
+		MsgNotificationChannelRateLimited: "The notification channel was sent as many messages as it allows this hour; try again later.",
+		MsgNotificationDeliveryFailed:     "The test message could not be delivered: %v",
+		MsgCalendarFeedInvalid:            "Invalid calendar feed token: %v",
+		MsgCalendarFeedRevoked:            "This calendar feed was revoked or replaced by a newer one",
+		MsgCalendarFeedFailed:             "Failed to create the calendar feed: %v",
+		MsgDocumentLinkInaccessible:       "Cannot link to document '%s': it does not exist or you cannot read it",
+		MsgDuplicateThresholdInvalid:      "Invalid threshold '%s': must be a number between %v and 1",
+		MsgDuplicateScopeInvalid:          "Invalid scope '%s': must be 'owned', 'shared' or 'all'",
+		MsgDocumentStatsLastInvalid:       "Invalid 'last' value '%s': must be an integer between 1 and %d",
+		MsgSDKLanguageUnknown:             "No client library for '%s': available languages are %s",
+		MsgSDKFailed:                      "Failed to load the client library: %v",
+		MsgQueryEmpty:                     "the query has no conditions, so every document matches",
+		MsgQueryValueType:                 "operator '%s' needs a %s value, but %s is a %s",
+		MsgQueryLengthValue:               "'%s' is a number of elements, so compare it with a number using equals, notequals, greaterthan, lessthan, greaterthanorequals or lessthanorequals",
+		MsgQueryUnquotedString:            "the value %s is not quoted, so it is read as a string; quote it to make that explicit",
+		MsgQueryMixedLogic:                "'and' and 'or' are applied from left to right without precedence: a or b and c means (a or b) and c",
+		MsgWorkflowInvalidBody:            "Invalid request body: %v. 'state' is required.",
+		MsgWorkflowInvalidState:           "Invalid workflow state '%s'. Expected 'draft', 'submitted', 'approved' or 'rejected'.",
+		MsgWorkflowInvalidTransition:      "A document in state '%s' cannot move to '%s'.",
+		MsgWorkflowOwnerOnly:              "Only the document owner can move it to '%s'.",
+		MsgWorkflowReviewerOnly:           "Only the document's reviewer or an administrator can approve or reject it.",
+		MsgWorkflowReviewerNotSharer:      "The reviewer '%s' must be a profile the document is shared with.",
+		MsgFetchURLInvalid:                "Invalid URL '%s': %v",
+		MsgFetchDomainNotAllowed:          "Fetching from '%s' is not allowed. Allowed domains: %s",
+		MsgFetchFailed:                    "Failed to fetch JSON from '%s': %v",
+		MsgFetchTimeout:                   "'%s' did not respond within %s.",
+		MsgReplaceInvalid:                 "Invalid replacement: %v",
+		MsgReplaceFailed:                  "Failed to apply the replacement; no document was changed: %v",
+
+		MsgAdminOnly:           "This endpoint is only available to administrators.",
+		MsgScriptNotFound:      "Script with ID '%s' not found.",
+		MsgScriptInvalid:       "Invalid script: %v",
+		MsgScriptSaveFailed:    "Failed to save script: %v",
+		MsgScriptRejected:      "Rejected by script '%s': %s",
+		MsgScriptFailed:        "Script '%s' failed: %v",
+		MsgScheduleNotFound:    "Schedule with ID '%s' not found.",
+		MsgScheduleInvalid:     "Invalid schedule: %v",
+		MsgScheduleSaveFailed:  "Failed to save schedule: %v",
+		MsgScheduleRunNotFound: "No download is available for run '%s'. Only successful runs of download schedules keep a snapshot, and only recent runs are kept.",
+
+		MsgReviewInvalidBody:         "Invalid request body: %v. 'reviewer_id' is required.",
+		MsgReviewFeedbackInvalidBody: "Invalid request body: %v. 'rating' is required.",
+		MsgReviewNotFound:            "Review '%s' not found.",
+		MsgReviewerNotFound:          "Reviewer profile '%s' not found.",
+		MsgReviewAssignDenied:        "Only the document owner or an administrator can assign and remove reviewers.",
+		MsgReviewAccessDenied:        "You do not have access to this review.",
+		MsgReviewOwnDocument:         "Owners cannot review their own documents.",
+		MsgReviewAlreadyAssigned:     "Profile '%s' is already assigned to review this document.",
+		MsgReviewReviewerOnly:        "Only the assigned reviewer can give feedback in this review.",
+		MsgReviewInvalidRating:       "Invalid rating %d for '%s'. Ratings are whole numbers from 1 to 5.",
+		MsgReviewInvalidCriteria:     "Invalid criteria. A review rates at most %d criteria, with names of 1-%d characters.",
+		MsgReviewInvalidStatus:       "Invalid review status '%s'. Expected 'pending' or 'submitted'.",
+
+		MsgMaintenance:      "The server is under maintenance and only accepts reads. Please try again later.",
+		MsgMaintenanceNote:  "The server is under maintenance and only accepts reads: %s",
+		MsgMaintenanceRoute: "Invalid maintenance route '%s'. Routes are paths such as /documents.",
+		MsgReadOnly:         "This server is read-only and does not accept changes.",
+		MsgMockDisabled:     "Mock data is only available while the server runs in debug mode.",
+		MsgMockCount:        "Invalid 'n' query parameter. Must be a whole number from 1 to %d.",
+		MsgMockShape:        "Invalid 'shape' query parameter: %v",
+		MsgMockSeed:         "Invalid 'seed' query parameter. Must be a whole number.",
+
+		MsgChaosDisabled:      "Chaos injection is only available while the server runs in debug mode.",
+		MsgChaosSettings:      "Invalid chaos settings: %v",
+		MsgChaosRoute:         "Invalid chaos route '%s'. Routes are paths such as /documents.",
+		MsgChaosInjected:      "Injected failure: chaos mode is on for this route.",
+		MsgInviteRequired:     "An invitation code is required to sign up on this server.",
+		MsgInviteInvalid:      "Invalid invitation code.",
+		MsgInviteExpired:      "This invitation code has expired.",
+		MsgInviteUsedUp:       "This invitation code has already been used the maximum number of times.",
+		MsgInviteNotFound:     "Invitation code '%s' not found.",
+		MsgInviteSettings:     "Invalid invitation settings: %v",
+		MsgInviteCreateFailed: "Failed to create invitation code: %v",
+
+		MsgCourseNotFound:    "Course '%s' not found.",
+		MsgCourseSettings:    "Invalid course settings: %v",
+		MsgCourseExists:      "Course could not be created: %v",
+		MsgCourseUnavailable: "The database of course '%s' could not be opened. Please try again later.",
+		MsgCourseDropFailed:  "Failed to drop course '%s': %v",
+
+		MsgProvisionInvalidBody:     "Invalid roster: %v. Send JSON with an 'accounts' list, or CSV.",
+		MsgProvisionInvalidCSV:      "Invalid CSV roster: %v. The first line must name the columns 'email', 'first_name', 'last_name' and, optionally, 'group'.",
+		MsgProvisionEmpty:           "The roster holds no accounts.",
+		MsgProvisionTooMany:         "The roster holds too many accounts. The limit is %d per request.",
+		MsgProvisionInvalidDelivery: "Invalid delivery '%s'. Expected 'response' or 'email'.",
+		MsgProvisionInvalidEmail:    "Invalid email address '%s'.",
+		MsgProvisionMissingName:     "'first_name' and 'last_name' are required.",
+		MsgProvisionDuplicate:       "Same email address as row %d.",
+		MsgProvisionFailed:          "Failed to create the account: %v",
+
+		MsgChallengeRequired:    "Solve the bot-protection challenge and send its response as 'challenge'.",
+		MsgChallengeFailed:      "The bot-protection challenge was not solved. Get a new challenge and try again.",
+		MsgChallengeUnavailable: "The bot-protection challenge could not be checked right now. Please try again later.",
+		MsgChallengeNotIssued:   "Failed to create a challenge: %v",
+
+		MsgShareOwnerOnly:     "Only the document owner can manage shares.",
+		MsgShareWithOwner:     "Cannot share document with the owner.",
+		MsgSharesUpdateFailed: "Failed to update shares: %v",
+		MsgSharerAddFailed:    "Failed to add sharer: %v",
+		MsgSharerRemoveFailed: "Failed to remove sharer: %v",
+
+		MsgAPIVersionMismatch:    "API version '%s' was requested but this path serves '%s'. Use the '/%s' prefix.",
+		MsgAPIVersionUnsupported: "API version '%s' is not supported. Supported versions: %s.",
+		MsgServerVersionTooOld:   "This server runs version %s, which is older than the required version %s.",
+		MsgInvalidMinVersion:     "Invalid minimum version '%s'. Use a release version such as v1.2.3.",
+
+		MsgQueryInvalid:              "invalid content_query: %v",
+		MsgQueryPartEmpty:            "query part at index %d is empty",
+		MsgQueryInvalidCondition:     "invalid condition at index %d ('%s'): %v",
+		MsgQueryInvalidLogic:         "invalid logical operator at index %d: '%s', expected 'and' or 'or'",
+		MsgQueryTrailingLogic:        "query must end with a condition, not a logical operator",
+		MsgQueryLogicMismatch:        "mismatch between number of conditions and logical operators",
+		MsgQueryMissingValue:         "condition must have at least an operator and a value",
+		MsgQueryValueStart:           "internal parsing error: could not find value start",
+		MsgQueryInvalidOperator:      "invalid operator '%s'",
+		MsgQueryInvalidFormat:        "invalid condition format",
+		MsgQueryInvalidInsensitive:   "invalid base operator for insensitive matching '%s'",
+		MsgQueryInvalidScope:         "invalid scope value: '%s', expected 'owned', 'shared', 'all', 'public' or 'any'",
+		MsgQueryScopeAdminOnly:       "scope 'any' is only available to administrators",
+		MsgQueryInvalidOrder:         "invalid order value: '%s', expected 'asc' or 'desc'",
+		MsgQueryInvalidSortBy:        "invalid sort_by value: '%s', expected 'creation_date' or 'last_modified_date'",
+		MsgQueryInvalidMissing:       "invalid missing value: '%s', expected 'skip', 'false' or 'error'",
+		MsgQueryInvalidWorkflowState: "invalid workflow_state value: '%s', expected 'draft', 'submitted', 'approved' or 'rejected'",
+		MsgQueryInvalidExpired:       "invalid expired value: '%s', expected 'exclude', 'include' or 'only'",
+		MsgQueryMissingPath:          "document '%s' has no path '%s' (missing=error)",
+	},
+	"es": {
+		MsgInvalidRequestBody:  "Cuerpo de la solicitud no válido: %v",
+		MsgInvalidDocumentBody: "Cuerpo de la solicitud no válido: %v. Se debe proporcionar 'content'.",
+		MsgInvalidSharesBody:   "Cuerpo de la solicitud no válido: %v. El arreglo 'shared_with' es obligatorio.",
+		MsgInvalidPagination:   "Parámetro 'page' o 'limit' no válido. Deben ser enteros positivos.",
+		MsgIDAndKeyExclusive:   "Proporcione 'id' o 'key', pero no ambos.",
+		MsgInvalidCustomID:     "'id' no válido: debe tener de 1 a 64 caracteres entre letras, dígitos, '_' o '-'.",
+		MsgKeyDerivedID:        "'id' no válido: tiene la forma de un ID derivado de una 'key'; envíe la 'key' en su lugar.",
+		MsgInvalidDocumentID:   "ID de documento no válido: debe tener de 1 a 64 caracteres entre letras, dígitos, '_' o '-'.",
+		MsgDocumentIDRequired:  "El ID del documento es obligatorio en la ruta.",
+		MsgShareIDsRequired:    "El ID del documento y el ID del perfil son obligatorios en la ruta.",
+		MsgInvalidContentType:  "content_type '%s' no válido. Use json, markdown, text o csv.",
+		MsgContentNotText:      "El contenido de un documento %s debe ser una cadena.",
+		MsgContentInvalidCSV:   "El contenido no es CSV válido: %v",
+		MsgInvalidRender:       "Valor de render '%s' no válido. Use html.",
+		MsgInvalidInclude:      "Valor de include '%s' no válido. Use shares, owner o ambos, separados por una coma.",
+		MsgMalformedID:         "'%s' no es un ID válido. Los ID tienen de 1 a 64 letras, dígitos, '_' o '-'.",
+		MsgWrongIDKind:         "'%s' tiene el prefijo '%s_' de otro tipo de ID. Se esperaba un ID que empiece por '%s_'.",
+		MsgRenderUnsupported:   "Solo los documentos markdown se pueden mostrar como HTML; este documento es %s.",
+		MsgInvalidDurability:   "Valor de durability '%s' no válido. Use flush.",
+
+		MsgRequestBodyTooLarge: "El cuerpo de la solicitud es demasiado grande. El límite es de %d bytes.",
+		MsgBodyEmpty:           "el cuerpo está vacío",
+		MsgBodySyntax:          "JSON mal formado en el byte %d",
+		MsgBodyTruncated:       "el JSON termina de forma inesperada",
+		MsgBodyTrailingData:    "datos inesperados después del valor JSON",
+		MsgBodyNotObject:       "el cuerpo debe ser un %s JSON, no %s",
+		MsgBodyFieldType:       "'%s' debe ser un %s JSON, no %s",
+		MsgBodyUnknownField:    "campo desconocido '%s'",
+		MsgBodyFieldRequired:   "'%s' es obligatorio",
+		MsgBodyFieldInvalid:    "'%s' no cumple '%s'",
+
+		MsgAuthHeaderRequired:    "Se requiere el encabezado Authorization",
+		MsgAuthHeaderFormat:      "El encabezado Authorization debe tener el formato Bearer {token}",
+		MsgInvalidToken:          "Token no válido: %v",
+		MsgInvalidCredentials:    "Correo electrónico o contraseña no válidos",
+		MsgInvalidOTP:            "OTP no válido.",
+		MsgOTPNotFound:           "no se encontró un OTP para este correo electrónico o ha caducado",
+		MsgOTPExpired:            "el OTP ha caducado",
+		MsgOTPMismatch:           "OTP no válido",
+		MsgOTPAttemptsExceeded:   "demasiados intentos de OTP incorrectos; solicite un nuevo código",
+		MsgOTPLocked:             "demasiados intentos de OTP fallidos; vuelva a intentarlo en %d segundos",
+		MsgUserIDMissing:         "No se encontró el ID de usuario en el contexto.",
+		MsgUserIDInvalid:         "Formato de ID de usuario no válido en el contexto.",
+		MsgPasswordProcessFailed: "No se pudo procesar la contraseña.",
+		MsgTokenGenerateFailed:   "No se pudo generar el token de autenticación.",
+		MsgOTPGenerateFailed:     "No se pudo generar el OTP: %v",
+		MsgPasswordUpdateFailed:  "No se pudo actualizar la contraseña: %v",
+
+		MsgProfileNotFound:       "No se encontró el perfil del usuario autenticado.",
+		MsgProfileEmailNotFound:  "no se encontró el perfil con correo electrónico '%s'",
+		MsgEmailAlreadyExists:    "el correo electrónico '%s' ya existe",
+		MsgInvalidPhone:          "Número de teléfono no válido: use el formato internacional, p. ej. +14155550123.",
+		MsgProfileSearchInvalid:  "Búsqueda de perfiles no válida: %v",
+		MsgTooManyProfileIDs:     "Se pueden resolver entre 1 y %d IDs de perfil a la vez.",
+		MsgRateLimited:           "Demasiadas solicitudes. Inténtelo de nuevo en %d segundos.",
+		MsgProfileCreateFailed:   "No se pudo crear el perfil: %v",
+		MsgProfileUpdateFailed:   "No se pudo actualizar el perfil: %v",
+		MsgProfileDeleteFailed:   "No se pudo eliminar el perfil: %v",
+		MsgInvalidVisibility:     "Configuración de privacidad no válida '%s' para '%s': debe ser 'public', 'sharers' o 'private'.",
+		MsgErasureNotFound:       "No se ha solicitado el borrado de su perfil.",
+		MsgErasureNotPending:     "No hay ninguna solicitud de borrado pendiente que cancelar.",
+		MsgErasureFailed:         "No se pudo programar el borrado: %v",
+		MsgAccountDeactivated:    "Esta cuenta está desactivada. Un administrador puede reactivarla hasta %s, cuando será borrada.",
+		MsgTokenRevoked:          "Este token se emitió antes de cambiar la contraseña. Vuelva a iniciar sesión.",
+		MsgDeactivationInvalid:   "Solicitud de desactivación no válida: %v",
+		MsgProfileNotDeactivated: "El perfil '%s' no está desactivado.",
+		MsgPurgeFailed:           "No se pudo purgar la cuenta: %v",
+		MsgExportFailed:          "No se pudieron exportar los datos: %v",
+		MsgTosNotConfigured:      "Este servidor no tiene términos de servicio que aceptar.",
+		MsgTosVersionMismatch:    "La versión '%s' de los términos de servicio no es la versión actual '%s'.",
+		MsgTosNotAccepted:        "Debe aceptar los términos de servicio (versión '%s') con POST /profiles/me/accept-tos antes de usar este endpoint.",
+		MsgTosAcceptFailed:       "No se pudo registrar la aceptación de los términos de servicio: %v",
+		MsgPasswordIncorrect:     "La contraseña actual es incorrecta.",
+		MsgEmailChangeSame:       "La nueva dirección de correo es igual a la actual.",
+		MsgEmailChangeNotFound:   "No hay ningún cambio de correo pendiente de confirmar.",
+		MsgEmailChangeExpired:    "El cambio de correo ha caducado. Solicítelo de nuevo.",
+		MsgEmailChangeMismatch:   "El token de confirmación no es válido.",
+		MsgEmailChangeFailed:     "No se pudo cambiar la dirección de correo: %v",
+
+		MsgDocumentNotFound:               "No se encontró el documento con ID '%s'.",
+		MsgDocumentAlreadyExists:          "el documento con ID '%s' ya existe",
+		MsgDocumentExistsCondition:        "El documento con ID '%s' ya existe (If-None-Match: *).",
+		MsgDocumentAccessDenied:           "No tiene permiso para acceder a este documento.",
+		MsgDocumentUpdateDenied:           "No tiene permiso para actualizar este documento.",
+		MsgDocumentDeleteDenied:           "No tiene permiso para eliminar este documento.",
+		MsgDocumentCreateFailed:           "No se pudo crear el documento: %v",
+		MsgDocumentUpdateFailed:           "No se pudo actualizar el documento: %v",
+		MsgDocumentSyncFailed:             "El documento se actualizó pero no se pudo guardar en disco: %v",
+		MsgDocumentDeleteFailed:           "No se pudo eliminar el documento: %v",
+		MsgDocumentQueryFailed:            "No se pudieron consultar los documentos: %v",
+		MsgFavoriteFailed:                 "No se pudieron actualizar los favoritos: %v",
+		MsgInvalidVersion:                 "Parámetro '%s' no válido. Debe ser un número de versión entero positivo.",
+		MsgInvalidRevision:                "Parámetro de consulta 'revision' no válido. Debe ser un número entero igual o mayor que 0.",
+		MsgDocumentVersionNotFound:        "No se encontró la versión %d del documento '%s'. Solo se conservan las versiones recientes.",
+		MsgDocumentFrozen:                 "El documento '%s' está congelado y no se puede modificar hasta que se descongele.",
+		MsgDocumentFreezeDenied:           "Solo el propietario del documento o un administrador puede congelar o descongelar este documento.",
+		MsgDocumentUnfreezeDenied:         "Este documento fue congelado por un administrador. Solo un administrador puede descongelarlo.",
+		MsgDocumentExpiryDenied:           "Solo el propietario del documento o un administrador puede cambiar cuándo caduca este documento.",
+		MsgSignedURLInvalidOp:             "Operación '%s' no válida: use 'read' o 'write'.",
+		MsgSignedURLInvalidTTL:            "ttl '%s' no válido: use una duración entre 1s y %s, p. ej. 10m.",
+		MsgSignedURLDenied:                "No puede crear una URL firmada para esta operación sobre este documento.",
+		MsgSignedURLCreateFailed:          "No se pudo crear la URL firmada: %v",
+		MsgSignedURLInvalid:               "URL firmada no válida: %v",
+		MsgSignedURLWrongOp:               "Esta URL firmada solo permite '%s'.",
+		MsgSignedURLUsed:                  "Esta URL firmada ya se ha utilizado.",
+		MsgProfileExtraTooLarge:           "'extra' es demasiado grande: %d bytes, se permiten como máximo %d.",
+		MsgProfileExtraSensitiveKey:       "'extra' no puede contener la clave '%s'.",
+		MsgProfileExtraSchema:             "'extra' no coincide con el esquema: %v",
+		MsgExtraPolicyInvalid:             "Política de extra no válida: %v",
+		MsgDeviceRevoked:                  "Este token se emitió para un dispositivo que revocó. Vuelva a iniciar sesión.",
+		MsgDeviceNotFound:                 "No se encontró el dispositivo '%s'",
+		MsgIntrospectClientInvalid:        "Credenciales de cliente de introspección no válidas o ausentes",
+		MsgScopeDenied:                    "Los ámbitos de este token (%s) no permiten %s %s",
+		MsgTokenAccountMissing:            "La cuenta para la que se emitió este token ya no existe",
+		MsgServiceAccountNotFound:         "No se encontró la cuenta de servicio '%s'",
+		MsgServiceAccountInvalid:          "Cuenta de servicio no válida: %v",
+		MsgServiceTokenScopes:             "Los tokens de esta cuenta de servicio solo pueden llevar sus ámbitos: %s",
+		MsgServiceTokenTTL:                "ttl '%s' no válido: se espera una duración positiva de %s como máximo",
+		MsgTokenDocumentsOnly:             "Este token está limitado a algunos documentos y no permite %s %s",
+		MsgTokenDocumentDenied:            "No puede leer el documento '%s'",
+		MsgRestrictedTokenInvalid:         "Token restringido no válido: %v",
+		MsgRestrictedTokenDocuments:       "Un token puede limitarse a %d documentos como máximo",
+		MsgDocumentChanged:                "El documento '%s' cambió mientras se procesaba la solicitud. Vuelva a leerlo e inténtelo de nuevo.",
+		MsgDocumentVersionMismatch:        "El documento '%s' está en la versión %d, no en la versión %d. Vuelva a leerlo e inténtelo de nuevo.",
+		MsgDocumentRevisionMismatch:       "La versión %d del documento '%s' fue reemplazada por una actualización combinada (revisión %d, no revisión %d). Vuelva a leerlo e inténtelo de nuevo.",
+		MsgLintUnsupported:                "Solo se pueden revisar documentos JSON; el documento '%s' tiene contenido %s.",
+		MsgLintInvalid:                    "Solicitud de revisión no válida: %v",
+		MsgContentPathInvalid:             "Ruta de contenido '%s' no válida. Use claves de objeto e índices de matriz separados por puntos, p. ej. 'chapters.2.title', escapando los puntos y los caracteres *?#@|[]{}! de las claves con una barra invertida.",
+		MsgContentPathNotFound:            "No hay ningún valor en '%s' del documento '%s'.",
+		MsgContentPathUnusable:            "No se puede cambiar '%s': %v",
+		MsgContentPathNotArray:            "El valor en '%s' del documento '%s' no es una matriz.",
+		MsgArrayIndexOutOfRange:           "El índice %d está fuera del rango de '%s', que tiene %d elementos.",
+		MsgArrayIndexInvalid:              "Parámetro 'index' no válido '%s'. Debe ser un número entero, -1 o mayor.",
+		MsgContentPathNotNumber:           "El valor en '%s' del documento '%s' no es un número.",
+		MsgIncrementOverflow:              "Incrementar '%s' daría un número demasiado grande para almacenarlo.",
+		MsgIncrementInvalid:               "Incremento no válido: %v. 'delta' debe ser un número finito.",
+		MsgContentConditionFailed:         "El documento '%s' no cumple la condición If-Content-Matches, por lo que no se modificó.",
+		MsgContentConditionError:          "La condición If-Content-Matches no se puede evaluar con el documento '%s': %v",
+		MsgContentConditionInvalid:        "Encabezado If-Content-Matches no válido: %v",
+		MsgLoadGenerationDisabled:         "La generación de carga está desactivada en este servidor (ver -allow-load-generation)",
+		MsgLoadGenerationInvalid:          "Valor de '%s' no válido '%s': se esperaba un número entero de al menos %d",
+		MsgLoadGenerationTooLarge:         "'%s' es %d, pero este servidor genera como máximo %d a la vez",
+		MsgLoadGenerationFailed:           "La generación de carga falló tras crear %d documentos: %v",
+		MsgNotificationChannelNotFound:    "No se encontró el canal de notificaciones con ID '%s'.",
+		MsgNotificationChannelInvalid:     "Canal de notificaciones no válido: %v",
+		MsgNotificationChannelSaveFailed:  "No se pudo guardar el canal de notificaciones: %v",
+		MsgNotificationChannelRateLimited: "El canal de notificaciones ya recibió todos los mensajes que permite esta hora; inténtelo más tarde.",
+		MsgNotificationDeliveryFailed:     "No se pudo entregar el mensaje de prueba: %v",
+		MsgCalendarFeedInvalid:            "Token de calendario no válido: %v",
+		MsgCalendarFeedRevoked:            "Este calendario fue revocado o sustituido por uno más reciente",
+		MsgCalendarFeedFailed:             "No se pudo crear el calendario: %v",
+		MsgDocumentLinkInaccessible:       "No se puede enlazar al documento '%s': no existe o no puede leerlo",
+		MsgDuplicateThresholdInvalid:      "Umbral '%s' no válido: debe ser un número entre %v y 1",
+		MsgDuplicateScopeInvalid:          "Ámbito '%s' no válido: debe ser 'owned', 'shared' o 'all'",
+		MsgDocumentStatsLastInvalid:       "Valor de 'last' '%s' no válido: debe ser un entero entre 1 y %d",
+		MsgSDKLanguageUnknown:             "No hay biblioteca cliente para '%s': los lenguajes disponibles son %s",
+		MsgSDKFailed:                      "No se pudo cargar la biblioteca cliente: %v",
+		MsgQueryEmpty:                     "la consulta no tiene condiciones, así que coinciden todos los documentos",
+		MsgQueryValueType:                 "el operador '%s' necesita un valor de tipo %s, pero %s es de tipo %s",
+		MsgQueryLengthValue:               "'%s' es un número de elementos, así que compárelo con un número usando equals, notequals, greaterthan, lessthan, greaterthanorequals o lessthanorequals",
+		MsgQueryUnquotedString:            "el valor %s no está entre comillas, así que se lee como texto; póngalo entre comillas para que quede explícito",
+		MsgQueryMixedLogic:                "'and' y 'or' se aplican de izquierda a derecha sin precedencia: a or b and c significa (a or b) and c",
+		MsgWorkflowInvalidBody:            "Cuerpo de la solicitud no válido: %v. 'state' es obligatorio.",
+		MsgWorkflowInvalidState:           "Estado de flujo de trabajo no válido '%s'. Se esperaba 'draft', 'submitted', 'approved' o 'rejected'.",
+		MsgWorkflowInvalidTransition:      "Un documento en estado '%s' no puede pasar a '%s'.",
+		MsgWorkflowOwnerOnly:              "Solo el propietario del documento puede pasarlo a '%s'.",
+		MsgWorkflowReviewerOnly:           "Solo el revisor del documento o un administrador puede aprobarlo o rechazarlo.",
+		MsgWorkflowReviewerNotSharer:      "El revisor '%s' debe ser un perfil con el que se comparte el documento.",
+		MsgFetchURLInvalid:                "URL '%s' no válida: %v",
+		MsgFetchDomainNotAllowed:          "No se permite descargar desde '%s'. Dominios permitidos: %s",
+		MsgFetchFailed:                    "No se pudo obtener JSON de '%s': %v",
+		MsgFetchTimeout:                   "'%s' no respondió en %s.",
+		MsgReplaceInvalid:                 "Reemplazo no válido: %v",
+		MsgReplaceFailed:                  "No se pudo aplicar el reemplazo; no se modificó ningún documento: %v",
+
+		MsgAdminOnly:           "Este endpoint solo está disponible para administradores.",
+		MsgScriptNotFound:      "No se encontró el script con ID '%s'.",
+		MsgScriptInvalid:       "Script no válido: %v",
+		MsgScriptSaveFailed:    "No se pudo guardar el script: %v",
+		MsgScriptRejected:      "Rechazado por el script '%s': %s",
+		MsgScriptFailed:        "El script '%s' falló: %v",
+		MsgScheduleNotFound:    "No se encontró la programación con ID '%s'.",
+		MsgScheduleInvalid:     "Programación no válida: %v",
+		MsgScheduleSaveFailed:  "No se pudo guardar la programación: %v",
+		MsgScheduleRunNotFound: "No hay descarga disponible para la ejecución '%s'. Solo las ejecuciones correctas de programaciones de descarga guardan una instantánea, y solo se conservan las recientes.",
+
+		MsgReviewInvalidBody:         "Cuerpo de la solicitud no válido: %v. 'reviewer_id' es obligatorio.",
+		MsgReviewFeedbackInvalidBody: "Cuerpo de la solicitud no válido: %v. 'rating' es obligatorio.",
+		MsgReviewNotFound:            "No se encontró la revisión '%s'.",
+		MsgReviewerNotFound:          "No se encontró el perfil revisor '%s'.",
+		MsgReviewAssignDenied:        "Solo el propietario del documento o un administrador puede asignar y quitar revisores.",
+		MsgReviewAccessDenied:        "No tiene acceso a esta revisión.",
+		MsgReviewOwnDocument:         "Los propietarios no pueden revisar sus propios documentos.",
+		MsgReviewAlreadyAssigned:     "El perfil '%s' ya está asignado para revisar este documento.",
+		MsgReviewReviewerOnly:        "Solo el revisor asignado puede dar su opinión en esta revisión.",
+		MsgReviewInvalidRating:       "Valoración %d no válida para '%s'. Las valoraciones son números enteros del 1 al 5.",
+		MsgReviewInvalidCriteria:     "Criterios no válidos. Una revisión valora como máximo %d criterios, con nombres de 1 a %d caracteres.",
+		MsgReviewInvalidStatus:       "Estado de revisión no válido '%s'. Se esperaba 'pending' o 'submitted'.",
+
+		MsgMaintenance:      "El servidor está en mantenimiento y solo acepta lecturas. Inténtelo de nuevo más tarde.",
+		MsgMaintenanceNote:  "El servidor está en mantenimiento y solo acepta lecturas: %s",
+		MsgMaintenanceRoute: "Ruta de mantenimiento '%s' no válida. Las rutas son caminos como /documents.",
+		MsgReadOnly:         "Este servidor es de solo lectura y no acepta cambios.",
+		MsgMockDisabled:     "Los datos de prueba solo están disponibles mientras el servidor se ejecuta en modo debug.",
+		MsgMockCount:        "Parámetro 'n' no válido. Debe ser un número entero de 1 a %d.",
+		MsgMockShape:        "Parámetro 'shape' no válido: %v",
+		MsgMockSeed:         "Parámetro 'seed' no válido. Debe ser un número entero.",
+
+		MsgChaosDisabled:      "La inyección de caos solo está disponible mientras el servidor se ejecuta en modo debug.",
+		MsgChaosSettings:      "Configuración de caos no válida: %v",
+		MsgChaosRoute:         "Ruta de caos '%s' no válida. Las rutas son caminos como /documents.",
+		MsgChaosInjected:      "Fallo inyectado: el modo caos está activo para esta ruta.",
+		MsgInviteRequired:     "Se requiere un código de invitación para registrarse en este servidor.",
+		MsgInviteInvalid:      "Código de invitación no válido.",
+		MsgInviteExpired:      "Este código de invitación ha caducado.",
+		MsgInviteUsedUp:       "Este código de invitación ya se ha usado el número máximo de veces.",
+		MsgInviteNotFound:     "No se encontró el código de invitación '%s'.",
+		MsgInviteSettings:     "Configuración de invitación no válida: %v",
+		MsgInviteCreateFailed: "No se pudo crear el código de invitación: %v",
+
+		MsgCourseNotFound:    "No se encontró el curso '%s'.",
+		MsgCourseSettings:    "Configuración de curso no válida: %v",
+		MsgCourseExists:      "No se pudo crear el curso: %v",
+		MsgCourseUnavailable: "No se pudo abrir la base de datos del curso '%s'. Inténtelo de nuevo más tarde.",
+		MsgCourseDropFailed:  "No se pudo eliminar el curso '%s': %v",
+
+		MsgProvisionInvalidBody:     "Lista de alumnos no válida: %v. Envíe JSON con una lista 'accounts', o CSV.",
+		MsgProvisionInvalidCSV:      "Lista CSV no válida: %v. La primera línea debe nombrar las columnas 'email', 'first_name', 'last_name' y, opcionalmente, 'group'.",
+		MsgProvisionEmpty:           "La lista no contiene cuentas.",
+		MsgProvisionTooMany:         "La lista contiene demasiadas cuentas. El límite es %d por solicitud.",
+		MsgProvisionInvalidDelivery: "Entrega '%s' no válida. Se esperaba 'response' o 'email'.",
+		MsgProvisionInvalidEmail:    "Dirección de correo electrónico '%s' no válida.",
+		MsgProvisionMissingName:     "'first_name' y 'last_name' son obligatorios.",
+		MsgProvisionDuplicate:       "Misma dirección de correo electrónico que la fila %d.",
+		MsgProvisionFailed:          "No se pudo crear la cuenta: %v",
+
+		MsgChallengeRequired:    "Resuelva el desafío antibots y envíe su respuesta como 'challenge'.",
+		MsgChallengeFailed:      "No se resolvió el desafío antibots. Obtenga un nuevo desafío e inténtelo de nuevo.",
+		MsgChallengeUnavailable: "No se pudo comprobar el desafío antibots en este momento. Inténtelo de nuevo más tarde.",
+		MsgChallengeNotIssued:   "No se pudo crear un desafío: %v",
+
+		MsgShareOwnerOnly:     "Solo el propietario del documento puede gestionar los permisos compartidos.",
+		MsgShareWithOwner:     "No se puede compartir el documento con su propietario.",
+		MsgSharesUpdateFailed: "No se pudieron actualizar los permisos compartidos: %v",
+		MsgSharerAddFailed:    "No se pudo añadir el usuario compartido: %v",
+		MsgSharerRemoveFailed: "No se pudo quitar el usuario compartido: %v",
+
+		MsgAPIVersionMismatch:    "Se solicitó la versión de API '%s', pero esta ruta sirve '%s'. Use el prefijo '/%s'.",
+		MsgAPIVersionUnsupported: "La versión de API '%s' no es compatible. Versiones compatibles: %s.",
+		MsgServerVersionTooOld:   "Este servidor ejecuta la versión %s, anterior a la versión requerida %s.",
+		MsgInvalidMinVersion:     "Versión mínima '%s' no válida. Use una versión como v1.2.3.",
+
+		MsgQueryInvalid:              "content_query no válido: %v",
+		MsgQueryPartEmpty:            "la parte de la consulta en el índice %d está vacía",
+		MsgQueryInvalidCondition:     "condición no válida en el índice %d ('%s'): %v",
+		MsgQueryInvalidLogic:         "operador lógico no válido en el índice %d: '%s'; se esperaba 'and' u 'or'",
+		MsgQueryTrailingLogic:        "la consulta debe terminar con una condición, no con un operador lógico",
+		MsgQueryLogicMismatch:        "el número de condiciones y de operadores lógicos no coincide",
+		MsgQueryMissingValue:         "la condición debe tener al menos un operador y un valor",
+		MsgQueryValueStart:           "error interno de análisis: no se encontró el inicio del valor",
+		MsgQueryInvalidOperator:      "operador no válido '%s'",
+		MsgQueryInvalidFormat:        "formato de condición no válido",
+		MsgQueryInvalidInsensitive:   "operador base no válido para comparación sin distinción de mayúsculas '%s'",
+		MsgQueryInvalidScope:         "valor de scope no válido: '%s'; se esperaba 'owned', 'shared', 'all', 'public' o 'any'",
+		MsgQueryScopeAdminOnly:       "el scope 'any' solo está disponible para administradores",
+		MsgQueryInvalidOrder:         "valor de order no válido: '%s'; se esperaba 'asc' o 'desc'",
+		MsgQueryInvalidSortBy:        "valor de sort_by no válido: '%s'; se esperaba 'creation_date' o 'last_modified_date'",
+		MsgQueryInvalidMissing:       "valor de missing no válido: '%s'; se esperaba 'skip', 'false' o 'error'",
+		MsgQueryInvalidWorkflowState: "valor de workflow_state no válido: '%s'; se esperaba 'draft', 'submitted', 'approved' o 'rejected'",
+		MsgQueryInvalidExpired:       "valor de expired no válido: '%s'; se esperaba 'exclude', 'include' u 'only'",
+		MsgQueryMissingPath:          "el documento '%s' no tiene la ruta '%s' (missing=error)",
+	},
+	"fr": {
+		MsgInvalidRequestBody:  "Corps de requête invalide : %v",
+		MsgInvalidDocumentBody: "Corps de requête invalide : %v. 'content' doit être fourni.",
+		MsgInvalidSharesBody:   "Corps de requête invalide : %v. Le tableau 'shared_with' est obligatoire.",
+		MsgInvalidPagination:   "Paramètre 'page' ou 'limit' invalide. Ce doivent être des entiers positifs.",
+		MsgIDAndKeyExclusive:   "Fournissez soit 'id', soit 'key', mais pas les deux.",
+		MsgInvalidCustomID:     "'id' invalide : il doit comporter de 1 à 64 lettres, chiffres, '_' ou '-'.",
+		MsgKeyDerivedID:        "'id' invalide : il a la forme d'un ID dérivé d'une 'key' ; envoyez plutôt la 'key'.",
+		MsgInvalidDocumentID:   "ID de document invalide : il doit comporter de 1 à 64 lettres, chiffres, '_' ou '-'.",
+		MsgDocumentIDRequired:  "L'ID du document est requis dans le chemin.",
+		MsgShareIDsRequired:    "L'ID du document et l'ID du profil sont requis dans le chemin.",
+		MsgInvalidContentType:  "content_type '%s' invalide. Utilisez json, markdown, text ou csv.",
+		MsgContentNotText:      "Le contenu d'un document %s doit être une chaîne.",
+		MsgContentInvalidCSV:   "Le contenu n'est pas un CSV valide : %v",
+		MsgInvalidRender:       "Valeur de render '%s' invalide. Utilisez html.",
+		MsgInvalidInclude:      "Valeur de include '%s' invalide. Utilisez shares, owner ou les deux, séparés par une virgule.",
+		MsgMalformedID:         "'%s' n'est pas un ID valide. Les ID comptent 1 à 64 lettres, chiffres, '_' ou '-'.",
+		MsgWrongIDKind:         "'%s' porte le préfixe '%s_' d'un autre type d'ID. Un ID commençant par '%s_' est attendu.",
+		MsgRenderUnsupported:   "Seuls les documents markdown peuvent être rendus en HTML ; ce document est %s.",
+		MsgInvalidDurability:   "Valeur de durability '%s' invalide. Utilisez flush.",
+
+		MsgRequestBodyTooLarge: "Le corps de la requête est trop volumineux. La limite est de %d octets.",
+		MsgBodyEmpty:           "le corps est vide",
+		MsgBodySyntax:          "JSON mal formé à l'octet %d",
+		MsgBodyTruncated:       "le JSON se termine de façon inattendue",
+		MsgBodyTrailingData:    "données inattendues après la valeur JSON",
+		MsgBodyNotObject:       "le corps doit être un %s JSON, pas %s",
+		MsgBodyFieldType:       "'%s' doit être un %s JSON, pas %s",
+		MsgBodyUnknownField:    "champ inconnu '%s'",
+		MsgBodyFieldRequired:   "'%s' est obligatoire",
+		MsgBodyFieldInvalid:    "'%s' ne respecte pas '%s'",
+
+		MsgAuthHeaderRequired:    "En-tête Authorization requis",
+		MsgAuthHeaderFormat:      "L'en-tête Authorization doit avoir le format Bearer {token}",
+		MsgInvalidToken:          "Jeton invalide : %v",
+		MsgInvalidCredentials:    "E-mail ou mot de passe invalide",
+		MsgInvalidOTP:            "OTP invalide.",
+		MsgOTPNotFound:           "aucun OTP trouvé pour cet e-mail ou il a expiré",
+		MsgOTPExpired:            "l'OTP a expiré",
+		MsgOTPMismatch:           "OTP invalide",
+		MsgOTPAttemptsExceeded:   "trop de tentatives d'OTP incorrectes ; demandez un nouveau code",
+		MsgOTPLocked:             "trop de tentatives d'OTP échouées ; réessayez dans %d secondes",
+		MsgUserIDMissing:         "ID utilisateur introuvable dans le contexte.",
+		MsgUserIDInvalid:         "Format d'ID utilisateur invalide dans le contexte.",
+		MsgPasswordProcessFailed: "Échec du traitement du mot de passe.",
+		MsgTokenGenerateFailed:   "Échec de la génération du jeton d'authentification.",
+		MsgOTPGenerateFailed:     "Échec de la génération de l'OTP : %v",
+		MsgPasswordUpdateFailed:  "Échec de la mise à jour du mot de passe : %v",
+
+		MsgProfileNotFound:       "Profil de l'utilisateur authentifié introuvable.",
+		MsgProfileEmailNotFound:  "profil avec l'e-mail '%s' introuvable",
+		MsgEmailAlreadyExists:    "l'e-mail '%s' existe déjà",
+		MsgInvalidPhone:          "Numéro de téléphone invalide : utilisez le format international, p. ex. +14155550123.",
+		MsgProfileSearchInvalid:  "Recherche de profils invalide : %v",
+		MsgTooManyProfileIDs:     "Entre 1 et %d identifiants de profil peuvent être résolus à la fois.",
+		MsgRateLimited:           "Trop de requêtes. Réessayez dans %d secondes.",
+		MsgProfileCreateFailed:   "Échec de la création du profil : %v",
+		MsgProfileUpdateFailed:   "Échec de la mise à jour du profil : %v",
+		MsgProfileDeleteFailed:   "Échec de la suppression du profil : %v",
+		MsgInvalidVisibility:     "Paramètre de confidentialité invalide '%s' pour '%s' : doit être 'public', 'sharers' ou 'private'.",
+		MsgErasureNotFound:       "Aucun effacement n'a été demandé pour votre profil.",
+		MsgErasureNotPending:     "Aucune demande d'effacement en attente à annuler.",
+		MsgErasureFailed:         "Échec de la planification de l'effacement : %v",
+		MsgAccountDeactivated:    "Ce compte est désactivé. Un administrateur peut le réactiver jusqu'au %s, date à laquelle il sera effacé.",
+		MsgTokenRevoked:          "Ce jeton a été émis avant le changement du mot de passe. Veuillez vous reconnecter.",
+		MsgDeactivationInvalid:   "Demande de désactivation invalide : %v",
+		MsgProfileNotDeactivated: "Le profil '%s' n'est pas désactivé.",
+		MsgPurgeFailed:           "Échec de la purge du compte : %v",
+		MsgExportFailed:          "Échec de l'exportation des données : %v",
+		MsgTosNotConfigured:      "Ce serveur n'a pas de conditions d'utilisation à accepter.",
+		MsgTosVersionMismatch:    "La version '%s' des conditions d'utilisation n'est pas la version actuelle '%s'.",
+		MsgTosNotAccepted:        "Vous devez accepter les conditions d'utilisation (version '%s') avec POST /profiles/me/accept-tos avant d'utiliser ce point d'accès.",
+		MsgTosAcceptFailed:       "Échec de l'enregistrement de l'acceptation des conditions d'utilisation : %v",
+		MsgPasswordIncorrect:     "Le mot de passe actuel est incorrect.",
+		MsgEmailChangeSame:       "La nouvelle adresse e-mail est identique à l'actuelle.",
+		MsgEmailChangeNotFound:   "Aucun changement d'adresse e-mail n'est en attente de confirmation.",
+		MsgEmailChangeExpired:    "Le changement d'adresse e-mail a expiré. Veuillez le demander à nouveau.",
+		MsgEmailChangeMismatch:   "Le jeton de confirmation est invalide.",
+		MsgEmailChangeFailed:     "Échec du changement d'adresse e-mail : %v",
+
+		MsgDocumentNotFound:               "Document avec l'ID '%s' introuvable.",
+		MsgDocumentAlreadyExists:          "le document avec l'ID '%s' existe déjà",
+		MsgDocumentExistsCondition:        "Le document avec l'ID '%s' existe déjà (If-None-Match: *).",
+		MsgDocumentAccessDenied:           "Vous n'avez pas l'autorisation d'accéder à ce document.",
+		MsgDocumentUpdateDenied:           "Vous n'avez pas l'autorisation de modifier ce document.",
+		MsgDocumentDeleteDenied:           "Vous n'avez pas l'autorisation de supprimer ce document.",
+		MsgDocumentCreateFailed:           "Échec de la création du document : %v",
+		MsgDocumentUpdateFailed:           "Échec de la mise à jour du document : %v",
+		MsgDocumentSyncFailed:             "Le document a été mis à jour mais n'a pas pu être enregistré sur le disque : %v",
+		MsgDocumentDeleteFailed:           "Échec de la suppression du document : %v",
+		MsgDocumentQueryFailed:            "Échec de la recherche de documents : %v",
+		MsgFavoriteFailed:                 "Échec de la mise à jour des favoris : %v",
+		MsgInvalidVersion:                 "Paramètre '%s' invalide. Ce doit être un numéro de version entier positif.",
+		MsgInvalidRevision:                "Paramètre de requête 'revision' invalide. Doit être un nombre entier supérieur ou égal à 0.",
+		MsgDocumentVersionNotFound:        "Version %d du document '%s' introuvable. Seules les versions récentes sont conservées.",
+		MsgDocumentFrozen:                 "Le document '%s' est gelé et ne peut pas être modifié tant qu'il n'est pas dégelé.",
+		MsgDocumentFreezeDenied:           "Seul le propriétaire du document ou un administrateur peut geler ou dégeler ce document.",
+		MsgDocumentUnfreezeDenied:         "Ce document a été gelé par un administrateur. Seul un administrateur peut le dégeler.",
+		MsgDocumentExpiryDenied:           "Seul le propriétaire du document ou un administrateur peut modifier la date d'expiration de ce document.",
+		MsgSignedURLInvalidOp:             "Opération '%s' invalide : utilisez 'read' ou 'write'.",
+		MsgSignedURLInvalidTTL:            "ttl '%s' invalide : utilisez une durée entre 1s et %s, par ex. 10m.",
+		MsgSignedURLDenied:                "Vous ne pouvez pas créer d'URL signée pour cette opération sur ce document.",
+		MsgSignedURLCreateFailed:          "Échec de la création de l'URL signée : %v",
+		MsgSignedURLInvalid:               "URL signée invalide : %v",
+		MsgSignedURLWrongOp:               "Cette URL signée ne permet que '%s'.",
+		MsgSignedURLUsed:                  "Cette URL signée a déjà été utilisée.",
+		MsgProfileExtraTooLarge:           "'extra' est trop volumineux : %d octets, %d au maximum.",
+		MsgProfileExtraSensitiveKey:       "'extra' ne peut pas contenir la clé '%s'.",
+		MsgProfileExtraSchema:             "'extra' ne correspond pas au schéma : %v",
+		MsgExtraPolicyInvalid:             "Politique d'extra invalide : %v",
+		MsgDeviceRevoked:                  "Ce jeton a été émis pour un appareil que vous avez révoqué. Veuillez vous reconnecter.",
+		MsgDeviceNotFound:                 "Appareil '%s' introuvable",
+		MsgIntrospectClientInvalid:        "Identifiants du client d'introspection invalides ou manquants",
+		MsgScopeDenied:                    "Les portées de ce jeton (%s) ne permettent pas %s %s",
+		MsgTokenAccountMissing:            "Le compte pour lequel ce jeton a été émis n'existe plus",
+		MsgServiceAccountNotFound:         "Compte de service '%s' introuvable",
+		MsgServiceAccountInvalid:          "Compte de service invalide : %v",
+		MsgServiceTokenScopes:             "Les jetons de ce compte de service ne peuvent porter que ses portées : %s",
+		MsgServiceTokenTTL:                "ttl '%s' invalide : une durée positive d'au plus %s est attendue",
+		MsgTokenDocumentsOnly:             "Ce jeton est limité à certains documents et ne permet pas %s %s",
+		MsgTokenDocumentDenied:            "Vous ne pouvez pas lire le document '%s'",
+		MsgRestrictedTokenInvalid:         "Jeton restreint invalide : %v",
+		MsgRestrictedTokenDocuments:       "Un jeton peut être limité à %d documents au plus",
+		MsgDocumentChanged:                "Le document '%s' a changé pendant le traitement de la requête. Relisez-le et réessayez.",
+		MsgDocumentVersionMismatch:        "Le document '%s' est à la version %d, pas à la version %d. Relisez-le et réessayez.",
+		MsgDocumentRevisionMismatch:       "La version %d du document '%s' a été remplacée par une mise à jour fusionnée (révision %d, pas révision %d). Relisez-le et réessayez.",
+		MsgLintUnsupported:                "Seuls les documents JSON peuvent être vérifiés ; le document '%s' contient du contenu %s.",
+		MsgLintInvalid:                    "Requête de vérification invalide : %v",
+		MsgContentPathInvalid:             "Chemin de contenu '%s' invalide. Utilisez des clés d'objet et des index de tableau séparés par des points, p. ex. 'chapters.2.title', en échappant les points et les caractères *?#@|[]{}! des clés avec une barre oblique inverse.",
+		MsgContentPathNotFound:            "Il n'y a aucune valeur à '%s' dans le document '%s'.",
+		MsgContentPathUnusable:            "Impossible de modifier '%s' : %v",
+		MsgContentPathNotArray:            "La valeur à '%s' dans le document '%s' n'est pas un tableau.",
+		MsgArrayIndexOutOfRange:           "L'index %d est hors limites pour '%s', qui contient %d éléments.",
+		MsgArrayIndexInvalid:              "Paramètre 'index' invalide '%s'. Doit être un nombre entier, -1 ou plus.",
+		MsgContentPathNotNumber:           "La valeur à '%s' dans le document '%s' n'est pas un nombre.",
+		MsgIncrementOverflow:              "Incrémenter '%s' donnerait un nombre trop grand pour être stocké.",
+		MsgIncrementInvalid:               "Incrément invalide : %v. 'delta' doit être un nombre fini.",
+		MsgContentConditionFailed:         "Le document '%s' ne satisfait pas la condition If-Content-Matches, il n'a donc pas été modifié.",
+		MsgContentConditionError:          "La condition If-Content-Matches ne peut pas être évaluée sur le document '%s' : %v",
+		MsgContentConditionInvalid:        "En-tête If-Content-Matches invalide : %v",
+		MsgLoadGenerationDisabled:         "La génération de charge est désactivée sur ce serveur (voir -allow-load-generation)",
+		MsgLoadGenerationInvalid:          "Valeur de '%s' invalide '%s' : un nombre entier d'au moins %d est attendu",
+		MsgLoadGenerationTooLarge:         "'%s' vaut %d, mais ce serveur en génère au plus %d à la fois",
+		MsgLoadGenerationFailed:           "La génération de charge a échoué après avoir créé %d documents : %v",
+		MsgNotificationChannelNotFound:    "Canal de notification avec l'ID '%s' introuvable.",
+		MsgNotificationChannelInvalid:     "Canal de notification invalide : %v",
+		MsgNotificationChannelSaveFailed:  "Échec de l'enregistrement du canal de notification : %v",
+		MsgNotificationChannelRateLimited: "Le canal de notification a reçu autant de messages qu'il en autorise cette heure-ci ; réessayez plus tard.",
+		MsgNotificationDeliveryFailed:     "Le message de test n'a pas pu être remis : %v",
+		MsgCalendarFeedInvalid:            "Jeton de calendrier invalide : %v",
+		MsgCalendarFeedRevoked:            "Ce calendrier a été révoqué ou remplacé par un plus récent",
+		MsgCalendarFeedFailed:             "Impossible de créer le calendrier : %v",
+		MsgDocumentLinkInaccessible:       "Impossible de lier le document '%s' : il n'existe pas ou vous ne pouvez pas le lire",
+		MsgDuplicateThresholdInvalid:      "Seuil '%s' invalide : doit être un nombre entre %v et 1",
+		MsgDuplicateScopeInvalid:          "Portée '%s' invalide : doit être 'owned', 'shared' ou 'all'",
+		MsgDocumentStatsLastInvalid:       "Valeur de 'last' '%s' invalide : doit être un entier entre 1 et %d",
+		MsgSDKLanguageUnknown:             "Aucune bibliothèque cliente pour '%s' : les langages disponibles sont %s",
+		MsgSDKFailed:                      "Impossible de charger la bibliothèque cliente : %v",
+		MsgQueryEmpty:                     "la requête n'a aucune condition, donc tous les documents correspondent",
+		MsgQueryValueType:                 "l'opérateur '%s' attend une valeur de type %s, mais %s est de type %s",
+		MsgQueryLengthValue:               "'%s' est un nombre d'éléments : comparez-le à un nombre avec equals, notequals, greaterthan, lessthan, greaterthanorequals ou lessthanorequals",
+		MsgQueryUnquotedString:            "la valeur %s n'est pas entre guillemets, elle est donc lue comme une chaîne ; mettez-la entre guillemets pour le rendre explicite",
+		MsgQueryMixedLogic:                "'and' et 'or' s'appliquent de gauche à droite sans priorité : a or b and c signifie (a or b) and c",
+		MsgWorkflowInvalidBody:            "Corps de requête invalide : %v. 'state' est obligatoire.",
+		MsgWorkflowInvalidState:           "État de workflow invalide '%s'. 'draft', 'submitted', 'approved' ou 'rejected' attendu.",
+		MsgWorkflowInvalidTransition:      "Un document à l'état '%s' ne peut pas passer à '%s'.",
+		MsgWorkflowOwnerOnly:              "Seul le propriétaire du document peut le faire passer à '%s'.",
+		MsgWorkflowReviewerOnly:           "Seul le relecteur du document ou un administrateur peut l'approuver ou le refuser.",
+		MsgWorkflowReviewerNotSharer:      "Le relecteur '%s' doit être un profil avec lequel le document est partagé.",
+		MsgFetchURLInvalid:                "URL '%s' invalide : %v",
+		MsgFetchDomainNotAllowed:          "Le téléchargement depuis '%s' n'est pas autorisé. Domaines autorisés : %s",
+		MsgFetchFailed:                    "Échec de la récupération du JSON depuis '%s' : %v",
+		MsgFetchTimeout:                   "'%s' n'a pas répondu dans le délai de %s.",
+		MsgReplaceInvalid:                 "Remplacement invalide : %v",
+		MsgReplaceFailed:                  "Échec du remplacement ; aucun document n'a été modifié : %v",
+
+		MsgAdminOnly:           "Ce point d'accès est réservé aux administrateurs.",
+		MsgScriptNotFound:      "Script avec l'ID '%s' introuvable.",
+		MsgScriptInvalid:       "Script invalide : %v",
+		MsgScriptSaveFailed:    "Échec de l'enregistrement du script : %v",
+		MsgScriptRejected:      "Rejeté par le script '%s' : %s",
+		MsgScriptFailed:        "Le script '%s' a échoué : %v",
+		MsgScheduleNotFound:    "Planification avec l'ID '%s' introuvable.",
+		MsgScheduleInvalid:     "Planification invalide : %v",
+		MsgScheduleSaveFailed:  "Échec de l'enregistrement de la planification : %v",
+		MsgScheduleRunNotFound: "Aucun téléchargement n'est disponible pour l'exécution '%s'. Seules les exécutions réussies des planifications de téléchargement conservent un instantané, et seules les plus récentes sont gardées.",
+
+		MsgReviewInvalidBody:         "Corps de requête invalide : %v. 'reviewer_id' est obligatoire.",
+		MsgReviewFeedbackInvalidBody: "Corps de requête invalide : %v. 'rating' est obligatoire.",
+		MsgReviewNotFound:            "Relecture '%s' introuvable.",
+		MsgReviewerNotFound:          "Profil relecteur '%s' introuvable.",
+		MsgReviewAssignDenied:        "Seul le propriétaire du document ou un administrateur peut attribuer et retirer des relecteurs.",
+		MsgReviewAccessDenied:        "Vous n'avez pas accès à cette relecture.",
+		MsgReviewOwnDocument:         "Les propriétaires ne peuvent pas relire leurs propres documents.",
+		MsgReviewAlreadyAssigned:     "Le profil '%s' est déjà chargé de relire ce document.",
+		MsgReviewReviewerOnly:        "Seul le relecteur désigné peut donner son avis dans cette relecture.",
+		MsgReviewInvalidRating:       "Note %d invalide pour '%s'. Les notes sont des nombres entiers de 1 à 5.",
+		MsgReviewInvalidCriteria:     "Critères invalides. Une relecture note au plus %d critères, dont les noms font de 1 à %d caractères.",
+		MsgReviewInvalidStatus:       "Statut de relecture invalide '%s'. 'pending' ou 'submitted' attendu.",
+
+		MsgMaintenance:      "Le serveur est en maintenance et n'accepte que les lectures. Veuillez réessayer plus tard.",
+		MsgMaintenanceNote:  "Le serveur est en maintenance et n'accepte que les lectures : %s",
+		MsgMaintenanceRoute: "Route de maintenance '%s' invalide. Les routes sont des chemins comme /documents.",
+		MsgReadOnly:         "Ce serveur est en lecture seule et n'accepte pas de modifications.",
+		MsgMockDisabled:     "Les données fictives ne sont disponibles que lorsque le serveur fonctionne en mode debug.",
+		MsgMockCount:        "Paramètre 'n' invalide. Ce doit être un nombre entier de 1 à %d.",
+		MsgMockShape:        "Paramètre 'shape' invalide : %v",
+		MsgMockSeed:         "Paramètre 'seed' invalide. Ce doit être un nombre entier.",
+
+		MsgChaosDisabled:      "L'injection de chaos n'est disponible que lorsque le serveur fonctionne en mode debug.",
+		MsgChaosSettings:      "Paramètres de chaos invalides : %v",
+		MsgChaosRoute:         "Route de chaos '%s' invalide. Les routes sont des chemins comme /documents.",
+		MsgChaosInjected:      "Panne injectée : le mode chaos est actif pour cette route.",
+		MsgInviteRequired:     "Un code d'invitation est requis pour s'inscrire sur ce serveur.",
+		MsgInviteInvalid:      "Code d'invitation invalide.",
+		MsgInviteExpired:      "Ce code d'invitation a expiré.",
+		MsgInviteUsedUp:       "Ce code d'invitation a déjà été utilisé le nombre maximal de fois.",
+		MsgInviteNotFound:     "Code d'invitation '%s' introuvable.",
+		MsgInviteSettings:     "Paramètres d'invitation invalides : %v",
+		MsgInviteCreateFailed: "Échec de la création du code d'invitation : %v",
+
+		MsgCourseNotFound:    "Cours '%s' introuvable.",
+		MsgCourseSettings:    "Paramètres de cours invalides : %v",
+		MsgCourseExists:      "Impossible de créer le cours : %v",
+		MsgCourseUnavailable: "La base de données du cours '%s' n'a pas pu être ouverte. Veuillez réessayer plus tard.",
+		MsgCourseDropFailed:  "Échec de la suppression du cours '%s' : %v",
+
+		MsgProvisionInvalidBody:     "Liste d'élèves invalide : %v. Envoyez du JSON avec une liste 'accounts', ou du CSV.",
+		MsgProvisionInvalidCSV:      "Liste CSV invalide : %v. La première ligne doit nommer les colonnes 'email', 'first_name', 'last_name' et, éventuellement, 'group'.",
+		MsgProvisionEmpty:           "La liste ne contient aucun compte.",
+		MsgProvisionTooMany:         "La liste contient trop de comptes. La limite est de %d par requête.",
+		MsgProvisionInvalidDelivery: "Mode de remise '%s' invalide. Valeurs attendues : 'response' ou 'email'.",
+		MsgProvisionInvalidEmail:    "Adresse e-mail '%s' invalide.",
+		MsgProvisionMissingName:     "'first_name' et 'last_name' sont obligatoires.",
+		MsgProvisionDuplicate:       "Même adresse e-mail que la ligne %d.",
+		MsgProvisionFailed:          "Échec de la création du compte : %v",
+
+		MsgChallengeRequired:    "Résolvez le défi anti-robots et envoyez sa réponse dans 'challenge'.",
+		MsgChallengeFailed:      "Le défi anti-robots n'a pas été résolu. Obtenez un nouveau défi et réessayez.",
+		MsgChallengeUnavailable: "Le défi anti-robots ne peut pas être vérifié pour le moment. Veuillez réessayer plus tard.",
+		MsgChallengeNotIssued:   "Échec de la création d'un défi : %v",
+
+		MsgShareOwnerOnly:     "Seul le propriétaire du document peut gérer les partages.",
+		MsgShareWithOwner:     "Impossible de partager le document avec son propriétaire.",
+		MsgSharesUpdateFailed: "Échec de la mise à jour des partages : %v",
+		MsgSharerAddFailed:    "Échec de l'ajout du partage : %v",
+		MsgSharerRemoveFailed: "Échec de la suppression du partage : %v",
+
+		MsgAPIVersionMismatch:    "La version d'API '%s' a été demandée mais ce chemin sert '%s'. Utilisez le préfixe '/%s'.",
+		MsgAPIVersionUnsupported: "La version d'API '%s' n'est pas prise en charge. Versions prises en charge : %s.",
+		MsgServerVersionTooOld:   "Ce serveur exécute la version %s, antérieure à la version requise %s.",
+		MsgInvalidMinVersion:     "Version minimale '%s' invalide. Utilisez une version telle que v1.2.3.",
+
+		MsgQueryInvalid:              "content_query invalide : %v",
+		MsgQueryPartEmpty:            "la partie de requête à l'index %d est vide",
+		MsgQueryInvalidCondition:     "condition invalide à l'index %d ('%s') : %v",
+		MsgQueryInvalidLogic:         "opérateur logique invalide à l'index %d : '%s', 'and' ou 'or' attendu",
+		MsgQueryTrailingLogic:        "la requête doit se terminer par une condition, pas par un opérateur logique",
+		MsgQueryLogicMismatch:        "le nombre de conditions et d'opérateurs logiques ne correspond pas",
+		MsgQueryMissingValue:         "la condition doit comporter au moins un opérateur et une valeur",
+		MsgQueryValueStart:           "erreur d'analyse interne : début de la valeur introuvable",
+		MsgQueryInvalidOperator:      "opérateur invalide '%s'",
+		MsgQueryInvalidFormat:        "format de condition invalide",
+		MsgQueryInvalidInsensitive:   "opérateur de base invalide pour une comparaison insensible à la casse '%s'",
+		MsgQueryInvalidScope:         "valeur de scope invalide : '%s', 'owned', 'shared', 'all', 'public' ou 'any' attendu",
+		MsgQueryScopeAdminOnly:       "le scope 'any' est réservé aux administrateurs",
+		MsgQueryInvalidOrder:         "valeur de order invalide : '%s', 'asc' ou 'desc' attendu",
+		MsgQueryInvalidSortBy:        "valeur de sort_by invalide : '%s', 'creation_date' ou 'last_modified_date' attendu",
+		MsgQueryInvalidMissing:       "valeur de missing invalide : '%s', 'skip', 'false' ou 'error' attendu",
+		MsgQueryInvalidWorkflowState: "valeur de workflow_state invalide : '%s', 'draft', 'submitted', 'approved' ou 'rejected' attendu",
+		MsgQueryInvalidExpired:       "valeur de expired invalide : '%s', 'exclude', 'include' ou 'only' attendu",
+		MsgQueryMissingPath:          "le document '%s' n'a pas de chemin '%s' (missing=error)",
+	},
+}
@@ -0,0 +1,122 @@
+// Package i18n provides the message catalog used to localize API error and
+// validation messages. Messages are identified by stable IDs (see catalog.go)
+// which are also returned to clients so they can apply their own translations.
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLanguage is used when the client does not ask for a supported language,
+// and as the fallback for messages missing from another language's catalog.
+const DefaultLanguage = "en"
+
+// SupportedLanguages lists the languages with a message catalog.
+var SupportedLanguages = []string{"en", "es", "fr"}
+
+// Translate renders the message with the given ID in the requested language.
+// Arguments are formatted with fmt verbs; arguments that are themselves *Error values
+// are translated into the same language first. Unknown languages fall back to
+// DefaultLanguage, and unknown IDs are returned as-is.
+func Translate(lang, id string, args ...any) string {
+	template, ok := catalogs[lang][id]
+	if !ok {
+		template, ok = catalogs[DefaultLanguage][id]
+		if !ok {
+			return id
+		}
+	}
+	if len(args) == 0 {
+		return template
+	}
+
+	localizedArgs := make([]any, len(args))
+	for i, arg := range args {
+		if msgErr, ok := arg.(*Error); ok {
+			localizedArgs[i] = msgErr.Localize(lang)
+		} else {
+			localizedArgs[i] = arg
+		}
+	}
+	return fmt.Sprintf(template, localizedArgs...)
+}
+
+// NegotiateLanguage picks the best supported language for an Accept-Language header value.
+// Quality values are honored and region subtags are ignored ("fr-CA" matches "fr").
+// Returns DefaultLanguage when nothing matches.
+func NegotiateLanguage(acceptLanguage string) string {
+	type candidate struct {
+		lang    string
+		quality float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		if quality <= 0 {
+			continue
+		}
+		primary := strings.SplitN(tag, "-", 2)[0]
+		candidates = append(candidates, candidate{lang: primary, quality: quality})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+	for _, c := range candidates {
+		if _, ok := catalogs[c.lang]; ok {
+			return c.lang
+		}
+	}
+	return DefaultLanguage
+}
+
+// Error is an error that carries a catalog message ID and its arguments,
+// so it can be rendered in the client's language at the API edge.
+// Error() always renders the DefaultLanguage text.
+type Error struct {
+	ID   string
+	Args []any
+}
+
+// NewError creates an Error for the given message ID.
+func NewError(id string, args ...any) *Error {
+	return &Error{ID: id, Args: args}
+}
+
+// Error implements the error interface using the default language.
+func (e *Error) Error() string {
+	return e.Localize(DefaultLanguage)
+}
+
+// Localize renders the error in the given language.
+func (e *Error) Localize(lang string) string {
+	return Translate(lang, e.ID, e.Args...)
+}
+
+// Unwrap returns the first argument that is an error, so wrapped causes remain
+// reachable through errors.Is and errors.As.
+func (e *Error) Unwrap() error {
+	for _, arg := range e.Args {
+		if err, ok := arg.(error); ok {
+			return err
+		}
+	}
+	return nil
+}
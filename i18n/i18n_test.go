@@ -0,0 +1,77 @@
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateLanguage(t *testing.T) {
+	testCases := []struct {
+		header   string
+		expected string
+	}{
+		{"", "en"},
+		{"es", "es"},
+		{"fr-CA", "fr"},
+		{"de, fr;q=0.8, es;q=0.5", "fr"},
+		{"en;q=0.1, es;q=0.9", "es"},
+		{"es;q=0, fr", "fr"},
+		{"de, ja", "en"},
+		{"*", "en"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("Header %q", tc.header), func(t *testing.T) {
+			assert.Equal(t, tc.expected, NegotiateLanguage(tc.header))
+		})
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	t.Run("Formats arguments", func(t *testing.T) {
+		assert.Equal(t, "Document with ID 'abc' not found.", Translate("en", MsgDocumentNotFound, "abc"))
+		assert.Equal(t, "No se encontró el documento con ID 'abc'.", Translate("es", MsgDocumentNotFound, "abc"))
+	})
+
+	t.Run("Unknown language falls back to default", func(t *testing.T) {
+		assert.Equal(t, Translate("en", MsgInvalidPagination), Translate("de", MsgInvalidPagination))
+	})
+
+	t.Run("Unknown ID is returned as-is", func(t *testing.T) {
+		assert.Equal(t, "no_such_message", Translate("fr", "no_such_message"))
+	})
+
+	t.Run("Nested errors are translated too", func(t *testing.T) {
+		inner := NewError(MsgQueryInvalidOperator, "foo")
+		assert.Equal(t, "content_query invalide : opérateur invalide 'foo'", Translate("fr", MsgQueryInvalid, inner))
+	})
+}
+
+func TestCatalogsComplete(t *testing.T) {
+	for _, lang := range SupportedLanguages {
+		catalog, ok := catalogs[lang]
+		if !assert.True(t, ok, "missing catalog for %s", lang) {
+			continue
+		}
+		for id := range catalogs[DefaultLanguage] {
+			assert.Contains(t, catalog, id, "catalog %s is missing message %s", lang, id)
+		}
+	}
+}
+
+func TestError(t *testing.T) {
+	cause := errors.New("disk full")
+	err := NewError(MsgDocumentCreateFailed, cause)
+
+	assert.Equal(t, "Failed to create document: disk full", err.Error())
+	assert.Equal(t, "No se pudo crear el documento: disk full", err.Localize("es"))
+	assert.True(t, errors.Is(err, cause), "cause should be reachable via Unwrap")
+
+	var target *Error
+	wrapped := fmt.Errorf("context: %w", err)
+	assert.True(t, errors.As(wrapped, &target))
+	assert.Equal(t, MsgDocumentCreateFailed, target.ID)
+}
@@ -2,19 +2,21 @@ package main
 
 import (
 	"docserver/api"
+	"docserver/bench"
 	"docserver/config"
 	"docserver/db"
 	_ "docserver/docs" // Import for side effect: registers swagger spec via init()
-	"docserver/utils" // For AuthMiddleware
+	"docserver/tracing"
+	"docserver/utils"
 	"embed"           // Added for embedding files
 	"fmt"
 	"io/fs" // Added for filesystem interface
 	"log"
 	"math/rand"
 	"net/http"
+	"os"
 	"time"
 
-	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"     // swagger embed files
 	ginSwagger "github.com/swaggo/gin-swagger" // gin-swagger middleware
 )
@@ -38,7 +40,8 @@ import (
 // @description     **Query Syntax:**
 // @description     Each `content_query` parameter string follows the format: `path operator value`
 // @description
-// @description     *   **`path`**: A dot-separated path to navigate the JSON structure (e.g., `user.name`, `details.metadata.version`). Use numeric indices for arrays (e.g., `items.0.id`, `tags.1`).
+// @description     *   **`path`**: A dot-separated path to navigate the JSON structure (e.g., `user.name`, `details.metadata.version`). Use numeric indices for arrays (e.g., `items.0.id`, `tags.1`). End a path with `.#` to compare the number of elements of an array or keys of an object with the numeric operators (e.g., `tags.#`; `#` alone counts the content itself).
+// @description     *   **`not` (optional prefix)**: Negates the condition, e.g. `not tags contains \"archived\"`. A negated condition also matches documents that lack its path, but not ones where the operator does not suit the value. `not` at the start always negates, so a field named `not` cannot be queried at the top level.
 // @description     *   **`operator`**: The comparison operator. Supported operators include:
 // @description         *   `equals`: Equal to (strings, numbers, booleans, null)
 // @description         *   `notequals`: Not equal to
@@ -53,6 +56,9 @@ import (
 // @description         *   Strings MUST be enclosed in double quotes (e.g., `\"John Doe\"`). Remember to URL-encode the query parameter string. Add `-insensitive` suffix to string operators (e.g., `equals-insensitive`, `contains-insensitive`) for case-insensitive matching.
 // @description         *   Numbers (e.g., `123`, `45.6`), booleans (`true`/`false`), and `null` should be used directly.
 // @description
+// @description     **Missing Paths (`missing` parameter):**
+// @description     A condition whose path a document lacks cannot be evaluated. By default (`missing=skip`) such documents are left out, whatever the other conditions say. `missing=false` treats the condition as false instead, so conditions joined with `or` can still match, and `missing=error` rejects the whole query with `400 Bad Request`.
+// @description
 // @description     **Logical Operators (Combining Queries):**
 // @description     You combine multiple conditions by providing `content_query` parameters for conditions interleaved with explicit logical operators (`and` or `or`).
 // @description     *   **`and` (Explicit):** To link two conditions with AND, place `content_query=and` between them. The document must match *both* conditions.
@@ -96,13 +102,16 @@ import (
 // @description
 // @description     8.  **Nested Field with `AND`:** Find documents where `assignee.name` is `Alice` **AND** `metadata.reviewed` is `true`.
 // @description         `?content_query=assignee.name equals \"Alice\"&content_query=and&content_query=metadata.reviewed equals true`
+// @description
+// @description     9.  **Array Length:** Find documents with more than one tag.
+// @description         `?content_query=tags.# greaterthan 1`
 // @description Type "Bearer" followed by a space and JWT token.
 //
 // @license.name  MIT
 // @license.url   https://github.com/HWilliams64/docserver/blob/main/License.md
 //
 // @host      localhost:8080
-// @BasePath  /
+// @BasePath  /v1
 //
 // @securityDefinitions.jwt BearerAuth
 // @in header
@@ -122,6 +131,53 @@ func main() { // coverage-ignore
 	if err != nil {
 		log.Fatalf("CRITICAL: Failed to load configuration: %v", err)
 	}
+	utils.CalibratePasswordHashing(cfg) // Only with -password-hash-target
+
+	// --- Migration Dry Run ---
+	if cfg.MigrateDryRun {
+		report, err := db.PlanMigrations(db.SchemaFile(cfg))
+		if err != nil {
+			log.Fatalf("CRITICAL: Failed to inspect database for migrations: %v", err)
+		}
+		fmt.Println(report.String())
+		return
+	}
+
+	// --- Benchmarks ---
+	// Measure queries, saves and loads on generated datasets (docserver bench), then exit.
+	if cfg.Bench {
+		if err := bench.Run(*cfg, cfg.BenchSizes, os.Stdout); err != nil {
+			log.Fatalf("CRITICAL: Benchmark failed: %v", err)
+		}
+		return
+	}
+
+	// --- Replay ---
+	// Rebuild a database from a journal made with -record-file, then exit. It is written to a new
+	// file so an existing database is never mixed with the replayed requests.
+	if cfg.ReplayFile != "" {
+		if _, err := os.Stat(db.SchemaFile(cfg)); err == nil {
+			log.Fatalf("CRITICAL: Cannot replay into '%s': the file exists. Choose a new -db-file or -data-dir.", db.SchemaFile(cfg))
+		}
+		database, err := db.NewDatabase(cfg)
+		if err != nil {
+			log.Fatalf("CRITICAL: Failed to initialize database: %v", err)
+		}
+		router, err := api.NewRouter(cfg)
+		if err != nil {
+			log.Fatalf("CRITICAL: Failed to set up router: %v", err)
+		}
+		api.RegisterRoutes(router, database, cfg)
+		report, err := api.ReplayJournal(router, database, cfg, cfg.ReplayFile, cfg.ReplayUntil)
+		if closeErr := database.Close(); closeErr != nil {
+			log.Fatalf("CRITICAL: Failed to save replayed database: %v", closeErr)
+		}
+		if err != nil {
+			log.Fatalf("CRITICAL: Failed to replay journal: %v", err)
+		}
+		fmt.Println(report.String())
+		return
+	}
 
 	// --- Database ---
 	database, err := db.NewDatabase(cfg)
@@ -130,116 +186,43 @@ func main() { // coverage-ignore
 		log.Fatalf("CRITICAL: Failed to initialize database: %v", err)
 	}
 
-	// --- Gin Router Setup ---
-	// Consider gin.ReleaseMode for production, gin.DebugMode for development
-	// gin.SetMode(gin.ReleaseMode)
-	router := gin.Default()
-
-	// Simple logging middleware (can be customized)
-	router.Use(gin.Logger())
-	// Recovery middleware recovers from any panics and writes a 500 if there was one.
-	router.Use(gin.Recovery())
-
-	// --- Public Routes (No Auth Required) ---
-	authGroup := router.Group("/auth")
-	{
-		// POST /auth/signup
-		authGroup.POST("/signup", func(c *gin.Context) {
-			api.SignupHandler(c, database, cfg)
-		})
-		// POST /auth/login
-		authGroup.POST("/login", func(c *gin.Context) {
-			api.LoginHandler(c, database, cfg)
-		})
-		// POST /auth/forgot-password
-		authGroup.POST("/forgot-password", func(c *gin.Context) {
-			api.ForgotPasswordHandler(c, database, cfg)
-		})
-		// POST /auth/reset-password
-		authGroup.POST("/reset-password", func(c *gin.Context) {
-			api.ResetPasswordHandler(c, database, cfg)
-		})
+	// --- Tracing ---
+	// Export OpenTelemetry spans when a collector is configured; queued spans are sent on exit.
+	if cfg.OTLPEndpoint != "" {
+		exporter, err := tracing.NewOTLPExporter(cfg.OTLPEndpoint, tracing.ParseHeaders(cfg.OTLPHeaders))
+		if err != nil {
+			log.Fatalf("CRITICAL: Failed to set up trace export: %v", err)
+		}
+		stopTracing := tracing.Enable(exporter, cfg.OTelServiceName)
+		defer stopTracing()
 	}
 
-	// --- Protected Routes (Auth Required) ---
-	// Apply AuthMiddleware
-	authMiddleware := utils.AuthMiddleware(cfg)
+	// --- Background Workers ---
+	// Erasures, scheduled exports, deactivations and API usage flushes (course databases start their own).
+	stopWorkers := database.StartWorkers(time.Minute)
+	defer stopWorkers()
 
-	// Profile Routes
-	profileGroup := router.Group("/profiles")
-	profileGroup.Use(authMiddleware)
-	{
-		// GET /profiles/me
-		profileGroup.GET("/me", func(c *gin.Context) {
-			api.GetProfileMeHandler(c, database, cfg)
-		})
-		// PUT /profiles/me
-		profileGroup.PUT("/me", func(c *gin.Context) {
-			api.UpdateProfileMeHandler(c, database, cfg)
-		})
-		// DELETE /profiles/me
-		profileGroup.DELETE("/me", func(c *gin.Context) {
-			api.DeleteProfileMeHandler(c, database, cfg)
-		})
-		// GET /profiles (Search)
-		profileGroup.GET("", func(c *gin.Context) { // Note: Empty path for group root
-			api.SearchProfilesHandler(c, database, cfg)
-		})
+	// --- Gin Router Setup ---
+	// Gin mode, logging and recovery middleware, and which proxies may report the client IP (see -gin-mode, -trusted-proxies).
+	router, err := api.NewRouter(cfg)
+	if err != nil {
+		log.Fatalf("CRITICAL: Failed to set up router: %v", err)
 	}
-
-	// Document Routes
-	docGroup := router.Group("/documents")
-	docGroup.Use(authMiddleware)
-	{
-		// POST /documents
-		docGroup.POST("", func(c *gin.Context) {
-			api.CreateDocumentHandler(c, database, cfg)
-		})
-		// GET /documents (List/Query)
-		docGroup.GET("", func(c *gin.Context) {
-			api.GetDocumentsHandler(c, database, cfg)
-		})
-		// GET /documents/{id}
-		docGroup.GET("/:id", func(c *gin.Context) {
-			api.GetDocumentByIDHandler(c, database, cfg)
-		})
-		// PUT /documents/{id}
-		docGroup.PUT("/:id", func(c *gin.Context) {
-			api.UpdateDocumentHandler(c, database, cfg)
-		})
-		// DELETE /documents/{id}
-		docGroup.DELETE("/:id", func(c *gin.Context) {
-			api.DeleteDocumentHandler(c, database, cfg)
-		})
-
-		// Sharing Sub-routes (nested under /documents/{id})
-		shareGroup := docGroup.Group("/:id/shares")
-		{
-			// GET /documents/{id}/shares
-			shareGroup.GET("", func(c *gin.Context) {
-				api.GetSharersHandler(c, database, cfg)
-			})
-			// PUT /documents/{id}/shares
-			shareGroup.PUT("", func(c *gin.Context) {
-				api.SetSharersHandler(c, database, cfg)
-			})
-			// PUT /documents/{id}/shares/{profile_id}
-			shareGroup.PUT("/:profile_id", func(c *gin.Context) {
-				api.AddSharerHandler(c, database, cfg)
-			})
-			// DELETE /documents/{id}/shares/{profile_id}
-			shareGroup.DELETE("/:profile_id", func(c *gin.Context) {
-				api.RemoveSharerHandler(c, database, cfg)
-			})
+	// Records a span per request (no-op unless tracing is enabled).
+	router.Use(tracing.Middleware())
+	// Appends mutating requests to a journal for -replay-file (only with -record-file).
+	if cfg.RecordFile != "" {
+		recorder, err := api.NewRecorder(cfg.RecordFile, database, cfg)
+		if err != nil {
+			log.Fatalf("CRITICAL: Failed to open request journal: %v", err)
 		}
+		defer recorder.Close()
+		router.Use(recorder.Middleware())
 	}
-	
-	// Logout route (needs auth middleware)
-	// POST /auth/logout 
-	// It's under /auth conceptually, but needs the middleware
-	router.POST("/auth/logout", authMiddleware, func(c *gin.Context) {
-		api.LogoutHandler(c, database, cfg)
-	})
+
+	// --- API Routes ---
+	// Served under /v1 and, deprecated, under the legacy unversioned paths.
+	api.RegisterRoutes(router, database, cfg)
 
 	// --- Swagger Route ---
 	// Create a sub-filesystem rooted at the 'docs' directory within the embedded FS
@@ -250,9 +233,18 @@ func main() { // coverage-ignore
 	// Serve static files from the embedded filesystem under the /docs URL path
 	router.StaticFS("/static", http.FS(docsFS))
 
-	// Use ginSwagger to handle the UI rendering, pointing it to the served swagger.json
-	// The URL path remains the same as it's served via StaticFS above.
-	router.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.URL("/static/swagger.json")))
+	// Use ginSwagger to handle the UI rendering, pointing it to /docs/swagger.json, which is
+	// generated from the routes registered above (keeping the info of the embedded swagger.json).
+	// /docs/postman.json serves a Postman collection generated from the same spec.
+	swaggerJSON, err := fs.ReadFile(docsFS, "swagger.json")
+	if err != nil {
+		log.Fatalf("CRITICAL: Failed to read embedded swagger.json: %v", err)
+	}
+	docsHandler, err := api.DocsHandler(swaggerJSON, router.Routes(), ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.URL("/docs/swagger.json")))
+	if err != nil {
+		log.Fatalf("CRITICAL: Failed to generate API documentation: %v", err)
+	}
+	router.GET("/docs/*any", docsHandler)
 
 
 	// --- Start Server ---
@@ -15,29 +15,458 @@ type Profile struct {
 	CreationDate   time.Time `json:"creation_date"`   // UTC
 	LastModifiedDate time.Time `json:"last_modified_date"` // UTC
 	Extra          any       `json:"extra,omitempty"` // User-defined data
+	Privacy        ProfilePrivacy `json:"privacy"`    // Per-field visibility to other users
+	TosAcceptance  *TosAcceptance `json:"tos_acceptance,omitempty"` // Latest terms of service accepted, if any
+	Deactivation   *Deactivation  `json:"deactivation,omitempty"`   // Set while the account is deactivated
+	Phone          string         `json:"phone,omitempty"`          // E.164 number OTPs are texted to when SMS delivery is enabled
+	TokensNotBefore *time.Time    `json:"tokens_not_before,omitempty"` // Tokens issued before this are refused; set when the password changes
+	Group          string         `json:"group,omitempty"`          // Class or workspace an administrator provisioned the account into
+	Service        *ServiceAccount `json:"service,omitempty"`       // Set for service accounts, which cannot log in
+	CalendarFeedID string          `json:"calendar_feed_id,omitempty"` // ID of the one calendar feed token that works; empty when the feed is off
+}
+
+// ServiceAccount marks a profile as a non-human account for a bot or integration. It has no
+// password: an administrator issues it tokens, each limited to some of Scopes.
+type ServiceAccount struct {
+	Scopes    []string `json:"scopes"`     // Scopes its tokens may carry; narrowing them also narrows tokens already issued
+	CreatedBy string   `json:"created_by"` // Profile ID of the administrator who created it
+}
+
+// OTPRecord is a password reset OTP waiting to be used. Only a hash of the OTP is kept, as for
+// passwords, so the database file and its backups and exports do not reveal it.
+type OTPRecord struct {
+	OTPHash   string    `json:"otp_hash"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Attempts  int       `json:"attempts,omitempty"` // Wrong guesses so far
+}
+
+// OTPLockout tracks consecutive failed OTP verifications for an email. Unlike OTPRecord.Attempts
+// it survives requesting a new OTP, so the backoff keeps growing until a verification succeeds.
+type OTPLockout struct {
+	Failures     int       `json:"failures"`
+	LastFailedAt time.Time `json:"last_failed_at"` // UTC
+	LockedUntil  time.Time `json:"locked_until"`   // UTC; verifications are refused before this time
+}
+
+// Deactivation records that a user deactivated their account. While it is set the user cannot
+// log in and their documents are hidden from everyone else; the data itself is kept.
+type Deactivation struct {
+	DeactivatedAt time.Time  `json:"deactivated_at"`          // UTC
+	ReactivateAt  *time.Time `json:"reactivate_at,omitempty"` // UTC; the account is reactivated automatically at this time, if set
+	PurgeAt       time.Time  `json:"purge_at"`                // UTC; the account is erased at this time unless reactivated first
+}
+
+// Maintenance is the maintenance mode set by an administrator. While it is enabled, write
+// requests (to Routes, or to every route when Routes is empty) are refused with 503 Service Unavailable.
+type Maintenance struct {
+	Enabled           bool      `json:"enabled"`
+	Routes            []string  `json:"routes,omitempty"`              // Path prefixes writes are refused on, e.g. "/documents" (empty = all)
+	Message           string    `json:"message,omitempty"`             // Shown to clients whose writes are refused
+	RetryAfterSeconds int       `json:"retry_after_seconds,omitempty"` // Sent as Retry-After
+	Since             time.Time `json:"since,omitempty"`               // UTC; when it was last switched on or off
+	ChangedBy         string    `json:"changed_by,omitempty"`          // Profile ID of the administrator who last changed it
+}
+
+// Device is a combination of IP address and user agent a profile logged in from. Tokens issued at
+// login carry the device's ID, so revoking the device rejects them.
+type Device struct {
+	ID        string     `json:"id"`
+	IPAddress string     `json:"ip_address"`
+	UserAgent string     `json:"user_agent"`
+	FirstSeen time.Time  `json:"first_seen"` // UTC; the first login from it
+	LastSeen  time.Time  `json:"last_seen"`  // UTC; the latest login from it
+	Logins    int        `json:"logins"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"` // UTC; when the profile stopped trusting it
+}
+
+// ExtraPolicy limits what profiles may keep in Extra. It is set by administrators and applies to
+// Extra as it is created or changed; what profiles already hold is left alone.
+type ExtraPolicy struct {
+	Schema          any       `json:"schema,omitempty"`           // JSON Schema Extra must match (see utils.CheckJSONSchema for the keywords supported); nil = any JSON
+	MaxBytes        int       `json:"max_bytes,omitempty"`        // Largest Extra, JSON-encoded (0 = the default, 16 KiB)
+	SensitiveKeys   []string  `json:"sensitive_keys,omitempty"`   // Object key patterns, e.g. "*token*"; * matches anything, case is ignored
+	RejectSensitive bool      `json:"reject_sensitive,omitempty"` // Refuse Extra with sensitive keys instead of redacting their values in search results
+	UpdatedAt       time.Time `json:"updated_at,omitempty"`       // UTC; when the policy was last set
+	ChangedBy       string    `json:"changed_by,omitempty"`       // Profile ID of the administrator who last set it
+}
+
+// EmailChange is a pending change of a profile's email address. It is applied once the
+// token sent to the new address is confirmed; only a hash of the token is stored.
+type EmailChange struct {
+	NewEmail    string    `json:"new_email"`
+	TokenHash   string    `json:"token_hash"`   // Hex SHA-256 of the confirmation token
+	RequestedAt time.Time `json:"requested_at"` // UTC
+	ExpiresAt   time.Time `json:"expires_at"`   // UTC
+}
+
+// Course is a course database hosted next to the main one (see -courses-dir), with its own
+// profiles and documents, served under /courses/{id}/ or to requests with an X-Course header.
+type Course struct {
+	ID           string          `json:"id"`             // Lowercase letters, digits and dashes; also names the database file
+	Name         string          `json:"name,omitempty"` // For people, e.g. "Web Development, Fall 2025"
+	Audience     string          `json:"audience"`       // JWT audience of the course's tokens, so they are refused elsewhere
+	Overrides    CourseOverrides `json:"overrides"`      // Settings that differ from the server's
+	CreatedBy    string          `json:"created_by"`     // Profile ID of the administrator who created it
+	CreationDate time.Time       `json:"creation_date"`  // UTC
+}
+
+// CourseOverrides are the server settings a course can change. Unset fields keep the server's.
+type CourseOverrides struct {
+	AdminEmails        []string `json:"admin_emails,omitempty"`         // Administrators of the course, e.g. its instructors (lowercased)
+	InviteOnly         *bool    `json:"invite_only,omitempty"`          // See -invite-only
+	EnablePublicAccess *bool    `json:"enable_public_access,omitempty"` // See -enable-public-access
+	ReadOnly           *bool    `json:"read_only,omitempty"`            // See -read-only, e.g. for a finished course
+}
+
+// Invite is an invitation code that lets people sign up while the server is invite-only.
+type Invite struct {
+	Code         string     `json:"code"`
+	Note         string     `json:"note,omitempty"`         // For administrators, e.g. the class the code was handed to
+	MaxUses      int        `json:"max_uses"`               // Signups the code allows
+	Uses         int        `json:"uses"`                   // Signups so far
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`   // UTC; nil = never
+	CreatedBy    string     `json:"created_by"`             // Profile ID of the administrator who created it
+	CreationDate time.Time  `json:"creation_date"`          // UTC
+	LastUsedAt   *time.Time `json:"last_used_at,omitempty"` // UTC
+}
+
+// TosAcceptance records which terms of service version a user accepted and when.
+type TosAcceptance struct {
+	Version    string    `json:"version"`
+	AcceptedAt time.Time `json:"accepted_at"` // UTC
+}
+
+// FieldVisibility controls who, other than the profile owner, may see a profile field.
+type FieldVisibility string
+
+const (
+	VisibilityPublic  FieldVisibility = "public"  // Any authenticated user (default)
+	VisibilitySharers FieldVisibility = "sharers" // Users the owner shares documents with, or who share with the owner
+	VisibilityPrivate FieldVisibility = "private" // Only the owner
+)
+
+// ProfilePrivacy holds the visibility of the optional profile fields.
+// An empty value means VisibilityPublic, which matches the behaviour before privacy settings existed.
+// ID, first name and last name are always visible so profiles remain discoverable.
+type ProfilePrivacy struct {
+	Email FieldVisibility `json:"email,omitempty"`
+	Extra FieldVisibility `json:"extra,omitempty"`
 }
 
 // Document represents a stored document
 type Document struct {
-	ID             string    `json:"id"`              // Unique ID (UUID, dashless)
-	OwnerID        string    `json:"owner_id"`        // Profile ID of the owner
-	Content        any       `json:"content"`         // Can be any JSON structure or simple text
+	ID             string    `json:"id" example:"3f2b8c1e9d4a4e7b8a6c5d0e1f2a3b4c"`       // Unique ID (UUID, dashless)
+	OwnerID        string    `json:"owner_id" example:"9a8b7c6d5e4f40318a2b1c0d9e8f7a6b"` // Profile ID of the owner
+	Content        any       `json:"content" swaggertype:"object"`                           // Can be any JSON structure or simple text
+	ContentType    string    `json:"content_type,omitempty"` // One of the ContentType* constants; empty for JSON
+	Public         bool      `json:"public,omitempty"` // Readable by anyone, including guests when public access is enabled
+	Version        int       `json:"version,omitempty"` // Content version, starting at 1 and incremented on every content update
+	Revision       int       `json:"revision,omitempty"` // Times the version's content was replaced by a coalesced update (see -coalesce-window)
+	Frozen         bool       `json:"frozen,omitempty"`    // Read-only: content, public flag and shares cannot change until it is unfrozen
+	FrozenAt       *time.Time `json:"frozen_at,omitempty"` // UTC; when it was frozen
+	FrozenBy       string     `json:"frozen_by,omitempty"` // Profile ID of the user who froze it
+	Workflow       *DocumentWorkflow `json:"workflow,omitempty"` // Submission workflow; nil until the document is first submitted
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"` // UTC; from then on the document is gone for everyone, and it is purged after -expiry-grace-period
+	Links          []string   `json:"links,omitempty"`      // IDs of the documents it links to (see GET /documents/{id}/backlinks)
 	CreationDate   time.Time `json:"creation_date"`   // UTC
 	LastModifiedDate time.Time `json:"last_modified_date"` // UTC
 }
 
+// Document content types. JSON documents may hold any JSON value; the others hold their text as
+// a JSON string. JSON is the default and is stored as an empty ContentType.
+const (
+	ContentTypeJSON     = "json"
+	ContentTypeMarkdown = "markdown"
+	ContentTypeText     = "text"
+	ContentTypeCSV      = "csv"
+)
+
+// Submission workflow states. Documents start as drafts; the owner submits them, and a reviewer
+// approves or rejects them.
+const (
+	WorkflowStateDraft     = "draft"
+	WorkflowStateSubmitted = "submitted"
+	WorkflowStateApproved  = "approved"
+	WorkflowStateRejected  = "rejected"
+)
+
+// DocumentWorkflow tracks a document through the submission workflow.
+type DocumentWorkflow struct {
+	State       string               `json:"state"`                 // One of the WorkflowState* constants
+	ReviewerID  string               `json:"reviewer_id,omitempty"` // Only this profile may approve or reject; empty = anyone the document is shared with
+	Transitions []WorkflowTransition `json:"transitions"`           // Oldest first
+}
+
+// WorkflowTransition records one change of a document's workflow state.
+type WorkflowTransition struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	ActorID   string    `json:"actor_id"`          // Profile ID of the user who made the change
+	Timestamp time.Time `json:"timestamp"`         // UTC
+	Version   int       `json:"version"`           // Content version the transition applied to
+	Comment   string    `json:"comment,omitempty"` // e.g. the reason for a rejection
+}
+
+// Review statuses
+const (
+	ReviewStatusPending   = "pending"   // Assigned; the reviewer has not given feedback yet
+	ReviewStatusSubmitted = "submitted" // The reviewer gave feedback (and may still revise it)
+)
+
+// Review assigns a reviewer to a document and holds their feedback. Assigned reviewers can read
+// the document while the review exists.
+type Review struct {
+	ID          string         `json:"id"`
+	DocumentID  string         `json:"document_id"`
+	ReviewerID  string         `json:"reviewer_id"`
+	AssignedBy  string         `json:"assigned_by"`        // Profile ID of the owner or administrator who assigned it
+	Status      string         `json:"status"`             // One of the ReviewStatus* constants
+	Rating      int            `json:"rating,omitempty"`   // Overall rating, 1-5; set once submitted
+	Criteria    map[string]int `json:"criteria,omitempty"` // Optional ratings (1-5) per criterion, e.g. "clarity"
+	Comment     string         `json:"comment,omitempty"`
+	AssignedAt  time.Time      `json:"assigned_at"`            // UTC
+	SubmittedAt *time.Time     `json:"submitted_at,omitempty"` // UTC; when the feedback was last submitted
+}
+
+// APIUsageCounter totals a profile's requests to one route.
+type APIUsageCounter struct {
+	Requests      int64     `json:"requests"`
+	Errors        int64     `json:"errors"`          // Requests answered with a 4xx or 5xx status
+	RequestBytes  int64     `json:"request_bytes"`   // Request bodies received
+	ResponseBytes int64     `json:"response_bytes"`  // Response bodies sent
+	LastRequestAt time.Time `json:"last_request_at"` // UTC
+}
+
 // ShareRecord links a document to users it's shared with
 // There will be one ShareRecord per Document ID that has shares.
 type ShareRecord struct {
 	DocumentID string   `json:"-"`           // Document ID (acts as the key in the map, dashless)
 	SharedWith []string `json:"shared_with"` // List of Profile IDs allowed access (dashless)
+	// Profiles in SharedWith limited to part of the content, keyed by profile ID; the others can read all of it
+	Scopes map[string]ShareScope `json:"scopes,omitempty"`
+}
+
+// ShareScope limits a share to the subtree of a document's content at Path.
+type ShareScope struct {
+	Path  string `json:"path"`            // Dot-separated object keys within the content, e.g. "public" for content.public
+	Write bool   `json:"write,omitempty"` // Whether the sharer may replace the subtree
+}
+
+// Erasure request statuses
+const (
+	ErasureStatusPending   = "pending"   // Waiting for the grace period to elapse; can be cancelled
+	ErasureStatusCompleted = "completed" // All data has been erased; only the report remains
+)
+
+// ErasureRequest tracks a user's request to have all of their data erased.
+// Once completed, the email is cleared so the record holds no personal data.
+type ErasureRequest struct {
+	ProfileID    string         `json:"profile_id"`
+	Email        string         `json:"email,omitempty"`
+	Status       string         `json:"status"`
+	RequestedAt  time.Time      `json:"requested_at"`  // UTC
+	ScheduledFor time.Time      `json:"scheduled_for"` // UTC; erasure runs after this time
+	CompletedAt  *time.Time     `json:"completed_at,omitempty"`
+	Report       *ErasureReport `json:"report,omitempty"`
+}
+
+// ErasureReport summarizes what was removed when an erasure was carried out.
+type ErasureReport struct {
+	ProfileDeleted      bool   `json:"profile_deleted"`
+	DocumentsDeleted    int    `json:"documents_deleted"`     // Documents owned by the profile
+	ShareRecordsDeleted int    `json:"share_records_deleted"` // Share lists of the deleted documents
+	SharesRevoked       int    `json:"shares_revoked"`        // Removals of the profile from other users' share lists
+	OTPCleared          bool   `json:"otp_cleared"`           // A pending password reset OTP was discarded
+	FavoritesCleared    bool   `json:"favorites_cleared"`     // The profile's favorites list was removed
+	EventsScrubbed      int    `json:"events_scrubbed"`       // Activity entries on other users' documents that referenced the profile
+	SchedulesDeleted    int    `json:"schedules_deleted"`     // Scheduled exports owned by the profile, with their run history
+	SnapshotsScrubbed   int    `json:"snapshots_scrubbed"`    // Export snapshots of other users that contained the profile's documents
+	EmailChangeCleared  bool   `json:"email_change_cleared"`  // A pending email change was discarded
+	ReviewsDeleted      int    `json:"reviews_deleted"`       // Reviews of the deleted documents, and reviews assigned to the profile
+	APIUsageCleared     bool   `json:"api_usage_cleared"`     // The profile's API usage counts were removed
+	DevicesCleared      bool   `json:"devices_cleared"`       // The profile's known devices were removed
+	ChannelsDeleted     int    `json:"channels_deleted"`      // Notification channels owned by the profile
+	Backup              string `json:"backup"`                // "scrubbed", "not_enabled", "failed" or "deferred" (erased in a transaction)
+}
+
+// ProfileStats summarizes a profile's documents and activity.
+type ProfileStats struct {
+	OwnedDocuments   int        `json:"owned_documents"`
+	PublicDocuments  int        `json:"public_documents"`         // Owned documents readable by anyone
+	SharedDocuments  int        `json:"shared_documents"`         // Owned documents shared with at least one user
+	SharedWithMe     int        `json:"shared_with_me"`           // Documents of other (active) users shared with the profile
+	Favorites        int        `json:"favorites"`
+	ContentBytes     int64      `json:"content_bytes"`            // Size of the owned documents' content, JSON-encoded
+	LastCreatedAt    *time.Time `json:"last_created_at,omitempty"`  // UTC; most recent creation of an owned document
+	LastModifiedAt   *time.Time `json:"last_modified_at,omitempty"` // UTC; most recent change to an owned document
+	LastActivityAt   *time.Time `json:"last_activity_at,omitempty"` // UTC; most recent document change made by the profile, on any document
+}
+
+// DocumentStats describes the shape of a document's content and when it last changed.
+type DocumentStats struct {
+	ContentBytes  int            `json:"content_bytes"` // Size of the content, JSON-encoded
+	Depth         int            `json:"depth"`         // Nesting of objects and arrays: 0 for a plain value, 1 for a flat object or array
+	KeyCount      int            `json:"key_count"`     // Keys of all objects, at any depth
+	ArrayLengths  map[string]int `json:"array_lengths"` // Length of each top-level array by key ("@this" if the content is an array)
+	Version       int            `json:"version"`
+	Modifications []time.Time    `json:"modifications"` // UTC; when the latest versions were saved, newest first
+}
+
+// DocumentVersion is a snapshot of a document's content as of one version.
+type DocumentVersion struct {
+	Version   int       `json:"version"`
+	Content   any       `json:"content"`
+	Timestamp time.Time `json:"timestamp"` // UTC; when this version was saved
+}
+
+// Events a script can be attached to
+const (
+	ScriptEventCreate = "create" // Before a document is created
+	ScriptEventUpdate = "update" // Before a document's content is replaced
+	ScriptEventDelete = "delete" // Before a document is deleted (content changes are ignored)
+)
+
+// Script is an administrator-provided Lua script run on a document event.
+// Scripts can change the content (create/update) or reject the operation.
+type Script struct {
+	ID               string    `json:"id"`
+	Name             string    `json:"name"`
+	Event            string    `json:"event"`   // One of the ScriptEvent* constants
+	Source           string    `json:"source"`  // Lua source code
+	Enabled          bool      `json:"enabled"`
+	CreatedBy        string    `json:"created_by"` // Profile ID of the administrator who added it
+	CreationDate     time.Time `json:"creation_date"`      // UTC
+	LastModifiedDate time.Time `json:"last_modified_date"` // UTC
+}
+
+// Delivery targets of a scheduled export
+const (
+	ScheduleTargetDownload = "download" // Snapshots are kept on the server for download
+	ScheduleTargetWebhook  = "webhook"  // Snapshots are POSTed to a URL
+)
+
+// Schedule is a user's recurring export of the documents matching a content query.
+type Schedule struct {
+	ID               string     `json:"id"`
+	OwnerID          string     `json:"owner_id"`
+	Name             string     `json:"name"`
+	ContentQuery     []string   `json:"content_query,omitempty"` // Same syntax as GET /documents; empty exports every document in scope
+	Scope            string     `json:"scope"`                   // "owned", "shared" or "all"
+	Interval         string     `json:"interval"`                // Go duration between runs, e.g. "24h"
+	Target           string     `json:"target"`                  // One of the ScheduleTarget* constants
+	WebhookURL       string     `json:"webhook_url,omitempty"`   // Only for webhook targets
+	Enabled          bool       `json:"enabled"`
+	NextRun          time.Time  `json:"next_run"`           // UTC
+	LastRun          *time.Time `json:"last_run,omitempty"` // UTC
+	CreationDate     time.Time  `json:"creation_date"`      // UTC
+	LastModifiedDate time.Time  `json:"last_modified_date"` // UTC
+}
+
+// Schedule run statuses and triggers
+const (
+	ScheduleRunSucceeded = "succeeded"
+	ScheduleRunFailed    = "failed"
+
+	ScheduleTriggerTimer  = "schedule" // Started by the background worker
+	ScheduleTriggerManual = "manual"   // Started through the API
+)
+
+// ScheduleRun records one execution of a schedule.
+type ScheduleRun struct {
+	ID            string     `json:"id"`
+	Trigger       string     `json:"trigger"` // One of the ScheduleTrigger* constants
+	Target        string     `json:"target"`  // Where the snapshot went (the schedule's target at the time)
+	Status        string     `json:"status"`  // One of the ScheduleRun* statuses
+	StartedAt     time.Time  `json:"started_at"`  // UTC
+	FinishedAt    time.Time  `json:"finished_at"` // UTC
+	DocumentCount int        `json:"document_count"`
+	Error         string     `json:"error,omitempty"`
+	Documents     []Document `json:"documents,omitempty"` // Snapshot kept for download targets; left out of run listings
+}
+
+// Kinds of notification channels: chat webhooks messages are posted to
+const (
+	NotificationChannelSlack   = "slack"   // A Slack incoming webhook
+	NotificationChannelDiscord = "discord" // A Discord channel webhook
+)
+
+// Events notification channels can be sent
+const (
+	NotificationDocumentShared     = "document.shared"     // A document was shared with the channel's owner
+	NotificationSubmissionReceived = "submission.received" // A document shared with the channel's owner was submitted for review
+)
+
+// NotificationChannel is a chat webhook a user has events they are concerned in posted to.
+type NotificationChannel struct {
+	ID               string    `json:"id"`
+	OwnerID          string    `json:"owner_id"`
+	Name             string    `json:"name"`
+	Kind             string    `json:"kind"`               // One of the NotificationChannel* constants
+	WebhookURL       string    `json:"webhook_url"`
+	Events           []string  `json:"events"`             // Notification* events sent to the channel
+	AllUsers         bool      `json:"all_users"`          // Administrators only: events of every user, not just the owner's
+	MaxPerHour       int       `json:"max_per_hour"`       // Messages posted per hour at most; further events are dropped
+	Enabled          bool      `json:"enabled"`
+	CreationDate     time.Time `json:"creation_date"`      // UTC
+	LastModifiedDate time.Time `json:"last_modified_date"` // UTC
+}
+
+// Document event types recorded in a document's activity feed
+const (
+	EventCreated     = "created"
+	EventUpdated     = "updated"
+	EventShared      = "shared"
+	EventUnshared    = "unshared"
+	EventPublished   = "published"
+	EventUnpublished = "unpublished"
+	EventFrozen      = "frozen"
+	EventUnfrozen    = "unfrozen"
+)
+
+// PendingShare is a document shared with an email address nobody has signed up with yet. The share
+// becomes a regular one when a profile with that email is created.
+type PendingShare struct {
+	DocumentID   string    `json:"document_id"`
+	SharedBy     string    `json:"shared_by"`     // Profile ID of the owner who shared it
+	CreationDate time.Time `json:"creation_date"` // UTC
+}
+
+// DocumentEvent is one entry in a document's activity feed.
+type DocumentEvent struct {
+	Type         string    `json:"type"`
+	Timestamp    time.Time `json:"timestamp"`                // UTC
+	ActorID      string    `json:"actor_id"`                 // Profile ID of the user who made the change
+	Version      int       `json:"version,omitempty"`        // For "created"/"updated": the content version it produced
+	ChangedPaths []string  `json:"changed_paths,omitempty"`  // For "updated": content paths that were added, removed or changed
+	ProfileIDs   []string  `json:"profile_ids,omitempty"`    // For "shared"/"unshared": the profiles affected
 }
 
 // Database holds all application data and manages concurrent access
 type Database struct {
+	SchemaVersion int                    `json:"schema_version"` // Version of the persisted format (see db/migrations.go)
 	Profiles     map[string]Profile     `json:"profiles"`      // Keyed by Profile ID (dashless)
 	Documents    map[string]Document    `json:"documents"`     // Keyed by Document ID (dashless)
 	ShareRecords map[string]ShareRecord `json:"share_records"` // Keyed by Document ID (dashless)
+	ErasureRequests map[string]ErasureRequest `json:"erasure_requests"` // Keyed by Profile ID (dashless)
+	Favorites    map[string][]string    `json:"favorites"`     // Keyed by Profile ID; bookmarked Document IDs in the order added
+	DocumentEvents map[string][]DocumentEvent `json:"document_events"` // Keyed by Document ID; activity oldest first
+	DocumentVersions map[string][]DocumentVersion `json:"document_versions"` // Keyed by Document ID; content history oldest first
+	Scripts      map[string]Script      `json:"scripts"`       // Keyed by Script ID
+	Schedules    map[string]Schedule    `json:"schedules"`     // Keyed by Schedule ID
+	ScheduleRuns map[string][]ScheduleRun `json:"schedule_runs"` // Keyed by Schedule ID; run history oldest first
+	NotificationChannels map[string]NotificationChannel `json:"notification_channels"` // Keyed by channel ID
+	EmailChanges map[string]EmailChange `json:"email_changes"` // Keyed by Profile ID; at most one pending change each
+	OTPs         map[string]OTPRecord   `json:"otps"`          // Keyed by email; in-flight password reset OTPs, kept across restarts
+	OTPLockouts  map[string]OTPLockout  `json:"otp_lockouts"`  // Keyed by email; failed OTP verifications and backoff
+	Invites      map[string]Invite      `json:"invites"`       // Keyed by invitation code
+	Reviews      map[string]Review      `json:"reviews"`       // Keyed by Review ID
+	APIUsage     map[string]map[string]APIUsageCounter `json:"api_usage"` // Keyed by Profile ID, then route (e.g. "GET /documents/:id")
+	Courses      map[string]Course      `json:"courses"`       // Keyed by Course ID; course databases hosted next to this one
+	PendingShares map[string][]PendingShare `json:"pending_shares"` // Keyed by normalized email; shares waiting for that email to sign up, oldest first
+	SignedURLUses map[string]time.Time `json:"signed_url_uses"` // Keyed by signed URL token ID; when the used-up token expires
+	Devices      map[string][]Device    `json:"devices"`       // Keyed by Profile ID; devices logged in from, oldest first
+	Maintenance  *Maintenance           `json:"maintenance,omitempty"` // Maintenance mode; nil when it was never enabled
+	ExtraPolicy  *ExtraPolicy           `json:"extra_policy,omitempty"` // Limits on Profile.Extra; nil for the defaults (see db/profile_extra.go)
 
 	// Mutex for thread-safe access to the maps
 	Mu sync.RWMutex `json:"-"` // Exclude mutex from serialization (Exported)
@@ -0,0 +1,375 @@
+// Package scripting runs small administrator-provided Lua scripts on document events,
+// letting them validate, change or reject content before it is stored.
+//
+// Scripts run in a sandbox: only the base, table, string and math libraries are available
+// (without file loading or dynamic code loading), each run is bounded by a time limit and a limit
+// on the memory it allocates, and the call stack, value stack and string.rep results are capped.
+package scripting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/metrics"
+	"strings"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+// Limits bound the resources one script run may use.
+type Limits struct {
+	Timeout        time.Duration // Wall-clock time limit for a run (CPU limit)
+	CallStackSize  int           // Maximum depth of nested function calls
+	RegistryMax    int           // Maximum number of values on the Lua value stack
+	MaxStringBytes int           // Largest string string.rep may build
+	MaxMemoryBytes int           // Most memory a run may allocate (see watchMemory)
+	MaxSourceBytes int           // Largest script accepted by Compile
+	MaxDepth       int           // Deepest nesting of tables doc.content may be left with
+}
+
+// DefaultLimits are used for fields left zero in the limits passed to Run.
+var DefaultLimits = Limits{
+	Timeout:        100 * time.Millisecond,
+	CallStackSize:  200,
+	RegistryMax:    64 * 1024,
+	MaxStringBytes: 1 << 20,
+	MaxMemoryBytes: 64 << 20,
+	MaxSourceBytes: 64 * 1024,
+	MaxDepth:       100,
+}
+
+// withDefaults fills zero fields from DefaultLimits.
+func (l Limits) withDefaults() Limits {
+	if l.Timeout <= 0 {
+		l.Timeout = DefaultLimits.Timeout
+	}
+	if l.CallStackSize <= 0 {
+		l.CallStackSize = DefaultLimits.CallStackSize
+	}
+	if l.RegistryMax <= 0 {
+		l.RegistryMax = DefaultLimits.RegistryMax
+	}
+	if l.MaxStringBytes <= 0 {
+		l.MaxStringBytes = DefaultLimits.MaxStringBytes
+	}
+	if l.MaxMemoryBytes <= 0 {
+		l.MaxMemoryBytes = DefaultLimits.MaxMemoryBytes
+	}
+	if l.MaxSourceBytes <= 0 {
+		l.MaxSourceBytes = DefaultLimits.MaxSourceBytes
+	}
+	if l.MaxDepth <= 0 {
+		l.MaxDepth = DefaultLimits.MaxDepth
+	}
+	return l
+}
+
+// Input is what a script sees when it runs.
+type Input struct {
+	Event      string // "create", "update" or "delete" (global `event`)
+	DocumentID string // Global `doc.id`
+	OwnerID    string // Global `doc.owner_id`
+	Content    any    // Global `doc.content`; changes to it are kept unless the script rejects
+	Previous   any    // Global `previous`: the stored content before an update or delete (nil on create)
+}
+
+// Result is the outcome of a script run.
+type Result struct {
+	Content  any    // doc.content after the script ran
+	Rejected bool   // The script called reject()
+	Reason   string // Reason passed to reject()
+}
+
+// ErrTimeout is returned when a script exceeds its time limit.
+var ErrTimeout = errors.New("script exceeded its time limit")
+
+// ErrMemoryLimit is returned when a script allocates more memory than its limit allows.
+var ErrMemoryLimit = errors.New("script exceeded its memory limit")
+
+// memoryCheckInterval is how often watchMemory checks what a run has allocated.
+const memoryCheckInterval = time.Millisecond
+
+// jsonArrayMeta marks Lua tables that hold JSON arrays so that empty arrays survive the round trip.
+const jsonArrayMeta = "__json_array"
+
+// Compile checks that a script is valid Lua and within the size limit, without running it.
+func Compile(name, source string, limits Limits) error {
+	limits = limits.withDefaults()
+	if len(source) > limits.MaxSourceBytes {
+		return fmt.Errorf("script is %d bytes; the limit is %d", len(source), limits.MaxSourceBytes)
+	}
+	chunk, err := parse.Parse(strings.NewReader(source), name)
+	if err != nil {
+		return err
+	}
+	_, err = lua.Compile(chunk, name)
+	return err
+}
+
+// Run executes a script against a document in a fresh sandbox.
+// An error means the script itself failed (syntax or runtime error, or a limit was hit);
+// a script that deliberately refuses the change returns Result.Rejected instead.
+func Run(name, source string, in Input, limits Limits) (Result, error) {
+	limits = limits.withDefaults()
+
+	L := lua.NewState(lua.Options{
+		SkipOpenLibs:    true,
+		CallStackSize:   limits.CallStackSize,
+		RegistrySize:    1024,
+		RegistryMaxSize: limits.RegistryMax,
+	})
+	defer L.Close()
+	openSandbox(L, name, limits)
+
+	memoryCtx, stopMemory := context.WithCancelCause(context.Background())
+	defer stopMemory(nil)
+	ctx, cancel := context.WithTimeout(memoryCtx, limits.Timeout)
+	defer cancel()
+	go watchMemory(ctx, stopMemory, uint64(limits.MaxMemoryBytes))
+	L.SetContext(ctx)
+
+	content, err := toLua(L, in.Content)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to pass content to script: %w", err)
+	}
+	previous, err := toLua(L, in.Previous)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to pass previous content to script: %w", err)
+	}
+	doc := L.NewTable()
+	doc.RawSetString("id", lua.LString(in.DocumentID))
+	doc.RawSetString("owner_id", lua.LString(in.OwnerID))
+	doc.RawSetString("content", content)
+	L.SetGlobal("doc", doc)
+	L.SetGlobal("previous", previous)
+	L.SetGlobal("event", lua.LString(in.Event))
+
+	var result Result
+	L.SetGlobal("reject", L.NewFunction(func(L *lua.LState) int {
+		result.Rejected = true
+		result.Reason = L.OptString(1, "rejected by script")
+		L.RaiseError("rejected")
+		return 0
+	}))
+
+	fn, err := L.LoadString(source)
+	if err == nil {
+		L.Push(fn)
+		err = L.PCall(0, lua.MultRet, nil)
+	}
+	if result.Rejected {
+		return result, nil
+	}
+	if err != nil {
+		if errors.Is(context.Cause(ctx), ErrMemoryLimit) {
+			return Result{}, fmt.Errorf("%w (%d bytes)", ErrMemoryLimit, limits.MaxMemoryBytes)
+		}
+		if ctx.Err() != nil {
+			return Result{}, fmt.Errorf("%w (%s)", ErrTimeout, limits.Timeout)
+		}
+		return Result{}, err
+	}
+
+	result.Content, err = fromLua(doc.RawGetString("content"), make(map[*lua.LTable]bool), limits.MaxDepth)
+	if err != nil {
+		return Result{}, fmt.Errorf("script left doc.content in an unsupported state: %w", err)
+	}
+	return result, nil
+}
+
+// watchMemory stops a run with ErrMemoryLimit, by cancelling its context, once more than limit
+// bytes have been allocated since it started; it checks every memoryCheckInterval until ctx is
+// done. The interpreter checks the context before every instruction, so a run is stopped
+// whichever way it builds strings or tables (.., string.format, table.concat, ...). Go counts
+// allocations per process, not per goroutine, so what other requests allocate meanwhile counts
+// too; the limit is set well above what scripts need to keep that from stopping them.
+func watchMemory(ctx context.Context, stop context.CancelCauseFunc, limit uint64) {
+	sample := []metrics.Sample{{Name: "/gc/heap/allocs:bytes"}}
+	metrics.Read(sample)
+	start := sample[0].Value.Uint64()
+	ticker := time.NewTicker(memoryCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			metrics.Read(sample)
+			if sample[0].Value.Uint64()-start > limit {
+				stop(ErrMemoryLimit)
+				return
+			}
+		}
+	}
+}
+
+// openSandbox loads the safe standard libraries and removes functions that could load code or files.
+func openSandbox(L *lua.LState, name string, limits Limits) {
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+	for _, unsafe := range []string{"dofile", "loadfile", "load", "loadstring", "require", "module", "collectgarbage", "getfenv", "setfenv", "newproxy", "_printregs"} {
+		L.SetGlobal(unsafe, lua.LNil)
+	}
+
+	// print goes to the server log
+	L.SetGlobal("print", L.NewFunction(func(L *lua.LState) int {
+		parts := make([]string, L.GetTop())
+		for i := range parts {
+			parts[i] = L.ToStringMeta(L.Get(i + 1)).String()
+		}
+		log.Printf("INFO: Script '%s': %s", name, strings.Join(parts, "\t"))
+		return 0
+	}))
+
+	// string.rep is the easy way to exhaust memory; cap the size of what it builds
+	if stringLib, ok := L.GetGlobal(lua.StringLibName).(*lua.LTable); ok {
+		stringLib.RawSetString("rep", L.NewFunction(func(L *lua.LState) int {
+			s := L.CheckString(1)
+			n := L.CheckInt(2)
+			if n > 0 && len(s) > 0 && n > limits.MaxStringBytes/len(s) {
+				L.RaiseError("string.rep result exceeds the %d byte limit", limits.MaxStringBytes)
+			}
+			if n < 0 {
+				n = 0
+			}
+			L.Push(lua.LString(strings.Repeat(s, n)))
+			return 1
+		}))
+	}
+}
+
+// toLua converts a JSON-compatible Go value into a Lua value.
+func toLua(L *lua.LState, value any) (lua.LValue, error) {
+	// Normalize to the generic form produced by encoding/json (e.g. []string -> []any, int -> float64)
+	switch value.(type) {
+	case nil, bool, float64, string, map[string]any, []any:
+	default:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &value); err != nil {
+			return nil, err
+		}
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return lua.LNil, nil
+	case bool:
+		return lua.LBool(v), nil
+	case float64:
+		return lua.LNumber(v), nil
+	case string:
+		return lua.LString(v), nil
+	case []any:
+		table := L.NewTable()
+		for _, item := range v {
+			converted, err := toLua(L, item)
+			if err != nil {
+				return nil, err
+			}
+			table.Append(converted)
+		}
+		meta := L.NewTable()
+		meta.RawSetString(jsonArrayMeta, lua.LTrue)
+		L.SetMetatable(table, meta)
+		return table, nil
+	case map[string]any:
+		table := L.NewTable()
+		for key, item := range v {
+			converted, err := toLua(L, item)
+			if err != nil {
+				return nil, err
+			}
+			table.RawSetString(key, converted)
+		}
+		return table, nil
+	}
+	return nil, fmt.Errorf("cannot convert %T to Lua", value)
+}
+
+// fromLua converts a Lua value back into a JSON-compatible Go value.
+// Tables with keys 1..n (or that started out as JSON arrays) become arrays; other tables become objects.
+// open holds the tables being converted, which would make a cycle if found again within them (the same
+// table may appear more than once elsewhere), and depth is how many more levels of tables may follow.
+func fromLua(value lua.LValue, open map[*lua.LTable]bool, depth int) (any, error) {
+	switch v := value.(type) {
+	case *lua.LNilType:
+		return nil, nil
+	case lua.LBool:
+		return bool(v), nil
+	case lua.LNumber:
+		return float64(v), nil
+	case lua.LString:
+		return string(v), nil
+	case *lua.LTable:
+		if open[v] {
+			return nil, errors.New("a table contains itself")
+		}
+		if depth <= 0 {
+			return nil, errors.New("tables are nested too deeply")
+		}
+		open[v] = true
+		defer delete(open, v)
+		if isArrayTable(v) {
+			items := make([]any, 0, v.Len())
+			for i := 1; i <= v.Len(); i++ {
+				item, err := fromLua(v.RawGetInt(i), open, depth-1)
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, item)
+			}
+			return items, nil
+		}
+		object := map[string]any{}
+		var convErr error
+		v.ForEach(func(key, item lua.LValue) {
+			if convErr != nil {
+				return
+			}
+			converted, err := fromLua(item, open, depth-1)
+			if err != nil {
+				convErr = err
+				return
+			}
+			object[key.String()] = converted
+		})
+		return object, convErr
+	}
+	return nil, fmt.Errorf("cannot convert a Lua %s to JSON", value.Type())
+}
+
+// isArrayTable reports whether a table should be encoded as a JSON array.
+func isArrayTable(table *lua.LTable) bool {
+	n := table.Len()
+	count := 0
+	table.ForEach(func(lua.LValue, lua.LValue) { count++ })
+	if n > 0 {
+		return count == n
+	}
+	if count > 0 {
+		return false
+	}
+	// Empty: an array only if it came from one
+	if meta, ok := table.Metatable.(*lua.LTable); ok {
+		return meta.RawGetString(jsonArrayMeta) == lua.LTrue
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package scripting
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompile(t *testing.T) {
+	assert.NoError(t, Compile("ok", `if doc.content.title == nil then reject("missing title") end`, Limits{}))
+	assert.Error(t, Compile("bad", `if then end`, Limits{}))
+	assert.ErrorContains(t, Compile("big", strings.Repeat("-", 100), Limits{MaxSourceBytes: 10}), "limit is 10")
+}
+
+func TestRun_Mutate(t *testing.T) {
+	source := `
+doc.content.title = string.upper(doc.content.title)
+doc.content.tags[#doc.content.tags + 1] = event
+doc.content.reviewed_by = doc.owner_id
+doc.content.removed = nil
+`
+	result, err := Run("mutate", source, Input{
+		Event:   "create",
+		OwnerID: "owner1",
+		Content: map[string]any{"title": "essay", "tags": []any{"draft"}, "empty": []any{}, "removed": true, "n": 2.5},
+	}, Limits{})
+	require.NoError(t, err)
+	assert.False(t, result.Rejected)
+	assert.Equal(t, map[string]any{
+		"title":       "ESSAY",
+		"tags":        []any{"draft", "create"},
+		"empty":       []any{},
+		"reviewed_by": "owner1",
+		"n":           2.5,
+	}, result.Content)
+}
+
+func TestRun_Reject(t *testing.T) {
+	source := `
+if previous ~= nil and doc.content.score < previous.score then
+  reject("scores may only go up")
+end`
+	result, err := Run("validate", source, Input{
+		Event:    "update",
+		Content:  map[string]any{"score": 3.0},
+		Previous: map[string]any{"score": 5.0},
+	}, Limits{})
+	require.NoError(t, err)
+	assert.True(t, result.Rejected)
+	assert.Equal(t, "scores may only go up", result.Reason)
+
+	result, err = Run("validate", source, Input{Event: "update", Content: map[string]any{"score": 6.0}, Previous: map[string]any{"score": 5.0}}, Limits{})
+	require.NoError(t, err)
+	assert.False(t, result.Rejected)
+
+	result, err = Run("swallow", `pcall(reject, "caught")`, Input{Event: "create"}, Limits{})
+	require.NoError(t, err)
+	assert.True(t, result.Rejected, "rejecting cannot be undone with pcall")
+}
+
+func TestRun_NonObjectContent(t *testing.T) {
+	result, err := Run("text", `doc.content = doc.content .. "!"`, Input{Event: "create", Content: "hello"}, Limits{})
+	require.NoError(t, err)
+	assert.Equal(t, "hello!", result.Content)
+}
+
+func TestRun_Sandbox(t *testing.T) {
+	for _, source := range []string{
+		`dofile("/etc/passwd")`,
+		`loadstring("return 1")()`,
+		`os.exit(1)`,
+		`io.open("/etc/passwd")`,
+		`require("os")`,
+	} {
+		_, err := Run("escape", source, Input{Event: "create"}, Limits{})
+		assert.Error(t, err, source)
+	}
+}
+
+func TestRun_Limits(t *testing.T) {
+	start := time.Now()
+	_, err := Run("loop", `while true do end`, Input{Event: "create"}, Limits{Timeout: 50 * time.Millisecond})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrTimeout))
+	assert.Less(t, time.Since(start), 2*time.Second)
+
+	_, err = Run("recurse", `local function f() return 1 + f() end f()`, Input{Event: "create"}, Limits{CallStackSize: 50})
+	assert.Error(t, err)
+
+	_, err = Run("rep", `local s = string.rep("x", 10000000)`, Input{Event: "create"}, Limits{MaxStringBytes: 1024})
+	assert.ErrorContains(t, err, "byte limit")
+
+	for name, source := range map[string]string{
+		"concat":       `local s = "x" for i = 1, 30 do s = s .. s end`,
+		"format":       `local s = "x" for i = 1, 30 do s = string.format("%s%s", s, s) end`,
+		"table.concat": `local s = "x" for i = 1, 30 do s = table.concat({s, s}) end`,
+	} {
+		start := time.Now()
+		_, err = Run(name, source, Input{Event: "create"}, Limits{Timeout: time.Minute, MaxMemoryBytes: 16 << 20})
+		assert.ErrorIs(t, err, ErrMemoryLimit, name)
+		assert.Less(t, time.Since(start), 10*time.Second, name)
+	}
+
+	_, err = Run("runtime", `local x = nil; x.y = 1`, Input{Event: "create"}, Limits{})
+	assert.Error(t, err)
+}
+
+func TestRun_UnsupportedResult(t *testing.T) {
+	_, err := Run("fn", `doc.content = function() end`, Input{Event: "create"}, Limits{})
+	assert.ErrorContains(t, err, "unsupported")
+}
+
+func TestRun_NestedResult(t *testing.T) {
+	_, err := Run("cycle", `doc.content.self = doc.content`, Input{Event: "create", Content: map[string]any{}}, Limits{})
+	assert.ErrorContains(t, err, "contains itself")
+
+	_, err = Run("deep", `local t = {} for i = 1, 100000 do t = {t} end doc.content = t`, Input{Event: "create"}, Limits{})
+	assert.ErrorContains(t, err, "nested too deeply")
+
+	result, err := Run("shared", `local t = {a = 1} doc.content = {x = t, y = {t}}`, Input{Event: "create"}, Limits{MaxDepth: 3})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"x": map[string]any{"a": 1.0}, "y": []any{map[string]any{"a": 1.0}}}, result.Content)
+	_, err = Run("limit", `doc.content = {{{{}}}}`, Input{Event: "create"}, Limits{MaxDepth: 3})
+	assert.ErrorContains(t, err, "nested too deeply")
+}
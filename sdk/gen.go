@@ -0,0 +1,41 @@
+//go:build ignore
+
+// gen.go writes the clients of the server's routes to generated/, with the descriptions of
+// docs/swagger.json (see api.ClientSwagger); run it with go generate ./sdk.
+package main
+
+import (
+	"docserver/api"
+	"docserver/sdk"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+)
+
+func main() {
+	gin.SetMode(gin.ReleaseMode)
+	data, err := os.ReadFile(filepath.Join("..", "docs", "swagger.json"))
+	if err != nil {
+		log.Fatalf("Failed to read swagger spec: %v", err)
+	}
+	data, err = api.ClientSwagger(data)
+	if err != nil {
+		log.Fatalf("Failed to generate swagger spec: %v", err)
+	}
+	spec, err := sdk.ParseSpec(data)
+	if err != nil {
+		log.Fatalf("Failed to parse swagger spec: %v", err)
+	}
+	if err := os.MkdirAll("generated", 0o755); err != nil {
+		log.Fatalf("Failed to create generated/: %v", err)
+	}
+	for _, language := range sdk.Languages {
+		path := filepath.Join("generated", language.File)
+		if err := os.WriteFile(path, language.Generate(spec), 0o644); err != nil {
+			log.Fatalf("Failed to write %s: %v", path, err)
+		}
+		log.Printf("Wrote %s", path)
+	}
+}
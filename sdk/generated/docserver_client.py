@@ -0,0 +1,1901 @@
+# DocServer API client, generated from the server's Swagger spec by "go generate ./sdk". Do not edit.
+"""Client for the DocServer API.
+
+    from docserver_client import DocServerClient
+
+    client = DocServerClient()
+    client.token = client.post_auth_login({"email": "ada@example.com", "password": "secret123"})["token"]
+    print(client.get_documents(limit=5)["data"])
+
+Methods return the data of the response, and lists the page with its "data", "meta" and
+"links". Error responses raise DocServerError. Needs Python 3.8 or later and no packages.
+"""
+from __future__ import annotations
+
+import json
+import urllib.error
+import urllib.parse
+import urllib.request
+from typing import Any, Dict, List, Literal, Optional, TypedDict
+
+BASE_URL = "__DOCSERVER_BASE_URL__"
+
+
+class DocServerError(Exception):
+    """An error response of the API."""
+
+    def __init__(self, status: int, code: str, message: str) -> None:
+        super().__init__(f"{status} {code}: {message}")
+        self.status = status
+        self.code = code
+        self.message = message
+
+
+class Page(TypedDict, total=False):
+    """One page of a list."""
+
+    data: List[Any]
+    meta: Dict[str, Any]  # total, page, limit and total_pages
+    links: Dict[str, str]  # self, first, last, and next and prev when there are such pages
+
+
+class AcceptTosRequest(TypedDict, total=False):
+    """api.AcceptTosRequest of the API."""
+
+    version: str
+
+
+class AssignReviewRequest(TypedDict, total=False):
+    """api.AssignReviewRequest of the API."""
+
+    reviewer_id: str
+
+
+class CalendarFeedResponse(TypedDict, total=False):
+    """api.CalendarFeedResponse of the API."""
+
+    token: str
+    url: str
+
+
+class ChallengeResponse(TypedDict, total=False):
+    """api.ChallengeResponse of the API."""
+
+    challenge: str
+    difficulty: int
+    expires_at: str
+    provider: str
+    site_key: str
+
+
+class ChaosSettings(TypedDict, total=False):
+    """api.ChaosSettings of the API."""
+
+    drop_rate: float
+    enabled: bool
+    error_rate: float
+    jitter_ms: int
+    latency_ms: int
+    routes: List[str]
+
+
+class ConfirmEmailChangeRequest(TypedDict, total=False):
+    """api.ConfirmEmailChangeRequest of the API."""
+
+    token: str
+
+
+class ContentPathResponse(TypedDict, total=False):
+    """api.ContentPathResponse of the API."""
+
+    document_id: str
+    path: str
+    previous: Any
+    value: Any
+    version: int
+
+
+class CreateDocumentRequest(TypedDict, total=False):
+    """api.CreateDocumentRequest of the API."""
+
+    content: Any
+    content_type: str
+    expires_at: str
+    id: str
+    key: str
+    links: List[str]
+    public: bool
+
+
+class DeactivateRequest(TypedDict, total=False):
+    """api.DeactivateRequest of the API."""
+
+    reactivate_at: str
+
+
+class DeactivatedProfile(TypedDict, total=False):
+    """api.DeactivatedProfile of the API."""
+
+    deactivation: Deactivation
+    email: str
+    first_name: str
+    id: str
+    last_name: str
+
+
+class DeviceResponse(TypedDict, total=False):
+    """api.DeviceResponse of the API."""
+
+    current: bool
+    first_seen: str
+    id: str
+    ip_address: str
+    last_seen: str
+    logins: int
+    revoked_at: str
+    user_agent: str
+
+
+class DiffDocumentRequest(TypedDict, total=False):
+    """api.DiffDocumentRequest of the API."""
+
+    content: Any
+
+
+class DocumentDiffResponse(TypedDict, total=False):
+    """api.DocumentDiffResponse of the API."""
+
+    added: List[DiffEntry]
+    changed: List[DiffEntry]
+    document_id: str
+    from_version: int
+    removed: List[DiffEntry]
+    to_version: int
+
+
+class DocumentExpiryRequest(TypedDict, total=False):
+    """api.DocumentExpiryRequest of the API."""
+
+    expires_at: str
+
+
+class DocumentListItem(TypedDict, total=False):
+    """api.DocumentListItem of the API."""
+
+    content: Any
+    content_type: str
+    creation_date: str
+    expires_at: str
+    favorite: bool
+    frozen: bool
+    frozen_at: str
+    frozen_by: str
+    id: str
+    last_modified_date: str
+    links: List[str]
+    owner: ProfileSummary
+    owner_id: str
+    public: bool
+    revision: int
+    shared_with: List[ProfileSummary]
+    ttl_seconds: int
+    version: int
+    workflow: DocumentWorkflow
+
+
+class DocumentResponse(TypedDict, total=False):
+    """api.DocumentResponse of the API."""
+
+    content: Any
+    content_type: str
+    creation_date: str
+    expires_at: str
+    frozen: bool
+    frozen_at: str
+    frozen_by: str
+    id: str
+    last_modified_date: str
+    links: List[str]
+    owner: ProfileSummary
+    owner_id: str
+    public: bool
+    revision: int
+    shared_with: List[ProfileSummary]
+    ttl_seconds: int
+    version: int
+    workflow: DocumentWorkflow
+
+
+class DocumentReviewsResponse(TypedDict, total=False):
+    """api.DocumentReviewsResponse of the API."""
+
+    reviews: List[Review]
+    summary: ReviewSummary
+
+
+class DuplicateClusterResponse(TypedDict, total=False):
+    """api.DuplicateClusterResponse of the API."""
+
+    documents: List[GraphNode]
+    pairs: List[DuplicatePair]
+    similarity: float
+
+
+class EmailChangeRequest(TypedDict, total=False):
+    """api.EmailChangeRequest of the API."""
+
+    new_email: str
+    password: str
+
+
+class EmailChangeResponse(TypedDict, total=False):
+    """api.EmailChangeResponse of the API."""
+
+    expires_at: str
+    new_email: str
+
+
+class ExportManifest(TypedDict, total=False):
+    """api.ExportManifest of the API."""
+
+    format: str
+    generated_at: str
+    profile_id: str
+    sections: List[ExportSection]
+    version: int
+
+
+class ExportResponse(TypedDict, total=False):
+    """api.ExportResponse of the API."""
+
+    documents: List[Document]
+    erasure: ErasureRequest
+    favorites: List[str]
+    manifest: ExportManifest
+    profile: ProfileResponse
+    shared_with_me: List[str]
+    shares: List[ExportShare]
+
+
+class ExportSection(TypedDict, total=False):
+    """api.ExportSection of the API."""
+
+    count: int
+    name: str
+    sha256: str
+
+
+class ExportShare(TypedDict, total=False):
+    """api.ExportShare of the API."""
+
+    document_id: str
+    shared_with: List[str]
+
+
+class ExtraPolicyRequest(TypedDict, total=False):
+    """api.ExtraPolicyRequest of the API."""
+
+    max_bytes: int
+    reject_sensitive: bool
+    schema: Any
+    sensitive_keys: List[str]
+
+
+class FavoriteResponse(TypedDict, total=False):
+    """api.FavoriteResponse of the API."""
+
+    document_id: str
+    favorite: bool
+
+
+class FetchDocumentRequest(TypedDict, total=False):
+    """api.FetchDocumentRequest of the API."""
+
+    public: bool
+    url: str
+
+
+class ForgotPasswordRequest(TypedDict, total=False):
+    """api.ForgotPasswordRequest of the API."""
+
+    challenge: str
+    email: str
+
+
+class GenerateLoadResponse(TypedDict, total=False):
+    """api.GenerateLoadResponse of the API."""
+
+    documents: int
+    duration_ms: float
+    shared_with_you: int
+    user_ids: List[str]
+    users: int
+
+
+class GetSharersResponse(TypedDict, total=False):
+    """api.GetSharersResponse of the API."""
+
+    pending_emails: List[str]
+    scopes: Dict[str, ShareScope]
+    shared_with: List[str]
+
+
+GraphEdge = TypedDict("GraphEdge", {"from": str, "to": str}, total=False)
+
+
+class GraphNode(TypedDict, total=False):
+    """api.GraphNode of the API."""
+
+    id: str
+    owner_id: str
+    title: str
+
+
+class IncrementRequest(TypedDict, total=False):
+    """api.IncrementRequest of the API."""
+
+    delta: float
+
+
+class IntrospectResponse(TypedDict, total=False):
+    """api.IntrospectResponse of the API."""
+
+    active: bool
+    admin: bool
+    aud: List[str]
+    device_id: str
+    documents: List[str]
+    exp: int
+    iat: int
+    iss: str
+    nbf: int
+    scope: str
+    sub: str
+    token_type: str
+    username: str
+
+
+class InviteRequest(TypedDict, total=False):
+    """api.InviteRequest of the API."""
+
+    expires_at: str
+    max_uses: int
+    note: str
+
+
+class LimitsResponse(TypedDict, total=False):
+    """api.LimitsResponse of the API."""
+
+    archive_max_bytes: int
+    fetch_max_bytes: int
+    max_body_bytes: int
+    max_page_size: int
+    rate_limits: List[RateLimitInfo]
+
+
+class LinkGraph(TypedDict, total=False):
+    """api.LinkGraph of the API."""
+
+    edges: List[GraphEdge]
+    nodes: List[GraphNode]
+
+
+class LintDocumentRequest(TypedDict, total=False):
+    """api.LintDocumentRequest of the API."""
+
+    apply: bool
+    normalize: List[str]
+    rules: LintRules
+    version: int
+
+
+class LintDocumentResponse(TypedDict, total=False):
+    """api.LintDocumentResponse of the API."""
+
+    applied: bool
+    changes: JsonDiff
+    document_id: str
+    issues: List[LintIssue]
+    normalized: Any
+    version: int
+
+
+class LoginRequest(TypedDict, total=False):
+    """api.LoginRequest of the API."""
+
+    email: str
+    password: str
+
+
+class LoginResponse(TypedDict, total=False):
+    """api.LoginResponse of the API."""
+
+    token: str
+    tos: TosStatus
+
+
+class MaintenanceRequest(TypedDict, total=False):
+    """api.MaintenanceRequest of the API."""
+
+    enabled: bool
+    message: str
+    retry_after_seconds: int
+    routes: List[str]
+
+
+class NotificationChannelRequest(TypedDict, total=False):
+    """api.NotificationChannelRequest of the API."""
+
+    all_users: bool
+    enabled: bool
+    events: List[str]
+    kind: str
+    max_per_hour: int
+    name: str
+    webhook_url: str
+
+
+class ProfileApiUsage(TypedDict, total=False):
+    """api.ProfileAPIUsage of the API."""
+
+    email: str
+    errors: int
+    first_name: str
+    group: str
+    last_name: str
+    last_request_at: str
+    profile_id: str
+    request_bytes: int
+    requests: int
+    response_bytes: int
+    routes: List[ApiRouteUsage]
+
+
+class ProfileResponse(TypedDict, total=False):
+    """api.ProfileResponse of the API."""
+
+    creation_date: str
+    email: str
+    extra: Any
+    first_name: str
+    id: str
+    last_modified_date: str
+    last_name: str
+    phone: str
+    privacy: ProfilePrivacy
+    tos_acceptance: TosAcceptance
+
+
+class ProfileSummary(TypedDict, total=False):
+    """api.ProfileSummary of the API."""
+
+    first_name: str
+    id: str
+    last_name: str
+
+
+class ProvisionAccount(TypedDict, total=False):
+    """api.ProvisionAccount of the API."""
+
+    email: str
+    first_name: str
+    group: str
+    last_name: str
+
+
+class ProvisionReport(TypedDict, total=False):
+    """api.ProvisionReport of the API."""
+
+    accounts: List[ProvisionResult]
+    created: int
+    delivery: str
+    duplicates: int
+    existing: int
+    invalid: int
+
+
+class ProvisionRequest(TypedDict, total=False):
+    """api.ProvisionRequest of the API."""
+
+    accounts: List[ProvisionAccount]
+    delivery: str
+    group: str
+
+
+class ProvisionResult(TypedDict, total=False):
+    """api.ProvisionResult of the API."""
+
+    email: str
+    error: str
+    group: str
+    profile_id: str
+    row: int
+    status: str
+    temporary_password: str
+
+
+class QueryOperatorsResponse(TypedDict, total=False):
+    """api.QueryOperatorsResponse of the API."""
+
+    insensitive_suffix: str
+    length_suffix: str
+    logical: List[str]
+    negation: str
+    operators: List[QueryOperator]
+
+
+class RateLimitInfo(TypedDict, total=False):
+    """api.RateLimitInfo of the API."""
+
+    limit: int
+    remaining: int
+    reset_seconds: int
+    route: str
+    window_seconds: int
+
+
+class RecoveryResponse(TypedDict, total=False):
+    """api.RecoveryResponse of the API."""
+
+    recovered: bool
+    reports: List[RecoveryReport]
+
+
+class ReplaceDocumentsRequest(TypedDict, total=False):
+    """api.ReplaceDocumentsRequest of the API."""
+
+    content_query: List[str]
+    dry_run: bool
+    new: Any
+    old: Any
+    path: str
+    regex: str
+
+
+class ResetPasswordRequest(TypedDict, total=False):
+    """api.ResetPasswordRequest of the API."""
+
+    email: str
+    new_password: str
+    otp: str
+
+
+class ResolveProfilesRequest(TypedDict, total=False):
+    """api.ResolveProfilesRequest of the API."""
+
+    ids: List[str]
+
+
+class ResolveProfilesResponse(TypedDict, total=False):
+    """api.ResolveProfilesResponse of the API."""
+
+    missing: List[str]
+    profiles: List[ProfileSummary]
+
+
+class RestrictedTokenRequest(TypedDict, total=False):
+    """api.RestrictedTokenRequest of the API."""
+
+    documents: List[str]
+    scopes: List[str]
+    ttl: str
+
+
+class RestrictedTokenResponse(TypedDict, total=False):
+    """api.RestrictedTokenResponse of the API."""
+
+    documents: List[str]
+    expires_at: str
+    scopes: List[str]
+    token: str
+
+
+class ScheduleRequest(TypedDict, total=False):
+    """api.ScheduleRequest of the API."""
+
+    content_query: List[str]
+    enabled: bool
+    interval: str
+    name: str
+    scope: str
+    target: str
+    webhook_url: str
+
+
+class ScriptRequest(TypedDict, total=False):
+    """api.ScriptRequest of the API."""
+
+    enabled: bool
+    event: str
+    name: str
+    source: str
+
+
+class ServiceAccountRequest(TypedDict, total=False):
+    """api.ServiceAccountRequest of the API."""
+
+    name: str
+    scopes: List[str]
+
+
+class ServiceAccountResponse(TypedDict, total=False):
+    """api.ServiceAccountResponse of the API."""
+
+    created_by: str
+    creation_date: str
+    email: str
+    id: str
+    name: str
+    scopes: List[str]
+
+
+class ServiceAccountScopesRequest(TypedDict, total=False):
+    """api.ServiceAccountScopesRequest of the API."""
+
+    scopes: List[str]
+
+
+class ServiceTokenRequest(TypedDict, total=False):
+    """api.ServiceTokenRequest of the API."""
+
+    scopes: List[str]
+    ttl: str
+
+
+class ServiceTokenResponse(TypedDict, total=False):
+    """api.ServiceTokenResponse of the API."""
+
+    expires_at: str
+    scopes: List[str]
+    token: str
+
+
+class SetSharersRequest(TypedDict, total=False):
+    """api.SetSharersRequest of the API."""
+
+    shared_with: List[str]
+
+
+class ShareByEmailRequest(TypedDict, total=False):
+    """api.ShareByEmailRequest of the API."""
+
+    email: str
+
+
+class ShareByEmailResponse(TypedDict, total=False):
+    """api.ShareByEmailResponse of the API."""
+
+    email: str
+    profile_id: str
+    status: str
+
+
+class ShareScopeRequest(TypedDict, total=False):
+    """api.ShareScopeRequest of the API."""
+
+    path: str
+    write: bool
+
+
+class ShareScopeResponse(TypedDict, total=False):
+    """api.ShareScopeResponse of the API."""
+
+    path: str
+    profile_id: str
+    write: bool
+
+
+class SignedUrlResponse(TypedDict, total=False):
+    """api.SignedURLResponse of the API."""
+
+    expires_at: str
+    op: str
+    token: str
+    url: str
+
+
+class SignupRequest(TypedDict, total=False):
+    """api.SignupRequest of the API."""
+
+    accept_tos: bool
+    challenge: str
+    email: str
+    extra: Any
+    first_name: str
+    invite_code: str
+    last_name: str
+    password: str
+
+
+class SignupResponse(TypedDict, total=False):
+    """api.SignupResponse of the API."""
+
+    creation_date: str
+    email: str
+    extra: Any
+    first_name: str
+    id: str
+    last_modified_date: str
+    last_name: str
+    tos: TosStatus
+
+
+class StatusResponse(TypedDict, total=False):
+    """api.StatusResponse of the API."""
+
+    api_version: str
+    build_date: str
+    commit: str
+    ephemeral: bool
+    maintenance: Maintenance
+    persistence: PersistenceStatus
+    read_only: bool
+    uptime_seconds: int
+    version: str
+
+
+class SubmitReviewRequest(TypedDict, total=False):
+    """api.SubmitReviewRequest of the API."""
+
+    comment: str
+    criteria: Dict[str, int]
+    rating: int
+
+
+class TosStatus(TypedDict, total=False):
+    """api.TosStatus of the API."""
+
+    accepted: bool
+    accepted_at: str
+    accepted_version: str
+    url: str
+    version: str
+
+
+class UpdateDocumentRequest(TypedDict, total=False):
+    """api.UpdateDocumentRequest of the API."""
+
+    content: Any
+    content_type: str
+    links: List[str]
+    public: bool
+
+
+class UpdateProfileRequest(TypedDict, total=False):
+    """api.UpdateProfileRequest of the API."""
+
+    extra: Any
+    first_name: str
+    last_name: str
+    phone: str
+    privacy: ProfilePrivacy
+
+
+class ValidateQueryRequest(TypedDict, total=False):
+    """api.ValidateQueryRequest of the API."""
+
+    content_query: List[str]
+
+
+class VersionResponse(TypedDict, total=False):
+    """api.VersionResponse of the API."""
+
+    api_version: str
+    build_date: str
+    commit: str
+    supported_api_versions: List[str]
+    version: str
+
+
+class WorkflowTransitionRequest(TypedDict, total=False):
+    """api.WorkflowTransitionRequest of the API."""
+
+    comment: str
+    reviewer_id: str
+    state: str
+
+
+class ApiRouteUsage(TypedDict, total=False):
+    """db.APIRouteUsage of the API."""
+
+    errors: int
+    last_request_at: str
+    request_bytes: int
+    requests: int
+    response_bytes: int
+    route: str
+
+
+class ApiUsageReport(TypedDict, total=False):
+    """db.APIUsageReport of the API."""
+
+    errors: int
+    last_request_at: str
+    profile_id: str
+    request_bytes: int
+    requests: int
+    response_bytes: int
+    routes: List[ApiRouteUsage]
+
+
+class Dashboard(TypedDict, total=False):
+    """db.Dashboard of the API."""
+
+    generated_at: str
+    queries: DashboardQueries
+    recent_activity: List[DashboardActivity]
+    storage: DashboardStorage
+    users: List[DashboardUser]
+
+
+class DashboardActivity(TypedDict, total=False):
+    """db.DashboardActivity of the API."""
+
+    actor_id: str
+    changed_paths: List[str]
+    document_id: str
+    profile_ids: List[str]
+    timestamp: str
+    type: str
+    version: int
+
+
+class DashboardQueries(TypedDict, total=False):
+    """db.DashboardQueries of the API."""
+
+    api_errors: int
+    api_requests: int
+    cache_hits: int
+    cache_misses: int
+    content_queries: int
+    document_queries: int
+    slow_queries: int
+
+
+class DashboardStorage(TypedDict, total=False):
+    """db.DashboardStorage of the API."""
+
+    content_bytes: int
+    documents: int
+    profiles: int
+    version_bytes: int
+    versions: int
+
+
+class DashboardUser(TypedDict, total=False):
+    """db.DashboardUser of the API."""
+
+    content_bytes: int
+    documents: int
+    email: str
+    first_name: str
+    last_activity_at: str
+    last_name: str
+    profile_id: str
+    public_documents: int
+    requests: int
+    shared_documents: int
+
+
+class DuplicatePair(TypedDict, total=False):
+    """db.DuplicatePair of the API."""
+
+    a: str
+    b: str
+    similarity: float
+
+
+class Orphan(TypedDict, total=False):
+    """db.Orphan of the API."""
+
+    collection: str
+    key: str
+    missing_id: str
+    missing_type: str
+
+
+class OrphanReport(TypedDict, total=False):
+    """db.OrphanReport of the API."""
+
+    counts: Dict[str, int]
+    orphans: List[Orphan]
+
+
+class PasswordHashGroup(TypedDict, total=False):
+    """db.PasswordHashGroup of the API."""
+
+    accounts: int
+    current: bool
+    parameters: str
+
+
+class PasswordHashReport(TypedDict, total=False):
+    """db.PasswordHashReport of the API."""
+
+    accounts: int
+    configured: str
+    current: int
+    groups: List[PasswordHashGroup]
+    outdated: int
+    rehashed: int
+    unrecognized: int
+
+
+class PersistenceStatus(TypedDict, total=False):
+    """db.PersistenceStatus of the API."""
+
+    healthy: bool
+    last_failure_at: str
+    last_saved_at: str
+    save_pending: bool
+
+
+class QueryCacheStats(TypedDict, total=False):
+    """db.QueryCacheStats of the API."""
+
+    capacity: int
+    hits: int
+    misses: int
+    size: int
+
+
+class QueryDiagnostic(TypedDict, total=False):
+    """db.QueryDiagnostic of the API."""
+
+    code: str
+    index: int
+    message: str
+    severity: str
+    suggestion: str
+
+
+class QueryOperator(TypedDict, total=False):
+    """db.QueryOperator of the API."""
+
+    description: str
+    example: str
+    insensitive: bool
+    name: str
+    plain_text: bool
+    target_types: List[str]
+    value_types: List[str]
+
+
+class QueryValidation(TypedDict, total=False):
+    """db.QueryValidation of the API."""
+
+    diagnostics: List[QueryDiagnostic]
+    query: str
+    valid: bool
+
+
+class RecoveryReport(TypedDict, total=False):
+    """db.RecoveryReport of the API."""
+
+    file: str
+    quarantined_as: str
+    reason: str
+    recovered: Dict[str, int]
+    recovered_at: str
+    source: str
+
+
+ReplaceChange = TypedDict("ReplaceChange", {"document_id": str, "from": Any, "to": Any, "version": int}, total=False)
+
+
+class ReplaceReport(TypedDict, total=False):
+    """db.ReplaceReport of the API."""
+
+    changed: int
+    changes: List[ReplaceChange]
+    dry_run: bool
+    frozen: int
+    matched: int
+
+
+class ReviewSummary(TypedDict, total=False):
+    """db.ReviewSummary of the API."""
+
+    assigned: int
+    average_rating: float
+    criteria: Dict[str, float]
+    pending: int
+    submitted: int
+
+
+class ScheduleExport(TypedDict, total=False):
+    """db.ScheduleExport of the API."""
+
+    documents: List[Document]
+    generated_at: str
+    run_id: str
+    schedule_id: str
+    schedule_name: str
+
+
+class SlowQuery(TypedDict, total=False):
+    """db.SlowQuery of the API."""
+
+    caller_id: str
+    content_query: List[str]
+    documents_evaluated: int
+    documents_matched: int
+    documents_scanned: int
+    duration_ms: float
+    parsed_query: str
+    scope: str
+    timestamp: str
+
+
+class Deactivation(TypedDict, total=False):
+    """models.Deactivation of the API."""
+
+    deactivated_at: str
+    purge_at: str
+    reactivate_at: str
+
+
+class Document(TypedDict, total=False):
+    """models.Document of the API."""
+
+    content: Any
+    content_type: str
+    creation_date: str
+    expires_at: str
+    frozen: bool
+    frozen_at: str
+    frozen_by: str
+    id: str
+    last_modified_date: str
+    links: List[str]
+    owner_id: str
+    public: bool
+    revision: int
+    version: int
+    workflow: DocumentWorkflow
+
+
+class DocumentEvent(TypedDict, total=False):
+    """models.DocumentEvent of the API."""
+
+    actor_id: str
+    changed_paths: List[str]
+    profile_ids: List[str]
+    timestamp: str
+    type: str
+    version: int
+
+
+class DocumentStats(TypedDict, total=False):
+    """models.DocumentStats of the API."""
+
+    array_lengths: Dict[str, int]
+    content_bytes: int
+    depth: int
+    key_count: int
+    modifications: List[str]
+    version: int
+
+
+class DocumentWorkflow(TypedDict, total=False):
+    """models.DocumentWorkflow of the API."""
+
+    reviewer_id: str
+    state: str
+    transitions: List[WorkflowTransition]
+
+
+class ErasureReport(TypedDict, total=False):
+    """models.ErasureReport of the API."""
+
+    api_usage_cleared: bool
+    backup: str
+    channels_deleted: int
+    devices_cleared: bool
+    documents_deleted: int
+    email_change_cleared: bool
+    events_scrubbed: int
+    favorites_cleared: bool
+    otp_cleared: bool
+    profile_deleted: bool
+    reviews_deleted: int
+    schedules_deleted: int
+    share_records_deleted: int
+    shares_revoked: int
+    snapshots_scrubbed: int
+
+
+class ErasureRequest(TypedDict, total=False):
+    """models.ErasureRequest of the API."""
+
+    completed_at: str
+    email: str
+    profile_id: str
+    report: ErasureReport
+    requested_at: str
+    scheduled_for: str
+    status: str
+
+
+class ExtraPolicy(TypedDict, total=False):
+    """models.ExtraPolicy of the API."""
+
+    changed_by: str
+    max_bytes: int
+    reject_sensitive: bool
+    schema: Any
+    sensitive_keys: List[str]
+    updated_at: str
+
+
+class Invite(TypedDict, total=False):
+    """models.Invite of the API."""
+
+    code: str
+    created_by: str
+    creation_date: str
+    expires_at: str
+    last_used_at: str
+    max_uses: int
+    note: str
+    uses: int
+
+
+class Maintenance(TypedDict, total=False):
+    """models.Maintenance of the API."""
+
+    changed_by: str
+    enabled: bool
+    message: str
+    retry_after_seconds: int
+    routes: List[str]
+    since: str
+
+
+class NotificationChannel(TypedDict, total=False):
+    """models.NotificationChannel of the API."""
+
+    all_users: bool
+    creation_date: str
+    enabled: bool
+    events: List[str]
+    id: str
+    kind: str
+    last_modified_date: str
+    max_per_hour: int
+    name: str
+    owner_id: str
+    webhook_url: str
+
+
+class ProfilePrivacy(TypedDict, total=False):
+    """models.ProfilePrivacy of the API."""
+
+    email: str
+    extra: str
+
+
+class ProfileStats(TypedDict, total=False):
+    """models.ProfileStats of the API."""
+
+    content_bytes: int
+    favorites: int
+    last_activity_at: str
+    last_created_at: str
+    last_modified_at: str
+    owned_documents: int
+    public_documents: int
+    shared_documents: int
+    shared_with_me: int
+
+
+class Review(TypedDict, total=False):
+    """models.Review of the API."""
+
+    assigned_at: str
+    assigned_by: str
+    comment: str
+    criteria: Dict[str, int]
+    document_id: str
+    id: str
+    rating: int
+    reviewer_id: str
+    status: str
+    submitted_at: str
+
+
+class Schedule(TypedDict, total=False):
+    """models.Schedule of the API."""
+
+    content_query: List[str]
+    creation_date: str
+    enabled: bool
+    id: str
+    interval: str
+    last_modified_date: str
+    last_run: str
+    name: str
+    next_run: str
+    owner_id: str
+    scope: str
+    target: str
+    webhook_url: str
+
+
+class ScheduleRun(TypedDict, total=False):
+    """models.ScheduleRun of the API."""
+
+    document_count: int
+    documents: List[Document]
+    error: str
+    finished_at: str
+    id: str
+    started_at: str
+    status: str
+    target: str
+    trigger: str
+
+
+class Script(TypedDict, total=False):
+    """models.Script of the API."""
+
+    created_by: str
+    creation_date: str
+    enabled: bool
+    event: str
+    id: str
+    last_modified_date: str
+    name: str
+    source: str
+
+
+class ShareScope(TypedDict, total=False):
+    """models.ShareScope of the API."""
+
+    path: str
+    write: bool
+
+
+class TosAcceptance(TypedDict, total=False):
+    """models.TosAcceptance of the API."""
+
+    accepted_at: str
+    version: str
+
+
+WorkflowTransition = TypedDict("WorkflowTransition", {"actor_id": str, "comment": str, "from": str, "timestamp": str, "to": str, "version": int}, total=False)
+
+
+DiffEntry = TypedDict("DiffEntry", {"from": Any, "path": str, "to": Any}, total=False)
+
+
+class Envelope(TypedDict, total=False):
+    """utils.Envelope of the API."""
+
+    data: Any
+    links: UtilsPageLinks
+    meta: UtilsPageMeta
+
+
+class ErrorEnvelope(TypedDict, total=False):
+    """utils.ErrorEnvelope of the API."""
+
+    error: ErrorObject
+
+
+class ErrorObject(TypedDict, total=False):
+    """utils.ErrorObject of the API."""
+
+    code: str
+    message: str
+    message_id: str
+    status: int
+
+
+class JsonDiff(TypedDict, total=False):
+    """utils.JSONDiff of the API."""
+
+    added: List[DiffEntry]
+    changed: List[DiffEntry]
+    removed: List[DiffEntry]
+
+
+class LintIssue(TypedDict, total=False):
+    """utils.LintIssue of the API."""
+
+    message: str
+    path: str
+    rule: str
+
+
+class LintRules(TypedDict, total=False):
+    """utils.LintRules of the API."""
+
+    allow_mixed_dates: bool
+    allow_nan_strings: bool
+    max_depth: int
+    max_key_length: int
+
+
+class UtilsPageLinks(TypedDict, total=False):
+    """utils.PageLinks of the API."""
+
+    first: str
+    last: str
+    next: str
+    prev: str
+    self: str
+
+
+class UtilsPageMeta(TypedDict, total=False):
+    """utils.PageMeta of the API."""
+
+    limit: int
+    page: int
+    total: int
+    total_pages: int
+
+
+def _path(value: Any) -> str:
+    return urllib.parse.quote(str(value), safe="")
+
+
+class DocServerClient:
+    """Calls the DocServer API at base_url, authenticated with token once it is set."""
+
+    def __init__(self, base_url: str = BASE_URL, token: Optional[str] = None) -> None:
+        self.base_url = base_url.rstrip("/")
+        self.token = token
+
+    def _request(self, method: str, path: str, query: Optional[Dict[str, Any]] = None, body: Any = None, page: bool = False) -> Any:
+        url = self.base_url + path
+        pairs = []
+        for key, value in (query or {}).items():
+            for item in value if isinstance(value, list) else [value]:
+                if item is not None:
+                    pairs.append((key, json.dumps(item) if isinstance(item, bool) else str(item)))
+        if pairs:
+            url += "?" + urllib.parse.urlencode(pairs)
+        headers = {"Accept": "application/json"}
+        data = None
+        if body is not None:
+            data = json.dumps(body).encode("utf-8")
+            headers["Content-Type"] = "application/json"
+        if self.token:
+            headers["Authorization"] = "Bearer " + self.token
+        request = urllib.request.Request(url, data=data, headers=headers, method=method)
+        try:
+            with urllib.request.urlopen(request) as response:
+                raw = response.read()
+        except urllib.error.HTTPError as err:
+            raw = err.read()
+            try:
+                error = json.loads(raw)["error"]
+                status, code, message = error["status"], error["code"], error["message"]
+            except (ValueError, KeyError, TypeError):
+                status, code, message = err.code, "", raw.decode("utf-8", "replace")
+            raise DocServerError(status, code, message) from None
+        if not raw:
+            return None
+        envelope = json.loads(raw)
+        return envelope if page else envelope.get("data")
+
+    def get_admin_chaos(self) -> ChaosSettings:
+        """Get Chaos Settings (Admin, Debug Mode) (GET /admin/chaos)."""
+        return self._request("GET", "/admin/chaos")
+
+    def put_admin_chaos(self, body: ChaosSettings) -> ChaosSettings:
+        """Switch Chaos Mode On (Admin, Debug Mode) (PUT /admin/chaos)."""
+        return self._request("PUT", "/admin/chaos", body=body)
+
+    def delete_admin_chaos(self) -> None:
+        """Switch Chaos Mode Off (Admin, Debug Mode) (DELETE /admin/chaos)."""
+        return self._request("DELETE", "/admin/chaos")
+
+    def get_admin_dashboard(self, *, limit: Optional[int] = None) -> Dashboard:
+        """Get the Dashboard (Admin) (GET /admin/dashboard)."""
+        return self._request("GET", "/admin/dashboard", query={"limit": limit})
+
+    def post_admin_generate_load(self, *, docs: Optional[int] = None, users: Optional[int] = None, seed: Optional[int] = None) -> GenerateLoadResponse:
+        """Generate Load (Admin) (POST /admin/generate-load)."""
+        return self._request("POST", "/admin/generate-load", query={"docs": docs, "users": users, "seed": seed})
+
+    def get_admin_invites(self) -> List[Invite]:
+        """List Invitation Codes (Admin) (GET /admin/invites)."""
+        return self._request("GET", "/admin/invites")
+
+    def post_admin_invites(self, body: Optional[InviteRequest] = None) -> Invite:
+        """Create an Invitation Code (Admin) (POST /admin/invites)."""
+        return self._request("POST", "/admin/invites", body=body)
+
+    def delete_admin_invites_by_code(self, code: str) -> None:
+        """Delete an Invitation Code (Admin) (DELETE /admin/invites/{code})."""
+        return self._request("DELETE", f"/admin/invites/{_path(code)}")
+
+    def post_admin_maintenance(self, body: MaintenanceRequest) -> Maintenance:
+        """Switch Maintenance Mode (Admin) (POST /admin/maintenance)."""
+        return self._request("POST", "/admin/maintenance", body=body)
+
+    def get_admin_orphans(self) -> OrphanReport:
+        """Report Orphaned References (Admin) (GET /admin/orphans)."""
+        return self._request("GET", "/admin/orphans")
+
+    def post_admin_orphans_clean(self) -> OrphanReport:
+        """Remove Orphaned References (Admin) (POST /admin/orphans/clean)."""
+        return self._request("POST", "/admin/orphans/clean")
+
+    def get_admin_password_hashes(self) -> PasswordHashReport:
+        """Report Password Hash Migration (Admin) (GET /admin/password-hashes)."""
+        return self._request("GET", "/admin/password-hashes")
+
+    def get_admin_profile_extra_policy(self) -> ExtraPolicy:
+        """Get the Profile Extra Policy (Admin) (GET /admin/profile-extra-policy)."""
+        return self._request("GET", "/admin/profile-extra-policy")
+
+    def put_admin_profile_extra_policy(self, body: ExtraPolicyRequest) -> ExtraPolicy:
+        """Set the Profile Extra Policy (Admin) (PUT /admin/profile-extra-policy)."""
+        return self._request("PUT", "/admin/profile-extra-policy", body=body)
+
+    def delete_admin_profile_extra_policy(self) -> ExtraPolicy:
+        """Reset the Profile Extra Policy (Admin) (DELETE /admin/profile-extra-policy)."""
+        return self._request("DELETE", "/admin/profile-extra-policy")
+
+    def get_admin_profiles_deactivated(self) -> List[DeactivatedProfile]:
+        """List Deactivated Accounts (Admin) (GET /admin/profiles/deactivated)."""
+        return self._request("GET", "/admin/profiles/deactivated")
+
+    def post_admin_profiles_by_id_purge(self, id: str) -> ErasureReport:
+        """Purge a Deactivated Account (Admin) (POST /admin/profiles/{id}/purge)."""
+        return self._request("POST", f"/admin/profiles/{_path(id)}/purge")
+
+    def post_admin_profiles_by_id_reactivate(self, id: str) -> DeactivatedProfile:
+        """Reactivate an Account (Admin) (POST /admin/profiles/{id}/reactivate)."""
+        return self._request("POST", f"/admin/profiles/{_path(id)}/reactivate")
+
+    def post_admin_provision(self, body: ProvisionRequest, *, group: Optional[str] = None, delivery: Optional[str] = None) -> ProvisionReport:
+        """Provision Accounts from a Roster (Admin) (POST /admin/provision)."""
+        return self._request("POST", "/admin/provision", query={"group": group, "delivery": delivery}, body=body)
+
+    def get_admin_query_cache(self) -> QueryCacheStats:
+        """Get Query Cache Statistics (Admin) (GET /admin/query-cache)."""
+        return self._request("GET", "/admin/query-cache")
+
+    def get_admin_recovery(self) -> RecoveryResponse:
+        """Report Database Recovery (Admin) (GET /admin/recovery)."""
+        return self._request("GET", "/admin/recovery")
+
+    def get_admin_scripts(self) -> List[Script]:
+        """List Document Scripts (Admin) (GET /admin/scripts)."""
+        return self._request("GET", "/admin/scripts")
+
+    def post_admin_scripts(self, body: ScriptRequest) -> Script:
+        """Add a Document Script (Admin) (POST /admin/scripts)."""
+        return self._request("POST", "/admin/scripts", body=body)
+
+    def get_admin_scripts_by_id(self, id: str) -> Script:
+        """Get a Document Script (Admin) (GET /admin/scripts/{id})."""
+        return self._request("GET", f"/admin/scripts/{_path(id)}")
+
+    def put_admin_scripts_by_id(self, id: str, body: ScriptRequest) -> Script:
+        """Replace a Document Script (Admin) (PUT /admin/scripts/{id})."""
+        return self._request("PUT", f"/admin/scripts/{_path(id)}", body=body)
+
+    def delete_admin_scripts_by_id(self, id: str) -> None:
+        """Delete a Document Script (Admin) (DELETE /admin/scripts/{id})."""
+        return self._request("DELETE", f"/admin/scripts/{_path(id)}")
+
+    def get_admin_service_accounts(self) -> List[ServiceAccountResponse]:
+        """List Service Accounts (Admin) (GET /admin/service-accounts)."""
+        return self._request("GET", "/admin/service-accounts")
+
+    def post_admin_service_accounts(self, body: ServiceAccountRequest) -> ServiceAccountResponse:
+        """Create a Service Account (Admin) (POST /admin/service-accounts)."""
+        return self._request("POST", "/admin/service-accounts", body=body)
+
+    def put_admin_service_accounts_by_id(self, id: str, body: ServiceAccountScopesRequest) -> ServiceAccountResponse:
+        """Change a Service Account's Scopes (Admin) (PUT /admin/service-accounts/{id})."""
+        return self._request("PUT", f"/admin/service-accounts/{_path(id)}", body=body)
+
+    def delete_admin_service_accounts_by_id(self, id: str) -> None:
+        """Delete a Service Account (Admin) (DELETE /admin/service-accounts/{id})."""
+        return self._request("DELETE", f"/admin/service-accounts/{_path(id)}")
+
+    def post_admin_service_accounts_by_id_tokens(self, id: str, body: Optional[ServiceTokenRequest] = None) -> ServiceTokenResponse:
+        """Issue a Service Account Token (Admin) (POST /admin/service-accounts/{id}/tokens)."""
+        return self._request("POST", f"/admin/service-accounts/{_path(id)}/tokens", body=body)
+
+    def get_admin_slow_queries(self) -> List[SlowQuery]:
+        """List Slow Queries (Admin) (GET /admin/slow-queries)."""
+        return self._request("GET", "/admin/slow-queries")
+
+    def get_admin_usage_api(self, *, group: Optional[str] = None) -> List[ProfileApiUsage]:
+        """List API Usage by Profile (Admin) (GET /admin/usage/api)."""
+        return self._request("GET", "/admin/usage/api", query={"group": group})
+
+    def get_auth_challenge(self) -> ChallengeResponse:
+        """Get a Bot-Protection Challenge (GET /auth/challenge)."""
+        return self._request("GET", "/auth/challenge")
+
+    def post_auth_forgot_password(self, body: ForgotPasswordRequest) -> None:
+        """Request Password Reset Code (OTP) (POST /auth/forgot-password)."""
+        return self._request("POST", "/auth/forgot-password", body=body)
+
+    def post_auth_introspect(self) -> IntrospectResponse:
+        """Introspect an Access Token (POST /auth/introspect)."""
+        return self._request("POST", "/auth/introspect")
+
+    def post_auth_login(self, body: LoginRequest) -> LoginResponse:
+        """Log In to Your Account (POST /auth/login)."""
+        return self._request("POST", "/auth/login", body=body)
+
+    def post_auth_logout(self) -> None:
+        """Log Out (Client-Side Action) (POST /auth/logout)."""
+        return self._request("POST", "/auth/logout")
+
+    def post_auth_reset_password(self, body: ResetPasswordRequest) -> None:
+        """Set New Password Using Reset Code (OTP) (POST /auth/reset-password)."""
+        return self._request("POST", "/auth/reset-password", body=body)
+
+    def post_auth_signup(self, body: SignupRequest) -> SignupResponse:
+        """Register a New User Account (POST /auth/signup)."""
+        return self._request("POST", "/auth/signup", body=body)
+
+    def post_auth_tokens(self, body: RestrictedTokenRequest) -> RestrictedTokenResponse:
+        """Create a Restricted Token (POST /auth/tokens)."""
+        return self._request("POST", "/auth/tokens", body=body)
+
+    def get_calendar_ics(self, *, token: Optional[str] = None) -> str:
+        """Get the Calendar Feed (GET /calendar.ics)."""
+        return self._request("GET", "/calendar.ics", query={"token": token})
+
+    def post_calendar_feed(self) -> CalendarFeedResponse:
+        """Create a Calendar Feed URL (POST /calendar/feed)."""
+        return self._request("POST", "/calendar/feed")
+
+    def delete_calendar_feed(self) -> None:
+        """Delete the Calendar Feed (DELETE /calendar/feed)."""
+        return self._request("DELETE", "/calendar/feed")
+
+    def get_documents(self, *, scope: Optional[Literal["owned", "shared", "all", "public", "any"]] = None, owner_id: Optional[str] = None, favorites: Optional[bool] = None, workflow_state: Optional[Literal["draft", "submitted", "approved", "rejected"]] = None, expired: Optional[Literal["exclude", "include", "only"]] = None, content_query: Optional[List[str]] = None, missing: Optional[Literal["skip", "false", "error"]] = None, include: Optional[str] = None, sort_by: Optional[Literal["creation_date", "last_modified_date"]] = None, order: Optional[Literal["asc", "desc"]] = None, page: Optional[int] = None, limit: Optional[int] = None) -> Page:
+        """List and Search Your Documents (GET /documents)."""
+        return self._request("GET", "/documents", query={"scope": scope, "owner_id": owner_id, "favorites": favorites, "workflow_state": workflow_state, "expired": expired, "content_query": content_query, "missing": missing, "include": include, "sort_by": sort_by, "order": order, "page": page, "limit": limit}, page=True)
+
+    def post_documents(self, body: CreateDocumentRequest) -> DocumentResponse:
+        """Create a New Document (POST /documents)."""
+        return self._request("POST", "/documents", body=body)
+
+    def get_documents_archive(self, *, scope: Optional[Literal["owned", "shared", "all", "public"]] = None, content_query: Optional[List[str]] = None, missing: Optional[Literal["skip", "false", "error"]] = None, sort_by: Optional[Literal["creation_date", "last_modified_date"]] = None, order: Optional[Literal["asc", "desc"]] = None) -> str:
+        """Download Documents as a Zip Archive (GET /documents/archive)."""
+        return self._request("GET", "/documents/archive", query={"scope": scope, "content_query": content_query, "missing": missing, "sort_by": sort_by, "order": order})
+
+    def get_documents_duplicates(self, *, threshold: Optional[float] = None, scope: Optional[Literal["owned", "shared", "all"]] = None) -> List[DuplicateClusterResponse]:
+        """Find Near-Duplicate Documents (GET /documents/duplicates)."""
+        return self._request("GET", "/documents/duplicates", query={"threshold": threshold, "scope": scope})
+
+    def post_documents_fetch(self, body: FetchDocumentRequest) -> Document:
+        """Create a Document from a URL (POST /documents/fetch)."""
+        return self._request("POST", "/documents/fetch", body=body)
+
+    def post_documents_replace(self, body: ReplaceDocumentsRequest) -> ReplaceReport:
+        """Search and Replace Across Your Documents (POST /documents/replace)."""
+        return self._request("POST", "/documents/replace", body=body)
+
+    def get_documents_by_id(self, id: str, *, render: Optional[Literal["html"]] = None, include: Optional[str] = None) -> DocumentResponse:
+        """Get a Specific Document by ID (GET /documents/{id})."""
+        return self._request("GET", f"/documents/{_path(id)}", query={"render": render, "include": include})
+
+    def put_documents_by_id(self, id: str, body: UpdateDocumentRequest, *, sync: Optional[bool] = None, upsert: Optional[bool] = None, create: Optional[bool] = None) -> Document:
+        """Update a Document's Content (PUT /documents/{id})."""
+        return self._request("PUT", f"/documents/{_path(id)}", query={"sync": sync, "upsert": upsert, "create": create}, body=body)
+
+    def delete_documents_by_id(self, id: str) -> None:
+        """Delete a Document (DELETE /documents/{id})."""
+        return self._request("DELETE", f"/documents/{_path(id)}")
+
+    def get_documents_by_id_activity(self, id: str, *, page: Optional[int] = None, limit: Optional[int] = None) -> Page:
+        """Get a Document's Activity (GET /documents/{id}/activity)."""
+        return self._request("GET", f"/documents/{_path(id)}/activity", query={"page": page, "limit": limit}, page=True)
+
+    def get_documents_by_id_backlinks(self, id: str) -> List[GraphNode]:
+        """Get a Document's Backlinks (GET /documents/{id}/backlinks)."""
+        return self._request("GET", f"/documents/{_path(id)}/backlinks")
+
+    def put_documents_by_id_content_by_path(self, id: str, path: str, body: Any, *, version: Optional[int] = None, revision: Optional[int] = None) -> ContentPathResponse:
+        """Set a Value in a Document (PUT /documents/{id}/content/{path})."""
+        return self._request("PUT", f"/documents/{_path(id)}/content/{_path(path)}", query={"version": version, "revision": revision}, body=body)
+
+    def delete_documents_by_id_content_by_path(self, id: str, path: str, *, version: Optional[int] = None, revision: Optional[int] = None) -> ContentPathResponse:
+        """Remove a Value from a Document (DELETE /documents/{id}/content/{path})."""
+        return self._request("DELETE", f"/documents/{_path(id)}/content/{_path(path)}", query={"version": version, "revision": revision})
+
+    def post_documents_by_id_content_by_path_append(self, id: str, path: str, body: Any, *, version: Optional[int] = None, revision: Optional[int] = None) -> ContentPathResponse:
+        """Append to an Array in a Document (POST /documents/{id}/content/{path}/append)."""
+        return self._request("POST", f"/documents/{_path(id)}/content/{_path(path)}/append", query={"version": version, "revision": revision}, body=body)
+
+    def post_documents_by_id_content_by_path_increment(self, id: str, path: str, body: Optional[IncrementRequest] = None, *, version: Optional[int] = None, revision: Optional[int] = None) -> ContentPathResponse:
+        """Increment a Number in a Document (POST /documents/{id}/content/{path}/increment)."""
+        return self._request("POST", f"/documents/{_path(id)}/content/{_path(path)}/increment", query={"version": version, "revision": revision}, body=body)
+
+    def post_documents_by_id_content_by_path_insert(self, id: str, path: str, body: Any, *, index: Optional[int] = None, version: Optional[int] = None, revision: Optional[int] = None) -> ContentPathResponse:
+        """Insert into an Array in a Document (POST /documents/{id}/content/{path}/insert)."""
+        return self._request("POST", f"/documents/{_path(id)}/content/{_path(path)}/insert", query={"index": index, "version": version, "revision": revision}, body=body)
+
+    def post_documents_by_id_content_by_path_remove(self, id: str, path: str, *, index: Optional[int] = None, version: Optional[int] = None, revision: Optional[int] = None) -> ContentPathResponse:
+        """Remove from an Array in a Document (POST /documents/{id}/content/{path}/remove)."""
+        return self._request("POST", f"/documents/{_path(id)}/content/{_path(path)}/remove", query={"index": index, "version": version, "revision": revision})
+
+    def get_documents_by_id_diff(self, id: str, *, from_: Optional[int] = None, to: Optional[int] = None) -> DocumentDiffResponse:
+        """Compare Two Versions of a Document (GET /documents/{id}/diff)."""
+        return self._request("GET", f"/documents/{_path(id)}/diff", query={"from": from_, "to": to})
+
+    def post_documents_by_id_diff(self, id: str, body: DiffDocumentRequest, *, from_: Optional[int] = None) -> DocumentDiffResponse:
+        """Compare a Document with New Content (POST /documents/{id}/diff)."""
+        return self._request("POST", f"/documents/{_path(id)}/diff", query={"from": from_}, body=body)
+
+    def put_documents_by_id_expiry(self, id: str, body: DocumentExpiryRequest) -> DocumentResponse:
+        """Set When a Document Expires (PUT /documents/{id}/expiry)."""
+        return self._request("PUT", f"/documents/{_path(id)}/expiry", body=body)
+
+    def delete_documents_by_id_expiry(self, id: str) -> DocumentResponse:
+        """Keep a Document for Good (DELETE /documents/{id}/expiry)."""
+        return self._request("DELETE", f"/documents/{_path(id)}/expiry")
+
+    def post_documents_by_id_favorite(self, id: str) -> FavoriteResponse:
+        """Mark a Document as Favorite (POST /documents/{id}/favorite)."""
+        return self._request("POST", f"/documents/{_path(id)}/favorite")
+
+    def delete_documents_by_id_favorite(self, id: str) -> None:
+        """Remove a Document from Favorites (DELETE /documents/{id}/favorite)."""
+        return self._request("DELETE", f"/documents/{_path(id)}/favorite")
+
+    def post_documents_by_id_freeze(self, id: str) -> Document:
+        """Freeze a Document (POST /documents/{id}/freeze)."""
+        return self._request("POST", f"/documents/{_path(id)}/freeze")
+
+    def delete_documents_by_id_freeze(self, id: str) -> Document:
+        """Unfreeze a Document (DELETE /documents/{id}/freeze)."""
+        return self._request("DELETE", f"/documents/{_path(id)}/freeze")
+
+    def post_documents_by_id_lint(self, id: str, body: Optional[LintDocumentRequest] = None) -> LintDocumentResponse:
+        """Lint and Normalize a Document (POST /documents/{id}/lint)."""
+        return self._request("POST", f"/documents/{_path(id)}/lint", body=body)
+
+    def get_documents_by_id_reviews(self, id: str) -> DocumentReviewsResponse:
+        """List a Document's Reviews (GET /documents/{id}/reviews)."""
+        return self._request("GET", f"/documents/{_path(id)}/reviews")
+
+    def post_documents_by_id_reviews(self, id: str, body: AssignReviewRequest) -> Review:
+        """Assign a Reviewer (POST /documents/{id}/reviews)."""
+        return self._request("POST", f"/documents/{_path(id)}/reviews", body=body)
+
+    def get_documents_by_id_shares(self, id: str) -> GetSharersResponse:
+        """See Who a Document is Shared With (GET /documents/{id}/shares)."""
+        return self._request("GET", f"/documents/{_path(id)}/shares")
+
+    def put_documents_by_id_shares(self, id: str, body: SetSharersRequest) -> None:
+        """Set/Replace Who a Document is Shared With (PUT /documents/{id}/shares)."""
+        return self._request("PUT", f"/documents/{_path(id)}/shares", body=body)
+
+    def post_documents_by_id_shares_email(self, id: str, body: ShareByEmailRequest) -> ShareByEmailResponse:
+        """Share a Document by Email (POST /documents/{id}/shares/email)."""
+        return self._request("POST", f"/documents/{_path(id)}/shares/email", body=body)
+
+    def delete_documents_by_id_shares_email_by_email(self, id: str, email: str) -> None:
+        """Withdraw a Pending Share (DELETE /documents/{id}/shares/email/{email})."""
+        return self._request("DELETE", f"/documents/{_path(id)}/shares/email/{_path(email)}")
+
+    def put_documents_by_id_shares_by_profile_id(self, id: str, profile_id: str) -> None:
+        """Share a Document with One User (PUT /documents/{id}/shares/{profile_id})."""
+        return self._request("PUT", f"/documents/{_path(id)}/shares/{_path(profile_id)}")
+
+    def delete_documents_by_id_shares_by_profile_id(self, id: str, profile_id: str) -> None:
+        """Stop Sharing a Document with One User (DELETE /documents/{id}/shares/{profile_id})."""
+        return self._request("DELETE", f"/documents/{_path(id)}/shares/{_path(profile_id)}")
+
+    def put_documents_by_id_shares_by_profile_id_scope(self, id: str, profile_id: str, body: ShareScopeRequest) -> ShareScopeResponse:
+        """Share Only Part of a Document (PUT /documents/{id}/shares/{profile_id}/scope)."""
+        return self._request("PUT", f"/documents/{_path(id)}/shares/{_path(profile_id)}/scope", body=body)
+
+    def delete_documents_by_id_shares_by_profile_id_scope(self, id: str, profile_id: str) -> None:
+        """Share the Whole Document Again (DELETE /documents/{id}/shares/{profile_id}/scope)."""
+        return self._request("DELETE", f"/documents/{_path(id)}/shares/{_path(profile_id)}/scope")
+
+    def post_documents_by_id_signed_url(self, id: str, *, op: Optional[Literal["read", "write"]] = None, ttl: Optional[str] = None) -> SignedUrlResponse:
+        """Create a Signed URL (POST /documents/{id}/signed-url)."""
+        return self._request("POST", f"/documents/{_path(id)}/signed-url", query={"op": op, "ttl": ttl})
+
+    def get_documents_by_id_stats(self, id: str, *, last: Optional[int] = None) -> DocumentStats:
+        """Get a Document's Statistics (GET /documents/{id}/stats)."""
+        return self._request("GET", f"/documents/{_path(id)}/stats", query={"last": last})
+
+    def post_documents_by_id_workflow(self, id: str, body: WorkflowTransitionRequest) -> Document:
+        """Submit, Approve or Reject a Document (POST /documents/{id}/workflow)."""
+        return self._request("POST", f"/documents/{_path(id)}/workflow", body=body)
+
+    def get_graph(self) -> LinkGraph:
+        """Get the Link Graph (GET /graph)."""
+        return self._request("GET", "/graph")
+
+    def get_limits(self) -> LimitsResponse:
+        """Get Your Limits (GET /limits)."""
+        return self._request("GET", "/limits")
+
+    def get_mock_documents(self, *, n: Optional[int] = None, shape: Optional[str] = None, seed: Optional[int] = None) -> List[Document]:
+        """Generate Mock Documents (Debug Mode) (GET /mock/documents)."""
+        return self._request("GET", "/mock/documents", query={"n": n, "shape": shape, "seed": seed})
+
+    def get_notification_channels(self) -> List[NotificationChannel]:
+        """List Your Notification Channels (GET /notification-channels)."""
+        return self._request("GET", "/notification-channels")
+
+    def post_notification_channels(self, body: NotificationChannelRequest) -> NotificationChannel:
+        """Add a Notification Channel (POST /notification-channels)."""
+        return self._request("POST", "/notification-channels", body=body)
+
+    def get_notification_channels_by_id(self, id: str) -> NotificationChannel:
+        """Get a Notification Channel (GET /notification-channels/{id})."""
+        return self._request("GET", f"/notification-channels/{_path(id)}")
+
+    def put_notification_channels_by_id(self, id: str, body: NotificationChannelRequest) -> NotificationChannel:
+        """Replace a Notification Channel (PUT /notification-channels/{id})."""
+        return self._request("PUT", f"/notification-channels/{_path(id)}", body=body)
+
+    def delete_notification_channels_by_id(self, id: str) -> None:
+        """Delete a Notification Channel (DELETE /notification-channels/{id})."""
+        return self._request("DELETE", f"/notification-channels/{_path(id)}")
+
+    def post_notification_channels_by_id_test(self, id: str) -> None:
+        """Send a Test Message (POST /notification-channels/{id}/test)."""
+        return self._request("POST", f"/notification-channels/{_path(id)}/test")
+
+    def get_profiles(self, *, email: Optional[str] = None, first_name: Optional[str] = None, last_name: Optional[str] = None, created_after: Optional[str] = None, created_before: Optional[str] = None, ids: Optional[str] = None, sort_by: Optional[Literal["email", "name", "creation_date"]] = None, order: Optional[Literal["asc", "desc"]] = None, page: Optional[int] = None, limit: Optional[int] = None) -> Page:
+        """Search User Profiles (GET /profiles)."""
+        return self._request("GET", "/profiles", query={"email": email, "first_name": first_name, "last_name": last_name, "created_after": created_after, "created_before": created_before, "ids": ids, "sort_by": sort_by, "order": order, "page": page, "limit": limit}, page=True)
+
+    def get_profiles_me(self) -> ProfileResponse:
+        """Get Your Own Profile (GET /profiles/me)."""
+        return self._request("GET", "/profiles/me")
+
+    def put_profiles_me(self, body: UpdateProfileRequest) -> ProfileResponse:
+        """Update Your Own Profile (PUT /profiles/me)."""
+        return self._request("PUT", "/profiles/me", body=body)
+
+    def delete_profiles_me(self) -> None:
+        """Delete Your Own Profile (DELETE /profiles/me)."""
+        return self._request("DELETE", "/profiles/me")
+
+    def post_profiles_me_accept_tos(self, body: Optional[AcceptTosRequest] = None) -> TosStatus:
+        """Accept the Terms of Service (POST /profiles/me/accept-tos)."""
+        return self._request("POST", "/profiles/me/accept-tos", body=body)
+
+    def post_profiles_me_deactivate(self, body: Optional[DeactivateRequest] = None) -> Deactivation:
+        """Deactivate Your Account (POST /profiles/me/deactivate)."""
+        return self._request("POST", "/profiles/me/deactivate", body=body)
+
+    def get_profiles_me_devices(self) -> List[DeviceResponse]:
+        """List Your Devices (GET /profiles/me/devices)."""
+        return self._request("GET", "/profiles/me/devices")
+
+    def delete_profiles_me_devices_by_device_id(self, device_id: str) -> None:
+        """Revoke a Device (DELETE /profiles/me/devices/{device_id})."""
+        return self._request("DELETE", f"/profiles/me/devices/{_path(device_id)}")
+
+    def post_profiles_me_email_change(self, body: EmailChangeRequest) -> EmailChangeResponse:
+        """Request an Email Change (POST /profiles/me/email-change)."""
+        return self._request("POST", "/profiles/me/email-change", body=body)
+
+    def post_profiles_me_email_change_confirm(self, body: ConfirmEmailChangeRequest) -> ProfileResponse:
+        """Confirm an Email Change (POST /profiles/me/email-change/confirm)."""
+        return self._request("POST", "/profiles/me/email-change/confirm", body=body)
+
+    def get_profiles_me_erase(self) -> ErasureRequest:
+        """Check Your Erasure Request (GET /profiles/me/erase)."""
+        return self._request("GET", "/profiles/me/erase")
+
+    def post_profiles_me_erase(self) -> ErasureRequest:
+        """Request Erasure of Your Data (POST /profiles/me/erase)."""
+        return self._request("POST", "/profiles/me/erase")
+
+    def delete_profiles_me_erase(self) -> None:
+        """Cancel Your Erasure Request (DELETE /profiles/me/erase)."""
+        return self._request("DELETE", "/profiles/me/erase")
+
+    def get_profiles_me_export(self) -> ExportResponse:
+        """Export Your Data (GET /profiles/me/export)."""
+        return self._request("GET", "/profiles/me/export")
+
+    def get_profiles_me_stats(self) -> ProfileStats:
+        """Get Your Statistics (GET /profiles/me/stats)."""
+        return self._request("GET", "/profiles/me/stats")
+
+    def get_profiles_me_usage_api(self) -> ApiUsageReport:
+        """Get Your API Usage (GET /profiles/me/usage/api)."""
+        return self._request("GET", "/profiles/me/usage/api")
+
+    def post_profiles_resolve(self, body: ResolveProfilesRequest) -> ResolveProfilesResponse:
+        """Resolve Profile IDs (POST /profiles/resolve)."""
+        return self._request("POST", "/profiles/resolve", body=body)
+
+    def get_public_documents(self, *, content_query: Optional[List[str]] = None, missing: Optional[Literal["skip", "false", "error"]] = None, include: Optional[str] = None, sort_by: Optional[Literal["creation_date", "last_modified_date"]] = None, order: Optional[Literal["asc", "desc"]] = None, page: Optional[int] = None, limit: Optional[int] = None) -> Page:
+        """List Public Documents (No Login Needed) (GET /public/documents)."""
+        return self._request("GET", "/public/documents", query={"content_query": content_query, "missing": missing, "include": include, "sort_by": sort_by, "order": order, "page": page, "limit": limit}, page=True)
+
+    def get_public_documents_by_id(self, id: str) -> Document:
+        """Get a Public Document (No Login Needed) (GET /public/documents/{id})."""
+        return self._request("GET", f"/public/documents/{_path(id)}")
+
+    def get_query_operators(self) -> QueryOperatorsResponse:
+        """List the Query Operators (GET /query/operators)."""
+        return self._request("GET", "/query/operators")
+
+    def post_query_validate(self, body: ValidateQueryRequest) -> QueryValidation:
+        """Validate a Content Query (POST /query/validate)."""
+        return self._request("POST", "/query/validate", body=body)
+
+    def get_reviews(self, *, status: Optional[str] = None) -> List[Review]:
+        """List Your Reviews (GET /reviews)."""
+        return self._request("GET", "/reviews", query={"status": status})
+
+    def get_reviews_by_id(self, id: str) -> Review:
+        """Get a Review (GET /reviews/{id})."""
+        return self._request("GET", f"/reviews/{_path(id)}")
+
+    def put_reviews_by_id(self, id: str, body: SubmitReviewRequest) -> Review:
+        """Submit Review Feedback (PUT /reviews/{id})."""
+        return self._request("PUT", f"/reviews/{_path(id)}", body=body)
+
+    def delete_reviews_by_id(self, id: str) -> None:
+        """Remove a Reviewer (DELETE /reviews/{id})."""
+        return self._request("DELETE", f"/reviews/{_path(id)}")
+
+    def get_schedules(self) -> List[Schedule]:
+        """List Your Scheduled Exports (GET /schedules)."""
+        return self._request("GET", "/schedules")
+
+    def post_schedules(self, body: ScheduleRequest) -> Schedule:
+        """Schedule a Recurring Export (POST /schedules)."""
+        return self._request("POST", "/schedules", body=body)
+
+    def get_schedules_by_id(self, id: str) -> Schedule:
+        """Get a Scheduled Export (GET /schedules/{id})."""
+        return self._request("GET", f"/schedules/{_path(id)}")
+
+    def put_schedules_by_id(self, id: str, body: ScheduleRequest) -> Schedule:
+        """Replace a Scheduled Export (PUT /schedules/{id})."""
+        return self._request("PUT", f"/schedules/{_path(id)}", body=body)
+
+    def delete_schedules_by_id(self, id: str) -> None:
+        """Delete a Scheduled Export (DELETE /schedules/{id})."""
+        return self._request("DELETE", f"/schedules/{_path(id)}")
+
+    def post_schedules_by_id_run(self, id: str) -> ScheduleRun:
+        """Run a Scheduled Export Now (POST /schedules/{id}/run)."""
+        return self._request("POST", f"/schedules/{_path(id)}/run")
+
+    def get_schedules_by_id_runs(self, id: str) -> List[ScheduleRun]:
+        """List the Runs of a Scheduled Export (GET /schedules/{id}/runs)."""
+        return self._request("GET", f"/schedules/{_path(id)}/runs")
+
+    def get_schedules_by_id_runs_by_run_id_download(self, id: str, run_id: str) -> ScheduleExport:
+        """Download a Scheduled Export (GET /schedules/{id}/runs/{run_id}/download)."""
+        return self._request("GET", f"/schedules/{_path(id)}/runs/{_path(run_id)}/download")
+
+    def get_sdk_by_language(self, language: str) -> str:
+        """Download a Client Library (GET /sdk/{language})."""
+        return self._request("GET", f"/sdk/{_path(language)}")
+
+    def get_signed_by_token(self, token: str, *, render: Optional[Literal["html"]] = None) -> DocumentResponse:
+        """Read a Document Through a Signed URL (GET /signed/{token})."""
+        return self._request("GET", f"/signed/{_path(token)}", query={"render": render})
+
+    def put_signed_by_token(self, token: str, body: UpdateDocumentRequest, *, sync: Optional[bool] = None) -> Document:
+        """Update a Document Through a Signed URL (PUT /signed/{token})."""
+        return self._request("PUT", f"/signed/{_path(token)}", query={"sync": sync}, body=body)
+
+    def get_status(self) -> StatusResponse:
+        """Get Server Status (GET /status)."""
+        return self._request("GET", "/status")
+
+    def get_version(self) -> VersionResponse:
+        """Get Server Version (GET /version)."""
+        return self._request("GET", "/version")
@@ -0,0 +1,1929 @@
+// DocServer API client, generated from the server's Swagger spec by "go generate ./sdk". Do not edit.
+//
+//   import { DocServerClient } from "./docserver_client";
+//
+//   const client = new DocServerClient();
+//   client.token = (await client.postAuthLogin({ email: "ada@example.com", password: "secret123" })).token;
+//   console.log((await client.getDocuments({ limit: 5 })).data);
+//
+// Methods resolve to the data of the response, and lists to the page with its data, meta and
+// links. Error responses reject with a DocServerError. Uses fetch (browsers, Node.js 18 or later).
+
+export const BASE_URL = "__DOCSERVER_BASE_URL__";
+
+/** An error response of the API. */
+export class DocServerError extends Error {
+  readonly status: number;
+  readonly code: string;
+
+  constructor(status: number, code: string, message: string) {
+    super(`${status} ${code}: ${message}`);
+    this.name = "DocServerError";
+    this.status = status;
+    this.code = code;
+  }
+}
+
+/** Pagination state of a list. */
+export interface PageMeta {
+  total: number;
+  page: number;
+  limit: number;
+  total_pages: number;
+}
+
+/** Links to the pages of a list; next and prev are left out when there is no such page. */
+export interface PageLinks {
+  self: string;
+  first: string;
+  last: string;
+  next?: string;
+  prev?: string;
+}
+
+/** One page of a list. */
+export interface Page<T> {
+  data: T[];
+  meta: PageMeta;
+  links: PageLinks;
+}
+
+/** api.AcceptTosRequest of the API. */
+export interface AcceptTosRequest {
+  version?: string;
+}
+
+/** api.AssignReviewRequest of the API. */
+export interface AssignReviewRequest {
+  reviewer_id: string;
+}
+
+/** api.CalendarFeedResponse of the API. */
+export interface CalendarFeedResponse {
+  token?: string;
+  url?: string;
+}
+
+/** api.ChallengeResponse of the API. */
+export interface ChallengeResponse {
+  challenge?: string;
+  difficulty?: number;
+  expires_at?: string;
+  provider?: string;
+  site_key?: string;
+}
+
+/** api.ChaosSettings of the API. */
+export interface ChaosSettings {
+  drop_rate?: number;
+  enabled?: boolean;
+  error_rate?: number;
+  jitter_ms?: number;
+  latency_ms?: number;
+  routes?: string[];
+}
+
+/** api.ConfirmEmailChangeRequest of the API. */
+export interface ConfirmEmailChangeRequest {
+  token: string;
+}
+
+/** api.ContentPathResponse of the API. */
+export interface ContentPathResponse {
+  document_id?: string;
+  path?: string;
+  previous?: unknown;
+  value?: unknown;
+  version?: number;
+}
+
+/** api.CreateDocumentRequest of the API. */
+export interface CreateDocumentRequest {
+  content: unknown;
+  content_type?: string;
+  expires_at?: string;
+  id?: string;
+  key?: string;
+  links?: string[];
+  public?: boolean;
+}
+
+/** api.DeactivateRequest of the API. */
+export interface DeactivateRequest {
+  reactivate_at?: string;
+}
+
+/** api.DeactivatedProfile of the API. */
+export interface DeactivatedProfile {
+  deactivation?: Deactivation;
+  email?: string;
+  first_name?: string;
+  id?: string;
+  last_name?: string;
+}
+
+/** api.DeviceResponse of the API. */
+export interface DeviceResponse {
+  current?: boolean;
+  first_seen?: string;
+  id?: string;
+  ip_address?: string;
+  last_seen?: string;
+  logins?: number;
+  revoked_at?: string;
+  user_agent?: string;
+}
+
+/** api.DiffDocumentRequest of the API. */
+export interface DiffDocumentRequest {
+  content: unknown;
+}
+
+/** api.DocumentDiffResponse of the API. */
+export interface DocumentDiffResponse {
+  added?: DiffEntry[];
+  changed?: DiffEntry[];
+  document_id?: string;
+  from_version?: number;
+  removed?: DiffEntry[];
+  to_version?: number;
+}
+
+/** api.DocumentExpiryRequest of the API. */
+export interface DocumentExpiryRequest {
+  expires_at: string;
+}
+
+/** api.DocumentListItem of the API. */
+export interface DocumentListItem {
+  content?: unknown;
+  content_type?: string;
+  creation_date?: string;
+  expires_at?: string;
+  favorite?: boolean;
+  frozen?: boolean;
+  frozen_at?: string;
+  frozen_by?: string;
+  id?: string;
+  last_modified_date?: string;
+  links?: string[];
+  owner?: ProfileSummary;
+  owner_id?: string;
+  public?: boolean;
+  revision?: number;
+  shared_with?: ProfileSummary[];
+  ttl_seconds?: number;
+  version?: number;
+  workflow?: DocumentWorkflow;
+}
+
+/** api.DocumentResponse of the API. */
+export interface DocumentResponse {
+  content?: unknown;
+  content_type?: string;
+  creation_date?: string;
+  expires_at?: string;
+  frozen?: boolean;
+  frozen_at?: string;
+  frozen_by?: string;
+  id?: string;
+  last_modified_date?: string;
+  links?: string[];
+  owner?: ProfileSummary;
+  owner_id?: string;
+  public?: boolean;
+  revision?: number;
+  shared_with?: ProfileSummary[];
+  ttl_seconds?: number;
+  version?: number;
+  workflow?: DocumentWorkflow;
+}
+
+/** api.DocumentReviewsResponse of the API. */
+export interface DocumentReviewsResponse {
+  reviews?: Review[];
+  summary?: ReviewSummary;
+}
+
+/** api.DuplicateClusterResponse of the API. */
+export interface DuplicateClusterResponse {
+  documents?: GraphNode[];
+  pairs?: DuplicatePair[];
+  similarity?: number;
+}
+
+/** api.EmailChangeRequest of the API. */
+export interface EmailChangeRequest {
+  new_email: string;
+  password: string;
+}
+
+/** api.EmailChangeResponse of the API. */
+export interface EmailChangeResponse {
+  expires_at?: string;
+  new_email?: string;
+}
+
+/** api.ExportManifest of the API. */
+export interface ExportManifest {
+  format?: string;
+  generated_at?: string;
+  profile_id?: string;
+  sections?: ExportSection[];
+  version?: number;
+}
+
+/** api.ExportResponse of the API. */
+export interface ExportResponse {
+  documents?: Document[];
+  erasure?: ErasureRequest;
+  favorites?: string[];
+  manifest?: ExportManifest;
+  profile?: ProfileResponse;
+  shared_with_me?: string[];
+  shares?: ExportShare[];
+}
+
+/** api.ExportSection of the API. */
+export interface ExportSection {
+  count?: number;
+  name?: string;
+  sha256?: string;
+}
+
+/** api.ExportShare of the API. */
+export interface ExportShare {
+  document_id?: string;
+  shared_with?: string[];
+}
+
+/** api.ExtraPolicyRequest of the API. */
+export interface ExtraPolicyRequest {
+  max_bytes?: number;
+  reject_sensitive?: boolean;
+  schema?: unknown;
+  sensitive_keys?: string[];
+}
+
+/** api.FavoriteResponse of the API. */
+export interface FavoriteResponse {
+  document_id?: string;
+  favorite?: boolean;
+}
+
+/** api.FetchDocumentRequest of the API. */
+export interface FetchDocumentRequest {
+  public?: boolean;
+  url: string;
+}
+
+/** api.ForgotPasswordRequest of the API. */
+export interface ForgotPasswordRequest {
+  challenge?: string;
+  email: string;
+}
+
+/** api.GenerateLoadResponse of the API. */
+export interface GenerateLoadResponse {
+  documents?: number;
+  duration_ms?: number;
+  shared_with_you?: number;
+  user_ids?: string[];
+  users?: number;
+}
+
+/** api.GetSharersResponse of the API. */
+export interface GetSharersResponse {
+  pending_emails?: string[];
+  scopes?: Record<string, ShareScope>;
+  shared_with?: string[];
+}
+
+/** api.GraphEdge of the API. */
+export interface GraphEdge {
+  from?: string;
+  to?: string;
+}
+
+/** api.GraphNode of the API. */
+export interface GraphNode {
+  id?: string;
+  owner_id?: string;
+  title?: string;
+}
+
+/** api.IncrementRequest of the API. */
+export interface IncrementRequest {
+  delta?: number;
+}
+
+/** api.IntrospectResponse of the API. */
+export interface IntrospectResponse {
+  active?: boolean;
+  admin?: boolean;
+  aud?: string[];
+  device_id?: string;
+  documents?: string[];
+  exp?: number;
+  iat?: number;
+  iss?: string;
+  nbf?: number;
+  scope?: string;
+  sub?: string;
+  token_type?: string;
+  username?: string;
+}
+
+/** api.InviteRequest of the API. */
+export interface InviteRequest {
+  expires_at?: string;
+  max_uses?: number;
+  note?: string;
+}
+
+/** api.LimitsResponse of the API. */
+export interface LimitsResponse {
+  archive_max_bytes?: number;
+  fetch_max_bytes?: number;
+  max_body_bytes?: number;
+  max_page_size?: number;
+  rate_limits?: RateLimitInfo[];
+}
+
+/** api.LinkGraph of the API. */
+export interface LinkGraph {
+  edges?: GraphEdge[];
+  nodes?: GraphNode[];
+}
+
+/** api.LintDocumentRequest of the API. */
+export interface LintDocumentRequest {
+  apply?: boolean;
+  normalize?: string[];
+  rules?: LintRules;
+  version?: number;
+}
+
+/** api.LintDocumentResponse of the API. */
+export interface LintDocumentResponse {
+  applied?: boolean;
+  changes?: JsonDiff;
+  document_id?: string;
+  issues?: LintIssue[];
+  normalized?: unknown;
+  version?: number;
+}
+
+/** api.LoginRequest of the API. */
+export interface LoginRequest {
+  email: string;
+  password: string;
+}
+
+/** api.LoginResponse of the API. */
+export interface LoginResponse {
+  token?: string;
+  tos?: TosStatus;
+}
+
+/** api.MaintenanceRequest of the API. */
+export interface MaintenanceRequest {
+  enabled: boolean;
+  message?: string;
+  retry_after_seconds?: number;
+  routes?: string[];
+}
+
+/** api.NotificationChannelRequest of the API. */
+export interface NotificationChannelRequest {
+  all_users?: boolean;
+  enabled?: boolean;
+  events?: string[];
+  kind: string;
+  max_per_hour?: number;
+  name: string;
+  webhook_url: string;
+}
+
+/** api.ProfileAPIUsage of the API. */
+export interface ProfileApiUsage {
+  email?: string;
+  errors?: number;
+  first_name?: string;
+  group?: string;
+  last_name?: string;
+  last_request_at?: string;
+  profile_id?: string;
+  request_bytes?: number;
+  requests?: number;
+  response_bytes?: number;
+  routes?: ApiRouteUsage[];
+}
+
+/** api.ProfileResponse of the API. */
+export interface ProfileResponse {
+  creation_date?: string;
+  email?: string;
+  extra?: unknown;
+  first_name?: string;
+  id?: string;
+  last_modified_date?: string;
+  last_name?: string;
+  phone?: string;
+  privacy?: ProfilePrivacy;
+  tos_acceptance?: TosAcceptance;
+}
+
+/** api.ProfileSummary of the API. */
+export interface ProfileSummary {
+  first_name?: string;
+  id?: string;
+  last_name?: string;
+}
+
+/** api.ProvisionAccount of the API. */
+export interface ProvisionAccount {
+  email?: string;
+  first_name?: string;
+  group?: string;
+  last_name?: string;
+}
+
+/** api.ProvisionReport of the API. */
+export interface ProvisionReport {
+  accounts?: ProvisionResult[];
+  created?: number;
+  delivery?: string;
+  duplicates?: number;
+  existing?: number;
+  invalid?: number;
+}
+
+/** api.ProvisionRequest of the API. */
+export interface ProvisionRequest {
+  accounts: ProvisionAccount[];
+  delivery?: string;
+  group?: string;
+}
+
+/** api.ProvisionResult of the API. */
+export interface ProvisionResult {
+  email?: string;
+  error?: string;
+  group?: string;
+  profile_id?: string;
+  row?: number;
+  status?: string;
+  temporary_password?: string;
+}
+
+/** api.QueryOperatorsResponse of the API. */
+export interface QueryOperatorsResponse {
+  insensitive_suffix?: string;
+  length_suffix?: string;
+  logical?: string[];
+  negation?: string;
+  operators?: QueryOperator[];
+}
+
+/** api.RateLimitInfo of the API. */
+export interface RateLimitInfo {
+  limit?: number;
+  remaining?: number;
+  reset_seconds?: number;
+  route?: string;
+  window_seconds?: number;
+}
+
+/** api.RecoveryResponse of the API. */
+export interface RecoveryResponse {
+  recovered?: boolean;
+  reports?: RecoveryReport[];
+}
+
+/** api.ReplaceDocumentsRequest of the API. */
+export interface ReplaceDocumentsRequest {
+  content_query?: string[];
+  dry_run?: boolean;
+  new?: unknown;
+  old?: unknown;
+  path: string;
+  regex?: string;
+}
+
+/** api.ResetPasswordRequest of the API. */
+export interface ResetPasswordRequest {
+  email: string;
+  new_password: string;
+  otp: string;
+}
+
+/** api.ResolveProfilesRequest of the API. */
+export interface ResolveProfilesRequest {
+  ids: string[];
+}
+
+/** api.ResolveProfilesResponse of the API. */
+export interface ResolveProfilesResponse {
+  missing?: string[];
+  profiles?: ProfileSummary[];
+}
+
+/** api.RestrictedTokenRequest of the API. */
+export interface RestrictedTokenRequest {
+  documents?: string[];
+  scopes: string[];
+  ttl?: string;
+}
+
+/** api.RestrictedTokenResponse of the API. */
+export interface RestrictedTokenResponse {
+  documents?: string[];
+  expires_at?: string;
+  scopes?: string[];
+  token?: string;
+}
+
+/** api.ScheduleRequest of the API. */
+export interface ScheduleRequest {
+  content_query?: string[];
+  enabled?: boolean;
+  interval: string;
+  name: string;
+  scope?: string;
+  target?: string;
+  webhook_url?: string;
+}
+
+/** api.ScriptRequest of the API. */
+export interface ScriptRequest {
+  enabled?: boolean;
+  event: string;
+  name: string;
+  source: string;
+}
+
+/** api.ServiceAccountRequest of the API. */
+export interface ServiceAccountRequest {
+  name: string;
+  scopes: string[];
+}
+
+/** api.ServiceAccountResponse of the API. */
+export interface ServiceAccountResponse {
+  created_by?: string;
+  creation_date?: string;
+  email?: string;
+  id?: string;
+  name?: string;
+  scopes?: string[];
+}
+
+/** api.ServiceAccountScopesRequest of the API. */
+export interface ServiceAccountScopesRequest {
+  scopes: string[];
+}
+
+/** api.ServiceTokenRequest of the API. */
+export interface ServiceTokenRequest {
+  scopes?: string[];
+  ttl?: string;
+}
+
+/** api.ServiceTokenResponse of the API. */
+export interface ServiceTokenResponse {
+  expires_at?: string;
+  scopes?: string[];
+  token?: string;
+}
+
+/** api.SetSharersRequest of the API. */
+export interface SetSharersRequest {
+  shared_with: string[];
+}
+
+/** api.ShareByEmailRequest of the API. */
+export interface ShareByEmailRequest {
+  email: string;
+}
+
+/** api.ShareByEmailResponse of the API. */
+export interface ShareByEmailResponse {
+  email?: string;
+  profile_id?: string;
+  status?: string;
+}
+
+/** api.ShareScopeRequest of the API. */
+export interface ShareScopeRequest {
+  path: string;
+  write?: boolean;
+}
+
+/** api.ShareScopeResponse of the API. */
+export interface ShareScopeResponse {
+  path?: string;
+  profile_id?: string;
+  write?: boolean;
+}
+
+/** api.SignedURLResponse of the API. */
+export interface SignedUrlResponse {
+  expires_at?: string;
+  op?: string;
+  token?: string;
+  url?: string;
+}
+
+/** api.SignupRequest of the API. */
+export interface SignupRequest {
+  accept_tos?: boolean;
+  challenge?: string;
+  email: string;
+  extra?: unknown;
+  first_name: string;
+  invite_code?: string;
+  last_name: string;
+  password: string;
+}
+
+/** api.SignupResponse of the API. */
+export interface SignupResponse {
+  creation_date?: string;
+  email?: string;
+  extra?: unknown;
+  first_name?: string;
+  id?: string;
+  last_modified_date?: string;
+  last_name?: string;
+  tos?: TosStatus;
+}
+
+/** api.StatusResponse of the API. */
+export interface StatusResponse {
+  api_version?: string;
+  build_date?: string;
+  commit?: string;
+  ephemeral?: boolean;
+  maintenance?: Maintenance;
+  persistence?: PersistenceStatus;
+  read_only?: boolean;
+  uptime_seconds?: number;
+  version?: string;
+}
+
+/** api.SubmitReviewRequest of the API. */
+export interface SubmitReviewRequest {
+  comment?: string;
+  criteria?: Record<string, number>;
+  rating: number;
+}
+
+/** api.TosStatus of the API. */
+export interface TosStatus {
+  accepted?: boolean;
+  accepted_at?: string;
+  accepted_version?: string;
+  url?: string;
+  version?: string;
+}
+
+/** api.UpdateDocumentRequest of the API. */
+export interface UpdateDocumentRequest {
+  content: unknown;
+  content_type?: string;
+  links?: string[];
+  public?: boolean;
+}
+
+/** api.UpdateProfileRequest of the API. */
+export interface UpdateProfileRequest {
+  extra?: unknown;
+  first_name: string;
+  last_name: string;
+  phone?: string;
+  privacy?: ProfilePrivacy;
+}
+
+/** api.ValidateQueryRequest of the API. */
+export interface ValidateQueryRequest {
+  content_query?: string[];
+}
+
+/** api.VersionResponse of the API. */
+export interface VersionResponse {
+  api_version?: string;
+  build_date?: string;
+  commit?: string;
+  supported_api_versions?: string[];
+  version?: string;
+}
+
+/** api.WorkflowTransitionRequest of the API. */
+export interface WorkflowTransitionRequest {
+  comment?: string;
+  reviewer_id?: string;
+  state: string;
+}
+
+/** db.APIRouteUsage of the API. */
+export interface ApiRouteUsage {
+  errors?: number;
+  last_request_at?: string;
+  request_bytes?: number;
+  requests?: number;
+  response_bytes?: number;
+  route?: string;
+}
+
+/** db.APIUsageReport of the API. */
+export interface ApiUsageReport {
+  errors?: number;
+  last_request_at?: string;
+  profile_id?: string;
+  request_bytes?: number;
+  requests?: number;
+  response_bytes?: number;
+  routes?: ApiRouteUsage[];
+}
+
+/** db.Dashboard of the API. */
+export interface Dashboard {
+  generated_at?: string;
+  queries?: DashboardQueries;
+  recent_activity?: DashboardActivity[];
+  storage?: DashboardStorage;
+  users?: DashboardUser[];
+}
+
+/** db.DashboardActivity of the API. */
+export interface DashboardActivity {
+  actor_id?: string;
+  changed_paths?: string[];
+  document_id?: string;
+  profile_ids?: string[];
+  timestamp?: string;
+  type?: string;
+  version?: number;
+}
+
+/** db.DashboardQueries of the API. */
+export interface DashboardQueries {
+  api_errors?: number;
+  api_requests?: number;
+  cache_hits?: number;
+  cache_misses?: number;
+  content_queries?: number;
+  document_queries?: number;
+  slow_queries?: number;
+}
+
+/** db.DashboardStorage of the API. */
+export interface DashboardStorage {
+  content_bytes?: number;
+  documents?: number;
+  profiles?: number;
+  version_bytes?: number;
+  versions?: number;
+}
+
+/** db.DashboardUser of the API. */
+export interface DashboardUser {
+  content_bytes?: number;
+  documents?: number;
+  email?: string;
+  first_name?: string;
+  last_activity_at?: string;
+  last_name?: string;
+  profile_id?: string;
+  public_documents?: number;
+  requests?: number;
+  shared_documents?: number;
+}
+
+/** db.DuplicatePair of the API. */
+export interface DuplicatePair {
+  a?: string;
+  b?: string;
+  similarity?: number;
+}
+
+/** db.Orphan of the API. */
+export interface Orphan {
+  collection?: string;
+  key?: string;
+  missing_id?: string;
+  missing_type?: string;
+}
+
+/** db.OrphanReport of the API. */
+export interface OrphanReport {
+  counts?: Record<string, number>;
+  orphans?: Orphan[];
+}
+
+/** db.PasswordHashGroup of the API. */
+export interface PasswordHashGroup {
+  accounts?: number;
+  current?: boolean;
+  parameters?: string;
+}
+
+/** db.PasswordHashReport of the API. */
+export interface PasswordHashReport {
+  accounts?: number;
+  configured?: string;
+  current?: number;
+  groups?: PasswordHashGroup[];
+  outdated?: number;
+  rehashed?: number;
+  unrecognized?: number;
+}
+
+/** db.PersistenceStatus of the API. */
+export interface PersistenceStatus {
+  healthy?: boolean;
+  last_failure_at?: string;
+  last_saved_at?: string;
+  save_pending?: boolean;
+}
+
+/** db.QueryCacheStats of the API. */
+export interface QueryCacheStats {
+  capacity?: number;
+  hits?: number;
+  misses?: number;
+  size?: number;
+}
+
+/** db.QueryDiagnostic of the API. */
+export interface QueryDiagnostic {
+  code?: string;
+  index?: number;
+  message?: string;
+  severity?: string;
+  suggestion?: string;
+}
+
+/** db.QueryOperator of the API. */
+export interface QueryOperator {
+  description?: string;
+  example?: string;
+  insensitive?: boolean;
+  name?: string;
+  plain_text?: boolean;
+  target_types?: string[];
+  value_types?: string[];
+}
+
+/** db.QueryValidation of the API. */
+export interface QueryValidation {
+  diagnostics?: QueryDiagnostic[];
+  query?: string;
+  valid?: boolean;
+}
+
+/** db.RecoveryReport of the API. */
+export interface RecoveryReport {
+  file?: string;
+  quarantined_as?: string;
+  reason?: string;
+  recovered?: Record<string, number>;
+  recovered_at?: string;
+  source?: string;
+}
+
+/** db.ReplaceChange of the API. */
+export interface ReplaceChange {
+  document_id?: string;
+  from?: unknown;
+  to?: unknown;
+  version?: number;
+}
+
+/** db.ReplaceReport of the API. */
+export interface ReplaceReport {
+  changed?: number;
+  changes?: ReplaceChange[];
+  dry_run?: boolean;
+  frozen?: number;
+  matched?: number;
+}
+
+/** db.ReviewSummary of the API. */
+export interface ReviewSummary {
+  assigned?: number;
+  average_rating?: number;
+  criteria?: Record<string, number>;
+  pending?: number;
+  submitted?: number;
+}
+
+/** db.ScheduleExport of the API. */
+export interface ScheduleExport {
+  documents?: Document[];
+  generated_at?: string;
+  run_id?: string;
+  schedule_id?: string;
+  schedule_name?: string;
+}
+
+/** db.SlowQuery of the API. */
+export interface SlowQuery {
+  caller_id?: string;
+  content_query?: string[];
+  documents_evaluated?: number;
+  documents_matched?: number;
+  documents_scanned?: number;
+  duration_ms?: number;
+  parsed_query?: string;
+  scope?: string;
+  timestamp?: string;
+}
+
+/** models.Deactivation of the API. */
+export interface Deactivation {
+  deactivated_at?: string;
+  purge_at?: string;
+  reactivate_at?: string;
+}
+
+/** models.Document of the API. */
+export interface Document {
+  content?: unknown;
+  content_type?: string;
+  creation_date?: string;
+  expires_at?: string;
+  frozen?: boolean;
+  frozen_at?: string;
+  frozen_by?: string;
+  id?: string;
+  last_modified_date?: string;
+  links?: string[];
+  owner_id?: string;
+  public?: boolean;
+  revision?: number;
+  version?: number;
+  workflow?: DocumentWorkflow;
+}
+
+/** models.DocumentEvent of the API. */
+export interface DocumentEvent {
+  actor_id?: string;
+  changed_paths?: string[];
+  profile_ids?: string[];
+  timestamp?: string;
+  type?: string;
+  version?: number;
+}
+
+/** models.DocumentStats of the API. */
+export interface DocumentStats {
+  array_lengths?: Record<string, number>;
+  content_bytes?: number;
+  depth?: number;
+  key_count?: number;
+  modifications?: string[];
+  version?: number;
+}
+
+/** models.DocumentWorkflow of the API. */
+export interface DocumentWorkflow {
+  reviewer_id?: string;
+  state?: string;
+  transitions?: WorkflowTransition[];
+}
+
+/** models.ErasureReport of the API. */
+export interface ErasureReport {
+  api_usage_cleared?: boolean;
+  backup?: string;
+  channels_deleted?: number;
+  devices_cleared?: boolean;
+  documents_deleted?: number;
+  email_change_cleared?: boolean;
+  events_scrubbed?: number;
+  favorites_cleared?: boolean;
+  otp_cleared?: boolean;
+  profile_deleted?: boolean;
+  reviews_deleted?: number;
+  schedules_deleted?: number;
+  share_records_deleted?: number;
+  shares_revoked?: number;
+  snapshots_scrubbed?: number;
+}
+
+/** models.ErasureRequest of the API. */
+export interface ErasureRequest {
+  completed_at?: string;
+  email?: string;
+  profile_id?: string;
+  report?: ErasureReport;
+  requested_at?: string;
+  scheduled_for?: string;
+  status?: string;
+}
+
+/** models.ExtraPolicy of the API. */
+export interface ExtraPolicy {
+  changed_by?: string;
+  max_bytes?: number;
+  reject_sensitive?: boolean;
+  schema?: unknown;
+  sensitive_keys?: string[];
+  updated_at?: string;
+}
+
+/** models.Invite of the API. */
+export interface Invite {
+  code?: string;
+  created_by?: string;
+  creation_date?: string;
+  expires_at?: string;
+  last_used_at?: string;
+  max_uses?: number;
+  note?: string;
+  uses?: number;
+}
+
+/** models.Maintenance of the API. */
+export interface Maintenance {
+  changed_by?: string;
+  enabled?: boolean;
+  message?: string;
+  retry_after_seconds?: number;
+  routes?: string[];
+  since?: string;
+}
+
+/** models.NotificationChannel of the API. */
+export interface NotificationChannel {
+  all_users?: boolean;
+  creation_date?: string;
+  enabled?: boolean;
+  events?: string[];
+  id?: string;
+  kind?: string;
+  last_modified_date?: string;
+  max_per_hour?: number;
+  name?: string;
+  owner_id?: string;
+  webhook_url?: string;
+}
+
+/** models.ProfilePrivacy of the API. */
+export interface ProfilePrivacy {
+  email?: string;
+  extra?: string;
+}
+
+/** models.ProfileStats of the API. */
+export interface ProfileStats {
+  content_bytes?: number;
+  favorites?: number;
+  last_activity_at?: string;
+  last_created_at?: string;
+  last_modified_at?: string;
+  owned_documents?: number;
+  public_documents?: number;
+  shared_documents?: number;
+  shared_with_me?: number;
+}
+
+/** models.Review of the API. */
+export interface Review {
+  assigned_at?: string;
+  assigned_by?: string;
+  comment?: string;
+  criteria?: Record<string, number>;
+  document_id?: string;
+  id?: string;
+  rating?: number;
+  reviewer_id?: string;
+  status?: string;
+  submitted_at?: string;
+}
+
+/** models.Schedule of the API. */
+export interface Schedule {
+  content_query?: string[];
+  creation_date?: string;
+  enabled?: boolean;
+  id?: string;
+  interval?: string;
+  last_modified_date?: string;
+  last_run?: string;
+  name?: string;
+  next_run?: string;
+  owner_id?: string;
+  scope?: string;
+  target?: string;
+  webhook_url?: string;
+}
+
+/** models.ScheduleRun of the API. */
+export interface ScheduleRun {
+  document_count?: number;
+  documents?: Document[];
+  error?: string;
+  finished_at?: string;
+  id?: string;
+  started_at?: string;
+  status?: string;
+  target?: string;
+  trigger?: string;
+}
+
+/** models.Script of the API. */
+export interface Script {
+  created_by?: string;
+  creation_date?: string;
+  enabled?: boolean;
+  event?: string;
+  id?: string;
+  last_modified_date?: string;
+  name?: string;
+  source?: string;
+}
+
+/** models.ShareScope of the API. */
+export interface ShareScope {
+  path?: string;
+  write?: boolean;
+}
+
+/** models.TosAcceptance of the API. */
+export interface TosAcceptance {
+  accepted_at?: string;
+  version?: string;
+}
+
+/** models.WorkflowTransition of the API. */
+export interface WorkflowTransition {
+  actor_id?: string;
+  comment?: string;
+  from?: string;
+  timestamp?: string;
+  to?: string;
+  version?: number;
+}
+
+/** utils.DiffEntry of the API. */
+export interface DiffEntry {
+  from?: unknown;
+  path?: string;
+  to?: unknown;
+}
+
+/** utils.Envelope of the API. */
+export interface Envelope {
+  data?: unknown;
+  links?: UtilsPageLinks;
+  meta?: UtilsPageMeta;
+}
+
+/** utils.ErrorEnvelope of the API. */
+export interface ErrorEnvelope {
+  error?: ErrorObject;
+}
+
+/** utils.ErrorObject of the API. */
+export interface ErrorObject {
+  code?: string;
+  message?: string;
+  message_id?: string;
+  status?: number;
+}
+
+/** utils.JSONDiff of the API. */
+export interface JsonDiff {
+  added?: DiffEntry[];
+  changed?: DiffEntry[];
+  removed?: DiffEntry[];
+}
+
+/** utils.LintIssue of the API. */
+export interface LintIssue {
+  message?: string;
+  path?: string;
+  rule?: string;
+}
+
+/** utils.LintRules of the API. */
+export interface LintRules {
+  allow_mixed_dates?: boolean;
+  allow_nan_strings?: boolean;
+  max_depth?: number;
+  max_key_length?: number;
+}
+
+/** utils.PageLinks of the API. */
+export interface UtilsPageLinks {
+  first?: string;
+  last?: string;
+  next?: string;
+  prev?: string;
+  self?: string;
+}
+
+/** utils.PageMeta of the API. */
+export interface UtilsPageMeta {
+  limit?: number;
+  page?: number;
+  total?: number;
+  total_pages?: number;
+}
+
+type QueryValue = string | number | boolean | undefined;
+
+/** Calls the DocServer API at baseUrl, authenticated with token once it is set. */
+export class DocServerClient {
+  baseUrl: string;
+  token?: string;
+
+  constructor(baseUrl: string = BASE_URL, token?: string) {
+    this.baseUrl = baseUrl.replace(/\/+$/, "");
+    this.token = token;
+  }
+
+  private async request<T>(method: string, path: string, query?: Record<string, QueryValue | QueryValue[]>, body?: unknown, page = false): Promise<T> {
+    const params = new URLSearchParams();
+    for (const [key, value] of Object.entries(query ?? {})) {
+      for (const item of Array.isArray(value) ? value : [value]) {
+        if (item !== undefined) params.append(key, String(item));
+      }
+    }
+    const search = params.toString();
+    const headers: Record<string, string> = { Accept: "application/json" };
+    if (body !== undefined) headers["Content-Type"] = "application/json";
+    if (this.token) headers["Authorization"] = `Bearer ${this.token}`;
+    const response = await fetch(this.baseUrl + path + (search ? "?" + search : ""), {
+      method,
+      headers,
+      body: body === undefined ? undefined : JSON.stringify(body),
+    });
+    const text = await response.text();
+    if (!response.ok) {
+      let error: { status?: number; code?: string; message?: string } = {};
+      try {
+        error = JSON.parse(text).error ?? {};
+      } catch {
+        // Not a JSON error: the text is the message
+      }
+      throw new DocServerError(error.status ?? response.status, error.code ?? "", error.message ?? text);
+    }
+    if (!text) return undefined as T;
+    const envelope = JSON.parse(text);
+    return (page ? envelope : envelope.data) as T;
+  }
+
+  /** Get Chaos Settings (Admin, Debug Mode) (GET /admin/chaos). */
+  getAdminChaos(): Promise<ChaosSettings> {
+    return this.request("GET", "/admin/chaos");
+  }
+
+  /** Switch Chaos Mode On (Admin, Debug Mode) (PUT /admin/chaos). */
+  putAdminChaos(body: ChaosSettings): Promise<ChaosSettings> {
+    return this.request("PUT", "/admin/chaos", undefined, body);
+  }
+
+  /** Switch Chaos Mode Off (Admin, Debug Mode) (DELETE /admin/chaos). */
+  deleteAdminChaos(): Promise<void> {
+    return this.request("DELETE", "/admin/chaos");
+  }
+
+  /** Get the Dashboard (Admin) (GET /admin/dashboard). */
+  getAdminDashboard(query: { limit?: number } = {}): Promise<Dashboard> {
+    return this.request("GET", "/admin/dashboard", query);
+  }
+
+  /** Generate Load (Admin) (POST /admin/generate-load). */
+  postAdminGenerateLoad(query: { docs?: number; users?: number; seed?: number } = {}): Promise<GenerateLoadResponse> {
+    return this.request("POST", "/admin/generate-load", query);
+  }
+
+  /** List Invitation Codes (Admin) (GET /admin/invites). */
+  getAdminInvites(): Promise<Invite[]> {
+    return this.request("GET", "/admin/invites");
+  }
+
+  /** Create an Invitation Code (Admin) (POST /admin/invites). */
+  postAdminInvites(body?: InviteRequest): Promise<Invite> {
+    return this.request("POST", "/admin/invites", undefined, body);
+  }
+
+  /** Delete an Invitation Code (Admin) (DELETE /admin/invites/{code}). */
+  deleteAdminInvitesByCode(code: string): Promise<void> {
+    return this.request("DELETE", `/admin/invites/${encodeURIComponent(code)}`);
+  }
+
+  /** Switch Maintenance Mode (Admin) (POST /admin/maintenance). */
+  postAdminMaintenance(body: MaintenanceRequest): Promise<Maintenance> {
+    return this.request("POST", "/admin/maintenance", undefined, body);
+  }
+
+  /** Report Orphaned References (Admin) (GET /admin/orphans). */
+  getAdminOrphans(): Promise<OrphanReport> {
+    return this.request("GET", "/admin/orphans");
+  }
+
+  /** Remove Orphaned References (Admin) (POST /admin/orphans/clean). */
+  postAdminOrphansClean(): Promise<OrphanReport> {
+    return this.request("POST", "/admin/orphans/clean");
+  }
+
+  /** Report Password Hash Migration (Admin) (GET /admin/password-hashes). */
+  getAdminPasswordHashes(): Promise<PasswordHashReport> {
+    return this.request("GET", "/admin/password-hashes");
+  }
+
+  /** Get the Profile Extra Policy (Admin) (GET /admin/profile-extra-policy). */
+  getAdminProfileExtraPolicy(): Promise<ExtraPolicy> {
+    return this.request("GET", "/admin/profile-extra-policy");
+  }
+
+  /** Set the Profile Extra Policy (Admin) (PUT /admin/profile-extra-policy). */
+  putAdminProfileExtraPolicy(body: ExtraPolicyRequest): Promise<ExtraPolicy> {
+    return this.request("PUT", "/admin/profile-extra-policy", undefined, body);
+  }
+
+  /** Reset the Profile Extra Policy (Admin) (DELETE /admin/profile-extra-policy). */
+  deleteAdminProfileExtraPolicy(): Promise<ExtraPolicy> {
+    return this.request("DELETE", "/admin/profile-extra-policy");
+  }
+
+  /** List Deactivated Accounts (Admin) (GET /admin/profiles/deactivated). */
+  getAdminProfilesDeactivated(): Promise<DeactivatedProfile[]> {
+    return this.request("GET", "/admin/profiles/deactivated");
+  }
+
+  /** Purge a Deactivated Account (Admin) (POST /admin/profiles/{id}/purge). */
+  postAdminProfilesByIdPurge(id: string): Promise<ErasureReport> {
+    return this.request("POST", `/admin/profiles/${encodeURIComponent(id)}/purge`);
+  }
+
+  /** Reactivate an Account (Admin) (POST /admin/profiles/{id}/reactivate). */
+  postAdminProfilesByIdReactivate(id: string): Promise<DeactivatedProfile> {
+    return this.request("POST", `/admin/profiles/${encodeURIComponent(id)}/reactivate`);
+  }
+
+  /** Provision Accounts from a Roster (Admin) (POST /admin/provision). */
+  postAdminProvision(body: ProvisionRequest, query: { group?: string; delivery?: string } = {}): Promise<ProvisionReport> {
+    return this.request("POST", "/admin/provision", query, body);
+  }
+
+  /** Get Query Cache Statistics (Admin) (GET /admin/query-cache). */
+  getAdminQueryCache(): Promise<QueryCacheStats> {
+    return this.request("GET", "/admin/query-cache");
+  }
+
+  /** Report Database Recovery (Admin) (GET /admin/recovery). */
+  getAdminRecovery(): Promise<RecoveryResponse> {
+    return this.request("GET", "/admin/recovery");
+  }
+
+  /** List Document Scripts (Admin) (GET /admin/scripts). */
+  getAdminScripts(): Promise<Script[]> {
+    return this.request("GET", "/admin/scripts");
+  }
+
+  /** Add a Document Script (Admin) (POST /admin/scripts). */
+  postAdminScripts(body: ScriptRequest): Promise<Script> {
+    return this.request("POST", "/admin/scripts", undefined, body);
+  }
+
+  /** Get a Document Script (Admin) (GET /admin/scripts/{id}). */
+  getAdminScriptsById(id: string): Promise<Script> {
+    return this.request("GET", `/admin/scripts/${encodeURIComponent(id)}`);
+  }
+
+  /** Replace a Document Script (Admin) (PUT /admin/scripts/{id}). */
+  putAdminScriptsById(id: string, body: ScriptRequest): Promise<Script> {
+    return this.request("PUT", `/admin/scripts/${encodeURIComponent(id)}`, undefined, body);
+  }
+
+  /** Delete a Document Script (Admin) (DELETE /admin/scripts/{id}). */
+  deleteAdminScriptsById(id: string): Promise<void> {
+    return this.request("DELETE", `/admin/scripts/${encodeURIComponent(id)}`);
+  }
+
+  /** List Service Accounts (Admin) (GET /admin/service-accounts). */
+  getAdminServiceAccounts(): Promise<ServiceAccountResponse[]> {
+    return this.request("GET", "/admin/service-accounts");
+  }
+
+  /** Create a Service Account (Admin) (POST /admin/service-accounts). */
+  postAdminServiceAccounts(body: ServiceAccountRequest): Promise<ServiceAccountResponse> {
+    return this.request("POST", "/admin/service-accounts", undefined, body);
+  }
+
+  /** Change a Service Account's Scopes (Admin) (PUT /admin/service-accounts/{id}). */
+  putAdminServiceAccountsById(id: string, body: ServiceAccountScopesRequest): Promise<ServiceAccountResponse> {
+    return this.request("PUT", `/admin/service-accounts/${encodeURIComponent(id)}`, undefined, body);
+  }
+
+  /** Delete a Service Account (Admin) (DELETE /admin/service-accounts/{id}). */
+  deleteAdminServiceAccountsById(id: string): Promise<void> {
+    return this.request("DELETE", `/admin/service-accounts/${encodeURIComponent(id)}`);
+  }
+
+  /** Issue a Service Account Token (Admin) (POST /admin/service-accounts/{id}/tokens). */
+  postAdminServiceAccountsByIdTokens(id: string, body?: ServiceTokenRequest): Promise<ServiceTokenResponse> {
+    return this.request("POST", `/admin/service-accounts/${encodeURIComponent(id)}/tokens`, undefined, body);
+  }
+
+  /** List Slow Queries (Admin) (GET /admin/slow-queries). */
+  getAdminSlowQueries(): Promise<SlowQuery[]> {
+    return this.request("GET", "/admin/slow-queries");
+  }
+
+  /** List API Usage by Profile (Admin) (GET /admin/usage/api). */
+  getAdminUsageApi(query: { group?: string } = {}): Promise<ProfileApiUsage[]> {
+    return this.request("GET", "/admin/usage/api", query);
+  }
+
+  /** Get a Bot-Protection Challenge (GET /auth/challenge). */
+  getAuthChallenge(): Promise<ChallengeResponse> {
+    return this.request("GET", "/auth/challenge");
+  }
+
+  /** Request Password Reset Code (OTP) (POST /auth/forgot-password). */
+  postAuthForgotPassword(body: ForgotPasswordRequest): Promise<void> {
+    return this.request("POST", "/auth/forgot-password", undefined, body);
+  }
+
+  /** Introspect an Access Token (POST /auth/introspect). */
+  postAuthIntrospect(): Promise<IntrospectResponse> {
+    return this.request("POST", "/auth/introspect");
+  }
+
+  /** Log In to Your Account (POST /auth/login). */
+  postAuthLogin(body: LoginRequest): Promise<LoginResponse> {
+    return this.request("POST", "/auth/login", undefined, body);
+  }
+
+  /** Log Out (Client-Side Action) (POST /auth/logout). */
+  postAuthLogout(): Promise<void> {
+    return this.request("POST", "/auth/logout");
+  }
+
+  /** Set New Password Using Reset Code (OTP) (POST /auth/reset-password). */
+  postAuthResetPassword(body: ResetPasswordRequest): Promise<void> {
+    return this.request("POST", "/auth/reset-password", undefined, body);
+  }
+
+  /** Register a New User Account (POST /auth/signup). */
+  postAuthSignup(body: SignupRequest): Promise<SignupResponse> {
+    return this.request("POST", "/auth/signup", undefined, body);
+  }
+
+  /** Create a Restricted Token (POST /auth/tokens). */
+  postAuthTokens(body: RestrictedTokenRequest): Promise<RestrictedTokenResponse> {
+    return this.request("POST", "/auth/tokens", undefined, body);
+  }
+
+  /** Get the Calendar Feed (GET /calendar.ics). */
+  getCalendarIcs(query: { token?: string } = {}): Promise<string> {
+    return this.request("GET", "/calendar.ics", query);
+  }
+
+  /** Create a Calendar Feed URL (POST /calendar/feed). */
+  postCalendarFeed(): Promise<CalendarFeedResponse> {
+    return this.request("POST", "/calendar/feed");
+  }
+
+  /** Delete the Calendar Feed (DELETE /calendar/feed). */
+  deleteCalendarFeed(): Promise<void> {
+    return this.request("DELETE", "/calendar/feed");
+  }
+
+  /** List and Search Your Documents (GET /documents). */
+  getDocuments(query: { scope?: "owned" | "shared" | "all" | "public" | "any"; owner_id?: string; favorites?: boolean; workflow_state?: "draft" | "submitted" | "approved" | "rejected"; expired?: "exclude" | "include" | "only"; content_query?: string[]; missing?: "skip" | "false" | "error"; include?: string; sort_by?: "creation_date" | "last_modified_date"; order?: "asc" | "desc"; page?: number; limit?: number } = {}): Promise<Page<DocumentListItem>> {
+    return this.request("GET", "/documents", query, undefined, true);
+  }
+
+  /** Create a New Document (POST /documents). */
+  postDocuments(body: CreateDocumentRequest): Promise<DocumentResponse> {
+    return this.request("POST", "/documents", undefined, body);
+  }
+
+  /** Download Documents as a Zip Archive (GET /documents/archive). */
+  getDocumentsArchive(query: { scope?: "owned" | "shared" | "all" | "public"; content_query?: string[]; missing?: "skip" | "false" | "error"; sort_by?: "creation_date" | "last_modified_date"; order?: "asc" | "desc" } = {}): Promise<string> {
+    return this.request("GET", "/documents/archive", query);
+  }
+
+  /** Find Near-Duplicate Documents (GET /documents/duplicates). */
+  getDocumentsDuplicates(query: { threshold?: number; scope?: "owned" | "shared" | "all" } = {}): Promise<DuplicateClusterResponse[]> {
+    return this.request("GET", "/documents/duplicates", query);
+  }
+
+  /** Create a Document from a URL (POST /documents/fetch). */
+  postDocumentsFetch(body: FetchDocumentRequest): Promise<Document> {
+    return this.request("POST", "/documents/fetch", undefined, body);
+  }
+
+  /** Search and Replace Across Your Documents (POST /documents/replace). */
+  postDocumentsReplace(body: ReplaceDocumentsRequest): Promise<ReplaceReport> {
+    return this.request("POST", "/documents/replace", undefined, body);
+  }
+
+  /** Get a Specific Document by ID (GET /documents/{id}). */
+  getDocumentsById(id: string, query: { render?: "html"; include?: string } = {}): Promise<DocumentResponse> {
+    return this.request("GET", `/documents/${encodeURIComponent(id)}`, query);
+  }
+
+  /** Update a Document's Content (PUT /documents/{id}). */
+  putDocumentsById(id: string, body: UpdateDocumentRequest, query: { sync?: boolean; upsert?: boolean; create?: boolean } = {}): Promise<Document> {
+    return this.request("PUT", `/documents/${encodeURIComponent(id)}`, query, body);
+  }
+
+  /** Delete a Document (DELETE /documents/{id}). */
+  deleteDocumentsById(id: string): Promise<void> {
+    return this.request("DELETE", `/documents/${encodeURIComponent(id)}`);
+  }
+
+  /** Get a Document's Activity (GET /documents/{id}/activity). */
+  getDocumentsByIdActivity(id: string, query: { page?: number; limit?: number } = {}): Promise<Page<DocumentEvent>> {
+    return this.request("GET", `/documents/${encodeURIComponent(id)}/activity`, query, undefined, true);
+  }
+
+  /** Get a Document's Backlinks (GET /documents/{id}/backlinks). */
+  getDocumentsByIdBacklinks(id: string): Promise<GraphNode[]> {
+    return this.request("GET", `/documents/${encodeURIComponent(id)}/backlinks`);
+  }
+
+  /** Set a Value in a Document (PUT /documents/{id}/content/{path}). */
+  putDocumentsByIdContentByPath(id: string, path: string, body: unknown, query: { version?: number; revision?: number } = {}): Promise<ContentPathResponse> {
+    return this.request("PUT", `/documents/${encodeURIComponent(id)}/content/${encodeURIComponent(path)}`, query, body);
+  }
+
+  /** Remove a Value from a Document (DELETE /documents/{id}/content/{path}). */
+  deleteDocumentsByIdContentByPath(id: string, path: string, query: { version?: number; revision?: number } = {}): Promise<ContentPathResponse> {
+    return this.request("DELETE", `/documents/${encodeURIComponent(id)}/content/${encodeURIComponent(path)}`, query);
+  }
+
+  /** Append to an Array in a Document (POST /documents/{id}/content/{path}/append). */
+  postDocumentsByIdContentByPathAppend(id: string, path: string, body: unknown, query: { version?: number; revision?: number } = {}): Promise<ContentPathResponse> {
+    return this.request("POST", `/documents/${encodeURIComponent(id)}/content/${encodeURIComponent(path)}/append`, query, body);
+  }
+
+  /** Increment a Number in a Document (POST /documents/{id}/content/{path}/increment). */
+  postDocumentsByIdContentByPathIncrement(id: string, path: string, body?: IncrementRequest, query: { version?: number; revision?: number } = {}): Promise<ContentPathResponse> {
+    return this.request("POST", `/documents/${encodeURIComponent(id)}/content/${encodeURIComponent(path)}/increment`, query, body);
+  }
+
+  /** Insert into an Array in a Document (POST /documents/{id}/content/{path}/insert). */
+  postDocumentsByIdContentByPathInsert(id: string, path: string, body: unknown, query: { index?: number; version?: number; revision?: number } = {}): Promise<ContentPathResponse> {
+    return this.request("POST", `/documents/${encodeURIComponent(id)}/content/${encodeURIComponent(path)}/insert`, query, body);
+  }
+
+  /** Remove from an Array in a Document (POST /documents/{id}/content/{path}/remove). */
+  postDocumentsByIdContentByPathRemove(id: string, path: string, query: { index?: number; version?: number; revision?: number } = {}): Promise<ContentPathResponse> {
+    return this.request("POST", `/documents/${encodeURIComponent(id)}/content/${encodeURIComponent(path)}/remove`, query);
+  }
+
+  /** Compare Two Versions of a Document (GET /documents/{id}/diff). */
+  getDocumentsByIdDiff(id: string, query: { from?: number; to?: number } = {}): Promise<DocumentDiffResponse> {
+    return this.request("GET", `/documents/${encodeURIComponent(id)}/diff`, query);
+  }
+
+  /** Compare a Document with New Content (POST /documents/{id}/diff). */
+  postDocumentsByIdDiff(id: string, body: DiffDocumentRequest, query: { from?: number } = {}): Promise<DocumentDiffResponse> {
+    return this.request("POST", `/documents/${encodeURIComponent(id)}/diff`, query, body);
+  }
+
+  /** Set When a Document Expires (PUT /documents/{id}/expiry). */
+  putDocumentsByIdExpiry(id: string, body: DocumentExpiryRequest): Promise<DocumentResponse> {
+    return this.request("PUT", `/documents/${encodeURIComponent(id)}/expiry`, undefined, body);
+  }
+
+  /** Keep a Document for Good (DELETE /documents/{id}/expiry). */
+  deleteDocumentsByIdExpiry(id: string): Promise<DocumentResponse> {
+    return this.request("DELETE", `/documents/${encodeURIComponent(id)}/expiry`);
+  }
+
+  /** Mark a Document as Favorite (POST /documents/{id}/favorite). */
+  postDocumentsByIdFavorite(id: string): Promise<FavoriteResponse> {
+    return this.request("POST", `/documents/${encodeURIComponent(id)}/favorite`);
+  }
+
+  /** Remove a Document from Favorites (DELETE /documents/{id}/favorite). */
+  deleteDocumentsByIdFavorite(id: string): Promise<void> {
+    return this.request("DELETE", `/documents/${encodeURIComponent(id)}/favorite`);
+  }
+
+  /** Freeze a Document (POST /documents/{id}/freeze). */
+  postDocumentsByIdFreeze(id: string): Promise<Document> {
+    return this.request("POST", `/documents/${encodeURIComponent(id)}/freeze`);
+  }
+
+  /** Unfreeze a Document (DELETE /documents/{id}/freeze). */
+  deleteDocumentsByIdFreeze(id: string): Promise<Document> {
+    return this.request("DELETE", `/documents/${encodeURIComponent(id)}/freeze`);
+  }
+
+  /** Lint and Normalize a Document (POST /documents/{id}/lint). */
+  postDocumentsByIdLint(id: string, body?: LintDocumentRequest): Promise<LintDocumentResponse> {
+    return this.request("POST", `/documents/${encodeURIComponent(id)}/lint`, undefined, body);
+  }
+
+  /** List a Document's Reviews (GET /documents/{id}/reviews). */
+  getDocumentsByIdReviews(id: string): Promise<DocumentReviewsResponse> {
+    return this.request("GET", `/documents/${encodeURIComponent(id)}/reviews`);
+  }
+
+  /** Assign a Reviewer (POST /documents/{id}/reviews). */
+  postDocumentsByIdReviews(id: string, body: AssignReviewRequest): Promise<Review> {
+    return this.request("POST", `/documents/${encodeURIComponent(id)}/reviews`, undefined, body);
+  }
+
+  /** See Who a Document is Shared With (GET /documents/{id}/shares). */
+  getDocumentsByIdShares(id: string): Promise<GetSharersResponse> {
+    return this.request("GET", `/documents/${encodeURIComponent(id)}/shares`);
+  }
+
+  /** Set/Replace Who a Document is Shared With (PUT /documents/{id}/shares). */
+  putDocumentsByIdShares(id: string, body: SetSharersRequest): Promise<void> {
+    return this.request("PUT", `/documents/${encodeURIComponent(id)}/shares`, undefined, body);
+  }
+
+  /** Share a Document by Email (POST /documents/{id}/shares/email). */
+  postDocumentsByIdSharesEmail(id: string, body: ShareByEmailRequest): Promise<ShareByEmailResponse> {
+    return this.request("POST", `/documents/${encodeURIComponent(id)}/shares/email`, undefined, body);
+  }
+
+  /** Withdraw a Pending Share (DELETE /documents/{id}/shares/email/{email}). */
+  deleteDocumentsByIdSharesEmailByEmail(id: string, email: string): Promise<void> {
+    return this.request("DELETE", `/documents/${encodeURIComponent(id)}/shares/email/${encodeURIComponent(email)}`);
+  }
+
+  /** Share a Document with One User (PUT /documents/{id}/shares/{profile_id}). */
+  putDocumentsByIdSharesByProfileId(id: string, profileId: string): Promise<void> {
+    return this.request("PUT", `/documents/${encodeURIComponent(id)}/shares/${encodeURIComponent(profileId)}`);
+  }
+
+  /** Stop Sharing a Document with One User (DELETE /documents/{id}/shares/{profile_id}). */
+  deleteDocumentsByIdSharesByProfileId(id: string, profileId: string): Promise<void> {
+    return this.request("DELETE", `/documents/${encodeURIComponent(id)}/shares/${encodeURIComponent(profileId)}`);
+  }
+
+  /** Share Only Part of a Document (PUT /documents/{id}/shares/{profile_id}/scope). */
+  putDocumentsByIdSharesByProfileIdScope(id: string, profileId: string, body: ShareScopeRequest): Promise<ShareScopeResponse> {
+    return this.request("PUT", `/documents/${encodeURIComponent(id)}/shares/${encodeURIComponent(profileId)}/scope`, undefined, body);
+  }
+
+  /** Share the Whole Document Again (DELETE /documents/{id}/shares/{profile_id}/scope). */
+  deleteDocumentsByIdSharesByProfileIdScope(id: string, profileId: string): Promise<void> {
+    return this.request("DELETE", `/documents/${encodeURIComponent(id)}/shares/${encodeURIComponent(profileId)}/scope`);
+  }
+
+  /** Create a Signed URL (POST /documents/{id}/signed-url). */
+  postDocumentsByIdSignedUrl(id: string, query: { op?: "read" | "write"; ttl?: string } = {}): Promise<SignedUrlResponse> {
+    return this.request("POST", `/documents/${encodeURIComponent(id)}/signed-url`, query);
+  }
+
+  /** Get a Document's Statistics (GET /documents/{id}/stats). */
+  getDocumentsByIdStats(id: string, query: { last?: number } = {}): Promise<DocumentStats> {
+    return this.request("GET", `/documents/${encodeURIComponent(id)}/stats`, query);
+  }
+
+  /** Submit, Approve or Reject a Document (POST /documents/{id}/workflow). */
+  postDocumentsByIdWorkflow(id: string, body: WorkflowTransitionRequest): Promise<Document> {
+    return this.request("POST", `/documents/${encodeURIComponent(id)}/workflow`, undefined, body);
+  }
+
+  /** Get the Link Graph (GET /graph). */
+  getGraph(): Promise<LinkGraph> {
+    return this.request("GET", "/graph");
+  }
+
+  /** Get Your Limits (GET /limits). */
+  getLimits(): Promise<LimitsResponse> {
+    return this.request("GET", "/limits");
+  }
+
+  /** Generate Mock Documents (Debug Mode) (GET /mock/documents). */
+  getMockDocuments(query: { n?: number; shape?: string; seed?: number } = {}): Promise<Document[]> {
+    return this.request("GET", "/mock/documents", query);
+  }
+
+  /** List Your Notification Channels (GET /notification-channels). */
+  getNotificationChannels(): Promise<NotificationChannel[]> {
+    return this.request("GET", "/notification-channels");
+  }
+
+  /** Add a Notification Channel (POST /notification-channels). */
+  postNotificationChannels(body: NotificationChannelRequest): Promise<NotificationChannel> {
+    return this.request("POST", "/notification-channels", undefined, body);
+  }
+
+  /** Get a Notification Channel (GET /notification-channels/{id}). */
+  getNotificationChannelsById(id: string): Promise<NotificationChannel> {
+    return this.request("GET", `/notification-channels/${encodeURIComponent(id)}`);
+  }
+
+  /** Replace a Notification Channel (PUT /notification-channels/{id}). */
+  putNotificationChannelsById(id: string, body: NotificationChannelRequest): Promise<NotificationChannel> {
+    return this.request("PUT", `/notification-channels/${encodeURIComponent(id)}`, undefined, body);
+  }
+
+  /** Delete a Notification Channel (DELETE /notification-channels/{id}). */
+  deleteNotificationChannelsById(id: string): Promise<void> {
+    return this.request("DELETE", `/notification-channels/${encodeURIComponent(id)}`);
+  }
+
+  /** Send a Test Message (POST /notification-channels/{id}/test). */
+  postNotificationChannelsByIdTest(id: string): Promise<void> {
+    return this.request("POST", `/notification-channels/${encodeURIComponent(id)}/test`);
+  }
+
+  /** Search User Profiles (GET /profiles). */
+  getProfiles(query: { email?: string; first_name?: string; last_name?: string; created_after?: string; created_before?: string; ids?: string; sort_by?: "email" | "name" | "creation_date"; order?: "asc" | "desc"; page?: number; limit?: number } = {}): Promise<Page<ProfileResponse>> {
+    return this.request("GET", "/profiles", query, undefined, true);
+  }
+
+  /** Get Your Own Profile (GET /profiles/me). */
+  getProfilesMe(): Promise<ProfileResponse> {
+    return this.request("GET", "/profiles/me");
+  }
+
+  /** Update Your Own Profile (PUT /profiles/me). */
+  putProfilesMe(body: UpdateProfileRequest): Promise<ProfileResponse> {
+    return this.request("PUT", "/profiles/me", undefined, body);
+  }
+
+  /** Delete Your Own Profile (DELETE /profiles/me). */
+  deleteProfilesMe(): Promise<void> {
+    return this.request("DELETE", "/profiles/me");
+  }
+
+  /** Accept the Terms of Service (POST /profiles/me/accept-tos). */
+  postProfilesMeAcceptTos(body?: AcceptTosRequest): Promise<TosStatus> {
+    return this.request("POST", "/profiles/me/accept-tos", undefined, body);
+  }
+
+  /** Deactivate Your Account (POST /profiles/me/deactivate). */
+  postProfilesMeDeactivate(body?: DeactivateRequest): Promise<Deactivation> {
+    return this.request("POST", "/profiles/me/deactivate", undefined, body);
+  }
+
+  /** List Your Devices (GET /profiles/me/devices). */
+  getProfilesMeDevices(): Promise<DeviceResponse[]> {
+    return this.request("GET", "/profiles/me/devices");
+  }
+
+  /** Revoke a Device (DELETE /profiles/me/devices/{device_id}). */
+  deleteProfilesMeDevicesByDeviceId(deviceId: string): Promise<void> {
+    return this.request("DELETE", `/profiles/me/devices/${encodeURIComponent(deviceId)}`);
+  }
+
+  /** Request an Email Change (POST /profiles/me/email-change). */
+  postProfilesMeEmailChange(body: EmailChangeRequest): Promise<EmailChangeResponse> {
+    return this.request("POST", "/profiles/me/email-change", undefined, body);
+  }
+
+  /** Confirm an Email Change (POST /profiles/me/email-change/confirm). */
+  postProfilesMeEmailChangeConfirm(body: ConfirmEmailChangeRequest): Promise<ProfileResponse> {
+    return this.request("POST", "/profiles/me/email-change/confirm", undefined, body);
+  }
+
+  /** Check Your Erasure Request (GET /profiles/me/erase). */
+  getProfilesMeErase(): Promise<ErasureRequest> {
+    return this.request("GET", "/profiles/me/erase");
+  }
+
+  /** Request Erasure of Your Data (POST /profiles/me/erase). */
+  postProfilesMeErase(): Promise<ErasureRequest> {
+    return this.request("POST", "/profiles/me/erase");
+  }
+
+  /** Cancel Your Erasure Request (DELETE /profiles/me/erase). */
+  deleteProfilesMeErase(): Promise<void> {
+    return this.request("DELETE", "/profiles/me/erase");
+  }
+
+  /** Export Your Data (GET /profiles/me/export). */
+  getProfilesMeExport(): Promise<ExportResponse> {
+    return this.request("GET", "/profiles/me/export");
+  }
+
+  /** Get Your Statistics (GET /profiles/me/stats). */
+  getProfilesMeStats(): Promise<ProfileStats> {
+    return this.request("GET", "/profiles/me/stats");
+  }
+
+  /** Get Your API Usage (GET /profiles/me/usage/api). */
+  getProfilesMeUsageApi(): Promise<ApiUsageReport> {
+    return this.request("GET", "/profiles/me/usage/api");
+  }
+
+  /** Resolve Profile IDs (POST /profiles/resolve). */
+  postProfilesResolve(body: ResolveProfilesRequest): Promise<ResolveProfilesResponse> {
+    return this.request("POST", "/profiles/resolve", undefined, body);
+  }
+
+  /** List Public Documents (No Login Needed) (GET /public/documents). */
+  getPublicDocuments(query: { content_query?: string[]; missing?: "skip" | "false" | "error"; include?: string; sort_by?: "creation_date" | "last_modified_date"; order?: "asc" | "desc"; page?: number; limit?: number } = {}): Promise<Page<DocumentListItem>> {
+    return this.request("GET", "/public/documents", query, undefined, true);
+  }
+
+  /** Get a Public Document (No Login Needed) (GET /public/documents/{id}). */
+  getPublicDocumentsById(id: string): Promise<Document> {
+    return this.request("GET", `/public/documents/${encodeURIComponent(id)}`);
+  }
+
+  /** List the Query Operators (GET /query/operators). */
+  getQueryOperators(): Promise<QueryOperatorsResponse> {
+    return this.request("GET", "/query/operators");
+  }
+
+  /** Validate a Content Query (POST /query/validate). */
+  postQueryValidate(body: ValidateQueryRequest): Promise<QueryValidation> {
+    return this.request("POST", "/query/validate", undefined, body);
+  }
+
+  /** List Your Reviews (GET /reviews). */
+  getReviews(query: { status?: string } = {}): Promise<Review[]> {
+    return this.request("GET", "/reviews", query);
+  }
+
+  /** Get a Review (GET /reviews/{id}). */
+  getReviewsById(id: string): Promise<Review> {
+    return this.request("GET", `/reviews/${encodeURIComponent(id)}`);
+  }
+
+  /** Submit Review Feedback (PUT /reviews/{id}). */
+  putReviewsById(id: string, body: SubmitReviewRequest): Promise<Review> {
+    return this.request("PUT", `/reviews/${encodeURIComponent(id)}`, undefined, body);
+  }
+
+  /** Remove a Reviewer (DELETE /reviews/{id}). */
+  deleteReviewsById(id: string): Promise<void> {
+    return this.request("DELETE", `/reviews/${encodeURIComponent(id)}`);
+  }
+
+  /** List Your Scheduled Exports (GET /schedules). */
+  getSchedules(): Promise<Schedule[]> {
+    return this.request("GET", "/schedules");
+  }
+
+  /** Schedule a Recurring Export (POST /schedules). */
+  postSchedules(body: ScheduleRequest): Promise<Schedule> {
+    return this.request("POST", "/schedules", undefined, body);
+  }
+
+  /** Get a Scheduled Export (GET /schedules/{id}). */
+  getSchedulesById(id: string): Promise<Schedule> {
+    return this.request("GET", `/schedules/${encodeURIComponent(id)}`);
+  }
+
+  /** Replace a Scheduled Export (PUT /schedules/{id}). */
+  putSchedulesById(id: string, body: ScheduleRequest): Promise<Schedule> {
+    return this.request("PUT", `/schedules/${encodeURIComponent(id)}`, undefined, body);
+  }
+
+  /** Delete a Scheduled Export (DELETE /schedules/{id}). */
+  deleteSchedulesById(id: string): Promise<void> {
+    return this.request("DELETE", `/schedules/${encodeURIComponent(id)}`);
+  }
+
+  /** Run a Scheduled Export Now (POST /schedules/{id}/run). */
+  postSchedulesByIdRun(id: string): Promise<ScheduleRun> {
+    return this.request("POST", `/schedules/${encodeURIComponent(id)}/run`);
+  }
+
+  /** List the Runs of a Scheduled Export (GET /schedules/{id}/runs). */
+  getSchedulesByIdRuns(id: string): Promise<ScheduleRun[]> {
+    return this.request("GET", `/schedules/${encodeURIComponent(id)}/runs`);
+  }
+
+  /** Download a Scheduled Export (GET /schedules/{id}/runs/{run_id}/download). */
+  getSchedulesByIdRunsByRunIdDownload(id: string, runId: string): Promise<ScheduleExport> {
+    return this.request("GET", `/schedules/${encodeURIComponent(id)}/runs/${encodeURIComponent(runId)}/download`);
+  }
+
+  /** Download a Client Library (GET /sdk/{language}). */
+  getSdkByLanguage(language: string): Promise<string> {
+    return this.request("GET", `/sdk/${encodeURIComponent(language)}`);
+  }
+
+  /** Read a Document Through a Signed URL (GET /signed/{token}). */
+  getSignedByToken(token: string, query: { render?: "html" } = {}): Promise<DocumentResponse> {
+    return this.request("GET", `/signed/${encodeURIComponent(token)}`, query);
+  }
+
+  /** Update a Document Through a Signed URL (PUT /signed/{token}). */
+  putSignedByToken(token: string, body: UpdateDocumentRequest, query: { sync?: boolean } = {}): Promise<Document> {
+    return this.request("PUT", `/signed/${encodeURIComponent(token)}`, query, body);
+  }
+
+  /** Get Server Status (GET /status). */
+  getStatus(): Promise<StatusResponse> {
+    return this.request("GET", "/status");
+  }
+
+  /** Get Server Version (GET /version). */
+  getVersion(): Promise<VersionResponse> {
+    return this.request("GET", "/version");
+  }
+}
@@ -0,0 +1,295 @@
+package sdk
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// --- Postman Collection ---
+
+// postmanSchema identifies the Postman collection format written.
+const postmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// postmanLoginPath is the operation whose response holds the token the collection sends.
+const postmanLoginPath = "/auth/login"
+
+// postmanLoginScript stores the token of a successful login in the collection's token variable,
+// from an enveloped ({"data": {"token": ...}}) or a legacy ({"token": ...}) response.
+var postmanLoginScript = []string{
+	"if (pm.response.code === 200) {",
+	"    const body = pm.response.json();",
+	"    pm.collectionVariables.set(\"token\", (body.data || body).token);",
+	"}",
+}
+
+// contentQueryExamples are example searches of documents by content, as in the API description.
+var contentQueryExamples = []struct {
+	Name   string
+	Values []string
+}{
+	{"Simple equality", []string{`status equals "active"`}},
+	{"Numeric comparison", []string{`priority greaterthanorequals 5`}},
+	{"Nested field", []string{`assignee.name equals "Alice"`}},
+	{"Array element", []string{`tags.0 equals "urgent"`}},
+	{"Explicit AND", []string{`project equals "Alpha"`, "and", `status equals "active"`}},
+	{"Explicit OR", []string{`status equals "active"`, "or", `priority lessthan 3`}},
+	{"Combined AND and OR", []string{`project equals "Alpha"`, "and", `status equals "active"`, "or", `priority equals 10`}},
+	{"Array length", []string{`tags.# greaterthan 1`}},
+}
+
+// exampleStrings are example values of string properties, by name.
+var exampleStrings = map[string]string{
+	"email":        "ada@example.com",
+	"password":     "secret123",
+	"new_password": "secret456",
+	"first_name":   "Ada",
+	"last_name":    "Lovelace",
+}
+
+// exampleContent is the example content of documents, matching contentQueryExamples.
+var exampleContent = map[string]any{
+	"project":  "Alpha",
+	"status":   "active",
+	"priority": 5,
+	"tags":     []any{"urgent", "review"},
+	"assignee": map[string]any{"name": "Alice"},
+}
+
+type postmanCollection struct {
+	Info     postmanInfo       `json:"info"`
+	Auth     *postmanAuth      `json:"auth,omitempty"`
+	Variable []postmanVariable `json:"variable,omitempty"`
+	Item     []postmanItem     `json:"item"`
+}
+
+type postmanInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Schema      string `json:"schema"`
+}
+
+type postmanAuth struct {
+	Type   string            `json:"type"`
+	Bearer []postmanVariable `json:"bearer,omitempty"`
+}
+
+type postmanVariable struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Type     string `json:"type,omitempty"`
+	Disabled bool   `json:"disabled,omitempty"`
+}
+
+type postmanItem struct {
+	Name    string          `json:"name"`
+	Item    []postmanItem   `json:"item,omitempty"` // Folders
+	Request *postmanRequest `json:"request,omitempty"`
+	Event   []postmanEvent  `json:"event,omitempty"`
+}
+
+type postmanRequest struct {
+	Method string          `json:"method"`
+	Auth   *postmanAuth    `json:"auth,omitempty"`
+	Header []postmanHeader `json:"header"`
+	Body   *postmanBody    `json:"body,omitempty"`
+	URL    postmanURL      `json:"url"`
+}
+
+type postmanHeader struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type postmanBody struct {
+	Mode    string         `json:"mode"`
+	Raw     string         `json:"raw"`
+	Options map[string]any `json:"options"`
+}
+
+type postmanURL struct {
+	Raw      string            `json:"raw"`
+	Host     []string          `json:"host"`
+	Path     []string          `json:"path"`
+	Query    []postmanVariable `json:"query,omitempty"`
+	Variable []postmanVariable `json:"variable,omitempty"`
+}
+
+type postmanEvent struct {
+	Listen string        `json:"listen"`
+	Script postmanScript `json:"script"`
+}
+
+type postmanScript struct {
+	Type string   `json:"type"`
+	Exec []string `json:"exec"`
+}
+
+// GeneratePostman returns a Postman (v2.1) collection of the spec's operations, in a folder per
+// tag. Requests send the collection's token variable as a bearer token, which logging in sets;
+// bodies and query parameters are filled in with examples, and a folder holds example searches
+// by content. The base URL is the baseUrl variable, BaseURLPlaceholder until filled in (see
+// WithBaseURL).
+func GeneratePostman(spec *Spec) []byte {
+	collection := postmanCollection{
+		Info: postmanInfo{
+			Name:        "DocServer API",
+			Description: "Generated from the server's Swagger spec. Log in with \"" + postmanLoginPath + "\" first: the token is stored in the collection's `token` variable and sent with every request.",
+			Schema:      postmanSchema,
+		},
+		Auth: &postmanAuth{Type: "bearer", Bearer: []postmanVariable{{Key: "token", Value: "{{token}}", Type: "string"}}},
+		Variable: []postmanVariable{
+			{Key: "baseUrl", Value: BaseURLPlaceholder, Type: "string"},
+			{Key: "token", Value: "", Type: "string"},
+		},
+	}
+
+	var folders []string
+	byTag := make(map[string][]postmanItem)
+	var searches []postmanItem
+	for _, e := range spec.endpoints() {
+		tag := "Other"
+		if len(e.Tags) > 0 {
+			tag = e.Tags[0]
+		}
+		if _, found := byTag[tag]; !found {
+			folders = append(folders, tag)
+		}
+		byTag[tag] = append(byTag[tag], postmanRequestItem(spec, e))
+
+		if e.Method == "GET" && hasQueryParam(e.Query, "content_query") && searches == nil {
+			for _, example := range contentQueryExamples {
+				item := postmanRequestItem(spec, endpoint{Method: e.Method, Path: e.Path, Secured: e.Secured})
+				item.Name = example.Name
+				for _, value := range example.Values {
+					item.Request.URL.Query = append(item.Request.URL.Query, postmanVariable{Key: "content_query", Value: value})
+				}
+				item.Request.URL.Raw += "?" + postmanQueryString(item.Request.URL.Query)
+				searches = append(searches, item)
+			}
+		}
+	}
+	for _, folder := range folders {
+		collection.Item = append(collection.Item, postmanItem{Name: folder, Item: byTag[folder]})
+	}
+	if searches != nil {
+		collection.Item = append(collection.Item, postmanItem{Name: "Search by Content (content_query examples)", Item: searches})
+	}
+
+	data, _ := json.MarshalIndent(collection, "", "  ") // Only marshals plain values
+	return append(data, '\n')
+}
+
+// postmanRequestItem returns the request of an operation.
+func postmanRequestItem(spec *Spec, e endpoint) postmanItem {
+	url := postmanURL{Raw: "{{baseUrl}}", Host: []string{"{{baseUrl}}"}}
+	for _, segment := range strings.Split(strings.Trim(e.Path, "/"), "/") {
+		if match := pathParamPattern.FindStringSubmatch(segment); match != nil {
+			segment = ":" + match[1]
+			url.Variable = append(url.Variable, postmanVariable{Key: match[1], Value: ""})
+		}
+		url.Path = append(url.Path, segment)
+		url.Raw += "/" + segment
+	}
+	for _, param := range e.Query {
+		// Optional parameters are left disabled, with an example value to enable
+		url.Query = append(url.Query, postmanVariable{Key: param.Name, Value: exampleParam(param), Disabled: !param.Required})
+	}
+	if enabled := postmanQueryString(url.Query); enabled != "" {
+		url.Raw += "?" + enabled
+	}
+
+	request := &postmanRequest{Method: e.Method, Header: []postmanHeader{{Key: "Accept", Value: "application/json"}}, URL: url}
+	if !e.Secured {
+		request.Auth = &postmanAuth{Type: "noauth"}
+	}
+	if e.Body != nil {
+		example, _ := json.MarshalIndent(exampleValue(spec, e.Body.Schema, "", 0), "", "  ")
+		request.Header = append(request.Header, postmanHeader{Key: "Content-Type", Value: "application/json"})
+		request.Body = &postmanBody{Mode: "raw", Raw: string(example), Options: map[string]any{"raw": map[string]string{"language": "json"}}}
+	}
+
+	item := postmanItem{Name: strings.TrimSuffix(firstLine(e.Summary), "."), Request: request}
+	if item.Name == "" {
+		item.Name = e.Method + " " + e.Path
+	}
+	if e.Path == postmanLoginPath {
+		item.Event = []postmanEvent{{Listen: "test", Script: postmanScript{Type: "text/javascript", Exec: postmanLoginScript}}}
+	}
+	return item
+}
+
+// postmanQueryString returns the enabled query parameters as they appear in a raw URL.
+func postmanQueryString(query []postmanVariable) string {
+	var pairs []string
+	for _, param := range query {
+		if !param.Disabled {
+			pairs = append(pairs, param.Key+"="+param.Value)
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// exampleParam returns an example value of a query parameter.
+func exampleParam(param Parameter) string {
+	if param.Name == "content_query" {
+		return contentQueryExamples[0].Values[0]
+	}
+	schema := &Schema{Type: param.Type, Enum: param.Enum}
+	if param.Type == "array" && param.Items != nil {
+		schema = param.Items
+	}
+	value := exampleValue(nil, schema, param.Name, 0)
+	if s, ok := value.(string); ok {
+		return s
+	}
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
+
+// exampleValue returns an example of a value matching a schema; name is the property it is for.
+func exampleValue(spec *Spec, schema *Schema, name string, depth int) any {
+	if schema == nil || depth > 5 {
+		return nil
+	}
+	if schema.Example != nil {
+		return schema.Example
+	}
+	if schema.Ref != "" {
+		if spec == nil || spec.Definitions[definitionName(schema.Ref)] == nil {
+			return map[string]any{}
+		}
+		return exampleValue(spec, spec.Definitions[definitionName(schema.Ref)], name, depth+1)
+	}
+	if len(schema.Enum) > 0 {
+		return schema.Enum[0]
+	}
+	switch schema.Type {
+	case "string":
+		return exampleStrings[name]
+	case "integer", "number":
+		if name == "page" || name == "limit" {
+			return 1
+		}
+		return 0
+	case "boolean":
+		return false
+	case "array":
+		if item := exampleValue(spec, schema.Items, name, depth+1); item != nil {
+			return []any{item}
+		}
+		return []any{}
+	case "object", "":
+		if len(schema.Properties) == 0 {
+			if name == "content" {
+				return exampleContent
+			}
+			return map[string]any{}
+		}
+		object := make(map[string]any, len(schema.Properties))
+		for _, property := range sortedProperties(schema) {
+			object[property] = exampleValue(spec, schema.Properties[property], property, depth+1)
+		}
+		return object
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+package sdk
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// --- Python Client ---
+
+// pythonPreamble is the start of the Python client, up to the types.
+const pythonPreamble = `# DocServer API client, generated from the server's Swagger spec by "go generate ./sdk". Do not edit.
+"""Client for the DocServer API.
+
+    from docserver_client import DocServerClient
+
+    client = DocServerClient()
+    client.token = client.post_auth_login({"email": "ada@example.com", "password": "secret123"})["token"]
+    print(client.get_documents(limit=5)["data"])
+
+Methods return the data of the response, and lists the page with its "data", "meta" and
+"links". Error responses raise DocServerError. Needs Python 3.8 or later and no packages.
+"""
+from __future__ import annotations
+
+import json
+import urllib.error
+import urllib.parse
+import urllib.request
+from typing import Any, Dict, List, Literal, Optional, TypedDict
+
+BASE_URL = "` + BaseURLPlaceholder + `"
+
+
+class DocServerError(Exception):
+    """An error response of the API."""
+
+    def __init__(self, status: int, code: str, message: str) -> None:
+        super().__init__(f"{status} {code}: {message}")
+        self.status = status
+        self.code = code
+        self.message = message
+
+
+class Page(TypedDict, total=False):
+    """One page of a list."""
+
+    data: List[Any]
+    meta: Dict[str, Any]  # total, page, limit and total_pages
+    links: Dict[str, str]  # self, first, last, and next and prev when there are such pages
+`
+
+// pythonClient is the start of the client class, up to the operations.
+const pythonClient = `
+
+def _path(value: Any) -> str:
+    return urllib.parse.quote(str(value), safe="")
+
+
+class DocServerClient:
+    """Calls the DocServer API at base_url, authenticated with token once it is set."""
+
+    def __init__(self, base_url: str = BASE_URL, token: Optional[str] = None) -> None:
+        self.base_url = base_url.rstrip("/")
+        self.token = token
+
+    def _request(self, method: str, path: str, query: Optional[Dict[str, Any]] = None, body: Any = None, page: bool = False) -> Any:
+        url = self.base_url + path
+        pairs = []
+        for key, value in (query or {}).items():
+            for item in value if isinstance(value, list) else [value]:
+                if item is not None:
+                    pairs.append((key, json.dumps(item) if isinstance(item, bool) else str(item)))
+        if pairs:
+            url += "?" + urllib.parse.urlencode(pairs)
+        headers = {"Accept": "application/json"}
+        data = None
+        if body is not None:
+            data = json.dumps(body).encode("utf-8")
+            headers["Content-Type"] = "application/json"
+        if self.token:
+            headers["Authorization"] = "Bearer " + self.token
+        request = urllib.request.Request(url, data=data, headers=headers, method=method)
+        try:
+            with urllib.request.urlopen(request) as response:
+                raw = response.read()
+        except urllib.error.HTTPError as err:
+            raw = err.read()
+            try:
+                error = json.loads(raw)["error"]
+                status, code, message = error["status"], error["code"], error["message"]
+            except (ValueError, KeyError, TypeError):
+                status, code, message = err.code, "", raw.decode("utf-8", "replace")
+            raise DocServerError(status, code, message) from None
+        if not raw:
+            return None
+        envelope = json.loads(raw)
+        return envelope if page else envelope.get("data")
+`
+
+// pythonKeywords are the names Python parameters and fields cannot have.
+var pythonKeywords = map[string]bool{
+	"False": true, "None": true, "True": true, "and": true, "as": true, "assert": true, "async": true,
+	"await": true, "break": true, "class": true, "continue": true, "def": true, "del": true, "elif": true,
+	"else": true, "except": true, "finally": true, "for": true, "from": true, "global": true, "if": true,
+	"import": true, "in": true, "is": true, "lambda": true, "nonlocal": true, "not": true, "or": true,
+	"pass": true, "raise": true, "return": true, "try": true, "while": true, "with": true, "yield": true,
+}
+
+// identifierPattern matches names usable as identifiers in Python and TypeScript.
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// GeneratePython returns a Python client for the spec: a TypedDict per definition and a
+// DocServerClient with a method per operation, using only the standard library.
+func GeneratePython(spec *Spec) []byte {
+	names := spec.typeNames()
+	var b strings.Builder
+	b.WriteString(pythonPreamble)
+
+	for _, def := range spec.sortedDefinitions() {
+		schema := spec.Definitions[def]
+		b.WriteString("\n\n")
+		properties := sortedProperties(schema)
+		usable := true
+		for _, property := range properties {
+			if !identifierPattern.MatchString(property) || pythonKeywords[property] {
+				usable = false
+			}
+		}
+		if !usable {
+			// Names that are not identifiers need the functional syntax
+			fields := make([]string, len(properties))
+			for i, property := range properties {
+				fields[i] = fmt.Sprintf("%s: %s", strconv.Quote(property), pythonType(schema.Properties[property], names))
+			}
+			fmt.Fprintf(&b, "%s = TypedDict(%s, {%s}, total=False)\n", names[def], strconv.Quote(names[def]), strings.Join(fields, ", "))
+			continue
+		}
+		fmt.Fprintf(&b, "class %s(TypedDict, total=False):\n", names[def])
+		fmt.Fprintf(&b, "    %s\n", pythonDocstring(def+" of the API."))
+		if len(properties) > 0 {
+			b.WriteString("\n")
+		}
+		for _, property := range properties {
+			fmt.Fprintf(&b, "    %s: %s%s\n", property, pythonType(schema.Properties[property], names), pythonComment(schema.Properties[property].Description))
+		}
+	}
+
+	b.WriteString(pythonClient)
+	for _, e := range spec.endpoints() {
+		var params, query []string
+		path := e.Path
+		for _, param := range e.PathParams {
+			ident := pythonIdentifier(param.Name)
+			params = append(params, fmt.Sprintf("%s: %s", ident, pythonParamType(param, names)))
+			path = strings.Replace(path, "{"+param.Name+"}", "{_path("+ident+")}", 1)
+		}
+		if len(e.PathParams) > 0 {
+			path = "f" + strconv.Quote(path)
+		} else {
+			path = strconv.Quote(path)
+		}
+		call := []string{strconv.Quote(e.Method), path}
+		if e.Body != nil {
+			if e.Body.Required {
+				params = append(params, "body: "+pythonType(e.Body.Schema, names))
+			} else {
+				params = append(params, fmt.Sprintf("body: Optional[%s] = None", pythonType(e.Body.Schema, names)))
+			}
+		}
+		if len(e.Query) > 0 {
+			params = append(params, "*")
+			for _, param := range e.Query {
+				ident := pythonIdentifier(param.Name)
+				params = append(params, fmt.Sprintf("%s: Optional[%s] = None", ident, pythonParamType(param, names)))
+				query = append(query, fmt.Sprintf("%s: %s", strconv.Quote(param.Name), ident))
+			}
+			call = append(call, "query={"+strings.Join(query, ", ")+"}")
+		}
+		if e.Body != nil {
+			call = append(call, "body=body")
+		}
+		result := "None"
+		switch {
+		case e.Paginated:
+			result = "Page"
+			call = append(call, "page=True")
+		case e.Result != nil:
+			result = pythonType(e.Result, names)
+		}
+
+		fmt.Fprintf(&b, "\n    def %s(%s) -> %s:\n", snakeCase(e.Name), strings.Join(append([]string{"self"}, params...), ", "), result)
+		fmt.Fprintf(&b, "        %s\n", pythonDocstring(strings.TrimSuffix(firstLine(e.Summary), ".")+" ("+e.Method+" "+e.Path+")."))
+		fmt.Fprintf(&b, "        return self._request(%s)\n", strings.Join(call, ", "))
+	}
+	return []byte(b.String())
+}
+
+// pythonType returns the Python type of a schema.
+func pythonType(schema *Schema, names map[string]string) string {
+	if schema == nil {
+		return "Any"
+	}
+	if schema.Ref != "" {
+		if name, found := names[definitionName(schema.Ref)]; found {
+			return name
+		}
+		return "Any"
+	}
+	switch schema.Type {
+	case "string":
+		if literals := enumLiterals(schema.Enum); literals != nil {
+			return "Literal[" + strings.Join(literals, ", ") + "]"
+		}
+		return "str"
+	case "integer":
+		return "int"
+	case "number":
+		return "float"
+	case "boolean":
+		return "bool"
+	case "array":
+		return "List[" + pythonType(schema.Items, names) + "]"
+	case "object":
+		if schema.AdditionalProperties != nil {
+			return "Dict[str, " + pythonType(schema.AdditionalProperties, names) + "]"
+		}
+		return "Dict[str, Any]"
+	}
+	return "Any"
+}
+
+// pythonParamType returns the Python type of a path or query parameter.
+func pythonParamType(param Parameter, names map[string]string) string {
+	return pythonType(&Schema{Type: param.Type, Items: param.Items, Enum: param.Enum}, names)
+}
+
+// pythonIdentifier returns a name usable for a Python parameter.
+func pythonIdentifier(name string) string {
+	ident := snakeCase(splitIdentifier(name))
+	if ident == "" || !identifierPattern.MatchString(ident) {
+		ident = "param_" + ident
+	}
+	if pythonKeywords[ident] || ident == "self" || ident == "body" { // self and body are taken by the methods
+		ident += "_"
+	}
+	return ident
+}
+
+// pythonDocstring returns text as a one-line docstring.
+func pythonDocstring(text string) string {
+	text = strings.ReplaceAll(text, `\`, `\\`)
+	return `"""` + strings.ReplaceAll(text, `"`, `\"`) + `"""`
+}
+
+// pythonComment returns the first line of a description as a trailing comment, if there is one.
+func pythonComment(description string) string {
+	if line := firstLine(description); line != "" {
+		return "  # " + line
+	}
+	return ""
+}
+
+// enumLiterals returns the quoted values of a string enum, or nil if it has other values.
+func enumLiterals(values []any) []string {
+	var literals []string
+	for _, value := range values {
+		s, ok := value.(string)
+		if !ok {
+			return nil
+		}
+		literals = append(literals, strconv.Quote(s))
+	}
+	return literals
+}
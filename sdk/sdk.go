@@ -0,0 +1,61 @@
+// Package sdk generates client libraries for the API from its Swagger spec, the one the server
+// generates from its routes (see api.ClientSwagger). The clients are generated when building (go
+// generate ./sdk, after swag init) and embedded in the server, which serves them with its own base URL filled in. It also generates a Postman
+// collection of the spec (see GeneratePostman).
+package sdk
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+)
+
+//go:generate go run gen.go
+
+// BaseURLPlaceholder stands for the server's base URL in the generated clients.
+const BaseURLPlaceholder = "__DOCSERVER_BASE_URL__"
+
+// Language is a language clients are generated in.
+type Language struct {
+	Name        string // As in GET /sdk/{name}
+	File        string // Name of the generated file
+	ContentType string
+	Generate    func(spec *Spec) []byte
+}
+
+// Languages are the languages clients are generated in.
+var Languages = []Language{
+	{Name: "python", File: "docserver_client.py", ContentType: "text/x-python; charset=utf-8", Generate: GeneratePython},
+	{Name: "typescript", File: "docserver_client.ts", ContentType: "text/x-typescript; charset=utf-8", Generate: GenerateTypeScript},
+}
+
+// Embed the clients generated by gen.go
+//
+//go:embed generated
+var generatedFS embed.FS
+
+// LanguageByName looks up a language by its name.
+func LanguageByName(name string) (Language, bool) {
+	for _, language := range Languages {
+		if language.Name == name {
+			return language, true
+		}
+	}
+	return Language{}, false
+}
+
+// Source returns the embedded client of the language, calling the API at baseURL by default.
+func (l Language) Source(baseURL string) ([]byte, error) {
+	source, err := generatedFS.ReadFile("generated/" + l.File)
+	if err != nil {
+		return nil, err
+	}
+	return WithBaseURL(source, baseURL), nil
+}
+
+// WithBaseURL fills baseURL in for the quoted BaseURLPlaceholder of generated source.
+func WithBaseURL(source []byte, baseURL string) []byte {
+	// A JSON string is a valid string literal in Python and TypeScript too
+	literal, _ := json.Marshal(baseURL)
+	return bytes.ReplaceAll(source, []byte(`"`+BaseURLPlaceholder+`"`), literal)
+}
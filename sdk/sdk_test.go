@@ -0,0 +1,194 @@
+package sdk
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSpec has an enveloped response, a paginated list, and names needing care.
+const testSpec = `{
+	"swagger": "2.0",
+	"basePath": "/v1",
+	"paths": {
+		"/notes": {
+			"get": {
+				"summary": "List Notes",
+				"tags": ["Notes"],
+				"security": [{"BearerAuth": []}],
+				"parameters": [
+					{"name": "page", "in": "query", "type": "integer"},
+					{"name": "tag", "in": "query", "type": "array", "items": {"type": "string"}},
+					{"name": "from", "in": "query", "type": "string", "enum": ["a", "b"]},
+					{"name": "content_query", "in": "query", "type": "array", "items": {"type": "string"}}
+				],
+				"responses": {"200": {"schema": {"allOf": [
+					{"$ref": "#/definitions/utils.Envelope"},
+					{"type": "object", "properties": {"data": {"type": "array", "items": {"$ref": "#/definitions/models.Note"}}}}
+				]}}}
+			},
+			"post": {
+				"summary": "Create a Note.",
+				"tags": ["Notes"],
+				"security": [{"BearerAuth": []}],
+				"parameters": [{"name": "note", "in": "body", "required": true, "schema": {"$ref": "#/definitions/models.Note"}}],
+				"responses": {"201": {"schema": {"allOf": [
+					{"$ref": "#/definitions/utils.Envelope"},
+					{"type": "object", "properties": {"data": {"$ref": "#/definitions/models.Note"}}}
+				]}}}
+			}
+		},
+		"/auth/login": {
+			"post": {"summary": "Log In", "tags": ["Auth"], "responses": {"200": {}}}
+		},
+		"/notes/{noteID}": {
+			"delete": {"summary": "Delete a Note", "responses": {"204": {}}}
+		}
+	},
+	"definitions": {
+		"models.Note": {"type": "object", "required": ["text"], "properties": {
+			"text": {"type": "string", "description": "The note\nand more", "example": "Buy milk"},
+			"priority": {"type": "integer"}
+		}},
+		"api.Page": {"type": "object", "properties": {"@this": {"type": "integer"}}},
+		"utils.Envelope": {"type": "object", "properties": {"data": {}}}
+	}
+}`
+
+func TestSplitIdentifier(t *testing.T) {
+	assert.Equal(t, []string{"api", "error"}, splitIdentifier("APIError"))
+	assert.Equal(t, []string{"profile", "id"}, splitIdentifier("profile_id"))
+	assert.Equal(t, []string{"get", "document", "by", "id"}, splitIdentifier("getDocumentByID"))
+	assert.Equal(t, []string{"forgot", "password"}, splitIdentifier("forgot-password"))
+}
+
+func TestEndpoints(t *testing.T) {
+	spec, err := ParseSpec([]byte(testSpec))
+	require.NoError(t, err)
+	endpoints := spec.endpoints()
+	require.Len(t, endpoints, 4)
+	assert.Equal(t, "/auth/login", endpoints[0].Path, "by path")
+	assert.False(t, endpoints[0].Secured)
+	endpoints = endpoints[1:]
+
+	list := endpoints[0]
+	assert.True(t, list.Secured)
+	assert.Equal(t, []string{"Notes"}, list.Tags)
+	assert.Equal(t, []string{"get", "notes"}, list.Name)
+	assert.True(t, list.Paginated)
+	assert.Equal(t, "#/definitions/models.Note", list.Result.Ref, "the item of the page")
+
+	create := endpoints[1]
+	assert.False(t, create.Paginated)
+	assert.Equal(t, "#/definitions/models.Note", create.Result.Ref, "the data of the envelope")
+	require.NotNil(t, create.Body)
+
+	remove := endpoints[2]
+	assert.Equal(t, []string{"delete", "notes", "by", "note", "id"}, remove.Name)
+	assert.Nil(t, remove.Result)
+	require.Len(t, remove.PathParams, 1, "undeclared path parameters are strings")
+
+	_, err = ParseSpec([]byte(`{"paths": {}}`))
+	assert.Error(t, err)
+	_, err = ParseSpec([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestGenerate(t *testing.T) {
+	spec, err := ParseSpec([]byte(testSpec))
+	require.NoError(t, err)
+
+	python := string(GeneratePython(spec))
+	assert.Contains(t, python, "class Note(TypedDict, total=False):")
+	assert.Contains(t, python, "    text: str  # The note\n")
+	assert.Contains(t, python, `ApiPage = TypedDict("ApiPage", {"@this": int}, total=False)`, "Page is taken, and @this needs the functional syntax")
+	assert.Contains(t, python, `def get_notes(self, *, page: Optional[int] = None, tag: Optional[List[str]] = None, from_: Optional[Literal["a", "b"]] = None, content_query: Optional[List[str]] = None) -> Page:`)
+	assert.Contains(t, python, `return self._request("GET", "/notes", query={"page": page, "tag": tag, "from": from_, "content_query": content_query}, page=True)`)
+	assert.Contains(t, python, `def post_notes(self, body: Note) -> Note:`)
+	assert.Contains(t, python, `"""Create a Note (POST /notes)."""`)
+	assert.Contains(t, python, `def delete_notes_by_note_id(self, note_id: str) -> None:`)
+	assert.Contains(t, python, `return self._request("DELETE", f"/notes/{_path(note_id)}")`)
+
+	typeScript := string(GenerateTypeScript(spec))
+	assert.Contains(t, typeScript, "export interface Note {\n  priority?: number;\n  /** The note */\n  text: string;\n}", "required fields are not optional")
+	assert.Contains(t, typeScript, "export interface ApiPage {\n  \"@this\"?: number;\n}")
+	assert.Contains(t, typeScript, `getNotes(query: { page?: number; tag?: string[]; from?: "a" | "b"; content_query?: string[] } = {}): Promise<Page<Note>> {`)
+	assert.Contains(t, typeScript, `return this.request("GET", "/notes", query, undefined, true);`)
+	assert.Contains(t, typeScript, `postNotes(body: Note): Promise<Note> {`)
+	assert.Contains(t, typeScript, "deleteNotesByNoteId(noteId: string): Promise<void> {\n    return this.request(\"DELETE\", `/notes/${encodeURIComponent(noteId)}`);")
+}
+
+func TestSource(t *testing.T) {
+	python, found := LanguageByName("python")
+	require.True(t, found)
+	source, err := python.Source(`https://docs.example.com/v1`)
+	require.NoError(t, err)
+	assert.Contains(t, string(source), "\nBASE_URL = \"https://docs.example.com/v1\"\n")
+	assert.NotContains(t, string(source), BaseURLPlaceholder)
+
+	typeScript, found := LanguageByName("typescript")
+	require.True(t, found)
+	source, err = typeScript.Source(`http://a"b/v1`)
+	require.NoError(t, err)
+	assert.True(t, strings.Contains(string(source), `export const BASE_URL = "http://a\"b/v1";`), "quotes are escaped")
+
+	_, found = LanguageByName("cobol")
+	assert.False(t, found)
+}
+
+func TestGeneratePostman(t *testing.T) {
+	spec, err := ParseSpec([]byte(testSpec))
+	require.NoError(t, err)
+	var collection postmanCollection
+	require.NoError(t, json.Unmarshal(GeneratePostman(spec), &collection))
+
+	assert.Equal(t, postmanSchema, collection.Info.Schema)
+	assert.Equal(t, "bearer", collection.Auth.Type)
+	assert.Equal(t, []postmanVariable{{Key: "baseUrl", Value: BaseURLPlaceholder, Type: "string"}, {Key: "token", Value: "", Type: "string"}}, collection.Variable)
+	require.Len(t, collection.Item, 4)
+	assert.Equal(t, []string{"Auth", "Notes", "Other", "Search by Content (content_query examples)"},
+		[]string{collection.Item[0].Name, collection.Item[1].Name, collection.Item[2].Name, collection.Item[3].Name}, "a folder per tag, in path order")
+
+	login := collection.Item[0].Item[0]
+	assert.Equal(t, "noauth", login.Request.Auth.Type)
+	require.Len(t, login.Event, 1, "logging in stores the token")
+	assert.Contains(t, strings.Join(login.Event[0].Script.Exec, "\n"), `pm.collectionVariables.set("token"`)
+
+	notes := collection.Item[1].Item
+	require.Len(t, notes, 2)
+	list := notes[0].Request
+	assert.Nil(t, list.Auth, "uses the collection's bearer token")
+	assert.Equal(t, "{{baseUrl}}/notes", list.URL.Raw, "optional parameters are disabled")
+	assert.Equal(t, postmanVariable{Key: "from", Value: "a", Disabled: true}, list.URL.Query[2])
+	assert.Equal(t, postmanVariable{Key: "content_query", Value: `status equals "active"`, Disabled: true}, list.URL.Query[3])
+	create := notes[1].Request
+	assert.Equal(t, "Create a Note", notes[1].Name)
+	require.NotNil(t, create.Body)
+	assert.JSONEq(t, `{"priority": 0, "text": "Buy milk"}`, create.Body.Raw, "examples of the spec are used")
+
+	remove := collection.Item[2].Item[0].Request
+	assert.Equal(t, []string{"notes", ":noteID"}, remove.URL.Path)
+	assert.Equal(t, []postmanVariable{{Key: "noteID"}}, remove.URL.Variable)
+
+	searches := collection.Item[3].Item
+	require.Len(t, searches, len(contentQueryExamples))
+	assert.Equal(t, "Explicit AND", searches[4].Name)
+	assert.Equal(t, `{{baseUrl}}/notes?content_query=project equals "Alpha"&content_query=and&content_query=status equals "active"`, searches[4].Request.URL.Raw)
+}
+
+func TestExampleValue(t *testing.T) {
+	spec := &Spec{Definitions: map[string]*Schema{"api.Signup": {Type: "object", Properties: map[string]*Schema{
+		"email":   {Type: "string"},
+		"extra":   {},
+		"content": {},
+		"tags":    {Type: "array", Items: &Schema{Type: "string", Enum: []any{"x"}}},
+	}}}}
+	assert.Equal(t, map[string]any{"email": "ada@example.com", "extra": map[string]any{}, "content": exampleContent, "tags": []any{"x"}},
+		exampleValue(spec, &Schema{Ref: "#/definitions/api.Signup"}, "", 0))
+	assert.Equal(t, map[string]any{}, exampleValue(spec, &Schema{Ref: "#/definitions/missing"}, "", 0))
+	assert.Equal(t, "1", exampleParam(Parameter{Name: "limit", Type: "integer"}))
+	assert.Equal(t, "false", exampleParam(Parameter{Name: "public", Type: "boolean"}))
+}
@@ -0,0 +1,326 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// --- Swagger Spec ---
+
+// Spec is the part of a Swagger 2.0 document (docs/swagger.json) the generators use.
+type Spec struct {
+	Host        string                          `json:"host"`
+	BasePath    string                          `json:"basePath"`
+	Paths       map[string]map[string]Operation `json:"paths"`
+	Definitions map[string]*Schema              `json:"definitions"`
+}
+
+// Operation is one method of a path.
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary"`
+	Tags        []string              `json:"tags"`
+	Security    []map[string][]string `json:"security"` // Set on operations needing a token
+	Parameters  []Parameter           `json:"parameters"`
+	Responses   map[string]Response   `json:"responses"`
+}
+
+// Parameter is a path, query or body parameter of an operation.
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"` // path, query or body
+	Required bool    `json:"required"`
+	Type     string  `json:"type"`
+	Items    *Schema `json:"items"`
+	Enum     []any   `json:"enum"`
+	Schema   *Schema `json:"schema"` // Body parameters
+}
+
+// Response is the documented response of an operation for one status code.
+type Response struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is a JSON schema of the spec, as swag writes them.
+type Schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Description          string             `json:"description"`
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	Items                *Schema            `json:"items"`
+	AdditionalProperties *Schema            `json:"additionalProperties"`
+	AllOf                []*Schema          `json:"allOf"`
+	Enum                 []any              `json:"enum"`
+	Example              any                `json:"example"`
+}
+
+// ParseSpec decodes a Swagger 2.0 document.
+func ParseSpec(data []byte) (*Spec, error) {
+	var spec Spec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("invalid swagger spec: %w", err)
+	}
+	if len(spec.Paths) == 0 {
+		return nil, fmt.Errorf("invalid swagger spec: no paths")
+	}
+	return &spec, nil
+}
+
+// endpoint is an operation of the spec in the shape the generators need.
+type endpoint struct {
+	Method     string   // Upper case, e.g. GET
+	Path       string   // e.g. /documents/{id}
+	Name       []string // Words of the method name, e.g. get, documents, by, id
+	Summary    string
+	Tags       []string
+	Secured    bool        // Needs a token
+	PathParams []Parameter // In path order
+	Query      []Parameter
+	Body       *Parameter
+	Result     *Schema // Schema of the successful response's data; nil if there is none
+	Paginated  bool    // Lists answer with data, meta and links; Result is the item schema
+}
+
+// methodOrder is the order of the operations of a path.
+var methodOrder = []string{"get", "post", "put", "patch", "delete"}
+
+// pathParamPattern matches the parameters of a path template.
+var pathParamPattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+// endpoints lists the operations of the spec by path, then method.
+func (s *Spec) endpoints() []endpoint {
+	paths := make([]string, 0, len(s.Paths))
+	for path := range s.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var endpoints []endpoint
+	taken := make(map[string]bool)
+	for _, path := range paths {
+		for _, method := range methodOrder {
+			op, found := s.Paths[path][method]
+			if !found {
+				continue
+			}
+			e := endpoint{Method: strings.ToUpper(method), Path: path, Summary: op.Summary, Tags: op.Tags, Secured: len(op.Security) > 0, Name: operationName(method, path, op.OperationID)}
+			base := e.Name
+			for n := 2; taken[snakeCase(e.Name)]; n++ { // Paths such as /a/{b} and /a/by/b would share a name
+				e.Name = append(base[:len(base):len(base)], strconv.Itoa(n))
+			}
+			taken[snakeCase(e.Name)] = true
+			byName := make(map[string]Parameter)
+			for _, param := range op.Parameters {
+				switch param.In {
+				case "path":
+					byName[param.Name] = param
+				case "query":
+					e.Query = append(e.Query, param)
+				case "body":
+					body := param
+					e.Body = &body
+				}
+			}
+			for _, match := range pathParamPattern.FindAllStringSubmatch(path, -1) {
+				param, found := byName[match[1]]
+				if !found {
+					param = Parameter{Name: match[1], In: "path", Required: true, Type: "string"}
+				}
+				e.PathParams = append(e.PathParams, param)
+			}
+			e.Result = s.successSchema(op)
+			if e.Result != nil && hasQueryParam(e.Query, "page") {
+				// Lists are documented as the envelope's data array, or as the legacy {data, page, limit, total}
+				if e.Result.Type == "array" {
+					e.Result, e.Paginated = e.Result.Items, true
+				} else if data := s.resolve(e.Result).Properties["data"]; data != nil && data.Type == "array" {
+					e.Result, e.Paginated = data.Items, true
+				}
+			}
+			endpoints = append(endpoints, e)
+		}
+	}
+	return endpoints
+}
+
+// successSchema returns the schema of the data of an operation's first 2xx response, without
+// the response envelope (utils.Envelope{data=...}), or nil if it has none.
+func (s *Spec) successSchema(op Operation) *Schema {
+	codes := make([]string, 0, len(op.Responses))
+	for code := range op.Responses {
+		if strings.HasPrefix(code, "2") {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		schema := op.Responses[code].Schema
+		if schema == nil {
+			continue
+		}
+		for _, part := range schema.AllOf {
+			if data := part.Properties["data"]; data != nil {
+				return data
+			}
+		}
+		return schema
+	}
+	return nil
+}
+
+// resolve follows a schema's reference to its definition.
+func (s *Spec) resolve(schema *Schema) *Schema {
+	if schema.Ref != "" {
+		if def := s.Definitions[definitionName(schema.Ref)]; def != nil {
+			return def
+		}
+	}
+	return schema
+}
+
+// hasQueryParam reports whether params holds a parameter of that name.
+func hasQueryParam(params []Parameter, name string) bool {
+	for _, param := range params {
+		if param.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// operationName returns the words of an operation's method name: those of its operationId when
+// set, or else the method and path, with parameters as "by {name}" (GET /documents/{id} is
+// get documents by id).
+func operationName(method, path, operationID string) []string {
+	if operationID != "" {
+		return splitIdentifier(operationID)
+	}
+	words := []string{method}
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" {
+			continue
+		}
+		if match := pathParamPattern.FindStringSubmatch(segment); match != nil {
+			words = append(words, "by")
+			segment = match[1]
+		}
+		words = append(words, splitIdentifier(segment)...)
+	}
+	return words
+}
+
+// splitIdentifier splits a name on anything but letters and digits and where a new word starts
+// with an upper case letter, into lower case words.
+func splitIdentifier(name string) []string {
+	var words []string
+	var current []rune
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, strings.ToLower(string(current)))
+			current = nil
+		}
+	}
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) ||
+			unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1])): // The end of an acronym, as in APIError
+			flush()
+			current = append(current, r)
+		default:
+			current = append(current, r)
+		}
+	}
+	flush()
+	return words
+}
+
+// definitionName returns the definition a reference points to.
+func definitionName(ref string) string {
+	return strings.TrimPrefix(ref, "#/definitions/")
+}
+
+// reservedTypeNames are the names the clients give their own types.
+var reservedTypeNames = map[string]bool{"Page": true, "PageMeta": true, "PageLinks": true, "DocServerError": true, "DocServerClient": true, "QueryValue": true}
+
+// typeNames names the definitions' types after the last part of their names (models.Document is
+// Document), or after all of it where two definitions would share one (ApiProfile, ModelsProfile)
+// or it is taken by the clients.
+func (s *Spec) typeNames() map[string]string {
+	short := func(name string) string {
+		return pascalCase(splitIdentifier(name[strings.LastIndex(name, ".")+1:]))
+	}
+	count := make(map[string]int)
+	for name := range s.Definitions {
+		count[short(name)]++
+	}
+	names := make(map[string]string, len(s.Definitions))
+	for name := range s.Definitions {
+		if count[short(name)] > 1 || reservedTypeNames[short(name)] {
+			names[name] = pascalCase(splitIdentifier(name))
+		} else {
+			names[name] = short(name)
+		}
+	}
+	return names
+}
+
+// sortedDefinitions returns the names of the spec's definitions in order.
+func (s *Spec) sortedDefinitions() []string {
+	names := make([]string, 0, len(s.Definitions))
+	for name := range s.Definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sortedProperties returns the names of a schema's properties in order.
+func sortedProperties(schema *Schema) []string {
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// pascalCase joins words as in ProfileResponse.
+func pascalCase(words []string) string {
+	var b strings.Builder
+	for _, word := range words {
+		if word == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+// camelCase joins words as in getDocumentsById.
+func camelCase(words []string) string {
+	name := pascalCase(words)
+	if name == "" {
+		return name
+	}
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+// snakeCase joins words as in get_documents_by_id.
+func snakeCase(words []string) string {
+	return strings.Join(words, "_")
+}
+
+// firstLine returns the first line of text, trimmed.
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	return strings.TrimSpace(line)
+}
@@ -0,0 +1,253 @@
+package sdk
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// --- TypeScript Client ---
+
+// typeScriptPreamble is the start of the TypeScript client, up to the types.
+const typeScriptPreamble = `// DocServer API client, generated from the server's Swagger spec by "go generate ./sdk". Do not edit.
+//
+//   import { DocServerClient } from "./docserver_client";
+//
+//   const client = new DocServerClient();
+//   client.token = (await client.postAuthLogin({ email: "ada@example.com", password: "secret123" })).token;
+//   console.log((await client.getDocuments({ limit: 5 })).data);
+//
+// Methods resolve to the data of the response, and lists to the page with its data, meta and
+// links. Error responses reject with a DocServerError. Uses fetch (browsers, Node.js 18 or later).
+
+export const BASE_URL = "` + BaseURLPlaceholder + `";
+
+/** An error response of the API. */
+export class DocServerError extends Error {
+  readonly status: number;
+  readonly code: string;
+
+  constructor(status: number, code: string, message: string) {
+    super(` + "`${status} ${code}: ${message}`" + `);
+    this.name = "DocServerError";
+    this.status = status;
+    this.code = code;
+  }
+}
+
+/** Pagination state of a list. */
+export interface PageMeta {
+  total: number;
+  page: number;
+  limit: number;
+  total_pages: number;
+}
+
+/** Links to the pages of a list; next and prev are left out when there is no such page. */
+export interface PageLinks {
+  self: string;
+  first: string;
+  last: string;
+  next?: string;
+  prev?: string;
+}
+
+/** One page of a list. */
+export interface Page<T> {
+  data: T[];
+  meta: PageMeta;
+  links: PageLinks;
+}
+`
+
+// typeScriptClient is the start of the client class, up to the operations.
+const typeScriptClient = `
+type QueryValue = string | number | boolean | undefined;
+
+/** Calls the DocServer API at baseUrl, authenticated with token once it is set. */
+export class DocServerClient {
+  baseUrl: string;
+  token?: string;
+
+  constructor(baseUrl: string = BASE_URL, token?: string) {
+    this.baseUrl = baseUrl.replace(/\/+$/, "");
+    this.token = token;
+  }
+
+  private async request<T>(method: string, path: string, query?: Record<string, QueryValue | QueryValue[]>, body?: unknown, page = false): Promise<T> {
+    const params = new URLSearchParams();
+    for (const [key, value] of Object.entries(query ?? {})) {
+      for (const item of Array.isArray(value) ? value : [value]) {
+        if (item !== undefined) params.append(key, String(item));
+      }
+    }
+    const search = params.toString();
+    const headers: Record<string, string> = { Accept: "application/json" };
+    if (body !== undefined) headers["Content-Type"] = "application/json";
+    if (this.token) headers["Authorization"] = ` + "`Bearer ${this.token}`" + `;
+    const response = await fetch(this.baseUrl + path + (search ? "?" + search : ""), {
+      method,
+      headers,
+      body: body === undefined ? undefined : JSON.stringify(body),
+    });
+    const text = await response.text();
+    if (!response.ok) {
+      let error: { status?: number; code?: string; message?: string } = {};
+      try {
+        error = JSON.parse(text).error ?? {};
+      } catch {
+        // Not a JSON error: the text is the message
+      }
+      throw new DocServerError(error.status ?? response.status, error.code ?? "", error.message ?? text);
+    }
+    if (!text) return undefined as T;
+    const envelope = JSON.parse(text);
+    return (page ? envelope : envelope.data) as T;
+  }
+`
+
+// GenerateTypeScript returns a TypeScript client for the spec: an interface per definition and a
+// DocServerClient with a method per operation, using fetch.
+func GenerateTypeScript(spec *Spec) []byte {
+	names := spec.typeNames()
+	var b strings.Builder
+	b.WriteString(typeScriptPreamble)
+
+	for _, def := range spec.sortedDefinitions() {
+		schema := spec.Definitions[def]
+		required := make(map[string]bool, len(schema.Required))
+		for _, name := range schema.Required {
+			required[name] = true
+		}
+		fmt.Fprintf(&b, "\n/** %s of the API. */\nexport interface %s {\n", typeScriptComment(def), names[def])
+		for _, property := range sortedProperties(schema) {
+			if line := firstLine(schema.Properties[property].Description); line != "" {
+				fmt.Fprintf(&b, "  /** %s */\n", typeScriptComment(line))
+			}
+			optional := "?"
+			if required[property] {
+				optional = ""
+			}
+			fmt.Fprintf(&b, "  %s%s: %s;\n", typeScriptKey(property), optional, typeScriptType(schema.Properties[property], names))
+		}
+		b.WriteString("}\n")
+	}
+
+	b.WriteString(typeScriptClient)
+	for _, e := range spec.endpoints() {
+		var params []string
+		path := e.Path
+		for _, param := range e.PathParams {
+			ident := typeScriptIdentifier(param.Name)
+			params = append(params, fmt.Sprintf("%s: %s", ident, typeScriptParamType(param, names)))
+			path = strings.Replace(path, "{"+param.Name+"}", "${encodeURIComponent("+ident+")}", 1)
+		}
+		if len(e.PathParams) > 0 {
+			path = "`" + path + "`"
+		} else {
+			path = strconv.Quote(path)
+		}
+		call := []string{strconv.Quote(e.Method), path, "undefined", "undefined"}
+		if e.Body != nil {
+			if e.Body.Required {
+				params = append(params, "body: "+typeScriptType(e.Body.Schema, names))
+			} else {
+				params = append(params, "body?: "+typeScriptType(e.Body.Schema, names))
+			}
+			call[3] = "body"
+		}
+		if len(e.Query) > 0 {
+			fields := make([]string, len(e.Query))
+			for i, param := range e.Query {
+				fields[i] = fmt.Sprintf("%s?: %s", typeScriptKey(param.Name), typeScriptParamType(param, names))
+			}
+			params = append(params, "query: { "+strings.Join(fields, "; ")+" } = {}")
+			call[2] = "query"
+		}
+		result := "void"
+		switch {
+		case e.Paginated:
+			result = "Page<" + typeScriptType(e.Result, names) + ">"
+			call = append(call, "true")
+		case e.Result != nil:
+			result = typeScriptType(e.Result, names)
+		}
+		// Leave out trailing undefined arguments
+		for len(call) > 2 && call[len(call)-1] == "undefined" {
+			call = call[:len(call)-1]
+		}
+
+		fmt.Fprintf(&b, "\n  /** %s (%s %s). */\n", typeScriptComment(strings.TrimSuffix(firstLine(e.Summary), ".")), e.Method, e.Path)
+		fmt.Fprintf(&b, "  %s(%s): Promise<%s> {\n", camelCase(e.Name), strings.Join(params, ", "), result)
+		fmt.Fprintf(&b, "    return this.request(%s);\n  }\n", strings.Join(call, ", "))
+	}
+	b.WriteString("}\n")
+	return []byte(b.String())
+}
+
+// typeScriptType returns the TypeScript type of a schema.
+func typeScriptType(schema *Schema, names map[string]string) string {
+	if schema == nil {
+		return "unknown"
+	}
+	if schema.Ref != "" {
+		if name, found := names[definitionName(schema.Ref)]; found {
+			return name
+		}
+		return "unknown"
+	}
+	switch schema.Type {
+	case "string":
+		if literals := enumLiterals(schema.Enum); literals != nil {
+			return strings.Join(literals, " | ")
+		}
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "array":
+		item := typeScriptType(schema.Items, names)
+		if strings.Contains(item, " ") {
+			item = "(" + item + ")"
+		}
+		return item + "[]"
+	case "object":
+		if schema.AdditionalProperties != nil {
+			return "Record<string, " + typeScriptType(schema.AdditionalProperties, names) + ">"
+		}
+		return "Record<string, unknown>"
+	}
+	return "unknown"
+}
+
+// typeScriptParamType returns the TypeScript type of a path or query parameter.
+func typeScriptParamType(param Parameter, names map[string]string) string {
+	return typeScriptType(&Schema{Type: param.Type, Items: param.Items, Enum: param.Enum}, names)
+}
+
+// typeScriptKey returns a property name, quoted if it is not an identifier.
+func typeScriptKey(name string) string {
+	if identifierPattern.MatchString(name) {
+		return name
+	}
+	return strconv.Quote(name)
+}
+
+// typeScriptIdentifier returns a name usable for a TypeScript parameter.
+func typeScriptIdentifier(name string) string {
+	ident := camelCase(splitIdentifier(name))
+	if ident == "" || !identifierPattern.MatchString(ident) {
+		ident = "param" + pascalCase(splitIdentifier(name))
+	}
+	switch ident {
+	case "body", "query", "delete", "function", "new", "default", "class", "in", "this":
+		ident += "Param" // Taken by the methods, or reserved
+	}
+	return ident
+}
+
+// typeScriptComment makes text safe inside a /** */ comment.
+func typeScriptComment(text string) string {
+	return strings.ReplaceAll(text, "*/", "*\\/")
+}
@@ -0,0 +1,66 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+	router.GET("/documents/:id", func(c *gin.Context) {
+		_, span := Start(c.Request.Context(), "db.GetDocumentByID")
+		span.End()
+		c.Set("userID", "user-1")
+		c.Status(http.StatusInternalServerError)
+	})
+
+	t.Run("Off", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/documents/abc", nil))
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+
+	t.Run("Server span continues the caller's trace", func(t *testing.T) {
+		exporter := enableRecording(t)
+		req := httptest.NewRequest(http.MethodGet, "/documents/abc", nil)
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+		Flush()
+
+		server := exporter.byName(t, "GET /documents/:id")
+		assert.Equal(t, trace.SpanKindServer, server.SpanKind)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext.TraceID().String())
+		assert.Equal(t, "00f067aa0ba902b7", server.Parent.SpanID().String())
+		assert.True(t, server.Parent.IsRemote())
+		assert.Contains(t, server.Attributes, attribute.String("url.path", "/documents/abc"))
+		assert.Contains(t, server.Attributes, attribute.Int("http.response.status_code", http.StatusInternalServerError))
+		assert.Contains(t, server.Attributes, attribute.String("enduser.id", "user-1"))
+		assert.Equal(t, codes.Error, server.Status.Code, "5xx responses mark the span as failed")
+
+		child := exporter.byName(t, "db.GetDocumentByID")
+		assert.Equal(t, server.SpanContext.SpanID(), child.Parent.SpanID(), "handlers start children from the request context")
+	})
+
+	t.Run("Invalid traceparent starts a new trace", func(t *testing.T) {
+		for _, invalid := range []string{"garbage", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", "00-4bf92f3577b34da6a3ce929d0e0e4736-zzf067aa0ba902b7-01"} {
+			exporter := enableRecording(t)
+			req := httptest.NewRequest(http.MethodGet, "/documents/abc", nil)
+			req.Header.Set("traceparent", invalid)
+			router.ServeHTTP(httptest.NewRecorder(), req)
+			Flush()
+
+			server := exporter.byName(t, "GET /documents/:id")
+			assert.False(t, server.Parent.IsValid(), invalid)
+			assert.True(t, server.SpanContext.IsValid(), invalid)
+		}
+	})
+}
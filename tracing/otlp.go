@@ -0,0 +1,35 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+)
+
+// --- OTLP Export ---
+
+// NewOTLPExporter returns an exporter sending spans to the OpenTelemetry collector at endpoint
+// (e.g. http://localhost:4318) using OTLP over HTTP, with headers sent along with every request,
+// e.g. for authentication. A trailing /v1/traces is added unless the endpoint already ends with it.
+func NewOTLPExporter(endpoint string, headers map[string]string) (*otlptrace.Exporter, error) {
+	url := strings.TrimRight(endpoint, "/")
+	if !strings.HasSuffix(url, "/v1/traces") {
+		url += "/v1/traces"
+	}
+	return otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(url), otlptracehttp.WithHeaders(headers))
+}
+
+// ParseHeaders parses OTLP headers in the OTEL_EXPORTER_OTLP_HEADERS form "key1=value1,key2=value2".
+// Malformed entries are skipped.
+func ParseHeaders(s string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		key, value, found := strings.Cut(pair, "=")
+		if key = strings.TrimSpace(key); found && key != "" {
+			headers[key] = strings.TrimSpace(value)
+		}
+	}
+	return headers
+}
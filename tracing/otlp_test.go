@@ -0,0 +1,69 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestParseHeaders(t *testing.T) {
+	assert.Equal(t, map[string]string{"api-key": "secret", "team": "docs"}, ParseHeaders("api-key = secret, malformed,team=docs,=empty"))
+	assert.Empty(t, ParseHeaders(""))
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var received collectortrace.ExportTraceServiceRequest
+	var header http.Header
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		header = r.Header
+		body, _ := io.ReadAll(r.Body)
+		require.NoError(t, proto.Unmarshal(body, &received))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	for _, endpoint := range []string{collector.URL + "/", collector.URL + "/v1/traces"} {
+		exporter, err := NewOTLPExporter(endpoint, ParseHeaders("api-key=secret"))
+		require.NoError(t, err)
+		shutdown := Enable(exporter, "docserver-test")
+		ctx, parent := Start(context.Background(), "GET /documents")
+		_, child := Start(ctx, "db.QueryDocuments")
+		child.SetAttributes(attribute.Int("documents.matched", 2))
+		RecordError(child, errors.New("boom"))
+		child.End()
+		parent.End()
+		shutdown()
+
+		assert.Equal(t, "application/x-protobuf", header.Get("Content-Type"), endpoint)
+		assert.Equal(t, "secret", header.Get("api-key"), endpoint)
+		require.Len(t, received.ResourceSpans, 1, endpoint)
+		resourceSpans := received.ResourceSpans[0]
+		assert.Equal(t, "service.name", resourceSpans.Resource.Attributes[0].Key)
+		assert.Equal(t, "docserver-test", resourceSpans.Resource.Attributes[0].Value.GetStringValue())
+		spans := resourceSpans.ScopeSpans[0].Spans
+		require.Len(t, spans, 2, endpoint)
+		assert.Equal(t, "db.QueryDocuments", spans[0].Name)
+		assert.Equal(t, spans[1].SpanId, spans[0].ParentSpanId)
+		assert.Equal(t, "boom", spans[0].Status.Message)
+	}
+	t.Run("Collector errors are reported", func(t *testing.T) {
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer failing.Close()
+		exporter, err := NewOTLPExporter(failing.URL, nil)
+		require.NoError(t, err)
+		assert.Error(t, exporter.ExportSpans(context.Background(), tracetest.SpanStubs{{Name: "lost"}}.Snapshots()))
+	})
+}
@@ -0,0 +1,77 @@
+// Package tracing records OpenTelemetry trace spans for requests and database operations with
+// the OpenTelemetry SDK and exports them to an OTLP collector (see otlp.go). Tracing is off until
+// Enable is called; while it is off, Start returns non-recording spans that cost next to nothing.
+package tracing
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// instrumentationName names the tracer the server's spans are recorded with.
+const instrumentationName = "docserver"
+
+// active is the tracer provider in use, or nil while tracing is off.
+var active atomic.Pointer[sdktrace.TracerProvider]
+
+// Enabled reports whether spans are being recorded.
+func Enabled() bool {
+	return active.Load() != nil
+}
+
+// Start begins an internal span named name, as a child of the span in ctx if there is one.
+// It returns a context carrying the new span. Call End on the span when the operation is done.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+}
+
+// RecordError records err on span and marks the span as failed, if err is not nil.
+func RecordError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// Enable starts recording spans and exporting them in batches with exporter, reporting them
+// under serviceName. Trace context is propagated in W3C traceparent headers. The returned
+// function stops tracing and exports the spans still queued; call it before the program exits.
+func Enable(exporter sdktrace.SpanExporter, serviceName string) (shutdown func()) {
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	active.Store(provider)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			if active.CompareAndSwap(provider, nil) {
+				otel.SetTracerProvider(noop.NewTracerProvider())
+			}
+			if err := provider.Shutdown(context.Background()); err != nil {
+				log.Printf("WARN: Failed to export the last trace spans: %v", err)
+			}
+		})
+	}
+}
+
+// Flush exports all spans finished so far. It is mainly useful in tests.
+func Flush() {
+	if provider := active.Load(); provider != nil {
+		_ = provider.ForceFlush(context.Background())
+	}
+}
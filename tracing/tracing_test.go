@@ -0,0 +1,94 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordingExporter keeps exported spans for inspection.
+type recordingExporter struct {
+	*tracetest.InMemoryExporter
+}
+
+// Shutdown keeps the spans, which the in-memory exporter would drop, to check what was exported.
+func (r recordingExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+// byName returns the exported span called name.
+func (r recordingExporter) byName(t *testing.T, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, span := range r.GetSpans() {
+		if span.Name == name {
+			return span
+		}
+	}
+	require.Failf(t, "span not exported", "no span named %q", name)
+	return tracetest.SpanStub{}
+}
+
+// enableRecording turns tracing on for the duration of the test.
+func enableRecording(t *testing.T) recordingExporter {
+	exporter := recordingExporter{tracetest.NewInMemoryExporter()}
+	shutdown := Enable(exporter, "docserver-test")
+	t.Cleanup(shutdown)
+	return exporter
+}
+
+func TestSpans(t *testing.T) {
+	t.Run("Off by default", func(t *testing.T) {
+		assert.False(t, Enabled())
+		_, span := Start(context.Background(), "ignored")
+		assert.False(t, span.IsRecording())
+		assert.False(t, span.SpanContext().IsValid())
+		// Non-recording spans accept every call
+		span.SetAttributes(attribute.Int("key", 1))
+		RecordError(span, errors.New("boom"))
+		span.End()
+	})
+
+	t.Run("Parent and child", func(t *testing.T) {
+		exporter := enableRecording(t)
+
+		ctx, parent := Start(context.Background(), "parent")
+		_, child := Start(ctx, "child")
+		child.SetAttributes(attribute.Int("documents.scanned", 3))
+		RecordError(child, errors.New("boom"))
+		RecordError(child, nil) // No effect
+		child.End()
+		child.End() // Only exported once
+		parent.End()
+		Flush()
+
+		exported := exporter.byName(t, "child")
+		assert.Equal(t, parent.SpanContext().TraceID(), exported.SpanContext.TraceID())
+		assert.Equal(t, parent.SpanContext().SpanID(), exported.Parent.SpanID())
+		assert.Equal(t, []attribute.KeyValue{attribute.Int("documents.scanned", 3)}, exported.Attributes)
+		assert.Equal(t, codes.Error, exported.Status.Code)
+		assert.Equal(t, "boom", exported.Status.Description)
+		serviceName, _ := exported.Resource.Set().Value("service.name")
+		assert.Equal(t, "docserver-test", serviceName.AsString())
+		assert.False(t, exporter.byName(t, "parent").Parent.IsValid(), "root span")
+		assert.Len(t, exporter.GetSpans(), 2)
+	})
+
+	t.Run("Shutdown exports queued spans", func(t *testing.T) {
+		exporter := recordingExporter{tracetest.NewInMemoryExporter()}
+		shutdown := Enable(exporter, "docserver-test")
+		_, span := Start(context.Background(), "last")
+		span.End()
+		shutdown()
+		assert.False(t, Enabled())
+		exporter.byName(t, "last")
+
+		_, span = Start(context.Background(), "after")
+		assert.False(t, span.IsRecording(), "spans are not recorded once tracing is off")
+	})
+}
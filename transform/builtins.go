@@ -0,0 +1,90 @@
+package transform
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// --- Built-in Transformers ---
+
+// wordCount writes the number of words in the string at Field to Target (default "word_count").
+func wordCount(content map[string]any, rule Rule) error {
+	text, ok := stringAt(content, rule.Field)
+	if !ok {
+		return nil
+	}
+	setPath(content, targetOrDefault(rule, "word_count"), float64(len(strings.Fields(text))))
+	return nil
+}
+
+// charCount writes the number of characters in the string at Field to Target (default "char_count").
+func charCount(content map[string]any, rule Rule) error {
+	text, ok := stringAt(content, rule.Field)
+	if !ok {
+		return nil
+	}
+	setPath(content, targetOrDefault(rule, "char_count"), float64(utf8.RuneCountInString(text)))
+	return nil
+}
+
+// dateInputLayouts are the date formats normalizeDate recognizes, tried in order.
+var dateInputLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"2006/01/02",
+	"01/02/2006",
+	"Jan 2, 2006",
+	"January 2, 2006",
+	"2 Jan 2006",
+	"2 January 2006",
+}
+
+// normalizeDate rewrites the date string at Field (or to Target, if set) in a single layout:
+// Format if given (a Go time layout), otherwise YYYY-MM-DD. Unrecognized strings are left as they are.
+func normalizeDate(content map[string]any, rule Rule) error {
+	text, ok := stringAt(content, rule.Field)
+	if !ok {
+		return nil
+	}
+	layout := rule.Format
+	if layout == "" {
+		layout = "2006-01-02"
+	}
+	text = strings.TrimSpace(text)
+	for _, input := range dateInputLayouts {
+		if parsed, err := time.Parse(input, text); err == nil {
+			setPath(content, targetOrDefault(rule, rule.Field), parsed.UTC().Format(layout))
+			return nil
+		}
+	}
+	return nil
+}
+
+// trim removes leading and trailing whitespace from the string at Field (or writes it to Target).
+func trim(content map[string]any, rule Rule) error {
+	if text, ok := stringAt(content, rule.Field); ok {
+		setPath(content, targetOrDefault(rule, rule.Field), strings.TrimSpace(text))
+	}
+	return nil
+}
+
+// lowercase lowercases the string at Field (or writes it to Target).
+func lowercase(content map[string]any, rule Rule) error {
+	if text, ok := stringAt(content, rule.Field); ok {
+		setPath(content, targetOrDefault(rule, rule.Field), strings.ToLower(text))
+	}
+	return nil
+}
+
+// stringAt returns the string at a path, if there is one.
+func stringAt(content map[string]any, path string) (string, bool) {
+	value, ok := getPath(content, path)
+	if !ok {
+		return "", false
+	}
+	text, ok := value.(string)
+	return text, ok
+}
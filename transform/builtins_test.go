@@ -0,0 +1,58 @@
+package transform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func applyRule(t *testing.T, rule Rule, content map[string]any) map[string]any {
+	t.Helper()
+	require.NoError(t, builtins[rule.Transformer](content, rule))
+	return content
+}
+
+func TestWordAndCharCount(t *testing.T) {
+	content := applyRule(t, Rule{Transformer: "word_count", Field: "body"}, map[string]any{"body": " Le café est  chaud "})
+	assert.Equal(t, 4.0, content["word_count"])
+
+	content = applyRule(t, Rule{Transformer: "char_count", Field: "body", Target: "stats.chars"}, map[string]any{"body": "café"})
+	assert.Equal(t, map[string]any{"chars": 4.0}, content["stats"], "counts characters, not bytes")
+
+	content = applyRule(t, Rule{Transformer: "word_count", Field: "body"}, map[string]any{"body": 42.0})
+	assert.NotContains(t, content, "word_count", "non-string fields are left alone")
+}
+
+func TestNormalizeDate(t *testing.T) {
+	for input, expected := range map[string]string{
+		"2024-03-05":           "2024-03-05",
+		"03/05/2024":           "2024-03-05",
+		"March 5, 2024":        "2024-03-05",
+		"5 Mar 2024":           "2024-03-05",
+		"2024-03-05T23:30:00Z": "2024-03-05",
+		" 2024/03/05 ":         "2024-03-05",
+	} {
+		content := applyRule(t, Rule{Transformer: "normalize_date", Field: "due"}, map[string]any{"due": input})
+		assert.Equal(t, expected, content["due"], input)
+	}
+
+	content := applyRule(t, Rule{Transformer: "normalize_date", Field: "due", Format: "02 Jan 2006"}, map[string]any{"due": "2024-03-05"})
+	assert.Equal(t, "05 Mar 2024", content["due"])
+
+	content = applyRule(t, Rule{Transformer: "normalize_date", Field: "due", Target: "due_iso"}, map[string]any{"due": "March 5, 2024"})
+	assert.Equal(t, "March 5, 2024", content["due"])
+	assert.Equal(t, "2024-03-05", content["due_iso"])
+
+	content = applyRule(t, Rule{Transformer: "normalize_date", Field: "due"}, map[string]any{"due": "next tuesday"})
+	assert.Equal(t, "next tuesday", content["due"], "unrecognized dates are kept")
+}
+
+func TestTrimAndLowercase(t *testing.T) {
+	content := applyRule(t, Rule{Transformer: "trim", Field: "name"}, map[string]any{"name": "\t Ada \n"})
+	assert.Equal(t, "Ada", content["name"])
+
+	content = applyRule(t, Rule{Transformer: "lowercase", Field: "code", Target: "code_key"}, map[string]any{"code": "CS101"})
+	assert.Equal(t, "CS101", content["code"])
+	assert.Equal(t, "cs101", content["code_key"])
+}
@@ -0,0 +1,173 @@
+// Package transform implements server-side content transformations that run when documents
+// are created or updated, such as keeping a computed word count up to date or normalizing dates.
+//
+// Transformations are configured as a list of rules (see LoadRules). Each rule applies one
+// built-in transformer to a field of object contents, optionally only for documents of a given
+// type (the value of the content's "type" field).
+package transform
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Rule wires a built-in transformer to a content field.
+type Rule struct {
+	Type        string `json:"type,omitempty"`   // Only documents whose content "type" equals this; empty = all documents
+	Transformer string `json:"transformer"`      // Name of a built-in transformer, e.g. "word_count"
+	Field       string `json:"field"`            // Dot-separated path of the field to read (e.g. "body" or "meta.due")
+	Target      string `json:"target,omitempty"` // Dot-separated path to write; defaults depend on the transformer
+	Format      string `json:"format,omitempty"` // Transformer-specific option, e.g. the output layout of normalize_date
+}
+
+// Transformer changes an object content in place according to a rule.
+// Transformers are lenient: fields that are missing or of an unexpected type are left alone.
+type Transformer func(content map[string]any, rule Rule) error
+
+// builtins holds the transformers that rules can refer to by name.
+var builtins = map[string]Transformer{
+	"word_count":     wordCount,
+	"char_count":     charCount,
+	"normalize_date": normalizeDate,
+	"trim":           trim,
+	"lowercase":      lowercase,
+}
+
+// Names returns the names of the built-in transformers, sorted.
+func Names() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Pipeline applies an ordered list of rules to document contents.
+// A nil *Pipeline is valid and leaves contents unchanged.
+type Pipeline struct {
+	rules []Rule
+}
+
+// NewPipeline validates the rules and returns a pipeline that applies them in order.
+func NewPipeline(rules []Rule) (*Pipeline, error) {
+	for i, rule := range rules {
+		if _, ok := builtins[rule.Transformer]; !ok {
+			return nil, fmt.Errorf("rule %d: unknown transformer '%s' (available: %s)", i+1, rule.Transformer, strings.Join(Names(), ", "))
+		}
+		if strings.TrimSpace(rule.Field) == "" {
+			return nil, fmt.Errorf("rule %d: 'field' is required", i+1)
+		}
+	}
+	return &Pipeline{rules: append([]Rule{}, rules...)}, nil
+}
+
+// LoadRules reads a JSON array of rules from a file and builds a pipeline from them.
+func LoadRules(path string) (*Pipeline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transforms file '%s': %w", path, err)
+	}
+	var rules []Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse transforms file '%s': %w", path, err)
+	}
+	pipeline, err := NewPipeline(rules)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transforms file '%s': %w", path, err)
+	}
+	return pipeline, nil
+}
+
+// Rules returns a copy of the pipeline's rules.
+func (p *Pipeline) Rules() []Rule {
+	if p == nil {
+		return nil
+	}
+	return append([]Rule{}, p.rules...)
+}
+
+// Apply runs the pipeline on a content value and returns the transformed content.
+// The input is never modified. Contents that are not JSON objects are returned unchanged.
+func (p *Pipeline) Apply(content any) (any, error) {
+	if p == nil || len(p.rules) == 0 {
+		return content, nil
+	}
+	if _, isObject := content.(map[string]any); !isObject {
+		return content, nil
+	}
+
+	// Work on a deep copy so the caller's value (and any stored version sharing it) is untouched
+	data, err := json.Marshal(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy content: %w", err)
+	}
+	var object map[string]any
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, fmt.Errorf("failed to copy content: %w", err)
+	}
+
+	docType, _ := object["type"].(string)
+	for _, rule := range p.rules {
+		if rule.Type != "" && rule.Type != docType {
+			continue
+		}
+		if err := builtins[rule.Transformer](object, rule); err != nil {
+			return nil, fmt.Errorf("transformer '%s' on '%s' failed: %w", rule.Transformer, rule.Field, err)
+		}
+	}
+	return object, nil
+}
+
+// getPath returns the value at a dot-separated path in an object.
+func getPath(object map[string]any, path string) (any, bool) {
+	parts := strings.Split(path, ".")
+	current := object
+	for i, part := range parts {
+		value, ok := current[part]
+		if !ok {
+			return nil, false
+		}
+		if i == len(parts)-1 {
+			return value, true
+		}
+		if current, ok = value.(map[string]any); !ok {
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// setPath writes a value at a dot-separated path, creating intermediate objects as needed.
+// It gives up (returning false) if an intermediate value exists but is not an object.
+func setPath(object map[string]any, path string, value any) bool {
+	parts := strings.Split(path, ".")
+	current := object
+	for _, part := range parts[:len(parts)-1] {
+		next, exists := current[part]
+		if !exists {
+			created := map[string]any{}
+			current[part] = created
+			current = created
+			continue
+		}
+		nextObject, ok := next.(map[string]any)
+		if !ok {
+			return false
+		}
+		current = nextObject
+	}
+	current[parts[len(parts)-1]] = value
+	return true
+}
+
+// targetOrDefault returns the rule's target, or def if none is set.
+func targetOrDefault(rule Rule, def string) string {
+	if rule.Target != "" {
+		return rule.Target
+	}
+	return def
+}
@@ -0,0 +1,95 @@
+package transform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPipeline_Validation(t *testing.T) {
+	_, err := NewPipeline([]Rule{{Transformer: "nope", Field: "body"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown transformer 'nope'")
+	assert.Contains(t, err.Error(), "word_count")
+
+	_, err = NewPipeline([]Rule{{Transformer: "trim"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "'field' is required")
+}
+
+func TestPipeline_Apply(t *testing.T) {
+	pipeline, err := NewPipeline([]Rule{
+		{Transformer: "trim", Field: "title"},
+		{Type: "essay", Transformer: "word_count", Field: "body"},
+		{Type: "quiz", Transformer: "char_count", Field: "body"},
+	})
+	require.NoError(t, err)
+
+	input := map[string]any{"type": "essay", "title": "  Hello  ", "body": "one two  three"}
+	output, err := pipeline.Apply(input)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"type": "essay", "title": "Hello", "body": "one two  three", "word_count": 3.0}, output)
+	assert.Equal(t, "  Hello  ", input["title"], "the input is not modified")
+
+	output, err = pipeline.Apply(map[string]any{"title": " untyped ", "body": "x y"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"title": "untyped", "body": "x y"}, output, "typed rules skip other documents")
+
+	output, err = pipeline.Apply("plain text")
+	require.NoError(t, err)
+	assert.Equal(t, "plain text", output, "non-object contents are unchanged")
+}
+
+func TestPipeline_Nil(t *testing.T) {
+	var pipeline *Pipeline
+	output, err := pipeline.Apply(map[string]any{"a": 1})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"a": 1}, output)
+	assert.Empty(t, pipeline.Rules())
+}
+
+func TestLoadRules(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "transforms.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"transformer": "lowercase", "field": "meta.email"}]`), 0600))
+	pipeline, err := LoadRules(path)
+	require.NoError(t, err)
+	assert.Equal(t, []Rule{{Transformer: "lowercase", Field: "meta.email"}}, pipeline.Rules())
+
+	output, err := pipeline.Apply(map[string]any{"meta": map[string]any{"email": "A@B.com"}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"meta": map[string]any{"email": "a@b.com"}}, output)
+
+	_, err = LoadRules(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+
+	bad := filepath.Join(dir, "bad.json")
+	require.NoError(t, os.WriteFile(bad, []byte(`{"not": "a list"}`), 0600))
+	_, err = LoadRules(bad)
+	assert.Error(t, err)
+
+	invalid := filepath.Join(dir, "invalid.json")
+	require.NoError(t, os.WriteFile(invalid, []byte(`[{"transformer": "explode", "field": "x"}]`), 0600))
+	_, err = LoadRules(invalid)
+	assert.ErrorContains(t, err, "unknown transformer")
+}
+
+func TestPaths(t *testing.T) {
+	object := map[string]any{"a": map[string]any{"b": "value"}, "scalar": 1.0}
+
+	value, ok := getPath(object, "a.b")
+	assert.True(t, ok)
+	assert.Equal(t, "value", value)
+	_, ok = getPath(object, "a.missing")
+	assert.False(t, ok)
+	_, ok = getPath(object, "scalar.x")
+	assert.False(t, ok)
+
+	assert.True(t, setPath(object, "new.nested.key", true))
+	assert.Equal(t, map[string]any{"nested": map[string]any{"key": true}}, object["new"])
+	assert.False(t, setPath(object, "scalar.x", 1), "cannot descend into a non-object")
+}
@@ -1,12 +1,17 @@
 package utils
 
 import (
+	cryptorand "crypto/rand"
+	"crypto/sha256"
 	"docserver/config"
+	"docserver/i18n"
 	"docserver/models" // Assuming models are needed for context, e.g., profile data
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log"
-	"math/rand"
+	"math"
+	"math/big"
 	"net/http"
 	"strings"
 	// "sync" // Removed unused import
@@ -19,7 +24,8 @@ import (
 
 // --- Password Hashing ---
 
-// HashPassword generates a bcrypt hash for the given password using the cost from config.
+// HashPassword generates a bcrypt hash for the given password with the given cost.
+// HashPasswordWithConfig picks the configured algorithm instead.
 func HashPassword(password string, cost int) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), cost)
 	if err != nil {
@@ -29,8 +35,11 @@ func HashPassword(password string, cost int) (string, error) {
 	return string(bytes), nil
 }
 
-// CheckPasswordHash compares a plain text password with a stored bcrypt hash.
+// CheckPasswordHash compares a plain text password with a stored bcrypt or argon2id hash.
 func CheckPasswordHash(password, hash string) bool {
+	if strings.HasPrefix(hash, argon2idPrefix) {
+		return checkArgon2idHash(password, hash)
+	}
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	// Returns nil on success, error on failure
 	return err == nil
@@ -42,27 +51,69 @@ func CheckPasswordHash(password, hash string) bool {
 type Claims struct {
 	UserID string `json:"user_id"` // Dashless UUID
 	Email  string `json:"email"`
+	// DeviceID names the device the token was issued to at login (see db.RecordLogin); tokens
+	// issued otherwise have none.
+	DeviceID string `json:"device_id,omitempty"`
+	// Scopes restrict what the token may be used for (see ScopeDocumentsRead and the like); a
+	// token without scopes may do anything its user may.
+	Scopes []string `json:"scopes,omitempty"`
+	// Documents restrict the token to these document IDs (see authz.Subject.Documents); a token
+	// without them may reach any document its user may.
+	Documents []string `json:"documents,omitempty"`
 	jwt.RegisteredClaims
 }
 
 // GenerateJWT creates a new JWT token for a given user profile.
 func GenerateJWT(profile *models.Profile, cfg *config.Config) (string, error) {
+	return GenerateJWTForDevice(profile, "", cfg)
+}
+
+// GenerateJWTForDevice creates a new JWT token for a given user profile, bound to the device the
+// user logged in from: the token stops working once the user revokes the device.
+func GenerateJWTForDevice(profile *models.Profile, deviceID string, cfg *config.Config) (string, error) {
+	return generateJWT(profile, Claims{DeviceID: deviceID}, cfg.TokenLifetime, cfg)
+}
+
+// GenerateScopedJWT creates a new JWT token for a given user profile that is limited to scopes
+// and valid for lifetime, e.g. for a service account.
+func GenerateScopedJWT(profile *models.Profile, scopes []string, lifetime time.Duration, cfg *config.Config) (string, error) {
+	return generateJWT(profile, Claims{Scopes: scopes}, lifetime, cfg)
+}
+
+// GenerateRestrictedJWT creates a new JWT token for a given user profile, bound to the same
+// device as the token it is minted with (if any), limited to scopes and, unless documents is
+// empty, to those documents, and valid for lifetime.
+func GenerateRestrictedJWT(profile *models.Profile, deviceID string, scopes, documents []string, lifetime time.Duration, cfg *config.Config) (string, error) {
+	return generateJWT(profile, Claims{DeviceID: deviceID, Scopes: scopes, Documents: documents}, lifetime, cfg)
+}
+
+// generateJWT signs a token for profile valid for lifetime, with the device, scopes and
+// documents of extra.
+func generateJWT(profile *models.Profile, extra Claims, lifetime time.Duration, cfg *config.Config) (string, error) {
 	if cfg.JwtSecret == "" {
 		log.Println("CRITICAL: JWT Secret is empty. Cannot generate token.")
 		return "", errors.New("JWT secret is not configured")
 	}
 
-	expirationTime := time.Now().Add(cfg.TokenLifetime)
+	now := cfg.Now()
+	expirationTime := now.Add(lifetime)
 	claims := &Claims{
-		UserID: profile.ID, // Assumes profile.ID is already dashless
-		Email:  profile.Email,
+		UserID:    profile.ID, // Assumes profile.ID is already dashless
+		Email:     profile.Email,
+		DeviceID:  extra.DeviceID,
+		Scopes:    extra.Scopes,
+		Documents: extra.Documents,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    "docserver", // As per plan
-			Subject:   profile.ID,  // Often set to user ID
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    jwtIssuer(cfg),
+			Subject:   profile.ID, // Often set to user ID
 		},
 	}
+	if cfg.JwtAudience != "" {
+		claims.Audience = jwt.ClaimStrings{cfg.JwtAudience}
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, err := token.SignedString([]byte(cfg.JwtSecret))
@@ -74,7 +125,17 @@ func GenerateJWT(profile *models.Profile, cfg *config.Config) (string, error) {
 	return tokenString, nil
 }
 
+// jwtIssuer returns the issuer tokens are signed with, "docserver" unless configured otherwise.
+func jwtIssuer(cfg *config.Config) string {
+	if cfg.JwtIssuer != "" {
+		return cfg.JwtIssuer
+	}
+	return "docserver"
+}
+
 // ValidateJWT parses and validates a JWT token string.
+// Besides the signature it checks the expiry, not-before and issued-at times (allowing
+// cfg.JwtLeeway of clock skew), the issuer and, when configured, the audience.
 // Returns the claims if valid, otherwise returns an error.
 func ValidateJWT(tokenString string, cfg *config.Config) (*Claims, error) {
 	if cfg.JwtSecret == "" {
@@ -89,7 +150,7 @@ func ValidateJWT(tokenString string, cfg *config.Config) (*Claims, error) {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 		return []byte(cfg.JwtSecret), nil
-	})
+	}, jwtParserOptions(cfg)...)
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
@@ -105,40 +166,62 @@ func ValidateJWT(tokenString string, cfg *config.Config) (*Claims, error) {
 		return nil, errors.New("invalid token")
 	}
 
-	// Check issuer?
-	// if !claims.VerifyIssuer("docserver", true) {
-	// 	return nil, errors.New("invalid token issuer")
-	// }
-
 	return claims, nil
 }
 
+// jwtParserOptions returns the claim checks ValidateJWT applies on top of the signature.
+func jwtParserOptions(cfg *config.Config) []jwt.ParserOption {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithLeeway(cfg.JwtLeeway),
+		jwt.WithIssuedAt(),
+		jwt.WithIssuer(jwtIssuer(cfg)),
+		jwt.WithTimeFunc(cfg.Now), // Frozen in fixtures mode
+	}
+	if cfg.JwtAudience != "" {
+		options = append(options, jwt.WithAudience(cfg.JwtAudience))
+	}
+	return options
+}
+
 // AuthMiddleware creates a Gin middleware function to protect routes.
 // It validates the JWT token from the Authorization header.
 func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			GinUnauthorized(c, "Authorization header required")
+			GinLocalizedError(c, http.StatusUnauthorized, i18n.MsgAuthHeaderRequired)
 			return
 		}
 
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			GinError(c, http.StatusBadRequest, "Authorization header format must be Bearer {token}")
+			GinLocalizedError(c, http.StatusBadRequest, i18n.MsgAuthHeaderFormat)
 			return
 		}
 
 		tokenString := parts[1]
 		claims, err := ValidateJWT(tokenString, cfg)
 		if err != nil {
-			GinUnauthorized(c, fmt.Sprintf("Invalid token: %v", err))
+			GinLocalizedError(c, http.StatusUnauthorized, i18n.MsgInvalidToken, err)
 			return
 		}
 
 		// Store user ID and email in context for handlers to use
 		c.Set("userID", claims.UserID)
 		c.Set("userEmail", claims.Email) // Add email as well, might be useful
+		if claims.IssuedAt != nil {
+			c.Set("tokenIssuedAt", claims.IssuedAt.Time) // Checked against the user's token cutoff (see RequireActiveMiddleware)
+		}
+		if claims.DeviceID != "" {
+			c.Set("deviceID", claims.DeviceID) // Checked against the user's revoked devices (see RequireActiveMiddleware)
+		}
+		if len(claims.Scopes) > 0 {
+			c.Set("tokenScopes", claims.Scopes) // Checked against the route (see api.RequireScopeMiddleware)
+		}
+		if len(claims.Documents) > 0 {
+			c.Set("tokenDocuments", claims.Documents) // Checked by the route and by authz.Can
+		}
 
 		c.Next() // Proceed to the next handler
 	}
@@ -146,78 +229,191 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 
 // --- OTP Handling (for Password Reset) ---
 
-// otpStore holds the temporary OTPs. In a real app, use Redis or similar.
-// For this project, an in-memory map within the Database struct is used (defined in db/database.go).
-// We need functions here to interact with that store via the Database instance.
+// OTPs are kept by the Database (see db/database.go); the functions here reach it through small
+// interfaces to avoid a circular dependency.
 
-const otpLifetime = 5 * time.Minute // OTP validity duration
-const otpLength = 6                  // Length of the numeric OTP
+const otpLifetime = 5 * time.Minute // Default OTP validity duration
+const otpLength = 6                  // Default OTP length
+const otpDigits = "0123456789"       // Default OTP characters
+const otpMaxAttempts = 5             // Default wrong guesses allowed per OTP
 
-// generateOTP creates a random numeric string of specified length.
-func generateOTP(length int) string {
-	const digits = "0123456789"
-	otp := make([]byte, length)
-	// Use crypto/rand for better randomness if needed, but math/rand is simpler for demo
-	// Seed rand only once ideally (e.g., in main.go: rand.Seed(time.Now().UnixNano()))
+// otpSettings returns the configured OTP length, characters, lifetime and attempt limit,
+// using the defaults for anything not set.
+func otpSettings(cfg *config.Config) (length int, charset string, lifetime time.Duration, maxAttempts int) {
+	length, charset, lifetime, maxAttempts = otpLength, otpDigits, otpLifetime, otpMaxAttempts
+	if cfg == nil {
+		return
+	}
+	if cfg.OTPLength > 0 {
+		length = cfg.OTPLength
+	}
+	if cfg.OTPCharset != "" {
+		charset = cfg.OTPCharset
+	}
+	if cfg.OTPLifetime > 0 {
+		lifetime = cfg.OTPLifetime
+	}
+	if cfg.OTPMaxAttempts > 0 {
+		maxAttempts = cfg.OTPMaxAttempts
+	}
+	return
+}
+
+// generateOTP creates a random string of the given length drawn from charset.
+func generateOTP(length int, charset string) (string, error) {
+	chars := []rune(charset)
+	otp := make([]rune, length)
 	for i := range otp {
-		otp[i] = digits[rand.Intn(len(digits))]
+		n, err := cryptorand.Int(cryptorand.Reader, big.NewInt(int64(len(chars))))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate OTP: %w", err)
+		}
+		otp[i] = chars[n.Int64()]
 	}
-	return string(otp)
+	return string(otp), nil
 }
 
-// GenerateAndStoreOTP generates a new OTP, stores it temporarily (using the db's store),
-// and returns the OTP. It should be printed to the console as per the plan.
-// Note: This function needs access to the Database instance to store the OTP.
-// It might be better placed as a method on the Database type in `db/database.go`.
-// Let's define it here for now, assuming a db instance is passed.
-func GenerateAndStoreOTP(email string, db interface { // Use interface to avoid circular dependency
-	StoreOTP(email string, otp string, expiry time.Time)
-}) (string, error) {
-	otp := generateOTP(otpLength)
-	expiry := time.Now().Add(otpLifetime)
+// HashOTP returns the bcrypt hash of an OTP, which is what gets stored instead of the OTP itself.
+// OTPs are short, so they are hashed as slowly as passwords (cfg.BcryptCost) rather than with
+// HashToken.
+func HashOTP(otp string, cfg *config.Config) (string, error) {
+	cost := bcrypt.DefaultCost
+	if cfg != nil && cfg.BcryptCost > 0 {
+		cost = cfg.BcryptCost
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(otp), cost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash OTP: %w", err)
+	}
+	return string(hash), nil
+}
 
-	// Store the OTP using the passed database instance's method
-	db.StoreOTP(email, otp, expiry)
+// GenerateAndStoreOTP generates a new OTP as configured, stores its hash using the db's store
+// and returns it with its expiry. Sending it to the user is up to the caller (see DeliverOTP).
+func GenerateAndStoreOTP(email string, cfg *config.Config, db interface { // Use interface to avoid circular dependency
+	StoreOTP(email string, otpHash string, expiry time.Time)
+}) (string, time.Time, error) {
+	length, charset, lifetime, _ := otpSettings(cfg)
+	otp, err := generateOTP(length, charset)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	otpHash, err := HashOTP(otp, cfg)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expiry := cfg.Now().Add(lifetime)
 
-	// Log clearly to console (as per plan)
-	log.Printf("*****************************************************")
-	log.Printf("PASSWORD RESET OTP GENERATED for %s: %s", email, otp)
-	log.Printf("OTP expires at: %s", expiry.Format(time.RFC1123))
-	log.Printf("*****************************************************")
+	// Store the OTP's hash using the passed database instance's method
+	db.StoreOTP(email, otpHash, expiry)
+	log.Printf("INFO: Password reset OTP generated for %s, expires at %s", email, expiry.Format(time.RFC1123))
 
-	return otp, nil
+	return otp, expiry, nil
 }
 
-// VerifyOTP checks if the provided OTP for the email is valid and not expired.
-// Note: Needs access to the Database instance. Better as a method on Database.
-func VerifyOTP(email, providedOTP string, db interface { // Use interface
+// OTPVerifyStore is what VerifyOTP needs from the database (see db/database.go).
+type OTPVerifyStore interface {
 	RetrieveOTP(email string) (string, time.Time, bool)
+	RecordFailedOTPAttempt(email string, backoffBase, backoffMax time.Duration) (int, time.Time)
+	OTPLockedUntil(email string) time.Time
+	ClearOTPLockout(email string)
 	DeleteOTP(email string)
-}) (bool, error) {
-	storedOTP, expiry, found := db.RetrieveOTP(email)
+}
+
+// VerifyOTP checks if the provided OTP for the email is valid and not expired.
+// Wrong guesses are counted: once the configured limit is reached the OTP is invalidated, and
+// after each one further verifications for the email are refused for an exponentially growing
+// backoff (MsgOTPLocked, whose argument is the number of seconds to wait).
+func VerifyOTP(email, providedOTP string, cfg *config.Config, db OTPVerifyStore) (bool, error) {
+	now := cfg.Now()
+	if lockedUntil := db.OTPLockedUntil(email); now.Before(lockedUntil) {
+		log.Printf("AUDIT: OTP verification for %s refused, locked until %s", email, lockedUntil.Format(time.RFC3339))
+		return false, i18n.NewError(i18n.MsgOTPLocked, int(math.Ceil(lockedUntil.Sub(now).Seconds())))
+	}
+
+	otpHash, expiry, found := db.RetrieveOTP(email)
 
 	if !found {
-		return false, errors.New("no OTP found for this email or it has expired")
+		return false, i18n.NewError(i18n.MsgOTPNotFound)
 	}
 
-	if time.Now().After(expiry) {
+	if now.After(expiry) {
 		// Clean up expired OTP
 		db.DeleteOTP(email)
-		return false, errors.New("OTP has expired")
+		return false, i18n.NewError(i18n.MsgOTPExpired)
 	}
 
-	if storedOTP != providedOTP {
-		return false, errors.New("invalid OTP")
+	// bcrypt compares in constant time
+	if bcrypt.CompareHashAndPassword([]byte(otpHash), []byte(providedOTP)) != nil {
+		_, _, _, maxAttempts := otpSettings(cfg)
+		var backoffBase, backoffMax time.Duration
+		if cfg != nil {
+			backoffBase, backoffMax = cfg.OTPBackoff, cfg.OTPBackoffMax
+		}
+		attempts, lockedUntil := db.RecordFailedOTPAttempt(email, backoffBase, backoffMax)
+		log.Printf("AUDIT: Failed OTP verification for %s (attempt %d of %d)", email, attempts, maxAttempts)
+		if !lockedUntil.IsZero() {
+			log.Printf("AUDIT: OTP verification for %s locked until %s", email, lockedUntil.Format(time.RFC3339))
+		}
+		if attempts >= maxAttempts {
+			db.DeleteOTP(email)
+			log.Printf("AUDIT: OTP for %s invalidated after %d failed attempts", email, attempts)
+			return false, i18n.NewError(i18n.MsgOTPAttemptsExceeded)
+		}
+		return false, i18n.NewError(i18n.MsgOTPMismatch)
 	}
 
 	// OTP is valid, delete it after verification
 	db.DeleteOTP(email)
+	db.ClearOTPLockout(email)
+	log.Printf("AUDIT: OTP verified for %s", email)
 	return true, nil
 }
 
-// --- Helper Methods for Database (to be implemented in db/database.go) ---
+// --- Confirmation Tokens ---
+
+// GenerateToken returns a random, URL-safe token for confirming an action by email.
+func GenerateToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := cryptorand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// HashToken returns the hex SHA-256 of a token, which is what gets stored instead of the token itself.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// --- Invitation Codes ---
+
+const inviteCodeLength = 10
+const inviteCodeCharset = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789" // No look-alikes such as 0/O and 1/I
+
+// GenerateInviteCode returns a random invitation code that is easy to read out and type.
+func GenerateInviteCode() (string, error) {
+	return generateOTP(inviteCodeLength, inviteCodeCharset)
+}
+
+// --- Temporary Passwords ---
+
+const temporaryPasswordLength = 14
+const temporaryPasswordCharset = "abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789" // No look-alikes
+
+// GenerateTemporaryPassword returns a random password for an account created on a user's behalf,
+// such as by a roster import, for them to change after their first login.
+func GenerateTemporaryPassword() (string, error) {
+	return generateOTP(temporaryPasswordLength, temporaryPasswordCharset)
+}
+
+// --- Helper Methods for Database (implemented in db/database.go) ---
 // These methods are needed by GenerateAndStoreOTP and VerifyOTP
 
-// StoreOTP(email string, otp string, expiry time.Time)
-// RetrieveOTP(email string) (otp string, expiry time.Time, found bool)
+// StoreOTP(email string, otpHash string, expiry time.Time)
+// RetrieveOTP(email string) (otpHash string, expiry time.Time, found bool)
+// RecordFailedOTPAttempt(email string, backoffBase, backoffMax time.Duration) (attempts int, lockedUntil time.Time)
+// OTPLockedUntil(email string) time.Time
+// ClearOTPLockout(email string)
 // DeleteOTP(email string)
\ No newline at end of file
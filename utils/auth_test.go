@@ -2,8 +2,10 @@ package utils
 
 import (
 	"docserver/config"
+	"docserver/i18n"
 	"docserver/models"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -11,6 +13,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -114,6 +117,33 @@ func TestGenerateJWT(t *testing.T) {
 	}
 }
 
+func TestGenerateRestrictedJWT(t *testing.T) {
+	cfg := createTestJWTConfig()
+	profile := createTestProfile()
+
+	tokenString, err := GenerateRestrictedJWT(profile, "dev_1", []string{ScopeDocumentsRead}, []string{"doc_1", "doc_2"}, 5*time.Minute, cfg)
+	if err != nil {
+		t.Fatalf("GenerateRestrictedJWT failed: %v", err)
+	}
+	claims, err := ValidateJWT(tokenString, cfg)
+	if err != nil {
+		t.Fatalf("ValidateJWT failed: %v", err)
+	}
+	if claims.DeviceID != "dev_1" || len(claims.Scopes) != 1 || claims.Scopes[0] != ScopeDocumentsRead || len(claims.Documents) != 2 {
+		t.Errorf("Unexpected restrictions: device %q, scopes %v, documents %v", claims.DeviceID, claims.Scopes, claims.Documents)
+	}
+	if lifetime := claims.ExpiresAt.Sub(claims.IssuedAt.Time); lifetime != 5*time.Minute {
+		t.Errorf("Expected a 5m lifetime, got %s", lifetime)
+	}
+
+	// Unrestricted tokens carry none of the claims
+	tokenString, _ = GenerateJWT(profile, cfg)
+	claims, _ = ValidateJWT(tokenString, cfg)
+	if claims.DeviceID != "" || claims.Scopes != nil || claims.Documents != nil {
+		t.Errorf("Expected no restrictions, got device %q, scopes %v, documents %v", claims.DeviceID, claims.Scopes, claims.Documents)
+	}
+}
+
 func TestValidateJWT(t *testing.T) {
 	cfg := createTestJWTConfig()
 	profile := createTestProfile()
@@ -182,6 +212,94 @@ func TestValidateJWT(t *testing.T) {
 	}
 }
 
+func TestValidateJWT_Claims(t *testing.T) {
+	cfg := createTestJWTConfig()
+	profile := createTestProfile()
+
+	// sign creates a token with the given registered claims, bypassing GenerateJWT
+	sign := func(claims jwt.RegisteredClaims) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{UserID: profile.ID, Email: profile.Email, RegisteredClaims: claims})
+		tokenString, err := token.SignedString([]byte(cfg.JwtSecret))
+		if err != nil {
+			t.Fatalf("Setup failed: signing token: %v", err)
+		}
+		return tokenString
+	}
+	now := time.Now()
+
+	t.Run("Leeway tolerates clock skew", func(t *testing.T) {
+		skewed := sign(jwt.RegisteredClaims{
+			Issuer:    "docserver",
+			IssuedAt:  jwt.NewNumericDate(now.Add(10 * time.Second)), // Issued by a server whose clock runs ahead
+			NotBefore: jwt.NewNumericDate(now.Add(10 * time.Second)),
+			ExpiresAt: jwt.NewNumericDate(now.Add(-10 * time.Second)),
+		})
+		_, err := ValidateJWT(skewed, cfg)
+		assert.Error(t, err, "without leeway the token is not valid yet and expired")
+
+		cfgLeeway := createTestJWTConfig()
+		cfgLeeway.JwtLeeway = 30 * time.Second
+		_, err = ValidateJWT(skewed, cfgLeeway)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Issuer must match", func(t *testing.T) {
+		cfgIssuer := createTestJWTConfig()
+		cfgIssuer.JwtIssuer = "https://docs.example.com"
+		token, err := GenerateJWT(profile, cfgIssuer)
+		assert.NoError(t, err)
+		_, err = ValidateJWT(token, cfgIssuer)
+		assert.NoError(t, err)
+
+		_, err = ValidateJWT(token, cfg)
+		assert.ErrorIs(t, err, jwt.ErrTokenInvalidIssuer)
+	})
+
+	t.Run("Audience must match when configured", func(t *testing.T) {
+		cfgAudience := createTestJWTConfig()
+		cfgAudience.JwtAudience = "docs-web"
+		token, err := GenerateJWT(profile, cfgAudience)
+		assert.NoError(t, err)
+		claims, err := ValidateJWT(token, cfgAudience)
+		assert.NoError(t, err)
+		assert.Equal(t, jwt.ClaimStrings{"docs-web"}, claims.Audience)
+
+		cfgOther := createTestJWTConfig()
+		cfgOther.JwtAudience = "docs-mobile"
+		_, err = ValidateJWT(token, cfgOther)
+		assert.ErrorIs(t, err, jwt.ErrTokenInvalidAudience)
+
+		withoutAudience, _ := GenerateJWT(profile, cfg)
+		_, err = ValidateJWT(withoutAudience, cfgAudience)
+		assert.ErrorIs(t, err, jwt.ErrTokenRequiredClaimMissing)
+	})
+
+	t.Run("Frozen clock", func(t *testing.T) {
+		clock := config.NewFixedClock(time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC))
+		cfgFrozen := createTestJWTConfig()
+		cfgFrozen.Clock = clock
+		token, err := GenerateJWT(profile, cfgFrozen)
+		assert.NoError(t, err)
+		claims, err := ValidateJWT(token, cfgFrozen)
+		assert.NoError(t, err, "valid on the frozen clock")
+		assert.Equal(t, clock.Now(), claims.IssuedAt.Time.UTC())
+
+		_, err = ValidateJWT(token, cfg)
+		assert.ErrorContains(t, err, "token has expired", "long expired on the wall clock")
+		clock.Advance(cfgFrozen.TokenLifetime + time.Second)
+		_, err = ValidateJWT(token, cfgFrozen)
+		assert.ErrorContains(t, err, "token has expired")
+	})
+
+	t.Run("Other signing methods are refused", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS512, &Claims{UserID: profile.ID, RegisteredClaims: jwt.RegisteredClaims{Issuer: "docserver"}})
+		tokenString, err := token.SignedString([]byte(cfg.JwtSecret))
+		assert.NoError(t, err)
+		_, err = ValidateJWT(tokenString, cfg)
+		assert.Error(t, err)
+	})
+}
+
 // --- OTP Tests ---
 
 // Mock Database for OTP testing
@@ -198,6 +316,8 @@ type mockOtpDb struct {
 	lastStoredExpiry time.Time
 	lastRetrievedEmail string
 	lastDeletedEmail string
+	failedAttempts   map[string]int
+	lockedUntil      map[string]time.Time
 }
 
 func newMockOtpDb() *mockOtpDb {
@@ -206,6 +326,8 @@ func newMockOtpDb() *mockOtpDb {
 			otp    string
 			expiry time.Time
 		}),
+		failedAttempts: make(map[string]int),
+		lockedUntil:    make(map[string]time.Time),
 	}
 }
 
@@ -237,10 +359,36 @@ func (m *mockOtpDb) DeleteOTP(email string) {
 	m.deleteCalled = true
 	m.lastDeletedEmail = email
 	delete(m.storedOtps, email)
+	delete(m.failedAttempts, email)
+}
+
+// Mock implementation of RecordFailedOTPAttempt
+func (m *mockOtpDb) RecordFailedOTPAttempt(email string, backoffBase, backoffMax time.Duration) (int, time.Time) {
+	if _, found := m.storedOtps[email]; !found {
+		return 0, time.Time{}
+	}
+	m.failedAttempts[email]++
+	if backoffBase > 0 {
+		m.lockedUntil[email] = time.Now().Add(backoffBase)
+	}
+	return m.failedAttempts[email], m.lockedUntil[email]
+}
+
+// Mock implementation of OTPLockedUntil
+func (m *mockOtpDb) OTPLockedUntil(email string) time.Time {
+	return m.lockedUntil[email]
+}
+
+// Mock implementation of ClearOTPLockout
+func (m *mockOtpDb) ClearOTPLockout(email string) {
+	delete(m.lockedUntil, email)
 }
 
 func TestGenerateOTP(t *testing.T) {
-	otp := generateOTP(otpLength) // Use const from auth.go
+	otp, err := generateOTP(otpLength, otpDigits) // Use consts from auth.go
+	if err != nil {
+		t.Fatalf("generateOTP failed: %v", err)
+	}
 
 	if len(otp) != otpLength {
 		t.Errorf("Expected OTP length %d, got %d", otpLength, len(otp))
@@ -255,19 +403,44 @@ func TestGenerateOTP(t *testing.T) {
 	}
 
 	// Generate another one, should likely be different (though collisions are possible)
-	otp2 := generateOTP(otpLength)
+	otp2, _ := generateOTP(otpLength, otpDigits)
 	if otp == otp2 {
 		t.Logf("Warning: Generated two identical OTPs (%s), which is possible but unlikely.", otp)
 	}
+
+	// Custom charsets may contain multi-byte characters
+	custom, err := generateOTP(10, "AB✓")
+	if err != nil {
+		t.Fatalf("generateOTP failed: %v", err)
+	}
+	if runes := []rune(custom); len(runes) != 10 {
+		t.Errorf("Expected 10 characters, got %q", custom)
+	}
+	for _, char := range custom {
+		if !strings.ContainsRune("AB✓", char) {
+			t.Errorf("Expected OTP to contain only charset characters, got %q", custom)
+			break
+		}
+	}
 }
 
 
+func TestHashOTP(t *testing.T) {
+	hash, err := HashOTP("123456", &config.Config{BcryptCost: bcrypt.MinCost})
+	assert.NoError(t, err)
+	assert.NotContains(t, hash, "123456")
+	cost, err := bcrypt.Cost([]byte(hash))
+	assert.NoError(t, err)
+	assert.Equal(t, bcrypt.MinCost, cost, "OTPs are hashed at the configured cost")
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(hash), []byte("123456")))
+}
+
 func TestGenerateAndStoreOTP(t *testing.T) {
 	mockDb := newMockOtpDb()
 	email := "otpuser@example.com"
 
 	// Note: We don't capture log output here, but assume it works per auth.go
-	generatedOtp, err := GenerateAndStoreOTP(email, mockDb)
+	generatedOtp, expiry, err := GenerateAndStoreOTP(email, nil, mockDb)
 	if err != nil {
 		t.Fatalf("GenerateAndStoreOTP failed: %v", err)
 	}
@@ -278,27 +451,56 @@ func TestGenerateAndStoreOTP(t *testing.T) {
 	if mockDb.lastStoredEmail != email {
 		t.Errorf("Expected StoreOTP to be called with email %s, got %s", email, mockDb.lastStoredEmail)
 	}
-	if mockDb.lastStoredOtp != generatedOtp {
-		t.Errorf("Expected StoreOTP to be called with generated OTP %s, got %s", generatedOtp, mockDb.lastStoredOtp)
+	if mockDb.lastStoredOtp == generatedOtp || bcrypt.CompareHashAndPassword([]byte(mockDb.lastStoredOtp), []byte(generatedOtp)) != nil {
+		t.Errorf("Expected StoreOTP to be called with the hash of the generated OTP %s, got %s", generatedOtp, mockDb.lastStoredOtp)
 	}
 	if mockDb.lastStoredExpiry.IsZero() || !mockDb.lastStoredExpiry.After(time.Now()) {
 		t.Errorf("Expected StoreOTP expiry time to be in the future, got %v", mockDb.lastStoredExpiry)
 	}
+	if !expiry.Equal(mockDb.lastStoredExpiry) {
+		t.Errorf("Expected returned expiry %v to match the stored one %v", expiry, mockDb.lastStoredExpiry)
+	}
 
 	// Check if it's actually stored in the mock's internal map
 	storedData, found := mockDb.storedOtps[email]
 	if !found {
 		t.Fatal("OTP was not found in the mock DB's internal map after StoreOTP call")
 	}
-	if storedData.otp != generatedOtp {
-		t.Errorf("Stored OTP in mock map (%s) does not match generated OTP (%s)", storedData.otp, generatedOtp)
+	if storedData.otp != mockDb.lastStoredOtp {
+		t.Errorf("Stored OTP hash in mock map (%s) does not match the one passed to StoreOTP (%s)", storedData.otp, mockDb.lastStoredOtp)
 	}
 }
 
+func TestGenerateAndStoreOTP_Configured(t *testing.T) {
+	mockDb := newMockOtpDb()
+	cfg := &config.Config{OTPLength: 8, OTPCharset: "XY", OTPLifetime: time.Hour}
+
+	otp, expiry, err := GenerateAndStoreOTP("configured@example.com", cfg, mockDb)
+	if err != nil {
+		t.Fatalf("GenerateAndStoreOTP failed: %v", err)
+	}
+	if len(otp) != 8 || strings.Trim(otp, "XY") != "" {
+		t.Errorf("Expected 8 characters from 'XY', got %q", otp)
+	}
+	if remaining := time.Until(expiry); remaining < 59*time.Minute || remaining > time.Hour {
+		t.Errorf("Expected the OTP to expire in about an hour, got %v", remaining)
+	}
+
+	frozen := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	cfg.Clock = config.NewFixedClock(frozen)
+	_, expiry, err = GenerateAndStoreOTP("configured@example.com", cfg, mockDb)
+	assert.NoError(t, err)
+	assert.Equal(t, frozen.Add(time.Hour), expiry, "expiry is taken from the configured clock")
+}
+
 func TestVerifyOTP(t *testing.T) {
 	email := "verify@example.com"
 	correctOtp := "123456"
 	wrongOtp := "987654"
+	correctOtpHash, err := HashOTP(correctOtp, &config.Config{BcryptCost: bcrypt.MinCost})
+	if err != nil {
+		t.Fatalf("HashOTP failed: %v", err)
+	}
 	validExpiry := time.Now().Add(5 * time.Minute)
 	expiredExpiry := time.Now().Add(-5 * time.Minute) // Expired 5 mins ago
 
@@ -307,10 +509,10 @@ func TestVerifyOTP(t *testing.T) {
 	// 1. Valid OTP, not expired
 	t.Run("ValidOTP_NotExpired", func(t *testing.T) {
 		mockDb := newMockOtpDb() // Reset mock for isolation
-		mockDb.StoreOTP(email, correctOtp, validExpiry)
+		mockDb.StoreOTP(email, correctOtpHash, validExpiry)
 		mockDb.deleteCalled = false // Reset delete flag
 
-		valid, err := VerifyOTP(email, correctOtp, mockDb)
+		valid, err := VerifyOTP(email, correctOtp, nil, mockDb)
 		if err != nil {
 			t.Errorf("Expected no error for valid OTP, got: %v", err)
 		}
@@ -332,10 +534,10 @@ func TestVerifyOTP(t *testing.T) {
 	// 2. Invalid OTP
 	t.Run("InvalidOTP", func(t *testing.T) {
 		mockDb := newMockOtpDb()
-		mockDb.StoreOTP(email, correctOtp, validExpiry)
+		mockDb.StoreOTP(email, correctOtpHash, validExpiry)
 		mockDb.deleteCalled = false
 
-		valid, err := VerifyOTP(email, wrongOtp, mockDb)
+		valid, err := VerifyOTP(email, wrongOtp, nil, mockDb)
 		if err == nil {
 			t.Error("Expected error for invalid OTP, got nil")
 		} else if !strings.Contains(err.Error(), "invalid OTP") {
@@ -352,10 +554,10 @@ func TestVerifyOTP(t *testing.T) {
 	// 3. Correct OTP, but expired
 	t.Run("CorrectOTP_Expired", func(t *testing.T) {
 		mockDb := newMockOtpDb()
-		mockDb.StoreOTP(email, correctOtp, expiredExpiry)
+		mockDb.StoreOTP(email, correctOtpHash, expiredExpiry)
 		mockDb.deleteCalled = false
 
-		valid, err := VerifyOTP(email, correctOtp, mockDb)
+		valid, err := VerifyOTP(email, correctOtp, nil, mockDb)
 		if err == nil {
 			t.Error("Expected error for expired OTP, got nil")
 		} else if !strings.Contains(err.Error(), "OTP has expired") {
@@ -376,11 +578,63 @@ func TestVerifyOTP(t *testing.T) {
 		}
 	})
 
-	// 4. No OTP found for email
+	// 4. Too many wrong guesses invalidate the OTP
+	t.Run("TooManyAttempts", func(t *testing.T) {
+		mockDb := newMockOtpDb()
+		mockDb.StoreOTP(email, correctOtpHash, validExpiry)
+		cfg := &config.Config{OTPMaxAttempts: 3}
+
+		for i := 0; i < 2; i++ {
+			if _, err := VerifyOTP(email, wrongOtp, cfg, mockDb); err == nil || !strings.Contains(err.Error(), "invalid OTP") {
+				t.Fatalf("Attempt %d: expected 'invalid OTP', got: %v", i+1, err)
+			}
+		}
+		_, err := VerifyOTP(email, wrongOtp, cfg, mockDb)
+		if err == nil || !strings.Contains(err.Error(), "too many incorrect OTP attempts") {
+			t.Errorf("Expected the third wrong guess to exhaust the OTP, got: %v", err)
+		}
+		valid, err := VerifyOTP(email, correctOtp, cfg, mockDb)
+		if valid || err == nil || !strings.Contains(err.Error(), "no OTP found") {
+			t.Errorf("Expected the exhausted OTP to be gone, got valid=%v err=%v", valid, err)
+		}
+	})
+
+	// 5. Wrong guesses lock further verifications for a while
+	t.Run("Backoff", func(t *testing.T) {
+		mockDb := newMockOtpDb()
+		mockDb.StoreOTP(email, correctOtpHash, validExpiry)
+		cfg := &config.Config{OTPBackoff: time.Minute, OTPBackoffMax: time.Hour}
+
+		if _, err := VerifyOTP(email, wrongOtp, cfg, mockDb); err == nil || !strings.Contains(err.Error(), "invalid OTP") {
+			t.Fatalf("Expected 'invalid OTP', got: %v", err)
+		}
+		valid, err := VerifyOTP(email, correctOtp, cfg, mockDb)
+		var msgErr *i18n.Error
+		if valid || !errors.As(err, &msgErr) || msgErr.ID != i18n.MsgOTPLocked {
+			t.Fatalf("Expected the locked error while backing off, got valid=%v err=%v", valid, err)
+		}
+		if seconds := msgErr.Args[0].(int); seconds < 59 || seconds > 60 {
+			t.Errorf("Expected about 60 seconds to wait, got %d", seconds)
+		}
+		if _, _, found := mockDb.RetrieveOTP(email); !found {
+			t.Error("A locked verification should not use up the OTP")
+		}
+
+		mockDb.lockedUntil[email] = time.Now().Add(-time.Second) // Backoff over
+		valid, err = VerifyOTP(email, correctOtp, cfg, mockDb)
+		if !valid || err != nil {
+			t.Fatalf("Expected the OTP to verify after the backoff, got valid=%v err=%v", valid, err)
+		}
+		if _, locked := mockDb.lockedUntil[email]; locked {
+			t.Error("Expected a successful verification to clear the lockout")
+		}
+	})
+
+	// 6. No OTP found for email
 	t.Run("NoOTPFound", func(t *testing.T) {
 		mockDb := newMockOtpDb() // Create mock inside subtest (empty store)
 
-		valid, err := VerifyOTP(email, correctOtp, mockDb)
+		valid, err := VerifyOTP(email, correctOtp, nil, mockDb)
 		if err == nil {
 			t.Error("Expected error when no OTP found, got nil")
 		} else if !strings.Contains(err.Error(), "no OTP found") {
@@ -395,8 +649,53 @@ func TestVerifyOTP(t *testing.T) {
 	})
 }
 
+func TestGenerateInviteCode(t *testing.T) {
+	code, err := GenerateInviteCode()
+	if err != nil {
+		t.Fatalf("GenerateInviteCode failed: %v", err)
+	}
+	if len(code) != inviteCodeLength || strings.Trim(code, inviteCodeCharset) != "" {
+		t.Errorf("Expected %d characters from the invite charset, got %q", inviteCodeLength, code)
+	}
+}
+
+func TestGenerateTemporaryPassword(t *testing.T) {
+	password, err := GenerateTemporaryPassword()
+	if err != nil {
+		t.Fatalf("GenerateTemporaryPassword failed: %v", err)
+	}
+	if len(password) != temporaryPasswordLength || strings.Trim(password, temporaryPasswordCharset) != "" {
+		t.Errorf("Expected %d characters from the temporary password charset, got %q", temporaryPasswordLength, password)
+	}
+	if other, _ := GenerateTemporaryPassword(); other == password {
+		t.Errorf("Expected different passwords, got %q twice", password)
+	}
+}
+
 // --- AuthMiddleware Tests ---
 
+func TestGenerateToken(t *testing.T) {
+	token, err := GenerateToken()
+	if err != nil {
+		t.Fatalf("GenerateToken failed: %v", err)
+	}
+	if len(token) != 64 {
+		t.Errorf("Expected a 64-character hex token, got %q", token)
+	}
+	other, _ := GenerateToken()
+	if token == other {
+		t.Error("Expected two generated tokens to differ")
+	}
+
+	hash := HashToken(token)
+	if hash == token || len(hash) != 64 {
+		t.Errorf("Expected a 64-character hash different from the token, got %q", hash)
+	}
+	if HashToken(token) != hash {
+		t.Error("Expected HashToken to be deterministic")
+	}
+}
+
 func TestAuthMiddleware(t *testing.T) {
 	// Setup
 	gin.SetMode(gin.TestMode)
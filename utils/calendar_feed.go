@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"docserver/config"
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// --- Calendar Feed Tokens ---
+
+// calendarFeedAudience returns the audience of calendar feed tokens, which sets them apart from
+// access tokens and signed URLs, and between servers sharing a secret (see signedURLAudience).
+func calendarFeedAudience(cfg *config.Config) string {
+	if cfg.JwtAudience != "" {
+		return "calendar-feed:" + cfg.JwtAudience
+	}
+	return "calendar-feed"
+}
+
+// calendarFeedKey returns the key calendar feed tokens are signed with, derived from the JWT
+// secret so they never pass for other tokens.
+func calendarFeedKey(cfg *config.Config) []byte {
+	return []byte("calendar-feed:" + cfg.JwtSecret)
+}
+
+// GenerateCalendarFeedToken creates the token of a user's calendar feed URL. Calendar apps poll
+// the URL for as long as they are subscribed, so the token does not expire; its ID (jti) is
+// feedID, which the server keeps per user, so issuing a new feed ID revokes the old token.
+func GenerateCalendarFeedToken(profileID, feedID string, cfg *config.Config) (string, error) {
+	if cfg.JwtSecret == "" {
+		return "", errors.New("JWT secret is not configured")
+	}
+
+	now := cfg.Now()
+	claims := &jwt.RegisteredClaims{
+		ID:       feedID,
+		IssuedAt: jwt.NewNumericDate(now),
+		Issuer:   jwtIssuer(cfg),
+		Subject:  profileID,
+		Audience: jwt.ClaimStrings{calendarFeedAudience(cfg)},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(calendarFeedKey(cfg))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+	return token, nil
+}
+
+// ValidateCalendarFeedToken parses a calendar feed token and checks its signature and issuer.
+// Whether its ID is still the user's feed ID is up to the caller.
+func ValidateCalendarFeedToken(tokenString string, cfg *config.Config) (*jwt.RegisteredClaims, error) {
+	if cfg.JwtSecret == "" {
+		return nil, errors.New("JWT secret is not configured")
+	}
+
+	claims := &jwt.RegisteredClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return calendarFeedKey(cfg), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithLeeway(cfg.JwtLeeway),
+		jwt.WithIssuedAt(),
+		jwt.WithIssuer(jwtIssuer(cfg)),
+		jwt.WithAudience(calendarFeedAudience(cfg)),
+		jwt.WithTimeFunc(cfg.Now),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid calendar feed token: %w", err)
+	}
+	if claims.ID == "" || claims.Subject == "" || claims.IssuedAt == nil {
+		return nil, errors.New("invalid calendar feed token: missing claims")
+	}
+	return claims, nil
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"docserver/config"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendarFeedTokens(t *testing.T) {
+	cfg := createTestJWTConfig()
+	clock := config.NewFixedClock(time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC))
+	cfg.Clock = clock
+
+	token, err := GenerateCalendarFeedToken("user_1", "feed_1", cfg)
+	require.NoError(t, err)
+
+	claims, err := ValidateCalendarFeedToken(token, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "user_1", claims.Subject)
+	assert.Equal(t, "feed_1", claims.ID)
+	assert.True(t, clock.Now().Equal(claims.IssuedAt.Time))
+
+	t.Run("Does not expire", func(t *testing.T) {
+		clock.Advance(365 * 24 * time.Hour)
+		_, err := ValidateCalendarFeedToken(token, cfg)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Other tokens are not feed tokens", func(t *testing.T) {
+		accessToken, err := GenerateJWT(createTestProfile(), cfg)
+		require.NoError(t, err)
+		_, err = ValidateCalendarFeedToken(accessToken, cfg)
+		assert.Error(t, err)
+		signedURL, _, err := GenerateSignedURLToken("doc_1", SignedURLRead, "user_1", time.Minute, cfg)
+		require.NoError(t, err)
+		_, err = ValidateCalendarFeedToken(signedURL, cfg)
+		assert.Error(t, err)
+		_, err = ValidateJWT(token, cfg)
+		assert.Error(t, err, "and feed tokens are not access tokens")
+	})
+
+	t.Run("Other secrets are rejected", func(t *testing.T) {
+		otherCfg := createTestJWTConfig()
+		otherCfg.JwtSecret = "another-secret-key-longer-than-32-bytes"
+		otherCfg.Clock = clock
+		_, err := ValidateCalendarFeedToken(token, otherCfg)
+		assert.Error(t, err)
+	})
+
+	t.Run("Missing secret", func(t *testing.T) {
+		_, err := GenerateCalendarFeedToken("user_1", "feed_1", &config.Config{})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,217 @@
+package utils
+
+import (
+	"context"
+	"crypto/hmac"
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"docserver/config"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrChallengeFailed is returned (wrapped, with the reason) when a challenge response is not accepted.
+var ErrChallengeFailed = errors.New("challenge verification failed")
+
+// Token verification endpoints of the hosted providers (variables so tests can point them elsewhere).
+var (
+	hcaptchaVerifyURL  = "https://api.hcaptcha.com/siteverify"
+	turnstileVerifyURL = "https://challenges.cloudflare.com/turnstile/v0/siteverify"
+)
+
+// challengeTimeout limits each request to a hosted provider.
+const challengeTimeout = 10 * time.Second
+
+// PoWChallengeLifetime is how long an issued proof-of-work challenge can be solved and used.
+const PoWChallengeLifetime = 5 * time.Minute
+
+// ChallengeVerifier checks the response a client sends to prove it is not a bot.
+type ChallengeVerifier interface {
+	Verify(ctx context.Context, response, remoteIP string) error
+}
+
+// NewChallengeVerifier returns the verifier configured by cfg.ChallengeProvider, or nil when no
+// challenge is required.
+func NewChallengeVerifier(cfg *config.Config) ChallengeVerifier {
+	if cfg == nil {
+		return nil
+	}
+	switch cfg.ChallengeProvider {
+	case config.ChallengeProviderHCaptcha:
+		return &SiteVerifyVerifier{URL: hcaptchaVerifyURL, Secret: cfg.ChallengeSecret}
+	case config.ChallengeProviderTurnstile:
+		return &SiteVerifyVerifier{URL: turnstileVerifyURL, Secret: cfg.ChallengeSecret}
+	case config.ChallengeProviderPoW:
+		return &PoWVerifier{Key: []byte(cfg.JwtSecret), Difficulty: cfg.ChallengePoWDifficulty}
+	default:
+		return nil
+	}
+}
+
+// --- Hosted CAPTCHA ---
+
+// SiteVerifyVerifier checks tokens with a "siteverify" endpoint, the protocol shared by hCaptcha
+// and Cloudflare Turnstile: the secret, token and client IP are POSTed as a form and the JSON
+// answer says whether the token is valid.
+type SiteVerifyVerifier struct {
+	URL    string
+	Secret string
+	Client *http.Client // Optional; defaults to a client with challengeTimeout
+}
+
+// Verify asks the provider whether the token is valid.
+func (v *SiteVerifyVerifier) Verify(ctx context.Context, response, remoteIP string) error {
+	if strings.TrimSpace(response) == "" {
+		return fmt.Errorf("%w: no token", ErrChallengeFailed)
+	}
+	form := url.Values{"secret": {v.Secret}, "response": {response}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := v.Client
+	if client == nil {
+		client = &http.Client{Timeout: challengeTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach the challenge provider: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("challenge provider answered %s", resp.Status)
+	}
+
+	var result struct {
+		Success    bool     `json:"success"`
+		ErrorCodes []string `json:"error-codes"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&result); err != nil {
+		return fmt.Errorf("invalid answer from the challenge provider: %w", err)
+	}
+	if !result.Success {
+		return fmt.Errorf("%w: %s", ErrChallengeFailed, strings.Join(result.ErrorCodes, ", "))
+	}
+	return nil
+}
+
+// --- Proof of Work ---
+
+// PoWVerifier implements a built-in proof-of-work challenge. IssueChallenge hands out a signed
+// challenge; the client must find a nonce such that SHA-256("<challenge>:<nonce>") starts with
+// Difficulty zero bits, and sends "<challenge>:<nonce>" as its response. Challenges are stateless
+// until used: each can be used once and only before it expires.
+type PoWVerifier struct {
+	Key        []byte // Signs challenges so clients cannot make up easy ones
+	Difficulty int
+}
+
+// usedPoWChallenges remembers used challenges until they expire, to refuse replays.
+var usedPoWChallenges = struct {
+	sync.Mutex
+	expiries map[string]time.Time
+}{expiries: make(map[string]time.Time)}
+
+// IssueChallenge returns a new challenge and when it expires.
+func (v *PoWVerifier) IssueChallenge() (string, time.Time, error) {
+	salt := make([]byte, 16)
+	if _, err := cryptorand.Read(salt); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate challenge: %w", err)
+	}
+	expiresAt := time.Now().Add(PoWChallengeLifetime).UTC().Truncate(time.Second)
+	payload := fmt.Sprintf("%d.%d.%s", expiresAt.Unix(), v.Difficulty, hex.EncodeToString(salt))
+	return payload + "." + v.sign(payload), expiresAt, nil
+}
+
+// Verify checks the signature, expiry and work of a "<challenge>:<nonce>" response and uses up the challenge.
+func (v *PoWVerifier) Verify(ctx context.Context, response, remoteIP string) error {
+	separator := strings.LastIndex(response, ":")
+	if separator < 0 {
+		return fmt.Errorf("%w: expected '<challenge>:<nonce>'", ErrChallengeFailed)
+	}
+	challenge := response[:separator]
+
+	parts := strings.Split(challenge, ".")
+	if len(parts) != 4 {
+		return fmt.Errorf("%w: malformed challenge", ErrChallengeFailed)
+	}
+	payload := strings.Join(parts[:3], ".")
+	if subtle.ConstantTimeCompare([]byte(v.sign(payload)), []byte(parts[3])) != 1 {
+		return fmt.Errorf("%w: challenge was not issued by this server", ErrChallengeFailed)
+	}
+	expiresUnix, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: malformed challenge", ErrChallengeFailed)
+	}
+	expiresAt := time.Unix(expiresUnix, 0)
+	now := time.Now()
+	if now.After(expiresAt) {
+		return fmt.Errorf("%w: challenge expired", ErrChallengeFailed)
+	}
+	difficulty, err := strconv.Atoi(parts[1])
+	if err != nil || difficulty < v.Difficulty {
+		return fmt.Errorf("%w: challenge is easier than required", ErrChallengeFailed)
+	}
+	if leadingZeroBits(sha256.Sum256([]byte(response))) < difficulty {
+		return fmt.Errorf("%w: not enough work", ErrChallengeFailed)
+	}
+
+	usedPoWChallenges.Lock()
+	defer usedPoWChallenges.Unlock()
+	for used, expiry := range usedPoWChallenges.expiries {
+		if now.After(expiry) {
+			delete(usedPoWChallenges.expiries, used)
+		}
+	}
+	if _, used := usedPoWChallenges.expiries[challenge]; used {
+		return fmt.Errorf("%w: challenge already used", ErrChallengeFailed)
+	}
+	usedPoWChallenges.expiries[challenge] = expiresAt
+	return nil
+}
+
+// sign returns the hex HMAC-SHA256 of a challenge payload.
+func (v *PoWVerifier) sign(payload string) string {
+	mac := hmac.New(sha256.New, v.Key)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SolvePoWChallenge finds a response to a proof-of-work challenge by brute force.
+// It is what clients do; the server only uses it in tests.
+func SolvePoWChallenge(challenge string, difficulty int) string {
+	for nonce := 0; ; nonce++ {
+		response := challenge + ":" + strconv.Itoa(nonce)
+		if leadingZeroBits(sha256.Sum256([]byte(response))) >= difficulty {
+			return response
+		}
+	}
+}
+
+// leadingZeroBits counts the zero bits at the start of a hash.
+func leadingZeroBits(sum [sha256.Size]byte) int {
+	count := 0
+	for _, b := range sum {
+		if b != 0 {
+			return count + bits.LeadingZeros8(b)
+		}
+		count += 8
+	}
+	return count
+}
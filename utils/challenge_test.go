@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"docserver/config"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewChallengeVerifier(t *testing.T) {
+	assert.Nil(t, NewChallengeVerifier(&config.Config{}))
+	assert.IsType(t, &PoWVerifier{}, NewChallengeVerifier(&config.Config{ChallengeProvider: config.ChallengeProviderPoW}))
+
+	hcaptcha := NewChallengeVerifier(&config.Config{ChallengeProvider: config.ChallengeProviderHCaptcha, ChallengeSecret: "s"})
+	require.IsType(t, &SiteVerifyVerifier{}, hcaptcha)
+	assert.Equal(t, hcaptchaVerifyURL, hcaptcha.(*SiteVerifyVerifier).URL)
+
+	turnstile := NewChallengeVerifier(&config.Config{ChallengeProvider: config.ChallengeProviderTurnstile, ChallengeSecret: "s"})
+	require.IsType(t, &SiteVerifyVerifier{}, turnstile)
+	assert.Equal(t, turnstileVerifyURL, turnstile.(*SiteVerifyVerifier).URL)
+}
+
+func TestSiteVerifyVerifier(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "secret-key", r.PostForm.Get("secret"))
+		assert.Equal(t, "203.0.113.7", r.PostForm.Get("remoteip"))
+		w.Header().Set("Content-Type", "application/json")
+		if r.PostForm.Get("response") == "good-token" {
+			w.Write([]byte(`{"success": true}`))
+		} else {
+			w.Write([]byte(`{"success": false, "error-codes": ["invalid-input-response"]}`))
+		}
+	}))
+	defer server.Close()
+	verifier := &SiteVerifyVerifier{URL: server.URL, Secret: "secret-key"}
+
+	assert.NoError(t, verifier.Verify(context.Background(), "good-token", "203.0.113.7"))
+
+	err := verifier.Verify(context.Background(), "bad-token", "203.0.113.7")
+	assert.True(t, errors.Is(err, ErrChallengeFailed), err)
+	assert.Contains(t, err.Error(), "invalid-input-response")
+
+	err = verifier.Verify(context.Background(), "", "203.0.113.7")
+	assert.True(t, errors.Is(err, ErrChallengeFailed), err)
+
+	unreachable := &SiteVerifyVerifier{URL: "http://127.0.0.1:1", Secret: "secret-key"}
+	err = unreachable.Verify(context.Background(), "good-token", "")
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrChallengeFailed), "provider outages are not the client's fault")
+}
+
+func TestPoWVerifier(t *testing.T) {
+	verifier := &PoWVerifier{Key: []byte("pow-key"), Difficulty: 8}
+	failed := func(response string) bool {
+		return errors.Is(verifier.Verify(context.Background(), response, ""), ErrChallengeFailed)
+	}
+
+	challenge, expiresAt, err := verifier.IssueChallenge()
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(PoWChallengeLifetime), expiresAt, 2*time.Second)
+
+	response := SolvePoWChallenge(challenge, 8)
+	assert.GreaterOrEqual(t, leadingZeroBits(sha256.Sum256([]byte(response))), 8)
+
+	t.Run("Wrong or missing work", func(t *testing.T) {
+		assert.True(t, failed(challenge))
+		for nonce := 0; ; nonce++ {
+			candidate := challenge + ":x" + strings.Repeat("0", nonce)
+			if leadingZeroBits(sha256.Sum256([]byte(candidate))) < 8 {
+				assert.True(t, failed(candidate))
+				break
+			}
+		}
+	})
+
+	t.Run("Forged challenges", func(t *testing.T) {
+		other := &PoWVerifier{Key: []byte("other-key"), Difficulty: 8}
+		forged, _, err := other.IssueChallenge()
+		require.NoError(t, err)
+		assert.True(t, failed(SolvePoWChallenge(forged, 8)), "signed with another key")
+
+		easy := &PoWVerifier{Key: []byte("pow-key"), Difficulty: 1}
+		easyChallenge, _, err := easy.IssueChallenge()
+		require.NoError(t, err)
+		assert.True(t, failed(SolvePoWChallenge(easyChallenge, 1)), "easier than required")
+	})
+
+	t.Run("Used once", func(t *testing.T) {
+		require.NoError(t, verifier.Verify(context.Background(), response, ""))
+		assert.True(t, failed(response))
+	})
+}
@@ -0,0 +1,200 @@
+package utils
+
+import (
+	"docserver/config"
+	"docserver/i18n"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// --- Request Body Decoding ---
+
+// BodyTooLargeError reports a request body over the configured limit (see -max-body-bytes).
+type BodyTooLargeError struct {
+	Limit int64
+}
+
+func (e *BodyTooLargeError) Error() string {
+	return fmt.Sprintf("request body is larger than %d bytes", e.Limit)
+}
+
+// errEmptyBody is returned by DecodeJSON for a request without a body.
+var errEmptyBody = i18n.NewError(i18n.MsgBodyEmpty)
+
+// DecodeJSON reads the JSON request body into obj and validates its binding tags, like
+// c.ShouldBindJSON, but reads at most cfg.MaxBodyBytes (returning a *BodyTooLargeError beyond
+// that), rejects unknown fields when cfg.StrictJSON is set, and returns localizable errors
+// that name the offending field by its JSON path (e.g. "'items.0.price' must be a JSON number").
+func DecodeJSON(c *gin.Context, cfg *config.Config, obj any) error {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return errEmptyBody
+	}
+	body := c.Request.Body
+	if cfg.MaxBodyBytes > 0 {
+		body = http.MaxBytesReader(c.Writer, body, cfg.MaxBodyBytes)
+	}
+	decoder := json.NewDecoder(body)
+	if cfg.StrictJSON {
+		decoder.DisallowUnknownFields()
+	}
+
+	if err := decoder.Decode(obj); err != nil {
+		return describeDecodeError(err)
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return &BodyTooLargeError{Limit: maxBytesErr.Limit}
+		}
+		return i18n.NewError(i18n.MsgBodyTrailingData)
+	}
+
+	if binding.Validator == nil {
+		return nil
+	}
+	if err := binding.Validator.ValidateStruct(obj); err != nil {
+		var fieldErrs validator.ValidationErrors
+		if errors.As(err, &fieldErrs) && len(fieldErrs) > 0 {
+			fieldErr := fieldErrs[0]
+			path := jsonPath(reflect.TypeOf(obj), fieldErr.StructNamespace())
+			if fieldErr.Tag() == "required" {
+				return i18n.NewError(i18n.MsgBodyFieldRequired, path)
+			}
+			rule := fieldErr.Tag()
+			if fieldErr.Param() != "" {
+				rule += "=" + fieldErr.Param()
+			}
+			return i18n.NewError(i18n.MsgBodyFieldInvalid, path, rule)
+		}
+		return err
+	}
+	return nil
+}
+
+// BindJSON decodes the request body into obj with DecodeJSON. If that fails it sends the error
+// response (413 for bodies over the limit, otherwise 400 with msgID, which must take the
+// detail as its only argument) and returns false.
+func BindJSON(c *gin.Context, cfg *config.Config, obj any, msgID string) bool {
+	if err := DecodeJSON(c, cfg, obj); err != nil {
+		respondDecodeError(c, err, msgID)
+		return false
+	}
+	return true
+}
+
+// BindOptionalJSON is BindJSON for endpoints whose body may be left out: an empty body leaves obj as is.
+func BindOptionalJSON(c *gin.Context, cfg *config.Config, obj any, msgID string) bool {
+	if c.Request.ContentLength == 0 {
+		return true
+	}
+	if err := DecodeJSON(c, cfg, obj); err != nil && !errors.Is(err, errEmptyBody) {
+		respondDecodeError(c, err, msgID)
+		return false
+	}
+	return true
+}
+
+// respondDecodeError sends the error response for a DecodeJSON error.
+func respondDecodeError(c *gin.Context, err error, msgID string) {
+	var tooLarge *BodyTooLargeError
+	if errors.As(err, &tooLarge) {
+		GinLocalizedError(c, http.StatusRequestEntityTooLarge, i18n.MsgRequestBodyTooLarge, tooLarge.Limit)
+		return
+	}
+	GinLocalizedError(c, http.StatusBadRequest, msgID, err)
+}
+
+// describeDecodeError turns an error from json.Decoder.Decode into one of the catalog errors.
+func describeDecodeError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return &BodyTooLargeError{Limit: maxBytesErr.Limit}
+	case errors.Is(err, io.EOF):
+		return errEmptyBody
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return i18n.NewError(i18n.MsgBodyTruncated)
+	case errors.As(err, &syntaxErr):
+		return i18n.NewError(i18n.MsgBodySyntax, syntaxErr.Offset)
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return i18n.NewError(i18n.MsgBodyNotObject, jsonKind(typeErr.Type), jsonValueKind(typeErr.Value))
+		}
+		return i18n.NewError(i18n.MsgBodyFieldType, typeErr.Field, jsonKind(typeErr.Type), jsonValueKind(typeErr.Value))
+	}
+	// The decoder reports unknown fields as `json: unknown field "name"`
+	if field, found := strings.CutPrefix(err.Error(), "json: unknown field "); found {
+		return i18n.NewError(i18n.MsgBodyUnknownField, strings.Trim(field, `"`))
+	}
+	return err
+}
+
+// jsonKind names the JSON type a Go type is decoded from.
+func jsonKind(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// jsonValueKind normalizes the value description of a json.UnmarshalTypeError ("number 1.5", "bool").
+func jsonValueKind(value string) string {
+	kind, _, _ := strings.Cut(value, " ")
+	if kind == "bool" {
+		return "boolean"
+	}
+	return kind
+}
+
+// jsonPath converts a validator struct namespace ("SignupRequest.Items[0].Name") into the
+// dot-separated JSON path of the field ("items.0.name"), following the json tags of t.
+func jsonPath(t reflect.Type, namespace string) string {
+	parts := strings.Split(namespace, ".")[1:] // Drop the struct name
+	for i, part := range parts {
+		for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map) {
+			t = t.Elem()
+		}
+		name, index, _ := strings.Cut(part, "[")
+		if index != "" {
+			index = "." + strings.NewReplacer("][", ".", "]", "").Replace(index)
+		}
+		if t == nil || t.Kind() != reflect.Struct {
+			continue
+		}
+		field, found := t.FieldByName(name)
+		if !found {
+			t = nil
+			continue
+		}
+		if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag != "" && tag != "-" {
+			name = tag
+		}
+		parts[i] = name + index
+		t = field.Type
+	}
+	return strings.Join(parts, ".")
+}
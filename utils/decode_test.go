@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"docserver/config"
+	"docserver/i18n"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type decodeTestItem struct {
+	Name  string  `json:"name" binding:"required"`
+	Price float64 `json:"price" binding:"min=0"`
+}
+
+type decodeTestRequest struct {
+	Email string           `json:"email" binding:"required,email"`
+	Items []decodeTestItem `json:"items" binding:"dive"`
+	Note  string           `json:"note,omitempty"`
+}
+
+func TestDecodeJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	decode := func(cfg *config.Config, body string) error {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		var req decodeTestRequest
+		return DecodeJSON(c, cfg, &req)
+	}
+	message := func(err error) string {
+		require.Error(t, err)
+		return err.Error()
+	}
+	cfg := &config.Config{MaxBodyBytes: 200}
+
+	assert.NoError(t, decode(cfg, `{"email": "a@example.com", "items": [{"name": "pen", "price": 2}], "extra": true}`), "unknown fields are ignored by default")
+	assert.Equal(t, "the body is empty", message(decode(cfg, "")))
+	assert.Equal(t, "malformed JSON at byte 11", message(decode(cfg, `{"email": x}`)))
+	assert.Equal(t, "the JSON ends unexpectedly", message(decode(cfg, `{"email": "a@example.com"`)))
+	assert.Equal(t, "unexpected data after the JSON value", message(decode(cfg, `{"email": "a@example.com"} {}`)))
+	assert.Equal(t, "the body must be a JSON object, not array", message(decode(cfg, `[]`)))
+	assert.Equal(t, "'items.0.price' must be a JSON number, not string", message(decode(cfg, `{"email": "a@example.com", "items": [{"price": "2"}]}`)))
+	assert.Equal(t, "'email' is required", message(decode(cfg, `{}`)))
+	assert.Equal(t, "'email' does not satisfy 'email'", message(decode(cfg, `{"email": "nope"}`)))
+	assert.Equal(t, "'items.1.name' is required", message(decode(cfg, `{"email": "a@example.com", "items": [{"name": "pen"}, {"price": 1}]}`)))
+	assert.Equal(t, "'items.0.price' does not satisfy 'min=0'", message(decode(cfg, `{"email": "a@example.com", "items": [{"name": "pen", "price": -1}]}`)))
+
+	var tooLarge *BodyTooLargeError
+	assert.ErrorAs(t, decode(cfg, `{"email": "`+strings.Repeat("a", 300)+`@example.com"}`), &tooLarge)
+	assert.Equal(t, int64(200), tooLarge.Limit)
+
+	strict := &config.Config{StrictJSON: true}
+	assert.Equal(t, "unknown field 'extra'", message(decode(strict, `{"email": "a@example.com", "extra": true}`)))
+	assert.NoError(t, decode(strict, `{"email": "`+strings.Repeat("a", 300)+`@example.com"}`), "0 means no size limit")
+}
+
+func TestBindJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	bind := func(optional bool, body string) (*httptest.ResponseRecorder, bool) {
+		rr := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rr)
+		c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		c.Request.Header.Set("Accept-Language", "fr")
+		EnvelopeMiddleware()(c)
+		var req decodeTestRequest
+		cfg := &config.Config{MaxBodyBytes: 64}
+		if optional {
+			return rr, BindOptionalJSON(c, cfg, &req, i18n.MsgInvalidRequestBody)
+		}
+		return rr, BindJSON(c, cfg, &req, i18n.MsgInvalidRequestBody)
+	}
+
+	rr, ok := bind(false, `{}`)
+	assert.False(t, ok)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.JSONEq(t, `{"error": {"status": 400, "code": "bad_request", "message_id": "invalid_request_body", "message": "Corps de requête invalide : 'email' est obligatoire"}}`, rr.Body.String())
+
+	rr, ok = bind(false, `{"email": "`+strings.Repeat("a", 100)+`"}`)
+	assert.False(t, ok)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	assert.Contains(t, rr.Body.String(), "request_body_too_large")
+
+	_, ok = bind(false, "")
+	assert.False(t, ok, "the body is required")
+	_, ok = bind(true, "")
+	assert.True(t, ok, "the body may be left out")
+	rr, ok = bind(true, `{"email": 1}`)
+	assert.False(t, ok, "optional bodies are still checked")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
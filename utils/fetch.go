@@ -0,0 +1,185 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Errors returned by FetchJSON and PostJSON, so callers can tell the failure modes apart.
+var (
+	ErrFetchHostNotAllowed = errors.New("host is not in the allow list")
+	ErrFetchStatus         = errors.New("unexpected response status")
+	ErrFetchContentType    = errors.New("response is not JSON")
+	ErrFetchTooLarge       = errors.New("response exceeds the size limit")
+	ErrFetchInvalidJSON    = errors.New("response body is not valid JSON")
+	ErrFetchTimeout        = errors.New("request timed out")
+)
+
+// DefaultFetchMaxBytes is the response size limit used when FetchOptions.MaxBytes is not set.
+const DefaultFetchMaxBytes = 1 << 20
+
+// FetchOptions limits an outbound fetch.
+type FetchOptions struct {
+	AllowedDomains []string      // Hosts that may be contacted; subdomains of an entry are allowed too
+	MaxBytes       int64         // Largest response body accepted (0 = DefaultFetchMaxBytes)
+	Timeout        time.Duration // Limit for the whole request, including redirects
+	Client         *http.Client  // Optional; defaults to a fresh client (tests inject their own)
+}
+
+// ParseFetchURL checks that rawURL is an absolute http(s) URL and returns it parsed.
+func ParseFetchURL(rawURL string) (*url.URL, error) {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, fmt.Errorf("scheme must be http or https")
+	}
+	if parsed.Hostname() == "" {
+		return nil, fmt.Errorf("a host is required")
+	}
+	return parsed, nil
+}
+
+// HostAllowed reports whether host is one of the allowed domains or a subdomain of one.
+func HostAllowed(host string, allowedDomains []string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, domain := range allowedDomains {
+		domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+		if domain == "" {
+			continue
+		}
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// FetchJSON downloads a JSON document from an allow-listed host and decodes it.
+// Redirects are followed only to allowed hosts. The response must have a JSON content type
+// (application/json or a +json type) and fit within opts.MaxBytes.
+func FetchJSON(ctx context.Context, target *url.URL, opts FetchOptions) (any, error) {
+	if !HostAllowed(target.Hostname(), opts.AllowedDomains) {
+		return nil, fmt.Errorf("%w: %s", ErrFetchHostNotAllowed, target.Hostname())
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultFetchMaxBytes
+	}
+
+	client := newFetchClient(opts)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "docserver-fetch")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fetchError(err, opts)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%w: %s", ErrFetchStatus, resp.Status)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+		return nil, fmt.Errorf("%w (content type '%s')", ErrFetchContentType, resp.Header.Get("Content-Type"))
+	}
+	if opts.MaxBytes > 0 && resp.ContentLength > opts.MaxBytes {
+		return nil, fmt.Errorf("%w of %d bytes", ErrFetchTooLarge, opts.MaxBytes)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, opts.MaxBytes+1))
+	if err != nil {
+		var netErr interface{ Timeout() bool }
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return nil, fmt.Errorf("%w after %s", ErrFetchTimeout, opts.Timeout)
+		}
+		return nil, err
+	}
+	if int64(len(body)) > opts.MaxBytes {
+		return nil, fmt.Errorf("%w of %d bytes", ErrFetchTooLarge, opts.MaxBytes)
+	}
+
+	var content any
+	if err := json.Unmarshal(body, &content); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFetchInvalidJSON, err)
+	}
+	return content, nil
+}
+
+// PostJSON sends body, encoded as JSON, to an allow-listed URL. Redirects are followed only to
+// allowed hosts and any 2xx response counts as delivered; the response body is discarded.
+func PostJSON(ctx context.Context, target *url.URL, body any, opts FetchOptions) error {
+	if !HostAllowed(target.Hostname(), opts.AllowedDomains) {
+		return fmt.Errorf("%w: %s", ErrFetchHostNotAllowed, target.Hostname())
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	client := newFetchClient(opts)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.String(), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "docserver-fetch")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fetchError(err, opts)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, DefaultFetchMaxBytes))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%w: %s", ErrFetchStatus, resp.Status)
+	}
+	return nil
+}
+
+// newFetchClient returns an HTTP client enforcing the options' timeout and redirect rules.
+func newFetchClient(opts FetchOptions) *http.Client {
+	client := &http.Client{}
+	if opts.Client != nil {
+		copied := *opts.Client
+		client = &copied
+	}
+	client.Timeout = opts.Timeout
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 5 {
+			return errors.New("too many redirects")
+		}
+		if !HostAllowed(req.URL.Hostname(), opts.AllowedDomains) {
+			return fmt.Errorf("%w: redirect to %s", ErrFetchHostNotAllowed, req.URL.Hostname())
+		}
+		return nil
+	}
+	return client
+}
+
+// fetchError maps a failed request to ErrFetchTimeout when it ran out of time.
+func fetchError(err error, opts FetchOptions) error {
+	if errors.Is(err, ErrFetchHostNotAllowed) {
+		return err
+	}
+	var netErr interface{ Timeout() bool }
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return fmt.Errorf("%w after %s", ErrFetchTimeout, opts.Timeout)
+	}
+	return err
+}
@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFetchURL(t *testing.T) {
+	parsed, err := ParseFetchURL(" https://data.example.com/items.json ")
+	require.NoError(t, err)
+	assert.Equal(t, "data.example.com", parsed.Hostname())
+
+	for _, bad := range []string{"ftp://example.com/x", "/relative/path", "https://", "::not a url"} {
+		_, err := ParseFetchURL(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestHostAllowed(t *testing.T) {
+	allowed := []string{"example.com", " Data.Org. "}
+	assert.True(t, HostAllowed("example.com", allowed))
+	assert.True(t, HostAllowed("api.example.com", allowed))
+	assert.True(t, HostAllowed("DATA.org", allowed))
+	assert.False(t, HostAllowed("badexample.com", allowed), "suffix must be a whole label")
+	assert.False(t, HostAllowed("example.com.evil.net", allowed))
+	assert.False(t, HostAllowed("example.com", nil))
+}
+
+func TestFetchJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Write([]byte(`{"items": [1, 2]}`))
+		case "/problem":
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.Write([]byte(`{"title": "fine"}`))
+		case "/html":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<html></html>`))
+		case "/big":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`"` + strings.Repeat("x", 100) + `"`))
+		case "/broken":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"unterminated":`))
+		case "/missing":
+			http.NotFound(w, r)
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{}`))
+		case "/redirect-out":
+			http.Redirect(w, r, "http://elsewhere.invalid/data", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	opts := FetchOptions{AllowedDomains: []string{"127.0.0.1"}, MaxBytes: 64, Timeout: time.Second}
+	fetch := func(path string, opts FetchOptions) (any, error) {
+		target, err := ParseFetchURL(server.URL + path)
+		require.NoError(t, err)
+		return FetchJSON(context.Background(), target, opts)
+	}
+
+	content, err := fetch("/ok", opts)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"items": []any{1.0, 2.0}}, content)
+
+	_, err = fetch("/problem", opts)
+	assert.NoError(t, err, "+json media types are accepted")
+
+	for path, expected := range map[string]error{
+		"/html":         ErrFetchContentType,
+		"/big":          ErrFetchTooLarge,
+		"/broken":       ErrFetchInvalidJSON,
+		"/missing":      ErrFetchStatus,
+		"/redirect-out": ErrFetchHostNotAllowed,
+	} {
+		_, err := fetch(path, opts)
+		assert.True(t, errors.Is(err, expected), "%s: %v", path, err)
+	}
+
+	_, err = fetch("/slow", FetchOptions{AllowedDomains: opts.AllowedDomains, Timeout: 50 * time.Millisecond})
+	assert.True(t, errors.Is(err, ErrFetchTimeout), "%v", err)
+
+	_, err = fetch("/ok", FetchOptions{AllowedDomains: []string{"example.com"}})
+	assert.True(t, errors.Is(err, ErrFetchHostNotAllowed))
+}
+
+func TestPostJSON(t *testing.T) {
+	var received map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	opts := FetchOptions{AllowedDomains: []string{"127.0.0.1"}, Timeout: time.Second}
+
+	target, err := ParseFetchURL(server.URL + "/hook")
+	require.NoError(t, err)
+	require.NoError(t, PostJSON(context.Background(), target, map[string]any{"count": 2}, opts))
+	assert.Equal(t, map[string]any{"count": 2.0}, received)
+
+	target, _ = ParseFetchURL(server.URL + "/fail")
+	err = PostJSON(context.Background(), target, map[string]any{}, opts)
+	assert.True(t, errors.Is(err, ErrFetchStatus), err)
+
+	err = PostJSON(context.Background(), target, map[string]any{}, FetchOptions{AllowedDomains: []string{"example.com"}})
+	assert.True(t, errors.Is(err, ErrFetchHostNotAllowed), err)
+}
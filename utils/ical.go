@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"strings"
+	"time"
+)
+
+// --- iCalendar ---
+
+// ICalEvent is an event of an iCalendar (RFC 5545) feed.
+type ICalEvent struct {
+	UID         string    // Stable across regenerations, so calendar apps update the event instead of adding a copy
+	Summary     string    // Title shown in the calendar
+	Description string    // Optional details
+	Start       time.Time // When it happens; for all-day events only the date counts
+	AllDay      bool      // Whether the event is a whole day rather than a point in time
+	Stamp       time.Time // When the event last changed
+}
+
+// icalTimeLayout and icalDateLayout are the UTC date-time and date formats of RFC 5545.
+const (
+	icalTimeLayout = "20060102T150405Z"
+	icalDateLayout = "20060102"
+)
+
+// RenderICalendar returns an iCalendar feed named name holding events, with CRLF line endings
+// and long lines folded as RFC 5545 requires.
+func RenderICalendar(name string, events []ICalEvent) string {
+	var b strings.Builder
+	line := func(content string) {
+		b.WriteString(foldICalLine(content))
+		b.WriteString("\r\n")
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//docserver//Deadlines//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:" + escapeICalText(name))
+	for _, event := range events {
+		line("BEGIN:VEVENT")
+		line("UID:" + escapeICalText(event.UID))
+		line("DTSTAMP:" + event.Stamp.UTC().Format(icalTimeLayout))
+		if event.AllDay {
+			line("DTSTART;VALUE=DATE:" + event.Start.Format(icalDateLayout))
+		} else {
+			line("DTSTART:" + event.Start.UTC().Format(icalTimeLayout))
+		}
+		line("SUMMARY:" + escapeICalText(event.Summary))
+		if event.Description != "" {
+			line("DESCRIPTION:" + escapeICalText(event.Description))
+		}
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return b.String()
+}
+
+// escapeICalText escapes a TEXT value: backslashes, semicolons, commas and newlines.
+func escapeICalText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`).Replace(s)
+}
+
+// foldICalLine splits a content line longer than 75 bytes into lines starting with a space,
+// without splitting a UTF-8 character.
+func foldICalLine(content string) string {
+	const limit = 75
+	if len(content) <= limit {
+		return content
+	}
+	var b strings.Builder
+	width := limit // The first line has no leading space
+	for len(content) > width {
+		cut := width
+		for cut > 0 && !isUTF8Start(content[cut]) {
+			cut--
+		}
+		b.WriteString(content[:cut])
+		b.WriteString("\r\n ")
+		content = content[cut:]
+		width = limit - 1
+	}
+	b.WriteString(content)
+	return b.String()
+}
+
+// isUTF8Start reports whether b begins a UTF-8 character rather than continuing one.
+func isUTF8Start(b byte) bool {
+	return b&0xC0 != 0x80
+}
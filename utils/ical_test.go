@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderICalendar(t *testing.T) {
+	stamp := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	feed := RenderICalendar("Deadlines", []ICalEvent{
+		{UID: "doc_1@docserver", Summary: "Due: Essay; draft, v2", Start: time.Date(2026, 3, 10, 17, 0, 0, 0, time.FixedZone("CET", 3600)), Stamp: stamp},
+		{UID: "doc_2@docserver", Summary: "Due: Lab", Description: "line one\nline two", Start: time.Date(2026, 3, 12, 0, 0, 0, 0, time.UTC), AllDay: true, Stamp: stamp},
+	})
+
+	assert.True(t, strings.HasPrefix(feed, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(feed, "END:VCALENDAR\r\n"))
+	assert.Contains(t, feed, "X-WR-CALNAME:Deadlines\r\n")
+	assert.Contains(t, feed, "DTSTART:20260310T160000Z\r\n", "times are in UTC")
+	assert.Contains(t, feed, "SUMMARY:Due: Essay\\; draft\\, v2\r\n")
+	assert.Contains(t, feed, "DTSTART;VALUE=DATE:20260312\r\n")
+	assert.Contains(t, feed, "DESCRIPTION:line one\\nline two\r\n")
+	assert.Contains(t, feed, "DTSTAMP:20260301T093000Z\r\n")
+	assert.Equal(t, 2, strings.Count(feed, "BEGIN:VEVENT"))
+}
+
+func TestFoldICalLine(t *testing.T) {
+	assert.Equal(t, "SUMMARY:short", foldICalLine("SUMMARY:short"))
+
+	long := "SUMMARY:" + strings.Repeat("é", 60) // 128 bytes
+	folded := foldICalLine(long)
+	lines := strings.Split(folded, "\r\n")
+	assert.Greater(t, len(lines), 1)
+	for i, line := range lines {
+		assert.LessOrEqual(t, len(line), 75)
+		if i > 0 {
+			assert.True(t, strings.HasPrefix(line, " "))
+		}
+	}
+	unfolded := strings.ReplaceAll(folded, "\r\n ", "")
+	assert.Equal(t, long, unfolded, "no character is split")
+}
@@ -0,0 +1,166 @@
+package utils
+
+import (
+	"crypto/rand"
+	"docserver/config"
+	"docserver/i18n"
+	"encoding/binary"
+	"io"
+	mathrand "math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// --- ID Generation ---
+
+// IDKind names the kind of entity an ID is for. With config.IDStrategyPrefixed it is the ID's prefix.
+type IDKind string
+
+// Entity kinds
+const (
+	IDKindProfile     IDKind = "usr"
+	IDKindDocument    IDKind = "doc"
+	IDKindSchedule    IDKind = "sch"
+	IDKindScheduleRun IDKind = "run"
+	IDKindScript      IDKind = "scr"
+	IDKindReview      IDKind = "rev"
+	IDKindDevice      IDKind = "dev"
+	IDKindChannel     IDKind = "nch"
+)
+
+// idKinds lists every entity kind, so prefixes of other kinds can be recognized.
+var idKinds = []IDKind{IDKindProfile, IDKindDocument, IDKindSchedule, IDKindScheduleRun, IDKindScript, IDKindReview, IDKindDevice, IDKindChannel}
+
+// nanoIDAlphabet holds the 64 URL-safe characters NanoIDs are made of.
+const nanoIDAlphabet = "useandom-26T198340PX75pxJACKVERYMINDBUSHWOLF_GQZbfghjklqvwyzrict"
+
+// nanoIDLength gives NanoIDs about as many random bits as a UUIDv4 (126 against 122).
+const nanoIDLength = 21
+
+// NewID generates an ID for an entity of the given kind using strategy (one of the
+// config.IDStrategy* constants). Unknown strategies fall back to config.IDStrategyUUID.
+// IDs only ever contain letters, digits, '_' and '-'.
+func NewID(strategy string, kind IDKind) string {
+	switch strategy {
+	case config.IDStrategyUUIDv7:
+		return dashlessUUIDv7()
+	case config.IDStrategyNanoID:
+		return nanoID()
+	case config.IDStrategyPrefixed:
+		return string(kind) + "_" + dashlessUUIDv7()
+	default:
+		return GenerateDashlessUUID()
+	}
+}
+
+// dashlessUUIDv7 returns a time-ordered UUIDv7 with its dashes removed, or a UUIDv4 if the
+// clock or random source fails.
+func dashlessUUIDv7() string {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return GenerateDashlessUUID()
+	}
+	return strings.ReplaceAll(id.String(), "-", "")
+}
+
+// nanoID returns nanoIDLength random characters of nanoIDAlphabet. As the alphabet has 64
+// characters, the low 6 bits of each random byte pick one without bias.
+func nanoID() string {
+	return nanoIDFrom(rand.Reader)
+}
+
+// nanoIDFrom is nanoID reading its random bytes from random.
+func nanoIDFrom(random io.Reader) string {
+	bytes := make([]byte, nanoIDLength)
+	if _, err := io.ReadFull(random, bytes); err != nil {
+		return GenerateDashlessUUID()
+	}
+	id := make([]byte, nanoIDLength)
+	for i, b := range bytes {
+		id[i] = nanoIDAlphabet[b&63]
+	}
+	return string(id)
+}
+
+// SeededIDs generates IDs from a seeded pseudo-random source and a given time instead of the
+// system's, so the same sequence of requests yields the same IDs (see -fixtures). Not for production
+// use: the IDs are predictable.
+type SeededIDs struct {
+	mu       sync.Mutex
+	random   *mathrand.Rand
+	sequence uint32 // Keeps UUIDv7s generated at the same instant in order
+}
+
+// NewSeededIDs returns a generator whose IDs are determined by seed.
+func NewSeededIDs(seed int64) *SeededIDs {
+	return &SeededIDs{random: mathrand.New(mathrand.NewSource(seed))}
+}
+
+// NewID is NewID with the generator's random source and the time now.
+func (g *SeededIDs) NewID(strategy string, kind IDKind, now time.Time) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	switch strategy {
+	case config.IDStrategyUUIDv7:
+		return g.uuidV7(now)
+	case config.IDStrategyNanoID:
+		return nanoIDFrom(g.random)
+	case config.IDStrategyPrefixed:
+		return string(kind) + "_" + g.uuidV7(now)
+	default:
+		id, _ := uuid.NewRandomFromReader(g.random) // Reading from a math/rand source never fails
+		return strings.ReplaceAll(id.String(), "-", "")
+	}
+}
+
+// uuidV7 builds a dashless UUIDv7 for now by hand: 48 bits of Unix milliseconds, then a 26 bit
+// sequence number where the standard allows a counter, then random bits.
+func (g *SeededIDs) uuidV7(now time.Time) string {
+	var id uuid.UUID
+	binary.BigEndian.PutUint64(id[:8], uint64(now.UnixMilli())<<16)
+	seq := g.sequence & (1<<26 - 1)
+	g.sequence++
+	id[6] = 0x70 | byte(seq>>22)     // Version 7 and the top 4 bits of the sequence
+	id[7] = byte(seq >> 14)          // Next 8 bits
+	id[8] = 0x80 | byte(seq>>8)&0x3f // RFC 4122 variant and the next 6 bits
+	id[9] = byte(seq)                // Last 8 bits
+	_, _ = g.random.Read(id[10:])
+	return strings.ReplaceAll(id.String(), "-", "")
+}
+
+// ValidateID checks that id is well formed for an entity of the given kind: 1-64 letters, digits,
+// '_' or '-', and not carrying the type prefix of another kind (a "usr_" ID where a document
+// is expected). IDs without a type prefix, such as those of older strategies, are accepted.
+func ValidateID(id string, kind IDKind) error {
+	if !IsValidCustomID(id) {
+		return i18n.NewError(i18n.MsgMalformedID, id)
+	}
+	for _, other := range idKinds {
+		if other != kind && strings.HasPrefix(id, string(other)+"_") {
+			return i18n.NewError(i18n.MsgWrongIDKind, id, other, kind)
+		}
+	}
+	return nil
+}
+
+// ValidateIDParams returns middleware that rejects requests with 400 Bad Request when a route
+// parameter named in params holds an ID that is malformed for its kind (see ValidateID), before
+// the handler looks it up. Parameters missing from the route are skipped.
+func ValidateIDParams(params map[string]IDKind) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for name, kind := range params {
+			if id := c.Param(name); id != "" {
+				if err := ValidateID(id, kind); err != nil {
+					GinErrorFromErr(c, http.StatusBadRequest, err)
+					return
+				}
+			}
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"docserver/config"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewID(t *testing.T) {
+	for strategy, pattern := range map[string]string{
+		config.IDStrategyUUID:     `^[0-9a-f]{32}$`,
+		config.IDStrategyUUIDv7:   `^[0-9a-f]{12}7[0-9a-f]{19}$`,
+		config.IDStrategyNanoID:   `^[A-Za-z0-9_-]{21}$`,
+		config.IDStrategyPrefixed: `^doc_[0-9a-f]{12}7[0-9a-f]{19}$`,
+		"":                        `^[0-9a-f]{32}$`,
+	} {
+		id := NewID(strategy, IDKindDocument)
+		assert.Regexp(t, regexp.MustCompile(pattern), id, "strategy %q", strategy)
+		assert.NotEqual(t, id, NewID(strategy, IDKindDocument), "strategy %q repeats IDs", strategy)
+	}
+	assert.Regexp(t, `^usr_`, NewID(config.IDStrategyPrefixed, IDKindProfile))
+
+	t.Run("UUIDv7 sorts by creation time", func(t *testing.T) {
+		ids := make([]string, 3)
+		for i := range ids {
+			ids[i] = NewID(config.IDStrategyUUIDv7, IDKindDocument)
+			time.Sleep(2 * time.Millisecond)
+		}
+		assert.True(t, sort.StringsAreSorted(ids), ids)
+	})
+}
+
+func TestSeededIDs(t *testing.T) {
+	now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	generate := func(seed int64) []string {
+		ids := NewSeededIDs(seed)
+		var generated []string
+		for _, strategy := range []string{config.IDStrategyUUID, config.IDStrategyUUIDv7, config.IDStrategyNanoID, config.IDStrategyPrefixed, config.IDStrategyPrefixed} {
+			generated = append(generated, ids.NewID(strategy, IDKindDocument, now))
+		}
+		return generated
+	}
+
+	ids := generate(7)
+	assert.Regexp(t, `^[0-9a-f]{12}4[0-9a-f]{19}$`, ids[0])
+	assert.Regexp(t, `^01941f297c007[0-9a-f]{3}[89ab][0-9a-f]{15}$`, ids[1], "UUIDv7 carries the given time")
+	assert.Regexp(t, `^[A-Za-z0-9_-]{21}$`, ids[2])
+	assert.Regexp(t, `^doc_01941f297c007[0-9a-f]{19}$`, ids[3])
+	assert.Less(t, ids[3], ids[4], "UUIDv7s of the same instant keep their order")
+	assert.Equal(t, ids, generate(7), "the same seed gives the same IDs")
+	assert.NotEqual(t, ids, generate(8))
+}
+
+func TestValidateID(t *testing.T) {
+	assert.NoError(t, ValidateID("doc_0190b5c4", IDKindDocument))
+	assert.NoError(t, ValidateID("0123456789abcdef0123456789abcdef", IDKindDocument), "unprefixed IDs of older strategies")
+	assert.NoError(t, ValidateID("my-custom_id", IDKindDocument))
+	assert.ErrorContains(t, ValidateID("usr_0190b5c4", IDKindDocument), "'usr_' prefix of another kind")
+	assert.ErrorContains(t, ValidateID("has space", IDKindProfile), "not a valid ID")
+	assert.ErrorContains(t, ValidateID(strings.Repeat("a", 65), IDKindProfile), "not a valid ID")
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/documents/:id", ValidateIDParams(map[string]IDKind{"id": IDKindDocument}), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	for path, want := range map[string]int{"/documents/doc_1": http.StatusNoContent, "/documents/sch_1": http.StatusBadRequest, "/documents/a.b": http.StatusBadRequest} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, want, rr.Code, path)
+	}
+}
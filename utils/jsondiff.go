@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RootPath is the path reported when the whole value differs (e.g. the content is a plain string).
+// It matches gjson's "@this" modifier, so every diff path can be fed back to gjson.
+const RootPath = "@this"
+
+// DiffEntry is a single difference between two JSON values.
+type DiffEntry struct {
+	Path string `json:"path"` // gjson-style path, e.g. "chapters.2.title"
+	From any    `json:"from"` // Previous value (null for added paths)
+	To   any    `json:"to"`   // New value (null for removed paths)
+}
+
+// JSONDiff is a structured, path-level difference between two JSON values.
+// A path added or removed as a whole (e.g. a new object) is reported once, not per leaf.
+type JSONDiff struct {
+	Added   []DiffEntry `json:"added"`
+	Removed []DiffEntry `json:"removed"`
+	Changed []DiffEntry `json:"changed"`
+}
+
+// Empty reports whether the two values were identical.
+func (d JSONDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Paths returns every path that differs, sorted.
+func (d JSONDiff) Paths() []string {
+	paths := make([]string, 0, len(d.Added)+len(d.Removed)+len(d.Changed))
+	for _, group := range [][]DiffEntry{d.Added, d.Removed, d.Changed} {
+		for _, entry := range group {
+			paths = append(paths, entry.Path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// DiffJSON compares two decoded JSON values (as produced by encoding/json into `any`)
+// and reports the paths that were added, removed or changed going from `from` to `to`.
+// Objects are compared key by key and arrays index by index.
+func DiffJSON(from, to any) JSONDiff {
+	diff := JSONDiff{Added: []DiffEntry{}, Removed: []DiffEntry{}, Changed: []DiffEntry{}}
+	diffValues(normalizeJSON(from), normalizeJSON(to), "", &diff)
+	return diff
+}
+
+// normalizeJSON converts a Go value into the generic form produced by encoding/json,
+// so that e.g. structs, []string and map[string]string compare like their decoded JSON.
+func normalizeJSON(value any) any {
+	switch value.(type) {
+	case nil, bool, float64, string:
+		return value
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var normalized any
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return value
+	}
+	return normalized
+}
+
+// diffValues records the differences between from and to found under path.
+func diffValues(from, to any, path string, diff *JSONDiff) {
+	fromMap, fromIsMap := from.(map[string]any)
+	toMap, toIsMap := to.(map[string]any)
+	if fromIsMap && toIsMap {
+		keys := make([]string, 0, len(fromMap)+len(toMap))
+		for key := range fromMap {
+			keys = append(keys, key)
+		}
+		for key := range toMap {
+			if _, inFrom := fromMap[key]; !inFrom {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			childPath := joinDiffPath(path, escapeDiffPathKey(key))
+			fromValue, inFrom := fromMap[key]
+			toValue, inTo := toMap[key]
+			switch {
+			case !inFrom:
+				diff.Added = append(diff.Added, DiffEntry{Path: childPath, To: toValue})
+			case !inTo:
+				diff.Removed = append(diff.Removed, DiffEntry{Path: childPath, From: fromValue})
+			default:
+				diffValues(fromValue, toValue, childPath, diff)
+			}
+		}
+		return
+	}
+
+	fromSlice, fromIsSlice := from.([]any)
+	toSlice, toIsSlice := to.([]any)
+	if fromIsSlice && toIsSlice {
+		for i := 0; i < len(fromSlice) || i < len(toSlice); i++ {
+			childPath := joinDiffPath(path, strconv.Itoa(i))
+			switch {
+			case i >= len(fromSlice):
+				diff.Added = append(diff.Added, DiffEntry{Path: childPath, To: toSlice[i]})
+			case i >= len(toSlice):
+				diff.Removed = append(diff.Removed, DiffEntry{Path: childPath, From: fromSlice[i]})
+			default:
+				diffValues(fromSlice[i], toSlice[i], childPath, diff)
+			}
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(from, to) {
+		if path == "" {
+			path = RootPath
+		}
+		diff.Changed = append(diff.Changed, DiffEntry{Path: path, From: from, To: to})
+	}
+}
+
+// joinDiffPath appends a path component using gjson's dot syntax.
+func joinDiffPath(path, component string) string {
+	if path == "" {
+		return component
+	}
+	return path + "." + component
+}
+
+// escapeDiffPathKey escapes characters that have a special meaning in gjson paths.
+func escapeDiffPathKey(key string) string {
+	var b strings.Builder
+	for _, r := range key {
+		switch r {
+		case '.', '*', '?', '|', '#', '@', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// SplitDiffPath splits a diff path into its unescaped object keys and array indexes; RootPath
+// has none.
+func SplitDiffPath(path string) []string {
+	if path == RootPath {
+		return nil
+	}
+	var parts []string
+	var part strings.Builder
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '\\':
+			if i+1 < len(path) {
+				i++
+			}
+			part.WriteByte(path[i])
+		case '.':
+			parts = append(parts, part.String())
+			part.Reset()
+		default:
+			part.WriteByte(path[i])
+		}
+	}
+	return append(parts, part.String())
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func decodeJSON(t *testing.T, s string) any {
+	t.Helper()
+	var v any
+	require.NoError(t, json.Unmarshal([]byte(s), &v))
+	return v
+}
+
+func TestDiffJSON(t *testing.T) {
+	from := decodeJSON(t, `{"title": "Essay", "score": 7, "tags": ["a", "b"], "meta": {"draft": true}, "old": 1}`)
+	to := decodeJSON(t, `{"title": "Essay", "score": 9, "tags": ["a"], "meta": {"draft": true, "reviewer": "kim"}, "new": {"x": 1}}`)
+
+	diff := DiffJSON(from, to)
+	assert.Equal(t, []DiffEntry{
+		{Path: "meta.reviewer", To: "kim"},
+		{Path: "new", To: map[string]any{"x": float64(1)}},
+	}, diff.Added)
+	assert.Equal(t, []DiffEntry{
+		{Path: "old", From: float64(1)},
+		{Path: "tags.1", From: "b"},
+	}, diff.Removed)
+	assert.Equal(t, []DiffEntry{
+		{Path: "score", From: float64(7), To: float64(9)},
+	}, diff.Changed)
+	assert.Equal(t, []string{"meta.reviewer", "new", "old", "score", "tags.1"}, diff.Paths())
+	assert.False(t, diff.Empty())
+}
+
+func TestDiffJSON_Identical(t *testing.T) {
+	v := decodeJSON(t, `{"a": [1, {"b": null}]}`)
+	diff := DiffJSON(v, decodeJSON(t, `{"a": [1, {"b": null}]}`))
+	assert.True(t, diff.Empty())
+	assert.NotNil(t, diff.Added, "lists are never nil so they encode as []")
+}
+
+func TestDiffJSON_RootAndTypeChanges(t *testing.T) {
+	diff := DiffJSON("plain text", "other text")
+	assert.Equal(t, []DiffEntry{{Path: RootPath, From: "plain text", To: "other text"}}, diff.Changed)
+
+	diff = DiffJSON(decodeJSON(t, `{"a": [1]}`), decodeJSON(t, `{"a": {"0": 1}}`))
+	require.Len(t, diff.Changed, 1)
+	assert.Equal(t, "a", diff.Changed[0].Path, "a type change is reported at the path itself")
+}
+
+func TestDiffJSON_NormalizesGoValues(t *testing.T) {
+	diff := DiffJSON(map[string]any{"tags": []string{"a"}}, decodeJSON(t, `{"tags": ["a"]}`))
+	assert.True(t, diff.Empty())
+}
+
+func TestDiffJSON_PathsWorkWithGjson(t *testing.T) {
+	doc := `{"a.b": {"c": 1}}`
+	diff := DiffJSON(decodeJSON(t, `{"a.b": {"c": 0}}`), decodeJSON(t, doc))
+	require.Len(t, diff.Changed, 1)
+	assert.Equal(t, `a\.b.c`, diff.Changed[0].Path)
+	assert.Equal(t, int64(1), gjson.Get(doc, diff.Changed[0].Path).Int())
+	assert.Equal(t, doc, gjson.Get(doc, RootPath).Raw)
+}
+
+func TestSplitDiffPath(t *testing.T) {
+	from := map[string]any{"a.b": map[string]any{"c*": []any{1.0}}, "x": 1.0}
+	to := map[string]any{"a.b": map[string]any{"c*": []any{2.0}}, "x": 1.0}
+	paths := DiffJSON(from, to).Paths()
+	require.Equal(t, []string{`a\.b.c\*.0`}, paths)
+	assert.Equal(t, []string{"a.b", "c*", "0"}, SplitDiffPath(paths[0]))
+	assert.Nil(t, SplitDiffPath(RootPath))
+	assert.Equal(t, []string{"title"}, SplitDiffPath("title"))
+}
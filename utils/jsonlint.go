@@ -0,0 +1,251 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// --- JSON Linting ---
+
+// Rule names reported in LintIssue.Rule.
+const (
+	LintRuleMaxDepth     = "max_depth"
+	LintRuleMaxKeyLength = "max_key_length"
+	LintRuleNaNString    = "nan_string"
+	LintRuleDateFormat   = "date_format"
+)
+
+// Normalizations LintNormalize can apply.
+const (
+	NormalizeNumbers    = "numbers"
+	NormalizeNaNStrings = "nan_strings"
+	NormalizeTrim       = "trim_strings"
+)
+
+// KnownNormalizations lists the normalizations in the order LintNormalize applies them.
+var KnownNormalizations = []string{NormalizeTrim, NormalizeNaNStrings, NormalizeNumbers}
+
+// Limits used when LintRules leaves them at zero.
+const (
+	DefaultLintMaxDepth     = 20
+	DefaultLintMaxKeyLength = 64
+)
+
+// LintRules configures LintJSON. Zero limits use the defaults; the string checks are on unless disabled.
+type LintRules struct {
+	MaxDepth        int  `json:"max_depth,omitempty" binding:"min=0"`      // Deepest nesting of objects and arrays; the content itself is at depth 0
+	MaxKeyLength    int  `json:"max_key_length,omitempty" binding:"min=0"` // Longest object key, in characters
+	AllowNaNStrings bool `json:"allow_nan_strings,omitempty"`              // Do not report strings such as "NaN" or "-Infinity"
+	AllowMixedDates bool `json:"allow_mixed_dates,omitempty"`              // Do not report dates written in a different format than most others
+}
+
+// LintIssue is a problem LintJSON found at a content path (dot notation, as in JSONDiff).
+type LintIssue struct {
+	Path    string `json:"path"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// nanStrings are the strings, lowercased, that stand for numbers JSON cannot represent.
+var nanStrings = map[string]bool{
+	"nan": true, "+nan": true, "-nan": true,
+	"inf": true, "+inf": true, "-inf": true,
+	"infinity": true, "+infinity": true, "-infinity": true,
+}
+
+// dateFormats are the date formats the date_format rule tells apart, most specific first.
+var dateFormats = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{"RFC 3339", regexp.MustCompile(`^\d{4}-\d{2}-\d{2}[Tt]\d{2}:\d{2}:\d{2}(\.\d+)?([Zz]|[+-]\d{2}:\d{2})$`)},
+	{"YYYY-MM-DD hh:mm:ss", regexp.MustCompile(`^\d{4}-\d{2}-\d{2} \d{2}:\d{2}(:\d{2}(\.\d+)?)?$`)},
+	{"YYYY-MM-DD", regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)},
+	{"YYYY/MM/DD", regexp.MustCompile(`^\d{4}/\d{1,2}/\d{1,2}$`)},
+	{"DD/MM/YYYY or MM/DD/YYYY", regexp.MustCompile(`^\d{1,2}/\d{1,2}/\d{4}$`)},
+	{"DD.MM.YYYY", regexp.MustCompile(`^\d{1,2}\.\d{1,2}\.\d{4}$`)},
+}
+
+// numberPattern matches strings NormalizeNumbers turns into numbers: JSON numbers without
+// leading zeros, so identifiers such as zip codes ("02134") stay strings.
+var numberPattern = regexp.MustCompile(`^-?(0|[1-9]\d*)(\.\d+)?([eE][+-]?\d+)?$`)
+
+// dateFormatOf returns the name of the date format s is written in, or "" if it is not a date.
+func dateFormatOf(s string) string {
+	if len(s) < 8 || len(s) > 40 {
+		return ""
+	}
+	for _, format := range dateFormats {
+		if format.pattern.MatchString(s) {
+			return format.name
+		}
+	}
+	return ""
+}
+
+// isNaNString reports whether s spells a number JSON cannot represent.
+func isNaNString(s string) bool {
+	return nanStrings[strings.ToLower(strings.TrimSpace(s))]
+}
+
+// LintJSON checks value (decoded JSON) against the rules and returns the issues found, ordered
+// by path. A container deeper than allowed is reported once; what it holds is not checked further.
+func LintJSON(value any, rules LintRules) []LintIssue {
+	if rules.MaxDepth <= 0 {
+		rules.MaxDepth = DefaultLintMaxDepth
+	}
+	if rules.MaxKeyLength <= 0 {
+		rules.MaxKeyLength = DefaultLintMaxKeyLength
+	}
+
+	linter := &jsonLinter{rules: rules, issues: []LintIssue{}, dates: map[string][]string{}}
+	linter.walk(normalizeJSON(value), "", 0)
+	if !rules.AllowMixedDates {
+		linter.checkDates()
+	}
+	issues := linter.issues
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Path < issues[j].Path })
+	return issues
+}
+
+// jsonLinter collects issues while walking a value.
+type jsonLinter struct {
+	rules  LintRules
+	issues []LintIssue
+	dates  map[string][]string // Paths of the date strings, by format
+	order  []string            // Date formats in the order they were first seen
+}
+
+func (l *jsonLinter) report(path, rule, format string, args ...any) {
+	if path == "" {
+		path = RootPath
+	}
+	l.issues = append(l.issues, LintIssue{Path: path, Rule: rule, Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *jsonLinter) walk(value any, path string, depth int) {
+	switch typed := value.(type) {
+	case map[string]any:
+		if depth+1 > l.rules.MaxDepth {
+			l.report(path, LintRuleMaxDepth, "nested deeper than %d levels", l.rules.MaxDepth)
+			return
+		}
+		keys := make([]string, 0, len(typed))
+		for key := range typed {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			childPath := joinDiffPath(path, escapeDiffPathKey(key))
+			if n := utf8.RuneCountInString(key); n > l.rules.MaxKeyLength {
+				l.report(childPath, LintRuleMaxKeyLength, "key is %d characters long, more than %d", n, l.rules.MaxKeyLength)
+			}
+			l.walk(typed[key], childPath, depth+1)
+		}
+	case []any:
+		if depth+1 > l.rules.MaxDepth {
+			l.report(path, LintRuleMaxDepth, "nested deeper than %d levels", l.rules.MaxDepth)
+			return
+		}
+		for i, item := range typed {
+			l.walk(item, joinDiffPath(path, strconv.Itoa(i)), depth+1)
+		}
+	case string:
+		if !l.rules.AllowNaNStrings && isNaNString(typed) {
+			l.report(path, LintRuleNaNString, "'%s' is not a number JSON can hold; use null or a number", typed)
+		}
+		if format := dateFormatOf(typed); format != "" {
+			if _, seen := l.dates[format]; !seen {
+				l.order = append(l.order, format)
+			}
+			l.dates[format] = append(l.dates[format], path)
+		}
+	}
+}
+
+// checkDates reports the dates written in another format than the most common one (on a tie,
+// the one seen first).
+func (l *jsonLinter) checkDates() {
+	if len(l.order) < 2 {
+		return
+	}
+	common := l.order[0]
+	for _, format := range l.order[1:] {
+		if len(l.dates[format]) > len(l.dates[common]) {
+			common = format
+		}
+	}
+	for _, format := range l.order {
+		if format == common {
+			continue
+		}
+		for _, path := range l.dates[format] {
+			l.report(path, LintRuleDateFormat, "date is written as %s, but most dates are %s", format, common)
+		}
+	}
+}
+
+// CheckNormalizations returns an error naming the first unknown normalization.
+func CheckNormalizations(names []string) error {
+	for _, name := range names {
+		if !slices.Contains(KnownNormalizations, name) {
+			return fmt.Errorf("unknown normalization '%s' (expected one of %s)", name, strings.Join(KnownNormalizations, ", "))
+		}
+	}
+	return nil
+}
+
+// LintNormalize returns a normalized copy of value (decoded JSON); value itself is not changed.
+// trim_strings trims the white space around strings, nan_strings replaces NaN-like strings with
+// null, and numbers turns strings that are plain JSON numbers into numbers and -0 into 0. Object
+// keys need no normalization: content is always stored, and returned, with its keys sorted.
+func LintNormalize(value any, names []string) any {
+	enabled := map[string]bool{}
+	for _, name := range names {
+		enabled[name] = true
+	}
+	return normalizeLintValue(normalizeJSON(value), enabled)
+}
+
+func normalizeLintValue(value any, enabled map[string]bool) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		normalized := make(map[string]any, len(typed))
+		for key, child := range typed {
+			normalized[key] = normalizeLintValue(child, enabled)
+		}
+		return normalized
+	case []any:
+		normalized := make([]any, len(typed))
+		for i, item := range typed {
+			normalized[i] = normalizeLintValue(item, enabled)
+		}
+		return normalized
+	case string:
+		if enabled[NormalizeTrim] {
+			typed = strings.TrimSpace(typed)
+		}
+		if enabled[NormalizeNaNStrings] && isNaNString(typed) {
+			return nil
+		}
+		if enabled[NormalizeNumbers] && numberPattern.MatchString(typed) {
+			if n, err := strconv.ParseFloat(typed, 64); err == nil && !math.IsInf(n, 0) {
+				return normalizeLintValue(n, enabled)
+			}
+		}
+		return typed
+	case float64:
+		if enabled[NormalizeNumbers] && typed == 0 {
+			return float64(0) // -0 is written as 0
+		}
+		return typed
+	default:
+		return value
+	}
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLintJSON(t *testing.T) {
+	content := decodeJSON(t, `{
+		"a_rather_long_key_name": 1,
+		"score": "NaN",
+		"due": "2024-05-01",
+		"handed_in": "2024-05-03",
+		"graded": "03/05/2024",
+		"deep": {"x": {"y": [1]}}
+	}`)
+
+	issues := LintJSON(content, LintRules{MaxDepth: 3, MaxKeyLength: 10})
+	assert.Equal(t, []LintIssue{
+		{Path: "a_rather_long_key_name", Rule: LintRuleMaxKeyLength, Message: "key is 22 characters long, more than 10"},
+		{Path: "deep.x.y", Rule: LintRuleMaxDepth, Message: "nested deeper than 3 levels"},
+		{Path: "graded", Rule: LintRuleDateFormat, Message: "date is written as DD/MM/YYYY or MM/DD/YYYY, but most dates are YYYY-MM-DD"},
+		{Path: "score", Rule: LintRuleNaNString, Message: "'NaN' is not a number JSON can hold; use null or a number"},
+	}, issues)
+
+	issues = LintJSON(content, LintRules{MaxDepth: 3, MaxKeyLength: 30, AllowNaNStrings: true, AllowMixedDates: true})
+	assert.Equal(t, []LintIssue{{Path: "deep.x.y", Rule: LintRuleMaxDepth, Message: "nested deeper than 3 levels"}}, issues)
+}
+
+func TestLintJSON_Defaults(t *testing.T) {
+	assert.Equal(t, []LintIssue{}, LintJSON(decodeJSON(t, `{"a": [{"b": "2024-01-01T10:00:00Z"}]}`), LintRules{}), "never nil, so it encodes as []")
+	assert.Equal(t, []LintIssue{{Path: RootPath, Rule: LintRuleNaNString, Message: "'-Infinity' is not a number JSON can hold; use null or a number"}},
+		LintJSON("-Infinity", LintRules{}))
+
+	// 21 nested arrays are one more than the default allows
+	deep := any("x")
+	for i := 0; i < DefaultLintMaxDepth+1; i++ {
+		deep = []any{deep}
+	}
+	issues := LintJSON(deep, LintRules{})
+	if assert.Len(t, issues, 1) {
+		assert.Equal(t, LintRuleMaxDepth, issues[0].Rule)
+	}
+}
+
+func TestLintNormalize(t *testing.T) {
+	content := decodeJSON(t, `{"n": " 42 ", "zip": "02134", "f": "1.50", "x": "inf", "z": -0, "list": ["1e3", "text"]}`)
+
+	normalized := LintNormalize(content, []string{NormalizeTrim, NormalizeNaNStrings, NormalizeNumbers})
+	assert.Equal(t, map[string]any{
+		"n": float64(42), "zip": "02134", "f": 1.5, "x": nil, "z": float64(0),
+		"list": []any{float64(1000), "text"},
+	}, normalized)
+	assert.Equal(t, " 42 ", content.(map[string]any)["n"], "the input is not changed")
+
+	normalized = LintNormalize(content, []string{NormalizeNumbers})
+	assert.Equal(t, " 42 ", normalized.(map[string]any)["n"], "only trimmed strings are numbers")
+	assert.Equal(t, content, LintNormalize(content, nil))
+}
+
+func TestCheckNormalizations(t *testing.T) {
+	assert.NoError(t, CheckNormalizations([]string{NormalizeNumbers, NormalizeTrim}))
+	assert.EqualError(t, CheckNormalizations([]string{"sort"}), "unknown normalization 'sort' (expected one of trim_strings, nan_strings, numbers)")
+}
@@ -0,0 +1,358 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// --- JSON Schema (subset) ---
+
+// Schemas are decoded JSON (map[string]any). Only the keywords below are supported; CheckJSONSchema
+// rejects others rather than ignoring them, so a schema never looks stricter than it is.
+var jsonSchemaKeywords = map[string]bool{
+	"type": true, "enum": true, "const": true,
+	"properties": true, "required": true, "additionalProperties": true, "maxProperties": true,
+	"items": true, "minItems": true, "maxItems": true,
+	"minLength": true, "maxLength": true, "pattern": true,
+	"minimum": true, "maximum": true,
+	// Annotations, which do not affect validation
+	"$schema": true, "$id": true, "title": true, "description": true, "default": true, "examples": true,
+}
+
+// jsonSchemaTypes are the values "type" may take.
+var jsonSchemaTypes = map[string]bool{
+	"object": true, "array": true, "string": true, "number": true, "integer": true, "boolean": true, "null": true,
+}
+
+// CheckJSONSchema reports whether schema is a JSON Schema ValidateJSONSchema can apply: an object
+// using only the supported keywords (type, enum, const, properties, required,
+// additionalProperties, maxProperties, items, minItems, maxItems, minLength, maxLength, pattern,
+// minimum and maximum), with valid values.
+func CheckJSONSchema(schema any) error {
+	return checkSchemaAt(schema, "")
+}
+
+// checkSchemaAt checks the schema found at path.
+func checkSchemaAt(schema any, path string) error {
+	node, isObject := schema.(map[string]any)
+	if !isObject {
+		if _, isBool := schema.(bool); isBool {
+			return nil // true allows anything, false nothing
+		}
+		return schemaError(path, "a schema must be an object or a boolean")
+	}
+	keywords := make([]string, 0, len(node))
+	for keyword := range node {
+		keywords = append(keywords, keyword)
+	}
+	sort.Strings(keywords)
+	for _, keyword := range keywords {
+		if !jsonSchemaKeywords[keyword] {
+			return schemaError(path, fmt.Sprintf("unsupported keyword '%s'", keyword))
+		}
+	}
+
+	if types, found := node["type"]; found {
+		names, ok := schemaTypeNames(types)
+		if !ok {
+			return schemaError(path, "'type' must be a type name or a list of them")
+		}
+		for _, name := range names {
+			if !jsonSchemaTypes[name] {
+				return schemaError(path, fmt.Sprintf("unknown type '%s'", name))
+			}
+		}
+	}
+	if enum, found := node["enum"]; found {
+		if _, isList := enum.([]any); !isList {
+			return schemaError(path, "'enum' must be a list")
+		}
+	}
+	if properties, found := node["properties"]; found {
+		children, isObject := properties.(map[string]any)
+		if !isObject {
+			return schemaError(path, "'properties' must be an object")
+		}
+		for name, child := range children {
+			if err := checkSchemaAt(child, joinSchemaPath(path, name)); err != nil {
+				return err
+			}
+		}
+	}
+	if required, found := node["required"]; found {
+		names, isList := required.([]any)
+		if !isList {
+			return schemaError(path, "'required' must be a list of property names")
+		}
+		for _, name := range names {
+			if _, isString := name.(string); !isString {
+				return schemaError(path, "'required' must be a list of property names")
+			}
+		}
+	}
+	for _, keyword := range []string{"additionalProperties", "items"} {
+		if child, found := node[keyword]; found {
+			if err := checkSchemaAt(child, joinSchemaPath(path, keyword)); err != nil {
+				return err
+			}
+		}
+	}
+	for _, keyword := range []string{"maxProperties", "minItems", "maxItems", "minLength", "maxLength"} {
+		if value, found := node[keyword]; found {
+			if n, ok := schemaNumber(value); !ok || n < 0 || n != math.Trunc(n) {
+				return schemaError(path, fmt.Sprintf("'%s' must be a non-negative integer", keyword))
+			}
+		}
+	}
+	for _, keyword := range []string{"minimum", "maximum"} {
+		if value, found := node[keyword]; found {
+			if _, ok := schemaNumber(value); !ok {
+				return schemaError(path, fmt.Sprintf("'%s' must be a number", keyword))
+			}
+		}
+	}
+	if pattern, found := node["pattern"]; found {
+		text, isString := pattern.(string)
+		if !isString {
+			return schemaError(path, "'pattern' must be a string")
+		}
+		if _, err := regexp.Compile(text); err != nil {
+			return schemaError(path, fmt.Sprintf("invalid 'pattern': %v", err))
+		}
+	}
+	return nil
+}
+
+// ValidateJSONSchema checks value (decoded JSON) against schema, which should have passed
+// CheckJSONSchema. The error names the first offending path, e.g. "address.zip: expected string".
+func ValidateJSONSchema(schema, value any) error {
+	return validateSchemaAt(schema, value, "")
+}
+
+// validateSchemaAt checks the value found at path.
+func validateSchemaAt(schema, value any, path string) error {
+	if allowed, isBool := schema.(bool); isBool {
+		if !allowed {
+			return schemaError(path, "not allowed")
+		}
+		return nil
+	}
+	node, isObject := schema.(map[string]any)
+	if !isObject {
+		return nil
+	}
+
+	if types, found := node["type"]; found {
+		names, _ := schemaTypeNames(types)
+		matched := false
+		for _, name := range names {
+			if schemaTypeMatches(name, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return schemaError(path, "expected "+strings.Join(names, " or "))
+		}
+	}
+	if enum, found := node["enum"].([]any); found {
+		matched := false
+		for _, allowed := range enum {
+			if schemaEqual(allowed, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return schemaError(path, "not one of the allowed values")
+		}
+	}
+	if constant, found := node["const"]; found && !schemaEqual(constant, value) {
+		return schemaError(path, "not the allowed value")
+	}
+
+	switch typed := value.(type) {
+	case map[string]any:
+		return validateSchemaObject(node, typed, path)
+	case []any:
+		if n, found := schemaLimit(node, "minItems"); found && float64(len(typed)) < n {
+			return schemaError(path, fmt.Sprintf("expected at least %v items", n))
+		}
+		if n, found := schemaLimit(node, "maxItems"); found && float64(len(typed)) > n {
+			return schemaError(path, fmt.Sprintf("expected at most %v items", n))
+		}
+		if items, found := node["items"]; found {
+			for i, item := range typed {
+				if err := validateSchemaAt(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		length := float64(len([]rune(typed)))
+		if n, found := schemaLimit(node, "minLength"); found && length < n {
+			return schemaError(path, fmt.Sprintf("expected at least %v characters", n))
+		}
+		if n, found := schemaLimit(node, "maxLength"); found && length > n {
+			return schemaError(path, fmt.Sprintf("expected at most %v characters", n))
+		}
+		if pattern, found := node["pattern"].(string); found {
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(typed) {
+				return schemaError(path, fmt.Sprintf("does not match '%s'", pattern))
+			}
+		}
+	default:
+		if number, isNumber := schemaNumber(value); isNumber {
+			if n, found := schemaLimit(node, "minimum"); found && number < n {
+				return schemaError(path, fmt.Sprintf("expected at least %v", n))
+			}
+			if n, found := schemaLimit(node, "maximum"); found && number > n {
+				return schemaError(path, fmt.Sprintf("expected at most %v", n))
+			}
+		}
+	}
+	return nil
+}
+
+// validateSchemaObject checks the properties of an object.
+func validateSchemaObject(node map[string]any, object map[string]any, path string) error {
+	if n, found := schemaLimit(node, "maxProperties"); found && float64(len(object)) > n {
+		return schemaError(path, fmt.Sprintf("expected at most %v properties", n))
+	}
+	if required, found := node["required"].([]any); found {
+		for _, name := range required {
+			if key, _ := name.(string); key != "" {
+				if _, present := object[key]; !present {
+					return schemaError(joinSchemaPath(path, key), "required")
+				}
+			}
+		}
+	}
+
+	properties, _ := node["properties"].(map[string]any)
+	additional, restricted := node["additionalProperties"]
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys) // Report the same error every time
+	for _, key := range keys {
+		child, declared := properties[key]
+		if !declared {
+			if !restricted {
+				continue
+			}
+			child = additional
+		}
+		if err := validateSchemaAt(child, object[key], joinSchemaPath(path, key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// schemaTypeNames returns the type names of a "type" keyword.
+func schemaTypeNames(types any) ([]string, bool) {
+	switch typed := types.(type) {
+	case string:
+		return []string{typed}, true
+	case []any:
+		names := make([]string, 0, len(typed))
+		for _, name := range typed {
+			text, isString := name.(string)
+			if !isString {
+				return nil, false
+			}
+			names = append(names, text)
+		}
+		return names, len(names) > 0
+	default:
+		return nil, false
+	}
+}
+
+// schemaTypeMatches reports whether value is of the named JSON type.
+func schemaTypeMatches(name string, value any) bool {
+	switch name {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	case "number":
+		_, ok := schemaNumber(value)
+		return ok
+	case "integer":
+		n, ok := schemaNumber(value)
+		return ok && n == math.Trunc(n)
+	default:
+		return false
+	}
+}
+
+// schemaNumber returns value as a float64 if it is a number.
+func schemaNumber(value any) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// schemaLimit returns the numeric value of keyword in node.
+func schemaLimit(node map[string]any, keyword string) (float64, bool) {
+	value, found := node[keyword]
+	if !found {
+		return 0, false
+	}
+	return schemaNumber(value)
+}
+
+// schemaEqual compares two decoded JSON values, treating equal numbers of different Go types as equal.
+func schemaEqual(a, b any) bool {
+	if x, ok := schemaNumber(a); ok {
+		y, ok := schemaNumber(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// joinSchemaPath appends an object key to a path.
+func joinSchemaPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// schemaError describes a problem at path.
+func schemaError(path, problem string) error {
+	if path == "" {
+		return fmt.Errorf("%s", problem)
+	}
+	return fmt.Errorf("%s: %s", path, problem)
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckJSONSchema(t *testing.T) {
+	valid := decodeJSON(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"properties": {"age": {"type": "integer", "minimum": 0}, "tags": {"type": "array", "items": {"type": "string"}}},
+		"required": ["age"],
+		"additionalProperties": false
+	}`)
+	require.NoError(t, CheckJSONSchema(valid))
+	assert.NoError(t, CheckJSONSchema(true))
+
+	for name, schema := range map[string]string{
+		"not an object":       `"object"`,
+		"unsupported keyword": `{"oneOf": [{"type": "string"}]}`,
+		"unknown type":        `{"type": "text"}`,
+		"nested":              `{"properties": {"a": {"type": 1}}}`,
+		"negative limit":      `{"maxLength": -1}`,
+		"bad pattern":         `{"pattern": "("}`,
+		"required names":      `{"required": [1]}`,
+	} {
+		assert.Error(t, CheckJSONSchema(decodeJSON(t, schema)), name)
+	}
+}
+
+func TestValidateJSONSchema(t *testing.T) {
+	schema := decodeJSON(t, `{
+		"type": "object",
+		"properties": {
+			"age": {"type": "integer", "minimum": 0, "maximum": 150},
+			"nick": {"type": ["string", "null"], "maxLength": 5, "pattern": "^[a-z]*$"},
+			"role": {"enum": ["student", "teacher"]},
+			"tags": {"type": "array", "maxItems": 2, "items": {"type": "string"}}
+		},
+		"required": ["age"],
+		"additionalProperties": false
+	}`)
+
+	assert.NoError(t, ValidateJSONSchema(schema, decodeJSON(t, `{"age": 20, "nick": "kim", "role": "student", "tags": ["a"]}`)))
+	assert.NoError(t, ValidateJSONSchema(schema, decodeJSON(t, `{"age": 20, "nick": null}`)))
+	assert.NoError(t, ValidateJSONSchema(schema, map[string]any{"age": 20}), "Go integers count as numbers")
+
+	for value, want := range map[string]string{
+		`[]`:                                  "expected object",
+		`{}`:                                  "age: required",
+		`{"age": 1.5}`:                        "age: expected integer",
+		`{"age": -1}`:                         "age: expected at least 0",
+		`{"age": 1, "nick": "toolong"}`:       "nick: expected at most 5 characters",
+		`{"age": 1, "nick": "Kim"}`:           "nick: does not match",
+		`{"age": 1, "role": "admin"}`:         "role: not one of the allowed values",
+		`{"age": 1, "tags": ["a", 2]}`:        "tags[1]: expected string",
+		`{"age": 1, "tags": ["a", "b", "c"]}`: "tags: expected at most 2 items",
+		`{"age": 1, "extra": true}`:           "extra: not allowed",
+	} {
+		err := ValidateJSONSchema(schema, decodeJSON(t, value))
+		if assert.Error(t, err, value) {
+			assert.Contains(t, err.Error(), want, value)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"log"
+	"strings"
+)
+
+// SendEmail delivers a notification email to a user.
+// There is no mail transport configured yet, so, like password reset OTPs,
+// the message is written to the server log for the operator to relay.
+func SendEmail(to, subject, body string) {
+	log.Printf("*****************************************************")
+	log.Printf("EMAIL to %s: %s", to, subject)
+	for _, line := range strings.Split(strings.TrimRight(body, "\n"), "\n") {
+		log.Printf("  %s", line)
+	}
+	log.Printf("*****************************************************")
+}
@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendEmail(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	SendEmail("user@example.com", "Hello", "First line\nSecond line\n")
+
+	output := buf.String()
+	assert.Contains(t, output, "EMAIL to user@example.com: Hello")
+	assert.Contains(t, output, "  First line")
+	assert.Contains(t, output, "  Second line")
+}
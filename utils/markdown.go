@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// --- Markdown Rendering ---
+
+// RenderMarkdown converts Markdown to HTML. It supports the common subset students use:
+// ATX headings (# Title), paragraphs, emphasis (*em*, **strong**), `inline code`, fenced
+// code blocks, bulleted and numbered lists, block quotes, horizontal rules and links.
+// Raw HTML in the source is escaped rather than passed through, and links are only kept
+// for http(s), mailto and relative URLs, so the output is safe to embed in a page.
+func RenderMarkdown(source string) string {
+	lines := strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n")
+	var out strings.Builder
+	var paragraph []string
+
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			out.WriteString("<p>" + renderInline(strings.Join(paragraph, "\n")) + "</p>\n")
+			paragraph = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			flushParagraph()
+
+		case strings.HasPrefix(trimmed, "```"):
+			flushParagraph()
+			language := strings.TrimSpace(strings.TrimPrefix(trimmed, "```"))
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, lines[i])
+			}
+			if language != "" {
+				out.WriteString(`<pre><code class="language-` + html.EscapeString(language) + `">`)
+			} else {
+				out.WriteString("<pre><code>")
+			}
+			out.WriteString(html.EscapeString(strings.Join(code, "\n")))
+			if len(code) > 0 {
+				out.WriteString("\n")
+			}
+			out.WriteString("</code></pre>\n")
+
+		case markdownHeading.MatchString(trimmed):
+			flushParagraph()
+			match := markdownHeading.FindStringSubmatch(trimmed)
+			level := strconv.Itoa(len(match[1]))
+			text := strings.TrimSpace(strings.TrimRight(match[2], "#"))
+			out.WriteString("<h" + level + ">" + renderInline(text) + "</h" + level + ">\n")
+
+		case markdownRule.MatchString(trimmed):
+			flushParagraph()
+			out.WriteString("<hr>\n")
+
+		case strings.HasPrefix(trimmed, ">"):
+			flushParagraph()
+			var quoted []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				quoted = append(quoted, strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(lines[i]), ">"), " "))
+			}
+			i--
+			out.WriteString("<blockquote>\n" + RenderMarkdown(strings.Join(quoted, "\n")) + "</blockquote>\n")
+
+		case markdownBullet.MatchString(trimmed), markdownNumbered.MatchString(trimmed):
+			flushParagraph()
+			item, tag := markdownBullet, "ul"
+			if markdownNumbered.MatchString(trimmed) {
+				item, tag = markdownNumbered, "ol"
+			}
+			out.WriteString("<" + tag + ">\n")
+			for ; i < len(lines) && item.MatchString(strings.TrimSpace(lines[i])); i++ {
+				text := item.ReplaceAllString(strings.TrimSpace(lines[i]), "")
+				out.WriteString("<li>" + renderInline(text) + "</li>\n")
+			}
+			i--
+			out.WriteString("</" + tag + ">\n")
+
+		default:
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+	flushParagraph()
+	return out.String()
+}
+
+var (
+	markdownHeading  = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	markdownRule     = regexp.MustCompile(`^([-*_])(\s*[-*_]){2,}$`)
+	markdownBullet   = regexp.MustCompile(`^[-*+]\s+`)
+	markdownNumbered = regexp.MustCompile(`^\d+[.)]\s+`)
+
+	markdownCode   = regexp.MustCompile("`([^`]+)`")
+	markdownLink   = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	markdownStrong = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	markdownEm     = regexp.MustCompile(`\*([^*]+)\*|\b_([^_]+)_\b`)
+)
+
+// renderInline escapes text and renders code spans, links and emphasis.
+// Code spans are set aside first so nothing inside them is interpreted.
+func renderInline(text string) string {
+	var spans []string
+	text = markdownCode.ReplaceAllStringFunc(text, func(match string) string {
+		spans = append(spans, "<code>"+html.EscapeString(markdownCode.FindStringSubmatch(match)[1])+"</code>")
+		return "\x00" + strconv.Itoa(len(spans)-1) + "\x00"
+	})
+
+	text = html.EscapeString(text)
+	text = markdownLink.ReplaceAllStringFunc(text, func(match string) string {
+		parts := markdownLink.FindStringSubmatch(match)
+		label, target := parts[1], html.UnescapeString(parts[2])
+		if !isSafeLinkTarget(target) {
+			return label
+		}
+		return `<a href="` + html.EscapeString(target) + `">` + label + "</a>"
+	})
+	text = markdownStrong.ReplaceAllString(text, "<strong>$1$2</strong>")
+	text = markdownEm.ReplaceAllString(text, "<em>$1$2</em>")
+
+	for i, span := range spans {
+		text = strings.Replace(text, "\x00"+strconv.Itoa(i)+"\x00", span, 1)
+	}
+	return text
+}
+
+// isSafeLinkTarget reports whether a link may be rendered: http(s) and mailto URLs, and
+// relative ones. Anything else (javascript:, data:, ...) is dropped.
+func isSafeLinkTarget(target string) bool {
+	lower := strings.ToLower(target)
+	for _, scheme := range []string{"http://", "https://", "mailto:"} {
+		if strings.HasPrefix(lower, scheme) {
+			return true
+		}
+	}
+	colon := strings.Index(lower, ":")
+	return colon < 0 || strings.ContainsAny(lower[:colon], "/?#")
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	source := "# Lab *1* #\n\nSome **bold** and _quiet_ text\nwith `a < b` inline.\n\n" +
+		"- one\n- [two](https://example.com/?a=1&b=2)\n\n1. first\n2) second\n\n" +
+		"> quoted\n> more\n\n---\n\n```go\nif a < b {\n}\n```\n"
+	expected := "<h1>Lab <em>1</em></h1>\n" +
+		"<p>Some <strong>bold</strong> and <em>quiet</em> text\nwith <code>a &lt; b</code> inline.</p>\n" +
+		"<ul>\n<li>one</li>\n<li><a href=\"https://example.com/?a=1&amp;b=2\">two</a></li>\n</ul>\n" +
+		"<ol>\n<li>first</li>\n<li>second</li>\n</ol>\n" +
+		"<blockquote>\n<p>quoted\nmore</p>\n</blockquote>\n" +
+		"<hr>\n" +
+		"<pre><code class=\"language-go\">if a &lt; b {\n}\n</code></pre>\n"
+	assert.Equal(t, expected, RenderMarkdown(source))
+
+	t.Run("Unsafe input", func(t *testing.T) {
+		assert.Equal(t, "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>\n", RenderMarkdown("<script>alert(1)</script>"))
+		assert.Equal(t, "<p>click</p>\n", RenderMarkdown("[click](javascript:void)"), "unsafe links lose their target")
+		assert.Equal(t, "<p><a href=\"notes/2.md\">next</a></p>\n", RenderMarkdown("[next](notes/2.md)"))
+		assert.Equal(t, "<p><code>**not bold**</code></p>\n", RenderMarkdown("`**not bold**`"))
+	})
+
+	assert.Empty(t, RenderMarkdown(""))
+}
@@ -0,0 +1,210 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --- Mock Data ---
+
+// MockDocumentShape is the shape mock documents get when none is given: a typical lab report.
+var MockDocumentShape = map[string]any{
+	"title":     "sentence",
+	"author":    "name",
+	"email":     "email",
+	"grade":     "int:0..100",
+	"submitted": "bool",
+	"status":    "enum:draft|submitted|graded",
+	"tags":      []any{"word"},
+	"date":      "date",
+	"summary":   "paragraph",
+}
+
+// MockValueTypes lists the value types a mock shape may use. "int" and "number" take an
+// optional range ("int:1..10"), "enum" a list of choices ("enum:red|green|blue").
+var MockValueTypes = []string{"string", "word", "sentence", "paragraph", "name", "first_name", "last_name", "email", "url", "id", "int", "number", "bool", "date", "enum"}
+
+var (
+	mockWords      = strings.Fields("lorem ipsum dolor sit amet consectetur adipiscing elit sed do eiusmod tempor incididunt labore dolore magna aliqua enim minim veniam quis nostrud exercitation ullamco laboris nisi aliquip commodo consequat")
+	mockFirstNames = []string{"Ada", "Alan", "Grace", "Linus", "Margaret", "Dennis", "Barbara", "Ken", "Frances", "Edsger", "Radia", "Tim"}
+	mockLastNames  = []string{"Lovelace", "Turing", "Hopper", "Torvalds", "Hamilton", "Ritchie", "Liskov", "Thompson", "Allen", "Dijkstra", "Perlman", "Berners-Lee"}
+	mockDomains    = []string{"example.com", "example.org", "example.net"}
+	mockEpoch      = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// ValidateMockShape checks that a shape only uses known value types.
+func ValidateMockShape(shape any) error {
+	_, err := GenerateMockContent(shape, rand.New(rand.NewSource(0)))
+	return err
+}
+
+// GenerateMockContent returns fake content of the given shape, drawing from rng.
+// A shape is a JSON value: a string names the type of value to generate (one of MockValueTypes),
+// an object generates an object with a value for each of its fields, and an array with one
+// element generates one to five values of that element's shape. Numbers, booleans and null are
+// copied as they are.
+func GenerateMockContent(shape any, rng *rand.Rand) (any, error) {
+	return generateMockValue(shape, rng, "", 0)
+}
+
+// maxMockDepth is how deeply objects and arrays may nest in a shape, which keeps the amount of
+// content a shape generates reasonable.
+const maxMockDepth = 5
+
+// generateMockValue generates the value for the part of the shape at path (used in errors),
+// nested depth objects or arrays deep.
+func generateMockValue(shape any, rng *rand.Rand, path string, depth int) (any, error) {
+	switch shape.(type) {
+	case map[string]any, []any:
+		if depth >= maxMockDepth {
+			return nil, fmt.Errorf("%s: shapes may nest at most %d levels deep", mockPathName(path), maxMockDepth)
+		}
+	}
+	switch spec := shape.(type) {
+	case map[string]any:
+		// Visit fields in a fixed order so a seeded rng always produces the same content
+		fields := make([]string, 0, len(spec))
+		for field := range spec {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		content := make(map[string]any, len(spec))
+		for _, field := range fields {
+			value, err := generateMockValue(spec[field], rng, joinMockPath(path, field), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			content[field] = value
+		}
+		return content, nil
+	case []any:
+		if len(spec) != 1 {
+			return nil, fmt.Errorf("%s: an array shape must have exactly one element", mockPathName(path))
+		}
+		values := make([]any, 1+rng.Intn(5))
+		for i := range values {
+			value, err := generateMockValue(spec[0], rng, joinMockPath(path, strconv.Itoa(i)), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return values, nil
+	case string:
+		value, err := generateMockScalar(spec, rng)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", mockPathName(path), err)
+		}
+		return value, nil
+	default:
+		return spec, nil
+	}
+}
+
+// generateMockScalar generates a value of a type named in a shape, such as "email" or "int:1..10".
+func generateMockScalar(typeName string, rng *rand.Rand) (any, error) {
+	kind, arg, hasArg := strings.Cut(strings.TrimSpace(typeName), ":")
+	pick := func(choices []string) string { return choices[rng.Intn(len(choices))] }
+	words := func(min, max int) string {
+		parts := make([]string, min+rng.Intn(max-min+1))
+		for i := range parts {
+			parts[i] = pick(mockWords)
+		}
+		return strings.Join(parts, " ")
+	}
+	sentence := func() string {
+		text := words(4, 10)
+		return strings.ToUpper(text[:1]) + text[1:] + "."
+	}
+
+	if hasArg && kind != "int" && kind != "number" && kind != "enum" {
+		return nil, fmt.Errorf("type %q takes no argument", kind)
+	}
+	switch kind {
+	case "string", "word":
+		return pick(mockWords), nil
+	case "sentence":
+		return sentence(), nil
+	case "paragraph":
+		sentences := make([]string, 2+rng.Intn(4))
+		for i := range sentences {
+			sentences[i] = sentence()
+		}
+		return strings.Join(sentences, " "), nil
+	case "name":
+		return pick(mockFirstNames) + " " + pick(mockLastNames), nil
+	case "first_name":
+		return pick(mockFirstNames), nil
+	case "last_name":
+		return pick(mockLastNames), nil
+	case "email":
+		return strings.ToLower(pick(mockFirstNames)+"."+pick(mockLastNames)) + "@" + pick(mockDomains), nil
+	case "url":
+		return "https://" + pick(mockDomains) + "/" + pick(mockWords) + "/" + strconv.Itoa(rng.Intn(1000)), nil
+	case "id":
+		return fmt.Sprintf("%016x%016x", rng.Uint64(), rng.Uint64()), nil
+	case "bool":
+		return rng.Intn(2) == 1, nil
+	case "date":
+		return mockEpoch.Add(time.Duration(rng.Int63n(int64(2 * 365 * 24 * time.Hour)))).Truncate(time.Second).Format(time.RFC3339), nil
+	case "int", "number":
+		low, high := 0.0, 100.0
+		if hasArg {
+			var err error
+			if low, high, err = parseMockRange(arg); err != nil {
+				return nil, err
+			}
+		}
+		if kind == "int" {
+			low, high = math.Ceil(low), math.Floor(high)
+			if low > high {
+				return nil, fmt.Errorf("range %q holds no whole number", arg)
+			}
+			return int64(low) + rng.Int63n(int64(high-low)+1), nil
+		}
+		return math.Round((low+rng.Float64()*(high-low))*100) / 100, nil
+	case "enum":
+		if !hasArg || arg == "" {
+			return nil, errors.New(`type "enum" needs choices, e.g. "enum:red|green|blue"`)
+		}
+		return pick(strings.Split(arg, "|")), nil
+	default:
+		return nil, fmt.Errorf("unknown type %q (expected one of %s)", kind, strings.Join(MockValueTypes, ", "))
+	}
+}
+
+// mockRangeLimit bounds the ends of int and number ranges, keeping them exact as float64 and int64.
+const mockRangeLimit = 1e15
+
+// parseMockRange parses the "min..max" range of an int or number type.
+func parseMockRange(arg string) (float64, float64, error) {
+	lowStr, highStr, found := strings.Cut(arg, "..")
+	low, errLow := strconv.ParseFloat(strings.TrimSpace(lowStr), 64)
+	high, errHigh := strconv.ParseFloat(strings.TrimSpace(highStr), 64)
+	if !found || errLow != nil || errHigh != nil || !(math.Abs(low) <= mockRangeLimit && math.Abs(high) <= mockRangeLimit) || low > high {
+		return 0, 0, fmt.Errorf("invalid range %q (expected min..max, e.g. 1..10)", arg)
+	}
+	return low, high, nil
+}
+
+// joinMockPath appends a field name or array index to a dot-separated shape path.
+func joinMockPath(path, part string) string {
+	if path == "" {
+		return part
+	}
+	return path + "." + part
+}
+
+// mockPathName names a shape path in error messages.
+func mockPathName(path string) string {
+	if path == "" {
+		return "shape"
+	}
+	return "shape." + path
+}
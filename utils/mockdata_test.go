@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateMockContent(t *testing.T) {
+	t.Run("Default shape", func(t *testing.T) {
+		content, err := GenerateMockContent(MockDocumentShape, rand.New(rand.NewSource(1)))
+		require.NoError(t, err)
+		doc := content.(map[string]any)
+		require.Len(t, doc, len(MockDocumentShape))
+
+		assert.Contains(t, doc["email"], "@example.")
+		assert.Contains(t, doc["author"], " ")
+		grade := doc["grade"].(int64)
+		assert.True(t, grade >= 0 && grade <= 100, grade)
+		assert.IsType(t, true, doc["submitted"])
+		assert.Contains(t, []any{"draft", "submitted", "graded"}, doc["status"])
+		tags := doc["tags"].([]any)
+		assert.True(t, len(tags) >= 1 && len(tags) <= 5, len(tags))
+		_, err = time.Parse(time.RFC3339, doc["date"].(string))
+		assert.NoError(t, err)
+		assert.True(t, strings.HasSuffix(doc["title"].(string), "."))
+	})
+
+	t.Run("Seeded generation is repeatable", func(t *testing.T) {
+		first, err := GenerateMockContent(MockDocumentShape, rand.New(rand.NewSource(42)))
+		require.NoError(t, err)
+		second, err := GenerateMockContent(MockDocumentShape, rand.New(rand.NewSource(42)))
+		require.NoError(t, err)
+		assert.Equal(t, first, second)
+	})
+
+	t.Run("Nested shapes, ranges and literals", func(t *testing.T) {
+		shape := map[string]any{
+			"student": map[string]any{"first": "first_name", "id": "id"},
+			"scores":  []any{"number:1.5..2.5"},
+			"year":    "int:-3..-3",
+			"version": float64(2),
+			"notes":   nil,
+		}
+		content, err := GenerateMockContent(shape, rand.New(rand.NewSource(7)))
+		require.NoError(t, err)
+		doc := content.(map[string]any)
+		assert.Len(t, doc["student"].(map[string]any)["id"], 32)
+		for _, score := range doc["scores"].([]any) {
+			assert.InDelta(t, 2.0, score, 0.5)
+		}
+		assert.Equal(t, int64(-3), doc["year"])
+		assert.Equal(t, float64(2), doc["version"])
+		assert.Nil(t, doc["notes"])
+	})
+
+	t.Run("Invalid shapes", func(t *testing.T) {
+		for message, shape := range map[string]any{
+			"unknown type \"colour\"":    map[string]any{"a": map[string]any{"b": "colour"}},
+			"shape.a.b":                  map[string]any{"a": map[string]any{"b": "colour"}},
+			"exactly one element":        map[string]any{"tags": []any{"word", "word"}},
+			"invalid range":              "int:10..1",
+			"holds no whole number":      "int:1.2..1.8",
+			"needs choices":              "enum:",
+			"takes no argument":          "email:x",
+			"at most 5 levels":           []any{[]any{[]any{[]any{[]any{[]any{"word"}}}}}},
+			"invalid range \"1..1e300\"": "number:1..1e300",
+		} {
+			assert.ErrorContains(t, ValidateMockShape(shape), message)
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"context"
+	"docserver/config"
+	"docserver/models"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+)
+
+// smsTimeout limits each request to the SMS gateway.
+const smsTimeout = 10 * time.Second
+
+// SMSGateway sends text messages. Implementations must be safe for concurrent use.
+type SMSGateway interface {
+	SendSMS(ctx context.Context, to, message string) error
+}
+
+// LogSMSGateway writes text messages to the server log for the operator to relay,
+// the same way SendEmail does for email.
+type LogSMSGateway struct{}
+
+// SendSMS logs the message.
+func (LogSMSGateway) SendSMS(ctx context.Context, to, message string) error {
+	log.Printf("*****************************************************")
+	log.Printf("SMS to %s: %s", to, message)
+	log.Printf("*****************************************************")
+	return nil
+}
+
+// HTTPSMSGateway POSTs text messages as JSON ({"to": ..., "message": ...}) to a gateway URL.
+type HTTPSMSGateway struct {
+	URL     *url.URL
+	Options FetchOptions
+}
+
+// SendSMS posts the message to the gateway; any non-2xx response is an error.
+func (g *HTTPSMSGateway) SendSMS(ctx context.Context, to, message string) error {
+	return PostJSON(ctx, g.URL, map[string]string{"to": to, "message": message}, g.Options)
+}
+
+// NewSMSGateway returns the gateway configured by cfg.SMSGatewayURL, or a LogSMSGateway when none is set.
+func NewSMSGateway(cfg *config.Config) (SMSGateway, error) {
+	if cfg == nil || cfg.SMSGatewayURL == "" {
+		return LogSMSGateway{}, nil
+	}
+	target, err := ParseFetchURL(cfg.SMSGatewayURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMS gateway URL '%s': %w", cfg.SMSGatewayURL, err)
+	}
+	return &HTTPSMSGateway{
+		URL:     target,
+		Options: FetchOptions{AllowedDomains: []string{target.Hostname()}, Timeout: smsTimeout},
+	}, nil
+}
+
+// DeliverOTP sends a password reset OTP to the profile over the configured channel.
+// With SMS delivery the OTP is texted to the profile's phone number; profiles without one get an email.
+func DeliverOTP(ctx context.Context, cfg *config.Config, gateway SMSGateway, profile models.Profile, otp string, expiry time.Time) error {
+	if cfg != nil && cfg.OTPDelivery == config.OTPDeliverySMS && profile.Phone != "" && gateway != nil {
+		message := fmt.Sprintf("Your docserver password reset code is %s. It expires at %s.", otp, expiry.Format(time.RFC1123))
+		if err := gateway.SendSMS(ctx, profile.Phone, message); err != nil {
+			return fmt.Errorf("failed to send OTP by SMS: %w", err)
+		}
+		return nil
+	}
+	SendEmail(profile.Email, "Your password reset code", fmt.Sprintf(
+		"Use this code with POST /auth/reset-password to set a new password:\n%s\n"+
+			"It expires at %s. If you did not ask to reset your password, ignore this email.",
+		otp, expiry.Format(time.RFC1123)))
+	return nil
+}
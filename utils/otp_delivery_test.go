@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"docserver/config"
+	"docserver/models"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingSMSGateway struct {
+	to, message string
+	err         error
+}
+
+func (g *recordingSMSGateway) SendSMS(ctx context.Context, to, message string) error {
+	g.to, g.message = to, message
+	return g.err
+}
+
+func TestDeliverOTP(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	expiry := time.Now().Add(5 * time.Minute)
+	withPhone := models.Profile{Email: "phone@example.com", Phone: "+14155550123"}
+	withoutPhone := models.Profile{Email: "nophone@example.com"}
+
+	t.Run("Email", func(t *testing.T) {
+		logs.Reset()
+		gateway := &recordingSMSGateway{}
+		require.NoError(t, DeliverOTP(context.Background(), &config.Config{OTPDelivery: config.OTPDeliveryEmail}, gateway, withPhone, "123456", expiry))
+		assert.Contains(t, logs.String(), "EMAIL to phone@example.com")
+		assert.Contains(t, logs.String(), "123456")
+		assert.Empty(t, gateway.to)
+	})
+
+	t.Run("SMS", func(t *testing.T) {
+		logs.Reset()
+		gateway := &recordingSMSGateway{}
+		require.NoError(t, DeliverOTP(context.Background(), &config.Config{OTPDelivery: config.OTPDeliverySMS}, gateway, withPhone, "123456", expiry))
+		assert.Equal(t, "+14155550123", gateway.to)
+		assert.Contains(t, gateway.message, "123456")
+		assert.NotContains(t, logs.String(), "EMAIL to")
+	})
+
+	t.Run("SMS falls back to email without a phone number", func(t *testing.T) {
+		logs.Reset()
+		gateway := &recordingSMSGateway{}
+		require.NoError(t, DeliverOTP(context.Background(), &config.Config{OTPDelivery: config.OTPDeliverySMS}, gateway, withoutPhone, "123456", expiry))
+		assert.Contains(t, logs.String(), "EMAIL to nophone@example.com")
+		assert.Empty(t, gateway.to)
+	})
+
+	t.Run("Gateway failure", func(t *testing.T) {
+		gateway := &recordingSMSGateway{err: errors.New("gateway down")}
+		err := DeliverOTP(context.Background(), &config.Config{OTPDelivery: config.OTPDeliverySMS}, gateway, withPhone, "123456", expiry)
+		assert.ErrorContains(t, err, "gateway down")
+	})
+}
+
+func TestNewSMSGateway(t *testing.T) {
+	gateway, err := NewSMSGateway(&config.Config{})
+	require.NoError(t, err)
+	assert.IsType(t, LogSMSGateway{}, gateway)
+
+	_, err = NewSMSGateway(&config.Config{SMSGatewayURL: "ftp://sms.example.com"})
+	assert.Error(t, err)
+
+	var received map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	gateway, err = NewSMSGateway(&config.Config{SMSGatewayURL: server.URL + "/send"})
+	require.NoError(t, err)
+	require.NoError(t, gateway.SendSMS(context.Background(), "+14155550123", "hello"))
+	assert.Equal(t, map[string]string{"to": "+14155550123", "message": "hello"}, received)
+}
@@ -0,0 +1,184 @@
+package utils
+
+import (
+	cryptorand "crypto/rand"
+	"crypto/subtle"
+	"docserver/config"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// --- Password Hashing Algorithms ---
+
+// argon2idPrefix starts every argon2id hash, which are stored in the PHC string format:
+// $argon2id$v=19$m=<memory KiB>,t=<passes>,p=<threads>$<salt>$<key>, base64 without padding.
+const argon2idPrefix = "$argon2id$"
+
+const (
+	argon2SaltBytes = 16
+	argon2KeyBytes  = 32
+)
+
+// argon2Params are the parameters an argon2id hash was made with.
+type argon2Params struct {
+	memoryKiB uint32
+	time      uint32
+	threads   uint8
+}
+
+// configuredArgon2Params returns the argon2id parameters in cfg, raised to the least argon2 accepts.
+func configuredArgon2Params(cfg *config.Config) argon2Params {
+	params := argon2Params{memoryKiB: cfg.Argon2MemoryKiB, time: max(cfg.Argon2Time, 1), threads: max(cfg.Argon2Threads, 1)}
+	params.memoryKiB = max(params.memoryKiB, 8*uint32(params.threads))
+	return params
+}
+
+// String describes the parameters the way PasswordHashParameters does.
+func (p argon2Params) String() string {
+	return fmt.Sprintf("argon2id m=%d,t=%d,p=%d", p.memoryKiB, p.time, p.threads)
+}
+
+// HashPasswordWithConfig hashes a password with the algorithm and parameters configured in cfg
+// (see -password-hash): bcrypt by default, or argon2id.
+func HashPasswordWithConfig(password string, cfg *config.Config) (string, error) {
+	if cfg.PasswordHash != config.PasswordHashArgon2id {
+		return HashPassword(password, cfg.BcryptCost)
+	}
+	salt := make([]byte, argon2SaltBytes)
+	if _, err := cryptorand.Read(salt); err != nil {
+		log.Printf("ERROR: Failed to hash password: %v", err)
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	params := configuredArgon2Params(cfg)
+	key := argon2.IDKey([]byte(password), salt, params.time, params.memoryKiB, params.threads, argon2KeyBytes)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version, params.memoryKiB, params.time, params.threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// parseArgon2idHash splits an argon2id hash into its parameters, salt and key.
+func parseArgon2idHash(hash string) (argon2Params, []byte, []byte, error) {
+	var params argon2Params
+	parts := strings.Split(hash, "$") // "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, errors.New("not an argon2id hash")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2 version %q", parts[2])
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memoryKiB, &params.time, &params.threads); err != nil || params.time < 1 || params.threads < 1 {
+		return params, nil, nil, fmt.Errorf("invalid argon2 parameters %q", parts[3])
+	}
+	salt, errSalt := base64.RawStdEncoding.DecodeString(parts[4])
+	key, errKey := base64.RawStdEncoding.DecodeString(parts[5])
+	if errSalt != nil || errKey != nil || len(key) == 0 {
+		return params, nil, nil, errors.New("invalid argon2 salt or key")
+	}
+	return params, salt, key, nil
+}
+
+// checkArgon2idHash reports whether password matches an argon2id hash.
+func checkArgon2idHash(password, hash string) bool {
+	params, salt, key, err := parseArgon2idHash(hash)
+	if err != nil {
+		return false
+	}
+	computed := argon2.IDKey([]byte(password), salt, params.time, params.memoryKiB, params.threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(computed, key) == 1
+}
+
+// PasswordNeedsRehash reports whether hash was made with another algorithm or other parameters
+// than cfg now sets, so the password should be hashed again the next time it is known (at login).
+// Hashes that cannot be parsed are left alone.
+func PasswordNeedsRehash(hash string, cfg *config.Config) bool {
+	if strings.HasPrefix(hash, argon2idPrefix) {
+		if cfg.PasswordHash != config.PasswordHashArgon2id {
+			return true
+		}
+		params, _, _, err := parseArgon2idHash(hash)
+		return err == nil && params != configuredArgon2Params(cfg)
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return false
+	}
+	return cfg.PasswordHash == config.PasswordHashArgon2id || cost != cfg.BcryptCost
+}
+
+// PasswordHashParameters describes the algorithm and parameters a hash was made with, such as
+// "bcrypt cost=12" or "argon2id m=19456,t=2,p=1", so hashes can be grouped. It returns "" for
+// hashes it does not recognize.
+func PasswordHashParameters(hash string) string {
+	if strings.HasPrefix(hash, argon2idPrefix) {
+		params, _, _, err := parseArgon2idHash(hash)
+		if err != nil {
+			return ""
+		}
+		return params.String()
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("bcrypt cost=%d", cost)
+}
+
+// ConfiguredPasswordHashParameters describes the algorithm and parameters new hashes are made
+// with, in the form of PasswordHashParameters.
+func ConfiguredPasswordHashParameters(cfg *config.Config) string {
+	if cfg.PasswordHash == config.PasswordHashArgon2id {
+		return configuredArgon2Params(cfg).String()
+	}
+	return fmt.Sprintf("bcrypt cost=%d", cfg.BcryptCost)
+}
+
+// --- Calibration ---
+
+// Bounds of the parameters calibration may choose, so a mismeasured target cannot make
+// logins take minutes.
+const (
+	maxCalibratedBcryptCost = 20
+	maxCalibratedArgon2Time = 64
+)
+
+// calibrationBcryptCost is the bcrypt cost timed to estimate the others; each step up doubles the time.
+const calibrationBcryptCost = 8
+
+// passwordHashTimer measures how long hashing a password with cfg takes. Replaceable in tests.
+var passwordHashTimer = func(cfg *config.Config) time.Duration {
+	started := time.Now()
+	_, _ = HashPasswordWithConfig("calibration-password", cfg)
+	return time.Since(started)
+}
+
+// CalibratePasswordHashing sets the bcrypt cost (or, with argon2id, the number of passes) in cfg so
+// that hashing a password takes about cfg.PasswordHashTarget on this machine, at least as long
+// but no more than one step longer. It does nothing if no target is set. Stored hashes made with
+// other parameters are rehashed at their owners' next login.
+func CalibratePasswordHashing(cfg *config.Config) {
+	if cfg.PasswordHashTarget <= 0 {
+		return
+	}
+	probe := *cfg
+	if cfg.PasswordHash == config.PasswordHashArgon2id {
+		probe.Argon2Time = 1
+		perPass := max(passwordHashTimer(&probe), time.Nanosecond)
+		passes := math.Ceil(float64(cfg.PasswordHashTarget) / float64(perPass))
+		cfg.Argon2Time = uint32(min(max(passes, 1), maxCalibratedArgon2Time))
+		log.Printf("INFO: Calibrated argon2id to %d passes (%s per pass, target %s)", cfg.Argon2Time, perPass, cfg.PasswordHashTarget)
+		return
+	}
+	probe.BcryptCost = calibrationBcryptCost
+	measured := max(passwordHashTimer(&probe), time.Nanosecond)
+	steps := math.Ceil(math.Log2(float64(cfg.PasswordHashTarget) / float64(measured)))
+	cfg.BcryptCost = int(min(max(calibrationBcryptCost+steps, float64(bcrypt.MinCost)), maxCalibratedBcryptCost))
+	log.Printf("INFO: Calibrated bcrypt cost to %d (%s at cost %d, target %s)", cfg.BcryptCost, measured, calibrationBcryptCost, cfg.PasswordHashTarget)
+}
@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"docserver/config"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testArgon2Config hashes with cheap argon2id parameters to keep tests fast.
+func testArgon2Config() *config.Config {
+	return &config.Config{PasswordHash: config.PasswordHashArgon2id, Argon2Time: 1, Argon2MemoryKiB: 64, Argon2Threads: 1, BcryptCost: 4}
+}
+
+func TestHashPasswordWithConfig(t *testing.T) {
+	t.Run("argon2id", func(t *testing.T) {
+		cfg := testArgon2Config()
+		hash, err := HashPasswordWithConfig("s3cret", cfg)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$"), hash)
+		assert.True(t, CheckPasswordHash("s3cret", hash))
+		assert.False(t, CheckPasswordHash("wrong", hash))
+		assert.False(t, CheckPasswordHash("s3cret", hash[:len(hash)-4]+"AAAA"))
+		assert.False(t, CheckPasswordHash("s3cret", "$argon2id$v=19$m=64,t=0,p=1$c2FsdA$a2V5"), "invalid parameters")
+
+		other, err := HashPasswordWithConfig("s3cret", cfg)
+		require.NoError(t, err)
+		assert.NotEqual(t, hash, other, "salted")
+	})
+
+	t.Run("bcrypt", func(t *testing.T) {
+		hash, err := HashPasswordWithConfig("s3cret", &config.Config{PasswordHash: config.PasswordHashBcrypt, BcryptCost: 4})
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(hash, "$2a$04$"), hash)
+		assert.True(t, CheckPasswordHash("s3cret", hash))
+	})
+}
+
+func TestPasswordNeedsRehash(t *testing.T) {
+	bcryptCfg := &config.Config{PasswordHash: config.PasswordHashBcrypt, BcryptCost: 4}
+	argonCfg := testArgon2Config()
+	bcryptHash, err := HashPasswordWithConfig("pw", bcryptCfg)
+	require.NoError(t, err)
+	argonHash, err := HashPasswordWithConfig("pw", argonCfg)
+	require.NoError(t, err)
+
+	assert.False(t, PasswordNeedsRehash(bcryptHash, bcryptCfg))
+	assert.False(t, PasswordNeedsRehash(argonHash, argonCfg))
+	assert.True(t, PasswordNeedsRehash(bcryptHash, argonCfg), "algorithm changed")
+	assert.True(t, PasswordNeedsRehash(argonHash, bcryptCfg), "algorithm changed")
+	assert.True(t, PasswordNeedsRehash(bcryptHash, &config.Config{BcryptCost: 5}), "cost changed")
+	changed := testArgon2Config()
+	changed.Argon2Time = 2
+	assert.True(t, PasswordNeedsRehash(argonHash, changed), "passes changed")
+	assert.False(t, PasswordNeedsRehash("not-a-hash", bcryptCfg))
+}
+
+func TestCalibratePasswordHashing(t *testing.T) {
+	original := passwordHashTimer
+	defer func() { passwordHashTimer = original }()
+	passwordHashTimer = func(cfg *config.Config) time.Duration {
+		if cfg.PasswordHash == config.PasswordHashArgon2id {
+			return time.Duration(cfg.Argon2Time) * 30 * time.Millisecond
+		}
+		return time.Duration(1<<cfg.BcryptCost) * 100 * time.Microsecond // 25.6ms at cost 8
+	}
+
+	cfg := &config.Config{BcryptCost: 12}
+	CalibratePasswordHashing(cfg)
+	assert.Equal(t, 12, cfg.BcryptCost, "no target, no change")
+
+	cfg.PasswordHashTarget = 250 * time.Millisecond
+	CalibratePasswordHashing(cfg)
+	assert.Equal(t, 12, cfg.BcryptCost, "cost 12 takes 410ms, cost 11 only 205ms")
+	cfg.PasswordHashTarget = time.Microsecond
+	CalibratePasswordHashing(cfg)
+	assert.Equal(t, 4, cfg.BcryptCost, "never below bcrypt's minimum")
+	cfg.PasswordHashTarget = time.Hour
+	CalibratePasswordHashing(cfg)
+	assert.Equal(t, maxCalibratedBcryptCost, cfg.BcryptCost)
+
+	argonCfg := testArgon2Config()
+	argonCfg.PasswordHashTarget = 100 * time.Millisecond
+	CalibratePasswordHashing(argonCfg)
+	assert.Equal(t, uint32(4), argonCfg.Argon2Time, "4 passes of 30ms")
+}
+
+func TestPasswordHashParameters(t *testing.T) {
+	argonCfg := testArgon2Config()
+	argonHash, err := HashPasswordWithConfig("pw", argonCfg)
+	require.NoError(t, err)
+	bcryptHash, err := HashPassword("pw", 4)
+	require.NoError(t, err)
+
+	assert.Equal(t, "argon2id m=64,t=1,p=1", PasswordHashParameters(argonHash))
+	assert.Equal(t, "bcrypt cost=4", PasswordHashParameters(bcryptHash))
+	assert.Empty(t, PasswordHashParameters("garbage"))
+	assert.Equal(t, PasswordHashParameters(argonHash), ConfiguredPasswordHashParameters(argonCfg))
+	assert.Equal(t, "bcrypt cost=4", ConfiguredPasswordHashParameters(&config.Config{BcryptCost: 4}))
+}
@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"docserver/i18n"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Rate Limiting ---
+
+// RateLimiter allows each client a fixed number of requests per time window.
+// Windows start with a client's first request; state for idle clients is dropped as windows end.
+type RateLimiter struct {
+	limit  int
+	window time.Duration
+	now    func() time.Time // Replaceable in tests
+
+	mu      sync.Mutex
+	clients map[string]*rateWindow
+	pruneAt time.Time
+}
+
+// rateWindow counts one client's requests in its current window.
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// NewRateLimiter returns a limiter allowing limit requests per window to each client.
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{limit: limit, window: window, now: time.Now, clients: make(map[string]*rateWindow)}
+}
+
+// RateLimitState describes a client's allowance in its current window.
+type RateLimitState struct {
+	Limit     int           // Requests allowed per window
+	Remaining int           // Requests left in the current window
+	Reset     time.Duration // Time until the current window ends; a full window if none has started
+}
+
+// Allow counts a request from key. If the client is over its limit the request is refused,
+// and the returned duration says how long until its window ends.
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	allowed, state := l.Take(key)
+	if allowed {
+		return true, 0
+	}
+	return false, state.Reset
+}
+
+// Take is Allow, returning the client's allowance after the request.
+func (l *RateLimiter) Take(key string) (bool, RateLimitState) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if !now.Before(l.pruneAt) {
+		for k, w := range l.clients {
+			if now.Sub(w.start) >= l.window {
+				delete(l.clients, k)
+			}
+		}
+		l.pruneAt = now.Add(l.window)
+	}
+
+	w, found := l.clients[key]
+	if !found || now.Sub(w.start) >= l.window {
+		w = &rateWindow{start: now}
+		l.clients[key] = w
+	}
+	allowed := w.count < l.limit
+	if allowed {
+		w.count++
+	}
+	return allowed, RateLimitState{Limit: l.limit, Remaining: l.limit - w.count, Reset: w.start.Add(l.window).Sub(now)}
+}
+
+// Peek returns the client's allowance without counting a request.
+func (l *RateLimiter) Peek(key string) RateLimitState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	w, found := l.clients[key]
+	if !found || now.Sub(w.start) >= l.window {
+		return RateLimitState{Limit: l.limit, Remaining: l.limit, Reset: l.window}
+	}
+	return RateLimitState{Limit: l.limit, Remaining: l.limit - w.count, Reset: w.start.Add(l.window).Sub(now)}
+}
+
+// Window returns the length of the limiter's time windows.
+func (l *RateLimiter) Window() time.Duration {
+	return l.window
+}
+
+// Middleware refuses requests from clients (by IP address) over their limit with
+// 429 Too Many Requests and a Retry-After header giving the seconds to wait. Every response
+// carries X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset (seconds until the
+// window ends) headers, so clients can pace themselves.
+func (l *RateLimiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed, state := l.Take(c.ClientIP())
+		seconds := ResetSeconds(state.Reset)
+		c.Header("X-RateLimit-Limit", fmt.Sprint(state.Limit))
+		c.Header("X-RateLimit-Remaining", fmt.Sprint(state.Remaining))
+		c.Header("X-RateLimit-Reset", fmt.Sprint(seconds))
+		if !allowed {
+			c.Header("Retry-After", fmt.Sprint(seconds))
+			GinLocalizedError(c, http.StatusTooManyRequests, i18n.MsgRateLimited, seconds)
+			return
+		}
+		c.Next()
+	}
+}
+
+// ResetSeconds rounds a wait up to whole seconds, as sent in Retry-After and X-RateLimit-Reset.
+func ResetSeconds(wait time.Duration) int {
+	return int(math.Ceil(wait.Seconds()))
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(2, time.Minute)
+	limiter.now = func() time.Time { return now }
+
+	allowed, _ := limiter.Allow("a")
+	assert.True(t, allowed)
+	allowed, _ = limiter.Allow("a")
+	assert.True(t, allowed)
+	allowed, retryAfter := limiter.Allow("a")
+	assert.False(t, allowed, "third request in the window")
+	assert.Equal(t, time.Minute, retryAfter)
+
+	allowed, _ = limiter.Allow("b")
+	assert.True(t, allowed, "clients are counted separately")
+
+	now = now.Add(40 * time.Second)
+	_, retryAfter = limiter.Allow("a")
+	assert.Equal(t, 20*time.Second, retryAfter)
+
+	now = now.Add(20 * time.Second)
+	allowed, _ = limiter.Allow("a")
+	assert.True(t, allowed, "a new window starts")
+	assert.NotContains(t, limiter.clients, "b", "idle clients are dropped")
+}
+
+func TestRateLimiter_State(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(2, time.Minute)
+	limiter.now = func() time.Time { return now }
+
+	assert.Equal(t, RateLimitState{Limit: 2, Remaining: 2, Reset: time.Minute}, limiter.Peek("a"), "no window started yet")
+	allowed, state := limiter.Take("a")
+	assert.True(t, allowed)
+	assert.Equal(t, RateLimitState{Limit: 2, Remaining: 1, Reset: time.Minute}, state)
+
+	now = now.Add(15 * time.Second)
+	assert.Equal(t, RateLimitState{Limit: 2, Remaining: 1, Reset: 45 * time.Second}, limiter.Peek("a"))
+	assert.Equal(t, 1, limiter.Peek("a").Remaining, "peeking does not count")
+	limiter.Take("a")
+	allowed, state = limiter.Take("a")
+	assert.False(t, allowed)
+	assert.Equal(t, 0, state.Remaining)
+}
+
+func TestRateLimiter_Middleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/limited", NewRateLimiter(1, time.Minute).Middleware(), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/limited", nil))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "1", rr.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "0", rr.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "60", rr.Header().Get("X-RateLimit-Reset"))
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/limited", nil))
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "60", rr.Header().Get("Retry-After"))
+	assert.Contains(t, rr.Body.String(), "Too many requests")
+}
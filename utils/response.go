@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// envelopeContextKey marks a request whose responses must use the standard envelope.
+const envelopeContextKey = "useEnvelope"
+
+// Envelope is the standard wrapper for successful responses on versioned routes.
+// Single resources only populate Data; lists also carry Meta and Links.
+type Envelope struct {
+	Data  any        `json:"data"`
+	Meta  *PageMeta  `json:"meta,omitempty"`
+	Links *PageLinks `json:"links,omitempty"`
+}
+
+// PageMeta describes the pagination state of a list response.
+type PageMeta struct {
+	Total      int `json:"total"`       // Total number of items matching the request
+	Page       int `json:"page"`        // Current page (1-based)
+	Limit      int `json:"limit"`       // Maximum items per page
+	TotalPages int `json:"total_pages"` // Number of pages available (at least 1)
+}
+
+// PageLinks holds relative URLs for navigating a paginated list.
+// Next and Prev are omitted when there is no such page.
+type PageLinks struct {
+	Self  string `json:"self"`
+	First string `json:"first"`
+	Last  string `json:"last"`
+	Next  string `json:"next,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+}
+
+// ErrorEnvelope is the standard wrapper for error responses on versioned routes.
+type ErrorEnvelope struct {
+	Error ErrorObject `json:"error"`
+}
+
+// ErrorObject is a typed error with a stable machine-readable code.
+type ErrorObject struct {
+	Status    int    `json:"status"`               // HTTP status code
+	Code      string `json:"code"`                 // Machine-readable code, e.g. "not_found"
+	MessageID string `json:"message_id,omitempty"` // i18n catalog ID of Message, when localized
+	Message   string `json:"message"`              // Human-readable description
+}
+
+// PaginatedResponse is the legacy list shape served on unversioned routes.
+type PaginatedResponse struct {
+	Data  any `json:"data"`
+	Total int `json:"total"`
+	Page  int `json:"page"`
+	Limit int `json:"limit"`
+}
+
+// EnvelopeMiddleware makes all responses written through the shared helpers
+// (RespondData, RespondList, GinError and friends) use the standard envelope.
+func EnvelopeMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(envelopeContextKey, true)
+		c.Next()
+	}
+}
+
+// usesEnvelope reports whether the current request should be answered with an envelope.
+func usesEnvelope(c *gin.Context) bool {
+	return c.GetBool(envelopeContextKey)
+}
+
+// ErrorCodeForStatus derives the default error code for an HTTP status,
+// e.g. 404 -> "not_found", 412 -> "precondition_failed".
+func ErrorCodeForStatus(statusCode int) string {
+	text := http.StatusText(statusCode)
+	if text == "" {
+		return "error"
+	}
+	text = strings.ToLower(text)
+	text = strings.NewReplacer(" ", "_", "-", "_", "'", "").Replace(text)
+	return text
+}
+
+// RespondData sends a single resource with the given status code.
+// On enveloped routes the resource is wrapped as {"data": ...}; otherwise it is sent as-is.
+func RespondData(c *gin.Context, statusCode int, data any) {
+	if usesEnvelope(c) {
+		c.JSON(statusCode, Envelope{Data: data})
+		return
+	}
+	c.JSON(statusCode, data)
+}
+
+// RespondList sends one page of a list with pagination metadata.
+// On enveloped routes the response includes a meta block and next/prev links;
+// otherwise the legacy {data,total,page,limit} shape is used.
+func RespondList(c *gin.Context, data any, total, page, limit int) {
+	if !usesEnvelope(c) {
+		c.JSON(http.StatusOK, PaginatedResponse{Data: data, Total: total, Page: page, Limit: limit})
+		return
+	}
+
+	totalPages := 1
+	if limit > 0 && total > 0 {
+		totalPages = (total + limit - 1) / limit
+	}
+
+	links := &PageLinks{
+		Self:  pageURL(c, page),
+		First: pageURL(c, 1),
+		Last:  pageURL(c, totalPages),
+	}
+	if page < totalPages {
+		links.Next = pageURL(c, page+1)
+	}
+	if page > 1 {
+		// Clamp so an out-of-range page still points back at real data.
+		prev := page - 1
+		if prev > totalPages {
+			prev = totalPages
+		}
+		links.Prev = pageURL(c, prev)
+	}
+
+	c.JSON(http.StatusOK, Envelope{
+		Data:  data,
+		Meta:  &PageMeta{Total: total, Page: page, Limit: limit, TotalPages: totalPages},
+		Links: links,
+	})
+}
+
+// pageURL returns the current request URL (path and query) with the page parameter replaced.
+func pageURL(c *gin.Context, page int) string {
+	query := c.Request.URL.Query()
+	query.Set("page", strconv.Itoa(page))
+	return c.Request.URL.Path + "?" + query.Encode()
+}
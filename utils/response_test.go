@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Helper function to create a test Gin context for an enveloped request to the given URL
+func createEnvelopeTestContext(url string) (*gin.Context, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest(http.MethodGet, url, nil)
+	c.Set(envelopeContextKey, true)
+	return c, w
+}
+
+func TestErrorCodeForStatus(t *testing.T) {
+	assert.Equal(t, "not_found", ErrorCodeForStatus(http.StatusNotFound))
+	assert.Equal(t, "bad_request", ErrorCodeForStatus(http.StatusBadRequest))
+	assert.Equal(t, "precondition_failed", ErrorCodeForStatus(http.StatusPreconditionFailed))
+	assert.Equal(t, "im_a_teapot", ErrorCodeForStatus(http.StatusTeapot))
+	assert.Equal(t, "error", ErrorCodeForStatus(599))
+}
+
+func TestRespondData(t *testing.T) {
+	payload := map[string]string{"id": "abc"}
+
+	t.Run("Legacy sends bare object", func(t *testing.T) {
+		c, w := createTestContext()
+		RespondData(c, http.StatusCreated, payload)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.JSONEq(t, `{"id": "abc"}`, w.Body.String())
+	})
+
+	t.Run("Enveloped wraps in data", func(t *testing.T) {
+		c, w := createEnvelopeTestContext("/v1/test")
+		RespondData(c, http.StatusOK, payload)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"data": {"id": "abc"}}`, w.Body.String())
+	})
+}
+
+func TestRespondList(t *testing.T) {
+	items := []string{"a", "b"}
+
+	t.Run("Legacy shape", func(t *testing.T) {
+		c, w := createTestContext()
+		RespondList(c, items, 5, 1, 2)
+		assert.JSONEq(t, `{"data": ["a", "b"], "total": 5, "page": 1, "limit": 2}`, w.Body.String())
+	})
+
+	t.Run("Middle page has next and prev links", func(t *testing.T) {
+		c, w := createEnvelopeTestContext("/v1/items?scope=owned&page=2&limit=2")
+		RespondList(c, items, 5, 2, 2)
+
+		var env struct {
+			Data  []string  `json:"data"`
+			Meta  PageMeta  `json:"meta"`
+			Links PageLinks `json:"links"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &env))
+		assert.Equal(t, items, env.Data)
+		assert.Equal(t, PageMeta{Total: 5, Page: 2, Limit: 2, TotalPages: 3}, env.Meta)
+		assert.Equal(t, "/v1/items?limit=2&page=2&scope=owned", env.Links.Self)
+		assert.Equal(t, "/v1/items?limit=2&page=1&scope=owned", env.Links.First)
+		assert.Equal(t, "/v1/items?limit=2&page=3&scope=owned", env.Links.Last)
+		assert.Equal(t, "/v1/items?limit=2&page=3&scope=owned", env.Links.Next)
+		assert.Equal(t, "/v1/items?limit=2&page=1&scope=owned", env.Links.Prev)
+	})
+
+	t.Run("Empty list has a single page and no navigation", func(t *testing.T) {
+		c, w := createEnvelopeTestContext("/v1/items")
+		RespondList(c, []string{}, 0, 1, 20)
+
+		var env Envelope
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &env))
+		assert.Equal(t, []interface{}{}, env.Data)
+		require.NotNil(t, env.Meta)
+		assert.Equal(t, 1, env.Meta.TotalPages)
+		require.NotNil(t, env.Links)
+		assert.Empty(t, env.Links.Next)
+		assert.Empty(t, env.Links.Prev)
+	})
+
+	t.Run("Out of range page points prev at last page", func(t *testing.T) {
+		c, w := createEnvelopeTestContext("/v1/items?page=9")
+		RespondList(c, []string{}, 3, 9, 2)
+
+		var env Envelope
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &env))
+		assert.Equal(t, "/v1/items?page=2", env.Links.Prev)
+		assert.Empty(t, env.Links.Next)
+	})
+}
+
+func TestGinError_Enveloped(t *testing.T) {
+	c, w := createEnvelopeTestContext("/v1/test")
+	GinNotFound(c, "Document not found")
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	var env ErrorEnvelope
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &env))
+	assert.Equal(t, ErrorObject{Status: http.StatusNotFound, Code: "not_found", Message: "Document not found"}, env.Error)
+	assert.True(t, c.IsAborted())
+}
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// --- Token Scopes ---
+
+// Scopes a token can be restricted to. A token without scopes may do anything its user may; one
+// with scopes only reaches the routes they cover (see api.RequireScopeMiddleware).
+const (
+	ScopeDocumentsRead  = "documents:read"  // Read documents
+	ScopeDocumentsWrite = "documents:write" // Create, change and delete documents; implies documents:read
+	ScopeProfilesRead   = "profiles:read"   // Read your profile and search profiles
+	ScopeProfilesWrite  = "profiles:write"  // Change your profile; implies profiles:read
+)
+
+// KnownScopes lists every scope, in the order they are documented.
+var KnownScopes = []string{ScopeDocumentsRead, ScopeDocumentsWrite, ScopeProfilesRead, ScopeProfilesWrite}
+
+// NormalizeScopes checks that scopes is a non-empty list of known scopes, and returns them
+// sorted and without duplicates.
+func NormalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("at least one scope is required (%s)", strings.Join(KnownScopes, ", "))
+	}
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if !slices.Contains(KnownScopes, scope) {
+			return nil, fmt.Errorf("unknown scope '%s' (expected %s)", scope, strings.Join(KnownScopes, ", "))
+		}
+		normalized = append(normalized, scope)
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized), nil
+}
+
+// HasScope reports whether granted covers needed. A write scope covers the read scope of the
+// same resource.
+func HasScope(granted []string, needed string) bool {
+	if slices.Contains(granted, needed) {
+		return true
+	}
+	resource, access, _ := strings.Cut(needed, ":")
+	return access == "read" && slices.Contains(granted, resource+":write")
+}
+
+// ScopesWithin returns the scopes of requested that allowed covers, e.g. to narrow a token's
+// scopes to those its account still has.
+func ScopesWithin(requested, allowed []string) []string {
+	within := make([]string, 0, len(requested))
+	for _, scope := range requested {
+		if HasScope(allowed, scope) {
+			within = append(within, scope)
+		}
+	}
+	return within
+}
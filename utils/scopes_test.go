@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeScopes(t *testing.T) {
+	scopes, err := NormalizeScopes([]string{" Profiles:Read", "documents:read", "profiles:read"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{ScopeDocumentsRead, ScopeProfilesRead}, scopes)
+
+	_, err = NormalizeScopes(nil)
+	assert.Error(t, err)
+	_, err = NormalizeScopes([]string{"documents:admin"})
+	assert.ErrorContains(t, err, "unknown scope 'documents:admin'")
+}
+
+func TestHasScope(t *testing.T) {
+	assert.True(t, HasScope([]string{ScopeDocumentsRead}, ScopeDocumentsRead))
+	assert.True(t, HasScope([]string{ScopeDocumentsWrite}, ScopeDocumentsRead), "write implies read")
+	assert.False(t, HasScope([]string{ScopeDocumentsRead}, ScopeDocumentsWrite))
+	assert.False(t, HasScope([]string{ScopeProfilesWrite}, ScopeDocumentsRead))
+	assert.False(t, HasScope(nil, ScopeDocumentsRead))
+
+	assert.Equal(t, []string{ScopeDocumentsRead}, ScopesWithin([]string{ScopeDocumentsRead, ScopeProfilesRead}, []string{ScopeDocumentsWrite}))
+	assert.Empty(t, ScopesWithin([]string{ScopeDocumentsWrite}, []string{ScopeDocumentsRead}))
+}
@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"docserver/config"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// --- Signed URLs ---
+
+// Operations a signed URL can allow
+const (
+	SignedURLRead  = "read"  // Get the document
+	SignedURLWrite = "write" // Replace its content
+)
+
+// signedURLAudience returns the audience of signed URL tokens, which sets them apart from
+// access tokens and, like -jwt-audience, between servers sharing a secret (e.g. course databases).
+func signedURLAudience(cfg *config.Config) string {
+	if cfg.JwtAudience != "" {
+		return "signed-url:" + cfg.JwtAudience
+	}
+	return "signed-url"
+}
+
+// SignedURLClaims defines the claims of a signed URL token. The token's ID (jti) makes each
+// signed URL unique, so it can be used up.
+type SignedURLClaims struct {
+	DocumentID string `json:"document_id"`
+	Op         string `json:"op"` // SignedURLRead or SignedURLWrite
+	jwt.RegisteredClaims
+}
+
+// signedURLKey returns the key signed URL tokens are signed with. It is derived from the JWT
+// secret rather than the secret itself, so signed URL tokens and access tokens never pass for
+// one another.
+func signedURLKey(cfg *config.Config) []byte {
+	return []byte("signed-url:" + cfg.JwtSecret)
+}
+
+// GenerateSignedURLToken creates the token of a signed URL allowing op on a document until ttl
+// from now, on behalf of the user creatorID. It returns the token and when it expires.
+func GenerateSignedURLToken(docID, op, creatorID string, ttl time.Duration, cfg *config.Config) (string, time.Time, error) {
+	if cfg.JwtSecret == "" {
+		return "", time.Time{}, errors.New("JWT secret is not configured")
+	}
+
+	now := cfg.Now()
+	expiresAt := now.Add(ttl)
+	claims := &SignedURLClaims{
+		DocumentID: docID,
+		Op:         op,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        GenerateDashlessUUID(),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    jwtIssuer(cfg),
+			Subject:   creatorID,
+			Audience:  jwt.ClaimStrings{signedURLAudience(cfg)},
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(signedURLKey(cfg))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign token: %w", err)
+	}
+	return token, claims.ExpiresAt.Time, nil
+}
+
+// ValidateSignedURLToken parses a signed URL token and checks its signature, expiry and issuer,
+// allowing cfg.JwtLeeway of clock skew. Whether it was used already is up to the caller.
+func ValidateSignedURLToken(tokenString string, cfg *config.Config) (*SignedURLClaims, error) {
+	if cfg.JwtSecret == "" {
+		return nil, errors.New("JWT secret is not configured")
+	}
+
+	claims := &SignedURLClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return signedURLKey(cfg), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithLeeway(cfg.JwtLeeway),
+		jwt.WithIssuedAt(),
+		jwt.WithIssuer(jwtIssuer(cfg)),
+		jwt.WithAudience(signedURLAudience(cfg)),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(cfg.Now),
+	)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, errors.New("signed URL has expired")
+		}
+		return nil, fmt.Errorf("invalid signed URL: %w", err)
+	}
+	if claims.ID == "" || claims.DocumentID == "" || claims.Subject == "" {
+		return nil, errors.New("invalid signed URL: missing claims")
+	}
+	return claims, nil
+}
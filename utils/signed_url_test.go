@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"docserver/config"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignedURLTokens(t *testing.T) {
+	cfg := createTestJWTConfig()
+	clock := config.NewFixedClock(time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC))
+	cfg.Clock = clock
+
+	token, expiresAt, err := GenerateSignedURLToken("doc_1", SignedURLRead, "user_1", 10*time.Minute, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, clock.Now().Add(10*time.Minute), expiresAt)
+
+	claims, err := ValidateSignedURLToken(token, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "doc_1", claims.DocumentID)
+	assert.Equal(t, SignedURLRead, claims.Op)
+	assert.Equal(t, "user_1", claims.Subject)
+	assert.NotEmpty(t, claims.ID)
+
+	other, _, err := GenerateSignedURLToken("doc_1", SignedURLRead, "user_1", 10*time.Minute, cfg)
+	require.NoError(t, err)
+	otherClaims, err := ValidateSignedURLToken(other, cfg)
+	require.NoError(t, err)
+	assert.NotEqual(t, claims.ID, otherClaims.ID, "each signed URL is unique")
+
+	t.Run("Access tokens are not signed URLs", func(t *testing.T) {
+		accessToken, err := GenerateJWT(createTestProfile(), cfg)
+		require.NoError(t, err)
+		_, err = ValidateSignedURLToken(accessToken, cfg)
+		assert.Error(t, err)
+		_, err = ValidateJWT(token, cfg)
+		assert.Error(t, err, "and signed URLs are not access tokens")
+	})
+
+	t.Run("Other secrets are rejected", func(t *testing.T) {
+		otherCfg := createTestJWTConfig()
+		otherCfg.JwtSecret = "another-secret-key-longer-than-32-bytes"
+		otherCfg.Clock = clock
+		_, err := ValidateSignedURLToken(token, otherCfg)
+		assert.Error(t, err)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		clock.Advance(11 * time.Minute)
+		_, err := ValidateSignedURLToken(token, cfg)
+		assert.ErrorContains(t, err, "expired")
+	})
+
+	t.Run("Missing secret", func(t *testing.T) {
+		_, _, err := GenerateSignedURLToken("doc_1", SignedURLRead, "user_1", time.Minute, &config.Config{})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// --- Text Similarity ---
+
+// ShingleSize is the number of consecutive words in a shingle.
+const ShingleSize = 3
+
+// MinHashSize is the number of hash functions in a MinHash signature.
+const MinHashSize = 64
+
+// ContentWords returns the words of a JSON value's text: its strings, numbers and booleans, in
+// key order, lowercased and normalized, split on anything but letters and digits. Object keys are
+// left out, so documents filled in from the same template do not look alike for that alone.
+func ContentWords(content any) []string {
+	var words []string
+	var walk func(value any)
+	walk = func(value any) {
+		switch v := value.(type) {
+		case map[string]any:
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				walk(v[key])
+			}
+		case []any:
+			for _, item := range v {
+				walk(item)
+			}
+		case string:
+			words = append(words, splitWords(v)...)
+		case float64:
+			words = append(words, strconv.FormatFloat(v, 'f', -1, 64))
+		case bool:
+			words = append(words, strconv.FormatBool(v))
+		}
+	}
+	walk(content)
+	return words
+}
+
+// splitWords lowercases and normalizes text and splits it into words.
+func splitWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(NormalizeText(text)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Shingles returns the set of hashes of the ShingleSize-word runs of words. Texts shorter than a
+// shingle are one shingle; no words make an empty set.
+func Shingles(words []string) map[uint64]bool {
+	shingles := make(map[uint64]bool)
+	if len(words) == 0 {
+		return shingles
+	}
+	size := min(ShingleSize, len(words))
+	for i := 0; i+size <= len(words); i++ {
+		h := fnv.New64a()
+		for _, word := range words[i : i+size] {
+			h.Write([]byte(word))
+			h.Write([]byte{0})
+		}
+		shingles[h.Sum64()] = true
+	}
+	return shingles
+}
+
+// Jaccard returns the share of shingles two sets have in common, from 0 (none) to 1 (the same).
+func Jaccard(a, b map[uint64]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	common := 0
+	for shingle := range a {
+		if b[shingle] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
+
+// MinHash returns the MinHash signature of a shingle set: for each of MinHashSize hash functions,
+// the smallest hash of a shingle. Two sets agree on a given position with a probability equal to
+// their Jaccard similarity, so signatures find likely matches without comparing whole sets.
+func MinHash(shingles map[uint64]bool) [MinHashSize]uint64 {
+	var signature [MinHashSize]uint64
+	for i := range signature {
+		signature[i] = ^uint64(0)
+	}
+	for shingle := range shingles {
+		for i := range signature {
+			if h := mix64(shingle ^ (uint64(i+1) * 0x9e3779b97f4a7c15)); h < signature[i] {
+				signature[i] = h
+			}
+		}
+	}
+	return signature
+}
+
+// mix64 scrambles the bits of x (the splitmix64 finalizer).
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentWords(t *testing.T) {
+	content := map[string]any{
+		"title":  "Essay: The Café",
+		"body":   []any{"It's short.", 42.5, true, nil},
+		"author": map[string]any{"name": "Ada"},
+	}
+	assert.Equal(t, []string{"ada", "it", "s", "short", "42.5", "true", "essay", "the", "café"}, ContentWords(content), "values in key order, without the keys")
+	assert.Equal(t, []string{"plain", "text"}, ContentWords("Plain TEXT"))
+	assert.Empty(t, ContentWords(map[string]any{"empty": ""}))
+}
+
+func TestShinglesAndJaccard(t *testing.T) {
+	a := Shingles([]string{"the", "quick", "brown", "fox", "jumps"})
+	assert.Len(t, a, 3)
+	assert.Len(t, Shingles([]string{"hi", "there"}), 1, "short texts are one shingle")
+	assert.Empty(t, Shingles(nil))
+
+	b := Shingles([]string{"the", "quick", "brown", "fox", "sleeps"})
+	assert.InDelta(t, 2.0/4.0, Jaccard(a, b), 1e-9)
+	assert.Equal(t, 1.0, Jaccard(a, a))
+	assert.Equal(t, 0.0, Jaccard(a, Shingles([]string{"something", "else", "entirely"})))
+	assert.Equal(t, 1.0, Jaccard(Shingles(nil), Shingles(nil)))
+}
+
+func TestMinHash(t *testing.T) {
+	words := ContentWords("the quick brown fox jumps over the lazy dog while the cat watches from the old wooden fence")
+	a := Shingles(words)
+	assert.Equal(t, MinHash(a), MinHash(Shingles(words)), "deterministic")
+
+	changed := append([]string{}, words...)
+	changed[len(changed)-1] = "gate"
+	b := Shingles(changed)
+	sigA, sigB := MinHash(a), MinHash(b)
+	agree := 0
+	for i := range sigA {
+		if sigA[i] == sigB[i] {
+			agree++
+		}
+	}
+	estimate := float64(agree) / MinHashSize
+	assert.InDelta(t, Jaccard(a, b), estimate, 0.25, "signatures agree about as often as the sets overlap")
+
+	other := MinHash(Shingles(ContentWords("completely unrelated words about quarterly budget planning meetings")))
+	agree = 0
+	for i := range sigA {
+		if sigA[i] == other[i] {
+			agree++
+		}
+	}
+	assert.Less(t, agree, 4)
+}
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeText returns s in Unicode normalization form C (NFC), in which an accented letter is
+// one code point (U+00E9 "é") rather than a letter followed by a combining mark ("e" U+0301).
+// Both look the same, but only strings in the same form compare equal, so text is stored and
+// searched for in NFC. Strings already in NFC, such as all ASCII, are returned as they are.
+func NormalizeText(s string) string {
+	return norm.NFC.String(s)
+}
+
+// NormalizeTextJSON returns decoded JSON (as encoding/json produces it) with every string, object
+// keys included, in NFC (see NormalizeText). v is not changed; maps and slices are copied.
+func NormalizeTextJSON(v any) any {
+	switch value := v.(type) {
+	case string:
+		return NormalizeText(value)
+	case map[string]any:
+		normalized := make(map[string]any, len(value))
+		for key, child := range value {
+			normalized[NormalizeText(key)] = NormalizeTextJSON(child)
+		}
+		return normalized
+	case []any:
+		normalized := make([]any, len(value))
+		for i, child := range value {
+			normalized[i] = NormalizeTextJSON(child)
+		}
+		return normalized
+	default:
+		return v
+	}
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	cafeComposed   = "caf\u00e9"  // é as one code point (NFC)
+	cafeDecomposed = "cafe\u0301" // e followed by a combining acute accent (NFD)
+)
+
+func TestNormalizeText(t *testing.T) {
+	assert.NotEqual(t, cafeComposed, cafeDecomposed)
+	assert.Equal(t, cafeComposed, NormalizeText(cafeDecomposed))
+	assert.Equal(t, cafeComposed, NormalizeText(cafeComposed))
+	assert.Equal(t, "plain ascii", NormalizeText("plain ascii"))
+}
+
+func TestNormalizeTextJSON(t *testing.T) {
+	content := map[string]any{
+		cafeDecomposed: "menu",
+		"items":        []any{cafeDecomposed, 3.5, true, nil, map[string]any{"name": "Cre\u0300me"}},
+		"count":        float64(2),
+	}
+
+	normalized := NormalizeTextJSON(content)
+
+	assert.Equal(t, map[string]any{
+		cafeComposed: "menu",
+		"items":      []any{cafeComposed, 3.5, true, nil, map[string]any{"name": "Cr\u00e8me"}},
+		"count":      float64(2),
+	}, normalized)
+	assert.Contains(t, content, cafeDecomposed, "the input is not changed")
+	assert.Equal(t, cafeComposed, NormalizeTextJSON(cafeDecomposed))
+	assert.Equal(t, 42.0, NormalizeTextJSON(42.0))
+}
@@ -1,10 +1,13 @@
 package utils
 
 import (
+	"docserver/i18n"
+	"errors"
 	"github.com/google/uuid"
 	"strings"
 	"log"
 	"net/http"
+	"regexp"
 	"github.com/gin-gonic/gin"
 )
 
@@ -15,16 +18,90 @@ func GenerateDashlessUUID() string {
 	return strings.ReplaceAll(id.String(), "-", "")
 }
 
+// documentIDNamespace is the fixed UUID namespace used to derive deterministic document IDs.
+var documentIDNamespace = uuid.MustParse("6f1c2b7e-4d3a-5e8f-9a0b-1c2d3e4f5a6b")
+
+// DeterministicDocumentID derives a stable, dashless ID from an owner ID and a
+// client-supplied key (UUID v5). The same owner and key always yield the same ID,
+// while different owners using the same key never collide.
+func DeterministicDocumentID(ownerID, key string) string {
+	id := uuid.NewSHA1(documentIDNamespace, []byte(ownerID+"/"+key))
+	return strings.ReplaceAll(id.String(), "-", "")
+}
+
+// IsKeyDerivedID reports whether id has the form of an ID derived by DeterministicDocumentID
+// (a dashless UUID v5). Such IDs are reserved for keyed creates, so that no one can take the ID
+// another user's key derives to.
+func IsKeyDerivedID(id string) bool {
+	if len(id) != 32 || strings.ToLower(id) != id {
+		return false
+	}
+	parsed, err := uuid.Parse(id)
+	return err == nil && parsed.Version() == 5 && parsed.Variant() == uuid.RFC4122
+}
+
+// customIDPattern restricts client-supplied IDs to URL-safe characters.
+var customIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// IsValidCustomID reports whether a client-supplied ID is acceptable:
+// 1-64 characters of letters, digits, underscores, or dashes.
+func IsValidCustomID(id string) bool {
+	return customIDPattern.MatchString(id)
+}
+
 // APIError is a standard structure for returning errors as JSON.
 type APIError struct {
-	Error string `json:"error"`
+	Error     string `json:"error"`
+	MessageID string `json:"message_id,omitempty"` // i18n catalog ID, when the message is localized
 }
 
 // GinError sends a JSON error response with a specific status code.
-// It logs the error server-side as well.
+// It logs the error server-side as well. On enveloped routes the error is sent
+// as a typed ErrorObject; otherwise the legacy APIError shape is used.
 func GinError(c *gin.Context, statusCode int, message string) {
+	writeError(c, statusCode, "", message)
+}
+
+// GinLocalizedError sends an error response using a message from the i18n catalog,
+// rendered in the language negotiated from the request's Accept-Language header.
+// The message ID is included in the response so clients can apply their own translation.
+func GinLocalizedError(c *gin.Context, statusCode int, msgID string, args ...any) {
+	lang := RequestLanguage(c)
+	writeError(c, statusCode, msgID, i18n.Translate(lang, msgID, args...))
+}
+
+// GinErrorFromErr sends err as an error response. If err carries an i18n message ID
+// it is localized like GinLocalizedError; otherwise err.Error() is sent as-is.
+func GinErrorFromErr(c *gin.Context, statusCode int, err error) {
+	var msgErr *i18n.Error
+	if errors.As(err, &msgErr) {
+		writeError(c, statusCode, msgErr.ID, msgErr.Localize(RequestLanguage(c)))
+		return
+	}
+	writeError(c, statusCode, "", err.Error())
+}
+
+// RequestLanguage negotiates the response language from the Accept-Language header
+// and advertises it via Content-Language.
+func RequestLanguage(c *gin.Context) string {
+	lang := i18n.NegotiateLanguage(c.GetHeader("Accept-Language"))
+	c.Header("Content-Language", lang)
+	return lang
+}
+
+// writeError logs and sends an error response in the shape expected by the route.
+func writeError(c *gin.Context, statusCode int, msgID, message string) {
 	log.Printf("ERROR: Request %s %s - Status %d - %s", c.Request.Method, c.Request.URL.Path, statusCode, message)
-	c.AbortWithStatusJSON(statusCode, APIError{Error: message})
+	if usesEnvelope(c) {
+		c.AbortWithStatusJSON(statusCode, ErrorEnvelope{Error: ErrorObject{
+			Status:    statusCode,
+			Code:      ErrorCodeForStatus(statusCode),
+			MessageID: msgID,
+			Message:   message,
+		}})
+		return
+	}
+	c.AbortWithStatusJSON(statusCode, APIError{Error: message, MessageID: msgID})
 }
 
 // GinBadRequest sends a 400 Bad Request error response.
@@ -47,6 +124,11 @@ func GinNotFound(c *gin.Context, message string) {
 	GinError(c, http.StatusNotFound, message)
 }
 
+// GinConflict sends a 409 Conflict error response.
+func GinConflict(c *gin.Context, message string) {
+	GinError(c, http.StatusConflict, message)
+}
+
 // GinInternalServerError sends a 500 Internal Server Error response.
 func GinInternalServerError(c *gin.Context, message string) {
 	GinError(c, http.StatusInternalServerError, message)
@@ -31,6 +31,35 @@ func TestGenerateDashlessUUID(t *testing.T) {
 	// }
 }
 
+func TestDeterministicDocumentID(t *testing.T) {
+	first := DeterministicDocumentID("owner1", "assignment-1")
+	again := DeterministicDocumentID("owner1", "assignment-1")
+	otherOwner := DeterministicDocumentID("owner2", "assignment-1")
+	otherKey := DeterministicDocumentID("owner1", "assignment-2")
+
+	assert.Equal(t, first, again, "Same owner and key should produce the same ID")
+	assert.NotEqual(t, first, otherOwner, "Different owners should not collide")
+	assert.NotEqual(t, first, otherKey, "Different keys should not collide")
+	assert.Len(t, first, 32)
+	assert.NotContains(t, first, "-")
+}
+
+func TestIsKeyDerivedID(t *testing.T) {
+	assert.True(t, IsKeyDerivedID(DeterministicDocumentID("owner1", "assignment-1")))
+	assert.False(t, IsKeyDerivedID(GenerateDashlessUUID()), "Random (v4) IDs are not derived")
+	assert.False(t, IsKeyDerivedID(strings.ToUpper(DeterministicDocumentID("owner1", "assignment-1"))))
+	assert.False(t, IsKeyDerivedID("syllabus_2024"))
+}
+
+func TestIsValidCustomID(t *testing.T) {
+	assert.True(t, IsValidCustomID("my-doc_1"))
+	assert.True(t, IsValidCustomID(strings.Repeat("a", 64)))
+	assert.False(t, IsValidCustomID(""), "Empty ID is invalid")
+	assert.False(t, IsValidCustomID(strings.Repeat("a", 65)), "IDs longer than 64 characters are invalid")
+	assert.False(t, IsValidCustomID("has space"))
+	assert.False(t, IsValidCustomID("slash/id"))
+}
+
 // Helper function to create a test Gin context
 func createTestContext() (*gin.Context, *httptest.ResponseRecorder) {
 	w := httptest.NewRecorder()
@@ -86,6 +115,12 @@ func TestGinErrorHelpers(t *testing.T) {
 			wantCode:   http.StatusNotFound,
 			wantMsg:    "Not found test",
 		},
+		{
+			name:       "Conflict",
+			helperFunc: GinConflict,
+			wantCode:   http.StatusConflict,
+			wantMsg:    "Conflict test",
+		},
 		{
 			name:       "InternalServerError",
 			helperFunc: GinInternalServerError,
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --- Build Information ---
+
+// Version, Commit and BuildDate identify the release. They are set at build time, e.g.
+//
+//	go build -ldflags "-X docserver/utils.Version=v1.4.0 -X docserver/utils.Commit=$(git rev-parse HEAD) -X docserver/utils.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Builds without them report "dev" and, when Go recorded them, the VCS revision and commit time.
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildDate = ""
+)
+
+// startTime is when the process started, for reporting uptime.
+var startTime = time.Now()
+
+// BuildCommit returns the commit the binary was built from, or "unknown".
+func BuildCommit() string {
+	if Commit != "" {
+		return Commit
+	}
+	return buildSetting("vcs.revision", "unknown")
+}
+
+// BuildTime returns when the binary was built (RFC 3339), or "unknown".
+// Without an injected BuildDate the commit time recorded by Go is used.
+func BuildTime() string {
+	if BuildDate != "" {
+		return BuildDate
+	}
+	return buildSetting("vcs.time", "unknown")
+}
+
+// buildSetting returns a setting Go recorded in the binary, or fallback.
+func buildSetting(key, fallback string) string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == key && setting.Value != "" {
+				return setting.Value
+			}
+		}
+	}
+	return fallback
+}
+
+// ParseReleaseVersion parses a release version such as "v1.2.3" or "1.2" (missing parts are 0).
+// Pre-release and build suffixes ("-rc1", "+meta", or the "-3-gabc123" of git describe) are ignored.
+func ParseReleaseVersion(version string) ([3]int, error) {
+	var parts [3]int
+	trimmed := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(trimmed, "-+"); i >= 0 {
+		trimmed = trimmed[:i]
+	}
+	fields := strings.Split(trimmed, ".")
+	if trimmed == "" || len(fields) > len(parts) {
+		return parts, fmt.Errorf("invalid release version '%s'", version)
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return parts, fmt.Errorf("invalid release version '%s'", version)
+		}
+		parts[i] = n
+	}
+	return parts, nil
+}
+
+// VersionAtLeast reports whether this build's Version is min or newer. Development builds,
+// whose Version is not a release version, are taken to satisfy any minimum.
+// It returns an error if min is not a release version.
+func VersionAtLeast(min string) (bool, error) {
+	wanted, err := ParseReleaseVersion(min)
+	if err != nil {
+		return false, err
+	}
+	current, err := ParseReleaseVersion(Version)
+	if err != nil {
+		return true, nil
+	}
+	for i := range current {
+		if current[i] != wanted[i] {
+			return current[i] > wanted[i], nil
+		}
+	}
+	return true, nil
+}
+
+// Uptime returns how long the server has been running.
+func Uptime() time.Duration {
+	return time.Since(startTime)
+}
@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildInfo(t *testing.T) {
+	assert.Equal(t, "dev", Version, "unset unless injected at build time")
+
+	original := Commit
+	t.Cleanup(func() { Commit = original })
+	Commit = "abc123"
+	assert.Equal(t, "abc123", BuildCommit())
+	Commit = ""
+	assert.NotEmpty(t, BuildCommit(), "falls back to the VCS revision or 'unknown'")
+
+	assert.NotEmpty(t, BuildTime())
+	assert.Positive(t, Uptime())
+}
+
+func TestVersionAtLeast(t *testing.T) {
+	parts, err := ParseReleaseVersion("v1.12.3-4-gabc123")
+	require.NoError(t, err)
+	assert.Equal(t, [3]int{1, 12, 3}, parts)
+	parts, err = ParseReleaseVersion("2")
+	require.NoError(t, err)
+	assert.Equal(t, [3]int{2, 0, 0}, parts)
+	for _, invalid := range []string{"", "dev", "v1.x", "1.2.3.4", "v-1"} {
+		_, err := ParseReleaseVersion(invalid)
+		assert.Error(t, err, invalid)
+	}
+
+	original := Version
+	t.Cleanup(func() { Version = original })
+
+	Version = "dev"
+	ok, err := VersionAtLeast("v99.0.0")
+	require.NoError(t, err)
+	assert.True(t, ok, "development builds satisfy any minimum")
+
+	Version = "v1.4.2"
+	for min, want := range map[string]bool{"v1.4.2": true, "1.4": true, "v1.3.9": true, "v1.10.0": false, "v2": false} {
+		ok, err := VersionAtLeast(min)
+		require.NoError(t, err)
+		assert.Equal(t, want, ok, min)
+	}
+	_, err = VersionAtLeast("latest")
+	assert.Error(t, err)
+}